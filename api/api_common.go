@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -29,15 +30,46 @@ type Common interface {
 	AuthVerify(ctx context.Context, token string) ([]auth.Permission, error) //perm:read
 	AuthNew(ctx context.Context, perms []auth.Permission) ([]byte, error)    //perm:admin
 
+	// AuthTokenCreate creates an API token with the permissions up to the
+	// given scope, eg. sign gives read, write and sign. The token expires after
+	// the ttl, unless it is zero. Unlike the tokens created with AuthNew, these
+	// tokens are listed by AuthTokenList and can be revoked.
+	AuthTokenCreate(ctx context.Context, scope auth.Permission, label string, ttl time.Duration) (NewAPIToken, error) //perm:admin
+
+	// AuthTokenList lists the tokens created with AuthTokenCreate which weren't
+	// revoked, including the expired ones
+	AuthTokenList(ctx context.Context) ([]APIToken, error) //perm:admin
+
+	// AuthTokenRevoke revokes a token created with AuthTokenCreate
+	AuthTokenRevoke(ctx context.Context, id uuid.UUID) error //perm:admin
+
 	// MethodGroup: Log
 
 	LogList(context.Context) ([]string, error)         //perm:write
 	LogSetLevel(context.Context, string, string) error //perm:write
 
+	// LogPersistLevel sets the log level of a subsystem, and saves it in the
+	// node config so that it applies after restarts
+	LogPersistLevel(ctx context.Context, subsystem, level string) error //perm:admin
+
+	// LogTail streams the log entries of a subsystem, or of all subsystems when
+	// empty, at or above the given level. Entries are only logged at the levels
+	// enabled for their subsystem, see LogSetLevel. Entries are dropped when
+	// the client doesn't keep up.
+	LogTail(ctx context.Context, subsystem, level string) (<-chan LogEntry, error) //perm:admin
+
 	// LogAlerts returns list of all, active and inactive alerts tracked by the
 	// node
 	LogAlerts(ctx context.Context) ([]alerting.Alert, error) //perm:admin
 
+	// MethodGroup: Config
+
+	// ConfigReload reloads the config from the repo, and applies the changes of
+	// the settings which don't require a restart. Nothing is applied when a
+	// setting which requires a restart changed, those changes are returned as
+	// rejected.
+	ConfigReload(ctx context.Context) (ConfigReloadResult, error) //perm:admin
+
 	// MethodGroup: Common
 
 	// Version provides information about API provider
@@ -22,6 +22,7 @@ import (
 	"github.com/filecoin-project/go-state-types/builtin/v8/paych"
 	"github.com/filecoin-project/go-state-types/crypto"
 	"github.com/filecoin-project/go-state-types/dline"
+	"github.com/filecoin-project/go-state-types/exitcode"
 	abinetwork "github.com/filecoin-project/go-state-types/network"
 
 	apitypes "github.com/filecoin-project/lotus/api/types"
@@ -44,6 +45,14 @@ type ChainIO interface {
 
 const LookbackNoLimit = abi.ChainEpoch(-1)
 
+// LookbackFinality can be passed as the lookback limit of StateWaitMsg and
+// StateSearchMsg to search back as far as the finality configured on the node
+const LookbackFinality = abi.ChainEpoch(-2)
+
+// ConfidenceDefault can be passed as the confidence of StateWaitMsg to wait for
+// the confidence configured on the node
+const ConfidenceDefault = ^uint64(0)
+
 //                       MODIFYING THE API INTERFACE
 //
 // NOTE: This is the V1 (Unstable) API - to add methods to the V0 (Stable) API
@@ -71,6 +80,13 @@ type FullNode interface {
 	// First message is guaranteed to be of len == 1, and type == 'current'.
 	ChainNotify(context.Context) (<-chan []*HeadChange, error) //perm:read
 
+	// ChainNotifyDetailed returns a channel with chain head updates like ChainNotify,
+	// where apply and revert changes also report the effects of the state transition
+	// recorded by the tipset: the CIDs of the messages it executed, and the actors
+	// whose state heads changed. This allows indexers to repair their databases
+	// precisely on reorgs.
+	ChainNotifyDetailed(context.Context) (<-chan []*DetailedHeadChange, error) //perm:read
+
 	// ChainHead returns the current head of the chain.
 	ChainHead(context.Context) (*types.TipSet, error) //perm:read
 
@@ -162,6 +178,13 @@ type FullNode interface {
 	// Would return `[revert(tBA), apply(tAB), apply(tAA)]`
 	ChainGetPath(ctx context.Context, from types.TipSetKey, to types.TipSetKey) ([]*HeadChange, error) //perm:read
 
+	// ChainIndexActorMessages returns the messages sent or received by the given
+	// address, as it appears in the messages, which were included in the height
+	// range [from, to] of the node's chain, ordered by height. At most limit
+	// messages are returned when limit is positive. It requires the chain index to
+	// be enabled (Chainstore.Index.EnableIndex), and only covers the indexed epochs.
+	ChainIndexActorMessages(ctx context.Context, addr address.Address, from, to abi.ChainEpoch, limit int) ([]IndexedMessage, error) //perm:read
+
 	// ChainExport returns a stream of bytes with CAR dump of chain data.
 	// The exported chain data includes the header chain from the given tipset
 	// back to genesis, the entire genesis state, and the most recent 'nroots'
@@ -169,6 +192,23 @@ type FullNode interface {
 	// If oldmsgskip is set, messages from before the requested roots are also not included.
 	ChainExport(ctx context.Context, nroots abi.ChainEpoch, oldmsgskip bool, tsk types.TipSetKey) (<-chan []byte, error) //perm:read
 
+	// ChainExportLatestState returns a stream of bytes with a CAR dump of the
+	// latest state of the chain, which a node can boot from without the chain
+	// history. The exported chain data only includes the headers of the most
+	// recent 'nheaders' epochs, and the state trees and messages of the last
+	// finality, needed by consensus lookbacks. It doesn't include the genesis,
+	// nor message receipts.
+	ChainExportLatestState(ctx context.Context, nheaders abi.ChainEpoch, tsk types.TipSetKey) (<-chan []byte, error) //perm:read
+
+	// ChainExportRange returns a stream of bytes with a CAR dump of the chain
+	// between the heights 'from' and 'to', inclusive, of the chain ending at the
+	// given tipset. The root of the CAR is the tipset at height 'to', or the
+	// closest one below it if 'to' is a null round. All the block headers of the
+	// range are included; the options select the depths, counted down from the
+	// top of the range, for which messages, message receipts and state trees are
+	// included. It allows consuming the chain history in slices.
+	ChainExportRange(ctx context.Context, from, to abi.ChainEpoch, tsk types.TipSetKey, opts ChainExportRangeOpts) (<-chan []byte, error) //perm:read
+
 	// ChainCheckBlockstore performs an (asynchronous) health check on the chain/state blockstore
 	// if supported by the underlying implementation.
 	ChainCheckBlockstore(context.Context) error //perm:admin
@@ -176,6 +216,78 @@ type FullNode interface {
 	// ChainBlockstoreInfo returns some basic information about the blockstore
 	ChainBlockstoreInfo(context.Context) (map[string]interface{}, error) //perm:read
 
+	// ChainBlockstoreGC starts an online garbage collection of the chain/state blockstore
+	// in the background, if supported by the underlying implementation. The run stops
+	// early when any of the limits in the options is reached.
+	ChainBlockstoreGC(context.Context, BlockstoreGCOpts) error //perm:admin
+
+	// ChainBlockstoreGCStatus returns the status of the current or last blockstore
+	// garbage collection run, and of the GC schedule.
+	ChainBlockstoreGCStatus(context.Context) (BlockstoreGCStatus, error) //perm:read
+
+	// ChainBlockstoreScrub starts a pass of the blockstore scrubber in the background,
+	// verifying that the stored blocks hash to their CIDs. Corrupt blocks are
+	// quarantined and re-fetched from the network when possible.
+	ChainBlockstoreScrub(context.Context) error //perm:admin
+	// ChainBlockstoreScrubStatus returns the progress of the current or last scrub pass
+	ChainBlockstoreScrubStatus(context.Context) (BlockstoreScrubStatus, error) //perm:read
+	// ChainBlockstoreQuarantine lists the CIDs of the corrupt blocks found by the
+	// scrubber which couldn't be re-fetched yet
+	ChainBlockstoreQuarantine(context.Context) ([]cid.Cid, error) //perm:read
+
+	// ChainBlockstoreCacheFlush drops all blocks from the chain/state block cache,
+	// if the cache is enabled.
+	ChainBlockstoreCacheFlush(context.Context) error //perm:admin
+
+	// ChainBlockstoreMount mounts the CAR file at the given path on the node's
+	// filesystem (e.g. an exported snapshot) as a read-only overlay of the
+	// chain/state blockstore, which serves blocks missing from the blockstore
+	// without importing them.
+	ChainBlockstoreMount(ctx context.Context, name string, path string) (BlockstoreMount, error) //perm:admin
+	// ChainBlockstoreUnmount removes a CAR file mounted with ChainBlockstoreMount
+	ChainBlockstoreUnmount(ctx context.Context, name string) error //perm:admin
+	// ChainBlockstoreMounts lists the CAR files mounted as blockstore overlays
+	ChainBlockstoreMounts(context.Context) ([]BlockstoreMount, error) //perm:read
+
+	// ChainSnapshotStatus returns the status of the scheduled snapshot service,
+	// including the location of the latest snapshot it produced and of the
+	// retained ones. It fails if scheduled snapshots are not enabled in the
+	// Chainstore.Snapshots section of the node config.
+	ChainSnapshotStatus(context.Context) (ChainSnapshotStatus, error) //perm:read
+
+	// ChainBlockPropagation returns when the blocks at or above the given height
+	// arrived over pubsub relative to the start of their epoch, who they were
+	// first received from, and how long they took to validate. It is kept for
+	// the blocks of the last day, and helps diagnosing late and orphaned blocks.
+	ChainBlockPropagation(ctx context.Context, from abi.ChainEpoch) ([]BlockPropagation, error) //perm:read
+
+	// ChainConsensusFaults returns the consensus faults detected in incoming
+	// blocks by the consensus fault reporter, along with their evidence and
+	// whether they were reported. It fails if the reporter is not enabled in the
+	// FaultReporter section of the node config.
+	ChainConsensusFaults(context.Context) ([]ConsensusFault, error) //perm:read
+	// ChainConsensusFaultSubmit reports the pending consensus fault of the given
+	// block to the miner actor, from the configured reporter address, and returns
+	// the cid of the message
+	ChainConsensusFaultSubmit(ctx context.Context, block cid.Cid) (cid.Cid, error) //perm:sign
+	// ChainConsensusFaultDismiss marks the pending consensus fault of the given
+	// block as dismissed, so that it isn't reported
+	ChainConsensusFaultDismiss(ctx context.Context, block cid.Cid) error //perm:write
+
+	// ChainBackfill fetches the historical data selected by the spec for the
+	// tipsets in the given height range of the current chain from the network,
+	// and stores it in the local blockstore. It allows restoring parts of the
+	// history on a pruned node without importing a full snapshot.
+	//
+	// Messages are validated against the message roots of the block headers,
+	// which must be present locally. Receipts and state are the receipt and state
+	// roots referenced by the block headers of the range, and are fetched over
+	// bitswap.
+	//
+	// The progress is streamed on the returned channel, which is closed once
+	// the backfill is done or has failed.
+	ChainBackfill(ctx context.Context, spec BackfillSpec) (<-chan BackfillProgress, error) //perm:admin
+
 	// GasEstimateFeeCap estimates gas fee cap
 	GasEstimateFeeCap(context.Context, *types.Message, int64, types.TipSetKey) (types.BigInt, error) //perm:read
 
@@ -199,6 +311,11 @@ type FullNode interface {
 	// SyncState returns the current status of the lotus sync system.
 	SyncState(context.Context) (*SyncState, error) //perm:read
 
+	// SyncSubscribe returns a channel streaming the progress of the sync workers,
+	// with an update for each synced tipset breaking down the time spent in
+	// each sync stage. Updates are dropped when the reader falls behind.
+	SyncSubscribe(context.Context) (<-chan SyncProgress, error) //perm:read
+
 	// SyncSubmitBlock can be used to submit a newly created block to the.
 	// network through this node
 	SyncSubmitBlock(ctx context.Context, blk *types.BlockMsg) error //perm:write
@@ -210,6 +327,15 @@ type FullNode interface {
 	// SyncCheckpoint marks a blocks as checkpointed, meaning that it won't ever fork away from it.
 	SyncCheckpoint(ctx context.Context, tsk types.TipSetKey) error //perm:admin
 
+	// SyncCheckpointSigned verifies the authority signatures on a checkpoint
+	// against the configured checkpoint authority set, and checkpoints the tipset
+	// it designates, treating it as final.
+	SyncCheckpointSigned(ctx context.Context, cp *SignedCheckpoint) error //perm:admin
+
+	// SyncCheckpointFeedStatus returns the status of the checkpoint feed
+	// subscription, including the latest verified authority checkpoint.
+	SyncCheckpointFeedStatus(context.Context) (CheckpointFeedStatus, error) //perm:read
+
 	// SyncMarkBad marks a blocks as bad, meaning that it won't ever by synced.
 	// Use with extreme caution.
 	SyncMarkBad(ctx context.Context, bcid cid.Cid) error //perm:admin
@@ -227,6 +353,11 @@ type FullNode interface {
 	// SyncValidateTipset indicates whether the provided tipset is valid or not
 	SyncValidateTipset(ctx context.Context, tsk types.TipSetKey) (bool, error) //perm:read
 
+	// SyncForks returns the competing chain heads advertised by peers at recent
+	// heights, along with the tipsets recently orphaned by reorgs of the local
+	// chain.
+	SyncForks(context.Context) (ForkStatus, error) //perm:read
+
 	// MethodGroup: Mpool
 	// The Mpool methods are for interacting with the message pool. The message pool
 	// manages all incoming and outgoing 'messages' going over the network.
@@ -341,6 +472,9 @@ type FullNode interface {
 	ClientGetDealUpdates(ctx context.Context) (<-chan DealInfo, error) //perm:write
 	// ClientGetDealStatus returns status given a code
 	ClientGetDealStatus(ctx context.Context, statusCode uint64) (string, error) //perm:read
+	// ClientDealHealth returns the health of the sectors holding the active deals of the local client, as of the
+	// last check of the deal health monitor, with the replacement deals proposed for the failed ones.
+	ClientDealHealth(ctx context.Context) ([]DealHealth, error) //perm:read
 	// ClientHasLocal indicates whether a certain CID is locally stored.
 	ClientHasLocal(ctx context.Context, root cid.Cid) (bool, error) //perm:write
 	// ClientFindData identifies peers that have a certain file, and returns QueryOffers (one per peer).
@@ -476,6 +610,7 @@ type FullNode interface {
 	// StateSectorPartition finds deadline/partition with the specified sector
 	StateSectorPartition(ctx context.Context, maddr address.Address, sectorNumber abi.SectorNumber, tok types.TipSetKey) (*lminer.SectorLocation, error) //perm:read
 	// StateSearchMsg looks back up to limit epochs in the chain for a message, and returns its receipt and the tipset where it was executed
+	// LookbackFinality can be passed as limit to search back as far as the finality configured on the node.
 	//
 	// NOTE: If a replacing message is found on chain, this method will return
 	// a MsgLookup for the replacing message - the MsgLookup.Message will be a different
@@ -495,6 +630,8 @@ type FullNode interface {
 	// StateWaitMsg looks back up to limit epochs in the chain for a message.
 	// If not found, it blocks until the message arrives on chain, and gets to the
 	// indicated confidence depth.
+	// ConfidenceDefault and LookbackFinality can be passed as confidence and limit to
+	// use the values configured on the node instead of the mainnet defaults.
 	//
 	// NOTE: If a replacing message is found on chain, this method will return
 	// a MsgLookup for the replacing message - the MsgLookup.Message will be a different
@@ -624,6 +761,11 @@ type FullNode interface {
 	//appear here.
 	MsigGetPending(context.Context, address.Address, types.TipSetKey) ([]*MsigTransaction, error) //perm:read
 
+	// MsigGetReport returns the balances, vesting schedule and signers of a multisig, the transactions
+	// it executed in the lookback period before the tipset, and a projection of its balance unlocking
+	// every unlockStep epochs until fully vested.
+	MsigGetReport(ctx context.Context, addr address.Address, lookback abi.ChainEpoch, unlockStep abi.ChainEpoch, tsk types.TipSetKey) (*MsigReport, error) //perm:read
+
 	// MsigCreate creates a multisig wallet
 	// It takes the following params: <required number of senders>, <approving addresses>, <unlock duration>
 	//<initial balance>, <sender address of the create msg>, <gas price>
@@ -732,11 +874,40 @@ type FullNode interface {
 	PaychVoucherAdd(context.Context, address.Address, *paych.SignedVoucher, []byte, types.BigInt) (types.BigInt, error) //perm:write
 	PaychVoucherList(context.Context, address.Address) ([]*paych.SignedVoucher, error)                                  //perm:write
 	PaychVoucherSubmit(context.Context, address.Address, *paych.SignedVoucher, []byte, []byte) (cid.Cid, error)         //perm:sign
+	// PaychBudget returns the budget of the payment channels managed for retrievals, and the channels it
+	// applies to
+	PaychBudget(ctx context.Context) (*PaychBudgetStatus, error) //perm:read
+	// PaychSetBudget changes the budget of the payment channels managed for retrievals
+	PaychSetBudget(ctx context.Context, budget PaychBudget) error //perm:sign
+	// PaychExport returns the local state of a payment channel, including all its vouchers, signed by
+	// the node's address on the channel, so that it can be imported by another node
+	PaychExport(ctx context.Context, ch address.Address) (*PaychBundle, error) //perm:sign
+	// PaychImport verifies and merges an exported payment channel into the local state. The node must
+	// hold the key of the address which signed the bundle.
+	PaychImport(ctx context.Context, bundle *PaychBundle) error //perm:sign
+
+	// MethodGroup: Watchlist
+	// The Watchlist methods manage addresses whose balances are monitored by
+	// the node. Violations of the configured conditions are raised as alerts,
+	// which can be inspected with LogAlerts.
+
+	// WatchlistAdd adds an address to the watchlist, or replaces the conditions
+	// of an address which is already watched.
+	WatchlistAdd(ctx context.Context, entry WatchlistEntry) error //perm:admin
+	// WatchlistRemove removes an address from the watchlist and resolves any
+	// alert raised for it.
+	WatchlistRemove(ctx context.Context, addr address.Address) error //perm:admin
+	// WatchlistList returns all watched addresses along with their status as of
+	// the last processed tipset.
+	WatchlistList(ctx context.Context) ([]WatchlistStatus, error) //perm:read
 
 	// MethodGroup: Node
 	// These methods are general node management and status commands
 
 	NodeStatus(ctx context.Context, inclChainStatus bool) (NodeStatus, error) //perm:read
+	// NodeHealth returns the health of the subsystems of the node, with an
+	// overall OK, WARN or CRIT level
+	NodeHealth(ctx context.Context) (NodeHealth, error) //perm:read
 
 	// CreateBackup creates node backup onder the specified file name. The
 	// method requires that the lotus daemon is running with the
@@ -814,6 +985,43 @@ type DealInfo struct {
 	DataTransfer      *DataTransferChannel
 }
 
+// Health statuses of the active deals of the client
+const (
+	// DealHealthActive means the deal's sector is being proven
+	DealHealthActive = "active"
+	// DealHealthFaulty means the deal's sector is faulty
+	DealHealthFaulty = "faulty"
+	// DealHealthTerminated means the deal was slashed, or its sector terminated
+	DealHealthTerminated = "terminated"
+	// DealHealthUnknown means the deal's sector wasn't found yet
+	DealHealthUnknown = "unknown"
+)
+
+// DealHealth is the health of the sector holding an active deal of the client
+type DealHealth struct {
+	ProposalCid cid.Cid
+	DealID      abi.DealID
+	Provider    address.Address
+	PieceCID    cid.Cid
+	Sector      abi.SectorNumber
+
+	Status string
+	// FaultySince is when the deal's sector was first seen faulty
+	FaultySince time.Time
+	Error       string
+
+	// Replacement is the deal proposed to a backup provider to replace the
+	// deal, if it failed
+	Replacement *DealReplacement
+}
+
+// DealReplacement is a deal proposed to replace a failed deal
+type DealReplacement struct {
+	ProposalCid cid.Cid
+	Provider    address.Address
+	ProposedAt  time.Time
+}
+
 type MsgLookup struct {
 	Message   cid.Cid // Can be different than requested, in case it was replaced, but only gas values changed
 	Receipt   types.MessageReceipt
@@ -875,6 +1083,79 @@ type ChannelInfo struct {
 	WaitSentinel cid.Cid
 }
 
+// PaychBudget limits the funds of the payment channels created and funded
+// automatically to pay for retrievals
+type PaychBudget struct {
+	// Enable manages the payment channels within the budget. When disabled,
+	// channels receive the exact funds of each retrieval.
+	Enable bool
+	// MaxTotal is the most funds locked in active outbound channels, zero for
+	// no limit
+	MaxTotal types.BigInt
+	// MaxPerChannel is the most funds locked in a single channel, zero for no
+	// limit
+	MaxPerChannel types.BigInt
+	// TopUp is the least amount added to a channel lacking funds, so that the
+	// following retrievals don't each need an on-chain message
+	TopUp types.BigInt
+	// MaxLanes settles a channel once it allocated that many lanes, the
+	// following retrievals use a new channel. Zero for no limit.
+	MaxLanes uint64
+	// SettleAfter settles channels without retrieval activity for that long,
+	// and collects them when the settlement period is over. Zero to never
+	// settle.
+	SettleAfter time.Duration
+}
+
+// PaychBudgetStatus is the state of the payment channels managed for
+// retrievals
+type PaychBudgetStatus struct {
+	Budget PaychBudget
+	// Locked is the amount of funds in active outbound channels
+	Locked   types.BigInt
+	Channels []PaychBudgetChannel
+}
+
+// PaychBudgetChannel describes an outbound payment channel
+type PaychBudgetChannel struct {
+	Channel      address.Address
+	To           address.Address
+	Amount       types.BigInt
+	Lanes        uint64
+	LastActivity time.Time
+	Settling     bool
+	Collected    bool
+}
+
+// PaychBundle is a portable copy of the local state of a payment channel
+type PaychBundle struct {
+	Channel address.Address
+	// Control is the address of the exporting node on the channel, which
+	// signs the bundle
+	Control   address.Address
+	Target    address.Address
+	Direction PCHDir
+	NextLane  uint64
+	Amount    types.BigInt
+	Available types.BigInt
+	// Lanes are the lane states on chain when the bundle was exported
+	Lanes    []PaychLaneState
+	Vouchers []PaychBundleVoucher
+
+	Signature *crypto.Signature
+}
+
+type PaychLaneState struct {
+	Lane     uint64
+	Redeemed types.BigInt
+	Nonce    uint64
+}
+
+type PaychBundleVoucher struct {
+	Voucher   *paych.SignedVoucher
+	Submitted bool
+}
+
 type ChannelAvailableFunds struct {
 	// Channel is the address of the channel
 	Channel *address.Address
@@ -1053,6 +1334,9 @@ type ActiveSync struct {
 	Start   time.Time
 	End     time.Time
 	Message string
+
+	// Timings are the time spent in each stage so far
+	Timings SyncStageTimings
 }
 
 type SyncState struct {
@@ -1061,6 +1345,127 @@ type SyncState struct {
 	VMApplied uint64
 }
 
+// SyncProgress is a progress update of a sync worker
+type SyncProgress struct {
+	WorkerID uint64
+	Time     time.Time
+
+	// Target is the tipset the worker is syncing to
+	Target types.TipSetKey
+
+	// Stage is the stage the update concludes:
+	//  - StagePersistHeaders once the headers up to the target were fetched and
+	//    persisted, with Fetch and Persist timings
+	//  - StageMessages for each tipset whose messages were synced and validated
+	//  - StageSyncComplete and StageSyncErrored when the worker is done, with
+	//    timings summed over the whole sync
+	Stage SyncStateStage
+
+	// Height and TipSet are set for StageMessages updates
+	Height abi.ChainEpoch
+	TipSet types.TipSetKey
+
+	Timings SyncStageTimings
+	Error   string
+}
+
+// SyncStageTimings breaks down the time spent syncing
+type SyncStageTimings struct {
+	// Fetch is the time spent fetching headers or messages from the network;
+	// messages are fetched in batches, and each tipset of a batch is charged an
+	// equal share of the batch
+	Fetch time.Duration
+	// ValidateMessages is the time spent validating the messages of the tipset,
+	// including their signatures
+	ValidateMessages time.Duration
+	// ComputeState is the time spent computing the parent state of the tipset
+	ComputeState time.Duration
+	// Persist is the time spent writing headers or messages to the chain blockstore
+	Persist time.Duration
+}
+
+func (t *SyncStageTimings) Add(o SyncStageTimings) {
+	t.Fetch += o.Fetch
+	t.ValidateMessages += o.ValidateMessages
+	t.ComputeState += o.ComputeState
+	t.Persist += o.Persist
+}
+
+// ForkStatus lists the competing heads and the orphaned tipsets known to the node
+type ForkStatus struct {
+	// Head is the current head of the node's chain
+	Head   types.TipSetKey
+	Height abi.ChainEpoch
+
+	// Heads are ordered from the highest to the lowest
+	Heads []ForkHead
+	// Orphans are ordered from the most recently orphaned
+	Orphans []OrphanedTipSet
+}
+
+// ForkHead is a head advertised by peers. The blocks advertised at the same
+// height with the same parents are grouped into one head.
+type ForkHead struct {
+	Height  abi.ChainEpoch
+	Parents types.TipSetKey
+	TipSet  types.TipSetKey
+	Miners  []address.Address
+
+	ParentWeight types.BigInt
+	// Weight is zero until the parent state of the head was computed
+	Weight types.BigInt
+
+	// Peers is the number of peers which advertised blocks of the head
+	Peers int
+	// Canonical is set when the head's parents are those of the tipset at the same
+	// height in the node's chain
+	Canonical bool
+
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// OrphanedTipSet is a tipset reverted by a reorg of the node's chain
+type OrphanedTipSet struct {
+	TipSet       types.TipSetKey
+	Height       abi.ChainEpoch
+	Miners       []address.Address
+	ParentWeight types.BigInt
+	OrphanedAt   time.Time
+}
+
+// Checkpoint designates a tipset which the checkpoint authorities consider final
+type Checkpoint struct {
+	Height abi.ChainEpoch
+	TipSet types.TipSetKey
+}
+
+type CheckpointSignature struct {
+	Signer    address.Address
+	Signature crypto.Signature
+}
+
+// SignedCheckpoint is a checkpoint signed by members of a checkpoint authority set
+type SignedCheckpoint struct {
+	Checkpoint
+	Signatures []CheckpointSignature
+}
+
+type CheckpointFeedStatus struct {
+	// URL of the feed; empty when only signed checkpoints submitted through the
+	// API are accepted
+	URL         string
+	Authorities []address.Address
+	Threshold   int
+
+	// Latest is the latest verified authority checkpoint, nil if none was seen yet
+	Latest    *SignedCheckpoint
+	AppliedAt time.Time
+
+	LastPoll  time.Time
+	LastError string
+}
+
 type SyncStateStage int
 
 const (
@@ -1168,6 +1573,29 @@ type HeadChange struct {
 	Val  *types.TipSet
 }
 
+// IndexedMessage locates a message included in the node's chain
+type IndexedMessage struct {
+	Cid cid.Cid
+	// TipSet is the tipset which included the message
+	TipSet types.TipSetKey
+	Height abi.ChainEpoch
+}
+
+// DetailedHeadChange is a HeadChange along with the effects of the state
+// transition recorded by the tipset, which are undone by a revert
+type DetailedHeadChange struct {
+	HeadChange
+
+	// Messages are the messages executed in the transition, i.e. the messages of
+	// the parent tipset, whose receipts are referenced by the tipset
+	Messages []cid.Cid
+	// Actors are the actors whose state heads changed in the transition
+	Actors []address.Address
+
+	// Error is set when the effects couldn't be computed
+	Error string
+}
+
 type MsigProposeResponse int
 
 const (
@@ -1219,3 +1647,288 @@ type MsigTransaction struct {
 
 	Approved []address.Address
 }
+
+// MsigReport describes the funds and activity of a multisig
+type MsigReport struct {
+	Address address.Address
+	ID      address.Address
+	Height  abi.ChainEpoch
+
+	Balance   abi.TokenAmount
+	Locked    abi.TokenAmount
+	Spendable abi.TokenAmount
+	Vesting   MsigVesting
+
+	Threshold uint64
+	Signers   []address.Address
+
+	Pending  []*MsigTransaction
+	Executed []MsigExecutedTransaction
+	Unlocks  []MsigUnlock
+}
+
+// MsigExecutedTransaction is a transaction executed by a multisig, once it
+// gathered enough approvals
+type MsigExecutedTransaction struct {
+	ID int64
+	// Message is the proposal or approval which executed the transaction
+	Message  cid.Cid
+	Approver address.Address
+	Epoch    abi.ChainEpoch
+
+	To     address.Address
+	Value  abi.TokenAmount
+	Method abi.MethodNum
+	Params []byte
+	// DecodedParams are the JSON params, when the method of the target actor
+	// is known
+	DecodedParams json.RawMessage `json:",omitempty"`
+	ExitCode      exitcode.ExitCode
+}
+
+// MsigUnlock is the projected locked balance of a multisig at an epoch
+type MsigUnlock struct {
+	Epoch     abi.ChainEpoch
+	Locked    abi.TokenAmount
+	Spendable abi.TokenAmount
+}
+
+// WatchlistEntry describes an address watched by the node, and the balance
+// conditions which raise an alert when violated.
+type WatchlistEntry struct {
+	Address address.Address
+	Label   string
+
+	// MinBalance and MaxBalance bound the expected balance of the address.
+	// Zero values disable the respective check.
+	MinBalance abi.TokenAmount
+	MaxBalance abi.TokenAmount
+
+	// MaxChange is the maximum absolute balance change allowed over the last
+	// ChangeWindow epochs. A zero value disables the check.
+	MaxChange    abi.TokenAmount
+	ChangeWindow abi.ChainEpoch
+}
+
+type WatchlistStatus struct {
+	Entry WatchlistEntry
+
+	// Height is the height of the tipset the status was computed at; zero if
+	// the entry wasn't checked yet.
+	Height  abi.ChainEpoch
+	Balance abi.TokenAmount
+	Change  abi.TokenAmount
+
+	Violations []string
+}
+
+type BlockstoreGCOpts struct {
+	// MaxDuration limits how long the GC run may take; 0 means no limit
+	MaxDuration time.Duration
+	// MaxProbeLatency stops the GC run when a probe read from the blockstore takes
+	// longer than this, in order not to starve the node; 0 disables the probe
+	MaxProbeLatency time.Duration
+	// Threshold is the discard ratio above which a value log file is rewritten;
+	// 0 uses the blockstore default
+	Threshold float64
+}
+
+type BlockstoreGCStatus struct {
+	Running bool
+
+	// Scheduled is set when daily GC runs are enabled; NextWindow is the start
+	// of the next scheduled run window
+	Scheduled  bool
+	NextWindow time.Time
+
+	// Start and End of the current or last run; End is zero while running
+	Start time.Time
+	End   time.Time
+
+	// Iterations is the number of value log GC iterations performed
+	Iterations int64
+	// SizeBefore and SizeAfter are the blockstore sizes around the run, when supported
+	SizeBefore int64
+	SizeAfter  int64
+
+	// AbortReason is set if the run was stopped early because a limit was reached
+	AbortReason string
+	Error       string
+}
+
+type BlockstoreScrubStatus struct {
+	Running bool
+
+	// Periodic is set when periodic scrub passes are enabled; NextPass is the start
+	// of the next periodic pass
+	Periodic bool
+	NextPass time.Time
+
+	// Start and End of the current or last pass; End is zero while running
+	Start time.Time
+	End   time.Time
+
+	// Checked and CheckedBytes are the number and size of the blocks verified so far
+	Checked      int64
+	CheckedBytes int64
+	// Corrupt is the number of corrupt blocks found, of which Refetched were
+	// re-fetched from the network
+	Corrupt   int64
+	Refetched int64
+	// Quarantined is the total number of quarantined blocks, including ones found
+	// in earlier passes
+	Quarantined int64
+
+	Error string
+}
+
+// BackfillSpec selects the height range and the kinds of data fetched by ChainBackfill
+type BackfillSpec struct {
+	From abi.ChainEpoch
+	To   abi.ChainEpoch
+
+	Messages bool
+	Receipts bool
+	State    bool
+}
+
+const (
+	BackfillStageMessages = "messages"
+	BackfillStageObjects  = "receipts/state"
+)
+
+type BackfillProgress struct {
+	Stage string
+	// Height of the last processed tipset
+	Height abi.ChainEpoch
+	// TipSets is the number of tipsets processed in the current stage, out of Total
+	TipSets int
+	Total   int
+
+	// Messages is the number of tipsets whose messages were fetched
+	Messages int
+	// Objects and Bytes are the number and size of the receipt and state blocks
+	// fetched
+	Objects int64
+	Bytes   int64
+
+	Done  bool
+	Error string
+}
+
+type BlockstoreMount struct {
+	Name      string
+	Path      string
+	Roots     []cid.Cid
+	MountedAt time.Time
+}
+
+type ChainExportRangeOpts struct {
+	// MessagesDepth, ReceiptsDepth and StateDepth are the number of epochs at the
+	// top of the range for which the messages, the message receipts and the state
+	// trees of the blocks are included. Zero includes none, and a negative depth
+	// includes them for the whole range.
+	MessagesDepth abi.ChainEpoch
+	ReceiptsDepth abi.ChainEpoch
+	StateDepth    abi.ChainEpoch
+}
+
+type ChainSnapshotStatus struct {
+	// Schedule is the cron expression of the snapshot schedule; NextRun is the
+	// time of the next scheduled snapshot
+	Schedule string
+	NextRun  time.Time
+
+	// Running is set while a snapshot is being written
+	Running bool
+
+	// Latest is the most recent snapshot, nil if none was taken yet; Snapshots
+	// lists the retained snapshots, most recent first
+	Latest    *ChainSnapshot
+	Snapshots []ChainSnapshot
+
+	// LastError is the error of the last snapshot attempt, if it failed
+	LastError string
+}
+
+type ChainSnapshot struct {
+	// Path is the location of the snapshot on the node's filesystem; URL is its
+	// public location when the snapshots are uploaded
+	Path string
+	URL  string `json:",omitempty"`
+
+	Height abi.ChainEpoch
+	TipSet types.TipSetKey
+
+	// LatestState is set if the snapshot only has the latest state and recent
+	// headers, without the chain history
+	LatestState bool
+	Compressed  bool
+
+	Size     int64
+	Created  time.Time
+	Duration time.Duration
+}
+
+type BlockPropagation struct {
+	Cid    cid.Cid
+	Height abi.ChainEpoch
+	Miner  address.Address
+
+	// EpochStart is the start of the epoch of the block, from its timestamp
+	EpochStart time.Time
+	// Arrival is when the block was first received, Delay how long after the
+	// start of its epoch
+	Arrival time.Time
+	Delay   time.Duration
+	// FirstPeer is the peer the block was first received from; Local is set for
+	// the blocks published by the node
+	FirstPeer peer.ID
+	Local     bool
+	// Receipts is the number of times the block was received
+	Receipts int
+
+	// ValidationDuration is how long the pubsub validation of the block took,
+	// MessageFetchDuration how long fetching its messages took once accepted
+	ValidationDuration   time.Duration
+	MessageFetchDuration time.Duration
+	// Result is the result of the validation, accept, ignore or reject, followed
+	// by the reason if any
+	Result string
+
+	// Orphaned is set when the block is below the head, but not part of the
+	// current chain
+	Orphaned bool
+}
+
+// The states of consensus faults detected by the fault reporter
+const (
+	ConsensusFaultPending   = "pending"
+	ConsensusFaultSubmitted = "submitted"
+	ConsensusFaultDismissed = "dismissed"
+)
+
+// ConsensusFault is a consensus fault detected in incoming blocks, identified
+// by the block which completed it
+type ConsensusFault struct {
+	Block    cid.Cid
+	Miner    address.Address
+	Epoch    abi.ChainEpoch
+	Type     string
+	Detected time.Time
+
+	// The evidence, as passed to ReportConsensusFault: BlockHeader1 and
+	// BlockHeader2 are the CBOR encoded headers of the two blocks of the fault,
+	// BlockHeaderExtra the header of the sibling of the first block which the
+	// second one builds on, for parent-grinding faults
+	BlockHeader1     []byte
+	BlockHeader2     []byte
+	BlockHeaderExtra []byte
+
+	// Status is pending, submitted or dismissed; Message is the
+	// ReportConsensusFault message once submitted
+	Status  string
+	Message *cid.Cid
+	// Error is the last error submitting the fault
+	Error string
+}
@@ -36,6 +36,11 @@ type Net interface {
 	NetAgentVersion(ctx context.Context, p peer.ID) (string, error)           //perm:read
 	NetPeerInfo(context.Context, peer.ID) (*ExtendedPeerInfo, error)          //perm:read
 
+	// NetPeerInfoDetailed returns the extended info of a peer along with its
+	// gossipsub score, how well it propagates blocks and messages over pubsub,
+	// and its bitswap ledger, on nodes running bitswap.
+	NetPeerInfoDetailed(context.Context, peer.ID) (*PeerInfoDetailed, error) //perm:read
+
 	// NetBandwidthStats returns statistics about the nodes total bandwidth
 	// usage and current rate across all peers and protocols.
 	NetBandwidthStats(ctx context.Context) (metrics.Stats, error) //perm:read
@@ -48,14 +53,31 @@ type Net interface {
 	// usage and current rate per protocol
 	NetBandwidthStatsByProtocol(ctx context.Context) (map[protocol.ID]metrics.Stats, error) //perm:read
 
+	// NetBandwidthStatsByProtocolGroup returns statistics about the nodes bandwidth
+	// usage by group of protocols: gossipsub, bitswap (including chain exchange),
+	// graphsync and other, along with the bandwidth limits applied to the groups
+	NetBandwidthStatsByProtocolGroup(ctx context.Context) (map[string]ProtocolGroupBandwidth, error) //perm:read
+
 	// ConnectionGater API
 	NetBlockAdd(ctx context.Context, acl NetBlockList) error    //perm:admin
 	NetBlockRemove(ctx context.Context, acl NetBlockList) error //perm:admin
 	NetBlockList(ctx context.Context) (NetBlockList, error)     //perm:read
 
+	// NetAllowAdd adds peers, IP addresses and subnets to the allow list, which
+	// are accepted by the connection gater even when blocked, e.g. to allow a
+	// trusted subnet within a blocked range. Connections from allowed peers and
+	// addresses are protected from the connection manager. The allow list is
+	// persisted across restarts, like the block list.
+	NetAllowAdd(ctx context.Context, acl NetBlockList) error    //perm:admin
+	NetAllowRemove(ctx context.Context, acl NetBlockList) error //perm:admin
+	NetAllowList(ctx context.Context) (NetBlockList, error)     //perm:read
+
+	// NetProtectAdd protects the connections to the peers from the connection
+	// manager; the protected peers are persisted across restarts
 	NetProtectAdd(ctx context.Context, acl []peer.ID) error    //perm:admin
 	NetProtectRemove(ctx context.Context, acl []peer.ID) error //perm:admin
-	NetProtectList(ctx context.Context) ([]peer.ID, error)     //perm:read
+	// NetProtectList lists the protected peers, whether they are connected or not
+	NetProtectList(ctx context.Context) ([]peer.ID, error) //perm:read
 
 	// ResourceManager API
 	NetStat(ctx context.Context, scope string) (NetStat, error)          //perm:read
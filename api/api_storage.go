@@ -3,6 +3,8 @@ package api
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"net/http"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,10 +20,12 @@ import (
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/builtin/v8/market"
 	"github.com/filecoin-project/go-state-types/builtin/v8/miner"
+	"github.com/filecoin-project/go-state-types/exitcode"
 	abinetwork "github.com/filecoin-project/go-state-types/network"
 
 	"github.com/filecoin-project/lotus/chain/actors/builtin"
 	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/storage/pipeline/sealiface"
 	"github.com/filecoin-project/lotus/storage/sealer/fsutil"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
@@ -49,8 +53,31 @@ type StorageMiner interface {
 	ActorAddressConfig(ctx context.Context) (AddressConfig, error)            //perm:read
 
 	MiningBase(context.Context) (*types.TipSet, error) //perm:read
+	// WinningPoStHistory lists the elections won since the miner started, with
+	// the time taken by each phase of the block production, and whether the
+	// mined block made it into the chain
+	WinningPoStHistory(context.Context) ([]WinningPoStRecord, error) //perm:read
 
 	ComputeWindowPoSt(ctx context.Context, dlIdx uint64, tsk types.TipSetKey) ([]miner.SubmitWindowedPoStParams, error) //perm:admin
+	// WindowPoStDryRun generates the vanilla proofs of the challenges of the next
+	// occurrence of a deadline, without computing or submitting the snarks
+	WindowPoStDryRun(ctx context.Context, dlIdx uint64) (WdPoStDryRun, error) //perm:admin
+	// WindowPoStSenderStatus lists the WindowPoSt messages sent in the last
+	// proving period, and whether they landed
+	WindowPoStSenderStatus(ctx context.Context) ([]WdPoStMessage, error) //perm:read
+
+	// ProvingDisputeCheck simulates disputing each of the WindowPoSts submitted
+	// by a miner at the last occurrence of a deadline
+	ProvingDisputeCheck(ctx context.Context, maddr address.Address, dlIdx uint64) ([]DisputablePoSt, error) //perm:read
+	// ProvingDispute sends a DisputeWindowedPoSt message for an invalid
+	// WindowPoSt; it fails when the simulated dispute doesn't succeed
+	ProvingDispute(ctx context.Context, maddr address.Address, dlIdx uint64, postIdx uint64) (cid.Cid, error) //perm:admin
+	// ProvingDisputeEvidence records sectors of a miner known to be faulty; the
+	// WindowPoSts of their deadlines are checked by the disputer
+	ProvingDisputeEvidence(ctx context.Context, maddr address.Address, sectors []abi.SectorNumber) error //perm:admin
+	// ProvingDisputeStatus lists the WindowPoSts checked by the disputer which
+	// can still be disputed
+	ProvingDisputeStatus(ctx context.Context) ([]DisputablePoSt, error) //perm:read
 
 	ComputeDataCid(ctx context.Context, pieceSize abi.UnpaddedPieceSize, pieceData storiface.Data) (abi.PieceInfo, error) //perm:admin
 
@@ -78,6 +105,9 @@ type StorageMiner interface {
 
 	SectorsRefs(context.Context) (map[string][]SealedRef, error) //perm:read
 
+	// SectorsPackingStatus returns the deal packing limits, the sectors open for deals and the deals
+	// waiting to be added to sectors
+	SectorsPackingStatus(ctx context.Context) (DealPackingStatus, error) //perm:read
 	// SectorStartSealing can be called on sectors in Empty or WaitDeals states
 	// to trigger sealing early
 	SectorStartSealing(context.Context, abi.SectorNumber) error //perm:write
@@ -104,6 +134,10 @@ type StorageMiner interface {
 	SectorTerminateFlush(ctx context.Context) (*cid.Cid, error) //perm:admin
 	// SectorTerminatePending returns a list of pending sector terminations to be sent in the next batch message
 	SectorTerminatePending(ctx context.Context) ([]abi.SectorID, error) //perm:admin
+	// SectorTerminateEstimate computes the penalties which would be paid if the given sectors were
+	// terminated at the given epoch. Epoch 0 means the current chain head. Future epochs are
+	// estimated with the current reward and network power estimates
+	SectorTerminateEstimate(ctx context.Context, sectors []abi.SectorNumber, epoch abi.ChainEpoch) (*TerminateEstimate, error) //perm:read
 	// SectorPreCommitFlush immediately sends a PreCommit message with sectors batched for PreCommit.
 	// Returns null if message wasn't sent
 	SectorPreCommitFlush(ctx context.Context) ([]sealiface.PreCommitBatchRes, error) //perm:admin
@@ -114,9 +148,24 @@ type StorageMiner interface {
 	SectorCommitFlush(ctx context.Context) ([]sealiface.CommitBatchRes, error) //perm:admin
 	// SectorCommitPending returns a list of pending Commit sectors to be sent in the next aggregate message
 	SectorCommitPending(ctx context.Context) ([]abi.SectorID, error) //perm:admin
-	SectorMatchPendingPiecesToOpenSectors(ctx context.Context) error //perm:admin
+	// SectorPreCommitBatchPlan returns how, and when, the pending PreCommit sectors will be sent
+	SectorPreCommitBatchPlan(ctx context.Context) (sealiface.BatchPlan, error) //perm:admin
+	// SectorPreCommitSetBatchMode overrides the choice between PreCommit batches and individual
+	// messages, 'auto' follows the config
+	SectorPreCommitSetBatchMode(ctx context.Context, mode sealiface.BatchMode) error //perm:admin
+	// SectorCommitBatchPlan returns how, and when, the pending Commit sectors will be sent
+	SectorCommitBatchPlan(ctx context.Context) (sealiface.BatchPlan, error) //perm:admin
+	// SectorCommitSetBatchMode overrides the choice between Commit aggregates and individual
+	// messages, 'auto' follows the config
+	SectorCommitSetBatchMode(ctx context.Context, mode sealiface.BatchMode) error //perm:admin
+	SectorMatchPendingPiecesToOpenSectors(ctx context.Context) error              //perm:admin
 	// SectorAbortUpgrade can be called on sectors that are in the process of being upgraded to abort it
 	SectorAbortUpgrade(context.Context, abi.SectorNumber) error //perm:admin
+	// SectorsSnapList lists the sectors in the SnapDeals pipeline, from Available CC sectors to the
+	// release of the sector key of upgraded ones
+	SectorsSnapList(context.Context) ([]SnapSectorInfo, error) //perm:read
+	// SectorSnapRetry retries the failed step of a sector in a failed SnapDeals state right away
+	SectorSnapRetry(context.Context, abi.SectorNumber) error //perm:admin
 
 	// WorkerConnect tells the node to connect to workers RPC
 	WorkerConnect(context.Context, string) error                              //perm:admin retry:true
@@ -146,6 +195,22 @@ type StorageMiner interface {
 	SealingSchedDiag(ctx context.Context, doSched bool) (interface{}, error) //perm:admin
 	SealingAbort(ctx context.Context, call storiface.CallID) error           //perm:admin
 
+	// SealingSchedPriorities returns the priorities of the sealing tasks by class of sector
+	SealingSchedPriorities(ctx context.Context) (storiface.SchedPriorities, error) //perm:admin
+	// SealingSetSchedPriorities changes the priorities of the sealing tasks, including the
+	// tasks waiting to be scheduled, until the miner restarts
+	SealingSetSchedPriorities(ctx context.Context, p storiface.SchedPriorities) error //perm:admin
+
+	// SealingWorkersTag adds tags to the workers on a host, which the workers need to run the
+	// tasks matched by the affinity rules, until the miner restarts
+	SealingWorkersTag(ctx context.Context, hostname string, tags []string) error //perm:admin
+	// SealingWorkersUntag removes tags from the workers on a host, until the miner restarts
+	SealingWorkersUntag(ctx context.Context, hostname string, tags []string) error //perm:admin
+	// SealingWorkersBootstrapToken creates a one-time token registering a worker with
+	// 'lotus-worker run --bootstrap', valid for ttl unless it is zero. The worker gets
+	// the named template, or the first template its host satisfies when template is empty.
+	SealingWorkersBootstrapToken(ctx context.Context, template string, ttl time.Duration) (string, error) //perm:admin
+
 	// paths.SectorIndex
 	StorageAttach(context.Context, storiface.StorageInfo, fsutil.FsStat) error                                                         //perm:admin
 	StorageInfo(context.Context, storiface.ID) (storiface.StorageInfo, error)                                                          //perm:admin
@@ -170,9 +235,32 @@ type StorageMiner interface {
 	StorageList(ctx context.Context) (map[storiface.ID][]storiface.Decl, error)                                                                                  //perm:admin
 	StorageGetLocks(ctx context.Context) (storiface.SectorLocks, error)                                                                                          //perm:admin
 
+	// StorageHealth returns the read statistics of the storage paths, and the
+	// reasons why the sectors in each path are predicted to fail their proofs
+	StorageHealth(ctx context.Context) ([]storiface.PathHealth, error) //perm:admin
+	// StorageAtRiskSectors returns the sectors with files in storage paths
+	// at risk, to be migrated or declared faulty before WindowPoSt fails
+	StorageAtRiskSectors(ctx context.Context) ([]storiface.AtRiskSector, error) //perm:admin
+
 	StorageLocal(ctx context.Context) (map[storiface.ID]string, error)       //perm:admin
 	StorageStat(ctx context.Context, id storiface.ID) (fsutil.FsStat, error) //perm:admin
 
+	// StorageUnsealQueue returns the running and waiting unseals, and the
+	// unsealed copies they created
+	StorageUnsealQueue(ctx context.Context) (storiface.UnsealQueue, error) //perm:admin
+
+	// StorageRebalance plans the moves of sectors between the local storage
+	// paths, following their weights and drain flags, and starts the moves
+	// unless dryRun is set
+	StorageRebalance(ctx context.Context, dryRun bool) ([]storiface.RebalanceMove, error) //perm:admin
+	// StorageRebalanceStatus returns the progress of the current or last
+	// rebalance
+	StorageRebalanceStatus(ctx context.Context) (storiface.RebalanceStatus, error) //perm:admin
+	// StorageSetDrain sets the drain flag of a local storage path; no new
+	// sectors are stored in draining paths, and rebalancing moves their
+	// sectors out
+	StorageSetDrain(ctx context.Context, id storiface.ID, drain bool) error //perm:admin
+
 	StorageAuthVerify(ctx context.Context, token string) ([]auth.Permission, error) //perm:read
 
 	MarketImportDealData(ctx context.Context, propcid cid.Cid, path string) error                                                                                                        //perm:write
@@ -195,6 +283,24 @@ type StorageMiner interface {
 	MarketPendingDeals(ctx context.Context) (PendingDealInfo, error)                                                             //perm:write
 	MarketPublishPendingDeals(ctx context.Context) error                                                                         //perm:admin
 	MarketRetryPublishDeal(ctx context.Context, propcid cid.Cid) error                                                           //perm:admin
+	// MarketPublishPendingDeal publishes a deal waiting in the publish queue right away, in a message of its own
+	MarketPublishPendingDeal(ctx context.Context, propcid cid.Cid) error //perm:admin
+	// MarketGetPublishConfig returns the batching configuration of deal publish messages
+	MarketGetPublishConfig(ctx context.Context) (DealPublishConfig, error) //perm:read
+	// MarketSetPublishConfig changes the batching configuration of deal publish messages until the node restarts
+	MarketSetPublishConfig(ctx context.Context, cfg DealPublishConfig) error //perm:admin
+	// MarketPendingFilterDecisions lists the storage deals queued by the deal filter webhook, waiting for a decision
+	MarketPendingFilterDecisions(ctx context.Context) ([]PendingFilterDecision, error) //perm:read
+	// MarketResolveFilterDecision accepts or rejects a storage deal queued by the deal filter webhook
+	MarketResolveFilterDecision(ctx context.Context, propcid cid.Cid, accept bool, reason string) error //perm:admin
+	// MarketSubscribeDealEvents returns a channel of the lifecycle transitions of storage deals, closed when the
+	// context is cancelled
+	MarketSubscribeDealEvents(ctx context.Context) (<-chan MarketDealEvent, error) //perm:read
+	// MarketDataTransferLimits returns the bandwidth and concurrency limits of the data sent by graphsync, and
+	// the transfers they apply to
+	MarketDataTransferLimits(ctx context.Context) (DataTransferLimitsStatus, error) //perm:read
+	// MarketSetDataTransferLimits changes the limits of the data sent by graphsync until the node restarts
+	MarketSetDataTransferLimits(ctx context.Context, limits DataTransferLimits) error //perm:admin
 
 	// DagstoreListShards returns information about all shards known to the
 	// DAG store. Only available on nodes running the markets subsystem.
@@ -255,6 +361,20 @@ type StorageMiner interface {
 	// in this instance.
 	RuntimeSubsystems(ctx context.Context) (MinerSubsystems, error) //perm:read
 
+	// NodeHealth returns the health of the subsystems of the miner, including
+	// the sealing workers and the readiness for the current deadline, with an
+	// overall OK, WARN or CRIT level
+	NodeHealth(ctx context.Context) (NodeHealth, error) //perm:read
+
+	// NotificationsTest fires a test event of the given type, e.g. "test" or
+	// "post-deadline", to the notification webhooks subscribed to it
+	NotificationsTest(ctx context.Context, event string) error //perm:admin
+
+	// MinerEvents streams the journal events recorded by this process. The
+	// events this process mirrors from the services it is connected to are not
+	// included, so that processes can subscribe to each other.
+	MinerEvents(ctx context.Context) (<-chan MinerEvent, error) //perm:read
+
 	DealsImportData(ctx context.Context, dealPropCid cid.Cid, file string) error //perm:admin
 	DealsList(ctx context.Context) ([]*MarketDeal, error)                        //perm:admin
 	DealsConsiderOnlineStorageDeals(context.Context) (bool, error)               //perm:admin
@@ -271,6 +391,13 @@ type StorageMiner interface {
 	DealsSetConsiderVerifiedStorageDeals(context.Context, bool) error            //perm:admin
 	DealsConsiderUnverifiedStorageDeals(context.Context) (bool, error)           //perm:admin
 	DealsSetConsiderUnverifiedStorageDeals(context.Context, bool) error          //perm:admin
+	// DealsImportDataFromURL imports the data of an offline deal streamed from a http(s) URL, resuming the
+	// transfer when it gets interrupted
+	DealsImportDataFromURL(ctx context.Context, dealPropCid cid.Cid, src DealDataURL) error //perm:admin
+	// DealsRetrievalPricingPolicy returns the retrieval pricing policy, used when the retrieval pricing strategy is "policy"
+	DealsRetrievalPricingPolicy(context.Context) (dtypes.RetrievalPricingPolicy, error) //perm:admin
+	// DealsSetRetrievalPricingPolicy sets the retrieval pricing policy, which applies to the following retrieval deals
+	DealsSetRetrievalPricingPolicy(context.Context, dtypes.RetrievalPricingPolicy) error //perm:admin
 
 	StorageAddLocal(ctx context.Context, path string) error //perm:admin
 
@@ -292,6 +419,145 @@ type StorageMiner interface {
 
 var _ storiface.WorkerReturn = *new(StorageMiner)
 
+// WdPoStDryRun is the result of a WindowPoSt dry-run of a deadline
+type WdPoStDryRun struct {
+	Deadline uint64
+	// Height of the chain head the dry-run ran at
+	Height abi.ChainEpoch
+	// Open and Close epochs of the next occurrence of the deadline
+	Open  abi.ChainEpoch
+	Close abi.ChainEpoch
+
+	// Challenge is the epoch of the randomness of the challenges. Before it's
+	// reached, the challenges are drawn from the randomness at Height, and
+	// RealChallenges is false.
+	Challenge      abi.ChainEpoch
+	RealChallenges bool
+
+	// SnarkTime is the time the last WindowPoSt computed by the node took for
+	// a partition, including its vanilla proofs; 0 when none was computed
+	SnarkTime time.Duration
+
+	Partitions []WdPoStDryRunPartition
+}
+
+type WdPoStDryRunPartition struct {
+	Index   uint64
+	Sectors int
+
+	// Vanilla is the time taken to generate the vanilla proofs of the
+	// partition, Slowest the time taken by its slowest sector
+	Vanilla time.Duration
+	Slowest time.Duration
+	// Estimate of the time to prove the partition: its vanilla proofs, and
+	// the snark time of the last WindowPoSt
+	Estimate time.Duration
+
+	// Failed are the sectors whose vanilla proof failed, with the error
+	Failed map[abi.SectorNumber]string
+}
+
+// WdPoStMessage is a SubmitWindowedPoSt message sent by the WindowPoSt sender
+type WdPoStMessage struct {
+	Deadline   uint64
+	Partitions int
+	// Close epoch of the deadline; the message isn't bumped after it
+	Close abi.ChainEpoch
+
+	// Cid of the last version of the message, after the bumps
+	Cid   cid.Cid
+	From  address.Address
+	Nonce uint64
+	// Pushed is the epoch the message was first pushed at
+	Pushed     abi.ChainEpoch
+	Bumps      int
+	GasFeeCap  abi.TokenAmount
+	GasPremium abi.TokenAmount
+	// FullNodes is the number of additional full nodes which accepted the
+	// last version of the message
+	FullNodes int
+
+	State WdPoStMessageState
+	// Epoch and ExitCode of the message once it landed
+	Epoch    abi.ChainEpoch
+	ExitCode exitcode.ExitCode
+	Error    string `json:",omitempty"`
+}
+
+type WdPoStMessageState string
+
+const (
+	WdPoStMsgPending WdPoStMessageState = "pending"
+	WdPoStMsgLanded  WdPoStMessageState = "landed"
+	// the deadline closed before the message landed
+	WdPoStMsgExpired WdPoStMessageState = "expired"
+)
+
+// WinningPoStRecord is an election won by the miner, and the production of its
+// block
+type WinningPoStRecord struct {
+	Epoch      abi.ChainEpoch
+	Base       types.TipSetKey
+	NullRounds abi.ChainEpoch
+	// Block is the mined block, cid.Undef when it couldn't be created
+	Block cid.Cid
+
+	// Started is the time the mining of the epoch started, Late is true when
+	// it started after the propagation delay of the base
+	Started time.Time
+	Late    bool
+
+	// BaseInfo is the time taken to get the mining base info, including the
+	// beacon entries the randomness is drawn from
+	BaseInfo time.Duration
+	// Election is the time taken to compute the ticket and check the election
+	Election time.Duration
+	// Randomness is the time taken to draw the challenge randomness
+	Randomness time.Duration
+	Proof      time.Duration
+	// Messages is the time taken to select the messages of the block, and
+	// Assembly the time taken to create and sign it
+	Messages time.Duration
+	Assembly time.Duration
+	// Submission is the time from the creation of the block to its submission,
+	// including the wait for its timestamp
+	Submission time.Duration
+
+	State WinningBlockState
+	Error string `json:",omitempty"`
+}
+
+type WinningBlockState string
+
+const (
+	// the block couldn't be created or submitted
+	WinningBlockFailed WinningBlockState = "failed"
+	// the chain didn't reach the epoch of the block yet
+	WinningBlockPending  WinningBlockState = "pending"
+	WinningBlockIncluded WinningBlockState = "included"
+	// the tipset of the epoch in the chain doesn't include the block
+	WinningBlockOrphaned WinningBlockState = "orphaned"
+)
+
+// DisputablePoSt is a WindowPoSt which was checked for a dispute
+type DisputablePoSt struct {
+	Miner     address.Address
+	Deadline  uint64
+	PoStIndex uint64
+	// Close epoch of the deadline the WindowPoSt was submitted in, and last
+	// epoch it can be disputed at
+	Close      abi.ChainEpoch
+	DisputeEnd abi.ChainEpoch
+
+	// Valid is false when the simulated dispute succeeds
+	Valid bool
+	// Evidence are the sectors of the deadline known to be faulty
+	Evidence []abi.SectorNumber `json:",omitempty"`
+	// Dispute is the DisputeWindowedPoSt message sent for the WindowPoSt, if any
+	Dispute *cid.Cid `json:",omitempty"`
+	Error   string   `json:",omitempty"`
+}
+
 type SealRes struct {
 	Err   string
 	GoErr error `json:"-"`
@@ -328,6 +594,8 @@ type SectorInfo struct {
 	Retries              uint64
 	ToUpgrade            bool
 	ReplicaUpdateMessage *cid.Cid
+	TerminateMessage     *cid.Cid
+	TerminatedAt         abi.ChainEpoch
 
 	LastErr string
 
@@ -347,6 +615,40 @@ type SectorInfo struct {
 	Early abi.ChainEpoch
 }
 
+// SectorTerminateEstimate is the estimated cost of terminating a single sector
+type SectorTerminateEstimate struct {
+	Number     abi.SectorNumber
+	Deadline   uint64
+	Partition  uint64
+	Activation abi.ChainEpoch
+	Expiration abi.ChainEpoch
+	// Age of the sector at the termination epoch
+	Age     abi.ChainEpoch
+	QAPower abi.StoragePower
+	// Pledge released by the termination
+	Pledge  abi.TokenAmount
+	Penalty abi.TokenAmount
+	// set if the sector expires before the termination epoch, or isn't live
+	Expired bool
+	Faulty  bool
+}
+
+type TerminateEstimate struct {
+	// Epoch the penalties are computed at
+	Epoch abi.ChainEpoch
+	// Chain head the estimate was based on
+	Head abi.ChainEpoch
+
+	Sectors []SectorTerminateEstimate
+
+	Penalty          abi.TokenAmount
+	Pledge           abi.TokenAmount
+	QAPower          abi.StoragePower
+	AvailableBalance abi.TokenAmount
+	// Number of terminate messages needed to terminate all sectors
+	Messages int
+}
+
 type SealedRef struct {
 	SectorID abi.SectorNumber
 	Offset   abi.PaddedPieceSize
@@ -404,6 +706,177 @@ type PendingDealInfo struct {
 	Deals              []market.ClientDealProposal
 	PublishPeriodStart time.Time
 	PublishPeriod      time.Duration
+	MaxDealsPerMsg     uint64
+}
+
+// DealPublishConfig is the batching configuration of PublishStorageDeals
+// messages
+type DealPublishConfig struct {
+	// The amount of time to wait for more deals to arrive before publishing
+	Period time.Duration
+	// The maximum number of deals to include in a single message
+	MaxDealsPerMsg uint64
+}
+
+// DataTransferLimits caps the data sent by graphsync, e.g. to serve
+// retrievals. Zero values mean no limit.
+type DataTransferLimits struct {
+	// Bytes per second sent to all peers combined
+	MaxBandwidth int64
+	// Bytes per second sent to a single peer
+	MaxBandwidthPerPeer int64
+	// Transfers sending data at the same time, others wait for a free slot
+	MaxConcurrentTransfers        int
+	MaxConcurrentTransfersPerPeer int
+	// Schedule replaces the bandwidth caps during times of the day, the first
+	// matching window applies
+	Schedule []BandwidthWindow
+}
+
+// BandwidthWindow sets the bandwidth caps from Start until End, in the node's
+// local time formatted as 15:04. A window ending before its start spans
+// midnight.
+type BandwidthWindow struct {
+	Start               string
+	End                 string
+	MaxBandwidth        int64
+	MaxBandwidthPerPeer int64
+}
+
+// DataTransferLimitsStatus is the state of the data transfer limits
+type DataTransferLimitsStatus struct {
+	Limits DataTransferLimits
+	// The bandwidth caps in effect now, taking the schedule into account
+	MaxBandwidth        int64
+	MaxBandwidthPerPeer int64
+	// Active transfers, and transfers waiting for a free slot
+	Active int
+	Queued int
+	Peers  []PeerTransferStatus
+}
+
+// PeerTransferStatus describes the transfers to a peer
+type PeerTransferStatus struct {
+	Peer   peer.ID
+	Active int
+	Queued int
+	// Bytes sent by the current transfers
+	Sent uint64
+}
+
+// DealDataURL locates the data of an offline deal served over http(s)
+type DealDataURL struct {
+	URL string
+	// Headers are added to the requests, e.g. for authentication
+	Headers http.Header
+	// SHA256 is the optional hex encoded sha256 digest of the data, verified
+	// before the import completes
+	SHA256 string
+}
+
+// SnapSectorInfo is a sector in the SnapDeals pipeline
+type SnapSectorInfo struct {
+	SectorID abi.SectorNumber
+	State    SectorState
+	Deals    []abi.DealID
+	// Updated is when the sector last changed state
+	Updated time.Time
+	LastErr string `json:",omitempty"`
+}
+
+// DealPackingStatus describes how deals are being packed into sectors
+type DealPackingStatus struct {
+	SectorSize abi.SectorSize
+	// Limits
+	MaxDealsPerSector           int
+	TargetSectorFillPercent     uint64
+	MaxConcurrentDealsPerClient uint64
+	WaitDealsDelay              time.Duration
+
+	OpenSectors []PackingSector
+	Clients     []ClientPackingStatus
+	// WaitingDeals is the number of deals not assigned to a sector yet
+	WaitingDeals int
+}
+
+// PackingSector is a sector open for deals
+type PackingSector struct {
+	Sector   abi.SectorNumber
+	CCUpdate bool
+	// Used is the space taken by the deals in the sector, including the ones being added
+	Used  abi.PaddedPieceSize
+	Deals int
+}
+
+// ClientPackingStatus counts the deals of a client waiting to be packed into sectors
+type ClientPackingStatus struct {
+	Client address.Address
+	// Adding is the number of deals being added to sectors
+	Adding int
+	// Waiting is the number of deals not assigned to a sector yet
+	Waiting int
+}
+
+// PendingFilterDecision is a storage deal queued by the deal filter webhook,
+// waiting for a decision
+type PendingFilterDecision struct {
+	ProposalCid  cid.Cid
+	Client       address.Address
+	PieceCID     cid.Cid
+	PieceSize    abi.PaddedPieceSize
+	VerifiedDeal bool
+	StartEpoch   abi.ChainEpoch
+	EndEpoch     abi.ChainEpoch
+	// Reason is the reason given by the webhook for queueing the deal
+	Reason   string
+	QueuedAt time.Time
+	// Deadline is when the deal gets rejected if no decision was made
+	Deadline time.Time
+}
+
+// MarketDealEventType is the lifecycle transition of a MarketDealEvent
+type MarketDealEventType string
+
+const (
+	// MarketDealEventProposalReceived is sent when a deal proposal is received
+	MarketDealEventProposalReceived MarketDealEventType = "proposal-received"
+	// MarketDealEventAccepted is sent when a deal is accepted by the deal filters
+	MarketDealEventAccepted MarketDealEventType = "accepted"
+	// MarketDealEventPublished is sent when the deal publish message lands on chain
+	MarketDealEventPublished MarketDealEventType = "published"
+	// MarketDealEventSealed is sent when the sector containing the deal is committed
+	MarketDealEventSealed MarketDealEventType = "sealed"
+	// MarketDealEventActive is sent when the deal is active and all its processing is done
+	MarketDealEventActive MarketDealEventType = "active"
+	// MarketDealEventSlashed is sent when the deal is slashed
+	MarketDealEventSlashed MarketDealEventType = "slashed"
+	// MarketDealEventExpired is sent when the deal expires
+	MarketDealEventExpired MarketDealEventType = "expired"
+	// MarketDealEventFailed is sent when the deal fails, with the failure in Error
+	MarketDealEventFailed MarketDealEventType = "failed"
+)
+
+// MarketDealEvent is a lifecycle transition of a storage deal
+type MarketDealEvent struct {
+	Type        MarketDealEventType
+	ProposalCid cid.Cid
+	Client      address.Address
+	PieceCID    cid.Cid
+	DealID      abi.DealID
+	State       storagemarket.StorageDealStatus
+	Time        time.Time
+	// Error is the reason of the failure for failed deals
+	Error string
+}
+
+// MinerEvent is a journal event recorded by a miner process
+type MinerEvent struct {
+	System    string
+	Event     string
+	Timestamp time.Time
+	Data      json.RawMessage
+	// Subsystems are the subsystems of the process which recorded the event
+	Subsystems MinerSubsystems
 }
 
 type SectorOffset struct {
@@ -12,6 +12,10 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/build"
 )
 
 func goCmd() string {
@@ -124,3 +128,19 @@ func TestPermTags(t *testing.T) {
 	_ = PermissionedStorMinerAPI(&StorageMinerStruct{})
 	_ = PermissionedWorkerAPI(&WorkerStruct{})
 }
+
+func TestMessageSendSpecWaitParams(t *testing.T) {
+	var spec *MessageSendSpec
+	confidence, limit := spec.WaitParams()
+	require.Equal(t, build.MessageConfidence, confidence)
+	require.Equal(t, LookbackNoLimit, limit)
+
+	spec = &MessageSendSpec{MsgConfidence: 2}
+	confidence, limit = spec.WaitParams()
+	require.Equal(t, uint64(2), confidence)
+	require.Equal(t, LookbackNoLimit, limit)
+
+	spec.MsgLookbackLimit = 50
+	_, limit = spec.WaitParams()
+	require.Equal(t, abi.ChainEpoch(50), limit)
+}
@@ -0,0 +1,308 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/v1api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var log = logging.Logger("apiclient")
+
+// the head change types of chain/store, which imports this package through the
+// wallet
+const (
+	hcRevert  = "revert"
+	hcCurrent = "current"
+)
+
+// ConnState is a change of the connection of a resumed subscription
+type ConnState int
+
+const (
+	// ConnLost means that the channel of the subscription was closed while its
+	// context wasn't done, which happens when the connection to the node is lost
+	ConnLost ConnState = iota
+	// ConnRetry means that an attempt to subscribe again failed
+	ConnRetry
+	// ConnResumed means that the subscription was made again; the updates
+	// missed while disconnected are sent before the new ones
+	ConnResumed
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case ConnLost:
+		return "lost"
+	case ConnRetry:
+		return "retry"
+	case ConnResumed:
+		return "resumed"
+	default:
+		return "unknown"
+	}
+}
+
+// Backoff returns the delay before an attempt to subscribe again, starting at
+// attempt 0
+type Backoff func(attempt int) time.Duration
+
+// ExponentialBackoff returns a Backoff doubling the delay from min up to max
+func ExponentialBackoff(min, max time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		d := min
+		for i := 0; i < attempt && d < max; i++ {
+			d *= 2
+		}
+		if d > max {
+			d = max
+		}
+		return d
+	}
+}
+
+type ResumeConfig struct {
+	// Backoff of the attempts to subscribe again, ExponentialBackoff(100ms, 30s)
+	// when nil
+	Backoff Backoff
+
+	// OnStateChange, when set, is called with the connection changes of the
+	// subscriptions; err is set with ConnRetry
+	OnStateChange func(method string, state ConnState, err error)
+}
+
+type resumingFullNode struct {
+	v1api.FullNode
+
+	cfg ResumeConfig
+}
+
+// ResumeSubscriptions wraps a full node API client so that the channels of
+// ChainNotify and MpoolSub survive reconnections: when the connection is lost,
+// the subscription is made again, and the changes missed while disconnected are
+// sent on the channel before the new ones. The channels are closed when the
+// context of the subscription is done.
+//
+// ChainNotify sends the revert and apply changes from the last tipset sent to
+// the new head instead of the current head notification. MpoolSub sends the
+// messages added to and removed from the pool, compared to the pending messages
+// when the subscription started.
+func ResumeSubscriptions(n v1api.FullNode, cfg ResumeConfig) v1api.FullNode {
+	if cfg.Backoff == nil {
+		cfg.Backoff = ExponentialBackoff(100*time.Millisecond, 30*time.Second)
+	}
+	return &resumingFullNode{FullNode: n, cfg: cfg}
+}
+
+func (n *resumingFullNode) notify(method string, state ConnState, err error) {
+	log.Debugw("subscription connection", "method", method, "state", state, "error", err)
+	if n.cfg.OnStateChange != nil {
+		n.cfg.OnStateChange(method, state, err)
+	}
+}
+
+// resubscribe calls subscribe, with the backoff, until it succeeds; it returns
+// false when the context is done first
+func (n *resumingFullNode) resubscribe(ctx context.Context, method string, subscribe func() error) bool {
+	n.notify(method, ConnLost, nil)
+
+	for attempt := 0; ; attempt++ {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(n.cfg.Backoff(attempt)):
+		}
+
+		err := subscribe()
+		if err == nil {
+			n.notify(method, ConnResumed, nil)
+			return true
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+		n.notify(method, ConnRetry, err)
+	}
+}
+
+func (n *resumingFullNode) ChainNotify(ctx context.Context) (<-chan []*api.HeadChange, error) {
+	sub, err := n.FullNode.ChainNotify(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan []*api.HeadChange)
+	go func() {
+		defer close(out)
+
+		var last types.TipSetKey
+		resumed := false
+		for {
+			for changes := range sub {
+				if resumed {
+					resumed = false
+					changes = n.chainPath(ctx, last, changes)
+					if len(changes) == 0 {
+						continue
+					}
+				}
+
+				for _, c := range changes {
+					if c.Type == hcRevert {
+						last = c.Val.Parents()
+					} else {
+						last = c.Val.Key()
+					}
+				}
+
+				select {
+				case out <- changes:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			if !n.resubscribe(ctx, "ChainNotify", func() (err error) {
+				sub, err = n.FullNode.ChainNotify(ctx)
+				return err
+			}) {
+				return
+			}
+			resumed = true
+		}
+	}()
+
+	return out, nil
+}
+
+// chainPath replaces the current head notification of a new subscription with
+// the changes from the last tipset sent. The notification is kept when the
+// path can't be found.
+func (n *resumingFullNode) chainPath(ctx context.Context, last types.TipSetKey, changes []*api.HeadChange) []*api.HeadChange {
+	if len(changes) != 1 || changes[0].Type != hcCurrent || last == types.EmptyTSK {
+		return changes
+	}
+
+	path, err := n.FullNode.ChainGetPath(ctx, last, changes[0].Val.Key())
+	if err != nil {
+		log.Warnw("getting the chain changes missed while disconnected, sending the current head", "from", last, "to", changes[0].Val.Key(), "error", err)
+		return changes
+	}
+	return path
+}
+
+func (n *resumingFullNode) MpoolSub(ctx context.Context) (<-chan api.MpoolUpdate, error) {
+	sub, err := n.FullNode.MpoolSub(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// the pending messages, as sent on the channel, to find the changes missed
+	// while disconnected
+	pending := map[cid.Cid]*types.SignedMessage{}
+	msgs, err := n.FullNode.MpoolPending(ctx, types.EmptyTSK)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range msgs {
+		pending[m.Cid()] = m
+	}
+
+	out := make(chan api.MpoolUpdate)
+	go func() {
+		defer close(out)
+
+		cancelSub := func() {}
+		defer func() { cancelSub() }()
+
+		send := func(u api.MpoolUpdate) bool {
+			switch u.Type {
+			case api.MpoolAdd:
+				pending[u.Message.Cid()] = u.Message
+			case api.MpoolRemove:
+				delete(pending, u.Message.Cid())
+			}
+
+			select {
+			case out <- u:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			for u := range sub {
+				if !send(u) {
+					return
+				}
+			}
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			var missed []api.MpoolUpdate
+			if !n.resubscribe(ctx, "MpoolSub", func() error {
+				sctx, cancel := context.WithCancel(ctx)
+				s, err := n.FullNode.MpoolSub(sctx)
+				if err != nil {
+					cancel()
+					return err
+				}
+				msgs, err := n.FullNode.MpoolPending(ctx, types.EmptyTSK)
+				if err != nil {
+					cancel()
+					return err
+				}
+
+				cancelSub()
+				cancelSub = cancel
+				sub, missed = s, mpoolChanges(pending, msgs)
+				return nil
+			}) {
+				return
+			}
+
+			for _, u := range missed {
+				if !send(u) {
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// mpoolChanges returns the updates from the pending messages sent to the current
+// pending messages
+func mpoolChanges(sent map[cid.Cid]*types.SignedMessage, cur []*types.SignedMessage) []api.MpoolUpdate {
+	var out []api.MpoolUpdate
+
+	curSet := map[cid.Cid]struct{}{}
+	for _, m := range cur {
+		c := m.Cid()
+		curSet[c] = struct{}{}
+		if _, ok := sent[c]; !ok {
+			out = append(out, api.MpoolUpdate{Type: api.MpoolAdd, Message: m})
+		}
+	}
+
+	for c, m := range sent {
+		if _, ok := curSet[c]; !ok {
+			out = append(out, api.MpoolUpdate{Type: api.MpoolRemove, Message: m})
+		}
+	}
+
+	return out
+}
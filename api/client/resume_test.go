@@ -0,0 +1,184 @@
+//stm: #unit
+package client_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/client"
+	"github.com/filecoin-project/lotus/api/v1api"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+type subNode struct {
+	v1api.FullNode
+
+	failures  int
+	chainSubs chan chan []*api.HeadChange
+	head      *types.TipSet
+	paths     map[[2]types.TipSetKey][]*api.HeadChange
+
+	mpoolSubs chan chan api.MpoolUpdate
+	pending   []*types.SignedMessage
+}
+
+func (n *subNode) ChainNotify(ctx context.Context) (<-chan []*api.HeadChange, error) {
+	if n.failures > 0 {
+		n.failures--
+		return nil, xerrors.New("websocket connection closed")
+	}
+
+	ch := make(chan []*api.HeadChange, 4)
+	ch <- []*api.HeadChange{{Type: store.HCCurrent, Val: n.head}}
+	n.chainSubs <- ch
+	return ch, nil
+}
+
+func (n *subNode) ChainGetPath(ctx context.Context, from, to types.TipSetKey) ([]*api.HeadChange, error) {
+	path, ok := n.paths[[2]types.TipSetKey{from, to}]
+	if !ok {
+		return nil, xerrors.New("no path")
+	}
+	return path, nil
+}
+
+func (n *subNode) MpoolSub(ctx context.Context) (<-chan api.MpoolUpdate, error) {
+	ch := make(chan api.MpoolUpdate, 4)
+	n.mpoolSubs <- ch
+	return ch, nil
+}
+
+func (n *subNode) MpoolPending(ctx context.Context, tsk types.TipSetKey) ([]*types.SignedMessage, error) {
+	return n.pending, nil
+}
+
+func signedMessage(nonce uint64) *types.SignedMessage {
+	return &types.SignedMessage{Message: *mock.UnsignedMessage(mock.Address(100), mock.Address(200), nonce)}
+}
+
+func TestResumeChainNotify(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ts0 := mock.TipSet(mock.MkBlock(nil, 1, 0))
+	ts1 := mock.TipSet(mock.MkBlock(ts0, 1, 1))
+	ts2 := mock.TipSet(mock.MkBlock(ts1, 1, 2))
+	alt1 := mock.TipSet(mock.MkBlock(ts0, 2, 3))
+
+	n := &subNode{
+		chainSubs: make(chan chan []*api.HeadChange, 1),
+		head:      ts1,
+		paths: map[[2]types.TipSetKey][]*api.HeadChange{
+			{ts2.Key(), alt1.Key()}: {
+				{Type: store.HCRevert, Val: ts2},
+				{Type: store.HCRevert, Val: ts1},
+				{Type: store.HCApply, Val: alt1},
+			},
+		},
+	}
+
+	var states []client.ConnState
+	rn := client.ResumeSubscriptions(n, client.ResumeConfig{
+		Backoff: func(int) time.Duration { return time.Millisecond },
+		OnStateChange: func(method string, state client.ConnState, err error) {
+			require.Equal(t, "ChainNotify", method)
+			states = append(states, state)
+		},
+	})
+
+	out, err := rn.ChainNotify(ctx)
+	require.NoError(t, err)
+	sub := <-n.chainSubs
+
+	changes := <-out
+	require.Equal(t, store.HCCurrent, changes[0].Type)
+	require.Equal(t, ts1, changes[0].Val)
+
+	sub <- []*api.HeadChange{{Type: store.HCApply, Val: ts2}}
+	changes = <-out
+	require.Equal(t, ts2, changes[0].Val)
+
+	// the connection is lost, and the node switched to another fork meanwhile
+	n.head = alt1
+	n.failures = 2
+	close(sub)
+	sub = <-n.chainSubs
+
+	changes = <-out
+	require.Len(t, changes, 3)
+	require.Equal(t, store.HCRevert, changes[0].Type)
+	require.Equal(t, ts2, changes[0].Val)
+	require.Equal(t, store.HCApply, changes[2].Type)
+	require.Equal(t, alt1, changes[2].Val)
+	require.Equal(t, []client.ConnState{client.ConnLost, client.ConnRetry, client.ConnRetry, client.ConnResumed}, states)
+
+	// the new subscription is forwarded
+	ts2b := mock.TipSet(mock.MkBlock(alt1, 1, 4))
+	sub <- []*api.HeadChange{{Type: store.HCApply, Val: ts2b}}
+	changes = <-out
+	require.Equal(t, ts2b, changes[0].Val)
+
+	// the channel is closed with the context
+	cancel()
+	close(sub)
+	for range out {
+	}
+}
+
+func TestResumeMpoolSub(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m0, m1, m2 := signedMessage(0), signedMessage(1), signedMessage(2)
+
+	n := &subNode{
+		mpoolSubs: make(chan chan api.MpoolUpdate, 1),
+		pending:   []*types.SignedMessage{m0},
+	}
+
+	rn := client.ResumeSubscriptions(n, client.ResumeConfig{
+		Backoff: func(int) time.Duration { return time.Millisecond },
+	})
+
+	out, err := rn.MpoolSub(ctx)
+	require.NoError(t, err)
+	sub := <-n.mpoolSubs
+
+	sub <- api.MpoolUpdate{Type: api.MpoolAdd, Message: m1}
+	u := <-out
+	require.Equal(t, api.MpoolAdd, u.Type)
+	require.Equal(t, m1, u.Message)
+
+	// while disconnected, m0 was included in a block and m2 was added
+	n.pending = []*types.SignedMessage{m1, m2}
+	close(sub)
+	sub = <-n.mpoolSubs
+
+	u = <-out
+	require.Equal(t, api.MpoolAdd, u.Type)
+	require.Equal(t, m2, u.Message)
+	u = <-out
+	require.Equal(t, api.MpoolRemove, u.Type)
+	require.Equal(t, m0, u.Message)
+
+	sub <- api.MpoolUpdate{Type: api.MpoolRemove, Message: m1}
+	u = <-out
+	require.Equal(t, api.MpoolRemove, u.Type)
+	require.Equal(t, m1, u.Message)
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := client.ExponentialBackoff(time.Second, 5*time.Second)
+	require.Equal(t, time.Second, b(0))
+	require.Equal(t, 2*time.Second, b(1))
+	require.Equal(t, 4*time.Second, b(2))
+	require.Equal(t, 5*time.Second, b(3))
+	require.Equal(t, 5*time.Second, b(100))
+}
@@ -6,6 +6,7 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"net/http"
 	"path/filepath"
 	"reflect"
 	"strings"
@@ -42,6 +43,7 @@ import (
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/repo/imports"
 	sealing "github.com/filecoin-project/lotus/storage/pipeline"
+	"github.com/filecoin-project/lotus/storage/pipeline/sealiface"
 	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
 )
@@ -138,6 +140,11 @@ func init() {
 	addExample(network.ReachabilityPublic)
 	addExample(build.NewestNetworkVersion)
 	addExample(map[string]int{"name": 42})
+	addExample(api.MarketDealEventPublished)
+	addExample(api.HealthOK)
+	addExample(api.WdPoStMsgLanded)
+	addExample(api.WinningBlockIncluded)
+	addExample(http.Header{"Authorization": []string{"Bearer ey.."}})
 	addExample(map[string]time.Time{"name": time.Unix(1615243938, 0).UTC()})
 	addExample(&types.ExecutionTrace{
 		Msg:    ExampleValue("init", reflect.TypeOf(&types.Message{}), nil).(*types.Message),
@@ -180,6 +187,19 @@ func init() {
 			TotalOut: 12500,
 		},
 	})
+	addExample(map[string]api.ProtocolGroupBandwidth{
+		"bitswap": {
+			Stats: metrics.Stats{
+				RateIn:   100,
+				RateOut:  50,
+				TotalIn:  174000,
+				TotalOut: 12500,
+			},
+			Ceiling:   1 << 20,
+			Throttled: true,
+			Delay:     time.Second,
+		},
+	})
 
 	maddr, err := multiaddr.NewMultiaddr("/ip4/52.36.61.156/tcp/1347/p2p/12D3KooWFETiESTf1v4PGUvtnxMAcEFMzLZbJGg4tjWfGEimYior")
 	if err != nil {
@@ -280,6 +300,8 @@ func init() {
 		sealtasks.TTPreCommit2: {},
 	})
 	addExample(sealtasks.TTCommit2)
+	addExample(sealiface.BatchAuto)
+	addExample(storiface.UnsealRetrieval)
 	addExample(apitypes.OpenRPCDocument{
 		"openrpc": "1.2.6",
 		"info": map[string]interface{}{
@@ -22,6 +22,8 @@ const (
 	SubsystemSealing
 	// SubsystemSectorStorage signifies the sector storage subsystem.
 	SubsystemSectorStorage
+	// SubsystemProving signifies the WindowPoSt subsystem.
+	SubsystemProving
 )
 
 var MinerSubsystemToString = map[MinerSubsystem]string{
@@ -30,6 +32,7 @@ var MinerSubsystemToString = map[MinerSubsystem]string{
 	SubsystemMining:        "Mining",
 	SubsystemSealing:       "Sealing",
 	SubsystemSectorStorage: "SectorStorage",
+	SubsystemProving:       "Proving",
 }
 
 var MinerSubsystemToID = map[string]MinerSubsystem{
@@ -38,6 +41,7 @@ var MinerSubsystemToID = map[string]MinerSubsystem{
 	"Mining":        SubsystemMining,
 	"Sealing":       SubsystemSealing,
 	"SectorStorage": SubsystemSectorStorage,
+	"Proving":       SubsystemProving,
 }
 
 func (ms MinerSubsystem) MarshalJSON() ([]byte, error) {
@@ -79,6 +79,50 @@ func (mr *MockFullNodeMockRecorder) AuthNew(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthNew", reflect.TypeOf((*MockFullNode)(nil).AuthNew), arg0, arg1)
 }
 
+// AuthTokenCreate mocks base method.
+func (m *MockFullNode) AuthTokenCreate(arg0 context.Context, arg1 auth.Permission, arg2 string, arg3 time.Duration) (api.NewAPIToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthTokenCreate", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(api.NewAPIToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthTokenCreate indicates an expected call of AuthTokenCreate.
+func (mr *MockFullNodeMockRecorder) AuthTokenCreate(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthTokenCreate", reflect.TypeOf((*MockFullNode)(nil).AuthTokenCreate), arg0, arg1, arg2, arg3)
+}
+
+// AuthTokenList mocks base method.
+func (m *MockFullNode) AuthTokenList(arg0 context.Context) ([]api.APIToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthTokenList", arg0)
+	ret0, _ := ret[0].([]api.APIToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthTokenList indicates an expected call of AuthTokenList.
+func (mr *MockFullNodeMockRecorder) AuthTokenList(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthTokenList", reflect.TypeOf((*MockFullNode)(nil).AuthTokenList), arg0)
+}
+
+// AuthTokenRevoke mocks base method.
+func (m *MockFullNode) AuthTokenRevoke(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthTokenRevoke", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthTokenRevoke indicates an expected call of AuthTokenRevoke.
+func (mr *MockFullNodeMockRecorder) AuthTokenRevoke(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthTokenRevoke", reflect.TypeOf((*MockFullNode)(nil).AuthTokenRevoke), arg0, arg1)
+}
+
 // AuthVerify mocks base method.
 func (m *MockFullNode) AuthVerify(arg0 context.Context, arg1 string) ([]auth.Permission, error) {
 	m.ctrl.T.Helper()
@@ -94,6 +138,79 @@ func (mr *MockFullNodeMockRecorder) AuthVerify(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthVerify", reflect.TypeOf((*MockFullNode)(nil).AuthVerify), arg0, arg1)
 }
 
+// ChainBackfill mocks base method.
+func (m *MockFullNode) ChainBackfill(arg0 context.Context, arg1 api.BackfillSpec) (<-chan api.BackfillProgress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainBackfill", arg0, arg1)
+	ret0, _ := ret[0].(<-chan api.BackfillProgress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainBackfill indicates an expected call of ChainBackfill.
+func (mr *MockFullNodeMockRecorder) ChainBackfill(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainBackfill", reflect.TypeOf((*MockFullNode)(nil).ChainBackfill), arg0, arg1)
+}
+
+// ChainBlockPropagation mocks base method.
+func (m *MockFullNode) ChainBlockPropagation(arg0 context.Context, arg1 abi.ChainEpoch) ([]api.BlockPropagation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainBlockPropagation", arg0, arg1)
+	ret0, _ := ret[0].([]api.BlockPropagation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainBlockPropagation indicates an expected call of ChainBlockPropagation.
+func (mr *MockFullNodeMockRecorder) ChainBlockPropagation(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainBlockPropagation", reflect.TypeOf((*MockFullNode)(nil).ChainBlockPropagation), arg0, arg1)
+}
+
+// ChainBlockstoreCacheFlush mocks base method.
+func (m *MockFullNode) ChainBlockstoreCacheFlush(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainBlockstoreCacheFlush", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChainBlockstoreCacheFlush indicates an expected call of ChainBlockstoreCacheFlush.
+func (mr *MockFullNodeMockRecorder) ChainBlockstoreCacheFlush(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainBlockstoreCacheFlush", reflect.TypeOf((*MockFullNode)(nil).ChainBlockstoreCacheFlush), arg0)
+}
+
+// ChainBlockstoreGC mocks base method.
+func (m *MockFullNode) ChainBlockstoreGC(arg0 context.Context, arg1 api.BlockstoreGCOpts) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainBlockstoreGC", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChainBlockstoreGC indicates an expected call of ChainBlockstoreGC.
+func (mr *MockFullNodeMockRecorder) ChainBlockstoreGC(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainBlockstoreGC", reflect.TypeOf((*MockFullNode)(nil).ChainBlockstoreGC), arg0, arg1)
+}
+
+// ChainBlockstoreGCStatus mocks base method.
+func (m *MockFullNode) ChainBlockstoreGCStatus(arg0 context.Context) (api.BlockstoreGCStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainBlockstoreGCStatus", arg0)
+	ret0, _ := ret[0].(api.BlockstoreGCStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainBlockstoreGCStatus indicates an expected call of ChainBlockstoreGCStatus.
+func (mr *MockFullNodeMockRecorder) ChainBlockstoreGCStatus(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainBlockstoreGCStatus", reflect.TypeOf((*MockFullNode)(nil).ChainBlockstoreGCStatus), arg0)
+}
+
 // ChainBlockstoreInfo mocks base method.
 func (m *MockFullNode) ChainBlockstoreInfo(arg0 context.Context) (map[string]interface{}, error) {
 	m.ctrl.T.Helper()
@@ -109,6 +226,94 @@ func (mr *MockFullNodeMockRecorder) ChainBlockstoreInfo(arg0 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainBlockstoreInfo", reflect.TypeOf((*MockFullNode)(nil).ChainBlockstoreInfo), arg0)
 }
 
+// ChainBlockstoreMount mocks base method.
+func (m *MockFullNode) ChainBlockstoreMount(arg0 context.Context, arg1, arg2 string) (api.BlockstoreMount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainBlockstoreMount", arg0, arg1, arg2)
+	ret0, _ := ret[0].(api.BlockstoreMount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainBlockstoreMount indicates an expected call of ChainBlockstoreMount.
+func (mr *MockFullNodeMockRecorder) ChainBlockstoreMount(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainBlockstoreMount", reflect.TypeOf((*MockFullNode)(nil).ChainBlockstoreMount), arg0, arg1, arg2)
+}
+
+// ChainBlockstoreMounts mocks base method.
+func (m *MockFullNode) ChainBlockstoreMounts(arg0 context.Context) ([]api.BlockstoreMount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainBlockstoreMounts", arg0)
+	ret0, _ := ret[0].([]api.BlockstoreMount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainBlockstoreMounts indicates an expected call of ChainBlockstoreMounts.
+func (mr *MockFullNodeMockRecorder) ChainBlockstoreMounts(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainBlockstoreMounts", reflect.TypeOf((*MockFullNode)(nil).ChainBlockstoreMounts), arg0)
+}
+
+// ChainBlockstoreQuarantine mocks base method.
+func (m *MockFullNode) ChainBlockstoreQuarantine(arg0 context.Context) ([]cid.Cid, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainBlockstoreQuarantine", arg0)
+	ret0, _ := ret[0].([]cid.Cid)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainBlockstoreQuarantine indicates an expected call of ChainBlockstoreQuarantine.
+func (mr *MockFullNodeMockRecorder) ChainBlockstoreQuarantine(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainBlockstoreQuarantine", reflect.TypeOf((*MockFullNode)(nil).ChainBlockstoreQuarantine), arg0)
+}
+
+// ChainBlockstoreScrub mocks base method.
+func (m *MockFullNode) ChainBlockstoreScrub(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainBlockstoreScrub", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChainBlockstoreScrub indicates an expected call of ChainBlockstoreScrub.
+func (mr *MockFullNodeMockRecorder) ChainBlockstoreScrub(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainBlockstoreScrub", reflect.TypeOf((*MockFullNode)(nil).ChainBlockstoreScrub), arg0)
+}
+
+// ChainBlockstoreScrubStatus mocks base method.
+func (m *MockFullNode) ChainBlockstoreScrubStatus(arg0 context.Context) (api.BlockstoreScrubStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainBlockstoreScrubStatus", arg0)
+	ret0, _ := ret[0].(api.BlockstoreScrubStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainBlockstoreScrubStatus indicates an expected call of ChainBlockstoreScrubStatus.
+func (mr *MockFullNodeMockRecorder) ChainBlockstoreScrubStatus(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainBlockstoreScrubStatus", reflect.TypeOf((*MockFullNode)(nil).ChainBlockstoreScrubStatus), arg0)
+}
+
+// ChainBlockstoreUnmount mocks base method.
+func (m *MockFullNode) ChainBlockstoreUnmount(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainBlockstoreUnmount", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChainBlockstoreUnmount indicates an expected call of ChainBlockstoreUnmount.
+func (mr *MockFullNodeMockRecorder) ChainBlockstoreUnmount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainBlockstoreUnmount", reflect.TypeOf((*MockFullNode)(nil).ChainBlockstoreUnmount), arg0, arg1)
+}
+
 // ChainCheckBlockstore mocks base method.
 func (m *MockFullNode) ChainCheckBlockstore(arg0 context.Context) error {
 	m.ctrl.T.Helper()
@@ -123,6 +328,50 @@ func (mr *MockFullNodeMockRecorder) ChainCheckBlockstore(arg0 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainCheckBlockstore", reflect.TypeOf((*MockFullNode)(nil).ChainCheckBlockstore), arg0)
 }
 
+// ChainConsensusFaultDismiss mocks base method.
+func (m *MockFullNode) ChainConsensusFaultDismiss(arg0 context.Context, arg1 cid.Cid) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainConsensusFaultDismiss", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChainConsensusFaultDismiss indicates an expected call of ChainConsensusFaultDismiss.
+func (mr *MockFullNodeMockRecorder) ChainConsensusFaultDismiss(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainConsensusFaultDismiss", reflect.TypeOf((*MockFullNode)(nil).ChainConsensusFaultDismiss), arg0, arg1)
+}
+
+// ChainConsensusFaultSubmit mocks base method.
+func (m *MockFullNode) ChainConsensusFaultSubmit(arg0 context.Context, arg1 cid.Cid) (cid.Cid, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainConsensusFaultSubmit", arg0, arg1)
+	ret0, _ := ret[0].(cid.Cid)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainConsensusFaultSubmit indicates an expected call of ChainConsensusFaultSubmit.
+func (mr *MockFullNodeMockRecorder) ChainConsensusFaultSubmit(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainConsensusFaultSubmit", reflect.TypeOf((*MockFullNode)(nil).ChainConsensusFaultSubmit), arg0, arg1)
+}
+
+// ChainConsensusFaults mocks base method.
+func (m *MockFullNode) ChainConsensusFaults(arg0 context.Context) ([]api.ConsensusFault, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainConsensusFaults", arg0)
+	ret0, _ := ret[0].([]api.ConsensusFault)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainConsensusFaults indicates an expected call of ChainConsensusFaults.
+func (mr *MockFullNodeMockRecorder) ChainConsensusFaults(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainConsensusFaults", reflect.TypeOf((*MockFullNode)(nil).ChainConsensusFaults), arg0)
+}
+
 // ChainDeleteObj mocks base method.
 func (m *MockFullNode) ChainDeleteObj(arg0 context.Context, arg1 cid.Cid) error {
 	m.ctrl.T.Helper()
@@ -152,6 +401,36 @@ func (mr *MockFullNodeMockRecorder) ChainExport(arg0, arg1, arg2, arg3 interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainExport", reflect.TypeOf((*MockFullNode)(nil).ChainExport), arg0, arg1, arg2, arg3)
 }
 
+// ChainExportLatestState mocks base method.
+func (m *MockFullNode) ChainExportLatestState(arg0 context.Context, arg1 abi.ChainEpoch, arg2 types.TipSetKey) (<-chan []byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainExportLatestState", arg0, arg1, arg2)
+	ret0, _ := ret[0].(<-chan []byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainExportLatestState indicates an expected call of ChainExportLatestState.
+func (mr *MockFullNodeMockRecorder) ChainExportLatestState(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainExportLatestState", reflect.TypeOf((*MockFullNode)(nil).ChainExportLatestState), arg0, arg1, arg2)
+}
+
+// ChainExportRange mocks base method.
+func (m *MockFullNode) ChainExportRange(arg0 context.Context, arg1, arg2 abi.ChainEpoch, arg3 types.TipSetKey, arg4 api.ChainExportRangeOpts) (<-chan []byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainExportRange", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(<-chan []byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainExportRange indicates an expected call of ChainExportRange.
+func (mr *MockFullNodeMockRecorder) ChainExportRange(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainExportRange", reflect.TypeOf((*MockFullNode)(nil).ChainExportRange), arg0, arg1, arg2, arg3, arg4)
+}
+
 // ChainGetBlock mocks base method.
 func (m *MockFullNode) ChainGetBlock(arg0 context.Context, arg1 cid.Cid) (*types.BlockHeader, error) {
 	m.ctrl.T.Helper()
@@ -362,6 +641,21 @@ func (mr *MockFullNodeMockRecorder) ChainHead(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainHead", reflect.TypeOf((*MockFullNode)(nil).ChainHead), arg0)
 }
 
+// ChainIndexActorMessages mocks base method.
+func (m *MockFullNode) ChainIndexActorMessages(arg0 context.Context, arg1 address.Address, arg2, arg3 abi.ChainEpoch, arg4 int) ([]api.IndexedMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainIndexActorMessages", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]api.IndexedMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainIndexActorMessages indicates an expected call of ChainIndexActorMessages.
+func (mr *MockFullNodeMockRecorder) ChainIndexActorMessages(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainIndexActorMessages", reflect.TypeOf((*MockFullNode)(nil).ChainIndexActorMessages), arg0, arg1, arg2, arg3, arg4)
+}
+
 // ChainNotify mocks base method.
 func (m *MockFullNode) ChainNotify(arg0 context.Context) (<-chan []*api.HeadChange, error) {
 	m.ctrl.T.Helper()
@@ -377,6 +671,21 @@ func (mr *MockFullNodeMockRecorder) ChainNotify(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainNotify", reflect.TypeOf((*MockFullNode)(nil).ChainNotify), arg0)
 }
 
+// ChainNotifyDetailed mocks base method.
+func (m *MockFullNode) ChainNotifyDetailed(arg0 context.Context) (<-chan []*api.DetailedHeadChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainNotifyDetailed", arg0)
+	ret0, _ := ret[0].(<-chan []*api.DetailedHeadChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainNotifyDetailed indicates an expected call of ChainNotifyDetailed.
+func (mr *MockFullNodeMockRecorder) ChainNotifyDetailed(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainNotifyDetailed", reflect.TypeOf((*MockFullNode)(nil).ChainNotifyDetailed), arg0)
+}
+
 // ChainPutObj mocks base method.
 func (m *MockFullNode) ChainPutObj(arg0 context.Context, arg1 blocks.Block) error {
 	m.ctrl.T.Helper()
@@ -420,6 +729,21 @@ func (mr *MockFullNodeMockRecorder) ChainSetHead(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainSetHead", reflect.TypeOf((*MockFullNode)(nil).ChainSetHead), arg0, arg1)
 }
 
+// ChainSnapshotStatus mocks base method.
+func (m *MockFullNode) ChainSnapshotStatus(arg0 context.Context) (api.ChainSnapshotStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainSnapshotStatus", arg0)
+	ret0, _ := ret[0].(api.ChainSnapshotStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainSnapshotStatus indicates an expected call of ChainSnapshotStatus.
+func (mr *MockFullNodeMockRecorder) ChainSnapshotStatus(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainSnapshotStatus", reflect.TypeOf((*MockFullNode)(nil).ChainSnapshotStatus), arg0)
+}
+
 // ChainStatObj mocks base method.
 func (m *MockFullNode) ChainStatObj(arg0 context.Context, arg1, arg2 cid.Cid) (api.ObjStat, error) {
 	m.ctrl.T.Helper()
@@ -508,6 +832,21 @@ func (mr *MockFullNodeMockRecorder) ClientDataTransferUpdates(arg0 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientDataTransferUpdates", reflect.TypeOf((*MockFullNode)(nil).ClientDataTransferUpdates), arg0)
 }
 
+// ClientDealHealth mocks base method.
+func (m *MockFullNode) ClientDealHealth(arg0 context.Context) ([]api.DealHealth, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientDealHealth", arg0)
+	ret0, _ := ret[0].([]api.DealHealth)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClientDealHealth indicates an expected call of ClientDealHealth.
+func (mr *MockFullNodeMockRecorder) ClientDealHealth(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientDealHealth", reflect.TypeOf((*MockFullNode)(nil).ClientDealHealth), arg0)
+}
+
 // ClientDealPieceCID mocks base method.
 func (m *MockFullNode) ClientDealPieceCID(arg0 context.Context, arg1 cid.Cid) (api.DataCIDSize, error) {
 	m.ctrl.T.Helper()
@@ -877,6 +1216,21 @@ func (mr *MockFullNodeMockRecorder) Closing(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Closing", reflect.TypeOf((*MockFullNode)(nil).Closing), arg0)
 }
 
+// ConfigReload mocks base method.
+func (m *MockFullNode) ConfigReload(arg0 context.Context) (api.ConfigReloadResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfigReload", arg0)
+	ret0, _ := ret[0].(api.ConfigReloadResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfigReload indicates an expected call of ConfigReload.
+func (mr *MockFullNodeMockRecorder) ConfigReload(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfigReload", reflect.TypeOf((*MockFullNode)(nil).ConfigReload), arg0)
+}
+
 // CreateBackup mocks base method.
 func (m *MockFullNode) CreateBackup(arg0 context.Context, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -1011,6 +1365,20 @@ func (mr *MockFullNodeMockRecorder) LogList(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogList", reflect.TypeOf((*MockFullNode)(nil).LogList), arg0)
 }
 
+// LogPersistLevel mocks base method.
+func (m *MockFullNode) LogPersistLevel(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogPersistLevel", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogPersistLevel indicates an expected call of LogPersistLevel.
+func (mr *MockFullNodeMockRecorder) LogPersistLevel(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogPersistLevel", reflect.TypeOf((*MockFullNode)(nil).LogPersistLevel), arg0, arg1, arg2)
+}
+
 // LogSetLevel mocks base method.
 func (m *MockFullNode) LogSetLevel(arg0 context.Context, arg1, arg2 string) error {
 	m.ctrl.T.Helper()
@@ -1025,6 +1393,21 @@ func (mr *MockFullNodeMockRecorder) LogSetLevel(arg0, arg1, arg2 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogSetLevel", reflect.TypeOf((*MockFullNode)(nil).LogSetLevel), arg0, arg1, arg2)
 }
 
+// LogTail mocks base method.
+func (m *MockFullNode) LogTail(arg0 context.Context, arg1, arg2 string) (<-chan api.LogEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogTail", arg0, arg1, arg2)
+	ret0, _ := ret[0].(<-chan api.LogEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LogTail indicates an expected call of LogTail.
+func (mr *MockFullNodeMockRecorder) LogTail(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogTail", reflect.TypeOf((*MockFullNode)(nil).LogTail), arg0, arg1, arg2)
+}
+
 // MarketAddBalance mocks base method.
 func (m *MockFullNode) MarketAddBalance(arg0 context.Context, arg1, arg2 address.Address, arg3 big.Int) (cid.Cid, error) {
 	m.ctrl.T.Helper()
@@ -1517,6 +1900,21 @@ func (mr *MockFullNodeMockRecorder) MsigGetPending(arg0, arg1, arg2 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MsigGetPending", reflect.TypeOf((*MockFullNode)(nil).MsigGetPending), arg0, arg1, arg2)
 }
 
+// MsigGetReport mocks base method.
+func (m *MockFullNode) MsigGetReport(arg0 context.Context, arg1 address.Address, arg2, arg3 abi.ChainEpoch, arg4 types.TipSetKey) (*api.MsigReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MsigGetReport", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*api.MsigReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MsigGetReport indicates an expected call of MsigGetReport.
+func (mr *MockFullNodeMockRecorder) MsigGetReport(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MsigGetReport", reflect.TypeOf((*MockFullNode)(nil).MsigGetReport), arg0, arg1, arg2, arg3, arg4)
+}
+
 // MsigGetVested mocks base method.
 func (m *MockFullNode) MsigGetVested(arg0 context.Context, arg1 address.Address, arg2, arg3 types.TipSetKey) (big.Int, error) {
 	m.ctrl.T.Helper()
@@ -1652,6 +2050,49 @@ func (mr *MockFullNodeMockRecorder) NetAgentVersion(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetAgentVersion", reflect.TypeOf((*MockFullNode)(nil).NetAgentVersion), arg0, arg1)
 }
 
+// NetAllowAdd mocks base method.
+func (m *MockFullNode) NetAllowAdd(arg0 context.Context, arg1 api.NetBlockList) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetAllowAdd", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NetAllowAdd indicates an expected call of NetAllowAdd.
+func (mr *MockFullNodeMockRecorder) NetAllowAdd(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetAllowAdd", reflect.TypeOf((*MockFullNode)(nil).NetAllowAdd), arg0, arg1)
+}
+
+// NetAllowList mocks base method.
+func (m *MockFullNode) NetAllowList(arg0 context.Context) (api.NetBlockList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetAllowList", arg0)
+	ret0, _ := ret[0].(api.NetBlockList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NetAllowList indicates an expected call of NetAllowList.
+func (mr *MockFullNodeMockRecorder) NetAllowList(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetAllowList", reflect.TypeOf((*MockFullNode)(nil).NetAllowList), arg0)
+}
+
+// NetAllowRemove mocks base method.
+func (m *MockFullNode) NetAllowRemove(arg0 context.Context, arg1 api.NetBlockList) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetAllowRemove", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NetAllowRemove indicates an expected call of NetAllowRemove.
+func (mr *MockFullNodeMockRecorder) NetAllowRemove(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetAllowRemove", reflect.TypeOf((*MockFullNode)(nil).NetAllowRemove), arg0, arg1)
+}
+
 // NetAutoNatStatus mocks base method.
 func (m *MockFullNode) NetAutoNatStatus(arg0 context.Context) (api.NatInfo, error) {
 	m.ctrl.T.Helper()
@@ -1712,6 +2153,21 @@ func (mr *MockFullNodeMockRecorder) NetBandwidthStatsByProtocol(arg0 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetBandwidthStatsByProtocol", reflect.TypeOf((*MockFullNode)(nil).NetBandwidthStatsByProtocol), arg0)
 }
 
+// NetBandwidthStatsByProtocolGroup mocks base method.
+func (m *MockFullNode) NetBandwidthStatsByProtocolGroup(arg0 context.Context) (map[string]api.ProtocolGroupBandwidth, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetBandwidthStatsByProtocolGroup", arg0)
+	ret0, _ := ret[0].(map[string]api.ProtocolGroupBandwidth)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NetBandwidthStatsByProtocolGroup indicates an expected call of NetBandwidthStatsByProtocolGroup.
+func (mr *MockFullNodeMockRecorder) NetBandwidthStatsByProtocolGroup(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetBandwidthStatsByProtocolGroup", reflect.TypeOf((*MockFullNode)(nil).NetBandwidthStatsByProtocolGroup), arg0)
+}
+
 // NetBlockAdd mocks base method.
 func (m *MockFullNode) NetBlockAdd(arg0 context.Context, arg1 api.NetBlockList) error {
 	m.ctrl.T.Helper()
@@ -1843,6 +2299,21 @@ func (mr *MockFullNodeMockRecorder) NetPeerInfo(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetPeerInfo", reflect.TypeOf((*MockFullNode)(nil).NetPeerInfo), arg0, arg1)
 }
 
+// NetPeerInfoDetailed mocks base method.
+func (m *MockFullNode) NetPeerInfoDetailed(arg0 context.Context, arg1 peer.ID) (*api.PeerInfoDetailed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetPeerInfoDetailed", arg0, arg1)
+	ret0, _ := ret[0].(*api.PeerInfoDetailed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NetPeerInfoDetailed indicates an expected call of NetPeerInfoDetailed.
+func (mr *MockFullNodeMockRecorder) NetPeerInfoDetailed(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetPeerInfoDetailed", reflect.TypeOf((*MockFullNode)(nil).NetPeerInfoDetailed), arg0, arg1)
+}
+
 // NetPeers mocks base method.
 func (m *MockFullNode) NetPeers(arg0 context.Context) ([]peer.AddrInfo, error) {
 	m.ctrl.T.Helper()
@@ -1960,6 +2431,21 @@ func (mr *MockFullNodeMockRecorder) NetStat(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetStat", reflect.TypeOf((*MockFullNode)(nil).NetStat), arg0, arg1)
 }
 
+// NodeHealth mocks base method.
+func (m *MockFullNode) NodeHealth(arg0 context.Context) (api.NodeHealth, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NodeHealth", arg0)
+	ret0, _ := ret[0].(api.NodeHealth)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NodeHealth indicates an expected call of NodeHealth.
+func (mr *MockFullNodeMockRecorder) NodeHealth(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NodeHealth", reflect.TypeOf((*MockFullNode)(nil).NodeHealth), arg0)
+}
+
 // NodeStatus mocks base method.
 func (m *MockFullNode) NodeStatus(arg0 context.Context, arg1 bool) (api.NodeStatus, error) {
 	m.ctrl.T.Helper()
@@ -2020,6 +2506,21 @@ func (mr *MockFullNodeMockRecorder) PaychAvailableFundsByFromTo(arg0, arg1, arg2
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PaychAvailableFundsByFromTo", reflect.TypeOf((*MockFullNode)(nil).PaychAvailableFundsByFromTo), arg0, arg1, arg2)
 }
 
+// PaychBudget mocks base method.
+func (m *MockFullNode) PaychBudget(arg0 context.Context) (*api.PaychBudgetStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PaychBudget", arg0)
+	ret0, _ := ret[0].(*api.PaychBudgetStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PaychBudget indicates an expected call of PaychBudget.
+func (mr *MockFullNodeMockRecorder) PaychBudget(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PaychBudget", reflect.TypeOf((*MockFullNode)(nil).PaychBudget), arg0)
+}
+
 // PaychCollect mocks base method.
 func (m *MockFullNode) PaychCollect(arg0 context.Context, arg1 address.Address) (cid.Cid, error) {
 	m.ctrl.T.Helper()
@@ -2035,6 +2536,21 @@ func (mr *MockFullNodeMockRecorder) PaychCollect(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PaychCollect", reflect.TypeOf((*MockFullNode)(nil).PaychCollect), arg0, arg1)
 }
 
+// PaychExport mocks base method.
+func (m *MockFullNode) PaychExport(arg0 context.Context, arg1 address.Address) (*api.PaychBundle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PaychExport", arg0, arg1)
+	ret0, _ := ret[0].(*api.PaychBundle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PaychExport indicates an expected call of PaychExport.
+func (mr *MockFullNodeMockRecorder) PaychExport(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PaychExport", reflect.TypeOf((*MockFullNode)(nil).PaychExport), arg0, arg1)
+}
+
 // PaychFund mocks base method.
 func (m *MockFullNode) PaychFund(arg0 context.Context, arg1, arg2 address.Address, arg3 big.Int) (*api.ChannelInfo, error) {
 	m.ctrl.T.Helper()
@@ -2080,6 +2596,20 @@ func (mr *MockFullNodeMockRecorder) PaychGetWaitReady(arg0, arg1 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PaychGetWaitReady", reflect.TypeOf((*MockFullNode)(nil).PaychGetWaitReady), arg0, arg1)
 }
 
+// PaychImport mocks base method.
+func (m *MockFullNode) PaychImport(arg0 context.Context, arg1 *api.PaychBundle) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PaychImport", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PaychImport indicates an expected call of PaychImport.
+func (mr *MockFullNodeMockRecorder) PaychImport(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PaychImport", reflect.TypeOf((*MockFullNode)(nil).PaychImport), arg0, arg1)
+}
+
 // PaychList mocks base method.
 func (m *MockFullNode) PaychList(arg0 context.Context) ([]address.Address, error) {
 	m.ctrl.T.Helper()
@@ -2110,6 +2640,20 @@ func (mr *MockFullNodeMockRecorder) PaychNewPayment(arg0, arg1, arg2, arg3 inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PaychNewPayment", reflect.TypeOf((*MockFullNode)(nil).PaychNewPayment), arg0, arg1, arg2, arg3)
 }
 
+// PaychSetBudget mocks base method.
+func (m *MockFullNode) PaychSetBudget(arg0 context.Context, arg1 api.PaychBudget) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PaychSetBudget", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PaychSetBudget indicates an expected call of PaychSetBudget.
+func (mr *MockFullNodeMockRecorder) PaychSetBudget(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PaychSetBudget", reflect.TypeOf((*MockFullNode)(nil).PaychSetBudget), arg0, arg1)
+}
+
 // PaychSettle mocks base method.
 func (m *MockFullNode) PaychSettle(arg0 context.Context, arg1 address.Address) (cid.Cid, error) {
 	m.ctrl.T.Helper()
@@ -3082,6 +3626,50 @@ func (mr *MockFullNodeMockRecorder) SyncCheckpoint(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncCheckpoint", reflect.TypeOf((*MockFullNode)(nil).SyncCheckpoint), arg0, arg1)
 }
 
+// SyncCheckpointFeedStatus mocks base method.
+func (m *MockFullNode) SyncCheckpointFeedStatus(arg0 context.Context) (api.CheckpointFeedStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncCheckpointFeedStatus", arg0)
+	ret0, _ := ret[0].(api.CheckpointFeedStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncCheckpointFeedStatus indicates an expected call of SyncCheckpointFeedStatus.
+func (mr *MockFullNodeMockRecorder) SyncCheckpointFeedStatus(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncCheckpointFeedStatus", reflect.TypeOf((*MockFullNode)(nil).SyncCheckpointFeedStatus), arg0)
+}
+
+// SyncCheckpointSigned mocks base method.
+func (m *MockFullNode) SyncCheckpointSigned(arg0 context.Context, arg1 *api.SignedCheckpoint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncCheckpointSigned", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SyncCheckpointSigned indicates an expected call of SyncCheckpointSigned.
+func (mr *MockFullNodeMockRecorder) SyncCheckpointSigned(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncCheckpointSigned", reflect.TypeOf((*MockFullNode)(nil).SyncCheckpointSigned), arg0, arg1)
+}
+
+// SyncForks mocks base method.
+func (m *MockFullNode) SyncForks(arg0 context.Context) (api.ForkStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncForks", arg0)
+	ret0, _ := ret[0].(api.ForkStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncForks indicates an expected call of SyncForks.
+func (mr *MockFullNodeMockRecorder) SyncForks(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncForks", reflect.TypeOf((*MockFullNode)(nil).SyncForks), arg0)
+}
+
 // SyncIncomingBlocks mocks base method.
 func (m *MockFullNode) SyncIncomingBlocks(arg0 context.Context) (<-chan *types.BlockHeader, error) {
 	m.ctrl.T.Helper()
@@ -3140,6 +3728,21 @@ func (mr *MockFullNodeMockRecorder) SyncSubmitBlock(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncSubmitBlock", reflect.TypeOf((*MockFullNode)(nil).SyncSubmitBlock), arg0, arg1)
 }
 
+// SyncSubscribe mocks base method.
+func (m *MockFullNode) SyncSubscribe(arg0 context.Context) (<-chan api.SyncProgress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncSubscribe", arg0)
+	ret0, _ := ret[0].(<-chan api.SyncProgress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncSubscribe indicates an expected call of SyncSubscribe.
+func (mr *MockFullNodeMockRecorder) SyncSubscribe(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncSubscribe", reflect.TypeOf((*MockFullNode)(nil).SyncSubscribe), arg0)
+}
+
 // SyncUnmarkAllBad mocks base method.
 func (m *MockFullNode) SyncUnmarkAllBad(arg0 context.Context) error {
 	m.ctrl.T.Helper()
@@ -3390,3 +3993,46 @@ func (mr *MockFullNodeMockRecorder) WalletVerify(arg0, arg1, arg2, arg3 interfac
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalletVerify", reflect.TypeOf((*MockFullNode)(nil).WalletVerify), arg0, arg1, arg2, arg3)
 }
+
+// WatchlistAdd mocks base method.
+func (m *MockFullNode) WatchlistAdd(arg0 context.Context, arg1 api.WatchlistEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchlistAdd", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WatchlistAdd indicates an expected call of WatchlistAdd.
+func (mr *MockFullNodeMockRecorder) WatchlistAdd(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchlistAdd", reflect.TypeOf((*MockFullNode)(nil).WatchlistAdd), arg0, arg1)
+}
+
+// WatchlistList mocks base method.
+func (m *MockFullNode) WatchlistList(arg0 context.Context) ([]api.WatchlistStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchlistList", arg0)
+	ret0, _ := ret[0].([]api.WatchlistStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WatchlistList indicates an expected call of WatchlistList.
+func (mr *MockFullNodeMockRecorder) WatchlistList(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchlistList", reflect.TypeOf((*MockFullNode)(nil).WatchlistList), arg0)
+}
+
+// WatchlistRemove mocks base method.
+func (m *MockFullNode) WatchlistRemove(arg0 context.Context, arg1 address.Address) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchlistRemove", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WatchlistRemove indicates an expected call of WatchlistRemove.
+func (mr *MockFullNodeMockRecorder) WatchlistRemove(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchlistRemove", reflect.TypeOf((*MockFullNode)(nil).WatchlistRemove), arg0, arg1)
+}
@@ -63,18 +63,30 @@ type CommonStruct struct {
 	Internal struct {
 		AuthNew func(p0 context.Context, p1 []auth.Permission) ([]byte, error) `perm:"admin"`
 
+		AuthTokenCreate func(p0 context.Context, p1 auth.Permission, p2 string, p3 time.Duration) (NewAPIToken, error) `perm:"admin"`
+
+		AuthTokenList func(p0 context.Context) ([]APIToken, error) `perm:"admin"`
+
+		AuthTokenRevoke func(p0 context.Context, p1 uuid.UUID) error `perm:"admin"`
+
 		AuthVerify func(p0 context.Context, p1 string) ([]auth.Permission, error) `perm:"read"`
 
 		Closing func(p0 context.Context) (<-chan struct{}, error) `perm:"read"`
 
+		ConfigReload func(p0 context.Context) (ConfigReloadResult, error) `perm:"admin"`
+
 		Discover func(p0 context.Context) (apitypes.OpenRPCDocument, error) `perm:"read"`
 
 		LogAlerts func(p0 context.Context) ([]alerting.Alert, error) `perm:"admin"`
 
 		LogList func(p0 context.Context) ([]string, error) `perm:"write"`
 
+		LogPersistLevel func(p0 context.Context, p1 string, p2 string) error `perm:"admin"`
+
 		LogSetLevel func(p0 context.Context, p1 string, p2 string) error `perm:"write"`
 
+		LogTail func(p0 context.Context, p1 string, p2 string) (<-chan LogEntry, error) `perm:"admin"`
+
 		Session func(p0 context.Context) (uuid.UUID, error) `perm:"read"`
 
 		Shutdown func(p0 context.Context) error `perm:"admin"`
@@ -107,14 +119,46 @@ type FullNodeStruct struct {
 	NetStruct
 
 	Internal struct {
+		ChainBackfill func(p0 context.Context, p1 BackfillSpec) (<-chan BackfillProgress, error) `perm:"admin"`
+
+		ChainBlockPropagation func(p0 context.Context, p1 abi.ChainEpoch) ([]BlockPropagation, error) `perm:"read"`
+
+		ChainBlockstoreCacheFlush func(p0 context.Context) error `perm:"admin"`
+
+		ChainBlockstoreGC func(p0 context.Context, p1 BlockstoreGCOpts) error `perm:"admin"`
+
+		ChainBlockstoreGCStatus func(p0 context.Context) (BlockstoreGCStatus, error) `perm:"read"`
+
 		ChainBlockstoreInfo func(p0 context.Context) (map[string]interface{}, error) `perm:"read"`
 
+		ChainBlockstoreMount func(p0 context.Context, p1 string, p2 string) (BlockstoreMount, error) `perm:"admin"`
+
+		ChainBlockstoreMounts func(p0 context.Context) ([]BlockstoreMount, error) `perm:"read"`
+
+		ChainBlockstoreQuarantine func(p0 context.Context) ([]cid.Cid, error) `perm:"read"`
+
+		ChainBlockstoreScrub func(p0 context.Context) error `perm:"admin"`
+
+		ChainBlockstoreScrubStatus func(p0 context.Context) (BlockstoreScrubStatus, error) `perm:"read"`
+
+		ChainBlockstoreUnmount func(p0 context.Context, p1 string) error `perm:"admin"`
+
 		ChainCheckBlockstore func(p0 context.Context) error `perm:"admin"`
 
+		ChainConsensusFaultDismiss func(p0 context.Context, p1 cid.Cid) error `perm:"write"`
+
+		ChainConsensusFaultSubmit func(p0 context.Context, p1 cid.Cid) (cid.Cid, error) `perm:"sign"`
+
+		ChainConsensusFaults func(p0 context.Context) ([]ConsensusFault, error) `perm:"read"`
+
 		ChainDeleteObj func(p0 context.Context, p1 cid.Cid) error `perm:"admin"`
 
 		ChainExport func(p0 context.Context, p1 abi.ChainEpoch, p2 bool, p3 types.TipSetKey) (<-chan []byte, error) `perm:"read"`
 
+		ChainExportLatestState func(p0 context.Context, p1 abi.ChainEpoch, p2 types.TipSetKey) (<-chan []byte, error) `perm:"read"`
+
+		ChainExportRange func(p0 context.Context, p1 abi.ChainEpoch, p2 abi.ChainEpoch, p3 types.TipSetKey, p4 ChainExportRangeOpts) (<-chan []byte, error) `perm:"read"`
+
 		ChainGetBlock func(p0 context.Context, p1 cid.Cid) (*types.BlockHeader, error) `perm:"read"`
 
 		ChainGetBlockMessages func(p0 context.Context, p1 cid.Cid) (*BlockMessages, error) `perm:"read"`
@@ -143,14 +187,20 @@ type FullNodeStruct struct {
 
 		ChainHead func(p0 context.Context) (*types.TipSet, error) `perm:"read"`
 
+		ChainIndexActorMessages func(p0 context.Context, p1 address.Address, p2 abi.ChainEpoch, p3 abi.ChainEpoch, p4 int) ([]IndexedMessage, error) `perm:"read"`
+
 		ChainNotify func(p0 context.Context) (<-chan []*HeadChange, error) `perm:"read"`
 
+		ChainNotifyDetailed func(p0 context.Context) (<-chan []*DetailedHeadChange, error) `perm:"read"`
+
 		ChainPutObj func(p0 context.Context, p1 blocks.Block) error `perm:"admin"`
 
 		ChainReadObj func(p0 context.Context, p1 cid.Cid) ([]byte, error) `perm:"read"`
 
 		ChainSetHead func(p0 context.Context, p1 types.TipSetKey) error `perm:"admin"`
 
+		ChainSnapshotStatus func(p0 context.Context) (ChainSnapshotStatus, error) `perm:"read"`
+
 		ChainStatObj func(p0 context.Context, p1 cid.Cid, p2 cid.Cid) (ObjStat, error) `perm:"read"`
 
 		ChainTipSetWeight func(p0 context.Context, p1 types.TipSetKey) (types.BigInt, error) `perm:"read"`
@@ -163,6 +213,8 @@ type FullNodeStruct struct {
 
 		ClientDataTransferUpdates func(p0 context.Context) (<-chan DataTransferChannel, error) `perm:"write"`
 
+		ClientDealHealth func(p0 context.Context) ([]DealHealth, error) `perm:"read"`
+
 		ClientDealPieceCID func(p0 context.Context, p1 cid.Cid) (DataCIDSize, error) `perm:"read"`
 
 		ClientDealSize func(p0 context.Context, p1 cid.Cid) (DataSize, error) `perm:"read"`
@@ -287,6 +339,8 @@ type FullNodeStruct struct {
 
 		MsigGetPending func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) ([]*MsigTransaction, error) `perm:"read"`
 
+		MsigGetReport func(p0 context.Context, p1 address.Address, p2 abi.ChainEpoch, p3 abi.ChainEpoch, p4 types.TipSetKey) (*MsigReport, error) `perm:"read"`
+
 		MsigGetVested func(p0 context.Context, p1 address.Address, p2 types.TipSetKey, p3 types.TipSetKey) (types.BigInt, error) `perm:"read"`
 
 		MsigGetVestingSchedule func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (MsigVesting, error) `perm:"read"`
@@ -301,6 +355,8 @@ type FullNodeStruct struct {
 
 		MsigSwapPropose func(p0 context.Context, p1 address.Address, p2 address.Address, p3 address.Address, p4 address.Address) (*MessagePrototype, error) `perm:"sign"`
 
+		NodeHealth func(p0 context.Context) (NodeHealth, error) `perm:"read"`
+
 		NodeStatus func(p0 context.Context, p1 bool) (NodeStatus, error) `perm:"read"`
 
 		PaychAllocateLane func(p0 context.Context, p1 address.Address) (uint64, error) `perm:"sign"`
@@ -309,18 +365,26 @@ type FullNodeStruct struct {
 
 		PaychAvailableFundsByFromTo func(p0 context.Context, p1 address.Address, p2 address.Address) (*ChannelAvailableFunds, error) `perm:"sign"`
 
+		PaychBudget func(p0 context.Context) (*PaychBudgetStatus, error) `perm:"read"`
+
 		PaychCollect func(p0 context.Context, p1 address.Address) (cid.Cid, error) `perm:"sign"`
 
+		PaychExport func(p0 context.Context, p1 address.Address) (*PaychBundle, error) `perm:"sign"`
+
 		PaychFund func(p0 context.Context, p1 address.Address, p2 address.Address, p3 types.BigInt) (*ChannelInfo, error) `perm:"sign"`
 
 		PaychGet func(p0 context.Context, p1 address.Address, p2 address.Address, p3 types.BigInt, p4 PaychGetOpts) (*ChannelInfo, error) `perm:"sign"`
 
 		PaychGetWaitReady func(p0 context.Context, p1 cid.Cid) (address.Address, error) `perm:"sign"`
 
+		PaychImport func(p0 context.Context, p1 *PaychBundle) error `perm:"sign"`
+
 		PaychList func(p0 context.Context) ([]address.Address, error) `perm:"read"`
 
 		PaychNewPayment func(p0 context.Context, p1 address.Address, p2 address.Address, p3 []VoucherSpec) (*PaymentInfo, error) `perm:"sign"`
 
+		PaychSetBudget func(p0 context.Context, p1 PaychBudget) error `perm:"sign"`
+
 		PaychSettle func(p0 context.Context, p1 address.Address) (cid.Cid, error) `perm:"sign"`
 
 		PaychStatus func(p0 context.Context, p1 address.Address) (*PaychStatus, error) `perm:"read"`
@@ -447,6 +511,12 @@ type FullNodeStruct struct {
 
 		SyncCheckpoint func(p0 context.Context, p1 types.TipSetKey) error `perm:"admin"`
 
+		SyncCheckpointFeedStatus func(p0 context.Context) (CheckpointFeedStatus, error) `perm:"read"`
+
+		SyncCheckpointSigned func(p0 context.Context, p1 *SignedCheckpoint) error `perm:"admin"`
+
+		SyncForks func(p0 context.Context) (ForkStatus, error) `perm:"read"`
+
 		SyncIncomingBlocks func(p0 context.Context) (<-chan *types.BlockHeader, error) `perm:"read"`
 
 		SyncMarkBad func(p0 context.Context, p1 cid.Cid) error `perm:"admin"`
@@ -455,6 +525,8 @@ type FullNodeStruct struct {
 
 		SyncSubmitBlock func(p0 context.Context, p1 *types.BlockMsg) error `perm:"write"`
 
+		SyncSubscribe func(p0 context.Context) (<-chan SyncProgress, error) `perm:"read"`
+
 		SyncUnmarkAllBad func(p0 context.Context) error `perm:"admin"`
 
 		SyncUnmarkBad func(p0 context.Context, p1 cid.Cid) error `perm:"admin"`
@@ -486,6 +558,12 @@ type FullNodeStruct struct {
 		WalletValidateAddress func(p0 context.Context, p1 string) (address.Address, error) `perm:"read"`
 
 		WalletVerify func(p0 context.Context, p1 address.Address, p2 []byte, p3 *crypto.Signature) (bool, error) `perm:"read"`
+
+		WatchlistAdd func(p0 context.Context, p1 WatchlistEntry) error `perm:"admin"`
+
+		WatchlistList func(p0 context.Context) ([]WatchlistStatus, error) `perm:"read"`
+
+		WatchlistRemove func(p0 context.Context, p1 address.Address) error `perm:"admin"`
 	}
 }
 
@@ -588,6 +666,12 @@ type NetStruct struct {
 
 		NetAgentVersion func(p0 context.Context, p1 peer.ID) (string, error) `perm:"read"`
 
+		NetAllowAdd func(p0 context.Context, p1 NetBlockList) error `perm:"admin"`
+
+		NetAllowList func(p0 context.Context) (NetBlockList, error) `perm:"read"`
+
+		NetAllowRemove func(p0 context.Context, p1 NetBlockList) error `perm:"admin"`
+
 		NetAutoNatStatus func(p0 context.Context) (NatInfo, error) `perm:"read"`
 
 		NetBandwidthStats func(p0 context.Context) (metrics.Stats, error) `perm:"read"`
@@ -596,6 +680,8 @@ type NetStruct struct {
 
 		NetBandwidthStatsByProtocol func(p0 context.Context) (map[protocol.ID]metrics.Stats, error) `perm:"read"`
 
+		NetBandwidthStatsByProtocolGroup func(p0 context.Context) (map[string]ProtocolGroupBandwidth, error) `perm:"read"`
+
 		NetBlockAdd func(p0 context.Context, p1 NetBlockList) error `perm:"admin"`
 
 		NetBlockList func(p0 context.Context) (NetBlockList, error) `perm:"read"`
@@ -614,6 +700,8 @@ type NetStruct struct {
 
 		NetPeerInfo func(p0 context.Context, p1 peer.ID) (*ExtendedPeerInfo, error) `perm:"read"`
 
+		NetPeerInfoDetailed func(p0 context.Context, p1 peer.ID) (*PeerInfoDetailed, error) `perm:"read"`
+
 		NetPeers func(p0 context.Context) ([]peer.AddrInfo, error) `perm:"read"`
 
 		NetPing func(p0 context.Context, p1 peer.ID) (time.Duration, error) `perm:"read"`
@@ -694,10 +782,14 @@ type StorageMinerStruct struct {
 
 		DealsImportData func(p0 context.Context, p1 cid.Cid, p2 string) error `perm:"admin"`
 
+		DealsImportDataFromURL func(p0 context.Context, p1 cid.Cid, p2 DealDataURL) error `perm:"admin"`
+
 		DealsList func(p0 context.Context) ([]*MarketDeal, error) `perm:"admin"`
 
 		DealsPieceCidBlocklist func(p0 context.Context) ([]cid.Cid, error) `perm:"admin"`
 
+		DealsRetrievalPricingPolicy func(p0 context.Context) (dtypes.RetrievalPricingPolicy, error) `perm:"admin"`
+
 		DealsSetConsiderOfflineRetrievalDeals func(p0 context.Context, p1 bool) error `perm:"admin"`
 
 		DealsSetConsiderOfflineStorageDeals func(p0 context.Context, p1 bool) error `perm:"admin"`
@@ -712,6 +804,8 @@ type StorageMinerStruct struct {
 
 		DealsSetPieceCidBlocklist func(p0 context.Context, p1 []cid.Cid) error `perm:"admin"`
 
+		DealsSetRetrievalPricingPolicy func(p0 context.Context, p1 dtypes.RetrievalPricingPolicy) error `perm:"admin"`
+
 		IndexerAnnounceAllDeals func(p0 context.Context) error `perm:"admin"`
 
 		IndexerAnnounceDeal func(p0 context.Context, p1 cid.Cid) error `perm:"admin"`
@@ -720,12 +814,16 @@ type StorageMinerStruct struct {
 
 		MarketDataTransferDiagnostics func(p0 context.Context, p1 peer.ID) (*TransferDiagnostics, error) `perm:"write"`
 
+		MarketDataTransferLimits func(p0 context.Context) (DataTransferLimitsStatus, error) `perm:"read"`
+
 		MarketDataTransferUpdates func(p0 context.Context) (<-chan DataTransferChannel, error) `perm:"write"`
 
 		MarketGetAsk func(p0 context.Context) (*storagemarket.SignedStorageAsk, error) `perm:"read"`
 
 		MarketGetDealUpdates func(p0 context.Context) (<-chan storagemarket.MinerDeal, error) `perm:"read"`
 
+		MarketGetPublishConfig func(p0 context.Context) (DealPublishConfig, error) `perm:"read"`
+
 		MarketGetRetrievalAsk func(p0 context.Context) (*retrievalmarket.Ask, error) `perm:"read"`
 
 		MarketImportDealData func(p0 context.Context, p1 cid.Cid, p2 string) error `perm:"write"`
@@ -740,18 +838,36 @@ type StorageMinerStruct struct {
 
 		MarketPendingDeals func(p0 context.Context) (PendingDealInfo, error) `perm:"write"`
 
+		MarketPendingFilterDecisions func(p0 context.Context) ([]PendingFilterDecision, error) `perm:"read"`
+
+		MarketPublishPendingDeal func(p0 context.Context, p1 cid.Cid) error `perm:"admin"`
+
 		MarketPublishPendingDeals func(p0 context.Context) error `perm:"admin"`
 
+		MarketResolveFilterDecision func(p0 context.Context, p1 cid.Cid, p2 bool, p3 string) error `perm:"admin"`
+
 		MarketRestartDataTransfer func(p0 context.Context, p1 datatransfer.TransferID, p2 peer.ID, p3 bool) error `perm:"write"`
 
 		MarketRetryPublishDeal func(p0 context.Context, p1 cid.Cid) error `perm:"admin"`
 
 		MarketSetAsk func(p0 context.Context, p1 types.BigInt, p2 types.BigInt, p3 abi.ChainEpoch, p4 abi.PaddedPieceSize, p5 abi.PaddedPieceSize) error `perm:"admin"`
 
+		MarketSetDataTransferLimits func(p0 context.Context, p1 DataTransferLimits) error `perm:"admin"`
+
+		MarketSetPublishConfig func(p0 context.Context, p1 DealPublishConfig) error `perm:"admin"`
+
 		MarketSetRetrievalAsk func(p0 context.Context, p1 *retrievalmarket.Ask) error `perm:"admin"`
 
+		MarketSubscribeDealEvents func(p0 context.Context) (<-chan MarketDealEvent, error) `perm:"read"`
+
+		MinerEvents func(p0 context.Context) (<-chan MinerEvent, error) `perm:"read"`
+
 		MiningBase func(p0 context.Context) (*types.TipSet, error) `perm:"read"`
 
+		NodeHealth func(p0 context.Context) (NodeHealth, error) `perm:"read"`
+
+		NotificationsTest func(p0 context.Context, p1 string) error `perm:"admin"`
+
 		PiecesGetCIDInfo func(p0 context.Context, p1 cid.Cid) (*piecestore.CIDInfo, error) `perm:"read"`
 
 		PiecesGetPieceInfo func(p0 context.Context, p1 cid.Cid) (*piecestore.PieceInfo, error) `perm:"read"`
@@ -762,6 +878,14 @@ type StorageMinerStruct struct {
 
 		PledgeSector func(p0 context.Context) (abi.SectorID, error) `perm:"write"`
 
+		ProvingDispute func(p0 context.Context, p1 address.Address, p2 uint64, p3 uint64) (cid.Cid, error) `perm:"admin"`
+
+		ProvingDisputeCheck func(p0 context.Context, p1 address.Address, p2 uint64) ([]DisputablePoSt, error) `perm:"read"`
+
+		ProvingDisputeEvidence func(p0 context.Context, p1 address.Address, p2 []abi.SectorNumber) error `perm:"admin"`
+
+		ProvingDisputeStatus func(p0 context.Context) ([]DisputablePoSt, error) `perm:"read"`
+
 		ReturnAddPiece func(p0 context.Context, p1 storiface.CallID, p2 abi.PieceInfo, p3 *storiface.CallError) error `perm:"admin"`
 
 		ReturnDataCid func(p0 context.Context, p1 storiface.CallID, p2 abi.PieceInfo, p3 *storiface.CallError) error `perm:"admin"`
@@ -802,14 +926,28 @@ type StorageMinerStruct struct {
 
 		SealingSchedDiag func(p0 context.Context, p1 bool) (interface{}, error) `perm:"admin"`
 
+		SealingSchedPriorities func(p0 context.Context) (storiface.SchedPriorities, error) `perm:"admin"`
+
+		SealingSetSchedPriorities func(p0 context.Context, p1 storiface.SchedPriorities) error `perm:"admin"`
+
+		SealingWorkersBootstrapToken func(p0 context.Context, p1 string, p2 time.Duration) (string, error) `perm:"admin"`
+
+		SealingWorkersTag func(p0 context.Context, p1 string, p2 []string) error `perm:"admin"`
+
+		SealingWorkersUntag func(p0 context.Context, p1 string, p2 []string) error `perm:"admin"`
+
 		SectorAbortUpgrade func(p0 context.Context, p1 abi.SectorNumber) error `perm:"admin"`
 
 		SectorAddPieceToAny func(p0 context.Context, p1 abi.UnpaddedPieceSize, p2 storiface.Data, p3 PieceDealInfo) (SectorOffset, error) `perm:"admin"`
 
+		SectorCommitBatchPlan func(p0 context.Context) (sealiface.BatchPlan, error) `perm:"admin"`
+
 		SectorCommitFlush func(p0 context.Context) ([]sealiface.CommitBatchRes, error) `perm:"admin"`
 
 		SectorCommitPending func(p0 context.Context) ([]abi.SectorID, error) `perm:"admin"`
 
+		SectorCommitSetBatchMode func(p0 context.Context, p1 sealiface.BatchMode) error `perm:"admin"`
+
 		SectorGetExpectedSealDuration func(p0 context.Context) (time.Duration, error) `perm:"read"`
 
 		SectorGetSealDelay func(p0 context.Context) (time.Duration, error) `perm:"read"`
@@ -818,20 +956,28 @@ type StorageMinerStruct struct {
 
 		SectorMatchPendingPiecesToOpenSectors func(p0 context.Context) error `perm:"admin"`
 
+		SectorPreCommitBatchPlan func(p0 context.Context) (sealiface.BatchPlan, error) `perm:"admin"`
+
 		SectorPreCommitFlush func(p0 context.Context) ([]sealiface.PreCommitBatchRes, error) `perm:"admin"`
 
 		SectorPreCommitPending func(p0 context.Context) ([]abi.SectorID, error) `perm:"admin"`
 
+		SectorPreCommitSetBatchMode func(p0 context.Context, p1 sealiface.BatchMode) error `perm:"admin"`
+
 		SectorRemove func(p0 context.Context, p1 abi.SectorNumber) error `perm:"admin"`
 
 		SectorSetExpectedSealDuration func(p0 context.Context, p1 time.Duration) error `perm:"write"`
 
 		SectorSetSealDelay func(p0 context.Context, p1 time.Duration) error `perm:"write"`
 
+		SectorSnapRetry func(p0 context.Context, p1 abi.SectorNumber) error `perm:"admin"`
+
 		SectorStartSealing func(p0 context.Context, p1 abi.SectorNumber) error `perm:"write"`
 
 		SectorTerminate func(p0 context.Context, p1 abi.SectorNumber) error `perm:"admin"`
 
+		SectorTerminateEstimate func(p0 context.Context, p1 []abi.SectorNumber, p2 abi.ChainEpoch) (*TerminateEstimate, error) `perm:"read"`
+
 		SectorTerminateFlush func(p0 context.Context) (*cid.Cid, error) `perm:"admin"`
 
 		SectorTerminatePending func(p0 context.Context) ([]abi.SectorID, error) `perm:"admin"`
@@ -840,8 +986,12 @@ type StorageMinerStruct struct {
 
 		SectorsListInStates func(p0 context.Context, p1 []SectorState) ([]abi.SectorNumber, error) `perm:"read"`
 
+		SectorsPackingStatus func(p0 context.Context) (DealPackingStatus, error) `perm:"read"`
+
 		SectorsRefs func(p0 context.Context) (map[string][]SealedRef, error) `perm:"read"`
 
+		SectorsSnapList func(p0 context.Context) ([]SnapSectorInfo, error) `perm:"read"`
+
 		SectorsStatus func(p0 context.Context, p1 abi.SectorNumber, p2 bool) (SectorInfo, error) `perm:"read"`
 
 		SectorsSummary func(p0 context.Context) (map[SectorState]int, error) `perm:"read"`
@@ -852,6 +1002,8 @@ type StorageMinerStruct struct {
 
 		StorageAddLocal func(p0 context.Context, p1 string) error `perm:"admin"`
 
+		StorageAtRiskSectors func(p0 context.Context) ([]storiface.AtRiskSector, error) `perm:"admin"`
+
 		StorageAttach func(p0 context.Context, p1 storiface.StorageInfo, p2 fsutil.FsStat) error `perm:"admin"`
 
 		StorageAuthVerify func(p0 context.Context, p1 string) ([]auth.Permission, error) `perm:"read"`
@@ -866,6 +1018,8 @@ type StorageMinerStruct struct {
 
 		StorageGetLocks func(p0 context.Context) (storiface.SectorLocks, error) `perm:"admin"`
 
+		StorageHealth func(p0 context.Context) ([]storiface.PathHealth, error) `perm:"admin"`
+
 		StorageInfo func(p0 context.Context, p1 storiface.ID) (storiface.StorageInfo, error) `perm:"admin"`
 
 		StorageList func(p0 context.Context) (map[storiface.ID][]storiface.Decl, error) `perm:"admin"`
@@ -874,12 +1028,26 @@ type StorageMinerStruct struct {
 
 		StorageLock func(p0 context.Context, p1 abi.SectorID, p2 storiface.SectorFileType, p3 storiface.SectorFileType) error `perm:"admin"`
 
+		StorageRebalance func(p0 context.Context, p1 bool) ([]storiface.RebalanceMove, error) `perm:"admin"`
+
+		StorageRebalanceStatus func(p0 context.Context) (storiface.RebalanceStatus, error) `perm:"admin"`
+
 		StorageReportHealth func(p0 context.Context, p1 storiface.ID, p2 storiface.HealthReport) error `perm:"admin"`
 
+		StorageSetDrain func(p0 context.Context, p1 storiface.ID, p2 bool) error `perm:"admin"`
+
 		StorageStat func(p0 context.Context, p1 storiface.ID) (fsutil.FsStat, error) `perm:"admin"`
 
 		StorageTryLock func(p0 context.Context, p1 abi.SectorID, p2 storiface.SectorFileType, p3 storiface.SectorFileType) (bool, error) `perm:"admin"`
 
+		StorageUnsealQueue func(p0 context.Context) (storiface.UnsealQueue, error) `perm:"admin"`
+
+		WindowPoStDryRun func(p0 context.Context, p1 uint64) (WdPoStDryRun, error) `perm:"admin"`
+
+		WindowPoStSenderStatus func(p0 context.Context) ([]WdPoStMessage, error) `perm:"read"`
+
+		WinningPoStHistory func(p0 context.Context) ([]WinningPoStRecord, error) `perm:"read"`
+
 		WorkerConnect func(p0 context.Context, p1 string) error `perm:"admin"`
 
 		WorkerJobs func(p0 context.Context) (map[uuid.UUID][]storiface.WorkerJob, error) `perm:"admin"`
@@ -1028,6 +1196,39 @@ func (s *CommonStub) AuthNew(p0 context.Context, p1 []auth.Permission) ([]byte,
 	return *new([]byte), ErrNotSupported
 }
 
+func (s *CommonStruct) AuthTokenCreate(p0 context.Context, p1 auth.Permission, p2 string, p3 time.Duration) (NewAPIToken, error) {
+	if s.Internal.AuthTokenCreate == nil {
+		return *new(NewAPIToken), ErrNotSupported
+	}
+	return s.Internal.AuthTokenCreate(p0, p1, p2, p3)
+}
+
+func (s *CommonStub) AuthTokenCreate(p0 context.Context, p1 auth.Permission, p2 string, p3 time.Duration) (NewAPIToken, error) {
+	return *new(NewAPIToken), ErrNotSupported
+}
+
+func (s *CommonStruct) AuthTokenList(p0 context.Context) ([]APIToken, error) {
+	if s.Internal.AuthTokenList == nil {
+		return *new([]APIToken), ErrNotSupported
+	}
+	return s.Internal.AuthTokenList(p0)
+}
+
+func (s *CommonStub) AuthTokenList(p0 context.Context) ([]APIToken, error) {
+	return *new([]APIToken), ErrNotSupported
+}
+
+func (s *CommonStruct) AuthTokenRevoke(p0 context.Context, p1 uuid.UUID) error {
+	if s.Internal.AuthTokenRevoke == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.AuthTokenRevoke(p0, p1)
+}
+
+func (s *CommonStub) AuthTokenRevoke(p0 context.Context, p1 uuid.UUID) error {
+	return ErrNotSupported
+}
+
 func (s *CommonStruct) AuthVerify(p0 context.Context, p1 string) ([]auth.Permission, error) {
 	if s.Internal.AuthVerify == nil {
 		return *new([]auth.Permission), ErrNotSupported
@@ -1050,6 +1251,17 @@ func (s *CommonStub) Closing(p0 context.Context) (<-chan struct{}, error) {
 	return nil, ErrNotSupported
 }
 
+func (s *CommonStruct) ConfigReload(p0 context.Context) (ConfigReloadResult, error) {
+	if s.Internal.ConfigReload == nil {
+		return *new(ConfigReloadResult), ErrNotSupported
+	}
+	return s.Internal.ConfigReload(p0)
+}
+
+func (s *CommonStub) ConfigReload(p0 context.Context) (ConfigReloadResult, error) {
+	return *new(ConfigReloadResult), ErrNotSupported
+}
+
 func (s *CommonStruct) Discover(p0 context.Context) (apitypes.OpenRPCDocument, error) {
 	if s.Internal.Discover == nil {
 		return *new(apitypes.OpenRPCDocument), ErrNotSupported
@@ -1083,6 +1295,17 @@ func (s *CommonStub) LogList(p0 context.Context) ([]string, error) {
 	return *new([]string), ErrNotSupported
 }
 
+func (s *CommonStruct) LogPersistLevel(p0 context.Context, p1 string, p2 string) error {
+	if s.Internal.LogPersistLevel == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.LogPersistLevel(p0, p1, p2)
+}
+
+func (s *CommonStub) LogPersistLevel(p0 context.Context, p1 string, p2 string) error {
+	return ErrNotSupported
+}
+
 func (s *CommonStruct) LogSetLevel(p0 context.Context, p1 string, p2 string) error {
 	if s.Internal.LogSetLevel == nil {
 		return ErrNotSupported
@@ -1094,6 +1317,17 @@ func (s *CommonStub) LogSetLevel(p0 context.Context, p1 string, p2 string) error
 	return ErrNotSupported
 }
 
+func (s *CommonStruct) LogTail(p0 context.Context, p1 string, p2 string) (<-chan LogEntry, error) {
+	if s.Internal.LogTail == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.LogTail(p0, p1, p2)
+}
+
+func (s *CommonStub) LogTail(p0 context.Context, p1 string, p2 string) (<-chan LogEntry, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *CommonStruct) Session(p0 context.Context) (uuid.UUID, error) {
 	if s.Internal.Session == nil {
 		return *new(uuid.UUID), ErrNotSupported
@@ -1127,6 +1361,61 @@ func (s *CommonStub) Version(p0 context.Context) (APIVersion, error) {
 	return *new(APIVersion), ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainBackfill(p0 context.Context, p1 BackfillSpec) (<-chan BackfillProgress, error) {
+	if s.Internal.ChainBackfill == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ChainBackfill(p0, p1)
+}
+
+func (s *FullNodeStub) ChainBackfill(p0 context.Context, p1 BackfillSpec) (<-chan BackfillProgress, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainBlockPropagation(p0 context.Context, p1 abi.ChainEpoch) ([]BlockPropagation, error) {
+	if s.Internal.ChainBlockPropagation == nil {
+		return *new([]BlockPropagation), ErrNotSupported
+	}
+	return s.Internal.ChainBlockPropagation(p0, p1)
+}
+
+func (s *FullNodeStub) ChainBlockPropagation(p0 context.Context, p1 abi.ChainEpoch) ([]BlockPropagation, error) {
+	return *new([]BlockPropagation), ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainBlockstoreCacheFlush(p0 context.Context) error {
+	if s.Internal.ChainBlockstoreCacheFlush == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ChainBlockstoreCacheFlush(p0)
+}
+
+func (s *FullNodeStub) ChainBlockstoreCacheFlush(p0 context.Context) error {
+	return ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainBlockstoreGC(p0 context.Context, p1 BlockstoreGCOpts) error {
+	if s.Internal.ChainBlockstoreGC == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ChainBlockstoreGC(p0, p1)
+}
+
+func (s *FullNodeStub) ChainBlockstoreGC(p0 context.Context, p1 BlockstoreGCOpts) error {
+	return ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainBlockstoreGCStatus(p0 context.Context) (BlockstoreGCStatus, error) {
+	if s.Internal.ChainBlockstoreGCStatus == nil {
+		return *new(BlockstoreGCStatus), ErrNotSupported
+	}
+	return s.Internal.ChainBlockstoreGCStatus(p0)
+}
+
+func (s *FullNodeStub) ChainBlockstoreGCStatus(p0 context.Context) (BlockstoreGCStatus, error) {
+	return *new(BlockstoreGCStatus), ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainBlockstoreInfo(p0 context.Context) (map[string]interface{}, error) {
 	if s.Internal.ChainBlockstoreInfo == nil {
 		return *new(map[string]interface{}), ErrNotSupported
@@ -1138,6 +1427,72 @@ func (s *FullNodeStub) ChainBlockstoreInfo(p0 context.Context) (map[string]inter
 	return *new(map[string]interface{}), ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainBlockstoreMount(p0 context.Context, p1 string, p2 string) (BlockstoreMount, error) {
+	if s.Internal.ChainBlockstoreMount == nil {
+		return *new(BlockstoreMount), ErrNotSupported
+	}
+	return s.Internal.ChainBlockstoreMount(p0, p1, p2)
+}
+
+func (s *FullNodeStub) ChainBlockstoreMount(p0 context.Context, p1 string, p2 string) (BlockstoreMount, error) {
+	return *new(BlockstoreMount), ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainBlockstoreMounts(p0 context.Context) ([]BlockstoreMount, error) {
+	if s.Internal.ChainBlockstoreMounts == nil {
+		return *new([]BlockstoreMount), ErrNotSupported
+	}
+	return s.Internal.ChainBlockstoreMounts(p0)
+}
+
+func (s *FullNodeStub) ChainBlockstoreMounts(p0 context.Context) ([]BlockstoreMount, error) {
+	return *new([]BlockstoreMount), ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainBlockstoreQuarantine(p0 context.Context) ([]cid.Cid, error) {
+	if s.Internal.ChainBlockstoreQuarantine == nil {
+		return *new([]cid.Cid), ErrNotSupported
+	}
+	return s.Internal.ChainBlockstoreQuarantine(p0)
+}
+
+func (s *FullNodeStub) ChainBlockstoreQuarantine(p0 context.Context) ([]cid.Cid, error) {
+	return *new([]cid.Cid), ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainBlockstoreScrub(p0 context.Context) error {
+	if s.Internal.ChainBlockstoreScrub == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ChainBlockstoreScrub(p0)
+}
+
+func (s *FullNodeStub) ChainBlockstoreScrub(p0 context.Context) error {
+	return ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainBlockstoreScrubStatus(p0 context.Context) (BlockstoreScrubStatus, error) {
+	if s.Internal.ChainBlockstoreScrubStatus == nil {
+		return *new(BlockstoreScrubStatus), ErrNotSupported
+	}
+	return s.Internal.ChainBlockstoreScrubStatus(p0)
+}
+
+func (s *FullNodeStub) ChainBlockstoreScrubStatus(p0 context.Context) (BlockstoreScrubStatus, error) {
+	return *new(BlockstoreScrubStatus), ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainBlockstoreUnmount(p0 context.Context, p1 string) error {
+	if s.Internal.ChainBlockstoreUnmount == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ChainBlockstoreUnmount(p0, p1)
+}
+
+func (s *FullNodeStub) ChainBlockstoreUnmount(p0 context.Context, p1 string) error {
+	return ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainCheckBlockstore(p0 context.Context) error {
 	if s.Internal.ChainCheckBlockstore == nil {
 		return ErrNotSupported
@@ -1149,6 +1504,39 @@ func (s *FullNodeStub) ChainCheckBlockstore(p0 context.Context) error {
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainConsensusFaultDismiss(p0 context.Context, p1 cid.Cid) error {
+	if s.Internal.ChainConsensusFaultDismiss == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ChainConsensusFaultDismiss(p0, p1)
+}
+
+func (s *FullNodeStub) ChainConsensusFaultDismiss(p0 context.Context, p1 cid.Cid) error {
+	return ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainConsensusFaultSubmit(p0 context.Context, p1 cid.Cid) (cid.Cid, error) {
+	if s.Internal.ChainConsensusFaultSubmit == nil {
+		return *new(cid.Cid), ErrNotSupported
+	}
+	return s.Internal.ChainConsensusFaultSubmit(p0, p1)
+}
+
+func (s *FullNodeStub) ChainConsensusFaultSubmit(p0 context.Context, p1 cid.Cid) (cid.Cid, error) {
+	return *new(cid.Cid), ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainConsensusFaults(p0 context.Context) ([]ConsensusFault, error) {
+	if s.Internal.ChainConsensusFaults == nil {
+		return *new([]ConsensusFault), ErrNotSupported
+	}
+	return s.Internal.ChainConsensusFaults(p0)
+}
+
+func (s *FullNodeStub) ChainConsensusFaults(p0 context.Context) ([]ConsensusFault, error) {
+	return *new([]ConsensusFault), ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainDeleteObj(p0 context.Context, p1 cid.Cid) error {
 	if s.Internal.ChainDeleteObj == nil {
 		return ErrNotSupported
@@ -1171,6 +1559,28 @@ func (s *FullNodeStub) ChainExport(p0 context.Context, p1 abi.ChainEpoch, p2 boo
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainExportLatestState(p0 context.Context, p1 abi.ChainEpoch, p2 types.TipSetKey) (<-chan []byte, error) {
+	if s.Internal.ChainExportLatestState == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ChainExportLatestState(p0, p1, p2)
+}
+
+func (s *FullNodeStub) ChainExportLatestState(p0 context.Context, p1 abi.ChainEpoch, p2 types.TipSetKey) (<-chan []byte, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) ChainExportRange(p0 context.Context, p1 abi.ChainEpoch, p2 abi.ChainEpoch, p3 types.TipSetKey, p4 ChainExportRangeOpts) (<-chan []byte, error) {
+	if s.Internal.ChainExportRange == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ChainExportRange(p0, p1, p2, p3, p4)
+}
+
+func (s *FullNodeStub) ChainExportRange(p0 context.Context, p1 abi.ChainEpoch, p2 abi.ChainEpoch, p3 types.TipSetKey, p4 ChainExportRangeOpts) (<-chan []byte, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainGetBlock(p0 context.Context, p1 cid.Cid) (*types.BlockHeader, error) {
 	if s.Internal.ChainGetBlock == nil {
 		return nil, ErrNotSupported
@@ -1325,6 +1735,17 @@ func (s *FullNodeStub) ChainHead(p0 context.Context) (*types.TipSet, error) {
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainIndexActorMessages(p0 context.Context, p1 address.Address, p2 abi.ChainEpoch, p3 abi.ChainEpoch, p4 int) ([]IndexedMessage, error) {
+	if s.Internal.ChainIndexActorMessages == nil {
+		return *new([]IndexedMessage), ErrNotSupported
+	}
+	return s.Internal.ChainIndexActorMessages(p0, p1, p2, p3, p4)
+}
+
+func (s *FullNodeStub) ChainIndexActorMessages(p0 context.Context, p1 address.Address, p2 abi.ChainEpoch, p3 abi.ChainEpoch, p4 int) ([]IndexedMessage, error) {
+	return *new([]IndexedMessage), ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainNotify(p0 context.Context) (<-chan []*HeadChange, error) {
 	if s.Internal.ChainNotify == nil {
 		return nil, ErrNotSupported
@@ -1336,6 +1757,17 @@ func (s *FullNodeStub) ChainNotify(p0 context.Context) (<-chan []*HeadChange, er
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainNotifyDetailed(p0 context.Context) (<-chan []*DetailedHeadChange, error) {
+	if s.Internal.ChainNotifyDetailed == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ChainNotifyDetailed(p0)
+}
+
+func (s *FullNodeStub) ChainNotifyDetailed(p0 context.Context) (<-chan []*DetailedHeadChange, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainPutObj(p0 context.Context, p1 blocks.Block) error {
 	if s.Internal.ChainPutObj == nil {
 		return ErrNotSupported
@@ -1369,6 +1801,17 @@ func (s *FullNodeStub) ChainSetHead(p0 context.Context, p1 types.TipSetKey) erro
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainSnapshotStatus(p0 context.Context) (ChainSnapshotStatus, error) {
+	if s.Internal.ChainSnapshotStatus == nil {
+		return *new(ChainSnapshotStatus), ErrNotSupported
+	}
+	return s.Internal.ChainSnapshotStatus(p0)
+}
+
+func (s *FullNodeStub) ChainSnapshotStatus(p0 context.Context) (ChainSnapshotStatus, error) {
+	return *new(ChainSnapshotStatus), ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainStatObj(p0 context.Context, p1 cid.Cid, p2 cid.Cid) (ObjStat, error) {
 	if s.Internal.ChainStatObj == nil {
 		return *new(ObjStat), ErrNotSupported
@@ -1435,6 +1878,17 @@ func (s *FullNodeStub) ClientDataTransferUpdates(p0 context.Context) (<-chan Dat
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) ClientDealHealth(p0 context.Context) ([]DealHealth, error) {
+	if s.Internal.ClientDealHealth == nil {
+		return *new([]DealHealth), ErrNotSupported
+	}
+	return s.Internal.ClientDealHealth(p0)
+}
+
+func (s *FullNodeStub) ClientDealHealth(p0 context.Context) ([]DealHealth, error) {
+	return *new([]DealHealth), ErrNotSupported
+}
+
 func (s *FullNodeStruct) ClientDealPieceCID(p0 context.Context, p1 cid.Cid) (DataCIDSize, error) {
 	if s.Internal.ClientDealPieceCID == nil {
 		return *new(DataCIDSize), ErrNotSupported
@@ -2117,6 +2571,17 @@ func (s *FullNodeStub) MsigGetPending(p0 context.Context, p1 address.Address, p2
 	return *new([]*MsigTransaction), ErrNotSupported
 }
 
+func (s *FullNodeStruct) MsigGetReport(p0 context.Context, p1 address.Address, p2 abi.ChainEpoch, p3 abi.ChainEpoch, p4 types.TipSetKey) (*MsigReport, error) {
+	if s.Internal.MsigGetReport == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MsigGetReport(p0, p1, p2, p3, p4)
+}
+
+func (s *FullNodeStub) MsigGetReport(p0 context.Context, p1 address.Address, p2 abi.ChainEpoch, p3 abi.ChainEpoch, p4 types.TipSetKey) (*MsigReport, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) MsigGetVested(p0 context.Context, p1 address.Address, p2 types.TipSetKey, p3 types.TipSetKey) (types.BigInt, error) {
 	if s.Internal.MsigGetVested == nil {
 		return *new(types.BigInt), ErrNotSupported
@@ -2194,6 +2659,17 @@ func (s *FullNodeStub) MsigSwapPropose(p0 context.Context, p1 address.Address, p
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) NodeHealth(p0 context.Context) (NodeHealth, error) {
+	if s.Internal.NodeHealth == nil {
+		return *new(NodeHealth), ErrNotSupported
+	}
+	return s.Internal.NodeHealth(p0)
+}
+
+func (s *FullNodeStub) NodeHealth(p0 context.Context) (NodeHealth, error) {
+	return *new(NodeHealth), ErrNotSupported
+}
+
 func (s *FullNodeStruct) NodeStatus(p0 context.Context, p1 bool) (NodeStatus, error) {
 	if s.Internal.NodeStatus == nil {
 		return *new(NodeStatus), ErrNotSupported
@@ -2238,8 +2714,19 @@ func (s *FullNodeStub) PaychAvailableFundsByFromTo(p0 context.Context, p1 addres
 	return nil, ErrNotSupported
 }
 
-func (s *FullNodeStruct) PaychCollect(p0 context.Context, p1 address.Address) (cid.Cid, error) {
-	if s.Internal.PaychCollect == nil {
+func (s *FullNodeStruct) PaychBudget(p0 context.Context) (*PaychBudgetStatus, error) {
+	if s.Internal.PaychBudget == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.PaychBudget(p0)
+}
+
+func (s *FullNodeStub) PaychBudget(p0 context.Context) (*PaychBudgetStatus, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *FullNodeStruct) PaychCollect(p0 context.Context, p1 address.Address) (cid.Cid, error) {
+	if s.Internal.PaychCollect == nil {
 		return *new(cid.Cid), ErrNotSupported
 	}
 	return s.Internal.PaychCollect(p0, p1)
@@ -2249,6 +2736,17 @@ func (s *FullNodeStub) PaychCollect(p0 context.Context, p1 address.Address) (cid
 	return *new(cid.Cid), ErrNotSupported
 }
 
+func (s *FullNodeStruct) PaychExport(p0 context.Context, p1 address.Address) (*PaychBundle, error) {
+	if s.Internal.PaychExport == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.PaychExport(p0, p1)
+}
+
+func (s *FullNodeStub) PaychExport(p0 context.Context, p1 address.Address) (*PaychBundle, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) PaychFund(p0 context.Context, p1 address.Address, p2 address.Address, p3 types.BigInt) (*ChannelInfo, error) {
 	if s.Internal.PaychFund == nil {
 		return nil, ErrNotSupported
@@ -2282,6 +2780,17 @@ func (s *FullNodeStub) PaychGetWaitReady(p0 context.Context, p1 cid.Cid) (addres
 	return *new(address.Address), ErrNotSupported
 }
 
+func (s *FullNodeStruct) PaychImport(p0 context.Context, p1 *PaychBundle) error {
+	if s.Internal.PaychImport == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.PaychImport(p0, p1)
+}
+
+func (s *FullNodeStub) PaychImport(p0 context.Context, p1 *PaychBundle) error {
+	return ErrNotSupported
+}
+
 func (s *FullNodeStruct) PaychList(p0 context.Context) ([]address.Address, error) {
 	if s.Internal.PaychList == nil {
 		return *new([]address.Address), ErrNotSupported
@@ -2304,6 +2813,17 @@ func (s *FullNodeStub) PaychNewPayment(p0 context.Context, p1 address.Address, p
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) PaychSetBudget(p0 context.Context, p1 PaychBudget) error {
+	if s.Internal.PaychSetBudget == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.PaychSetBudget(p0, p1)
+}
+
+func (s *FullNodeStub) PaychSetBudget(p0 context.Context, p1 PaychBudget) error {
+	return ErrNotSupported
+}
+
 func (s *FullNodeStruct) PaychSettle(p0 context.Context, p1 address.Address) (cid.Cid, error) {
 	if s.Internal.PaychSettle == nil {
 		return *new(cid.Cid), ErrNotSupported
@@ -2997,6 +3517,39 @@ func (s *FullNodeStub) SyncCheckpoint(p0 context.Context, p1 types.TipSetKey) er
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) SyncCheckpointFeedStatus(p0 context.Context) (CheckpointFeedStatus, error) {
+	if s.Internal.SyncCheckpointFeedStatus == nil {
+		return *new(CheckpointFeedStatus), ErrNotSupported
+	}
+	return s.Internal.SyncCheckpointFeedStatus(p0)
+}
+
+func (s *FullNodeStub) SyncCheckpointFeedStatus(p0 context.Context) (CheckpointFeedStatus, error) {
+	return *new(CheckpointFeedStatus), ErrNotSupported
+}
+
+func (s *FullNodeStruct) SyncCheckpointSigned(p0 context.Context, p1 *SignedCheckpoint) error {
+	if s.Internal.SyncCheckpointSigned == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SyncCheckpointSigned(p0, p1)
+}
+
+func (s *FullNodeStub) SyncCheckpointSigned(p0 context.Context, p1 *SignedCheckpoint) error {
+	return ErrNotSupported
+}
+
+func (s *FullNodeStruct) SyncForks(p0 context.Context) (ForkStatus, error) {
+	if s.Internal.SyncForks == nil {
+		return *new(ForkStatus), ErrNotSupported
+	}
+	return s.Internal.SyncForks(p0)
+}
+
+func (s *FullNodeStub) SyncForks(p0 context.Context) (ForkStatus, error) {
+	return *new(ForkStatus), ErrNotSupported
+}
+
 func (s *FullNodeStruct) SyncIncomingBlocks(p0 context.Context) (<-chan *types.BlockHeader, error) {
 	if s.Internal.SyncIncomingBlocks == nil {
 		return nil, ErrNotSupported
@@ -3041,6 +3594,17 @@ func (s *FullNodeStub) SyncSubmitBlock(p0 context.Context, p1 *types.BlockMsg) e
 	return ErrNotSupported
 }
 
+func (s *FullNodeStruct) SyncSubscribe(p0 context.Context) (<-chan SyncProgress, error) {
+	if s.Internal.SyncSubscribe == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.SyncSubscribe(p0)
+}
+
+func (s *FullNodeStub) SyncSubscribe(p0 context.Context) (<-chan SyncProgress, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) SyncUnmarkAllBad(p0 context.Context) error {
 	if s.Internal.SyncUnmarkAllBad == nil {
 		return ErrNotSupported
@@ -3217,6 +3781,39 @@ func (s *FullNodeStub) WalletVerify(p0 context.Context, p1 address.Address, p2 [
 	return false, ErrNotSupported
 }
 
+func (s *FullNodeStruct) WatchlistAdd(p0 context.Context, p1 WatchlistEntry) error {
+	if s.Internal.WatchlistAdd == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.WatchlistAdd(p0, p1)
+}
+
+func (s *FullNodeStub) WatchlistAdd(p0 context.Context, p1 WatchlistEntry) error {
+	return ErrNotSupported
+}
+
+func (s *FullNodeStruct) WatchlistList(p0 context.Context) ([]WatchlistStatus, error) {
+	if s.Internal.WatchlistList == nil {
+		return *new([]WatchlistStatus), ErrNotSupported
+	}
+	return s.Internal.WatchlistList(p0)
+}
+
+func (s *FullNodeStub) WatchlistList(p0 context.Context) ([]WatchlistStatus, error) {
+	return *new([]WatchlistStatus), ErrNotSupported
+}
+
+func (s *FullNodeStruct) WatchlistRemove(p0 context.Context, p1 address.Address) error {
+	if s.Internal.WatchlistRemove == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.WatchlistRemove(p0, p1)
+}
+
+func (s *FullNodeStub) WatchlistRemove(p0 context.Context, p1 address.Address) error {
+	return ErrNotSupported
+}
+
 func (s *GatewayStruct) ChainGetBlockMessages(p0 context.Context, p1 cid.Cid) (*BlockMessages, error) {
 	if s.Internal.ChainGetBlockMessages == nil {
 		return nil, ErrNotSupported
@@ -3679,6 +4276,39 @@ func (s *NetStub) NetAgentVersion(p0 context.Context, p1 peer.ID) (string, error
 	return "", ErrNotSupported
 }
 
+func (s *NetStruct) NetAllowAdd(p0 context.Context, p1 NetBlockList) error {
+	if s.Internal.NetAllowAdd == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.NetAllowAdd(p0, p1)
+}
+
+func (s *NetStub) NetAllowAdd(p0 context.Context, p1 NetBlockList) error {
+	return ErrNotSupported
+}
+
+func (s *NetStruct) NetAllowList(p0 context.Context) (NetBlockList, error) {
+	if s.Internal.NetAllowList == nil {
+		return *new(NetBlockList), ErrNotSupported
+	}
+	return s.Internal.NetAllowList(p0)
+}
+
+func (s *NetStub) NetAllowList(p0 context.Context) (NetBlockList, error) {
+	return *new(NetBlockList), ErrNotSupported
+}
+
+func (s *NetStruct) NetAllowRemove(p0 context.Context, p1 NetBlockList) error {
+	if s.Internal.NetAllowRemove == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.NetAllowRemove(p0, p1)
+}
+
+func (s *NetStub) NetAllowRemove(p0 context.Context, p1 NetBlockList) error {
+	return ErrNotSupported
+}
+
 func (s *NetStruct) NetAutoNatStatus(p0 context.Context) (NatInfo, error) {
 	if s.Internal.NetAutoNatStatus == nil {
 		return *new(NatInfo), ErrNotSupported
@@ -3723,6 +4353,17 @@ func (s *NetStub) NetBandwidthStatsByProtocol(p0 context.Context) (map[protocol.
 	return *new(map[protocol.ID]metrics.Stats), ErrNotSupported
 }
 
+func (s *NetStruct) NetBandwidthStatsByProtocolGroup(p0 context.Context) (map[string]ProtocolGroupBandwidth, error) {
+	if s.Internal.NetBandwidthStatsByProtocolGroup == nil {
+		return *new(map[string]ProtocolGroupBandwidth), ErrNotSupported
+	}
+	return s.Internal.NetBandwidthStatsByProtocolGroup(p0)
+}
+
+func (s *NetStub) NetBandwidthStatsByProtocolGroup(p0 context.Context) (map[string]ProtocolGroupBandwidth, error) {
+	return *new(map[string]ProtocolGroupBandwidth), ErrNotSupported
+}
+
 func (s *NetStruct) NetBlockAdd(p0 context.Context, p1 NetBlockList) error {
 	if s.Internal.NetBlockAdd == nil {
 		return ErrNotSupported
@@ -3822,6 +4463,17 @@ func (s *NetStub) NetPeerInfo(p0 context.Context, p1 peer.ID) (*ExtendedPeerInfo
 	return nil, ErrNotSupported
 }
 
+func (s *NetStruct) NetPeerInfoDetailed(p0 context.Context, p1 peer.ID) (*PeerInfoDetailed, error) {
+	if s.Internal.NetPeerInfoDetailed == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.NetPeerInfoDetailed(p0, p1)
+}
+
+func (s *NetStub) NetPeerInfoDetailed(p0 context.Context, p1 peer.ID) (*PeerInfoDetailed, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *NetStruct) NetPeers(p0 context.Context) ([]peer.AddrInfo, error) {
 	if s.Internal.NetPeers == nil {
 		return *new([]peer.AddrInfo), ErrNotSupported
@@ -4163,6 +4815,17 @@ func (s *StorageMinerStub) DealsImportData(p0 context.Context, p1 cid.Cid, p2 st
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) DealsImportDataFromURL(p0 context.Context, p1 cid.Cid, p2 DealDataURL) error {
+	if s.Internal.DealsImportDataFromURL == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.DealsImportDataFromURL(p0, p1, p2)
+}
+
+func (s *StorageMinerStub) DealsImportDataFromURL(p0 context.Context, p1 cid.Cid, p2 DealDataURL) error {
+	return ErrNotSupported
+}
+
 func (s *StorageMinerStruct) DealsList(p0 context.Context) ([]*MarketDeal, error) {
 	if s.Internal.DealsList == nil {
 		return *new([]*MarketDeal), ErrNotSupported
@@ -4185,6 +4848,17 @@ func (s *StorageMinerStub) DealsPieceCidBlocklist(p0 context.Context) ([]cid.Cid
 	return *new([]cid.Cid), ErrNotSupported
 }
 
+func (s *StorageMinerStruct) DealsRetrievalPricingPolicy(p0 context.Context) (dtypes.RetrievalPricingPolicy, error) {
+	if s.Internal.DealsRetrievalPricingPolicy == nil {
+		return *new(dtypes.RetrievalPricingPolicy), ErrNotSupported
+	}
+	return s.Internal.DealsRetrievalPricingPolicy(p0)
+}
+
+func (s *StorageMinerStub) DealsRetrievalPricingPolicy(p0 context.Context) (dtypes.RetrievalPricingPolicy, error) {
+	return *new(dtypes.RetrievalPricingPolicy), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) DealsSetConsiderOfflineRetrievalDeals(p0 context.Context, p1 bool) error {
 	if s.Internal.DealsSetConsiderOfflineRetrievalDeals == nil {
 		return ErrNotSupported
@@ -4262,6 +4936,17 @@ func (s *StorageMinerStub) DealsSetPieceCidBlocklist(p0 context.Context, p1 []ci
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) DealsSetRetrievalPricingPolicy(p0 context.Context, p1 dtypes.RetrievalPricingPolicy) error {
+	if s.Internal.DealsSetRetrievalPricingPolicy == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.DealsSetRetrievalPricingPolicy(p0, p1)
+}
+
+func (s *StorageMinerStub) DealsSetRetrievalPricingPolicy(p0 context.Context, p1 dtypes.RetrievalPricingPolicy) error {
+	return ErrNotSupported
+}
+
 func (s *StorageMinerStruct) IndexerAnnounceAllDeals(p0 context.Context) error {
 	if s.Internal.IndexerAnnounceAllDeals == nil {
 		return ErrNotSupported
@@ -4306,6 +4991,17 @@ func (s *StorageMinerStub) MarketDataTransferDiagnostics(p0 context.Context, p1
 	return nil, ErrNotSupported
 }
 
+func (s *StorageMinerStruct) MarketDataTransferLimits(p0 context.Context) (DataTransferLimitsStatus, error) {
+	if s.Internal.MarketDataTransferLimits == nil {
+		return *new(DataTransferLimitsStatus), ErrNotSupported
+	}
+	return s.Internal.MarketDataTransferLimits(p0)
+}
+
+func (s *StorageMinerStub) MarketDataTransferLimits(p0 context.Context) (DataTransferLimitsStatus, error) {
+	return *new(DataTransferLimitsStatus), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) MarketDataTransferUpdates(p0 context.Context) (<-chan DataTransferChannel, error) {
 	if s.Internal.MarketDataTransferUpdates == nil {
 		return nil, ErrNotSupported
@@ -4339,6 +5035,17 @@ func (s *StorageMinerStub) MarketGetDealUpdates(p0 context.Context) (<-chan stor
 	return nil, ErrNotSupported
 }
 
+func (s *StorageMinerStruct) MarketGetPublishConfig(p0 context.Context) (DealPublishConfig, error) {
+	if s.Internal.MarketGetPublishConfig == nil {
+		return *new(DealPublishConfig), ErrNotSupported
+	}
+	return s.Internal.MarketGetPublishConfig(p0)
+}
+
+func (s *StorageMinerStub) MarketGetPublishConfig(p0 context.Context) (DealPublishConfig, error) {
+	return *new(DealPublishConfig), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) MarketGetRetrievalAsk(p0 context.Context) (*retrievalmarket.Ask, error) {
 	if s.Internal.MarketGetRetrievalAsk == nil {
 		return nil, ErrNotSupported
@@ -4416,6 +5123,28 @@ func (s *StorageMinerStub) MarketPendingDeals(p0 context.Context) (PendingDealIn
 	return *new(PendingDealInfo), ErrNotSupported
 }
 
+func (s *StorageMinerStruct) MarketPendingFilterDecisions(p0 context.Context) ([]PendingFilterDecision, error) {
+	if s.Internal.MarketPendingFilterDecisions == nil {
+		return *new([]PendingFilterDecision), ErrNotSupported
+	}
+	return s.Internal.MarketPendingFilterDecisions(p0)
+}
+
+func (s *StorageMinerStub) MarketPendingFilterDecisions(p0 context.Context) ([]PendingFilterDecision, error) {
+	return *new([]PendingFilterDecision), ErrNotSupported
+}
+
+func (s *StorageMinerStruct) MarketPublishPendingDeal(p0 context.Context, p1 cid.Cid) error {
+	if s.Internal.MarketPublishPendingDeal == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.MarketPublishPendingDeal(p0, p1)
+}
+
+func (s *StorageMinerStub) MarketPublishPendingDeal(p0 context.Context, p1 cid.Cid) error {
+	return ErrNotSupported
+}
+
 func (s *StorageMinerStruct) MarketPublishPendingDeals(p0 context.Context) error {
 	if s.Internal.MarketPublishPendingDeals == nil {
 		return ErrNotSupported
@@ -4427,6 +5156,17 @@ func (s *StorageMinerStub) MarketPublishPendingDeals(p0 context.Context) error {
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) MarketResolveFilterDecision(p0 context.Context, p1 cid.Cid, p2 bool, p3 string) error {
+	if s.Internal.MarketResolveFilterDecision == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.MarketResolveFilterDecision(p0, p1, p2, p3)
+}
+
+func (s *StorageMinerStub) MarketResolveFilterDecision(p0 context.Context, p1 cid.Cid, p2 bool, p3 string) error {
+	return ErrNotSupported
+}
+
 func (s *StorageMinerStruct) MarketRestartDataTransfer(p0 context.Context, p1 datatransfer.TransferID, p2 peer.ID, p3 bool) error {
 	if s.Internal.MarketRestartDataTransfer == nil {
 		return ErrNotSupported
@@ -4460,6 +5200,28 @@ func (s *StorageMinerStub) MarketSetAsk(p0 context.Context, p1 types.BigInt, p2
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) MarketSetDataTransferLimits(p0 context.Context, p1 DataTransferLimits) error {
+	if s.Internal.MarketSetDataTransferLimits == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.MarketSetDataTransferLimits(p0, p1)
+}
+
+func (s *StorageMinerStub) MarketSetDataTransferLimits(p0 context.Context, p1 DataTransferLimits) error {
+	return ErrNotSupported
+}
+
+func (s *StorageMinerStruct) MarketSetPublishConfig(p0 context.Context, p1 DealPublishConfig) error {
+	if s.Internal.MarketSetPublishConfig == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.MarketSetPublishConfig(p0, p1)
+}
+
+func (s *StorageMinerStub) MarketSetPublishConfig(p0 context.Context, p1 DealPublishConfig) error {
+	return ErrNotSupported
+}
+
 func (s *StorageMinerStruct) MarketSetRetrievalAsk(p0 context.Context, p1 *retrievalmarket.Ask) error {
 	if s.Internal.MarketSetRetrievalAsk == nil {
 		return ErrNotSupported
@@ -4471,6 +5233,28 @@ func (s *StorageMinerStub) MarketSetRetrievalAsk(p0 context.Context, p1 *retriev
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) MarketSubscribeDealEvents(p0 context.Context) (<-chan MarketDealEvent, error) {
+	if s.Internal.MarketSubscribeDealEvents == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MarketSubscribeDealEvents(p0)
+}
+
+func (s *StorageMinerStub) MarketSubscribeDealEvents(p0 context.Context) (<-chan MarketDealEvent, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *StorageMinerStruct) MinerEvents(p0 context.Context) (<-chan MinerEvent, error) {
+	if s.Internal.MinerEvents == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.MinerEvents(p0)
+}
+
+func (s *StorageMinerStub) MinerEvents(p0 context.Context) (<-chan MinerEvent, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *StorageMinerStruct) MiningBase(p0 context.Context) (*types.TipSet, error) {
 	if s.Internal.MiningBase == nil {
 		return nil, ErrNotSupported
@@ -4482,6 +5266,28 @@ func (s *StorageMinerStub) MiningBase(p0 context.Context) (*types.TipSet, error)
 	return nil, ErrNotSupported
 }
 
+func (s *StorageMinerStruct) NodeHealth(p0 context.Context) (NodeHealth, error) {
+	if s.Internal.NodeHealth == nil {
+		return *new(NodeHealth), ErrNotSupported
+	}
+	return s.Internal.NodeHealth(p0)
+}
+
+func (s *StorageMinerStub) NodeHealth(p0 context.Context) (NodeHealth, error) {
+	return *new(NodeHealth), ErrNotSupported
+}
+
+func (s *StorageMinerStruct) NotificationsTest(p0 context.Context, p1 string) error {
+	if s.Internal.NotificationsTest == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.NotificationsTest(p0, p1)
+}
+
+func (s *StorageMinerStub) NotificationsTest(p0 context.Context, p1 string) error {
+	return ErrNotSupported
+}
+
 func (s *StorageMinerStruct) PiecesGetCIDInfo(p0 context.Context, p1 cid.Cid) (*piecestore.CIDInfo, error) {
 	if s.Internal.PiecesGetCIDInfo == nil {
 		return nil, ErrNotSupported
@@ -4537,6 +5343,50 @@ func (s *StorageMinerStub) PledgeSector(p0 context.Context) (abi.SectorID, error
 	return *new(abi.SectorID), ErrNotSupported
 }
 
+func (s *StorageMinerStruct) ProvingDispute(p0 context.Context, p1 address.Address, p2 uint64, p3 uint64) (cid.Cid, error) {
+	if s.Internal.ProvingDispute == nil {
+		return *new(cid.Cid), ErrNotSupported
+	}
+	return s.Internal.ProvingDispute(p0, p1, p2, p3)
+}
+
+func (s *StorageMinerStub) ProvingDispute(p0 context.Context, p1 address.Address, p2 uint64, p3 uint64) (cid.Cid, error) {
+	return *new(cid.Cid), ErrNotSupported
+}
+
+func (s *StorageMinerStruct) ProvingDisputeCheck(p0 context.Context, p1 address.Address, p2 uint64) ([]DisputablePoSt, error) {
+	if s.Internal.ProvingDisputeCheck == nil {
+		return *new([]DisputablePoSt), ErrNotSupported
+	}
+	return s.Internal.ProvingDisputeCheck(p0, p1, p2)
+}
+
+func (s *StorageMinerStub) ProvingDisputeCheck(p0 context.Context, p1 address.Address, p2 uint64) ([]DisputablePoSt, error) {
+	return *new([]DisputablePoSt), ErrNotSupported
+}
+
+func (s *StorageMinerStruct) ProvingDisputeEvidence(p0 context.Context, p1 address.Address, p2 []abi.SectorNumber) error {
+	if s.Internal.ProvingDisputeEvidence == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ProvingDisputeEvidence(p0, p1, p2)
+}
+
+func (s *StorageMinerStub) ProvingDisputeEvidence(p0 context.Context, p1 address.Address, p2 []abi.SectorNumber) error {
+	return ErrNotSupported
+}
+
+func (s *StorageMinerStruct) ProvingDisputeStatus(p0 context.Context) ([]DisputablePoSt, error) {
+	if s.Internal.ProvingDisputeStatus == nil {
+		return *new([]DisputablePoSt), ErrNotSupported
+	}
+	return s.Internal.ProvingDisputeStatus(p0)
+}
+
+func (s *StorageMinerStub) ProvingDisputeStatus(p0 context.Context) ([]DisputablePoSt, error) {
+	return *new([]DisputablePoSt), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) ReturnAddPiece(p0 context.Context, p1 storiface.CallID, p2 abi.PieceInfo, p3 *storiface.CallError) error {
 	if s.Internal.ReturnAddPiece == nil {
 		return ErrNotSupported
@@ -4757,6 +5607,61 @@ func (s *StorageMinerStub) SealingSchedDiag(p0 context.Context, p1 bool) (interf
 	return nil, ErrNotSupported
 }
 
+func (s *StorageMinerStruct) SealingSchedPriorities(p0 context.Context) (storiface.SchedPriorities, error) {
+	if s.Internal.SealingSchedPriorities == nil {
+		return *new(storiface.SchedPriorities), ErrNotSupported
+	}
+	return s.Internal.SealingSchedPriorities(p0)
+}
+
+func (s *StorageMinerStub) SealingSchedPriorities(p0 context.Context) (storiface.SchedPriorities, error) {
+	return *new(storiface.SchedPriorities), ErrNotSupported
+}
+
+func (s *StorageMinerStruct) SealingSetSchedPriorities(p0 context.Context, p1 storiface.SchedPriorities) error {
+	if s.Internal.SealingSetSchedPriorities == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SealingSetSchedPriorities(p0, p1)
+}
+
+func (s *StorageMinerStub) SealingSetSchedPriorities(p0 context.Context, p1 storiface.SchedPriorities) error {
+	return ErrNotSupported
+}
+
+func (s *StorageMinerStruct) SealingWorkersBootstrapToken(p0 context.Context, p1 string, p2 time.Duration) (string, error) {
+	if s.Internal.SealingWorkersBootstrapToken == nil {
+		return "", ErrNotSupported
+	}
+	return s.Internal.SealingWorkersBootstrapToken(p0, p1, p2)
+}
+
+func (s *StorageMinerStub) SealingWorkersBootstrapToken(p0 context.Context, p1 string, p2 time.Duration) (string, error) {
+	return "", ErrNotSupported
+}
+
+func (s *StorageMinerStruct) SealingWorkersTag(p0 context.Context, p1 string, p2 []string) error {
+	if s.Internal.SealingWorkersTag == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SealingWorkersTag(p0, p1, p2)
+}
+
+func (s *StorageMinerStub) SealingWorkersTag(p0 context.Context, p1 string, p2 []string) error {
+	return ErrNotSupported
+}
+
+func (s *StorageMinerStruct) SealingWorkersUntag(p0 context.Context, p1 string, p2 []string) error {
+	if s.Internal.SealingWorkersUntag == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SealingWorkersUntag(p0, p1, p2)
+}
+
+func (s *StorageMinerStub) SealingWorkersUntag(p0 context.Context, p1 string, p2 []string) error {
+	return ErrNotSupported
+}
+
 func (s *StorageMinerStruct) SectorAbortUpgrade(p0 context.Context, p1 abi.SectorNumber) error {
 	if s.Internal.SectorAbortUpgrade == nil {
 		return ErrNotSupported
@@ -4779,6 +5684,17 @@ func (s *StorageMinerStub) SectorAddPieceToAny(p0 context.Context, p1 abi.Unpadd
 	return *new(SectorOffset), ErrNotSupported
 }
 
+func (s *StorageMinerStruct) SectorCommitBatchPlan(p0 context.Context) (sealiface.BatchPlan, error) {
+	if s.Internal.SectorCommitBatchPlan == nil {
+		return *new(sealiface.BatchPlan), ErrNotSupported
+	}
+	return s.Internal.SectorCommitBatchPlan(p0)
+}
+
+func (s *StorageMinerStub) SectorCommitBatchPlan(p0 context.Context) (sealiface.BatchPlan, error) {
+	return *new(sealiface.BatchPlan), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) SectorCommitFlush(p0 context.Context) ([]sealiface.CommitBatchRes, error) {
 	if s.Internal.SectorCommitFlush == nil {
 		return *new([]sealiface.CommitBatchRes), ErrNotSupported
@@ -4801,6 +5717,17 @@ func (s *StorageMinerStub) SectorCommitPending(p0 context.Context) ([]abi.Sector
 	return *new([]abi.SectorID), ErrNotSupported
 }
 
+func (s *StorageMinerStruct) SectorCommitSetBatchMode(p0 context.Context, p1 sealiface.BatchMode) error {
+	if s.Internal.SectorCommitSetBatchMode == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SectorCommitSetBatchMode(p0, p1)
+}
+
+func (s *StorageMinerStub) SectorCommitSetBatchMode(p0 context.Context, p1 sealiface.BatchMode) error {
+	return ErrNotSupported
+}
+
 func (s *StorageMinerStruct) SectorGetExpectedSealDuration(p0 context.Context) (time.Duration, error) {
 	if s.Internal.SectorGetExpectedSealDuration == nil {
 		return *new(time.Duration), ErrNotSupported
@@ -4845,6 +5772,17 @@ func (s *StorageMinerStub) SectorMatchPendingPiecesToOpenSectors(p0 context.Cont
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) SectorPreCommitBatchPlan(p0 context.Context) (sealiface.BatchPlan, error) {
+	if s.Internal.SectorPreCommitBatchPlan == nil {
+		return *new(sealiface.BatchPlan), ErrNotSupported
+	}
+	return s.Internal.SectorPreCommitBatchPlan(p0)
+}
+
+func (s *StorageMinerStub) SectorPreCommitBatchPlan(p0 context.Context) (sealiface.BatchPlan, error) {
+	return *new(sealiface.BatchPlan), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) SectorPreCommitFlush(p0 context.Context) ([]sealiface.PreCommitBatchRes, error) {
 	if s.Internal.SectorPreCommitFlush == nil {
 		return *new([]sealiface.PreCommitBatchRes), ErrNotSupported
@@ -4867,6 +5805,17 @@ func (s *StorageMinerStub) SectorPreCommitPending(p0 context.Context) ([]abi.Sec
 	return *new([]abi.SectorID), ErrNotSupported
 }
 
+func (s *StorageMinerStruct) SectorPreCommitSetBatchMode(p0 context.Context, p1 sealiface.BatchMode) error {
+	if s.Internal.SectorPreCommitSetBatchMode == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SectorPreCommitSetBatchMode(p0, p1)
+}
+
+func (s *StorageMinerStub) SectorPreCommitSetBatchMode(p0 context.Context, p1 sealiface.BatchMode) error {
+	return ErrNotSupported
+}
+
 func (s *StorageMinerStruct) SectorRemove(p0 context.Context, p1 abi.SectorNumber) error {
 	if s.Internal.SectorRemove == nil {
 		return ErrNotSupported
@@ -4900,6 +5849,17 @@ func (s *StorageMinerStub) SectorSetSealDelay(p0 context.Context, p1 time.Durati
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) SectorSnapRetry(p0 context.Context, p1 abi.SectorNumber) error {
+	if s.Internal.SectorSnapRetry == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SectorSnapRetry(p0, p1)
+}
+
+func (s *StorageMinerStub) SectorSnapRetry(p0 context.Context, p1 abi.SectorNumber) error {
+	return ErrNotSupported
+}
+
 func (s *StorageMinerStruct) SectorStartSealing(p0 context.Context, p1 abi.SectorNumber) error {
 	if s.Internal.SectorStartSealing == nil {
 		return ErrNotSupported
@@ -4922,6 +5882,17 @@ func (s *StorageMinerStub) SectorTerminate(p0 context.Context, p1 abi.SectorNumb
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) SectorTerminateEstimate(p0 context.Context, p1 []abi.SectorNumber, p2 abi.ChainEpoch) (*TerminateEstimate, error) {
+	if s.Internal.SectorTerminateEstimate == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.SectorTerminateEstimate(p0, p1, p2)
+}
+
+func (s *StorageMinerStub) SectorTerminateEstimate(p0 context.Context, p1 []abi.SectorNumber, p2 abi.ChainEpoch) (*TerminateEstimate, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *StorageMinerStruct) SectorTerminateFlush(p0 context.Context) (*cid.Cid, error) {
 	if s.Internal.SectorTerminateFlush == nil {
 		return nil, ErrNotSupported
@@ -4966,6 +5937,17 @@ func (s *StorageMinerStub) SectorsListInStates(p0 context.Context, p1 []SectorSt
 	return *new([]abi.SectorNumber), ErrNotSupported
 }
 
+func (s *StorageMinerStruct) SectorsPackingStatus(p0 context.Context) (DealPackingStatus, error) {
+	if s.Internal.SectorsPackingStatus == nil {
+		return *new(DealPackingStatus), ErrNotSupported
+	}
+	return s.Internal.SectorsPackingStatus(p0)
+}
+
+func (s *StorageMinerStub) SectorsPackingStatus(p0 context.Context) (DealPackingStatus, error) {
+	return *new(DealPackingStatus), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) SectorsRefs(p0 context.Context) (map[string][]SealedRef, error) {
 	if s.Internal.SectorsRefs == nil {
 		return *new(map[string][]SealedRef), ErrNotSupported
@@ -4977,6 +5959,17 @@ func (s *StorageMinerStub) SectorsRefs(p0 context.Context) (map[string][]SealedR
 	return *new(map[string][]SealedRef), ErrNotSupported
 }
 
+func (s *StorageMinerStruct) SectorsSnapList(p0 context.Context) ([]SnapSectorInfo, error) {
+	if s.Internal.SectorsSnapList == nil {
+		return *new([]SnapSectorInfo), ErrNotSupported
+	}
+	return s.Internal.SectorsSnapList(p0)
+}
+
+func (s *StorageMinerStub) SectorsSnapList(p0 context.Context) ([]SnapSectorInfo, error) {
+	return *new([]SnapSectorInfo), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) SectorsStatus(p0 context.Context, p1 abi.SectorNumber, p2 bool) (SectorInfo, error) {
 	if s.Internal.SectorsStatus == nil {
 		return *new(SectorInfo), ErrNotSupported
@@ -5032,6 +6025,17 @@ func (s *StorageMinerStub) StorageAddLocal(p0 context.Context, p1 string) error
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) StorageAtRiskSectors(p0 context.Context) ([]storiface.AtRiskSector, error) {
+	if s.Internal.StorageAtRiskSectors == nil {
+		return *new([]storiface.AtRiskSector), ErrNotSupported
+	}
+	return s.Internal.StorageAtRiskSectors(p0)
+}
+
+func (s *StorageMinerStub) StorageAtRiskSectors(p0 context.Context) ([]storiface.AtRiskSector, error) {
+	return *new([]storiface.AtRiskSector), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) StorageAttach(p0 context.Context, p1 storiface.StorageInfo, p2 fsutil.FsStat) error {
 	if s.Internal.StorageAttach == nil {
 		return ErrNotSupported
@@ -5109,6 +6113,17 @@ func (s *StorageMinerStub) StorageGetLocks(p0 context.Context) (storiface.Sector
 	return *new(storiface.SectorLocks), ErrNotSupported
 }
 
+func (s *StorageMinerStruct) StorageHealth(p0 context.Context) ([]storiface.PathHealth, error) {
+	if s.Internal.StorageHealth == nil {
+		return *new([]storiface.PathHealth), ErrNotSupported
+	}
+	return s.Internal.StorageHealth(p0)
+}
+
+func (s *StorageMinerStub) StorageHealth(p0 context.Context) ([]storiface.PathHealth, error) {
+	return *new([]storiface.PathHealth), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) StorageInfo(p0 context.Context, p1 storiface.ID) (storiface.StorageInfo, error) {
 	if s.Internal.StorageInfo == nil {
 		return *new(storiface.StorageInfo), ErrNotSupported
@@ -5153,6 +6168,28 @@ func (s *StorageMinerStub) StorageLock(p0 context.Context, p1 abi.SectorID, p2 s
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) StorageRebalance(p0 context.Context, p1 bool) ([]storiface.RebalanceMove, error) {
+	if s.Internal.StorageRebalance == nil {
+		return *new([]storiface.RebalanceMove), ErrNotSupported
+	}
+	return s.Internal.StorageRebalance(p0, p1)
+}
+
+func (s *StorageMinerStub) StorageRebalance(p0 context.Context, p1 bool) ([]storiface.RebalanceMove, error) {
+	return *new([]storiface.RebalanceMove), ErrNotSupported
+}
+
+func (s *StorageMinerStruct) StorageRebalanceStatus(p0 context.Context) (storiface.RebalanceStatus, error) {
+	if s.Internal.StorageRebalanceStatus == nil {
+		return *new(storiface.RebalanceStatus), ErrNotSupported
+	}
+	return s.Internal.StorageRebalanceStatus(p0)
+}
+
+func (s *StorageMinerStub) StorageRebalanceStatus(p0 context.Context) (storiface.RebalanceStatus, error) {
+	return *new(storiface.RebalanceStatus), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) StorageReportHealth(p0 context.Context, p1 storiface.ID, p2 storiface.HealthReport) error {
 	if s.Internal.StorageReportHealth == nil {
 		return ErrNotSupported
@@ -5164,6 +6201,17 @@ func (s *StorageMinerStub) StorageReportHealth(p0 context.Context, p1 storiface.
 	return ErrNotSupported
 }
 
+func (s *StorageMinerStruct) StorageSetDrain(p0 context.Context, p1 storiface.ID, p2 bool) error {
+	if s.Internal.StorageSetDrain == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.StorageSetDrain(p0, p1, p2)
+}
+
+func (s *StorageMinerStub) StorageSetDrain(p0 context.Context, p1 storiface.ID, p2 bool) error {
+	return ErrNotSupported
+}
+
 func (s *StorageMinerStruct) StorageStat(p0 context.Context, p1 storiface.ID) (fsutil.FsStat, error) {
 	if s.Internal.StorageStat == nil {
 		return *new(fsutil.FsStat), ErrNotSupported
@@ -5186,6 +6234,50 @@ func (s *StorageMinerStub) StorageTryLock(p0 context.Context, p1 abi.SectorID, p
 	return false, ErrNotSupported
 }
 
+func (s *StorageMinerStruct) StorageUnsealQueue(p0 context.Context) (storiface.UnsealQueue, error) {
+	if s.Internal.StorageUnsealQueue == nil {
+		return *new(storiface.UnsealQueue), ErrNotSupported
+	}
+	return s.Internal.StorageUnsealQueue(p0)
+}
+
+func (s *StorageMinerStub) StorageUnsealQueue(p0 context.Context) (storiface.UnsealQueue, error) {
+	return *new(storiface.UnsealQueue), ErrNotSupported
+}
+
+func (s *StorageMinerStruct) WindowPoStDryRun(p0 context.Context, p1 uint64) (WdPoStDryRun, error) {
+	if s.Internal.WindowPoStDryRun == nil {
+		return *new(WdPoStDryRun), ErrNotSupported
+	}
+	return s.Internal.WindowPoStDryRun(p0, p1)
+}
+
+func (s *StorageMinerStub) WindowPoStDryRun(p0 context.Context, p1 uint64) (WdPoStDryRun, error) {
+	return *new(WdPoStDryRun), ErrNotSupported
+}
+
+func (s *StorageMinerStruct) WindowPoStSenderStatus(p0 context.Context) ([]WdPoStMessage, error) {
+	if s.Internal.WindowPoStSenderStatus == nil {
+		return *new([]WdPoStMessage), ErrNotSupported
+	}
+	return s.Internal.WindowPoStSenderStatus(p0)
+}
+
+func (s *StorageMinerStub) WindowPoStSenderStatus(p0 context.Context) ([]WdPoStMessage, error) {
+	return *new([]WdPoStMessage), ErrNotSupported
+}
+
+func (s *StorageMinerStruct) WinningPoStHistory(p0 context.Context) ([]WinningPoStRecord, error) {
+	if s.Internal.WinningPoStHistory == nil {
+		return *new([]WinningPoStRecord), ErrNotSupported
+	}
+	return s.Internal.WinningPoStHistory(p0)
+}
+
+func (s *StorageMinerStub) WinningPoStHistory(p0 context.Context) ([]WinningPoStRecord, error) {
+	return *new([]WinningPoStRecord), ErrNotSupported
+}
+
 func (s *StorageMinerStruct) WorkerConnect(p0 context.Context, p1 string) error {
 	if s.Internal.WorkerConnect == nil {
 		return ErrNotSupported
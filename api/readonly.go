@@ -0,0 +1,57 @@
+package api
+
+import (
+	"reflect"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-jsonrpc/auth"
+)
+
+// ErrReadOnly is returned by the methods needing more than the read permission
+// on a read-only API
+var ErrReadOnly = xerrors.New("the API is read-only")
+
+func readOnlyProxies(in, out interface{}) {
+	outs := GetInternalStructs(out)
+	for _, o := range outs {
+		readOnlyProxy(in, o)
+	}
+}
+
+// readOnlyProxy sets the methods of the internal struct out to the methods of
+// in when they only need the read permission, and to methods returning
+// ErrReadOnly otherwise
+func readOnlyProxy(in, out interface{}) {
+	rint := reflect.ValueOf(out).Elem()
+	ra := reflect.ValueOf(in)
+
+	for f := 0; f < rint.NumField(); f++ {
+		field := rint.Type().Field(f)
+
+		if auth.Permission(field.Tag.Get("perm")) == PermRead {
+			rint.Field(f).Set(ra.MethodByName(field.Name))
+			continue
+		}
+
+		name, ft := field.Name, field.Type
+		rint.Field(f).Set(reflect.MakeFunc(ft, func(args []reflect.Value) []reflect.Value {
+			err := xerrors.Errorf("can't invoke '%s': %w", name, ErrReadOnly)
+			rerr := reflect.ValueOf(&err).Elem()
+
+			if ft.NumOut() == 2 {
+				return []reflect.Value{reflect.Zero(ft.Out(0)), rerr}
+			}
+			return []reflect.Value{rerr}
+		}))
+	}
+}
+
+// ReadOnlyFullAPI returns a full node API rejecting all the methods which need
+// more than the read permission, such as MpoolPush, WalletSign or
+// MarketAddBalance, regardless of the permissions of the caller
+func ReadOnlyFullAPI(a FullNode) FullNode {
+	var out FullNodeStruct
+	readOnlyProxies(a, &out)
+	return &out
+}
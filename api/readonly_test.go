@@ -0,0 +1,49 @@
+//stm: #unit
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-jsonrpc/auth"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestReadOnlyFullAPI(t *testing.T) {
+	ctx := auth.WithPerm(context.Background(), AllPermissions)
+
+	var in FullNodeStruct
+	in.Internal.ChainHead = func(context.Context) (*types.TipSet, error) {
+		return nil, nil
+	}
+	in.Internal.MpoolPush = func(context.Context, *types.SignedMessage) (c cid.Cid, err error) {
+		t.Fatal("MpoolPush called")
+		return
+	}
+
+	ro := ReadOnlyFullAPI(PermissionedFullAPI(&in))
+
+	_, err := ro.ChainHead(ctx)
+	require.NoError(t, err)
+
+	// methods only needing the read permission reach the node
+	_, err = ro.StateNetworkName(ctx)
+	require.True(t, xerrors.Is(err, ErrNotSupported))
+
+	_, err = ro.MpoolPush(ctx, &types.SignedMessage{})
+	require.True(t, xerrors.Is(err, ErrReadOnly))
+	_, err = ro.WalletSign(ctx, address.Undef, nil)
+	require.True(t, xerrors.Is(err, ErrReadOnly))
+	_, err = ro.MarketAddBalance(ctx, address.Undef, address.Undef, types.NewInt(1))
+	require.True(t, xerrors.Is(err, ErrReadOnly))
+
+	// including the methods of the embedded APIs
+	_, err = ro.AuthNew(ctx, AllPermissions)
+	require.True(t, xerrors.Is(err, ErrReadOnly))
+}
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-graphsync"
+	"github.com/libp2p/go-libp2p-core/metrics"
 	"github.com/libp2p/go-libp2p-core/network"
 	"github.com/libp2p/go-libp2p-core/peer"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
@@ -15,8 +17,10 @@ import (
 	"github.com/filecoin-project/go-address"
 	datatransfer "github.com/filecoin-project/go-data-transfer"
 	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
+	"github.com/filecoin-project/go-jsonrpc/auth"
 	"github.com/filecoin-project/go-state-types/abi"
 
+	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 )
@@ -54,6 +58,31 @@ type PubsubScore struct {
 
 type MessageSendSpec struct {
 	MaxFee abi.TokenAmount
+
+	// MsgConfidence overrides the confidence the sender waits for the message
+	// with; build.MessageConfidence applies when 0
+	MsgConfidence uint64
+	// MsgLookbackLimit overrides how far back the sender searches the chain
+	// for the message when waiting for it; there is no limit when 0
+	MsgLookbackLimit abi.ChainEpoch
+}
+
+// WaitParams returns the confidence and lookback limit to pass to StateWaitMsg
+// when waiting for a message sent with the spec. The defaults are resolved
+// here rather than by the node, so that they are understood by nodes which
+// predate ConfidenceDefault and LookbackFinality.
+func (ms *MessageSendSpec) WaitParams() (uint64, abi.ChainEpoch) {
+	confidence, limit := build.MessageConfidence, LookbackNoLimit
+	if ms == nil {
+		return confidence, limit
+	}
+	if ms.MsgConfidence != 0 {
+		confidence = ms.MsgConfidence
+	}
+	if ms.MsgLookbackLimit != 0 {
+		limit = ms.MsgLookbackLimit
+	}
+	return confidence, limit
 }
 
 // GraphSyncDataTransfer provides diagnostics on a data transfer happening over graphsync
@@ -168,6 +197,66 @@ type ConnMgrInfo struct {
 	Conns     map[string]time.Time
 }
 
+type ProtocolGroupBandwidth struct {
+	metrics.Stats
+
+	// Ceiling is the rate in bytes per second the group is throttled to while the
+	// bandwidth of the node exceeds the cap; 0 if the group isn't limited
+	Ceiling int64
+	// Throttled is set when the group has a ceiling and the node is above the cap
+	Throttled bool
+	// Delay is the total time reads and writes of the group were delayed
+	Delay time.Duration
+}
+
+type PeerInfoDetailed struct {
+	ExtendedPeerInfo
+
+	Connected bool
+	// Protected is set when the peer was protected with NetProtectAdd, Allowed
+	// when the peer or one of its addresses is in the allow list
+	Protected bool
+	Allowed   bool
+
+	// PubsubScore and Propagation are nil if the peer isn't a pubsub peer
+	PubsubScore *pubsub.PeerScoreSnapshot
+	Propagation *PeerPropagationStats
+
+	// Bitswap is nil if the node doesn't run bitswap
+	Bitswap *BitswapLedger
+}
+
+// PeerPropagationStats are the stats of the blocks and messages received from a
+// peer over pubsub since it joined
+type PeerPropagationStats struct {
+	Blocks   PubsubTopicStats
+	Messages PubsubTopicStats
+}
+
+type PubsubTopicStats struct {
+	// FirstDeliveries is the number of valid pubsub messages first received from
+	// the peer, LastDelivery the time of the last one
+	FirstDeliveries int64
+	LastDelivery    time.Time
+	// Duplicates is the number of pubsub messages received from the peer after
+	// another peer delivered them
+	Duplicates int64
+	// Rejected and Ignored are the numbers of pubsub messages from the peer which
+	// failed validation, or were ignored by it
+	Rejected int64
+	Ignored  int64
+}
+
+type BitswapLedger struct {
+	// Value is the ratio of the bytes sent to the peer and received from it
+	Value float64
+	// Sent and Recv are the bytes of blocks sent to and received from the peer
+	Sent uint64
+	Recv uint64
+	// Exchanged is the number of blocks exchanged with the peer
+	Exchanged uint64
+}
+
 type NodeStatus struct {
 	SyncStatus  NodeSyncStatus
 	PeerStatus  NodePeerStatus
@@ -189,6 +278,194 @@ type NodeChainStatus struct {
 	BlocksPerTipsetLastFinality float64
 }
 
+// ConfigChange is a single difference between the running and the reloaded config
+type ConfigChange struct {
+	// Key is the dotted config path of the setting, eg. Fees.MaxWindowPoStGasFee
+	Key string
+	Old string
+	New string
+}
+
+// ConfigReloadResult reports the changes found by a config reload
+type ConfigReloadResult struct {
+	// Applied is set when the changes were applied
+	Applied bool
+	// Changes are the changes of the settings which don't require a restart
+	Changes []ConfigChange
+	// Rejected are the changes which require a restart of the node
+	Rejected []ConfigChange
+}
+
+// LogEntry is a log entry streamed by LogTail
+type LogEntry struct {
+	Time      time.Time
+	Level     string
+	Subsystem string
+	Caller    string
+	Message   string
+	// Fields are the structured fields of the entry
+	Fields map[string]interface{} `json:",omitempty"`
+}
+
+// APIToken describes an API token created with AuthTokenCreate
+type APIToken struct {
+	ID    uuid.UUID
+	Label string
+	// Scope is the highest permission of the token
+	Scope   auth.Permission
+	Created time.Time
+	// Expiry is zero for the tokens which don't expire
+	Expiry time.Time
+}
+
+// NewAPIToken is an API token returned by AuthTokenCreate
+type NewAPIToken struct {
+	Info  APIToken
+	Token string
+}
+
+// HealthLevel is the level of the health of a node, or of one of its
+// subsystems
+type HealthLevel string
+
+const (
+	HealthOK   HealthLevel = "OK"
+	HealthWarn HealthLevel = "WARN"
+	HealthCrit HealthLevel = "CRIT"
+)
+
+func (l HealthLevel) rank() int {
+	switch l {
+	case HealthOK:
+		return 0
+	case HealthWarn:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Worse returns the worse of the two levels
+func (l HealthLevel) Worse(o HealthLevel) HealthLevel {
+	if o.rank() > l.rank() {
+		return o
+	}
+	return l
+}
+
+// HealthStatus is the level of a subsystem, with a message explaining it
+// when the level isn't OK
+type HealthStatus struct {
+	Level   HealthLevel
+	Message string `json:",omitempty"`
+}
+
+// NodeHealth is the health of the subsystems of a node. Subsystems which
+// don't apply to the node are nil, and the level of the node is the worst
+// level of its subsystems.
+type NodeHealth struct {
+	Level HealthLevel
+
+	Sync       *SyncHealth
+	Mpool      *MpoolHealth
+	Blockstore *BlockstoreHealth
+	Peers      *PeersHealth
+
+	// miner subsystems
+	Workers  *WorkersHealth
+	Deadline *DeadlineHealth
+}
+
+// Compute sets the level of the node from the levels of its subsystems
+func (h *NodeHealth) Compute() {
+	var statuses []HealthStatus
+	if h.Sync != nil {
+		statuses = append(statuses, h.Sync.HealthStatus)
+	}
+	if h.Mpool != nil {
+		statuses = append(statuses, h.Mpool.HealthStatus)
+	}
+	if h.Blockstore != nil {
+		statuses = append(statuses, h.Blockstore.HealthStatus)
+	}
+	if h.Peers != nil {
+		statuses = append(statuses, h.Peers.HealthStatus)
+	}
+	if h.Workers != nil {
+		statuses = append(statuses, h.Workers.HealthStatus)
+	}
+	if h.Deadline != nil {
+		statuses = append(statuses, h.Deadline.HealthStatus)
+	}
+
+	h.Level = HealthOK
+	for _, s := range statuses {
+		h.Level = h.Level.Worse(s.Level)
+	}
+}
+
+type SyncHealth struct {
+	HealthStatus
+
+	Height abi.ChainEpoch
+	// LagEpochs is the number of epochs the head is behind the wall clock
+	LagEpochs int64
+}
+
+type MpoolHealth struct {
+	HealthStatus
+
+	// LocalPending is the number of messages pushed by the node which aren't
+	// included yet
+	LocalPending int
+	// StuckLocal is the number of those pending for longer than expected
+	StuckLocal int
+	// OldestLocal is how long the oldest of those has been pending
+	OldestLocal time.Duration
+}
+
+type BlockstoreHealth struct {
+	HealthStatus
+
+	// Available and Capacity are the bytes of the filesystem of the repo
+	Available int64
+	Capacity  int64
+
+	// LastGC is the end of the last blockstore GC run, zero if none ran yet
+	LastGC time.Time
+	// GCError is the error of the last GC run
+	GCError string `json:",omitempty"`
+}
+
+type PeersHealth struct {
+	HealthStatus
+
+	Connected int
+}
+
+type WorkersHealth struct {
+	HealthStatus
+
+	Workers  int
+	Disabled int
+}
+
+type DeadlineHealth struct {
+	HealthStatus
+
+	// Index, Open and Close describe the current deadline
+	Index uint64
+	Open  abi.ChainEpoch
+	Close abi.ChainEpoch
+
+	// Partitions is the number of partitions with live sectors in the
+	// deadline, and Proven the number of those already proven
+	Partitions int
+	Proven     int
+
+	FaultySectors uint64
+}
+
 type CheckStatusCode int
 
 //go:generate go run golang.org/x/tools/cmd/stringer -type=CheckStatusCode -trimprefix=CheckStatus
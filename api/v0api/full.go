@@ -160,6 +160,14 @@ type FullNode interface {
 	// If oldmsgskip is set, messages from before the requested roots are also not included.
 	ChainExport(ctx context.Context, nroots abi.ChainEpoch, oldmsgskip bool, tsk types.TipSetKey) (<-chan []byte, error) //perm:read
 
+	// ChainExportLatestState returns a stream of bytes with a CAR dump of the
+	// latest state of the chain, which a node can boot from without the chain
+	// history. The exported chain data only includes the headers of the most
+	// recent 'nheaders' epochs, and the state trees and messages of the last
+	// finality, needed by consensus lookbacks. It doesn't include the genesis,
+	// nor message receipts.
+	ChainExportLatestState(ctx context.Context, nheaders abi.ChainEpoch, tsk types.TipSetKey) (<-chan []byte, error) //perm:read
+
 	// MethodGroup: Beacon
 	// The Beacon method group contains methods for interacting with the random beacon (DRAND)
 
@@ -45,6 +45,8 @@ type FullNodeStruct struct {
 
 		ChainExport func(p0 context.Context, p1 abi.ChainEpoch, p2 bool, p3 types.TipSetKey) (<-chan []byte, error) `perm:"read"`
 
+		ChainExportLatestState func(p0 context.Context, p1 abi.ChainEpoch, p2 types.TipSetKey) (<-chan []byte, error) `perm:"read"`
+
 		ChainGetBlock func(p0 context.Context, p1 cid.Cid) (*types.BlockHeader, error) `perm:"read"`
 
 		ChainGetBlockMessages func(p0 context.Context, p1 cid.Cid) (*api.BlockMessages, error) `perm:"read"`
@@ -515,6 +517,17 @@ func (s *FullNodeStub) ChainExport(p0 context.Context, p1 abi.ChainEpoch, p2 boo
 	return nil, ErrNotSupported
 }
 
+func (s *FullNodeStruct) ChainExportLatestState(p0 context.Context, p1 abi.ChainEpoch, p2 types.TipSetKey) (<-chan []byte, error) {
+	if s.Internal.ChainExportLatestState == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ChainExportLatestState(p0, p1, p2)
+}
+
+func (s *FullNodeStub) ChainExportLatestState(p0 context.Context, p1 abi.ChainEpoch, p2 types.TipSetKey) (<-chan []byte, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *FullNodeStruct) ChainGetBlock(p0 context.Context, p1 cid.Cid) (*types.BlockHeader, error) {
 	if s.Internal.ChainGetBlock == nil {
 		return nil, ErrNotSupported
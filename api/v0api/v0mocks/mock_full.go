@@ -81,6 +81,50 @@ func (mr *MockFullNodeMockRecorder) AuthNew(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthNew", reflect.TypeOf((*MockFullNode)(nil).AuthNew), arg0, arg1)
 }
 
+// AuthTokenCreate mocks base method.
+func (m *MockFullNode) AuthTokenCreate(arg0 context.Context, arg1 auth.Permission, arg2 string, arg3 time.Duration) (api.NewAPIToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthTokenCreate", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(api.NewAPIToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthTokenCreate indicates an expected call of AuthTokenCreate.
+func (mr *MockFullNodeMockRecorder) AuthTokenCreate(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthTokenCreate", reflect.TypeOf((*MockFullNode)(nil).AuthTokenCreate), arg0, arg1, arg2, arg3)
+}
+
+// AuthTokenList mocks base method.
+func (m *MockFullNode) AuthTokenList(arg0 context.Context) ([]api.APIToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthTokenList", arg0)
+	ret0, _ := ret[0].([]api.APIToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthTokenList indicates an expected call of AuthTokenList.
+func (mr *MockFullNodeMockRecorder) AuthTokenList(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthTokenList", reflect.TypeOf((*MockFullNode)(nil).AuthTokenList), arg0)
+}
+
+// AuthTokenRevoke mocks base method.
+func (m *MockFullNode) AuthTokenRevoke(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthTokenRevoke", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthTokenRevoke indicates an expected call of AuthTokenRevoke.
+func (mr *MockFullNodeMockRecorder) AuthTokenRevoke(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthTokenRevoke", reflect.TypeOf((*MockFullNode)(nil).AuthTokenRevoke), arg0, arg1)
+}
+
 // AuthVerify mocks base method.
 func (m *MockFullNode) AuthVerify(arg0 context.Context, arg1 string) ([]auth.Permission, error) {
 	m.ctrl.T.Helper()
@@ -140,6 +184,21 @@ func (mr *MockFullNodeMockRecorder) ChainExport(arg0, arg1, arg2, arg3 interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainExport", reflect.TypeOf((*MockFullNode)(nil).ChainExport), arg0, arg1, arg2, arg3)
 }
 
+// ChainExportLatestState mocks base method.
+func (m *MockFullNode) ChainExportLatestState(arg0 context.Context, arg1 abi.ChainEpoch, arg2 types.TipSetKey) (<-chan []byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChainExportLatestState", arg0, arg1, arg2)
+	ret0, _ := ret[0].(<-chan []byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChainExportLatestState indicates an expected call of ChainExportLatestState.
+func (mr *MockFullNodeMockRecorder) ChainExportLatestState(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainExportLatestState", reflect.TypeOf((*MockFullNode)(nil).ChainExportLatestState), arg0, arg1, arg2)
+}
+
 // ChainGetBlock mocks base method.
 func (m *MockFullNode) ChainGetBlock(arg0 context.Context, arg1 cid.Cid) (*types.BlockHeader, error) {
 	m.ctrl.T.Helper()
@@ -866,6 +925,21 @@ func (mr *MockFullNodeMockRecorder) Closing(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Closing", reflect.TypeOf((*MockFullNode)(nil).Closing), arg0)
 }
 
+// ConfigReload mocks base method.
+func (m *MockFullNode) ConfigReload(arg0 context.Context) (api.ConfigReloadResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfigReload", arg0)
+	ret0, _ := ret[0].(api.ConfigReloadResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfigReload indicates an expected call of ConfigReload.
+func (mr *MockFullNodeMockRecorder) ConfigReload(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfigReload", reflect.TypeOf((*MockFullNode)(nil).ConfigReload), arg0)
+}
+
 // CreateBackup mocks base method.
 func (m *MockFullNode) CreateBackup(arg0 context.Context, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -1000,6 +1074,20 @@ func (mr *MockFullNodeMockRecorder) LogList(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogList", reflect.TypeOf((*MockFullNode)(nil).LogList), arg0)
 }
 
+// LogPersistLevel mocks base method.
+func (m *MockFullNode) LogPersistLevel(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogPersistLevel", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogPersistLevel indicates an expected call of LogPersistLevel.
+func (mr *MockFullNodeMockRecorder) LogPersistLevel(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogPersistLevel", reflect.TypeOf((*MockFullNode)(nil).LogPersistLevel), arg0, arg1, arg2)
+}
+
 // LogSetLevel mocks base method.
 func (m *MockFullNode) LogSetLevel(arg0 context.Context, arg1, arg2 string) error {
 	m.ctrl.T.Helper()
@@ -1014,6 +1102,21 @@ func (mr *MockFullNodeMockRecorder) LogSetLevel(arg0, arg1, arg2 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogSetLevel", reflect.TypeOf((*MockFullNode)(nil).LogSetLevel), arg0, arg1, arg2)
 }
 
+// LogTail mocks base method.
+func (m *MockFullNode) LogTail(arg0 context.Context, arg1, arg2 string) (<-chan api.LogEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogTail", arg0, arg1, arg2)
+	ret0, _ := ret[0].(<-chan api.LogEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LogTail indicates an expected call of LogTail.
+func (mr *MockFullNodeMockRecorder) LogTail(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogTail", reflect.TypeOf((*MockFullNode)(nil).LogTail), arg0, arg1, arg2)
+}
+
 // MarketAddBalance mocks base method.
 func (m *MockFullNode) MarketAddBalance(arg0 context.Context, arg1, arg2 address.Address, arg3 big.Int) (cid.Cid, error) {
 	m.ctrl.T.Helper()
@@ -1581,6 +1684,49 @@ func (mr *MockFullNodeMockRecorder) NetAgentVersion(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetAgentVersion", reflect.TypeOf((*MockFullNode)(nil).NetAgentVersion), arg0, arg1)
 }
 
+// NetAllowAdd mocks base method.
+func (m *MockFullNode) NetAllowAdd(arg0 context.Context, arg1 api.NetBlockList) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetAllowAdd", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NetAllowAdd indicates an expected call of NetAllowAdd.
+func (mr *MockFullNodeMockRecorder) NetAllowAdd(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetAllowAdd", reflect.TypeOf((*MockFullNode)(nil).NetAllowAdd), arg0, arg1)
+}
+
+// NetAllowList mocks base method.
+func (m *MockFullNode) NetAllowList(arg0 context.Context) (api.NetBlockList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetAllowList", arg0)
+	ret0, _ := ret[0].(api.NetBlockList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NetAllowList indicates an expected call of NetAllowList.
+func (mr *MockFullNodeMockRecorder) NetAllowList(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetAllowList", reflect.TypeOf((*MockFullNode)(nil).NetAllowList), arg0)
+}
+
+// NetAllowRemove mocks base method.
+func (m *MockFullNode) NetAllowRemove(arg0 context.Context, arg1 api.NetBlockList) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetAllowRemove", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NetAllowRemove indicates an expected call of NetAllowRemove.
+func (mr *MockFullNodeMockRecorder) NetAllowRemove(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetAllowRemove", reflect.TypeOf((*MockFullNode)(nil).NetAllowRemove), arg0, arg1)
+}
+
 // NetAutoNatStatus mocks base method.
 func (m *MockFullNode) NetAutoNatStatus(arg0 context.Context) (api.NatInfo, error) {
 	m.ctrl.T.Helper()
@@ -1641,6 +1787,21 @@ func (mr *MockFullNodeMockRecorder) NetBandwidthStatsByProtocol(arg0 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetBandwidthStatsByProtocol", reflect.TypeOf((*MockFullNode)(nil).NetBandwidthStatsByProtocol), arg0)
 }
 
+// NetBandwidthStatsByProtocolGroup mocks base method.
+func (m *MockFullNode) NetBandwidthStatsByProtocolGroup(arg0 context.Context) (map[string]api.ProtocolGroupBandwidth, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetBandwidthStatsByProtocolGroup", arg0)
+	ret0, _ := ret[0].(map[string]api.ProtocolGroupBandwidth)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NetBandwidthStatsByProtocolGroup indicates an expected call of NetBandwidthStatsByProtocolGroup.
+func (mr *MockFullNodeMockRecorder) NetBandwidthStatsByProtocolGroup(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetBandwidthStatsByProtocolGroup", reflect.TypeOf((*MockFullNode)(nil).NetBandwidthStatsByProtocolGroup), arg0)
+}
+
 // NetBlockAdd mocks base method.
 func (m *MockFullNode) NetBlockAdd(arg0 context.Context, arg1 api.NetBlockList) error {
 	m.ctrl.T.Helper()
@@ -1772,6 +1933,21 @@ func (mr *MockFullNodeMockRecorder) NetPeerInfo(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetPeerInfo", reflect.TypeOf((*MockFullNode)(nil).NetPeerInfo), arg0, arg1)
 }
 
+// NetPeerInfoDetailed mocks base method.
+func (m *MockFullNode) NetPeerInfoDetailed(arg0 context.Context, arg1 peer.ID) (*api.PeerInfoDetailed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetPeerInfoDetailed", arg0, arg1)
+	ret0, _ := ret[0].(*api.PeerInfoDetailed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NetPeerInfoDetailed indicates an expected call of NetPeerInfoDetailed.
+func (mr *MockFullNodeMockRecorder) NetPeerInfoDetailed(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetPeerInfoDetailed", reflect.TypeOf((*MockFullNode)(nil).NetPeerInfoDetailed), arg0, arg1)
+}
+
 // NetPeers mocks base method.
 func (m *MockFullNode) NetPeers(arg0 context.Context) ([]peer.AddrInfo, error) {
 	m.ctrl.T.Helper()
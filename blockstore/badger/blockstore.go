@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"sync"
 	"time"
 
@@ -19,6 +20,8 @@ import (
 	logger "github.com/ipfs/go-log/v2"
 	pool "github.com/libp2p/go-buffer-pool"
 	"github.com/multiformats/go-base32"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
 	"go.uber.org/zap"
 
 	"github.com/filecoin-project/lotus/blockstore"
@@ -54,6 +57,10 @@ type Options struct {
 
 	// Prefix is an optional prefix to prepend to keys. Default: "".
 	Prefix string
+
+	// Name is the name of the blockstore in its metrics. Default: the base
+	// name of Dir.
+	Name string
 }
 
 func DefaultOptions(path string) Options {
@@ -119,6 +126,10 @@ type Blockstore struct {
 	prefixing bool
 	prefix    []byte
 	prefixLen int
+
+	metricsCtx context.Context
+	closing    chan struct{}
+	metricsWg  sync.WaitGroup
 }
 
 var _ blockstore.Blockstore = (*Blockstore)(nil)
@@ -149,6 +160,16 @@ func Open(opts Options) (*Blockstore, error) {
 
 	bs.moveCond.L = &bs.moveMx
 
+	name := opts.Name
+	if name == "" {
+		name = filepath.Base(opts.Dir)
+	}
+	bs.metricsCtx, _ = tag.New(context.Background(), tag.Upsert(blockstore.BadgerName, name))
+	bs.closing = make(chan struct{})
+
+	bs.metricsWg.Add(1)
+	go bs.metricsLoop()
+
 	return bs, nil
 }
 
@@ -163,6 +184,9 @@ func (b *Blockstore) Close() error {
 	b.state = stateClosing
 	b.stateLk.Unlock()
 
+	close(b.closing)
+	b.metricsWg.Wait()
+
 	defer func() {
 		b.stateLk.Lock()
 		b.state = stateClosed
@@ -175,6 +199,56 @@ func (b *Blockstore) Close() error {
 	return b.db.Close()
 }
 
+// metricsLoop emits the size and LSM metrics of the blockstore every
+// BadgerMetricsEmitInterval, until the blockstore is closed
+func (b *Blockstore) metricsLoop() {
+	defer b.metricsWg.Done()
+
+	ticker := time.NewTicker(blockstore.BadgerMetricsEmitInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.emitMetrics()
+		case <-b.closing:
+			return
+		}
+	}
+}
+
+func (b *Blockstore) emitMetrics() {
+	if err := b.access(); err != nil {
+		return
+	}
+	defer b.viewers.Done()
+
+	b.lockDB()
+	defer b.unlockDB()
+
+	lsm, vlog := b.db.Size()
+	stats.Record(b.metricsCtx,
+		blockstore.BadgerMeasures.LSMSize.M(lsm),
+		blockstore.BadgerMeasures.VlogSize.M(vlog))
+
+	tables := make([]int64, b.opts.MaxLevels)
+	sizes := make([]int64, b.opts.MaxLevels)
+	for _, t := range b.db.Tables(false) {
+		if t.Level >= len(tables) {
+			continue
+		}
+		tables[t.Level]++
+		sizes[t.Level] += int64(t.EstimatedSz)
+	}
+
+	for level := range tables {
+		ctx, _ := tag.New(b.metricsCtx, tag.Upsert(blockstore.BadgerLevel, strconv.Itoa(level)))
+		stats.Record(ctx,
+			blockstore.BadgerMeasures.LevelTables.M(tables[level]),
+			blockstore.BadgerMeasures.LevelSize.M(sizes[level]))
+	}
+}
+
 func (b *Blockstore) access() error {
 	b.stateLk.RLock()
 	defer b.stateLk.RUnlock()
@@ -258,6 +332,8 @@ func (b *Blockstore) movingGC() error {
 	b.moveCond.Broadcast()
 	b.moveMx.Unlock()
 
+	stats.Record(b.metricsCtx, blockstore.BadgerMeasures.MovingGCRuns.M(1))
+
 	var newPath string
 
 	defer func() {
@@ -439,7 +515,7 @@ func (b *Blockstore) deleteDB(path string) {
 	}
 }
 
-func (b *Blockstore) onlineGC() error {
+func (b *Blockstore) onlineGC(threshold float64, check func() error) error {
 	b.lockDB()
 	defer b.unlockDB()
 
@@ -454,8 +530,23 @@ func (b *Blockstore) onlineGC() error {
 		return err
 	}
 
+	if threshold == 0 {
+		threshold = 0.125
+	}
+
 	for err == nil {
-		err = b.db.RunValueLogGC(0.125)
+		if check != nil {
+			if err := check(); err != nil {
+				return err
+			}
+		}
+
+		err = b.db.RunValueLogGC(threshold)
+
+		stats.Record(b.metricsCtx, blockstore.BadgerMeasures.VlogGCRuns.M(1))
+		if err == nil {
+			stats.Record(b.metricsCtx, blockstore.BadgerMeasures.VlogGCRewrites.M(1))
+		}
 	}
 
 	if err == badger.ErrNoRewrite {
@@ -486,7 +577,7 @@ func (b *Blockstore) CollectGarbage(opts ...blockstore.BlockstoreGCOption) error
 		return b.movingGC()
 	}
 
-	return b.onlineGC()
+	return b.onlineGC(options.Threshold, options.Check)
 }
 
 // Size returns the aggregate size of the blockstore
@@ -13,6 +13,7 @@ import (
 	blocks "github.com/ipfs/go-block-format"
 	"github.com/ipfs/go-cid"
 	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/filecoin-project/lotus/blockstore"
@@ -72,6 +73,47 @@ func TestStorageKey(t *testing.T) {
 	require.Equal(t, k3, k2)
 }
 
+func TestBadgerMetrics(t *testing.T) {
+	views := []*view.View{
+		blockstore.BadgerViews.LSMSize,
+		blockstore.BadgerViews.LevelTables,
+		blockstore.BadgerViews.VlogGCRuns,
+	}
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	named := func(path string) Options {
+		opts := DefaultOptions(path)
+		opts.Name = "metrics"
+		return opts
+	}
+	bs, _ := newBlockstore(named)(t)
+	bbs := bs.(*Blockstore)
+	defer bbs.Close() //nolint:errcheck
+
+	require.NoError(t, bbs.Put(context.Background(), blocks.NewBlock([]byte("some data"))))
+	require.NoError(t, bbs.CollectGarbage())
+	bbs.emitMetrics()
+
+	hasStore := func(name string) {
+		rows, err := view.RetrieveData(name)
+		require.NoError(t, err)
+
+		var found bool
+		for _, r := range rows {
+			for _, tg := range r.Tags {
+				if tg.Key == blockstore.BadgerName && tg.Value == "metrics" {
+					found = true
+				}
+			}
+		}
+		require.True(t, found, "no %s row for the store", name)
+	}
+	hasStore(blockstore.BadgerViews.LSMSize.Name)
+	hasStore(blockstore.BadgerViews.LevelTables.Name)
+	hasStore(blockstore.BadgerViews.VlogGCRuns.Name)
+}
+
 func newBlockstore(optsSupplier func(path string) Options) func(tb testing.TB) (bs blockstore.BasicBlockstore, path string) {
 	return func(tb testing.TB) (bs blockstore.BasicBlockstore, path string) {
 		tb.Helper()
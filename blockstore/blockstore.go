@@ -45,6 +45,12 @@ type BlockstoreGCOption = func(*BlockstoreGCOptions) error
 // BlockstoreGCOptions is a struct with GC options
 type BlockstoreGCOptions struct {
 	FullGC bool
+	// Threshold is the discard ratio above which a value log file is rewritten during
+	// online GC; a value of 0 uses the implementation default.
+	Threshold float64
+	// Check is called between online GC iterations; GC stops early, returning the
+	// error, if it returns one.
+	Check func() error
 }
 
 func WithFullGC(fullgc bool) BlockstoreGCOption {
@@ -54,6 +60,20 @@ func WithFullGC(fullgc bool) BlockstoreGCOption {
 	}
 }
 
+func WithThreshold(threshold float64) BlockstoreGCOption {
+	return func(opts *BlockstoreGCOptions) error {
+		opts.Threshold = threshold
+		return nil
+	}
+}
+
+func WithCheck(check func() error) BlockstoreGCOption {
+	return func(opts *BlockstoreGCOptions) error {
+		opts.Check = check
+		return nil
+	}
+}
+
 // BlockstoreSize is a trait for on-disk blockstores that can report their size
 type BlockstoreSize interface {
 	Size() (int64, error)
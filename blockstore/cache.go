@@ -0,0 +1,388 @@
+package blockstore
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/hashicorp/golang-lru/simplelru"
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"golang.org/x/xerrors"
+)
+
+// Cache callers, used to break down cache hits and misses by the subsystem
+// reading from the blockstore.
+const (
+	CacheCallerSync    = "sync"
+	CacheCallerAPI     = "api"
+	CacheCallerSealing = "sealing"
+	CacheCallerOther   = "other"
+)
+
+// CacheCallerHeader is the API request header through which clients identify
+// themselves as a cache caller, e.g. the miner as the sealing caller.
+const CacheCallerHeader = "X-Lotus-Cache-Caller"
+
+// WithCacheCaller tags the context with the subsystem reading from the
+// blockstore, for the cache hit/miss metrics.
+func WithCacheCaller(ctx context.Context, caller string) context.Context {
+	ctx, _ = tag.New(ctx, tag.Upsert(CacheCaller, caller))
+	return ctx
+}
+
+// BlockCache is an in-memory cache of blocks read from a blockstore. An "lru"
+// cache is bounded by the total size of the cached blocks, while an "arc" cache
+// is bounded by the number of cached blocks.
+type BlockCache struct {
+	name  string
+	cache blockCache
+
+	hits, misses, adds, evictions, costAdded, costEvicted int64
+
+	closing chan struct{}
+	wg      sync.WaitGroup
+}
+
+type blockCache interface {
+	get(c cid.Cid) ([]byte, bool)
+	add(c cid.Cid, data []byte) (evicted int, evictedBytes int64)
+	remove(c cid.Cid)
+	purge()
+	len() int
+}
+
+func NewBlockCache(name, cacheType string, maxBytes int64, maxEntries int) (*BlockCache, error) {
+	var cache blockCache
+	switch cacheType {
+	case "lru":
+		if maxBytes <= 0 {
+			return nil, xerrors.Errorf("lru block cache requires a positive memory budget")
+		}
+
+		c, err := newLRUCache(maxBytes, maxEntries)
+		if err != nil {
+			return nil, err
+		}
+		cache = c
+	case "arc":
+		if maxEntries <= 0 {
+			return nil, xerrors.Errorf("arc block cache requires a positive max entry count")
+		}
+
+		c, err := lru.NewARC(maxEntries)
+		if err != nil {
+			return nil, err
+		}
+		cache = &arcCache{ARCCache: c}
+	default:
+		return nil, xerrors.Errorf("unknown block cache type %q", cacheType)
+	}
+
+	return &BlockCache{
+		name:    name,
+		cache:   cache,
+		closing: make(chan struct{}),
+	}, nil
+}
+
+// Start starts emitting the cache metrics every CacheMetricsEmitInterval
+func (bc *BlockCache) Start(_ context.Context) error {
+	bc.wg.Add(1)
+	go func() {
+		defer bc.wg.Done()
+
+		ticker := time.NewTicker(CacheMetricsEmitInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				bc.emitMetrics()
+			case <-bc.closing:
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (bc *BlockCache) Stop(_ context.Context) error {
+	close(bc.closing)
+	bc.wg.Wait()
+	return nil
+}
+
+// Flush drops all blocks from the cache
+func (bc *BlockCache) Flush() {
+	bc.cache.purge()
+	log.Infow("block cache flushed", "cache", bc.name)
+}
+
+func (bc *BlockCache) get(ctx context.Context, c cid.Cid) ([]byte, bool) {
+	data, ok := bc.cache.get(c)
+
+	measure := CacheMeasures.CallerMisses
+	if ok {
+		atomic.AddInt64(&bc.hits, 1)
+		measure = CacheMeasures.CallerHits
+	} else {
+		atomic.AddInt64(&bc.misses, 1)
+	}
+
+	mutators := []tag.Mutator{tag.Upsert(CacheName, bc.name)}
+	if _, tagged := tag.FromContext(ctx).Value(CacheCaller); !tagged {
+		mutators = append(mutators, tag.Upsert(CacheCaller, CacheCallerOther))
+	}
+	_ = stats.RecordWithTags(ctx, mutators, measure.M(1))
+
+	return data, ok
+}
+
+func (bc *BlockCache) add(c cid.Cid, data []byte) {
+	evicted, evictedBytes := bc.cache.add(c, data)
+
+	atomic.AddInt64(&bc.adds, 1)
+	atomic.AddInt64(&bc.costAdded, int64(len(data)))
+	atomic.AddInt64(&bc.evictions, int64(evicted))
+	atomic.AddInt64(&bc.costEvicted, evictedBytes)
+}
+
+func (bc *BlockCache) emitMetrics() {
+	hits, misses := atomic.LoadInt64(&bc.hits), atomic.LoadInt64(&bc.misses)
+
+	var ratio float64
+	if hits+misses > 0 {
+		ratio = float64(hits) / float64(hits+misses)
+	}
+
+	ctx, _ := tag.New(context.Background(), tag.Upsert(CacheName, bc.name))
+	stats.Record(ctx,
+		CacheMeasures.HitRatio.M(ratio),
+		CacheMeasures.Hits.M(hits),
+		CacheMeasures.Misses.M(misses),
+		CacheMeasures.Entries.M(int64(bc.cache.len())),
+		CacheMeasures.QueriesServed.M(hits+misses),
+		CacheMeasures.Adds.M(atomic.LoadInt64(&bc.adds)),
+		CacheMeasures.Evictions.M(atomic.LoadInt64(&bc.evictions)),
+		CacheMeasures.CostAdded.M(atomic.LoadInt64(&bc.costAdded)),
+		CacheMeasures.CostEvicted.M(atomic.LoadInt64(&bc.costEvicted)),
+	)
+}
+
+// lruCache is an lru cache bounded by the total size of the cached blocks
+type lruCache struct {
+	lk       sync.Mutex
+	lru      *simplelru.LRU
+	size     int64
+	maxBytes int64
+
+	evicted      int
+	evictedBytes int64
+}
+
+func newLRUCache(maxBytes int64, maxEntries int) (*lruCache, error) {
+	c := &lruCache{maxBytes: maxBytes}
+
+	if maxEntries <= 0 {
+		// only bounded by size
+		maxEntries = int(^uint(0) >> 1)
+	}
+
+	l, err := simplelru.NewLRU(maxEntries, func(_ interface{}, value interface{}) {
+		n := int64(len(value.([]byte)))
+		c.size -= n
+		c.evicted++
+		c.evictedBytes += n
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.lru = l
+
+	return c, nil
+}
+
+func (c *lruCache) get(k cid.Cid) ([]byte, bool) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	v, ok := c.lru.Get(k)
+	if !ok {
+		return nil, false
+	}
+	return v.([]byte), true
+}
+
+func (c *lruCache) add(k cid.Cid, data []byte) (int, int64) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	if int64(len(data)) > c.maxBytes {
+		return 0, 0
+	}
+
+	c.evicted, c.evictedBytes = 0, 0
+
+	if c.lru.Contains(k) {
+		return 0, 0
+	}
+
+	c.lru.Add(k, data)
+	c.size += int64(len(data))
+
+	for c.size > c.maxBytes {
+		c.lru.RemoveOldest()
+	}
+
+	return c.evicted, c.evictedBytes
+}
+
+func (c *lruCache) remove(k cid.Cid) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	c.lru.Remove(k)
+}
+
+func (c *lruCache) purge() {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	c.lru.Purge()
+}
+
+func (c *lruCache) len() int {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	return c.lru.Len()
+}
+
+// arcCache is an arc cache bounded by the number of cached blocks
+type arcCache struct {
+	*lru.ARCCache
+}
+
+func (c *arcCache) get(k cid.Cid) ([]byte, bool) {
+	v, ok := c.Get(k)
+	if !ok {
+		return nil, false
+	}
+	return v.([]byte), true
+}
+
+func (c *arcCache) add(k cid.Cid, data []byte) (int, int64) {
+	// the arc cache doesn't report evictions
+	c.Add(k, data)
+	return 0, 0
+}
+
+func (c *arcCache) remove(k cid.Cid) {
+	c.Remove(k)
+}
+
+func (c *arcCache) purge() {
+	c.Purge()
+}
+
+func (c *arcCache) len() int {
+	return c.Len()
+}
+
+// cachedBlockstore is a read-through cache in front of a blockstore
+type cachedBlockstore struct {
+	bs    Blockstore
+	cache *BlockCache
+}
+
+var _ Blockstore = (*cachedBlockstore)(nil)
+
+// NewCachedBlockstore wraps the blockstore with a read-through block cache.
+// Several blockstores can share the same cache, provided they're backed by
+// the same underlying store.
+func NewCachedBlockstore(bs Blockstore, cache *BlockCache) Blockstore {
+	return &cachedBlockstore{
+		bs:    bs,
+		cache: cache,
+	}
+}
+
+func (c *cachedBlockstore) Get(ctx context.Context, k cid.Cid) (blocks.Block, error) {
+	if data, ok := c.cache.get(ctx, k); ok {
+		return blocks.NewBlockWithCid(data, k)
+	}
+
+	blk, err := c.bs.Get(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+
+	c.cache.add(k, blk.RawData())
+	return blk, nil
+}
+
+func (c *cachedBlockstore) View(ctx context.Context, k cid.Cid, callback func([]byte) error) error {
+	if data, ok := c.cache.get(ctx, k); ok {
+		return callback(data)
+	}
+
+	return c.bs.View(ctx, k, func(data []byte) error {
+		// the data passed to the callback is only valid for the duration of the
+		// call, so we cache a copy
+		cp := make([]byte, len(data))
+		copy(cp, data)
+		c.cache.add(k, cp)
+
+		return callback(data)
+	})
+}
+
+func (c *cachedBlockstore) Has(ctx context.Context, k cid.Cid) (bool, error) {
+	if _, ok := c.cache.get(ctx, k); ok {
+		return true, nil
+	}
+
+	return c.bs.Has(ctx, k)
+}
+
+func (c *cachedBlockstore) GetSize(ctx context.Context, k cid.Cid) (int, error) {
+	if data, ok := c.cache.get(ctx, k); ok {
+		return len(data), nil
+	}
+
+	return c.bs.GetSize(ctx, k)
+}
+
+func (c *cachedBlockstore) Put(ctx context.Context, blk blocks.Block) error {
+	return c.bs.Put(ctx, blk)
+}
+
+func (c *cachedBlockstore) PutMany(ctx context.Context, blks []blocks.Block) error {
+	return c.bs.PutMany(ctx, blks)
+}
+
+func (c *cachedBlockstore) DeleteBlock(ctx context.Context, k cid.Cid) error {
+	c.cache.cache.remove(k)
+	return c.bs.DeleteBlock(ctx, k)
+}
+
+func (c *cachedBlockstore) DeleteMany(ctx context.Context, ks []cid.Cid) error {
+	for _, k := range ks {
+		c.cache.cache.remove(k)
+	}
+	return c.bs.DeleteMany(ctx, ks)
+}
+
+func (c *cachedBlockstore) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error) {
+	return c.bs.AllKeysChan(ctx)
+}
+
+func (c *cachedBlockstore) HashOnRead(enabled bool) {
+	c.bs.HashOnRead(enabled)
+}
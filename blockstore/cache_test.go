@@ -0,0 +1,106 @@
+//stm: #unit
+package blockstore
+
+import (
+	"context"
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedBlockstoreReadThrough(t *testing.T) {
+	ctx := context.Background()
+
+	for _, typ := range []string{"lru", "arc"} {
+		t.Run(typ, func(t *testing.T) {
+			bc, err := NewBlockCache("test", typ, 1<<20, 16)
+			require.NoError(t, err)
+
+			under := NewMemory()
+			bs := NewCachedBlockstore(under, bc)
+
+			require.NoError(t, bs.Put(ctx, b0))
+
+			// first read misses and populates the cache
+			blk, err := bs.Get(ctx, b0.Cid())
+			require.NoError(t, err)
+			require.Equal(t, b0.RawData(), blk.RawData())
+			require.EqualValues(t, 1, bc.misses)
+
+			// reads are now served from the cache, even when the underlying block is gone
+			require.NoError(t, under.DeleteBlock(ctx, b0.Cid()))
+
+			err = bs.View(ctx, b0.Cid(), func(b []byte) error {
+				require.Equal(t, b0.RawData(), b)
+				return nil
+			})
+			require.NoError(t, err)
+			require.EqualValues(t, 1, bc.hits)
+
+			has, err := bs.Has(ctx, b0.Cid())
+			require.NoError(t, err)
+			require.True(t, has)
+			require.EqualValues(t, 2, bc.hits)
+
+			// flushing drops the cached blocks
+			bc.Flush()
+			has, err = bs.Has(ctx, b0.Cid())
+			require.NoError(t, err)
+			require.False(t, has)
+
+			// deletes through the cached blockstore evict the block
+			require.NoError(t, bs.Put(ctx, b1))
+			_, err = bs.Get(ctx, b1.Cid())
+			require.NoError(t, err)
+			require.NoError(t, bs.DeleteBlock(ctx, b1.Cid()))
+			has, err = bs.Has(ctx, b1.Cid())
+			require.NoError(t, err)
+			require.False(t, has)
+		})
+	}
+}
+
+func TestLRUBlockCacheBudget(t *testing.T) {
+	ctx := context.Background()
+
+	bc, err := NewBlockCache("test", "lru", 10, 0)
+	require.NoError(t, err)
+
+	bs := NewCachedBlockstore(NewMemory(), bc)
+
+	blks := []blocks.Block{
+		blocks.NewBlock([]byte("aaaa")),
+		blocks.NewBlock([]byte("bbbb")),
+		blocks.NewBlock([]byte("cccc")),
+		blocks.NewBlock([]byte("this block is over the budget")),
+	}
+	require.NoError(t, bs.PutMany(ctx, blks))
+
+	for _, blk := range blks {
+		_, err := bs.Get(ctx, blk.Cid())
+		require.NoError(t, err)
+	}
+
+	// the first block was evicted to make room for the third one, and the last
+	// block doesn't fit at all
+	require.Equal(t, 2, bc.cache.len())
+	require.EqualValues(t, 1, bc.evictions)
+	require.EqualValues(t, 4, bc.costEvicted)
+
+	_, ok := bc.cache.get(blks[0].Cid())
+	require.False(t, ok)
+	_, ok = bc.cache.get(blks[2].Cid())
+	require.True(t, ok)
+}
+
+func TestBlockCacheConfig(t *testing.T) {
+	_, err := NewBlockCache("test", "lru", 0, 0)
+	require.Error(t, err)
+
+	_, err = NewBlockCache("test", "arc", 1<<20, 0)
+	require.Error(t, err)
+
+	_, err = NewBlockCache("test", "fifo", 1<<20, 16)
+	require.Error(t, err)
+}
@@ -0,0 +1,257 @@
+package blockstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	carbs "github.com/ipld/go-car/v2/blockstore"
+	"golang.org/x/xerrors"
+)
+
+// CarMount describes a CAR file mounted as a read-only blockstore
+type CarMount struct {
+	Name      string
+	Path      string
+	Roots     []cid.Cid
+	MountedAt time.Time
+
+	bs *carbs.ReadOnly
+}
+
+// CarMounts is a set of CAR files (e.g. exported snapshots) mounted as
+// read-only blockstores at runtime. Blockstores returned by Overlay consult
+// the mounted CAR files, in mount order, for blocks missing from the
+// underlying store, so that historical data can be served without importing
+// it.
+type CarMounts struct {
+	lk     sync.RWMutex
+	mounts []*CarMount
+}
+
+func NewCarMounts() *CarMounts {
+	return &CarMounts{}
+}
+
+// Mount opens the CAR file at path and mounts it under the given name. CARv1
+// files are indexed in memory when mounted, which can take a while for large
+// files.
+func (m *CarMounts) Mount(name, path string) (CarMount, error) {
+	if name == "" {
+		return CarMount{}, xerrors.Errorf("mount name must not be empty")
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return CarMount{}, err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return CarMount{}, xerrors.Errorf("stat car file: %w", err)
+	}
+
+	m.lk.RLock()
+	exists := m.find(name) >= 0
+	m.lk.RUnlock()
+	if exists {
+		return CarMount{}, xerrors.Errorf("mount %s already exists", name)
+	}
+
+	// open outside of the lock, as indexing the file can take a while
+	bs, err := carbs.OpenReadOnly(path)
+	if err != nil {
+		return CarMount{}, xerrors.Errorf("opening car file %s: %w", path, err)
+	}
+
+	roots, err := bs.Roots()
+	if err != nil {
+		_ = bs.Close()
+		return CarMount{}, xerrors.Errorf("reading car roots: %w", err)
+	}
+
+	mnt := &CarMount{
+		Name:      name,
+		Path:      path,
+		Roots:     roots,
+		MountedAt: time.Now(),
+		bs:        bs,
+	}
+
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	if m.find(name) >= 0 {
+		_ = bs.Close()
+		return CarMount{}, xerrors.Errorf("mount %s already exists", name)
+	}
+
+	m.mounts = append(m.mounts, mnt)
+	log.Infow("mounted car file", "name", name, "path", path, "roots", roots)
+
+	return *mnt, nil
+}
+
+// Unmount removes and closes the named mount
+func (m *CarMounts) Unmount(name string) error {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	i := m.find(name)
+	if i < 0 {
+		return xerrors.Errorf("mount %s not found", name)
+	}
+
+	mnt := m.mounts[i]
+	m.mounts = append(m.mounts[:i], m.mounts[i+1:]...)
+
+	if err := mnt.bs.Close(); err != nil {
+		return xerrors.Errorf("closing car file %s: %w", mnt.Path, err)
+	}
+
+	log.Infow("unmounted car file", "name", name, "path", mnt.Path)
+	return nil
+}
+
+// List returns the current mounts, in mount order
+func (m *CarMounts) List() []CarMount {
+	m.lk.RLock()
+	defer m.lk.RUnlock()
+
+	out := make([]CarMount, len(m.mounts))
+	for i, mnt := range m.mounts {
+		out[i] = *mnt
+	}
+	return out
+}
+
+// Close closes all mounted CAR files
+func (m *CarMounts) Close() error {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	var err error
+	for _, mnt := range m.mounts {
+		if cerr := mnt.bs.Close(); cerr != nil {
+			err = cerr
+		}
+	}
+	m.mounts = nil
+
+	return err
+}
+
+func (m *CarMounts) find(name string) int {
+	for i, mnt := range m.mounts {
+		if mnt.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// get returns the block from the first mount which has it. The CAR files are
+// not validated when mounted, so the hash of each block is checked when it is
+// read.
+func (m *CarMounts) get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	m.lk.RLock()
+	defer m.lk.RUnlock()
+
+	for _, mnt := range m.mounts {
+		blk, err := mnt.bs.Get(ctx, c)
+		if err != nil {
+			if ipld.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		sum, err := c.Prefix().Sum(blk.RawData())
+		if err != nil {
+			return nil, xerrors.Errorf("hashing block %s from mount %s: %w", c, mnt.Name, err)
+		}
+		if !sum.Equals(c) {
+			return nil, xerrors.Errorf("block %s from mount %s doesn't match its hash", c, mnt.Name)
+		}
+
+		return blk, nil
+	}
+
+	return nil, ipld.ErrNotFound{Cid: c}
+}
+
+// has reads the block, so that only blocks matching their hash are reported
+func (m *CarMounts) has(ctx context.Context, c cid.Cid) (bool, error) {
+	_, err := m.get(ctx, c)
+	switch {
+	case err == nil:
+		return true, nil
+	case ipld.IsNotFound(err):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// Overlay returns a blockstore which serves blocks missing from bs out of the
+// mounted CAR files. Writes, deletes and key iteration only concern bs.
+func (m *CarMounts) Overlay(bs Blockstore) Blockstore {
+	return &carOverlay{Blockstore: bs, mounts: m}
+}
+
+type carOverlay struct {
+	Blockstore
+
+	mounts *CarMounts
+}
+
+var _ Blockstore = (*carOverlay)(nil)
+
+func (o *carOverlay) Has(ctx context.Context, c cid.Cid) (bool, error) {
+	has, err := o.Blockstore.Has(ctx, c)
+	if has || err != nil {
+		return has, err
+	}
+
+	return o.mounts.has(ctx, c)
+}
+
+func (o *carOverlay) Get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	blk, err := o.Blockstore.Get(ctx, c)
+	if err == nil || !ipld.IsNotFound(err) {
+		return blk, err
+	}
+
+	return o.mounts.get(ctx, c)
+}
+
+func (o *carOverlay) View(ctx context.Context, c cid.Cid, callback func([]byte) error) error {
+	err := o.Blockstore.View(ctx, c, callback)
+	if err == nil || !ipld.IsNotFound(err) {
+		return err
+	}
+
+	blk, err := o.mounts.get(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	return callback(blk.RawData())
+}
+
+func (o *carOverlay) GetSize(ctx context.Context, c cid.Cid) (int, error) {
+	size, err := o.Blockstore.GetSize(ctx, c)
+	if err == nil || !ipld.IsNotFound(err) {
+		return size, err
+	}
+
+	blk, err := o.mounts.get(ctx, c)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(blk.RawData()), nil
+}
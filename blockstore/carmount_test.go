@@ -0,0 +1,113 @@
+//stm: #unit
+package blockstore
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	carbs "github.com/ipld/go-car/v2/blockstore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCarMountOverlay(t *testing.T) {
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "test.car")
+	cw, err := carbs.OpenReadWrite(path, []cid.Cid{b1.Cid()})
+	require.NoError(t, err)
+	require.NoError(t, cw.PutMany(ctx, []blocks.Block{b1, b2}))
+	require.NoError(t, cw.Finalize())
+
+	mounts := NewCarMounts()
+	defer mounts.Close() //nolint:errcheck
+
+	base := NewMemory()
+	require.NoError(t, base.Put(ctx, b0))
+
+	bs := mounts.Overlay(base)
+
+	// nothing is mounted yet
+	_, err = bs.Get(ctx, b1.Cid())
+	require.True(t, ipld.IsNotFound(err))
+
+	mnt, err := mounts.Mount("test", path)
+	require.NoError(t, err)
+	require.Equal(t, []cid.Cid{b1.Cid()}, mnt.Roots)
+
+	_, err = mounts.Mount("test", path)
+	require.Error(t, err)
+
+	// blocks are served from both the base store and the mount
+	for _, blk := range []blocks.Block{b0, b1, b2} {
+		got, err := bs.Get(ctx, blk.Cid())
+		require.NoError(t, err)
+		require.Equal(t, blk.RawData(), got.RawData())
+
+		size, err := bs.GetSize(ctx, blk.Cid())
+		require.NoError(t, err)
+		require.Equal(t, len(blk.RawData()), size)
+
+		err = bs.View(ctx, blk.Cid(), func(b []byte) error {
+			require.Equal(t, blk.RawData(), b)
+			return nil
+		})
+		require.NoError(t, err)
+	}
+
+	// writes only go to the base store
+	has, err := base.Has(ctx, b1.Cid())
+	require.NoError(t, err)
+	require.False(t, has)
+
+	require.Len(t, mounts.List(), 1)
+
+	require.NoError(t, mounts.Unmount("test"))
+	require.Error(t, mounts.Unmount("test"))
+	require.Empty(t, mounts.List())
+
+	has, err = bs.Has(ctx, b1.Cid())
+	require.NoError(t, err)
+	require.False(t, has)
+}
+
+func TestCarMountVerifiesHashes(t *testing.T) {
+	ctx := context.Background()
+
+	// b1's CID with b2's data
+	bad, err := blocks.NewBlockWithCid(b2.RawData(), b1.Cid())
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "bad.car")
+	cw, err := carbs.OpenReadWrite(path, []cid.Cid{b1.Cid()})
+	require.NoError(t, err)
+	require.NoError(t, cw.Put(ctx, bad))
+	require.NoError(t, cw.Finalize())
+
+	mounts := NewCarMounts()
+	defer mounts.Close() //nolint:errcheck
+
+	_, err = mounts.Mount("bad", path)
+	require.NoError(t, err)
+
+	bs := mounts.Overlay(NewMemory())
+
+	_, err = bs.Get(ctx, b1.Cid())
+	require.ErrorContains(t, err, "doesn't match its hash")
+
+	_, err = bs.GetSize(ctx, b1.Cid())
+	require.Error(t, err)
+
+	err = bs.View(ctx, b1.Cid(), func([]byte) error {
+		t.Fatal("callback called with a corrupt block")
+		return nil
+	})
+	require.Error(t, err)
+
+	has, err := bs.Has(ctx, b1.Cid())
+	require.Error(t, err)
+	require.False(t, has)
+}
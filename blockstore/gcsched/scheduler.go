@@ -0,0 +1,383 @@
+package gcsched
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	mh "github.com/multiformats/go-multihash"
+	"go.opencensus.io/stats"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+var log = logging.Logger("gcsched")
+
+var (
+	ErrGCUnsupported = errors.New("blockstore doesn't support online garbage collection")
+	ErrGCRunning     = errors.New("blockstore garbage collection already running")
+
+	errAborted = errors.New("gc aborted")
+)
+
+// probeCid is looked up between GC iterations to measure the read latency of the
+// blockstore; it is a hashed (non-identity) cid so that the lookup actually hits
+// the store.
+var probeCid = func() cid.Cid {
+	h, err := mh.Sum([]byte("lotus/blockstore/gc-probe"), mh.SHA2_256, -1)
+	if err != nil {
+		panic(err)
+	}
+	return cid.NewCidV1(cid.Raw, h)
+}()
+
+// Config specifies the daily schedule and the default limits of GC runs
+type Config struct {
+	// Scheduled enables daily GC runs within the window
+	Scheduled bool
+	// WindowStart and WindowEnd are local times of day in HH:MM format; the window
+	// may cross midnight
+	WindowStart string
+	WindowEnd   string
+
+	MaxDuration     time.Duration
+	MaxProbeLatency time.Duration
+	Threshold       float64
+}
+
+// Scheduler runs online garbage collection on a blockstore, either on demand or
+// daily within a configured window, stopping runs early when they take too long or
+// degrade the read latency of the blockstore.
+type Scheduler struct {
+	bs  blockstore.Blockstore
+	cfg Config
+
+	windowStart, windowEnd time.Duration
+
+	lk      sync.Mutex
+	status  api.BlockstoreGCStatus
+	running bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewScheduler(bs blockstore.Blockstore, cfg Config) (*Scheduler, error) {
+	s := &Scheduler{
+		bs:  bs,
+		cfg: cfg,
+	}
+
+	if cfg.Scheduled {
+		var err error
+		if s.windowStart, err = parseTimeOfDay(cfg.WindowStart); err != nil {
+			return nil, xerrors.Errorf("parsing GC window start: %w", err)
+		}
+		if s.windowEnd, err = parseTimeOfDay(cfg.WindowEnd); err != nil {
+			return nil, xerrors.Errorf("parsing GC window end: %w", err)
+		}
+		if s.windowStart == s.windowEnd {
+			return nil, xerrors.Errorf("empty GC window %s-%s", cfg.WindowStart, cfg.WindowEnd)
+		}
+	}
+
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	return s, nil
+}
+
+func (s *Scheduler) Start(ctx context.Context) error {
+	if !s.cfg.Scheduled {
+		return nil
+	}
+
+	if _, ok := s.bs.(blockstore.BlockstoreGC); !ok {
+		log.Warnf("not scheduling blockstore GC: %s (%T)", ErrGCUnsupported, s.bs)
+		return nil
+	}
+
+	s.lk.Lock()
+	s.status.Scheduled = true
+	s.lk.Unlock()
+
+	s.wg.Add(1)
+	go s.schedule()
+
+	return nil
+}
+
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run starts a GC run in the background; zero limits in the options are taken
+// from the config.
+func (s *Scheduler) Run(opts api.BlockstoreGCOpts) error {
+	if opts.MaxDuration == 0 {
+		opts.MaxDuration = s.cfg.MaxDuration
+	}
+	if opts.MaxProbeLatency == 0 {
+		opts.MaxProbeLatency = s.cfg.MaxProbeLatency
+	}
+	if opts.Threshold == 0 {
+		opts.Threshold = s.cfg.Threshold
+	}
+
+	if opts.Threshold < 0 || opts.Threshold >= 1 {
+		return xerrors.Errorf("GC threshold must be between 0 and 1, got %f", opts.Threshold)
+	}
+
+	if err := s.begin(); err != nil {
+		return err
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.collect(opts)
+	}()
+
+	return nil
+}
+
+// Status returns the status of the current or last GC run
+func (s *Scheduler) Status() api.BlockstoreGCStatus {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	return s.status
+}
+
+func (s *Scheduler) schedule() {
+	defer s.wg.Done()
+
+	for {
+		start, end := s.nextWindow(time.Now())
+
+		s.lk.Lock()
+		s.status.NextWindow = start
+		s.lk.Unlock()
+
+		log.Infow("next blockstore GC window", "start", start, "end", end)
+
+		timer := time.NewTimer(time.Until(start))
+		select {
+		case <-timer.C:
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		// don't run past the end of the window
+		opts := api.BlockstoreGCOpts{
+			MaxDuration:     time.Until(end),
+			MaxProbeLatency: s.cfg.MaxProbeLatency,
+			Threshold:       s.cfg.Threshold,
+		}
+		if s.cfg.MaxDuration > 0 && s.cfg.MaxDuration < opts.MaxDuration {
+			opts.MaxDuration = s.cfg.MaxDuration
+		}
+
+		if err := s.begin(); err != nil {
+			log.Warnf("skipping scheduled blockstore GC: %s", err)
+		} else {
+			s.collect(opts)
+		}
+
+		// make sure we move on to the next day's window
+		select {
+		case <-time.After(time.Until(end)):
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// nextWindow returns the bounds of the current window if now is within one, or
+// of the next window otherwise
+func (s *Scheduler) nextWindow(now time.Time) (time.Time, time.Time) {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	length := s.windowEnd - s.windowStart
+	if length < 0 {
+		length += 24 * time.Hour
+	}
+
+	// the window that started yesterday may still be open if it crosses midnight
+	for _, day := range []int{-1, 0, 1} {
+		start := midnight.AddDate(0, 0, day).Add(s.windowStart)
+		end := start.Add(length)
+		if now.Before(end) {
+			return start, end
+		}
+	}
+
+	// unreachable, the window that starts tomorrow always ends after now
+	return now, now.Add(length)
+}
+
+func (s *Scheduler) begin() error {
+	if _, ok := s.bs.(blockstore.BlockstoreGC); !ok {
+		return xerrors.Errorf("%w: %T", ErrGCUnsupported, s.bs)
+	}
+
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	if s.running {
+		return ErrGCRunning
+	}
+	s.running = true
+
+	s.status = api.BlockstoreGCStatus{
+		Running:    true,
+		Scheduled:  s.status.Scheduled,
+		NextWindow: s.status.NextWindow,
+		Start:      time.Now(),
+	}
+
+	return nil
+}
+
+func (s *Scheduler) collect(opts api.BlockstoreGCOpts) {
+	gc := s.bs.(blockstore.BlockstoreGC)
+	sizer, hasSize := s.bs.(blockstore.BlockstoreSize)
+
+	var sizeBefore int64
+	if hasSize {
+		var err error
+		if sizeBefore, err = sizer.Size(); err != nil {
+			log.Warnf("error getting blockstore size: %s", err)
+		}
+	}
+
+	s.lk.Lock()
+	start := s.status.Start
+	s.status.SizeBefore = sizeBefore
+	s.lk.Unlock()
+
+	log.Infow("starting blockstore GC", "maxDuration", opts.MaxDuration, "maxProbeLatency", opts.MaxProbeLatency, "threshold", opts.Threshold)
+
+	var abortReason string
+	check := func() error {
+		s.lk.Lock()
+		s.status.Iterations++
+		s.lk.Unlock()
+
+		stats.Record(s.ctx, metrics.BlockstoreGCIterations.M(1))
+
+		if reason := s.checkLimits(start, opts); reason != "" {
+			abortReason = reason
+			return errAborted
+		}
+
+		return nil
+	}
+
+	var gcopts []blockstore.BlockstoreGCOption
+	gcopts = append(gcopts, blockstore.WithCheck(check))
+	if opts.Threshold > 0 {
+		gcopts = append(gcopts, blockstore.WithThreshold(opts.Threshold))
+	}
+
+	err := gc.CollectGarbage(gcopts...)
+	if errors.Is(err, errAborted) {
+		err = nil
+	}
+
+	sizeAfter := sizeBefore
+	if hasSize {
+		if size, serr := sizer.Size(); serr != nil {
+			log.Warnf("error getting blockstore size: %s", serr)
+		} else {
+			sizeAfter = size
+		}
+	}
+
+	end := time.Now()
+	stats.Record(s.ctx,
+		metrics.BlockstoreGCRuns.M(1),
+		metrics.BlockstoreGCTimeSeconds.M(end.Sub(start).Seconds()))
+	if sizeBefore > sizeAfter {
+		stats.Record(s.ctx, metrics.BlockstoreGCReclaimedBytes.M(sizeBefore-sizeAfter))
+	}
+	if abortReason != "" {
+		stats.Record(s.ctx, metrics.BlockstoreGCAborted.M(1))
+	}
+
+	s.lk.Lock()
+	s.running = false
+	s.status.Running = false
+	s.status.End = end
+	s.status.SizeAfter = sizeAfter
+	s.status.AbortReason = abortReason
+	if err != nil {
+		s.status.Error = err.Error()
+	}
+	iterations := s.status.Iterations
+	s.lk.Unlock()
+
+	if err != nil {
+		log.Errorf("blockstore GC failed: %s", err)
+		return
+	}
+
+	log.Infow("blockstore GC done", "took", end.Sub(start), "iterations", iterations,
+		"sizeBefore", sizeBefore, "sizeAfter", sizeAfter, "abortReason", abortReason)
+}
+
+// checkLimits returns the reason for stopping the run early, if any
+func (s *Scheduler) checkLimits(start time.Time, opts api.BlockstoreGCOpts) string {
+	if s.ctx.Err() != nil {
+		return "shutting down"
+	}
+
+	if opts.MaxDuration > 0 && time.Since(start) > opts.MaxDuration {
+		return fmt.Sprintf("exceeded max duration of %s", opts.MaxDuration)
+	}
+
+	if opts.MaxProbeLatency > 0 {
+		probeStart := time.Now()
+		if _, err := s.bs.Has(s.ctx, probeCid); err != nil {
+			return fmt.Sprintf("probe read failed: %s", err)
+		}
+
+		latency := time.Since(probeStart)
+		stats.Record(s.ctx, metrics.BlockstoreGCProbeLatency.M(metrics.SinceInMilliseconds(probeStart)))
+
+		if latency > opts.MaxProbeLatency {
+			return fmt.Sprintf("probe latency of %s exceeded %s", latency, opts.MaxProbeLatency)
+		}
+	}
+
+	return ""
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
@@ -0,0 +1,116 @@
+//stm: #unit
+package gcsched
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+)
+
+// gcBlockstore runs value log GC iterations until the check stops it or the
+// iterations run out
+type gcBlockstore struct {
+	blockstore.Blockstore
+
+	iterations int
+	delay      time.Duration
+}
+
+func (b *gcBlockstore) CollectGarbage(opts ...blockstore.BlockstoreGCOption) error {
+	var options blockstore.BlockstoreGCOptions
+	for _, opt := range opts {
+		if err := opt(&options); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < b.iterations; i++ {
+		if err := options.Check(); err != nil {
+			return err
+		}
+		time.Sleep(b.delay)
+	}
+
+	return nil
+}
+
+func waitDone(t *testing.T, s *Scheduler) api.BlockstoreGCStatus {
+	require.Eventually(t, func() bool {
+		return !s.Status().Running
+	}, 5*time.Second, 10*time.Millisecond)
+	return s.Status()
+}
+
+func TestGCRun(t *testing.T) {
+	bs := &gcBlockstore{Blockstore: blockstore.NewMemory(), iterations: 5}
+	s, err := NewScheduler(bs, Config{})
+	require.NoError(t, err)
+	defer s.Stop(context.Background()) //nolint:errcheck
+
+	require.NoError(t, s.Run(api.BlockstoreGCOpts{}))
+
+	st := waitDone(t, s)
+	require.EqualValues(t, 5, st.Iterations)
+	require.Empty(t, st.AbortReason)
+	require.Empty(t, st.Error)
+	require.False(t, st.End.IsZero())
+}
+
+func TestGCMaxDuration(t *testing.T) {
+	bs := &gcBlockstore{Blockstore: blockstore.NewMemory(), iterations: 1000, delay: 10 * time.Millisecond}
+	s, err := NewScheduler(bs, Config{MaxDuration: 50 * time.Millisecond})
+	require.NoError(t, err)
+	defer s.Stop(context.Background()) //nolint:errcheck
+
+	require.NoError(t, s.Run(api.BlockstoreGCOpts{}))
+	require.ErrorIs(t, s.Run(api.BlockstoreGCOpts{}), ErrGCRunning)
+
+	st := waitDone(t, s)
+	require.Less(t, st.Iterations, int64(1000))
+	require.Contains(t, st.AbortReason, "max duration")
+	require.Empty(t, st.Error)
+}
+
+func TestGCUnsupported(t *testing.T) {
+	s, err := NewScheduler(blockstore.NewMemory(), Config{})
+	require.NoError(t, err)
+
+	require.ErrorIs(t, s.Run(api.BlockstoreGCOpts{}), ErrGCUnsupported)
+}
+
+func TestGCWindow(t *testing.T) {
+	_, err := NewScheduler(blockstore.NewMemory(), Config{Scheduled: true, WindowStart: "25:00", WindowEnd: "03:00"})
+	require.Error(t, err)
+
+	s, err := NewScheduler(blockstore.NewMemory(), Config{Scheduled: true, WindowStart: "23:00", WindowEnd: "02:00"})
+	require.NoError(t, err)
+
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2022, 8, day, hour, min, 0, 0, time.Local)
+	}
+
+	// before the window
+	start, end := s.nextWindow(at(10, 12, 0))
+	require.Equal(t, at(10, 23, 0), start)
+	require.Equal(t, at(11, 2, 0), end)
+
+	// within the window, before midnight
+	start, end = s.nextWindow(at(10, 23, 30))
+	require.Equal(t, at(10, 23, 0), start)
+	require.Equal(t, at(11, 2, 0), end)
+
+	// within the window, after midnight
+	start, end = s.nextWindow(at(11, 1, 0))
+	require.Equal(t, at(10, 23, 0), start)
+	require.Equal(t, at(11, 2, 0), end)
+
+	// after the window
+	start, end = s.nextWindow(at(11, 2, 0))
+	require.Equal(t, at(11, 23, 0), start)
+	require.Equal(t, at(12, 2, 0), end)
+}
@@ -9,9 +9,8 @@ import (
 )
 
 //
-// Currently unused, but kept in repo in case we introduce one of the candidate
-// cache implementations (Freecache, Ristretto), both of which report these
-// metrics.
+// These metrics are reported by the BlockCache, and are compatible with the
+// ones reported by the candidate cache implementations (Freecache, Ristretto).
 //
 
 // CacheMetricsEmitInterval is the interval at which metrics are emitted onto
@@ -19,7 +18,8 @@ import (
 var CacheMetricsEmitInterval = 5 * time.Second
 
 var (
-	CacheName, _ = tag.NewKey("cache_name")
+	CacheName, _   = tag.NewKey("cache_name")
+	CacheCaller, _ = tag.NewKey("caller")
 )
 
 // CacheMeasures groups all metrics emitted by the blockstore caches.
@@ -37,6 +37,8 @@ var CacheMeasures = struct {
 	SetsDropped    *stats.Int64Measure
 	SetsRejected   *stats.Int64Measure
 	QueriesDropped *stats.Int64Measure
+	CallerHits     *stats.Int64Measure
+	CallerMisses   *stats.Int64Measure
 }{
 	HitRatio:       stats.Float64("blockstore/cache/hit_ratio", "Hit ratio of blockstore cache", stats.UnitDimensionless),
 	Hits:           stats.Int64("blockstore/cache/hits", "Total number of hits at blockstore cache", stats.UnitDimensionless),
@@ -51,6 +53,8 @@ var CacheMeasures = struct {
 	SetsDropped:    stats.Int64("blockstore/cache/sets_dropped", "Total number of sets dropped by blockstore cache", stats.UnitDimensionless),
 	SetsRejected:   stats.Int64("blockstore/cache/sets_rejected", "Total number of sets rejected by blockstore cache", stats.UnitDimensionless),
 	QueriesDropped: stats.Int64("blockstore/cache/queries_dropped", "Total number of queries dropped by blockstore cache", stats.UnitDimensionless),
+	CallerHits:     stats.Int64("blockstore/cache/caller_hits", "Number of hits at blockstore cache, by caller", stats.UnitDimensionless),
+	CallerMisses:   stats.Int64("blockstore/cache/caller_misses", "Number of misses at blockstore cache, by caller", stats.UnitDimensionless),
 }
 
 // CacheViews groups all cache-related default views.
@@ -68,6 +72,8 @@ var CacheViews = struct {
 	SetsDropped    *view.View
 	SetsRejected   *view.View
 	QueriesDropped *view.View
+	CallerHits     *view.View
+	CallerMisses   *view.View
 }{
 	HitRatio: &view.View{
 		Measure:     CacheMeasures.HitRatio,
@@ -134,6 +140,16 @@ var CacheViews = struct {
 		Aggregation: view.LastValue(),
 		TagKeys:     []tag.Key{CacheName},
 	},
+	CallerHits: &view.View{
+		Measure:     CacheMeasures.CallerHits,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{CacheName, CacheCaller},
+	},
+	CallerMisses: &view.View{
+		Measure:     CacheMeasures.CallerMisses,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{CacheName, CacheCaller},
+	},
 }
 
 // DefaultViews exports all default views for this package.
@@ -151,4 +167,92 @@ var DefaultViews = []*view.View{
 	CacheViews.SetsDropped,
 	CacheViews.SetsRejected,
 	CacheViews.QueriesDropped,
+	CacheViews.CallerHits,
+	CacheViews.CallerMisses,
+	BadgerViews.LSMSize,
+	BadgerViews.VlogSize,
+	BadgerViews.LevelTables,
+	BadgerViews.LevelSize,
+	BadgerViews.VlogGCRuns,
+	BadgerViews.VlogGCRewrites,
+	BadgerViews.MovingGCRuns,
+}
+
+//
+// These metrics are reported by the badger blockstores.
+//
+
+// BadgerMetricsEmitInterval is the interval at which the badger size and LSM
+// metrics are emitted onto OpenCensus.
+var BadgerMetricsEmitInterval = 30 * time.Second
+
+var (
+	BadgerName, _  = tag.NewKey("badger_store")
+	BadgerLevel, _ = tag.NewKey("badger_level")
+)
+
+// BadgerMeasures groups all metrics emitted by the badger blockstores.
+var BadgerMeasures = struct {
+	LSMSize        *stats.Int64Measure
+	VlogSize       *stats.Int64Measure
+	LevelTables    *stats.Int64Measure
+	LevelSize      *stats.Int64Measure
+	VlogGCRuns     *stats.Int64Measure
+	VlogGCRewrites *stats.Int64Measure
+	MovingGCRuns   *stats.Int64Measure
+}{
+	LSMSize:        stats.Int64("blockstore/badger/lsm_size", "Size of the badger LSM tree", stats.UnitBytes),
+	VlogSize:       stats.Int64("blockstore/badger/vlog_size", "Size of the badger value log", stats.UnitBytes),
+	LevelTables:    stats.Int64("blockstore/badger/level_tables", "Number of tables in a level of the badger LSM tree", stats.UnitDimensionless),
+	LevelSize:      stats.Int64("blockstore/badger/level_size", "Estimated size of the tables in a level of the badger LSM tree", stats.UnitBytes),
+	VlogGCRuns:     stats.Int64("blockstore/badger/vlog_gc_runs", "Number of badger value log GC runs", stats.UnitDimensionless),
+	VlogGCRewrites: stats.Int64("blockstore/badger/vlog_gc_rewrites", "Number of badger value log files rewritten by GC", stats.UnitDimensionless),
+	MovingGCRuns:   stats.Int64("blockstore/badger/moving_gc_runs", "Number of badger moving GC runs", stats.UnitDimensionless),
+}
+
+// BadgerViews groups all badger-related default views.
+var BadgerViews = struct {
+	LSMSize        *view.View
+	VlogSize       *view.View
+	LevelTables    *view.View
+	LevelSize      *view.View
+	VlogGCRuns     *view.View
+	VlogGCRewrites *view.View
+	MovingGCRuns   *view.View
+}{
+	LSMSize: &view.View{
+		Measure:     BadgerMeasures.LSMSize,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{BadgerName},
+	},
+	VlogSize: &view.View{
+		Measure:     BadgerMeasures.VlogSize,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{BadgerName},
+	},
+	LevelTables: &view.View{
+		Measure:     BadgerMeasures.LevelTables,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{BadgerName, BadgerLevel},
+	},
+	LevelSize: &view.View{
+		Measure:     BadgerMeasures.LevelSize,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{BadgerName, BadgerLevel},
+	},
+	VlogGCRuns: &view.View{
+		Measure:     BadgerMeasures.VlogGCRuns,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{BadgerName},
+	},
+	VlogGCRewrites: &view.View{
+		Measure:     BadgerMeasures.VlogGCRewrites,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{BadgerName},
+	},
+	MovingGCRuns: &view.View{
+		Measure:     BadgerMeasures.MovingGCRuns,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{BadgerName},
+	},
 }
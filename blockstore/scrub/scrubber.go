@@ -0,0 +1,443 @@
+package scrub
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"github.com/ipfs/go-datastore/query"
+	exchange "github.com/ipfs/go-ipfs-exchange-interface"
+	ipld "github.com/ipfs/go-ipld-format"
+	logging "github.com/ipfs/go-log/v2"
+	mh "github.com/multiformats/go-multihash"
+	"go.opencensus.io/stats"
+	"golang.org/x/time/rate"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+var log = logging.Logger("scrub")
+
+var ErrScrubRunning = errors.New("blockstore scrub already running")
+
+var quarantinePrefix = datastore.NewKey("/blockstore/scrub/quarantine")
+
+// Config specifies how often and how fast the blockstore is scrubbed
+type Config struct {
+	// Interval is the time between the starts of periodic scrub passes; 0 disables
+	// periodic passes
+	Interval time.Duration
+	// MaxBlocksPerSecond limits the rate at which blocks are verified; 0 means no
+	// limit
+	MaxBlocksPerSecond int
+
+	// Refetch enables fetching corrupt blocks from the network
+	Refetch        bool
+	RefetchTimeout time.Duration
+}
+
+// Scrubber verifies that the blocks stored in a blockstore hash to their CIDs.
+// Corrupt blocks are removed from the blockstore and quarantined in the
+// datastore, and are re-fetched from the network when possible.
+type Scrubber struct {
+	bs      blockstore.Blockstore
+	ds      datastore.Batching
+	fetcher exchange.Fetcher
+	cfg     Config
+
+	lk      sync.Mutex
+	status  api.BlockstoreScrubStatus
+	running bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScrubber creates a scrubber of bs, which quarantines corrupt blocks in ds.
+// The fetcher may be nil, in which case corrupt blocks are not re-fetched.
+func NewScrubber(bs blockstore.Blockstore, ds datastore.Batching, fetcher exchange.Fetcher, cfg Config) *Scrubber {
+	s := &Scrubber{
+		bs:      bs,
+		ds:      namespace.Wrap(ds, quarantinePrefix),
+		fetcher: fetcher,
+		cfg:     cfg,
+	}
+
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	return s
+}
+
+func (s *Scrubber) Start(ctx context.Context) error {
+	quarantined, err := s.Quarantined(ctx)
+	if err != nil {
+		return xerrors.Errorf("loading quarantined blocks: %w", err)
+	}
+
+	s.lk.Lock()
+	s.status.Quarantined = int64(len(quarantined))
+	s.status.Periodic = s.cfg.Interval > 0
+	s.lk.Unlock()
+
+	stats.Record(s.ctx, metrics.BlockstoreScrubQuarantined.M(int64(len(quarantined))))
+
+	if s.cfg.Interval > 0 {
+		s.wg.Add(1)
+		go s.schedule()
+	}
+
+	return nil
+}
+
+func (s *Scrubber) Stop(ctx context.Context) error {
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run starts a scrub pass in the background
+func (s *Scrubber) Run() error {
+	if err := s.begin(); err != nil {
+		return err
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.scrub()
+	}()
+
+	return nil
+}
+
+// Status returns the status of the current or last scrub pass
+func (s *Scrubber) Status() api.BlockstoreScrubStatus {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	return s.status
+}
+
+// Quarantined returns the CIDs of the corrupt blocks which haven't been
+// re-fetched yet
+func (s *Scrubber) Quarantined(ctx context.Context) ([]cid.Cid, error) {
+	res, err := s.ds.Query(ctx, query.Query{KeysOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := res.Rest()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]cid.Cid, 0, len(entries))
+	for _, e := range entries {
+		c, err := cid.Parse(datastore.RawKey(e.Key).BaseNamespace())
+		if err != nil {
+			log.Warnf("invalid quarantine key %s: %s", e.Key, err)
+			continue
+		}
+		out = append(out, c)
+	}
+
+	return out, nil
+}
+
+func (s *Scrubber) schedule() {
+	defer s.wg.Done()
+
+	next := time.Now().Add(s.cfg.Interval)
+	for {
+		s.lk.Lock()
+		s.status.NextPass = next
+		s.lk.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		}
+		next = next.Add(s.cfg.Interval)
+
+		if err := s.begin(); err != nil {
+			log.Warnf("skipping periodic blockstore scrub: %s", err)
+			continue
+		}
+		s.scrub()
+	}
+}
+
+func (s *Scrubber) begin() error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	if s.running {
+		return ErrScrubRunning
+	}
+	s.running = true
+
+	s.status = api.BlockstoreScrubStatus{
+		Running:     true,
+		Periodic:    s.status.Periodic,
+		NextPass:    s.status.NextPass,
+		Start:       time.Now(),
+		Quarantined: s.status.Quarantined,
+	}
+
+	return nil
+}
+
+func (s *Scrubber) scrub() {
+	log.Info("starting blockstore scrub")
+
+	// give blocks quarantined in earlier passes another chance
+	err := s.refetchQuarantined()
+	if err == nil {
+		err = s.scrubPass()
+	}
+
+	stats.Record(s.ctx, metrics.BlockstoreScrubPasses.M(1))
+
+	s.lk.Lock()
+	s.running = false
+	s.status.Running = false
+	s.status.End = time.Now()
+	if err != nil {
+		s.status.Error = err.Error()
+	}
+	st := s.status
+	s.lk.Unlock()
+
+	if err != nil {
+		log.Errorf("blockstore scrub failed: %s", err)
+		return
+	}
+
+	log.Infow("blockstore scrub done", "took", st.End.Sub(st.Start), "checked", st.Checked,
+		"corrupt", st.Corrupt, "refetched", st.Refetched, "quarantined", st.Quarantined)
+}
+
+func (s *Scrubber) scrubPass() error {
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+
+	keys, err := s.bs.AllKeysChan(ctx)
+	if err != nil {
+		return xerrors.Errorf("iterating blockstore keys: %w", err)
+	}
+
+	var limiter *rate.Limiter
+	if s.cfg.MaxBlocksPerSecond > 0 {
+		limiter = rate.NewLimiter(rate.Limit(s.cfg.MaxBlocksPerSecond), 1)
+	}
+
+	for c := range keys {
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				return err
+			}
+		}
+
+		if err := s.check(ctx, c); err != nil {
+			return err
+		}
+	}
+
+	return s.ctx.Err()
+}
+
+// check verifies a single block, quarantining and re-fetching it if corrupt
+func (s *Scrubber) check(ctx context.Context, c cid.Cid) error {
+	if c.Prefix().MhType == mh.IDENTITY {
+		return nil
+	}
+
+	var corrupt []byte
+	var size int
+	err := s.bs.View(ctx, c, func(data []byte) error {
+		size = len(data)
+
+		ok, err := verify(c, data)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			corrupt = make([]byte, len(data))
+			copy(corrupt, data)
+		}
+		return nil
+	})
+	switch {
+	case ipld.IsNotFound(err):
+		// deleted since we started iterating
+		return nil
+	case err != nil:
+		log.Warnf("error verifying block %s: %s", c, err)
+		return nil
+	}
+
+	s.lk.Lock()
+	s.status.Checked++
+	s.status.CheckedBytes += int64(size)
+	s.lk.Unlock()
+
+	stats.Record(ctx,
+		metrics.BlockstoreScrubChecked.M(1),
+		metrics.BlockstoreScrubCheckedBytes.M(int64(size)))
+
+	if corrupt == nil {
+		return nil
+	}
+
+	log.Errorw("found corrupt block", "cid", c, "size", size)
+	stats.Record(ctx, metrics.BlockstoreScrubCorrupt.M(1))
+
+	if err := s.quarantine(ctx, c, corrupt); err != nil {
+		return xerrors.Errorf("quarantining corrupt block %s: %w", c, err)
+	}
+
+	s.lk.Lock()
+	s.status.Corrupt++
+	s.lk.Unlock()
+
+	s.refetch(ctx, c)
+	return nil
+}
+
+// quarantine moves the corrupt block data out of the blockstore, so that it
+// isn't served anymore, but can still be inspected
+func (s *Scrubber) quarantine(ctx context.Context, c cid.Cid, data []byte) error {
+	if err := s.ds.Put(ctx, quarantineKey(c), data); err != nil {
+		return err
+	}
+
+	if err := s.bs.DeleteBlock(ctx, c); err != nil {
+		return xerrors.Errorf("deleting corrupt block: %w", err)
+	}
+
+	s.lk.Lock()
+	s.status.Quarantined++
+	quarantined := s.status.Quarantined
+	s.lk.Unlock()
+
+	stats.Record(ctx, metrics.BlockstoreScrubQuarantined.M(quarantined))
+	return nil
+}
+
+// refetch fetches a quarantined block from the network and puts it back in the
+// blockstore; it returns whether the block was recovered.
+func (s *Scrubber) refetch(ctx context.Context, c cid.Cid) bool {
+	if !s.cfg.Refetch || s.fetcher == nil {
+		return false
+	}
+
+	if s.cfg.RefetchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.RefetchTimeout)
+		defer cancel()
+	}
+
+	blk, err := s.fetchVerified(ctx, c)
+	if err != nil {
+		log.Warnw("failed to re-fetch corrupt block", "cid", c, "error", err)
+		stats.Record(ctx, metrics.BlockstoreScrubRefetchFailed.M(1))
+		return false
+	}
+
+	if err := s.bs.Put(ctx, blk); err != nil {
+		log.Errorw("failed to store re-fetched block", "cid", c, "error", err)
+		stats.Record(ctx, metrics.BlockstoreScrubRefetchFailed.M(1))
+		return false
+	}
+
+	if err := s.ds.Delete(ctx, quarantineKey(c)); err != nil {
+		log.Errorw("failed to remove re-fetched block from quarantine", "cid", c, "error", err)
+	}
+
+	s.lk.Lock()
+	s.status.Refetched++
+	s.status.Quarantined--
+	quarantined := s.status.Quarantined
+	s.lk.Unlock()
+
+	log.Infow("re-fetched corrupt block", "cid", c)
+	stats.Record(ctx,
+		metrics.BlockstoreScrubRefetched.M(1),
+		metrics.BlockstoreScrubQuarantined.M(quarantined))
+
+	return true
+}
+
+func (s *Scrubber) fetchVerified(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	blk, err := s.fetcher.GetBlock(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := verify(c, blk.RawData())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, xerrors.Errorf("fetched block doesn't match its cid")
+	}
+
+	return blk, nil
+}
+
+func (s *Scrubber) refetchQuarantined() error {
+	if !s.cfg.Refetch || s.fetcher == nil {
+		return nil
+	}
+
+	quarantined, err := s.Quarantined(s.ctx)
+	if err != nil {
+		return xerrors.Errorf("loading quarantined blocks: %w", err)
+	}
+
+	for _, c := range quarantined {
+		if s.ctx.Err() != nil {
+			return s.ctx.Err()
+		}
+		s.refetch(s.ctx, c)
+	}
+
+	return nil
+}
+
+// verify returns whether the data hashes to the cid
+func verify(c cid.Cid, data []byte) (bool, error) {
+	sum, err := c.Prefix().Sum(data)
+	if err != nil {
+		return false, xerrors.Errorf("hashing block: %w", err)
+	}
+
+	return bytes.Equal(sum.Hash(), c.Hash()), nil
+}
+
+func quarantineKey(c cid.Cid) datastore.Key {
+	return datastore.NewKey(c.String())
+}
@@ -0,0 +1,122 @@
+//stm: #unit
+package scrub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+)
+
+type mapFetcher map[cid.Cid]blocks.Block
+
+func (f mapFetcher) GetBlock(_ context.Context, c cid.Cid) (blocks.Block, error) {
+	if blk, ok := f[c]; ok {
+		return blk, nil
+	}
+	return nil, ipld.ErrNotFound{Cid: c}
+}
+
+func (f mapFetcher) GetBlocks(_ context.Context, _ []cid.Cid) (<-chan blocks.Block, error) {
+	panic("not implemented")
+}
+
+func waitScrub(t *testing.T, s *Scrubber) api.BlockstoreScrubStatus {
+	require.Eventually(t, func() bool {
+		return !s.Status().Running
+	}, 5*time.Second, 10*time.Millisecond)
+
+	return s.Status()
+}
+
+func TestScrubRefetch(t *testing.T) {
+	ctx := context.Background()
+
+	good := blocks.NewBlock([]byte("good"))
+	orig := blocks.NewBlock([]byte("original"))
+	corrupt, err := blocks.NewBlockWithCid([]byte("bitrot"), orig.Cid())
+	require.NoError(t, err)
+
+	bs := blockstore.NewMemory()
+	require.NoError(t, bs.PutMany(ctx, []blocks.Block{good, corrupt}))
+
+	s := NewScrubber(bs, dssync.MutexWrap(datastore.NewMapDatastore()), mapFetcher{orig.Cid(): orig}, Config{Refetch: true})
+	require.NoError(t, s.Start(ctx))
+	defer s.Stop(ctx) //nolint:errcheck
+
+	require.NoError(t, s.Run())
+	st := waitScrub(t, s)
+	require.Empty(t, st.Error)
+	require.EqualValues(t, 2, st.Checked)
+	require.EqualValues(t, 1, st.Corrupt)
+	require.EqualValues(t, 1, st.Refetched)
+	require.EqualValues(t, 0, st.Quarantined)
+
+	// the corrupt block was replaced with the re-fetched one
+	blk, err := bs.Get(ctx, orig.Cid())
+	require.NoError(t, err)
+	require.Equal(t, orig.RawData(), blk.RawData())
+
+	q, err := s.Quarantined(ctx)
+	require.NoError(t, err)
+	require.Empty(t, q)
+}
+
+func TestScrubQuarantine(t *testing.T) {
+	ctx := context.Background()
+
+	orig := blocks.NewBlock([]byte("original"))
+	corrupt, err := blocks.NewBlockWithCid([]byte("bitrot"), orig.Cid())
+	require.NoError(t, err)
+
+	bs := blockstore.NewMemory()
+	require.NoError(t, bs.Put(ctx, corrupt))
+
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	fetcher := mapFetcher{}
+
+	s := NewScrubber(bs, ds, fetcher, Config{Refetch: true})
+	require.NoError(t, s.Start(ctx))
+
+	require.NoError(t, s.Run())
+	st := waitScrub(t, s)
+	require.EqualValues(t, 1, st.Corrupt)
+	require.EqualValues(t, 0, st.Refetched)
+	require.EqualValues(t, 1, st.Quarantined)
+
+	// the corrupt block isn't served anymore
+	has, err := bs.Has(ctx, orig.Cid())
+	require.NoError(t, err)
+	require.False(t, has)
+
+	q, err := s.Quarantined(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []cid.Cid{orig.Cid()}, q)
+
+	require.NoError(t, s.Stop(ctx))
+
+	// the quarantine survives restarts, and the block is re-fetched on the next
+	// pass once it is available
+	fetcher[orig.Cid()] = orig
+
+	s = NewScrubber(bs, ds, fetcher, Config{Refetch: true})
+	require.NoError(t, s.Start(ctx))
+	defer s.Stop(ctx) //nolint:errcheck
+	require.EqualValues(t, 1, s.Status().Quarantined)
+
+	require.NoError(t, s.Run())
+	st = waitScrub(t, s)
+	require.EqualValues(t, 0, st.Corrupt)
+	require.EqualValues(t, 1, st.Refetched)
+	require.EqualValues(t, 0, st.Quarantined)
+	require.EqualValues(t, 1, st.Checked)
+}
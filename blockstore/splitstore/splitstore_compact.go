@@ -15,11 +15,13 @@ import (
 	ipld "github.com/ipfs/go-ipld-format"
 	cbg "github.com/whyrusleeping/cbor-gen"
 	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-state-types/abi"
 
+	bstore "github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/metrics"
@@ -489,6 +491,34 @@ func (s *SplitStore) compact(curTs *types.TipSet) {
 	if err != nil {
 		log.Errorf("COMPACTION ERROR: %s", err)
 	}
+
+	s.recordStoreSizes()
+}
+
+func (s *SplitStore) recordPhaseTime(phase string, start time.Time) {
+	ctx, _ := tag.New(s.ctx, tag.Upsert(metrics.CompactionPhase, phase))
+	stats.Record(ctx, metrics.SplitstoreCompactionPhaseTime.M(time.Since(start).Seconds()))
+}
+
+// recordStoreSizes records the size of the hotstore and the coldstore, when they can report it
+func (s *SplitStore) recordStoreSizes() {
+	if sizer, ok := s.hot.(bstore.BlockstoreSize); ok {
+		size, err := sizer.Size()
+		if err != nil {
+			log.Warnf("error getting hotstore size: %s", err)
+		} else {
+			stats.Record(s.ctx, metrics.SplitstoreHotstoreSize.M(size))
+		}
+	}
+
+	if sizer, ok := s.cold.(bstore.BlockstoreSize); ok {
+		size, err := sizer.Size()
+		if err != nil {
+			log.Warnf("error getting coldstore size: %s", err)
+		} else {
+			stats.Record(s.ctx, metrics.SplitstoreColdstoreSize.M(size))
+		}
+	}
 }
 
 func (s *SplitStore) doCompact(curTs *types.TipSet) error {
@@ -560,6 +590,7 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 	s.markSetSize = *count + *count>>2 // overestimate a bit
 
 	log.Infow("marking done", "took", time.Since(startMark), "marked", *count)
+	s.recordPhaseTime("mark", startMark)
 
 	if err := s.checkClosing(); err != nil {
 		return err
@@ -618,6 +649,7 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 	}
 
 	log.Infow("cold collection done", "took", time.Since(startCollect))
+	s.recordPhaseTime("collect", startCollect)
 
 	log.Infow("compaction stats", "hot", hotCnt, "cold", coldCnt)
 	stats.Record(s.ctx, metrics.SplitstoreCompactionHot.M(int64(hotCnt)))
@@ -651,6 +683,7 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 			return xerrors.Errorf("error moving cold objects: %w", err)
 		}
 		log.Infow("moving done", "took", time.Since(startMove))
+		s.recordPhaseTime("move", startMove)
 
 		if err := s.checkClosing(); err != nil {
 			return err
@@ -697,6 +730,7 @@ func (s *SplitStore) doCompact(curTs *types.TipSet) error {
 		return xerrors.Errorf("error purging cold objects: %w", err)
 	}
 	log.Infow("purging cold objects from hotstore done", "took", time.Since(startPurge))
+	s.recordPhaseTime("purge", startPurge)
 
 	s.endCriticalSection()
 
@@ -848,6 +882,11 @@ func (s *SplitStore) walkChain(ts *types.TipSet, inclState, inclMsgs abi.ChainEp
 		})
 
 		if err != nil {
+			// nodes booted from a latest state snapshot don't have the chain history, which
+			// they wouldn't keep anyway with a discarding coldstore
+			if s.cfg.DiscardColdBlocks && ipld.IsNotFound(err) {
+				return nil
+			}
 			return xerrors.Errorf("error unmarshaling block header (cid: %s): %w", c, err)
 		}
 
@@ -1111,6 +1150,12 @@ func (s *SplitStore) getSize(c cid.Cid) (int, error) {
 func (s *SplitStore) moveColdBlocks(coldr *ColdSetReader) error {
 	batch := make([]blocks.Block, 0, batchSize)
 
+	var moveCnt int
+	defer func() {
+		log.Infow("moved cold objects", "moved", moveCnt)
+		stats.Record(s.ctx, metrics.SplitstoreCompactionMoved.M(int64(moveCnt)))
+	}()
+
 	err := coldr.ForEach(func(c cid.Cid) error {
 		if err := s.checkClosing(); err != nil {
 			return err
@@ -1132,6 +1177,7 @@ func (s *SplitStore) moveColdBlocks(coldr *ColdSetReader) error {
 			if err != nil {
 				return xerrors.Errorf("error putting batch to coldstore: %w", err)
 			}
+			moveCnt += len(batch)
 			batch = batch[:0]
 		}
 
@@ -1147,6 +1193,7 @@ func (s *SplitStore) moveColdBlocks(coldr *ColdSetReader) error {
 		if err != nil {
 			return xerrors.Errorf("error putting batch to coldstore: %w", err)
 		}
+		moveCnt += len(batch)
 	}
 
 	return nil
@@ -1159,6 +1206,7 @@ func (s *SplitStore) purge(coldr *ColdSetReader, checkpoint *Checkpoint, markSet
 	var purgeCnt, liveCnt int
 	defer func() {
 		log.Infow("purged cold objects", "purged", purgeCnt, "live", liveCnt)
+		stats.Record(s.ctx, metrics.SplitstoreCompactionDead.M(int64(purgeCnt)))
 	}()
 
 	deleteBatch := func() error {
@@ -1310,6 +1358,7 @@ func (s *SplitStore) completePurge(coldr *ColdSetReader, checkpoint *Checkpoint,
 	var purgeCnt, liveCnt int
 	defer func() {
 		log.Infow("purged cold objects", "purged", purgeCnt, "live", liveCnt)
+		stats.Record(s.ctx, metrics.SplitstoreCompactionDead.M(int64(purgeCnt)))
 	}()
 
 	deleteBatch := func() error {
@@ -13,9 +13,11 @@ func (s *SplitStore) gcHotstore() {
 		opts = append(opts, bstore.WithFullGC(true))
 	}
 
+	startGC := time.Now()
 	if err := s.gcBlockstore(s.hot, opts); err != nil {
 		log.Warnf("error garbage collecting hostore: %s", err)
 	}
+	s.recordPhaseTime("gc", startGC)
 }
 
 func (s *SplitStore) gcBlockstore(b bstore.Blockstore, opts []bstore.BlockstoreGCOption) error {
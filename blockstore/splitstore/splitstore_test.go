@@ -240,6 +240,76 @@ func TestSplitStoreCompactionWithBadger(t *testing.T) {
 	testSplitStore(t, &Config{MarkSetType: "badger"})
 }
 
+func TestSplitStorePrunedHistory(t *testing.T) {
+	//stm: @SPLITSTORE_SPLITSTORE_OPEN_001, @SPLITSTORE_SPLITSTORE_CLOSE_001
+	//stm: @SPLITSTORE_SPLITSTORE_PUT_001
+	ctx := context.Background()
+	chain := &mockChain{t: t}
+
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	hot := newMockStore()
+	cold := newMockStore()
+
+	garbage := blocks.NewBlock([]byte{1, 2, 3})
+	err := cold.Put(ctx, garbage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the genesis header isn't stored, as when booting from a latest state snapshot
+	genBlock := mock.MkBlock(nil, 0, 0)
+	genBlock.Messages = garbage.Cid()
+	genBlock.ParentMessageReceipts = garbage.Cid()
+	genBlock.ParentStateRoot = garbage.Cid()
+	genBlock.Timestamp = uint64(time.Now().Unix())
+
+	curTs := mock.TipSet(genBlock)
+	chain.push(curTs)
+
+	ss, err := Open(t.TempDir(), ds, hot, cold, &Config{MarkSetType: "map", DiscardColdBlocks: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close() //nolint
+
+	err = ss.Start(chain, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i < 10; i++ {
+		blk := mock.MkBlock(curTs, uint64(i), uint64(i))
+		blk.Messages = garbage.Cid()
+		blk.ParentMessageReceipts = garbage.Cid()
+		blk.ParentStateRoot = garbage.Cid()
+		blk.Timestamp = uint64(time.Now().Unix())
+
+		sblk, err := blk.ToStorageBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ss.Put(ctx, sblk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		curTs = mock.TipSet(blk)
+		chain.push(curTs)
+
+		ss.txnSyncMx.Lock()
+		ss.txnSync = true
+		ss.txnSyncCond.Broadcast()
+		ss.txnSyncMx.Unlock()
+		for atomic.LoadInt32(&ss.compacting) == 1 {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+
+	// compaction went through, even though the chain history is missing
+	if ss.baseEpoch == 0 {
+		t.Fatal("splitstore wasn't compacted")
+	}
+}
+
 func TestSplitStoreSuppressCompactionNearUpgrade(t *testing.T) {
 	//stm: @SPLITSTORE_SPLITSTORE_OPEN_001, @SPLITSTORE_SPLITSTORE_CLOSE_001
 	//stm: @SPLITSTORE_SPLITSTORE_PUT_001, @SPLITSTORE_SPLITSTORE_ADD_PROTECTOR_001
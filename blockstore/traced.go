@@ -0,0 +1,66 @@
+package blockstore
+
+import (
+	"context"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"go.opencensus.io/trace"
+)
+
+// tracedBlockstore records the reads made on behalf of a traced operation,
+// like a sampled API call, as spans of its trace
+type tracedBlockstore struct {
+	Blockstore
+}
+
+var _ Blockstore = (*tracedBlockstore)(nil)
+
+// NewTracedBlockstore wraps the blockstore so that reads made with the
+// context of a sampled span are recorded as its children. Reads outside of a
+// sampled span aren't traced.
+func NewTracedBlockstore(bs Blockstore) Blockstore {
+	return &tracedBlockstore{Blockstore: bs}
+}
+
+func (t *tracedBlockstore) Get(ctx context.Context, k cid.Cid) (blocks.Block, error) {
+	if !sampled(ctx) {
+		return t.Blockstore.Get(ctx, k)
+	}
+
+	ctx, span := trace.StartSpan(ctx, "blockstore.Get")
+	defer span.End()
+	span.AddAttributes(trace.StringAttribute("cid", k.String()))
+
+	blk, err := t.Blockstore.Get(ctx, k)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: err.Error()})
+		return nil, err
+	}
+	span.AddAttributes(trace.Int64Attribute("size", int64(len(blk.RawData()))))
+	return blk, nil
+}
+
+func (t *tracedBlockstore) View(ctx context.Context, k cid.Cid, callback func([]byte) error) error {
+	if !sampled(ctx) {
+		return t.Blockstore.View(ctx, k, callback)
+	}
+
+	ctx, span := trace.StartSpan(ctx, "blockstore.View")
+	defer span.End()
+	span.AddAttributes(trace.StringAttribute("cid", k.String()))
+
+	err := t.Blockstore.View(ctx, k, func(data []byte) error {
+		span.AddAttributes(trace.Int64Attribute("size", int64(len(data))))
+		return callback(data)
+	})
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: err.Error()})
+	}
+	return err
+}
+
+func sampled(ctx context.Context) bool {
+	span := trace.FromContext(ctx)
+	return span != nil && span.IsRecordingEvents()
+}
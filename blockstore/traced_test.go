@@ -0,0 +1,49 @@
+//stm: #unit
+package blockstore
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/trace"
+)
+
+type spanRecorder struct {
+	lk    sync.Mutex
+	names []string
+}
+
+func (r *spanRecorder) ExportSpan(s *trace.SpanData) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	r.names = append(r.names, s.Name)
+}
+
+func TestTracedBlockstore(t *testing.T) {
+	ctx := context.Background()
+
+	rec := &spanRecorder{}
+	trace.RegisterExporter(rec)
+	defer trace.UnregisterExporter(rec)
+
+	bs := NewTracedBlockstore(NewMemory())
+	blk := blocks.NewBlock([]byte("traced"))
+	require.NoError(t, bs.Put(ctx, blk))
+
+	// reads outside of a sampled span aren't traced
+	_, err := bs.Get(ctx, blk.Cid())
+	require.NoError(t, err)
+
+	sctx, span := trace.StartSpan(ctx, "api.Test", trace.WithSampler(trace.AlwaysSample()))
+	_, err = bs.Get(sctx, blk.Cid())
+	require.NoError(t, err)
+	require.NoError(t, bs.View(sctx, blk.Cid(), func([]byte) error { return nil }))
+	span.End()
+
+	rec.lk.Lock()
+	defer rec.lk.Unlock()
+	require.Equal(t, []string{"blockstore.Get", "blockstore.View", "api.Test"}, rec.names)
+}
@@ -28,6 +28,10 @@ func DhtProtocolName(netName dtypes.NetworkName) protocol.ID {
 	return protocol.ID("/fil/kad/" + string(netName))
 }
 
+func PeerExchangeProtocolName(netName dtypes.NetworkName) protocol.ID {
+	return protocol.ID("/fil/pex/" + string(netName) + "/1.0.0")
+}
+
 func SetAddressNetwork(n address.Network) {
 	address.CurrentNetwork = n
 }
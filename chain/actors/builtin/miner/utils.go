@@ -5,7 +5,10 @@ import (
 
 	"github.com/filecoin-project/go-bitfield"
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin/v8/util/smoothing"
 	"github.com/filecoin-project/go-state-types/network"
+	miner8 "github.com/filecoin-project/specs-actors/v8/actors/builtin/miner"
+	smoothing8 "github.com/filecoin-project/specs-actors/v8/actors/util/smoothing"
 )
 
 func AllPartSectors(mas State, sget func(Partition) (bitfield.BitField, error)) (bitfield.BitField, error) {
@@ -86,3 +89,23 @@ func WindowPoStProofTypeFromSectorSize(ssize abi.SectorSize) (abi.RegisteredPoSt
 		return 0, xerrors.Errorf("unsupported sector size for miner: %v", ssize)
 	}
 }
+
+// TerminationPenalty computes the penalty the miner actor charges when the sector is
+// terminated at the given epoch, with the given reward and network power estimates
+func TerminationPenalty(ssize abi.SectorSize, epoch abi.ChainEpoch, s *SectorOnChainInfo, rewardEstimate, networkQAPowerEstimate smoothing.FilterEstimate) abi.TokenAmount {
+	return miner8.PledgePenaltyForTermination(
+		s.ExpectedDayReward,
+		epoch-s.Activation,
+		s.ExpectedStoragePledge,
+		smoothing8.FilterEstimate(networkQAPowerEstimate),
+		QAPowerForSector(ssize, s),
+		smoothing8.FilterEstimate(rewardEstimate),
+		s.ReplacedDayReward,
+		s.ReplacedSectorAge,
+	)
+}
+
+// QAPowerForSector returns the quality adjusted power of the sector
+func QAPowerForSector(ssize abi.SectorSize, s *SectorOnChainInfo) abi.StoragePower {
+	return miner8.QAPowerForWeight(ssize, s.Expiration-s.Activation, s.DealWeight, s.VerifiedDealWeight)
+}
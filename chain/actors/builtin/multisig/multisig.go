@@ -173,6 +173,7 @@ type ProposalHashData = msig8.ProposalHashData
 type ProposeReturn = msig8.ProposeReturn
 type ProposeParams = msig8.ProposeParams
 type ApproveReturn = msig8.ApproveReturn
+type TxnIDParams = msig8.TxnIDParams
 
 func txnParams(id uint64, data *ProposalHashData) ([]byte, error) {
 	params := msig8.TxnIDParams{ID: msig8.TxnID(id)}
@@ -186,13 +187,23 @@ func txnParams(id uint64, data *ProposalHashData) ([]byte, error) {
 		if data.To == address.Undef {
 			return nil, xerrors.Errorf("proposed destination address must be set")
 		}
-		pser, err := data.Serialize()
+		hash, err := ProposalHash(data)
 		if err != nil {
 			return nil, err
 		}
-		hash := blake2b.Sum256(pser)
-		params.ProposalHash = hash[:]
+		params.ProposalHash = hash
 	}
 
 	return actors.SerializeParams(&params)
 }
+
+// ProposalHash returns the hash identifying a transaction in approvals and
+// cancellations
+func ProposalHash(data *ProposalHashData) ([]byte, error) {
+	pser, err := data.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	hash := blake2b.Sum256(pser)
+	return hash[:], nil
+}
@@ -0,0 +1,297 @@
+package chain
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	bstore "github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+const (
+	// backfillFetchBatch is the number of blocks requested from the network at once
+	// when backfilling receipts and state
+	backfillFetchBatch = 512
+	// backfillFetchTimeout bounds the time spent fetching a single batch of blocks
+	backfillFetchTimeout = 5 * time.Minute
+)
+
+// BlockFetcher fetches blocks by CID from the network, e.g. bitswap
+type BlockFetcher interface {
+	GetBlocks(ctx context.Context, cids []cid.Cid) (<-chan blocks.Block, error)
+}
+
+// Backfill fetches the historical data selected by spec for the tipsets in the
+// range [spec.From, spec.To] of the current chain from the network, and stores
+// it in the chain and state blockstores. The block headers of the range must be
+// present locally.
+//
+// Messages are fetched with the chain exchange protocol and validated against
+// the message roots of the block headers. Receipts and state are the receipt and
+// state roots referenced by the block headers of the range; they are fetched
+// with bitswap, and verified to hash to their CIDs. Objects already present in
+// the blockstore are assumed to be complete DAGs and are not descended into.
+//
+// The progress is reported to cb after each processed tipset.
+func (syncer *Syncer) Backfill(ctx context.Context, spec api.BackfillSpec, fetcher BlockFetcher, cb func(api.BackfillProgress)) error {
+	if spec.From > spec.To {
+		return xerrors.Errorf("invalid range: from (%d) is above to (%d)", spec.From, spec.To)
+	}
+	if !spec.Messages && !spec.Receipts && !spec.State {
+		return xerrors.Errorf("nothing to backfill")
+	}
+	if (spec.Receipts || spec.State) && fetcher == nil {
+		return xerrors.Errorf("backfilling receipts and state requires a block fetcher")
+	}
+
+	head := syncer.store.GetHeaviestTipSet()
+	if spec.To > head.Height() {
+		return xerrors.Errorf("range end %d is above the current head (%d)", spec.To, head.Height())
+	}
+
+	headers, err := syncer.backfillHeaders(ctx, head, spec.From, spec.To)
+	if err != nil {
+		return err
+	}
+
+	progress := api.BackfillProgress{Total: len(headers)}
+
+	if spec.Messages {
+		progress.Stage = api.BackfillStageMessages
+		if err := syncer.backfillMessages(ctx, headers, &progress, cb); err != nil {
+			return xerrors.Errorf("backfilling messages: %w", err)
+		}
+	}
+
+	if spec.Receipts || spec.State {
+		progress.Stage = api.BackfillStageObjects
+		progress.TipSets = 0
+
+		seen := cid.NewSet()
+		for i := len(headers) - 1; i >= 0; i-- {
+			ts := headers[i]
+
+			var roots []cid.Cid
+			if spec.Receipts {
+				roots = append(roots, ts.Blocks()[0].ParentMessageReceipts)
+			}
+			if spec.State {
+				roots = append(roots, ts.ParentState())
+			}
+
+			if err := fetchDAG(ctx, fetcher, syncer.store.StateBlockstore(), roots, seen, func(blk blocks.Block) {
+				progress.Objects++
+				progress.Bytes += int64(len(blk.RawData()))
+			}); err != nil {
+				return xerrors.Errorf("backfilling objects of tipset %s (height %d): %w", ts.Key(), ts.Height(), err)
+			}
+
+			progress.Height = ts.Height()
+			progress.TipSets++
+			cb(progress)
+		}
+	}
+
+	return nil
+}
+
+// backfillHeaders loads the tipsets of the range [from, to] from the chain ending
+// in head, ordered from the highest to the lowest
+func (syncer *Syncer) backfillHeaders(ctx context.Context, head *types.TipSet, from, to abi.ChainEpoch) ([]*types.TipSet, error) {
+	ts, err := syncer.store.GetTipsetByHeight(ctx, to, head, true)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset at height %d: %w", to, err)
+	}
+
+	var headers []*types.TipSet
+	for ts.Height() >= from {
+		headers = append(headers, ts)
+		if ts.Height() == 0 {
+			break
+		}
+
+		ts, err = syncer.store.LoadTipSet(ctx, ts.Parents())
+		if err != nil {
+			return nil, xerrors.Errorf("loading parent of tipset at height %d (block headers must be present locally): %w", headers[len(headers)-1].Height(), err)
+		}
+	}
+
+	return headers, nil
+}
+
+// backfillMessages fetches the messages of the headers missing from the chain
+// blockstore, from the lowest tipset to the highest
+func (syncer *Syncer) backfillMessages(ctx context.Context, headers []*types.TipSet, progress *api.BackfillProgress, cb func(api.BackfillProgress)) error {
+	for i := len(headers) - 1; i >= 0; {
+		fts, err := syncer.store.TryFillTipSet(ctx, headers[i])
+		if err != nil {
+			return err
+		}
+		if fts != nil {
+			progress.Height = headers[i].Height()
+			progress.TipSets++
+			cb(*progress)
+			i--
+			continue
+		}
+
+		batchSize := concurrentSyncRequests * syncRequestBatchSize
+		if i < batchSize {
+			batchSize = i + 1
+		}
+
+		startOffset := i + 1 - batchSize
+		bstout, err := syncer.fetchMessages(ctx, headers[startOffset:startOffset+batchSize], startOffset)
+		if err != nil {
+			return xerrors.Errorf("failed to fetch messages: %w", err)
+		}
+
+		for bsi := 0; bsi < len(bstout); bsi++ {
+			bs := bstore.NewMemory()
+
+			this := headers[i-bsi]
+			bstip := bstout[len(bstout)-(bsi+1)]
+			if _, err := zipTipSetAndMessages(cbor.NewCborStore(bs), this, bstip.Bls, bstip.Secpk, bstip.BlsIncludes, bstip.SecpkIncludes); err != nil {
+				return xerrors.Errorf("validating messages of tipset %s (height %d): %w", this.Key(), this.Height(), err)
+			}
+
+			if err := persistMessages(ctx, bs, bstip); err != nil {
+				return err
+			}
+
+			if err := copyBlockstore(ctx, bs, syncer.store.ChainBlockstore()); err != nil {
+				return xerrors.Errorf("storing messages: %w", err)
+			}
+
+			progress.Height = this.Height()
+			progress.TipSets++
+			progress.Messages++
+			cb(*progress)
+		}
+
+		i -= batchSize
+	}
+
+	return nil
+}
+
+// fetchDAG fetches the blocks of the DAGs under roots which are missing from bs,
+// one level at a time, and stores them in bs. Present blocks aren't descended
+// into, and blocks in seen aren't visited again.
+func fetchDAG(ctx context.Context, fetcher BlockFetcher, bs bstore.Blockstore, roots []cid.Cid, seen *cid.Set, fetched func(blocks.Block)) error {
+	missing := func(cids []cid.Cid) ([]cid.Cid, error) {
+		var out []cid.Cid
+		for _, c := range cids {
+			if !seen.Visit(c) {
+				continue
+			}
+
+			has, err := bs.Has(ctx, c)
+			if err != nil {
+				return nil, xerrors.Errorf("checking for block %s: %w", c, err)
+			}
+			if !has {
+				out = append(out, c)
+			}
+		}
+		return out, nil
+	}
+
+	frontier, err := missing(roots)
+	if err != nil {
+		return err
+	}
+
+	for len(frontier) > 0 {
+		var next []cid.Cid
+
+		for start := 0; start < len(frontier); start += backfillFetchBatch {
+			end := start + backfillFetchBatch
+			if end > len(frontier) {
+				end = len(frontier)
+			}
+
+			blks, err := fetchBlocks(ctx, fetcher, frontier[start:end])
+			if err != nil {
+				return err
+			}
+
+			if err := bs.PutMany(ctx, blks); err != nil {
+				return xerrors.Errorf("storing fetched blocks: %w", err)
+			}
+
+			for _, blk := range blks {
+				fetched(blk)
+
+				if blk.Cid().Prefix().Codec != cid.DagCBOR {
+					continue
+				}
+
+				var links []cid.Cid
+				if err := cbg.ScanForLinks(bytes.NewReader(blk.RawData()), func(c cid.Cid) {
+					links = append(links, c)
+				}); err != nil {
+					return xerrors.Errorf("scanning links of block %s: %w", blk.Cid(), err)
+				}
+
+				linksMissing, err := missing(links)
+				if err != nil {
+					return err
+				}
+				next = append(next, linksMissing...)
+			}
+		}
+
+		frontier = next
+	}
+
+	return nil
+}
+
+// fetchBlocks fetches all the given blocks, verifying that they hash to their CIDs
+func fetchBlocks(ctx context.Context, fetcher BlockFetcher, cids []cid.Cid) ([]blocks.Block, error) {
+	ctx, cancel := context.WithTimeout(ctx, backfillFetchTimeout)
+	defer cancel()
+
+	ch, err := fetcher.GetBlocks(ctx, cids)
+	if err != nil {
+		return nil, xerrors.Errorf("requesting blocks: %w", err)
+	}
+
+	got := make(map[cid.Cid]blocks.Block, len(cids))
+	for blk := range ch {
+		c, err := blk.Cid().Prefix().Sum(blk.RawData())
+		if err != nil {
+			return nil, xerrors.Errorf("hashing block %s: %w", blk.Cid(), err)
+		}
+		if !c.Equals(blk.Cid()) {
+			return nil, xerrors.Errorf("fetched block doesn't match its cid %s", blk.Cid())
+		}
+
+		got[blk.Cid()] = blk
+	}
+
+	out := make([]blocks.Block, 0, len(cids))
+	for _, c := range cids {
+		blk, ok := got[c]
+		if !ok {
+			if ctx.Err() != nil && ctx.Err() != context.DeadlineExceeded {
+				return nil, ctx.Err()
+			}
+			return nil, xerrors.Errorf("failed to fetch %d of %d blocks (e.g. %s)", len(cids)-len(got), len(cids), c)
+		}
+		out = append(out, blk)
+	}
+
+	return out, nil
+}
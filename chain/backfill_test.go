@@ -0,0 +1,77 @@
+//stm: #unit
+package chain
+
+import (
+	"context"
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/stretchr/testify/require"
+
+	bstore "github.com/filecoin-project/lotus/blockstore"
+)
+
+// storeFetcher serves blocks from a blockstore, counting the requested blocks
+type storeFetcher struct {
+	bs        bstore.Blockstore
+	requested int
+}
+
+func (f *storeFetcher) GetBlocks(ctx context.Context, cids []cid.Cid) (<-chan blocks.Block, error) {
+	f.requested += len(cids)
+
+	out := make(chan blocks.Block, len(cids))
+	for _, c := range cids {
+		if blk, err := f.bs.Get(ctx, c); err == nil {
+			out <- blk
+		}
+	}
+	close(out)
+	return out, nil
+}
+
+func TestFetchDAG(t *testing.T) {
+	ctx := context.Background()
+
+	remote := bstore.NewMemory()
+	cst := cbor.NewCborStore(remote)
+
+	put := func(v interface{}) cid.Cid {
+		c, err := cst.Put(ctx, v)
+		require.NoError(t, err)
+		return c
+	}
+
+	leafA := put(map[string]interface{}{"v": "a"})
+	leafB := put(map[string]interface{}{"v": "b"})
+	mid := put(map[string]interface{}{"a": leafA, "b": leafB})
+	root := put(map[string]interface{}{"mid": mid, "b": leafB})
+
+	local := bstore.NewMemory()
+	fetcher := &storeFetcher{bs: remote}
+
+	var fetched int
+	require.NoError(t, fetchDAG(ctx, fetcher, local, []cid.Cid{root}, cid.NewSet(), func(blocks.Block) {
+		fetched++
+	}))
+	require.Equal(t, 4, fetched)
+	require.Equal(t, 4, fetcher.requested)
+
+	for _, c := range []cid.Cid{root, mid, leafA, leafB} {
+		has, err := local.Has(ctx, c)
+		require.NoError(t, err)
+		require.True(t, has)
+	}
+
+	// present blocks aren't fetched nor descended into
+	fetcher.requested = 0
+	require.NoError(t, fetchDAG(ctx, fetcher, local, []cid.Cid{root}, cid.NewSet(), func(blocks.Block) {}))
+	require.Zero(t, fetcher.requested)
+
+	// missing blocks fail the fetch
+	other := put(map[string]interface{}{"v": "c"})
+	require.NoError(t, remote.DeleteBlock(ctx, other))
+	require.Error(t, fetchDAG(ctx, fetcher, local, []cid.Cid{other}, cid.NewSet(), func(blocks.Block) {}))
+}
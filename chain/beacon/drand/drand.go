@@ -3,16 +3,20 @@ package drand
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
+	"fmt"
 	"time"
 
 	dchain "github.com/drand/drand/chain"
 	dclient "github.com/drand/drand/client"
-	hclient "github.com/drand/drand/client/http"
 	dlog "github.com/drand/drand/log"
 	gclient "github.com/drand/drand/lp2p/client"
 	"github.com/drand/kyber"
 	kzap "github.com/go-kit/kit/log/zap"
 	lru "github.com/hashicorp/golang-lru"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"github.com/ipfs/go-datastore/query"
 	logging "github.com/ipfs/go-log/v2"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"go.uber.org/zap/zapcore"
@@ -29,6 +33,14 @@ import (
 
 var log = logging.Logger("drand")
 
+const (
+	// cacheRetention is how long the entries of past rounds are kept in the
+	// persistent cache
+	cacheRetention = 7 * 24 * time.Hour
+
+	cachePruneInterval = 6 * time.Hour
+)
+
 type drandPeer struct {
 	addr string
 	tls  bool
@@ -45,12 +57,15 @@ func (dp *drandPeer) IsTLS() bool {
 // DrandBeacon connects Lotus with a drand network in order to provide
 // randomness to the system in a way that's aligned with Filecoin rounds/epochs.
 //
-// We connect to drand peers via their public HTTP endpoints. The peers are
-// enumerated in the drandServers variable.
+// We connect to drand peers via their public HTTP endpoints, and gRPC endpoints
+// configured by the user. The endpoints are health checked and the healthiest,
+// fastest ones are used first. Verified entries are kept in a persistent cache,
+// so that rounds which were seen once don't need the relays again.
 //
 // The root trust for the Drand chain is configured from build.DrandChain.
 type DrandBeacon struct {
 	client dclient.Client
+	relays *relayClient
 
 	pubkey kyber.Point
 
@@ -62,6 +77,11 @@ type DrandBeacon struct {
 	filRoundTime uint64
 
 	localCache *lru.Cache
+	// persistent cache of verified entries, nil when disabled
+	ds datastore.Batching
+
+	cancel context.CancelFunc
+	done   chan struct{}
 }
 
 // DrandHTTPClient interface overrides the user agent used by drand
@@ -69,7 +89,9 @@ type DrandHTTPClient interface {
 	SetUserAgent(string)
 }
 
-func NewDrandBeacon(genesisTs, interval uint64, ps *pubsub.PubSub, config dtypes.DrandConfig) (*DrandBeacon, error) {
+// NewDrandBeacon creates a beacon over the drand network of the config. When ds
+// is not nil, verified entries are persisted to it.
+func NewDrandBeacon(genesisTs, interval uint64, ps *pubsub.PubSub, config dtypes.DrandConfig, ccfg dtypes.DrandClientConfig, ds datastore.Batching) (*DrandBeacon, error) {
 	if genesisTs == 0 {
 		panic("what are you doing this cant be zero")
 	}
@@ -83,14 +105,13 @@ func NewDrandBeacon(genesisTs, interval uint64, ps *pubsub.PubSub, config dtypes
 		log.SugaredLogger.Desugar(), zapcore.InfoLevel))
 
 	var clients []dclient.Client
-	for _, url := range config.Servers {
-		hc, err := hclient.NewWithInfo(url, drandChain, nil)
+	var relays *relayClient
+	if len(config.Servers) > 0 {
+		relays, err = newRelayClient(config.Servers, drandChain, ccfg.HealthCheckInterval)
 		if err != nil {
-			return nil, xerrors.Errorf("could not create http drand client: %w", err)
+			return nil, xerrors.Errorf("could not create drand relays client: %w", err)
 		}
-		hc.(DrandHTTPClient).SetUserAgent("drand-client-lotus/" + build.BuildVersion)
-		clients = append(clients, hc)
-
+		clients = append(clients, relays)
 	}
 
 	opts := []dclient.Option{
@@ -117,18 +138,42 @@ func NewDrandBeacon(genesisTs, interval uint64, ps *pubsub.PubSub, config dtypes
 
 	db := &DrandBeacon{
 		client:     client,
+		relays:     relays,
 		localCache: lc,
 	}
 
+	if ds != nil {
+		db.ds = namespace.Wrap(ds, datastore.NewKey(hex.EncodeToString(drandChain.Hash())))
+	}
+
 	db.pubkey = drandChain.PublicKey
 	db.interval = drandChain.Period
 	db.drandGenTime = uint64(drandChain.GenesisTime)
 	db.filRoundTime = interval
 	db.filGenTime = genesisTs
 
+	if relays != nil {
+		relays.Start()
+	}
+	if db.ds != nil {
+		var ctx context.Context
+		ctx, db.cancel = context.WithCancel(context.Background())
+		db.done = make(chan struct{})
+		go db.runPruneCache(ctx)
+	}
+
 	return db, nil
 }
 
+// Close stops the background work of the beacon and closes the client
+func (db *DrandBeacon) Close() error {
+	if db.cancel != nil {
+		db.cancel()
+		<-db.done
+	}
+	return db.client.Close()
+}
+
 func (db *DrandBeacon) Entry(ctx context.Context, round uint64) <-chan beacon.Response {
 	out := make(chan beacon.Response, 1)
 	if round != 0 {
@@ -151,6 +196,8 @@ func (db *DrandBeacon) Entry(ctx context.Context, round uint64) <-chan beacon.Re
 		} else {
 			br.Entry.Round = resp.Round()
 			br.Entry.Data = resp.Signature()
+			// the client verifies the entries it returns
+			db.persistValue(br.Entry)
 		}
 		log.Debugw("done fetching randomness", "round", round, "took", build.Clock.Since(start))
 		out <- br
@@ -165,13 +212,102 @@ func (db *DrandBeacon) cacheValue(e types.BeaconEntry) {
 
 func (db *DrandBeacon) getCachedValue(round uint64) *types.BeaconEntry {
 	v, ok := db.localCache.Get(round)
-	if !ok {
+	if ok {
+		e, _ := v.(types.BeaconEntry)
+		return &e
+	}
+
+	if db.ds == nil {
+		return nil
+	}
+	data, err := db.ds.Get(context.TODO(), roundKey(round))
+	if err != nil {
+		if err != datastore.ErrNotFound {
+			log.Warnw("failed to read cached beacon entry", "round", round, "error", err)
+		}
 		return nil
 	}
-	e, _ := v.(types.BeaconEntry)
+	e := types.BeaconEntry{Round: round, Data: data}
+	db.localCache.Add(round, e)
 	return &e
 }
 
+// persistValue caches a verified entry, in memory and in the persistent cache
+func (db *DrandBeacon) persistValue(e types.BeaconEntry) {
+	db.cacheValue(e)
+
+	if db.ds == nil {
+		return
+	}
+	if err := db.ds.Put(context.TODO(), roundKey(e.Round), e.Data); err != nil {
+		log.Warnw("failed to persist beacon entry", "round", e.Round, "error", err)
+	}
+}
+
+func (db *DrandBeacon) runPruneCache(ctx context.Context) {
+	defer close(db.done)
+
+	tick := build.Clock.Ticker(cachePruneInterval)
+	defer tick.Stop()
+
+	for {
+		current := dchain.CurrentRound(build.Clock.Now().Unix(), db.interval, int64(db.drandGenTime))
+		if keep := uint64(cacheRetention / db.interval); current > keep {
+			pruned, err := db.pruneCache(ctx, current-keep)
+			if err != nil {
+				log.Warnw("failed to prune beacon cache", "error", err)
+			} else if pruned > 0 {
+				log.Debugw("pruned beacon cache", "entries", pruned)
+			}
+		}
+
+		select {
+		case <-tick.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// pruneCache removes the persisted entries of the rounds before the given round
+func (db *DrandBeacon) pruneCache(ctx context.Context, before uint64) (int, error) {
+	res, err := db.ds.Query(ctx, query.Query{KeysOnly: true})
+	if err != nil {
+		return 0, xerrors.Errorf("querying cached beacon entries: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	b, err := db.ds.Batch(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var pruned int
+	for r := range res.Next() {
+		if r.Error != nil {
+			return pruned, xerrors.Errorf("iterating cached beacon entries: %w", r.Error)
+		}
+
+		var round uint64
+		if _, err := fmt.Sscanf(datastore.RawKey(r.Key).BaseNamespace(), "%d", &round); err != nil {
+			continue
+		}
+		if round >= before {
+			continue
+		}
+		if err := b.Delete(ctx, datastore.RawKey(r.Key)); err != nil {
+			return pruned, err
+		}
+		pruned++
+	}
+
+	return pruned, b.Commit(ctx)
+}
+
+func roundKey(round uint64) datastore.Key {
+	return datastore.NewKey(fmt.Sprint(round))
+}
+
 func (db *DrandBeacon) VerifyEntry(curr types.BeaconEntry, prev types.BeaconEntry) error {
 	if prev.Round == 0 {
 		// TODO handle genesis better
@@ -196,7 +332,7 @@ func (db *DrandBeacon) VerifyEntry(curr types.BeaconEntry, prev types.BeaconEntr
 	}
 	err := dchain.VerifyBeacon(db.pubkey, b)
 	if err == nil {
-		db.cacheValue(curr)
+		db.persistValue(curr)
 	}
 	return err
 }
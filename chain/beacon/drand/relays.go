@@ -0,0 +1,268 @@
+package drand
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	dchain "github.com/drand/drand/chain"
+	dclient "github.com/drand/drand/client"
+	grpcclient "github.com/drand/drand/client/grpc"
+	hclient "github.com/drand/drand/client/http"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/build"
+)
+
+// DefaultHealthCheckInterval is the default time between health checks of the
+// drand relays
+const DefaultHealthCheckInterval = time.Minute
+
+const (
+	// relayRequestTimeout bounds a request to a single relay, so that a relay
+	// which hangs fails over to the next one
+	relayRequestTimeout = 5 * time.Second
+
+	// latencyDecay is the weight of the previous latency of a relay in its
+	// moving average
+	latencyDecay = 0.8
+)
+
+// newEndpointClient creates the drand client of an endpoint; grpc:// and grpcs://
+// addresses are gRPC endpoints, anything else is an HTTP relay
+func newEndpointClient(endpoint string, info *dchain.Info) (dclient.Client, error) {
+	switch {
+	case strings.HasPrefix(endpoint, "grpc://"):
+		return grpcclient.New(strings.TrimPrefix(endpoint, "grpc://"), "", true)
+	case strings.HasPrefix(endpoint, "grpcs://"):
+		return grpcclient.New(strings.TrimPrefix(endpoint, "grpcs://"), "", false)
+	default:
+		hc, err := hclient.NewWithInfo(endpoint, info, nil)
+		if err != nil {
+			return nil, err
+		}
+		hc.(DrandHTTPClient).SetUserAgent("drand-client-lotus/" + build.BuildVersion)
+		return hc, nil
+	}
+}
+
+// relay is a drand endpoint along with its observed health
+type relay struct {
+	dclient.Client
+	endpoint string
+
+	lk      sync.Mutex
+	healthy bool
+	// moving average of the latency of successful requests
+	latency time.Duration
+}
+
+func (r *relay) record(took time.Duration, err error) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	if err != nil {
+		if r.healthy {
+			log.Warnw("drand relay unhealthy", "endpoint", r.endpoint, "error", err)
+		}
+		r.healthy = false
+		return
+	}
+
+	if !r.healthy {
+		log.Infow("drand relay healthy", "endpoint", r.endpoint, "latency", took)
+	}
+	r.healthy = true
+	if r.latency == 0 {
+		r.latency = took
+	} else {
+		r.latency = time.Duration(latencyDecay*float64(r.latency) + (1-latencyDecay)*float64(took))
+	}
+}
+
+func (r *relay) state() (bool, time.Duration) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	return r.healthy, r.latency
+}
+
+// relayClient is a drand client over a set of relays. Requests go to the
+// healthy relays first, lowest latency first, and fail over to the next relay
+// on errors; relays are health checked periodically, so that a relay which
+// recovers is used again.
+type relayClient struct {
+	relays []*relay
+	info   *dchain.Info
+
+	interval time.Duration
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+var _ dclient.Client = (*relayClient)(nil)
+
+func newRelayClient(endpoints []string, info *dchain.Info, interval time.Duration) (*relayClient, error) {
+	if interval <= 0 {
+		interval = DefaultHealthCheckInterval
+	}
+
+	rc := &relayClient{
+		info:     info,
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+	for _, ep := range endpoints {
+		c, err := newEndpointClient(ep, info)
+		if err != nil {
+			return nil, xerrors.Errorf("creating drand client for %s: %w", ep, err)
+		}
+		// relays are assumed healthy until a request to them fails
+		rc.relays = append(rc.relays, &relay{Client: c, endpoint: ep, healthy: true})
+	}
+
+	return rc, nil
+}
+
+// Start starts the periodic health checks of the relays
+func (rc *relayClient) Start() {
+	var ctx context.Context
+	ctx, rc.cancel = context.WithCancel(context.Background())
+
+	go func() {
+		defer close(rc.done)
+
+		tick := build.Clock.Ticker(rc.interval)
+		defer tick.Stop()
+
+		for {
+			rc.healthCheck(ctx)
+
+			select {
+			case <-tick.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// healthCheck requests the latest round from all the relays
+func (rc *relayClient) healthCheck(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, r := range rc.relays {
+		wg.Add(1)
+		go func(r *relay) {
+			defer wg.Done()
+			_, _ = rc.getFrom(ctx, r, 0)
+		}(r)
+	}
+	wg.Wait()
+}
+
+func (rc *relayClient) getFrom(ctx context.Context, r *relay, round uint64) (dclient.Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, relayRequestTimeout)
+	defer cancel()
+
+	start := build.Clock.Now()
+	res, err := r.Get(ctx, round)
+	if err == nil && res == nil {
+		err = xerrors.Errorf("empty response")
+	}
+	if ctx.Err() == context.Canceled {
+		// the request was cancelled by the caller, which says nothing about the
+		// health of the relay
+		return nil, err
+	}
+	r.record(build.Clock.Since(start), err)
+	return res, err
+}
+
+// ranked returns the relays ordered by health, then latency
+func (rc *relayClient) ranked() []*relay {
+	type rankedRelay struct {
+		*relay
+		healthy bool
+		latency time.Duration
+	}
+
+	rs := make([]rankedRelay, len(rc.relays))
+	for i, r := range rc.relays {
+		healthy, latency := r.state()
+		rs[i] = rankedRelay{relay: r, healthy: healthy, latency: latency}
+	}
+	sort.SliceStable(rs, func(i, j int) bool {
+		if rs[i].healthy != rs[j].healthy {
+			return rs[i].healthy
+		}
+		return rs[i].latency < rs[j].latency
+	})
+
+	out := make([]*relay, len(rs))
+	for i, r := range rs {
+		out[i] = r.relay
+	}
+	return out
+}
+
+func (rc *relayClient) Get(ctx context.Context, round uint64) (dclient.Result, error) {
+	var errs []string
+	for _, r := range rc.ranked() {
+		res, err := rc.getFrom(ctx, r, round)
+		if err == nil {
+			return res, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		errs = append(errs, r.endpoint+": "+err.Error())
+	}
+	return nil, xerrors.Errorf("all drand relays failed: %s", strings.Join(errs, "; "))
+}
+
+// Watch watches the new rounds on the best relay at the time of the call
+func (rc *relayClient) Watch(ctx context.Context) <-chan dclient.Result {
+	rs := rc.ranked()
+	if len(rs) == 0 {
+		out := make(chan dclient.Result)
+		close(out)
+		return out
+	}
+	return rs[0].Watch(ctx)
+}
+
+func (rc *relayClient) Info(ctx context.Context) (*dchain.Info, error) {
+	return rc.info, nil
+}
+
+func (rc *relayClient) RoundAt(t time.Time) uint64 {
+	return dchain.CurrentRound(t.Unix(), rc.info.Period, rc.info.GenesisTime)
+}
+
+func (rc *relayClient) String() string {
+	eps := make([]string, len(rc.relays))
+	for i, r := range rc.relays {
+		eps[i] = r.endpoint
+	}
+	return "Relays(" + strings.Join(eps, ", ") + ")"
+}
+
+// Close stops the health checks and closes the relays
+func (rc *relayClient) Close() error {
+	if rc.cancel != nil {
+		rc.cancel()
+		<-rc.done
+	}
+
+	var errs []string
+	for _, r := range rc.relays {
+		if err := r.Close(); err != nil {
+			errs = append(errs, r.endpoint+": "+err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return xerrors.Errorf("closing drand relays: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+//stm: #unit
+package drand
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dchain "github.com/drand/drand/chain"
+	dclient "github.com/drand/drand/client"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+type testRelay struct {
+	dclient.Client
+
+	delay time.Duration
+	fail  int32
+	calls int32
+}
+
+func (tr *testRelay) Get(ctx context.Context, round uint64) (dclient.Result, error) {
+	atomic.AddInt32(&tr.calls, 1)
+	if atomic.LoadInt32(&tr.fail) != 0 {
+		return nil, xerrors.New("relay down")
+	}
+	time.Sleep(tr.delay)
+	return &dclient.RandomData{Rnd: round}, nil
+}
+
+func (tr *testRelay) Close() error {
+	return nil
+}
+
+func TestRelayFailover(t *testing.T) {
+	slow := &testRelay{delay: 100 * time.Millisecond}
+	fast := &testRelay{}
+
+	rc := &relayClient{relays: []*relay{
+		{Client: slow, endpoint: "slow", healthy: true},
+		{Client: fast, endpoint: "fast", healthy: true},
+	}}
+
+	ctx := context.Background()
+	rc.healthCheck(ctx)
+	require.Equal(t, "fast", rc.ranked()[0].endpoint)
+
+	res, err := rc.Get(ctx, 5)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), res.Round())
+	require.Equal(t, int32(1), atomic.LoadInt32(&slow.calls))
+
+	// the fast relay goes down, requests fail over to the slow one
+	atomic.StoreInt32(&fast.fail, 1)
+	res, err = rc.Get(ctx, 6)
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), res.Round())
+	require.Equal(t, "slow", rc.ranked()[0].endpoint)
+
+	// once it recovers, the health check brings it back
+	atomic.StoreInt32(&fast.fail, 0)
+	rc.healthCheck(ctx)
+	require.Equal(t, "fast", rc.ranked()[0].endpoint)
+
+	atomic.StoreInt32(&fast.fail, 1)
+	atomic.StoreInt32(&slow.fail, 1)
+	_, err = rc.Get(ctx, 7)
+	require.Error(t, err)
+}
+
+func TestPersistentCache(t *testing.T) {
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+
+	cfg := build.DrandConfigs[build.DrandMainnet]
+	// an unreachable relay
+	cfg.Servers = []string{"http://127.0.0.1:1"}
+
+	genTs := uint64(1652222222)
+	db, err := NewDrandBeacon(genTs, build.BlockDelaySecs, nil, cfg, dtypes.DrandClientConfig{}, ds)
+	require.NoError(t, err)
+
+	// a recent round, which isn't pruned
+	round := dchain.CurrentRound(time.Now().Unix(), db.interval, int64(db.drandGenTime))
+	e := types.BeaconEntry{Round: round, Data: []byte("signature")}
+	db.persistValue(e)
+	require.NoError(t, db.Close())
+
+	// a new beacon serves the entry from the persistent cache without the relay
+	db, err = NewDrandBeacon(genTs, build.BlockDelaySecs, nil, cfg, dtypes.DrandClientConfig{}, ds)
+	require.NoError(t, err)
+	defer db.Close() //nolint:errcheck
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	resp := <-db.Entry(ctx, round)
+	require.NoError(t, resp.Err)
+	require.Equal(t, e, resp.Entry)
+
+	pruned, err := db.pruneCache(ctx, round+1)
+	require.NoError(t, err)
+	require.Equal(t, 1, pruned)
+	_, err = db.ds.Get(ctx, roundKey(round))
+	require.Equal(t, datastore.ErrNotFound, err)
+}
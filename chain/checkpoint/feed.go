@@ -0,0 +1,389 @@
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/lib/sigs"
+	_ "github.com/filecoin-project/lotus/lib/sigs/bls"
+	_ "github.com/filecoin-project/lotus/lib/sigs/secp"
+)
+
+var log = logging.Logger("checkpoint")
+
+var (
+	ErrNoAuthorities    = errors.New("no checkpoint authorities configured")
+	ErrStaleCheckpoint  = errors.New("checkpoint is older than the latest authority checkpoint")
+	ErrConflictingChain = errors.New("checkpoint conflicts with the current chain")
+)
+
+var latestKey = datastore.NewKey("/chain/checkpoint/feed/latest")
+
+// maxFeedResponse bounds the size of a checkpoint document fetched from the feed
+const maxFeedResponse = 1 << 20
+
+// ChainAPI is the set of node methods used by the checkpoint feed
+type ChainAPI interface {
+	ChainHead(context.Context) (*types.TipSet, error)
+	ChainGetTipSet(context.Context, types.TipSetKey) (*types.TipSet, error)
+	ChainGetTipSetByHeight(context.Context, abi.ChainEpoch, types.TipSetKey) (*types.TipSet, error)
+	SyncCheckpoint(context.Context, types.TipSetKey) error
+}
+
+// Config specifies the checkpoint authority set and where its checkpoints are
+// published
+type Config struct {
+	// URL of the feed, which serves the latest signed checkpoint as JSON; empty
+	// disables polling
+	URL          string
+	PollInterval time.Duration
+
+	// Authorities are the key addresses allowed to sign checkpoints, of which
+	// Threshold distinct ones must sign a checkpoint for it to be accepted
+	Authorities []address.Address
+	Threshold   int
+}
+
+// Feed follows the signed checkpoints published by a checkpoint authority set,
+// and checkpoints the tipsets they designate once enough authority signatures
+// are verified. Checkpointed tipsets are final: the syncer will never fork
+// away from them, which protects nodes syncing without a snapshot from
+// long-range forks.
+type Feed struct {
+	api     ChainAPI
+	ds      datastore.Datastore
+	netName string
+	cfg     Config
+	client  *http.Client
+
+	authorities map[address.Address]struct{}
+
+	// applyLk serializes checkpoint application
+	applyLk sync.Mutex
+
+	lk     sync.Mutex
+	status api.CheckpointFeedStatus
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewFeed(chainAPI ChainAPI, ds datastore.Datastore, netName string, cfg Config) (*Feed, error) {
+	if len(cfg.Authorities) == 0 {
+		return nil, ErrNoAuthorities
+	}
+	if cfg.Threshold <= 0 || cfg.Threshold > len(cfg.Authorities) {
+		return nil, xerrors.Errorf("checkpoint threshold must be between 1 and %d, got %d", len(cfg.Authorities), cfg.Threshold)
+	}
+
+	authorities := make(map[address.Address]struct{}, len(cfg.Authorities))
+	for _, a := range cfg.Authorities {
+		if a.Protocol() != address.SECP256K1 && a.Protocol() != address.BLS {
+			return nil, xerrors.Errorf("checkpoint authority %s is not a key address", a)
+		}
+		authorities[a] = struct{}{}
+	}
+	if len(authorities) < cfg.Threshold {
+		return nil, xerrors.Errorf("checkpoint threshold %d exceeds the number of distinct authorities", cfg.Threshold)
+	}
+
+	f := &Feed{
+		api:         chainAPI,
+		ds:          ds,
+		netName:     netName,
+		cfg:         cfg,
+		client:      &http.Client{Timeout: time.Minute},
+		authorities: authorities,
+		status: api.CheckpointFeedStatus{
+			URL:         cfg.URL,
+			Authorities: cfg.Authorities,
+			Threshold:   cfg.Threshold,
+		},
+	}
+
+	f.ctx, f.cancel = context.WithCancel(context.Background())
+	return f, nil
+}
+
+func (f *Feed) Start(ctx context.Context) error {
+	latest, err := f.loadLatest(ctx)
+	if err != nil {
+		return xerrors.Errorf("loading latest authority checkpoint: %w", err)
+	}
+
+	f.lk.Lock()
+	f.status.Latest = latest
+	f.lk.Unlock()
+
+	if f.cfg.URL != "" && f.cfg.PollInterval > 0 {
+		f.wg.Add(1)
+		go f.poll()
+	}
+
+	return nil
+}
+
+func (f *Feed) Stop(ctx context.Context) error {
+	f.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Status returns the status of the feed subscription
+func (f *Feed) Status() api.CheckpointFeedStatus {
+	f.lk.Lock()
+	defer f.lk.Unlock()
+
+	return f.status
+}
+
+// Latest returns the latest verified authority checkpoint, or nil
+func (f *Feed) Latest() *api.SignedCheckpoint {
+	f.lk.Lock()
+	defer f.lk.Unlock()
+
+	return f.status.Latest
+}
+
+// SigningBytes returns the bytes authorities sign to endorse a checkpoint on the
+// given network
+func SigningBytes(netName string, cp api.Checkpoint) []byte {
+	prefix := fmt.Sprintf("lotus-checkpoint:%s:%d:", netName, cp.Height)
+	return append([]byte(prefix), cp.TipSet.Bytes()...)
+}
+
+// Verify checks that the checkpoint carries valid signatures from at least
+// Threshold distinct authorities
+func (f *Feed) Verify(cp *api.SignedCheckpoint) error {
+	if cp.TipSet == types.EmptyTSK {
+		return xerrors.Errorf("checkpoint with empty tipset key")
+	}
+
+	msg := SigningBytes(f.netName, cp.Checkpoint)
+
+	signed := make(map[address.Address]struct{}, len(cp.Signatures))
+	for i, s := range cp.Signatures {
+		if _, ok := f.authorities[s.Signer]; !ok {
+			log.Debugf("ignoring checkpoint signature %d from non-authority %s", i, s.Signer)
+			continue
+		}
+		if _, ok := signed[s.Signer]; ok {
+			continue
+		}
+
+		sig := s.Signature
+		if err := sigs.Verify(&sig, s.Signer, msg); err != nil {
+			return xerrors.Errorf("invalid checkpoint signature from authority %s: %w", s.Signer, err)
+		}
+		signed[s.Signer] = struct{}{}
+	}
+
+	if len(signed) < f.cfg.Threshold {
+		return xerrors.Errorf("checkpoint has %d valid authority signatures, need %d", len(signed), f.cfg.Threshold)
+	}
+
+	return nil
+}
+
+// Apply verifies a signed checkpoint and checkpoints the tipset it designates
+func (f *Feed) Apply(ctx context.Context, cp *api.SignedCheckpoint) error {
+	if err := f.Verify(cp); err != nil {
+		return err
+	}
+
+	f.applyLk.Lock()
+	defer f.applyLk.Unlock()
+
+	if latest := f.Latest(); latest != nil {
+		if cp.Height < latest.Height || (cp.Height == latest.Height && cp.TipSet != latest.TipSet) {
+			return xerrors.Errorf("checkpoint at height %d, latest at %d: %w", cp.Height, latest.Height, ErrStaleCheckpoint)
+		}
+		if cp.TipSet == latest.TipSet {
+			return nil
+		}
+	}
+
+	head, err := f.api.ChainHead(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	if head.Height()-cp.Height > build.ForkLengthThreshold {
+		// too deep to be set as the chainstore checkpoint, and the syncer won't fork
+		// this far back anyway; only make sure we are on the checkpointed chain
+		ts, err := f.api.ChainGetTipSetByHeight(ctx, cp.Height, head.Key())
+		if err != nil {
+			return xerrors.Errorf("getting tipset at checkpoint height %d: %w", cp.Height, err)
+		}
+		if ts.Key() != cp.TipSet {
+			return xerrors.Errorf("tipset at height %d is %s, checkpoint is %s: %w", cp.Height, ts.Key(), cp.TipSet, ErrConflictingChain)
+		}
+	} else if err := f.api.SyncCheckpoint(ctx, cp.TipSet); err != nil {
+		return xerrors.Errorf("syncing checkpoint: %w", err)
+	}
+
+	if err := f.saveLatest(ctx, cp); err != nil {
+		return xerrors.Errorf("persisting authority checkpoint: %w", err)
+	}
+
+	f.lk.Lock()
+	f.status.Latest = cp
+	f.status.AppliedAt = time.Now()
+	f.lk.Unlock()
+
+	log.Infow("applied authority checkpoint", "height", cp.Height, "tipset", cp.TipSet)
+	return nil
+}
+
+// CheckDescendant returns an error if the tipset doesn't descend from the latest
+// authority checkpoint, so that manual checkpoints can't move the node away
+// from the authority chain
+func (f *Feed) CheckDescendant(ctx context.Context, tsk types.TipSetKey) error {
+	latest := f.Latest()
+	if latest == nil {
+		return nil
+	}
+
+	ts, err := f.api.ChainGetTipSet(ctx, tsk)
+	if err != nil {
+		return xerrors.Errorf("loading tipset: %w", err)
+	}
+	if ts.Height() < latest.Height {
+		return xerrors.Errorf("tipset at height %d is below the latest authority checkpoint at %d: %w", ts.Height(), latest.Height, ErrConflictingChain)
+	}
+
+	anc, err := f.api.ChainGetTipSetByHeight(ctx, latest.Height, tsk)
+	if err != nil {
+		return xerrors.Errorf("getting tipset at checkpoint height %d: %w", latest.Height, err)
+	}
+	if anc.Key() != latest.TipSet {
+		return xerrors.Errorf("tipset doesn't descend from the latest authority checkpoint %s: %w", latest.TipSet, ErrConflictingChain)
+	}
+
+	return nil
+}
+
+func (f *Feed) poll() {
+	defer f.wg.Done()
+
+	ticker := time.NewTicker(f.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		err := f.pollOnce()
+		if err != nil {
+			log.Warnf("polling checkpoint feed: %s", err)
+		}
+
+		f.lk.Lock()
+		f.status.LastPoll = time.Now()
+		f.status.LastError = ""
+		if err != nil {
+			f.status.LastError = err.Error()
+		}
+		f.lk.Unlock()
+
+		select {
+		case <-ticker.C:
+		case <-f.ctx.Done():
+			return
+		}
+	}
+}
+
+func (f *Feed) pollOnce() error {
+	cp, err := f.fetch()
+	if err != nil {
+		return err
+	}
+
+	if latest := f.Latest(); latest != nil && cp.TipSet == latest.TipSet {
+		return nil
+	}
+
+	return f.Apply(f.ctx, cp)
+}
+
+func (f *Feed) fetch() (*api.SignedCheckpoint, error) {
+	req, err := http.NewRequestWithContext(f.ctx, http.MethodGet, f.cfg.URL, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("creating request: %w", err)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, xerrors.Errorf("fetching checkpoint: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, xerrors.Errorf("fetching checkpoint: non-200 response: %d", resp.StatusCode)
+	}
+
+	var cp api.SignedCheckpoint
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxFeedResponse)).Decode(&cp); err != nil {
+		return nil, xerrors.Errorf("decoding checkpoint: %w", err)
+	}
+
+	return &cp, nil
+}
+
+func (f *Feed) loadLatest(ctx context.Context) (*api.SignedCheckpoint, error) {
+	b, err := f.ds.Get(ctx, latestKey)
+	if err == datastore.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cp api.SignedCheckpoint
+	if err := json.Unmarshal(b, &cp); err != nil {
+		return nil, err
+	}
+
+	// the authority set may have changed since the checkpoint was stored
+	if err := f.Verify(&cp); err != nil {
+		log.Warnf("dropping stored authority checkpoint at height %d: %s", cp.Height, err)
+		return nil, nil
+	}
+
+	return &cp, nil
+}
+
+func (f *Feed) saveLatest(ctx context.Context, cp *api.SignedCheckpoint) error {
+	b, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+
+	return f.ds.Put(ctx, latestKey, b)
+}
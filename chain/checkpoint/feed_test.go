@@ -0,0 +1,189 @@
+//stm: #unit
+package checkpoint
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/crypto"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+	"github.com/filecoin-project/lotus/lib/sigs"
+)
+
+type authority struct {
+	addr address.Address
+	priv []byte
+}
+
+func newAuthority(t *testing.T) authority {
+	priv, err := sigs.Generate(crypto.SigTypeSecp256k1)
+	require.NoError(t, err)
+	pub, err := sigs.ToPublic(crypto.SigTypeSecp256k1, priv)
+	require.NoError(t, err)
+	addr, err := address.NewSecp256k1Address(pub)
+	require.NoError(t, err)
+
+	return authority{addr: addr, priv: priv}
+}
+
+func (a authority) sign(t *testing.T, netName string, cp *api.SignedCheckpoint) {
+	sig, err := sigs.Sign(crypto.SigTypeSecp256k1, a.priv, SigningBytes(netName, cp.Checkpoint))
+	require.NoError(t, err)
+
+	cp.Signatures = append(cp.Signatures, api.CheckpointSignature{Signer: a.addr, Signature: *sig})
+}
+
+// fakeChain is a linear chain of tipsets
+type fakeChain struct {
+	tipsets     []*types.TipSet
+	checkpoints []types.TipSetKey
+}
+
+func newFakeChain(length int) *fakeChain {
+	c := &fakeChain{}
+	var parent *types.TipSet
+	for i := 0; i < length; i++ {
+		parent = mock.TipSet(mock.MkBlock(parent, 1, uint64(i)))
+		c.tipsets = append(c.tipsets, parent)
+	}
+	return c
+}
+
+func (c *fakeChain) ChainHead(context.Context) (*types.TipSet, error) {
+	return c.tipsets[len(c.tipsets)-1], nil
+}
+
+func (c *fakeChain) ChainGetTipSet(_ context.Context, tsk types.TipSetKey) (*types.TipSet, error) {
+	for _, ts := range c.tipsets {
+		if ts.Key() == tsk {
+			return ts, nil
+		}
+	}
+	return nil, xerrors.Errorf("tipset %s not found", tsk)
+}
+
+func (c *fakeChain) ChainGetTipSetByHeight(_ context.Context, h abi.ChainEpoch, _ types.TipSetKey) (*types.TipSet, error) {
+	return c.tipsets[h], nil
+}
+
+func (c *fakeChain) SyncCheckpoint(_ context.Context, tsk types.TipSetKey) error {
+	c.checkpoints = append(c.checkpoints, tsk)
+	return nil
+}
+
+func checkpointAt(c *fakeChain, h abi.ChainEpoch) *api.SignedCheckpoint {
+	return &api.SignedCheckpoint{Checkpoint: api.Checkpoint{Height: h, TipSet: c.tipsets[h].Key()}}
+}
+
+func TestFeedVerify(t *testing.T) {
+	a, b, c := newAuthority(t), newAuthority(t), newAuthority(t)
+	outsider := newAuthority(t)
+	chain := newFakeChain(5)
+
+	f, err := NewFeed(chain, datastore.NewMapDatastore(), "testnet", Config{
+		Authorities: []address.Address{a.addr, b.addr, c.addr},
+		Threshold:   2,
+	})
+	require.NoError(t, err)
+
+	cp := checkpointAt(chain, 3)
+	a.sign(t, "testnet", cp)
+	require.Error(t, f.Verify(cp))
+
+	// duplicate and non-authority signatures don't count towards the threshold
+	a.sign(t, "testnet", cp)
+	outsider.sign(t, "testnet", cp)
+	require.Error(t, f.Verify(cp))
+
+	b.sign(t, "testnet", cp)
+	require.NoError(t, f.Verify(cp))
+
+	// signatures are bound to the network and the checkpoint
+	other := checkpointAt(chain, 3)
+	a.sign(t, "othernet", other)
+	b.sign(t, "othernet", other)
+	require.Error(t, f.Verify(other))
+
+	moved := *cp
+	moved.TipSet = chain.tipsets[4].Key()
+	require.Error(t, f.Verify(&moved))
+}
+
+func TestFeedConfig(t *testing.T) {
+	a := newAuthority(t)
+	chain := newFakeChain(1)
+
+	_, err := NewFeed(chain, datastore.NewMapDatastore(), "testnet", Config{Threshold: 1})
+	require.ErrorIs(t, err, ErrNoAuthorities)
+
+	_, err = NewFeed(chain, datastore.NewMapDatastore(), "testnet", Config{
+		Authorities: []address.Address{a.addr},
+		Threshold:   2,
+	})
+	require.Error(t, err)
+
+	id, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+	_, err = NewFeed(chain, datastore.NewMapDatastore(), "testnet", Config{
+		Authorities: []address.Address{id},
+		Threshold:   1,
+	})
+	require.Error(t, err)
+}
+
+func TestFeedApply(t *testing.T) {
+	ctx := context.Background()
+
+	a := newAuthority(t)
+	chain := newFakeChain(10)
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	cfg := Config{
+		Authorities: []address.Address{a.addr},
+		Threshold:   1,
+	}
+
+	f, err := NewFeed(chain, ds, "testnet", cfg)
+	require.NoError(t, err)
+	require.NoError(t, f.Start(ctx))
+
+	unsigned := checkpointAt(chain, 5)
+	require.Error(t, f.Apply(ctx, unsigned))
+	require.Empty(t, chain.checkpoints)
+
+	cp := checkpointAt(chain, 5)
+	a.sign(t, "testnet", cp)
+	require.NoError(t, f.Apply(ctx, cp))
+	require.Equal(t, []types.TipSetKey{cp.TipSet}, chain.checkpoints)
+	require.Equal(t, cp, f.Status().Latest)
+
+	// re-applying the latest checkpoint is a no-op
+	require.NoError(t, f.Apply(ctx, cp))
+	require.Len(t, chain.checkpoints, 1)
+
+	stale := checkpointAt(chain, 4)
+	a.sign(t, "testnet", stale)
+	require.ErrorIs(t, f.Apply(ctx, stale), ErrStaleCheckpoint)
+
+	require.NoError(t, f.CheckDescendant(ctx, chain.tipsets[7].Key()))
+	require.ErrorIs(t, f.CheckDescendant(ctx, chain.tipsets[3].Key()), ErrConflictingChain)
+
+	require.NoError(t, f.Stop(ctx))
+
+	// the latest checkpoint is persisted
+	f, err = NewFeed(chain, ds, "testnet", cfg)
+	require.NoError(t, err)
+	require.NoError(t, f.Start(ctx))
+	require.NotNil(t, f.Latest())
+	require.Equal(t, cp.TipSet, f.Latest().TipSet)
+	require.NoError(t, f.Stop(ctx))
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/go-multierror"
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/ipfs/go-cid"
 	cbor "github.com/ipfs/go-ipld-cbor"
 	logging "github.com/ipfs/go-log/v2"
@@ -61,12 +62,19 @@ type FilecoinEC struct {
 	verifier storiface.Verifier
 
 	genesis *types.TipSet
+
+	// block cids (for bls aggregates) and signed message cids whose signatures
+	// were verified by PrevalidateSignatures
+	prevalidated *lru.ARCCache
 }
 
 // Blocks that are more than MaxHeightDrift epochs above
 // the theoretical max height based on systime are quickly rejected
 const MaxHeightDrift = 5
 
+// enough for the signatures of the tipsets held in the sync validation pipeline
+const prevalidatedCacheSize = 1 << 17
+
 func NewFilecoinExpectedConsensus(sm *stmgr.StateManager, beacon beacon.Schedule, verifier storiface.Verifier, genesis chain.Genesis) consensus.Consensus {
 	if build.InsecurePoStValidation {
 		log.Warn("*********************************************************************************************")
@@ -74,12 +82,18 @@ func NewFilecoinExpectedConsensus(sm *stmgr.StateManager, beacon beacon.Schedule
 		log.Warn("*********************************************************************************************")
 	}
 
+	prevalidated, err := lru.NewARC(prevalidatedCacheSize)
+	if err != nil {
+		panic(err) // only errors for invalid sizes
+	}
+
 	return &FilecoinEC{
-		store:    sm.ChainStore(),
-		beacon:   beacon,
-		sm:       sm,
-		verifier: verifier,
-		genesis:  genesis,
+		store:        sm.ChainStore(),
+		beacon:       beacon,
+		sm:           sm,
+		verifier:     verifier,
+		genesis:      genesis,
+		prevalidated: prevalidated,
 	}
 }
 
@@ -130,6 +144,11 @@ func (filec *FilecoinEC) ValidateBlock(ctx context.Context, b *types.FullBlock)
 			return nil
 		}
 
+		start := build.Clock.Now()
+		defer func() {
+			consensus.RecordMessageValidation(ctx, build.Clock.Since(start))
+		}()
+
 		if err := filec.checkBlockMessages(ctx, b, baseTs); err != nil {
 			return xerrors.Errorf("block had invalid messages: %w", err)
 		}
@@ -165,7 +184,9 @@ func (filec *FilecoinEC) ValidateBlock(ctx context.Context, b *types.FullBlock)
 	}
 
 	stateRootCheck := async.Err(func() error {
+		start := build.Clock.Now()
 		stateroot, precp, err := filec.sm.TipSetState(ctx, baseTs)
+		consensus.RecordStateComputation(ctx, build.Clock.Since(start))
 		if err != nil {
 			return xerrors.Errorf("get tipsetstate(%d, %s) failed: %w", h.Height, h.Parents, err)
 		}
@@ -435,7 +456,7 @@ func (filec *FilecoinEC) VerifyWinningPoStProof(ctx context.Context, nv network.
 
 // TODO: We should extract this somewhere else and make the message pool and miner use the same logic
 func (filec *FilecoinEC) checkBlockMessages(ctx context.Context, b *types.FullBlock, baseTs *types.TipSet) error {
-	{
+	if !filec.consumePrevalidated(b.Cid()) {
 		var sigCids []cid.Cid // this is what we get for people not wanting the marshalcbor method on the cid type
 		var pubks [][]byte
 
@@ -559,8 +580,12 @@ func (filec *FilecoinEC) checkBlockMessages(ctx context.Context, b *types.FullBl
 			return xerrors.Errorf("failed to resolve key addr: %w", err)
 		}
 
-		if err := sigs.Verify(&m.Signature, kaddr, m.Message.Cid().Bytes()); err != nil {
-			return xerrors.Errorf("secpk message %s has invalid signature: %w", m.Cid(), err)
+		// signatures prevalidated against the sender key address are still valid
+		// when the sender resolves to itself
+		if kaddr != m.Message.From || !filec.consumePrevalidated(m.Cid()) {
+			if err := sigs.Verify(&m.Signature, kaddr, m.Message.Cid().Bytes()); err != nil {
+				return xerrors.Errorf("secpk message %s has invalid signature: %w", m.Cid(), err)
+			}
 		}
 
 		c, err := store.PutMessage(ctx, tmpbs, m)
@@ -605,6 +630,61 @@ func (filec *FilecoinEC) checkBlockMessages(ctx context.Context, b *types.FullBl
 	return nil
 }
 
+// PrevalidateSignatures verifies the signatures in the block which don't depend on
+// the parent state: the bls aggregate, when all bls messages are sent from key
+// addresses, and the signatures of the secpk messages sent from key addresses.
+func (filec *FilecoinEC) PrevalidateSignatures(ctx context.Context, b *types.FullBlock) error {
+	ctx, span := trace.StartSpan(ctx, "prevalidateSignatures")
+	defer span.End()
+
+	if b.Header.BLSAggregate != nil {
+		keyed := true
+		sigCids := make([]cid.Cid, 0, len(b.BlsMessages))
+		pubks := make([][]byte, 0, len(b.BlsMessages))
+		for _, m := range b.BlsMessages {
+			if m.From.Protocol() != address.BLS {
+				// needs the parent state to resolve the sender key
+				keyed = false
+				break
+			}
+
+			sigCids = append(sigCids, m.Cid())
+			pubks = append(pubks, m.From.Payload())
+		}
+
+		if keyed {
+			if err := consensus.VerifyBlsAggregate(ctx, b.Header.BLSAggregate, sigCids, pubks); err != nil {
+				return xerrors.Errorf("bls aggregate signature was invalid: %w", err)
+			}
+			filec.prevalidated.Add(b.Cid(), struct{}{})
+		}
+	}
+
+	for _, m := range b.SecpkMessages {
+		if m.Message.From.Protocol() != address.SECP256K1 || m.Signature.Type != crypto.SigTypeSecp256k1 {
+			continue
+		}
+
+		if err := sigs.Verify(&m.Signature, m.Message.From, m.Message.Cid().Bytes()); err != nil {
+			return xerrors.Errorf("secpk message %s has invalid signature: %w", m.Cid(), err)
+		}
+		filec.prevalidated.Add(m.Cid(), struct{}{})
+	}
+
+	return nil
+}
+
+// consumePrevalidated returns whether the signature of the block or message was
+// prevalidated, and forgets about it
+func (filec *FilecoinEC) consumePrevalidated(c cid.Cid) bool {
+	if !filec.prevalidated.Contains(c) {
+		return false
+	}
+
+	filec.prevalidated.Remove(c)
+	return true
+}
+
 func (filec *FilecoinEC) IsEpochBeyondCurrMax(epoch abi.ChainEpoch) bool {
 	if filec.genesis == nil {
 		return false
@@ -869,3 +949,4 @@ func (filec *FilecoinEC) isChainNearSynced() bool {
 }
 
 var _ consensus.Consensus = &FilecoinEC{}
+var _ consensus.SignaturePrevalidator = &FilecoinEC{}
@@ -18,3 +18,11 @@ type Consensus interface {
 
 	CreateBlock(ctx context.Context, w api.Wallet, bt *api.BlockTemplate) (*types.FullBlock, error)
 }
+
+// SignaturePrevalidator is implemented by consensus implementations which can
+// verify the message signatures of a block ahead of ValidateBlock, without
+// access to the parent state; signatures verified this way aren't verified
+// again by ValidateBlock.
+type SignaturePrevalidator interface {
+	PrevalidateSignatures(ctx context.Context, b *types.FullBlock) error
+}
@@ -0,0 +1,59 @@
+package consensus
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ValidationTimings collects the time spent in the stages of block validation.
+// Blocks of a tipset are validated in parallel, so each stage records the
+// longest time reported for it.
+type ValidationTimings struct {
+	lk               sync.Mutex
+	validateMessages time.Duration
+	computeState     time.Duration
+}
+
+type validationTimingsKey struct{}
+
+// WithValidationTimings returns a context under which ValidateBlock reports its
+// stage timings to t
+func WithValidationTimings(ctx context.Context, t *ValidationTimings) context.Context {
+	return context.WithValue(ctx, validationTimingsKey{}, t)
+}
+
+func extractValidationTimings(ctx context.Context) *ValidationTimings {
+	t, _ := ctx.Value(validationTimingsKey{}).(*ValidationTimings)
+	return t
+}
+
+// RecordMessageValidation reports the time spent validating the messages of a block
+func RecordMessageValidation(ctx context.Context, d time.Duration) {
+	if t := extractValidationTimings(ctx); t != nil {
+		t.lk.Lock()
+		if d > t.validateMessages {
+			t.validateMessages = d
+		}
+		t.lk.Unlock()
+	}
+}
+
+// RecordStateComputation reports the time spent computing the parent state of a block
+func RecordStateComputation(ctx context.Context, d time.Duration) {
+	if t := extractValidationTimings(ctx); t != nil {
+		t.lk.Lock()
+		if d > t.computeState {
+			t.computeState = d
+		}
+		t.lk.Unlock()
+	}
+}
+
+// Get returns the time spent validating messages and computing state
+func (t *ValidationTimings) Get() (validateMessages, computeState time.Duration) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	return t.validateMessages, t.computeState
+}
@@ -0,0 +1,204 @@
+package chain
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+const (
+	// forkTrackEpochs is how far below the current head competing heads are tracked
+	forkTrackEpochs = 20
+	// maxTrackedHeads bounds the number of tracked competing heads
+	maxTrackedHeads = 256
+	// maxOrphans is the number of recently orphaned tipsets retained
+	maxOrphans = 100
+)
+
+// forkKey identifies a head by its height and parents: blocks advertised with
+// the same key are candidates for the same tipset
+type forkKey struct {
+	height  abi.ChainEpoch
+	parents types.TipSetKey
+}
+
+type forkHead struct {
+	blocks    map[cid.Cid]*types.BlockHeader
+	peers     map[peer.ID]struct{}
+	firstSeen time.Time
+	lastSeen  time.Time
+}
+
+// forkTracker tracks the heads advertised by peers at recent heights, and the
+// tipsets orphaned by reorgs of the local chain
+type forkTracker struct {
+	lk      sync.Mutex
+	heads   map[forkKey]*forkHead
+	orphans []api.OrphanedTipSet
+}
+
+func newForkTracker() *forkTracker {
+	return &forkTracker{
+		heads: make(map[forkKey]*forkHead),
+	}
+}
+
+// observe records a head advertised by a peer, and drops the heads which fell
+// out of the tracked window below the current head height
+func (ft *forkTracker) observe(from peer.ID, ts *types.TipSet, curHeight abi.ChainEpoch) {
+	ft.lk.Lock()
+	defer ft.lk.Unlock()
+
+	for k := range ft.heads {
+		if k.height < curHeight-forkTrackEpochs {
+			delete(ft.heads, k)
+		}
+	}
+
+	if ts.Height() < curHeight-forkTrackEpochs {
+		return
+	}
+
+	now := build.Clock.Now()
+	k := forkKey{height: ts.Height(), parents: ts.Parents()}
+	h, ok := ft.heads[k]
+	if !ok {
+		if len(ft.heads) >= maxTrackedHeads {
+			return
+		}
+
+		h = &forkHead{
+			blocks:    make(map[cid.Cid]*types.BlockHeader),
+			peers:     make(map[peer.ID]struct{}),
+			firstSeen: now,
+		}
+		ft.heads[k] = h
+	}
+
+	for _, b := range ts.Blocks() {
+		h.blocks[b.Cid()] = b
+	}
+	h.peers[from] = struct{}{}
+	h.lastSeen = now
+}
+
+// headChange records the reverted tipsets as orphaned
+func (ft *forkTracker) headChange(rev, _ []*types.TipSet) error {
+	if len(rev) == 0 {
+		return nil
+	}
+
+	ft.lk.Lock()
+	defer ft.lk.Unlock()
+
+	now := build.Clock.Now()
+	for _, ts := range rev {
+		ft.orphans = append(ft.orphans, api.OrphanedTipSet{
+			TipSet:       ts.Key(),
+			Height:       ts.Height(),
+			Miners:       tipsetMiners(ts),
+			ParentWeight: ts.ParentWeight(),
+			OrphanedAt:   now,
+		})
+	}
+	if len(ft.orphans) > maxOrphans {
+		ft.orphans = append([]api.OrphanedTipSet(nil), ft.orphans[len(ft.orphans)-maxOrphans:]...)
+	}
+
+	return nil
+}
+
+// Forks returns the competing heads advertised by peers at recent heights, from
+// the highest to the lowest, and the recently orphaned tipsets, from the most
+// recent
+func (syncer *Syncer) Forks(ctx context.Context) (api.ForkStatus, error) {
+	hts := syncer.store.GetHeaviestTipSet()
+
+	type snapshot struct {
+		head api.ForkHead
+		blks []*types.BlockHeader
+	}
+
+	ft := syncer.forks
+	ft.lk.Lock()
+	snaps := make([]snapshot, 0, len(ft.heads))
+	for k, h := range ft.heads {
+		s := snapshot{head: api.ForkHead{
+			Height:    k.height,
+			Parents:   k.parents,
+			Peers:     len(h.peers),
+			FirstSeen: h.firstSeen,
+			LastSeen:  h.lastSeen,
+		}}
+		for _, b := range h.blocks {
+			s.blks = append(s.blks, b)
+		}
+		snaps = append(snaps, s)
+	}
+	orphans := make([]api.OrphanedTipSet, len(ft.orphans))
+	for i, o := range ft.orphans {
+		orphans[len(orphans)-1-i] = o
+	}
+	ft.lk.Unlock()
+
+	heads := make([]api.ForkHead, 0, len(snaps))
+	for _, s := range snaps {
+		fh := s.head
+
+		ts, err := types.NewTipSet(s.blks)
+		if err != nil {
+			log.Debugw("building tipset of tracked head", "height", fh.Height, "error", err)
+			continue
+		}
+
+		fh.TipSet = ts.Key()
+		fh.Miners = tipsetMiners(ts)
+		fh.ParentWeight = ts.ParentWeight()
+
+		// the weight is only known once the parent state was computed
+		fh.Weight = types.NewInt(0)
+		if w, err := syncer.store.Weight(ctx, ts); err == nil {
+			fh.Weight = w
+		}
+
+		if fh.Height <= hts.Height() {
+			cts, err := syncer.store.GetTipsetByHeight(ctx, fh.Height, hts, false)
+			fh.Canonical = err == nil && cts.Height() == fh.Height && cts.Parents() == fh.Parents
+		}
+
+		heads = append(heads, fh)
+	}
+
+	sort.Slice(heads, func(i, j int) bool {
+		if heads[i].Height != heads[j].Height {
+			return heads[i].Height > heads[j].Height
+		}
+		return heads[i].Peers > heads[j].Peers
+	})
+
+	return api.ForkStatus{
+		Head:    hts.Key(),
+		Height:  hts.Height(),
+		Heads:   heads,
+		Orphans: orphans,
+	}, nil
+}
+
+func tipsetMiners(ts *types.TipSet) []address.Address {
+	miners := make([]address.Address, len(ts.Blocks()))
+	for i, b := range ts.Blocks() {
+		miners[i] = b.Miner
+	}
+	return miners
+}
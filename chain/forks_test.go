@@ -0,0 +1,54 @@
+//stm: #unit
+package chain
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestForkTrackerObserve(t *testing.T) {
+	ft := newForkTracker()
+
+	base := mock.TipSet(mock.MkBlock(nil, 1, 1))
+	a1 := mock.MkBlock(base, 1, 2)
+	a2 := mock.MkBlock(base, 1, 3)
+	b := mock.MkBlock(mock.TipSet(mock.MkBlock(nil, 1, 4)), 1, 5)
+
+	// blocks with the same height and parents are grouped into one head
+	ft.observe(peer.ID("p1"), mock.TipSet(a1), 1)
+	ft.observe(peer.ID("p2"), mock.TipSet(a2), 1)
+	ft.observe(peer.ID("p2"), mock.TipSet(a1), 1)
+	ft.observe(peer.ID("p3"), mock.TipSet(b), 1)
+
+	require.Len(t, ft.heads, 2)
+
+	a := ft.heads[forkKey{height: 1, parents: base.Key()}]
+	require.NotNil(t, a)
+	require.Len(t, a.blocks, 2)
+	require.Len(t, a.peers, 2)
+
+	// heads falling out of the window are dropped
+	ft.observe(peer.ID("p1"), mock.TipSet(a1), 1+forkTrackEpochs+1)
+	require.Empty(t, ft.heads)
+}
+
+func TestForkTrackerOrphans(t *testing.T) {
+	ft := newForkTracker()
+
+	var ts *types.TipSet
+	for i := 0; i < maxOrphans+10; i++ {
+		ts = mock.TipSet(mock.MkBlock(ts, 1, uint64(i)))
+		require.NoError(t, ft.headChange([]*types.TipSet{ts}, nil))
+	}
+
+	require.Len(t, ft.orphans, maxOrphans)
+	require.Equal(t, ts.Key(), ft.orphans[len(ft.orphans)-1].TipSet)
+
+	require.NoError(t, ft.headChange(nil, []*types.TipSet{ts}))
+	require.Len(t, ft.orphans, maxOrphans)
+}
@@ -27,47 +27,81 @@ func New(dstore ds.Batching) *SlashFilter {
 	}
 }
 
+// The types of consensus faults
+const (
+	FaultDoubleFork     = "double-fork mining"
+	FaultTimeOffset     = "time-offset mining"
+	FaultParentGrinding = "parent-grinding"
+)
+
+// Fault is a consensus fault committed by the miner of a block together with
+// another block of the same miner
+type Fault struct {
+	Type string
+	// Other is the other block of the fault; for parent-grinding faults, the
+	// block of the miner at the parent epoch which the block doesn't build on
+	Other cid.Cid
+}
+
 func (f *SlashFilter) MinedBlock(ctx context.Context, bh *types.BlockHeader, parentEpoch abi.ChainEpoch) error {
-	if build.IsNearUpgrade(bh.Height, build.UpgradeOrangeHeight) {
+	fault, err := f.CheckBlock(ctx, bh, parentEpoch)
+	if err != nil {
+		return err
+	}
+	if fault == nil {
 		return nil
 	}
 
+	if fault.Type == FaultParentGrinding {
+		return xerrors.Errorf("produced block would trigger 'parent-grinding fault' consensus fault; miner: %s; bh: %s, expected parent: %s", bh.Miner, bh.Cid(), fault.Other)
+	}
+	return xerrors.Errorf("produced block would trigger '%s faults' consensus fault; miner: %s; bh: %s, other: %s", fault.Type, bh.Miner, bh.Cid(), fault.Other)
+}
+
+// CheckBlock checks whether the block is a consensus fault together with the
+// blocks of the same miner checked before it, and records it if it isn't.
+// Parent-grinding faults are only checked when parentEpoch isn't negative.
+func (f *SlashFilter) CheckBlock(ctx context.Context, bh *types.BlockHeader, parentEpoch abi.ChainEpoch) (*Fault, error) {
+	if build.IsNearUpgrade(bh.Height, build.UpgradeOrangeHeight) {
+		return nil, nil
+	}
+
 	epochKey := ds.NewKey(fmt.Sprintf("/%s/%d", bh.Miner, bh.Height))
 	{
 		// double-fork mining (2 blocks at one epoch)
-		if err := checkFault(ctx, f.byEpoch, epochKey, bh, "double-fork mining faults"); err != nil {
-			return err
+		if fault, err := checkFault(ctx, f.byEpoch, epochKey, bh, FaultDoubleFork); fault != nil || err != nil {
+			return fault, err
 		}
 	}
 
 	parentsKey := ds.NewKey(fmt.Sprintf("/%s/%x", bh.Miner, types.NewTipSetKey(bh.Parents...).Bytes()))
 	{
 		// time-offset mining faults (2 blocks with the same parents)
-		if err := checkFault(ctx, f.byParents, parentsKey, bh, "time-offset mining faults"); err != nil {
-			return err
+		if fault, err := checkFault(ctx, f.byParents, parentsKey, bh, FaultTimeOffset); fault != nil || err != nil {
+			return fault, err
 		}
 	}
 
-	{
+	if parentEpoch >= 0 {
 		// parent-grinding fault (didn't mine on top of our own block)
 
 		// First check if we have mined a block on the parent epoch
 		parentEpochKey := ds.NewKey(fmt.Sprintf("/%s/%d", bh.Miner, parentEpoch))
 		have, err := f.byEpoch.Has(ctx, parentEpochKey)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		if have {
 			// If we had, make sure it's in our parent tipset
 			cidb, err := f.byEpoch.Get(ctx, parentEpochKey)
 			if err != nil {
-				return xerrors.Errorf("getting other block cid: %w", err)
+				return nil, xerrors.Errorf("getting other block cid: %w", err)
 			}
 
 			_, parent, err := cid.CidFromBytes(cidb)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			var found bool
@@ -78,45 +112,45 @@ func (f *SlashFilter) MinedBlock(ctx context.Context, bh *types.BlockHeader, par
 			}
 
 			if !found {
-				return xerrors.Errorf("produced block would trigger 'parent-grinding fault' consensus fault; miner: %s; bh: %s, expected parent: %s", bh.Miner, bh.Cid(), parent)
+				return &Fault{Type: FaultParentGrinding, Other: parent}, nil
 			}
 		}
 	}
 
 	if err := f.byParents.Put(ctx, parentsKey, bh.Cid().Bytes()); err != nil {
-		return xerrors.Errorf("putting byEpoch entry: %w", err)
+		return nil, xerrors.Errorf("putting byEpoch entry: %w", err)
 	}
 
 	if err := f.byEpoch.Put(ctx, epochKey, bh.Cid().Bytes()); err != nil {
-		return xerrors.Errorf("putting byEpoch entry: %w", err)
+		return nil, xerrors.Errorf("putting byEpoch entry: %w", err)
 	}
 
-	return nil
+	return nil, nil
 }
 
-func checkFault(ctx context.Context, t ds.Datastore, key ds.Key, bh *types.BlockHeader, faultType string) error {
+func checkFault(ctx context.Context, t ds.Datastore, key ds.Key, bh *types.BlockHeader, faultType string) (*Fault, error) {
 	fault, err := t.Has(ctx, key)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if fault {
 		cidb, err := t.Get(ctx, key)
 		if err != nil {
-			return xerrors.Errorf("getting other block cid: %w", err)
+			return nil, xerrors.Errorf("getting other block cid: %w", err)
 		}
 
 		_, other, err := cid.CidFromBytes(cidb)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		if other == bh.Cid() {
-			return nil
+			return nil, nil
 		}
 
-		return xerrors.Errorf("produced block would trigger '%s' consensus fault; miner: %s; bh: %s, other: %s", faultType, bh.Miner, bh.Cid(), other)
+		return &Fault{Type: faultType, Other: other}, nil
 	}
 
-	return nil
+	return nil, nil
 }
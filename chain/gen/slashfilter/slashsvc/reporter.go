@@ -0,0 +1,371 @@
+package slashsvc
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	cborutil "github.com/filecoin-project/go-cbor-util"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+	miner8 "github.com/filecoin-project/go-state-types/builtin/v8/miner"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/actors/policy"
+	"github.com/filecoin-project/lotus/chain/gen/slashfilter"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var log = logging.Logger("slashsvc")
+
+// recentBlocks is the number of incoming block headers kept in memory, so that
+// the evidence of a fault can be built from blocks which never made it into
+// the chain
+const recentBlocks = 16 << 10
+
+// ChainAPI is the chain access of the reporter
+type ChainAPI interface {
+	GetBlock(ctx context.Context, c cid.Cid) (*types.BlockHeader, error)
+	LoadTipSet(ctx context.Context, tsk types.TipSetKey) (*types.TipSet, error)
+	GetHeaviestTipSet() *types.TipSet
+}
+
+// IncomingBlocks is the source of the blocks received by the node
+type IncomingBlocks interface {
+	IncomingBlocks(ctx context.Context) (<-chan *types.BlockHeader, error)
+}
+
+// MessagePusher pushes the ReportConsensusFault messages
+type MessagePusher interface {
+	MpoolPushMessage(ctx context.Context, msg *types.Message, spec *api.MessageSendSpec) (*types.SignedMessage, error)
+}
+
+// Reporter scans the blocks received by the node for consensus faults, and
+// archives the evidence of the faults it finds. Faults are queued for review,
+// and are reported to the miner actor from the reporter address when
+// submitted, or as soon as they are found when auto-submit is enabled.
+type Reporter struct {
+	chain    ChainAPI
+	incoming IncomingBlocks
+	mpool    MessagePusher
+
+	filter *slashfilter.SlashFilter
+	faults datastore.Datastore
+	recent *lru.Cache
+
+	from       address.Address
+	autoSubmit bool
+
+	// serialises the updates of the faults
+	lk sync.Mutex
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewReporter creates a reporter keeping its state in ds; from may be
+// address.Undef, in which case faults can't be submitted
+func NewReporter(ds datastore.Batching, chain ChainAPI, incoming IncomingBlocks, mpool MessagePusher, from address.Address, autoSubmit bool) (*Reporter, error) {
+	if autoSubmit && from == address.Undef {
+		return nil, xerrors.Errorf("auto-submitting consensus faults requires a reporter address")
+	}
+
+	recent, err := lru.New(recentBlocks)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reporter{
+		chain:      chain,
+		incoming:   incoming,
+		mpool:      mpool,
+		filter:     slashfilter.New(ds),
+		faults:     namespace.Wrap(ds, datastore.NewKey("/faults")),
+		recent:     recent,
+		from:       from,
+		autoSubmit: autoSubmit,
+	}, nil
+}
+
+func (r *Reporter) Start(context.Context) error {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	blocks, err := r.incoming.IncomingBlocks(ctx)
+	if err != nil {
+		cancel()
+		return xerrors.Errorf("subscribing to incoming blocks: %w", err)
+	}
+
+	r.cancel = cancel
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+
+		for {
+			select {
+			case bh, ok := <-blocks:
+				if !ok {
+					return
+				}
+				if err := r.checkBlock(ctx, bh); err != nil {
+					log.Errorw("checking block for consensus faults", "block", bh.Cid(), "miner", bh.Miner, "error", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (r *Reporter) Stop(ctx context.Context) error {
+	if r.cancel == nil {
+		return nil
+	}
+
+	r.cancel()
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *Reporter) checkBlock(ctx context.Context, bh *types.BlockHeader) error {
+	r.recent.Add(bh.Cid(), bh)
+
+	// parent-grinding faults can only be detected once the parents are known
+	parentEpoch := abi.ChainEpoch(-1)
+	if pts, err := r.chain.LoadTipSet(ctx, types.NewTipSetKey(bh.Parents...)); err == nil {
+		parentEpoch = pts.Height()
+	}
+
+	fault, err := r.filter.CheckBlock(ctx, bh, parentEpoch)
+	if err != nil || fault == nil {
+		return err
+	}
+
+	log.Warnw("detected consensus fault", "type", fault.Type, "miner", bh.Miner, "block", bh.Cid(), "other", fault.Other)
+
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	if _, err := r.get(ctx, bh.Cid()); err == nil {
+		// already archived
+		return nil
+	} else if !xerrors.Is(err, datastore.ErrNotFound) {
+		return err
+	}
+
+	other, err := r.getBlock(ctx, fault.Other)
+	if err != nil {
+		return xerrors.Errorf("loading the other block of the fault: %w", err)
+	}
+
+	cf := api.ConsensusFault{
+		Block:    bh.Cid(),
+		Miner:    bh.Miner,
+		Epoch:    bh.Height,
+		Type:     fault.Type,
+		Detected: build.Clock.Now(),
+		Status:   api.ConsensusFaultPending,
+	}
+	if cf.BlockHeader1, err = cborutil.Dump(other); err != nil {
+		return err
+	}
+	if cf.BlockHeader2, err = cborutil.Dump(bh); err != nil {
+		return err
+	}
+
+	if fault.Type == slashfilter.FaultParentGrinding {
+		// the extra block is the sibling of the other block which the block
+		// builds on instead
+		for _, p := range bh.Parents {
+			pb, err := r.getBlock(ctx, p)
+			if err != nil {
+				return xerrors.Errorf("loading parent block: %w", err)
+			}
+			if pb.Height == other.Height && types.CidArrsEqual(pb.Parents, other.Parents) {
+				if cf.BlockHeaderExtra, err = cborutil.Dump(pb); err != nil {
+					return err
+				}
+				break
+			}
+		}
+		if cf.BlockHeaderExtra == nil {
+			cf.Error = "no sibling of the other block in the parents of the block"
+		}
+	}
+
+	if err := r.put(ctx, &cf); err != nil {
+		return err
+	}
+
+	if r.autoSubmit {
+		if _, err := r.submit(ctx, &cf); err != nil {
+			return xerrors.Errorf("submitting consensus fault: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// getBlock returns a block header from the recent incoming blocks or the chain
+func (r *Reporter) getBlock(ctx context.Context, c cid.Cid) (*types.BlockHeader, error) {
+	if v, ok := r.recent.Get(c); ok {
+		return v.(*types.BlockHeader), nil
+	}
+	return r.chain.GetBlock(ctx, c)
+}
+
+// Faults returns the archived faults, most recent first
+func (r *Reporter) Faults(ctx context.Context) ([]api.ConsensusFault, error) {
+	res, err := r.faults.Query(ctx, query.Query{})
+	if err != nil {
+		return nil, xerrors.Errorf("querying consensus faults: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	out := []api.ConsensusFault{}
+	for e := range res.Next() {
+		if e.Error != nil {
+			return nil, xerrors.Errorf("iterating consensus faults: %w", e.Error)
+		}
+
+		var cf api.ConsensusFault
+		if err := json.Unmarshal(e.Value, &cf); err != nil {
+			return nil, xerrors.Errorf("decoding consensus fault %s: %w", e.Key, err)
+		}
+		out = append(out, cf)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Detected.After(out[j].Detected)
+	})
+
+	return out, nil
+}
+
+// Submit reports the pending fault of the block to the miner actor
+func (r *Reporter) Submit(ctx context.Context, block cid.Cid) (cid.Cid, error) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	cf, err := r.get(ctx, block)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	return r.submit(ctx, cf)
+}
+
+func (r *Reporter) submit(ctx context.Context, cf *api.ConsensusFault) (cid.Cid, error) {
+	if cf.Status != api.ConsensusFaultPending {
+		return cid.Undef, xerrors.Errorf("consensus fault of block %s is %s", cf.Block, cf.Status)
+	}
+	if r.from == address.Undef {
+		return cid.Undef, xerrors.Errorf("no reporter address configured")
+	}
+	if cf.Type == slashfilter.FaultParentGrinding && cf.BlockHeaderExtra == nil {
+		return cid.Undef, xerrors.Errorf("parent-grinding fault of block %s can't be reported: %s", cf.Block, cf.Error)
+	}
+	if head := r.chain.GetHeaviestTipSet(); head.Height() > cf.Epoch+policy.ChainFinality {
+		return cid.Undef, xerrors.Errorf("consensus fault of block %s at epoch %d is older than finality, and can no longer be reported", cf.Block, cf.Epoch)
+	}
+
+	params, aerr := actors.SerializeParams(&miner8.ReportConsensusFaultParams{
+		BlockHeader1:     cf.BlockHeader1,
+		BlockHeader2:     cf.BlockHeader2,
+		BlockHeaderExtra: cf.BlockHeaderExtra,
+	})
+	if aerr != nil {
+		return cid.Undef, aerr
+	}
+
+	smsg, err := r.mpool.MpoolPushMessage(ctx, &types.Message{
+		To:     cf.Miner,
+		From:   r.from,
+		Value:  big.Zero(),
+		Method: builtin.MethodsMiner.ReportConsensusFault,
+		Params: params,
+	}, nil)
+	if err != nil {
+		cf.Error = err.Error()
+		if perr := r.put(ctx, cf); perr != nil {
+			log.Errorw("failed to record consensus fault submission error", "block", cf.Block, "error", perr)
+		}
+		return cid.Undef, xerrors.Errorf("pushing ReportConsensusFault message: %w", err)
+	}
+
+	mcid := smsg.Cid()
+	log.Infow("reported consensus fault", "miner", cf.Miner, "block", cf.Block, "message", mcid)
+
+	cf.Status = api.ConsensusFaultSubmitted
+	cf.Message = &mcid
+	cf.Error = ""
+	if err := r.put(ctx, cf); err != nil {
+		return mcid, err
+	}
+
+	return mcid, nil
+}
+
+// Dismiss marks the pending fault of the block as dismissed
+func (r *Reporter) Dismiss(ctx context.Context, block cid.Cid) error {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	cf, err := r.get(ctx, block)
+	if err != nil {
+		return err
+	}
+	if cf.Status != api.ConsensusFaultPending {
+		return xerrors.Errorf("consensus fault of block %s is %s", cf.Block, cf.Status)
+	}
+
+	cf.Status = api.ConsensusFaultDismissed
+	return r.put(ctx, cf)
+}
+
+func (r *Reporter) get(ctx context.Context, block cid.Cid) (*api.ConsensusFault, error) {
+	b, err := r.faults.Get(ctx, datastore.NewKey(block.String()))
+	if err != nil {
+		if err == datastore.ErrNotFound {
+			return nil, xerrors.Errorf("no consensus fault of block %s: %w", block, err)
+		}
+		return nil, xerrors.Errorf("getting consensus fault of block %s: %w", block, err)
+	}
+
+	var cf api.ConsensusFault
+	if err := json.Unmarshal(b, &cf); err != nil {
+		return nil, xerrors.Errorf("decoding consensus fault of block %s: %w", block, err)
+	}
+	return &cf, nil
+}
+
+func (r *Reporter) put(ctx context.Context, cf *api.ConsensusFault) error {
+	b, err := json.Marshal(cf)
+	if err != nil {
+		return err
+	}
+	if err := r.faults.Put(ctx, datastore.NewKey(cf.Block.String()), b); err != nil {
+		return xerrors.Errorf("storing consensus fault of block %s: %w", cf.Block, err)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+//stm: #unit
+package slashsvc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/gen/slashfilter"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+type testChain struct {
+	blocks map[cid.Cid]*types.BlockHeader
+	head   *types.TipSet
+}
+
+func (tc *testChain) GetBlock(ctx context.Context, c cid.Cid) (*types.BlockHeader, error) {
+	b, ok := tc.blocks[c]
+	if !ok {
+		return nil, xerrors.Errorf("block %s not found", c)
+	}
+	return b, nil
+}
+
+func (tc *testChain) LoadTipSet(ctx context.Context, tsk types.TipSetKey) (*types.TipSet, error) {
+	var blks []*types.BlockHeader
+	for _, c := range tsk.Cids() {
+		b, err := tc.GetBlock(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		blks = append(blks, b)
+	}
+	return types.NewTipSet(blks)
+}
+
+func (tc *testChain) GetHeaviestTipSet() *types.TipSet {
+	return tc.head
+}
+
+type testMpool struct {
+	msgs []*types.Message
+}
+
+func (tm *testMpool) MpoolPushMessage(ctx context.Context, msg *types.Message, spec *api.MessageSendSpec) (*types.SignedMessage, error) {
+	tm.msgs = append(tm.msgs, msg)
+	return &types.SignedMessage{Message: *msg}, nil
+}
+
+func mkBlock(miner address.Address, h abi.ChainEpoch, nonce uint64, parents ...*types.BlockHeader) *types.BlockHeader {
+	b := mock.MkBlock(nil, 0, nonce)
+	b.Miner = miner
+	b.Height = h
+	b.Parents = nil
+	for _, p := range parents {
+		b.Parents = append(b.Parents, p.Cid())
+	}
+	return b
+}
+
+func TestReporter(t *testing.T) {
+	ctx := context.Background()
+
+	faulty, other := mock.Address(1000), mock.Address(2000)
+	from := mock.Address(3000)
+
+	gen := mkBlock(other, 9, 1)
+	tc := &testChain{blocks: map[cid.Cid]*types.BlockHeader{gen.Cid(): gen}}
+
+	head, err := types.NewTipSet([]*types.BlockHeader{gen})
+	require.NoError(t, err)
+	tc.head = head
+
+	mp := &testMpool{}
+	r, err := NewReporter(dssync.MutexWrap(datastore.NewMapDatastore()), tc, nil, mp, from, false)
+	require.NoError(t, err)
+
+	// double-fork mining
+	a := mkBlock(faulty, 10, 2, gen)
+	a2 := mkBlock(faulty, 10, 3, gen)
+	require.NoError(t, r.checkBlock(ctx, a))
+	require.NoError(t, r.checkBlock(ctx, a2))
+
+	faults, err := r.Faults(ctx)
+	require.NoError(t, err)
+	require.Len(t, faults, 1)
+	require.Equal(t, a2.Cid(), faults[0].Block)
+	require.Equal(t, slashfilter.FaultDoubleFork, faults[0].Type)
+	require.Equal(t, api.ConsensusFaultPending, faults[0].Status)
+
+	// the same block is only checked once
+	require.NoError(t, r.checkBlock(ctx, a2))
+	faults, err = r.Faults(ctx)
+	require.NoError(t, err)
+	require.Len(t, faults, 1)
+
+	require.NoError(t, r.Dismiss(ctx, a2.Cid()))
+	_, err = r.Submit(ctx, a2.Cid())
+	require.Error(t, err)
+
+	// parent-grinding: the faulty miner builds on a sibling of its own block
+	c := mkBlock(other, 10, 4, gen)
+	tc.blocks[c.Cid()] = c
+	require.NoError(t, r.checkBlock(ctx, c))
+
+	b := mkBlock(faulty, 11, 5, c)
+	require.NoError(t, r.checkBlock(ctx, b))
+
+	faults, err = r.Faults(ctx)
+	require.NoError(t, err)
+	require.Len(t, faults, 2)
+
+	var pg api.ConsensusFault
+	for _, f := range faults {
+		if f.Block == b.Cid() {
+			pg = f
+		}
+	}
+	require.Equal(t, slashfilter.FaultParentGrinding, pg.Type)
+	require.NotEmpty(t, pg.BlockHeaderExtra)
+
+	extra, err := types.DecodeBlock(pg.BlockHeaderExtra)
+	require.NoError(t, err)
+	require.Equal(t, c.Cid(), extra.Cid())
+
+	mcid, err := r.Submit(ctx, b.Cid())
+	require.NoError(t, err)
+	require.Len(t, mp.msgs, 1)
+	require.Equal(t, faulty, mp.msgs[0].To)
+	require.Equal(t, from, mp.msgs[0].From)
+	require.Equal(t, builtin.MethodsMiner.ReportConsensusFault, mp.msgs[0].Method)
+
+	faults, err = r.Faults(ctx)
+	require.NoError(t, err)
+	for _, f := range faults {
+		if f.Block == b.Cid() {
+			require.Equal(t, api.ConsensusFaultSubmitted, f.Status)
+			require.Equal(t, mcid, *f.Message)
+		}
+	}
+}
+
+func TestReporterAutoSubmit(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := NewReporter(datastore.NewMapDatastore(), &testChain{}, nil, &testMpool{}, address.Undef, true)
+	require.Error(t, err)
+
+	faulty := mock.Address(1000)
+	gen := mkBlock(mock.Address(2000), 9, 1)
+	head, err := types.NewTipSet([]*types.BlockHeader{gen})
+	require.NoError(t, err)
+	tc := &testChain{blocks: map[cid.Cid]*types.BlockHeader{gen.Cid(): gen}, head: head}
+
+	mp := &testMpool{}
+	r, err := NewReporter(dssync.MutexWrap(datastore.NewMapDatastore()), tc, nil, mp, mock.Address(3000), true)
+	require.NoError(t, err)
+
+	// time-offset mining: two blocks on the same parents at different epochs
+	require.NoError(t, r.checkBlock(ctx, mkBlock(faulty, 10, 2, gen)))
+	require.NoError(t, r.checkBlock(ctx, mkBlock(faulty, 11, 3, gen)))
+
+	require.Len(t, mp.msgs, 1)
+
+	faults, err := r.Faults(ctx)
+	require.NoError(t, err)
+	require.Len(t, faults, 1)
+	require.Equal(t, slashfilter.FaultTimeOffset, faults[0].Type)
+	require.Equal(t, api.ConsensusFaultSubmitted, faults[0].Status)
+}
@@ -0,0 +1,440 @@
+package index
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dsq "github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var log = logging.Logger("chainindex")
+
+const (
+	heightPrefix = "/height/"
+	msgPrefix    = "/msg/"
+	actorPrefix  = "/actor/"
+)
+
+// actorBucketEpochs is the height range of the buckets the messages of an
+// actor are grouped in, so that ActorMessages can start from the bucket of the
+// first height it looks for, as datastore queries can't seek within a prefix
+const actorBucketEpochs = 2880
+
+// ChainStore is the subset of the chain store the index is maintained from
+type ChainStore interface {
+	GetHeaviestTipSet() *types.TipSet
+	LoadTipSet(ctx context.Context, tsk types.TipSetKey) (*types.TipSet, error)
+	MessagesForTipset(ctx context.Context, ts *types.TipSet) ([]types.ChainMsg, error)
+	SubscribeHeadChanges(f store.ReorgNotifee)
+}
+
+// Index maps the heights of the node's chain, the messages included in it, and
+// the actors sending or receiving them to tipsets. It is kept up to date with
+// the head changes of the chain store.
+type Index struct {
+	cs ChainStore
+	ds datastore.Batching
+
+	// backfill is the number of epochs below the head indexed on start
+	backfill abi.ChainEpoch
+
+	// lk serializes the updates of the index
+	lk     sync.Mutex
+	closed bool
+
+	// caughtUp is set once the index caught up with the chain on start
+	caughtUp int32
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var _ Reader = (*Index)(nil)
+
+func NewIndex(cs ChainStore, ds datastore.Batching, backfill abi.ChainEpoch) *Index {
+	return &Index{
+		cs:       cs,
+		ds:       ds,
+		backfill: backfill,
+	}
+}
+
+func (x *Index) Start(ctx context.Context) error {
+	x.ctx, x.cancel = context.WithCancel(context.Background())
+
+	x.cs.SubscribeHeadChanges(x.headChange)
+
+	x.wg.Add(1)
+	go func() {
+		defer x.wg.Done()
+
+		if err := x.catchUp(x.ctx, x.cs.GetHeaviestTipSet()); err != nil && x.ctx.Err() == nil {
+			log.Errorw("indexing chain", "error", err)
+		}
+	}()
+
+	return nil
+}
+
+func (x *Index) Stop(ctx context.Context) error {
+	x.lk.Lock()
+	x.closed = true
+	x.lk.Unlock()
+
+	x.cancel()
+	x.wg.Wait()
+	return nil
+}
+
+// catchUp indexes the tipsets from head down to the first one already indexed,
+// at most backfill epochs below head
+func (x *Index) catchUp(ctx context.Context, head *types.TipSet) error {
+	ts := head
+	for head.Height()-ts.Height() <= x.backfill {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		indexed, err := x.GetTipSetKey(ctx, ts.Height())
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+		if err == nil && indexed == ts.Key() {
+			break
+		}
+
+		if err := x.apply(ctx, ts); err != nil {
+			return xerrors.Errorf("indexing tipset at height %d: %w", ts.Height(), err)
+		}
+
+		if ts.Height() == 0 {
+			break
+		}
+
+		ts, err = x.cs.LoadTipSet(ctx, ts.Parents())
+		if err != nil {
+			return xerrors.Errorf("loading parent tipset: %w", err)
+		}
+	}
+
+	atomic.StoreInt32(&x.caughtUp, 1)
+	log.Infow("chain index caught up", "head", head.Height(), "from", ts.Height())
+	return nil
+}
+
+func (x *Index) headChange(rev, app []*types.TipSet) error {
+	ctx := context.TODO()
+
+	for _, ts := range rev {
+		if err := x.revert(ctx, ts); err != nil {
+			return xerrors.Errorf("unindexing tipset at height %d: %w", ts.Height(), err)
+		}
+	}
+	for _, ts := range app {
+		if err := x.apply(ctx, ts); err != nil {
+			return xerrors.Errorf("indexing tipset at height %d: %w", ts.Height(), err)
+		}
+	}
+
+	return nil
+}
+
+// apply indexes the tipset
+func (x *Index) apply(ctx context.Context, ts *types.TipSet) error {
+	msgs, err := x.cs.MessagesForTipset(ctx, ts)
+	if err != nil {
+		return xerrors.Errorf("loading messages: %w", err)
+	}
+
+	parentHeight := ts.Height()
+	if ts.Height() > 0 {
+		pts, err := x.cs.LoadTipSet(ctx, ts.Parents())
+		if err != nil {
+			return xerrors.Errorf("loading parent tipset: %w", err)
+		}
+		parentHeight = pts.Height()
+	}
+
+	x.lk.Lock()
+	defer x.lk.Unlock()
+
+	if x.closed {
+		return nil
+	}
+
+	b, err := x.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := b.Put(ctx, heightKey(ts.Height()), ts.Key().Bytes()); err != nil {
+		return err
+	}
+
+	// the null rounds before the tipset may be indexed with the tipsets of
+	// another fork
+	for h := parentHeight + 1; h < ts.Height(); h++ {
+		if err := b.Delete(ctx, heightKey(h)); err != nil {
+			return err
+		}
+	}
+
+	entry := msgEntry(ts)
+	for _, m := range msgs {
+		if err := b.Put(ctx, msgKey(m.Cid()), entry); err != nil {
+			return err
+		}
+
+		vmm := m.VMMessage()
+		if err := b.Put(ctx, actorKey(vmm.From, ts.Height(), m.Cid()), []byte{}); err != nil {
+			return err
+		}
+		if err := b.Put(ctx, actorKey(vmm.To, ts.Height(), m.Cid()), []byte{}); err != nil {
+			return err
+		}
+	}
+
+	return b.Commit(ctx)
+}
+
+// revert removes the entries of the tipset from the index
+func (x *Index) revert(ctx context.Context, ts *types.TipSet) error {
+	msgs, err := x.cs.MessagesForTipset(ctx, ts)
+	if err != nil {
+		return xerrors.Errorf("loading messages: %w", err)
+	}
+
+	x.lk.Lock()
+	defer x.lk.Unlock()
+
+	if x.closed {
+		return nil
+	}
+
+	b, err := x.ds.Batch(ctx)
+	if err != nil {
+		return err
+	}
+
+	indexed, err := x.ds.Get(ctx, heightKey(ts.Height()))
+	switch {
+	case err == datastore.ErrNotFound:
+	case err != nil:
+		return err
+	case string(indexed) == string(ts.Key().Bytes()):
+		if err := b.Delete(ctx, heightKey(ts.Height())); err != nil {
+			return err
+		}
+	}
+
+	for _, m := range msgs {
+		// the message may have been included again by the tipsets applied since
+		info, err := x.getMsgEntry(ctx, m.Cid())
+		if err == nil && info.TipSet == ts.Key() {
+			if err := b.Delete(ctx, msgKey(m.Cid())); err != nil {
+				return err
+			}
+		} else if err != nil && err != ErrNotFound {
+			return err
+		}
+
+		vmm := m.VMMessage()
+		if err := b.Delete(ctx, actorKey(vmm.From, ts.Height(), m.Cid())); err != nil {
+			return err
+		}
+		if err := b.Delete(ctx, actorKey(vmm.To, ts.Height(), m.Cid())); err != nil {
+			return err
+		}
+	}
+
+	return b.Commit(ctx)
+}
+
+func (x *Index) GetTipSetKey(ctx context.Context, h abi.ChainEpoch) (types.TipSetKey, error) {
+	b, err := x.ds.Get(ctx, heightKey(h))
+	if err == datastore.ErrNotFound {
+		return types.EmptyTSK, ErrNotFound
+	}
+	if err != nil {
+		return types.EmptyTSK, err
+	}
+
+	return types.TipSetKeyFromBytes(b)
+}
+
+// HeightIndex returns the index as the height index of the chain store, which
+// only answers once the index caught up with the chain on start, as the heights
+// below the head may still map to tipsets orphaned while the node was down
+func (x *Index) HeightIndex() store.HeightIndex {
+	return (*heightIndex)(x)
+}
+
+type heightIndex Index
+
+func (hi *heightIndex) GetTipSetKey(ctx context.Context, h abi.ChainEpoch) (types.TipSetKey, error) {
+	x := (*Index)(hi)
+	if atomic.LoadInt32(&x.caughtUp) == 0 {
+		return types.EmptyTSK, ErrNotFound
+	}
+	return x.GetTipSetKey(ctx, h)
+}
+
+func (x *Index) GetMsgInfo(ctx context.Context, m cid.Cid) (MsgInfo, error) {
+	info, err := x.getMsgEntry(ctx, m)
+	if err != nil {
+		return MsgInfo{}, err
+	}
+
+	// entries of tipsets orphaned while the index wasn't running are stale
+	if !x.canonical(ctx, info) {
+		return MsgInfo{}, ErrNotFound
+	}
+
+	return info, nil
+}
+
+func (x *Index) ActorMessages(ctx context.Context, addr address.Address, from, to abi.ChainEpoch, limit int) ([]MsgInfo, error) {
+	if from < 0 {
+		from = 0
+	}
+	if head := x.cs.GetHeaviestTipSet(); head != nil && to > head.Height() {
+		to = head.Height()
+	}
+
+	var out []MsgInfo
+	for b := from / actorBucketEpochs; b <= to/actorBucketEpochs; b++ {
+		done, err := x.actorBucketMessages(ctx, addr, b, from, to, limit, &out)
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			break
+		}
+	}
+
+	return out, nil
+}
+
+// actorBucketMessages appends the messages of the actor in the bucket to out,
+// and returns whether the range or the limit was reached
+func (x *Index) actorBucketMessages(ctx context.Context, addr address.Address, bucket, from, to abi.ChainEpoch, limit int, out *[]MsgInfo) (bool, error) {
+	prefix := actorBucketPrefix(addr, bucket)
+	res, err := x.ds.Query(ctx, dsq.Query{Prefix: prefix, KeysOnly: true, Orders: []dsq.Order{dsq.OrderByKey{}}})
+	if err != nil {
+		return false, err
+	}
+	defer res.Close() //nolint:errcheck
+
+	for r := range res.Next() {
+		if r.Error != nil {
+			return false, r.Error
+		}
+
+		parts := strings.Split(strings.TrimPrefix(r.Key, prefix+"/"), "/")
+		if len(parts) != 2 {
+			return false, xerrors.Errorf("malformed actor index key %q", r.Key)
+		}
+
+		h, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return false, xerrors.Errorf("malformed height in actor index key %q: %w", r.Key, err)
+		}
+		if abi.ChainEpoch(h) < from {
+			continue
+		}
+		if abi.ChainEpoch(h) > to {
+			return true, nil
+		}
+
+		c, err := cid.Decode(parts[1])
+		if err != nil {
+			return false, xerrors.Errorf("malformed cid in actor index key %q: %w", r.Key, err)
+		}
+
+		info, err := x.GetMsgInfo(ctx, c)
+		if err == ErrNotFound || (err == nil && info.Height != abi.ChainEpoch(h)) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+
+		*out = append(*out, info)
+		if limit > 0 && len(*out) >= limit {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (x *Index) getMsgEntry(ctx context.Context, m cid.Cid) (MsgInfo, error) {
+	b, err := x.ds.Get(ctx, msgKey(m))
+	if err == datastore.ErrNotFound {
+		return MsgInfo{}, ErrNotFound
+	}
+	if err != nil {
+		return MsgInfo{}, err
+	}
+	if len(b) < 8 {
+		return MsgInfo{}, xerrors.Errorf("malformed message index entry for %s", m)
+	}
+
+	tsk, err := types.TipSetKeyFromBytes(b[8:])
+	if err != nil {
+		return MsgInfo{}, xerrors.Errorf("malformed message index entry for %s: %w", m, err)
+	}
+
+	return MsgInfo{
+		Message: m,
+		TipSet:  tsk,
+		Height:  abi.ChainEpoch(binary.BigEndian.Uint64(b[:8])),
+	}, nil
+}
+
+// canonical returns whether the tipset including the message is the one indexed
+// at its height
+func (x *Index) canonical(ctx context.Context, info MsgInfo) bool {
+	tsk, err := x.GetTipSetKey(ctx, info.Height)
+	return err == nil && tsk == info.TipSet
+}
+
+func heightKey(h abi.ChainEpoch) datastore.Key {
+	return datastore.NewKey(fmt.Sprintf("%s%020d", heightPrefix, h))
+}
+
+func msgKey(m cid.Cid) datastore.Key {
+	return datastore.NewKey(msgPrefix + m.String())
+}
+
+func actorBucketPrefix(addr address.Address, bucket abi.ChainEpoch) string {
+	return fmt.Sprintf("%s%s/%012d", actorPrefix, addr, bucket)
+}
+
+func actorKey(addr address.Address, h abi.ChainEpoch, m cid.Cid) datastore.Key {
+	return datastore.NewKey(fmt.Sprintf("%s/%020d/%s", actorBucketPrefix(addr, h/actorBucketEpochs), h, m))
+}
+
+// msgEntry encodes the height and key of the tipset including a message
+func msgEntry(ts *types.TipSet) []byte {
+	tsk := ts.Key().Bytes()
+	b := make([]byte, 8, 8+len(tsk))
+	binary.BigEndian.PutUint64(b, uint64(ts.Height()))
+	return append(b, tsk...)
+}
@@ -0,0 +1,212 @@
+//stm: #unit
+package index
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+type fakeChain struct {
+	head     *types.TipSet
+	tipsets  map[types.TipSetKey]*types.TipSet
+	messages map[types.TipSetKey][]types.ChainMsg
+	notifee  store.ReorgNotifee
+}
+
+func newFakeChain() *fakeChain {
+	return &fakeChain{
+		tipsets:  map[types.TipSetKey]*types.TipSet{},
+		messages: map[types.TipSetKey][]types.ChainMsg{},
+	}
+}
+
+// grow adds a tipset on top of parent, including messages between from and to
+func (c *fakeChain) grow(parent *types.TipSet, nonce uint64, from, to address.Address) *types.TipSet {
+	ts := mock.TipSet(mock.MkBlock(parent, 1, nonce))
+	c.tipsets[ts.Key()] = ts
+	c.messages[ts.Key()] = []types.ChainMsg{&types.Message{From: from, To: to, Nonce: nonce}}
+	return ts
+}
+
+func (c *fakeChain) GetHeaviestTipSet() *types.TipSet {
+	return c.head
+}
+
+func (c *fakeChain) LoadTipSet(_ context.Context, tsk types.TipSetKey) (*types.TipSet, error) {
+	ts, ok := c.tipsets[tsk]
+	if !ok {
+		return nil, xerrors.Errorf("tipset %s not found", tsk)
+	}
+	return ts, nil
+}
+
+func (c *fakeChain) MessagesForTipset(_ context.Context, ts *types.TipSet) ([]types.ChainMsg, error) {
+	return c.messages[ts.Key()], nil
+}
+
+func (c *fakeChain) SubscribeHeadChanges(f store.ReorgNotifee) {
+	c.notifee = f
+}
+
+func TestIndex(t *testing.T) {
+	ctx := context.Background()
+
+	alice, bob, carol := mock.Address(1000), mock.Address(1001), mock.Address(1002)
+
+	c := newFakeChain()
+	var chain []*types.TipSet
+	var parent *types.TipSet
+	for i := 0; i < 5; i++ {
+		parent = c.grow(parent, uint64(i), alice, bob)
+		chain = append(chain, parent)
+	}
+	c.head = parent
+
+	idx := NewIndex(c, dssync.MutexWrap(datastore.NewMapDatastore()), 3)
+	require.NoError(t, idx.catchUp(ctx, c.head))
+
+	// only the backfill window is indexed
+	for h, ts := range chain {
+		tsk, err := idx.GetTipSetKey(ctx, ts.Height())
+		if h == 0 {
+			require.ErrorIs(t, err, ErrNotFound)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, ts.Key(), tsk)
+	}
+
+	msg := c.messages[chain[2].Key()][0]
+	info, err := idx.GetMsgInfo(ctx, msg.Cid())
+	require.NoError(t, err)
+	require.Equal(t, chain[2].Key(), info.TipSet)
+	require.Equal(t, chain[2].Height(), info.Height)
+
+	msgs, err := idx.ActorMessages(ctx, bob, 2, 3, 0)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	require.Equal(t, chain[2].Height(), msgs[0].Height)
+	require.Equal(t, chain[3].Height(), msgs[1].Height)
+
+	msgs, err = idx.ActorMessages(ctx, alice, 0, 10, 1)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+
+	// reorg the last two tipsets onto a fork sending to carol
+	fork3 := c.grow(chain[2], 100, alice, carol)
+	fork4 := c.grow(fork3, 101, alice, carol)
+	require.NoError(t, idx.headChange([]*types.TipSet{chain[4], chain[3]}, []*types.TipSet{fork3, fork4}))
+
+	reverted := c.messages[chain[3].Key()][0]
+	_, err = idx.GetMsgInfo(ctx, reverted.Cid())
+	require.ErrorIs(t, err, ErrNotFound)
+
+	msgs, err = idx.ActorMessages(ctx, bob, 0, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+
+	msgs, err = idx.ActorMessages(ctx, carol, 0, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+
+	tsk, err := idx.GetTipSetKey(ctx, fork4.Height())
+	require.NoError(t, err)
+	require.Equal(t, fork4.Key(), tsk)
+}
+
+func TestIndexNullRounds(t *testing.T) {
+	ctx := context.Background()
+
+	alice, bob := mock.Address(1000), mock.Address(1001)
+
+	c := newFakeChain()
+	var chain []*types.TipSet
+	var parent *types.TipSet
+	for i := 0; i < 5; i++ {
+		parent = c.grow(parent, uint64(i), alice, bob)
+		chain = append(chain, parent)
+	}
+	c.head = parent
+
+	idx := NewIndex(c, dssync.MutexWrap(datastore.NewMapDatastore()), 10)
+	require.NoError(t, idx.catchUp(ctx, c.head))
+
+	// while the node is down, the chain switches to a fork with null rounds
+	// after chain[1]
+	blk := mock.MkBlock(chain[1], 1, 100)
+	blk.Height = chain[4].Height()
+	fork := mock.TipSet(blk)
+	c.tipsets[fork.Key()] = fork
+	c.head = fork
+
+	require.NoError(t, idx.catchUp(ctx, c.head))
+
+	tsk, err := idx.GetTipSetKey(ctx, fork.Height())
+	require.NoError(t, err)
+	require.Equal(t, fork.Key(), tsk)
+
+	// the heights of the null rounds no longer map to the orphaned tipsets
+	for _, ts := range chain[2:4] {
+		_, err := idx.GetTipSetKey(ctx, ts.Height())
+		require.ErrorIs(t, err, ErrNotFound)
+	}
+}
+
+func TestActorMessagesBuckets(t *testing.T) {
+	ctx := context.Background()
+
+	alice, bob := mock.Address(1000), mock.Address(1001)
+
+	c := newFakeChain()
+	var chain []*types.TipSet
+	var parent *types.TipSet
+	for i, h := range []abi.ChainEpoch{0, 10, actorBucketEpochs + 20, 2*actorBucketEpochs + 5, 2*actorBucketEpochs + 6} {
+		b := mock.MkBlock(parent, 1, uint64(i))
+		b.Height = h
+		parent = mock.TipSet(b)
+		c.tipsets[parent.Key()] = parent
+		c.messages[parent.Key()] = []types.ChainMsg{&types.Message{From: alice, To: bob, Nonce: uint64(i)}}
+		chain = append(chain, parent)
+	}
+	c.head = parent
+
+	idx := NewIndex(c, dssync.MutexWrap(datastore.NewMapDatastore()), 3*actorBucketEpochs)
+
+	// the chain store doesn't use the index before it caught up
+	_, err := idx.HeightIndex().GetTipSetKey(ctx, 10)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, idx.catchUp(ctx, c.head))
+
+	tsk, err := idx.HeightIndex().GetTipSetKey(ctx, 10)
+	require.NoError(t, err)
+	require.Equal(t, chain[1].Key(), tsk)
+
+	msgs, err := idx.ActorMessages(ctx, alice, 11, 2*actorBucketEpochs+5, 0)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	require.Equal(t, chain[2].Height(), msgs[0].Height)
+	require.Equal(t, chain[3].Height(), msgs[1].Height)
+
+	msgs, err = idx.ActorMessages(ctx, bob, 1, 1<<40, 2)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	require.Equal(t, chain[1].Height(), msgs[0].Height)
+	require.Equal(t, chain[2].Height(), msgs[1].Height)
+
+	msgs, err = idx.ActorMessages(ctx, bob, 11, 19, 0)
+	require.NoError(t, err)
+	require.Empty(t, msgs)
+}
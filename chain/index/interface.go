@@ -0,0 +1,58 @@
+package index
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ipfs/go-cid"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var ErrNotFound = errors.New("not found in chain index")
+
+// MsgInfo locates a message included in the indexed chain
+type MsgInfo struct {
+	// Message is the CID of the message
+	Message cid.Cid
+	// TipSet is the tipset which included the message
+	TipSet types.TipSetKey
+	// Height is the height of TipSet
+	Height abi.ChainEpoch
+}
+
+// Reader is the read side of the chain index
+type Reader interface {
+	// GetMsgInfo returns the tipset of the indexed chain which included the message,
+	// or ErrNotFound
+	GetMsgInfo(ctx context.Context, m cid.Cid) (MsgInfo, error)
+	// GetTipSetKey returns the key of the tipset at the given height of the indexed
+	// chain, or ErrNotFound for null rounds and heights which aren't indexed
+	GetTipSetKey(ctx context.Context, h abi.ChainEpoch) (types.TipSetKey, error)
+	// ActorMessages returns the messages of the indexed chain with the given
+	// address as sender or recipient, included in the height range [from, to],
+	// ordered by height. At most limit messages are returned when limit is
+	// positive.
+	ActorMessages(ctx context.Context, addr address.Address, from, to abi.ChainEpoch, limit int) ([]MsgInfo, error)
+}
+
+// DummyIndex is the Reader of nodes with the chain index disabled, which
+// doesn't find anything
+var DummyIndex Reader = dummyIndex{}
+
+type dummyIndex struct{}
+
+func (dummyIndex) GetMsgInfo(context.Context, cid.Cid) (MsgInfo, error) {
+	return MsgInfo{}, ErrNotFound
+}
+
+func (dummyIndex) GetTipSetKey(context.Context, abi.ChainEpoch) (types.TipSetKey, error) {
+	return types.EmptyTSK, ErrNotFound
+}
+
+func (dummyIndex) ActorMessages(context.Context, address.Address, abi.ChainEpoch, abi.ChainEpoch, int) ([]MsgInfo, error) {
+	return nil, ErrNotFound
+}
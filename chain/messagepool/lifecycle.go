@@ -0,0 +1,172 @@
+package messagepool
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin"
+
+	"github.com/filecoin-project/lotus/build"
+	lbuiltin "github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+// Method groups of the local messages, as reported in the lifecycle metrics
+const (
+	methodGroupWindowPoSt   = "windowpost"
+	methodGroupPreCommit    = "precommit"
+	methodGroupProveCommit  = "provecommit"
+	methodGroupPublishDeals = "publishdeals"
+	methodGroupOther        = "other"
+)
+
+// lifecycleExpiry is how long a local message is tracked without being
+// included before it is forgotten
+var lifecycleExpiry = 24 * time.Hour
+
+type lifecycleKey struct {
+	from  address.Address
+	nonce uint64
+}
+
+type lifecycleEntry struct {
+	group  string
+	pushed time.Time
+
+	// included is the epoch of the tipset which includes the message, or -1
+	included abi.ChainEpoch
+	// recorded is set once the inclusion was recorded, so that the message
+	// isn't recorded again when it is re-included after a reorg
+	recorded bool
+}
+
+// msgLifecycle tracks the local messages from their push to the finality of
+// their inclusion. Messages are keyed by sender and nonce, so a message
+// replaced by fee keeps the time of the original push.
+type msgLifecycle struct {
+	lk   sync.Mutex
+	msgs map[lifecycleKey]*lifecycleEntry
+}
+
+func newMsgLifecycle() *msgLifecycle {
+	return &msgLifecycle{
+		msgs: make(map[lifecycleKey]*lifecycleEntry),
+	}
+}
+
+func (l *msgLifecycle) pushed(m *types.Message, group string) {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+
+	k := lifecycleKey{from: m.From, nonce: m.Nonce}
+	if _, ok := l.msgs[k]; ok {
+		return
+	}
+	l.msgs[k] = &lifecycleEntry{
+		group:    group,
+		pushed:   build.Clock.Now(),
+		included: -1,
+	}
+}
+
+func (l *msgLifecycle) included(ctx context.Context, m *types.Message, epoch abi.ChainEpoch) {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+
+	e, ok := l.msgs[lifecycleKey{from: m.From, nonce: m.Nonce}]
+	if !ok || e.included >= 0 {
+		return
+	}
+	e.included = epoch
+	if !e.recorded {
+		e.recorded = true
+		recordLifecycle(ctx, e.group, metrics.MpoolMessageInclusionDuration, build.Clock.Since(e.pushed))
+	}
+}
+
+func (l *msgLifecycle) reverted(m *types.Message) {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+
+	if e, ok := l.msgs[lifecycleKey{from: m.From, nonce: m.Nonce}]; ok {
+		e.included = -1
+	}
+}
+
+// headChange records the finality of the messages included at least a
+// finality before the height, and forgets them along with the expired ones
+func (l *msgLifecycle) headChange(ctx context.Context, height abi.ChainEpoch) {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+
+	now := build.Clock.Now()
+	for k, e := range l.msgs {
+		switch {
+		case e.included >= 0 && height >= e.included+build.Finality:
+			recordLifecycle(ctx, e.group, metrics.MpoolMessageFinalityDuration, now.Sub(e.pushed))
+			delete(l.msgs, k)
+		case e.included < 0 && now.Sub(e.pushed) > lifecycleExpiry:
+			delete(l.msgs, k)
+		}
+	}
+}
+
+// pendingPushes returns the push times of the messages not included yet
+func (l *msgLifecycle) pendingPushes() []time.Time {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+
+	var out []time.Time
+	for _, e := range l.msgs {
+		if e.included < 0 {
+			out = append(out, e.pushed)
+		}
+	}
+	return out
+}
+
+func recordLifecycle(ctx context.Context, group string, m *stats.Float64Measure, d time.Duration) {
+	_ = stats.RecordWithTags(ctx,
+		[]tag.Mutator{tag.Upsert(metrics.MethodGroup, group)},
+		m.M(d.Seconds()),
+	)
+}
+
+// methodGroup returns the group of the message for the lifecycle metrics
+func (mp *MessagePool) methodGroup(m *types.Message, ts *types.TipSet) string {
+	if m.To == builtin.StorageMarketActorAddr && m.Method == builtin.MethodsMarket.PublishStorageDeals {
+		return methodGroupPublishDeals
+	}
+
+	var group string
+	switch m.Method {
+	case builtin.MethodsMiner.SubmitWindowedPoSt:
+		group = methodGroupWindowPoSt
+	case builtin.MethodsMiner.PreCommitSector, builtin.MethodsMiner.PreCommitSectorBatch:
+		group = methodGroupPreCommit
+	case builtin.MethodsMiner.ProveCommitSector, builtin.MethodsMiner.ProveCommitAggregate:
+		group = methodGroupProveCommit
+	default:
+		return methodGroupOther
+	}
+
+	// Method numbers are only meaningful for the actor they are sent to
+	act, err := mp.api.GetActorAfter(m.To, ts)
+	if err != nil || !lbuiltin.IsStorageMinerActor(act.Code) {
+		return methodGroupOther
+	}
+	return group
+}
+
+// PendingLocalPushes returns when the locally pushed messages which aren't
+// included yet were pushed
+func (mp *MessagePool) PendingLocalPushes() []time.Time {
+	return mp.lifecycle.pendingPushes()
+}
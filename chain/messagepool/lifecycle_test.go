@@ -0,0 +1,78 @@
+//stm: #unit
+package messagepool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+
+	"github.com/filecoin-project/go-state-types/builtin"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+func TestMsgLifecycle(t *testing.T) {
+	ctx := context.Background()
+
+	require.NoError(t, view.Register(metrics.MpoolMessageInclusionDurationView, metrics.MpoolMessageFinalityDurationView))
+	defer view.Unregister(metrics.MpoolMessageInclusionDurationView, metrics.MpoolMessageFinalityDurationView)
+
+	count := func(v *view.View) int64 {
+		rows, err := view.RetrieveData(v.Name)
+		require.NoError(t, err)
+
+		var n int64
+		for _, row := range rows {
+			require.Equal(t, methodGroupWindowPoSt, row.Tags[0].Value)
+			n += row.Data.(*view.DistributionData).Count
+		}
+		return n
+	}
+
+	l := newMsgLifecycle()
+	m := &types.Message{From: mock.Address(1000), To: mock.Address(1001), Nonce: 3}
+
+	l.pushed(m, methodGroupWindowPoSt)
+	// a replacement keeps the original push
+	l.pushed(m, methodGroupOther)
+	require.Equal(t, methodGroupWindowPoSt, l.msgs[lifecycleKey{m.From, m.Nonce}].group)
+
+	l.included(ctx, m, 10)
+	require.EqualValues(t, 1, count(metrics.MpoolMessageInclusionDurationView))
+
+	// re-inclusion after a reorg isn't recorded twice
+	l.reverted(m)
+	l.headChange(ctx, 10+build.Finality)
+	require.Len(t, l.msgs, 1)
+	l.included(ctx, m, 11)
+	require.EqualValues(t, 1, count(metrics.MpoolMessageInclusionDurationView))
+
+	l.headChange(ctx, 10+build.Finality)
+	require.Len(t, l.msgs, 1)
+	require.EqualValues(t, 0, count(metrics.MpoolMessageFinalityDurationView))
+
+	l.headChange(ctx, 11+build.Finality)
+	require.Len(t, l.msgs, 0)
+	require.EqualValues(t, 1, count(metrics.MpoolMessageFinalityDurationView))
+}
+
+func TestMethodGroup(t *testing.T) {
+	tma := newTestMpoolAPI()
+	mp := &MessagePool{api: tma}
+	ts := tma.tipsets[0]
+
+	deals := &types.Message{To: builtin.StorageMarketActorAddr, Method: builtin.MethodsMarket.PublishStorageDeals}
+	require.Equal(t, methodGroupPublishDeals, mp.methodGroup(deals, ts))
+
+	// the test actors aren't miners
+	post := &types.Message{To: mock.Address(1001), Method: builtin.MethodsMiner.SubmitWindowedPoSt}
+	require.Equal(t, methodGroupOther, mp.methodGroup(post, ts))
+
+	send := &types.Message{To: mock.Address(1001), Method: builtin.MethodSend}
+	require.Equal(t, methodGroupOther, mp.methodGroup(send, ts))
+}
@@ -174,6 +174,9 @@ type MessagePool struct {
 
 	evtTypes [3]journal.EventType
 	journal  journal.Journal
+
+	// lifecycle tracks the locally pushed messages until their inclusion is final
+	lifecycle *msgLifecycle
 }
 
 type nonceCacheKey struct {
@@ -402,7 +405,8 @@ func New(ctx context.Context, api Provider, ds dtypes.MetadataDS, us stmgr.Upgra
 			evtTypeMpoolRemove: j.RegisterEventType("mpool", "remove"),
 			evtTypeMpoolRepub:  j.RegisterEventType("mpool", "repub"),
 		},
-		journal: j,
+		journal:   j,
+		lifecycle: newMsgLifecycle(),
 	}
 
 	// enable initial prunes
@@ -688,6 +692,7 @@ func (mp *MessagePool) Push(ctx context.Context, m *types.SignedMessage) (cid.Ci
 		mp.curTsLk.Unlock()
 		return cid.Undef, err
 	}
+	mp.lifecycle.pushed(&m.Message, mp.methodGroup(&m.Message, mp.curTs))
 	mp.curTsLk.Unlock()
 
 	if publish {
@@ -1243,6 +1248,7 @@ func (mp *MessagePool) HeadChange(ctx context.Context, revert []*types.TipSet, a
 
 		for _, msg := range msgs {
 			add(msg)
+			mp.lifecycle.reverted(&msg.Message)
 		}
 	}
 
@@ -1261,15 +1267,21 @@ func (mp *MessagePool) HeadChange(ctx context.Context, revert []*types.TipSet, a
 			for _, msg := range smsgs {
 				rm(msg.Message.From, msg.Message.Nonce)
 				maybeRepub(msg.Cid())
+				mp.lifecycle.included(ctx, &msg.Message, ts.Height())
 			}
 
 			for _, msg := range bmsgs {
 				rm(msg.From, msg.Nonce)
 				maybeRepub(msg.Cid())
+				mp.lifecycle.included(ctx, msg, ts.Height())
 			}
 		}
 	}
 
+	if len(apply) > 0 {
+		mp.lifecycle.headChange(ctx, mp.curTs.Height())
+	}
+
 	if repubTrigger {
 		select {
 		case mp.repubTrigger <- struct{}{}:
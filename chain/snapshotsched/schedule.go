@@ -0,0 +1,178 @@
+package snapshotsched
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// schedule is a parsed cron expression with the standard five fields: minute,
+// hour, day of month, month and day of week
+type schedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// when both day fields are restricted, a time matches if either of them
+	// matches, as in cron
+	domAny, dowAny bool
+}
+
+var descriptors = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+}
+
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// parseSchedule parses a cron expression; each field is a comma separated list
+// of '*', values and 'a-b' ranges, optionally followed by a '/n' step. The
+// @hourly, @daily, @weekly and @monthly shorthands are also accepted.
+func parseSchedule(expr string) (*schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if d, ok := descriptors[expr]; ok {
+		expr = d
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, xerrors.Errorf("expected %d fields in schedule %q, got %d", len(fields), expr, len(parts))
+	}
+
+	var bits [5]uint64
+	for i, part := range parts {
+		b, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, xerrors.Errorf("parsing %s field of schedule %q: %w", fields[i].name, expr, err)
+		}
+		bits[i] = b
+	}
+
+	// both 0 and 7 are sunday
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+		bits[4] &^= 1 << 7
+	}
+
+	return &schedule{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		domAny: strings.HasPrefix(parts[2], "*"),
+		dowAny: strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+func parseField(s string, f field) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(s, ",") {
+		rng, stepStr, hasStep := strings.Cut(item, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepStr); err != nil || step <= 0 {
+				return 0, xerrors.Errorf("invalid step %q", stepStr)
+			}
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			loStr, hiStr, _ := strings.Cut(rng, "-")
+			var err error
+			if lo, err = parseValue(loStr, f); err != nil {
+				return 0, err
+			}
+			if hi, err = parseValue(hiStr, f); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, xerrors.Errorf("invalid range %q", rng)
+			}
+		default:
+			v, err := parseValue(rng, f)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			if !hasStep {
+				hi = v
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+
+	return bits, nil
+}
+
+func parseValue(s string, f field) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, xerrors.Errorf("invalid value %q", s)
+	}
+	if v < f.min || v > f.max {
+		return 0, xerrors.Errorf("value %d out of range %d-%d", v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Next returns the first time strictly after t matching the schedule, in the
+// location of t. It returns the zero time if nothing matches within five years,
+// e.g. for February 30th.
+func (s *schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s *schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,434 @@
+package snapshotsched
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/zstd"
+	logging "github.com/ipfs/go-log/v2"
+	"go.opencensus.io/stats"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+var log = logging.Logger("snapshotsched")
+
+const (
+	snapshotPrefix = "snapshot-"
+	infoSuffix     = ".json"
+	partialSuffix  = ".part"
+)
+
+// Config specifies the schedule, the contents and the retention of the snapshots
+type Config struct {
+	// Schedule is a cron expression, see parseSchedule
+	Schedule string
+	// Path is the directory the snapshots are written to
+	Path string
+	// Retain is the number of most recent snapshots kept; 0 keeps all of them
+	Retain int
+
+	// LatestState takes snapshots of the latest state and RecentHeaders headers,
+	// without the chain history
+	LatestState      bool
+	RecentHeaders    abi.ChainEpoch
+	RecentStateRoots abi.ChainEpoch
+	SkipOldMessages  bool
+	Compress         bool
+
+	// UploadCmd and RemoveCmd are shell commands run after a snapshot is written
+	// and before an uploaded snapshot is pruned, with the path and the name of the
+	// snapshot in the SNAPSHOT and SNAPSHOT_NAME environment variables. PublicURL
+	// is the location the snapshots are uploaded to, joined with their names.
+	UploadCmd string
+	RemoveCmd string
+	PublicURL string
+}
+
+type exportFunc func(ctx context.Context, ts *types.TipSet, w io.Writer) error
+
+// Scheduler takes snapshots of the chain on a cron schedule, keeping the most
+// recent ones in a directory and optionally uploading them, e.g. to S3.
+type Scheduler struct {
+	cfg   Config
+	sched *schedule
+
+	head   func() *types.TipSet
+	export exportFunc
+
+	lk        sync.Mutex
+	status    api.ChainSnapshotStatus
+	snapshots []api.ChainSnapshot // most recent first
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewScheduler(cs *store.ChainStore, cfg Config) (*Scheduler, error) {
+	export := func(ctx context.Context, ts *types.TipSet, w io.Writer) error {
+		return cs.Export(ctx, ts, cfg.RecentStateRoots, cfg.SkipOldMessages, w)
+	}
+	if cfg.LatestState {
+		export = func(ctx context.Context, ts *types.TipSet, w io.Writer) error {
+			return cs.ExportLatestState(ctx, ts, cfg.RecentHeaders, cfg.RecentStateRoots, w)
+		}
+	}
+
+	return newScheduler(cfg, cs.GetHeaviestTipSet, export)
+}
+
+func newScheduler(cfg Config, head func() *types.TipSet, export exportFunc) (*Scheduler, error) {
+	sched, err := parseSchedule(cfg.Schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Path == "" {
+		return nil, xerrors.Errorf("no snapshot directory configured")
+	}
+	if cfg.Retain < 0 {
+		return nil, xerrors.Errorf("invalid number of retained snapshots %d", cfg.Retain)
+	}
+	if cfg.LatestState && cfg.RecentHeaders < cfg.RecentStateRoots {
+		return nil, xerrors.Errorf("latest state snapshots must include the headers of the %d epochs with state roots", cfg.RecentStateRoots)
+	}
+
+	s := &Scheduler{
+		cfg:    cfg,
+		sched:  sched,
+		head:   head,
+		export: export,
+		status: api.ChainSnapshotStatus{
+			Schedule: cfg.Schedule,
+		},
+	}
+
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	return s, nil
+}
+
+func (s *Scheduler) Start(ctx context.Context) error {
+	if err := os.MkdirAll(s.cfg.Path, 0755); err != nil {
+		return xerrors.Errorf("creating snapshot directory: %w", err)
+	}
+
+	snapshots, err := s.load()
+	if err != nil {
+		return xerrors.Errorf("loading existing snapshots: %w", err)
+	}
+
+	s.lk.Lock()
+	s.snapshots = snapshots
+	s.lk.Unlock()
+
+	s.wg.Add(1)
+	go s.run()
+
+	return nil
+}
+
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Status returns the status of the schedule and the retained snapshots
+func (s *Scheduler) Status() api.ChainSnapshotStatus {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	st := s.status
+	st.Snapshots = append([]api.ChainSnapshot{}, s.snapshots...)
+	if len(st.Snapshots) > 0 {
+		latest := st.Snapshots[0]
+		st.Latest = &latest
+	}
+
+	return st
+}
+
+func (s *Scheduler) run() {
+	defer s.wg.Done()
+
+	for {
+		next := s.sched.Next(time.Now())
+		if next.IsZero() {
+			log.Errorf("snapshot schedule %q never matches", s.cfg.Schedule)
+			return
+		}
+
+		s.lk.Lock()
+		s.status.NextRun = next
+		s.lk.Unlock()
+
+		log.Infow("next chain snapshot", "time", next)
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		s.lk.Lock()
+		s.status.Running = true
+		s.lk.Unlock()
+
+		err := s.snapshot(s.ctx)
+		if err != nil {
+			stats.Record(s.ctx, metrics.ChainSnapshotFailures.M(1))
+			log.Errorf("taking chain snapshot: %s", err)
+		}
+
+		s.lk.Lock()
+		s.status.Running = false
+		s.status.LastError = ""
+		if err != nil {
+			s.status.LastError = err.Error()
+		}
+		s.lk.Unlock()
+	}
+}
+
+// snapshot exports the chain from the current head into the snapshot directory,
+// uploads the snapshot and prunes the snapshots which are no longer retained
+func (s *Scheduler) snapshot(ctx context.Context) error {
+	ts := s.head()
+	start := time.Now()
+
+	name := fmt.Sprintf("%s%d-%d.car", snapshotPrefix, ts.Height(), start.Unix())
+	if s.cfg.Compress {
+		name += ".zst"
+	}
+	path := filepath.Join(s.cfg.Path, name)
+
+	log.Infow("taking chain snapshot", "height", ts.Height(), "path", path)
+
+	size, err := s.write(ctx, ts, path)
+	if err != nil {
+		return err
+	}
+
+	snap := api.ChainSnapshot{
+		Path:        path,
+		Height:      ts.Height(),
+		TipSet:      ts.Key(),
+		LatestState: s.cfg.LatestState,
+		Compressed:  s.cfg.Compress,
+		Size:        size,
+		Created:     start,
+		Duration:    time.Since(start),
+	}
+
+	stats.Record(ctx,
+		metrics.ChainSnapshotRuns.M(1),
+		metrics.ChainSnapshotTimeSeconds.M(snap.Duration.Seconds()),
+		metrics.ChainSnapshotSizeBytes.M(size))
+
+	log.Infow("chain snapshot done", "height", ts.Height(), "path", path, "size", size, "took", snap.Duration)
+
+	// a snapshot which failed to upload is still kept locally
+	var uploadErr error
+	if s.cfg.UploadCmd != "" {
+		if uploadErr = s.runCmd(ctx, s.cfg.UploadCmd, path); uploadErr != nil {
+			uploadErr = xerrors.Errorf("uploading snapshot %s: %w", name, uploadErr)
+		} else if s.cfg.PublicURL != "" {
+			snap.URL = strings.TrimSuffix(s.cfg.PublicURL, "/") + "/" + name
+		}
+	}
+
+	if err := writeInfo(snap); err != nil {
+		return err
+	}
+
+	s.lk.Lock()
+	s.snapshots = append([]api.ChainSnapshot{snap}, s.snapshots...)
+	var pruned []api.ChainSnapshot
+	if s.cfg.Retain > 0 && len(s.snapshots) > s.cfg.Retain {
+		pruned = s.snapshots[s.cfg.Retain:]
+		s.snapshots = s.snapshots[:s.cfg.Retain:s.cfg.Retain]
+	}
+	s.lk.Unlock()
+
+	for _, old := range pruned {
+		if err := s.remove(ctx, old); err != nil {
+			log.Warnf("pruning snapshot %s: %s", old.Path, err)
+		}
+	}
+
+	return uploadErr
+}
+
+// write exports the snapshot to a partial file, renamed to path once complete,
+// and returns its size
+func (s *Scheduler) write(ctx context.Context, ts *types.TipSet, path string) (int64, error) {
+	f, err := os.Create(path + partialSuffix)
+	if err != nil {
+		return 0, xerrors.Errorf("creating snapshot file: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(path + partialSuffix)
+	}()
+
+	var w io.WriteCloser = nopWriteCloser{f}
+	if s.cfg.Compress {
+		w = zstd.NewWriter(f)
+	}
+
+	if err := s.export(ctx, ts, w); err != nil {
+		return 0, xerrors.Errorf("exporting chain: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return 0, xerrors.Errorf("closing compressor: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return 0, xerrors.Errorf("syncing snapshot file: %w", err)
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.Rename(path+partialSuffix, path); err != nil {
+		return 0, xerrors.Errorf("renaming snapshot file: %w", err)
+	}
+
+	return fi.Size(), nil
+}
+
+func (s *Scheduler) remove(ctx context.Context, snap api.ChainSnapshot) error {
+	log.Infow("pruning chain snapshot", "path", snap.Path)
+
+	if s.cfg.RemoveCmd != "" && snap.URL != "" {
+		if err := s.runCmd(ctx, s.cfg.RemoveCmd, snap.Path); err != nil {
+			return xerrors.Errorf("removing uploaded snapshot: %w", err)
+		}
+	}
+
+	if err := os.Remove(snap.Path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Remove(snap.Path + infoSuffix)
+}
+
+func (s *Scheduler) runCmd(ctx context.Context, cmd string, path string) error {
+	sh := exec.CommandContext(ctx, "sh", "-c", cmd)
+	sh.Env = append(os.Environ(), "SNAPSHOT="+path, "SNAPSHOT_NAME="+filepath.Base(path))
+
+	out, err := sh.CombinedOutput()
+	if err != nil {
+		return xerrors.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// load reads the info files of the snapshots in the snapshot directory, removing
+// the partial files left by interrupted snapshots
+func (s *Scheduler) load() ([]api.ChainSnapshot, error) {
+	ents, err := os.ReadDir(s.cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []api.ChainSnapshot
+	for _, ent := range ents {
+		name := ent.Name()
+		if !strings.HasPrefix(name, snapshotPrefix) {
+			continue
+		}
+
+		switch {
+		case strings.HasSuffix(name, partialSuffix):
+			if err := os.Remove(filepath.Join(s.cfg.Path, name)); err != nil {
+				log.Warnf("removing partial snapshot %s: %s", name, err)
+			}
+		case strings.HasSuffix(name, infoSuffix):
+			snap, err := readInfo(filepath.Join(s.cfg.Path, name))
+			if err != nil {
+				log.Warnf("skipping snapshot %s: %s", name, err)
+				continue
+			}
+			snapshots = append(snapshots, snap)
+		}
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Created.After(snapshots[j].Created)
+	})
+
+	return snapshots, nil
+}
+
+// writeInfo writes the description of a snapshot next to it, so that the
+// snapshots are known across restarts
+func writeInfo(snap api.ChainSnapshot) error {
+	b, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := snap.Path + infoSuffix
+	if err := os.WriteFile(path+partialSuffix, b, 0644); err != nil {
+		return xerrors.Errorf("writing snapshot info: %w", err)
+	}
+	return os.Rename(path+partialSuffix, path)
+}
+
+func readInfo(path string) (api.ChainSnapshot, error) {
+	var snap api.ChainSnapshot
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return snap, err
+	}
+	if err := json.Unmarshal(b, &snap); err != nil {
+		return snap, xerrors.Errorf("decoding snapshot info: %w", err)
+	}
+
+	// the directory may have moved since the snapshot was taken
+	snap.Path = strings.TrimSuffix(path, infoSuffix)
+	if _, err := os.Stat(snap.Path); err != nil {
+		return snap, err
+	}
+
+	return snap, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,165 @@
+//stm: #unit
+package snapshotsched
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DataDog/zstd"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func TestScheduleNext(t *testing.T) {
+	at := func(s string) time.Time {
+		tm, err := time.Parse("2006-01-02 15:04", s)
+		require.NoError(t, err)
+		return tm
+	}
+
+	cases := []struct {
+		expr string
+		from string
+		next string
+	}{
+		{"@hourly", "2022-03-01 10:00", "2022-03-01 11:00"},
+		{"@daily", "2022-03-01 10:00", "2022-03-02 00:00"},
+		{"30 */6 * * *", "2022-03-01 10:00", "2022-03-01 12:30"},
+		{"0 2 * * 7", "2022-03-01 10:00", "2022-03-06 02:00"},
+		{"0 0 1,15 * *", "2022-03-01 10:00", "2022-03-15 00:00"},
+		{"0 0 */2 * *", "2022-03-02 10:00", "2022-03-03 00:00"},
+		{"15 3 29 2 *", "2022-03-01 10:00", "2024-02-29 03:15"},
+		{"0 0 13 * 5", "2022-03-01 10:00", "2022-03-04 00:00"},
+		{"0-10/5 12-13 * 1-6 1-5", "2022-03-05 13:00", "2022-03-07 12:00"},
+	}
+
+	for _, c := range cases {
+		sched, err := parseSchedule(c.expr)
+		require.NoError(t, err, c.expr)
+		require.Equal(t, at(c.next), sched.Next(at(c.from)), c.expr)
+	}
+
+	sched, err := parseSchedule("0 0 30 2 *")
+	require.NoError(t, err)
+	require.True(t, sched.Next(at("2022-03-01 10:00")).IsZero())
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "@yearly"} {
+		_, err := parseSchedule(expr)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestSnapshotRetention(t *testing.T) {
+	dir := t.TempDir()
+
+	height := abi.ChainEpoch(100)
+	head := func() *types.TipSet {
+		height++
+		blk := mock.MkBlock(nil, 0, uint64(height))
+		blk.Height = height
+		return mock.TipSet(blk)
+	}
+	export := func(ctx context.Context, ts *types.TipSet, w io.Writer) error {
+		_, err := w.Write([]byte(ts.Key().String()))
+		return err
+	}
+
+	cfg := Config{
+		Schedule:  "@daily",
+		Path:      dir,
+		Retain:    2,
+		Compress:  true,
+		UploadCmd: `cp "$SNAPSHOT" "$SNAPSHOT.uploaded"`,
+		PublicURL: "https://snapshots.example.com/mainnet/",
+	}
+
+	s, err := newScheduler(cfg, head, export)
+	require.NoError(t, err)
+	require.NoError(t, s.Start(context.Background()))
+	require.NoError(t, s.Stop(context.Background()))
+
+	require.Nil(t, s.Status().Latest)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, s.snapshot(context.Background()))
+	}
+
+	st := s.Status()
+	require.Len(t, st.Snapshots, 2)
+	require.NotNil(t, st.Latest)
+	require.Equal(t, st.Snapshots[0], *st.Latest)
+
+	latest := st.Latest
+	require.Equal(t, abi.ChainEpoch(103), latest.Height)
+	require.True(t, latest.Compressed)
+	require.Equal(t, "https://snapshots.example.com/mainnet/"+filepath.Base(latest.Path), latest.URL)
+	require.FileExists(t, latest.Path+".uploaded")
+
+	f, err := os.Open(latest.Path)
+	require.NoError(t, err)
+	defer f.Close() //nolint:errcheck
+	b, err := io.ReadAll(zstd.NewReader(f))
+	require.NoError(t, err)
+	require.Equal(t, latest.TipSet.String(), string(b))
+
+	// the oldest snapshot was pruned
+	snaps, err := filepath.Glob(filepath.Join(dir, "snapshot-*.car.zst"))
+	require.NoError(t, err)
+	require.Len(t, snaps, 2)
+
+	// the snapshots are found again after a restart, and partial files are
+	// cleaned up
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "snapshot-104-1.car.zst.part"), []byte("partial"), 0644))
+
+	s2, err := newScheduler(cfg, head, export)
+	require.NoError(t, err)
+	require.NoError(t, s2.Start(context.Background()))
+	require.NoError(t, s2.Stop(context.Background()))
+
+	st2 := s2.Status()
+	require.Len(t, st2.Snapshots, 2)
+	for i, snap := range st2.Snapshots {
+		require.Equal(t, st.Snapshots[i].Path, snap.Path)
+		require.Equal(t, st.Snapshots[i].TipSet, snap.TipSet)
+		require.Equal(t, st.Snapshots[i].URL, snap.URL)
+		require.True(t, st.Snapshots[i].Created.Equal(snap.Created))
+	}
+	require.NoFileExists(t, filepath.Join(dir, "snapshot-104-1.car.zst.part"))
+}
+
+func TestSnapshotUploadFailure(t *testing.T) {
+	dir := t.TempDir()
+
+	head := func() *types.TipSet {
+		return mock.TipSet(mock.MkBlock(nil, 0, 1))
+	}
+	export := func(ctx context.Context, ts *types.TipSet, w io.Writer) error {
+		_, err := w.Write([]byte("car"))
+		return err
+	}
+
+	s, err := newScheduler(Config{
+		Schedule:  "@daily",
+		Path:      dir,
+		UploadCmd: "exit 1",
+		PublicURL: "https://snapshots.example.com",
+	}, head, export)
+	require.NoError(t, err)
+
+	require.Error(t, s.snapshot(context.Background()))
+
+	// the snapshot is kept locally, without a public location
+	latest := s.Status().Latest
+	require.NotNil(t, latest)
+	require.Empty(t, latest.URL)
+	require.EqualValues(t, 3, latest.Size)
+	require.FileExists(t, latest.Path)
+}
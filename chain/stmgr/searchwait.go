@@ -10,6 +10,7 @@ import (
 
 	"github.com/filecoin-project/go-state-types/abi"
 
+	"github.com/filecoin-project/lotus/chain/index"
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/types"
 )
@@ -55,7 +56,7 @@ func (sm *StateManager) WaitForMessage(ctx context.Context, mcid cid.Cid, confid
 	var backFm cid.Cid
 	backSearchWait := make(chan struct{})
 	go func() {
-		fts, r, foundMsg, err := sm.searchBackForMsg(ctx, head[0].Val, msg, lookbackLimit, allowReplaced)
+		fts, r, foundMsg, err := sm.searchForMsg(ctx, head[0].Val, msg, lookbackLimit, allowReplaced)
 		if err != nil {
 			log.Warnf("failed to look back through chain for message: %v", err)
 			return
@@ -145,7 +146,7 @@ func (sm *StateManager) SearchForMessage(ctx context.Context, head *types.TipSet
 		return head, r, foundMsg, nil
 	}
 
-	fts, r, foundMsg, err := sm.searchBackForMsg(ctx, head, msg, lookbackLimit, allowReplaced)
+	fts, r, foundMsg, err := sm.searchForMsg(ctx, head, msg, lookbackLimit, allowReplaced)
 
 	if err != nil {
 		log.Warnf("failed to look back through chain for message %s", mcid)
@@ -159,6 +160,58 @@ func (sm *StateManager) SearchForMessage(ctx context.Context, head *types.TipSet
 	return fts, r, foundMsg, nil
 }
 
+// searchForMsg looks up the message in the chain index, falling back to
+// searching backwards from head
+func (sm *StateManager) searchForMsg(ctx context.Context, head *types.TipSet, m types.ChainMsg, lookbackLimit abi.ChainEpoch, allowReplaced bool) (*types.TipSet, *types.MessageReceipt, cid.Cid, error) {
+	fts, r, foundMsg, err := sm.searchForIndexedMsg(ctx, head, m, lookbackLimit, allowReplaced)
+	switch {
+	case err == nil:
+		return fts, r, foundMsg, nil
+	case !errors.Is(err, index.ErrNotFound):
+		log.Warnw("error searching message in the chain index", "cid", m.Cid(), "error", err)
+	}
+
+	return sm.searchBackForMsg(ctx, head, m, lookbackLimit, allowReplaced)
+}
+
+// searchForIndexedMsg looks up the tipset including the message in the chain
+// index, and returns the receipt from the tipset executing it when it is on the
+// chain of head. It returns index.ErrNotFound when the index can't locate the
+// message within the lookback limit.
+func (sm *StateManager) searchForIndexedMsg(ctx context.Context, head *types.TipSet, m types.ChainMsg, lookbackLimit abi.ChainEpoch, allowReplaced bool) (*types.TipSet, *types.MessageReceipt, cid.Cid, error) {
+	info, err := sm.chainIndex.GetMsgInfo(ctx, m.Cid())
+	if err != nil {
+		return nil, nil, cid.Undef, err
+	}
+
+	if info.Height >= head.Height() {
+		return nil, nil, cid.Undef, index.ErrNotFound
+	}
+	if lookbackLimit != LookbackNoLimit && info.Height+1 <= head.Height()-lookbackLimit {
+		return nil, nil, cid.Undef, index.ErrNotFound
+	}
+
+	// the message is executed by the first child of the including tipset
+	xts, err := sm.cs.GetTipsetByHeight(ctx, info.Height+1, head, false)
+	if err != nil {
+		return nil, nil, cid.Undef, xerrors.Errorf("loading executing tipset: %w", err)
+	}
+	if xts.Parents() != info.TipSet {
+		// the index is ahead of or behind head's chain
+		return nil, nil, cid.Undef, index.ErrNotFound
+	}
+
+	r, foundMsg, err := sm.tipsetExecutedMessage(ctx, xts, m.Cid(), m.VMMessage(), allowReplaced)
+	if err != nil {
+		return nil, nil, cid.Undef, err
+	}
+	if r == nil {
+		return nil, nil, cid.Undef, index.ErrNotFound
+	}
+
+	return xts, r, foundMsg, nil
+}
+
 // searchBackForMsg searches up to limit tipsets backwards from the given
 // tipset for a message receipt.
 // If limit is
@@ -22,6 +22,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/actors/builtin/paych"
 	"github.com/filecoin-project/lotus/chain/actors/policy"
 	"github.com/filecoin-project/lotus/chain/beacon"
+	"github.com/filecoin-project/lotus/chain/index"
 	"github.com/filecoin-project/lotus/chain/rand"
 	"github.com/filecoin-project/lotus/chain/state"
 	"github.com/filecoin-project/lotus/chain/store"
@@ -95,6 +96,9 @@ type StateManager struct {
 	tsExec        Executor
 	tsExecMonitor ExecMonitor
 	beacon        beacon.Schedule
+
+	// chainIndex locates the tipsets including messages without walking the chain
+	chainIndex index.Reader
 }
 
 // Caches a single state tree
@@ -151,7 +155,8 @@ func NewStateManager(cs *store.ChainStore, exec Executor, sys vm.SyscallBuilder,
 			root: cid.Undef,
 			tree: nil,
 		},
-		compWait: make(map[string]chan struct{}),
+		compWait:   make(map[string]chan struct{}),
+		chainIndex: index.DummyIndex,
 	}, nil
 }
 
@@ -164,6 +169,15 @@ func NewStateManagerWithUpgradeScheduleAndMonitor(cs *store.ChainStore, exec Exe
 	return sm, nil
 }
 
+func NewStateManagerWithIndex(cs *store.ChainStore, exec Executor, sys vm.SyscallBuilder, us UpgradeSchedule, b beacon.Schedule, idx index.Reader) (*StateManager, error) {
+	sm, err := NewStateManager(cs, exec, sys, us, b)
+	if err != nil {
+		return nil, err
+	}
+	sm.chainIndex = idx
+	return sm, nil
+}
+
 func cidsToKey(cids []cid.Cid) string {
 	var out string
 	for _, c := range cids {
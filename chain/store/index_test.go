@@ -9,13 +9,17 @@ import (
 	"github.com/ipfs/go-datastore"
 	syncds "github.com/ipfs/go-datastore/sync"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-state-types/abi"
 
+	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/chain/consensus/filcns"
 	"github.com/filecoin-project/lotus/chain/gen"
 	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/chain/types/mock"
 )
 
@@ -86,3 +90,108 @@ func TestIndexSeeks(t *testing.T) {
 		assert.Equal(t, abi.ChainEpoch(i), ts3.Height())
 	}
 }
+
+type testHeightIndex map[abi.ChainEpoch]types.TipSetKey
+
+func (hi testHeightIndex) GetTipSetKey(ctx context.Context, h abi.ChainEpoch) (types.TipSetKey, error) {
+	tsk, ok := hi[h]
+	if !ok {
+		return types.EmptyTSK, xerrors.Errorf("height %d not indexed", h)
+	}
+	return tsk, nil
+}
+
+func TestHeightIndex(t *testing.T) {
+	ctx := context.TODO()
+
+	nbs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(nbs, nbs, syncds.MutexWrap(datastore.NewMapDatastore()), filcns.Weight, nil)
+	defer cs.Close() //nolint:errcheck
+
+	var chain []*types.TipSet
+	var cur *types.TipSet
+	for i := 0; i < 10; i++ {
+		cur = mock.TipSet(mock.MkBlock(cur, 1, 1))
+		require.NoError(t, cs.PersistBlockHeaders(ctx, cur.Blocks()...))
+		chain = append(chain, cur)
+	}
+
+	// a fork off height 4, which the index doesn't know about
+	fork := mock.TipSet(mock.MkBlock(chain[4], 1, 2))
+	require.NoError(t, cs.PersistBlockHeaders(ctx, fork.Blocks()...))
+
+	// the index maps height 3 to a tipset the walk wouldn't find, to tell the
+	// lookups answered by the index apart
+	other := mock.TipSet(mock.MkBlock(chain[2], 1, 3))
+	require.NoError(t, cs.PersistBlockHeaders(ctx, other.Blocks()...))
+
+	hi := testHeightIndex{}
+	for _, ts := range chain {
+		hi[ts.Height()] = ts.Key()
+	}
+	hi[3] = other.Key()
+	cs.SetHeightIndex(hi)
+
+	// tipsets of the indexed chain are looked up in the index
+	ts, err := cs.GetTipsetByHeight(ctx, 3, chain[9], true)
+	require.NoError(t, err)
+	require.Equal(t, other, ts)
+
+	// the chain is walked for tipsets off the indexed chain
+	ts, err = cs.GetTipsetByHeight(ctx, 3, fork, true)
+	require.NoError(t, err)
+	require.Equal(t, chain[3], ts)
+
+	// and for the heights which aren't indexed
+	delete(hi, 6)
+	ts, err = cs.GetTipsetByHeight(ctx, 6, chain[9], true)
+	require.NoError(t, err)
+	require.Equal(t, chain[6], ts)
+}
+
+func TestGetPathHeightIndex(t *testing.T) {
+	ctx := context.TODO()
+
+	nbs := blockstore.NewMemorySync()
+	cs := store.NewChainStore(nbs, nbs, syncds.MutexWrap(datastore.NewMapDatastore()), filcns.Weight, nil)
+	defer cs.Close() //nolint:errcheck
+
+	var chain []*types.TipSet
+	var cur *types.TipSet
+	for i := 0; i < 10; i++ {
+		cur = mock.TipSet(mock.MkBlock(cur, 1, 1))
+		require.NoError(t, cs.PersistBlockHeaders(ctx, cur.Blocks()...))
+		chain = append(chain, cur)
+	}
+
+	hi := testHeightIndex{}
+	for _, ts := range chain {
+		hi[ts.Height()] = ts.Key()
+	}
+	cs.SetHeightIndex(hi)
+
+	checkPath := func(from, to int) {
+		path, err := cs.GetPath(ctx, chain[from].Key(), chain[to].Key())
+		require.NoError(t, err)
+
+		var expect []*api.HeadChange
+		for i := from; i > to; i-- {
+			expect = append(expect, &api.HeadChange{Type: store.HCRevert, Val: chain[i]})
+		}
+		for i := from + 1; i <= to; i++ {
+			expect = append(expect, &api.HeadChange{Type: store.HCApply, Val: chain[i]})
+		}
+		require.Equal(t, expect, path)
+	}
+
+	checkPath(2, 7)
+	checkPath(8, 3)
+
+	// the chain is walked when the index doesn't map all the heights in
+	// between, or maps them to other tipsets
+	delete(hi, 5)
+	checkPath(2, 7)
+
+	hi[5] = mock.TipSet(mock.MkBlock(chain[3], 1, 2)).Key()
+	checkPath(8, 3)
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/filecoin-project/go-state-types/abi"
 
+	"github.com/filecoin-project/lotus/api"
 	bstore "github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/actors/builtin"
@@ -25,6 +26,41 @@ func (cs *ChainStore) UnionStore() bstore.Blockstore {
 }
 
 func (cs *ChainStore) Export(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs bool, w io.Writer) error {
+	return cs.export(ctx, ts, w, func(cb func(cid.Cid) error) error {
+		return cs.WalkSnapshot(ctx, ts, inclRecentRoots, skipOldMsgs, true, cb)
+	})
+}
+
+// ExportLatestState exports a snapshot of the latest state of the chain, which
+// a node can boot from without the chain history. It only includes the block
+// headers of the last inclRecentHeaders epochs, and the state trees and
+// messages of the last inclRecentRoots epochs. Older headers, message receipts
+// and the genesis aren't included.
+func (cs *ChainStore) ExportLatestState(ctx context.Context, ts *types.TipSet, inclRecentHeaders, inclRecentRoots abi.ChainEpoch, w io.Writer) error {
+	if inclRecentHeaders < inclRecentRoots {
+		return xerrors.Errorf("the snapshot must include the headers of the %d epochs with state roots", inclRecentRoots)
+	}
+
+	return cs.export(ctx, ts, w, func(cb func(cid.Cid) error) error {
+		return cs.walkSnapshot(ctx, ts, ts.Height()-inclRecentHeaders, inclRecentRoots, true, true, cb)
+	})
+}
+
+// ExportRange exports the chain between the height from and ts, inclusive. All
+// the block headers of the range are included, while messages, message receipts
+// and state trees are only included for the blocks within the depths given by
+// the options, counted down from ts.
+func (cs *ChainStore) ExportRange(ctx context.Context, ts *types.TipSet, from abi.ChainEpoch, opts api.ChainExportRangeOpts, w io.Writer) error {
+	if from < 0 || from > ts.Height() {
+		return xerrors.Errorf("invalid range start %d for a range ending at %d", from, ts.Height())
+	}
+
+	return cs.export(ctx, ts, w, func(cb func(cid.Cid) error) error {
+		return cs.walkRange(ctx, ts, from, opts, cb)
+	})
+}
+
+func (cs *ChainStore) export(ctx context.Context, ts *types.TipSet, w io.Writer, walk func(cb func(cid.Cid) error) error) error {
 	h := &car.CarHeader{
 		Roots:   ts.Cids(),
 		Version: 1,
@@ -35,7 +71,7 @@ func (cs *ChainStore) Export(ctx context.Context, ts *types.TipSet, inclRecentRo
 	}
 
 	unionBs := cs.UnionStore()
-	return cs.WalkSnapshot(ctx, ts, inclRecentRoots, skipOldMsgs, true, func(c cid.Cid) error {
+	return walk(func(c cid.Cid) error {
 		blk, err := unionBs.Get(ctx, c)
 		if err != nil {
 			return xerrors.Errorf("writing object to car, bs.Get: %w", err)
@@ -73,6 +109,13 @@ func (cs *ChainStore) WalkSnapshot(ctx context.Context, ts *types.TipSet, inclRe
 		ts = cs.GetHeaviestTipSet()
 	}
 
+	return cs.walkSnapshot(ctx, ts, 0, inclRecentRoots, skipOldMsgs, skipMsgReceipts, cb)
+}
+
+// walkSnapshot walks the chain down from ts, stopping at the first block
+// headers below minHeight.
+func (cs *ChainStore) walkSnapshot(ctx context.Context, ts *types.TipSet, minHeight, inclRecentRoots abi.ChainEpoch, skipOldMsgs, skipMsgReceipts bool, cb func(cid.Cid) error) error {
+
 	seen := cid.NewSet()
 	walked := cid.NewSet()
 
@@ -117,8 +160,8 @@ func (cs *ChainStore) WalkSnapshot(ctx context.Context, ts *types.TipSet, inclRe
 		}
 
 		if b.Height > 0 {
-			for _, p := range b.Parents {
-				blocksToWalk = append(blocksToWalk, p)
+			if b.Height > minHeight {
+				blocksToWalk = append(blocksToWalk, b.Parents...)
 			}
 		} else {
 			// include the genesis block
@@ -142,31 +185,7 @@ func (cs *ChainStore) WalkSnapshot(ctx context.Context, ts *types.TipSet, inclRe
 			}
 		}
 
-		for _, c := range out {
-			if seen.Visit(c) {
-				prefix := c.Prefix()
-
-				// Don't include identity CIDs.
-				if prefix.MhType == mh.IDENTITY {
-					continue
-				}
-
-				// We only include raw and dagcbor, for now.
-				// Raw for "code" CIDs.
-				switch prefix.Codec {
-				case cid.Raw, cid.DagCBOR:
-				default:
-					continue
-				}
-
-				if err := cb(c); err != nil {
-					return err
-				}
-
-			}
-		}
-
-		return nil
+		return visitLinks(seen, out, cb)
 	}
 
 	log.Infow("export started")
@@ -185,6 +204,120 @@ func (cs *ChainStore) WalkSnapshot(ctx context.Context, ts *types.TipSet, inclRe
 	return nil
 }
 
+// walkRange walks the block headers of the chain from ts down to the height
+// from, including the messages, receipts and state trees of the blocks within
+// the depths given by the options.
+func (cs *ChainStore) walkRange(ctx context.Context, ts *types.TipSet, from abi.ChainEpoch, opts api.ChainExportRangeOpts, cb func(cid.Cid) error) error {
+	seen := cid.NewSet()
+	walked := cid.NewSet()
+
+	// whether the data of a block at height h is within the given depth
+	inDepth := func(h, depth abi.ChainEpoch) bool {
+		return depth < 0 || h > ts.Height()-depth
+	}
+
+	log.Infow("range export started", "from", from, "to", ts.Height())
+	exportStart := build.Clock.Now()
+
+	blocksToWalk := ts.Cids()
+	for len(blocksToWalk) > 0 {
+		blk := blocksToWalk[0]
+		blocksToWalk = blocksToWalk[1:]
+
+		if !seen.Visit(blk) {
+			continue
+		}
+
+		data, err := cs.chainBlockstore.Get(ctx, blk)
+		if err != nil {
+			return xerrors.Errorf("getting block: %w", err)
+		}
+
+		var b types.BlockHeader
+		if err := b.UnmarshalCBOR(bytes.NewBuffer(data.RawData())); err != nil {
+			return xerrors.Errorf("unmarshaling block header (cid=%s): %w", blk, err)
+		}
+
+		// the parents of the lowest blocks of the range are below it when the
+		// range starts with null rounds
+		if b.Height < from {
+			continue
+		}
+
+		if err := cb(blk); err != nil {
+			return err
+		}
+
+		var out []cid.Cid
+		if inDepth(b.Height, opts.MessagesDepth) && walked.Visit(b.Messages) {
+			out, err = recurseLinks(ctx, cs.chainBlockstore, walked, b.Messages, append(out, b.Messages))
+			if err != nil {
+				return xerrors.Errorf("recursing messages failed: %w", err)
+			}
+		}
+
+		if inDepth(b.Height, opts.ReceiptsDepth) && walked.Visit(b.ParentMessageReceipts) {
+			out, err = recurseLinks(ctx, cs.stateBlockstore, walked, b.ParentMessageReceipts, append(out, b.ParentMessageReceipts))
+			if err != nil {
+				return xerrors.Errorf("recursing message receipts failed: %w", err)
+			}
+		}
+
+		if inDepth(b.Height, opts.StateDepth) && walked.Visit(b.ParentStateRoot) {
+			out, err = recurseLinks(ctx, cs.stateBlockstore, walked, b.ParentStateRoot, append(out, b.ParentStateRoot))
+			if err != nil {
+				return xerrors.Errorf("recursing state failed: %w", err)
+			}
+		}
+
+		if b.Height > from {
+			blocksToWalk = append(blocksToWalk, b.Parents...)
+		} else if b.Height == 0 {
+			// include the genesis block
+			out = append(out, b.Parents...)
+		}
+
+		if err := visitLinks(seen, out, cb); err != nil {
+			return err
+		}
+	}
+
+	log.Infow("range export finished", "duration", build.Clock.Now().Sub(exportStart).Seconds())
+
+	return nil
+}
+
+// visitLinks calls cb on the cids not seen yet, skipping identity cids and
+// codecs other than raw and dagcbor.
+func visitLinks(seen *cid.Set, cids []cid.Cid, cb func(cid.Cid) error) error {
+	for _, c := range cids {
+		if !seen.Visit(c) {
+			continue
+		}
+
+		prefix := c.Prefix()
+
+		// Don't include identity CIDs.
+		if prefix.MhType == mh.IDENTITY {
+			continue
+		}
+
+		// We only include raw and dagcbor, for now.
+		// Raw for "code" CIDs.
+		switch prefix.Codec {
+		case cid.Raw, cid.DagCBOR:
+		default:
+			continue
+		}
+
+		if err := cb(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func recurseLinks(ctx context.Context, bs bstore.Blockstore, walked *cid.Set, root cid.Cid, in []cid.Cid) ([]cid.Cid, error) {
 	if root.Prefix().Codec != cid.DagCBOR {
 		return in, nil
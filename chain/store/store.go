@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	lru "github.com/hashicorp/golang-lru"
@@ -116,6 +117,9 @@ type ChainStore struct {
 
 	cindex *ChainIndex
 
+	// heightIndex holds the HeightIndex set with SetHeightIndex, if any
+	heightIndex atomic.Value
+
 	reorgCh        chan<- reorg
 	reorgNotifeeCh chan ReorgNotifee
 
@@ -1068,9 +1072,12 @@ func (cs *ChainStore) GetPath(ctx context.Context, from types.TipSetKey, to type
 	if err != nil {
 		return nil, xerrors.Errorf("loading to tipset %s: %w", to, err)
 	}
-	revert, apply, err := cs.ReorgOps(ctx, fts, tts)
-	if err != nil {
-		return nil, xerrors.Errorf("error getting tipset branches: %w", err)
+	revert, apply, ok := cs.pathFromHeightIndex(ctx, fts, tts)
+	if !ok {
+		revert, apply, err = cs.ReorgOps(ctx, fts, tts)
+		if err != nil {
+			return nil, xerrors.Errorf("error getting tipset branches: %w", err)
+		}
 	}
 
 	path := make([]*api.HeadChange, len(revert)+len(apply))
@@ -1127,6 +1134,104 @@ func (cs *ChainStore) TryFillTipSet(ctx context.Context, ts *types.TipSet) (*Ful
 	return NewFullTipSet(out), nil
 }
 
+// HeightIndex maps the heights of the heaviest chain to the keys of its tipsets
+type HeightIndex interface {
+	// GetTipSetKey returns the key of the tipset at the height, or an error for
+	// null rounds and heights which aren't indexed
+	GetTipSetKey(ctx context.Context, h abi.ChainEpoch) (types.TipSetKey, error)
+}
+
+type heightIndexHolder struct {
+	HeightIndex
+}
+
+// SetHeightIndex sets the index GetTipsetByHeight looks heights up in before
+// walking the chain
+func (cs *ChainStore) SetHeightIndex(hi HeightIndex) {
+	cs.heightIndex.Store(heightIndexHolder{hi})
+}
+
+func (cs *ChainStore) getHeightIndex() HeightIndex {
+	hh, _ := cs.heightIndex.Load().(heightIndexHolder)
+	return hh.HeightIndex
+}
+
+// onHeightIndex returns whether ts is on the chain mapped by the height index;
+// the index can only answer for the tipsets on it
+func (cs *ChainStore) onHeightIndex(ctx context.Context, hi HeightIndex, ts *types.TipSet) bool {
+	tsk, err := hi.GetTipSetKey(ctx, ts.Height())
+	return err == nil && tsk == ts.Key()
+}
+
+// tipsetFromHeightIndex looks the tipset at height h in the chain of ts up in
+// the height index. It returns nil when the index can't answer, and the chain
+// has to be walked instead.
+func (cs *ChainStore) tipsetFromHeightIndex(ctx context.Context, h abi.ChainEpoch, ts *types.TipSet) *types.TipSet {
+	hi := cs.getHeightIndex()
+	if hi == nil || !cs.onHeightIndex(ctx, hi, ts) {
+		return nil
+	}
+
+	tsk, err := hi.GetTipSetKey(ctx, h)
+	if err != nil {
+		return nil
+	}
+
+	lbts, err := cs.LoadTipSet(ctx, tsk)
+	if err != nil {
+		log.Warnw("loading tipset from the height index", "height", h, "tipset", tsk, "error", err)
+		return nil
+	}
+	if lbts.Height() != h {
+		return nil
+	}
+	return lbts
+}
+
+// pathFromHeightIndex returns the tipsets to revert and apply to go from one
+// tipset to the other, like ReorgOps, when both are on the chain mapped by the
+// height index. It returns false when the index can't answer, and the chain
+// has to be walked instead.
+func (cs *ChainStore) pathFromHeightIndex(ctx context.Context, from, to *types.TipSet) ([]*types.TipSet, []*types.TipSet, bool) {
+	hi := cs.getHeightIndex()
+	if hi == nil || !cs.onHeightIndex(ctx, hi, from) || !cs.onHeightIndex(ctx, hi, to) {
+		return nil, nil, false
+	}
+
+	// both are on the same chain, so the lower one is an ancestor of the other
+	low, high := from, to
+	if low.Height() > high.Height() {
+		low, high = high, low
+	}
+
+	var chain []*types.TipSet
+	cur := high
+	for h := high.Height() - 1; h >= low.Height(); h-- {
+		tsk, err := hi.GetTipSetKey(ctx, h)
+		if err != nil {
+			// null round, or a height which isn't indexed, in which case the
+			// parent check below fails
+			continue
+		}
+		if tsk != cur.Parents() {
+			return nil, nil, false
+		}
+
+		chain = append(chain, cur)
+		if cur, err = cs.LoadTipSet(ctx, tsk); err != nil {
+			return nil, nil, false
+		}
+	}
+	if cur.Key() != low.Key() {
+		return nil, nil, false
+	}
+
+	if from.Height() > to.Height() {
+		return chain, nil, true
+	}
+	return nil, chain, true
+}
+
 // GetTipsetByHeight returns the tipset on the chain behind 'ts' at the given
 // height. In the case that the given height is a null round, the 'prev' flag
 // selects the tipset before the null round if true, and the tipset following
@@ -1144,6 +1249,11 @@ func (cs *ChainStore) GetTipsetByHeight(ctx context.Context, h abi.ChainEpoch, t
 		return ts, nil
 	}
 
+	// null rounds aren't indexed, their lookups walk the chain
+	if lbts := cs.tipsetFromHeightIndex(ctx, h, ts); lbts != nil {
+		return lbts, nil
+	}
+
 	lbts, err := cs.cindex.GetTipsetByHeight(ctx, ts, h)
 	if err != nil {
 		return nil, err
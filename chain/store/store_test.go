@@ -12,6 +12,7 @@ import (
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/crypto"
 
+	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/chain/actors/policy"
 	"github.com/filecoin-project/lotus/chain/consensus/filcns"
@@ -124,6 +125,153 @@ func TestChainExportImport(t *testing.T) {
 	}
 }
 
+func TestChainExportLatestState(t *testing.T) {
+	//stm: @CHAIN_GEN_NEXT_TIPSET_001
+	//stm: @CHAIN_STORE_IMPORT_001, @CHAIN_STORE_EXPORT_001
+	cg, err := gen.NewGenerator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var last *types.TipSet
+	for i := 0; i < 100; i++ {
+		ts, err := cg.NextTipSet()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		last = ts.TipSet.TipSet()
+	}
+
+	buf := new(bytes.Buffer)
+	if err := cg.ChainStore().ExportLatestState(context.TODO(), last, 20, 5, buf); err != nil {
+		t.Fatal(err)
+	}
+
+	nbs := blockstore.NewMemory()
+	cs := store.NewChainStore(nbs, nbs, datastore.NewMapDatastore(), filcns.Weight, nil)
+	defer cs.Close() //nolint:errcheck
+
+	root, err := cs.Import(context.TODO(), buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !root.Equals(last) {
+		t.Fatal("imported chain differed from exported chain")
+	}
+
+	// the recent headers and state trees are there, the chain history isn't
+	ts := root
+	for ts.Height() > last.Height()-20 {
+		if ts.Height() > last.Height()-5 {
+			has, err := nbs.Has(context.TODO(), ts.ParentState())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !has {
+				t.Fatalf("missing state root at height %d", ts.Height())
+			}
+		}
+
+		ts, err = cs.LoadTipSet(context.TODO(), ts.Parents())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	has, err := nbs.Has(context.TODO(), cg.Genesis().Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if has {
+		t.Fatal("genesis included in the latest state snapshot")
+	}
+}
+
+func TestChainExportRange(t *testing.T) {
+	//stm: @CHAIN_GEN_NEXT_TIPSET_001
+	//stm: @CHAIN_STORE_IMPORT_001, @CHAIN_STORE_EXPORT_001, @CHAIN_STORE_GET_TIPSET_BY_HEIGHT_001
+	cg, err := gen.NewGenerator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var last *types.TipSet
+	for i := 0; i < 100; i++ {
+		ts, err := cg.NextTipSet()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		last = ts.TipSet.TipSet()
+	}
+
+	top, err := cg.ChainStore().GetTipsetByHeight(context.TODO(), 80, last, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	err = cg.ChainStore().ExportRange(context.TODO(), top, 50, api.ChainExportRangeOpts{
+		MessagesDepth: -1,
+		StateDepth:    5,
+	}, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nbs := blockstore.NewMemory()
+	cs := store.NewChainStore(nbs, nbs, datastore.NewMapDatastore(), filcns.Weight, nil)
+	defer cs.Close() //nolint:errcheck
+
+	root, err := cs.Import(context.TODO(), buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !root.Equals(top) {
+		t.Fatal("imported chain differed from exported chain")
+	}
+
+	// all the headers and messages of the range are there, and the state trees
+	// of the top of the range
+	ts := root
+	for {
+		for _, b := range ts.Blocks() {
+			has, err := nbs.Has(context.TODO(), b.Messages)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !has {
+				t.Fatalf("missing messages at height %d", ts.Height())
+			}
+		}
+
+		has, err := nbs.Has(context.TODO(), ts.ParentState())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if has != (ts.Height() > top.Height()-5) {
+			t.Fatalf("unexpected state root presence %t at height %d", has, ts.Height())
+		}
+
+		if ts.Height() == 50 {
+			break
+		}
+
+		ts, err = cs.LoadTipSet(context.TODO(), ts.Parents())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the headers below the range aren't
+	if _, err := cs.LoadTipSet(context.TODO(), ts.Parents()); err == nil {
+		t.Fatal("header below the range included in the export")
+	}
+}
+
 func TestChainExportImportFull(t *testing.T) {
 	//stm: @CHAIN_GEN_NEXT_TIPSET_001
 	//stm: @CHAIN_STORE_IMPORT_001, @CHAIN_STORE_EXPORT_001, @CHAIN_STORE_SET_HEAD_001
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"fmt"
 	"sync"
 	"time"
 
@@ -27,6 +28,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/consensus"
 	"github.com/filecoin-project/lotus/chain/messagepool"
 	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/sub/propstats"
 	"github.com/filecoin-project/lotus/chain/sub/ratelimit"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/metrics"
@@ -43,7 +45,7 @@ var msgCidPrefix = cid.Prefix{
 	MhLength: 32,
 }
 
-func HandleIncomingBlocks(ctx context.Context, bsub *pubsub.Subscription, s *chain.Syncer, bs bserv.BlockService, cmgr connmgr.ConnManager) {
+func HandleIncomingBlocks(ctx context.Context, bsub *pubsub.Subscription, s *chain.Syncer, bs bserv.BlockService, cmgr connmgr.ConnManager, props *propstats.Tracker) {
 	// Timeout after (block time + propagation delay). This is useless at
 	// this point.
 	timeout := time.Duration(build.BlockDelaySecs+build.PropagationDelaySecs) * time.Second
@@ -90,6 +92,9 @@ func HandleIncomingBlocks(ctx context.Context, bsub *pubsub.Subscription, s *cha
 			}
 
 			took := build.Clock.Since(start)
+			if props != nil {
+				props.MessagesFetched(blk.Cid(), took)
+			}
 			log.Debugw("new block over pubsub", "cid", blk.Header.Cid(), "source", msg.GetFrom(), "msgfetch", took)
 			if took > 3*time.Second {
 				log.Warnw("Slow msg fetch", "cid", blk.Header.Cid(), "source", msg.GetFrom(), "msgfetch", took)
@@ -159,9 +164,12 @@ func FetchSignedMessagesByCids(
 }
 
 // Fetch `cids` from the block service, apply `cb` on each of them. Used
-//  by the fetch message functions above.
+//
+//	by the fetch message functions above.
+//
 // We check that each block is received only once and we do not received
-//  blocks we did not request.
+//
+//	blocks we did not request.
 func fetchCids(
 	ctx context.Context,
 	bserv bserv.BlockGetter,
@@ -225,9 +233,12 @@ type BlockValidator struct {
 	// necessary for block validation
 	chain     *store.ChainStore
 	consensus consensus.Consensus
+
+	// records the propagation of the validated blocks, may be nil
+	props *propstats.Tracker
 }
 
-func NewBlockValidator(self peer.ID, chain *store.ChainStore, cns consensus.Consensus, blacklist func(peer.ID)) *BlockValidator {
+func NewBlockValidator(self peer.ID, chain *store.ChainStore, cns consensus.Consensus, blacklist func(peer.ID), props *propstats.Tracker) *BlockValidator {
 	p, _ := lru.New2Q(4096)
 	return &BlockValidator{
 		self:       self,
@@ -237,6 +248,7 @@ func NewBlockValidator(self peer.ID, chain *store.ChainStore, cns consensus.Cons
 		recvBlocks: newBlockReceiptCache(),
 		chain:      chain,
 		consensus:  cns,
+		props:      props,
 	}
 }
 
@@ -259,17 +271,25 @@ func (bv *BlockValidator) flagPeer(p peer.ID) {
 }
 
 func (bv *BlockValidator) Validate(ctx context.Context, pid peer.ID, msg *pubsub.Message) (res pubsub.ValidationResult) {
+	var what string
+	if bv.props != nil {
+		arrival := build.Clock.Now()
+		defer func() {
+			bv.props.BlockValidated(msg, pid, pid == bv.self, arrival, res, what)
+		}()
+	}
+
 	defer func() {
 		if rerr := recover(); rerr != nil {
 			err := xerrors.Errorf("validate block: %s", rerr)
 			recordFailure(ctx, metrics.BlockValidationFailure, err.Error())
 			bv.flagPeer(pid)
 			res = pubsub.ValidationReject
+			what = fmt.Sprint(rerr)
 			return
 		}
 	}()
 
-	var what string
 	res, what = bv.consensus.ValidateBlockPubsub(ctx, pid == bv.self, msg)
 	if res == pubsub.ValidationAccept {
 		// it's a good block! make sure we've only seen it once
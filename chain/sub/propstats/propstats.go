@@ -0,0 +1,154 @@
+package propstats
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// Retention is the number of epochs the propagation of blocks is kept for
+const Retention = builtin.EpochsInDay
+
+// Tracker records when blocks received over pubsub arrive, who they are first
+// received from, and how long they take to validate
+type Tracker struct {
+	lk        sync.Mutex
+	blocks    map[cid.Cid]*api.BlockPropagation
+	byHeight  map[abi.ChainEpoch][]cid.Cid
+	maxHeight abi.ChainEpoch
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{
+		blocks:   make(map[cid.Cid]*api.BlockPropagation),
+		byHeight: make(map[abi.ChainEpoch][]cid.Cid),
+	}
+}
+
+// BlockValidated records the pubsub validation of a block which started at
+// arrival; only the first receipt of a block is recorded, later ones are
+// counted
+func (t *Tracker) BlockValidated(msg *pubsub.Message, from peer.ID, local bool, arrival time.Time, res pubsub.ValidationResult, reason string) {
+	took := build.Clock.Since(arrival)
+
+	// the block is only set as validator data once it passed validation
+	blk, ok := msg.ValidatorData.(*types.BlockMsg)
+	if !ok {
+		var err error
+		if blk, err = types.DecodeBlockMsg(msg.GetData()); err != nil {
+			return
+		}
+	}
+
+	c := blk.Cid()
+
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	if rec, ok := t.blocks[c]; ok {
+		rec.Receipts++
+		return
+	}
+
+	h := blk.Header.Height
+	if h <= t.maxHeight-Retention {
+		return
+	}
+
+	epochStart := time.Unix(int64(blk.Header.Timestamp), 0)
+	t.blocks[c] = &api.BlockPropagation{
+		Cid:                c,
+		Height:             h,
+		Miner:              blk.Header.Miner,
+		EpochStart:         epochStart,
+		Arrival:            arrival,
+		Delay:              arrival.Sub(epochStart),
+		FirstPeer:          from,
+		Local:              local,
+		Receipts:           1,
+		ValidationDuration: took,
+		Result:             result(res, reason),
+	}
+	t.byHeight[h] = append(t.byHeight[h], c)
+
+	if h > t.maxHeight {
+		t.maxHeight = h
+		t.prune()
+	}
+}
+
+// MessagesFetched records how long fetching the messages of an accepted block
+// took
+func (t *Tracker) MessagesFetched(c cid.Cid, took time.Duration) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	if rec, ok := t.blocks[c]; ok {
+		rec.MessageFetchDuration = took
+	}
+}
+
+// Blocks returns the propagation of the blocks at or above the height, ordered
+// by height and arrival
+func (t *Tracker) Blocks(from abi.ChainEpoch) []api.BlockPropagation {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	var out []api.BlockPropagation
+	for h, cids := range t.byHeight {
+		if h < from {
+			continue
+		}
+		for _, c := range cids {
+			out = append(out, *t.blocks[c])
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Height != out[j].Height {
+			return out[i].Height < out[j].Height
+		}
+		return out[i].Arrival.Before(out[j].Arrival)
+	})
+
+	return out
+}
+
+func (t *Tracker) prune() {
+	for h, cids := range t.byHeight {
+		if h > t.maxHeight-Retention {
+			continue
+		}
+		for _, c := range cids {
+			delete(t.blocks, c)
+		}
+		delete(t.byHeight, h)
+	}
+}
+
+func result(res pubsub.ValidationResult, reason string) string {
+	var out string
+	switch res {
+	case pubsub.ValidationAccept:
+		out = "accept"
+	case pubsub.ValidationIgnore:
+		out = "ignore"
+	default:
+		out = "reject"
+	}
+	if reason != "" {
+		out += ": " + reason
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+//stm: #unit
+package propstats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	pb "github.com/libp2p/go-libp2p-pubsub/pb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+func blockMsg(t *testing.T, h abi.ChainEpoch, nonce uint64) (*pubsub.Message, cid.Cid) {
+	hdr := mock.MkBlock(nil, 0, nonce)
+	hdr.Height = h
+	hdr.Timestamp = uint64(time.Now().Add(-10 * time.Second).Unix())
+
+	bm := &types.BlockMsg{Header: hdr}
+	data, err := bm.Serialize()
+	require.NoError(t, err)
+
+	return &pubsub.Message{Message: &pb.Message{Data: data}}, bm.Cid()
+}
+
+func TestTracker(t *testing.T) {
+	tr := NewTracker()
+
+	first, second := peer.ID("first"), peer.ID("second")
+
+	msg, c := blockMsg(t, 10, 1)
+	arrival := time.Now()
+	tr.BlockValidated(msg, first, false, arrival, pubsub.ValidationAccept, "")
+	tr.BlockValidated(msg, second, false, time.Now(), pubsub.ValidationIgnore, "")
+	tr.MessagesFetched(c, time.Second)
+
+	// rejected blocks are recorded too
+	rejected, _ := blockMsg(t, 11, 2)
+	tr.BlockValidated(rejected, second, false, time.Now(), pubsub.ValidationReject, "bad_sig")
+
+	blocks := tr.Blocks(0)
+	require.Len(t, blocks, 2)
+
+	b := blocks[0]
+	require.Equal(t, c, b.Cid)
+	require.Equal(t, abi.ChainEpoch(10), b.Height)
+	require.Equal(t, first, b.FirstPeer)
+	require.Equal(t, 2, b.Receipts)
+	require.Equal(t, "accept", b.Result)
+	require.Equal(t, time.Second, b.MessageFetchDuration)
+	require.Equal(t, arrival, b.Arrival)
+	require.InDelta(t, 10*time.Second, b.Delay, float64(2*time.Second))
+
+	require.Equal(t, "reject: bad_sig", blocks[1].Result)
+
+	require.Len(t, tr.Blocks(11), 1)
+
+	// blocks are dropped once they fall out of the retention window
+	recent, _ := blockMsg(t, 10+Retention, 3)
+	tr.BlockValidated(recent, first, false, time.Now(), pubsub.ValidationAccept, "")
+
+	blocks = tr.Blocks(0)
+	require.Len(t, blocks, 2)
+	require.Equal(t, abi.ChainEpoch(11), blocks[0].Height)
+
+	// and old blocks aren't recorded
+	old, _ := blockMsg(t, 5, 4)
+	tr.BlockValidated(old, first, false, time.Now(), pubsub.ValidationAccept, "")
+	require.Len(t, tr.Blocks(0), 2)
+}
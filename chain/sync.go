@@ -50,6 +50,10 @@ var (
 	// where the Syncer publishes candidate chain heads to be synced.
 	LocalIncoming = "incoming"
 
+	// LocalProgress is the _local_ pubsub topic where the Syncer publishes the
+	// progress of the sync workers.
+	LocalProgress = "progress"
+
 	log = logging.Logger("chain")
 
 	concurrentSyncRequests = exchange.ShufflePeersPrefix
@@ -108,12 +112,19 @@ type Syncer struct {
 	connmgr connmgr.ConnManager
 
 	incoming *pubsub.PubSub
+	progress *pubsub.PubSub
 
 	receiptTracker *blockReceiptTracker
 
+	// competing heads advertised by peers, and recently orphaned tipsets
+	forks *forkTracker
+
 	tickerCtxCancel context.CancelFunc
 
 	ds dtypes.MetadataDS
+
+	// validation pipeline config, nil when validating serially
+	pipeline *PipelineConfig
 }
 
 type SyncManagerCtor func(syncFn SyncFunc) SyncManager
@@ -151,11 +162,15 @@ func NewSyncer(ds dtypes.MetadataDS,
 		sm:             sm,
 		self:           self,
 		receiptTracker: newBlockReceiptTracker(),
+		forks:          newForkTracker(),
 		connmgr:        connmgr,
 
 		incoming: pubsub.New(50),
+		progress: pubsub.New(50),
 	}
 
+	s.store.SubscribeHeadChanges(s.forks.headChange)
+
 	s.syncmgr = syncMgrCtor(s.Sync)
 	return s, nil
 }
@@ -236,6 +251,8 @@ func (syncer *Syncer) InformNewHead(from peer.ID, fts *store.FullTipSet) bool {
 	syncer.Exchange.AddPeer(from)
 
 	hts := syncer.store.GetHeaviestTipSet()
+	syncer.forks.observe(from, fts.TipSet(), hts.Height())
+
 	bestPweight := hts.ParentWeight()
 	targetWeight := fts.TipSet().ParentWeight()
 	if targetWeight.LessThan(bestPweight) {
@@ -509,6 +526,8 @@ func (syncer *Syncer) Sync(ctx context.Context, maybeHead *types.TipSet) error {
 	ctx, span := trace.StartSpan(ctx, "chain.Sync")
 	defer span.End()
 
+	ctx = bstore.WithCacheCaller(ctx, bstore.CacheCallerSync)
+
 	if span.IsRecordingEvents() {
 		span.AddAttributes(
 			trace.StringAttribute("tipset", fmt.Sprint(maybeHead.Cids())),
@@ -940,6 +959,10 @@ func (syncer *Syncer) syncFork(ctx context.Context, incoming *types.TipSet, know
 }
 
 func (syncer *Syncer) syncMessagesAndCheckState(ctx context.Context, headers []*types.TipSet) error {
+	if syncer.pipeline != nil {
+		return syncer.syncMessagesPipelined(ctx, headers)
+	}
+
 	ss := extractSyncState(ctx)
 	ss.SetHeight(headers[len(headers)-1].Height())
 
@@ -971,9 +994,11 @@ func (syncer *Syncer) iterFullTipsets(ctx context.Context, headers []*types.TipS
 			return err
 		}
 		if fts != nil {
-			if err := cb(ctx, fts); err != nil {
+			var vt consensus.ValidationTimings
+			if err := cb(consensus.WithValidationTimings(ctx, &vt), fts); err != nil {
 				return err
 			}
+			syncer.publishTipSetProgress(ctx, fts.TipSet(), &vt, api.SyncStageTimings{})
 			i--
 			continue
 		}
@@ -985,7 +1010,9 @@ func (syncer *Syncer) iterFullTipsets(ctx context.Context, headers []*types.TipS
 
 		ss.SetStage(api.StageFetchingMessages)
 		startOffset := i + 1 - batchSize
+		fetchStart := build.Clock.Now()
 		bstout, batchErr := syncer.fetchMessages(ctx, headers[startOffset:startOffset+batchSize], startOffset)
+		fetchTime := build.Clock.Since(fetchStart)
 		ss.SetStage(api.StageMessages)
 
 		if batchErr != nil {
@@ -1007,10 +1034,12 @@ func (syncer *Syncer) iterFullTipsets(ctx context.Context, headers []*types.TipS
 				return xerrors.Errorf("message processing failed: %w", err)
 			}
 
-			if err := cb(ctx, fts); err != nil {
+			var vt consensus.ValidationTimings
+			if err := cb(consensus.WithValidationTimings(ctx, &vt), fts); err != nil {
 				return err
 			}
 
+			persistStart := build.Clock.Now()
 			if err := persistMessages(ctx, bs, bstip); err != nil {
 				return err
 			}
@@ -1018,6 +1047,11 @@ func (syncer *Syncer) iterFullTipsets(ctx context.Context, headers []*types.TipS
 			if err := copyBlockstore(ctx, bs, syncer.store.ChainBlockstore()); err != nil {
 				return xerrors.Errorf("message processing failed: %w", err)
 			}
+
+			syncer.publishTipSetProgress(ctx, this, &vt, api.SyncStageTimings{
+				Fetch:   fetchTime / time.Duration(len(bstout)),
+				Persist: build.Clock.Since(persistStart),
+			})
 		}
 
 		i -= batchSize
@@ -1184,12 +1218,19 @@ func (syncer *Syncer) collectChain(ctx context.Context, ts *types.TipSet, hts *t
 
 	ss.Init(hts, ts)
 
-	headers, err := syncer.collectHeaders(ctx, ts, hts, ignoreCheckpoint)
-	if err != nil {
+	fail := func(err error) error {
 		ss.Error(err)
+		syncer.publishProgress(ctx, api.SyncProgress{Stage: api.StageSyncErrored, Error: err.Error()})
 		return err
 	}
 
+	fetchStart := build.Clock.Now()
+	headers, err := syncer.collectHeaders(ctx, ts, hts, ignoreCheckpoint)
+	if err != nil {
+		return fail(err)
+	}
+	persistStart := build.Clock.Now()
+
 	span.AddAttributes(trace.Int64Attribute("syncChainLength", int64(len(headers))))
 
 	if !headers[0].Equals(ts) {
@@ -1203,21 +1244,26 @@ func (syncer *Syncer) collectChain(ctx context.Context, ts *types.TipSet, hts *t
 		toPersist = append(toPersist, ts.Blocks()...)
 	}
 	if err := syncer.store.PersistBlockHeaders(ctx, toPersist...); err != nil {
-		err = xerrors.Errorf("failed to persist synced blocks to the chainstore: %w", err)
-		ss.Error(err)
-		return err
+		return fail(xerrors.Errorf("failed to persist synced blocks to the chainstore: %w", err))
 	}
 	toPersist = nil
 
+	syncer.publishProgress(ctx, api.SyncProgress{
+		Stage: api.StagePersistHeaders,
+		Timings: api.SyncStageTimings{
+			Fetch:   persistStart.Sub(fetchStart),
+			Persist: build.Clock.Since(persistStart),
+		},
+	})
+
 	ss.SetStage(api.StageMessages)
 
 	if err := syncer.syncMessagesAndCheckState(ctx, headers); err != nil {
-		err = xerrors.Errorf("collectChain syncMessages: %w", err)
-		ss.Error(err)
-		return err
+		return fail(xerrors.Errorf("collectChain syncMessages: %w", err))
 	}
 
 	ss.SetStage(api.StageSyncComplete)
+	syncer.publishProgress(ctx, api.SyncProgress{Stage: api.StageSyncComplete})
 	log.Debugw("new tipset", "height", ts.Height(), "tipset", types.LogCids(ts.Cids()))
 
 	return nil
@@ -0,0 +1,248 @@
+package chain
+
+import (
+	"context"
+	"runtime"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/trace"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	bstore "github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/consensus"
+	"github.com/filecoin-project/lotus/chain/exchange"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/metrics"
+)
+
+// PipelineConfig configures the validation pipeline used by catch-up sync.
+//
+// The pipeline overlaps three stages: fetching the messages of upcoming
+// tipsets, verifying their state-independent message signatures on a pool of
+// workers, and executing/persisting the tipsets in order. Execution and
+// persistence stay serial, as executing a tipset reads the messages of its
+// parent from the chain blockstore.
+type PipelineConfig struct {
+	// SignatureWorkers is the number of workers verifying signatures; 0 means
+	// one per CPU
+	SignatureWorkers int
+	// Depth is the maximum number of tipsets fetched and verified ahead of
+	// execution
+	Depth int
+}
+
+// SetPipeline enables the validation pipeline for catch-up sync
+func (syncer *Syncer) SetPipeline(cfg PipelineConfig) {
+	if cfg.SignatureWorkers <= 0 {
+		cfg.SignatureWorkers = runtime.NumCPU()
+	}
+	if cfg.Depth <= 0 {
+		// enough to fetch the next batch of messages while executing the current one
+		cfg.Depth = 2 * concurrentSyncRequests * syncRequestBatchSize
+	}
+
+	syncer.pipeline = &cfg
+}
+
+// pipelineTipSet is a tipset moving through the validation pipeline
+type pipelineTipSet struct {
+	fts *store.FullTipSet
+
+	// set when the messages were fetched from the network and still need
+	// to be persisted after validation
+	bs   bstore.Blockstore
+	msgs *exchange.CompactedMessages
+
+	// closed once the signatures have been verified, sigErr is set if they
+	// failed to
+	sigsDone chan struct{}
+	sigErr   error
+
+	// share of the batch fetch time, and time spent verifying signatures
+	fetchTime time.Duration
+	sigTime   time.Duration
+}
+
+func (syncer *Syncer) syncMessagesPipelined(ctx context.Context, headers []*types.TipSet) error {
+	ss := extractSyncState(ctx)
+	ss.SetHeight(headers[len(headers)-1].Height())
+
+	ctx, span := trace.StartSpan(ctx, "syncMessagesPipelined")
+	defer span.End()
+
+	span.AddAttributes(trace.Int64Attribute("num_headers", int64(len(headers))))
+
+	cfg := *syncer.pipeline
+	prevalidator, _ := syncer.consensus.(consensus.SignaturePrevalidator)
+
+	eg, ctx := errgroup.WithContext(ctx)
+
+	toVerify := make(chan *pipelineTipSet, cfg.Depth)
+	toExecute := make(chan *pipelineTipSet, cfg.Depth)
+
+	// stage 1: load or fetch the messages, in order
+	eg.Go(func() error {
+		defer close(toVerify)
+		defer close(toExecute)
+
+		return syncer.fetchPipelined(ctx, headers, func(pts *pipelineTipSet) error {
+			for _, ch := range []chan *pipelineTipSet{toVerify, toExecute} {
+				select {
+				case ch <- pts:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			return nil
+		})
+	})
+
+	// stage 2: verify the signatures, in parallel
+	for i := 0; i < cfg.SignatureWorkers; i++ {
+		eg.Go(func() error {
+			for pts := range toVerify {
+				if prevalidator != nil {
+					start := build.Clock.Now()
+					pts.sigErr = syncer.prevalidateTipSet(ctx, prevalidator, pts.fts)
+					pts.sigTime = build.Clock.Since(start)
+				}
+				close(pts.sigsDone)
+			}
+			return nil
+		})
+	}
+
+	// stage 3: execute and persist, in order
+	eg.Go(func() error {
+		ss.SetStage(api.StageMessages)
+
+		for pts := range toExecute {
+			select {
+			case <-pts.sigsDone:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			ts := pts.fts.TipSet()
+			if pts.sigErr != nil {
+				log.Errorf("failed to validate tipset: %+v", pts.sigErr)
+				return xerrors.Errorf("message processing failed: %w", pts.sigErr)
+			}
+
+			log.Debugw("validating tipset", "height", ts.Height(), "size", len(ts.Cids()))
+			var vt consensus.ValidationTimings
+			if err := syncer.ValidateTipSet(consensus.WithValidationTimings(ctx, &vt), pts.fts, true); err != nil {
+				log.Errorf("failed to validate tipset: %+v", err)
+				return xerrors.Errorf("message processing failed: %w", err)
+			}
+
+			persistStart := build.Clock.Now()
+			if pts.bs != nil {
+				if err := persistMessages(ctx, pts.bs, pts.msgs); err != nil {
+					return err
+				}
+
+				if err := copyBlockstore(ctx, pts.bs, syncer.store.ChainBlockstore()); err != nil {
+					return xerrors.Errorf("message processing failed: %w", err)
+				}
+			}
+
+			syncer.publishTipSetProgress(ctx, ts, &vt, api.SyncStageTimings{
+				Fetch:            pts.fetchTime,
+				ValidateMessages: pts.sigTime,
+				Persist:          build.Clock.Since(persistStart),
+			})
+
+			stats.Record(ctx, metrics.ChainNodeWorkerHeight.M(int64(ts.Height())))
+			ss.SetHeight(ts.Height())
+		}
+
+		return nil
+	})
+
+	return eg.Wait()
+}
+
+// fetchPipelined fills out the tipsets with messages, like iterFullTipsets, and
+// passes them on to the next stage of the pipeline
+func (syncer *Syncer) fetchPipelined(ctx context.Context, headers []*types.TipSet, next func(*pipelineTipSet) error) error {
+	for i := len(headers) - 1; i >= 0; {
+		fts, err := syncer.store.TryFillTipSet(ctx, headers[i])
+		if err != nil {
+			return err
+		}
+		if fts != nil {
+			if err := next(&pipelineTipSet{fts: fts, sigsDone: make(chan struct{})}); err != nil {
+				return err
+			}
+			i--
+			continue
+		}
+
+		batchSize := concurrentSyncRequests * syncRequestBatchSize
+		if i < batchSize {
+			batchSize = i + 1
+		}
+
+		startOffset := i + 1 - batchSize
+		fetchStart := build.Clock.Now()
+		bstout, batchErr := syncer.fetchMessages(ctx, headers[startOffset:startOffset+batchSize], startOffset)
+		fetchTime := build.Clock.Since(fetchStart)
+		if batchErr != nil {
+			return xerrors.Errorf("failed to fetch messages: %w", batchErr)
+		}
+
+		for bsi := 0; bsi < len(bstout); bsi++ {
+			// temp storage so we don't persist data we dont want to
+			bs := bstore.NewMemory()
+			blks := cbor.NewCborStore(bs)
+
+			this := headers[i-bsi]
+			bstip := bstout[len(bstout)-(bsi+1)]
+			fts, err := zipTipSetAndMessages(blks, this, bstip.Bls, bstip.Secpk, bstip.BlsIncludes, bstip.SecpkIncludes)
+			if err != nil {
+				log.Warnw("zipping failed", "error", err, "bsi", bsi, "i", i,
+					"height", this.Height(),
+					"next-height", i+batchSize)
+				return xerrors.Errorf("message processing failed: %w", err)
+			}
+
+			err = next(&pipelineTipSet{
+				fts:       fts,
+				bs:        bs,
+				msgs:      bstip,
+				sigsDone:  make(chan struct{}),
+				fetchTime: fetchTime / time.Duration(len(bstout)),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		i -= batchSize
+	}
+
+	return nil
+}
+
+func (syncer *Syncer) prevalidateTipSet(ctx context.Context, prevalidator consensus.SignaturePrevalidator, fts *store.FullTipSet) error {
+	if fts.TipSet().Equals(syncer.Genesis) {
+		return nil
+	}
+
+	for _, b := range fts.Blocks {
+		if err := prevalidator.PrevalidateSignatures(ctx, b); err != nil {
+			syncer.bad.Add(b.Cid(), NewBadBlockReason([]cid.Cid{b.Cid()}, err.Error()))
+			return xerrors.Errorf("validating block %s: %w", b.Cid(), err)
+		}
+	}
+
+	return nil
+}
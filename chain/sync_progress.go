@@ -0,0 +1,85 @@
+package chain
+
+import (
+	"context"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/consensus"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// SubscribeProgress spawns a goroutine that subscribes to the local eventbus to
+// receive the progress updates of the sync workers, and sends them to the
+// returned channel.
+//
+// Updates are dropped when the channel is full, so that slow readers can't
+// stall sync.
+func (syncer *Syncer) SubscribeProgress(ctx context.Context) (<-chan api.SyncProgress, error) {
+	sub := syncer.progress.Sub(LocalProgress)
+	out := make(chan api.SyncProgress, 64)
+
+	go func() {
+		defer syncer.progress.Unsub(sub)
+		defer close(out)
+
+		for {
+			select {
+			case r, ok := <-sub:
+				if !ok {
+					return
+				}
+
+				select {
+				case out <- r.(api.SyncProgress):
+				default:
+					log.Debugw("dropping sync progress update, subscriber is falling behind")
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// publishProgress publishes a progress update of the sync worker running in ctx.
+// The timings of tipset updates are added to the worker's totals, which
+// completion updates report.
+func (syncer *Syncer) publishProgress(ctx context.Context, p api.SyncProgress) {
+	p.Time = build.Clock.Now()
+
+	if ss := extractSyncState(ctx); ss != nil {
+		done := p.Stage == api.StageSyncComplete || p.Stage == api.StageSyncErrored
+		if !done {
+			ss.AddTimings(p.Timings)
+		}
+
+		snap := ss.Snapshot()
+		p.WorkerID = snap.WorkerID
+		if snap.Target != nil {
+			p.Target = snap.Target.Key()
+		}
+		if done {
+			p.Timings = snap.Timings
+		}
+	}
+
+	syncer.progress.Pub(p, LocalProgress)
+}
+
+// publishTipSetProgress publishes the progress update of a synced tipset, adding
+// the validation timings collected in vt to t
+func (syncer *Syncer) publishTipSetProgress(ctx context.Context, ts *types.TipSet, vt *consensus.ValidationTimings, t api.SyncStageTimings) {
+	validateMessages, computeState := vt.Get()
+	t.ValidateMessages += validateMessages
+	t.ComputeState += computeState
+
+	syncer.publishProgress(ctx, api.SyncProgress{
+		Stage:   api.StageMessages,
+		Height:  ts.Height(),
+		TipSet:  ts.Key(),
+		Timings: t,
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sort"
 	"testing"
 	"time"
 
@@ -22,6 +23,7 @@ import (
 
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain"
 	"github.com/filecoin-project/lotus/chain/actors/policy"
 	"github.com/filecoin-project/lotus/chain/consensus/filcns"
 	"github.com/filecoin-project/lotus/chain/gen"
@@ -315,7 +317,7 @@ func (tu *syncTestUtil) addSourceNode(gen int) {
 	tu.nds = append(tu.nds, out) // always at 0
 }
 
-func (tu *syncTestUtil) addClientNode() int {
+func (tu *syncTestUtil) addClientNode(opts ...node.Option) int {
 	if tu.genesis == nil {
 		tu.t.Fatal("source doesn't exists")
 	}
@@ -332,6 +334,7 @@ func (tu *syncTestUtil) addClientNode() int {
 
 		node.Override(new(modules.Genesis), modules.LoadGenesis(tu.genesis)),
 		node.Override(new(stmgr.UpgradeSchedule), tu.us),
+		node.Options(opts...),
 	)
 	require.NoError(tu.t, err)
 	tu.t.Cleanup(func() { _ = stop(context.Background()) })
@@ -484,6 +487,21 @@ func TestSyncSimple(t *testing.T) {
 	tu.compareSourceState(client)
 }
 
+func TestSyncSimplePipelined(t *testing.T) {
+	H := 50
+	tu := prepSyncTest(t, H)
+
+	client := tu.addClientNode(node.Override(new(*chain.PipelineConfig), func() *chain.PipelineConfig {
+		return &chain.PipelineConfig{SignatureWorkers: 2, Depth: 4}
+	}))
+
+	require.NoError(t, tu.mn.LinkAll())
+	tu.connect(1, 0)
+	tu.waitUntilSync(0, client)
+
+	tu.compareSourceState(client)
+}
+
 func TestSyncMining(t *testing.T) {
 	//stm: @BLOCKCHAIN_BEACON_VALIDATE_BLOCK_VALUES_01, @CHAIN_SYNCER_LOAD_GENESIS_001, @CHAIN_SYNCER_FETCH_TIPSET_001, @CHAIN_SYNCER_START_001
 	//stm: @CHAIN_SYNCER_NEW_PEER_HEAD_001, @CHAIN_SYNCER_VALIDATE_MESSAGE_META_001, @CHAIN_SYNCER_STOP_001
@@ -1257,3 +1275,51 @@ func TestSyncState(t *testing.T) {
 	require.Equal(tu.t, len(state.ActiveSyncs), 1)
 	require.Equal(tu.t, state.ActiveSyncs[0].Stage, api.StageSyncComplete)
 }
+
+func TestSyncSubscribe(t *testing.T) {
+	H := 50
+	tu := prepSyncTest(t, H)
+
+	client := tu.addClientNode()
+	require.NoError(t, tu.mn.LinkAll())
+	clientNode := tu.nds[client]
+	sourceHead, err := tu.nds[source].ChainHead(tu.ctx)
+	require.NoError(tu.t, err)
+
+	progress, err := clientNode.SyncSubscribe(tu.ctx)
+	require.NoError(tu.t, err)
+
+	tu.connect(client, 0)
+
+	timeout := time.After(10 * time.Second)
+	var headers bool
+	var synced []abi.ChainEpoch
+	for {
+		var p api.SyncProgress
+		select {
+		case p = <-progress:
+		case <-timeout:
+			tu.t.Fatal("TestSyncSubscribe timeout")
+		}
+
+		require.Empty(tu.t, p.Error)
+		require.Equal(tu.t, sourceHead.Key(), p.Target)
+
+		switch p.Stage {
+		case api.StagePersistHeaders:
+			headers = true
+		case api.StageMessages:
+			synced = append(synced, p.Height)
+		}
+		if p.Stage == api.StageSyncComplete {
+			require.True(tu.t, headers)
+			require.Positive(tu.t, p.Timings.ComputeState)
+			break
+		}
+	}
+
+	// tipsets are reported in order, up to the target
+	require.NotEmpty(tu.t, synced)
+	require.True(tu.t, sort.SliceIsSorted(synced, func(i, j int) bool { return synced[i] < synced[j] }))
+	require.Equal(tu.t, sourceHead.Height(), synced[len(synced)-1])
+}
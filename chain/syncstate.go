@@ -20,6 +20,7 @@ type SyncerStateSnapshot struct {
 	Message  string
 	Start    time.Time
 	End      time.Time
+	Timings  api.SyncStageTimings
 }
 
 type SyncerState struct {
@@ -54,6 +55,7 @@ func (ss *SyncerState) Init(base, target *types.TipSet) {
 	ss.data.Message = ""
 	ss.data.Start = build.Clock.Now()
 	ss.data.End = time.Time{}
+	ss.data.Timings = api.SyncStageTimings{}
 }
 
 func (ss *SyncerState) SetHeight(h abi.ChainEpoch) {
@@ -66,6 +68,16 @@ func (ss *SyncerState) SetHeight(h abi.ChainEpoch) {
 	ss.data.Height = h
 }
 
+func (ss *SyncerState) AddTimings(t api.SyncStageTimings) {
+	if ss == nil {
+		return
+	}
+
+	ss.lk.Lock()
+	defer ss.lk.Unlock()
+	ss.data.Timings.Add(t)
+}
+
 func (ss *SyncerState) Error(err error) {
 	if ss == nil {
 		return
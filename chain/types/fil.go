@@ -73,12 +73,15 @@ func (f FIL) MarshalText() (text []byte, err error) {
 	return []byte(f.String()), nil
 }
 
-func (f FIL) UnmarshalText(text []byte) error {
+func (f *FIL) UnmarshalText(text []byte) error {
 	p, err := ParseFIL(string(text))
 	if err != nil {
 		return err
 	}
 
+	if f.Int == nil {
+		f.Int = big.NewInt(0)
+	}
 	f.Int.Set(p.Int)
 	return nil
 }
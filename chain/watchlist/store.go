@@ -0,0 +1,75 @@
+package watchlist
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ipfs/go-datastore"
+	dsq "github.com/ipfs/go-datastore/query"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+const dsKeyAddr = "Addr"
+
+// Store persists watchlist entries in the metadata datastore
+type Store struct {
+	ds datastore.Batching
+}
+
+func NewStore(ds datastore.Batching) *Store {
+	return &Store{
+		ds: ds,
+	}
+}
+
+// save the entry to the datastore
+func (ws *Store) save(ctx context.Context, entry *api.WatchlistEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return ws.ds.Put(ctx, dskeyForAddr(entry.Address), b)
+}
+
+// remove the entry for the given address from the datastore
+func (ws *Store) remove(ctx context.Context, addr address.Address) error {
+	return ws.ds.Delete(ctx, dskeyForAddr(addr))
+}
+
+// forEach calls iter with each entry in the datastore
+func (ws *Store) forEach(ctx context.Context, iter func(*api.WatchlistEntry)) error {
+	res, err := ws.ds.Query(ctx, dsq.Query{Prefix: dsKeyAddr})
+	if err != nil {
+		return err
+	}
+	defer res.Close() //nolint:errcheck
+
+	for {
+		res, ok := res.NextSync()
+		if !ok {
+			break
+		}
+
+		if res.Error != nil {
+			return res.Error
+		}
+
+		var stored api.WatchlistEntry
+		if err := json.Unmarshal(res.Value, &stored); err != nil {
+			return err
+		}
+
+		iter(&stored)
+	}
+
+	return nil
+}
+
+// The datastore key used to identify the watched address
+func dskeyForAddr(addr address.Address) datastore.Key {
+	return datastore.KeyWithNamespaces([]string{dsKeyAddr, addr.String()})
+}
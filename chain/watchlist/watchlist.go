@@ -0,0 +1,303 @@
+package watchlist
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/journal/alerting"
+)
+
+var log = logging.Logger("watchlist")
+
+const alertSystem = "watchlist"
+
+// WatchlistAPI is the set of node methods used by the watchlist
+type WatchlistAPI interface {
+	ChainNotify(context.Context) (<-chan []*api.HeadChange, error)
+	ChainGetTipSetByHeight(ctx context.Context, h abi.ChainEpoch, tsk types.TipSetKey) (*types.TipSet, error)
+	StateGetActor(ctx context.Context, actor address.Address, tsk types.TipSetKey) (*types.Actor, error)
+}
+
+// Watchlist keeps track of the balances of a set of addresses, raising an
+// alert for each address whose balance violates the conditions it was
+// registered with.
+type Watchlist struct {
+	ctx      context.Context
+	shutdown context.CancelFunc
+	api      WatchlistAPI
+	str      *Store
+	al       *alerting.Alerting
+
+	lk      sync.Mutex
+	entries map[address.Address]*watched
+	head    *types.TipSet
+}
+
+type watched struct {
+	status api.WatchlistStatus
+	alert  alerting.AlertType
+}
+
+func NewWatchlist(ctx context.Context, shutdown context.CancelFunc, str *Store, al *alerting.Alerting, api WatchlistAPI) *Watchlist {
+	return &Watchlist{
+		ctx:      ctx,
+		shutdown: shutdown,
+		api:      api,
+		str:      str,
+		al:       al,
+		entries:  make(map[address.Address]*watched),
+	}
+}
+
+// Start loads the persisted entries and starts following the chain head
+func (w *Watchlist) Start() error {
+	w.lk.Lock()
+	err := w.str.forEach(w.ctx, func(entry *api.WatchlistEntry) {
+		w.entries[entry.Address] = w.newWatched(*entry)
+	})
+	w.lk.Unlock()
+	if err != nil {
+		return xerrors.Errorf("loading watchlist: %w", err)
+	}
+
+	notifs, err := w.api.ChainNotify(w.ctx)
+	if err != nil {
+		return xerrors.Errorf("subscribing to chain head: %w", err)
+	}
+
+	go w.run(notifs)
+	return nil
+}
+
+func (w *Watchlist) Stop() error {
+	w.shutdown()
+	return nil
+}
+
+func (w *Watchlist) run(notifs <-chan []*api.HeadChange) {
+	for {
+		select {
+		case changes, ok := <-notifs:
+			if !ok {
+				log.Warn("chain notifications channel closed, watchlist stopped")
+				return
+			}
+
+			var head *types.TipSet
+			for _, change := range changes {
+				if change.Type == store.HCCurrent || change.Type == store.HCApply {
+					head = change.Val
+				}
+			}
+			if head == nil {
+				continue
+			}
+
+			w.lk.Lock()
+			w.head = head
+			for _, e := range w.entries {
+				w.check(w.ctx, head, e)
+			}
+			w.lk.Unlock()
+		case <-w.ctx.Done():
+			return
+		}
+	}
+}
+
+// Add starts watching the address of the given entry, replacing the
+// conditions of an existing entry for the same address
+func (w *Watchlist) Add(ctx context.Context, entry api.WatchlistEntry) error {
+	if err := validateEntry(&entry); err != nil {
+		return err
+	}
+
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	if err := w.str.save(ctx, &entry); err != nil {
+		return xerrors.Errorf("saving watchlist entry: %w", err)
+	}
+
+	e := w.newWatched(entry)
+	if old, ok := w.entries[entry.Address]; ok {
+		e.alert = old.alert
+	}
+	w.entries[entry.Address] = e
+
+	if w.head != nil {
+		w.check(ctx, w.head, e)
+	}
+
+	return nil
+}
+
+// Remove stops watching the given address
+func (w *Watchlist) Remove(ctx context.Context, addr address.Address) error {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	e, ok := w.entries[addr]
+	if !ok {
+		return xerrors.Errorf("address %s is not on the watchlist", addr)
+	}
+
+	if err := w.str.remove(ctx, addr); err != nil {
+		return xerrors.Errorf("removing watchlist entry: %w", err)
+	}
+
+	if w.al.IsRaised(e.alert) {
+		w.al.Resolve(e.alert, map[string]string{
+			"message": "address removed from watchlist",
+		})
+	}
+	delete(w.entries, addr)
+
+	return nil
+}
+
+// List returns the status of all watched addresses
+func (w *Watchlist) List() []api.WatchlistStatus {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	out := make([]api.WatchlistStatus, 0, len(w.entries))
+	for _, e := range w.entries {
+		out = append(out, e.status)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Entry.Address.String() < out[j].Entry.Address.String()
+	})
+
+	return out
+}
+
+func (w *Watchlist) newWatched(entry api.WatchlistEntry) *watched {
+	return &watched{
+		status: api.WatchlistStatus{
+			Entry:   entry,
+			Balance: big.Zero(),
+			Change:  big.Zero(),
+		},
+		alert: w.al.AddAlertType(alertSystem, entry.Address.String()),
+	}
+}
+
+// check refreshes the status of the entry at the given tipset, and raises or
+// resolves the alert for the entry accordingly. Must be called with the lock
+// held.
+func (w *Watchlist) check(ctx context.Context, ts *types.TipSet, e *watched) {
+	entry := e.status.Entry
+
+	bal, err := w.balance(ctx, entry.Address, ts)
+	if err != nil {
+		log.Errorw("getting watched address balance", "address", entry.Address, "height", ts.Height(), "error", err)
+		return
+	}
+
+	change := big.Zero()
+	if isSet(entry.MaxChange) && ts.Height() > entry.ChangeWindow {
+		pts, err := w.api.ChainGetTipSetByHeight(ctx, ts.Height()-entry.ChangeWindow, ts.Key())
+		if err != nil {
+			log.Errorw("getting lookback tipset", "address", entry.Address, "height", ts.Height(), "error", err)
+			return
+		}
+
+		pbal, err := w.balance(ctx, entry.Address, pts)
+		if err != nil {
+			log.Errorw("getting watched address lookback balance", "address", entry.Address, "height", pts.Height(), "error", err)
+			return
+		}
+
+		change = big.Sub(bal, pbal)
+	}
+
+	e.status.Height = ts.Height()
+	e.status.Balance = bal
+	e.status.Change = change
+	e.status.Violations = violations(&entry, bal, change)
+
+	// alerts are only raised / resolved on state transitions, the latest
+	// balance is always available through List
+	raised := w.al.IsRaised(e.alert)
+	switch {
+	case len(e.status.Violations) > 0 && !raised:
+		w.al.Raise(e.alert, map[string]interface{}{
+			"address":    entry.Address.String(),
+			"label":      entry.Label,
+			"height":     ts.Height(),
+			"balance":    types.FIL(bal).String(),
+			"violations": e.status.Violations,
+		})
+	case len(e.status.Violations) == 0 && raised:
+		w.al.Resolve(e.alert, map[string]interface{}{
+			"address": entry.Address.String(),
+			"label":   entry.Label,
+			"height":  ts.Height(),
+			"balance": types.FIL(bal).String(),
+		})
+	}
+}
+
+func (w *Watchlist) balance(ctx context.Context, addr address.Address, ts *types.TipSet) (abi.TokenAmount, error) {
+	act, err := w.api.StateGetActor(ctx, addr, ts.Key())
+	if err != nil {
+		if xerrors.Is(err, types.ErrActorNotFound) {
+			return big.Zero(), nil
+		}
+		return abi.TokenAmount{}, err
+	}
+
+	return act.Balance, nil
+}
+
+func violations(entry *api.WatchlistEntry, bal, change abi.TokenAmount) []string {
+	var out []string
+
+	if isSet(entry.MinBalance) && bal.LessThan(entry.MinBalance) {
+		out = append(out, fmt.Sprintf("balance %s below minimum %s", types.FIL(bal), types.FIL(entry.MinBalance)))
+	}
+	if isSet(entry.MaxBalance) && bal.GreaterThan(entry.MaxBalance) {
+		out = append(out, fmt.Sprintf("balance %s above maximum %s", types.FIL(bal), types.FIL(entry.MaxBalance)))
+	}
+	if isSet(entry.MaxChange) && change.Abs().GreaterThan(entry.MaxChange) {
+		out = append(out, fmt.Sprintf("balance changed by %s over %d epochs, more than %s", types.FIL(change), entry.ChangeWindow, types.FIL(entry.MaxChange)))
+	}
+
+	return out
+}
+
+func validateEntry(entry *api.WatchlistEntry) error {
+	if entry.Address == address.Undef {
+		return xerrors.Errorf("watchlist entry must specify an address")
+	}
+	for _, amt := range []abi.TokenAmount{entry.MinBalance, entry.MaxBalance, entry.MaxChange} {
+		if !amt.Nil() && amt.Sign() < 0 {
+			return xerrors.Errorf("watchlist amounts must not be negative")
+		}
+	}
+	if isSet(entry.MinBalance) && isSet(entry.MaxBalance) && entry.MinBalance.GreaterThan(entry.MaxBalance) {
+		return xerrors.Errorf("minimum balance %s is greater than maximum balance %s", types.FIL(entry.MinBalance), types.FIL(entry.MaxBalance))
+	}
+	if isSet(entry.MaxChange) && entry.ChangeWindow <= 0 {
+		return xerrors.Errorf("a positive change window must be specified with a maximum change")
+	}
+
+	return nil
+}
+
+func isSet(amt abi.TokenAmount) bool {
+	return !amt.Nil() && !amt.IsZero()
+}
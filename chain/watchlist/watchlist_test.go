@@ -0,0 +1,216 @@
+//stm: #unit
+package watchlist
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	ds_sync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/journal/alerting"
+)
+
+type mockAPI struct {
+	notifs chan []*api.HeadChange
+
+	lk       sync.Mutex
+	tipsets  map[abi.ChainEpoch]*types.TipSet
+	balances map[abi.ChainEpoch]abi.TokenAmount
+}
+
+func newMockAPI() *mockAPI {
+	return &mockAPI{
+		notifs:   make(chan []*api.HeadChange, 16),
+		tipsets:  map[abi.ChainEpoch]*types.TipSet{},
+		balances: map[abi.ChainEpoch]abi.TokenAmount{},
+	}
+}
+
+func (m *mockAPI) ChainNotify(ctx context.Context) (<-chan []*api.HeadChange, error) {
+	return m.notifs, nil
+}
+
+func (m *mockAPI) ChainGetTipSetByHeight(ctx context.Context, h abi.ChainEpoch, tsk types.TipSetKey) (*types.TipSet, error) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	return m.tipsets[h], nil
+}
+
+func (m *mockAPI) StateGetActor(ctx context.Context, addr address.Address, tsk types.TipSetKey) (*types.Actor, error) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	for h, ts := range m.tipsets {
+		if ts.Key() == tsk {
+			bal, ok := m.balances[h]
+			if !ok {
+				return nil, types.ErrActorNotFound
+			}
+			return &types.Actor{Balance: bal}, nil
+		}
+	}
+
+	return nil, types.ErrActorNotFound
+}
+
+// mine creates a tipset on top of the previous one, with the watched address
+// holding the given balance
+func (m *mockAPI) mine(prev *types.TipSet, bal int64) *types.TipSet {
+	ts := mock.TipSet(mock.MkBlock(prev, 1, 1))
+
+	m.lk.Lock()
+	m.tipsets[ts.Height()] = ts
+	m.balances[ts.Height()] = abi.NewTokenAmount(bal)
+	m.lk.Unlock()
+
+	m.notifs <- []*api.HeadChange{{Type: store.HCApply, Val: ts}}
+	return ts
+}
+
+func TestWatchlistBalanceRange(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	mapi := newMockAPI()
+	al := alerting.NewAlertingSystem(journal.NilJournal())
+	w := NewWatchlist(ctx, cancel, NewStore(ds_sync.MutexWrap(ds.NewMapDatastore())), al, mapi)
+	require.NoError(t, w.Start())
+	defer w.Stop() //nolint:errcheck
+
+	addr := mock.Address(1000)
+	require.NoError(t, w.Add(ctx, api.WatchlistEntry{
+		Address:    addr,
+		MinBalance: abi.NewTokenAmount(10),
+		MaxBalance: abi.NewTokenAmount(100),
+		MaxChange:  big.Zero(),
+	}))
+
+	waitHeight := func(h abi.ChainEpoch) api.WatchlistStatus {
+		var st api.WatchlistStatus
+		require.Eventually(t, func() bool {
+			sts := w.List()
+			require.Len(t, sts, 1)
+			st = sts[0]
+			return st.Height == h
+		}, 5*time.Second, 10*time.Millisecond)
+		return st
+	}
+
+	at := al.AddAlertType(alertSystem, addr.String())
+
+	// within range
+	ts := mapi.mine(nil, 50)
+	st := waitHeight(ts.Height())
+	require.Empty(t, st.Violations)
+	require.False(t, al.IsRaised(at))
+
+	// below minimum
+	ts = mapi.mine(ts, 5)
+	st = waitHeight(ts.Height())
+	require.Len(t, st.Violations, 1)
+	require.True(t, al.IsRaised(at))
+
+	// back in range
+	ts = mapi.mine(ts, 20)
+	st = waitHeight(ts.Height())
+	require.Empty(t, st.Violations)
+	require.False(t, al.IsRaised(at))
+
+	// above maximum
+	ts = mapi.mine(ts, 200)
+	st = waitHeight(ts.Height())
+	require.Len(t, st.Violations, 1)
+	require.True(t, al.IsRaised(at))
+
+	// removing the address resolves the alert
+	require.NoError(t, w.Remove(ctx, addr))
+	require.False(t, al.IsRaised(at))
+	require.Empty(t, w.List())
+}
+
+func TestWatchlistChangeRate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	mapi := newMockAPI()
+	al := alerting.NewAlertingSystem(journal.NilJournal())
+	w := NewWatchlist(ctx, cancel, NewStore(ds_sync.MutexWrap(ds.NewMapDatastore())), al, mapi)
+
+	addr := mock.Address(1000)
+	entry := api.WatchlistEntry{
+		Address:    addr,
+		MinBalance: big.Zero(),
+		MaxBalance: big.Zero(),
+		MaxChange:  abi.NewTokenAmount(10),
+	}
+
+	// a change window is required with a maximum change
+	require.Error(t, w.Add(ctx, entry))
+
+	entry.ChangeWindow = 2
+	require.NoError(t, w.Add(ctx, entry))
+
+	var ts *types.TipSet
+	for _, bal := range []int64{100, 100, 105, 108} {
+		ts = mapi.mine(ts, bal)
+	}
+
+	ts0 := ts
+	require.NoError(t, w.Start())
+	defer w.Stop() //nolint:errcheck
+
+	require.Eventually(t, func() bool {
+		return w.List()[0].Height == ts0.Height()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// 108 - 100 is within the allowed change
+	st := w.List()[0]
+	require.Equal(t, abi.NewTokenAmount(8), st.Change)
+	require.Empty(t, st.Violations)
+
+	// 120 - 105 isn't
+	ts = mapi.mine(ts, 120)
+	require.Eventually(t, func() bool {
+		return w.List()[0].Height == ts.Height()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	st = w.List()[0]
+	require.Equal(t, abi.NewTokenAmount(15), st.Change)
+	require.Len(t, st.Violations, 1)
+	require.True(t, al.IsRaised(al.AddAlertType(alertSystem, addr.String())))
+}
+
+func TestWatchlistPersistence(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dstore := ds_sync.MutexWrap(ds.NewMapDatastore())
+	al := alerting.NewAlertingSystem(journal.NilJournal())
+
+	w := NewWatchlist(ctx, cancel, NewStore(dstore), al, newMockAPI())
+	require.NoError(t, w.Add(ctx, api.WatchlistEntry{
+		Address:    mock.Address(1000),
+		Label:      "owner",
+		MinBalance: abi.NewTokenAmount(10),
+		MaxBalance: big.Zero(),
+		MaxChange:  big.Zero(),
+	}))
+
+	w2 := NewWatchlist(ctx, cancel, NewStore(dstore), al, newMockAPI())
+	require.NoError(t, w2.Start())
+
+	sts := w2.List()
+	require.Len(t, sts, 1)
+	require.Equal(t, "owner", sts[0].Entry.Label)
+	require.Equal(t, abi.NewTokenAmount(10), sts[0].Entry.MinBalance)
+}
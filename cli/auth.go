@@ -2,7 +2,10 @@ package cli
 
 import (
 	"fmt"
+	"text/tabwriter"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
 
@@ -18,6 +21,7 @@ var AuthCmd = &cli.Command{
 	Subcommands: []*cli.Command{
 		AuthCreateAdminToken,
 		AuthApiInfoToken,
+		AuthTokenCmd,
 	},
 }
 
@@ -132,3 +136,115 @@ var AuthApiInfoToken = &cli.Command{
 		return nil
 	},
 }
+
+var AuthTokenCmd = &cli.Command{
+	Name:  "token",
+	Usage: "Manage API tokens which can be listed and revoked",
+	Subcommands: []*cli.Command{
+		AuthTokenCreateCmd,
+		AuthTokenListCmd,
+		AuthTokenRevokeCmd,
+	},
+}
+
+var AuthTokenCreateCmd = &cli.Command{
+	Name:  "create",
+	Usage: "Create an API token",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "scope",
+			Usage:    "highest permission of the token, one of: read, write, sign, admin",
+			Required: true,
+		},
+		&cli.DurationFlag{
+			Name:  "ttl",
+			Usage: "time after which the token expires, it doesn't expire when not set",
+		},
+		&cli.StringFlag{
+			Name:  "label",
+			Usage: "label identifying the token",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		napi, closer, err := GetAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		t, err := napi.AuthTokenCreate(ctx, auth.Permission(cctx.String("scope")), cctx.String("label"), cctx.Duration("ttl"))
+		if err != nil {
+			return err
+		}
+
+		// the token alone on stdout, so that it can be used in scripts
+		fmt.Println(t.Token)
+		fmt.Fprintf(cctx.App.ErrWriter, "ID: %s, expires: %s\n", t.Info.ID, tokenExpiry(t.Info.Expiry)) // nolint:errcheck
+		return nil
+	},
+}
+
+var AuthTokenListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "List the API tokens",
+	Action: func(cctx *cli.Context) error {
+		napi, closer, err := GetAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		tokens, err := napi.AuthTokenList(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(cctx.App.Writer, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "ID\tScope\tLabel\tCreated\tExpires\n")
+		for _, t := range tokens {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", t.ID, t.Scope, t.Label, t.Created.Local().Format(time.RFC3339), tokenExpiry(t.Expiry))
+		}
+		return w.Flush()
+	},
+}
+
+var AuthTokenRevokeCmd = &cli.Command{
+	Name:      "revoke",
+	Usage:     "Revoke an API token",
+	ArgsUsage: "<token id>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return ShowHelp(cctx, xerrors.Errorf("expected 1 argument"))
+		}
+
+		id, err := uuid.Parse(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("parsing token id: %w", err)
+		}
+
+		napi, closer, err := GetAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		return napi.AuthTokenRevoke(ctx, id)
+	},
+}
+
+func tokenExpiry(expiry time.Time) string {
+	switch {
+	case expiry.IsZero():
+		return "never"
+	case time.Now().After(expiry):
+		return "expired"
+	default:
+		return expiry.Local().Format(time.RFC3339)
+	}
+}
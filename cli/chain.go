@@ -14,9 +14,12 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
 
+	"github.com/DataDog/zstd"
 	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
 	"github.com/urfave/cli/v2"
 	cbg "github.com/whyrusleeping/cbor-gen"
 	"golang.org/x/xerrors"
@@ -39,6 +42,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/actors"
 	"github.com/filecoin-project/lotus/chain/consensus/filcns"
 	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/lib/tablewriter"
 )
 
 var ChainCmd = &cli.Command{
@@ -56,12 +60,21 @@ var ChainCmd = &cli.Command{
 		ChainGetCmd,
 		ChainBisectCmd,
 		ChainExportCmd,
+		ChainExportRangeCmd,
 		SlashConsensusFault,
 		ChainGasPriceCmd,
 		ChainInspectUsage,
 		ChainDecodeCmd,
 		ChainEncodeCmd,
 		ChainDisputeSetCmd,
+		ChainGCCmd,
+		ChainScrubCmd,
+		ChainMountCmd,
+		ChainSnapshotsCmd,
+		ChainPropStatsCmd,
+		ChainConsensusFaultsCmd,
+		ChainBackfillCmd,
+		ChainForksCmd,
 	},
 }
 
@@ -1061,6 +1074,39 @@ var ChainExportCmd = &cli.Command{
 		&cli.BoolFlag{
 			Name: "skip-old-msgs",
 		},
+		&cli.BoolFlag{
+			Name:  "latest-state",
+			Usage: "only export the latest state of the chain, without the chain history, for nodes to boot from",
+		},
+		&cli.Int64Flag{
+			Name:  "recent-headers",
+			Usage: "specify the number of recent block headers to include in a latest state export",
+			Value: 2 * int64(builtin.EpochsInDay),
+		},
+		&cli.BoolFlag{
+			Name:  "compress",
+			Usage: "compress the export with zstd",
+		},
+		&cli.StringFlag{
+			Name:  "chunk-size",
+			Usage: "split the export into chunks of at most this uncompressed size, written along with a manifest to the output directory",
+		},
+		&cli.BoolFlag{
+			Name:  "resume",
+			Usage: "resume an interrupted chunked export in the output directory, with the parameters recorded in its manifest",
+		},
+		&cli.BoolFlag{
+			Name:  "upload-ipfs",
+			Usage: "add the chunks to ipfs as they are written",
+		},
+		&cli.StringFlag{
+			Name:  "ipfs-api",
+			Usage: "multiaddress of the ipfs api to add the chunks to (defaults to the local ipfs node)",
+		},
+		&cli.StringFlag{
+			Name:  "upload-cmd",
+			Usage: "shell command run on every chunk written, with the chunk path in $CHUNK and its name in $CHUNK_NAME (e.g. 'aws s3 cp $CHUNK s3://bucket/snapshot/')",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
@@ -1079,6 +1125,23 @@ var ChainExportCmd = &cli.Command{
 			return fmt.Errorf("\"recent-stateroots\" has to be greater than %d", build.Finality)
 		}
 
+		skipold := cctx.Bool("skip-old-msgs")
+
+		if rsrs == 0 && skipold {
+			return fmt.Errorf("must pass recent stateroots along with skip-old-msgs")
+		}
+
+		if cctx.Bool("latest-state") && (cctx.IsSet("recent-stateroots") || skipold) {
+			return fmt.Errorf("latest state exports include the state roots and messages of the last finality, recent-stateroots and skip-old-msgs can't be set")
+		}
+
+		if cctx.IsSet("chunk-size") || cctx.Bool("resume") {
+			return chainExportChunked(ctx, cctx, api, rsrs)
+		}
+		if cctx.IsSet("upload-ipfs") || cctx.IsSet("upload-cmd") {
+			return fmt.Errorf("uploading the export requires --chunk-size")
+		}
+
 		fi, err := createExportFile(cctx.App, cctx.Args().First())
 		if err != nil {
 			return err
@@ -1095,32 +1158,25 @@ var ChainExportCmd = &cli.Command{
 			return err
 		}
 
-		skipold := cctx.Bool("skip-old-msgs")
-
-		if rsrs == 0 && skipold {
-			return fmt.Errorf("must pass recent stateroots along with skip-old-msgs")
+		var stream <-chan []byte
+		if cctx.Bool("latest-state") {
+			stream, err = api.ChainExportLatestState(ctx, abi.ChainEpoch(cctx.Int64("recent-headers")), ts.Key())
+		} else {
+			stream, err = api.ChainExport(ctx, rsrs, skipold, ts.Key())
 		}
-
-		stream, err := api.ChainExport(ctx, rsrs, skipold, ts.Key())
 		if err != nil {
 			return err
 		}
 
-		var last bool
-		for b := range stream {
-			last = len(b) == 0
-
-			_, err := fi.Write(b)
-			if err != nil {
-				return err
-			}
+		if !cctx.Bool("compress") {
+			return readExportStream(stream, fi)
 		}
 
-		if !last {
-			return xerrors.Errorf("incomplete export (remote connection lost?)")
+		zw := zstd.NewWriter(fi)
+		if err := readExportStream(stream, zw); err != nil {
+			return err
 		}
-
-		return nil
+		return zw.Close()
 	},
 }
 
@@ -1395,11 +1451,6 @@ var chainEncodeParamsCmd = &cli.Command{
 			return ShowHelp(cctx, fmt.Errorf("incorrect number of arguments"))
 		}
 
-		method, err := strconv.ParseInt(cctx.Args().Get(1), 10, 64)
-		if err != nil {
-			return xerrors.Errorf("parsing method id: %w", err)
-		}
-
 		ctx := ReqContext(cctx)
 
 		var p []byte
@@ -1415,11 +1466,21 @@ var chainEncodeParamsCmd = &cli.Command{
 				return xerrors.Errorf("parsing to addr: %w", err)
 			}
 
-			p, err = svc.DecodeTypedParamsFromJSON(ctx, to, abi.MethodNum(method), cctx.Args().Get(2))
+			method, err := ResolveMethod(ctx, svc.FullNodeAPI(), to, cctx.Args().Get(1))
+			if err != nil {
+				return xerrors.Errorf("resolving method: %w", err)
+			}
+
+			p, err = svc.DecodeTypedParamsFromJSON(ctx, to, method, cctx.Args().Get(2))
 			if err != nil {
 				return xerrors.Errorf("decoding json params: %w", err)
 			}
 		} else {
+			method, err := strconv.ParseInt(cctx.Args().Get(1), 10, 64)
+			if err != nil {
+				return xerrors.Errorf("parsing method id: %w", err)
+			}
+
 			api, done, err := GetFullNodeAPIV1(cctx)
 			if err != nil {
 				return err
@@ -1450,7 +1511,1005 @@ var chainEncodeParamsCmd = &cli.Command{
 	},
 }
 
-// createExportFile returns the export file handle from the app metadata, or creates a new file if it doesn't exist
+var ChainGCCmd = &cli.Command{
+	Name:  "gc",
+	Usage: "Manage online garbage collection of the chain blockstore",
+	Subcommands: []*cli.Command{
+		chainGCRunCmd,
+		chainGCStatusCmd,
+	},
+}
+
+var chainGCRunCmd = &cli.Command{
+	Name:  "run",
+	Usage: "Start an online garbage collection of the chain blockstore",
+	Description: `GC runs in the background on the node; limits which are not set are
+   taken from the Chainstore.BlockstoreGC section of the node config.`,
+	Flags: []cli.Flag{
+		&cli.DurationFlag{
+			Name:  "max-duration",
+			Usage: "stop the GC run after this amount of time",
+		},
+		&cli.DurationFlag{
+			Name:  "max-probe-latency",
+			Usage: "stop the GC run when a probe read from the blockstore takes longer than this",
+		},
+		&cli.Float64Flag{
+			Name:  "threshold",
+			Usage: "discard ratio above which a value log file is rewritten",
+		},
+		&cli.BoolFlag{
+			Name:  "wait",
+			Usage: "wait for the GC run to finish",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		err = api.ChainBlockstoreGC(ctx, lapi.BlockstoreGCOpts{
+			MaxDuration:     cctx.Duration("max-duration"),
+			MaxProbeLatency: cctx.Duration("max-probe-latency"),
+			Threshold:       cctx.Float64("threshold"),
+		})
+		if err != nil {
+			return err
+		}
+
+		if !cctx.Bool("wait") {
+			afmt.Println("GC started")
+			return nil
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(5 * time.Second):
+			}
+
+			st, err := api.ChainBlockstoreGCStatus(ctx)
+			if err != nil {
+				return err
+			}
+
+			if !st.Running {
+				printGCStatus(afmt, st)
+				return nil
+			}
+
+			afmt.Printf("running for %s, %d iterations\n", time.Since(st.Start).Truncate(time.Second), st.Iterations)
+		}
+	},
+}
+
+var chainGCStatusCmd = &cli.Command{
+	Name:  "status",
+	Usage: "Show the status of the current or last blockstore GC run",
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		st, err := api.ChainBlockstoreGCStatus(ctx)
+		if err != nil {
+			return err
+		}
+
+		printGCStatus(afmt, st)
+		return nil
+	},
+}
+
+func printGCStatus(afmt *AppFmt, st lapi.BlockstoreGCStatus) {
+	if st.Scheduled {
+		afmt.Printf("Next window: %s\n", st.NextWindow.Format(time.RFC3339))
+	} else {
+		afmt.Println("Scheduled GC: disabled")
+	}
+
+	if st.Start.IsZero() {
+		afmt.Println("No GC run since the node started")
+		return
+	}
+
+	if st.Running {
+		afmt.Printf("Running since: %s (%s)\n", st.Start.Format(time.RFC3339), time.Since(st.Start).Truncate(time.Second))
+	} else {
+		afmt.Printf("Last run: %s (took %s)\n", st.Start.Format(time.RFC3339), st.End.Sub(st.Start).Truncate(time.Second))
+	}
+	afmt.Printf("Iterations: %d\n", st.Iterations)
+
+	if st.SizeBefore > 0 {
+		afmt.Printf("Size before: %s\n", types.SizeStr(types.NewInt(uint64(st.SizeBefore))))
+		if !st.Running {
+			afmt.Printf("Size after: %s\n", types.SizeStr(types.NewInt(uint64(st.SizeAfter))))
+		}
+	}
+
+	if st.AbortReason != "" {
+		afmt.Printf("Stopped early: %s\n", st.AbortReason)
+	}
+	if st.Error != "" {
+		afmt.Printf("Error: %s\n", st.Error)
+	}
+}
+
+var ChainScrubCmd = &cli.Command{
+	Name:  "scrub",
+	Usage: "Verify the integrity of the blocks in the chain blockstore",
+	Subcommands: []*cli.Command{
+		chainScrubRunCmd,
+		chainScrubStatusCmd,
+		chainScrubQuarantineCmd,
+	},
+}
+
+var chainScrubRunCmd = &cli.Command{
+	Name:  "run",
+	Usage: "Start a scrub pass over the chain blockstore",
+	Description: `The scrub pass runs in the background on the node, verifying that the stored
+   blocks hash to their CIDs. Corrupt blocks are quarantined and, when enabled in the
+   Chainstore.Scrub section of the node config, re-fetched from the network.`,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "wait",
+			Usage: "wait for the scrub pass to finish",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		if err := api.ChainBlockstoreScrub(ctx); err != nil {
+			return err
+		}
+
+		if !cctx.Bool("wait") {
+			afmt.Println("Scrub started")
+			return nil
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(5 * time.Second):
+			}
+
+			st, err := api.ChainBlockstoreScrubStatus(ctx)
+			if err != nil {
+				return err
+			}
+
+			if !st.Running {
+				printScrubStatus(afmt, st)
+				return nil
+			}
+
+			afmt.Printf("running for %s, checked %d blocks (%s), %d corrupt\n",
+				time.Since(st.Start).Truncate(time.Second), st.Checked,
+				types.SizeStr(types.NewInt(uint64(st.CheckedBytes))), st.Corrupt)
+		}
+	},
+}
+
+var chainScrubStatusCmd = &cli.Command{
+	Name:  "status",
+	Usage: "Show the progress of the current or last scrub pass",
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		st, err := api.ChainBlockstoreScrubStatus(ctx)
+		if err != nil {
+			return err
+		}
+
+		printScrubStatus(afmt, st)
+		return nil
+	},
+}
+
+var chainScrubQuarantineCmd = &cli.Command{
+	Name:  "quarantine",
+	Usage: "List the quarantined corrupt blocks which couldn't be re-fetched yet",
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		cids, err := api.ChainBlockstoreQuarantine(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, c := range cids {
+			afmt.Println(c)
+		}
+		return nil
+	},
+}
+
+func printScrubStatus(afmt *AppFmt, st lapi.BlockstoreScrubStatus) {
+	if st.Periodic {
+		afmt.Printf("Next pass: %s\n", st.NextPass.Format(time.RFC3339))
+	} else {
+		afmt.Println("Periodic scrub: disabled")
+	}
+	afmt.Printf("Quarantined: %d\n", st.Quarantined)
+
+	if st.Start.IsZero() {
+		afmt.Println("No scrub pass since the node started")
+		return
+	}
+
+	if st.Running {
+		afmt.Printf("Running since: %s (%s)\n", st.Start.Format(time.RFC3339), time.Since(st.Start).Truncate(time.Second))
+	} else {
+		afmt.Printf("Last pass: %s (took %s)\n", st.Start.Format(time.RFC3339), st.End.Sub(st.Start).Truncate(time.Second))
+	}
+	afmt.Printf("Checked: %d blocks (%s)\n", st.Checked, types.SizeStr(types.NewInt(uint64(st.CheckedBytes))))
+	afmt.Printf("Corrupt: %d (%d re-fetched)\n", st.Corrupt, st.Refetched)
+
+	if st.Error != "" {
+		afmt.Printf("Error: %s\n", st.Error)
+	}
+}
+
+var ChainMountCmd = &cli.Command{
+	Name:  "mount",
+	Usage: "Mount CAR files as read-only overlays of the chain blockstore",
+	Description: `Mounted CAR files (e.g. exported snapshots) serve blocks missing from the
+   chain blockstore, without importing them. Mounts don't persist across node restarts.`,
+	Subcommands: []*cli.Command{
+		chainMountAddCmd,
+		chainMountRemoveCmd,
+		chainMountListCmd,
+	},
+}
+
+var chainMountAddCmd = &cli.Command{
+	Name:      "add",
+	Usage:     "Mount a CAR file",
+	ArgsUsage: "[name] [path to car file on the node]",
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		if cctx.NArg() != 2 {
+			return ShowHelp(cctx, fmt.Errorf("incorrect number of arguments"))
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		mnt, err := api.ChainBlockstoreMount(ctx, cctx.Args().Get(0), cctx.Args().Get(1))
+		if err != nil {
+			return err
+		}
+
+		afmt.Printf("Mounted %s (roots: %s)\n", mnt.Path, mnt.Roots)
+		return nil
+	},
+}
+
+var chainMountRemoveCmd = &cli.Command{
+	Name:      "remove",
+	Usage:     "Unmount a CAR file",
+	ArgsUsage: "[name]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return ShowHelp(cctx, fmt.Errorf("incorrect number of arguments"))
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		return api.ChainBlockstoreUnmount(ctx, cctx.Args().First())
+	},
+}
+
+var chainMountListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "List mounted CAR files",
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		mnts, err := api.ChainBlockstoreMounts(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, mnt := range mnts {
+			afmt.Printf("%s\t%s\t%s\t%s\n", mnt.Name, mnt.Path, mnt.MountedAt.Format(time.RFC3339), mnt.Roots)
+		}
+		return nil
+	},
+}
+
+var ChainSnapshotsCmd = &cli.Command{
+	Name:  "snapshots",
+	Usage: "Inspect the snapshots taken on schedule by the node",
+	Subcommands: []*cli.Command{
+		chainSnapshotsStatusCmd,
+	},
+}
+
+var chainSnapshotsStatusCmd = &cli.Command{
+	Name:  "status",
+	Usage: "Show the snapshot schedule, and the latest and retained snapshots",
+	Description: `Snapshots are taken when enabled in the Chainstore.Snapshots section of the
+   node config.`,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the status as json",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		st, err := api.ChainSnapshotStatus(ctx)
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			out, err := json.MarshalIndent(st, "", "  ")
+			if err != nil {
+				return err
+			}
+			afmt.Println(string(out))
+			return nil
+		}
+
+		afmt.Printf("Schedule: %s\n", st.Schedule)
+		if st.Running {
+			afmt.Println("Taking a snapshot")
+		} else if !st.NextRun.IsZero() {
+			afmt.Printf("Next snapshot: %s\n", st.NextRun.Format(time.RFC3339))
+		}
+		if st.LastError != "" {
+			afmt.Printf("Last error: %s\n", st.LastError)
+		}
+
+		if st.Latest == nil {
+			afmt.Println("No snapshots yet")
+			return nil
+		}
+
+		afmt.Printf("Latest: %s\n", st.Latest.Path)
+		if st.Latest.URL != "" {
+			afmt.Printf("URL: %s\n", st.Latest.URL)
+		}
+
+		afmt.Println()
+		tw := tablewriter.New(
+			tablewriter.Col("Height"),
+			tablewriter.Col("Created"),
+			tablewriter.Col("Took"),
+			tablewriter.Col("Size"),
+			tablewriter.Col("Path"),
+			tablewriter.NewLineCol("URL"))
+		for _, snap := range st.Snapshots {
+			row := map[string]interface{}{
+				"Height":  snap.Height,
+				"Created": snap.Created.Format(time.RFC3339),
+				"Took":    snap.Duration.Truncate(time.Second),
+				"Size":    types.SizeStr(types.NewInt(uint64(snap.Size))),
+				"Path":    snap.Path,
+			}
+			if snap.URL != "" {
+				row["URL"] = snap.URL
+			}
+			tw.Write(row)
+		}
+		return tw.Flush(cctx.App.Writer)
+	},
+}
+
+var ChainPropStatsCmd = &cli.Command{
+	Name:  "prop-stats",
+	Usage: "Report how late blocks arrived over pubsub relative to the start of their epoch",
+	Description: `Lists when the blocks of the recent epochs arrived, relative to the start of their
+   epoch, who they were first received from, how long they took to validate and to fetch
+   their messages, and whether they were orphaned. Blocks arriving after the propagation
+   delay, which miners wait for before mining on a tipset, are flagged as late.`,
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "epochs",
+			Usage: "number of recent epochs to report",
+			Value: 120,
+		},
+		&cli.DurationFlag{
+			Name:  "late",
+			Usage: "arrival delay after which a block is late",
+			Value: time.Duration(build.PropagationDelaySecs) * time.Second,
+		},
+		&cli.BoolFlag{
+			Name:  "summary",
+			Usage: "only print the summary",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the blocks as json",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		head, err := api.ChainHead(ctx)
+		if err != nil {
+			return err
+		}
+
+		blocks, err := api.ChainBlockPropagation(ctx, head.Height()-abi.ChainEpoch(cctx.Int64("epochs")))
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			out, err := json.MarshalIndent(blocks, "", "  ")
+			if err != nil {
+				return err
+			}
+			afmt.Println(string(out))
+			return nil
+		}
+
+		if len(blocks) == 0 {
+			afmt.Println("No blocks received")
+			return nil
+		}
+
+		late := cctx.Duration("late")
+
+		var delays, validations, fetches []time.Duration
+		var nlate, norphaned, nlateOrphaned int
+		firstPeers := make(map[peer.ID]int)
+		for _, b := range blocks {
+			delays = append(delays, b.Delay)
+			validations = append(validations, b.ValidationDuration)
+			if b.MessageFetchDuration > 0 {
+				fetches = append(fetches, b.MessageFetchDuration)
+			}
+			if b.Delay > late {
+				nlate++
+				if b.Orphaned {
+					nlateOrphaned++
+				}
+			}
+			if b.Orphaned {
+				norphaned++
+			}
+			if !b.Local {
+				firstPeers[b.FirstPeer]++
+			}
+		}
+
+		afmt.Printf("Blocks: %d, heights %d to %d\n", len(blocks), blocks[0].Height, blocks[len(blocks)-1].Height)
+		afmt.Printf("Orphaned: %d\n", norphaned)
+		afmt.Printf("Late (after %s): %d, %d of them orphaned\n", late, nlate, nlateOrphaned)
+		afmt.Printf("Arrival delay:  %s\n", durationPercentiles(delays))
+		afmt.Printf("Validation:     %s\n", durationPercentiles(validations))
+		afmt.Printf("Message fetch:  %s\n", durationPercentiles(fetches))
+
+		peers := make([]peer.ID, 0, len(firstPeers))
+		for p := range firstPeers {
+			peers = append(peers, p)
+		}
+		sort.Slice(peers, func(i, j int) bool {
+			return firstPeers[peers[i]] > firstPeers[peers[j]]
+		})
+		if len(peers) > 5 {
+			peers = peers[:5]
+		}
+		afmt.Println("First delivered by:")
+		for _, p := range peers {
+			afmt.Printf("  %s: %d\n", p, firstPeers[p])
+		}
+
+		if cctx.Bool("summary") {
+			return nil
+		}
+
+		afmt.Println()
+		tw := tablewriter.New(
+			tablewriter.Col("Height"),
+			tablewriter.Col("Miner"),
+			tablewriter.Col("Delay"),
+			tablewriter.Col("Validation"),
+			tablewriter.Col("Fetch"),
+			tablewriter.Col("Receipts"),
+			tablewriter.Col("First Peer"),
+			tablewriter.Col("Result"),
+			tablewriter.NewLineCol("Flags"))
+		for _, b := range blocks {
+			var flags []string
+			if b.Delay > late {
+				flags = append(flags, "late")
+			}
+			if b.Orphaned {
+				flags = append(flags, "orphaned")
+			}
+
+			firstPeer := b.FirstPeer.String()
+			if b.Local {
+				firstPeer = "local"
+			}
+
+			row := map[string]interface{}{
+				"Height":     b.Height,
+				"Miner":      b.Miner,
+				"Delay":      b.Delay.Truncate(time.Millisecond),
+				"Validation": b.ValidationDuration.Truncate(time.Millisecond),
+				"Fetch":      b.MessageFetchDuration.Truncate(time.Millisecond),
+				"Receipts":   b.Receipts,
+				"First Peer": firstPeer,
+				"Result":     b.Result,
+			}
+			if len(flags) > 0 {
+				row["Flags"] = strings.Join(flags, ",")
+			}
+			tw.Write(row)
+		}
+		return tw.Flush(cctx.App.Writer)
+	},
+}
+
+// durationPercentiles formats the median, 90th percentile and maximum of the
+// durations
+func durationPercentiles(ds []time.Duration) string {
+	if len(ds) == 0 {
+		return "-"
+	}
+
+	sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+	pct := func(p int) time.Duration {
+		return ds[(len(ds)-1)*p/100].Truncate(time.Millisecond)
+	}
+	return fmt.Sprintf("median %s, p90 %s, max %s", pct(50), pct(90), pct(100))
+}
+
+var ChainConsensusFaultsCmd = &cli.Command{
+	Name:  "consensus-faults",
+	Usage: "Review the consensus faults detected by the fault reporter",
+	Description: `The fault reporter scans incoming blocks for consensus faults when enabled in the
+   FaultReporter section of the node config. Detected faults are queued for review,
+   unless FaultReporter.AutoSubmit is set, and can be reported to the miner actor
+   from the configured reporter address, or dismissed.`,
+	Subcommands: []*cli.Command{
+		chainConsensusFaultsListCmd,
+		chainConsensusFaultsSubmitCmd,
+		chainConsensusFaultsDismissCmd,
+	},
+}
+
+var chainConsensusFaultsListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "List the pending consensus faults",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "all",
+			Usage: "also list the submitted and dismissed faults",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the faults, with their evidence, as json",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		faults, err := api.ChainConsensusFaults(ctx)
+		if err != nil {
+			return err
+		}
+
+		if !cctx.Bool("all") {
+			pending := faults[:0]
+			for _, f := range faults {
+				if f.Status == lapi.ConsensusFaultPending {
+					pending = append(pending, f)
+				}
+			}
+			faults = pending
+		}
+
+		if cctx.Bool("json") {
+			out, err := json.MarshalIndent(faults, "", "  ")
+			if err != nil {
+				return err
+			}
+			afmt.Println(string(out))
+			return nil
+		}
+
+		if len(faults) == 0 {
+			afmt.Println("No consensus faults")
+			return nil
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("Block"),
+			tablewriter.Col("Miner"),
+			tablewriter.Col("Epoch"),
+			tablewriter.Col("Type"),
+			tablewriter.Col("Detected"),
+			tablewriter.Col("Status"),
+			tablewriter.Col("Message"),
+			tablewriter.NewLineCol("Error"))
+		for _, f := range faults {
+			row := map[string]interface{}{
+				"Block":    f.Block,
+				"Miner":    f.Miner,
+				"Epoch":    f.Epoch,
+				"Type":     f.Type,
+				"Detected": f.Detected.Format(time.RFC3339),
+				"Status":   f.Status,
+			}
+			if f.Message != nil {
+				row["Message"] = *f.Message
+			}
+			if f.Error != "" {
+				row["Error"] = f.Error
+			}
+			tw.Write(row)
+		}
+		return tw.Flush(cctx.App.Writer)
+	},
+}
+
+var chainConsensusFaultsSubmitCmd = &cli.Command{
+	Name:      "submit",
+	Usage:     "Report a pending consensus fault to the miner actor",
+	ArgsUsage: "[blockCid]",
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		if cctx.NArg() != 1 {
+			return ShowHelp(cctx, fmt.Errorf("incorrect number of arguments"))
+		}
+
+		block, err := cid.Parse(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("parsing block cid: %w", err)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		mcid, err := api.ChainConsensusFaultSubmit(ctx, block)
+		if err != nil {
+			return err
+		}
+
+		afmt.Printf("Consensus fault reported in message %s\n", mcid)
+		return nil
+	},
+}
+
+var chainConsensusFaultsDismissCmd = &cli.Command{
+	Name:      "dismiss",
+	Usage:     "Dismiss a pending consensus fault",
+	ArgsUsage: "[blockCid]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return ShowHelp(cctx, fmt.Errorf("incorrect number of arguments"))
+		}
+
+		block, err := cid.Parse(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("parsing block cid: %w", err)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		return api.ChainConsensusFaultDismiss(ctx, block)
+	},
+}
+
+var ChainBackfillCmd = &cli.Command{
+	Name:  "backfill",
+	Usage: "Fetch a historical range of chain data from the network",
+	Description: `Fetches the selected data of the tipsets in the range [from, to] from the network
+   into the node's blockstore, restoring parts of the history on a pruned node without
+   importing a full snapshot. The block headers of the range must be present locally.
+
+   messages: the messages included in the tipsets, validated against the block headers
+   receipts: the message receipts referenced by the tipsets
+   state:    the state trees referenced by the tipsets`,
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:     "from",
+			Usage:    "lowest height of the range",
+			Required: true,
+		},
+		&cli.Int64Flag{
+			Name:     "to",
+			Usage:    "highest height of the range",
+			Required: true,
+		},
+		&cli.StringSliceFlag{
+			Name:  "include",
+			Usage: "kinds of data to fetch: messages, receipts, state",
+			Value: cli.NewStringSlice("messages"),
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		spec := lapi.BackfillSpec{
+			From: abi.ChainEpoch(cctx.Int64("from")),
+			To:   abi.ChainEpoch(cctx.Int64("to")),
+		}
+		if spec.From > spec.To {
+			return xerrors.Errorf("--from (%d) must not be above --to (%d)", spec.From, spec.To)
+		}
+
+		for _, inc := range cctx.StringSlice("include") {
+			for _, kind := range strings.Split(inc, ",") {
+				switch strings.TrimSpace(kind) {
+				case "messages":
+					spec.Messages = true
+				case "receipts":
+					spec.Receipts = true
+				case "state":
+					spec.State = true
+				default:
+					return xerrors.Errorf("unknown data kind %q, expected messages, receipts or state", kind)
+				}
+			}
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		progress, err := api.ChainBackfill(ctx, spec)
+		if err != nil {
+			return err
+		}
+
+		var lastPrint time.Time
+		for p := range progress {
+			if p.Done {
+				if p.Error != "" {
+					return xerrors.Errorf("backfill failed at height %d: %s", p.Height, p.Error)
+				}
+
+				afmt.Printf("Backfilled %d tipsets: messages of %d tipsets, %d receipt/state blocks (%s)\n",
+					p.Total, p.Messages, p.Objects, types.SizeStr(types.NewInt(uint64(p.Bytes))))
+				return nil
+			}
+
+			if time.Since(lastPrint) < 5*time.Second {
+				continue
+			}
+			lastPrint = time.Now()
+
+			afmt.Printf("%s: %d/%d tipsets (height %d), %d blocks (%s) fetched\n",
+				p.Stage, p.TipSets, p.Total, p.Height, p.Objects, types.SizeStr(types.NewInt(uint64(p.Bytes))))
+		}
+
+		return ctx.Err()
+	},
+}
+
+var ChainForksCmd = &cli.Command{
+	Name:  "forks",
+	Usage: "Show competing chain heads advertised by peers and recently orphaned tipsets",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		st, err := api.SyncForks(ctx)
+		if err != nil {
+			return err
+		}
+
+		afmt := NewAppFmt(cctx.App)
+		afmt.Printf("Head: %d %s\n", st.Height, st.Head)
+
+		afmt.Println("\nHeads:")
+		w := tabwriter.NewWriter(cctx.App.Writer, 8, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "  Height\tBlocks\tMiners\tPeers\tParent Weight\tWeight\tCanonical\tFirst Seen\tParents")
+		for _, h := range st.Heads {
+			weight := "-"
+			if !h.Weight.IsZero() {
+				weight = h.Weight.String()
+			}
+			fmt.Fprintf(w, "  %d\t%d\t%s\t%d\t%s\t%s\t%t\t%s\t%s\n",
+				h.Height, len(h.TipSet.Cids()), h.Miners, h.Peers, h.ParentWeight, weight,
+				h.Canonical, h.FirstSeen.Format(time.RFC3339), h.Parents)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		afmt.Println("\nOrphaned tipsets:")
+		w = tabwriter.NewWriter(cctx.App.Writer, 8, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "  Height\tMiners\tParent Weight\tOrphaned At\tTipSet")
+		for _, o := range st.Orphans {
+			fmt.Fprintf(w, "  %d\t%s\t%s\t%s\t%s\n",
+				o.Height, o.Miners, o.ParentWeight, o.OrphanedAt.Format(time.RFC3339), o.TipSet)
+		}
+		return w.Flush()
+	},
+}
+
+// createExportFile returns the export file handle from the app metadata, or creates a new file if it doesn't exist
+var ChainExportRangeCmd = &cli.Command{
+	Name:      "export-range",
+	Usage:     "export a range of epochs of the chain to a car file",
+	ArgsUsage: "<outputPath>",
+	Description: `All the block headers of the range are exported. The messages, message receipts
+   and state trees are exported for the number of epochs at the top of the range
+   given by the depth flags; -1 exports them for the whole range.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "tipset",
+			Usage: "specify the tipset of the chain to export the range from",
+			Value: "@head",
+		},
+		&cli.Int64Flag{
+			Name:     "from",
+			Usage:    "height of the bottom of the range",
+			Required: true,
+		},
+		&cli.Int64Flag{
+			Name:     "to",
+			Usage:    "height of the top of the range",
+			Required: true,
+		},
+		&cli.Int64Flag{
+			Name:  "messages-depth",
+			Usage: "number of epochs at the top of the range to export the messages of",
+			Value: -1,
+		},
+		&cli.Int64Flag{
+			Name:  "receipts-depth",
+			Usage: "number of epochs at the top of the range to export the message receipts of",
+			Value: -1,
+		},
+		&cli.Int64Flag{
+			Name:  "state-depth",
+			Usage: "number of epochs at the top of the range to export the state trees of",
+		},
+		&cli.BoolFlag{
+			Name:  "compress",
+			Usage: "compress the export with zstd",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must specify filename to export chain to")
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		ts, err := LoadTipSet(ctx, cctx, &v0api.WrapperV1Full{FullNode: api})
+		if err != nil {
+			return err
+		}
+
+		stream, err := api.ChainExportRange(ctx, abi.ChainEpoch(cctx.Int64("from")), abi.ChainEpoch(cctx.Int64("to")), ts.Key(), lapi.ChainExportRangeOpts{
+			MessagesDepth: abi.ChainEpoch(cctx.Int64("messages-depth")),
+			ReceiptsDepth: abi.ChainEpoch(cctx.Int64("receipts-depth")),
+			StateDepth:    abi.ChainEpoch(cctx.Int64("state-depth")),
+		})
+		if err != nil {
+			return err
+		}
+
+		fi, err := createExportFile(cctx.App, cctx.Args().First())
+		if err != nil {
+			return err
+		}
+		defer func() {
+			err := fi.Close()
+			if err != nil {
+				fmt.Printf("error closing output file: %+v", err)
+			}
+		}()
+
+		if !cctx.Bool("compress") {
+			return readExportStream(stream, fi)
+		}
+
+		zw := zstd.NewWriter(fi)
+		if err := readExportStream(stream, zw); err != nil {
+			return err
+		}
+		return zw.Close()
+	},
+}
+
 func createExportFile(app *cli.App, path string) (io.WriteCloser, error) {
 	if wc, ok := app.Metadata["export-file"]; ok {
 		return wc.(io.WriteCloser), nil
@@ -0,0 +1,580 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/DataDog/zstd"
+	"github.com/docker/go-units"
+	"github.com/ipfs/go-cid"
+	files "github.com/ipfs/go-ipfs-files"
+	httpapi "github.com/ipfs/go-ipfs-http-client"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api/v0api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+const (
+	ExportManifestName  = "manifest.json"
+	exportManifestV1    = 1
+	ExportCompressZstd  = "zstd"
+	exportPartialSuffix = ".part"
+)
+
+// ExportManifest describes a chain export split into chunks. The chunks are
+// consecutive pieces of a single CAR stream, so the CAR file is restored by
+// decompressing and concatenating them in order.
+type ExportManifest struct {
+	Version int
+
+	Head             types.TipSetKey
+	Height           abi.ChainEpoch
+	RecentStateRoots abi.ChainEpoch
+	SkipOldMsgs      bool
+	// RecentHeaders is set for latest state exports, which only include the
+	// block headers of the last RecentHeaders epochs.
+	RecentHeaders abi.ChainEpoch `json:",omitempty"`
+	// StateRoots lists the state trees included in the export.
+	StateRoots []ExportStateRoot
+
+	Compression string
+	ChunkSize   int64
+	Chunks      []ExportChunk
+
+	// Complete is set once the whole export has been written.
+	Complete bool
+}
+
+// ExportStateRoot is a state tree included in a chunked export.
+type ExportStateRoot struct {
+	Height abi.ChainEpoch
+	Root   cid.Cid
+}
+
+// ExportChunk is a chunk file of a chunked export.
+type ExportChunk struct {
+	Name string
+	// Offset and Size locate the chunk in the uncompressed CAR stream, and
+	// RawSHA256 is the hash of these bytes.
+	Offset    int64
+	Size      int64
+	RawSHA256 string
+	// FileSize and SHA256 describe the chunk file, as written to disk.
+	FileSize int64
+	SHA256   string
+
+	Uploaded bool   `json:",omitempty"`
+	IPFS     string `json:",omitempty"`
+}
+
+// ReadExportManifest reads the manifest of the chunked export in dir.
+func ReadExportManifest(dir string) (*ExportManifest, error) {
+	b, err := os.ReadFile(filepath.Join(dir, ExportManifestName))
+	if err != nil {
+		return nil, err
+	}
+
+	var m ExportManifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, xerrors.Errorf("decoding export manifest: %w", err)
+	}
+	if m.Version != exportManifestV1 {
+		return nil, xerrors.Errorf("unsupported export manifest version %d", m.Version)
+	}
+	return &m, nil
+}
+
+// write replaces the manifest in dir atomically, so that an interrupted export
+// always leaves a readable manifest behind.
+func (m *ExportManifest) write(dir string) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(dir, ExportManifestName+exportPartialSuffix)
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, ExportManifestName))
+}
+
+func (m *ExportManifest) chunkName(i int) string {
+	name := fmt.Sprintf("chunk-%05d.car", i)
+	if m.Compression == ExportCompressZstd {
+		name += ".zst"
+	}
+	return name
+}
+
+// exportUploader uploads a chunk file once it has been written, recording
+// where it was uploaded in the chunk.
+type exportUploader func(ctx context.Context, path string, chunk *ExportChunk) error
+
+// exportChunker splits a CAR stream into chunk files, keeping the manifest up
+// to date after every chunk. When resuming, the part of the stream already
+// written to chunks is checked against the manifest instead of being written.
+type exportChunker struct {
+	ctx    context.Context
+	dir    string
+	m      *ExportManifest
+	upload exportUploader
+
+	// pos is the position in the uncompressed stream, and resumed the length
+	// of the stream covered by chunks of an interrupted export.
+	pos     int64
+	resumed int64
+	verify  int
+	rawHash hash.Hash
+
+	cur      *os.File
+	curPath  string
+	zw       io.WriteCloser
+	fileHash hash.Hash
+	fileSize int64
+}
+
+func newExportChunker(ctx context.Context, dir string, m *ExportManifest, upload exportUploader) *exportChunker {
+	ec := &exportChunker{
+		ctx:     ctx,
+		dir:     dir,
+		m:       m,
+		upload:  upload,
+		rawHash: sha256.New(),
+	}
+	for _, c := range m.Chunks {
+		ec.resumed += c.Size
+	}
+	return ec
+}
+
+func (ec *exportChunker) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		var err error
+		var w int
+		if ec.pos < ec.resumed {
+			w, err = ec.skip(p)
+		} else {
+			w, err = ec.write(p)
+		}
+		if err != nil {
+			return 0, err
+		}
+		p = p[w:]
+	}
+	return n, nil
+}
+
+// skip checks the stream against the chunks of an interrupted export, the
+// export of a tipset must produce the same stream every time.
+func (ec *exportChunker) skip(p []byte) (int, error) {
+	c := ec.m.Chunks[ec.verify]
+	left := c.Offset + c.Size - ec.pos
+	if int64(len(p)) > left {
+		p = p[:left]
+	}
+
+	ec.rawHash.Write(p) //nolint:errcheck
+	ec.pos += int64(len(p))
+
+	if ec.pos == c.Offset+c.Size {
+		if hex.EncodeToString(ec.rawHash.Sum(nil)) != c.RawSHA256 {
+			return 0, xerrors.Errorf("exported data doesn't match chunk %s of the interrupted export", c.Name)
+		}
+		ec.rawHash.Reset()
+		ec.verify++
+	}
+	return len(p), nil
+}
+
+func (ec *exportChunker) write(p []byte) (int, error) {
+	if ec.cur == nil {
+		if err := ec.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	c := &ec.m.Chunks[len(ec.m.Chunks)-1]
+	if left := ec.m.ChunkSize - c.Size; int64(len(p)) > left {
+		p = p[:left]
+	}
+
+	if _, err := ec.zw.Write(p); err != nil {
+		return 0, xerrors.Errorf("writing chunk %s: %w", c.Name, err)
+	}
+	ec.rawHash.Write(p) //nolint:errcheck
+	ec.pos += int64(len(p))
+	c.Size += int64(len(p))
+
+	if c.Size == ec.m.ChunkSize {
+		if err := ec.finish(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (ec *exportChunker) open() error {
+	name := ec.m.chunkName(len(ec.m.Chunks))
+	ec.curPath = filepath.Join(ec.dir, name)
+
+	fi, err := os.Create(ec.curPath + exportPartialSuffix)
+	if err != nil {
+		return xerrors.Errorf("creating chunk file: %w", err)
+	}
+
+	ec.cur = fi
+	ec.fileHash = sha256.New()
+	ec.fileSize = 0
+	ec.rawHash.Reset()
+
+	w := io.MultiWriter(fi, ec.fileHash, (*countWriter)(&ec.fileSize))
+	if ec.m.Compression == ExportCompressZstd {
+		ec.zw = zstd.NewWriter(w)
+	} else {
+		ec.zw = nopWriteCloser{w}
+	}
+
+	ec.m.Chunks = append(ec.m.Chunks, ExportChunk{
+		Name:   name,
+		Offset: ec.pos,
+	})
+	return nil
+}
+
+// finish closes the current chunk file, records it in the manifest and
+// uploads it.
+func (ec *exportChunker) finish() error {
+	c := &ec.m.Chunks[len(ec.m.Chunks)-1]
+
+	if err := ec.zw.Close(); err != nil {
+		return xerrors.Errorf("finishing compression of chunk %s: %w", c.Name, err)
+	}
+	if err := ec.cur.Close(); err != nil {
+		return xerrors.Errorf("closing chunk %s: %w", c.Name, err)
+	}
+	ec.cur = nil
+
+	if err := os.Rename(ec.curPath+exportPartialSuffix, ec.curPath); err != nil {
+		return err
+	}
+
+	c.RawSHA256 = hex.EncodeToString(ec.rawHash.Sum(nil))
+	c.FileSize = ec.fileSize
+	c.SHA256 = hex.EncodeToString(ec.fileHash.Sum(nil))
+
+	if err := ec.m.write(ec.dir); err != nil {
+		return xerrors.Errorf("writing export manifest: %w", err)
+	}
+
+	fmt.Printf("wrote %s (%s, offset %d)\n", c.Name, units.BytesSize(float64(c.FileSize)), c.Offset)
+
+	if ec.upload == nil {
+		return nil
+	}
+	if err := ec.upload(ec.ctx, ec.curPath, c); err != nil {
+		return xerrors.Errorf("uploading chunk %s: %w", c.Name, err)
+	}
+	c.Uploaded = true
+	return ec.m.write(ec.dir)
+}
+
+// Close writes the last chunk, and marks the export as complete.
+func (ec *exportChunker) Close() error {
+	if ec.pos < ec.resumed {
+		return xerrors.Errorf("export ended before the chunks of the interrupted export")
+	}
+	if ec.cur != nil {
+		if err := ec.finish(); err != nil {
+			return err
+		}
+	}
+
+	ec.m.Complete = true
+	return ec.m.write(ec.dir)
+}
+
+// abort removes the chunk being written, the chunks already in the manifest
+// are kept so that the export can be resumed.
+func (ec *exportChunker) abort() {
+	if ec.cur == nil {
+		return
+	}
+	_ = ec.cur.Close()
+	_ = os.Remove(ec.curPath + exportPartialSuffix)
+	ec.m.Chunks = ec.m.Chunks[:len(ec.m.Chunks)-1]
+	ec.cur = nil
+}
+
+// checkExportChunks keeps the chunks of an interrupted export up to the first
+// one which is missing or doesn't match the manifest, and uploads the chunks
+// which weren't uploaded yet.
+func checkExportChunks(ctx context.Context, dir string, m *ExportManifest, upload exportUploader) error {
+	for i := range m.Chunks {
+		c := &m.Chunks[i]
+
+		ok, err := CheckExportChunk(filepath.Join(dir, c.Name), c)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Printf("chunk %s is missing or corrupted, resuming from offset %d\n", c.Name, c.Offset)
+			m.Chunks = m.Chunks[:i]
+			break
+		}
+
+		if upload != nil && !c.Uploaded {
+			if err := upload(ctx, filepath.Join(dir, c.Name), c); err != nil {
+				return xerrors.Errorf("uploading chunk %s: %w", c.Name, err)
+			}
+			c.Uploaded = true
+		}
+	}
+
+	return m.write(dir)
+}
+
+// CheckExportChunk checks that the chunk file at path is the one described in
+// the manifest. A missing file isn't an error.
+func CheckExportChunk(path string, c *ExportChunk) (bool, error) {
+	fi, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer fi.Close() //nolint:errcheck
+
+	h := sha256.New()
+	n, err := io.Copy(h, fi)
+	if err != nil {
+		return false, xerrors.Errorf("reading chunk %s: %w", c.Name, err)
+	}
+	return n == c.FileSize && hex.EncodeToString(h.Sum(nil)) == c.SHA256, nil
+}
+
+// exportStateRoots lists the state roots included in the export of a tipset,
+// following the rules of ChainStore.WalkSnapshot. Only full exports include the
+// genesis state.
+func exportStateRoots(ctx context.Context, api v0api.FullNode, ts *types.TipSet, nroots abi.ChainEpoch, genesis bool) ([]ExportStateRoot, error) {
+	var roots []ExportStateRoot
+	for cur := ts; cur.Height() > 0 && cur.Height() > ts.Height()-nroots; {
+		roots = append(roots, ExportStateRoot{Height: cur.Height(), Root: cur.ParentState()})
+
+		next, err := api.ChainGetTipSet(ctx, cur.Parents())
+		if err != nil {
+			return nil, xerrors.Errorf("loading tipset %s: %w", cur.Parents(), err)
+		}
+		cur = next
+	}
+
+	if !genesis {
+		return roots, nil
+	}
+
+	gen, err := api.ChainGetGenesis(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("loading genesis: %w", err)
+	}
+	return append(roots, ExportStateRoot{Height: 0, Root: gen.ParentState()}), nil
+}
+
+func exportUploaders(cctx *cli.Context) ([]exportUploader, error) {
+	var uploaders []exportUploader
+
+	if cctx.Bool("upload-ipfs") {
+		var ipfs *httpapi.HttpApi
+		var err error
+		if cctx.IsSet("ipfs-api") {
+			var maddr multiaddr.Multiaddr
+			maddr, err = multiaddr.NewMultiaddr(cctx.String("ipfs-api"))
+			if err != nil {
+				return nil, xerrors.Errorf("parsing ipfs api address: %w", err)
+			}
+			ipfs, err = httpapi.NewApi(maddr)
+		} else {
+			ipfs, err = httpapi.NewLocalApi()
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("getting ipfs api: %w", err)
+		}
+
+		uploaders = append(uploaders, func(ctx context.Context, path string, c *ExportChunk) error {
+			fi, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer fi.Close() //nolint:errcheck
+
+			p, err := ipfs.Unixfs().Add(ctx, files.NewReaderFile(fi))
+			if err != nil {
+				return xerrors.Errorf("adding to ipfs: %w", err)
+			}
+			c.IPFS = p.Cid().String()
+			fmt.Printf("added %s to ipfs: %s\n", c.Name, c.IPFS)
+			return nil
+		})
+	}
+
+	if cmd := cctx.String("upload-cmd"); cmd != "" {
+		uploaders = append(uploaders, func(ctx context.Context, path string, c *ExportChunk) error {
+			sh := exec.CommandContext(ctx, "sh", "-c", cmd)
+			sh.Env = append(os.Environ(), "CHUNK="+path, "CHUNK_NAME="+c.Name)
+			sh.Stdout = os.Stdout
+			sh.Stderr = os.Stderr
+			return sh.Run()
+		})
+	}
+
+	return uploaders, nil
+}
+
+func chainExportChunked(ctx context.Context, cctx *cli.Context, api v0api.FullNode, nroots abi.ChainEpoch) error {
+	dir := cctx.Args().First()
+
+	uploaders, err := exportUploaders(cctx)
+	if err != nil {
+		return err
+	}
+	var upload exportUploader
+	if len(uploaders) > 0 {
+		upload = func(ctx context.Context, path string, c *ExportChunk) error {
+			for _, u := range uploaders {
+				if err := u(ctx, path, c); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	var m *ExportManifest
+	if cctx.Bool("resume") {
+		m, err = ReadExportManifest(dir)
+		if err != nil {
+			return xerrors.Errorf("reading manifest of the interrupted export: %w", err)
+		}
+		if m.Complete {
+			return xerrors.Errorf("export in %s is already complete", dir)
+		}
+		if err := checkExportChunks(ctx, dir, m, upload); err != nil {
+			return err
+		}
+	} else {
+		chunkSize, err := units.RAMInBytes(cctx.String("chunk-size"))
+		if err != nil {
+			return xerrors.Errorf("parsing chunk size: %w", err)
+		}
+		if chunkSize <= 0 {
+			return xerrors.Errorf("chunk size must be positive")
+		}
+
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		if _, err := os.Stat(filepath.Join(dir, ExportManifestName)); err == nil {
+			return xerrors.Errorf("%s already contains an export, pass --resume to resume it", dir)
+		}
+
+		ts, err := LoadTipSet(ctx, cctx, api)
+		if err != nil {
+			return err
+		}
+		m = &ExportManifest{
+			Version:          exportManifestV1,
+			Head:             ts.Key(),
+			Height:           ts.Height(),
+			RecentStateRoots: nroots,
+			SkipOldMsgs:      cctx.Bool("skip-old-msgs"),
+			ChunkSize:        chunkSize,
+		}
+		if cctx.Bool("latest-state") {
+			m.RecentHeaders = abi.ChainEpoch(cctx.Int64("recent-headers"))
+			m.RecentStateRoots = build.Finality
+			m.SkipOldMsgs = true
+		}
+
+		m.StateRoots, err = exportStateRoots(ctx, api, ts, m.RecentStateRoots, m.RecentHeaders == 0)
+		if err != nil {
+			return err
+		}
+		if cctx.Bool("compress") {
+			m.Compression = ExportCompressZstd
+		}
+		if err := m.write(dir); err != nil {
+			return xerrors.Errorf("writing export manifest: %w", err)
+		}
+	}
+
+	var stream <-chan []byte
+	if m.RecentHeaders > 0 {
+		stream, err = api.ChainExportLatestState(ctx, m.RecentHeaders, m.Head)
+	} else {
+		stream, err = api.ChainExport(ctx, m.RecentStateRoots, m.SkipOldMsgs, m.Head)
+	}
+	if err != nil {
+		return err
+	}
+
+	ec := newExportChunker(ctx, dir, m, upload)
+	if err := readExportStream(stream, ec); err != nil {
+		ec.abort()
+		return err
+	}
+	if err := ec.Close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("exported chain at height %d to %d chunks in %s\n", m.Height, len(m.Chunks), dir)
+	return nil
+}
+
+// readExportStream copies an export stream, which ends with an empty slice.
+func readExportStream(stream <-chan []byte, w io.Writer) error {
+	var last bool
+	for b := range stream {
+		last = len(b) == 0
+
+		_, err := w.Write(b)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !last {
+		return xerrors.Errorf("incomplete export (remote connection lost?)")
+	}
+
+	return nil
+}
+
+type countWriter int64
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	*c += countWriter(len(p))
+	return len(p), nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
@@ -6,13 +6,18 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
 
+	"github.com/DataDog/zstd"
 	"github.com/golang/mock/gomock"
 	"github.com/ipfs/go-cid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/big"
@@ -523,6 +528,86 @@ func TestChainExport(t *testing.T) {
 	assert.Equal(t, expBytes, mockFile.Bytes())
 }
 
+func TestChainExportChunked(t *testing.T) {
+	app, mockApi, _, done := NewMockAppWithFullAPI(t, WithCategory("chain", ChainExportCmd))
+	defer done()
+
+	ts := mock.TipSet(mock.MkBlock(nil, 0, 0))
+	dir := t.TempDir()
+
+	exportStream := func(parts ...string) <-chan []byte {
+		export := make(chan []byte, len(parts)+1)
+		for _, p := range parts {
+			export <- []byte(p)
+		}
+		export <- []byte{}
+		close(export)
+		return export
+	}
+
+	// reads back the car stream from the chunks listed in the manifest
+	readChunks := func() (*ExportManifest, string) {
+		m, err := ReadExportManifest(dir)
+		require.NoError(t, err)
+
+		var out bytes.Buffer
+		for _, c := range m.Chunks {
+			fi, err := os.Open(filepath.Join(dir, c.Name))
+			require.NoError(t, err)
+			_, err = io.Copy(&out, zstd.NewReader(fi))
+			require.NoError(t, err)
+			require.NoError(t, fi.Close())
+		}
+		return m, out.String()
+	}
+
+	gomock.InOrder(
+		mockApi.EXPECT().ChainHead(gomock.Any()).Return(ts, nil),
+		mockApi.EXPECT().ChainGetGenesis(gomock.Any()).Return(ts, nil),
+		mockApi.EXPECT().ChainExport(gomock.Any(), abi.ChainEpoch(0), false, ts.Key()).Return(exportStream("012", "3456", "789"), nil),
+	)
+
+	//stm: @CLI_CHAIN_EXPORT_001
+	err := app.Run([]string{"chain", "export", "--compress", "--chunk-size", "4", dir})
+	require.NoError(t, err)
+
+	m, data := readChunks()
+	assert.Equal(t, "0123456789", data)
+	assert.True(t, m.Complete)
+	assert.Equal(t, ts.Key(), m.Head)
+	assert.Equal(t, []ExportStateRoot{{Height: 0, Root: ts.ParentState()}}, m.StateRoots)
+	require.Len(t, m.Chunks, 3)
+	assert.Equal(t, int64(8), m.Chunks[2].Offset)
+	assert.Equal(t, int64(2), m.Chunks[2].Size)
+
+	// interrupt the export by dropping the last chunk, and corrupt the second
+	// one, which has to be written again
+	require.NoError(t, os.WriteFile(filepath.Join(dir, m.Chunks[1].Name), []byte("garbage"), 0644))
+	require.NoError(t, os.Remove(filepath.Join(dir, m.Chunks[2].Name)))
+	m.Complete = false
+	require.NoError(t, m.write(dir))
+
+	mockApi.EXPECT().ChainExport(gomock.Any(), abi.ChainEpoch(0), false, ts.Key()).Return(exportStream("0123456789"), nil)
+
+	err = app.Run([]string{"chain", "export", "--resume", dir})
+	require.NoError(t, err)
+
+	m, data = readChunks()
+	assert.Equal(t, "0123456789", data)
+	assert.True(t, m.Complete)
+	assert.Len(t, m.Chunks, 3)
+
+	// resuming fails when the export doesn't produce the same data again
+	m.Chunks = m.Chunks[:1]
+	m.Complete = false
+	require.NoError(t, m.write(dir))
+
+	mockApi.EXPECT().ChainExport(gomock.Any(), abi.ChainEpoch(0), false, ts.Key()).Return(exportStream("abcdefghij"), nil)
+
+	err = app.Run([]string{"chain", "export", "--resume", dir})
+	assert.Error(t, err)
+}
+
 func TestChainGasPrice(t *testing.T) {
 	app, mockApi, buf, done := NewMockAppWithFullAPI(t, WithCategory("chain", ChainGasPriceCmd))
 	defer done()
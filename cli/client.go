@@ -85,6 +85,7 @@ var clientCmd = &cli.Command{
 		WithCategory("storage", clientListAsksCmd),
 		WithCategory("storage", clientDealStatsCmd),
 		WithCategory("storage", clientInspectDealCmd),
+		WithCategory("storage", clientDealHealthCmd),
 		WithCategory("data", clientImportCmd),
 		WithCategory("data", clientDropCmd),
 		WithCategory("data", clientLocalCmd),
@@ -1319,6 +1320,53 @@ var clientInspectDealCmd = &cli.Command{
 	},
 }
 
+var clientDealHealthCmd = &cli.Command{
+	Name:  "deal-health",
+	Usage: "Print the health of the sectors holding the active storage deals, and the replacement deals proposed for the failed ones",
+	Description: `The health of the deals is checked by the deal health monitor, which is enabled in the
+   Client.DealRepair section of the node config.`,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "failed",
+			Usage: "only print the deals which aren't active",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		health, err := api.ClientDealHealth(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(cctx.App.Writer, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "DealID\tProvider\tSector\tStatus\tFaulty Since\tReplacement\tError\n")
+		for _, h := range health {
+			if cctx.Bool("failed") && h.Status == lapi.DealHealthActive {
+				continue
+			}
+
+			faultySince := ""
+			if !h.FaultySince.IsZero() {
+				faultySince = h.FaultySince.Format(time.Stamp)
+			}
+			replacement := ""
+			if h.Replacement != nil {
+				replacement = fmt.Sprintf("%s (%s)", h.Replacement.Provider, h.Replacement.ProposalCid)
+			}
+
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\t%s\t%s\n",
+				h.DealID, h.Provider, h.Sector, h.Status, faultySince, replacement, h.Error)
+		}
+		return w.Flush()
+	},
+}
+
 var clientDealStatsCmd = &cli.Command{
 	Name:  "deal-stats",
 	Usage: "Print statistics about local storage deals",
@@ -55,6 +55,7 @@ var GetGatewayAPI = cliutil.GetGatewayAPI
 
 var GetStorageMinerAPI = cliutil.GetStorageMinerAPI
 var GetMarketsAPI = cliutil.GetMarketsAPI
+var GetProvingAPI = cliutil.GetProvingAPI
 var GetWorkerAPI = cliutil.GetWorkerAPI
 
 var CommonCommands = []*cli.Command{
@@ -83,6 +84,8 @@ var Commands = []*cli.Command{
 	WithCategory("network", NetCmd),
 	WithCategory("network", SyncCmd),
 	WithCategory("status", StatusCmd),
+	WithCategory("status", HealthCmd),
+	WithCategory("status", watchlistCmd),
 	PprofCmd,
 	VersionCmd,
 }
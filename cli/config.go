@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+var ConfigReloadCmd = &cli.Command{
+	Name:  "reload",
+	Usage: "Reload the config of the running node",
+	Description: `Reloads the config file of the node, and applies the changed settings which
+   don't require a restart: the logging config, and the fees, addresses, deal
+   filters and deal acceptance settings of miners. Nothing is applied when a
+   setting which requires a restart changed.
+
+   Sending SIGHUP to the node reloads the config as well.`,
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		res, err := api.ConfigReload(ctx)
+		if err != nil {
+			return err
+		}
+
+		afmt := NewAppFmt(cctx.App)
+		if !res.Applied {
+			afmt.Println("Config not reloaded, these settings require a restart:")
+			for _, c := range res.Rejected {
+				afmt.Printf("  %s: %s -> %s\n", c.Key, c.Old, c.New)
+			}
+			return xerrors.Errorf("%d changed settings require a restart", len(res.Rejected))
+		}
+
+		if len(res.Changes) == 0 {
+			afmt.Println("Config reloaded, no settings changed")
+			return nil
+		}
+
+		afmt.Printf("Config reloaded, %d settings changed:\n", len(res.Changes))
+		for _, c := range res.Changes {
+			afmt.Printf("  %s: %s -> %s\n", c.Key, c.Old, c.New)
+		}
+		return nil
+	},
+}
+
+func ConfigValidateCmd(repoFlag string, rt repo.RepoType) *cli.Command {
+	return &cli.Command{
+		Name:      "validate",
+		Usage:     "Check a config file for unknown, deprecated and inconsistent settings",
+		ArgsUsage: "[config file]",
+		Description: `Checks the config file of the repo, or the given config file, without
+   starting the node. Unknown and deprecated fields are reported as warnings;
+   settings the node would fail on, or which are inconsistent with each other,
+   are reported as errors.
+
+   With --migrate, the renamed fields are given their new names and the removed
+   fields are commented out, in place; the rest of the file is left untouched.`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "migrate",
+				Usage: "rewrite the deprecated fields of the config file",
+			},
+		},
+		Action: func(cctx *cli.Context) error {
+			if cctx.NArg() > 1 {
+				return ShowHelp(cctx, xerrors.Errorf("expected at most 1 argument"))
+			}
+
+			var path string
+			if cctx.Args().Present() {
+				p, err := homedir.Expand(cctx.Args().First())
+				if err != nil {
+					return xerrors.Errorf("expanding file path: %w", err)
+				}
+				path = p
+			} else {
+				r, err := repo.NewFS(cctx.String(repoFlag))
+				if err != nil {
+					return err
+				}
+				path = r.ConfigPath()
+			}
+
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				return xerrors.Errorf("reading config: %w", err)
+			}
+
+			afmt := NewAppFmt(cctx.App)
+
+			if cctx.Bool("migrate") {
+				migrated, applied, err := config.MigrateConfig(b)
+				if err != nil {
+					return xerrors.Errorf("migrating config: %w", err)
+				}
+
+				if len(applied) > 0 {
+					fi, err := os.Stat(path)
+					if err != nil {
+						return err
+					}
+					if err := ioutil.WriteFile(path, migrated, fi.Mode()); err != nil {
+						return xerrors.Errorf("writing config: %w", err)
+					}
+				}
+				for _, m := range applied {
+					afmt.Printf("migrated: %s\n", m)
+				}
+				b = migrated
+			}
+
+			issues, err := config.ValidateConfig(b, rt.Config())
+			if err != nil {
+				return err
+			}
+
+			var errs int
+			for _, issue := range issues {
+				kind := "warning"
+				if issue.Error {
+					kind = "error"
+					errs++
+				}
+				afmt.Printf("%s: %s\n", kind, issue)
+			}
+
+			if errs > 0 {
+				return xerrors.Errorf("%d errors in %s", errs, path)
+			}
+			if len(issues) == 0 {
+				afmt.Printf("%s is valid\n", path)
+			}
+			return nil
+		},
+	}
+}
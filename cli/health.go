@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var HealthCmd = &cli.Command{
+	Name:  "health",
+	Usage: "Check the health of the node subsystems",
+	Description: `Prints the health of the sync, message pool, blockstore and peers of the node,
+   with an overall OK, WARN or CRIT level.
+
+   The command fails when the level of the node is CRIT, or with --fail-on=warn
+   when it is WARN or CRIT, so that it can be used as a health probe.`,
+	Flags: HealthFlags,
+	Action: func(cctx *cli.Context) error {
+		apic, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		h, err := apic.NodeHealth(ctx)
+		if err != nil {
+			return err
+		}
+
+		return PrintNodeHealth(cctx, h)
+	},
+}
+
+// HealthFlags are the flags of the health commands
+var HealthFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "fail-on",
+		Usage: "level at which the command fails: warn, crit or none",
+		Value: "crit",
+	},
+	&cli.BoolFlag{
+		Name:  "json",
+		Usage: "print the health as json",
+	},
+}
+
+// PrintNodeHealth prints the health of a node, and fails when its level
+// reaches the --fail-on level
+func PrintNodeHealth(cctx *cli.Context, h api.NodeHealth) error {
+	var failOn api.HealthLevel
+	switch cctx.String("fail-on") {
+	case "warn":
+		failOn = api.HealthWarn
+	case "crit":
+		failOn = api.HealthCrit
+	case "none":
+	default:
+		return xerrors.Errorf("unknown --fail-on level %q", cctx.String("fail-on"))
+	}
+
+	if cctx.Bool("json") {
+		out, err := json.MarshalIndent(h, "", "  ")
+		if err != nil {
+			return err
+		}
+		NewAppFmt(cctx.App).Println(string(out))
+	} else {
+		printHealth(cctx.App.Writer, h)
+	}
+
+	if failOn != "" && h.Level.Worse(failOn) == h.Level {
+		return xerrors.Errorf("node health is %s", h.Level)
+	}
+	return nil
+}
+
+func printHealth(w io.Writer, h api.NodeHealth) {
+	_, _ = fmt.Fprintf(w, "Health: %s\n\n", healthLevelStr(h.Level))
+
+	tw := tabwriter.NewWriter(w, 2, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "Subsystem\tLevel\tStatus\tMessage\n")
+	row := func(name string, s api.HealthStatus, status string) {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", name, s.Level, status, s.Message)
+	}
+
+	if h.Sync != nil {
+		row("sync", h.Sync.HealthStatus, fmt.Sprintf("height %d, %d epochs behind", h.Sync.Height, h.Sync.LagEpochs))
+	}
+	if h.Mpool != nil {
+		row("mpool", h.Mpool.HealthStatus, fmt.Sprintf("%d local pending, %d stuck, oldest %s", h.Mpool.LocalPending, h.Mpool.StuckLocal, h.Mpool.OldestLocal.Round(time.Second)))
+	}
+	if h.Blockstore != nil {
+		lastGC := "never"
+		if !h.Blockstore.LastGC.IsZero() {
+			lastGC = h.Blockstore.LastGC.Format(time.RFC3339)
+		}
+		row("blockstore", h.Blockstore.HealthStatus, fmt.Sprintf("%s of %s available, last GC %s", types.SizeStr(types.NewInt(uint64(h.Blockstore.Available))), types.SizeStr(types.NewInt(uint64(h.Blockstore.Capacity))), lastGC))
+	}
+	if h.Peers != nil {
+		row("peers", h.Peers.HealthStatus, fmt.Sprintf("%d connected", h.Peers.Connected))
+	}
+	if h.Workers != nil {
+		row("workers", h.Workers.HealthStatus, fmt.Sprintf("%d workers, %d disabled", h.Workers.Workers, h.Workers.Disabled))
+	}
+	if h.Deadline != nil {
+		row("deadline", h.Deadline.HealthStatus, fmt.Sprintf("deadline %d (%d-%d), %d/%d partitions proven, %d faulty sectors", h.Deadline.Index, h.Deadline.Open, h.Deadline.Close, h.Deadline.Proven, h.Deadline.Partitions, h.Deadline.FaultySectors))
+	}
+	_ = tw.Flush()
+}
+
+func healthLevelStr(l api.HealthLevel) string {
+	switch l {
+	case api.HealthOK:
+		return color.GreenString(string(l))
+	case api.HealthWarn:
+		return color.YellowString(string(l))
+	default:
+		return color.RedString(string(l))
+	}
+}
@@ -0,0 +1,50 @@
+//stm: #unit
+package cli
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+func TestHealth(t *testing.T) {
+	health := api.NodeHealth{
+		Sync: &api.SyncHealth{
+			HealthStatus: api.HealthStatus{Level: api.HealthOK},
+			Height:       1000,
+		},
+		Peers: &api.PeersHealth{
+			HealthStatus: api.HealthStatus{Level: api.HealthWarn, Message: "only 2 connected peers"},
+			Connected:    2,
+		},
+	}
+	health.Compute()
+	assert.Equal(t, api.HealthWarn, health.Level)
+
+	t.Run("warn", func(t *testing.T) {
+		app, mockApi, buf, done := NewMockAppWithFullAPI(t, WithCategory("status", HealthCmd))
+		defer done()
+
+		mockApi.EXPECT().NodeHealth(gomock.Any()).Return(health, nil)
+
+		err := app.Run([]string{"lotus", "health"})
+		assert.NoError(t, err)
+
+		out := buf.String()
+		assert.Contains(t, out, "height 1000")
+		assert.Contains(t, out, "only 2 connected peers")
+	})
+
+	t.Run("fail-on-warn", func(t *testing.T) {
+		app, mockApi, _, done := NewMockAppWithFullAPI(t, WithCategory("status", HealthCmd))
+		defer done()
+
+		mockApi.EXPECT().NodeHealth(gomock.Any()).Return(health, nil)
+
+		err := app.Run([]string{"lotus", "health", "--fail-on=warn"})
+		assert.Error(t, err)
+	})
+}
@@ -1,7 +1,9 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/fatih/color"
@@ -15,6 +17,7 @@ var LogCmd = &cli.Command{
 	Subcommands: []*cli.Command{
 		LogList,
 		LogSetLevel,
+		LogTail,
 		LogAlerts,
 	},
 }
@@ -72,6 +75,10 @@ var LogSetLevel = &cli.Command{
 			Usage: "limit to log system",
 			Value: &cli.StringSlice{},
 		},
+		&cli.BoolFlag{
+			Name:  "persist",
+			Usage: "save the level in the node config, so that it applies after restarts",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetAPI(cctx)
@@ -86,6 +93,19 @@ var LogSetLevel = &cli.Command{
 		}
 
 		systems := cctx.StringSlice("system")
+		if cctx.Bool("persist") {
+			if len(systems) == 0 {
+				return xerrors.Errorf("--persist requires the systems to be set with --system")
+			}
+
+			for _, system := range systems {
+				if err := api.LogPersistLevel(ctx, system, cctx.Args().First()); err != nil {
+					return xerrors.Errorf("setting log level on %s: %v", system, err)
+				}
+			}
+			return nil
+		}
+
 		if len(systems) == 0 {
 			var err error
 			systems, err = api.LogList(ctx)
@@ -104,6 +124,76 @@ var LogSetLevel = &cli.Command{
 	},
 }
 
+var LogTail = &cli.Command{
+	Name:  "tail",
+	Usage: "Stream the log entries of the node",
+	Description: `Streams the log entries of a subsystem of the node, or of all subsystems.
+
+   Entries are only logged at the levels enabled for their subsystem, so --level
+   also sets the level of the subsystem when --subsystem is set. Use log set-level
+   to restore it afterwards.
+
+   eg) log tail --subsystem chainstore --level debug`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "subsystem",
+			Usage: "only stream the entries of this log system",
+		},
+		&cli.StringFlag{
+			Name:  "level",
+			Usage: "only stream the entries at or above this level",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the entries as json",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		subsystem, level := cctx.String("subsystem"), cctx.String("level")
+		if subsystem != "" && level != "" {
+			if err := api.LogSetLevel(ctx, subsystem, level); err != nil {
+				return xerrors.Errorf("setting log level on %s: %w", subsystem, err)
+			}
+		}
+
+		entries, err := api.LogTail(ctx, subsystem, level)
+		if err != nil {
+			return err
+		}
+
+		afmt := NewAppFmt(cctx.App)
+		for e := range entries {
+			if cctx.Bool("json") {
+				out, err := json.Marshal(e)
+				if err != nil {
+					return err
+				}
+				afmt.Println(string(out))
+				continue
+			}
+
+			var fields string
+			if len(e.Fields) > 0 {
+				out, err := json.Marshal(e.Fields)
+				if err != nil {
+					return err
+				}
+				fields = " " + string(out)
+			}
+			afmt.Printf("%s\t%s\t%s\t%s\t%s%s\n", e.Time.Format("2006-01-02T15:04:05.000Z0700"), strings.ToUpper(e.Level), e.Subsystem, e.Caller, e.Message, fields)
+		}
+
+		return nil
+	},
+}
+
 var LogAlerts = &cli.Command{
 	Name:  "alerts",
 	Usage: "Get alert states",
@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+// RepoMigrateCmd manages the migrations of the repo, which are otherwise
+// applied when the node starts
+func RepoMigrateCmd(repoFlag string, rt repo.RepoType) *cli.Command {
+	lockRepo := func(cctx *cli.Context, readonly bool) (repo.LockedRepo, error) {
+		r, err := repo.NewFS(cctx.String(repoFlag))
+		if err != nil {
+			return nil, err
+		}
+
+		ok, err := r.Exists()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, xerrors.Errorf("repo at '%s' is not initialized", cctx.String(repoFlag))
+		}
+
+		var lr repo.LockedRepo
+		if readonly {
+			lr, err = r.LockRO(rt)
+		} else {
+			lr, err = r.Lock(rt)
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("locking repo: %w", err)
+		}
+		return lr, nil
+	}
+
+	dryRunFlag := &cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "only print the changes, without making them",
+	}
+
+	return &cli.Command{
+		Name:  "migrate",
+		Usage: "Manage the migrations of the repo",
+		Description: `Migrations change the layout of the repo (datastores, config, keystore)
+   between versions. Pending migrations are applied when the node starts; these
+   commands work on the repo of a node which isn't running.`,
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "List the applied and the pending migrations",
+				Action: func(cctx *cli.Context) error {
+					lr, err := lockRepo(cctx, true)
+					if err != nil {
+						return err
+					}
+					defer lr.Close() // nolint:errcheck
+
+					st, err := repo.Migrations(cctx.Context, lr)
+					if err != nil {
+						return err
+					}
+
+					afmt := NewAppFmt(cctx.App)
+
+					for _, m := range st.Applied {
+						afmt.Printf("%d\t%s\tapplied %s\n", m.Version, m.Name, m.AppliedAt.Format("2006-01-02 15:04:05"))
+						printMigrationChanges(afmt, m.Changes)
+					}
+					for _, m := range st.Pending {
+						afmt.Printf("%d\t%s\tpending\n", m.Version, m.Name)
+						printMigrationChanges(afmt, m.Changes)
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "apply",
+				Usage: "Apply the pending migrations",
+				Flags: []cli.Flag{dryRunFlag},
+				Action: func(cctx *cli.Context) error {
+					dryRun := cctx.Bool("dry-run")
+
+					lr, err := lockRepo(cctx, dryRun)
+					if err != nil {
+						return err
+					}
+					defer lr.Close() // nolint:errcheck
+
+					reports, err := repo.Migrate(cctx.Context, lr, dryRun)
+
+					afmt := NewAppFmt(cctx.App)
+					verb := "applied"
+					if dryRun {
+						verb = "would apply"
+					}
+					for _, m := range reports {
+						afmt.Printf("%s %d (%s)\n", verb, m.Version, m.Name)
+						printMigrationChanges(afmt, m.Changes)
+					}
+					if err != nil {
+						return err
+					}
+					if len(reports) == 0 {
+						afmt.Println("no changes to make")
+					}
+					return nil
+				},
+			},
+			{
+				Name:  "rollback",
+				Usage: "Revert the most recently applied migration",
+				Description: `The migration is applied again by 'migrate apply', or when the node
+   starts; to keep a migration reverted, run a version of the node which
+   doesn't have it.`,
+				Flags: []cli.Flag{dryRunFlag},
+				Action: func(cctx *cli.Context) error {
+					dryRun := cctx.Bool("dry-run")
+
+					lr, err := lockRepo(cctx, dryRun)
+					if err != nil {
+						return err
+					}
+					defer lr.Close() // nolint:errcheck
+
+					m, err := repo.RollbackMigration(cctx.Context, lr, dryRun)
+					if err != nil {
+						return err
+					}
+
+					afmt := NewAppFmt(cctx.App)
+					if dryRun {
+						afmt.Printf("would revert %d (%s)\n", m.Version, m.Name)
+					} else {
+						afmt.Printf("reverted %d (%s)\n", m.Version, m.Name)
+					}
+					printMigrationChanges(afmt, m.Changes)
+					return nil
+				},
+			},
+		},
+	}
+}
+
+func printMigrationChanges(afmt *AppFmt, changes []string) {
+	for _, c := range changes {
+		afmt.Printf("\t- %s\n", c)
+	}
+}
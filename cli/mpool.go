@@ -1,11 +1,14 @@
 package cli
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	stdbig "math/big"
+	"os"
 	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/ipfs/go-cid"
 	"github.com/urfave/cli/v2"
@@ -32,6 +35,7 @@ var MpoolCmd = &cli.Command{
 		MpoolStat,
 		MpoolReplaceCmd,
 		MpoolFindCmd,
+		MpoolPushSignedCmd,
 		MpoolConfig,
 		MpoolGasPerfCmd,
 		mpoolManage,
@@ -605,6 +609,52 @@ var MpoolFindCmd = &cli.Command{
 	},
 }
 
+var MpoolPushSignedCmd = &cli.Command{
+	Name:      "push-signed",
+	Usage:     "Push signed messages from CBOR files, in the order given",
+	ArgsUsage: "[file...]",
+	Description: `Each file holds a signed message serialized as CBOR, either raw or hex encoded,
+   as written by 'lotus-shed sign-messages'.`,
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return ShowHelp(cctx, fmt.Errorf("must pass at least one message file"))
+		}
+
+		afmt := NewAppFmt(cctx.App)
+
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		for _, f := range cctx.Args().Slice() {
+			data, err := os.ReadFile(f)
+			if err != nil {
+				return err
+			}
+			if hb, err := hex.DecodeString(strings.TrimSpace(string(data))); err == nil {
+				data = hb
+			}
+
+			sm, err := types.DecodeSignedMessage(data)
+			if err != nil {
+				return xerrors.Errorf("decoding signed message in %s: %w", f, err)
+			}
+
+			c, err := api.MpoolPush(ctx, sm)
+			if err != nil {
+				return xerrors.Errorf("pushing message in %s: %w", f, err)
+			}
+			afmt.Printf("%s: pushed message %s from %s, nonce %d\n", f, c, sm.Message.From, sm.Message.Nonce)
+		}
+
+		return nil
+	},
+}
+
 var MpoolConfig = &cli.Command{
 	Name:      "config",
 	Usage:     "get or set current mpool configuration",
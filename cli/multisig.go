@@ -2,6 +2,7 @@ package cli
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -19,9 +20,11 @@ import (
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/exitcode"
 	init2 "github.com/filecoin-project/specs-actors/v2/actors/builtin/init"
 	msig2 "github.com/filecoin-project/specs-actors/v2/actors/builtin/multisig"
 
+	"github.com/filecoin-project/lotus/api/v0api"
 	"github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/actors"
@@ -60,6 +63,9 @@ var multisigCmd = &cli.Command{
 		msigLockCancelCmd,
 		msigVestedCmd,
 		msigProposeThresholdCmd,
+		msigRotateSignerCmd,
+		msigReportCmd,
+		msigOfflineCmd,
 	},
 }
 
@@ -198,6 +204,10 @@ var msigInspectCmd = &cli.Command{
 			Name:  "decode-params",
 			Usage: "Decode parameters of transaction proposals",
 		},
+		&cli.BoolFlag{
+			Name:  "simulate",
+			Usage: "Simulate the execution of pending transactions, to flag the ones which would fail once approved",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		if !cctx.Args().Present() {
@@ -297,6 +307,7 @@ var msigInspectCmd = &cli.Command{
 		}
 
 		decParams := cctx.Bool("decode-params")
+		simulate := cctx.Bool("simulate")
 		fmt.Fprintln(cctx.App.Writer, "Transactions: ", len(pending))
 		if len(pending) > 0 {
 			var txids []int64
@@ -308,7 +319,11 @@ var msigInspectCmd = &cli.Command{
 			})
 
 			w := tabwriter.NewWriter(cctx.App.Writer, 8, 4, 2, ' ', 0)
-			fmt.Fprintf(w, "ID\tState\tApprovals\tTo\tValue\tMethod\tParams\n")
+			if simulate {
+				fmt.Fprintf(w, "ID\tState\tApprovals\tTo\tValue\tMethod\tParams\tSimulation\n")
+			} else {
+				fmt.Fprintf(w, "ID\tState\tApprovals\tTo\tValue\tMethod\tParams\n")
+			}
 			for _, txid := range txids {
 				tx := pending[txid]
 				target := tx.To.String()
@@ -318,16 +333,21 @@ var msigInspectCmd = &cli.Command{
 				targAct, err := api.StateGetActor(ctx, tx.To, types.EmptyTSK)
 				paramStr := fmt.Sprintf("%x", tx.Params)
 
+				var methodName string
 				if err != nil {
 					if tx.Method == 0 {
-						fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\t%s(%d)\t%s\n", txid, "pending", len(tx.Approved), target, types.FIL(tx.Value), "Send", tx.Method, paramStr)
+						methodName = "Send"
 					} else {
-						fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\t%s(%d)\t%s\n", txid, "pending", len(tx.Approved), target, types.FIL(tx.Value), "new account, unknown method", tx.Method, paramStr)
+						methodName = "new account, unknown method"
 					}
+				} else if tx.Method == 0 {
+					methodName = "Send"
+				} else if method, ok := filcns.NewActorRegistry().Methods[targAct.Code][tx.Method]; !ok { // TODO: use remote map
+					methodName = "unknown method"
 				} else {
-					method := filcns.NewActorRegistry().Methods[targAct.Code][tx.Method] // TODO: use remote map
+					methodName = method.Name
 
-					if decParams && tx.Method != 0 {
+					if decParams {
 						ptyp := reflect.New(method.Params.Elem()).Interface().(cbg.CBORUnmarshaler)
 						if err := ptyp.UnmarshalCBOR(bytes.NewReader(tx.Params)); err != nil {
 							return xerrors.Errorf("failed to decode parameters of transaction %d: %w", txid, err)
@@ -340,8 +360,16 @@ var msigInspectCmd = &cli.Command{
 
 						paramStr = string(b)
 					}
+				}
 
-					fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\t%s(%d)\t%s\n", txid, "pending", len(tx.Approved), target, types.FIL(tx.Value), method.Name, tx.Method, paramStr)
+				if simulate {
+					res, err := simulateMsigTxn(ctx, api, ownId, types.BigSub(act.Balance, locked), tx, head.Key())
+					if err != nil {
+						return xerrors.Errorf("simulating transaction %d: %w", txid, err)
+					}
+					fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\t%s(%d)\t%s\t%s\n", txid, "pending", len(tx.Approved), target, types.FIL(tx.Value), methodName, tx.Method, paramStr, res)
+				} else {
+					fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\t%s(%d)\t%s\n", txid, "pending", len(tx.Approved), target, types.FIL(tx.Value), methodName, tx.Method, paramStr)
 				}
 			}
 			if err := w.Flush(); err != nil {
@@ -354,10 +382,41 @@ var msigInspectCmd = &cli.Command{
 	},
 }
 
+// simulateMsigTxn executes the transaction as the multisig would once approved,
+// returning "ok" or why it would fail
+func simulateMsigTxn(ctx context.Context, api v0api.FullNode, msig address.Address, spendable abi.TokenAmount, tx multisig.Transaction, tsk types.TipSetKey) (string, error) {
+	// The multisig checks the vesting lock before sending
+	if tx.Value.GreaterThan(spendable) {
+		return fmt.Sprintf("fails: value exceeds the spendable balance of %s", types.FIL(spendable)), nil
+	}
+
+	res, err := api.StateCall(ctx, &types.Message{
+		From:   msig,
+		To:     tx.To,
+		Value:  tx.Value,
+		Method: tx.Method,
+		Params: tx.Params,
+	}, tsk)
+	if err != nil {
+		return "", err
+	}
+	if res.MsgRct.ExitCode != exitcode.Ok {
+		if res.Error != "" {
+			return fmt.Sprintf("fails: exit code %d: %s", res.MsgRct.ExitCode, res.Error), nil
+		}
+		return fmt.Sprintf("fails: exit code %d", res.MsgRct.ExitCode), nil
+	}
+	return "ok", nil
+}
+
 var msigProposeCmd = &cli.Command{
 	Name:      "propose",
 	Usage:     "Propose a multisig transaction",
 	ArgsUsage: "[multisigAddress destinationAddress value <methodId methodParams> (optional)]",
+	Description: `The method can be given by number or name, and its params either as hex
+   of their CBOR encoding, or as JSON, e.g.
+
+   lotus msig propose f01234 f01234 0 AddSigner '{"Signer": "f1...", "Increase": false}'`,
 	Flags: []cli.Flag{
 		&cli.StringFlag{
 			Name:  "from",
@@ -400,15 +459,15 @@ var msigProposeCmd = &cli.Command{
 		var method uint64
 		var params []byte
 		if cctx.Args().Len() == 5 {
-			m, err := strconv.ParseUint(cctx.Args().Get(3), 10, 64)
+			m, err := ResolveMethod(ctx, api, dest, cctx.Args().Get(3))
 			if err != nil {
 				return err
 			}
-			method = m
+			method = uint64(m)
 
-			p, err := hex.DecodeString(cctx.Args().Get(4))
+			p, err := EncodeTypedParams(ctx, api, dest, m, cctx.Args().Get(4))
 			if err != nil {
-				return err
+				return xerrors.Errorf("encoding params: %w", err)
 			}
 			params = p
 		}
@@ -572,15 +631,15 @@ var msigApproveCmd = &cli.Command{
 			var method uint64
 			var params []byte
 			if cctx.Args().Len() == 7 {
-				m, err := strconv.ParseUint(cctx.Args().Get(5), 10, 64)
+				m, err := ResolveMethod(ctx, api, dest, cctx.Args().Get(5))
 				if err != nil {
 					return err
 				}
-				method = m
+				method = uint64(m)
 
-				p, err := hex.DecodeString(cctx.Args().Get(6))
+				p, err := EncodeTypedParams(ctx, api, dest, m, cctx.Args().Get(6))
 				if err != nil {
-					return err
+					return xerrors.Errorf("encoding params: %w", err)
 				}
 				params = p
 			}
@@ -1821,3 +1880,108 @@ var msigProposeThresholdCmd = &cli.Command{
 		return nil
 	},
 }
+
+var msigReportCmd = &cli.Command{
+	Name:      "report",
+	Usage:     "Report the vesting schedule, spendable balance and executed transactions of a multisig",
+	ArgsUsage: "[multisigAddress]",
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "lookback",
+			Usage: "number of epochs to search for executed transactions",
+			Value: int64(builtin.EpochsInDay),
+		},
+		&cli.Int64Flag{
+			Name:  "unlock-step",
+			Usage: "number of epochs between the points of the unlock projection",
+			Value: int64(builtin.EpochsInDay),
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "output the report as json",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return ShowHelp(cctx, fmt.Errorf("must pass multisig address"))
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		msig, err := address.NewFromString(cctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+
+		rep, err := api.MsigGetReport(ctx, msig, abi.ChainEpoch(cctx.Int64("lookback")), abi.ChainEpoch(cctx.Int64("unlock-step")), types.EmptyTSK)
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			out, err := json.MarshalIndent(rep, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cctx.App.Writer, string(out))
+			return nil
+		}
+
+		w := cctx.App.Writer
+		fmt.Fprintf(w, "Multisig: %s (%s)\n", rep.Address, rep.ID)
+		fmt.Fprintf(w, "Height: %d\n", rep.Height)
+		fmt.Fprintf(w, "Balance: %s\n", types.FIL(rep.Balance))
+		fmt.Fprintf(w, "Locked: %s\n", types.FIL(rep.Locked))
+		fmt.Fprintf(w, "Spendable: %s\n", types.FIL(rep.Spendable))
+		fmt.Fprintf(w, "InitialBalance: %s\n", types.FIL(rep.Vesting.InitialBalance))
+		fmt.Fprintf(w, "StartEpoch: %d\n", rep.Vesting.StartEpoch)
+		fmt.Fprintf(w, "UnlockDuration: %d\n", rep.Vesting.UnlockDuration)
+		fmt.Fprintf(w, "Threshold: %d / %d\n", rep.Threshold, len(rep.Signers))
+		fmt.Fprintf(w, "Pending transactions: %d\n", len(rep.Pending))
+
+		fmt.Fprintf(w, "\nExecuted transactions (last %d epochs): %d\n", cctx.Int64("lookback"), len(rep.Executed))
+		if len(rep.Executed) > 0 {
+			tw := tabwriter.NewWriter(w, 8, 4, 2, ' ', 0)
+			fmt.Fprintf(tw, "Epoch\tID\tApprover\tTo\tValue\tMethod\tExitCode\tParams\n")
+			for _, tx := range rep.Executed {
+				method := fmt.Sprintf("%d", tx.Method)
+				if tx.Method == 0 {
+					method = "Send(0)"
+				} else if act, err := api.StateGetActor(ctx, tx.To, types.EmptyTSK); err == nil {
+					if m, ok := filcns.NewActorRegistry().Methods[act.Code][tx.Method]; ok {
+						method = fmt.Sprintf("%s(%d)", m.Name, tx.Method)
+					}
+				}
+
+				params := fmt.Sprintf("%x", tx.Params)
+				if len(tx.DecodedParams) > 0 {
+					params = string(tx.DecodedParams)
+				}
+
+				fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n", tx.Epoch, tx.ID, tx.Approver, tx.To, types.FIL(tx.Value), method, tx.ExitCode, params)
+			}
+			if err := tw.Flush(); err != nil {
+				return xerrors.Errorf("flushing output: %+v", err)
+			}
+		}
+
+		if len(rep.Unlocks) > 0 {
+			fmt.Fprintln(w, "\nUnlock projection:")
+			tw := tabwriter.NewWriter(w, 8, 4, 2, ' ', 0)
+			fmt.Fprintf(tw, "Epoch\tLocked\tSpendable\n")
+			for _, u := range rep.Unlocks {
+				fmt.Fprintf(tw, "%d\t%s\t%s\n", u.Epoch, types.FIL(u.Locked), types.FIL(u.Spendable))
+			}
+			if err := tw.Flush(); err != nil {
+				return xerrors.Errorf("flushing output: %+v", err)
+			}
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,651 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ipfs/go-cid"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/crypto"
+
+	lapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/v1api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/multisig"
+	"github.com/filecoin-project/lotus/chain/consensus/filcns"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/wallet/key"
+	"github.com/filecoin-project/lotus/lib/sigs"
+	_ "github.com/filecoin-project/lotus/lib/sigs/bls"
+	_ "github.com/filecoin-project/lotus/lib/sigs/secp"
+)
+
+const (
+	msigOfflinePropose = "propose"
+	msigOfflineApprove = "approve"
+	msigOfflineCancel  = "cancel"
+)
+
+// msigOfflineBundle holds multisig messages prepared by an online node, so
+// that they can be signed by air-gapped signers and submitted together
+type msigOfflineBundle struct {
+	Multisig address.Address
+	Action   string
+	// TxnID is the ID of the approved or cancelled transaction
+	TxnID int64
+	Txn   msigOfflineTxn
+
+	Messages []msigOfflineMessage
+}
+
+type msigOfflineTxn struct {
+	Proposer address.Address
+	To       address.Address
+	Value    abi.TokenAmount
+	Method   abi.MethodNum
+	Params   []byte
+
+	// MethodName and DecodedParams are resolved by the node preparing the
+	// bundle, for display only
+	MethodName    string          `json:",omitempty"`
+	DecodedParams json.RawMessage `json:",omitempty"`
+}
+
+type msigOfflineMessage struct {
+	Message   types.Message
+	Signature *crypto.Signature `json:",omitempty"`
+}
+
+func (m *msigOfflineMessage) signedMessage() *types.SignedMessage {
+	return &types.SignedMessage{Message: m.Message, Signature: *m.Signature}
+}
+
+var msigOfflineCmd = &cli.Command{
+	Name:  "offline",
+	Usage: "Prepare, sign and submit multisig messages for air-gapped signers",
+	Description: `The propose, approve and cancel commands prepare the messages of each signer
+   on a node connected to the chain, and write them to a bundle file. Each signer
+   then signs their message with the sign command, either with a key file on an
+   air-gapped machine or with the wallet of a node. Once signed, the messages are
+   pushed to the chain with the submit command.`,
+	Subcommands: []*cli.Command{
+		msigOfflineProposeCmd,
+		msigOfflineApproveCmd,
+		msigOfflineCancelCmd,
+		msigOfflineInspectCmd,
+		msigOfflineSignCmd,
+		msigOfflineSubmitCmd,
+	},
+}
+
+var msigOfflineOutputFlag = &cli.StringFlag{
+	Name:     "output",
+	Aliases:  []string{"o"},
+	Usage:    "file to write the bundle to",
+	Required: true,
+}
+
+var msigOfflineProposeCmd = &cli.Command{
+	Name:      "propose",
+	Usage:     "Prepare a multisig proposal to sign offline",
+	ArgsUsage: "[multisigAddress destinationAddress value <methodId methodParams> (optional)]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "from",
+			Usage:    "signer proposing the transaction",
+			Required: true,
+		},
+		msigOfflineOutputFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 3 && cctx.Args().Len() != 5 {
+			return ShowHelp(cctx, fmt.Errorf("must pass multisig address, destination and value, and optionally method and params"))
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		msig, err := address.NewFromString(cctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+		dest, err := address.NewFromString(cctx.Args().Get(1))
+		if err != nil {
+			return err
+		}
+		value, err := types.ParseFIL(cctx.Args().Get(2))
+		if err != nil {
+			return err
+		}
+
+		var method uint64
+		var params []byte
+		if cctx.Args().Len() == 5 {
+			m, err := ResolveMethod(ctx, api, dest, cctx.Args().Get(3))
+			if err != nil {
+				return err
+			}
+			method = uint64(m)
+			params, err = EncodeTypedParams(ctx, api, dest, m, cctx.Args().Get(4))
+			if err != nil {
+				return xerrors.Errorf("encoding params: %w", err)
+			}
+		}
+
+		from, err := msigOfflineSigner(ctx, api, cctx.String("from"))
+		if err != nil {
+			return err
+		}
+		proposer, err := api.StateLookupID(ctx, from, types.EmptyTSK)
+		if err != nil {
+			return err
+		}
+
+		b := &msigOfflineBundle{
+			Multisig: msig,
+			Action:   msigOfflinePropose,
+			Txn: msigOfflineTxn{
+				Proposer: proposer,
+				To:       dest,
+				Value:    abi.TokenAmount(value),
+				Method:   abi.MethodNum(method),
+				Params:   params,
+			},
+		}
+
+		proto, err := api.MsigPropose(ctx, msig, dest, types.BigInt(value), from, method, params)
+		if err != nil {
+			return err
+		}
+		if err := b.add(ctx, api, proto); err != nil {
+			return err
+		}
+
+		return b.write(cctx, cctx.String("output"))
+	},
+}
+
+var msigOfflineApproveCmd = &cli.Command{
+	Name:      "approve",
+	Usage:     "Prepare the approvals of a pending multisig transaction to sign offline",
+	ArgsUsage: "[multisigAddress transactionId]",
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:     "signer",
+			Usage:    "signer approving the transaction, can be repeated",
+			Required: true,
+		},
+		msigOfflineOutputFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		return msigOfflinePrepareTxnIDAction(cctx, msigOfflineApprove)
+	},
+}
+
+var msigOfflineCancelCmd = &cli.Command{
+	Name:      "cancel",
+	Usage:     "Prepare the cancellation of a pending multisig transaction to sign offline",
+	ArgsUsage: "[multisigAddress transactionId]",
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "signer",
+			Usage: "signer cancelling the transaction, the proposer if not set",
+		},
+		msigOfflineOutputFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		return msigOfflinePrepareTxnIDAction(cctx, msigOfflineCancel)
+	},
+}
+
+func msigOfflinePrepareTxnIDAction(cctx *cli.Context, action string) error {
+	if cctx.Args().Len() != 2 {
+		return ShowHelp(cctx, fmt.Errorf("must pass multisig address and transaction ID"))
+	}
+
+	api, closer, err := GetFullNodeAPIV1(cctx)
+	if err != nil {
+		return err
+	}
+	defer closer()
+	ctx := ReqContext(cctx)
+
+	msig, err := address.NewFromString(cctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	txid, err := strconv.ParseInt(cctx.Args().Get(1), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	pending, err := api.MsigGetPending(ctx, msig, types.EmptyTSK)
+	if err != nil {
+		return err
+	}
+	var txn *lapi.MsigTransaction
+	for _, p := range pending {
+		if p.ID == txid {
+			txn = p
+		}
+	}
+	if txn == nil {
+		return xerrors.Errorf("transaction %d is not pending", txid)
+	}
+
+	b := &msigOfflineBundle{
+		Multisig: msig,
+		Action:   action,
+		TxnID:    txid,
+		Txn: msigOfflineTxn{
+			Proposer: txn.Approved[0],
+			To:       txn.To,
+			Value:    txn.Value,
+			Method:   txn.Method,
+			Params:   txn.Params,
+		},
+	}
+
+	signers := cctx.StringSlice("signer")
+	if len(signers) == 0 {
+		signers = []string{b.Txn.Proposer.String()}
+	}
+	for _, s := range signers {
+		from, err := msigOfflineSigner(ctx, api, s)
+		if err != nil {
+			return err
+		}
+
+		var proto *lapi.MessagePrototype
+		if action == msigOfflineApprove {
+			proto, err = api.MsigApproveTxnHash(ctx, msig, uint64(txid), b.Txn.Proposer, b.Txn.To, b.Txn.Value, from, uint64(b.Txn.Method), b.Txn.Params)
+		} else {
+			proto, err = api.MsigCancelTxnHash(ctx, msig, uint64(txid), b.Txn.To, b.Txn.Value, from, uint64(b.Txn.Method), b.Txn.Params)
+		}
+		if err != nil {
+			return err
+		}
+		if err := b.add(ctx, api, proto); err != nil {
+			return err
+		}
+	}
+
+	return b.write(cctx, cctx.String("output"))
+}
+
+var msigOfflineInspectCmd = &cli.Command{
+	Name:      "inspect",
+	Usage:     "Print the summary of a bundle, and which of its messages are signed",
+	ArgsUsage: "[bundleFile]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return ShowHelp(cctx, fmt.Errorf("must pass bundle file"))
+		}
+
+		b, err := readMsigOfflineBundle(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+		if err := b.check(); err != nil {
+			return err
+		}
+
+		return b.print(cctx.App.Writer)
+	},
+}
+
+var msigOfflineSignCmd = &cli.Command{
+	Name:      "sign",
+	Usage:     "Sign the messages of a bundle, in place",
+	ArgsUsage: "[bundleFile]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "key-file",
+			Usage: "sign with the key in this file, in the hex-lotus format of 'lotus wallet export', instead of the wallet of the node",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return ShowHelp(cctx, fmt.Errorf("must pass bundle file"))
+		}
+
+		path := cctx.Args().First()
+		b, err := readMsigOfflineBundle(path)
+		if err != nil {
+			return err
+		}
+
+		// Make sure the messages do what the summary shows before signing
+		if err := b.check(); err != nil {
+			return err
+		}
+		if err := b.print(cctx.App.Writer); err != nil {
+			return err
+		}
+
+		var signed int
+		if kf := cctx.String("key-file"); kf != "" {
+			k, err := readKeyFile(kf)
+			if err != nil {
+				return err
+			}
+			signed, err = b.signWithKey(k)
+			if err != nil {
+				return err
+			}
+		} else {
+			api, closer, err := GetFullNodeAPIV1(cctx)
+			if err != nil {
+				return err
+			}
+			defer closer()
+			ctx := ReqContext(cctx)
+
+			for i := range b.Messages {
+				m := &b.Messages[i]
+				if m.Signature != nil {
+					continue
+				}
+				has, err := api.WalletHas(ctx, m.Message.From)
+				if err != nil {
+					return err
+				}
+				if !has {
+					continue
+				}
+				sm, err := api.WalletSignMessage(ctx, m.Message.From, &m.Message)
+				if err != nil {
+					return xerrors.Errorf("signing message from %s: %w", m.Message.From, err)
+				}
+				m.Signature = &sm.Signature
+				signed++
+			}
+		}
+
+		if signed == 0 {
+			return xerrors.Errorf("no unsigned message from the available keys")
+		}
+		fmt.Fprintf(cctx.App.Writer, "\nsigned %d messages\n", signed)
+
+		return b.write(cctx, path)
+	},
+}
+
+var msigOfflineSubmitCmd = &cli.Command{
+	Name:      "submit",
+	Usage:     "Push the signed messages of a bundle, and wait for them to execute",
+	ArgsUsage: "[bundleFile]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return ShowHelp(cctx, fmt.Errorf("must pass bundle file"))
+		}
+
+		b, err := readMsigOfflineBundle(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+		if err := b.check(); err != nil {
+			return err
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		var cids []cid.Cid
+		for _, m := range b.Messages {
+			if m.Signature == nil {
+				fmt.Fprintf(cctx.App.Writer, "skipping unsigned message from %s\n", m.Message.From)
+				continue
+			}
+			c, err := api.MpoolPush(ctx, m.signedMessage())
+			if err != nil {
+				return xerrors.Errorf("pushing message from %s: %w", m.Message.From, err)
+			}
+			fmt.Fprintf(cctx.App.Writer, "pushed message %s from %s\n", c, m.Message.From)
+			cids = append(cids, c)
+		}
+		if len(cids) == 0 {
+			return xerrors.Errorf("bundle has no signed messages")
+		}
+
+		for _, c := range cids {
+			wait, err := api.StateWaitMsg(ctx, c, uint64(cctx.Int("confidence")), build.Finality, true)
+			if err != nil {
+				return err
+			}
+			if wait.Receipt.ExitCode != 0 {
+				return xerrors.Errorf("message %s failed (exit code %d)", c, wait.Receipt.ExitCode)
+			}
+
+			switch b.Action {
+			case msigOfflinePropose:
+				var ret multisig.ProposeReturn
+				if err := ret.UnmarshalCBOR(bytes.NewReader(wait.Receipt.Return)); err != nil {
+					return xerrors.Errorf("decoding propose return: %w", err)
+				}
+				fmt.Fprintf(cctx.App.Writer, "proposed transaction %d\n", ret.TxnID)
+				if ret.Applied {
+					fmt.Fprintf(cctx.App.Writer, "transaction executed (exit code %d)\n", ret.Code)
+				}
+			case msigOfflineApprove:
+				var ret multisig.ApproveReturn
+				if err := ret.UnmarshalCBOR(bytes.NewReader(wait.Receipt.Return)); err != nil {
+					return xerrors.Errorf("decoding approve return: %w", err)
+				}
+				if ret.Applied {
+					fmt.Fprintf(cctx.App.Writer, "transaction %d executed (exit code %d)\n", b.TxnID, ret.Code)
+				}
+			case msigOfflineCancel:
+				fmt.Fprintf(cctx.App.Writer, "transaction %d cancelled\n", b.TxnID)
+			}
+		}
+
+		return nil
+	},
+}
+
+// msigOfflineSigner resolves a signer to its key address, so that the message
+// can be signed offline
+func msigOfflineSigner(ctx context.Context, api v1api.FullNode, s string) (address.Address, error) {
+	addr, err := address.NewFromString(s)
+	if err != nil {
+		return address.Undef, err
+	}
+	return api.StateAccountKey(ctx, addr, types.EmptyTSK)
+}
+
+// add fills the nonce and gas of the message, and adds it to the bundle
+func (b *msigOfflineBundle) add(ctx context.Context, api v1api.FullNode, proto *lapi.MessagePrototype) error {
+	msg, err := api.GasEstimateMessageGas(ctx, &proto.Message, nil, types.EmptyTSK)
+	if err != nil {
+		return xerrors.Errorf("estimating gas: %w", err)
+	}
+	msg.Nonce, err = api.MpoolGetNonce(ctx, msg.From)
+	if err != nil {
+		return xerrors.Errorf("getting nonce: %w", err)
+	}
+
+	if b.Txn.MethodName == "" {
+		b.Txn.MethodName = "Send"
+		if b.Txn.Method != 0 {
+			b.Txn.MethodName = fmt.Sprintf("%d", b.Txn.Method)
+			if act, err := api.StateGetActor(ctx, b.Txn.To, types.EmptyTSK); err == nil {
+				if m, ok := filcns.NewActorRegistry().Methods[act.Code][b.Txn.Method]; ok {
+					b.Txn.MethodName = m.Name
+				}
+			}
+			if dp, err := api.StateDecodeParams(ctx, b.Txn.To, b.Txn.Method, b.Txn.Params, types.EmptyTSK); err == nil {
+				b.Txn.DecodedParams, _ = json.Marshal(dp)
+			}
+		}
+	}
+
+	b.Messages = append(b.Messages, msigOfflineMessage{Message: *msg})
+	return nil
+}
+
+// check makes sure that the messages of the bundle do what its summary says,
+// and that their signatures are valid
+func (b *msigOfflineBundle) check() error {
+	var method abi.MethodNum
+	switch b.Action {
+	case msigOfflinePropose:
+		method = multisig.Methods.Propose
+	case msigOfflineApprove:
+		method = multisig.Methods.Approve
+	case msigOfflineCancel:
+		method = multisig.Methods.Cancel
+	default:
+		return xerrors.Errorf("unknown action %q", b.Action)
+	}
+
+	for _, m := range b.Messages {
+		msg := m.Message
+		if msg.To != b.Multisig || msg.Method != method || !msg.Value.IsZero() {
+			return xerrors.Errorf("message from %s is not a %s of multisig %s", msg.From, b.Action, b.Multisig)
+		}
+
+		if b.Action == msigOfflinePropose {
+			var params multisig.ProposeParams
+			if err := params.UnmarshalCBOR(bytes.NewReader(msg.Params)); err != nil {
+				return xerrors.Errorf("decoding params of message from %s: %w", msg.From, err)
+			}
+			if params.To != b.Txn.To || !params.Value.Equals(b.Txn.Value) || params.Method != b.Txn.Method || !bytes.Equal(params.Params, b.Txn.Params) {
+				return xerrors.Errorf("message from %s doesn't propose the transaction of the bundle", msg.From)
+			}
+		} else {
+			var params multisig.TxnIDParams
+			if err := params.UnmarshalCBOR(bytes.NewReader(msg.Params)); err != nil {
+				return xerrors.Errorf("decoding params of message from %s: %w", msg.From, err)
+			}
+			hash, err := multisig.ProposalHash(&multisig.ProposalHashData{
+				Requester: b.Txn.Proposer,
+				To:        b.Txn.To,
+				Value:     b.Txn.Value,
+				Method:    b.Txn.Method,
+				Params:    b.Txn.Params,
+			})
+			if err != nil {
+				return err
+			}
+			if int64(params.ID) != b.TxnID || !bytes.Equal(params.ProposalHash, hash) {
+				return xerrors.Errorf("message from %s doesn't %s the transaction of the bundle", msg.From, b.Action)
+			}
+		}
+
+		if m.Signature != nil {
+			if err := sigs.Verify(m.Signature, msg.From, msg.Cid().Bytes()); err != nil {
+				return xerrors.Errorf("invalid signature of message from %s: %w", msg.From, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// signWithKey signs the unsigned messages sent from the key
+func (b *msigOfflineBundle) signWithKey(k *key.Key) (int, error) {
+	var signed int
+	for i := range b.Messages {
+		m := &b.Messages[i]
+		if m.Signature != nil || m.Message.From != k.Address {
+			continue
+		}
+		sig, err := sigs.Sign(key.ActSigType(k.Type), k.PrivateKey, m.Message.Cid().Bytes())
+		if err != nil {
+			return signed, xerrors.Errorf("signing message from %s: %w", m.Message.From, err)
+		}
+		m.Signature = sig
+		signed++
+	}
+	return signed, nil
+}
+
+func (b *msigOfflineBundle) print(w io.Writer) error {
+	switch b.Action {
+	case msigOfflinePropose:
+		fmt.Fprintf(w, "Propose a transaction to multisig %s\n", b.Multisig)
+	case msigOfflineApprove:
+		fmt.Fprintf(w, "Approve transaction %d of multisig %s\n", b.TxnID, b.Multisig)
+	case msigOfflineCancel:
+		fmt.Fprintf(w, "Cancel transaction %d of multisig %s\n", b.TxnID, b.Multisig)
+	}
+	fmt.Fprintf(w, "Proposer: %s\n", b.Txn.Proposer)
+	fmt.Fprintf(w, "To: %s\n", b.Txn.To)
+	fmt.Fprintf(w, "Value: %s\n", types.FIL(b.Txn.Value))
+	fmt.Fprintf(w, "Method: %s(%d)\n", b.Txn.MethodName, b.Txn.Method)
+	if len(b.Txn.DecodedParams) > 0 {
+		fmt.Fprintf(w, "Params: %s\n", b.Txn.DecodedParams)
+	} else if len(b.Txn.Params) > 0 {
+		fmt.Fprintf(w, "Params: %x\n", b.Txn.Params)
+	}
+
+	fmt.Fprintln(w, "Messages:")
+	tw := tabwriter.NewWriter(w, 8, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "From\tNonce\tMaxFee\tSigned\n")
+	for _, m := range b.Messages {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%t\n", m.Message.From, m.Message.Nonce, types.FIL(m.Message.RequiredFunds()), m.Signature != nil)
+	}
+	return tw.Flush()
+}
+
+func (b *msigOfflineBundle) write(cctx *cli.Context, path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return xerrors.Errorf("writing bundle: %w", err)
+	}
+	fmt.Fprintf(cctx.App.Writer, "wrote bundle with %d messages to %s\n", len(b.Messages), path)
+	return nil
+}
+
+func readMsigOfflineBundle(path string) (*msigOfflineBundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, xerrors.Errorf("reading bundle: %w", err)
+	}
+	var b msigOfflineBundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, xerrors.Errorf("parsing bundle: %w", err)
+	}
+	return &b, nil
+}
+
+// readKeyFile reads a key exported with 'lotus wallet export'
+func readKeyFile(path string) (*key.Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, xerrors.Errorf("reading key file: %w", err)
+	}
+	kb, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, xerrors.Errorf("decoding key file: %w", err)
+	}
+	var ki types.KeyInfo
+	if err := json.Unmarshal(kb, &ki); err != nil {
+		return nil, xerrors.Errorf("decoding key file: %w", err)
+	}
+	return key.NewKey(ki)
+}
@@ -0,0 +1,77 @@
+//stm: #unit
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/multisig"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/wallet/key"
+)
+
+func TestMsigOfflineBundle(t *testing.T) {
+	msig, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+	proposer, err := address.NewIDAddress(1001)
+	require.NoError(t, err)
+	dest, err := address.NewIDAddress(1002)
+	require.NoError(t, err)
+
+	k1, err := key.GenerateKey(types.KTSecp256k1)
+	require.NoError(t, err)
+	k2, err := key.GenerateKey(types.KTSecp256k1)
+	require.NoError(t, err)
+
+	txn := msigOfflineTxn{
+		Proposer: proposer,
+		To:       dest,
+		Value:    big.NewInt(100),
+	}
+	b := &msigOfflineBundle{
+		Multisig: msig,
+		Action:   msigOfflineApprove,
+		TxnID:    3,
+		Txn:      txn,
+	}
+	for _, k := range []*key.Key{k1, k2} {
+		msg, err := multisig.Message(actors.Version8, k.Address).Approve(msig, 3, &multisig.ProposalHashData{
+			Requester: proposer,
+			To:        dest,
+			Value:     big.NewInt(100),
+		})
+		require.NoError(t, err)
+		msg.GasFeeCap, msg.GasPremium = big.NewInt(100), big.NewInt(10)
+		b.Messages = append(b.Messages, msigOfflineMessage{Message: *msg})
+	}
+	require.NoError(t, b.check())
+
+	// the summary must match the messages
+	b.Txn.Value = big.NewInt(1000)
+	require.Error(t, b.check())
+	b.Txn = txn
+	b.TxnID = 4
+	require.Error(t, b.check())
+	b.TxnID = 3
+
+	// each key only signs its own message
+	signed, err := b.signWithKey(k1)
+	require.NoError(t, err)
+	require.Equal(t, 1, signed)
+	require.NotNil(t, b.Messages[0].Signature)
+	require.Nil(t, b.Messages[1].Signature)
+
+	signed, err = b.signWithKey(k2)
+	require.NoError(t, err)
+	require.Equal(t, 1, signed)
+	require.NoError(t, b.check())
+
+	// the signatures cover the messages
+	b.Messages[0].Message.Nonce++
+	require.Error(t, b.check())
+}
@@ -0,0 +1,268 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	msig2 "github.com/filecoin-project/specs-actors/v2/actors/builtin/multisig"
+
+	lapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/multisig"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var msigRotateSignerCmd = &cli.Command{
+	Name:      "rotate-signer",
+	Usage:     "Replace a signer of a multisig, proposing and tracking each step",
+	ArgsUsage: "[multisigAddress oldSigner newSigner]",
+	Description: `Replaces the old signer with the new one by proposing, one after the other:
+   1. adding the new signer, keeping the threshold
+   2. removing the old signer, keeping the threshold
+   3. changing the threshold, when --threshold is set
+
+   Each step waits for its transaction to be approved and executed before the
+   next one is proposed, so the threshold stays satisfiable by the signers at
+   every step. Running the command again resumes the rotation from the current
+   signers of the multisig.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "account to send the propose messages from",
+		},
+		&cli.Uint64Flag{
+			Name:  "threshold",
+			Usage: "threshold to set once the signer is replaced",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 3 {
+			return ShowHelp(cctx, fmt.Errorf("must pass multisig address, old signer and new signer"))
+		}
+
+		srv, err := GetFullNodeServices(cctx)
+		if err != nil {
+			return err
+		}
+		defer srv.Close() //nolint:errcheck
+
+		api := srv.FullNodeAPI()
+		ctx := ReqContext(cctx)
+
+		msig, err := address.NewFromString(cctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+
+		oldSigner, err := address.NewFromString(cctx.Args().Get(1))
+		if err != nil {
+			return err
+		}
+
+		newSigner, err := address.NewFromString(cctx.Args().Get(2))
+		if err != nil {
+			return err
+		}
+
+		var from address.Address
+		if cctx.IsSet("from") {
+			from, err = address.NewFromString(cctx.String("from"))
+			if err != nil {
+				return err
+			}
+		} else {
+			from, err = api.WalletDefaultAddress(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		oldId, err := api.StateLookupID(ctx, oldSigner, types.EmptyTSK)
+		if err != nil {
+			return xerrors.Errorf("looking up old signer: %w", err)
+		}
+		fromId, err := api.StateLookupID(ctx, from, types.EmptyTSK)
+		if err != nil {
+			return xerrors.Errorf("looking up proposer: %w", err)
+		}
+		if fromId == oldId && cctx.IsSet("threshold") {
+			return fmt.Errorf("the old signer can't propose the threshold change after being removed, use another account with --from")
+		}
+
+		var last string
+		for {
+			signers, threshold, err := msigSigners(ctx, api, msig)
+			if err != nil {
+				return err
+			}
+
+			// The new signer may not have an actor until it is added
+			newId, err := api.StateLookupID(ctx, newSigner, types.EmptyTSK)
+			if err != nil {
+				newId = newSigner
+			}
+
+			hasOld, hasNew, hasFrom := false, false, false
+			for _, s := range signers {
+				hasOld = hasOld || s == oldId
+				hasNew = hasNew || s == newId
+				hasFrom = hasFrom || s == fromId
+			}
+			newThreshold := threshold
+			if cctx.IsSet("threshold") {
+				newThreshold = cctx.Uint64("threshold")
+			}
+
+			if !hasOld && hasNew && newThreshold == threshold {
+				fmt.Fprintf(cctx.App.Writer, "%s replaced %s, signers: %d, threshold: %d\n", newSigner, oldSigner, len(signers), threshold)
+				return nil
+			}
+			if !hasOld && !hasNew {
+				return fmt.Errorf("neither %s nor %s is a signer of %s", oldSigner, newSigner, msig)
+			}
+			if !hasFrom {
+				return fmt.Errorf("%s is not a signer of %s", from, msig)
+			}
+
+			var step string
+			var proto *lapi.MessagePrototype
+			switch {
+			case !hasNew:
+				step = "add " + newSigner.String()
+				if threshold > uint64(len(signers)+1) {
+					return fmt.Errorf("threshold %d can't be satisfied by %d signers", threshold, len(signers)+1)
+				}
+
+				proto, err = api.MsigAddPropose(ctx, msig, from, newSigner, false)
+			case hasOld:
+				step = "remove " + oldSigner.String()
+				if threshold > uint64(len(signers)-1) {
+					return fmt.Errorf("removing %s would leave %d signers for a threshold of %d", oldSigner, len(signers)-1, threshold)
+				}
+
+				proto, err = api.MsigRemoveSigner(ctx, msig, from, oldSigner, false)
+			default:
+				step = fmt.Sprintf("change threshold to %d", newThreshold)
+				if newThreshold == 0 || newThreshold > uint64(len(signers)) {
+					return fmt.Errorf("threshold %d can't be satisfied by %d signers", newThreshold, len(signers))
+				}
+
+				params, aerr := actors.SerializeParams(&msig2.ChangeNumApprovalsThresholdParams{
+					NewThreshold: newThreshold,
+				})
+				if aerr != nil {
+					return aerr
+				}
+
+				proto, err = api.MsigPropose(ctx, msig, msig, types.NewInt(0), from, uint64(multisig.Methods.ChangeNumApprovalsThreshold), params)
+			}
+			if err != nil {
+				return xerrors.Errorf("proposing to %s: %w", step, err)
+			}
+
+			// The same step comes up again when its transaction was cancelled,
+			// or failed to execute
+			if step == last {
+				return fmt.Errorf("the proposal to %s wasn't executed", step)
+			}
+			last = step
+
+			sm, err := InteractiveSend(ctx, cctx, srv, proto)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cctx.App.Writer, "sent proposal to %s in message: %s\n", step, sm.Cid())
+
+			if err := msigTrackProposal(ctx, cctx, api, msig, sm.Cid()); err != nil {
+				return xerrors.Errorf("proposal to %s: %w", step, err)
+			}
+		}
+	},
+}
+
+// msigSigners returns the current signers and threshold of the multisig
+func msigSigners(ctx context.Context, api lapi.FullNode, msig address.Address) ([]address.Address, uint64, error) {
+	store := adt.WrapStore(ctx, cbor.NewCborStore(blockstore.NewAPIBlockstore(api)))
+
+	act, err := api.StateGetActor(ctx, msig, types.EmptyTSK)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	mstate, err := multisig.Load(store, act)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	signers, err := mstate.Signers()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	threshold, err := mstate.Threshold()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return signers, threshold, nil
+}
+
+// msigTrackProposal waits for the transaction proposed in the message to leave
+// the pending transactions of the multisig, once approved or cancelled
+func msigTrackProposal(ctx context.Context, cctx *cli.Context, api lapi.FullNode, msig address.Address, msgCid cid.Cid) error {
+	wait, err := api.StateWaitMsg(ctx, msgCid, uint64(cctx.Int("confidence")), build.Finality, true)
+	if err != nil {
+		return err
+	}
+
+	if wait.Receipt.ExitCode != 0 {
+		return fmt.Errorf("proposal returned exit %d", wait.Receipt.ExitCode)
+	}
+
+	var ret msig2.ProposeReturn
+	if err := ret.UnmarshalCBOR(bytes.NewReader(wait.Receipt.Return)); err != nil {
+		return xerrors.Errorf("failed to unmarshal propose return value: %w", err)
+	}
+
+	if ret.Applied {
+		if ret.Code != 0 {
+			return fmt.Errorf("transaction %d failed with exit %d", ret.TxnID, ret.Code)
+		}
+		return nil
+	}
+
+	fmt.Fprintf(cctx.App.Writer, "transaction %d is waiting for approvals (lotus msig approve %s %d)\n", ret.TxnID, msig, ret.TxnID)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(build.BlockDelaySecs) * time.Second):
+		}
+
+		pending, err := api.MsigGetPending(ctx, msig, types.EmptyTSK)
+		if err != nil {
+			return err
+		}
+
+		found := false
+		for _, txn := range pending {
+			found = found || txn.ID == int64(ret.TxnID)
+		}
+		if !found {
+			return nil
+		}
+	}
+}
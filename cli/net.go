@@ -39,9 +39,12 @@ var NetCmd = &cli.Command{
 		NetId,
 		NetFindPeer,
 		NetScores,
+		NetDashboard,
+		NetPeerInfo,
 		NetReachability,
 		NetBandwidthCmd,
 		NetBlockCmd,
+		NetAllowCmd,
 		NetStatCmd,
 		NetLimitCmd,
 		NetProtectAdd,
@@ -239,6 +242,133 @@ var NetScores = &cli.Command{
 	},
 }
 
+var NetDashboard = &cli.Command{
+	Name:  "dashboard",
+	Usage: "Print pubsub scores, block and message propagation and bitswap stats of connected peers",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "agent",
+			Usage: "Print agent name",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		peers, err := api.NetPeers(ctx)
+		if err != nil {
+			return err
+		}
+
+		seen := make(map[peer.ID]struct{})
+		var infos []*atypes.PeerInfoDetailed
+		for _, p := range peers {
+			if _, dup := seen[p.ID]; dup {
+				continue
+			}
+			seen[p.ID] = struct{}{}
+
+			info, err := api.NetPeerInfoDetailed(ctx, p.ID)
+			if err != nil {
+				log.Warnf("error getting info of peer %s: %s", p.ID, err)
+				continue
+			}
+			infos = append(infos, info)
+		}
+
+		score := func(info *atypes.PeerInfoDetailed) float64 {
+			if info.PubsubScore == nil {
+				return math.Inf(-1)
+			}
+			return info.PubsubScore.Score
+		}
+		sort.SliceStable(infos, func(i, j int) bool {
+			return score(infos[i]) > score(infos[j])
+		})
+
+		tw := tabwriter.NewWriter(os.Stdout, 4, 4, 2, ' ', 0)
+		header := "Peer\tScore\tBlocks\tMessages\tDup Blocks\tDup Messages\tRejected\tBitswap Sent\tBitswap Recv\tFlags"
+		if cctx.Bool("agent") {
+			header += "\tAgent"
+		}
+		fmt.Fprintln(tw, header)
+
+		for _, info := range infos {
+			sc := "-"
+			if info.PubsubScore != nil {
+				sc = fmt.Sprintf("%.2f", info.PubsubScore.Score)
+			}
+
+			blocks, msgs, dupBlocks, dupMsgs, rejected := "-", "-", "-", "-", "-"
+			if pr := info.Propagation; pr != nil {
+				blocks = fmt.Sprint(pr.Blocks.FirstDeliveries)
+				msgs = fmt.Sprint(pr.Messages.FirstDeliveries)
+				dupBlocks = fmt.Sprint(pr.Blocks.Duplicates)
+				dupMsgs = fmt.Sprint(pr.Messages.Duplicates)
+				rejected = fmt.Sprint(pr.Blocks.Rejected + pr.Messages.Rejected)
+			}
+
+			sent, recv := "-", "-"
+			if bs := info.Bitswap; bs != nil {
+				sent = humanize.IBytes(bs.Sent)
+				recv = humanize.IBytes(bs.Recv)
+			}
+
+			var flags []string
+			if info.Protected {
+				flags = append(flags, "protected")
+			}
+			if info.Allowed {
+				flags = append(flags, "allowed")
+			}
+
+			line := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s", info.ID, sc, blocks, msgs, dupBlocks, dupMsgs, rejected, sent, recv, strings.Join(flags, ","))
+			if cctx.Bool("agent") {
+				line += "\t" + info.Agent
+			}
+			fmt.Fprintln(tw, line)
+		}
+
+		return tw.Flush()
+	},
+}
+
+var NetPeerInfo = &cli.Command{
+	Name:      "peer-info",
+	Usage:     "Print detailed information about a peer in json",
+	ArgsUsage: "<Peer>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return ShowHelp(cctx, fmt.Errorf("incorrect number of arguments"))
+		}
+
+		api, closer, err := GetAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		p, err := peer.Decode(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("parsing peer ID: %w", err)
+		}
+
+		info, err := api.NetPeerInfoDetailed(ctx, p)
+		if err != nil {
+			return err
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	},
+}
+
 var NetListen = &cli.Command{
 	Name:  "listen",
 	Usage: "List listen addresses",
@@ -463,6 +593,10 @@ var NetBandwidthCmd = &cli.Command{
 			Name:  "by-protocol",
 			Usage: "list bandwidth usage by protocol",
 		},
+		&cli.BoolFlag{
+			Name:  "by-group",
+			Usage: "list bandwidth usage and limits by protocol group",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetAPI(cctx)
@@ -478,6 +612,33 @@ var NetBandwidthCmd = &cli.Command{
 
 		tw := tabwriter.NewWriter(os.Stdout, 4, 4, 2, ' ', 0)
 
+		if cctx.Bool("by-group") {
+			bw, err := api.NetBandwidthStatsByProtocolGroup(ctx)
+			if err != nil {
+				return err
+			}
+
+			var groups []string
+			for g := range bw {
+				groups = append(groups, g)
+			}
+			sort.Strings(groups)
+
+			fmt.Fprintf(tw, "Group\tTotalIn\tTotalOut\tRateIn\tRateOut\tCeiling\tThrottled\tDelay\n")
+			for _, g := range groups {
+				s := bw[g]
+
+				ceiling := "-"
+				if s.Ceiling > 0 {
+					ceiling = humanize.Bytes(uint64(s.Ceiling)) + "/s"
+				}
+
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s/s\t%s/s\t%s\t%t\t%s\n", g, humanize.Bytes(uint64(s.TotalIn)), humanize.Bytes(uint64(s.TotalOut)), humanize.Bytes(uint64(s.RateIn)), humanize.Bytes(uint64(s.RateOut)), ceiling, s.Throttled, s.Delay.Truncate(time.Millisecond))
+			}
+
+			return tw.Flush()
+		}
+
 		fmt.Fprintf(tw, "Segment\tTotalIn\tTotalOut\tRateIn\tRateOut\n")
 
 		if bypeer {
@@ -735,6 +896,191 @@ var NetBlockListCmd = &cli.Command{
 	},
 }
 
+var NetAllowCmd = &cli.Command{
+	Name:  "allow",
+	Usage: "Manage the peers, IPs and subnets allowed to connect even when blocked",
+	Subcommands: []*cli.Command{
+		NetAllowAddCmd,
+		NetAllowRemoveCmd,
+		NetAllowListCmd,
+	},
+}
+
+var NetAllowAddCmd = &cli.Command{
+	Name:  "add",
+	Usage: "Add allow list rules",
+	Subcommands: []*cli.Command{
+		NetAllowAddPeer,
+		NetAllowAddIP,
+		NetAllowAddSubnet,
+	},
+}
+
+var NetAllowAddPeer = &cli.Command{
+	Name:      "peer",
+	Usage:     "Allow a peer",
+	ArgsUsage: "<Peer> ...",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		peers, err := decodePeerIDsFromArgs(cctx)
+		if err != nil {
+			return err
+		}
+
+		return api.NetAllowAdd(ctx, atypes.NetBlockList{Peers: peers})
+	},
+}
+
+var NetAllowAddIP = &cli.Command{
+	Name:      "ip",
+	Usage:     "Allow an IP address",
+	ArgsUsage: "<IP> ...",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		return api.NetAllowAdd(ctx, atypes.NetBlockList{IPAddrs: cctx.Args().Slice()})
+	},
+}
+
+var NetAllowAddSubnet = &cli.Command{
+	Name:      "subnet",
+	Usage:     "Allow an IP subnet",
+	ArgsUsage: "<CIDR> ...",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		return api.NetAllowAdd(ctx, atypes.NetBlockList{IPSubnets: cctx.Args().Slice()})
+	},
+}
+
+var NetAllowRemoveCmd = &cli.Command{
+	Name:  "remove",
+	Usage: "Remove allow list rules",
+	Subcommands: []*cli.Command{
+		NetAllowRemovePeer,
+		NetAllowRemoveIP,
+		NetAllowRemoveSubnet,
+	},
+}
+
+var NetAllowRemovePeer = &cli.Command{
+	Name:      "peer",
+	Usage:     "Remove a peer from the allow list",
+	ArgsUsage: "<Peer> ...",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		peers, err := decodePeerIDsFromArgs(cctx)
+		if err != nil {
+			return err
+		}
+
+		return api.NetAllowRemove(ctx, atypes.NetBlockList{Peers: peers})
+	},
+}
+
+var NetAllowRemoveIP = &cli.Command{
+	Name:      "ip",
+	Usage:     "Remove an IP address from the allow list",
+	ArgsUsage: "<IP> ...",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		return api.NetAllowRemove(ctx, atypes.NetBlockList{IPAddrs: cctx.Args().Slice()})
+	},
+}
+
+var NetAllowRemoveSubnet = &cli.Command{
+	Name:      "subnet",
+	Usage:     "Remove an IP subnet from the allow list",
+	ArgsUsage: "<CIDR> ...",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		return api.NetAllowRemove(ctx, atypes.NetBlockList{IPSubnets: cctx.Args().Slice()})
+	},
+}
+
+var NetAllowListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "list allow list rules",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		acl, err := api.NetAllowList(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(acl.Peers) != 0 {
+			sort.Slice(acl.Peers, func(i, j int) bool {
+				return strings.Compare(string(acl.Peers[i]), string(acl.Peers[j])) > 0
+			})
+
+			fmt.Println("Allowed Peers:")
+			for _, p := range acl.Peers {
+				fmt.Printf("\t%s\n", p)
+			}
+		}
+
+		if len(acl.IPAddrs) != 0 {
+			sort.Strings(acl.IPAddrs)
+
+			fmt.Println("Allowed IPs:")
+			for _, a := range acl.IPAddrs {
+				fmt.Printf("\t%s\n", a)
+			}
+		}
+
+		if len(acl.IPSubnets) != 0 {
+			sort.Strings(acl.IPSubnets)
+
+			fmt.Println("Allowed Subnets:")
+			for _, n := range acl.IPSubnets {
+				fmt.Printf("\t%s\n", n)
+			}
+		}
+
+		return nil
+	},
+}
+
 var BarCols = float64(64)
 
 func BarString(total, y, g float64) string {
@@ -3,12 +3,17 @@ package cli
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
+	"os"
 	"sort"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/builtin/v8/paych"
@@ -31,6 +36,7 @@ var paychCmd = &cli.Command{
 		paychStatusCmd,
 		paychStatusByFromToCmd,
 		paychCloseCmd,
+		paychBudgetCmd,
 	},
 }
 
@@ -345,6 +351,8 @@ var paychVoucherCmd = &cli.Command{
 		paychVoucherListCmd,
 		paychVoucherBestSpendableCmd,
 		paychVoucherSubmitCmd,
+		paychVoucherExportAllCmd,
+		paychVoucherImportCmd,
 	},
 }
 
@@ -644,6 +652,239 @@ var paychVoucherSubmitCmd = &cli.Command{
 	},
 }
 
+var paychVoucherExportAllCmd = &cli.Command{
+	Name:      "export-all",
+	Usage:     "Export all vouchers and the local state of a payment channel to a signed bundle",
+	ArgsUsage: "[channelAddress]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "file to write the bundle to, stdout if not set",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return ShowHelp(cctx, fmt.Errorf("must pass payment channel address"))
+		}
+
+		ch, err := address.NewFromString(cctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		bundle, err := api.PaychExport(ctx, ch)
+		if err != nil {
+			return err
+		}
+
+		b, err := json.MarshalIndent(bundle, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		if out := cctx.String("output"); out != "" {
+			if err := os.WriteFile(out, b, 0600); err != nil {
+				return xerrors.Errorf("writing bundle: %w", err)
+			}
+			fmt.Fprintf(cctx.App.Writer, "exported %d vouchers of channel %s to %s\n", len(bundle.Vouchers), ch, out)
+			return nil
+		}
+
+		fmt.Fprintln(cctx.App.Writer, string(b))
+		return nil
+	},
+}
+
+var paychVoucherImportCmd = &cli.Command{
+	Name:      "import",
+	Usage:     "Import a payment channel bundle created with export-all",
+	ArgsUsage: "[bundleFile]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return ShowHelp(cctx, fmt.Errorf("must pass bundle file"))
+		}
+
+		b, err := os.ReadFile(cctx.Args().Get(0))
+		if err != nil {
+			return xerrors.Errorf("reading bundle: %w", err)
+		}
+
+		var bundle lapi.PaychBundle
+		if err := json.Unmarshal(b, &bundle); err != nil {
+			return xerrors.Errorf("parsing bundle: %w", err)
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		if err := api.PaychImport(ctx, &bundle); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cctx.App.Writer, "imported %d vouchers of channel %s\n", len(bundle.Vouchers), bundle.Channel)
+		return nil
+	},
+}
+
+var paychBudgetCmd = &cli.Command{
+	Name:  "budget",
+	Usage: "Show the budget of the payment channels managed for retrievals",
+	Subcommands: []*cli.Command{
+		paychBudgetSetCmd,
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		st, err := api.PaychBudget(ctx)
+		if err != nil {
+			return err
+		}
+
+		limit := func(v types.BigInt) string {
+			if v.IsZero() {
+				return "unlimited"
+			}
+			return types.FIL(v).String()
+		}
+
+		w := cctx.App.Writer
+		b := st.Budget
+		fmt.Fprintf(w, "Auto-management: %t\n", b.Enable)
+		fmt.Fprintf(w, "Locked:          %s / %s\n", types.FIL(st.Locked), limit(b.MaxTotal))
+		fmt.Fprintf(w, "Per channel:     %s\n", limit(b.MaxPerChannel))
+		fmt.Fprintf(w, "Top-up:          %s\n", types.FIL(b.TopUp))
+		if b.MaxLanes > 0 {
+			fmt.Fprintf(w, "Max lanes:       %d\n", b.MaxLanes)
+		} else {
+			fmt.Fprintf(w, "Max lanes:       unlimited\n")
+		}
+		if b.SettleAfter > 0 {
+			fmt.Fprintf(w, "Settle after:    %s idle\n", b.SettleAfter)
+		} else {
+			fmt.Fprintf(w, "Settle after:    never\n")
+		}
+
+		if len(st.Channels) == 0 {
+			return nil
+		}
+
+		fmt.Fprintln(w)
+		tw := tabwriter.NewWriter(w, 8, 4, 2, ' ', 0)
+		fmt.Fprintf(tw, "Channel\tTo\tAmount\tLanes\tLast Activity\tState\n")
+		for _, ch := range st.Channels {
+			state := "active"
+			switch {
+			case ch.Collected:
+				state = "collected"
+			case ch.Settling:
+				state = "settling"
+			}
+
+			last := "-"
+			if !ch.LastActivity.IsZero() {
+				last = ch.LastActivity.Format(time.Stamp)
+			}
+
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n", ch.Channel, ch.To, types.FIL(ch.Amount), ch.Lanes, last, state)
+		}
+		return tw.Flush()
+	},
+}
+
+var paychBudgetSetCmd = &cli.Command{
+	Name:  "set",
+	Usage: "Change the budget of the payment channels managed for retrievals",
+	Description: `With auto-management enabled, payment channels paying for retrievals are
+   topped up within the budget, replaced once they allocated the maximum number
+   of lanes, settled once idle, and collected after the settlement period.
+   Amounts of 0 mean no limit.`,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "enable",
+			Usage: "manage payment channels within the budget",
+		},
+		&cli.StringFlag{
+			Name:  "max-total",
+			Usage: "most FIL locked in active outbound channels",
+		},
+		&cli.StringFlag{
+			Name:  "max-per-channel",
+			Usage: "most FIL locked in a single channel",
+		},
+		&cli.StringFlag{
+			Name:  "top-up",
+			Usage: "least FIL added to a channel lacking funds",
+		},
+		&cli.Uint64Flag{
+			Name:  "max-lanes",
+			Usage: "lanes a channel allocates before it is replaced",
+		},
+		&cli.DurationFlag{
+			Name:  "settle-after",
+			Usage: "settle channels without retrieval activity for this long, 0 to never settle",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		st, err := api.PaychBudget(ctx)
+		if err != nil {
+			return err
+		}
+		b := st.Budget
+
+		if cctx.IsSet("enable") {
+			b.Enable = cctx.Bool("enable")
+		}
+		for flag, amt := range map[string]*types.BigInt{
+			"max-total":       &b.MaxTotal,
+			"max-per-channel": &b.MaxPerChannel,
+			"top-up":          &b.TopUp,
+		} {
+			if !cctx.IsSet(flag) {
+				continue
+			}
+			v, err := types.ParseFIL(cctx.String(flag))
+			if err != nil {
+				return xerrors.Errorf("parsing %s: %w", flag, err)
+			}
+			*amt = types.BigInt(v)
+		}
+		if cctx.IsSet("max-lanes") {
+			b.MaxLanes = cctx.Uint64("max-lanes")
+		}
+		if cctx.IsSet("settle-after") {
+			b.SettleAfter = cctx.Duration("settle-after")
+		}
+
+		return api.PaychSetBudget(ctx, b)
+	},
+}
+
 func EncodedString(sv *paych.SignedVoucher) (string, error) {
 	buf := new(bytes.Buffer)
 	if err := sv.MarshalCBOR(buf); err != nil {
@@ -3,6 +3,7 @@ package cli
 import (
 	"encoding/hex"
 	"fmt"
+	"strconv"
 
 	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
@@ -43,10 +44,10 @@ var sendCmd = &cli.Command{
 			Usage: "specify the nonce to use",
 			Value: 0,
 		},
-		&cli.Uint64Flag{
+		&cli.StringFlag{
 			Name:  "method",
-			Usage: "specify method to invoke",
-			Value: uint64(builtin.MethodSend),
+			Usage: "specify method to invoke, by number or name",
+			Value: strconv.FormatUint(uint64(builtin.MethodSend), 10),
 		},
 		&cli.StringFlag{
 			Name:  "params-json",
@@ -120,7 +121,12 @@ var sendCmd = &cli.Command{
 			params.GasLimit = &limit
 		}
 
-		params.Method = abi.MethodNum(cctx.Uint64("method"))
+		if cctx.IsSet("method") {
+			params.Method, err = ResolveMethod(ctx, srv.FullNodeAPI(), params.To, cctx.String("method"))
+			if err != nil {
+				return ShowHelp(cctx, fmt.Errorf("failed to resolve method: %w", err))
+			}
+		}
 
 		if cctx.IsSet("params-json") {
 			decparams, err := srv.DecodeTypedParamsFromJSON(ctx, params.To, params.Method, cctx.String("params-json"))
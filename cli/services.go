@@ -3,9 +3,13 @@ package cli
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/ipfs/go-cid"
 	cbg "github.com/whyrusleeping/cbor-gen"
@@ -17,6 +21,7 @@ import (
 	"github.com/filecoin-project/go-state-types/big"
 
 	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
 	"github.com/filecoin-project/lotus/chain/consensus/filcns"
 	"github.com/filecoin-project/lotus/chain/types"
 )
@@ -83,20 +88,74 @@ func (s *ServicesImpl) GetBaseFee(ctx context.Context) (abi.TokenAmount, error)
 }
 
 func (s *ServicesImpl) DecodeTypedParamsFromJSON(ctx context.Context, to address.Address, method abi.MethodNum, paramstr string) ([]byte, error) {
-	act, err := s.api.StateGetActor(ctx, to, types.EmptyTSK)
+	return decodeTypedParamsFromJSON(ctx, s.api, to, method, paramstr)
+}
+
+// ResolveMethod returns the number of a method of the actor at the address,
+// given either by number or by name
+func ResolveMethod(ctx context.Context, fapi api.FullNode, to address.Address, method string) (abi.MethodNum, error) {
+	if m, err := strconv.ParseUint(method, 10, 64); err == nil {
+		return abi.MethodNum(m), nil
+	}
+	if strings.EqualFold(method, "Send") {
+		return builtin.MethodSend, nil
+	}
+
+	act, err := fapi.StateGetActor(ctx, to, types.EmptyTSK)
+	if err != nil {
+		return 0, xerrors.Errorf("looking up actor %s: %w", to, err)
+	}
+
+	for num, m := range filcns.NewActorRegistry().Methods[act.Code] { // TODO: use remote map
+		if strings.EqualFold(m.Name, method) {
+			return num, nil
+		}
+	}
+	return 0, xerrors.Errorf("method %s not found on actor %s", method, act.Code)
+}
+
+// EncodeTypedParams returns the CBOR encoded params of a method of the actor at
+// the address, given either as hex of their encoding, or as JSON of their type
+func EncodeTypedParams(ctx context.Context, fapi api.FullNode, to address.Address, method abi.MethodNum, params string) ([]byte, error) {
+	if params == "" {
+		return nil, nil
+	}
+	if p, err := hex.DecodeString(params); err == nil {
+		return p, nil
+	}
+	if !json.Valid([]byte(params)) {
+		return nil, xerrors.Errorf("params are neither hex nor JSON")
+	}
+	return decodeTypedParamsFromJSON(ctx, fapi, to, method, params)
+}
+
+func decodeTypedParamsFromJSON(ctx context.Context, fapi api.FullNode, to address.Address, method abi.MethodNum, paramstr string) ([]byte, error) {
+	act, err := fapi.StateGetActor(ctx, to, types.EmptyTSK)
 	if err != nil {
 		return nil, err
 	}
 
 	methodMeta, found := filcns.NewActorRegistry().Methods[act.Code][method] // TODO: use remote map
 	if !found {
-		return nil, fmt.Errorf("method %d not found on actor %s", method, act.Code)
+		// The node may know about actors this binary doesn't
+		p, err := fapi.StateEncodeParams(ctx, act.Code, method, json.RawMessage(paramstr))
+		if err != nil {
+			return nil, fmt.Errorf("method %d not found on actor %s: %w", method, act.Code, err)
+		}
+		return p, nil
 	}
 
 	p := reflect.New(methodMeta.Params.Elem()).Interface().(cbg.CBORMarshaler)
 
-	if err := json.Unmarshal([]byte(paramstr), p); err != nil {
-		return nil, fmt.Errorf("unmarshaling input into params type: %w", err)
+	// Reject fields which aren't part of the params type, as they would be
+	// silently dropped otherwise
+	dec := json.NewDecoder(strings.NewReader(paramstr))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(p); err != nil {
+		return nil, fmt.Errorf("unmarshaling input into params type %s of method %s: %w", methodMeta.Params.Elem().Name(), methodMeta.Name, err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after the params of method %s", methodMeta.Name)
 	}
 
 	buf := new(bytes.Buffer)
@@ -0,0 +1,68 @@
+//stm: #unit
+package cli
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	builtin2 "github.com/filecoin-project/specs-actors/v2/actors/builtin"
+	msig2 "github.com/filecoin-project/specs-actors/v2/actors/builtin/multisig"
+
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestEncodeTypedParams(t *testing.T) {
+	ctx := context.Background()
+
+	srvcs, mockApi := setupMockSrvcs(t)
+	defer srvcs.Close() //nolint:errcheck
+
+	code, ok := actors.GetActorCodeID(actors.Version7, actors.MultisigKey)
+	require.True(t, ok)
+
+	msig, _ := address.NewIDAddress(1000)
+	signer, _ := address.NewIDAddress(1001)
+	mockApi.EXPECT().StateGetActor(gomock.Any(), msig, types.EmptyTSK).Return(&types.Actor{Code: code}, nil).AnyTimes()
+
+	// methods resolve by number or name
+	m, err := ResolveMethod(ctx, mockApi, msig, "5")
+	require.NoError(t, err)
+	require.Equal(t, abi.MethodNum(5), m)
+
+	m, err = ResolveMethod(ctx, mockApi, msig, "addsigner")
+	require.NoError(t, err)
+	require.Equal(t, builtin2.MethodsMultisig.AddSigner, m)
+
+	_, err = ResolveMethod(ctx, mockApi, msig, "NoSuchMethod")
+	require.Error(t, err)
+
+	// the JSON params encode like the params type
+	var exp bytes.Buffer
+	require.NoError(t, (&msig2.AddSignerParams{Signer: signer, Increase: true}).MarshalCBOR(&exp))
+
+	p, err := EncodeTypedParams(ctx, mockApi, msig, m, `{"Signer": "`+signer.String()+`", "Increase": true}`)
+	require.NoError(t, err)
+	require.Equal(t, exp.Bytes(), p)
+
+	// hex passes through
+	p, err = EncodeTypedParams(ctx, mockApi, msig, m, "8200f5")
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x82, 0x00, 0xf5}, p)
+
+	// unknown fields and trailing data are rejected
+	_, err = EncodeTypedParams(ctx, mockApi, msig, m, `{"Signer": "`+signer.String()+`", "Increse": true}`)
+	require.Error(t, err)
+
+	_, err = EncodeTypedParams(ctx, mockApi, msig, m, `{"Signer": "`+signer.String()+`"} {}`)
+	require.Error(t, err)
+
+	_, err = EncodeTypedParams(ctx, mockApi, msig, m, "not params")
+	require.Error(t, err)
+}
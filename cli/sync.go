@@ -2,16 +2,20 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-state-types/abi"
 
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/api/v0api"
+	"github.com/filecoin-project/lotus/api/v1api"
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/types"
 )
@@ -26,6 +30,7 @@ var SyncCmd = &cli.Command{
 		SyncUnmarkBadCmd,
 		SyncCheckBadCmd,
 		SyncCheckpointCmd,
+		SyncCheckpointStatusCmd,
 	},
 }
 
@@ -76,6 +81,7 @@ var SyncStatusCmd = &cli.Command{
 			} else {
 				afmt.Printf("\tElapsed: %s\n", ss.End.Sub(ss.Start))
 			}
+			afmt.Printf("\tTime: %s\n", formatSyncTimings(ss.Timings))
 			if ss.Stage == api.StageSyncErrored {
 				afmt.Printf("\tError: %s\n", ss.Message)
 			}
@@ -94,14 +100,14 @@ var SyncWaitCmd = &cli.Command{
 		},
 	},
 	Action: func(cctx *cli.Context) error {
-		napi, closer, err := GetFullNodeAPI(cctx)
+		napi, closer, err := GetFullNodeAPIV1(cctx)
 		if err != nil {
 			return err
 		}
 		defer closer()
 		ctx := ReqContext(cctx)
 
-		return SyncWait(ctx, napi, cctx.Bool("watch"))
+		return SyncWaitStream(ctx, napi, cctx.Bool("watch"))
 	},
 }
 
@@ -212,8 +218,44 @@ var SyncCheckpointCmd = &cli.Command{
 			Name:  "epoch",
 			Usage: "checkpoint the tipset at the given epoch",
 		},
+		&cli.StringFlag{
+			Name:  "signed",
+			Usage: "checkpoint the tipset designated by the signed checkpoint in the given JSON file, after verifying the authority signatures",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		if cctx.IsSet("signed") {
+			if cctx.IsSet("epoch") || cctx.Args().Present() {
+				return fmt.Errorf("--signed can't be combined with --epoch or a tipset key")
+			}
+
+			b, err := os.ReadFile(cctx.String("signed"))
+			if err != nil {
+				return xerrors.Errorf("reading signed checkpoint: %w", err)
+			}
+
+			var cp api.SignedCheckpoint
+			if err := json.Unmarshal(b, &cp); err != nil {
+				return xerrors.Errorf("decoding signed checkpoint: %w", err)
+			}
+
+			napi, closer, err := GetFullNodeAPIV1(cctx)
+			if err != nil {
+				return err
+			}
+			defer closer()
+			ctx := ReqContext(cctx)
+
+			if err := napi.SyncCheckpointSigned(ctx, &cp); err != nil {
+				return err
+			}
+
+			afmt.Printf("Checkpointed tipset %s at height %d (%d signatures)\n", cp.TipSet, cp.Height, len(cp.Signatures))
+			return nil
+		}
+
 		napi, closer, err := GetFullNodeAPI(cctx)
 		if err != nil {
 			return err
@@ -224,6 +266,9 @@ var SyncCheckpointCmd = &cli.Command{
 		var ts *types.TipSet
 
 		if cctx.IsSet("epoch") {
+			if cctx.Args().Present() {
+				return fmt.Errorf("--epoch can't be combined with a tipset key")
+			}
 			ts, err = napi.ChainGetTipSetByHeight(ctx, abi.ChainEpoch(cctx.Uint64("epoch")), types.EmptyTSK)
 		}
 		if ts == nil {
@@ -241,6 +286,55 @@ var SyncCheckpointCmd = &cli.Command{
 			return err
 		}
 
+		afmt.Printf("Checkpointed tipset %s at height %d\n", ts.Key(), ts.Height())
+		return nil
+	},
+}
+
+var SyncCheckpointStatusCmd = &cli.Command{
+	Name:  "checkpoint-status",
+	Usage: "show the checkpoint authority set and the latest authority checkpoint",
+	Action: func(cctx *cli.Context) error {
+		afmt := NewAppFmt(cctx.App)
+
+		napi, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		st, err := napi.SyncCheckpointFeedStatus(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(st.Authorities) == 0 {
+			afmt.Println("No checkpoint authorities configured")
+		} else {
+			afmt.Printf("Authorities (%d of %d must sign):\n", st.Threshold, len(st.Authorities))
+			for _, a := range st.Authorities {
+				afmt.Printf("\t%s\n", a)
+			}
+		}
+
+		if st.URL != "" {
+			afmt.Printf("Feed: %s\n", st.URL)
+			if !st.LastPoll.IsZero() {
+				afmt.Printf("Last poll: %s\n", st.LastPoll.Format(time.RFC3339))
+			}
+			if st.LastError != "" {
+				afmt.Printf("Last error: %s\n", st.LastError)
+			}
+		}
+
+		if st.Latest != nil {
+			afmt.Printf("Latest checkpoint: %s at height %d (%d signatures)\n", st.Latest.TipSet, st.Latest.Height, len(st.Latest.Signatures))
+			if !st.AppliedAt.IsZero() {
+				afmt.Printf("Applied: %s\n", st.AppliedAt.Format(time.RFC3339))
+			}
+		}
+
 		return nil
 	},
 }
@@ -319,7 +413,8 @@ func SyncWait(ctx context.Context, napi v0api.FullNode, watch bool) error {
 
 		fmt.Printf("Worker: %d; Base: %d; Target: %d (diff: %d)\n", workerID, baseHeight, theight, heightDiff)
 		fmt.Printf("State: %s; Current Epoch: %d; Todo: %d\n", ss.Stage, ss.Height, theight-ss.Height)
-		lastLines = 2
+		fmt.Printf("Time: %s\n", formatSyncTimings(ss.Timings))
+		lastLines = 3
 
 		if i%samples == 0 {
 			lastApp = app
@@ -347,3 +442,72 @@ func SyncWait(ctx context.Context, napi v0api.FullNode, watch bool) error {
 		i++
 	}
 }
+
+// SyncWaitStream waits for the node to sync like SyncWait, following the
+// progress updates streamed by SyncSubscribe to show where sync time is spent
+func SyncWaitStream(ctx context.Context, napi v1api.FullNode, watch bool) error {
+	progress, err := napi.SyncSubscribe(ctx)
+	if err != nil {
+		return err
+	}
+
+	tick := time.Second / 4
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	lastLines := 0
+	var last api.SyncProgress
+	var total api.SyncStageTimings
+	var synced int
+
+	for {
+		select {
+		case p, ok := <-progress:
+			if !ok {
+				return xerrors.Errorf("sync progress stream closed")
+			}
+
+			switch p.Stage {
+			case api.StagePersistHeaders:
+				total.Add(p.Timings)
+			case api.StageMessages:
+				total.Add(p.Timings)
+				last = p
+				synced++
+			case api.StageSyncErrored:
+				fmt.Printf("\nWorker %d failed to sync: %s\n", p.WorkerID, p.Error)
+				lastLines = 0
+			}
+			continue
+		case <-ctx.Done():
+			fmt.Println("\nExit by user")
+			return nil
+		case <-ticker.C:
+		}
+
+		head, err := napi.ChainHead(ctx)
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < lastLines; i++ {
+			fmt.Print("\r\x1b[2K\x1b[A")
+		}
+
+		fmt.Printf("Worker: %d; Head: %d; Synced: %d tipsets\n", last.WorkerID, head.Height(), synced)
+		fmt.Printf("Last tipset (%d): %s\n", last.Height, formatSyncTimings(last.Timings))
+		fmt.Printf("Total: %s\n", formatSyncTimings(total))
+		lastLines = 3
+
+		if !watch && time.Now().Unix()-int64(head.MinTimestamp()) < int64(build.BlockDelaySecs) {
+			fmt.Println("\nDone!")
+			return nil
+		}
+	}
+}
+
+func formatSyncTimings(t api.SyncStageTimings) string {
+	return fmt.Sprintf("fetch %s, validate messages %s, compute state %s, persist %s",
+		t.Fetch.Truncate(time.Millisecond), t.ValidateMessages.Truncate(time.Millisecond),
+		t.ComputeState.Truncate(time.Millisecond), t.Persist.Truncate(time.Millisecond))
+}
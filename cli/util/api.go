@@ -21,6 +21,8 @@ import (
 	"github.com/filecoin-project/lotus/api/client"
 	"github.com/filecoin-project/lotus/api/v0api"
 	"github.com/filecoin-project/lotus/api/v1api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/lib/apitls"
 	"github.com/filecoin-project/lotus/node/repo"
 )
 
@@ -130,6 +132,10 @@ func GetRawAPI(ctx *cli.Context, t repo.RepoType, version string) (string, http.
 		return "", nil, xerrors.Errorf("could not get DialArgs: %w", err)
 	}
 
+	if err := apitls.SetupClient(); err != nil {
+		return "", nil, err
+	}
+
 	if IsVeryVerbose {
 		_, _ = fmt.Fprintf(ctx.App.Writer, "using raw API %s endpoint: %s\n", version, addr)
 	}
@@ -185,7 +191,31 @@ func GetFullNodeAPI(ctx *cli.Context) (v0api.FullNode, jsonrpc.ClientCloser, err
 	return client.NewFullNodeRPCV0(ctx.Context, addr, headers)
 }
 
-func GetFullNodeAPIV1(ctx *cli.Context) (v1api.FullNode, jsonrpc.ClientCloser, error) {
+type GetFullNodeOptions struct {
+	CacheCaller string
+}
+
+type GetFullNodeOption func(*GetFullNodeOptions)
+
+// FullNodeCacheCaller identifies the client to the full node as the given
+// block cache caller, see blockstore.CacheCallerHeader
+func FullNodeCacheCaller(caller string) GetFullNodeOption {
+	return func(opts *GetFullNodeOptions) {
+		opts.CacheCaller = caller
+	}
+}
+
+func GetFullNodeAPIV1(ctx *cli.Context, opts ...GetFullNodeOption) (v1api.FullNode, jsonrpc.ClientCloser, error) {
+	var options GetFullNodeOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	// use the mocked API in CLI unit tests, see cli/mocks_test.go for mock definition
+	if mock, ok := ctx.App.Metadata["test-full-api"]; ok {
+		return mock.(v1api.FullNode), func() {}, nil
+	}
+
 	if tn, ok := ctx.App.Metadata["testnode-full"]; ok {
 		return tn.(v1api.FullNode), func() {}, nil
 	}
@@ -195,6 +225,13 @@ func GetFullNodeAPIV1(ctx *cli.Context) (v1api.FullNode, jsonrpc.ClientCloser, e
 		return nil, nil, err
 	}
 
+	if options.CacheCaller != "" {
+		if headers == nil {
+			headers = http.Header{}
+		}
+		headers.Set(blockstore.CacheCallerHeader, options.CacheCaller)
+	}
+
 	if IsVeryVerbose {
 		_, _ = fmt.Fprintln(ctx.App.Writer, "using full node API v1 endpoint:", addr)
 	}
@@ -211,6 +248,21 @@ func GetFullNodeAPIV1(ctx *cli.Context) (v1api.FullNode, jsonrpc.ClientCloser, e
 	if !v.APIVersion.EqMajorMinor(api.FullAPIVersion1) {
 		return nil, nil, xerrors.Errorf("Remote API version didn't match (expected %s, remote %s)", api.FullAPIVersion1, v.APIVersion)
 	}
+
+	// keep the subscriptions going over reconnections of the websocket
+	v1API = client.ResumeSubscriptions(v1API, client.ResumeConfig{
+		OnStateChange: func(method string, state client.ConnState, err error) {
+			switch state {
+			case client.ConnLost:
+				log.Warnw("full node API subscription lost its connection, resubscribing", "method", method)
+			case client.ConnRetry:
+				log.Debugw("full node API subscription attempt failed", "method", method, "error", err)
+			case client.ConnResumed:
+				log.Infow("full node API subscription resumed", "method", method)
+			}
+		},
+	})
+
 	return v1API, closer, nil
 }
 
@@ -252,7 +304,8 @@ func GetStorageMinerAPI(ctx *cli.Context, opts ...GetStorageMinerOption) (api.St
 			u.Scheme = "https"
 		}
 
-		addr = u.String()
+		// only the websocket client presents the client certificate
+		addr = apitls.RPCURL(u.String())
 	}
 
 	if IsVeryVerbose {
@@ -296,6 +349,26 @@ func GetMarketsAPI(ctx *cli.Context) (api.StorageMiner, jsonrpc.ClientCloser, er
 	return client.NewStorageMinerRPCV0(ctx.Context, addr, headers)
 }
 
+func GetProvingAPI(ctx *cli.Context) (api.StorageMiner, jsonrpc.ClientCloser, error) {
+	// to support lotus-miner cli tests.
+	if tn, ok := ctx.App.Metadata["testnode-storage"]; ok {
+		return tn.(api.StorageMiner), func() {}, nil
+	}
+
+	addr, headers, err := GetRawAPI(ctx, repo.Proving, "v0")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if IsVeryVerbose {
+		_, _ = fmt.Fprintln(ctx.App.Writer, "using proving API v0 endpoint:", addr)
+	}
+
+	// the proving service is a specialised miner's node, running WindowPoSt
+	// for the mining node
+	return client.NewStorageMinerRPCV0(ctx.Context, addr, headers)
+}
+
 func GetGatewayAPI(ctx *cli.Context) (api.Gateway, jsonrpc.ClientCloser, error) {
 	addr, headers, err := GetRawAPI(ctx, repo.FullNode, "v1")
 	if err != nil {
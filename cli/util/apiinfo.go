@@ -9,6 +9,8 @@ import (
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/multiformats/go-multiaddr"
 	manet "github.com/multiformats/go-multiaddr/net"
+
+	"github.com/filecoin-project/lotus/lib/apitls"
 )
 
 var log = logging.Logger("cliutil")
@@ -44,6 +46,9 @@ func (a APIInfo) DialArgs(version string) (string, error) {
 			return "", err
 		}
 
+		if apitls.IsTLSEndpoint(ma) {
+			return "wss://" + addr + "/rpc/" + version, nil
+		}
 		return "ws://" + addr + "/rpc/" + version, nil
 	}
 
@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	lapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/lib/tablewriter"
+)
+
+var watchlistCmd = &cli.Command{
+	Name:  "watchlist",
+	Usage: "Manage addresses whose balances are monitored by the node",
+	Subcommands: []*cli.Command{
+		watchlistAddCmd,
+		watchlistRemoveCmd,
+		watchlistListCmd,
+	},
+}
+
+var watchlistAddCmd = &cli.Command{
+	Name:      "add",
+	Usage:     "Add an address to the watchlist, or update the conditions of a watched address",
+	ArgsUsage: "[address]",
+	Description: `Violations of the specified conditions are raised as node alerts,
+   which can be inspected with 'lotus log alerts'.
+
+   eg) lotus watchlist add --label owner --min-balance 10 f3...
+       lotus watchlist add --max-change 100 --change-window 2880 f1...`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "label",
+			Usage: "human readable label for the address",
+		},
+		&cli.StringFlag{
+			Name:  "min-balance",
+			Usage: "raise an alert when the balance falls below this amount (FIL)",
+		},
+		&cli.StringFlag{
+			Name:  "max-balance",
+			Usage: "raise an alert when the balance exceeds this amount (FIL)",
+		},
+		&cli.StringFlag{
+			Name:  "max-change",
+			Usage: "raise an alert when the balance changes by more than this amount (FIL) within the change window",
+		},
+		&cli.Int64Flag{
+			Name:  "change-window",
+			Usage: "number of epochs over which the balance change is measured",
+			Value: 2880,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return ShowHelp(cctx, fmt.Errorf("incorrect number of arguments"))
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		addr, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("parsing address: %w", err)
+		}
+
+		entry := lapi.WatchlistEntry{
+			Address:      addr,
+			Label:        cctx.String("label"),
+			MinBalance:   big.Zero(),
+			MaxBalance:   big.Zero(),
+			MaxChange:    big.Zero(),
+			ChangeWindow: abi.ChainEpoch(cctx.Int64("change-window")),
+		}
+
+		for flag, amt := range map[string]*abi.TokenAmount{
+			"min-balance": &entry.MinBalance,
+			"max-balance": &entry.MaxBalance,
+			"max-change":  &entry.MaxChange,
+		} {
+			if !cctx.IsSet(flag) {
+				continue
+			}
+
+			f, err := types.ParseFIL(cctx.String(flag))
+			if err != nil {
+				return xerrors.Errorf("parsing %s: %w", flag, err)
+			}
+			*amt = abi.TokenAmount(f)
+		}
+
+		return api.WatchlistAdd(ctx, entry)
+	},
+}
+
+var watchlistRemoveCmd = &cli.Command{
+	Name:      "remove",
+	Usage:     "Remove an address from the watchlist",
+	ArgsUsage: "[address]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return ShowHelp(cctx, fmt.Errorf("incorrect number of arguments"))
+		}
+
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		addr, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("parsing address: %w", err)
+		}
+
+		return api.WatchlistRemove(ctx, addr)
+	},
+}
+
+var watchlistListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "List watched addresses and their status",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPIV1(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		statuses, err := api.WatchlistList(ctx)
+		if err != nil {
+			return err
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("Address"),
+			tablewriter.Col("Label"),
+			tablewriter.Col("Height"),
+			tablewriter.Col("Balance"),
+			tablewriter.Col("Change"),
+			tablewriter.Col("Conditions"),
+			tablewriter.NewLineCol("Violations"))
+
+		for _, st := range statuses {
+			row := map[string]interface{}{
+				"Address":    st.Entry.Address,
+				"Label":      st.Entry.Label,
+				"Height":     st.Height,
+				"Balance":    types.FIL(st.Balance),
+				"Change":     types.FIL(st.Change),
+				"Conditions": watchlistConditions(&st.Entry),
+			}
+			if len(st.Violations) > 0 {
+				row["Violations"] = strings.Join(st.Violations, "; ")
+			}
+			tw.Write(row)
+		}
+
+		return tw.Flush(os.Stdout)
+	},
+}
+
+func watchlistConditions(e *lapi.WatchlistEntry) string {
+	var conds []string
+	if !e.MinBalance.Nil() && !e.MinBalance.IsZero() {
+		conds = append(conds, fmt.Sprintf("min %s", types.FIL(e.MinBalance)))
+	}
+	if !e.MaxBalance.Nil() && !e.MaxBalance.IsZero() {
+		conds = append(conds, fmt.Sprintf("max %s", types.FIL(e.MaxBalance)))
+	}
+	if !e.MaxChange.Nil() && !e.MaxChange.IsZero() {
+		conds = append(conds, fmt.Sprintf("change %s / %d epochs", types.FIL(e.MaxChange), e.ChangeWindow))
+	}
+	return strings.Join(conds, ", ")
+}
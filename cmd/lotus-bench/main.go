@@ -107,6 +107,9 @@ func main() {
 			sealBenchCmd,
 			simpleCmd,
 			importBenchCmd,
+			pipelineBenchCmd,
+			postBenchCmd,
+			rpcBenchCmd,
 		},
 	}
 
@@ -0,0 +1,483 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/google/uuid"
+	"github.com/ipfs/go-datastore"
+	"github.com/minio/blake2b-simd"
+	"github.com/mitchellh/go-homedir"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-paramfetch"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-statestore"
+
+	"github.com/filecoin-project/lotus/build"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/storage/paths"
+	"github.com/filecoin-project/lotus/storage/sealer"
+	"github.com/filecoin-project/lotus/storage/sealer/fsutil"
+	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+// pipelineStages lists the sealing stages in the order they run in
+var pipelineStages = []string{"AP", "PC1", "PC2", "C1", "C2"}
+
+// pipelineStageTasks maps the stages to the tasks of the benchmark workers.
+// C1 runs on the builtin worker of the manager, as on a miner.
+var pipelineStageTasks = map[string]sealtasks.TaskType{
+	"AP":  sealtasks.TTAddPiece,
+	"PC1": sealtasks.TTPreCommit1,
+	"PC2": sealtasks.TTPreCommit2,
+	"C2":  sealtasks.TTCommit2,
+}
+
+type PipelineResults struct {
+	EnvVar map[string]string
+
+	SectorSize abi.SectorSize
+	Sectors    int
+	Workers    int
+	Stages     []string
+
+	// Elapsed is the time taken to run all the sectors through the stages
+	Elapsed       time.Duration
+	SectorsPerDay float64
+
+	// StageAverage is the average time taken by the tasks of each stage,
+	// including the time spent waiting for the scheduler
+	StageAverage map[string]time.Duration
+}
+
+var pipelineBenchCmd = &cli.Command{
+	Name:  "pipeline",
+	Usage: "Benchmark the sealing pipeline running sectors concurrently through the sealing scheduler",
+	Description: `Seals synthetic sectors concurrently through the stages, with the tasks
+   scheduled by the sealing scheduler of the miner on local workers, and reports
+   the number of sectors per day the workers can seal.
+
+   The hardware of the workers can be set with --cpus, --memory and --gpus, to
+   evaluate a machine other than the one running the benchmark. The resources
+   of the tasks are set with the same environment variables as on lotus-worker,
+   e.g. PC1_32G_MAX_CONCURRENT=4. The estimate gets closer to the steady state
+   of the pipeline with more sectors.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "storage-dir",
+			Value: "~/.lotus-bench",
+			Usage: "path to the storage directory that will store sectors during the benchmark",
+		},
+		&cli.StringFlag{
+			Name:  "sector-size",
+			Value: "512MiB",
+			Usage: "size of the sectors in bytes, i.e. 32GiB",
+		},
+		&cli.StringFlag{
+			Name:  "miner-addr",
+			Value: "t01000",
+			Usage: "miner address the sectors are sealed for",
+		},
+		&cli.IntFlag{
+			Name:  "num-sectors",
+			Usage: "number of sectors to seal",
+			Value: 4,
+		},
+		&cli.StringFlag{
+			Name:  "stages",
+			Usage: "comma separated stages to run the sectors through, starting with AP",
+			Value: strings.Join(pipelineStages, ","),
+		},
+		&cli.IntFlag{
+			Name:  "workers",
+			Usage: "number of workers with the hardware of the profile",
+			Value: 1,
+		},
+		&cli.Uint64Flag{
+			Name:  "cpus",
+			Usage: "number of cpus of each worker, defaults to the cpus of this machine",
+		},
+		&cli.StringFlag{
+			Name:  "memory",
+			Usage: "memory of each worker, i.e. 256GiB, defaults to the memory of this machine",
+		},
+		&cli.IntFlag{
+			Name:  "gpus",
+			Usage: "number of gpus of each worker, defaults to the gpus of this machine",
+		},
+		&cli.BoolFlag{
+			Name:  "json-out",
+			Usage: "output results in json format",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		ctx := lcli.ReqContext(c)
+
+		stages, err := parsePipelineStages(c.String("stages"))
+		if err != nil {
+			return err
+		}
+
+		maddr, err := address.NewFromString(c.String("miner-addr"))
+		if err != nil {
+			return err
+		}
+		amid, err := address.IDFromAddress(maddr)
+		if err != nil {
+			return err
+		}
+
+		sectorSizeInt, err := units.RAMInBytes(c.String("sector-size"))
+		if err != nil {
+			return err
+		}
+		sectorSize := abi.SectorSize(sectorSizeInt)
+
+		numSectors := c.Int("num-sectors")
+		if numSectors < 1 || c.Int("workers") < 1 {
+			return xerrors.Errorf("the benchmark needs at least one sector and one worker")
+		}
+
+		profile := workerProfile{
+			cpus: c.Uint64("cpus"),
+			gpus: -1,
+		}
+		if c.IsSet("memory") {
+			mem, err := units.RAMInBytes(c.String("memory"))
+			if err != nil {
+				return xerrors.Errorf("parsing memory: %w", err)
+			}
+			profile.memory = uint64(mem)
+		}
+		if c.IsSet("gpus") {
+			profile.gpus = c.Int("gpus")
+		}
+
+		if stages[len(stages)-1] == "C2" {
+			if err := paramfetch.GetParams(ctx, build.ParametersJSON(), build.SrsJSON(), uint64(sectorSize)); err != nil {
+				return xerrors.Errorf("getting params: %w", err)
+			}
+		}
+
+		sdir, err := homedir.Expand(c.String("storage-dir"))
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(sdir, 0775); err != nil { //nolint:gosec
+			return xerrors.Errorf("creating storage dir: %w", err)
+		}
+		tsdir, err := ioutil.TempDir(sdir, "pipeline")
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := os.RemoveAll(tsdir); err != nil {
+				log.Warn("remove all: ", err)
+			}
+		}()
+
+		m, err := newPipelineManager(ctx, tsdir, stages, c.Int("workers"), profile)
+		if err != nil {
+			return err
+		}
+		defer m.Close(ctx) //nolint:errcheck
+
+		res := PipelineResults{
+			EnvVar:       map[string]string{},
+			SectorSize:   sectorSize,
+			Sectors:      numSectors,
+			Workers:      c.Int("workers"),
+			Stages:       stages,
+			StageAverage: map[string]time.Duration{},
+		}
+		for _, kv := range os.Environ() {
+			envKey, envValue := kv, ""
+			if i := strings.Index(kv, "="); i >= 0 {
+				envKey, envValue = kv[:i], kv[i+1:]
+			}
+			if strings.HasPrefix(envKey, "FIL_PROOFS_") || strings.HasPrefix(envKey, "BELLMAN_") {
+				res.EnvVar[envKey] = envValue
+			}
+			// Resources of the tasks, e.g. PC1_32G_MAX_CONCURRENT
+			for _, stage := range pipelineStages {
+				if strings.HasPrefix(envKey, stage+"_") {
+					res.EnvVar[envKey] = envValue
+				}
+			}
+		}
+
+		var lk sync.Mutex
+		stageSum := map[string]time.Duration{}
+
+		start := time.Now()
+		errs := make(chan error, numSectors)
+		for i := 0; i < numSectors; i++ {
+			sid := storiface.SectorRef{
+				ID: abi.SectorID{
+					Miner:  abi.ActorID(amid),
+					Number: abi.SectorNumber(i),
+				},
+				ProofType: spt(sectorSize),
+			}
+
+			go func() {
+				timings, err := runPipelineSector(ctx, m, sid, stages)
+				if err != nil {
+					errs <- xerrors.Errorf("sector %d: %w", sid.ID.Number, err)
+					return
+				}
+
+				lk.Lock()
+				for stage, d := range timings {
+					stageSum[stage] += d
+				}
+				lk.Unlock()
+				errs <- nil
+			}()
+		}
+		for i := 0; i < numSectors; i++ {
+			if err := <-errs; err != nil {
+				return err
+			}
+		}
+		res.Elapsed = time.Since(start)
+
+		res.SectorsPerDay = float64(numSectors) * float64(24*time.Hour) / float64(res.Elapsed)
+		for stage, d := range stageSum {
+			res.StageAverage[stage] = d / time.Duration(numSectors)
+		}
+
+		if c.Bool("json-out") {
+			data, err := json.MarshalIndent(res, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+
+		fmt.Println("environment variable list:")
+		for envKey, envValue := range res.EnvVar {
+			fmt.Printf("%s=%s\n", envKey, envValue)
+		}
+		fmt.Printf("----\nresults (v28) SectorSize:(%d), SectorNumber:(%d), Workers:(%d)\n", sectorSize, numSectors, res.Workers)
+		for _, stage := range stages {
+			fmt.Printf("%s: %s average\n", stage, res.StageAverage[stage])
+		}
+		fmt.Printf("\nsealed %d sectors in %s (%s)\n", numSectors, res.Elapsed, bps(sectorSize, numSectors, res.Elapsed))
+		fmt.Printf("achievable: %.2f sectors/day\n", res.SectorsPerDay)
+		return nil
+	},
+}
+
+func parsePipelineStages(s string) ([]string, error) {
+	stages := strings.Split(strings.ToUpper(s), ",")
+	if len(stages) > len(pipelineStages) {
+		return nil, xerrors.Errorf("too many stages: %s", s)
+	}
+	// Each stage needs the output of the previous one
+	for i, stage := range stages {
+		if strings.TrimSpace(stage) != pipelineStages[i] {
+			return nil, xerrors.Errorf("stages must be the first stages of %s in order, got %s", strings.Join(pipelineStages, ","), s)
+		}
+		stages[i] = pipelineStages[i]
+	}
+	return stages, nil
+}
+
+// runPipelineSector seals the sector through the stages, and returns the time
+// taken by each stage
+func runPipelineSector(ctx context.Context, m *sealer.Manager, sid storiface.SectorRef, stages []string) (map[string]time.Duration, error) {
+	timings := map[string]time.Duration{}
+	has := func(stage string) bool {
+		for _, s := range stages {
+			if s == stage {
+				return true
+			}
+		}
+		return false
+	}
+
+	ssize, err := sid.ProofType.SectorSize()
+	if err != nil {
+		return nil, err
+	}
+
+	trand := blake2b.Sum256([]byte(fmt.Sprintf("ticket-%d", sid.ID.Number)))
+	ticket := abi.SealRandomness(trand[:])
+	srand := blake2b.Sum256([]byte(fmt.Sprintf("seed-%d", sid.ID.Number)))
+	seed := abi.InteractiveSealRandomness(srand[:])
+
+	start := time.Now()
+	r := rand.New(rand.NewSource(100 + int64(sid.ID.Number)))
+	pi, err := m.AddPiece(ctx, sid, nil, abi.PaddedPieceSize(ssize).Unpadded(), r)
+	if err != nil {
+		return nil, xerrors.Errorf("add piece: %w", err)
+	}
+	timings["AP"] = time.Since(start)
+	pieces := []abi.PieceInfo{pi}
+
+	if !has("PC1") {
+		return timings, nil
+	}
+	start = time.Now()
+	pc1o, err := m.SealPreCommit1(ctx, sid, ticket, pieces)
+	if err != nil {
+		return nil, xerrors.Errorf("precommit1: %w", err)
+	}
+	timings["PC1"] = time.Since(start)
+
+	if !has("PC2") {
+		return timings, nil
+	}
+	start = time.Now()
+	cids, err := m.SealPreCommit2(ctx, sid, pc1o)
+	if err != nil {
+		return nil, xerrors.Errorf("precommit2: %w", err)
+	}
+	timings["PC2"] = time.Since(start)
+
+	if !has("C1") {
+		return timings, nil
+	}
+	start = time.Now()
+	c1o, err := m.SealCommit1(ctx, sid, ticket, seed, pieces, cids)
+	if err != nil {
+		return nil, xerrors.Errorf("commit1: %w", err)
+	}
+	timings["C1"] = time.Since(start)
+
+	if !has("C2") {
+		return timings, nil
+	}
+	start = time.Now()
+	if _, err := m.SealCommit2(ctx, sid, c1o); err != nil {
+		return nil, xerrors.Errorf("commit2: %w", err)
+	}
+	timings["C2"] = time.Since(start)
+
+	return timings, nil
+}
+
+// newPipelineManager creates a sealing manager with storage in dir, and the
+// workers running the tasks of the stages
+func newPipelineManager(ctx context.Context, dir string, stages []string, workers int, profile workerProfile) (*sealer.Manager, error) {
+	b, err := json.MarshalIndent(&paths.LocalStorageMeta{
+		ID:       storiface.ID(uuid.New().String()),
+		Weight:   1,
+		CanSeal:  true,
+		CanStore: true,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "sectorstore.json"), b, 0644); err != nil {
+		return nil, err
+	}
+
+	ls := &benchStorage{StoragePaths: []paths.LocalPath{{Path: dir}}}
+	si := paths.NewIndex(nil)
+	lstor, err := paths.NewLocal(ctx, ls, si, nil)
+	if err != nil {
+		return nil, err
+	}
+	stor := paths.NewRemote(lstor, si, nil, 6000, &paths.DefaultPartialFileHandler{})
+
+	m, err := sealer.New(ctx, lstor, stor, ls, si, sealer.Config{
+		ParallelFetchLimit: 10,
+	}, statestore.New(datastore.NewMapDatastore()), statestore.New(datastore.NewMapDatastore()))
+	if err != nil {
+		return nil, xerrors.Errorf("creating manager: %w", err)
+	}
+
+	var tasks []sealtasks.TaskType
+	for _, stage := range stages {
+		if tt, ok := pipelineStageTasks[stage]; ok {
+			tasks = append(tasks, tt)
+		}
+	}
+	for i := 0; i < workers; i++ {
+		w := sealer.NewLocalWorker(sealer.WorkerConfig{TaskTypes: tasks}, stor, lstor, si, m, statestore.New(datastore.NewMapDatastore()))
+		if err := m.AddWorker(ctx, &profiledWorker{LocalWorker: w, profile: profile}); err != nil {
+			return nil, xerrors.Errorf("adding worker: %w", err)
+		}
+	}
+
+	return m, nil
+}
+
+type workerProfile struct {
+	cpus   uint64
+	memory uint64
+	// gpus is -1 to use the gpus of this machine
+	gpus int
+}
+
+// profiledWorker is a local worker which reports the hardware of the profile
+// to the scheduler, instead of the hardware of this machine
+type profiledWorker struct {
+	*sealer.LocalWorker
+	profile workerProfile
+}
+
+func (w *profiledWorker) Info(ctx context.Context) (storiface.WorkerInfo, error) {
+	info, err := w.LocalWorker.Info(ctx)
+	if err != nil {
+		return info, err
+	}
+
+	if w.profile.cpus > 0 {
+		info.Resources.CPUs = w.profile.cpus
+	}
+	if w.profile.memory > 0 {
+		info.Resources.MemPhysical = w.profile.memory
+		info.Resources.MemUsed = 0
+		info.Resources.MemSwap = 0
+		info.Resources.MemSwapUsed = 0
+	}
+	if w.profile.gpus >= 0 {
+		info.Resources.GPUs = make([]string, w.profile.gpus)
+		for i := range info.Resources.GPUs {
+			info.Resources.GPUs[i] = fmt.Sprintf("bench-gpu-%d", i)
+		}
+	}
+	return info, nil
+}
+
+type benchStorage paths.StorageConfig
+
+func (s *benchStorage) GetStorage() (paths.StorageConfig, error) {
+	return paths.StorageConfig(*s), nil
+}
+
+func (s *benchStorage) SetStorage(f func(*paths.StorageConfig)) error {
+	f((*paths.StorageConfig)(s))
+	return nil
+}
+
+func (s *benchStorage) Stat(path string) (fsutil.FsStat, error) {
+	return fsutil.Statfs(path)
+}
+
+func (s *benchStorage) DiskUsage(path string) (int64, error) {
+	si, err := fsutil.FileSize(path)
+	if err != nil {
+		return 0, err
+	}
+	return si.OnDisk, nil
+}
+
+var _ paths.LocalStorage = &benchStorage{}
@@ -0,0 +1,433 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	dsq "github.com/ipfs/go-datastore/query"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-paramfetch"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin"
+
+	"github.com/filecoin-project/lotus/build"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/node/repo"
+	"github.com/filecoin-project/lotus/storage/paths"
+	pipeline "github.com/filecoin-project/lotus/storage/pipeline"
+	"github.com/filecoin-project/lotus/storage/sealer/ffiwrapper"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+type PoStPartitionResult struct {
+	Partition int
+	Sectors   int
+
+	// Vanilla is the time taken to read the challenges of the sectors, and
+	// Snark the time taken to compute the proof from them
+	Vanilla time.Duration
+	Snark   time.Duration
+
+	// GPUUtilization is the utilization of the GPUs in percent sampled
+	// during the snark, when nvidia-smi is available
+	GPUUtilizationAvg float64 `json:",omitempty"`
+	GPUUtilizationMax float64 `json:",omitempty"`
+}
+
+type PoStBenchResults struct {
+	EnvVar map[string]string
+
+	Miner      address.Address
+	SectorSize abi.SectorSize
+	Sectors    int
+	GPUs       []string
+
+	WindowPoSt  []PoStPartitionResult
+	WinningPoSt *PoStPartitionResult `json:",omitempty"`
+}
+
+var postBenchCmd = &cli.Command{
+	Name:  "post",
+	Usage: "Benchmark WindowPoSt and WinningPoSt on the sealed sectors of a miner",
+	Description: `Computes proofs for the sectors of the miner which are proving, reading the
+   sealed sectors from the storage paths of the miner repo without modifying them,
+   and reports the time taken by each partition to read the challenges of its
+   sectors and to compute the snark.
+
+   The repo is opened read-only, but the miner must be stopped to open it.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "miner-repo",
+			EnvVars: []string{"LOTUS_MINER_PATH", "LOTUS_STORAGE_PATH"},
+			Value:   "~/.lotusminer",
+		},
+		&cli.IntFlag{
+			Name:  "partitions",
+			Usage: "number of WindowPoSt partitions to prove, 0 proves all the sectors",
+			Value: 1,
+		},
+		&cli.BoolFlag{
+			Name:  "skip-winning",
+			Usage: "skip the WinningPoSt",
+		},
+		&cli.BoolFlag{
+			Name:  "no-gpu",
+			Usage: "disable gpu usage for the benchmark run",
+		},
+		&cli.BoolFlag{
+			Name:  "json-out",
+			Usage: "output results in json format",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.Bool("no-gpu") {
+			err := os.Setenv("BELLMAN_NO_GPU", "1")
+			if err != nil {
+				return xerrors.Errorf("setting no-gpu flag: %w", err)
+			}
+		}
+
+		ctx := lcli.ReqContext(c)
+
+		r, err := repo.NewFS(c.String("miner-repo"))
+		if err != nil {
+			return xerrors.Errorf("opening fs repo: %w", err)
+		}
+		exists, err := r.Exists()
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return xerrors.Errorf("miner repo doesn't exist")
+		}
+
+		lr, err := r.LockRO(repo.StorageMiner)
+		if err != nil {
+			return xerrors.Errorf("locking miner repo, is the miner running?: %w", err)
+		}
+		defer lr.Close() //nolint:errcheck
+
+		mds, err := lr.Datastore(ctx, "/metadata")
+		if err != nil {
+			return err
+		}
+
+		mb, err := mds.Get(ctx, datastore.NewKey("miner-address"))
+		if err != nil {
+			return xerrors.Errorf("getting miner address: %w", err)
+		}
+		maddr, err := address.NewFromBytes(mb)
+		if err != nil {
+			return err
+		}
+		amid, err := address.IDFromAddress(maddr)
+		if err != nil {
+			return err
+		}
+		mid := abi.ActorID(amid)
+
+		sectors, err := provingSectors(ctx, mds)
+		if err != nil {
+			return err
+		}
+		if len(sectors) == 0 {
+			return xerrors.Errorf("the miner has no proving sectors")
+		}
+
+		ssize, err := sectors[0].SealProof.SectorSize()
+		if err != nil {
+			return err
+		}
+		wpt, err := sectors[0].SealProof.RegisteredWindowPoStProof()
+		if err != nil {
+			return err
+		}
+		partSize, err := builtin.PoStProofWindowPoStPartitionSectors(wpt)
+		if err != nil {
+			return err
+		}
+
+		if err := paramfetch.GetParams(ctx, build.ParametersJSON(), build.SrsJSON(), uint64(ssize)); err != nil {
+			return xerrors.Errorf("getting params: %w", err)
+		}
+
+		si := paths.NewIndex(nil)
+		lstor, err := paths.NewLocal(ctx, lr, si, nil)
+		if err != nil {
+			return xerrors.Errorf("opening storage paths: %w", err)
+		}
+
+		sb, err := ffiwrapper.New(nil)
+		if err != nil {
+			return err
+		}
+
+		gpus, err := ffi.GetGPUDevices()
+		if err != nil {
+			log.Warnf("getting gpu devices: %s", err)
+		}
+
+		res := PoStBenchResults{
+			EnvVar:     map[string]string{},
+			Miner:      maddr,
+			SectorSize: ssize,
+			Sectors:    len(sectors),
+			GPUs:       gpus,
+		}
+		for _, envKey := range []string{"BELLMAN_NO_GPU", "FIL_PROOFS_USE_GPU_COLUMN_BUILDER",
+			"FIL_PROOFS_USE_GPU_TREE_BUILDER", "BELLMAN_CUSTOM_GPU"} {
+			if envValue, found := os.LookupEnv(envKey); found {
+				res.EnvVar[envKey] = envValue
+			}
+		}
+
+		var randomness abi.PoStRandomness = make([]byte, 32)
+		if _, err := rand.Read(randomness); err != nil {
+			return err
+		}
+		randomness[31] &= 0x3f
+
+		nparts := (len(sectors) + int(partSize) - 1) / int(partSize)
+		if p := c.Int("partitions"); p > 0 && p < nparts {
+			nparts = p
+		}
+		for part := 0; part < nparts; part++ {
+			end := (part + 1) * int(partSize)
+			if end > len(sectors) {
+				end = len(sectors)
+			}
+
+			log.Infof("proving partition %d", part)
+			pr, err := benchPoStPartition(ctx, sb, lstor, mid, wpt, sectors[part*int(partSize):end], part, randomness, true)
+			if err != nil {
+				return xerrors.Errorf("partition %d: %w", part, err)
+			}
+			res.WindowPoSt = append(res.WindowPoSt, *pr)
+		}
+
+		if !c.Bool("skip-winning") {
+			wnpt, err := sectors[0].SealProof.RegisteredWinningPoStProof()
+			if err != nil {
+				return err
+			}
+			idxs, err := ffiwrapper.ProofVerifier.GenerateWinningPoStSectorChallenge(ctx, wnpt, mid, randomness, uint64(len(sectors)))
+			if err != nil {
+				return xerrors.Errorf("generating winning post challenge: %w", err)
+			}
+			challenged := make([]storiface.PostSectorChallenge, len(idxs))
+			for i, idx := range idxs {
+				challenged[i] = sectors[idx]
+			}
+
+			log.Info("proving winning post")
+			pr, err := benchPoStPartition(ctx, sb, lstor, mid, wnpt, challenged, 0, randomness, false)
+			if err != nil {
+				return xerrors.Errorf("winning post: %w", err)
+			}
+			res.WinningPoSt = pr
+		}
+
+		if c.Bool("json-out") {
+			data, err := json.MarshalIndent(res, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+
+		fmt.Println("environment variable list:")
+		for envKey, envValue := range res.EnvVar {
+			fmt.Printf("%s=%s\n", envKey, envValue)
+		}
+		fmt.Printf("gpus: %s\n", strings.Join(gpus, ", "))
+		fmt.Printf("----\nresults (v28) Miner:(%s), SectorSize:(%d), SectorNumber:(%d)\n", maddr, ssize, len(sectors))
+		var total time.Duration
+		for _, pr := range res.WindowPoSt {
+			fmt.Printf("window post partition %d: %d sectors, vanilla: %s, snark: %s%s\n", pr.Partition, pr.Sectors, pr.Vanilla, pr.Snark, gpuUtilizationStr(pr))
+			total += pr.Vanilla + pr.Snark
+		}
+		fmt.Printf("window post: %d partitions in %s\n", len(res.WindowPoSt), total)
+		if pr := res.WinningPoSt; pr != nil {
+			fmt.Printf("winning post: %d sectors, vanilla: %s, snark: %s%s\n", pr.Sectors, pr.Vanilla, pr.Snark, gpuUtilizationStr(*pr))
+		}
+		return nil
+	},
+}
+
+func gpuUtilizationStr(pr PoStPartitionResult) string {
+	if pr.GPUUtilizationMax == 0 {
+		return ""
+	}
+	return fmt.Sprintf(", gpu: %.0f%% avg, %.0f%% max", pr.GPUUtilizationAvg, pr.GPUUtilizationMax)
+}
+
+// provingSectors returns the sectors of the pipeline which are proving, with
+// the sealed CID of their current replica
+func provingSectors(ctx context.Context, mds datastore.Batching) ([]storiface.PostSectorChallenge, error) {
+	sds := namespace.Wrap(mds, datastore.NewKey(pipeline.SectorStorePrefix))
+	q, err := sds.Query(ctx, dsq.Query{})
+	if err != nil {
+		return nil, xerrors.Errorf("querying sectors: %w", err)
+	}
+	defer q.Close() //nolint:errcheck
+
+	var out []storiface.PostSectorChallenge
+	for res := range q.Next() {
+		if res.Error != nil {
+			return nil, xerrors.Errorf("iterating sectors: %w", res.Error)
+		}
+
+		var info pipeline.SectorInfo
+		if err := info.UnmarshalCBOR(bytes.NewReader(res.Value)); err != nil {
+			return nil, xerrors.Errorf("decoding sector %s: %w", res.Key, err)
+		}
+		if info.State != pipeline.Proving && info.State != pipeline.Available || info.CommR == nil {
+			continue
+		}
+
+		sc := storiface.PostSectorChallenge{
+			SealProof:    info.SectorType,
+			SectorNumber: info.SectorNumber,
+			SealedCID:    *info.CommR,
+		}
+		if info.CCUpdate && info.UpdateSealed != nil {
+			sc.SealedCID = *info.UpdateSealed
+			sc.Update = true
+		}
+		out = append(out, sc)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].SectorNumber < out[j].SectorNumber
+	})
+	return out, nil
+}
+
+// benchPoStPartition proves the sectors, as a WindowPoSt partition or as the
+// WinningPoSt sectors
+func benchPoStPartition(ctx context.Context, sb *ffiwrapper.Sealer, lstor *paths.Local, mid abi.ActorID, ppt abi.RegisteredPoStProof, sectors []storiface.PostSectorChallenge, partIdx int, randomness abi.PoStRandomness, window bool) (*PoStPartitionResult, error) {
+	snums := make([]abi.SectorNumber, len(sectors))
+	for i, s := range sectors {
+		snums[i] = s.SectorNumber
+	}
+
+	pr := &PoStPartitionResult{
+		Partition: partIdx,
+		Sectors:   len(sectors),
+	}
+
+	start := time.Now()
+	challenges, err := ffi.GeneratePoStFallbackSectorChallenges(ppt, mid, randomness, snums)
+	if err != nil {
+		return nil, xerrors.Errorf("generating fallback challenges: %w", err)
+	}
+
+	vproofs := make([][]byte, len(sectors))
+	for i, s := range sectors {
+		s.Challenge = challenges.Challenges[s.SectorNumber]
+		vproofs[i], err = lstor.GenerateSingleVanillaProof(ctx, mid, s, ppt)
+		if err != nil {
+			return nil, xerrors.Errorf("reading challenges of sector %d: %w", s.SectorNumber, err)
+		}
+	}
+	pr.Vanilla = time.Since(start)
+
+	sampler := startGPUSampler()
+	start = time.Now()
+	if window {
+		_, err = sb.GenerateWindowPoStWithVanilla(ctx, ppt, mid, randomness, vproofs, partIdx)
+	} else {
+		_, err = sb.GenerateWinningPoStWithVanilla(ctx, ppt, mid, randomness, vproofs)
+	}
+	pr.Snark = time.Since(start)
+	pr.GPUUtilizationAvg, pr.GPUUtilizationMax = sampler.stop()
+	if err != nil {
+		return nil, xerrors.Errorf("computing snark: %w", err)
+	}
+
+	return pr, nil
+}
+
+// gpuSampler samples the utilization of the GPUs with nvidia-smi
+type gpuSampler struct {
+	samples []float64
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// startGPUSampler starts sampling, it returns nil when nvidia-smi isn't
+// available
+func startGPUSampler() *gpuSampler {
+	if _, err := exec.LookPath("nvidia-smi"); err != nil {
+		return nil
+	}
+
+	s := &gpuSampler{
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go func() {
+		defer close(s.stopped)
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-time.After(500 * time.Millisecond):
+			}
+
+			out, err := exec.Command("nvidia-smi", "--query-gpu=utilization.gpu", "--format=csv,noheader,nounits").Output()
+			if err != nil {
+				log.Warnf("sampling gpu utilization: %s", err)
+				return
+			}
+
+			// Busiest GPU
+			var util float64
+			for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+				u, err := strconv.ParseFloat(strings.TrimSpace(line), 64)
+				if err == nil && u > util {
+					util = u
+				}
+			}
+			s.samples = append(s.samples, util)
+		}
+	}()
+	return s
+}
+
+// stop stops sampling, and returns the average and max utilization
+func (s *gpuSampler) stop() (float64, float64) {
+	if s == nil {
+		return 0, 0
+	}
+	close(s.done)
+	<-s.stopped
+
+	var sum, max float64
+	for _, u := range s.samples {
+		sum += u
+		if u > max {
+			max = u
+		}
+	}
+	if len(s.samples) == 0 {
+		return 0, 0
+	}
+	return sum / float64(len(s.samples)), max
+}
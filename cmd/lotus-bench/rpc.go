@@ -0,0 +1,400 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+)
+
+// rpcCall is a JSON-RPC call of the mix, sent with a probability
+// proportional to its weight
+type rpcCall struct {
+	Method string
+	Params json.RawMessage
+	Weight int
+}
+
+type RPCMethodResult struct {
+	Method string
+	Calls  int
+	Errors int
+
+	Mean time.Duration
+	P50  time.Duration
+	P90  time.Duration
+	P99  time.Duration
+	Max  time.Duration
+}
+
+type RPCBenchResults struct {
+	Endpoint    string
+	Concurrency int
+	Duration    time.Duration
+	Calls       int
+	QPS         float64
+
+	Methods []RPCMethodResult
+}
+
+var rpcBenchCmd = &cli.Command{
+	Name:  "rpc",
+	Usage: "Benchmark a JSON-RPC endpoint with a mix of calls, reporting latency percentiles per method",
+	Description: `Sends calls of the mix to the endpoint from concurrent workers for the duration,
+   and reports the latency percentiles of each method. Calls which fail, either
+   with a transport error or with a JSON-RPC error, are counted as errors.
+
+   Calls are given with --method as name[:weight[:params]], where params is the
+   JSON array of parameters, or replayed from a file of recorded requests with
+   --calls-file, one JSON-RPC request object per line.
+
+   Examples
+
+   lotus-bench rpc --method Filecoin.ChainHead:5 \
+     --method 'Filecoin.StateGetActor:1:["f01000",null]' --concurrency 32
+   lotus-bench rpc --calls-file recorded.jsonl --qps 200`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "endpoint",
+			Usage: "url of the JSON-RPC endpoint",
+			Value: "http://127.0.0.1:1234/rpc/v1",
+		},
+		&cli.StringFlag{
+			Name:    "token",
+			Usage:   "API token sent with the calls",
+			EnvVars: []string{"LOTUS_BENCH_RPC_TOKEN"},
+		},
+		&cli.StringSliceFlag{
+			Name:  "method",
+			Usage: "call of the mix, as name[:weight[:params]]",
+		},
+		&cli.StringFlag{
+			Name:  "calls-file",
+			Usage: "file of recorded JSON-RPC requests to add to the mix",
+		},
+		&cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "number of concurrent workers sending calls",
+			Value: 10,
+		},
+		&cli.DurationFlag{
+			Name:  "duration",
+			Usage: "duration of the benchmark",
+			Value: 60 * time.Second,
+		},
+		&cli.IntFlag{
+			Name:  "qps",
+			Usage: "maximum number of calls sent per second, 0 for no limit",
+		},
+		&cli.BoolFlag{
+			Name:  "json-out",
+			Usage: "output results in json format",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		var calls []rpcCall
+		for _, spec := range c.StringSlice("method") {
+			call, err := parseRPCCall(spec)
+			if err != nil {
+				return err
+			}
+			calls = append(calls, call)
+		}
+		if c.IsSet("calls-file") {
+			recorded, err := readRPCCalls(c.String("calls-file"))
+			if err != nil {
+				return err
+			}
+			calls = append(calls, recorded...)
+		}
+		if len(calls) == 0 {
+			calls = []rpcCall{{Method: "Filecoin.ChainHead", Weight: 1}}
+		}
+
+		concurrency := c.Int("concurrency")
+		if concurrency < 1 {
+			return xerrors.Errorf("concurrency must be at least 1")
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context, c.Duration("duration"))
+		defer cancel()
+
+		var throttle <-chan time.Time
+		if qps := c.Int("qps"); qps > 0 {
+			ticker := time.NewTicker(time.Second / time.Duration(qps))
+			defer ticker.Stop()
+			throttle = ticker.C
+		}
+
+		header := http.Header{}
+		header.Set("Content-Type", "application/json")
+		if c.IsSet("token") {
+			header.Set("Authorization", "Bearer "+c.String("token"))
+		}
+
+		client := &http.Client{
+			Transport: &http.Transport{
+				MaxIdleConnsPerHost: concurrency,
+			},
+		}
+
+		var lk sync.Mutex
+		latencies := map[string][]time.Duration{}
+		failed := map[string]int{}
+
+		start := time.Now()
+		var wg sync.WaitGroup
+		for w := 0; w < concurrency; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				rng := rand.New(rand.NewSource(int64(w)))
+
+				for id := 0; ; id++ {
+					if throttle != nil {
+						select {
+						case <-throttle:
+						case <-ctx.Done():
+							return
+						}
+					}
+					if ctx.Err() != nil {
+						return
+					}
+
+					call := pickRPCCall(rng, calls)
+					callStart := time.Now()
+					err := sendRPCCall(ctx, client, c.String("endpoint"), header, call, id)
+					took := time.Since(callStart)
+
+					// Calls cut short by the end of the benchmark aren't counted
+					if ctx.Err() != nil {
+						return
+					}
+
+					lk.Lock()
+					if err != nil {
+						failed[call.Method]++
+						log.Debugf("%s: %s", call.Method, err)
+					} else {
+						latencies[call.Method] = append(latencies[call.Method], took)
+					}
+					lk.Unlock()
+				}
+			}(w)
+		}
+		wg.Wait()
+		elapsed := time.Since(start)
+
+		res := RPCBenchResults{
+			Endpoint:    c.String("endpoint"),
+			Concurrency: concurrency,
+			Duration:    elapsed,
+		}
+
+		methods := map[string]struct{}{}
+		for m := range latencies {
+			methods[m] = struct{}{}
+		}
+		for m := range failed {
+			methods[m] = struct{}{}
+		}
+		for m := range methods {
+			mr := rpcMethodResult(m, latencies[m])
+			mr.Errors = failed[m]
+			mr.Calls += mr.Errors
+			res.Calls += mr.Calls
+			res.Methods = append(res.Methods, mr)
+		}
+		sort.Slice(res.Methods, func(i, j int) bool {
+			return res.Methods[i].Method < res.Methods[j].Method
+		})
+		res.QPS = float64(res.Calls) / elapsed.Seconds()
+
+		if c.Bool("json-out") {
+			data, err := json.MarshalIndent(res, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+
+		fmt.Printf("%d calls in %s with %d workers: %.1f calls/s\n\n", res.Calls, elapsed.Round(time.Millisecond), concurrency, res.QPS)
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		fmt.Fprintf(tw, "Method\tCalls\tErrors\tMean\tP50\tP90\tP99\tMax\n")
+		for _, mr := range res.Methods {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t%s\n", mr.Method, mr.Calls, mr.Errors, mr.Mean, mr.P50, mr.P90, mr.P99, mr.Max)
+		}
+		return tw.Flush()
+	},
+}
+
+// parseRPCCall parses a call given as name[:weight[:params]]
+func parseRPCCall(spec string) (rpcCall, error) {
+	parts := strings.SplitN(spec, ":", 3)
+	call := rpcCall{
+		Method: parts[0],
+		Weight: 1,
+	}
+	if call.Method == "" {
+		return rpcCall{}, xerrors.Errorf("no method in %q", spec)
+	}
+	if len(parts) > 1 {
+		w, err := strconv.Atoi(parts[1])
+		if err != nil || w < 1 {
+			return rpcCall{}, xerrors.Errorf("invalid weight in %q", spec)
+		}
+		call.Weight = w
+	}
+	if len(parts) > 2 {
+		var params []json.RawMessage
+		if err := json.Unmarshal([]byte(parts[2]), &params); err != nil {
+			return rpcCall{}, xerrors.Errorf("params in %q must be a JSON array: %w", spec, err)
+		}
+		call.Params = json.RawMessage(parts[2])
+	}
+	return call, nil
+}
+
+// readRPCCalls reads recorded JSON-RPC requests, one per line
+func readRPCCalls(path string) ([]rpcCall, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck
+
+	var calls []rpcCall
+	s := bufio.NewScanner(f)
+	s.Buffer(make([]byte, 0, 64<<10), 16<<20)
+	for line := 1; s.Scan(); line++ {
+		if strings.TrimSpace(s.Text()) == "" {
+			continue
+		}
+		var req struct {
+			Method string
+			Params json.RawMessage
+		}
+		if err := json.Unmarshal(s.Bytes(), &req); err != nil {
+			return nil, xerrors.Errorf("decoding request on line %d: %w", line, err)
+		}
+		if req.Method == "" {
+			return nil, xerrors.Errorf("no method in request on line %d", line)
+		}
+		calls = append(calls, rpcCall{Method: req.Method, Params: req.Params, Weight: 1})
+	}
+	return calls, s.Err()
+}
+
+func pickRPCCall(rng *rand.Rand, calls []rpcCall) rpcCall {
+	var total int
+	for _, call := range calls {
+		total += call.Weight
+	}
+	n := rng.Intn(total)
+	for _, call := range calls {
+		if n < call.Weight {
+			return call
+		}
+		n -= call.Weight
+	}
+	return calls[len(calls)-1]
+}
+
+func sendRPCCall(ctx context.Context, client *http.Client, endpoint string, header http.Header, call rpcCall, id int) error {
+	params := call.Params
+	if params == nil {
+		params = json.RawMessage("[]")
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"method":  call.Method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header = header.Clone()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		return xerrors.Errorf("http status %d", resp.StatusCode)
+	}
+
+	var out struct {
+		Error *struct {
+			Code    int
+			Message string
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return xerrors.Errorf("decoding response: %w", err)
+	}
+	if out.Error != nil {
+		return xerrors.Errorf("rpc error %d: %s", out.Error.Code, out.Error.Message)
+	}
+	return nil
+}
+
+// rpcMethodResult computes the latency statistics of the successful calls
+func rpcMethodResult(method string, latencies []time.Duration) RPCMethodResult {
+	mr := RPCMethodResult{
+		Method: method,
+		Calls:  len(latencies),
+	}
+	if len(latencies) == 0 {
+		return mr
+	}
+
+	sorted := make([]time.Duration, len(latencies))
+	copy(sorted, latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var sum time.Duration
+	for _, l := range sorted {
+		sum += l
+	}
+	mr.Mean = sum / time.Duration(len(sorted))
+	mr.P50 = latencyPercentile(sorted, 50)
+	mr.P90 = latencyPercentile(sorted, 90)
+	mr.P99 = latencyPercentile(sorted, 99)
+	mr.Max = sorted[len(sorted)-1]
+	return mr
+}
+
+// latencyPercentile returns the nearest-rank percentile of the sorted latencies
+func latencyPercentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,41 @@
+//stm: #unit
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRPCCall(t *testing.T) {
+	call, err := parseRPCCall("Filecoin.ChainHead")
+	require.NoError(t, err)
+	require.Equal(t, rpcCall{Method: "Filecoin.ChainHead", Weight: 1}, call)
+
+	call, err = parseRPCCall(`Filecoin.StateGetActor:3:["f01000",{"/":"bafy"}]`)
+	require.NoError(t, err)
+	require.Equal(t, "Filecoin.StateGetActor", call.Method)
+	require.Equal(t, 3, call.Weight)
+	require.Equal(t, `["f01000",{"/":"bafy"}]`, string(call.Params))
+
+	_, err = parseRPCCall("Filecoin.ChainHead:0")
+	require.Error(t, err)
+	_, err = parseRPCCall(`Filecoin.ChainHead:1:{"a":1}`)
+	require.Error(t, err)
+}
+
+func TestRPCMethodResult(t *testing.T) {
+	var latencies []time.Duration
+	for i := 100; i > 0; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	mr := rpcMethodResult("m", latencies)
+	require.Equal(t, 100, mr.Calls)
+	require.Equal(t, 50*time.Millisecond, mr.P50)
+	require.Equal(t, 90*time.Millisecond, mr.P90)
+	require.Equal(t, 99*time.Millisecond, mr.P99)
+	require.Equal(t, 100*time.Millisecond, mr.Max)
+	require.Equal(t, 50500*time.Microsecond, mr.Mean)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
 
+	lcli "github.com/filecoin-project/lotus/cli"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/repo"
 )
@@ -16,6 +17,8 @@ var configCmd = &cli.Command{
 	Subcommands: []*cli.Command{
 		configDefaultCmd,
 		configUpdateCmd,
+		lcli.ConfigReloadCmd,
+		lcli.ConfigValidateCmd(FlagMinerRepo, repo.StorageMiner),
 	},
 }
 
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"github.com/urfave/cli/v2"
+
+	lcli "github.com/filecoin-project/lotus/cli"
+)
+
+var healthCmd = &cli.Command{
+	Name:  "health",
+	Usage: "Check the health of the miner subsystems",
+	Description: `Prints the health of the chain sync, peers, sealing workers and current proving
+   deadline of the miner, with an overall OK, WARN or CRIT level.
+
+   The command fails when the level of the miner is CRIT, or with --fail-on=warn
+   when it is WARN or CRIT, so that it can be used as a health probe.`,
+	Flags: lcli.HealthFlags,
+	Action: func(cctx *cli.Context) error {
+		minerApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		h, err := minerApi.NodeHealth(ctx)
+		if err != nil {
+			return err
+		}
+
+		return lcli.PrintNodeHealth(cctx, h)
+	},
+}
@@ -22,6 +22,7 @@ import (
 
 const (
 	MarketsService = "markets"
+	ProvingService = "proving"
 )
 
 var serviceCmd = &cli.Command{
@@ -39,7 +40,7 @@ var serviceCmd = &cli.Command{
 		},
 		&cli.StringSliceFlag{
 			Name:  "type",
-			Usage: "type of service to be enabled",
+			Usage: "type of service to be enabled: markets, proving",
 		},
 		&cli.StringFlag{
 			Name:  "api-sealer",
@@ -61,9 +62,10 @@ var serviceCmd = &cli.Command{
 			return xerrors.Errorf("at least one module must be enabled")
 		}
 
-		// we should remove this as soon as we have more service types and not just `markets`
-		if !es.Contains(MarketsService) {
-			return xerrors.Errorf("markets module must be enabled")
+		for _, s := range es {
+			if s != MarketsService && s != ProvingService {
+				return xerrors.Errorf("unknown service type %q", s)
+			}
 		}
 
 		if !cctx.IsSet("api-sealer") {
@@ -73,16 +75,34 @@ var serviceCmd = &cli.Command{
 			return xerrors.Errorf("--api-sector-index is required without the sector storage module enabled")
 		}
 
-		repoPath := cctx.String(FlagMarketsRepo)
+		repoFlag := FlagMarketsRepo
+		if !es.Contains(MarketsService) {
+			repoFlag = FlagProvingRepo
+		}
+		repoPath := cctx.String(repoFlag)
 		if repoPath == "" {
-			return xerrors.Errorf("please provide Lotus markets repo path via flag %s", FlagMarketsRepo)
+			return xerrors.Errorf("please provide the service repo path via flag %s", repoFlag)
 		}
 
 		if err := restore(ctx, cctx, repoPath, &paths.StorageConfig{}, func(cfg *config.StorageMiner) error {
 			cfg.Subsystems.EnableMarkets = es.Contains(MarketsService)
+			cfg.Subsystems.EnableProving = es.Contains(ProvingService)
 			cfg.Subsystems.EnableMining = false
 			cfg.Subsystems.EnableSealing = false
 			cfg.Subsystems.EnableSectorStorage = false
+			cfg.Subsystems.ProvingApiInfo = ""
+
+			if cfg.Subsystems.EnableProving {
+				// the local worker of the proving service only proves
+				cfg.Storage.AllowAddPiece = false
+				cfg.Storage.AllowPreCommit1 = false
+				cfg.Storage.AllowPreCommit2 = false
+				cfg.Storage.AllowCommit = false
+				cfg.Storage.AllowUnseal = false
+				cfg.Storage.AllowReplicaUpdate = false
+				cfg.Storage.AllowProveReplicaUpdate2 = false
+				cfg.Storage.AllowRegenSectorKey = false
+			}
 
 			if !cfg.Subsystems.EnableSealing {
 				ai, err := checkApiInfo(ctx, cctx.String("api-sealer"))
@@ -115,6 +135,11 @@ var serviceCmd = &cli.Command{
 			return err
 		}
 
+		if es.Contains(ProvingService) {
+			log.Infof("Proving service initialized; attach the sector storage paths to it with 'lotus-miner --%s=%s storage attach', "+
+				"and set Subsystems.ProvingApiInfo of the mining node to its API info before starting it", FlagMinerRepo, repoPath)
+		}
+
 		return nil
 	},
 }
@@ -25,6 +25,7 @@ var log = logging.Logger("main")
 const (
 	FlagMinerRepo   = "miner-repo"
 	FlagMarketsRepo = "markets-repo"
+	FlagProvingRepo = "proving-repo"
 )
 
 // TODO remove after deprecation period
@@ -40,9 +41,13 @@ func main() {
 		runCmd,
 		stopCmd,
 		configCmd,
+		policyCmd,
 		backupCmd,
+		lcli.RepoMigrateCmd(FlagMinerRepo, repo.StorageMiner),
 		lcli.WithCategory("chain", actorCmd),
 		lcli.WithCategory("chain", infoCmd),
+		lcli.WithCategory("chain", healthCmd),
+		lcli.WithCategory("chain", notificationsCmd),
 		lcli.WithCategory("market", storageDealsCmd),
 		lcli.WithCategory("market", retrievalDealsCmd),
 		lcli.WithCategory("market", dataTransfersCmd),
@@ -139,6 +144,11 @@ func main() {
 				EnvVars: []string{"LOTUS_MARKETS_PATH"},
 				Usage:   fmt.Sprintf("Markets repo path"),
 			},
+			&cli.StringFlag{
+				Name:    FlagProvingRepo,
+				EnvVars: []string{"LOTUS_PROVING_PATH"},
+				Usage:   "Proving service repo path",
+			},
 			&cli.BoolFlag{
 				Name:  "call-on-markets",
 				Usage: "(experimental; may be removed) call this command against a markets node; use only with common commands like net, auth, pprof, etc. whose target may be ambiguous",
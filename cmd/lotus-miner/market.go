@@ -7,10 +7,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
 	"strconv"
+	"strings"
 	"text/tabwriter"
 	"time"
 
@@ -340,8 +342,9 @@ var getAskCmd = &cli.Command{
 }
 
 var storageDealsCmd = &cli.Command{
-	Name:  "storage-deals",
-	Usage: "Manage storage deals and related configuration",
+	Name:    "storage-deals",
+	Aliases: []string{"deals"},
+	Usage:   "Manage storage deals and related configuration",
 	Subcommands: []*cli.Command{
 		dealsImportDataCmd,
 		dealsListCmd,
@@ -354,6 +357,9 @@ var storageDealsCmd = &cli.Command{
 		setSealDurationCmd,
 		dealsPendingPublish,
 		dealsRetryPublish,
+		dealsPublishQueueCmd,
+		dealsPendingFilter,
+		dealsPackingCmd,
 	},
 }
 
@@ -361,8 +367,22 @@ var dealsImportDataCmd = &cli.Command{
 	Name:      "import-data",
 	Usage:     "Manually import data for a deal",
 	ArgsUsage: "<proposal CID> <file>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "url",
+			Usage: "stream the data from a http(s) URL instead of a local file",
+		},
+		&cli.StringSliceFlag{
+			Name:  "header",
+			Usage: "header added to the requests made to --url, as 'Name: value'",
+		},
+		&cli.StringFlag{
+			Name:  "sha256",
+			Usage: "hex encoded sha256 digest of the data fetched from --url, verified before the import completes",
+		},
+	},
 	Action: func(cctx *cli.Context) error {
-		api, closer, err := lcli.GetMarketsAPI(cctx)
+		mapi, closer, err := lcli.GetMarketsAPI(cctx)
 		if err != nil {
 			return err
 		}
@@ -370,7 +390,11 @@ var dealsImportDataCmd = &cli.Command{
 
 		ctx := lcli.DaemonContext(cctx)
 
-		if cctx.Args().Len() < 2 {
+		if cctx.IsSet("url") {
+			if cctx.Args().Len() != 1 {
+				return fmt.Errorf("must specify proposal CID only when importing from a URL")
+			}
+		} else if cctx.Args().Len() < 2 {
 			return fmt.Errorf("must specify proposal CID and file path")
 		}
 
@@ -379,9 +403,26 @@ var dealsImportDataCmd = &cli.Command{
 			return err
 		}
 
+		if cctx.IsSet("url") {
+			src := api.DealDataURL{
+				URL:     cctx.String("url"),
+				Headers: http.Header{},
+				SHA256:  cctx.String("sha256"),
+			}
+			for _, h := range cctx.StringSlice("header") {
+				kv := strings.SplitN(h, ":", 2)
+				if len(kv) != 2 {
+					return fmt.Errorf("invalid header %q, expected 'Name: value'", h)
+				}
+				src.Headers.Add(strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]))
+			}
+
+			return mapi.DealsImportDataFromURL(ctx, propCid, src)
+		}
+
 		fpath := cctx.Args().Get(1)
 
-		return api.DealsImportData(ctx, propCid, fpath)
+		return mapi.DealsImportData(ctx, propCid, fpath)
 
 	},
 }
@@ -640,6 +681,7 @@ var dataTransfersCmd = &cli.Command{
 		marketRestartTransfer,
 		marketCancelTransfer,
 		transfersDiagnosticsCmd,
+		transferLimitsCmd,
 	},
 }
 
@@ -818,6 +860,18 @@ var transfersListCmd = &cli.Command{
 		completed := cctx.Bool("completed")
 		watch := cctx.Bool("watch")
 		showFailed := cctx.Bool("show-failed")
+
+		outputLimits := func(io.Writer) {}
+		if verbose {
+			st, err := api.MarketDataTransferLimits(ctx)
+			if err != nil {
+				return xerrors.Errorf("getting data transfer limits: %w", err)
+			}
+			outputLimits = func(out io.Writer) {
+				outputTransferLimits(out, st)
+			}
+		}
+
 		if watch {
 			channelUpdates, err := api.MarketDataTransferUpdates(ctx)
 			if err != nil {
@@ -829,6 +883,7 @@ var transfersListCmd = &cli.Command{
 
 				tm.MoveCursor(1, 1)
 
+				outputLimits(tm.Screen)
 				lcli.OutputDataTransferChannels(tm.Screen, channels, verbose, completed, showFailed)
 
 				tm.Flush()
@@ -854,11 +909,181 @@ var transfersListCmd = &cli.Command{
 				}
 			}
 		}
+		outputLimits(os.Stdout)
 		lcli.OutputDataTransferChannels(os.Stdout, channels, verbose, completed, showFailed)
 		return nil
 	},
 }
 
+func bandwidthStr(bytesPerSec int64) string {
+	if bytesPerSec == 0 {
+		return "unlimited"
+	}
+	return units.BytesSize(float64(bytesPerSec)) + "/s"
+}
+
+func transfersStr(limit int) string {
+	if limit == 0 {
+		return "unlimited"
+	}
+	return strconv.Itoa(limit)
+}
+
+func outputTransferLimits(out io.Writer, st api.DataTransferLimitsStatus) {
+	fmt.Fprintf(out, "Bandwidth: %s (per peer %s)\n", bandwidthStr(st.MaxBandwidth), bandwidthStr(st.MaxBandwidthPerPeer))
+	fmt.Fprintf(out, "Transfers: %d active, %d queued (max %s, per peer %s)\n", st.Active, st.Queued,
+		transfersStr(st.Limits.MaxConcurrentTransfers), transfersStr(st.Limits.MaxConcurrentTransfersPerPeer))
+
+	if len(st.Peers) > 0 {
+		w := tabwriter.NewWriter(out, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "Peer\tActive\tQueued\tSent\n")
+		for _, p := range st.Peers {
+			_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", p.Peer, p.Active, p.Queued, units.BytesSize(float64(p.Sent)))
+		}
+		_ = w.Flush()
+	}
+	fmt.Fprintln(out)
+}
+
+var transferLimitsCmd = &cli.Command{
+	Name:  "limits",
+	Usage: "Show the bandwidth and concurrency limits of the data sent to other peers",
+	Subcommands: []*cli.Command{
+		transferLimitsSetCmd,
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		st, err := api.MarketDataTransferLimits(ctx)
+		if err != nil {
+			return err
+		}
+
+		outputTransferLimits(os.Stdout, st)
+
+		if len(st.Limits.Schedule) > 0 {
+			fmt.Printf("Schedule (default bandwidth %s, per peer %s):\n", bandwidthStr(st.Limits.MaxBandwidth), bandwidthStr(st.Limits.MaxBandwidthPerPeer))
+			for _, w := range st.Limits.Schedule {
+				fmt.Printf("  %s-%s: %s (per peer %s)\n", w.Start, w.End, bandwidthStr(w.MaxBandwidth), bandwidthStr(w.MaxBandwidthPerPeer))
+			}
+		}
+		return nil
+	},
+}
+
+var transferLimitsSetCmd = &cli.Command{
+	Name:  "set",
+	Usage: "Change the limits of the data sent to other peers until the node restarts",
+	Description: `Bandwidth values are sizes sent per second, e.g. 100MiB, with 0 meaning unlimited.
+
+   Windows replace the bandwidth caps during times of the day, in the node's local time:
+     --window 08:00-18:00=50MiB sets a 50MiB/s cap during business hours
+     --window 22:00-06:00=0/10MiB lifts the global cap overnight, with a 10MiB/s cap per peer`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "max-bandwidth",
+			Usage: "bytes per second sent to all peers combined",
+		},
+		&cli.StringFlag{
+			Name:  "max-bandwidth-per-peer",
+			Usage: "bytes per second sent to a single peer",
+		},
+		&cli.IntFlag{
+			Name:  "max-transfers",
+			Usage: "transfers sending data at the same time",
+		},
+		&cli.IntFlag{
+			Name:  "max-transfers-per-peer",
+			Usage: "transfers sending data to a single peer at the same time",
+		},
+		&cli.StringSliceFlag{
+			Name:  "window",
+			Usage: "replace the schedule with windows formatted as start-end=bandwidth[/per-peer bandwidth]",
+		},
+		&cli.BoolFlag{
+			Name:  "clear-schedule",
+			Usage: "remove all the schedule windows",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		st, err := api.MarketDataTransferLimits(ctx)
+		if err != nil {
+			return err
+		}
+		limits := st.Limits
+
+		if cctx.IsSet("max-bandwidth") {
+			if limits.MaxBandwidth, err = units.RAMInBytes(cctx.String("max-bandwidth")); err != nil {
+				return xerrors.Errorf("parsing max-bandwidth: %w", err)
+			}
+		}
+		if cctx.IsSet("max-bandwidth-per-peer") {
+			if limits.MaxBandwidthPerPeer, err = units.RAMInBytes(cctx.String("max-bandwidth-per-peer")); err != nil {
+				return xerrors.Errorf("parsing max-bandwidth-per-peer: %w", err)
+			}
+		}
+		if cctx.IsSet("max-transfers") {
+			limits.MaxConcurrentTransfers = cctx.Int("max-transfers")
+		}
+		if cctx.IsSet("max-transfers-per-peer") {
+			limits.MaxConcurrentTransfersPerPeer = cctx.Int("max-transfers-per-peer")
+		}
+		if cctx.Bool("clear-schedule") {
+			limits.Schedule = nil
+		}
+		if cctx.IsSet("window") {
+			limits.Schedule = nil
+			for _, ws := range cctx.StringSlice("window") {
+				w, err := parseBandwidthWindow(ws)
+				if err != nil {
+					return xerrors.Errorf("parsing window %s: %w", ws, err)
+				}
+				limits.Schedule = append(limits.Schedule, w)
+			}
+		}
+
+		return api.MarketSetDataTransferLimits(ctx, limits)
+	},
+}
+
+func parseBandwidthWindow(s string) (api.BandwidthWindow, error) {
+	var w api.BandwidthWindow
+
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 {
+		return w, xerrors.Errorf("expected start-end=bandwidth")
+	}
+	span := strings.SplitN(parts[0], "-", 2)
+	if len(span) != 2 {
+		return w, xerrors.Errorf("expected start-end")
+	}
+	w.Start, w.End = span[0], span[1]
+
+	caps := strings.SplitN(parts[1], "/", 2)
+	var err error
+	if w.MaxBandwidth, err = units.RAMInBytes(caps[0]); err != nil {
+		return w, xerrors.Errorf("parsing bandwidth: %w", err)
+	}
+	if len(caps) == 2 {
+		if w.MaxBandwidthPerPeer, err = units.RAMInBytes(caps[1]); err != nil {
+			return w, xerrors.Errorf("parsing per peer bandwidth: %w", err)
+		}
+	}
+	return w, nil
+}
+
 var transfersDiagnosticsCmd = &cli.Command{
 	Name:  "diagnostics",
 	Usage: "Get detailed diagnostics on active transfers with a specific peer",
@@ -975,6 +1200,280 @@ var dealsRetryPublish = &cli.Command{
 	},
 }
 
+var dealsPublishQueueCmd = &cli.Command{
+	Name:  "publish-queue",
+	Usage: "list deals waiting in publish queue with the batching configuration",
+	Subcommands: []*cli.Command{
+		dealsPublishQueuePublishCmd,
+		dealsPublishQueueConfigCmd,
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		pending, err := api.MarketPendingDeals(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting pending deals: %w", err)
+		}
+
+		fmt.Printf("Publish period:     %s\n", pending.PublishPeriod)
+		fmt.Printf("Max deals per msg:  %d\n", pending.MaxDealsPerMsg)
+
+		if len(pending.Deals) == 0 {
+			fmt.Println("No deals queued to be published")
+			return nil
+		}
+
+		publishAt := pending.PublishPeriodStart.Add(pending.PublishPeriod)
+		fmt.Printf("Publishing at:      %s (in %s)\n", publishAt.Format(time.Stamp), time.Until(publishAt).Round(time.Second))
+		fmt.Printf("Queued deals:       %d\n", len(pending.Deals))
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "ProposalCID\tClient\tSize\tVerified\tStartEpoch\n")
+		for _, deal := range pending.Deals {
+			proposalNd, err := cborutil.AsIpld(&deal) // nolint
+			if err != nil {
+				return err
+			}
+
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%d\n", proposalNd.Cid(), deal.Proposal.Client,
+				units.BytesSize(float64(deal.Proposal.PieceSize)), deal.Proposal.VerifiedDeal, deal.Proposal.StartEpoch)
+		}
+		return w.Flush()
+	},
+}
+
+var dealsPublishQueuePublishCmd = &cli.Command{
+	Name:      "publish",
+	Usage:     "publish a deal waiting in publish queue right away, in a message of its own",
+	ArgsUsage: "<proposal CID>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return cli.ShowCommandHelp(cctx, cctx.Command.Name)
+		}
+
+		api, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		propcid, err := cid.Decode(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("parsing proposal CID: %w", err)
+		}
+
+		if err := api.MarketPublishPendingDeal(ctx, propcid); err != nil {
+			return xerrors.Errorf("publishing deal: %w", err)
+		}
+		fmt.Println("triggered deal publishing")
+		return nil
+	},
+}
+
+var dealsPublishQueueConfigCmd = &cli.Command{
+	Name:  "config",
+	Usage: "get or set the batching configuration of deal publish messages, until the miner restarts",
+	Flags: []cli.Flag{
+		&cli.DurationFlag{
+			Name:  "period",
+			Usage: "amount of time to wait for more deals to arrive before publishing",
+		},
+		&cli.Uint64Flag{
+			Name:  "max-deals",
+			Usage: "maximum number of deals to include in a single publish message",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		cfg, err := api.MarketGetPublishConfig(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting publish config: %w", err)
+		}
+
+		if cctx.IsSet("period") || cctx.IsSet("max-deals") {
+			if cctx.IsSet("period") {
+				cfg.Period = cctx.Duration("period")
+			}
+			if cctx.IsSet("max-deals") {
+				cfg.MaxDealsPerMsg = cctx.Uint64("max-deals")
+			}
+
+			if err := api.MarketSetPublishConfig(ctx, cfg); err != nil {
+				return xerrors.Errorf("setting publish config: %w", err)
+			}
+		}
+
+		fmt.Printf("Publish period:     %s\n", cfg.Period)
+		fmt.Printf("Max deals per msg:  %d\n", cfg.MaxDealsPerMsg)
+		return nil
+	},
+}
+
+var dealsPendingFilter = &cli.Command{
+	Name:      "pending-filter",
+	Usage:     "list deals waiting for a decision of the deal filter webhook, or make one",
+	ArgsUsage: "[proposal CID]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "accept",
+			Usage: "accept the deal",
+		},
+		&cli.BoolFlag{
+			Name:  "reject",
+			Usage: "reject the deal",
+		},
+		&cli.StringFlag{
+			Name:  "reason",
+			Usage: "reason given to the client for rejecting the deal",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		if cctx.Bool("accept") || cctx.Bool("reject") {
+			if cctx.Bool("accept") == cctx.Bool("reject") {
+				return xerrors.Errorf("only one of --accept and --reject can be set")
+			}
+			if cctx.NArg() != 1 {
+				return xerrors.Errorf("expected the proposal CID of the deal")
+			}
+
+			propcid, err := cid.Decode(cctx.Args().First())
+			if err != nil {
+				return xerrors.Errorf("parsing proposal CID: %w", err)
+			}
+
+			if err := api.MarketResolveFilterDecision(ctx, propcid, cctx.Bool("accept"), cctx.String("reason")); err != nil {
+				return err
+			}
+
+			if cctx.Bool("accept") {
+				fmt.Printf("accepted deal %s\n", propcid)
+			} else {
+				fmt.Printf("rejected deal %s\n", propcid)
+			}
+			return nil
+		}
+
+		pending, err := api.MarketPendingFilterDecisions(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting deals pending a filter decision: %w", err)
+		}
+
+		if len(pending) == 0 {
+			fmt.Println("No deals waiting for a filter decision")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "ProposalCID\tClient\tSize\tVerified\tStartEpoch\tQueued\tExpires In\tReason\n")
+		for _, d := range pending {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%d\t%s\t%s\t%s\n",
+				d.ProposalCid,
+				d.Client,
+				units.BytesSize(float64(d.PieceSize)),
+				d.VerifiedDeal,
+				d.StartEpoch,
+				d.QueuedAt.Format(time.Stamp),
+				time.Until(d.Deadline).Round(time.Second),
+				d.Reason)
+		}
+		return w.Flush()
+	},
+}
+
+var dealsPackingCmd = &cli.Command{
+	Name:  "packing",
+	Usage: "Inspect how deals are packed into sectors",
+	Subcommands: []*cli.Command{
+		dealsPackingStatusCmd,
+	},
+}
+
+var dealsPackingStatusCmd = &cli.Command{
+	Name:  "status",
+	Usage: "show the deal packing limits, the sectors open for deals and the deals waiting for a sector",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		st, err := api.SectorsPackingStatus(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting packing status: %w", err)
+		}
+
+		limit := func(v uint64, unit string) string {
+			if v == 0 {
+				return "unlimited"
+			}
+			return fmt.Sprintf("%d%s", v, unit)
+		}
+
+		fmt.Printf("Max deals per sector:   %d\n", st.MaxDealsPerSector)
+		fmt.Printf("Target sector fill:     %s\n", limit(st.TargetSectorFillPercent, "%"))
+		fmt.Printf("Max deals per client:   %s\n", limit(st.MaxConcurrentDealsPerClient, ""))
+		fmt.Printf("Wait deals delay:       %s\n", st.WaitDealsDelay)
+		fmt.Printf("Deals waiting a sector: %d\n", st.WaitingDeals)
+
+		fmt.Println()
+		if len(st.OpenSectors) == 0 {
+			fmt.Println("No sectors open for deals")
+		} else {
+			w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+			_, _ = fmt.Fprintf(w, "Sector\tType\tUsed\tFill\tDeals\n")
+			for _, s := range st.OpenSectors {
+				typ := "new"
+				if s.CCUpdate {
+					typ = "snap"
+				}
+				_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%d%%\t%d/%d\n",
+					s.Sector,
+					typ,
+					units.BytesSize(float64(s.Used)),
+					uint64(s.Used)*100/uint64(st.SectorSize),
+					s.Deals,
+					st.MaxDealsPerSector)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		}
+
+		if len(st.Clients) > 0 {
+			fmt.Println()
+			w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+			_, _ = fmt.Fprintf(w, "Client\tAdding\tWaiting\n")
+			for _, c := range st.Clients {
+				_, _ = fmt.Fprintf(w, "%s\t%d\t%d\n", c.Client, c.Adding, c.Waiting)
+			}
+			return w.Flush()
+		}
+
+		return nil
+	},
+}
+
 func listDealsWithJSON(cctx *cli.Context) error {
 	node, closer, err := lcli.GetMarketsAPI(cctx)
 	if err != nil {
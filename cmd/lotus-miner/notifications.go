@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	lcli "github.com/filecoin-project/lotus/cli"
+)
+
+var notificationsCmd = &cli.Command{
+	Name:  "notifications",
+	Usage: "Manage the notification webhooks of the miner events",
+	Subcommands: []*cli.Command{
+		notificationsTestCmd,
+	},
+}
+
+var notificationsTestCmd = &cli.Command{
+	Name:      "test",
+	Usage:     "Fire a test notification to the webhooks",
+	ArgsUsage: "[event]",
+	Description: `Fires a test event to the webhooks configured in the Notifications section of
+   the miner config, to check that they are delivered.
+
+   The event is one of post-deadline, sectors-faulty, worker-disconnected,
+   control-balance or test (the default), and is only sent to the webhooks
+   subscribed to it.`,
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() > 1 {
+			return lcli.ShowHelp(cctx, fmt.Errorf("expected at most one event"))
+		}
+
+		event := "test"
+		if cctx.Args().Present() {
+			event = cctx.Args().First()
+		}
+
+		minerApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		if err := minerApi.NotificationsTest(ctx, event); err != nil {
+			return err
+		}
+
+		fmt.Printf("Test %s notification sent\n", event)
+		return nil
+	},
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/crypto"
+
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/lib/sigs"
+	_ "github.com/filecoin-project/lotus/lib/sigs/bls"
+	_ "github.com/filecoin-project/lotus/lib/sigs/secp"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+var policyCmd = &cli.Command{
+	Name:  "policy",
+	Usage: "Export, import and compare the operational policy of the miner",
+	Description: `The policy document contains the Addresses, Fees, Dealmaking and Sealing
+   sections of the miner config, so that the same policy can be applied to
+   a fleet of miners.`,
+	Subcommands: []*cli.Command{
+		policyExportCmd,
+		policyImportCmd,
+		policyDiffCmd,
+	},
+}
+
+var policyExportCmd = &cli.Command{
+	Name:      "export",
+	Usage:     "Export the miner policy from the repo config",
+	ArgsUsage: "[output file (default: stdout)]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "signer",
+			Usage: "sign the document with this wallet address of the connected full node",
+		},
+		&cli.Uint64Flag{
+			Name:  "revision",
+			Usage: "revision number of the exported policy",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() > 1 {
+			return lcli.ShowHelp(cctx, fmt.Errorf("incorrect number of arguments"))
+		}
+
+		cfg, err := readMinerConfig(cctx)
+		if err != nil {
+			return err
+		}
+
+		doc := config.NewPolicyDocument(cfg, cctx.Uint64("revision"))
+
+		if cctx.IsSet("signer") {
+			signer, err := address.NewFromString(cctx.String("signer"))
+			if err != nil {
+				return xerrors.Errorf("parsing signer address: %w", err)
+			}
+			if signer.Protocol() == address.ID {
+				return xerrors.Errorf("signer must be a key address")
+			}
+
+			api, closer, err := lcli.GetFullNodeAPI(cctx)
+			if err != nil {
+				return err
+			}
+			defer closer()
+			ctx := lcli.ReqContext(cctx)
+
+			err = doc.Sign(signer, func(b []byte) (*crypto.Signature, error) {
+				return api.WalletSign(ctx, signer, b)
+			})
+			if err != nil {
+				return xerrors.Errorf("signing policy document: %w", err)
+			}
+		}
+
+		out, err := doc.Encode()
+		if err != nil {
+			return err
+		}
+
+		if cctx.NArg() == 0 {
+			_, err = os.Stdout.Write(out)
+			return err
+		}
+
+		return ioutil.WriteFile(cctx.Args().First(), out, 0644)
+	},
+}
+
+var policyImportCmd = &cli.Command{
+	Name:      "import",
+	Usage:     "Apply a policy document to the repo config",
+	ArgsUsage: "[policy file]",
+	Description: `The miner must be stopped while importing a policy; the new policy takes
+   effect when the miner is started again.`,
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "trusted-signer",
+			Usage: "accept documents signed by one of these addresses; required unless --allow-unsigned is set",
+		},
+		&cli.BoolFlag{
+			Name:  "allow-unsigned",
+			Usage: "accept documents without a signature, or signed by any address when no trusted signer is set",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return lcli.ShowHelp(cctx, fmt.Errorf("incorrect number of arguments"))
+		}
+
+		doc, err := readPolicyDocument(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		if err := verifyPolicyDocument(doc, cctx.StringSlice("trusted-signer"), cctx.Bool("allow-unsigned")); err != nil {
+			return err
+		}
+
+		r, err := repo.NewFS(cctx.String(FlagMinerRepo))
+		if err != nil {
+			return err
+		}
+
+		ok, err := r.Exists()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return xerrors.Errorf("repo not initialized")
+		}
+
+		lr, err := r.Lock(repo.StorageMiner)
+		if err != nil {
+			return xerrors.Errorf("locking repo (is the miner running?): %w", err)
+		}
+		defer lr.Close() //nolint:errcheck
+
+		raw, err := lr.Config()
+		if err != nil {
+			return xerrors.Errorf("reading config: %w", err)
+		}
+		cfg, ok := raw.(*config.StorageMiner)
+		if !ok {
+			return xerrors.Errorf("expected miner config, got %T", raw)
+		}
+
+		cur := config.PolicyFromConfig(cfg)
+		changes, err := config.DiffPolicy(&cur, &doc.Policy)
+		if err != nil {
+			return err
+		}
+
+		err = lr.SetConfig(func(raw interface{}) {
+			doc.Policy.ApplyTo(raw.(*config.StorageMiner))
+		})
+		if err != nil {
+			return xerrors.Errorf("setting config: %w", err)
+		}
+
+		fmt.Printf("Imported policy revision %d (%d settings changed)\n", doc.Revision, len(changes))
+		for _, c := range changes {
+			fmt.Println(c)
+		}
+
+		return nil
+	},
+}
+
+var policyDiffCmd = &cli.Command{
+	Name:      "diff",
+	Usage:     "Compare two policy documents, or a policy document with the repo config",
+	ArgsUsage: "[policy file] [other policy file (default: repo config)]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() < 1 || cctx.NArg() > 2 {
+			return lcli.ShowHelp(cctx, fmt.Errorf("incorrect number of arguments"))
+		}
+
+		to, err := readPolicyDocument(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		var from config.MinerPolicy
+		if cctx.NArg() == 2 {
+			doc, err := readPolicyDocument(cctx.Args().Get(1))
+			if err != nil {
+				return err
+			}
+			from = doc.Policy
+		} else {
+			cfg, err := readMinerConfig(cctx)
+			if err != nil {
+				return err
+			}
+			from = config.PolicyFromConfig(cfg)
+		}
+
+		changes, err := config.DiffPolicy(&from, &to.Policy)
+		if err != nil {
+			return err
+		}
+
+		if len(changes) == 0 {
+			fmt.Println("No differences")
+			return nil
+		}
+
+		for _, c := range changes {
+			fmt.Println(c)
+		}
+
+		return nil
+	},
+}
+
+func readPolicyDocument(path string) (*config.PolicyDocument, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, xerrors.Errorf("reading policy document: %w", err)
+	}
+
+	return config.ParsePolicyDocument(b)
+}
+
+func verifyPolicyDocument(doc *config.PolicyDocument, trusted []string, allowUnsigned bool) error {
+	if doc.Signature == "" {
+		if !allowUnsigned {
+			return xerrors.Errorf("policy document is not signed (use --allow-unsigned to import anyway)")
+		}
+		return nil
+	}
+
+	signer, sig, err := doc.SignedBy()
+	if err != nil {
+		return err
+	}
+
+	sb, err := doc.SigningBytes()
+	if err != nil {
+		return err
+	}
+
+	if err := sigs.Verify(sig, signer, sb); err != nil {
+		return xerrors.Errorf("invalid policy document signature: %w", err)
+	}
+
+	if len(trusted) == 0 {
+		if !allowUnsigned {
+			return xerrors.Errorf("policy document is signed by %s, but no trusted signers are set (use --trusted-signer, or --allow-unsigned to import anyway)", signer)
+		}
+		return nil
+	}
+
+	for _, t := range trusted {
+		ta, err := address.NewFromString(t)
+		if err != nil {
+			return xerrors.Errorf("parsing trusted signer address: %w", err)
+		}
+		if ta == signer {
+			return nil
+		}
+	}
+
+	return xerrors.Errorf("policy document signer %s is not trusted", signer)
+}
+
+func readMinerConfig(cctx *cli.Context) (*config.StorageMiner, error) {
+	r, err := repo.NewFS(cctx.String(FlagMinerRepo))
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := r.Exists()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, xerrors.Errorf("repo not initialized")
+	}
+
+	lr, err := r.LockRO(repo.StorageMiner)
+	if err != nil {
+		return nil, xerrors.Errorf("locking repo: %w", err)
+	}
+	defer lr.Close() //nolint:errcheck
+
+	raw, err := lr.Config()
+	if err != nil {
+		return nil, xerrors.Errorf("getting node config: %w", err)
+	}
+
+	cfg, ok := raw.(*config.StorageMiner)
+	if !ok {
+		return nil, xerrors.Errorf("expected miner config, got %T", raw)
+	}
+
+	return cfg, nil
+}
@@ -15,7 +15,9 @@ import (
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
 
+	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/types"
@@ -31,9 +33,14 @@ var provingCmd = &cli.Command{
 		provingDeadlinesCmd,
 		provingDeadlineInfoCmd,
 		provingFaultsCmd,
+		provingDeclareFaultsCmd,
+		provingDeclareRecoveriesCmd,
 		provingCheckProvableCmd,
 		workersCmd(false),
 		provingComputeCmd,
+		provingSenderCmd,
+		provingDisputeCmd,
+		provingWinningHistoryCmd,
 	},
 }
 
@@ -367,6 +374,14 @@ var provingCheckProvableCmd = &cli.Command{
 	Usage:     "Check sectors provable",
 	ArgsUsage: "<deadlineIdx>",
 	Flags: []cli.Flag{
+		&cli.Uint64Flag{
+			Name:  "deadline",
+			Usage: "deadline to check, instead of the argument",
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "generate the vanilla proofs of the next challenges of the deadline, and estimate the proving time of its partitions; no messages are sent",
+		},
 		&cli.BoolFlag{
 			Name:  "only-bad",
 			Usage: "print only bad sectors",
@@ -386,13 +401,22 @@ var provingCheckProvableCmd = &cli.Command{
 		},
 	},
 	Action: func(cctx *cli.Context) error {
-		if cctx.Args().Len() != 1 {
+		var dlIdx uint64
+		switch {
+		case cctx.IsSet("deadline") && cctx.Args().Len() == 0:
+			dlIdx = cctx.Uint64("deadline")
+		case !cctx.IsSet("deadline") && cctx.Args().Len() == 1:
+			var err error
+			dlIdx, err = strconv.ParseUint(cctx.Args().Get(0), 10, 64)
+			if err != nil {
+				return xerrors.Errorf("could not parse deadline index: %w", err)
+			}
+		default:
 			return xerrors.Errorf("must pass deadline index")
 		}
 
-		dlIdx, err := strconv.ParseUint(cctx.Args().Get(0), 10, 64)
-		if err != nil {
-			return xerrors.Errorf("could not parse deadline index: %w", err)
+		if cctx.Bool("dry-run") {
+			return provingDryRun(cctx, dlIdx)
 		}
 
 		api, closer, err := lcli.GetFullNodeAPIV1(cctx)
@@ -525,6 +549,73 @@ var provingCheckProvableCmd = &cli.Command{
 	},
 }
 
+func provingDryRun(cctx *cli.Context, dlIdx uint64) error {
+	if cctx.Bool("slow") || cctx.IsSet("storage-id") || cctx.Bool("faulty") {
+		return xerrors.Errorf("--slow, --storage-id and --faulty can't be used with --dry-run")
+	}
+
+	sapi, scloser, err := lcli.GetProvingAPI(cctx)
+	if err != nil {
+		return err
+	}
+	defer scloser()
+
+	ctx := lcli.ReqContext(cctx)
+
+	res, err := sapi.WindowPoStDryRun(ctx, dlIdx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Deadline %d opens at epoch %s\n", res.Deadline, lcli.EpochTime(res.Height, res.Open))
+	if res.RealChallenges {
+		fmt.Printf("Challenges drawn at epoch %d\n", res.Challenge)
+	} else {
+		fmt.Printf("Challenges simulated, the real ones are drawn at epoch %d\n", res.Challenge)
+	}
+	if res.SnarkTime == 0 {
+		fmt.Println("No proof computed since the miner started, estimates don't include the snark time")
+	}
+
+	window := time.Duration(res.Close-res.Open) * time.Duration(build.BlockDelaySecs) * time.Second
+
+	tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "partition\tsectors\tbad\tvanilla\tslowest sector\testimate")
+	var bad int
+	for _, p := range res.Partitions {
+		est := p.Estimate.Truncate(time.Millisecond).String()
+		if p.Estimate > window {
+			est = color.RedString(est)
+		}
+		nbad := fmt.Sprint(len(p.Failed))
+		if len(p.Failed) > 0 {
+			nbad = color.RedString(nbad)
+		}
+		_, _ = fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%s\t%s\n", p.Index, p.Sectors, nbad,
+			p.Vanilla.Truncate(time.Millisecond), p.Slowest.Truncate(time.Millisecond), est)
+		bad += len(p.Failed)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if bad > 0 {
+		fmt.Println()
+		tw = tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "partition\tsector\terror")
+		for _, p := range res.Partitions {
+			for s, e := range p.Failed {
+				_, _ = fmt.Fprintf(tw, "%d\t%d\t%s\n", p.Index, s, e)
+			}
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 var provingComputeCmd = &cli.Command{
 	Name:  "compute",
 	Usage: "Compute simulated proving tasks",
@@ -550,7 +641,7 @@ It will not send any messages to the chain.`,
 			return xerrors.Errorf("could not parse deadline index: %w", err)
 		}
 
-		sapi, scloser, err := lcli.GetStorageMinerAPI(cctx)
+		sapi, scloser, err := lcli.GetProvingAPI(cctx)
 		if err != nil {
 			return err
 		}
@@ -573,3 +664,320 @@ It will not send any messages to the chain.`,
 		return nil
 	},
 }
+
+var provingSenderCmd = &cli.Command{
+	Name:  "sender",
+	Usage: "List the WindowPoSt messages sent in the last proving period, and whether they landed",
+	Action: func(cctx *cli.Context) error {
+		sapi, scloser, err := lcli.GetProvingAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer scloser()
+
+		ctx := lcli.ReqContext(cctx)
+
+		msgs, err := sapi.WindowPoStSenderStatus(ctx)
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "deadline\tpartitions\tcid\tfrom\tnonce\tpushed\tbumps\tpremium\tnodes\tstate\terror")
+		for _, m := range msgs {
+			state := string(m.State)
+			switch m.State {
+			case api.WdPoStMsgLanded:
+				if m.ExitCode.IsSuccess() {
+					state = color.GreenString("landed at %d", m.Epoch)
+				} else {
+					state = color.RedString("failed at %d (exit %d)", m.Epoch, m.ExitCode)
+				}
+			case api.WdPoStMsgExpired:
+				state = color.RedString(state)
+			case api.WdPoStMsgPending:
+				state = color.YellowString("pending until %d", m.Close)
+			}
+			_, _ = fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%d\t%d\t%d\t%s\t%d\t%s\t%s\n", m.Deadline, m.Partitions, m.Cid, m.From, m.Nonce,
+				m.Pushed, m.Bumps, m.GasPremium, m.FullNodes, state, m.Error)
+		}
+		return tw.Flush()
+	},
+}
+
+var provingWinningHistoryCmd = &cli.Command{
+	Name:  "winning-history",
+	Usage: "List the elections won since the miner started, with the time taken by each phase of the block production",
+	Action: func(cctx *cli.Context) error {
+		sapi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		records, err := sapi.WinningPoStHistory(lcli.ReqContext(cctx))
+		if err != nil {
+			return err
+		}
+
+		ms := func(d time.Duration) string {
+			return d.Truncate(time.Millisecond).String()
+		}
+
+		var included, orphaned, failed int
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "epoch\tnulls\tstarted\tbase info\telection\trandomness\tproof\tmessages\tassembly\tsubmission\tblock\tstate\terror")
+		for _, r := range records {
+			started := r.Started.Format(time.Stamp)
+			if r.Late {
+				started = color.YellowString("%s (late)", started)
+			}
+
+			state := string(r.State)
+			switch r.State {
+			case api.WinningBlockIncluded:
+				included++
+				state = color.GreenString(state)
+			case api.WinningBlockOrphaned:
+				orphaned++
+				state = color.RedString(state)
+			case api.WinningBlockFailed:
+				failed++
+				state = color.RedString(state)
+			}
+
+			_, _ = fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.Epoch, r.NullRounds, started,
+				ms(r.BaseInfo), ms(r.Election), ms(r.Randomness), ms(r.Proof), ms(r.Messages), ms(r.Assembly), ms(r.Submission),
+				r.Block, state, r.Error)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Printf("Won: %d, included: %d, orphaned: %d, failed: %d\n", len(records), included, orphaned, failed)
+		return nil
+	},
+}
+
+var provingDisputeCmd = &cli.Command{
+	Name:  "dispute",
+	Usage: "Check the WindowPoSts of miners, and dispute the invalid ones",
+	Subcommands: []*cli.Command{
+		provingDisputeCheckCmd,
+		provingDisputeSendCmd,
+		provingDisputeEvidenceCmd,
+		provingDisputeStatusCmd,
+	},
+}
+
+var provingDisputeCheckCmd = &cli.Command{
+	Name:      "check",
+	Usage:     "Simulate disputing the WindowPoSts submitted by a miner at the last occurrence of a deadline",
+	ArgsUsage: "[deadlineIdx]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "miner",
+			Usage: "miner to check, the miner of the node by default",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() > 1 {
+			return xerrors.Errorf("pass at most one deadline index")
+		}
+
+		sapi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+
+		var maddr address.Address
+		if cctx.IsSet("miner") {
+			maddr, err = address.NewFromString(cctx.String("miner"))
+		} else {
+			maddr, err = sapi.ActorAddress(ctx)
+		}
+		if err != nil {
+			return xerrors.Errorf("getting miner address: %w", err)
+		}
+
+		var dls []uint64
+		if cctx.Args().Present() {
+			dlIdx, err := strconv.ParseUint(cctx.Args().First(), 10, 64)
+			if err != nil {
+				return xerrors.Errorf("could not parse deadline index: %w", err)
+			}
+			dls = append(dls, dlIdx)
+		} else {
+			napi, ncloser, err := lcli.GetFullNodeAPIV1(cctx)
+			if err != nil {
+				return err
+			}
+			defer ncloser()
+
+			di, err := napi.StateMinerProvingDeadline(ctx, maddr, types.EmptyTSK)
+			if err != nil {
+				return xerrors.Errorf("getting proving deadline: %w", err)
+			}
+			for dl := uint64(0); dl < di.WPoStPeriodDeadlines; dl++ {
+				dls = append(dls, dl)
+			}
+		}
+
+		var posts []api.DisputablePoSt
+		for _, dl := range dls {
+			dp, err := sapi.ProvingDisputeCheck(ctx, maddr, dl)
+			if err != nil {
+				return xerrors.Errorf("checking deadline %d: %w", dl, err)
+			}
+			posts = append(posts, dp...)
+		}
+
+		return printDisputablePoSts(posts)
+	},
+}
+
+var provingDisputeSendCmd = &cli.Command{
+	Name:      "send",
+	Usage:     "Send a DisputeWindowedPoSt message for an invalid WindowPoSt",
+	ArgsUsage: "<minerAddress> <deadlineIdx> <postIndex>",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "really-do-it",
+			Usage: "send the message",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 3 {
+			return xerrors.Errorf("must pass miner address, deadline index and post index")
+		}
+
+		maddr, err := address.NewFromString(cctx.Args().Get(0))
+		if err != nil {
+			return xerrors.Errorf("parsing miner address: %w", err)
+		}
+		dlIdx, err := strconv.ParseUint(cctx.Args().Get(1), 10, 64)
+		if err != nil {
+			return xerrors.Errorf("could not parse deadline index: %w", err)
+		}
+		postIdx, err := strconv.ParseUint(cctx.Args().Get(2), 10, 64)
+		if err != nil {
+			return xerrors.Errorf("could not parse post index: %w", err)
+		}
+
+		sapi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+
+		posts, err := sapi.ProvingDisputeCheck(ctx, maddr, dlIdx)
+		if err != nil {
+			return err
+		}
+		if postIdx >= uint64(len(posts)) {
+			return xerrors.Errorf("post index %d out of range, deadline %d has %d disputable window posts", postIdx, dlIdx, len(posts))
+		}
+		p := posts[postIdx]
+		if err := printDisputablePoSts([]api.DisputablePoSt{p}); err != nil {
+			return err
+		}
+		if p.Valid {
+			return xerrors.Errorf("the window post is valid, the dispute would fail")
+		}
+
+		own, err := sapi.ActorAddress(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting actor address: %w", err)
+		}
+		if own == p.Miner {
+			fmt.Println(color.RedString("WARNING: %s is the miner of this node; disputing its window post penalizes it", own))
+		}
+
+		if !cctx.Bool("really-do-it") {
+			fmt.Println("Pass --really-do-it to send the dispute")
+			return nil
+		}
+
+		c, err := sapi.ProvingDispute(ctx, maddr, dlIdx, postIdx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("dispute message:", c)
+		return nil
+	},
+}
+
+var provingDisputeEvidenceCmd = &cli.Command{
+	Name:      "evidence",
+	Usage:     "Record sectors of a miner known to be faulty, to check the WindowPoSts of their deadlines",
+	ArgsUsage: "<minerAddress> <sectorNum> ...",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() < 2 {
+			return xerrors.Errorf("must pass miner address and sector numbers")
+		}
+
+		maddr, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("parsing miner address: %w", err)
+		}
+
+		var sectors []abi.SectorNumber
+		for _, arg := range cctx.Args().Tail() {
+			n, err := strconv.ParseUint(arg, 10, 64)
+			if err != nil {
+				return xerrors.Errorf("could not parse sector number %q: %w", arg, err)
+			}
+			sectors = append(sectors, abi.SectorNumber(n))
+		}
+
+		sapi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return sapi.ProvingDisputeEvidence(lcli.ReqContext(cctx), maddr, sectors)
+	},
+}
+
+var provingDisputeStatusCmd = &cli.Command{
+	Name:  "status",
+	Usage: "List the WindowPoSts checked by the disputer which can still be disputed",
+	Action: func(cctx *cli.Context) error {
+		sapi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		posts, err := sapi.ProvingDisputeStatus(lcli.ReqContext(cctx))
+		if err != nil {
+			return err
+		}
+
+		return printDisputablePoSts(posts)
+	},
+}
+
+func printDisputablePoSts(posts []api.DisputablePoSt) error {
+	tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "miner\tdeadline\tpost\tclose\tdisputable until\tproof\tevidence\tdispute\terror")
+	for _, p := range posts {
+		proof := color.GreenString("valid")
+		if !p.Valid {
+			proof = color.RedString("invalid")
+		}
+		dispute := ""
+		if p.Dispute != nil {
+			dispute = p.Dispute.String()
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%s\t%v\t%s\t%s\n", p.Miner, p.Deadline, p.PoStIndex, p.Close, p.DisputeEnd, proof, p.Evidence, dispute, p.Error)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/go-state-types/builtin/v8/miner"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/v0api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/actors"
+	lminer "github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/actors/policy"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	lcli "github.com/filecoin-project/lotus/cli"
+)
+
+var provingDeclareFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "sector-file",
+		Usage: "provide a file containing one sector number in each line, in addition to the arguments",
+	},
+	&cli.StringFlag{
+		Name:  "max-fee",
+		Usage: "use up to this amount of FIL for the message",
+		Value: "0",
+	},
+	&cli.BoolFlag{
+		Name:  "really-do-it",
+		Usage: "send the message, otherwise only the declarations and their impact are shown",
+	},
+}
+
+var provingDeclareFaultsCmd = &cli.Command{
+	Name:      "declare-faults",
+	Usage:     "Declare sectors as faulty, ahead of their deadline",
+	ArgsUsage: "<sectorNum> ...",
+	Flags:     provingDeclareFlags,
+	Action: func(cctx *cli.Context) error {
+		return provingDeclare(cctx, false)
+	},
+}
+
+var provingDeclareRecoveriesCmd = &cli.Command{
+	Name:      "declare-recoveries",
+	Usage:     "Declare faulty sectors as recovered, to be proven again at their next deadline",
+	ArgsUsage: "<sectorNum> ...",
+	Flags:     provingDeclareFlags,
+	Action: func(cctx *cli.Context) error {
+		return provingDeclare(cctx, true)
+	},
+}
+
+type declaredPartition struct {
+	deadline  uint64
+	partition uint64
+	sectors   []uint64
+}
+
+// provingDeclare declares the given sectors as faulty, or as recovered, after
+// showing the declarations by partition, their impact on the power and funds of
+// the miner, and the result of the simulated message
+func provingDeclare(cctx *cli.Context, recovery bool) error {
+	sectors, err := getSectorsFromArgs(cctx)
+	if err != nil {
+		return err
+	}
+
+	mf, err := types.ParseFIL(cctx.String("max-fee"))
+	if err != nil {
+		return xerrors.Errorf("parsing max-fee: %w", err)
+	}
+
+	fullApi, closer, err := lcli.GetFullNodeAPI(cctx)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	ctx := lcli.ReqContext(cctx)
+
+	maddr, err := getActorAddress(ctx, cctx)
+	if err != nil {
+		return err
+	}
+
+	head, err := fullApi.ChainHead(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	di, err := fullApi.StateMinerProvingDeadline(ctx, maddr, head.Key())
+	if err != nil {
+		return xerrors.Errorf("getting proving deadline: %w", err)
+	}
+
+	// group the sectors by partition
+	byPartition := map[lminer.SectorLocation][]uint64{}
+	seen := map[uint64]struct{}{}
+	for _, s := range sectors {
+		if _, ok := seen[s]; ok {
+			continue
+		}
+		seen[s] = struct{}{}
+
+		loc, err := fullApi.StateSectorPartition(ctx, maddr, abi.SectorNumber(s), head.Key())
+		if err != nil {
+			return xerrors.Errorf("finding sector %d: %w", s, err)
+		}
+		if loc == nil {
+			return xerrors.Errorf("sector %d not found", s)
+		}
+		byPartition[*loc] = append(byPartition[*loc], s)
+	}
+
+	var decls []declaredPartition
+	partitions := map[uint64][]api.Partition{}
+	for loc, secs := range byPartition {
+		if _, ok := partitions[loc.Deadline]; !ok {
+			// the actor rejects the declarations for the deadlines opening
+			// within the fault declaration cutoff
+			open := di.Open + abi.ChainEpoch((loc.Deadline+di.WPoStPeriodDeadlines-di.Index)%di.WPoStPeriodDeadlines)*di.WPoStChallengeWindow
+			if open <= head.Height() {
+				open += di.WPoStProvingPeriod
+			}
+			if head.Height() >= open-di.FaultDeclarationCutoff {
+				return xerrors.Errorf("deadline %d opens at epoch %d, its declarations had to land before epoch %d; wait for it to close", loc.Deadline, open, open-di.FaultDeclarationCutoff)
+			}
+
+			parts, err := fullApi.StateMinerPartitions(ctx, maddr, loc.Deadline, head.Key())
+			if err != nil {
+				return xerrors.Errorf("getting partitions of deadline %d: %w", loc.Deadline, err)
+			}
+			partitions[loc.Deadline] = parts
+		}
+		if loc.Partition >= uint64(len(partitions[loc.Deadline])) {
+			return xerrors.Errorf("partition %d of deadline %d not found", loc.Partition, loc.Deadline)
+		}
+		part := partitions[loc.Deadline][loc.Partition]
+
+		d := declaredPartition{deadline: loc.Deadline, partition: loc.Partition}
+		for _, s := range secs {
+			live, err := part.LiveSectors.IsSet(s)
+			if err != nil {
+				return err
+			}
+			faulty, err := part.FaultySectors.IsSet(s)
+			if err != nil {
+				return err
+			}
+			recovering, err := part.RecoveringSectors.IsSet(s)
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case !live:
+				return xerrors.Errorf("sector %d isn't live", s)
+			case recovery && !faulty:
+				fmt.Printf("skipping sector %d: not faulty\n", s)
+			case recovery && recovering:
+				fmt.Printf("skipping sector %d: already recovering\n", s)
+			case !recovery && faulty && !recovering:
+				fmt.Printf("skipping sector %d: already faulty\n", s)
+			default:
+				d.sectors = append(d.sectors, s)
+			}
+		}
+		if len(d.sectors) > 0 {
+			sort.Slice(d.sectors, func(i, j int) bool { return d.sectors[i] < d.sectors[j] })
+			decls = append(decls, d)
+		}
+	}
+	if len(decls) == 0 {
+		fmt.Println("nothing to declare")
+		return nil
+	}
+	sort.Slice(decls, func(i, j int) bool {
+		if decls[i].deadline != decls[j].deadline {
+			return decls[i].deadline < decls[j].deadline
+		}
+		return decls[i].partition < decls[j].partition
+	})
+
+	nv, err := fullApi.StateNetworkVersion(ctx, head.Key())
+	if err != nil {
+		return xerrors.Errorf("getting network version: %w", err)
+	}
+	declMax, err := policy.GetDeclarationsMax(nv)
+	if err != nil {
+		return xerrors.Errorf("getting max declarations: %w", err)
+	}
+	if len(decls) > declMax {
+		return xerrors.Errorf("the sectors are in %d partitions, one message can declare at most %d; split the sectors", len(decls), declMax)
+	}
+
+	mi, err := fullApi.StateMinerInfo(ctx, maddr, head.Key())
+	if err != nil {
+		return xerrors.Errorf("getting miner info: %w", err)
+	}
+
+	msg, err := provingDeclareImpact(ctx, fullApi, maddr, head, mi, decls, recovery)
+	if err != nil {
+		return err
+	}
+
+	res, err := fullApi.StateCall(ctx, msg, head.Key())
+	if err != nil {
+		return xerrors.Errorf("simulating message: %w", err)
+	}
+	if res.MsgRct.ExitCode != 0 {
+		return xerrors.Errorf("simulated message failed (exit %d): %s", res.MsgRct.ExitCode, res.Error)
+	}
+	fmt.Printf("Simulation: %s (gas used %d)\n", color.GreenString("ok"), res.MsgRct.GasUsed)
+
+	if !cctx.Bool("really-do-it") {
+		fmt.Println("Pass --really-do-it to send the message")
+		return nil
+	}
+
+	sm, err := fullApi.MpoolPushMessage(ctx, msg, &api.MessageSendSpec{MaxFee: abi.TokenAmount(mf)})
+	if err != nil {
+		return xerrors.Errorf("mpool push message: %w", err)
+	}
+
+	fmt.Println("Message CID:", sm.Cid())
+	return nil
+}
+
+// provingDeclareImpact prints the declarations, with the power and funds of the
+// miner they affect, and returns the declaration message
+func provingDeclareImpact(ctx context.Context, fullApi v0api.FullNode, maddr address.Address, head *types.TipSet, mi api.MinerInfo, decls []declaredPartition, recovery bool) (*types.Message, error) {
+	var all []uint64
+	for _, d := range decls {
+		all = append(all, d.sectors...)
+	}
+	bf := bitfield.NewFromSet(all)
+	infos, err := fullApi.StateMinerSectors(ctx, maddr, &bf, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting sector infos: %w", err)
+	}
+	byNumber := map[abi.SectorNumber]*lminer.SectorOnChainInfo{}
+	for _, si := range infos {
+		byNumber[si.SectorNumber] = si
+	}
+
+	// the fault fee of a sector is its expected reward over the continued
+	// fault projection period of the miner actor, 3.51 days, charged at each
+	// proving period it is faulty
+	faultFee := func(si *lminer.SectorOnChainInfo) abi.TokenAmount {
+		return big.Div(big.Mul(si.ExpectedDayReward, big.NewInt(351)), big.NewInt(100))
+	}
+
+	totalFee, totalPledge := big.Zero(), big.Zero()
+	tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "deadline\tpartition\tsectors\traw power\tfault fee / period\tpledge")
+	for _, d := range decls {
+		fee, pledge := big.Zero(), big.Zero()
+		for _, s := range d.sectors {
+			si, ok := byNumber[abi.SectorNumber(s)]
+			if !ok {
+				return nil, xerrors.Errorf("sector %d info not found", s)
+			}
+			fee = big.Add(fee, faultFee(si))
+			pledge = big.Add(pledge, si.InitialPledge)
+		}
+		totalFee, totalPledge = big.Add(totalFee, fee), big.Add(totalPledge, pledge)
+
+		power := types.SizeStr(types.NewInt(uint64(mi.SectorSize) * uint64(len(d.sectors))))
+		_, _ = fmt.Fprintf(tw, "%d\t%d\t%d\t%s\t%s\t%s\n", d.deadline, d.partition, len(d.sectors), power, types.FIL(fee).Short(), types.FIL(pledge).Short())
+	}
+	if err := tw.Flush(); err != nil {
+		return nil, err
+	}
+
+	power := types.SizeStr(types.NewInt(uint64(mi.SectorSize) * uint64(len(all))))
+	if !recovery {
+		fmt.Printf("Power removed: %s (raw)\n", color.RedString(power))
+		fmt.Printf("Fault fee: ~%s for each proving period the sectors stay faulty\n", color.RedString(types.FIL(totalFee).Short()))
+		fmt.Printf("Pledge: %s; the sectors are terminated after being faulty for %d days\n", types.FIL(totalPledge).Short(), miner.FaultMaxAge/builtin.EpochsInDay)
+	} else {
+		mact, err := fullApi.StateGetActor(ctx, maddr, head.Key())
+		if err != nil {
+			return nil, err
+		}
+		mas, err := lminer.Load(store.ActorStore(ctx, blockstore.NewAPIBlockstore(fullApi)), mact)
+		if err != nil {
+			return nil, err
+		}
+		debt, err := mas.FeeDebt()
+		if err != nil {
+			return nil, xerrors.Errorf("getting fee debt: %w", err)
+		}
+		avail, err := fullApi.StateMinerAvailableBalance(ctx, maddr, head.Key())
+		if err != nil {
+			return nil, xerrors.Errorf("getting available balance: %w", err)
+		}
+
+		fmt.Printf("Power restored: %s (raw), once the sectors are proven at their deadline\n", color.GreenString(power))
+		fmt.Printf("Fault fee avoided: ~%s per proving period; it is charged again when the proofs of the sectors fail\n", types.FIL(totalFee).Short())
+		fmt.Printf("Fee debt: %s, repaid from the available balance (%s) when declaring\n", types.FIL(debt).Short(), types.FIL(avail).Short())
+	}
+
+	var params cbg.CBORMarshaler
+	method := builtin.MethodsMiner.DeclareFaults
+	if recovery {
+		p := &miner.DeclareFaultsRecoveredParams{}
+		for _, d := range decls {
+			p.Recoveries = append(p.Recoveries, miner.RecoveryDeclaration{Deadline: d.deadline, Partition: d.partition, Sectors: bitfield.NewFromSet(d.sectors)})
+		}
+		params, method = p, builtin.MethodsMiner.DeclareFaultsRecovered
+	} else {
+		p := &miner.DeclareFaultsParams{}
+		for _, d := range decls {
+			p.Faults = append(p.Faults, miner.FaultDeclaration{Deadline: d.deadline, Partition: d.partition, Sectors: bitfield.NewFromSet(d.sectors)})
+		}
+		params = p
+	}
+
+	enc, aerr := actors.SerializeParams(params)
+	if aerr != nil {
+		return nil, xerrors.Errorf("serializing params: %w", aerr)
+	}
+
+	return &types.Message{
+		From:   mi.Worker,
+		To:     maddr,
+		Method: method,
+		Value:  big.Zero(),
+		Params: enc,
+	}, nil
+}
@@ -7,13 +7,17 @@ import (
 	"text/tabwriter"
 
 	"github.com/docker/go-units"
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
 	"github.com/filecoin-project/go-state-types/abi"
 
 	"github.com/filecoin-project/lotus/chain/types"
 	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
 )
 
 var retrievalDealsCmd = &cli.Command{
@@ -24,6 +28,7 @@ var retrievalDealsCmd = &cli.Command{
 		retrievalDealsListCmd,
 		retrievalSetAskCmd,
 		retrievalGetAskCmd,
+		retrievalPricingCmd,
 	},
 }
 
@@ -274,3 +279,207 @@ var retrievalGetAskCmd = &cli.Command{
 
 	},
 }
+
+var retrievalPricingCmd = &cli.Command{
+	Name:  "pricing",
+	Usage: "Manage the retrieval pricing policy, used when the retrieval pricing strategy is 'policy'",
+	Subcommands: []*cli.Command{
+		retrievalPricingGetCmd,
+		retrievalPricingSetCmd,
+	},
+}
+
+var retrievalPricingGetCmd = &cli.Command{
+	Name:  "get",
+	Usage: "Show the retrieval pricing policy",
+	Action: func(cctx *cli.Context) error {
+		ctx := lcli.DaemonContext(cctx)
+
+		api, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		policy, err := api.DealsRetrievalPricingPolicy(ctx)
+		if err != nil {
+			return err
+		}
+
+		sealedPercent := policy.SealedPricePercent
+		if sealedPercent == 0 {
+			sealedPercent = 100
+		}
+
+		fmt.Printf("Verified deals free transfer: %t\n", policy.VerifiedDealsFreeTransfer)
+		fmt.Printf("Sealed retrieval price:       %d%% of the ask\n", sealedPercent)
+
+		fmt.Printf("Free clients:                 %d\n", len(policy.FreeClients))
+		for _, p := range policy.FreeClients {
+			fmt.Printf("  %s\n", p)
+		}
+
+		fmt.Printf("Piece prices:                 %d\n", len(policy.PiecePrices))
+		if len(policy.PiecePrices) == 0 {
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "  Piece CID\tPrice per GiB\tUnseal Price\n")
+		for _, pp := range policy.PiecePrices {
+			price, unseal := "ask", "ask"
+			if pp.PricePerByte != nil {
+				price = types.FIL(types.BigMul(*pp.PricePerByte, types.NewInt(1<<30))).String()
+			}
+			if pp.UnsealPrice != nil {
+				unseal = types.FIL(*pp.UnsealPrice).String()
+			}
+			_, _ = fmt.Fprintf(w, "  %s\t%s\t%s\n", pp.PieceCID, price, unseal)
+		}
+		return w.Flush()
+	},
+}
+
+var retrievalPricingSetCmd = &cli.Command{
+	Name:  "set",
+	Usage: "Update the retrieval pricing policy",
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "add-free-client",
+			Usage: "peer ID of a client retrieving for free",
+		},
+		&cli.StringSliceFlag{
+			Name:  "remove-free-client",
+			Usage: "peer ID of a client to remove from the free clients",
+		},
+		&cli.BoolFlag{
+			Name:  "verified-deals-free-transfer",
+			Usage: "don't charge for the transfer of payloads belonging to verified deals",
+		},
+		&cli.Uint64Flag{
+			Name:  "sealed-price-percent",
+			Usage: "price of retrievals which require unsealing, in percent of the ask price",
+		},
+		&cli.StringFlag{
+			Name:  "piece",
+			Usage: "piece CID to set the price of with --price and --unseal-price",
+		},
+		&cli.StringFlag{
+			Name:  "price",
+			Usage: "price of retrievals from --piece (FIL/GiB)",
+		},
+		&cli.StringFlag{
+			Name:  "unseal-price",
+			Usage: "price to unseal --piece",
+		},
+		&cli.StringSliceFlag{
+			Name:  "remove-piece",
+			Usage: "piece CID to remove the price of",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := lcli.DaemonContext(cctx)
+
+		api, closer, err := lcli.GetMarketsAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		policy, err := api.DealsRetrievalPricingPolicy(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, s := range cctx.StringSlice("remove-free-client") {
+			p, err := peer.Decode(s)
+			if err != nil {
+				return xerrors.Errorf("parsing peer ID %q: %w", s, err)
+			}
+			for i, c := range policy.FreeClients {
+				if c == p {
+					policy.FreeClients = append(policy.FreeClients[:i], policy.FreeClients[i+1:]...)
+					break
+				}
+			}
+		}
+
+	addClients:
+		for _, s := range cctx.StringSlice("add-free-client") {
+			p, err := peer.Decode(s)
+			if err != nil {
+				return xerrors.Errorf("parsing peer ID %q: %w", s, err)
+			}
+			for _, c := range policy.FreeClients {
+				if c == p {
+					continue addClients
+				}
+			}
+			policy.FreeClients = append(policy.FreeClients, p)
+		}
+
+		if cctx.IsSet("verified-deals-free-transfer") {
+			policy.VerifiedDealsFreeTransfer = cctx.Bool("verified-deals-free-transfer")
+		}
+
+		if cctx.IsSet("sealed-price-percent") {
+			policy.SealedPricePercent = cctx.Uint64("sealed-price-percent")
+		}
+
+		for _, s := range cctx.StringSlice("remove-piece") {
+			c, err := cid.Decode(s)
+			if err != nil {
+				return xerrors.Errorf("parsing piece CID %q: %w", s, err)
+			}
+			for i, pp := range policy.PiecePrices {
+				if pp.PieceCID.Equals(c) {
+					policy.PiecePrices = append(policy.PiecePrices[:i], policy.PiecePrices[i+1:]...)
+					break
+				}
+			}
+		}
+
+		if cctx.IsSet("piece") {
+			c, err := cid.Decode(cctx.String("piece"))
+			if err != nil {
+				return xerrors.Errorf("parsing piece CID: %w", err)
+			}
+			if !cctx.IsSet("price") && !cctx.IsSet("unseal-price") {
+				return xerrors.Errorf("--piece requires --price or --unseal-price")
+			}
+
+			idx := -1
+			for i, pp := range policy.PiecePrices {
+				if pp.PieceCID.Equals(c) {
+					idx = i
+					break
+				}
+			}
+			if idx < 0 {
+				policy.PiecePrices = append(policy.PiecePrices, dtypes.RetrievalPiecePrice{PieceCID: c})
+				idx = len(policy.PiecePrices) - 1
+			}
+
+			if cctx.IsSet("price") {
+				v, err := types.ParseFIL(cctx.String("price"))
+				if err != nil {
+					return err
+				}
+				price := types.BigDiv(types.BigInt(v), types.NewInt(1<<30))
+				policy.PiecePrices[idx].PricePerByte = &price
+			}
+			if cctx.IsSet("unseal-price") {
+				v, err := types.ParseFIL(cctx.String("unseal-price"))
+				if err != nil {
+					return err
+				}
+				price := abi.TokenAmount(v)
+				policy.PiecePrices[idx].UnsealPrice = &price
+			}
+		} else if cctx.IsSet("price") || cctx.IsSet("unseal-price") {
+			return xerrors.Errorf("--price and --unseal-price require --piece")
+		}
+
+		return api.DealsSetRetrievalPricingPolicy(ctx, policy)
+	},
+}
@@ -15,8 +15,10 @@ import (
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/api/v0api"
 	"github.com/filecoin-project/lotus/api/v1api"
+	"github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/build"
 	lcli "github.com/filecoin-project/lotus/cli"
+	cliutil "github.com/filecoin-project/lotus/cli/util"
 	"github.com/filecoin-project/lotus/lib/ulimit"
 	"github.com/filecoin-project/lotus/metrics"
 	"github.com/filecoin-project/lotus/node"
@@ -74,7 +76,9 @@ var runCmd = &cli.Command{
 			return err
 		}
 
-		nodeApi, ncloser, err := lcli.GetFullNodeAPIV1(cctx)
+		// reads made by the miner show up as sealing in the block cache metrics of
+		// the full node
+		nodeApi, ncloser, err := lcli.GetFullNodeAPIV1(cctx, cliutil.FullNodeCacheCaller(blockstore.CacheCallerSealing))
 		if err != nil {
 			return xerrors.Errorf("getting full node api: %w", err)
 		}
@@ -184,7 +188,7 @@ var runCmd = &cli.Command{
 		}
 
 		// Serve the RPC.
-		rpcStopper, err := node.ServeRPC(handler, "lotus-miner", endpoint)
+		rpcStopper, err := node.ServeRPC(handler, "lotus-miner", endpoint, node.APITLSConfig(minerapi))
 		if err != nil {
 			return fmt.Errorf("failed to start json-rpc endpoint: %s", err)
 		}
@@ -25,6 +25,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/types"
 	lcli "github.com/filecoin-project/lotus/cli"
 	"github.com/filecoin-project/lotus/lib/httpreader"
+	"github.com/filecoin-project/lotus/node/repo"
 	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
 )
@@ -37,14 +38,16 @@ var sealingCmd = &cli.Command{
 		workersCmd(true),
 		sealingSchedDiagCmd,
 		sealingAbortCmd,
+		sealingPrioritiesCmd,
 		sealingDataCidCmd,
 	},
 }
 
 func workersCmd(sealing bool) *cli.Command {
 	return &cli.Command{
-		Name:  "workers",
-		Usage: "list workers",
+		Name:        "workers",
+		Usage:       "list workers",
+		Subcommands: workersSubcommands(sealing),
 		Flags: []cli.Flag{
 			&cli.BoolFlag{
 				Name:        "color",
@@ -112,6 +115,10 @@ func workersCmd(sealing bool) *cli.Command {
 
 				fmt.Printf("Worker %s, host %s%s\n", stat.id, color.MagentaString(stat.Info.Hostname), disabled)
 
+				if len(stat.Tags) > 0 {
+					fmt.Printf("\tTAGS: %s\n", strings.Join(stat.Tags, ", "))
+				}
+
 				// Task counts
 				tc := make([][]string, 0, len(stat.TaskCounts))
 
@@ -214,6 +221,100 @@ func workersCmd(sealing bool) *cli.Command {
 	}
 }
 
+func workersSubcommands(sealing bool) []*cli.Command {
+	if !sealing {
+		return nil
+	}
+
+	return []*cli.Command{
+		sealingWorkersTagCmd,
+		sealingWorkersUntagCmd,
+		sealingWorkersBootstrapTokenCmd,
+	}
+}
+
+var sealingWorkersTagCmd = &cli.Command{
+	Name:      "tag",
+	Usage:     "Add tags to the workers on a host",
+	ArgsUsage: "[hostname] [tags...]",
+	Description: `The tasks matched by the affinity rules (Storage.AffinityRules in the config)
+only run on workers with their tags. The changes are lost when the miner
+restarts; set Storage.WorkerTags in the config to persist them.`,
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() < 2 {
+			return xerrors.Errorf("expected a hostname and at least 1 tag")
+		}
+
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return nodeApi.SealingWorkersTag(lcli.ReqContext(cctx), cctx.Args().First(), cctx.Args().Tail())
+	},
+}
+
+var sealingWorkersUntagCmd = &cli.Command{
+	Name:      "untag",
+	Usage:     "Remove tags from the workers on a host",
+	ArgsUsage: "[hostname] [tags...]",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() < 2 {
+			return xerrors.Errorf("expected a hostname and at least 1 tag")
+		}
+
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return nodeApi.SealingWorkersUntag(lcli.ReqContext(cctx), cctx.Args().First(), cctx.Args().Tail())
+	},
+}
+
+var sealingWorkersBootstrapTokenCmd = &cli.Command{
+	Name:  "bootstrap-token",
+	Usage: "Create a one-time token registering a worker",
+	Description: `Start a worker with 'lotus-worker run --bootstrap <printed value>' to register
+it without copying the API token of the miner. The worker gets its own API
+token, which is listed by 'lotus-miner auth list' and can be revoked, and the
+settings of a template (Storage.WorkerTemplates in the config): the template
+of the token, or the first template the host of the worker satisfies.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "template",
+			Usage: "template of the worker",
+		},
+		&cli.DurationFlag{
+			Name:  "ttl",
+			Usage: "validity of the token, 0 for no expiry",
+			Value: 24 * time.Hour,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ainfo, err := lcli.GetAPIInfo(cctx, repo.StorageMiner)
+		if err != nil {
+			return xerrors.Errorf("could not get miner API info: %w", err)
+		}
+
+		token, err := nodeApi.SealingWorkersBootstrapToken(lcli.ReqContext(cctx), cctx.String("template"), cctx.Duration("ttl"))
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s:%s\n", token, ainfo.Addr)
+		return nil
+	},
+}
+
 var sealingJobsCmd = &cli.Command{
 	Name:  "jobs",
 	Usage: "list running jobs",
@@ -405,6 +506,77 @@ var sealingAbortCmd = &cli.Command{
 	},
 }
 
+var sealingPrioritiesCmd = &cli.Command{
+	Name:  "priorities",
+	Usage: "Show or change the priorities of the sealing tasks by class of sector",
+	Description: `The priorities of the tasks waiting to be scheduled are changed as well.
+The changes are lost when the miner restarts; set Storage.Priorities in the
+config to persist them.`,
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "snap-deals",
+			Usage: "priority of the tasks of snap-deals sectors",
+		},
+		&cli.IntFlag{
+			Name:  "deals",
+			Usage: "priority of the tasks of new sectors with deals",
+		},
+		&cli.IntFlag{
+			Name:  "cc",
+			Usage: "priority of the tasks of committed capacity sectors",
+		},
+		&cli.BoolFlag{
+			Name:  "preempt",
+			Usage: "let prepared tasks give their worker away to waiting tasks of a higher priority",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+
+		prio, err := nodeApi.SealingSchedPriorities(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting priorities: %w", err)
+		}
+
+		if cctx.IsSet("snap-deals") || cctx.IsSet("deals") || cctx.IsSet("cc") || cctx.IsSet("preempt") {
+			if cctx.IsSet("snap-deals") {
+				prio.SnapDeals = cctx.Int("snap-deals")
+			}
+			if cctx.IsSet("deals") {
+				prio.Deals = cctx.Int("deals")
+			}
+			if cctx.IsSet("cc") {
+				prio.CC = cctx.Int("cc")
+			}
+			if cctx.IsSet("preempt") {
+				prio.Preempt = cctx.Bool("preempt")
+			}
+
+			if err := nodeApi.SealingSetSchedPriorities(ctx, prio); err != nil {
+				return xerrors.Errorf("setting priorities: %w", err)
+			}
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "Class\tPriority\n")
+		_, _ = fmt.Fprintf(tw, "snap-deals\t%d\n", prio.SnapDeals)
+		_, _ = fmt.Fprintf(tw, "deals\t%d\n", prio.Deals)
+		_, _ = fmt.Fprintf(tw, "cc\t%d\n", prio.CC)
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Printf("\nPreemption: %t\n", prio.Preempt)
+		return nil
+	},
+}
+
 var sealingDataCidCmd = &cli.Command{
 	Name:      "data-cid",
 	Usage:     "Compute data CID using workers",
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -17,6 +18,7 @@ import (
 	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-bitfield"
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/big"
@@ -25,6 +27,7 @@ import (
 	"github.com/filecoin-project/go-state-types/network"
 
 	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/v0api"
 	"github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/chain/actors"
 	"github.com/filecoin-project/lotus/chain/actors/adt"
@@ -34,6 +37,7 @@ import (
 	lcli "github.com/filecoin-project/lotus/cli"
 	"github.com/filecoin-project/lotus/lib/tablewriter"
 	sealing "github.com/filecoin-project/lotus/storage/pipeline"
+	"github.com/filecoin-project/lotus/storage/pipeline/sealiface"
 )
 
 var sectorsCmd = &cli.Command{
@@ -47,6 +51,7 @@ var sectorsCmd = &cli.Command{
 		sectorsPledgeCmd,
 		sectorPreCommitsCmd,
 		sectorsCheckExpireCmd,
+		sectorsExpirationsCmd,
 		sectorsExpiredCmd,
 		sectorsRenewCmd,
 		sectorsExtendCmd,
@@ -54,6 +59,7 @@ var sectorsCmd = &cli.Command{
 		sectorsRemoveCmd,
 		sectorsSnapUpCmd,
 		sectorsSnapAbortCmd,
+		sectorsSnapCmd,
 		sectorsStartSealCmd,
 		sectorsSealDelayCmd,
 		sectorsCapacityCollateralCmd,
@@ -740,6 +746,44 @@ func ArrayToString(array []uint64) string {
 	return strings.Join(sarray, ",")
 }
 
+// activeSectorLocations returns the deadline and partition of the active
+// sectors of a miner
+func activeSectorLocations(ctx context.Context, fullApi v0api.FullNode, maddr address.Address) (map[abi.SectorNumber]*lminer.SectorLocation, error) {
+	mact, err := fullApi.StateGetActor(ctx, maddr, types.EmptyTSK)
+	if err != nil {
+		return nil, err
+	}
+
+	tbs := blockstore.NewTieredBstore(blockstore.NewAPIBlockstore(fullApi), blockstore.NewMemory())
+	mas, err := lminer.Load(adt.WrapStore(ctx, cbor.NewCborStore(tbs)), mact)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := make(map[abi.SectorNumber]*lminer.SectorLocation)
+
+	if err := mas.ForEachDeadline(func(dlIdx uint64, dl lminer.Deadline) error {
+		return dl.ForEachPartition(func(partIdx uint64, part lminer.Partition) error {
+			pas, err := part.ActiveSectors()
+			if err != nil {
+				return err
+			}
+
+			return pas.ForEach(func(i uint64) error {
+				locations[abi.SectorNumber(i)] = &lminer.SectorLocation{
+					Deadline:  dlIdx,
+					Partition: partIdx,
+				}
+				return nil
+			})
+		})
+	}); err != nil {
+		return nil, err
+	}
+
+	return locations, nil
+}
+
 func getSectorsFromFile(filePath string) ([]uint64, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -767,6 +811,31 @@ func getSectorsFromFile(filePath string) ([]uint64, error) {
 	return sectors, nil
 }
 
+// getSectorsFromArgs returns the sector numbers passed as arguments, and the
+// ones in the file passed with the sector-file flag
+func getSectorsFromArgs(cctx *cli.Context) ([]uint64, error) {
+	var sectors []uint64
+	for _, arg := range cctx.Args().Slice() {
+		n, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			return nil, xerrors.Errorf("could not parse sector number %q: %w", arg, err)
+		}
+		sectors = append(sectors, n)
+	}
+	if cctx.IsSet("sector-file") {
+		fs, err := getSectorsFromFile(cctx.String("sector-file"))
+		if err != nil {
+			return nil, err
+		}
+		sectors = append(sectors, fs...)
+	}
+	if len(sectors) == 0 {
+		return nil, xerrors.Errorf("must pass sector numbers or a sector file")
+	}
+
+	return sectors, nil
+}
+
 var sectorsRenewCmd = &cli.Command{
 	Name:  "renew",
 	Usage: "Renew expiring sectors while not exceeding each sector's max life",
@@ -853,38 +922,11 @@ var sectorsRenewCmd = &cli.Command{
 			activeSectorsInfo[info.SectorNumber] = info
 		}
 
-		mact, err := fullApi.StateGetActor(ctx, maddr, types.EmptyTSK)
+		activeSectorsLocation, err := activeSectorLocations(ctx, fullApi, maddr)
 		if err != nil {
 			return err
 		}
 
-		tbs := blockstore.NewTieredBstore(blockstore.NewAPIBlockstore(fullApi), blockstore.NewMemory())
-		mas, err := lminer.Load(adt.WrapStore(ctx, cbor.NewCborStore(tbs)), mact)
-		if err != nil {
-			return err
-		}
-
-		activeSectorsLocation := make(map[abi.SectorNumber]*lminer.SectorLocation, len(activeSet))
-
-		if err := mas.ForEachDeadline(func(dlIdx uint64, dl lminer.Deadline) error {
-			return dl.ForEachPartition(func(partIdx uint64, part lminer.Partition) error {
-				pas, err := part.ActiveSectors()
-				if err != nil {
-					return err
-				}
-
-				return pas.ForEach(func(i uint64) error {
-					activeSectorsLocation[abi.SectorNumber(i)] = &lminer.SectorLocation{
-						Deadline:  dlIdx,
-						Partition: partIdx,
-					}
-					return nil
-				})
-			})
-		}); err != nil {
-			return err
-		}
-
 		excludeSet := make(map[uint64]struct{})
 
 		if cctx.IsSet("exclude") {
@@ -1357,6 +1399,9 @@ var sectorsTerminateCmd = &cli.Command{
 	Subcommands: []*cli.Command{
 		sectorsTerminateFlushCmd,
 		sectorsTerminatePendingCmd,
+		sectorsTerminateEstimateCmd,
+		sectorsTerminateBatchCmd,
+		sectorsTerminateStatusCmd,
 	},
 	Action: func(cctx *cli.Context) error {
 		if !cctx.Bool("really-do-it") {
@@ -1566,6 +1611,99 @@ var sectorsSnapAbortCmd = &cli.Command{
 	},
 }
 
+var sectorsSnapCmd = &cli.Command{
+	Name:  "snap",
+	Usage: "manage the SnapDeals (CC sector upgrade) pipeline",
+	Subcommands: []*cli.Command{
+		sectorsSnapListCmd,
+		sectorsSnapAbortSubCmd,
+		sectorsSnapRetryCmd,
+	},
+}
+
+var sectorsSnapListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "list the sectors in the SnapDeals pipeline",
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		list, err := nodeApi.SectorsSnapList(ctx)
+		if err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			fmt.Println("No sectors in the SnapDeals pipeline")
+			return nil
+		}
+
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].SectorID < list[j].SectorID
+		})
+
+		tw := tablewriter.New(
+			tablewriter.Col("ID"),
+			tablewriter.Col("State"),
+			tablewriter.Col("Deals"),
+			tablewriter.Col("Updated"),
+			tablewriter.NewLineCol("Error"))
+
+		for _, s := range list {
+			m := map[string]interface{}{
+				"ID":    s.SectorID,
+				"State": color.New(stateOrder[sealing.SectorState(s.State)].col).Sprint(s.State),
+				"Deals": len(s.Deals),
+			}
+			if !s.Updated.IsZero() {
+				m["Updated"] = time.Since(s.Updated).Truncate(time.Second).String() + " ago"
+			}
+			if s.LastErr != "" {
+				m["Error"] = s.LastErr
+			}
+			tw.Write(m)
+		}
+
+		return tw.Flush(os.Stdout)
+	},
+}
+
+var sectorsSnapAbortSubCmd = &cli.Command{
+	Name:      "abort",
+	Usage:     sectorsSnapAbortCmd.Usage,
+	ArgsUsage: sectorsSnapAbortCmd.ArgsUsage,
+	Flags:     sectorsSnapAbortCmd.Flags,
+	Action:    sectorsSnapAbortCmd.Action,
+}
+
+var sectorsSnapRetryCmd = &cli.Command{
+	Name:      "retry",
+	Usage:     "Retry the failed step of a sector in a failed SnapDeals state without waiting",
+	ArgsUsage: "<sectorNum>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return lcli.ShowHelp(cctx, xerrors.Errorf("must pass sector number"))
+		}
+
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		id, err := strconv.ParseUint(cctx.Args().Get(0), 10, 64)
+		if err != nil {
+			return xerrors.Errorf("could not parse sector number: %w", err)
+		}
+
+		return nodeApi.SectorSnapRetry(ctx, abi.SectorNumber(id))
+	},
+}
+
 var sectorsStartSealCmd = &cli.Command{
 	Name:      "seal",
 	Usage:     "Manually start sealing a sector (filling any unused space with junk)",
@@ -1942,9 +2080,94 @@ var sectorsBatching = &cli.Command{
 	Subcommands: []*cli.Command{
 		sectorsBatchingPendingCommit,
 		sectorsBatchingPendingPreCommit,
+		sectorsBatchingPlan,
+	},
+}
+
+var sectorsBatchingPlan = &cli.Command{
+	Name:  "plan",
+	Usage: "show how, and when, the pending sectors will be sent",
+	Description: `The batching planner decides whether the pending sectors are sent in batches, or
+in individual messages, from the base fee, the batching config and the cutoffs of
+the sectors. The plan can be overridden with the mode flags:
+  auto       - follow the batching config (default)
+  batch      - always batch the sectors, when the protocol allows it
+  individual - always send the sectors in individual messages
+
+Overrides aren't persisted, they are reset when the miner restarts.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "precommit-mode",
+			Usage: "override the PreCommit batching mode: auto, batch, individual",
+		},
+		&cli.StringFlag{
+			Name:  "commit-mode",
+			Usage: "override the Commit aggregation mode: auto, batch, individual",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		if cctx.IsSet("precommit-mode") {
+			if err := api.SectorPreCommitSetBatchMode(ctx, sealiface.BatchMode(cctx.String("precommit-mode"))); err != nil {
+				return xerrors.Errorf("setting precommit mode: %w", err)
+			}
+		}
+		if cctx.IsSet("commit-mode") {
+			if err := api.SectorCommitSetBatchMode(ctx, sealiface.BatchMode(cctx.String("commit-mode"))); err != nil {
+				return xerrors.Errorf("setting commit mode: %w", err)
+			}
+		}
+
+		pcPlan, err := api.SectorPreCommitBatchPlan(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting precommit plan: %w", err)
+		}
+		cPlan, err := api.SectorCommitBatchPlan(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting commit plan: %w", err)
+		}
+
+		printBatchPlan("PreCommit", pcPlan)
+		fmt.Println()
+		printBatchPlan("Commit", cPlan)
+		return nil
 	},
 }
 
+func printBatchPlan(name string, plan sealiface.BatchPlan) {
+	fmt.Printf("%s:\n", name)
+	fmt.Printf("\tMode:     %s\n", plan.Mode)
+	fmt.Printf("\tPending:  %d\n", plan.Pending)
+	if plan.Pending == 0 {
+		return
+	}
+
+	if plan.Individual {
+		fmt.Printf("\tPlan:     %d individual messages (%s)\n", plan.Pending, plan.Reason)
+	} else {
+		fmt.Printf("\tPlan:     batch of %d sectors (%s)\n", plan.BatchSize, plan.Reason)
+	}
+	fmt.Printf("\tSend:     %s\n", relTime(plan.Now, plan.SendAt))
+	if !plan.Cutoff.IsZero() {
+		fmt.Printf("\tCutoff:   %s\n", relTime(plan.Now, plan.Cutoff))
+	}
+	fmt.Printf("\tBase fee: %s\n", types.FIL(plan.BaseFee))
+	fmt.Printf("\tMax fee:  %s\n", types.FIL(plan.MaxFee))
+}
+
+func relTime(now, t time.Time) string {
+	if !t.After(now) {
+		return "now"
+	}
+	return fmt.Sprintf("in %s (%s)", t.Sub(now).Truncate(time.Second), t.Format(time.Stamp))
+}
+
 var sectorsBatchingPendingCommit = &cli.Command{
 	Name:  "commit",
 	Usage: "list sectors waiting in commit batch queue",
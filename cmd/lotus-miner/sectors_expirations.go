@@ -0,0 +1,506 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/go-state-types/builtin/v8/miner"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors"
+	lminer "github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/actors/policy"
+	"github.com/filecoin-project/lotus/chain/types"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/lib/tablewriter"
+)
+
+var sectorsExpirationsCmd = &cli.Command{
+	Name:  "expirations",
+	Usage: "Show the active sectors bucketed by expiration, with their power, pledge and deal weight",
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "bucket",
+			Usage: "size of the expiration buckets in epochs, defaults to 7 days",
+			Value: 20160,
+		},
+		&cli.Int64Flag{
+			Name:  "cutoff",
+			Usage: "skip sectors whose current expiration is more than <cutoff> epochs from now, 0 to show all the sectors",
+		},
+	},
+	Subcommands: []*cli.Command{
+		sectorsExpirationsPlanCmd,
+	},
+	Action: func(cctx *cli.Context) error {
+		bucket := abi.ChainEpoch(cctx.Int64("bucket"))
+		if bucket <= 0 {
+			return xerrors.Errorf("bucket must be positive")
+		}
+
+		fullApi, nCloser, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer nCloser()
+
+		ctx := lcli.ReqContext(cctx)
+
+		maddr, err := getActorAddress(ctx, cctx)
+		if err != nil {
+			return err
+		}
+
+		head, err := fullApi.ChainHead(ctx)
+		if err != nil {
+			return err
+		}
+		currEpoch := head.Height()
+
+		mi, err := fullApi.StateMinerInfo(ctx, maddr, head.Key())
+		if err != nil {
+			return err
+		}
+
+		sectors, err := fullApi.StateMinerActiveSectors(ctx, maddr, head.Key())
+		if err != nil {
+			return err
+		}
+
+		type expBucket struct {
+			start                                abi.ChainEpoch
+			sectors, cc                          int
+			qaPower, pledge, deals, verifiedDeal big.Int
+		}
+
+		buckets := map[abi.ChainEpoch]*expBucket{}
+		for _, s := range sectors {
+			if cctx.Int64("cutoff") > 0 && s.Expiration-currEpoch > abi.ChainEpoch(cctx.Int64("cutoff")) {
+				continue
+			}
+
+			start := s.Expiration / bucket * bucket
+			b, ok := buckets[start]
+			if !ok {
+				b = &expBucket{start: start, qaPower: big.Zero(), pledge: big.Zero(), deals: big.Zero(), verifiedDeal: big.Zero()}
+				buckets[start] = b
+			}
+
+			// the deal weights are spacetime, shown as the data they
+			// average to over the lifetime of the sector
+			duration := big.NewInt(int64(s.Expiration - s.Activation))
+
+			b.sectors++
+			if s.DealWeight.IsZero() && s.VerifiedDealWeight.IsZero() {
+				b.cc++
+			}
+			b.qaPower = big.Add(b.qaPower, miner.QAPowerForWeight(mi.SectorSize, s.Expiration-s.Activation, s.DealWeight, s.VerifiedDealWeight))
+			b.pledge = big.Add(b.pledge, s.InitialPledge)
+			b.deals = big.Add(b.deals, big.Div(s.DealWeight, duration))
+			b.verifiedDeal = big.Add(b.verifiedDeal, big.Div(s.VerifiedDealWeight, duration))
+		}
+
+		var sorted []*expBucket
+		for _, b := range buckets {
+			sorted = append(sorted, b)
+		}
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].start < sorted[j].start })
+
+		tw := tablewriter.New(
+			tablewriter.Col("Expiration"),
+			tablewriter.Col("Sectors"),
+			tablewriter.Col("CC"),
+			tablewriter.Col("RawPower"),
+			tablewriter.Col("QAPower"),
+			tablewriter.Col("Pledge"),
+			tablewriter.Col("DealWeight"),
+			tablewriter.Col("VerifiedDealWeight"))
+
+		total := &expBucket{qaPower: big.Zero(), pledge: big.Zero(), deals: big.Zero(), verifiedDeal: big.Zero()}
+		for _, b := range sorted {
+			tw.Write(map[string]interface{}{
+				"Expiration":         lcli.EpochTime(currEpoch, b.start),
+				"Sectors":            b.sectors,
+				"CC":                 b.cc,
+				"RawPower":           types.SizeStr(types.NewInt(uint64(mi.SectorSize) * uint64(b.sectors))),
+				"QAPower":            types.SizeStr(b.qaPower),
+				"Pledge":             types.FIL(b.pledge).Short(),
+				"DealWeight":         types.SizeStr(b.deals),
+				"VerifiedDealWeight": types.SizeStr(b.verifiedDeal),
+			})
+
+			total.sectors += b.sectors
+			total.cc += b.cc
+			total.qaPower = big.Add(total.qaPower, b.qaPower)
+			total.pledge = big.Add(total.pledge, b.pledge)
+			total.deals = big.Add(total.deals, b.deals)
+			total.verifiedDeal = big.Add(total.verifiedDeal, b.verifiedDeal)
+		}
+		tw.Write(map[string]interface{}{
+			"Expiration":         "Total",
+			"Sectors":            total.sectors,
+			"CC":                 total.cc,
+			"RawPower":           types.SizeStr(types.NewInt(uint64(mi.SectorSize) * uint64(total.sectors))),
+			"QAPower":            types.SizeStr(total.qaPower),
+			"Pledge":             types.FIL(total.pledge).Short(),
+			"DealWeight":         types.SizeStr(total.deals),
+			"VerifiedDealWeight": types.SizeStr(total.verifiedDeal),
+		})
+
+		return tw.Flush(os.Stdout)
+	},
+}
+
+var sectorsExpirationsPlanCmd = &cli.Command{
+	Name:  "plan",
+	Usage: "Plan the ExtendSectorExpiration messages extending the expiring sectors, with their estimated cost",
+	Description: `The sectors are grouped by partition and new expiration, and the groups are packed
+   into as few messages as the declaration and addressed sector limits allow,
+   keeping the partitions of a deadline together. Within the tolerance, the
+   sectors of a partition share the earliest of their new expirations.
+
+   The messages are only sent with --really-do-it.`,
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "from",
+			Usage: "only consider sectors whose current expiration epoch is in the range of [from, to], <from> defaults to: now + 120 (1 hour)",
+		},
+		&cli.Int64Flag{
+			Name:  "to",
+			Usage: "only consider sectors whose current expiration epoch is in the range of [from, to], <to> defaults to: now + 92160 (32 days)",
+		},
+		&cli.StringFlag{
+			Name:  "sector-file",
+			Usage: "provide a file containing one sector number in each line, ignoring above selecting criteria",
+		},
+		&cli.StringFlag{
+			Name:  "exclude",
+			Usage: "optionally provide a file containing excluding sectors",
+		},
+		&cli.BoolFlag{
+			Name:  "only-cc",
+			Usage: "only extend the sectors without deals",
+		},
+		&cli.Int64Flag{
+			Name:  "extension",
+			Usage: "try to extend selected sectors by this number of epochs, defaults to 540 days",
+			Value: 1555200,
+		},
+		&cli.Int64Flag{
+			Name:  "new-expiration",
+			Usage: "try to extend selected sectors to this epoch, ignoring extension",
+		},
+		&cli.Int64Flag{
+			Name:  "tolerance",
+			Usage: "don't try to extend sectors by fewer than this number of epochs, and let the sectors of a partition share expirations this close, defaults to 7 days",
+			Value: 20160,
+		},
+		&cli.StringFlag{
+			Name:  "max-fee",
+			Usage: "use up to this amount of FIL for one message",
+			Value: "0",
+		},
+		&cli.BoolFlag{
+			Name:  "really-do-it",
+			Usage: "send the planned messages",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		mf, err := types.ParseFIL(cctx.String("max-fee"))
+		if err != nil {
+			return err
+		}
+		spec := &api.MessageSendSpec{MaxFee: abi.TokenAmount(mf)}
+
+		fullApi, nCloser, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer nCloser()
+
+		ctx := lcli.ReqContext(cctx)
+
+		maddr, err := getActorAddress(ctx, cctx)
+		if err != nil {
+			return err
+		}
+
+		head, err := fullApi.ChainHead(ctx)
+		if err != nil {
+			return err
+		}
+		currEpoch := head.Height()
+
+		nv, err := fullApi.StateNetworkVersion(ctx, types.EmptyTSK)
+		if err != nil {
+			return err
+		}
+		addrSectors, err := policy.GetAddressedSectorsMax(nv)
+		if err != nil {
+			return err
+		}
+		declMax, err := policy.GetDeclarationsMax(nv)
+		if err != nil {
+			return err
+		}
+
+		mi, err := fullApi.StateMinerInfo(ctx, maddr, types.EmptyTSK)
+		if err != nil {
+			return xerrors.Errorf("getting miner info: %w", err)
+		}
+
+		activeSet, err := fullApi.StateMinerActiveSectors(ctx, maddr, types.EmptyTSK)
+		if err != nil {
+			return err
+		}
+
+		locations, err := activeSectorLocations(ctx, fullApi, maddr)
+		if err != nil {
+			return err
+		}
+
+		excludeSet := make(map[abi.SectorNumber]struct{})
+		if cctx.IsSet("exclude") {
+			excludeSectors, err := getSectorsFromFile(cctx.String("exclude"))
+			if err != nil {
+				return err
+			}
+			for _, id := range excludeSectors {
+				excludeSet[abi.SectorNumber(id)] = struct{}{}
+			}
+		}
+
+		var sis []*miner.SectorOnChainInfo
+		if cctx.IsSet("sector-file") {
+			active := make(map[abi.SectorNumber]*miner.SectorOnChainInfo, len(activeSet))
+			for _, si := range activeSet {
+				active[si.SectorNumber] = si
+			}
+
+			sectors, err := getSectorsFromFile(cctx.String("sector-file"))
+			if err != nil {
+				return err
+			}
+			for _, id := range sectors {
+				si, found := active[abi.SectorNumber(id)]
+				if !found {
+					return xerrors.Errorf("sector %d is not active", id)
+				}
+				sis = append(sis, si)
+			}
+		} else {
+			from := currEpoch + 120
+			to := currEpoch + 92160
+			if cctx.IsSet("from") {
+				from = abi.ChainEpoch(cctx.Int64("from"))
+			}
+			if cctx.IsSet("to") {
+				to = abi.ChainEpoch(cctx.Int64("to"))
+			}
+
+			for _, si := range activeSet {
+				if si.Expiration >= from && si.Expiration <= to {
+					sis = append(sis, si)
+				}
+			}
+		}
+
+		tolerance := abi.ChainEpoch(cctx.Int64("tolerance"))
+
+		type extension struct {
+			sector *miner.SectorOnChainInfo
+			newExp abi.ChainEpoch
+		}
+		byPartition := map[lminer.SectorLocation][]extension{}
+
+		var skippedDeals int
+		for _, si := range sis {
+			if _, exclude := excludeSet[si.SectorNumber]; exclude {
+				continue
+			}
+			if cctx.Bool("only-cc") && !(si.DealWeight.IsZero() && si.VerifiedDealWeight.IsZero()) {
+				skippedDeals++
+				continue
+			}
+
+			newExp := si.Expiration + abi.ChainEpoch(cctx.Int64("extension"))
+			if cctx.IsSet("new-expiration") {
+				newExp = abi.ChainEpoch(cctx.Int64("new-expiration"))
+			}
+			if maxExtendNow := currEpoch + policy.GetMaxSectorExpirationExtension(); newExp > maxExtendNow {
+				newExp = maxExtendNow
+			}
+			if maxExp := si.Activation + policy.GetSectorMaxLifetime(si.SealProof, nv); newExp > maxExp {
+				newExp = maxExp
+			}
+			if newExp-si.Expiration <= tolerance {
+				continue
+			}
+
+			l, found := locations[si.SectorNumber]
+			if !found {
+				return xerrors.Errorf("location for sector %d not found", si.SectorNumber)
+			}
+			byPartition[*l] = append(byPartition[*l], extension{sector: si, newExp: newExp})
+		}
+
+		// the sectors of a partition share the earliest new expiration of their
+		// group, which none of them exceeds the limits with
+		var decls []miner.ExpirationExtension
+		var extended []*miner.SectorOnChainInfo
+		for loc, exts := range byPartition {
+			sort.Slice(exts, func(i, j int) bool { return exts[i].newExp < exts[j].newExp })
+
+			for len(exts) > 0 {
+				exp := exts[0].newExp
+				var numbers []uint64
+				n := 0
+				for n < len(exts) && exts[n].newExp-exp <= tolerance && len(numbers) < addrSectors {
+					numbers = append(numbers, uint64(exts[n].sector.SectorNumber))
+					extended = append(extended, exts[n].sector)
+					n++
+				}
+				exts = exts[n:]
+
+				decls = append(decls, miner.ExpirationExtension{
+					Deadline:      loc.Deadline,
+					Partition:     loc.Partition,
+					Sectors:       bitfield.NewFromSet(numbers),
+					NewExpiration: exp,
+				})
+			}
+		}
+		if len(decls) == 0 {
+			fmt.Println("nothing to extend")
+			return nil
+		}
+
+		sort.Slice(decls, func(i, j int) bool {
+			if decls[i].Deadline != decls[j].Deadline {
+				return decls[i].Deadline < decls[j].Deadline
+			}
+			if decls[i].Partition != decls[j].Partition {
+				return decls[i].Partition < decls[j].Partition
+			}
+			return decls[i].NewExpiration < decls[j].NewExpiration
+		})
+
+		// pack the declarations in order, so that the messages load as few
+		// deadlines as possible
+		var params []miner.ExtendSectorExpirationParams
+		var p miner.ExtendSectorExpirationParams
+		scount := 0
+		for _, d := range decls {
+			c, err := d.Sectors.Count()
+			if err != nil {
+				return err
+			}
+			if len(p.Extensions) > 0 && (scount+int(c) > addrSectors || len(p.Extensions) == declMax) {
+				params = append(params, p)
+				p = miner.ExtendSectorExpirationParams{}
+				scount = 0
+			}
+			p.Extensions = append(p.Extensions, d)
+			scount += int(c)
+		}
+		params = append(params, p)
+
+		tw := tablewriter.New(
+			tablewriter.Col("Message"),
+			tablewriter.Col("Deadlines"),
+			tablewriter.Col("Declarations"),
+			tablewriter.Col("Sectors"),
+			tablewriter.Col("NewExpirations"),
+			tablewriter.Col("GasLimit"),
+			tablewriter.Col("EstimatedFee"))
+
+		msgs := make([]*types.Message, len(params))
+		totalFee := big.Zero()
+		for i := range params {
+			sp, aerr := actors.SerializeParams(&params[i])
+			if aerr != nil {
+				return xerrors.Errorf("serializing params: %w", aerr)
+			}
+
+			msg := &types.Message{
+				From:   mi.Worker,
+				To:     maddr,
+				Method: builtin.MethodsMiner.ExtendSectorExpiration,
+				Value:  big.Zero(),
+				Params: sp,
+			}
+
+			est, err := fullApi.GasEstimateMessageGas(ctx, msg, spec, types.EmptyTSK)
+			if err != nil {
+				return xerrors.Errorf("estimating gas of message %d: %w", i, err)
+			}
+			fee := big.Mul(est.GasFeeCap, big.NewInt(est.GasLimit))
+			totalFee = big.Add(totalFee, fee)
+			msgs[i] = msg
+
+			deadlines := map[uint64]struct{}{}
+			var sectors uint64
+			minExp, maxExp := params[i].Extensions[0].NewExpiration, params[i].Extensions[0].NewExpiration
+			for _, e := range params[i].Extensions {
+				deadlines[e.Deadline] = struct{}{}
+				c, err := e.Sectors.Count()
+				if err != nil {
+					return err
+				}
+				sectors += c
+				if e.NewExpiration < minExp {
+					minExp = e.NewExpiration
+				}
+				if e.NewExpiration > maxExp {
+					maxExp = e.NewExpiration
+				}
+			}
+
+			tw.Write(map[string]interface{}{
+				"Message":        i,
+				"Deadlines":      len(deadlines),
+				"Declarations":   len(params[i].Extensions),
+				"Sectors":        sectors,
+				"NewExpirations": fmt.Sprintf("%d-%d", minExp, maxExp),
+				"GasLimit":       est.GasLimit,
+				"EstimatedFee":   types.FIL(fee).Short(),
+			})
+		}
+		if err := tw.Flush(os.Stdout); err != nil {
+			return err
+		}
+
+		pledge := big.Zero()
+		for _, si := range extended {
+			pledge = big.Add(pledge, si.InitialPledge)
+		}
+		fmt.Printf("%d sectors (%s raw) in %d messages, estimated fee %s (at most), pledge kept locked %s\n", len(extended),
+			types.SizeStr(types.NewInt(uint64(mi.SectorSize)*uint64(len(extended)))), len(msgs), types.FIL(totalFee).Short(), types.FIL(pledge).Short())
+		if skippedDeals > 0 {
+			fmt.Printf("%d sectors with deals were skipped\n", skippedDeals)
+		}
+
+		if !cctx.Bool("really-do-it") {
+			fmt.Println("Pass --really-do-it to send the messages")
+			return nil
+		}
+
+		for i, msg := range msgs {
+			smsg, err := fullApi.MpoolPushMessage(ctx, msg, spec)
+			if err != nil {
+				return xerrors.Errorf("mpool push message %d: %w", i, err)
+			}
+			fmt.Println(smsg.Cid())
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,410 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/ipfs/go-cid"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/v0api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/lib/tablewriter"
+	sealing "github.com/filecoin-project/lotus/storage/pipeline"
+)
+
+var sectorsTerminateEstimateCmd = &cli.Command{
+	Name:      "estimate",
+	Usage:     "Compute the termination penalty of a set of sectors",
+	ArgsUsage: "<sectorNum> ...",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "sector-file",
+			Usage: "provide a file containing one sector number in each line, in addition to the arguments",
+		},
+		&cli.Int64SliceFlag{
+			Name:  "epoch",
+			Usage: "compute the penalty at these future epochs instead of the current one, can be repeated to compare them",
+		},
+		&cli.BoolFlag{
+			Name:  "verbose",
+			Usage: "show the penalty of each sector",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+
+		sectors, err := getTerminateSectors(cctx)
+		if err != nil {
+			return err
+		}
+
+		epochs := cctx.Int64Slice("epoch")
+		if len(epochs) == 0 {
+			epochs = []int64{0}
+		}
+
+		var ests []*api.TerminateEstimate
+		for _, epoch := range epochs {
+			est, err := nodeApi.SectorTerminateEstimate(ctx, sectors, abi.ChainEpoch(epoch))
+			if err != nil {
+				return xerrors.Errorf("estimating termination at epoch %d: %w", epoch, err)
+			}
+			ests = append(ests, est)
+		}
+
+		if len(ests) == 1 {
+			printTerminateEstimate(ests[0], cctx.Bool("verbose"))
+			return nil
+		}
+
+		// what-if comparison of the given epochs
+		tw := tablewriter.New(
+			tablewriter.Col("Epoch"),
+			tablewriter.Col("Time"),
+			tablewriter.Col("Sectors"),
+			tablewriter.Col("Expired"),
+			tablewriter.Col("QAPower"),
+			tablewriter.Col("Pledge"),
+			tablewriter.Col("Penalty"),
+		)
+		for _, est := range ests {
+			var expired int
+			for _, s := range est.Sectors {
+				if s.Expired {
+					expired++
+				}
+			}
+
+			tw.Write(map[string]interface{}{
+				"Epoch":   est.Epoch,
+				"Time":    lcli.EpochTime(est.Head, est.Epoch),
+				"Sectors": len(est.Sectors) - expired,
+				"Expired": expired,
+				"QAPower": types.SizeStr(est.QAPower),
+				"Pledge":  types.FIL(est.Pledge).Short(),
+				"Penalty": types.FIL(est.Penalty).Short(),
+			})
+		}
+		return tw.Flush(os.Stdout)
+	},
+}
+
+var sectorsTerminateBatchCmd = &cli.Command{
+	Name:      "batch",
+	Usage:     "Terminate a set of sectors through the termination batcher, and track them until they are terminated",
+	ArgsUsage: "<sectorNum> ...",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "sector-file",
+			Usage: "provide a file containing one sector number in each line, in addition to the arguments",
+		},
+		&cli.BoolFlag{
+			Name:  "flush",
+			Usage: "send the terminate messages right away instead of waiting for the batch to fill up",
+		},
+		&cli.BoolFlag{
+			Name:  "wait",
+			Usage: "wait for all sectors to be terminated, then print the message receipts",
+		},
+		&cli.BoolFlag{
+			Name:  "really-do-it",
+			Usage: "terminate the sectors, otherwise only the penalties are shown",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		fullApi, nCloser, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer nCloser()
+
+		ctx := lcli.ReqContext(cctx)
+
+		sectors, err := getTerminateSectors(cctx)
+		if err != nil {
+			return err
+		}
+
+		est, err := nodeApi.SectorTerminateEstimate(ctx, sectors, 0)
+		if err != nil {
+			return xerrors.Errorf("estimating termination: %w", err)
+		}
+		printTerminateEstimate(est, false)
+
+		var todo []abi.SectorNumber
+		for _, s := range est.Sectors {
+			if s.Expired {
+				fmt.Printf("skipping sector %d, it isn't live\n", s.Number)
+				continue
+			}
+			todo = append(todo, s.Number)
+		}
+		if len(todo) == 0 {
+			return xerrors.Errorf("no live sectors to terminate")
+		}
+
+		if !cctx.Bool("really-do-it") {
+			fmt.Printf("\nPass --really-do-it to terminate %d sectors\n", len(todo))
+			return nil
+		}
+
+		fmt.Println()
+		for i, s := range todo {
+			if err := nodeApi.SectorTerminate(ctx, s); err != nil {
+				return xerrors.Errorf("terminating sector %d (%d/%d queued): %w", s, i, len(todo), err)
+			}
+			fmt.Printf("\rqueued %d/%d sectors for termination", i+1, len(todo))
+		}
+		fmt.Println()
+
+		if cctx.Bool("flush") {
+			// the sectors are added to the batch by the sealing state machine,
+			// give it some time to process them
+			time.Sleep(time.Second)
+
+			mcid, err := nodeApi.SectorTerminateFlush(ctx)
+			if err != nil {
+				return xerrors.Errorf("flushing terminations: %w", err)
+			}
+			if mcid != nil {
+				fmt.Println("sent terminate message", mcid)
+			}
+		}
+
+		if !cctx.Bool("wait") {
+			fmt.Println("use 'lotus-miner sectors terminate status' to track the terminations")
+			return nil
+		}
+
+		for {
+			done, err := printTerminateStatus(ctx, nodeApi, fullApi, todo, false)
+			if err != nil {
+				return err
+			}
+			if done {
+				break
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(build.BlockDelaySecs) * time.Second):
+			}
+		}
+
+		_, err = printTerminateStatus(ctx, nodeApi, fullApi, todo, true)
+		return err
+	},
+}
+
+var sectorsTerminateStatusCmd = &cli.Command{
+	Name:      "status",
+	Usage:     "Show the termination progress of sectors, and the receipts of their terminate messages",
+	ArgsUsage: "<sectorNum> ...",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "sector-file",
+			Usage: "provide a file containing one sector number in each line, in addition to the arguments",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		fullApi, nCloser, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer nCloser()
+
+		ctx := lcli.ReqContext(cctx)
+
+		sectors, err := getTerminateSectors(cctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = printTerminateStatus(ctx, nodeApi, fullApi, sectors, true)
+		return err
+	},
+}
+
+func getTerminateSectors(cctx *cli.Context) ([]abi.SectorNumber, error) {
+	ns, err := getSectorsFromArgs(cctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sectors := make([]abi.SectorNumber, len(ns))
+	for i, n := range ns {
+		sectors[i] = abi.SectorNumber(n)
+	}
+	return sectors, nil
+}
+
+func printTerminateEstimate(est *api.TerminateEstimate, verbose bool) {
+	if verbose {
+		tw := tablewriter.New(
+			tablewriter.Col("Sector"),
+			tablewriter.Col("Deadline"),
+			tablewriter.Col("Partition"),
+			tablewriter.Col("Age"),
+			tablewriter.Col("Expiration"),
+			tablewriter.Col("QAPower"),
+			tablewriter.Col("Pledge"),
+			tablewriter.Col("Penalty"),
+			tablewriter.NewLineCol("Note"),
+		)
+		for _, s := range est.Sectors {
+			m := map[string]interface{}{
+				"Sector":     s.Number,
+				"Deadline":   s.Deadline,
+				"Partition":  s.Partition,
+				"Age":        time.Duration(s.Age) * time.Duration(build.BlockDelaySecs) * time.Second,
+				"Expiration": lcli.EpochTime(est.Head, s.Expiration),
+				"QAPower":    types.SizeStr(s.QAPower),
+				"Pledge":     types.FIL(s.Pledge).Short(),
+				"Penalty":    types.FIL(s.Penalty).Short(),
+			}
+			switch {
+			case s.Expired:
+				m["Deadline"], m["Partition"], m["Penalty"] = "-", "-", "-"
+				m["Note"] = color.YellowString("expired")
+			case s.Faulty:
+				m["Note"] = color.RedString("faulty")
+			}
+			tw.Write(m)
+		}
+		_ = tw.Flush(os.Stdout)
+		fmt.Println()
+	}
+
+	var expired int
+	for _, s := range est.Sectors {
+		if s.Expired {
+			expired++
+		}
+	}
+
+	fmt.Printf("Termination at epoch %s\n", lcli.EpochTime(est.Head, est.Epoch))
+	fmt.Printf("Sectors:           %d", len(est.Sectors)-expired)
+	if expired > 0 {
+		fmt.Printf(" (%d expired or not live)", expired)
+	}
+	fmt.Println()
+	fmt.Printf("Messages:          %d\n", est.Messages)
+	fmt.Printf("Power lost:        %s\n", types.SizeStr(est.QAPower))
+	fmt.Printf("Pledge released:   %s\n", types.FIL(est.Pledge))
+	fmt.Printf("Penalty:           %s\n", color.RedString(types.FIL(est.Penalty).String()))
+	fmt.Printf("Available balance: %s\n", types.FIL(est.AvailableBalance))
+
+	if big.Cmp(est.Penalty, big.Add(est.Pledge, est.AvailableBalance)) > 0 {
+		fmt.Println(color.YellowString("the penalty exceeds the released pledge and the available balance, the miner will accrue fee debt"))
+	}
+	if est.Epoch > est.Head {
+		fmt.Println("penalties at future epochs are estimated with the current reward and network power estimates")
+	}
+}
+
+// printTerminateStatus prints the termination state of the sectors, and the
+// receipts of their terminate messages if receipts is set. It returns true
+// when no sector is waiting to be terminated anymore
+func printTerminateStatus(ctx context.Context, nodeApi api.StorageMiner, fullApi v0api.FullNode, sectors []abi.SectorNumber, receipts bool) (bool, error) {
+	states := map[api.SectorState]int{}
+	msgs := map[cid.Cid][]abi.SectorNumber{}
+	done := true
+
+	for _, s := range sectors {
+		st, err := nodeApi.SectorsStatus(ctx, s, false)
+		if err != nil {
+			return false, xerrors.Errorf("getting sector %d status: %w", s, err)
+		}
+
+		states[st.State]++
+		if st.TerminateMessage != nil {
+			msgs[*st.TerminateMessage] = append(msgs[*st.TerminateMessage], s)
+		}
+
+		switch sealing.SectorState(st.State) {
+		case sealing.Terminating, sealing.TerminateWait, sealing.TerminateFinality:
+			done = false
+		}
+	}
+
+	var names []string
+	for st := range states {
+		names = append(names, string(st))
+	}
+	sort.Strings(names)
+	var counts []string
+	for _, st := range names {
+		counts = append(counts, fmt.Sprintf("%s: %d", st, states[api.SectorState(st)]))
+	}
+	fmt.Printf("%s %s\n", time.Now().Format(time.Stamp), strings.Join(counts, ", "))
+
+	if !receipts || len(msgs) == 0 {
+		return done, nil
+	}
+
+	tw := tablewriter.New(
+		tablewriter.Col("Message"),
+		tablewriter.Col("Sectors"),
+		tablewriter.Col("Epoch"),
+		tablewriter.Col("ExitCode"),
+		tablewriter.Col("GasUsed"),
+	)
+	for mcid, ss := range msgs {
+		m := map[string]interface{}{
+			"Message": mcid,
+			"Sectors": len(ss),
+			"Epoch":   "pending",
+		}
+
+		lookup, err := fullApi.StateSearchMsg(ctx, mcid)
+		if err != nil {
+			return false, xerrors.Errorf("searching message %s: %w", mcid, err)
+		}
+		if lookup != nil {
+			if lookup.Message != mcid {
+				m["Message"] = fmt.Sprintf("%s (replaced by %s)", mcid, lookup.Message)
+			}
+			m["Epoch"] = lookup.Height
+			m["ExitCode"] = lookup.Receipt.ExitCode
+			m["GasUsed"] = lookup.Receipt.GasUsed
+			if lookup.Receipt.ExitCode.IsError() {
+				m["ExitCode"] = color.RedString("%s", lookup.Receipt.ExitCode)
+			}
+		}
+		tw.Write(m)
+	}
+	fmt.Println()
+	return done, tw.Flush(os.Stdout)
+}
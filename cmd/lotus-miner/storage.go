@@ -50,6 +50,9 @@ stored while moving through the sealing pipeline (references as 'seal').`,
 		storageFindCmd,
 		storageCleanupCmd,
 		storageLocks,
+		storageUnsealQueueCmd,
+		storageHealthCmd,
+		storageRebalanceCmd,
 	},
 }
 
@@ -337,6 +340,9 @@ var storageListCmd = &cli.Command{
 			} else {
 				fmt.Print(color.HiYellowString("Use: ReadOnly"))
 			}
+			if si.Drain {
+				fmt.Print(color.RedString(" (draining)"))
+			}
 			fmt.Println()
 
 			if len(si.Groups) > 0 {
@@ -919,3 +925,352 @@ var storageLocks = &cli.Command{
 		return nil
 	},
 }
+
+var storageUnsealQueueCmd = &cli.Command{
+	Name:  "unseal-queue",
+	Usage: "show the unseal queue and the unsealed copies it created",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		q, err := api.StorageUnsealQueue(ctx)
+		if err != nil {
+			return err
+		}
+
+		limit := "unlimited"
+		if q.MaxPerPath > 0 {
+			limit = fmt.Sprint(q.MaxPerPath)
+		}
+		fmt.Printf("Max unseals per path: %s\n", limit)
+
+		printJobs := func(name string, jobs []storiface.UnsealJob) error {
+			fmt.Printf("\n%s: %d\n", name, len(jobs))
+			if len(jobs) == 0 {
+				return nil
+			}
+
+			tw := tablewriter.New(
+				tablewriter.Col("Sector"),
+				tablewriter.Col("Class"),
+				tablewriter.Col("Path"),
+				tablewriter.Col("Queued"),
+				tablewriter.Col("Running"),
+			)
+			for _, j := range jobs {
+				m := map[string]interface{}{
+					"Sector": j.Sector.Number,
+					"Class":  j.Class,
+					"Path":   j.Path,
+					"Queued": time.Since(j.Queued).Truncate(time.Second),
+				}
+				if !j.Started.IsZero() {
+					m["Running"] = time.Since(j.Started).Truncate(time.Second)
+				}
+				tw.Write(m)
+			}
+			return tw.Flush(os.Stdout)
+		}
+
+		if err := printJobs("Running", q.Running); err != nil {
+			return err
+		}
+		if err := printJobs("Waiting", q.Waiting); err != nil {
+			return err
+		}
+
+		cacheMax := "unlimited"
+		if q.CacheMaxBytes > 0 {
+			cacheMax = units.BytesSize(float64(q.CacheMaxBytes))
+		}
+		fmt.Printf("\nUnsealed copies: %d, %s / %s\n", len(q.Cached), units.BytesSize(float64(q.CacheBytes)), cacheMax)
+		if len(q.Cached) == 0 {
+			return nil
+		}
+
+		// least recently used first, the first to be removed
+		tw := tablewriter.New(
+			tablewriter.Col("Sector"),
+			tablewriter.Col("Size"),
+			tablewriter.Col("Last used"),
+		)
+		for _, c := range q.Cached {
+			tw.Write(map[string]interface{}{
+				"Sector":    c.Sector.Number,
+				"Size":      units.BytesSize(float64(c.Size)),
+				"Last used": time.Since(c.LastUsed).Truncate(time.Second),
+			})
+		}
+		return tw.Flush(os.Stdout)
+	},
+}
+
+var storageHealthCmd = &cli.Command{
+	Name:  "health",
+	Usage: "show the health of storage paths and the sectors at risk",
+	Description: `Storage paths are at risk when they fail heartbeats, or when sector reads
+from them fail or are slow. The sectors with sealed files in paths at risk are
+likely to fail WindowPoSt, and should be moved to healthy paths or declared
+faulty.`,
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		health, err := api.StorageHealth(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting storage health: %w", err)
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("Path"),
+			tablewriter.Col("Reads"),
+			tablewriter.Col("Errors"),
+			tablewriter.Col("Latency"),
+			tablewriter.Col("Status"),
+			tablewriter.NewLineCol("Reasons"),
+		)
+		for _, h := range health {
+			m := map[string]interface{}{
+				"Path":    h.ID,
+				"Reads":   h.IO.Reads,
+				"Errors":  h.IO.ReadErrors,
+				"Latency": h.IO.ReadLatency.Truncate(time.Millisecond),
+				"Status":  color.GreenString("ok"),
+			}
+			if len(h.AtRisk) > 0 {
+				m["Status"] = color.RedString("at risk")
+				m["Reasons"] = strings.Join(h.AtRisk, "; ")
+			}
+			tw.Write(m)
+		}
+		if err := tw.Flush(os.Stdout); err != nil {
+			return err
+		}
+
+		sectors, err := api.StorageAtRiskSectors(ctx)
+		if err != nil {
+			return xerrors.Errorf("getting sectors at risk: %w", err)
+		}
+		if len(sectors) == 0 {
+			return nil
+		}
+
+		fmt.Printf("\nSectors at risk: %d\n", len(sectors))
+
+		tw = tablewriter.New(
+			tablewriter.Col("Sector"),
+			tablewriter.Col("Type"),
+			tablewriter.Col("Path"),
+			tablewriter.Col("Healthy copies"),
+		)
+		for _, s := range sectors {
+			copies := color.RedString("%d", s.HealthyCopies)
+			if s.HealthyCopies > 0 {
+				copies = color.GreenString("%d", s.HealthyCopies)
+			}
+			tw.Write(map[string]interface{}{
+				"Sector":         s.Sector.Number,
+				"Type":           s.FileType.String(),
+				"Path":           s.Storage,
+				"Healthy copies": copies,
+			})
+		}
+		return tw.Flush(os.Stdout)
+	},
+}
+
+var storageRebalanceCmd = &cli.Command{
+	Name:  "rebalance",
+	Usage: "move sectors between local storage paths",
+	Description: `Rebalancing moves the sealed sectors between the local long-term storage paths,
+so that the share of the used space in each path follows its weight, and moves
+all the sectors out of the paths being drained. The bandwidth used by the moves
+can be limited with the Storage.RebalanceMaxBandwidth config option, and the
+rebalance can run in the background with Storage.RebalanceInterval.`,
+	Subcommands: []*cli.Command{
+		storageRebalancePlanCmd,
+		storageRebalanceStartCmd,
+		storageRebalanceStatusCmd,
+		storageRebalanceDrainCmd,
+	},
+}
+
+var storageRebalancePlanCmd = &cli.Command{
+	Name:  "plan",
+	Usage: "show the sector moves a rebalance would make",
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		moves, err := nodeApi.StorageRebalance(ctx, true)
+		if err != nil {
+			return err
+		}
+		if len(moves) == 0 {
+			fmt.Println("Storage paths are balanced")
+			return nil
+		}
+
+		return printRebalanceMoves(moves)
+	},
+}
+
+var storageRebalanceStartCmd = &cli.Command{
+	Name:  "start",
+	Usage: "start moving sectors between local storage paths",
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		moves, err := nodeApi.StorageRebalance(ctx, false)
+		if err != nil {
+			return err
+		}
+		if len(moves) == 0 {
+			fmt.Println("Storage paths are balanced")
+			return nil
+		}
+
+		var size int64
+		for _, mv := range moves {
+			size += mv.Size
+		}
+		fmt.Printf("Moving %d sectors (%s), see 'lotus-miner storage rebalance status'\n", len(moves), units.BytesSize(float64(size)))
+		return nil
+	},
+}
+
+var storageRebalanceStatusCmd = &cli.Command{
+	Name:  "status",
+	Usage: "show the progress of the current or last rebalance",
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		st, err := nodeApi.StorageRebalanceStatus(ctx)
+		if err != nil {
+			return err
+		}
+
+		bw := "unlimited"
+		if st.MaxBandwidth > 0 {
+			bw = units.BytesSize(float64(st.MaxBandwidth)) + "/s"
+		}
+		interval := "on demand"
+		if st.Interval > 0 {
+			interval = "every " + st.Interval.String()
+		}
+		fmt.Printf("Bandwidth: %s; Runs: %s\n", bw, interval)
+
+		if len(st.Moves) == 0 {
+			fmt.Println("No rebalance ran")
+			return nil
+		}
+
+		state := "finished"
+		if st.Running {
+			state = color.GreenString("running")
+		}
+		fmt.Printf("Rebalance started %s ago, %s\n\n", time.Since(st.Started).Truncate(time.Second), state)
+
+		return printRebalanceMoves(st.Moves)
+	},
+}
+
+var storageRebalanceDrainCmd = &cli.Command{
+	Name:      "drain",
+	Usage:     "mark a local storage path as being decommissioned",
+	ArgsUsage: "[storage path ID]",
+	Description: `No new sectors are stored in a draining path, and rebalancing moves all the
+sectors out of it. The flag is stored in the sectorstore.json file of the path.`,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "undo",
+			Usage: "stop draining the path",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return xerrors.Errorf("must pass storage path ID")
+		}
+
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		id := storiface.ID(cctx.Args().First())
+		drain := !cctx.Bool("undo")
+
+		if err := nodeApi.StorageSetDrain(ctx, id, drain); err != nil {
+			return xerrors.Errorf("setting drain flag of %s: %w", id, err)
+		}
+
+		if drain {
+			fmt.Printf("Draining %s, start a rebalance to move its sectors out\n", id)
+		} else {
+			fmt.Printf("Stopped draining %s\n", id)
+		}
+		return nil
+	},
+}
+
+func printRebalanceMoves(moves []storiface.RebalanceMove) error {
+	tw := tablewriter.New(
+		tablewriter.Col("Sector"),
+		tablewriter.Col("Types"),
+		tablewriter.Col("From"),
+		tablewriter.Col("To"),
+		tablewriter.Col("Size"),
+		tablewriter.Col("Progress"),
+		tablewriter.NewLineCol("Error"),
+	)
+
+	for _, mv := range moves {
+		m := map[string]interface{}{
+			"Sector": mv.Sector.Number,
+			"Types":  mv.FileType.String(),
+			"From":   mv.From,
+			"To":     mv.To,
+			"Size":   units.BytesSize(float64(mv.Size)),
+		}
+
+		switch {
+		case mv.Err != "":
+			m["Progress"] = color.RedString("failed")
+			m["Error"] = mv.Err
+		case !mv.Done.IsZero():
+			m["Progress"] = color.GreenString("done")
+		case !mv.Started.IsZero() && mv.Size > 0:
+			m["Progress"] = fmt.Sprintf("%d%%", mv.Copied*100/mv.Size)
+		case !mv.Started.IsZero():
+			m["Progress"] = "moving"
+		}
+
+		tw.Write(m)
+	}
+
+	return tw.Flush(os.Stdout)
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docker/go-units"
+	"github.com/ipfs/go-datastore"
+	dsq "github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+// knownNamespaces lists the namespaces written to the metadata datastore by
+// the subsystems of lotus nodes
+var knownNamespaces = map[string]string{
+	"/backupds":                   "backup log",
+	"/blockstore/scrub":           "blockstore scrubber",
+	"/chain/checks":               "chain checkpoint",
+	"/chain/checkpoint":           "checkpoint feed",
+	"/client":                     "client imports",
+	"/datatransfer":               "data transfers",
+	"/deals":                      "storage deals",
+	"/deals/provider/storage-ask": "storage ask",
+	"/fundmgr":                    "market funds manager",
+	"/index-provider":             "index provider",
+	"/ledgerkey":                  "ledger wallet keys",
+	"/marketfunds":                "legacy market funds, migrated on startup",
+	"/message-signer":             "message signer nonces",
+	"/miner-address":              "miner address",
+	"/mpool":                      "message pool",
+	"/paych":                      "payment channels",
+	"/retrievals":                 "retrieval deals",
+	"/sealedblocks":               "sector blocks",
+	"/sectors":                    "sealing pipeline",
+	"/slashfilter":                "slash filter",
+	"/splitstore":                 "splitstore",
+	"/stmgr/calls":                "sector manager calls",
+	"/storage/nextid":             "sector number counter",
+	"/storagemarket":              "piece store",
+	"/watchlist":                  "address watchlist",
+	"/worker/calls":               "worker calls",
+}
+
+// obsoleteNamespaces lists the namespaces of subsystems which were removed,
+// and which are safe to delete
+var obsoleteNamespaces = map[string]string{
+	"/sectorbuilder": "sector builder, replaced by the sealing pipeline",
+}
+
+// classifyKey returns whether the key belongs to a known or obsolete namespace,
+// or to neither, with the description of the namespace
+func classifyKey(k datastore.Key) (string, string) {
+	match := func(nss map[string]string) (string, bool) {
+		best := ""
+		for ns := range nss {
+			nk := datastore.NewKey(ns)
+			if (nk.Equal(k) || nk.IsAncestorOf(k)) && len(ns) > len(best) {
+				best = ns
+			}
+		}
+		return best, best != ""
+	}
+
+	if ns, ok := match(obsoleteNamespaces); ok {
+		return "obsolete", obsoleteNamespaces[ns]
+	}
+	if ns, ok := match(knownNamespaces); ok {
+		return "known", knownNamespaces[ns]
+	}
+	return "unknown", ""
+}
+
+type dsPrefixStats struct {
+	prefix string
+	class  string
+	desc   string
+	keys   int64
+	size   int64
+}
+
+var datastoreAnalyzeCmd = &cli.Command{
+	Name:  "analyze",
+	Usage: "report the size of the datastore by key prefix",
+	Description: `Scans a datastore of the repo, and reports the number of keys and the size of
+   the values by key prefix. Prefixes are classified as known when they belong to
+   a subsystem of lotus, obsolete when their subsystem was removed, or unknown.
+
+   With --delete-obsolete, the keys in obsolete namespaces are listed, and only
+   deleted with --really-do-it.`,
+	ArgsUsage: "[namespace]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "repo-type",
+			Usage: "node type (FullNode, StorageMiner, Worker, Wallet)",
+			Value: "FullNode",
+		},
+		&cli.IntFlag{
+			Name:  "depth",
+			Usage: "number of key components to group keys by",
+			Value: 2,
+		},
+		&cli.BoolFlag{
+			Name:  "delete-obsolete",
+			Usage: "delete the keys in obsolete namespaces",
+		},
+		&cli.BoolFlag{
+			Name:  "really-do-it",
+			Usage: "actually delete the keys, instead of only listing them",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		logging.SetLogLevel("badger", "ERROR") // nolint:errcheck
+
+		ns := "/metadata"
+		if cctx.Args().Present() {
+			ns = datastore.NewKey(cctx.Args().First()).String()
+		}
+		depth := cctx.Int("depth")
+		if depth < 1 {
+			return xerrors.Errorf("depth must be at least 1")
+		}
+
+		r, err := repo.NewFS(cctx.String("repo"))
+		if err != nil {
+			return xerrors.Errorf("opening fs repo: %w", err)
+		}
+
+		exists, err := r.Exists()
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return xerrors.Errorf("lotus repo doesn't exist")
+		}
+
+		lr, err := r.Lock(repo.NewRepoTypeFromString(cctx.String("repo-type")))
+		if err != nil {
+			return err
+		}
+		defer lr.Close() //nolint:errcheck
+
+		ctx := context.Background()
+		ds, err := lr.Datastore(ctx, ns)
+		if err != nil {
+			return err
+		}
+
+		q, err := ds.Query(ctx, dsq.Query{})
+		if err != nil {
+			return xerrors.Errorf("datastore query: %w", err)
+		}
+
+		stats := map[string]*dsPrefixStats{}
+		var obsolete []datastore.Key
+		var totalKeys, totalSize int64
+		for res := range q.Next() {
+			if res.Error != nil {
+				_ = q.Close()
+				return xerrors.Errorf("iterating datastore: %w", res.Error)
+			}
+
+			k := datastore.RawKey(res.Key)
+			parts := k.List()
+			if len(parts) > depth {
+				parts = parts[:depth]
+			}
+			prefix := "/" + strings.Join(parts, "/")
+
+			st, ok := stats[prefix]
+			if !ok {
+				// Keys of the prefix may belong to different namespaces,
+				// go by the first one
+				st = &dsPrefixStats{prefix: prefix}
+				st.class, st.desc = classifyKey(k)
+				stats[prefix] = st
+			}
+			st.keys++
+			st.size += int64(len(res.Value))
+			totalKeys++
+			totalSize += int64(len(res.Value))
+
+			if class, _ := classifyKey(k); class == "obsolete" {
+				obsolete = append(obsolete, k)
+			}
+		}
+		if err := q.Close(); err != nil {
+			return err
+		}
+
+		sorted := make([]*dsPrefixStats, 0, len(stats))
+		for _, st := range stats {
+			sorted = append(sorted, st)
+		}
+		sort.Slice(sorted, func(i, j int) bool {
+			if sorted[i].size != sorted[j].size {
+				return sorted[i].size > sorted[j].size
+			}
+			return sorted[i].prefix < sorted[j].prefix
+		})
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "Prefix\tKeys\tSize\tNamespace\n")
+		for _, st := range sorted {
+			desc := st.class
+			if st.desc != "" {
+				desc += ": " + st.desc
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", st.prefix, st.keys, units.BytesSize(float64(st.size)), desc)
+		}
+		fmt.Fprintf(w, "Total\t%d\t%s\t\n", totalKeys, units.BytesSize(float64(totalSize)))
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		if !cctx.Bool("delete-obsolete") {
+			if len(obsolete) > 0 {
+				fmt.Printf("\n%d keys in obsolete namespaces, delete them with --delete-obsolete\n", len(obsolete))
+			}
+			return nil
+		}
+
+		fmt.Println()
+		for _, k := range obsolete {
+			if !cctx.Bool("really-do-it") {
+				fmt.Printf("would delete %s\n", k)
+				continue
+			}
+			if err := ds.Delete(ctx, k); err != nil {
+				return xerrors.Errorf("deleting %s: %w", k, err)
+			}
+			fmt.Printf("deleted %s\n", k)
+		}
+		if !cctx.Bool("really-do-it") {
+			fmt.Printf("%d keys would be deleted, pass --really-do-it to delete them\n", len(obsolete))
+		}
+
+		return nil
+	},
+}
@@ -33,6 +33,7 @@ var datastoreCmd = &cli.Command{
 		datastoreGetCmd,
 		datastoreRewriteCmd,
 		datastoreVlog2CarCmd,
+		datastoreAnalyzeCmd,
 	},
 }
 
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	block "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/ipld/go-car"
+	carutil "github.com/ipld/go-car/util"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/urfave/cli/v2"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api/v0api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/state"
+	"github.com/filecoin-project/lotus/chain/types"
+	lcli "github.com/filecoin-project/lotus/cli"
+)
+
+var exportActorCmd = &cli.Command{
+	Name:      "export-actor",
+	Usage:     "Export the blocks needed to replay the state of an actor over a range of tipsets to a CAR",
+	ArgsUsage: "[actorAddress outputFile]",
+	Description: `For each tipset in the range, the CAR contains:
+   - the block headers of the tipset
+   - the nodes of the state tree on the path to the actor, and the whole state of
+     the actor, at the parent state of the tipset
+   - the messages to or from the actor executed in the tipset, with the receipts
+     of the tipset
+
+   The roots of the CAR are the blocks of the last tipset.`,
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:     "from",
+			Usage:    "first height to export",
+			Required: true,
+		},
+		&cli.Int64Flag{
+			Name:  "to",
+			Usage: "last height to export, defaults to the head",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 2 {
+			return lcli.ShowHelp(cctx, fmt.Errorf("must pass actor address and output file"))
+		}
+
+		api, closer, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		addr, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		head, err := api.ChainHead(ctx)
+		if err != nil {
+			return err
+		}
+		from, to := abi.ChainEpoch(cctx.Int64("from")), head.Height()
+		if cctx.IsSet("to") {
+			to = abi.ChainEpoch(cctx.Int64("to"))
+		}
+		if from > to {
+			return xerrors.Errorf("from height %d is after to height %d", from, to)
+		}
+
+		toTs, err := api.ChainGetTipSetByHeight(ctx, to, head.Key())
+		if err != nil {
+			return xerrors.Errorf("getting tipset at height %d: %w", to, err)
+		}
+
+		fi, err := os.Create(cctx.Args().Get(1))
+		if err != nil {
+			return xerrors.Errorf("opening the output file: %w", err)
+		}
+		defer fi.Close() //nolint:errcheck
+
+		if err := car.WriteHeader(&car.CarHeader{Roots: toTs.Cids(), Version: 1}, fi); err != nil {
+			return xerrors.Errorf("writing car header: %w", err)
+		}
+
+		e := &actorExporter{
+			api:    api,
+			bs:     blockstore.NewAPIBlockstore(api),
+			w:      fi,
+			seen:   cid.NewSet(),
+			walked: cid.NewSet(),
+			ids:    map[address.Address]address.Address{},
+		}
+
+		var last types.TipSetKey
+		var msgs int
+		for h := from; h <= to; h++ {
+			ts, err := api.ChainGetTipSetByHeight(ctx, h, toTs.Key())
+			if err != nil {
+				return xerrors.Errorf("getting tipset at height %d: %w", h, err)
+			}
+			// Null rounds resolve to the previous tipset
+			if ts.Key() == last {
+				continue
+			}
+			last = ts.Key()
+
+			n, err := e.exportTipSet(ctx, addr, ts)
+			if err != nil {
+				return xerrors.Errorf("exporting tipset at height %d: %w", ts.Height(), err)
+			}
+			msgs += n
+		}
+
+		fmt.Printf("exported %d blocks, with %d messages of %s\n", e.seen.Len(), msgs, addr)
+		return nil
+	},
+}
+
+type actorExporter struct {
+	api  v0api.FullNode
+	bs   blockstore.Blockstore
+	w    io.Writer
+	seen *cid.Set
+	// walked holds the roots of the DAGs already written
+	walked *cid.Set
+	// ids caches the ID addresses of the message senders and recipients
+	ids map[address.Address]address.Address
+}
+
+// exportTipSet writes the blocks of the tipset relevant to the actor, and
+// returns the number of messages of the actor executed in the tipset
+func (e *actorExporter) exportTipSet(ctx context.Context, addr address.Address, ts *types.TipSet) (int, error) {
+	for _, c := range ts.Cids() {
+		if err := e.write(ctx, c); err != nil {
+			return 0, err
+		}
+	}
+
+	// Record the nodes of the state tree read when looking up the actor
+	rec := &recordingBlockstore{bs: e.bs}
+	st, err := state.LoadStateTree(cbor.NewCborStore(rec), ts.ParentState())
+	if err != nil {
+		return 0, xerrors.Errorf("loading state tree: %w", err)
+	}
+	act, err := st.GetActor(addr)
+	switch {
+	case err == nil:
+	case xerrors.Is(err, types.ErrActorNotFound):
+		return 0, nil
+	default:
+		return 0, xerrors.Errorf("getting actor: %w", err)
+	}
+	for _, c := range rec.read {
+		if err := e.write(ctx, c); err != nil {
+			return 0, err
+		}
+	}
+	if err := e.writeDag(ctx, act.Head); err != nil {
+		return 0, xerrors.Errorf("writing actor state: %w", err)
+	}
+
+	if ts.Height() == 0 {
+		return 0, nil
+	}
+
+	id, err := st.LookupID(addr)
+	if err != nil {
+		return 0, err
+	}
+
+	// Messages of the parent tipset are executed in this one
+	pmsgs, err := e.api.ChainGetParentMessages(ctx, ts.Cids()[0])
+	if err != nil {
+		return 0, xerrors.Errorf("getting parent messages: %w", err)
+	}
+
+	var n int
+	for _, m := range pmsgs {
+		if !e.touches(ctx, m.Message, id, ts.Key()) {
+			continue
+		}
+		if err := e.write(ctx, m.Cid); err != nil {
+			return 0, err
+		}
+		n++
+	}
+	if n > 0 {
+		if err := e.writeDag(ctx, ts.Blocks()[0].ParentMessageReceipts); err != nil {
+			return 0, xerrors.Errorf("writing receipts: %w", err)
+		}
+	}
+
+	return n, nil
+}
+
+// touches returns whether the message is sent to or from the actor
+func (e *actorExporter) touches(ctx context.Context, m *types.Message, id address.Address, tsk types.TipSetKey) bool {
+	for _, a := range []address.Address{m.From, m.To} {
+		if a.Protocol() != address.ID {
+			resolved, ok := e.ids[a]
+			if !ok {
+				var err error
+				resolved, err = e.api.StateLookupID(ctx, a, tsk)
+				if err != nil {
+					// Not an actor yet, so not this one
+					resolved = address.Undef
+				}
+				e.ids[a] = resolved
+			}
+			a = resolved
+		}
+		if a == id {
+			return true
+		}
+	}
+	return false
+}
+
+// writeDag writes the block and all the blocks it links to
+func (e *actorExporter) writeDag(ctx context.Context, root cid.Cid) error {
+	if !e.walked.Visit(root) {
+		return nil
+	}
+	if err := e.write(ctx, root); err != nil {
+		return err
+	}
+	if root.Prefix().Codec != cid.DagCBOR || root.Prefix().MhType == mh.IDENTITY {
+		return nil
+	}
+
+	blk, err := e.bs.Get(ctx, root)
+	if err != nil {
+		return xerrors.Errorf("getting %s: %w", root, err)
+	}
+
+	var links []cid.Cid
+	if err := cbg.ScanForLinks(bytes.NewReader(blk.RawData()), func(c cid.Cid) {
+		links = append(links, c)
+	}); err != nil {
+		return xerrors.Errorf("scanning for links of %s: %w", root, err)
+	}
+	for _, c := range links {
+		if err := e.writeDag(ctx, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write writes the block to the CAR, unless it was already written, or isn't
+// stored as a block
+func (e *actorExporter) write(ctx context.Context, c cid.Cid) error {
+	switch {
+	case c.Prefix().Codec == cid.FilCommitmentSealed || c.Prefix().Codec == cid.FilCommitmentUnsealed:
+		return nil
+	case c.Prefix().MhType == mh.IDENTITY:
+		return nil
+	}
+	if !e.seen.Visit(c) {
+		return nil
+	}
+
+	blk, err := e.bs.Get(ctx, c)
+	if err != nil {
+		return xerrors.Errorf("getting %s: %w", c, err)
+	}
+	if err := carutil.LdWrite(e.w, c.Bytes(), blk.RawData()); err != nil {
+		return xerrors.Errorf("writing block to car: %w", err)
+	}
+	return nil
+}
+
+// recordingBlockstore records the blocks read through it
+type recordingBlockstore struct {
+	bs   blockstore.Blockstore
+	read []cid.Cid
+}
+
+func (r *recordingBlockstore) Get(ctx context.Context, c cid.Cid) (block.Block, error) {
+	blk, err := r.bs.Get(ctx, c)
+	if err == nil {
+		r.read = append(r.read, c)
+	}
+	return blk, err
+}
+
+func (r *recordingBlockstore) Put(ctx context.Context, blk block.Block) error {
+	return r.bs.Put(ctx, blk)
+}
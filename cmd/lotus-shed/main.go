@@ -47,6 +47,7 @@ func main() {
 		mpoolStatsCmd,
 		exportChainCmd,
 		exportCarCmd,
+		exportActorCmd,
 		consensusCmd,
 		storageStatsCmd,
 		syncCmd,
@@ -55,6 +56,8 @@ func main() {
 		ledgerCmd,
 		sectorsCmd,
 		msgCmd,
+		msgReplayCmd,
+		signMessagesCmd,
 		electionCmd,
 		rpcCmd,
 		cidCmd,
@@ -72,6 +75,7 @@ func main() {
 		terminationsCmd,
 		migrationsCmd,
 		diffCmd,
+		stateCmd,
 		itestdCmd,
 	}
 
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ipfs/go-cid"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	lcli "github.com/filecoin-project/lotus/cli"
+)
+
+var msgReplayCmd = &cli.Command{
+	Name:      "msg-replay",
+	Usage:     "Replay a message at a range of tipsets, comparing exit codes and gas used",
+	ArgsUsage: "[messageCid]",
+	Description: `Executes the message on the state at each tipset in the range, as if it was
+   included in that tipset, and compares the results with its execution on chain.
+   The message can be mutated before being replayed, to check whether it would
+   have succeeded with other gas, value or params. Exit codes which differ from
+   the execution on chain are marked with a *.
+
+   Heights default to the range from the inclusion of the message to the head.`,
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "from",
+			Usage: "first height to replay the message at",
+		},
+		&cli.Int64Flag{
+			Name:  "to",
+			Usage: "last height to replay the message at",
+		},
+		&cli.Int64Flag{
+			Name:  "step",
+			Usage: "number of epochs between replays",
+			Value: 1,
+		},
+		&cli.Int64Flag{
+			Name:  "gas-limit",
+			Usage: "replay with this gas limit",
+		},
+		&cli.StringFlag{
+			Name:  "value",
+			Usage: "replay with this value, in FIL",
+		},
+		&cli.StringFlag{
+			Name:  "params-hex",
+			Usage: "replay with these params",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return lcli.ShowHelp(cctx, fmt.Errorf("must pass the message CID"))
+		}
+
+		api, closer, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		mcid, err := cid.Parse(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("parsing message cid: %w", err)
+		}
+
+		msg, err := api.ChainGetMessage(ctx, mcid)
+		if err != nil {
+			return xerrors.Errorf("getting message: %w", err)
+		}
+
+		if cctx.IsSet("gas-limit") {
+			msg.GasLimit = cctx.Int64("gas-limit")
+		}
+		if cctx.IsSet("value") {
+			val, err := types.ParseFIL(cctx.String("value"))
+			if err != nil {
+				return xerrors.Errorf("parsing value: %w", err)
+			}
+			msg.Value = abi.TokenAmount(val)
+		}
+		if cctx.IsSet("params-hex") {
+			msg.Params, err = hex.DecodeString(cctx.String("params-hex"))
+			if err != nil {
+				return xerrors.Errorf("decoding params: %w", err)
+			}
+		}
+
+		head, err := api.ChainHead(ctx)
+		if err != nil {
+			return err
+		}
+
+		from, to := abi.ChainEpoch(cctx.Int64("from")), head.Height()
+		if cctx.IsSet("to") {
+			to = abi.ChainEpoch(cctx.Int64("to"))
+		}
+		step := abi.ChainEpoch(cctx.Int64("step"))
+		if step <= 0 {
+			return xerrors.Errorf("step must be positive")
+		}
+
+		// The message executes on the parent state of the tipset it was
+		// included in, which is where the results are compared from
+		lookup, err := api.StateSearchMsg(ctx, mcid)
+		if err != nil {
+			return xerrors.Errorf("searching message: %w", err)
+		}
+		if lookup != nil {
+			execTs, err := api.ChainGetTipSet(ctx, lookup.TipSet)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Executed on chain at height %d: exit code %d, gas used %d\n", execTs.Height(), lookup.Receipt.ExitCode, lookup.Receipt.GasUsed)
+			if !cctx.IsSet("from") {
+				inclTs, err := api.ChainGetTipSet(ctx, execTs.Parents())
+				if err != nil {
+					return err
+				}
+				from = inclTs.Height()
+			}
+		} else {
+			fmt.Println("Message not found on chain")
+			if !cctx.IsSet("from") {
+				return xerrors.Errorf("must pass --from for messages not found on chain")
+			}
+		}
+		if from > to {
+			return xerrors.Errorf("from height %d is after to height %d", from, to)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "Height\tExitCode\tGasUsed\tGasDiff\tError\n")
+
+		var last types.TipSetKey
+		for h := from; h <= to; h += step {
+			ts, err := api.ChainGetTipSetByHeight(ctx, h, head.Key())
+			if err != nil {
+				return xerrors.Errorf("getting tipset at height %d: %w", h, err)
+			}
+			// Null rounds resolve to the previous tipset
+			if ts.Key() == last {
+				continue
+			}
+			last = ts.Key()
+
+			m := *msg
+			res, err := api.StateCall(ctx, &m, ts.Key())
+			if err != nil {
+				fmt.Fprintf(w, "%d\t-\t-\t-\t%s\n", ts.Height(), err)
+				continue
+			}
+
+			gasDiff := "-"
+			marker := ""
+			if lookup != nil {
+				gasDiff = fmt.Sprintf("%+d", res.MsgRct.GasUsed-lookup.Receipt.GasUsed)
+				if res.MsgRct.ExitCode != lookup.Receipt.ExitCode {
+					marker = " *"
+				}
+			}
+			fmt.Fprintf(w, "%d\t%d%s\t%d\t%s\t%s\n", ts.Height(), res.MsgRct.ExitCode, marker, res.MsgRct.GasUsed, gasDiff, res.Error)
+		}
+
+		return w.Flush()
+	},
+}
@@ -0,0 +1,505 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	dsq "github.com/ipfs/go-datastore/query"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/lib/backupds"
+	"github.com/filecoin-project/lotus/node/repo"
+	"github.com/filecoin-project/lotus/storage/paths"
+	pipeline "github.com/filecoin-project/lotus/storage/pipeline"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+var importSectorsCmd = &cli.Command{
+	Name:  "import",
+	Usage: "Import sealed sectors and their metadata from another miner repo",
+	Description: `Imports the sectors of the miner from another miner repo, for example after
+   an ownership change or the acquisition of a storage provider, when the miner
+   actor is now run from a different repo.
+
+   The sector records are read from the metadata of the source repo, or from a
+   backup made with 'lotus-miner backup', and the sector files from the storage
+   paths of the source repo and the ones passed with --source-path. Only sectors
+   of the miner on chain whose sealed CID matches their record and whose sealed
+   file is found are imported; records missing from the source metadata are
+   rebuilt from chain state.
+
+   The source storage paths holding imported sectors are attached to the miner
+   repo, so they are indexed when the miner starts. With --copy-to, the sector
+   files are copied to that storage path of the miner repo instead.
+
+   Both miners must be stopped. Sectors which already have a record are kept, and
+   nothing is written without --really-do-it.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "source-repo",
+			Usage: "path to the miner repo to import the sectors from",
+		},
+		&cli.StringFlag{
+			Name:  "source-backup",
+			Usage: "path to a backup of the source miner metadata, used instead of the metadata of the source repo",
+		},
+		&cli.StringSliceFlag{
+			Name:  "source-path",
+			Usage: "storage path holding sector files of the source miner, can be repeated",
+		},
+		&cli.StringFlag{
+			Name:  "copy-to",
+			Usage: "copy the sector files to this storage path of the miner repo instead of attaching the source paths",
+		},
+		&cli.BoolFlag{
+			Name:  "really-do-it",
+			Usage: "write the sector records and the storage configuration",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := lcli.ReqContext(cctx)
+
+		if !cctx.IsSet("source-repo") && !cctx.IsSet("source-backup") {
+			return xerrors.Errorf("must pass --source-repo or --source-backup")
+		}
+
+		lr, err := openLockedRepo(cctx.String("miner-repo"))
+		if err != nil {
+			return err
+		}
+		defer lr.Close() //nolint:errcheck
+
+		mds, err := lr.Datastore(ctx, "/metadata")
+		if err != nil {
+			return err
+		}
+
+		maddr, err := repoMinerAddress(ctx, mds)
+		if err != nil {
+			return xerrors.Errorf("getting miner address: %w", err)
+		}
+		mid, err := address.IDFromAddress(maddr)
+		if err != nil {
+			return err
+		}
+
+		// Read the sector records, and the storage paths of the source miner
+		var srcAddr address.Address
+		records := map[abi.SectorNumber]*pipeline.SectorInfo{}
+		srcPaths := cctx.StringSlice("source-path")
+
+		if cctx.IsSet("source-repo") {
+			slr, err := openLockedRepo(cctx.String("source-repo"))
+			if err != nil {
+				return xerrors.Errorf("opening source repo, is the source miner running?: %w", err)
+			}
+			defer slr.Close() //nolint:errcheck
+
+			sc, err := slr.GetStorage()
+			if err != nil {
+				return xerrors.Errorf("getting source storage config: %w", err)
+			}
+			for _, p := range sc.StoragePaths {
+				srcPaths = append(srcPaths, p.Path)
+			}
+
+			if !cctx.IsSet("source-backup") {
+				smds, err := slr.Datastore(ctx, "/metadata")
+				if err != nil {
+					return err
+				}
+				if srcAddr, err = repoMinerAddress(ctx, smds); err != nil {
+					return xerrors.Errorf("getting source miner address: %w", err)
+				}
+				if records, err = readSectorRecords(ctx, smds); err != nil {
+					return err
+				}
+			}
+		}
+
+		if cctx.IsSet("source-backup") {
+			srcAddr, records, err = readBackupSectorRecords(cctx.String("source-backup"))
+			if err != nil {
+				return err
+			}
+		}
+
+		if srcAddr != address.Undef && srcAddr != maddr {
+			return xerrors.Errorf("the source miner is %s, sectors sealed for it can't be imported into %s", srcAddr, maddr)
+		}
+
+		// Find the sector files of the miner in the source paths
+		files := map[abi.SectorNumber]storiface.SectorFileType{}
+		fileLocs := map[abi.SectorNumber]map[storiface.SectorFileType]string{}
+		for _, p := range srcPaths {
+			found, err := findSectorFiles(p, abi.ActorID(mid))
+			if err != nil {
+				return err
+			}
+			for num, fts := range found {
+				files[num] |= fts
+				if fileLocs[num] == nil {
+					fileLocs[num] = map[storiface.SectorFileType]string{}
+				}
+				for _, ft := range storiface.PathTypes {
+					if fts.Has(ft) {
+						fileLocs[num][ft] = p
+					}
+				}
+			}
+		}
+
+		api, closer, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		head, err := api.ChainHead(ctx)
+		if err != nil {
+			return err
+		}
+
+		sectors, err := api.StateMinerSectors(ctx, maddr, nil, head.Key())
+		if err != nil {
+			return xerrors.Errorf("getting miner sectors: %w", err)
+		}
+		sort.Slice(sectors, func(i, j int) bool {
+			return sectors[i].SectorNumber < sectors[j].SectorNumber
+		})
+
+		sds := namespace.Wrap(mds, datastore.NewKey(pipeline.SectorStorePrefix))
+		write := cctx.Bool("really-do-it")
+
+		var imported []abi.SectorNumber
+		infos := map[abi.SectorNumber]*pipeline.SectorInfo{}
+		var existing, rebuilt, mismatched, missing int
+		onChain := map[abi.SectorNumber]struct{}{}
+		for _, si := range sectors {
+			onChain[si.SectorNumber] = struct{}{}
+
+			rec, hasRec := records[si.SectorNumber]
+			fts := files[si.SectorNumber]
+			if !hasRec && fts == 0 {
+				// not held by the source miner
+				continue
+			}
+
+			has, err := sds.Has(ctx, sectorKey(si.SectorNumber))
+			if err != nil {
+				return err
+			}
+			if has {
+				existing++
+				continue
+			}
+
+			if fts&(storiface.FTSealed|storiface.FTUpdate) == 0 {
+				missing++
+				fmt.Printf("sector %d: sealed file not found, skipping\n", si.SectorNumber)
+				continue
+			}
+
+			info := rec
+			if hasRec {
+				if err := reconcileSectorRecord(rec, si); err != nil {
+					mismatched++
+					fmt.Printf("sector %d: %s, skipping\n", si.SectorNumber, err)
+					continue
+				}
+			} else {
+				info, err = rebuildSectorInfo(ctx, api, head.Key(), si, fts)
+				if err != nil {
+					return xerrors.Errorf("rebuilding sector %d: %w", si.SectorNumber, err)
+				}
+				rebuilt++
+			}
+
+			imported = append(imported, si.SectorNumber)
+			infos[si.SectorNumber] = info
+			fmt.Printf("sector %d: importing %s\n", si.SectorNumber, strings.Join(fts.Strings(), ", "))
+		}
+
+		var notOnChain []abi.SectorNumber
+		for num := range records {
+			if _, ok := onChain[num]; !ok {
+				notOnChain = append(notOnChain, num)
+			}
+		}
+		sort.Slice(notOnChain, func(i, j int) bool { return notOnChain[i] < notOnChain[j] })
+		for _, num := range notOnChain {
+			fmt.Printf("sector %d: not on chain, not imported\n", num)
+		}
+
+		// Make the files available to the miner before it learns about the sectors
+		if len(imported) > 0 {
+			if cctx.IsSet("copy-to") {
+				if err := copySectorFiles(lr, cctx.String("copy-to"), abi.ActorID(mid), imported, fileLocs, write); err != nil {
+					return err
+				}
+			} else if err := attachSourcePaths(lr, imported, fileLocs, write); err != nil {
+				return err
+			}
+		}
+
+		if write {
+			for _, num := range imported {
+				var buf bytes.Buffer
+				if err := infos[num].MarshalCBOR(&buf); err != nil {
+					return err
+				}
+				if err := sds.Put(ctx, sectorKey(num), buf.Bytes()); err != nil {
+					return xerrors.Errorf("writing sector %d: %w", num, err)
+				}
+			}
+		}
+
+		if err := updateSectorCounter(ctx, api, mds, maddr, head.Key(), imported, write); err != nil {
+			return err
+		}
+
+		verb := "imported"
+		if !write {
+			verb = "to import"
+		}
+		fmt.Printf("%d sectors %s (%d rebuilt from chain state), %d already tracked, %d missing on disk, %d not matching chain state\n",
+			len(imported), verb, rebuilt, existing, missing, mismatched)
+		if !write && len(imported) > 0 {
+			fmt.Println("pass --really-do-it to import the sectors")
+		}
+		return nil
+	},
+}
+
+func sectorKey(num abi.SectorNumber) datastore.Key {
+	return datastore.NewKey(fmt.Sprint(uint64(num)))
+}
+
+// readSectorRecords reads the sealing pipeline records from a miner metadata
+// datastore
+func readSectorRecords(ctx context.Context, mds datastore.Datastore) (map[abi.SectorNumber]*pipeline.SectorInfo, error) {
+	res, err := mds.Query(ctx, dsq.Query{Prefix: pipeline.SectorStorePrefix})
+	if err != nil {
+		return nil, xerrors.Errorf("querying sector records: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	out := map[abi.SectorNumber]*pipeline.SectorInfo{}
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("reading sector records: %w", r.Error)
+		}
+
+		var info pipeline.SectorInfo
+		if err := info.UnmarshalCBOR(bytes.NewReader(r.Value)); err != nil {
+			return nil, xerrors.Errorf("decoding sector record %s: %w", r.Key, err)
+		}
+		out[info.SectorNumber] = &info
+	}
+	return out, nil
+}
+
+// readBackupSectorRecords reads the miner address and the sealing pipeline
+// records from a backup of a miner metadata datastore
+func readBackupSectorRecords(path string) (address.Address, map[abi.SectorNumber]*pipeline.SectorInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return address.Undef, nil, xerrors.Errorf("opening backup: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	maddr := address.Undef
+	out := map[abi.SectorNumber]*pipeline.SectorInfo{}
+	prefix := datastore.NewKey(pipeline.SectorStorePrefix)
+	_, err = backupds.ReadBackup(bufio.NewReader(f), func(key datastore.Key, value []byte, _ bool) error {
+		switch {
+		case key == datastore.NewKey("miner-address"):
+			a, err := address.NewFromBytes(value)
+			if err != nil {
+				return xerrors.Errorf("decoding miner address: %w", err)
+			}
+			maddr = a
+		case prefix.IsAncestorOf(key):
+			var info pipeline.SectorInfo
+			if err := info.UnmarshalCBOR(bytes.NewReader(value)); err != nil {
+				return xerrors.Errorf("decoding sector record %s: %w", key, err)
+			}
+			out[info.SectorNumber] = &info
+		}
+		return nil
+	})
+	if err != nil {
+		return address.Undef, nil, xerrors.Errorf("reading backup: %w", err)
+	}
+	return maddr, out, nil
+}
+
+// reconcileSectorRecord checks that a sector record matches the sector on
+// chain, and puts it in the state matching the sector on chain
+func reconcileSectorRecord(info *pipeline.SectorInfo, si *miner.SectorOnChainInfo) error {
+	sealed := info.CommR
+	if info.CCUpdate && info.UpdateSealed != nil {
+		sealed = info.UpdateSealed
+	}
+	if sealed == nil || *sealed != si.SealedCID {
+		return xerrors.Errorf("sealed CID doesn't match the one on chain (%s)", si.SealedCID)
+	}
+
+	from := info.State
+	switch info.State {
+	case pipeline.Proving, pipeline.Available:
+	default:
+		// the sector is committed on chain, whatever the source miner was doing
+		// with it can't be continued here
+		info.State = pipeline.Proving
+	}
+
+	info.Log = append(info.Log, pipeline.Log{
+		Timestamp: uint64(time.Now().Unix()),
+		Message:   fmt.Sprintf("imported from another miner repo in state %s", from),
+		Kind:      "event;shed.ImportSector",
+	})
+	return nil
+}
+
+// attachSourcePaths adds the source storage paths holding the imported sectors
+// to the storage configuration of the miner repo
+func attachSourcePaths(lr repo.LockedRepo, sectors []abi.SectorNumber, fileLocs map[abi.SectorNumber]map[storiface.SectorFileType]string, write bool) error {
+	sc, err := lr.GetStorage()
+	if err != nil {
+		return xerrors.Errorf("getting storage config: %w", err)
+	}
+
+	ids := map[storiface.ID]string{}
+	for _, p := range sc.StoragePaths {
+		meta, err := readStorageMeta(p.Path)
+		if err != nil {
+			return err
+		}
+		ids[meta.ID] = p.Path
+	}
+
+	needed := map[string]struct{}{}
+	for _, num := range sectors {
+		for _, p := range fileLocs[num] {
+			needed[p] = struct{}{}
+		}
+	}
+
+	var attach []string
+	for p := range needed {
+		meta, err := readStorageMeta(p)
+		if err != nil {
+			return err
+		}
+		if other, ok := ids[meta.ID]; ok {
+			if filepath.Clean(other) != filepath.Clean(p) {
+				return xerrors.Errorf("storage path %s has the same ID (%s) as %s, which is already attached", p, meta.ID, other)
+			}
+			continue
+		}
+		ids[meta.ID] = p
+		attach = append(attach, p)
+	}
+	sort.Strings(attach)
+
+	for _, p := range attach {
+		fmt.Printf("attaching storage path %s\n", p)
+	}
+	if !write || len(attach) == 0 {
+		return nil
+	}
+
+	return lr.SetStorage(func(sc *paths.StorageConfig) {
+		for _, p := range attach {
+			sc.StoragePaths = append(sc.StoragePaths, paths.LocalPath{Path: p})
+		}
+	})
+}
+
+func readStorageMeta(p string) (*paths.LocalStorageMeta, error) {
+	mb, err := os.ReadFile(filepath.Join(p, paths.MetaFile))
+	if err != nil {
+		return nil, xerrors.Errorf("reading storage metadata for %s: %w", p, err)
+	}
+
+	var meta paths.LocalStorageMeta
+	if err := json.Unmarshal(mb, &meta); err != nil {
+		return nil, xerrors.Errorf("unmarshalling storage metadata for %s: %w", p, err)
+	}
+	return &meta, nil
+}
+
+// copySectorFiles copies the files of the imported sectors to a storage path
+// of the miner repo
+func copySectorFiles(lr repo.LockedRepo, dest string, mid abi.ActorID, sectors []abi.SectorNumber, fileLocs map[abi.SectorNumber]map[storiface.SectorFileType]string, write bool) error {
+	sc, err := lr.GetStorage()
+	if err != nil {
+		return xerrors.Errorf("getting storage config: %w", err)
+	}
+
+	var found bool
+	for _, p := range sc.StoragePaths {
+		if filepath.Clean(p.Path) == filepath.Clean(dest) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return xerrors.Errorf("%s is not a storage path of the miner repo", dest)
+	}
+
+	for _, num := range sectors {
+		name := storiface.SectorName(abi.SectorID{Miner: mid, Number: num})
+		for ft, src := range fileLocs[num] {
+			from := filepath.Join(src, ft.String(), name)
+			to := filepath.Join(dest, ft.String(), name)
+			if _, err := os.Stat(to); err == nil {
+				return xerrors.Errorf("%s already exists", to)
+			}
+
+			fmt.Printf("copying %s to %s\n", from, to)
+			if !write {
+				continue
+			}
+			if err := copyPath(from, to); err != nil {
+				return xerrors.Errorf("copying sector %d %s: %w", num, ft, err)
+			}
+		}
+	}
+	return nil
+}
+
+func copyPath(from, to string) error {
+	toDir := filepath.Dir(to)
+	if err := os.MkdirAll(toDir, 0755); err != nil {
+		return err
+	}
+
+	// sector files are large, and cache directories have many files; `cp`
+	// handles both well
+	var errOut bytes.Buffer
+	cmd := exec.Command("/usr/bin/env", "cp", "-r", from, toDir) // nolint
+	cmd.Stderr = &errOut
+	if err := cmd.Run(); err != nil {
+		return xerrors.Errorf("exec cp (stderr: %s): %w", strings.TrimSpace(errOut.String()), err)
+	}
+	return nil
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	lapi "github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/v0api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/types"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/node/modules"
+	"github.com/filecoin-project/lotus/node/repo"
+	pipeline "github.com/filecoin-project/lotus/storage/pipeline"
+	"github.com/filecoin-project/lotus/storage/sealer/ffiwrapper"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+var rebuildSectorMetadataCmd = &cli.Command{
+	Name:  "rebuild-metadata",
+	Usage: "Rebuild the sector metadata of a miner from chain state and the sectors on disk",
+	Description: `Recreates the records of the sealing pipeline in the datastore of the miner
+   repo, for the sectors of the miner on chain whose sealed files are found in the
+   storage paths of the repo. Sectors are restored in the Proving state, with the
+   deals they contain. The sector number counter is moved past the sectors
+   allocated on chain.
+
+   The miner must be stopped. Sectors which already have a record are kept, and
+   nothing is written without --really-do-it.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "actor",
+			Usage: "miner address, defaults to the address of the miner repo",
+		},
+		&cli.BoolFlag{
+			Name:  "include-missing",
+			Usage: "also rebuild sectors whose files aren't found in the storage paths",
+		},
+		&cli.BoolFlag{
+			Name:  "really-do-it",
+			Usage: "write the rebuilt records to the datastore",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := lcli.ReqContext(cctx)
+
+		r, err := repo.NewFS(cctx.String("miner-repo"))
+		if err != nil {
+			return xerrors.Errorf("opening fs repo: %w", err)
+		}
+
+		exists, err := r.Exists()
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return xerrors.Errorf("miner repo doesn't exist")
+		}
+
+		lr, err := r.Lock(repo.StorageMiner)
+		if err != nil {
+			return xerrors.Errorf("locking miner repo, is the miner running?: %w", err)
+		}
+		defer lr.Close() //nolint:errcheck
+
+		mds, err := lr.Datastore(ctx, "/metadata")
+		if err != nil {
+			return err
+		}
+
+		var maddr address.Address
+		if cctx.IsSet("actor") {
+			maddr, err = address.NewFromString(cctx.String("actor"))
+		} else {
+			maddr, err = repoMinerAddress(ctx, mds)
+		}
+		if err != nil {
+			return xerrors.Errorf("getting miner address: %w", err)
+		}
+		mid, err := address.IDFromAddress(maddr)
+		if err != nil {
+			return err
+		}
+
+		local, err := findLocalSectors(lr, abi.ActorID(mid))
+		if err != nil {
+			return err
+		}
+
+		api, closer, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		head, err := api.ChainHead(ctx)
+		if err != nil {
+			return err
+		}
+
+		sectors, err := api.StateMinerSectors(ctx, maddr, nil, head.Key())
+		if err != nil {
+			return xerrors.Errorf("getting miner sectors: %w", err)
+		}
+		sort.Slice(sectors, func(i, j int) bool {
+			return sectors[i].SectorNumber < sectors[j].SectorNumber
+		})
+
+		sds := namespace.Wrap(mds, datastore.NewKey(pipeline.SectorStorePrefix))
+		write := cctx.Bool("really-do-it")
+
+		var rebuilt, existing, missing int
+		onChain := map[abi.SectorNumber]struct{}{}
+		for _, si := range sectors {
+			onChain[si.SectorNumber] = struct{}{}
+
+			key := datastore.NewKey(fmt.Sprint(uint64(si.SectorNumber)))
+			has, err := sds.Has(ctx, key)
+			if err != nil {
+				return err
+			}
+			if has {
+				existing++
+				continue
+			}
+
+			files := local[si.SectorNumber]
+			if files&(storiface.FTSealed|storiface.FTUpdate) == 0 && !cctx.Bool("include-missing") {
+				missing++
+				fmt.Printf("sector %d: sealed file not found, skipping\n", si.SectorNumber)
+				continue
+			}
+
+			info, err := rebuildSectorInfo(ctx, api, head.Key(), si, files)
+			if err != nil {
+				return xerrors.Errorf("rebuilding sector %d: %w", si.SectorNumber, err)
+			}
+
+			rebuilt++
+			if !write {
+				fmt.Printf("sector %d: would rebuild, %d deals\n", si.SectorNumber, len(si.DealIDs))
+				continue
+			}
+
+			var buf bytes.Buffer
+			if err := info.MarshalCBOR(&buf); err != nil {
+				return err
+			}
+			if err := sds.Put(ctx, key, buf.Bytes()); err != nil {
+				return xerrors.Errorf("writing sector %d: %w", si.SectorNumber, err)
+			}
+			fmt.Printf("sector %d: rebuilt, %d deals\n", si.SectorNumber, len(si.DealIDs))
+		}
+
+		for num := range local {
+			if _, ok := onChain[num]; !ok {
+				fmt.Printf("sector %d: found on disk but not active on chain, not restored\n", num)
+			}
+		}
+
+		// Make sure new sectors don't reuse allocated numbers
+		var used []abi.SectorNumber
+		for num := range local {
+			used = append(used, num)
+		}
+		if err := updateSectorCounter(ctx, api, mds, maddr, head.Key(), used, write); err != nil {
+			return err
+		}
+
+		fmt.Printf("%d sectors rebuilt, %d already tracked, %d missing on disk\n", rebuilt, existing, missing)
+		if !write && rebuilt > 0 {
+			fmt.Println("pass --really-do-it to write the rebuilt sectors")
+		}
+		return nil
+	},
+}
+
+// repoMinerAddress returns the address of the miner stored in the metadata
+// datastore of a miner repo
+func repoMinerAddress(ctx context.Context, mds datastore.Datastore) (address.Address, error) {
+	b, err := mds.Get(ctx, datastore.NewKey("miner-address"))
+	if err != nil {
+		return address.Undef, err
+	}
+	return address.NewFromBytes(b)
+}
+
+// updateSectorCounter moves the sector number counter of the miner repo past
+// the sectors allocated on chain and the given sectors, so that new sectors
+// don't reuse their numbers
+func updateSectorCounter(ctx context.Context, api v0api.FullNode, mds datastore.Datastore, maddr address.Address, tsk types.TipSetKey, sectors []abi.SectorNumber, write bool) error {
+	act, err := api.StateGetActor(ctx, maddr, tsk)
+	if err != nil {
+		return err
+	}
+	mas, err := miner.Load(adt.WrapStore(ctx, cbor.NewCborStore(blockstore.NewAPIBlockstore(api))), act)
+	if err != nil {
+		return err
+	}
+	allocated, err := mas.GetAllocatedSectors()
+	if err != nil {
+		return err
+	}
+	last, err := allocated.Last()
+	if err != nil && err != bitfield.ErrNoBitsSet {
+		return xerrors.Errorf("getting last allocated sector: %w", err)
+	}
+	used := err == nil
+	for _, num := range sectors {
+		if uint64(num) > last {
+			last = uint64(num)
+		}
+		used = true
+	}
+
+	// The counter holds the last number handed out
+	counterKey := datastore.NewKey(modules.StorageCounterDSPrefix)
+	var counter uint64
+	hasCounter := true
+	cb, err := mds.Get(ctx, counterKey)
+	switch err {
+	case nil:
+		counter, _ = binary.Uvarint(cb)
+	case datastore.ErrNotFound:
+		hasCounter = false
+	default:
+		return err
+	}
+	if used && (!hasCounter || counter < last) {
+		if write {
+			buf := make([]byte, binary.MaxVarintLen64)
+			size := binary.PutUvarint(buf, last)
+			if err := mds.Put(ctx, counterKey, buf[:size]); err != nil {
+				return xerrors.Errorf("updating sector number counter: %w", err)
+			}
+		}
+		fmt.Printf("sector number counter: %d -> %d\n", counter, last)
+	}
+	return nil
+}
+
+// findLocalSectors lists the file types found for each sector of the miner in
+// the storage paths of the repo
+func findLocalSectors(lr repo.LockedRepo, mid abi.ActorID) (map[abi.SectorNumber]storiface.SectorFileType, error) {
+	sc, err := lr.GetStorage()
+	if err != nil {
+		return nil, xerrors.Errorf("getting storage config: %w", err)
+	}
+
+	out := map[abi.SectorNumber]storiface.SectorFileType{}
+	for _, p := range sc.StoragePaths {
+		found, err := findSectorFiles(p.Path, mid)
+		if err != nil {
+			return nil, err
+		}
+		for num, ft := range found {
+			out[num] |= ft
+		}
+	}
+	return out, nil
+}
+
+// findSectorFiles lists the file types found for each sector of the miner in
+// a storage path
+func findSectorFiles(p string, mid abi.ActorID) (map[abi.SectorNumber]storiface.SectorFileType, error) {
+	out := map[abi.SectorNumber]storiface.SectorFileType{}
+	for _, ft := range storiface.PathTypes {
+		ents, err := os.ReadDir(filepath.Join(p, ft.String()))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, xerrors.Errorf("listing %s: %w", filepath.Join(p, ft.String()), err)
+		}
+
+		for _, ent := range ents {
+			sid, err := storiface.ParseSectorID(ent.Name())
+			if err != nil || sid.Miner != mid {
+				continue
+			}
+			out[sid.Number] |= ft
+		}
+	}
+	return out, nil
+}
+
+// rebuildSectorInfo returns the pipeline record of a proving sector, from its
+// info on chain
+func rebuildSectorInfo(ctx context.Context, fapi v0api.FullNode, tsk types.TipSetKey, si *miner.SectorOnChainInfo, files storiface.SectorFileType) (*pipeline.SectorInfo, error) {
+	info := &pipeline.SectorInfo{
+		State:        pipeline.Proving,
+		SectorNumber: si.SectorNumber,
+		SectorType:   si.SealProof,
+		CommR:        &si.SealedCID,
+		Log: []pipeline.Log{{
+			Timestamp: uint64(time.Now().Unix()),
+			Message:   "rebuilt from chain state by lotus-shed",
+			Kind:      "event;shed.RebuildMetadata",
+		}},
+	}
+
+	if si.SectorKeyCID != nil {
+		info.CCUpdate = true
+		info.CommR = si.SectorKeyCID
+		info.UpdateSealed = &si.SealedCID
+	}
+
+	// Deals which were already cleaned up from the market can't be restored,
+	// and neither can the unsealed CID without all the pieces
+	complete := true
+	for _, did := range si.DealIDs {
+		deal, err := fapi.StateMarketStorageDeal(ctx, did, tsk)
+		if err != nil {
+			fmt.Printf("sector %d: deal %d not found on chain: %s\n", si.SectorNumber, did, err)
+			complete = false
+			continue
+		}
+
+		prop := deal.Proposal
+		piece := pipeline.Piece{
+			Piece: abi.PieceInfo{
+				Size:     prop.PieceSize,
+				PieceCID: prop.PieceCID,
+			},
+			DealInfo: &lapi.PieceDealInfo{
+				DealID:       did,
+				DealProposal: &prop,
+				DealSchedule: lapi.DealSchedule{
+					StartEpoch: prop.StartEpoch,
+					EndEpoch:   prop.EndEpoch,
+				},
+				KeepUnsealed: files&storiface.FTUnsealed != 0,
+			},
+		}
+		if info.CCUpdate {
+			info.CCPieces = append(info.CCPieces, piece)
+		} else {
+			info.Pieces = append(info.Pieces, piece)
+		}
+	}
+
+	if complete {
+		pieces := info.Pieces
+		if info.CCUpdate {
+			pieces = info.CCPieces
+		}
+		pis := make([]abi.PieceInfo, len(pieces))
+		for i, p := range pieces {
+			pis[i] = p.Piece
+		}
+
+		commD, err := ffiwrapper.GenerateUnsealedCID(si.SealProof, pis)
+		if err != nil {
+			return nil, xerrors.Errorf("computing unsealed CID: %w", err)
+		}
+		if info.CCUpdate {
+			info.UpdateUnsealed = &commD
+		} else {
+			info.CommD = &commD
+		}
+	}
+
+	return info, nil
+}
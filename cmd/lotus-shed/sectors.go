@@ -42,6 +42,8 @@ var sectorsCmd = &cli.Command{
 		terminateSectorPenaltyEstimationCmd,
 		visAllocatedSectorsCmd,
 		dumpRLESectorCmd,
+		rebuildSectorMetadataCmd,
+		importSectorsCmd,
 	},
 }
 
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/wallet/key"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/lib/sigs"
+	_ "github.com/filecoin-project/lotus/lib/sigs/bls"
+	_ "github.com/filecoin-project/lotus/lib/sigs/secp"
+)
+
+var signMessagesCmd = &cli.Command{
+	Name:      "sign-messages",
+	Usage:     "Sign a directory of unsigned messages offline with an exported wallet key",
+	ArgsUsage: "[inputDir outputDir]",
+	Description: `Signs every message in the JSON files of the input directory with the key, and
+   writes each signed message as CBOR to the output directory, to be pushed with
+   'lotus mpool push-signed' once a node is available. No node is needed to sign.
+
+   The key file is the output of 'lotus wallet export'. Messages must be sent
+   from the address of the key, and have their gas fields set, as they can't be
+   estimated offline. With --nonce, nonces are assigned in the order of the file
+   names, starting from the given value.
+
+   Examples
+
+   lotus-shed sign-messages --key-file=worker.key --nonce=42 ./unsigned ./signed
+   lotus mpool push-signed ./signed/*.cbor`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "key-file",
+			Usage:    "file with the hex encoded key, as exported by lotus wallet export",
+			Required: true,
+		},
+		&cli.Uint64Flag{
+			Name:  "nonce",
+			Usage: "assign nonces to the messages starting from this value",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 2 {
+			return lcli.ShowHelp(cctx, fmt.Errorf("must pass input and output directories"))
+		}
+		inDir, outDir := cctx.Args().Get(0), cctx.Args().Get(1)
+
+		kb, err := os.ReadFile(cctx.String("key-file"))
+		if err != nil {
+			return xerrors.Errorf("reading key file: %w", err)
+		}
+		kb, err = hex.DecodeString(strings.TrimSpace(string(kb)))
+		if err != nil {
+			return xerrors.Errorf("decoding key file: %w", err)
+		}
+		var ki types.KeyInfo
+		if err := json.Unmarshal(kb, &ki); err != nil {
+			return xerrors.Errorf("decoding key file: %w", err)
+		}
+		k, err := key.NewKey(ki)
+		if err != nil {
+			return err
+		}
+
+		files, err := filepath.Glob(filepath.Join(inDir, "*.json"))
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return xerrors.Errorf("no message files found in %s", inDir)
+		}
+		sort.Strings(files)
+
+		if err := os.MkdirAll(outDir, 0755); err != nil {
+			return xerrors.Errorf("creating output directory: %w", err)
+		}
+
+		nonce := cctx.Uint64("nonce")
+		for _, f := range files {
+			data, err := os.ReadFile(f)
+			if err != nil {
+				return err
+			}
+			var msg types.Message
+			if err := json.Unmarshal(data, &msg); err != nil {
+				return xerrors.Errorf("decoding message in %s: %w", f, err)
+			}
+
+			if msg.From != k.Address {
+				return xerrors.Errorf("message in %s is sent from %s, not from the key address %s", f, msg.From, k.Address)
+			}
+			if cctx.IsSet("nonce") {
+				msg.Nonce = nonce
+				nonce++
+			}
+			if err := msg.ValidForBlockInclusion(0, build.NewestNetworkVersion); err != nil {
+				return xerrors.Errorf("message in %s is invalid: %w", f, err)
+			}
+
+			sig, err := sigs.Sign(key.ActSigType(k.Type), k.PrivateKey, msg.Cid().Bytes())
+			if err != nil {
+				return xerrors.Errorf("signing message in %s: %w", f, err)
+			}
+			sm := &types.SignedMessage{
+				Message:   msg,
+				Signature: *sig,
+			}
+
+			var buf bytes.Buffer
+			if err := sm.MarshalCBOR(&buf); err != nil {
+				return err
+			}
+			out := filepath.Join(outDir, strings.TrimSuffix(filepath.Base(f), ".json")+".cbor")
+			if err := os.WriteFile(out, buf.Bytes(), 0644); err != nil {
+				return xerrors.Errorf("writing signed message: %w", err)
+			}
+
+			fmt.Printf("%s: nonce %d, signed message %s\n", out, msg.Nonce, sm.Cid())
+		}
+
+		return nil
+	},
+}
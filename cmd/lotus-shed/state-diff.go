@@ -0,0 +1,342 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api/v0api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/market"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/types"
+	lcli "github.com/filecoin-project/lotus/cli"
+)
+
+var stateCmd = &cli.Command{
+	Name:        "state",
+	Usage:       "inspect the state tree",
+	Subcommands: []*cli.Command{stateDiffCmd},
+}
+
+type stateDiffTipSet struct {
+	TipSet    types.TipSetKey
+	Height    abi.ChainEpoch
+	StateRoot cid.Cid
+}
+
+type stateDiffValue struct {
+	From interface{}
+	To   interface{}
+}
+
+type stateDiffActor struct {
+	Address string
+	// Change is one of created, deleted or modified
+	Change string
+	// Fields maps the path of each changed field to its values, e.g.
+	// Balance, State.Info or Miner.LiveSectors
+	Fields map[string]stateDiffValue
+}
+
+type stateDiff struct {
+	From   stateDiffTipSet
+	To     stateDiffTipSet
+	Actors []stateDiffActor
+}
+
+var stateDiffCmd = &cli.Command{
+	Name:      "diff",
+	Usage:     "diff the actors of the states at two tipsets, as JSON",
+	ArgsUsage: "<tipset-a> <tipset-b>",
+	Description: `Tipsets are given as comma separated block CIDs, or @height. The states
+   compared are the parent states of the tipsets, as returned by the state APIs.`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "actor",
+			Usage: "only diff the given actor",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 2 {
+			return lcli.ShowHelp(cctx, fmt.Errorf("expected two tipsets"))
+		}
+
+		api, closer, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := lcli.ReqContext(cctx)
+
+		tsA, err := lcli.ParseTipSetRef(ctx, api, cctx.Args().Get(0))
+		if err != nil {
+			return xerrors.Errorf("parsing first tipset: %w", err)
+		}
+		tsB, err := lcli.ParseTipSetRef(ctx, api, cctx.Args().Get(1))
+		if err != nil {
+			return xerrors.Errorf("parsing second tipset: %w", err)
+		}
+		if tsA == nil || tsB == nil {
+			return xerrors.Errorf("both tipsets must be given")
+		}
+
+		// The actor may only exist in one of the states
+		var only string
+		if cctx.IsSet("actor") {
+			addr, err := address.NewFromString(cctx.String("actor"))
+			if err != nil {
+				return err
+			}
+			id, err := api.StateLookupID(ctx, addr, tsB.Key())
+			if err != nil {
+				id, err = api.StateLookupID(ctx, addr, tsA.Key())
+			}
+			if err != nil {
+				return xerrors.Errorf("looking up actor: %w", err)
+			}
+			only = id.String()
+		}
+
+		out := stateDiff{
+			From:   stateDiffTipSet{TipSet: tsA.Key(), Height: tsA.Height(), StateRoot: tsA.ParentState()},
+			To:     stateDiffTipSet{TipSet: tsB.Key(), Height: tsB.Height(), StateRoot: tsB.ParentState()},
+			Actors: []stateDiffActor{},
+		}
+
+		if tsA.ParentState() != tsB.ParentState() {
+			changedB, err := api.StateChangedActors(ctx, tsA.ParentState(), tsB.ParentState())
+			if err != nil {
+				return err
+			}
+			changedA, err := api.StateChangedActors(ctx, tsB.ParentState(), tsA.ParentState())
+			if err != nil {
+				return err
+			}
+
+			addrs := map[string]struct{}{}
+			for addr := range changedA {
+				addrs[addr] = struct{}{}
+			}
+			for addr := range changedB {
+				addrs[addr] = struct{}{}
+			}
+
+			store := adt.WrapStore(ctx, cbor.NewCborStore(blockstore.NewAPIBlockstore(api)))
+			for addr := range addrs {
+				if only != "" && addr != only {
+					continue
+				}
+
+				var actA, actB *types.Actor
+				if act, ok := changedA[addr]; ok {
+					actA = &act
+				}
+				if act, ok := changedB[addr]; ok {
+					actB = &act
+				}
+
+				d, err := diffActor(ctx, api, store, addr, tsA, actA, tsB, actB)
+				if err != nil {
+					return xerrors.Errorf("diffing actor %s: %w", addr, err)
+				}
+				out.Actors = append(out.Actors, *d)
+			}
+			sort.Slice(out.Actors, func(i, j int) bool {
+				return out.Actors[i].Address < out.Actors[j].Address
+			})
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	},
+}
+
+// diffActor compares an actor in the states at two tipsets, either actor being
+// nil when it doesn't exist in that state
+func diffActor(ctx context.Context, api v0api.FullNode, store adt.Store, addr string, tsA *types.TipSet, actA *types.Actor, tsB *types.TipSet, actB *types.Actor) (*stateDiffActor, error) {
+	d := &stateDiffActor{
+		Address: addr,
+		Change:  "modified",
+		Fields:  map[string]stateDiffValue{},
+	}
+	switch {
+	case actA == nil:
+		d.Change = "created"
+	case actB == nil:
+		d.Change = "deleted"
+	}
+
+	a, err := address.NewFromString(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	fieldsA, err := actorDiffFields(ctx, api, store, a, tsA, actA)
+	if err != nil {
+		return nil, err
+	}
+	fieldsB, err := actorDiffFields(ctx, api, store, a, tsB, actB)
+	if err != nil {
+		return nil, err
+	}
+
+	diffFields("", fieldsA, fieldsB, d.Fields)
+	return d, nil
+}
+
+// actorDiffFields returns the fields of the actor compared by the diff, as
+// decoded JSON
+func actorDiffFields(ctx context.Context, api v0api.FullNode, store adt.Store, addr address.Address, ts *types.TipSet, act *types.Actor) (interface{}, error) {
+	if act == nil {
+		return nil, nil
+	}
+
+	fields := map[string]interface{}{
+		"Code":    act.Code,
+		"Head":    act.Head,
+		"Nonce":   act.Nonce,
+		"Balance": act.Balance,
+	}
+
+	st, err := api.StateReadState(ctx, addr, ts.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("reading state: %w", err)
+	}
+	fields["State"] = st.State
+
+	switch {
+	case builtin.IsStorageMinerActor(act.Code):
+		mas, err := miner.Load(store, act)
+		if err != nil {
+			return nil, err
+		}
+		fields["Miner"], err = minerSectorCounts(mas)
+		if err != nil {
+			return nil, xerrors.Errorf("counting sectors: %w", err)
+		}
+	case addr == market.Address:
+		ms, err := market.Load(store, act)
+		if err != nil {
+			return nil, err
+		}
+		escrow, err := ms.EscrowTable()
+		if err != nil {
+			return nil, err
+		}
+		locked, err := ms.LockedTable()
+		if err != nil {
+			return nil, err
+		}
+		escrowMap, err := balanceTableMap(escrow)
+		if err != nil {
+			return nil, xerrors.Errorf("loading escrow table: %w", err)
+		}
+		lockedMap, err := balanceTableMap(locked)
+		if err != nil {
+			return nil, xerrors.Errorf("loading locked table: %w", err)
+		}
+		fields["Market"] = map[string]interface{}{
+			"Escrow": escrowMap,
+			"Locked": lockedMap,
+		}
+	}
+
+	// Round trip through JSON so that both sides compare as plain values
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func minerSectorCounts(mas miner.State) (map[string]uint64, error) {
+	counts := map[string]uint64{}
+	err := mas.ForEachDeadline(func(_ uint64, dl miner.Deadline) error {
+		return dl.ForEachPartition(func(_ uint64, part miner.Partition) error {
+			for name, get := range map[string]func() (bitfield.BitField, error){
+				"LiveSectors":       part.LiveSectors,
+				"ActiveSectors":     part.ActiveSectors,
+				"FaultySectors":     part.FaultySectors,
+				"RecoveringSectors": part.RecoveringSectors,
+			} {
+				bf, err := get()
+				if err != nil {
+					return err
+				}
+				n, err := bf.Count()
+				if err != nil {
+					return err
+				}
+				counts[name] += n
+			}
+			return nil
+		})
+	})
+	return counts, err
+}
+
+func balanceTableMap(bt market.BalanceTable) (map[string]abi.TokenAmount, error) {
+	out := map[string]abi.TokenAmount{}
+	err := bt.ForEach(func(addr address.Address, amt abi.TokenAmount) error {
+		out[addr.String()] = amt
+		return nil
+	})
+	return out, err
+}
+
+// diffFields records the values of the fields which differ between a and b,
+// recursing into objects
+func diffFields(path string, a, b interface{}, out map[string]stateDiffValue) {
+	ma, okA := a.(map[string]interface{})
+	mb, okB := b.(map[string]interface{})
+
+	// List the top level fields of created and deleted actors
+	if path == "" && a == nil {
+		ma, okA = map[string]interface{}{}, true
+	}
+	if path == "" && b == nil {
+		mb, okB = map[string]interface{}{}, true
+	}
+
+	if !okA || !okB {
+		if !reflect.DeepEqual(a, b) {
+			out[path] = stateDiffValue{From: a, To: b}
+		}
+		return
+	}
+
+	keys := map[string]struct{}{}
+	for k := range ma {
+		keys[k] = struct{}{}
+	}
+	for k := range mb {
+		keys[k] = struct{}{}
+	}
+	for k := range keys {
+		p := k
+		if path != "" {
+			p = path + "." + k
+		}
+		diffFields(p, ma[k], mb[k], out)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	lcli "github.com/filecoin-project/lotus/cli"
+	cliutil "github.com/filecoin-project/lotus/cli/util"
+	"github.com/filecoin-project/lotus/lib/apitls"
+	"github.com/filecoin-project/lotus/storage/sealer"
+	"github.com/filecoin-project/lotus/storage/sealer/fsutil"
+	"github.com/filecoin-project/lotus/storage/sealer/workerboot"
+)
+
+// bootstrapFile keeps the registration of a worker started with --bootstrap in
+// the worker repo, for the next runs
+const bootstrapFile = "bootstrap.json"
+
+const minerAPIInfoEnv = "MINER_API_INFO"
+
+type bootstrapState struct {
+	// APIInfo of the miner, with the API token of the worker
+	APIInfo  string
+	Template workerboot.Template
+}
+
+// workerBootstrap registers the worker when --bootstrap is set, or loads the
+// registration of a previous run, and applies the template of the worker to
+// the environment and the flags which weren't given on the command line
+func workerBootstrap(cctx *cli.Context) error {
+	repoPath, err := homedir.Expand(cctx.String(FlagWorkerRepo))
+	if err != nil {
+		return err
+	}
+	statePath := filepath.Join(repoPath, bootstrapFile)
+
+	var st bootstrapState
+	if cctx.IsSet("bootstrap") {
+		if err := os.MkdirAll(repoPath, 0755); err != nil { // nolint
+			return err
+		}
+		if st, err = register(cctx, repoPath); err != nil {
+			return err
+		}
+
+		b, err := json.MarshalIndent(&st, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(statePath, b, 0600); err != nil {
+			return xerrors.Errorf("persisting registration: %w", err)
+		}
+
+		if err := os.Setenv(minerAPIInfoEnv, st.APIInfo); err != nil {
+			return err
+		}
+	} else {
+		b, err := ioutil.ReadFile(statePath)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return xerrors.Errorf("reading registration: %w", err)
+		}
+		if err := json.Unmarshal(b, &st); err != nil {
+			return xerrors.Errorf("decoding registration (%s): %w", statePath, err)
+		}
+
+		// the environment overrides the registration
+		if _, ok := os.LookupEnv(minerAPIInfoEnv); !ok {
+			if err := os.Setenv(minerAPIInfoEnv, st.APIInfo); err != nil {
+				return err
+			}
+		}
+	}
+
+	return applyTemplate(cctx, st.Template)
+}
+
+func register(cctx *cli.Context, repoPath string) (bootstrapState, error) {
+	binfo := cliutil.ParseApiInfo(cctx.String("bootstrap"))
+	ma, err := multiaddr.NewMultiaddr(binfo.Addr)
+	if err != nil {
+		return bootstrapState{}, xerrors.Errorf("parsing miner address of the bootstrap token: %w", err)
+	}
+	_, addr, err := manet.DialArgs(ma)
+	if err != nil {
+		return bootstrapState{}, err
+	}
+	url := "http://" + addr
+	if apitls.IsTLSEndpoint(ma) {
+		url = "https://" + addr
+	}
+	if err := apitls.SetupClient(); err != nil {
+		return bootstrapState{}, err
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return bootstrapState{}, err
+	}
+	res, err := sealer.HostResources(cctx.Bool("no-swap"))
+	if err != nil {
+		return bootstrapState{}, xerrors.Errorf("probing resources: %w", err)
+	}
+	probe := workerboot.Probe{
+		Hostname:  hostname,
+		Resources: res,
+	}
+
+	if !cctx.Bool("no-local-storage") {
+		// the default storage path of the worker is its repo
+		fst, err := fsutil.Statfs(repoPath)
+		if err != nil {
+			return bootstrapState{}, xerrors.Errorf("probing storage: %w", err)
+		}
+		probe.Paths = append(probe.Paths, workerboot.ProbedPath{
+			Path:      repoPath,
+			Capacity:  fst.Capacity,
+			Available: fst.Available,
+		})
+	}
+
+	reg, err := workerboot.Register(lcli.ReqContext(cctx), url, string(binfo.Token), probe)
+	if err != nil {
+		return bootstrapState{}, err
+	}
+
+	log.Infow("worker registered", "miner", url, "template", reg.Template.Name)
+
+	return bootstrapState{
+		APIInfo:  reg.APIToken + ":" + binfo.Addr,
+		Template: reg.Template,
+	}, nil
+}
+
+func applyTemplate(cctx *cli.Context, t workerboot.Template) error {
+	for k, v := range t.Env {
+		if err := os.Setenv(k, v); err != nil {
+			return err
+		}
+	}
+
+	set := func(name, value string) error {
+		if cctx.IsSet(name) {
+			return nil
+		}
+		return cctx.Set(name, value)
+	}
+
+	tts, err := t.TaskTypes()
+	if err != nil {
+		return err
+	}
+	if len(tts) > 0 {
+		if err := set("no-default", "true"); err != nil {
+			return err
+		}
+		for _, tt := range tts {
+			if err := set(workerboot.TaskFlags[tt], "true"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if t.NoSwap {
+		if err := set("no-swap", "true"); err != nil {
+			return err
+		}
+	}
+	if t.ParallelFetchLimit > 0 {
+		if err := set("parallel-fetch-limit", strconv.Itoa(t.ParallelFetchLimit)); err != nil {
+			return err
+		}
+	}
+	if t.AllocationPolicy != "" {
+		if err := set("allocation-policy", t.AllocationPolicy); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -30,6 +31,7 @@ import (
 	lcli "github.com/filecoin-project/lotus/cli"
 	cliutil "github.com/filecoin-project/lotus/cli/util"
 	"github.com/filecoin-project/lotus/cmd/lotus-worker/sealworker"
+	"github.com/filecoin-project/lotus/lib/apitls"
 	"github.com/filecoin-project/lotus/lib/lotuslog"
 	"github.com/filecoin-project/lotus/lib/ulimit"
 	"github.com/filecoin-project/lotus/metrics"
@@ -128,10 +130,34 @@ var runCmd = &cli.Command{
 			Name:   "address",
 			Hidden: true,
 		},
+		&cli.StringFlag{
+			Name:    "tls-cert",
+			Usage:   "PEM certificate chain to serve the worker api with TLS; the certificate is reloaded when the file changes",
+			EnvVars: []string{"LOTUS_WORKER_TLS_CERT"},
+		},
+		&cli.StringFlag{
+			Name:    "tls-key",
+			Usage:   "PEM private key of the TLS certificate",
+			EnvVars: []string{"LOTUS_WORKER_TLS_KEY"},
+		},
+		&cli.StringFlag{
+			Name:    "tls-client-ca",
+			Usage:   "PEM file with the CAs issuing the client certificates; when set, clients must present one",
+			EnvVars: []string{"LOTUS_WORKER_TLS_CLIENT_CA"},
+		},
 		&cli.BoolFlag{
 			Name:  "no-local-storage",
 			Usage: "don't use storageminer repo for sector storage",
 		},
+		&cli.StringFlag{
+			Name:    "bootstrap",
+			Usage:   "register with the miner using a one-time token from 'lotus-miner sealing workers bootstrap-token', then apply the template given by the miner to the flags which aren't set; the registration is kept in the worker repo for the next runs",
+			EnvVars: []string{"LOTUS_WORKER_BOOTSTRAP"},
+		},
+		&cli.StringFlag{
+			Name:  "allocation-policy",
+			Usage: "place the files of a sector in long-term storage paths sharing a group ('same-group'), or sharing no group ('spread-groups')",
+		},
 		&cli.BoolFlag{
 			Name:  "no-swap",
 			Usage: "don't use swap",
@@ -226,6 +252,10 @@ var runCmd = &cli.Command{
 	Action: func(cctx *cli.Context) error {
 		log.Info("Starting lotus worker")
 
+		if err := workerBootstrap(cctx); err != nil {
+			return xerrors.Errorf("worker bootstrap: %w", err)
+		}
+
 		if !cctx.Bool("enable-gpu-proving") {
 			if err := os.Setenv("BELLMAN_NO_GPU", "true"); err != nil {
 				return xerrors.Errorf("could not set no-gpu env: %+v", err)
@@ -456,10 +486,31 @@ var runCmd = &cli.Command{
 			}
 		}
 
-		localStore, err := paths.NewLocal(ctx, lr, nodeApi, []string{"http://" + address + "/remote"})
+		tlsFiles := apitls.Files{
+			CertFile:     cctx.String("tls-cert"),
+			KeyFile:      cctx.String("tls-key"),
+			ClientCAFile: cctx.String("tls-client-ca"),
+		}
+
+		var tlsSrv *apitls.Server
+		scheme := "http"
+		if tlsFiles.Enabled() {
+			tlsSrv, err = apitls.NewServer(tlsFiles)
+			if err != nil {
+				return xerrors.Errorf("loading TLS config: %w", err)
+			}
+			scheme = "https"
+		} else if tlsFiles.ClientCAFile != "" {
+			return xerrors.Errorf("--tls-client-ca requires --tls-cert and --tls-key")
+		}
+
+		localStore, err := paths.NewLocal(ctx, lr, nodeApi, []string{scheme + "://" + address + "/remote"})
 		if err != nil {
 			return err
 		}
+		if err := localStore.SetAllocationPolicy(paths.AllocationPolicy(cctx.String("allocation-policy"))); err != nil {
+			return err
+		}
 
 		// Setup remote sector store
 		sminfo, err := lcli.GetAPIInfo(cctx, repo.StorageMiner)
@@ -519,6 +570,9 @@ var runCmd = &cli.Command{
 		if err != nil {
 			return err
 		}
+		if tlsSrv != nil {
+			nl = tls.NewListener(nl, tlsSrv.ServerConfig())
+		}
 
 		{
 			a, err := net.ResolveTCPAddr("tcp", address)
@@ -531,6 +585,10 @@ var runCmd = &cli.Command{
 				return xerrors.Errorf("creating api multiaddress: %w", err)
 			}
 
+			if tlsSrv != nil {
+				ma = apitls.Endpoint(ma)
+			}
+
 			if err := lr.SetAPIEndpoint(ma); err != nil {
 				return xerrors.Errorf("setting api endpoint: %w", err)
 			}
@@ -602,7 +660,7 @@ var runCmd = &cli.Command{
 
 					select {
 					case <-readyCh:
-						if err := nodeApi.WorkerConnect(ctx, "http://"+address+"/rpc/v0"); err != nil {
+						if err := nodeApi.WorkerConnect(ctx, scheme+"://"+address+"/rpc/v0"); err != nil {
 							log.Errorf("Registering worker failed: %+v", err)
 							cancel()
 							return
@@ -40,6 +40,7 @@ import (
 	lcli "github.com/filecoin-project/lotus/cli"
 	"github.com/filecoin-project/lotus/journal"
 	"github.com/filecoin-project/lotus/journal/fsjournal"
+	"github.com/filecoin-project/lotus/lib/apitls"
 	"github.com/filecoin-project/lotus/lib/peermgr"
 	"github.com/filecoin-project/lotus/lib/ulimit"
 	"github.com/filecoin-project/lotus/metrics"
@@ -113,7 +114,11 @@ var DaemonCmd = &cli.Command{
 		},
 		&cli.StringFlag{
 			Name:  "import-snapshot",
-			Usage: "import chain state from a given chain export file or url",
+			Usage: "import chain state from a given chain export file or url, or from the directory or manifest of a chunked export",
+		},
+		&cli.StringFlag{
+			Name:  "import-status-listen",
+			Usage: "serve the progress of the chain import as JSON on this address (e.g. 127.0.0.1:1235)",
 		},
 		&cli.BoolFlag{
 			Name:  "halt-after-import",
@@ -148,6 +153,10 @@ var DaemonCmd = &cli.Command{
 			Name:  "api-max-req-size",
 			Usage: "maximum API request size accepted by the JSON RPC server",
 		},
+		&cli.BoolFlag{
+			Name:  "api-read-only",
+			Usage: "reject the API methods changing the node state or using the wallet (eg. MpoolPush, WalletSign, MarketAddBalance), whatever the permissions of the API token",
+		},
 		&cli.PathFlag{
 			Name:  "restore",
 			Usage: "restore from backup file",
@@ -271,7 +280,7 @@ var DaemonCmd = &cli.Command{
 				issnapshot = true
 			}
 
-			if err := ImportChain(ctx, r, chainfile, issnapshot); err != nil {
+			if err := ImportChain(ctx, r, chainfile, issnapshot, cctx.String("import-status-listen")); err != nil {
 				return err
 			}
 			if cctx.Bool("halt-after-import") {
@@ -322,17 +331,21 @@ var DaemonCmd = &cli.Command{
 
 			node.Override(new(dtypes.Bootstrapper), isBootstrapper),
 			node.Override(new(dtypes.ShutdownChan), shutdownChan),
+			node.Override(new(dtypes.ReadOnlyAPI), dtypes.ReadOnlyAPI(cctx.Bool("api-read-only"))),
 
 			genesis,
 			liteModeDeps,
 
 			node.ApplyIf(func(s *node.Settings) bool { return cctx.IsSet("api") },
-				node.Override(node.SetApiEndpointKey, func(lr repo.LockedRepo) error {
+				node.Override(node.SetApiEndpointKey, func(lr repo.LockedRepo, tlsSrv *apitls.Server) error {
 					apima, err := multiaddr.NewMultiaddr("/ip4/127.0.0.1/tcp/" +
 						cctx.String("api"))
 					if err != nil {
 						return err
 					}
+					if tlsSrv != nil {
+						apima = apitls.Endpoint(apima)
+					}
 					return lr.SetAPIEndpoint(apima)
 				})),
 			node.ApplyIf(func(s *node.Settings) bool { return !cctx.Bool("bootstrap") },
@@ -372,7 +385,7 @@ var DaemonCmd = &cli.Command{
 		}
 
 		// Serve the RPC.
-		rpcStopper, err := node.ServeRPC(h, "lotus-daemon", endpoint)
+		rpcStopper, err := node.ServeRPC(h, "lotus-daemon", endpoint, node.APITLSConfig(api))
 		if err != nil {
 			return fmt.Errorf("failed to start json-rpc endpoint: %s", err)
 		}
@@ -426,9 +439,23 @@ func importKey(ctx context.Context, api api.FullNode, f string) error {
 	return nil
 }
 
-func ImportChain(ctx context.Context, r repo.Repo, fname string, snapshot bool) (err error) {
+func ImportChain(ctx context.Context, r repo.Repo, fname string, snapshot bool, statusAddr string) (err error) {
+	status := new(importStatus)
+	if statusAddr != "" {
+		stop, err := serveImportStatus(statusAddr, status)
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
+	defer func() {
+		status.finish(err)
+	}()
+
 	var rd io.Reader
 	var l int64
+	var manifest *lcli.ExportManifest
+	var manifestDir string
 	if strings.HasPrefix(fname, "http://") || strings.HasPrefix(fname, "https://") {
 		resp, err := http.Get(fname) //nolint:gosec
 		if err != nil {
@@ -448,19 +475,31 @@ func ImportChain(ctx context.Context, r repo.Repo, fname string, snapshot bool)
 			return err
 		}
 
-		fi, err := os.Open(fname)
+		manifestDir, err = chunkedSnapshotDir(fname)
 		if err != nil {
 			return err
 		}
-		defer fi.Close() //nolint:errcheck
 
-		st, err := os.Stat(fname)
-		if err != nil {
-			return err
-		}
+		if manifestDir != "" {
+			manifest, err = lcli.ReadExportManifest(manifestDir)
+			if err != nil {
+				return xerrors.Errorf("reading snapshot manifest: %w", err)
+			}
+		} else {
+			fi, err := os.Open(fname)
+			if err != nil {
+				return err
+			}
+			defer fi.Close() //nolint:errcheck
+
+			st, err := os.Stat(fname)
+			if err != nil {
+				return err
+			}
 
-		rd = fi
-		l = st.Size()
+			rd = fi
+			l = st.Size()
+		}
 	}
 
 	lr, err := r.Lock(repo.FullNode)
@@ -489,35 +528,63 @@ func ImportChain(ctx context.Context, r repo.Repo, fname string, snapshot bool)
 
 	log.Infof("importing chain from %s...", fname)
 
-	bufr := bufio.NewReaderSize(rd, 1<<20)
+	var ts *types.TipSet
+	if manifest != nil {
+		err = importSnapshotChunks(ctx, bs, mds, manifestDir, manifest, status)
+		if err != nil {
+			return xerrors.Errorf("importing chunked snapshot failed: %w", err)
+		}
 
-	bar := pb.New64(l)
-	br := bar.NewProxyReader(bufr)
-	bar.ShowTimeLeft = true
-	bar.ShowPercent = true
-	bar.ShowSpeed = true
-	bar.Units = pb.U_BYTES
+		ts, err = cst.LoadTipSet(ctx, manifest.Head)
+		if err != nil {
+			return xerrors.Errorf("failed to load root tipset from snapshot: %w", err)
+		}
+	} else {
+		status.start(l, 0)
 
-	bar.Start()
-	ts, err := cst.Import(ctx, br)
-	bar.Finish()
+		bufr := bufio.NewReaderSize(io.TeeReader(rd, status), 1<<20)
 
-	if err != nil {
-		return xerrors.Errorf("importing chain failed: %w", err)
+		bar := pb.New64(l)
+		br := bar.NewProxyReader(bufr)
+		bar.ShowTimeLeft = true
+		bar.ShowPercent = true
+		bar.ShowSpeed = true
+		bar.Units = pb.U_BYTES
+
+		bar.Start()
+		ts, err = cst.Import(ctx, br)
+		bar.Finish()
+
+		if err != nil {
+			return xerrors.Errorf("importing chain failed: %w", err)
+		}
 	}
 
 	if err := cst.FlushValidationCache(ctx); err != nil {
 		return xerrors.Errorf("flushing validation cache failed: %w", err)
 	}
 
-	gb, err := cst.GetTipsetByHeight(ctx, 0, ts, true)
+	base, err := snapshotBase(ctx, cst, bs, ts)
 	if err != nil {
 		return err
 	}
 
-	err = cst.SetGenesis(ctx, gb.Blocks()[0])
-	if err != nil {
-		return err
+	if base.Height() == 0 {
+		err = cst.SetGenesis(ctx, base.Blocks()[0])
+		if err != nil {
+			return err
+		}
+	} else {
+		// latest state snapshots don't include the chain history, the genesis
+		// is loaded from the network genesis when the node starts.
+		if !snapshot {
+			return xerrors.Errorf("imported chain only goes back to height %d, chain validation needs the chain back to genesis", base.Height())
+		}
+
+		log.Infof("imported a latest state snapshot with the chain down to height %d, enabling the splitstore with a discard coldstore", base.Height())
+		if err := setPrunedConfig(lr); err != nil {
+			return err
+		}
 	}
 
 	// TODO: We need to supply the actual beacon after v14
@@ -538,5 +605,11 @@ func ImportChain(ctx context.Context, r repo.Repo, fname string, snapshot bool)
 		return err
 	}
 
+	if manifest != nil {
+		if err := mds.Delete(ctx, snapshotImportProgressKey); err != nil {
+			return xerrors.Errorf("clearing snapshot import progress: %w", err)
+		}
+	}
+
 	return nil
 }
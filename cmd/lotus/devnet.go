@@ -0,0 +1,511 @@
+//go:build !nodaemon
+// +build !nodaemon
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/mitchellh/go-homedir"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/sha3"
+	"golang.org/x/xerrors"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/network"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/wallet/key"
+	"github.com/filecoin-project/lotus/lib/tablewriter"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+const (
+	devnetParamsFile  = "devnet.json"
+	devnetGenesisFile = "devnet.car"
+	devnetFullRepo    = "full"
+	devnetMinerRepo   = "miner"
+	devnetSnapshots   = "snapshots"
+)
+
+// devnetParams are the parameters the devnet was created with. They are kept
+// in the devnet directory, so that the devnet can be restarted, and its keys
+// derived again.
+type devnetParams struct {
+	Seed           string
+	Accounts       int
+	AccountBalance abi.TokenAmount
+	Sectors        int
+	SectorSize     abi.SectorSize
+	Miner          address.Address
+	NetworkVersion network.Version
+}
+
+func init() {
+	DevnetCmd = &cli.Command{
+		Name:  "devnet",
+		Usage: "Run a single process local network with fake proofs",
+		Description: `Start a full node and a miner in a single process, on a local network using
+fake proofs, so that sectors seal instantly. The devnet is created on the first
+run, with accounts derived from --seed; running it again with the same seed
+creates the same genesis and the same accounts.
+
+The full node and miner repos are kept in the devnet directory, point the lotus
+and lotus-miner commands at them to use the devnet, eg.:
+  lotus --repo ~/.lotus-devnet/full wallet list
+  lotus-miner --miner-repo ~/.lotus-devnet/miner info`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "devnet-repo",
+				EnvVars: []string{"LOTUS_DEVNET_PATH"},
+				Value:   "~/.lotus-devnet",
+				Usage:   "directory keeping the state of the devnet",
+			},
+			&cli.StringFlag{
+				Name:  "api",
+				Usage: "full node API port",
+				Value: "1234",
+			},
+			&cli.StringFlag{
+				Name:  "miner-api",
+				Usage: "miner API port",
+				Value: "2345",
+			},
+			&cli.DurationFlag{
+				Name:  "block-time",
+				Usage: "time between blocks, can be under a second",
+				Value: time.Second,
+			},
+			&cli.StringFlag{
+				Name:  "seed",
+				Usage: "seed the devnet keys are derived from, when creating the devnet",
+				Value: "lotus-devnet",
+			},
+			&cli.IntFlag{
+				Name:  "accounts",
+				Usage: "number of pre-funded accounts, when creating the devnet",
+				Value: 5,
+			},
+			&cli.StringFlag{
+				Name:  "account-balance",
+				Usage: "balance of each pre-funded account, when creating the devnet",
+				Value: "1000000",
+			},
+			&cli.IntFlag{
+				Name:  "sectors",
+				Usage: "number of sectors pre-sealed by the miner, when creating the devnet",
+				Value: 2,
+			},
+			&cli.StringFlag{
+				Name:  "sector-size",
+				Usage: "sector size of the miner, when creating the devnet",
+				Value: "2KiB",
+			},
+		},
+		Action: runDevnet,
+		Subcommands: []*cli.Command{
+			devnetAccountsCmd,
+			devnetResetCmd,
+			devnetSnapshotCmd,
+		},
+	}
+}
+
+var devnetAccountsCmd = &cli.Command{
+	Name:  "accounts",
+	Usage: "List the pre-funded accounts of the devnet, with their private keys",
+	Description: `The accounts use secp256k1 keys, so their private keys are also valid Ethereum
+keys, for the listed Ethereum addresses. The exported keys can be imported with
+'lotus wallet import'.`,
+	Action: func(cctx *cli.Context) error {
+		dir, err := devnetDir(cctx)
+		if err != nil {
+			return err
+		}
+
+		p, err := readDevnetParams(dir)
+		if err != nil {
+			return err
+		}
+		if p == nil {
+			return xerrors.Errorf("no devnet in %s, start it with 'lotus devnet' first", dir)
+		}
+
+		keys, err := p.accountKeys()
+		if err != nil {
+			return err
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("Address"),
+			tablewriter.Col("Eth Address"),
+			tablewriter.Col("Balance"),
+			tablewriter.Col("Private Key"),
+			tablewriter.NewLineCol("Export"),
+		)
+		for _, k := range keys {
+			kb, err := json.Marshal(k.KeyInfo)
+			if err != nil {
+				return err
+			}
+
+			tw.Write(map[string]interface{}{
+				"Address":     k.Address,
+				"Eth Address": ethAddress(k),
+				"Balance":     types.FIL(p.AccountBalance).Short(),
+				"Private Key": "0x" + hex.EncodeToString(k.PrivateKey),
+				"Export":      hex.EncodeToString(kb),
+			})
+		}
+		return tw.Flush(cctx.App.Writer)
+	},
+}
+
+var devnetResetCmd = &cli.Command{
+	Name:        "reset",
+	Usage:       "Remove the devnet state, the next run creates a new devnet",
+	Description: `The snapshots of the devnet are kept, and can be restored after the reset.`,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "really-do-it",
+			Usage: "actually remove the devnet state",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		dir, err := devnetDir(cctx)
+		if err != nil {
+			return err
+		}
+		if err := checkDevnetStopped(dir); err != nil {
+			return err
+		}
+
+		if !cctx.Bool("really-do-it") {
+			fmt.Fprintf(cctx.App.Writer, "Pass --really-do-it to remove the devnet state in %s\n", dir)
+			return nil
+		}
+
+		if err := removeDevnetState(dir); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cctx.App.Writer, "Removed the devnet state in %s\n", dir)
+		return nil
+	},
+}
+
+var devnetSnapshotCmd = &cli.Command{
+	Name:  "snapshot",
+	Usage: "Save and restore the state of a stopped devnet",
+	Subcommands: []*cli.Command{
+		devnetSnapshotSaveCmd,
+		devnetSnapshotRestoreCmd,
+		devnetSnapshotListCmd,
+	},
+}
+
+var devnetSnapshotSaveCmd = &cli.Command{
+	Name:      "save",
+	Usage:     "Save the state of the devnet in a named snapshot",
+	ArgsUsage: "<name>",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "overwrite",
+			Usage: "replace an existing snapshot with the same name",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return xerrors.Errorf("expected 1 argument, the snapshot name")
+		}
+
+		dir, err := devnetDir(cctx)
+		if err != nil {
+			return err
+		}
+		if err := checkDevnetStopped(dir); err != nil {
+			return err
+		}
+
+		p, err := readDevnetParams(dir)
+		if err != nil {
+			return err
+		}
+		if p == nil {
+			return xerrors.Errorf("no devnet in %s", dir)
+		}
+
+		snap := filepath.Join(dir, devnetSnapshots, cctx.Args().First())
+		if _, err := os.Stat(snap); err == nil {
+			if !cctx.Bool("overwrite") {
+				return xerrors.Errorf("snapshot %s already exists, pass --overwrite to replace it", cctx.Args().First())
+			}
+			if err := os.RemoveAll(snap); err != nil {
+				return xerrors.Errorf("removing snapshot: %w", err)
+			}
+		}
+
+		if err := os.MkdirAll(snap, 0755); err != nil {
+			return xerrors.Errorf("creating snapshot directory: %w", err)
+		}
+		if err := copyDevnetState(dir, snap); err != nil {
+			return xerrors.Errorf("saving snapshot: %w", err)
+		}
+
+		fmt.Fprintf(cctx.App.Writer, "Saved snapshot %s\n", cctx.Args().First())
+		return nil
+	},
+}
+
+var devnetSnapshotRestoreCmd = &cli.Command{
+	Name:      "restore",
+	Usage:     "Replace the state of the devnet with a snapshot",
+	ArgsUsage: "<name>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return xerrors.Errorf("expected 1 argument, the snapshot name")
+		}
+
+		dir, err := devnetDir(cctx)
+		if err != nil {
+			return err
+		}
+		if err := checkDevnetStopped(dir); err != nil {
+			return err
+		}
+
+		snap := filepath.Join(dir, devnetSnapshots, cctx.Args().First())
+		if _, err := os.Stat(filepath.Join(snap, devnetParamsFile)); err != nil {
+			return xerrors.Errorf("snapshot %s not found: %w", cctx.Args().First(), err)
+		}
+
+		if err := removeDevnetState(dir); err != nil {
+			return err
+		}
+		if err := copyDevnetState(snap, dir); err != nil {
+			return xerrors.Errorf("restoring snapshot: %w", err)
+		}
+
+		fmt.Fprintf(cctx.App.Writer, "Restored snapshot %s\n", cctx.Args().First())
+		return nil
+	},
+}
+
+var devnetSnapshotListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "List the snapshots of the devnet",
+	Action: func(cctx *cli.Context) error {
+		dir, err := devnetDir(cctx)
+		if err != nil {
+			return err
+		}
+
+		ents, err := ioutil.ReadDir(filepath.Join(dir, devnetSnapshots))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return xerrors.Errorf("reading snapshots: %w", err)
+		}
+		sort.Slice(ents, func(i, j int) bool {
+			return ents[i].ModTime().Before(ents[j].ModTime())
+		})
+
+		tw := tablewriter.New(tablewriter.Col("Name"), tablewriter.Col("Saved"))
+		for _, ent := range ents {
+			if !ent.IsDir() {
+				continue
+			}
+			tw.Write(map[string]interface{}{
+				"Name":  ent.Name(),
+				"Saved": ent.ModTime().Format("2006-01-02 15:04:05"),
+			})
+		}
+		return tw.Flush(cctx.App.Writer)
+	},
+}
+
+func devnetDir(cctx *cli.Context) (string, error) {
+	dir, err := homedir.Expand(cctx.String("devnet-repo"))
+	if err != nil {
+		return "", xerrors.Errorf("expanding devnet directory: %w", err)
+	}
+	return dir, nil
+}
+
+// readDevnetParams reads the parameters of the devnet in the directory, it
+// returns nil when the devnet wasn't created yet
+func readDevnetParams(dir string) (*devnetParams, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, devnetParamsFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, xerrors.Errorf("reading devnet parameters: %w", err)
+	}
+
+	var p devnetParams
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, xerrors.Errorf("decoding devnet parameters: %w", err)
+	}
+	return &p, nil
+}
+
+func writeDevnetParams(dir string, p *devnetParams) error {
+	b, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, devnetParamsFile), b, 0644)
+}
+
+// newDevnetParams returns the parameters of a new devnet, from the command
+// flags
+func newDevnetParams(cctx *cli.Context) (*devnetParams, error) {
+	bal, err := types.ParseFIL(cctx.String("account-balance"))
+	if err != nil {
+		return nil, xerrors.Errorf("parsing account balance: %w", err)
+	}
+
+	ssize, err := units.RAMInBytes(cctx.String("sector-size"))
+	if err != nil {
+		return nil, xerrors.Errorf("parsing sector size: %w", err)
+	}
+
+	if cctx.Int("accounts") < 1 {
+		return nil, xerrors.Errorf("the devnet needs at least one account")
+	}
+	if cctx.Int("sectors") < 1 {
+		return nil, xerrors.Errorf("the miner needs at least one sector to mine blocks")
+	}
+
+	maddr, err := address.NewIDAddress(1000)
+	if err != nil {
+		return nil, err
+	}
+
+	return &devnetParams{
+		Seed:           cctx.String("seed"),
+		Accounts:       cctx.Int("accounts"),
+		AccountBalance: abi.TokenAmount(bal),
+		Sectors:        cctx.Int("sectors"),
+		SectorSize:     abi.SectorSize(ssize),
+		Miner:          maddr,
+		NetworkVersion: build.NewestNetworkVersion,
+	}, nil
+}
+
+// devnetKey derives a key from the seed of the devnet, so that a devnet created
+// with the same seed has the same keys
+func (p *devnetParams) devnetKey(name string, typ types.KeyType) (*key.Key, error) {
+	ikm := sha256.Sum256([]byte(p.Seed + "/" + name))
+
+	var pk []byte
+	switch typ {
+	case types.KTSecp256k1:
+		pk = ikm[:]
+	case types.KTBLS:
+		sk := ffi.PrivateKeyGenerateWithSeed(ikm)
+		pk = sk[:]
+	default:
+		return nil, xerrors.Errorf("unsupported key type %s", typ)
+	}
+
+	return key.NewKey(types.KeyInfo{Type: typ, PrivateKey: pk})
+}
+
+func (p *devnetParams) accountKeys() ([]*key.Key, error) {
+	keys := make([]*key.Key, p.Accounts)
+	for i := range keys {
+		k, err := p.devnetKey(fmt.Sprintf("account/%d", i), types.KTSecp256k1)
+		if err != nil {
+			return nil, xerrors.Errorf("deriving account key %d: %w", i, err)
+		}
+		keys[i] = k
+	}
+	return keys, nil
+}
+
+// ownerKey is the owner and worker key of the miner; miner workers need BLS
+// keys
+func (p *devnetParams) ownerKey() (*key.Key, error) {
+	return p.devnetKey("miner/owner", types.KTBLS)
+}
+
+// ethAddress returns the Ethereum address of a secp256k1 key
+func ethAddress(k *key.Key) string {
+	h := sha3.NewLegacyKeccak256()
+	_, _ = h.Write(k.PublicKey[1:])
+	return "0x" + hex.EncodeToString(h.Sum(nil)[12:])
+}
+
+// checkDevnetStopped returns an error if the devnet is running
+func checkDevnetStopped(dir string) error {
+	for _, r := range []struct {
+		path string
+		typ  repo.RepoType
+	}{
+		{devnetFullRepo, repo.FullNode},
+		{devnetMinerRepo, repo.StorageMiner},
+	} {
+		fsr, err := repo.NewFS(filepath.Join(dir, r.path))
+		if err != nil {
+			return err
+		}
+		ok, err := fsr.Exists()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		lr, err := fsr.Lock(r.typ)
+		if err == repo.ErrRepoAlreadyLocked {
+			return xerrors.Errorf("the devnet is running, stop it first")
+		}
+		if err != nil {
+			return xerrors.Errorf("locking %s repo: %w", r.path, err)
+		}
+		if err := lr.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func removeDevnetState(dir string) error {
+	for _, p := range []string{devnetParamsFile, devnetGenesisFile, devnetFullRepo, devnetMinerRepo} {
+		if err := os.RemoveAll(filepath.Join(dir, p)); err != nil {
+			return xerrors.Errorf("removing %s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+func copyDevnetState(from, to string) error {
+	for _, p := range []string{devnetParamsFile, devnetGenesisFile, devnetFullRepo, devnetMinerRepo} {
+		src := filepath.Join(from, p)
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			continue
+		}
+
+		if out, err := exec.Command("cp", "-r", src, filepath.Join(to, p)).CombinedOutput(); err != nil {
+			return xerrors.Errorf("copying %s: %w: %s", p, err, string(out))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,488 @@
+//go:build !nodaemon
+// +build !nodaemon
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-storedcounter"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/v1api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/gen"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/genesis"
+	"github.com/filecoin-project/lotus/lib/apitls"
+	lotusminer "github.com/filecoin-project/lotus/miner"
+	"github.com/filecoin-project/lotus/node"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/modules"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/modules/testing"
+	"github.com/filecoin-project/lotus/node/repo"
+	"github.com/filecoin-project/lotus/storage/paths"
+	sectorstorage "github.com/filecoin-project/lotus/storage/sealer"
+	"github.com/filecoin-project/lotus/storage/sealer/mock"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+// devnetGenesisTimestamp is the timestamp of all devnet genesis blocks, so that
+// devnets created with the same parameters have the same genesis. Block
+// timestamps move forward by the block delay of the network every epoch, so
+// it's far enough in the past for blocks mined faster than that.
+const devnetGenesisTimestamp = 1640995200 // 2022-01-01T00:00:00Z
+
+var devnetOwnerBalance = big.Mul(big.NewInt(100000000), types.NewInt(build.FilecoinPrecision))
+
+var devnetMinerSubsystems = config.MinerSubsystemConfig{
+	EnableMining:        true,
+	EnableSealing:       true,
+	EnableSectorStorage: true,
+}
+
+func runDevnet(cctx *cli.Context) error {
+	blockTime := cctx.Duration("block-time")
+	if blockTime <= 0 {
+		return xerrors.Errorf("block time must be positive")
+	}
+
+	dir, err := devnetDir(cctx)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return xerrors.Errorf("creating devnet directory: %w", err)
+	}
+
+	p, err := readDevnetParams(dir)
+	if err != nil {
+		return err
+	}
+
+	fresh := p == nil
+	if fresh {
+		p, err = newDevnetParams(cctx)
+		if err != nil {
+			return err
+		}
+
+		// leftovers of a devnet which failed to start
+		if err := removeDevnetState(dir); err != nil {
+			return err
+		}
+	} else {
+		for _, f := range []string{"seed", "accounts", "account-balance", "sectors", "sector-size"} {
+			if cctx.IsSet(f) {
+				log.Warnf("ignoring --%s, the devnet in %s already exists; run 'lotus devnet reset' to create a new one", f, dir)
+			}
+		}
+	}
+
+	if err := build.UseNetworkBundle("testing-fake-proofs"); err != nil {
+		return xerrors.Errorf("loading the fake proofs actors bundle: %w", err)
+	}
+
+	// the local miner is the only source of blocks
+	chain.BootstrapPeerThreshold = 1
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	shutdownChan := make(chan struct{})
+
+	full, stopFull, err := startDevnetFullNode(ctx, cctx, dir, p, fresh, shutdownChan)
+	if err != nil {
+		return err
+	}
+
+	if fresh {
+		if err := writeDevnetParams(dir, p); err != nil {
+			return xerrors.Errorf("writing devnet parameters: %w", err)
+		}
+	}
+
+	minerapi, mineBlock, stopMiner, err := startDevnetMiner(ctx, cctx, dir, p, fresh, full, shutdownChan)
+	if err != nil {
+		return err
+	}
+
+	fullEndpoint, err := devnetRepoEndpoint(dir, devnetFullRepo)
+	if err != nil {
+		return err
+	}
+	fullHandler, err := node.FullNodeHandler(full, true)
+	if err != nil {
+		return xerrors.Errorf("failed to instantiate rpc handler: %w", err)
+	}
+	fullRPCStopper, err := node.ServeRPC(fullHandler, "lotus-daemon", fullEndpoint, node.APITLSConfig(full))
+	if err != nil {
+		return xerrors.Errorf("failed to start json-rpc endpoint: %w", err)
+	}
+
+	minerEndpoint, err := devnetRepoEndpoint(dir, devnetMinerRepo)
+	if err != nil {
+		return err
+	}
+	minerHandler, err := node.MinerHandler(minerapi, true)
+	if err != nil {
+		return xerrors.Errorf("failed to instantiate rpc handler: %w", err)
+	}
+	minerRPCStopper, err := node.ServeRPC(minerHandler, "lotus-miner", minerEndpoint, node.APITLSConfig(minerapi))
+	if err != nil {
+		return xerrors.Errorf("failed to start json-rpc endpoint: %w", err)
+	}
+
+	if err := printDevnetInfo(ctx, cctx, dir, p, full); err != nil {
+		return err
+	}
+
+	mctx, stopMining := context.WithCancel(ctx)
+	go mineDevnetBlocks(mctx, mineBlock, blockTime)
+
+	finishCh := node.MonitorShutdown(shutdownChan,
+		node.ShutdownHandler{Component: "block production", StopFunc: func(context.Context) error {
+			stopMining()
+			return nil
+		}},
+		node.ShutdownHandler{Component: "miner rpc server", StopFunc: minerRPCStopper},
+		node.ShutdownHandler{Component: "miner", StopFunc: stopMiner},
+		node.ShutdownHandler{Component: "rpc server", StopFunc: fullRPCStopper},
+		node.ShutdownHandler{Component: "node", StopFunc: stopFull},
+	)
+	<-finishCh
+
+	return nil
+}
+
+// devnetGenesisTemplate returns the genesis of the devnet: the pre-funded
+// accounts, and a miner with pre-sealed sectors
+func devnetGenesisTemplate(p *devnetParams) (*genesis.Template, error) {
+	keys, err := p.accountKeys()
+	if err != nil {
+		return nil, err
+	}
+	owner, err := p.ownerKey()
+	if err != nil {
+		return nil, xerrors.Errorf("deriving miner owner key: %w", err)
+	}
+
+	spt, err := miner.SealProofTypeFromSectorSize(p.SectorSize, p.NetworkVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	genm, _, err := mock.PreSeal(spt, p.Miner, p.Sectors)
+	if err != nil {
+		return nil, xerrors.Errorf("pre-sealing sectors: %w", err)
+	}
+
+	// the pre-seal comes with a random key, use the key derived from the seed
+	genm.Owner, genm.Worker = owner.Address, owner.Address
+	for _, s := range genm.Sectors {
+		s.Deal.Client = owner.Address
+		s.DealClientKey = owner
+	}
+
+	templ := &genesis.Template{
+		NetworkVersion:   p.NetworkVersion,
+		Miners:           []genesis.Miner{*genm},
+		NetworkName:      "devnet",
+		Timestamp:        devnetGenesisTimestamp,
+		VerifregRootKey:  gen.DefaultVerifregRootkeyActor,
+		RemainderAccount: gen.DefaultRemainderAccountActor,
+	}
+
+	for _, k := range keys {
+		templ.Accounts = append(templ.Accounts, genesis.Actor{
+			Type:    genesis.TAccount,
+			Balance: p.AccountBalance,
+			Meta:    (&genesis.AccountMeta{Owner: k.Address}).ActorMeta(),
+		})
+	}
+	templ.Accounts = append(templ.Accounts, genesis.Actor{
+		Type:    genesis.TAccount,
+		Balance: devnetOwnerBalance,
+		Meta:    (&genesis.AccountMeta{Owner: owner.Address}).ActorMeta(),
+	})
+
+	return templ, nil
+}
+
+func devnetNodeOptions(p *devnetParams, shutdownChan chan struct{}) node.Option {
+	return node.Options(
+		node.Test(),
+		node.Override(new(dtypes.ShutdownChan), shutdownChan),
+		node.Override(new(dtypes.BootstrapPeers), dtypes.BootstrapPeers(nil)),
+		node.Override(new(stmgr.UpgradeSchedule), stmgr.UpgradeSchedule{{
+			Network: p.NetworkVersion,
+			Height:  -1,
+		}}),
+		node.Override(new(storiface.Verifier), mock.MockVerifier),
+		node.Override(new(storiface.Prover), mock.MockProver),
+	)
+}
+
+func startDevnetFullNode(ctx context.Context, cctx *cli.Context, dir string, p *devnetParams, fresh bool, shutdownChan chan struct{}) (api.FullNode, node.StopFunc, error) {
+	r, err := repo.NewFS(filepath.Join(dir, devnetFullRepo))
+	if err != nil {
+		return nil, nil, xerrors.Errorf("opening fs repo: %w", err)
+	}
+	if err := r.Init(repo.FullNode); err != nil && err != repo.ErrRepoExists {
+		return nil, nil, xerrors.Errorf("repo init error: %w", err)
+	}
+
+	var genBytes bytes.Buffer
+	var genesisOpt node.Option
+	if fresh {
+		templ, err := devnetGenesisTemplate(p)
+		if err != nil {
+			return nil, nil, err
+		}
+		genesisOpt = node.Override(new(modules.Genesis), testing.MakeGenesisMem(&genBytes, *templ))
+	} else {
+		b, err := ioutil.ReadFile(filepath.Join(dir, devnetGenesisFile))
+		if err != nil {
+			return nil, nil, xerrors.Errorf("reading genesis: %w", err)
+		}
+		genesisOpt = node.Override(new(modules.Genesis), modules.LoadGenesis(b))
+	}
+
+	var full api.FullNode
+	stop, err := node.New(ctx,
+		node.FullAPI(&full),
+		node.Base(),
+		node.Repo(r),
+		devnetNodeOptions(p, shutdownChan),
+
+		// so that we subscribe to pubsub topics immediately
+		node.Override(new(dtypes.Bootstrapper), dtypes.Bootstrapper(true)),
+
+		genesisOpt,
+
+		node.Override(node.SetApiEndpointKey, func(lr repo.LockedRepo, tlsSrv *apitls.Server) error {
+			apima, err := multiaddr.NewMultiaddr("/ip4/127.0.0.1/tcp/" + cctx.String("api"))
+			if err != nil {
+				return err
+			}
+			if tlsSrv != nil {
+				apima = apitls.Endpoint(apima)
+			}
+			return lr.SetAPIEndpoint(apima)
+		}),
+	)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("initializing full node: %w", err)
+	}
+
+	if !fresh {
+		return full, stop, nil
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, devnetGenesisFile), genBytes.Bytes(), 0644); err != nil {
+		return nil, nil, xerrors.Errorf("writing genesis: %w", err)
+	}
+
+	keys, err := p.accountKeys()
+	if err != nil {
+		return nil, nil, err
+	}
+	owner, err := p.ownerKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, k := range append(keys, owner) {
+		if _, err := full.WalletImport(ctx, &k.KeyInfo); err != nil {
+			return nil, nil, xerrors.Errorf("importing key %s: %w", k.Address, err)
+		}
+	}
+	if err := full.WalletSetDefault(ctx, keys[0].Address); err != nil {
+		return nil, nil, xerrors.Errorf("setting default wallet: %w", err)
+	}
+
+	return full, stop, nil
+}
+
+func startDevnetMiner(ctx context.Context, cctx *cli.Context, dir string, p *devnetParams, fresh bool, full api.FullNode, shutdownChan chan struct{}) (api.StorageMiner, chan lotusminer.MineReq, node.StopFunc, error) {
+	r, err := repo.NewFS(filepath.Join(dir, devnetMinerRepo))
+	if err != nil {
+		return nil, nil, nil, xerrors.Errorf("opening fs repo: %w", err)
+	}
+
+	if fresh {
+		if err := initDevnetMinerRepo(ctx, r, p); err != nil {
+			return nil, nil, nil, xerrors.Errorf("initializing miner repo: %w", err)
+		}
+	}
+
+	mid, err := address.IDFromAddress(p.Miner)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// fake proofs don't keep any sector data, so the mock sector manager
+	// only needs to know about the sectors already on chain
+	onChain, err := full.StateMinerSectors(ctx, p.Miner, nil, types.EmptyTSK)
+	if err != nil {
+		return nil, nil, nil, xerrors.Errorf("getting miner sectors: %w", err)
+	}
+	sectors := make([]abi.SectorID, 0, len(onChain))
+	for _, s := range onChain {
+		sectors = append(sectors, abi.SectorID{Miner: abi.ActorID(mid), Number: s.SectorNumber})
+	}
+
+	mineBlock := make(chan lotusminer.MineReq)
+
+	var minerapi api.StorageMiner
+	stop, err := node.New(ctx,
+		node.StorageMiner(&minerapi, devnetMinerSubsystems),
+		node.Base(),
+		node.Repo(r),
+		devnetNodeOptions(p, shutdownChan),
+
+		node.Override(new(dtypes.APIEndpoint), func() (dtypes.APIEndpoint, error) {
+			return multiaddr.NewMultiaddr("/ip4/127.0.0.1/tcp/" + cctx.String("miner-api"))
+		}),
+		node.Override(new(v1api.FullNode), full),
+		node.Override(new(*lotusminer.Miner), lotusminer.NewTestMiner(mineBlock, p.Miner)),
+
+		node.Override(new(*mock.SectorMgr), func() (*mock.SectorMgr, error) {
+			return mock.NewMockSectorMgr(sectors), nil
+		}),
+		node.Override(new(sectorstorage.SectorManager), node.From(new(*mock.SectorMgr))),
+		node.Override(new(sectorstorage.Unsealer), node.From(new(*mock.SectorMgr))),
+		node.Override(new(sectorstorage.PieceProvider), node.From(new(*mock.SectorMgr))),
+		node.Unset(new(*sectorstorage.Manager)),
+	)
+	if err != nil {
+		return nil, nil, nil, xerrors.Errorf("initializing miner: %w", err)
+	}
+
+	return minerapi, mineBlock, stop, nil
+}
+
+func initDevnetMinerRepo(ctx context.Context, r *repo.FsRepo, p *devnetParams) error {
+	if err := r.Init(repo.StorageMiner); err != nil {
+		return err
+	}
+
+	lr, err := r.Lock(repo.StorageMiner)
+	if err != nil {
+		return err
+	}
+	defer lr.Close() //nolint:errcheck
+
+	err = lr.SetConfig(func(raw interface{}) {
+		cfg := raw.(*config.StorageMiner)
+		cfg.Subsystems = devnetMinerSubsystems
+	})
+	if err != nil {
+		return xerrors.Errorf("setting config: %w", err)
+	}
+
+	// sectors are sealed with fake proofs, there is no sector data to store
+	if err := lr.SetStorage(func(sc *paths.StorageConfig) {
+		sc.StoragePaths = []paths.LocalPath{}
+	}); err != nil {
+		return xerrors.Errorf("setting storage config: %w", err)
+	}
+
+	ds, err := lr.Datastore(ctx, "/metadata")
+	if err != nil {
+		return err
+	}
+
+	if err := ds.Put(ctx, datastore.NewKey("miner-address"), p.Miner.Bytes()); err != nil {
+		return err
+	}
+
+	nic := storedcounter.New(ds, datastore.NewKey(modules.StorageCounterDSPrefix))
+	for i := 0; i <= p.Sectors; i++ {
+		if _, err := nic.Next(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func devnetRepoEndpoint(dir, name string) (multiaddr.Multiaddr, error) {
+	r, err := repo.NewFS(filepath.Join(dir, name))
+	if err != nil {
+		return nil, err
+	}
+	endpoint, err := r.APIEndpoint()
+	if err != nil {
+		return nil, xerrors.Errorf("getting %s api endpoint: %w", name, err)
+	}
+	return endpoint, nil
+}
+
+// mineDevnetBlocks asks the miner to mine a block every block time
+func mineDevnetBlocks(ctx context.Context, mineBlock chan<- lotusminer.MineReq, blockTime time.Duration) {
+	for {
+		select {
+		case <-time.After(blockTime):
+		case <-ctx.Done():
+			return
+		}
+
+		req := lotusminer.MineReq{
+			Done: func(_ bool, epoch abi.ChainEpoch, err error) {
+				if err != nil {
+					log.Errorf("mining block at epoch %d: %s", epoch, err)
+				}
+			},
+		}
+
+		select {
+		case mineBlock <- req:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func printDevnetInfo(ctx context.Context, cctx *cli.Context, dir string, p *devnetParams, full api.FullNode) error {
+	gts, err := full.ChainGetGenesis(ctx)
+	if err != nil {
+		return err
+	}
+	keys, err := p.accountKeys()
+	if err != nil {
+		return err
+	}
+
+	w := cctx.App.Writer
+	fmt.Fprintf(w, "Devnet running in %s\n", dir)
+	fmt.Fprintf(w, "Genesis: %s\n", gts.Cids()[0])
+	fmt.Fprintf(w, "Miner: %s (%d sectors of %s)\n", p.Miner, p.Sectors, types.SizeStr(types.NewInt(uint64(p.SectorSize))))
+	fmt.Fprintf(w, "Block time: %s\n", cctx.Duration("block-time"))
+	fmt.Fprintf(w, "Accounts:\n")
+	for _, k := range keys {
+		fmt.Fprintf(w, "  %s  %s  %s\n", k.Address, ethAddress(k), types.FIL(p.AccountBalance).Short())
+	}
+	fmt.Fprintf(w, "\nList the account private keys with: lotus devnet accounts\n")
+	fmt.Fprintf(w, "Use the devnet with: lotus --repo %s ...\n", filepath.Join(dir, devnetFullRepo))
+	fmt.Fprintf(w, "                     lotus-miner --miner-repo %s ...\n", filepath.Join(dir, devnetMinerRepo))
+
+	return nil
+}
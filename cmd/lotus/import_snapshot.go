@@ -0,0 +1,463 @@
+//go:build !nodaemon
+// +build !nodaemon
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/DataDog/zstd"
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-datastore"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/ipld/go-car"
+	"github.com/ipld/go-car/util"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	lcli "github.com/filecoin-project/lotus/cli"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+// snapshotImportProgressKey holds the progress of the import of a chunked
+// snapshot in the metadata datastore, so that an import interrupted by a
+// crash can be resumed.
+var snapshotImportProgressKey = datastore.NewKey("/snapshot-import/progress")
+
+type snapshotImportProgress struct {
+	Head types.TipSetKey
+	// Offset is the position in the CAR stream of the first block which
+	// wasn't imported yet.
+	Offset int64
+}
+
+// chunkedSnapshotDir returns the directory of the chunked snapshot at path,
+// which is either the directory itself or its manifest, or an empty string if
+// path isn't a chunked snapshot.
+func chunkedSnapshotDir(path string) (string, error) {
+	st, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if st.IsDir() {
+		return path, nil
+	}
+	if filepath.Base(path) == lcli.ExportManifestName {
+		return filepath.Dir(path), nil
+	}
+	return "", nil
+}
+
+func loadSnapshotImportProgress(ctx context.Context, mds datastore.Batching, m *lcli.ExportManifest) (snapshotImportProgress, error) {
+	prog := snapshotImportProgress{Head: m.Head}
+
+	b, err := mds.Get(ctx, snapshotImportProgressKey)
+	if err == datastore.ErrNotFound {
+		return prog, nil
+	}
+	if err != nil {
+		return prog, xerrors.Errorf("loading snapshot import progress: %w", err)
+	}
+
+	var saved snapshotImportProgress
+	if err := json.Unmarshal(b, &saved); err != nil {
+		return prog, xerrors.Errorf("decoding snapshot import progress: %w", err)
+	}
+	if saved.Head != m.Head {
+		log.Warnf("discarding the progress of the interrupted import of snapshot %s", saved.Head)
+		return prog, nil
+	}
+	return saved, nil
+}
+
+// importSnapshotChunks imports the blocks of a chunked snapshot into bs,
+// recording its progress in mds. Each chunk file is verified against the
+// manifest before its blocks are imported.
+func importSnapshotChunks(ctx context.Context, bs blockstore.Blockstore, mds datastore.Batching, dir string, m *lcli.ExportManifest, status *importStatus) error {
+	if !m.Complete {
+		return xerrors.Errorf("snapshot export in %s is incomplete", dir)
+	}
+
+	var total int64
+	for _, c := range m.Chunks {
+		total += c.Size
+	}
+
+	prog, err := loadSnapshotImportProgress(ctx, mds, m)
+	if err != nil {
+		return err
+	}
+	if prog.Offset > 0 {
+		log.Infof("resuming the import of snapshot %s at offset %d", m.Head, prog.Offset)
+	}
+	status.start(total, prog.Offset)
+
+	cr, err := newSnapshotChunkReader(dir, m, prog.Offset, status)
+	if err != nil {
+		return err
+	}
+	defer cr.Close() //nolint:errcheck
+
+	br := bufio.NewReaderSize(cr, 1<<20)
+
+	if prog.Offset == 0 {
+		hb, err := util.LdRead(br)
+		if err != nil {
+			return xerrors.Errorf("reading snapshot header: %w", err)
+		}
+		var h car.CarHeader
+		if err := cbor.DecodeInto(hb, &h); err != nil {
+			return xerrors.Errorf("invalid snapshot header: %w", err)
+		}
+		if types.NewTipSetKey(h.Roots...) != m.Head {
+			return xerrors.Errorf("snapshot roots %s don't match the manifest head %s", h.Roots, m.Head)
+		}
+		prog.Offset += int64(util.LdSize(hb))
+	}
+
+	var batch []blocks.Block
+	flush := func() error {
+		if err := bs.PutMany(ctx, batch); err != nil {
+			return xerrors.Errorf("storing blocks: %w", err)
+		}
+		batch = batch[:0]
+
+		b, err := json.Marshal(prog)
+		if err != nil {
+			return err
+		}
+		if err := mds.Put(ctx, snapshotImportProgressKey, b); err != nil {
+			return xerrors.Errorf("saving snapshot import progress: %w", err)
+		}
+
+		status.progress(prog.Offset)
+		return nil
+	}
+
+	for {
+		data, err := util.LdRead(br)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return xerrors.Errorf("reading snapshot at offset %d: %w", prog.Offset, err)
+		}
+
+		c, n, err := util.ReadCid(data)
+		if err != nil {
+			return xerrors.Errorf("reading block cid at offset %d: %w", prog.Offset, err)
+		}
+		blk, err := blocks.NewBlockWithCid(data[n:], c)
+		if err != nil {
+			return err
+		}
+
+		batch = append(batch, blk)
+		prog.Offset += int64(util.LdSize(data))
+
+		if len(batch) >= 1000 {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	if prog.Offset != total {
+		return xerrors.Errorf("snapshot ended at offset %d, expected %d", prog.Offset, total)
+	}
+	return nil
+}
+
+// snapshotBase returns the lowest tipset of an imported chain: the genesis for
+// full snapshots, or the lowest tipset with all its headers for latest state
+// snapshots, which don't include the chain history.
+func snapshotBase(ctx context.Context, cst *store.ChainStore, bs blockstore.Blockstore, ts *types.TipSet) (*types.TipSet, error) {
+	for ts.Height() > 0 {
+		for _, c := range ts.Parents().Cids() {
+			has, err := bs.Has(ctx, c)
+			if err != nil {
+				return nil, xerrors.Errorf("checking for block header %s: %w", c, err)
+			}
+			if !has {
+				return ts, nil
+			}
+		}
+
+		parent, err := cst.LoadTipSet(ctx, ts.Parents())
+		if err != nil {
+			return nil, xerrors.Errorf("loading tipset %s: %w", ts.Parents(), err)
+		}
+		ts = parent
+	}
+	return ts, nil
+}
+
+// setPrunedConfig configures a node booting from a latest state snapshot to
+// run pruned: the splitstore discards the objects falling out of the hotstore
+// rather than keeping the chain history, which the node doesn't have.
+func setPrunedConfig(lr repo.LockedRepo) error {
+	var typeErr error
+	err := lr.SetConfig(func(raw interface{}) {
+		cfg, ok := raw.(*config.FullNode)
+		if !ok {
+			typeErr = xerrors.Errorf("expected full node config, got %T", raw)
+			return
+		}
+
+		cfg.Chainstore.EnableSplitstore = true
+		cfg.Chainstore.Splitstore.ColdStoreType = "discard"
+	})
+	if err != nil {
+		return xerrors.Errorf("setting pruned node config: %w", err)
+	}
+	return typeErr
+}
+
+// snapshotChunkReader reads the CAR stream of a chunked snapshot from its
+// chunk files, verifying each of them before it's read.
+type snapshotChunkReader struct {
+	dir    string
+	m      *lcli.ExportManifest
+	status *importStatus
+
+	next int
+	pos  int64
+	fi   *os.File
+	cur  io.ReadCloser
+}
+
+// newSnapshotChunkReader returns a reader of the CAR stream of a chunked
+// snapshot, starting at the given offset.
+func newSnapshotChunkReader(dir string, m *lcli.ExportManifest, offset int64, status *importStatus) (*snapshotChunkReader, error) {
+	r := &snapshotChunkReader{dir: dir, m: m, status: status, next: len(m.Chunks), pos: offset}
+
+	for i, c := range m.Chunks {
+		if offset >= c.Offset+c.Size {
+			continue
+		}
+
+		r.next = i
+		if err := r.open(); err != nil {
+			return nil, err
+		}
+		if _, err := io.CopyN(io.Discard, r.cur, offset-c.Offset); err != nil {
+			return nil, xerrors.Errorf("seeking to offset %d in chunk %s: %w", offset, c.Name, err)
+		}
+		break
+	}
+
+	return r, nil
+}
+
+func (r *snapshotChunkReader) open() error {
+	c := &r.m.Chunks[r.next]
+	path := filepath.Join(r.dir, c.Name)
+
+	r.status.chunk(r.next, len(r.m.Chunks))
+	log.Infof("importing snapshot chunk %s (%d/%d)", c.Name, r.next+1, len(r.m.Chunks))
+
+	ok, err := lcli.CheckExportChunk(path, c)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return xerrors.Errorf("snapshot chunk %s is missing or corrupted", c.Name)
+	}
+
+	fi, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	r.fi = fi
+	r.cur = fi
+	if r.m.Compression == lcli.ExportCompressZstd {
+		r.cur = zstd.NewReader(fi)
+	}
+	r.next++
+	return nil
+}
+
+func (r *snapshotChunkReader) Read(p []byte) (int, error) {
+	for {
+		if r.cur == nil {
+			if r.next == len(r.m.Chunks) {
+				return 0, io.EOF
+			}
+			if err := r.open(); err != nil {
+				return 0, err
+			}
+		}
+
+		n, err := r.cur.Read(p)
+		r.pos += int64(n)
+		if err == io.EOF {
+			c := r.m.Chunks[r.next-1]
+			if r.pos != c.Offset+c.Size {
+				return n, xerrors.Errorf("snapshot chunk %s has %d bytes, expected %d", c.Name, r.pos-c.Offset, c.Size)
+			}
+			if err := r.Close(); err != nil {
+				return n, err
+			}
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (r *snapshotChunkReader) Close() error {
+	if r.cur == nil {
+		return nil
+	}
+	if r.cur != io.ReadCloser(r.fi) {
+		if err := r.cur.Close(); err != nil {
+			return err
+		}
+	}
+	r.cur = nil
+	return r.fi.Close()
+}
+
+// importStatus is the progress of a chain import, served by the import status
+// endpoint.
+type importStatus struct {
+	lk sync.Mutex
+
+	chunks, curChunk int
+	bytes, total     int64
+
+	started      time.Time
+	startedBytes int64
+	done         bool
+	err          error
+}
+
+type importStatusJSON struct {
+	Chunk      int `json:",omitempty"`
+	Chunks     int `json:",omitempty"`
+	Bytes      int64
+	TotalBytes int64
+	Percent    float64
+	ETA        string
+	Done       bool
+	Error      string `json:",omitempty"`
+}
+
+func (s *importStatus) start(total, bytes int64) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	s.total = total
+	s.bytes = bytes
+	s.started = time.Now()
+	s.startedBytes = bytes
+}
+
+func (s *importStatus) chunk(i, chunks int) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	s.curChunk = i
+	s.chunks = chunks
+}
+
+func (s *importStatus) progress(bytes int64) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	s.bytes = bytes
+}
+
+func (s *importStatus) finish(err error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	s.done = true
+	s.err = err
+}
+
+// Write counts the bytes imported from an unchunked chain file.
+func (s *importStatus) Write(p []byte) (int, error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	s.bytes += int64(len(p))
+	return len(p), nil
+}
+
+func (s *importStatus) MarshalJSON() ([]byte, error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	out := importStatusJSON{
+		Bytes:      s.bytes,
+		TotalBytes: s.total,
+		Done:       s.done,
+	}
+	if s.chunks > 0 {
+		out.Chunk = s.curChunk + 1
+		out.Chunks = s.chunks
+	}
+	if s.err != nil {
+		out.Error = s.err.Error()
+	}
+
+	if s.total > 0 {
+		out.Percent = float64(s.bytes) * 100 / float64(s.total)
+
+		elapsed := time.Since(s.started)
+		if done := s.bytes - s.startedBytes; done > 0 && !s.done {
+			eta := time.Duration(float64(elapsed) * float64(s.total-s.bytes) / float64(done))
+			out.ETA = eta.Round(time.Second).String()
+		}
+	}
+
+	return json.Marshal(out)
+}
+
+// serveImportStatus serves the status of an import as JSON on addr, until
+// the returned function is called.
+func serveImportStatus(addr string, status *importStatus) (func(), error) {
+	lst, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, xerrors.Errorf("listening on import status address: %w", err)
+	}
+
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(status); err != nil {
+				log.Warnf("writing import status: %s", err)
+			}
+		}),
+	}
+	go func() {
+		if err := srv.Serve(lst); err != http.ErrServerClosed {
+			log.Warnf("serving import status: %s", err)
+		}
+	}()
+
+	log.Infof("serving import status on http://%s", lst.Addr())
+
+	return func() {
+		_ = srv.Close()
+	}, nil
+}
@@ -21,6 +21,7 @@ import (
 var log = logging.Logger("main")
 
 var AdvanceBlockCmd *cli.Command
+var DevnetCmd *cli.Command
 
 func main() {
 	api.RunningNodeType = api.NodeFull
@@ -31,10 +32,14 @@ func main() {
 		DaemonCmd,
 		backupCmd,
 		configCmd,
+		lcli.RepoMigrateCmd("repo", repo.FullNode),
 	}
 	if AdvanceBlockCmd != nil {
 		local = append(local, AdvanceBlockCmd)
 	}
+	if DevnetCmd != nil {
+		local = append(local, DevnetCmd)
+	}
 
 	jaeger := tracing.SetupJaegerTracing("lotus")
 	defer func() {
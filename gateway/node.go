@@ -480,16 +480,24 @@ func (gw *Node) StateNetworkVersion(ctx context.Context, tsk types.TipSetKey) (n
 	return gw.target.StateNetworkVersion(ctx, tsk)
 }
 
+// stateWaitLimit caps the lookback limit of a message search to the limit of
+// the gateway. The finality configured on the node is unknown here, so
+// api.LookbackFinality is capped like api.LookbackNoLimit.
+func (gw *Node) stateWaitLimit(limit abi.ChainEpoch) abi.ChainEpoch {
+	if gw.stateWaitLookbackLimit == api.LookbackNoLimit {
+		return limit
+	}
+	if limit == api.LookbackNoLimit || limit == api.LookbackFinality || limit > gw.stateWaitLookbackLimit {
+		return gw.stateWaitLookbackLimit
+	}
+	return limit
+}
+
 func (gw *Node) StateSearchMsg(ctx context.Context, from types.TipSetKey, msg cid.Cid, limit abi.ChainEpoch, allowReplaced bool) (*api.MsgLookup, error) {
 	if err := gw.limit(ctx, stateRateLimitTokens); err != nil {
 		return nil, err
 	}
-	if limit == api.LookbackNoLimit {
-		limit = gw.stateWaitLookbackLimit
-	}
-	if gw.stateWaitLookbackLimit != api.LookbackNoLimit && limit > gw.stateWaitLookbackLimit {
-		limit = gw.stateWaitLookbackLimit
-	}
+	limit = gw.stateWaitLimit(limit)
 	if err := gw.checkTipsetKey(ctx, from); err != nil {
 		return nil, err
 	}
@@ -500,12 +508,7 @@ func (gw *Node) StateWaitMsg(ctx context.Context, msg cid.Cid, confidence uint64
 	if err := gw.limit(ctx, stateRateLimitTokens); err != nil {
 		return nil, err
 	}
-	if limit == api.LookbackNoLimit {
-		limit = gw.stateWaitLookbackLimit
-	}
-	if gw.stateWaitLookbackLimit != api.LookbackNoLimit && limit > gw.stateWaitLookbackLimit {
-		limit = gw.stateWaitLookbackLimit
-	}
+	limit = gw.stateWaitLimit(limit)
 	return gw.target.StateWaitMsg(ctx, msg, confidence, limit, allowReplaced)
 }
 
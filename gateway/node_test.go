@@ -275,3 +275,15 @@ func TestGatewayLimitTokensNotAvailable(t *testing.T) {
 	}
 	require.Error(t, err, "requiests should be rate limited when they hit limits")
 }
+
+func TestGatewayStateWaitLimit(t *testing.T) {
+	a := NewNode(&mockGatewayDepsAPI{}, DefaultLookbackCap, 20, 0, time.Minute)
+
+	require.Equal(t, abi.ChainEpoch(20), a.stateWaitLimit(api.LookbackNoLimit))
+	require.Equal(t, abi.ChainEpoch(20), a.stateWaitLimit(api.LookbackFinality))
+	require.Equal(t, abi.ChainEpoch(20), a.stateWaitLimit(100))
+	require.Equal(t, abi.ChainEpoch(10), a.stateWaitLimit(10))
+
+	a = NewNode(&mockGatewayDepsAPI{}, DefaultLookbackCap, api.LookbackNoLimit, 0, time.Minute)
+	require.Equal(t, api.LookbackFinality, a.stateWaitLimit(api.LookbackFinality))
+}
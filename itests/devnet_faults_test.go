@@ -0,0 +1,75 @@
+//stm: #integration
+package itests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/itests/kit"
+)
+
+func TestDevnetPartitionAndRestart(t *testing.T) {
+	//stm: @CHAIN_SYNCER_SYNC_001, @CHAIN_SYNCER_COLLECT_CHAIN_001
+	kit.QuietMiningLogs()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fulls, miners, ens := kit.EnsembleDevnet(t, 3, 2, kit.MockProofs())
+	ens.InterconnectAll().SetLatency(5 * time.Millisecond)
+	ens.SetLinkLatency(fulls[0], fulls[2], 50*time.Millisecond)
+	ens.BeginMining(50 * time.Millisecond)
+
+	// the first full node backs the first miner, so it keeps following a
+	// growing chain through all the faults
+	ens.Faults(fulls[0]).
+		At(10, "partition the miners", func() {
+			ens.Partition(
+				[]api.Net{fulls[0], miners[0]},
+				[]api.Net{fulls[1], fulls[2], miners[1]},
+			)
+		}).
+		At(20, "heal the partition", func() {
+			ens.Heal()
+		}).
+		At(25, "crash the third full node", func() {
+			ens.StopFullNode(fulls[2])
+		}).
+		At(30, "stop the second miner", func() {
+			ens.StopMining(miners[1])
+		}).
+		At(35, "restart the third full node", func() {
+			ens.RestartFullNode(fulls[2])
+		}).
+		Run(ctx)
+
+	ctx, cancel = context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	// the forks of the partition are resolved, and the restarted full node
+	// catches up with the others
+	ts := ens.WaitConsensus(ctx)
+	require.GreaterOrEqual(t, ts.Height(), abi.ChainEpoch(35))
+
+	// blocks of both miners made it into the chain
+	for _, m := range miners {
+		won := false
+		for cur := ts; cur.Height() > 0 && !won; {
+			for _, b := range cur.Blocks() {
+				if b.Miner == m.ActorAddr {
+					won = true
+				}
+			}
+			next, err := fulls[0].ChainGetTipSet(ctx, cur.Parents())
+			require.NoError(t, err)
+			cur = next
+		}
+		require.True(t, won, "no block of miner %s in the chain", m.ActorAddr)
+	}
+}
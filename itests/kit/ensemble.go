@@ -113,6 +113,7 @@ type Ensemble struct {
 	genesisBlock bytes.Buffer
 	mn           mocknet.Mocknet
 	options      *ensembleOpts
+	topology     topology
 
 	inactive struct {
 		fullnodes []*TestFullNode
@@ -335,48 +336,17 @@ func (n *Ensemble) Start() *Ensemble {
 
 	// Create all inactive full nodes.
 	for i, full := range n.inactive.fullnodes {
-		r := repo.NewMemory(nil)
-		opts := []node.Option{
-			node.FullAPI(&full.FullNode, node.Lite(full.options.lite)),
-			node.Base(),
-			node.Repo(r),
-			node.If(full.options.disableLibp2p, node.MockHost(n.mn)),
-			node.Test(),
-
-			// so that we subscribe to pubsub topics immediately
-			node.Override(new(dtypes.Bootstrapper), dtypes.Bootstrapper(true)),
-
-			// upgrades
-			node.Override(new(stmgr.UpgradeSchedule), n.options.upgradeSchedule),
-		}
-
-		// append any node builder options.
-		opts = append(opts, full.options.extraNodeOpts...)
-
 		// Either generate the genesis or inject it.
+		var genesis node.Option
 		if i == 0 && !n.bootstrapped {
-			opts = append(opts, node.Override(new(modules.Genesis), testing2.MakeGenesisMem(&n.genesisBlock, *gtempl)))
+			genesis = node.Override(new(modules.Genesis), testing2.MakeGenesisMem(&n.genesisBlock, *gtempl))
 		} else {
-			opts = append(opts, node.Override(new(modules.Genesis), modules.LoadGenesis(n.genesisBlock.Bytes())))
-		}
-
-		// Are we mocking proofs?
-		if n.options.mockProofs {
-			opts = append(opts,
-				node.Override(new(storiface.Verifier), mock.MockVerifier),
-				node.Override(new(storiface.Prover), mock.MockProver),
-			)
-		}
-
-		// Call option builders, passing active nodes as the parameter
-		for _, bopt := range full.options.optBuilders {
-			opts = append(opts, bopt(n.active.fullnodes))
+			genesis = node.Override(new(modules.Genesis), modules.LoadGenesis(n.genesisBlock.Bytes()))
 		}
 
 		// Construct the full node.
-		stop, err := node.New(ctx, opts...)
-
-		require.NoError(n.t, err)
+		full.repo = repo.NewMemory(nil)
+		n.startFullNode(full, genesis)
 
 		addr, err := full.WalletImport(context.Background(), &full.DefaultKey.KeyInfo)
 		require.NoError(n.t, err)
@@ -390,7 +360,7 @@ func (n *Ensemble) Start() *Ensemble {
 			n.inactive.fullnodes[i] = withRPC
 		}
 
-		n.t.Cleanup(func() { _ = stop(context.Background()) })
+		n.t.Cleanup(func() { _ = full.Stop(context.Background()) })
 
 		n.active.fullnodes = append(n.active.fullnodes, full)
 	}
@@ -781,6 +751,54 @@ func (n *Ensemble) Start() *Ensemble {
 	return n
 }
 
+// startFullNode constructs a full node on its repo, with the given genesis
+// option. The node can be stopped and started again on the same repo.
+func (n *Ensemble) startFullNode(full *TestFullNode, genesis node.Option) {
+	opts := []node.Option{
+		node.FullAPI(&full.FullNode, node.Lite(full.options.lite)),
+		node.Base(),
+		node.Repo(full.repo),
+		node.If(full.options.disableLibp2p, node.MockHost(n.mn)),
+		node.Test(),
+
+		// so that we subscribe to pubsub topics immediately
+		node.Override(new(dtypes.Bootstrapper), dtypes.Bootstrapper(true)),
+
+		// upgrades
+		node.Override(new(stmgr.UpgradeSchedule), n.options.upgradeSchedule),
+	}
+
+	// append any node builder options.
+	opts = append(opts, full.options.extraNodeOpts...)
+	opts = append(opts, genesis)
+
+	// Are we mocking proofs?
+	if n.options.mockProofs {
+		opts = append(opts,
+			node.Override(new(storiface.Verifier), mock.MockVerifier),
+			node.Override(new(storiface.Prover), mock.MockProver),
+		)
+	}
+
+	// Call option builders, passing active nodes as the parameter
+	for _, bopt := range full.options.optBuilders {
+		opts = append(opts, bopt(n.active.fullnodes))
+	}
+
+	stop, err := node.New(context.Background(), opts...)
+	require.NoError(n.t, err)
+
+	// the node may be stopped by the test before the cleanup runs
+	var (
+		once    sync.Once
+		stopErr error
+	)
+	full.Stop = func(ctx context.Context) error {
+		once.Do(func() { stopErr = stop(ctx) })
+		return stopErr
+	}
+}
+
 // InterconnectAll connects all miners and full nodes to one another.
 func (n *Ensemble) InterconnectAll() *Ensemble {
 	// connect full nodes to miners.
@@ -0,0 +1,364 @@
+package kit
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/node"
+	"github.com/filecoin-project/lotus/node/modules"
+)
+
+// The fault injection helpers below change the topology of the mocknet, so
+// they only affect nodes started with the DisableLibp2p option.
+
+type peerPair [2]peer.ID
+
+func pairOf(a, b peer.ID) peerPair {
+	if b < a {
+		a, b = b, a
+	}
+	return peerPair{a, b}
+}
+
+// stoppedNode is a full node crashed by StopFullNode.
+type stoppedNode struct {
+	id    peer.ID
+	peers []peer.ID
+}
+
+// topology holds the changes made to the mocknet by the fault injection
+// helpers, so that they are kept when nodes get linked again.
+type topology struct {
+	latency   map[peerPair]time.Duration
+	partition map[peer.ID]int
+	cut       []peerPair
+	stopped   map[*TestFullNode]stoppedNode
+}
+
+// peerID returns the peer ID of a node on the mocknet.
+func (n *Ensemble) peerID(node api.Net) peer.ID {
+	id, err := node.ID(context.Background())
+	require.NoError(n.t, err)
+	require.NotNil(n.t, n.mn.Host(id), "node %s is not on the mocknet, start it with DisableLibp2p", id)
+	return id
+}
+
+func (n *Ensemble) isStopped(id peer.ID) bool {
+	for _, s := range n.topology.stopped {
+		if s.id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// partitioned returns true when the peers are in different groups of the
+// current partition.
+func (n *Ensemble) partitioned(a, b peer.ID) bool {
+	ga, gb := n.topology.partition[a], n.topology.partition[b]
+	return ga != 0 && gb != 0 && ga != gb
+}
+
+func (n *Ensemble) linkOptions(a, b peer.ID) mocknet.LinkOptions {
+	opts := n.mn.LinkDefaults()
+	if latency, ok := n.topology.latency[pairOf(a, b)]; ok {
+		opts.Latency = latency
+	}
+	return opts
+}
+
+// link links two peers, unless they are already linked.
+func (n *Ensemble) link(a, b peer.ID) {
+	if len(n.mn.LinksBetweenPeers(a, b)) > 0 {
+		return
+	}
+	l, err := n.mn.LinkPeers(a, b)
+	require.NoError(n.t, err)
+	l.SetOptions(n.linkOptions(a, b))
+}
+
+// unlink removes the links between two peers, and closes their connections.
+// It returns true if the peers were connected.
+func (n *Ensemble) unlink(a, b peer.ID) bool {
+	connected := n.mn.Net(a).Connectedness(b) == network.Connected
+	require.NoError(n.t, n.mn.UnlinkPeers(a, b))
+	require.NoError(n.t, n.mn.DisconnectPeers(a, b))
+	require.NoError(n.t, n.mn.DisconnectPeers(b, a))
+	return connected
+}
+
+// SetLatency sets the latency of all the links of the mocknet, including the
+// links created later on.
+func (n *Ensemble) SetLatency(latency time.Duration) *Ensemble {
+	opts := n.mn.LinkDefaults()
+	opts.Latency = latency
+	n.mn.SetLinkDefaults(opts)
+
+	for _, a := range n.mn.Links() {
+		for _, b := range a {
+			for l := range b {
+				ps := l.Peers()
+				l.SetOptions(n.linkOptions(ps[0], ps[1]))
+			}
+		}
+	}
+	return n
+}
+
+// SetLinkLatency sets the latency of the links between two nodes, overriding
+// the latency set with SetLatency.
+func (n *Ensemble) SetLinkLatency(a, b api.Net, latency time.Duration) *Ensemble {
+	pa, pb := n.peerID(a), n.peerID(b)
+
+	if n.topology.latency == nil {
+		n.topology.latency = map[peerPair]time.Duration{}
+	}
+	n.topology.latency[pairOf(pa, pb)] = latency
+
+	for _, l := range n.mn.LinksBetweenPeers(pa, pb) {
+		l.SetOptions(n.linkOptions(pa, pb))
+	}
+	return n
+}
+
+// Partition splits the network into the given groups of nodes. Nodes in
+// different groups are unlinked and disconnected until Heal is called. Nodes
+// which aren't in any group stay linked to all the others.
+func (n *Ensemble) Partition(groups ...[]api.Net) *Ensemble {
+	require.Nil(n.t, n.topology.partition, "the network is already partitioned")
+
+	n.topology.partition = map[peer.ID]int{}
+	for i, group := range groups {
+		for _, node := range group {
+			n.topology.partition[n.peerID(node)] = i + 1
+		}
+	}
+
+	peers := n.mn.Peers()
+	for i, a := range peers {
+		for _, b := range peers[i+1:] {
+			if !n.partitioned(a, b) {
+				continue
+			}
+			if n.unlink(a, b) {
+				n.topology.cut = append(n.topology.cut, pairOf(a, b))
+			}
+		}
+	}
+	return n
+}
+
+// Heal undoes the partition of the network: the nodes get linked again, and
+// the connections closed by Partition are opened again.
+func (n *Ensemble) Heal() *Ensemble {
+	require.NotNil(n.t, n.topology.partition, "the network isn't partitioned")
+
+	part := n.topology.partition
+	n.topology.partition = nil
+
+	peers := n.mn.Peers()
+	for i, a := range peers {
+		for _, b := range peers[i+1:] {
+			ga, gb := part[a], part[b]
+			if ga == 0 || gb == 0 || ga == gb || n.isStopped(a) || n.isStopped(b) {
+				continue
+			}
+			n.link(a, b)
+		}
+	}
+
+	for _, p := range n.topology.cut {
+		if n.isStopped(p[0]) || n.isStopped(p[1]) {
+			continue
+		}
+		_, err := n.mn.ConnectPeers(p[0], p[1])
+		require.NoError(n.t, err)
+	}
+	n.topology.cut = nil
+
+	return n
+}
+
+// StopFullNode crashes a full node: it's cut from the network, and stopped.
+// The node keeps its repo, and can be started again with RestartFullNode.
+// Full nodes backing a miner, and full nodes accessed through RPC can't be
+// stopped.
+func (n *Ensemble) StopFullNode(full *TestFullNode) *Ensemble {
+	require.False(n.t, full.options.rpc, "can't stop a full node accessed through RPC")
+	for _, m := range n.active.miners {
+		require.False(n.t, m.FullNode == full, "can't stop the full node of miner %s", m.ActorAddr)
+	}
+
+	id := n.peerID(full)
+	stopped := stoppedNode{id: id}
+
+	for _, p := range n.mn.Peers() {
+		if p == id {
+			continue
+		}
+		if n.unlink(id, p) {
+			stopped.peers = append(stopped.peers, p)
+		}
+	}
+
+	require.NoError(n.t, full.Stop(context.Background()))
+
+	if n.topology.stopped == nil {
+		n.topology.stopped = map[*TestFullNode]stoppedNode{}
+	}
+	n.topology.stopped[full] = stopped
+
+	return n
+}
+
+// RestartFullNode starts a full node stopped with StopFullNode again, on the
+// same repo. The node gets linked back into the network, respecting the
+// current partition, and reconnects to the peers it had when it was stopped.
+func (n *Ensemble) RestartFullNode(full *TestFullNode) *Ensemble {
+	stopped, ok := n.topology.stopped[full]
+	require.True(n.t, ok, "full node wasn't stopped")
+	delete(n.topology.stopped, full)
+
+	n.startFullNode(full, node.Override(new(modules.Genesis), modules.LoadGenesis(n.genesisBlock.Bytes())))
+	require.Equal(n.t, stopped.id, n.peerID(full), "full node restarted with a different peer ID")
+
+	for _, p := range n.mn.Peers() {
+		if p == stopped.id || n.isStopped(p) || n.partitioned(stopped.id, p) {
+			continue
+		}
+		n.link(stopped.id, p)
+	}
+
+	for _, p := range stopped.peers {
+		if n.isStopped(p) || n.partitioned(stopped.id, p) {
+			continue
+		}
+		_, err := n.mn.ConnectPeers(stopped.id, p)
+		require.NoError(n.t, err)
+	}
+
+	return n
+}
+
+// StopMining stops the block miner of a miner, as if it crashed. The miner
+// stops producing blocks until it's given to BeginMining again.
+func (n *Ensemble) StopMining(m *TestMiner) *Ensemble {
+	bm, ok := n.active.bms[m]
+	require.True(n.t, ok, "miner %s isn't mining", m.ActorAddr)
+
+	bm.Stop()
+	delete(n.active.bms, m)
+
+	return n
+}
+
+// WaitConsensus waits until the full nodes agree on the chain, that is until
+// they have the same tipset right below the lowest of their heads. Without
+// nodes, it waits for all the running full nodes of the ensemble.
+func (n *Ensemble) WaitConsensus(ctx context.Context, nodes ...*TestFullNode) *types.TipSet {
+	if len(nodes) == 0 {
+		for _, full := range n.active.fullnodes {
+			if _, ok := n.topology.stopped[full]; !ok {
+				nodes = append(nodes, full)
+			}
+		}
+	}
+
+	for {
+		if ts := n.commonTipSet(ctx, nodes); ts != nil {
+			return ts
+		}
+
+		select {
+		case <-ctx.Done():
+			require.Fail(n.t, "full nodes didn't reach consensus")
+			return nil
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func (n *Ensemble) commonTipSet(ctx context.Context, nodes []*TestFullNode) *types.TipSet {
+	heads := make([]*types.TipSet, len(nodes))
+	for i, full := range nodes {
+		head, err := full.ChainHead(ctx)
+		require.NoError(n.t, err)
+		heads[i] = head
+	}
+
+	lowest := heads[0].Height()
+	for _, head := range heads[1:] {
+		if head.Height() < lowest {
+			lowest = head.Height()
+		}
+	}
+	height := lowest - 1
+	if height <= 0 {
+		return nil
+	}
+
+	var common *types.TipSet
+	for i, full := range nodes {
+		ts, err := full.ChainGetTipSetByHeight(ctx, height, heads[i].Key())
+		require.NoError(n.t, err)
+
+		if common == nil {
+			common = ts
+		} else if common.Key() != ts.Key() {
+			return nil
+		}
+	}
+	return common
+}
+
+// FaultSchedule injects faults into an ensemble at given epochs, following
+// the chain of an observer full node.
+type FaultSchedule struct {
+	t        *testing.T
+	observer *TestFullNode
+	faults   []scheduledFault
+}
+
+type scheduledFault struct {
+	epoch  abi.ChainEpoch
+	name   string
+	inject func()
+}
+
+// Faults returns an empty fault schedule, following the chain of the given
+// full node. The observer must not be stopped by the schedule.
+func (n *Ensemble) Faults(observer *TestFullNode) *FaultSchedule {
+	return &FaultSchedule{t: n.t, observer: observer}
+}
+
+// At schedules a fault at an epoch. Faults scheduled at the same epoch are
+// injected in the order they were scheduled.
+func (s *FaultSchedule) At(epoch abi.ChainEpoch, name string, inject func()) *FaultSchedule {
+	s.faults = append(s.faults, scheduledFault{epoch: epoch, name: name, inject: inject})
+	return s
+}
+
+// Run waits for the chain of the observer to reach the epoch of each fault,
+// and injects it. The ensemble must be mining.
+func (s *FaultSchedule) Run(ctx context.Context) {
+	sort.SliceStable(s.faults, func(i, j int) bool {
+		return s.faults[i].epoch < s.faults[j].epoch
+	})
+
+	for _, f := range s.faults {
+		ts := s.observer.WaitTillChain(ctx, HeightAtLeast(f.epoch))
+		s.t.Logf("injecting fault at epoch %d: %s", ts.Height(), f.name)
+		f.inject()
+	}
+}
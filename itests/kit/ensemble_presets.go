@@ -101,6 +101,38 @@ func EnsembleOneTwo(t *testing.T, opts ...interface{}) (*TestFullNode, *TestMine
 	return &full, &one, &two, ens
 }
 
+// EnsembleDevnet creates and starts an Ensemble with the given number of full
+// nodes and miners, all on the mocknet, so that faults can be injected into
+// the network. Miners are spread over the full nodes, the i-th miner using the
+// (i % fullNodes)-th full node as its chain daemon.
+// It does not interconnect nodes nor does it begin mining.
+//
+// This function supports passing both ensemble and node functional options.
+// Functional options are applied to all nodes.
+func EnsembleDevnet(t *testing.T, fullNodes, miners int, opts ...interface{}) ([]*TestFullNode, []*TestMiner, *Ensemble) {
+	opts = append(opts, DisableLibp2p())
+
+	eopts, nopts := siftOptions(t, opts)
+	mopts := append([]NodeOpt{WithSubsystems(SSealing, SSectorStorage, SMining)}, nopts...)
+
+	ens := NewEnsemble(t, eopts...)
+
+	fulls := make([]*TestFullNode, fullNodes)
+	for i := range fulls {
+		fulls[i] = new(TestFullNode)
+		ens.FullNode(fulls[i], nopts...)
+	}
+
+	ms := make([]*TestMiner, miners)
+	for i := range ms {
+		ms[i] = new(TestMiner)
+		ens.Miner(ms[i], fulls[i%fullNodes], mopts...)
+	}
+
+	ens.Start()
+	return fulls, ms, ens
+}
+
 func siftOptions(t *testing.T, opts []interface{}) (eopts []EnsembleOpt, nopts []NodeOpt) {
 	for _, v := range opts {
 		switch o := v.(type) {
@@ -16,6 +16,7 @@ import (
 	"github.com/filecoin-project/lotus/api/v1api"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/chain/wallet/key"
+	"github.com/filecoin-project/lotus/node/repo"
 )
 
 // TestFullNode represents a full node enrolled in an Ensemble.
@@ -28,7 +29,9 @@ type TestFullNode struct {
 	// API server is created for this Node.
 	ListenAddr multiaddr.Multiaddr
 	DefaultKey *key.Key
+	Stop       func(context.Context) error
 
+	repo    repo.Repo
 	options nodeOpts
 }
 
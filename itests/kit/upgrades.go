@@ -0,0 +1,184 @@
+package kit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/go-state-types/network"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/consensus/filcns"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// UpgradeAt returns the upgrade to a network version at the given height, with
+// the migration and pre-migrations of the default upgrade schedule.
+func UpgradeAt(nv network.Version, height abi.ChainEpoch) stmgr.Upgrade {
+	for _, u := range filcns.DefaultUpgradeSchedule() {
+		if u.Network == nv {
+			u.Height = height
+			return u
+		}
+	}
+	return stmgr.Upgrade{Network: nv, Height: height}
+}
+
+// NetworkUpgrades starts the network at the genesis network version, and
+// schedules the given upgrades. Migrations can't be skipped, so the upgrades
+// must go through every network version after the genesis one.
+func NetworkUpgrades(genesis network.Version, upgrades ...stmgr.Upgrade) EnsembleOpt {
+	return func(opts *ensembleOpts) error {
+		prev := genesis
+		for _, u := range upgrades {
+			if u.Network != prev+1 {
+				return xerrors.Errorf("upgrade to network version %d follows network version %d", u.Network, prev)
+			}
+			prev = u.Network
+		}
+
+		return UpgradeSchedule(append([]stmgr.Upgrade{{
+			Network: genesis,
+			Height:  -1,
+		}}, upgrades...)...)(opts)
+	}
+}
+
+// UpgradeCheck checks the state of the chain around a network upgrade. The
+// state checked is the parent state of the tipset.
+type UpgradeCheck func(ctx context.Context, t *testing.T, ts *types.TipSet)
+
+type upgradeInvariant struct {
+	name string
+	get  func(ctx context.Context, ts *types.TipSet) (interface{}, error)
+}
+
+// UpgradeScenario follows the chain of a full node through the network
+// upgrades scheduled in the ensemble. For each upgrade, it checks that the
+// network version changes at the upgrade height, that the builtin actors are
+// swapped for the ones of the new network version, and that the registered
+// invariants hold across the migration.
+type UpgradeScenario struct {
+	t        *testing.T
+	node     *TestFullNode
+	schedule stmgr.UpgradeSchedule
+
+	invariants []upgradeInvariant
+	before     map[network.Version][]UpgradeCheck
+	after      map[network.Version][]UpgradeCheck
+}
+
+// UpgradeScenario returns a scenario following the upgrades of the ensemble
+// on the chain of the given full node.
+func (n *Ensemble) UpgradeScenario(node *TestFullNode) *UpgradeScenario {
+	return &UpgradeScenario{
+		t:        n.t,
+		node:     node,
+		schedule: n.options.upgradeSchedule,
+		before:   map[network.Version][]UpgradeCheck{},
+		after:    map[network.Version][]UpgradeCheck{},
+	}
+}
+
+// Invariant registers a value which must be the same right before and right
+// after every upgrade.
+func (s *UpgradeScenario) Invariant(name string, get func(ctx context.Context, ts *types.TipSet) (interface{}, error)) *UpgradeScenario {
+	s.invariants = append(s.invariants, upgradeInvariant{name: name, get: get})
+	return s
+}
+
+// Before registers a check of the last state before the upgrade to a network
+// version.
+func (s *UpgradeScenario) Before(nv network.Version, check UpgradeCheck) *UpgradeScenario {
+	s.before[nv] = append(s.before[nv], check)
+	return s
+}
+
+// After registers a check of the first state after the upgrade to a network
+// version.
+func (s *UpgradeScenario) After(nv network.Version, check UpgradeCheck) *UpgradeScenario {
+	s.after[nv] = append(s.after[nv], check)
+	return s
+}
+
+// Run waits for the chain to go through all the scheduled upgrades, and checks
+// each of them. The ensemble must be mining.
+func (s *UpgradeScenario) Run(ctx context.Context) {
+	prev := build.GenesisNetworkVersion
+	for _, u := range s.schedule {
+		if u.Height < 0 {
+			prev = u.Network
+			continue
+		}
+
+		s.t.Logf("waiting for the upgrade to network version %d at epoch %d", u.Network, u.Height)
+		head := s.node.WaitTillChain(ctx, HeightAtLeast(u.Height+1))
+
+		// u.Height is the last epoch of the previous network version, the
+		// migration runs at the end of it
+		pre, err := s.node.ChainGetTipSetByHeight(ctx, u.Height, head.Key())
+		require.NoError(s.t, err)
+		post, err := s.node.ChainGetTipSetAfterHeight(ctx, u.Height+1, head.Key())
+		require.NoError(s.t, err)
+
+		s.checkNetwork(ctx, pre, prev)
+		s.checkNetwork(ctx, post, u.Network)
+
+		for _, inv := range s.invariants {
+			before, err := inv.get(ctx, pre)
+			require.NoError(s.t, err, "getting %s before the upgrade to network version %d", inv.name, u.Network)
+			after, err := inv.get(ctx, post)
+			require.NoError(s.t, err, "getting %s after the upgrade to network version %d", inv.name, u.Network)
+			require.Equal(s.t, before, after, "%s changed across the upgrade to network version %d", inv.name, u.Network)
+		}
+
+		for _, check := range s.before[u.Network] {
+			check(ctx, s.t, pre)
+		}
+		for _, check := range s.after[u.Network] {
+			check(ctx, s.t, post)
+		}
+
+		prev = u.Network
+	}
+}
+
+// checkNetwork checks that the state of the tipset is at the network version,
+// and that the builtin actors are the ones of the network version
+func (s *UpgradeScenario) checkNetwork(ctx context.Context, ts *types.TipSet, nv network.Version) {
+	v, err := s.node.StateNetworkVersion(ctx, ts.Key())
+	require.NoError(s.t, err)
+	require.Equal(s.t, nv, v, "network version at epoch %d", ts.Height())
+
+	codes, err := s.node.StateActorCodeCIDs(ctx, nv)
+	require.NoError(s.t, err)
+
+	for key, addr := range map[string]address.Address{
+		actors.SystemKey:   builtin.SystemActorAddr,
+		actors.InitKey:     builtin.InitActorAddr,
+		actors.RewardKey:   builtin.RewardActorAddr,
+		actors.CronKey:     builtin.CronActorAddr,
+		actors.PowerKey:    builtin.StoragePowerActorAddr,
+		actors.MarketKey:   builtin.StorageMarketActorAddr,
+		actors.VerifregKey: builtin.VerifiedRegistryActorAddr,
+	} {
+		act, err := s.node.StateGetActor(ctx, addr, ts.Key())
+		require.NoError(s.t, err)
+		require.Equal(s.t, codes[key], act.Code, "%s actor code at epoch %d, network version %d", key, ts.Height(), nv)
+	}
+
+	miners, err := s.node.StateListMiners(ctx, ts.Key())
+	require.NoError(s.t, err)
+	for _, maddr := range miners {
+		act, err := s.node.StateGetActor(ctx, maddr, ts.Key())
+		require.NoError(s.t, err)
+		require.Equal(s.t, codes[actors.MinerKey], act.Code, "miner %s actor code at epoch %d, network version %d", maddr, ts.Height(), nv)
+	}
+}
@@ -82,6 +82,13 @@ func RunMultisigTests(t *testing.T, client *kit.TestFullNode) {
 	// Expect transaction to be "AddSigner"
 	require.Regexp(t, regexp.MustCompile(`AddSigner`), out)
 
+	// msig inspect --simulate <msig>
+	out = clientCLI.RunCmd("msig", "inspect", "--simulate", msigRobustAddr)
+	fmt.Println(out)
+
+	// Expect the self-call adding a signer to succeed
+	require.Regexp(t, regexp.MustCompile(`AddSigner\(5\)\s+\S+\s+ok`), out)
+
 	// Approve adding the new address
 	// msig add-approve --from=<addr> <msig> <addr> 0 <addr> false
 	txnID := "0"
@@ -96,4 +103,13 @@ func RunMultisigTests(t *testing.T, client *kit.TestFullNode) {
 		"false",
 	)
 	fmt.Println(out)
+
+	// msig report <msig>
+	out = clientCLI.RunCmd("msig", "report", "--lookback=1000", msigRobustAddr)
+	fmt.Println(out)
+
+	// Expect the approval to have executed the transaction
+	require.Regexp(t, regexp.MustCompile(`Threshold: 2 / 4`), out)
+	require.Regexp(t, regexp.MustCompile(`Executed transactions \(last 1000 epochs\): 1`), out)
+	require.Regexp(t, regexp.MustCompile(`AddSigner`), out)
 }
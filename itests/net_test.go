@@ -273,6 +273,78 @@ func TestNetBlockIPAddr(t *testing.T) {
 	require.Equal(t, connectedness, network.Connected)
 }
 
+func TestNetAllowSubnet(t *testing.T) {
+	ctx := context.Background()
+
+	firstNode, secondNode, _, _ := kit.EnsembleTwoOne(t)
+
+	firstAddrInfo, _ := firstNode.NetAddrsListen(ctx)
+	secondAddrInfo, _ := secondNode.NetAddrsListen(ctx)
+
+	var secondNodeIPs, secondNodeSubnets []string
+	for _, addr := range secondAddrInfo.Addrs {
+		ip, err := manet.ToIP(addr)
+		if err != nil {
+			continue
+		}
+		secondNodeIPs = append(secondNodeIPs, ip.String())
+		if ip.To4() != nil {
+			secondNodeSubnets = append(secondNodeSubnets, fmt.Sprintf("%s/8", ip))
+		} else {
+			secondNodeSubnets = append(secondNodeSubnets, fmt.Sprintf("%s/64", ip))
+		}
+	}
+
+	// block the subnets of the second node, but allow its addresses
+	require.NoError(t, firstNode.NetBlockAdd(ctx, api.NetBlockList{IPSubnets: secondNodeSubnets}))
+	require.NoError(t, firstNode.NetAllowAdd(ctx, api.NetBlockList{IPAddrs: secondNodeIPs}))
+
+	list, err := firstNode.NetAllowList(ctx)
+	require.NoError(t, err)
+	require.ElementsMatch(t, secondNodeIPs, list.IPAddrs)
+
+	require.NoError(t, secondNode.NetConnect(ctx, firstAddrInfo), "allowed node should be able to connect")
+	require.Equal(t, network.Connected, getConnState(ctx, t, secondNode, firstAddrInfo.ID))
+
+	info, err := firstNode.NetPeerInfoDetailed(ctx, secondAddrInfo.ID)
+	require.NoError(t, err)
+	require.True(t, info.Connected)
+	require.True(t, info.Allowed)
+	require.False(t, info.Protected)
+
+	// once disallowed, the subnet block applies again
+	require.NoError(t, firstNode.NetAllowRemove(ctx, api.NetBlockList{IPAddrs: secondNodeIPs}))
+	require.NoError(t, secondNode.NetDisconnect(ctx, firstAddrInfo.ID))
+
+	require.Error(t, secondNode.NetConnect(ctx, firstAddrInfo), "shouldn't be able to connect to first node")
+	require.NotEqual(t, network.Connected, getConnState(ctx, t, secondNode, firstAddrInfo.ID))
+}
+
+func TestNetProtectPersisted(t *testing.T) {
+	ctx := context.Background()
+
+	firstNode, secondNode, _, _ := kit.EnsembleTwoOne(t)
+
+	secondAddrInfo, _ := secondNode.NetAddrsListen(ctx)
+
+	// peers are protected whether they are connected or not
+	require.NoError(t, firstNode.NetProtectAdd(ctx, []peer.ID{secondAddrInfo.ID}))
+
+	protected, err := firstNode.NetProtectList(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []peer.ID{secondAddrInfo.ID}, protected)
+
+	info, err := firstNode.NetPeerInfoDetailed(ctx, secondAddrInfo.ID)
+	require.NoError(t, err)
+	require.True(t, info.Protected)
+
+	require.NoError(t, firstNode.NetProtectRemove(ctx, []peer.ID{secondAddrInfo.ID}))
+
+	protected, err = firstNode.NetProtectList(ctx)
+	require.NoError(t, err)
+	require.Empty(t, protected)
+}
+
 func getConnState(ctx context.Context, t *testing.T, node *kit.TestFullNode, peer peer.ID) network.Connectedness {
 	//stm: @NETWORK_COMMON_CONNECTEDNESS_001
 	connState, err := node.NetConnectedness(ctx, peer)
@@ -0,0 +1,60 @@
+//stm: #integration
+package itests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/network"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/itests/kit"
+)
+
+func TestNetworkUpgrades(t *testing.T) {
+	//stm: @CHAIN_STATE_NETWORK_VERSION_001
+	kit.QuietMiningLogs()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client, miner, ens := kit.EnsembleMinimal(t,
+		kit.MockProofs(),
+		kit.NetworkUpgrades(network.Version14,
+			kit.UpgradeAt(network.Version15, 20),
+			kit.UpgradeAt(network.Version16, 40),
+		))
+	ens.InterconnectAll().BeginMining(10 * time.Millisecond)
+
+	maddr, err := miner.ActorAddress(ctx)
+	require.NoError(t, err)
+
+	caddr, err := client.WalletDefaultAddress(ctx)
+	require.NoError(t, err)
+
+	ens.UpgradeScenario(client).
+		Invariant("miner sectors", func(ctx context.Context, ts *types.TipSet) (interface{}, error) {
+			return client.StateMinerSectorCount(ctx, maddr, ts.Key())
+		}).
+		Invariant("client balance", func(ctx context.Context, ts *types.TipSet) (interface{}, error) {
+			act, err := client.StateGetActor(ctx, caddr, ts.Key())
+			if err != nil {
+				return nil, err
+			}
+			return act.Balance, nil
+		}).
+		After(network.Version16, func(ctx context.Context, t *testing.T, ts *types.TipSet) {
+			// the miner state written by the migration can be read back
+			mi, err := client.StateMinerInfo(ctx, maddr, ts.Key())
+			require.NoError(t, err)
+			require.NotEqual(t, address.Undef, mi.Worker)
+		}).
+		Run(ctx)
+
+	// the chain keeps going on the new actors
+	client.WaitTillChain(ctx, kit.HeightAtLeast(60))
+}
@@ -30,7 +30,6 @@ func TestWindowPostNoPreChecks(t *testing.T) {
 		kit.MockProofs(),
 		kit.ConstructorOpts(
 			node.Override(new(*wdpost.WindowPoStScheduler), modules.WindowPostScheduler(
-				config.DefaultStorageMiner().Fees,
 				config.ProvingConfig{
 					DisableWDPoStPreChecks: true,
 				},
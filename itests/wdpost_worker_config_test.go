@@ -41,7 +41,6 @@ func TestWindowPostNoBuiltinWindow(t *testing.T) {
 				return c
 			}),
 			node.Override(new(*wdpost.WindowPoStScheduler), modules.WindowPostScheduler(
-				config.DefaultStorageMiner().Fees,
 				config.ProvingConfig{
 					DisableWDPoStPreChecks: false,
 				},
@@ -98,7 +97,6 @@ func TestWindowPostNoBuiltinWindowWithWorker(t *testing.T) {
 				return c
 			}),
 			node.Override(new(*wdpost.WindowPoStScheduler), modules.WindowPostScheduler(
-				config.DefaultStorageMiner().Fees,
 				config.ProvingConfig{
 					DisableBuiltinWindowPoSt:  true,
 					DisableBuiltinWinningPoSt: false,
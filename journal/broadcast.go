@@ -0,0 +1,102 @@
+package journal
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+)
+
+var log = logging.Logger("journal")
+
+// subscriberBuffer is the number of events buffered for each subscriber;
+// events are dropped for subscribers which fall further behind
+const subscriberBuffer = 256
+
+// Broadcaster is a Journal which also sends the events it records to its
+// subscribers, so that they can be propagated to other processes.
+type Broadcaster struct {
+	Journal
+
+	lk   sync.Mutex
+	subs map[chan *Event]struct{}
+}
+
+var _ Journal = (*Broadcaster)(nil)
+
+func NewBroadcaster(j Journal) *Broadcaster {
+	return &Broadcaster{
+		Journal: j,
+		subs:    map[chan *Event]struct{}{},
+	}
+}
+
+func (b *Broadcaster) RecordEvent(evtType EventType, supplier func() interface{}) {
+	if !evtType.Enabled() {
+		return
+	}
+
+	data, ok := supply(evtType, supplier)
+	if !ok {
+		return
+	}
+	b.Journal.RecordEvent(evtType, func() interface{} {
+		return data
+	})
+
+	evt := &Event{
+		EventType: evtType,
+		Timestamp: time.Now(),
+		Data:      data,
+	}
+
+	b.lk.Lock()
+	defer b.lk.Unlock()
+
+	for ch := range b.subs {
+		select {
+		case ch <- evt:
+		default:
+			log.Warnw("dropping journal event for slow subscriber", "type", evtType)
+		}
+	}
+}
+
+// RecordMirrored records an event of another process, without sending it to
+// the subscribers
+func (b *Broadcaster) RecordMirrored(evtType EventType, supplier func() interface{}) {
+	b.Journal.RecordEvent(evtType, supplier)
+}
+
+// Subscribe returns the events recorded until the context is cancelled
+func (b *Broadcaster) Subscribe(ctx context.Context) <-chan *Event {
+	ch := make(chan *Event, subscriberBuffer)
+
+	b.lk.Lock()
+	b.subs[ch] = struct{}{}
+	b.lk.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		b.lk.Lock()
+		delete(b.subs, ch)
+		b.lk.Unlock()
+
+		close(ch)
+	}()
+
+	return ch
+}
+
+func supply(evtType EventType, supplier func() interface{}) (data interface{}, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Warnf("recovered from panic while recording journal event; type=%s, err=%v", evtType, r)
+			ok = false
+		}
+	}()
+
+	return supplier(), true
+}
@@ -0,0 +1,49 @@
+//stm: #unit
+package journal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordingJournal struct {
+	EventTypeRegistry
+	events []interface{}
+}
+
+func (r *recordingJournal) RecordEvent(evtType EventType, supplier func() interface{}) {
+	if evtType.Enabled() {
+		r.events = append(r.events, supplier())
+	}
+}
+
+func (r *recordingJournal) Close() error { return nil }
+
+func TestBroadcaster(t *testing.T) {
+	inner := &recordingJournal{EventTypeRegistry: NewEventTypeRegistry(DisabledEvents{{System: "sys", Event: "disabled"}})}
+	b := NewBroadcaster(inner)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	evts := b.Subscribe(ctx)
+
+	b.RecordEvent(b.RegisterEventType("sys", "evt"), func() interface{} { return 1 })
+	b.RecordEvent(b.RegisterEventType("sys", "disabled"), func() interface{} { return 2 })
+	b.RecordEvent(b.RegisterEventType("sys", "evt"), func() interface{} { panic("boom") })
+	b.RecordMirrored(b.RegisterEventType("remote", "evt"), func() interface{} { return 3 })
+
+	// everything is recorded in the journal, only the local events are
+	// broadcast
+	require.Equal(t, []interface{}{1, 3}, inner.events)
+
+	evt := <-evts
+	require.Equal(t, "sys", evt.System)
+	require.Equal(t, "evt", evt.Event)
+	require.Equal(t, 1, evt.Data)
+
+	cancel()
+	for range evts {
+		t.Fatal("unexpected event")
+	}
+}
@@ -0,0 +1,215 @@
+// Package apitls serves the JSON-RPC APIs over TLS, optionally requiring
+// client certificates (mTLS), and sets up the API clients to connect to them.
+package apitls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/multiformats/go-multiaddr"
+	"golang.org/x/xerrors"
+)
+
+var log = logging.Logger("apitls")
+
+// Files are the PEM files of the TLS config of an API listener
+type Files struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile, when set, requires the clients to present a certificate
+	// issued by one of the CAs in the file
+	ClientCAFile string
+}
+
+func (f Files) Enabled() bool {
+	return f.CertFile != "" || f.KeyFile != ""
+}
+
+type loaded struct {
+	files     Files
+	modTimes  []time.Time
+	cert      tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+func load(f Files) (*loaded, error) {
+	if f.CertFile == "" || f.KeyFile == "" {
+		return nil, xerrors.Errorf("both the certificate and the key files must be set")
+	}
+
+	l := &loaded{files: f}
+
+	modTimes, err := f.modTimes()
+	if err != nil {
+		return nil, err
+	}
+	l.modTimes = modTimes
+
+	l.cert, err = tls.LoadX509KeyPair(f.CertFile, f.KeyFile)
+	if err != nil {
+		return nil, xerrors.Errorf("loading certificate: %w", err)
+	}
+
+	if f.ClientCAFile != "" {
+		l.clientCAs, err = loadCertPool(x509.NewCertPool(), f.ClientCAFile)
+		if err != nil {
+			return nil, xerrors.Errorf("loading client CAs: %w", err)
+		}
+	}
+
+	return l, nil
+}
+
+func (f Files) modTimes() ([]time.Time, error) {
+	var out []time.Time
+	for _, p := range []string{f.CertFile, f.KeyFile, f.ClientCAFile} {
+		if p == "" {
+			continue
+		}
+		fi, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, fi.ModTime())
+	}
+	return out, nil
+}
+
+// loadCertPool adds the certificates of the PEM file to the pool
+func loadCertPool(pool *x509.CertPool, path string) (*x509.CertPool, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, xerrors.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// Validate checks that the files can be loaded
+func Validate(f Files) error {
+	_, err := load(f)
+	return err
+}
+
+// Server holds the TLS config of an API listener. The files are loaded again
+// when they change, which rotates the certificate without restarting the
+// listener; new connections use the new certificate.
+type Server struct {
+	lk  sync.Mutex
+	cur *loaded
+}
+
+func NewServer(f Files) (*Server, error) {
+	l, err := load(f)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{cur: l}, nil
+}
+
+// Update switches to other files, when they can be loaded
+func (s *Server) Update(f Files) error {
+	l, err := load(f)
+	if err != nil {
+		return err
+	}
+
+	s.lk.Lock()
+	s.cur = l
+	s.lk.Unlock()
+
+	log.Infow("updated API TLS config", "cert", f.CertFile, "clientCAs", f.ClientCAFile)
+	return nil
+}
+
+// current returns the loaded files, loading them again when they changed. The
+// previous files are kept when the changed ones fail to load, eg. while they
+// are being replaced.
+func (s *Server) current() *loaded {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	modTimes, err := s.cur.files.modTimes()
+	if err != nil || equalTimes(modTimes, s.cur.modTimes) {
+		return s.cur
+	}
+
+	l, err := load(s.cur.files)
+	if err != nil {
+		log.Warnw("reloading API TLS files failed, using the previous ones", "error", err)
+		return s.cur
+	}
+
+	log.Infow("reloaded API TLS files", "cert", l.files.CertFile, "clientCAs", l.files.ClientCAFile)
+	s.cur = l
+	return l
+}
+
+func equalTimes(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// ServerConfig returns the config of the listener, nil for a nil Server so
+// that the API is served without TLS
+func (s *Server) ServerConfig() *tls.Config {
+	if s == nil {
+		return nil
+	}
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			l := s.current()
+
+			cfg := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{l.cert},
+			}
+			if l.clientCAs != nil {
+				cfg.ClientAuth = tls.RequireAndVerifyClientCert
+				cfg.ClientCAs = l.clientCAs
+			}
+			return cfg, nil
+		},
+	}
+}
+
+// Endpoint returns the API endpoint of a TLS listener, which tells the clients
+// to connect with TLS
+func Endpoint(ep multiaddr.Multiaddr) multiaddr.Multiaddr {
+	if IsTLSEndpoint(ep) {
+		return ep
+	}
+
+	if rest, last := multiaddr.SplitLast(ep); last != nil && last.Protocol().Code == multiaddr.P_HTTP {
+		ep = rest
+	}
+	return ep.Encapsulate(multiaddr.StringCast("/tls/http"))
+}
+
+// IsTLSEndpoint returns whether the API endpoint is served with TLS
+func IsTLSEndpoint(ep multiaddr.Multiaddr) bool {
+	for _, p := range ep.Protocols() {
+		switch p.Code {
+		case multiaddr.P_TLS, multiaddr.P_HTTPS, multiaddr.P_WSS:
+			return true
+		}
+	}
+	return false
+}
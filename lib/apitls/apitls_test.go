@@ -0,0 +1,197 @@
+//stm: #unit
+package apitls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns the PEM certificate and key of a certificate issued by the CA
+func (ca *testCA) issue(t *testing.T, serial int64, name string) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	kb, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: kb})
+}
+
+func writeFile(t *testing.T, path string, b []byte, mtime time.Time) {
+	require.NoError(t, ioutil.WriteFile(path, b, 0600))
+	require.NoError(t, os.Chtimes(path, mtime, mtime))
+}
+
+func TestServerTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+
+	f := Files{
+		CertFile:     filepath.Join(dir, "cert.pem"),
+		KeyFile:      filepath.Join(dir, "key.pem"),
+		ClientCAFile: filepath.Join(dir, "ca.pem"),
+	}
+
+	mtime := time.Now().Add(-time.Minute)
+	cert, key := ca.issue(t, 2, "server 1")
+	writeFile(t, f.CertFile, cert, mtime)
+	writeFile(t, f.KeyFile, key, mtime)
+	writeFile(t, f.ClientCAFile, ca.pem, mtime)
+
+	srv, err := NewServer(f)
+	require.NoError(t, err)
+
+	l, err := tls.Listen("tcp", "127.0.0.1:0", srv.ServerConfig())
+	require.NoError(t, err)
+	defer l.Close() // nolint:errcheck
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_ = c.(*tls.Conn).Handshake()
+				_ = c.Close()
+			}()
+		}
+	}()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	clientCert, clientKey := ca.issue(t, 3, "client")
+	kp, err := tls.X509KeyPair(clientCert, clientKey)
+	require.NoError(t, err)
+
+	dial := func(certs ...tls.Certificate) (string, error) {
+		c, err := tls.Dial("tcp", l.Addr().String(), &tls.Config{RootCAs: roots, Certificates: certs})
+		if err != nil {
+			return "", err
+		}
+		defer c.Close() // nolint:errcheck
+
+		// the client certificate is checked after the handshake completes on
+		// the client side
+		if _, err := c.Read(make([]byte, 1)); err != nil && err != io.EOF {
+			return "", err
+		}
+		return c.ConnectionState().PeerCertificates[0].Subject.CommonName, nil
+	}
+
+	name, err := dial(kp)
+	require.NoError(t, err)
+	require.Equal(t, "server 1", name)
+
+	_, err = dial()
+	require.Error(t, err)
+
+	// the rotated certificate is used by new connections
+	cert, key = ca.issue(t, 4, "server 2")
+	writeFile(t, f.CertFile, cert, time.Now())
+	writeFile(t, f.KeyFile, key, time.Now())
+
+	name, err = dial(kp)
+	require.NoError(t, err)
+	require.Equal(t, "server 2", name)
+
+	// broken files keep the previous certificate
+	writeFile(t, f.KeyFile, []byte("not a key"), time.Now().Add(time.Minute))
+
+	name, err = dial(kp)
+	require.NoError(t, err)
+	require.Equal(t, "server 2", name)
+	require.Error(t, Validate(f))
+}
+
+func TestEndpoint(t *testing.T) {
+	for in, out := range map[string]string{
+		"/ip4/127.0.0.1/tcp/1234/http":     "/ip4/127.0.0.1/tcp/1234/tls/http",
+		"/ip4/127.0.0.1/tcp/1234":          "/ip4/127.0.0.1/tcp/1234/tls/http",
+		"/ip4/127.0.0.1/tcp/1234/tls/http": "/ip4/127.0.0.1/tcp/1234/tls/http",
+	} {
+		ep := Endpoint(multiaddr.StringCast(in))
+		require.Equal(t, out, ep.String())
+		require.True(t, IsTLSEndpoint(ep))
+	}
+
+	require.False(t, IsTLSEndpoint(multiaddr.StringCast("/ip4/127.0.0.1/tcp/1234/http")))
+}
+
+func TestClientConfigCAs(t *testing.T) {
+	ca := newTestCA(t)
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	writeFile(t, path, ca.pem, time.Now())
+	t.Setenv(EnvCA, path)
+
+	cfg, err := clientConfig()
+	require.NoError(t, err)
+
+	// the CA is trusted in addition to the system CAs
+	sys, err := x509.SystemCertPool()
+	if err != nil {
+		sys = x509.NewCertPool()
+	}
+	require.Len(t, cfg.RootCAs.Subjects(), len(sys.Subjects())+1) //nolint:staticcheck
+
+	_, err = ca.cert.Verify(x509.VerifyOptions{Roots: cfg.RootCAs})
+	require.NoError(t, err)
+}
@@ -0,0 +1,107 @@
+package apitls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"golang.org/x/xerrors"
+)
+
+// Environment of the API clients connecting to TLS listeners
+const (
+	// EnvCA is a PEM file with the CAs trusted to issue the API certificates,
+	// in addition to the system ones
+	EnvCA = "LOTUS_API_TLS_CA"
+	// EnvClientCert and EnvClientKey are the PEM files of the client
+	// certificate, presented to the APIs which require one
+	EnvClientCert = "LOTUS_API_TLS_CLIENT_CERT"
+	EnvClientKey  = "LOTUS_API_TLS_CLIENT_KEY"
+)
+
+var (
+	clientOnce sync.Once
+	clientErr  error
+	clientSet  bool
+)
+
+// SetupClient configures the websocket JSON-RPC client and the default HTTP
+// client of the process with the TLS environment of the API clients, once.
+func SetupClient() error {
+	clientOnce.Do(func() {
+		cfg, err := clientConfig()
+		if err != nil {
+			clientErr = xerrors.Errorf("setting up API TLS client: %w", err)
+			return
+		}
+		if cfg == nil {
+			return
+		}
+
+		websocket.DefaultDialer.TLSClientConfig = cfg
+		if t, ok := http.DefaultTransport.(*http.Transport); ok {
+			t.TLSClientConfig = cfg
+		}
+		clientSet = true
+	})
+	return clientErr
+}
+
+func clientConfig() (*tls.Config, error) {
+	ca, cert, key := os.Getenv(EnvCA), os.Getenv(EnvClientCert), os.Getenv(EnvClientKey)
+	if ca == "" && cert == "" && key == "" {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if ca != "" {
+		// the CAs are trusted in addition to the system ones, not instead of them
+		sys, err := x509.SystemCertPool()
+		if err != nil {
+			log.Warnw("loading system CAs, only trusting the API CAs", "error", err)
+			sys = x509.NewCertPool()
+		}
+
+		pool, err := loadCertPool(sys, ca)
+		if err != nil {
+			return nil, xerrors.Errorf("loading CAs: %w", err)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if cert != "" || key != "" {
+		if cert == "" || key == "" {
+			return nil, xerrors.Errorf("both %s and %s must be set", EnvClientCert, EnvClientKey)
+		}
+
+		c, err := tls.LoadX509KeyPair(cert, key)
+		if err != nil {
+			return nil, xerrors.Errorf("loading client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{c}
+	}
+
+	return cfg, nil
+}
+
+// RPCURL returns the URL to connect a JSON-RPC client to. The JSON-RPC HTTP
+// client doesn't use the TLS environment, so https URLs are switched to
+// websockets when it is set.
+func RPCURL(u string) string {
+	if err := SetupClient(); err != nil || !clientSet {
+		return u
+	}
+
+	pu, err := url.Parse(u)
+	if err != nil || pu.Scheme != "https" {
+		return u
+	}
+
+	pu.Scheme = "wss"
+	return pu.String()
+}
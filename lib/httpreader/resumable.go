@@ -0,0 +1,175 @@
+package httpreader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+)
+
+var log = logging.Logger("httpreader")
+
+// ResumableReader reads a http resource with GET requests. When the transfer
+// fails mid-way, it is resumed from the current offset with a range request, up
+// to Retries times in a row.
+type ResumableReader struct {
+	ctx    context.Context
+	url    string
+	header http.Header
+
+	// Retries is the number of consecutive failed attempts after which reading
+	// fails
+	Retries int
+	// Backoff is the delay before the first retry, increased with each
+	// consecutive failure
+	Backoff time.Duration
+
+	client *http.Client
+	reader io.ReadCloser
+
+	offset   int64
+	size     int64 // -1 when unknown
+	failures int
+}
+
+func NewResumableReader(ctx context.Context, url string, header http.Header) *ResumableReader {
+	return &ResumableReader{
+		ctx:     ctx,
+		url:     url,
+		header:  header,
+		Retries: 5,
+		Backoff: time.Second,
+		client:  &http.Client{},
+		size:    -1,
+	}
+}
+
+// fatalError is an error which isn't worth retrying, e.g. a client error status
+type fatalError struct {
+	error
+}
+
+func (r *ResumableReader) Read(p []byte) (int, error) {
+	for {
+		if r.reader == nil {
+			if err := r.open(); err != nil {
+				if err := r.retry(err); err != nil {
+					return 0, err
+				}
+				continue
+			}
+		}
+
+		n, err := r.reader.Read(p)
+		r.offset += int64(n)
+		if n > 0 {
+			r.failures = 0
+		}
+
+		switch {
+		case err == nil:
+			return n, nil
+		case err == io.EOF && (r.size < 0 || r.offset >= r.size):
+			return n, io.EOF
+		case err == io.EOF:
+			err = io.ErrUnexpectedEOF
+		}
+
+		_ = r.reader.Close()
+		r.reader = nil
+
+		if n > 0 {
+			// resume on the next read
+			log.Warnw("http transfer interrupted", "url", r.url, "offset", r.offset, "error", err)
+			return n, nil
+		}
+		if err := r.retry(err); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// retry waits before the next attempt, or returns the error when the attempts
+// are exhausted
+func (r *ResumableReader) retry(err error) error {
+	var fe fatalError
+	if xerrors.As(err, &fe) {
+		return fe.error
+	}
+
+	r.failures++
+	if r.failures > r.Retries {
+		return xerrors.Errorf("reading %s at offset %d failed after %d attempts: %w", r.url, r.offset, r.failures, err)
+	}
+
+	log.Warnw("retrying http transfer", "url", r.url, "offset", r.offset, "attempt", r.failures, "error", err)
+
+	select {
+	case <-time.After(r.Backoff * time.Duration(r.failures)):
+		return nil
+	case <-r.ctx.Done():
+		return r.ctx.Err()
+	}
+}
+
+func (r *ResumableReader) open() error {
+	req, err := http.NewRequestWithContext(r.ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return fatalError{xerrors.Errorf("creating request: %w", err)}
+	}
+	for k, v := range r.header {
+		req.Header[k] = v
+	}
+	if r.offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", r.offset))
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		if r.ctx.Err() != nil {
+			return fatalError{r.ctx.Err()}
+		}
+		return err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && r.offset > 0:
+	case resp.StatusCode == http.StatusOK:
+		if r.size < 0 {
+			r.size = resp.ContentLength
+		}
+
+		if r.offset > 0 {
+			// the server doesn't support range requests, skip what was already read
+			if _, err := io.CopyN(io.Discard, resp.Body, r.offset); err != nil {
+				_ = resp.Body.Close()
+				return xerrors.Errorf("skipping to offset %d: %w", r.offset, err)
+			}
+		}
+	default:
+		_ = resp.Body.Close()
+		err := xerrors.Errorf("unexpected http status %d", resp.StatusCode)
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+			return fatalError{err}
+		}
+		return err
+	}
+
+	r.reader = resp.Body
+	return nil
+}
+
+func (r *ResumableReader) Close() error {
+	if r.reader != nil {
+		err := r.reader.Close()
+		r.reader = nil
+		return err
+	}
+	return nil
+}
+
+var _ io.ReadCloser = &ResumableReader{}
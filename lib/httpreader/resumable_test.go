@@ -0,0 +1,74 @@
+//stm: #unit
+package httpreader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResumableReader(t *testing.T) {
+	data := []byte(strings.Repeat("0123456789", 1000))
+
+	var requests, ranged int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		require.Equal(t, "secret", r.Header.Get("X-Token"))
+
+		start := 0
+		if rh := r.Header.Get("Range"); rh != "" {
+			ranged++
+			var err error
+			start, err = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rh, "bytes="), "-"))
+			require.NoError(t, err)
+
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(data)-1, len(data)))
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)-start))
+			w.WriteHeader(http.StatusPartialContent)
+		} else {
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			w.WriteHeader(http.StatusOK)
+		}
+
+		// send at most 3000 bytes per request, cutting the connection short
+		end := start + 3000
+		if end > len(data) {
+			end = len(data)
+		}
+		_, _ = w.Write(data[start:end])
+	}))
+	defer srv.Close()
+
+	hr := NewResumableReader(context.Background(), srv.URL, http.Header{"X-Token": []string{"secret"}})
+	hr.Backoff = time.Millisecond
+
+	got, err := io.ReadAll(hr)
+	require.NoError(t, err)
+	require.Equal(t, data, got)
+	require.Equal(t, 4, requests)
+	require.Equal(t, 3, ranged)
+}
+
+func TestResumableReaderClientError(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	hr := NewResumableReader(context.Background(), srv.URL, nil)
+	hr.Backoff = time.Millisecond
+
+	_, err := io.ReadAll(hr)
+	require.Error(t, err)
+	require.Equal(t, 1, requests)
+}
@@ -1,6 +1,11 @@
 package lotuslog
 
-import logging "github.com/ipfs/go-log/v2"
+import (
+	"os"
+
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+)
 
 func SetLevelsFromConfig(l map[string]string) {
 	for sys, level := range l {
@@ -9,3 +14,41 @@ func SetLevelsFromConfig(l map[string]string) {
 		}
 	}
 }
+
+// SetFormat sets the format of the log output to color, nocolor or json,
+// unless it is set with the GOLOG_LOG_FMT environment variable. The default
+// levels are set up again, so the levels from the config must be applied
+// after it.
+func SetFormat(format string) error {
+	if _, set := os.LookupEnv("GOLOG_LOG_FMT"); set || format == "" {
+		return nil
+	}
+
+	f, err := ParseFormat(format)
+	if err != nil {
+		return err
+	}
+
+	cfg := logging.GetConfig()
+	if cfg.Format == f {
+		return nil
+	}
+	cfg.Format = f
+	logging.SetupLogging(cfg)
+	SetupLogLevels()
+	return nil
+}
+
+// ParseFormat parses the name of a log format: color, nocolor or json
+func ParseFormat(format string) (logging.LogFormat, error) {
+	switch format {
+	case "color":
+		return logging.ColorizedOutput, nil
+	case "nocolor":
+		return logging.PlaintextOutput, nil
+	case "json":
+		return logging.JSONOutput, nil
+	default:
+		return 0, xerrors.Errorf("unknown log format %q", format)
+	}
+}
@@ -52,6 +52,10 @@ type PeerMgr struct {
 	h   host.Host
 	dht *dht.IpfsDHT
 
+	// disableDiscovery keeps the peer manager from looking for new peers through
+	// the dht when it is short of peers
+	disableDiscovery bool
+
 	notifee *net.NotifyBundle
 	emitter event.Emitter
 
@@ -70,11 +74,12 @@ const (
 	RemoveFilPeerEvt
 )
 
-func NewPeerMgr(lc fx.Lifecycle, h host.Host, dht *dht.IpfsDHT, bootstrap dtypes.BootstrapPeers) (*PeerMgr, error) {
+func NewPeerMgr(lc fx.Lifecycle, h host.Host, dht *dht.IpfsDHT, bootstrap dtypes.BootstrapPeers, disc dtypes.DisablePeerDiscovery) (*PeerMgr, error) {
 	pm := &PeerMgr{
-		h:             h,
-		dht:           dht,
-		bootstrappers: bootstrap,
+		h:                h,
+		dht:              dht,
+		bootstrappers:    bootstrap,
+		disableDiscovery: bool(disc),
 
 		peers:     make(map[peer.ID]time.Duration),
 		expanding: make(chan struct{}, 1),
@@ -221,6 +226,11 @@ func (pmgr *PeerMgr) doExpand(ctx context.Context) {
 		return
 	}
 
+	// the dht is nil when disabled
+	if pmgr.disableDiscovery || pmgr.dht == nil {
+		return
+	}
+
 	// if we already have some peers and need more, the dht is really good at connecting to most peers. Use that for now until something better comes along.
 	if err := pmgr.dht.Bootstrap(ctx); err != nil {
 		log.Warnf("dht bootstrapping failed: %s", err)
@@ -0,0 +1,142 @@
+package rpccbor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+)
+
+// Error is the error of a call
+type Error struct {
+	Code    int64
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Call calls a method over HTTP asking for a CBOR response, and decodes the
+// result into out. The params are encoded with JSON.
+func Call(ctx context.Context, addr string, header http.Header, method string, out cbg.CBORUnmarshaler, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return xerrors.Errorf("encoding request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, addr, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", ContentType)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint
+
+	if resp.Header.Get("Content-Type") != ContentType {
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return xerrors.Errorf("unexpected response (status %d, content type %q): %s", resp.StatusCode, resp.Header.Get("Content-Type"), b)
+	}
+
+	return readResponse(bufio.NewReader(resp.Body), out)
+}
+
+func readResponse(br io.Reader, out cbg.CBORUnmarshaler) error {
+	maj, n, err := cbg.CborReadHeader(br)
+	if err != nil {
+		return xerrors.Errorf("reading response: %w", err)
+	}
+	if maj != cbg.MajMap {
+		return xerrors.Errorf("response isn't a map")
+	}
+
+	for i := uint64(0); i < n; i++ {
+		key, err := cbg.ReadString(br)
+		if err != nil {
+			return xerrors.Errorf("reading response key: %w", err)
+		}
+
+		switch key {
+		case "result":
+			if err := out.UnmarshalCBOR(br); err != nil {
+				return xerrors.Errorf("decoding result: %w", err)
+			}
+		case "error":
+			return readError(br)
+		default:
+			var skip cbg.Deferred
+			if err := skip.UnmarshalCBOR(br); err != nil {
+				return xerrors.Errorf("reading response field %s: %w", key, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func readError(br io.Reader) error {
+	maj, n, err := cbg.CborReadHeader(br)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajMap {
+		return xerrors.Errorf("error isn't a map")
+	}
+
+	var e Error
+	for i := uint64(0); i < n; i++ {
+		key, err := cbg.ReadString(br)
+		if err != nil {
+			return err
+		}
+
+		switch key {
+		case "code":
+			maj, v, err := cbg.CborReadHeader(br)
+			if err != nil {
+				return err
+			}
+			switch maj {
+			case cbg.MajUnsignedInt:
+				e.Code = int64(v)
+			case cbg.MajNegativeInt:
+				e.Code = -1 - int64(v)
+			default:
+				return xerrors.Errorf("unexpected error code type %d", maj)
+			}
+		case "message":
+			if e.Message, err = cbg.ReadString(br); err != nil {
+				return err
+			}
+		default:
+			var skip cbg.Deferred
+			if err := skip.UnmarshalCBOR(br); err != nil {
+				return err
+			}
+		}
+	}
+
+	return &e
+}
@@ -0,0 +1,158 @@
+package rpccbor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+)
+
+var bytesType = reflect.TypeOf([]byte(nil))
+
+// EncodeValue writes the CBOR encoding of a value: its own CBOR encoding when it
+// has one, a byte string for byte slices, and otherwise the CBOR image of its
+// JSON encoding
+func EncodeValue(w io.Writer, v reflect.Value) error {
+	if !v.IsValid() {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			_, err := w.Write(cbg.CborNull)
+			return err
+		}
+	}
+
+	if m, ok := v.Interface().(cbg.CBORMarshaler); ok {
+		return m.MarshalCBOR(w)
+	}
+	if v.Kind() != reflect.Ptr {
+		// values with a pointer receiver encoder
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		if m, ok := p.Interface().(cbg.CBORMarshaler); ok {
+			return m.MarshalCBOR(w)
+		}
+	}
+
+	if v.Type() == bytesType {
+		b := v.Bytes()
+		if err := cbg.WriteMajorTypeHeader(w, cbg.MajByteString, uint64(len(b))); err != nil {
+			return err
+		}
+		_, err := w.Write(b)
+		return err
+	}
+
+	b, err := json.Marshal(v.Interface())
+	if err != nil {
+		return xerrors.Errorf("encoding JSON: %w", err)
+	}
+	return TranscodeJSON(w, b)
+}
+
+// TranscodeJSON writes the CBOR image of a JSON value: objects are encoded as
+// maps keeping the order of the keys, and numbers as integers when they are
+// integers
+func TranscodeJSON(w io.Writer, b []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var buf bytes.Buffer
+	if err := transcode(dec, &buf); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func transcode(dec *json.Decoder, w *bytes.Buffer) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		var body bytes.Buffer
+		var n uint64
+		for dec.More() {
+			if t == '{' {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				if err := writeString(&body, key.(string)); err != nil {
+					return err
+				}
+			}
+			if err := transcode(dec, &body); err != nil {
+				return err
+			}
+			n++
+		}
+		if _, err := dec.Token(); err != nil { // closing delimiter
+			return err
+		}
+
+		maj := byte(cbg.MajArray)
+		if t == '{' {
+			maj = cbg.MajMap
+		}
+		if err := cbg.WriteMajorTypeHeader(w, maj, n); err != nil {
+			return err
+		}
+		_, err := w.Write(body.Bytes())
+		return err
+	case string:
+		return writeString(w, t)
+	case json.Number:
+		return writeNumber(w, t)
+	case bool:
+		return cbg.WriteBool(w, t)
+	case nil:
+		_, err := w.Write(cbg.CborNull)
+		return err
+	default:
+		return xerrors.Errorf("unexpected JSON token %v", tok)
+	}
+}
+
+func writeString(w io.Writer, s string) error {
+	if err := cbg.WriteMajorTypeHeader(w, cbg.MajTextString, uint64(len(s))); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+func writeNumber(w io.Writer, n json.Number) error {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		if i >= 0 {
+			return cbg.WriteMajorTypeHeader(w, cbg.MajUnsignedInt, uint64(i))
+		}
+		return cbg.WriteMajorTypeHeader(w, cbg.MajNegativeInt, uint64(-i-1))
+	}
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		return cbg.WriteMajorTypeHeader(w, cbg.MajUnsignedInt, u)
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return err
+	}
+	var b [9]byte
+	b[0] = 0xfb // float64
+	binary.BigEndian.PutUint64(b[1:], math.Float64bits(f))
+	_, err = w.Write(b[:])
+	return err
+}
@@ -0,0 +1,278 @@
+// Package rpccbor encodes the JSON-RPC responses with CBOR for the HTTP clients
+// asking for it with the Accept header, which cuts the size of large responses,
+// and their encoding cost for the values with a CBOR encoding.
+//
+// The request stays a JSON-RPC request. The response is a CBOR map with the
+// keys of the JSON-RPC response. Results with a CBOR encoding (eg. tipsets,
+// block headers, messages) are encoded with it, byte slices are encoded as byte
+// strings, and the other results as the CBOR image of their JSON encoding.
+// Methods returning channels (eg. ChainExport) respond with a CBOR sequence of
+// the channel values, streamed as they are received.
+package rpccbor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+
+	logging "github.com/ipfs/go-log/v2"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	"github.com/filecoin-project/go-jsonrpc"
+)
+
+var log = logging.Logger("rpccbor")
+
+const (
+	ContentType = "application/cbor"
+	// ContentTypeSeq is the content type of the responses of methods returning
+	// channels
+	ContentTypeSeq = "application/cbor-seq"
+)
+
+const (
+	rpcParseError    = -32700
+	rpcInvalidParams = -32602
+	rpcCallError     = 1
+)
+
+var (
+	contextType = reflect.TypeOf(new(context.Context)).Elem()
+	errorType   = reflect.TypeOf(new(error)).Elem()
+)
+
+// Accepts returns whether the request asks for a CBOR response
+func Accepts(r *http.Request) bool {
+	for _, v := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(v, ",") {
+			switch strings.TrimSpace(strings.SplitN(part, ";", 2)[0]) {
+			case ContentType, ContentTypeSeq:
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type method struct {
+	fn     reflect.Value
+	hasCtx bool
+	params []reflect.Type
+	// the method returns a value before the error
+	hasValue bool
+}
+
+type handler struct {
+	next    http.Handler
+	methods map[string]method
+}
+
+// Handler serves the HTTP requests asking for CBOR responses by calling the
+// methods of hnd, registered in the namespace like with the JSON-RPC server.
+// The other requests, batches and notifications are passed to next, which
+// responds with JSON.
+func Handler(next http.Handler, namespace string, hnd interface{}) http.Handler {
+	h := &handler{next: next, methods: map[string]method{}}
+
+	v := reflect.ValueOf(hnd)
+	for i := 0; i < v.NumMethod(); i++ {
+		fn := v.Method(i)
+		ft := fn.Type()
+
+		m := method{fn: fn}
+		in := 0
+		if ft.NumIn() > 0 && ft.In(0) == contextType {
+			m.hasCtx = true
+			in = 1
+		}
+		for ; in < ft.NumIn(); in++ {
+			m.params = append(m.params, ft.In(in))
+		}
+
+		switch {
+		case ft.NumOut() == 1 && ft.Out(0) == errorType:
+		case ft.NumOut() == 2 && ft.Out(1) == errorType:
+			m.hasValue = true
+		default:
+			continue
+		}
+
+		h.methods[namespace+"."+v.Type().Method(i).Name] = m
+	}
+
+	return h
+}
+
+type request struct {
+	ID     *json.RawMessage  `json:"id,omitempty"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !Accepts(r) {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, jsonrpc.DEFAULT_MAX_REQUEST_SIZE))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("reading request: %s", err), http.StatusBadRequest)
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	var req request
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || trimmed[0] != '{' || json.Unmarshal(trimmed, &req) != nil || req.ID == nil {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	m, ok := h.methods[req.Method]
+	if !ok {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	h.call(w, r, *req.ID, req, m)
+}
+
+func (h *handler) call(w http.ResponseWriter, r *http.Request, id json.RawMessage, req request, m method) {
+	if len(req.Params) != len(m.params) {
+		writeError(w, http.StatusInternalServerError, id, rpcInvalidParams, fmt.Sprintf("wrong param count (method '%s'): %d != %d", req.Method, len(req.Params), len(m.params)))
+		return
+	}
+
+	var args []reflect.Value
+	if m.hasCtx {
+		args = append(args, reflect.ValueOf(r.Context()))
+	}
+	for i, pt := range m.params {
+		pv := reflect.New(pt)
+		if err := json.Unmarshal(req.Params[i], pv.Interface()); err != nil {
+			writeError(w, http.StatusInternalServerError, id, rpcParseError, fmt.Sprintf("unmarshaling params for '%s' (param: %T): %s", req.Method, pv.Interface(), err))
+			return
+		}
+		args = append(args, pv.Elem())
+	}
+
+	out := m.fn.Call(args)
+
+	if err := out[len(out)-1]; !err.IsNil() {
+		log.Warnf("error in RPC call to '%s': %+v", req.Method, err.Interface())
+		writeError(w, http.StatusOK, id, rpcCallError, err.Interface().(error).Error())
+		return
+	}
+
+	var res reflect.Value
+	if m.hasValue {
+		res = out[0]
+	}
+
+	if res.IsValid() && res.Kind() == reflect.Chan {
+		h.stream(w, r, req.Method, res)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := writeResponseHeader(&buf, id, "result"); err != nil {
+		log.Errorf("encoding response of '%s': %s", req.Method, err)
+		return
+	}
+	if err := EncodeValue(&buf, res); err != nil {
+		writeError(w, http.StatusInternalServerError, id, rpcCallError, fmt.Sprintf("encoding result of '%s': %s", req.Method, err))
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Debugf("writing response of '%s': %s", req.Method, err)
+	}
+}
+
+// stream writes the values of the channel until it is closed or the request is
+// done
+func (h *handler) stream(w http.ResponseWriter, r *http.Request, name string, ch reflect.Value) {
+	w.Header().Set("Content-Type", ContentTypeSeq)
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(r.Context().Done())},
+		{Dir: reflect.SelectRecv, Chan: ch},
+	}
+
+	var buf bytes.Buffer
+	for {
+		chosen, v, ok := reflect.Select(cases)
+		if chosen == 0 || !ok {
+			return
+		}
+
+		buf.Reset()
+		if err := EncodeValue(&buf, v); err != nil {
+			log.Errorf("encoding channel value of '%s': %s", name, err)
+			return
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			log.Debugf("writing channel value of '%s': %s", name, err)
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// writeResponseHeader writes the start of the response map, up to the key of
+// the result or the error
+func writeResponseHeader(w io.Writer, id json.RawMessage, key string) error {
+	if err := cbg.WriteMajorTypeHeader(w, cbg.MajMap, 3); err != nil {
+		return err
+	}
+	if err := writeString(w, "jsonrpc"); err != nil {
+		return err
+	}
+	if err := writeString(w, "2.0"); err != nil {
+		return err
+	}
+	if err := writeString(w, "id"); err != nil {
+		return err
+	}
+	if err := TranscodeJSON(w, id); err != nil {
+		return err
+	}
+	return writeString(w, key)
+}
+
+func writeError(w http.ResponseWriter, status int, id json.RawMessage, code int, msg string) {
+	var buf bytes.Buffer
+	err := writeResponseHeader(&buf, id, "error")
+	if err == nil {
+		err = TranscodeJSON(&buf, mustJSON(map[string]interface{}{"code": code, "message": msg}))
+	}
+	if err != nil {
+		log.Errorf("encoding error response: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+}
+
+func mustJSON(v interface{}) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
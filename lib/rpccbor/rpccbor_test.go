@@ -0,0 +1,125 @@
+//stm: #unit
+package rpccbor
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-jsonrpc"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+type testAPI struct{}
+
+func (testAPI) Block(ctx context.Context, height abi.ChainEpoch) (*types.BlockHeader, error) {
+	return mock.MkBlock(nil, 1, uint64(height)), nil
+}
+
+func (testAPI) Info(ctx context.Context) (map[string]interface{}, error) {
+	return map[string]interface{}{"a": "x", "b": []int{1, -2}}, nil
+}
+
+func (testAPI) Fail(ctx context.Context) error {
+	return xerrors.New("failed")
+}
+
+func (testAPI) Count(ctx context.Context, n int) (<-chan []byte, error) {
+	ch := make(chan []byte, n)
+	for i := 0; i < n; i++ {
+		ch <- []byte{byte(i)}
+	}
+	close(ch)
+	return ch, nil
+}
+
+func testServer(t *testing.T) *httptest.Server {
+	rpcServer := jsonrpc.NewServer()
+	rpcServer.Register("Test", testAPI{})
+
+	srv := httptest.NewServer(Handler(rpcServer, "Test", testAPI{}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func post(t *testing.T, url, accept, body string) *http.Response {
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func TestCallCBOR(t *testing.T) {
+	srv := testServer(t)
+
+	var b types.BlockHeader
+	require.NoError(t, Call(context.Background(), srv.URL, nil, "Test.Block", &b, 5))
+	require.Equal(t, mock.MkBlock(nil, 1, 5), &b)
+}
+
+func TestTranscodedResult(t *testing.T) {
+	srv := testServer(t)
+
+	var res cbg.Deferred
+	require.NoError(t, Call(context.Background(), srv.URL, nil, "Test.Info", &res))
+
+	var exp bytes.Buffer
+	require.NoError(t, TranscodeJSON(&exp, []byte(`{"a":"x","b":[1,-2]}`)))
+	require.Equal(t, exp.Bytes(), res.Raw)
+	// map of 2 entries, "a": "x"
+	require.Equal(t, []byte{0xa2, 0x61, 'a', 0x61, 'x'}, res.Raw[:5])
+}
+
+func TestCallError(t *testing.T) {
+	srv := testServer(t)
+
+	var res cbg.Deferred
+	err := Call(context.Background(), srv.URL, nil, "Test.Fail", &res)
+	var rerr *Error
+	require.True(t, xerrors.As(err, &rerr))
+	require.Equal(t, int64(1), rerr.Code)
+	require.Equal(t, "failed", rerr.Message)
+}
+
+func TestStream(t *testing.T) {
+	srv := testServer(t)
+
+	resp := post(t, srv.URL, ContentTypeSeq, `{"jsonrpc":"2.0","id":1,"method":"Test.Count","params":[3]}`)
+	require.Equal(t, ContentTypeSeq, resp.Header.Get("Content-Type"))
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x41, 0, 0x41, 1, 0x41, 2}, body)
+}
+
+func TestJSONPassthrough(t *testing.T) {
+	srv := testServer(t)
+
+	// without the Accept header
+	resp := post(t, srv.URL, "", `{"jsonrpc":"2.0","id":1,"method":"Test.Info","params":[]}`)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":1,"result":{"a":"x","b":[1,-2]}}`, string(body))
+
+	// unknown methods are answered by the JSON-RPC server
+	resp = post(t, srv.URL, ContentType, `{"jsonrpc":"2.0","id":1,"method":"Test.Nope","params":[]}`)
+	require.NotEqual(t, ContentType, resp.Header.Get("Content-Type"))
+}
@@ -0,0 +1,213 @@
+// Package rpcconn handles the requests of the connections to the JSON-RPC
+// servers: batch requests, and the limit of the requests of a connection
+// processed concurrently.
+package rpcconn
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-jsonrpc"
+)
+
+var log = logging.Logger("rpcconn")
+
+// JSON-RPC 2.0 error codes
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+)
+
+type Config struct {
+	// MaxBatchSize is the maximum number of requests in a batch request; batch
+	// requests are rejected when it is 0
+	MaxBatchSize int
+	// MaxConnRequests is the maximum number of requests of a connection which
+	// are processed concurrently, including the requests of a batch and the
+	// calls over a websocket; 0 for no limit
+	MaxConnRequests int
+}
+
+type connKey struct{}
+
+// conn is the state of a connection to the server
+type conn struct {
+	once sync.Once
+	// slots limits the requests processed concurrently, nil for no limit. The
+	// requests waiting for a slot get it in the order they asked for it.
+	slots chan struct{}
+}
+
+// ConnContext tracks the connections of an http.Server, it must be set as its
+// ConnContext for the per-connection limits to apply
+func ConnContext(ctx context.Context, _ net.Conn) context.Context {
+	return context.WithValue(ctx, connKey{}, &conn{})
+}
+
+// Acquire waits until a request of the connection of the context can be
+// processed. The returned func must be called once the request is processed.
+// Contexts without a connection aren't limited.
+func Acquire(ctx context.Context) (func(), error) {
+	c, ok := ctx.Value(connKey{}).(*conn)
+	if !ok || c.slots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case c.slots <- struct{}{}:
+		return func() { <-c.slots }, nil
+	case <-ctx.Done():
+		return nil, xerrors.Errorf("waiting for a request slot of the connection: %w", ctx.Err())
+	}
+}
+
+// Handler applies the config to the requests of a JSON-RPC server
+func Handler(next http.Handler, cfg Config) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c, ok := r.Context().Value(connKey{}).(*conn); ok && cfg.MaxConnRequests > 0 {
+			c.once.Do(func() {
+				c.slots = make(chan struct{}, cfg.MaxConnRequests)
+			})
+		}
+
+		if r.Method != http.MethodPost || strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := ioutil.ReadAll(io.LimitReader(r.Body, jsonrpc.DEFAULT_MAX_REQUEST_SIZE+1))
+		if err != nil {
+			writeError(w, rpcParseError, xerrors.Errorf("reading request: %w", err))
+			return
+		}
+
+		trimmed := bytes.TrimLeft(body, " \t\r\n")
+		if len(trimmed) == 0 || trimmed[0] != '[' {
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if int64(len(body)) > jsonrpc.DEFAULT_MAX_REQUEST_SIZE {
+			writeError(w, rpcParseError, xerrors.Errorf("batch request bigger than %d bytes", jsonrpc.DEFAULT_MAX_REQUEST_SIZE))
+			return
+		}
+
+		serveBatch(next, cfg, w, r, trimmed)
+	})
+}
+
+func serveBatch(next http.Handler, cfg Config, w http.ResponseWriter, r *http.Request, body []byte) {
+	var reqs []json.RawMessage
+	if err := json.Unmarshal(body, &reqs); err != nil {
+		writeError(w, rpcParseError, xerrors.Errorf("unmarshaling batch request: %w", err))
+		return
+	}
+	switch {
+	case cfg.MaxBatchSize == 0:
+		writeError(w, rpcInvalidRequest, xerrors.Errorf("batch requests are disabled"))
+		return
+	case len(reqs) == 0:
+		writeError(w, rpcInvalidRequest, xerrors.Errorf("empty batch request"))
+		return
+	case len(reqs) > cfg.MaxBatchSize:
+		writeError(w, rpcInvalidRequest, xerrors.Errorf("batch of %d requests, the maximum is %d", len(reqs), cfg.MaxBatchSize))
+		return
+	}
+
+	// the requests are processed concurrently, up to the limit of the
+	// connection, and their responses are returned in the order of the
+	// requests
+	resps := make([]*bytes.Buffer, len(reqs))
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		i, req := i, req
+		resps[i] = new(bytes.Buffer)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			sub := r.Clone(r.Context())
+			sub.Body = ioutil.NopCloser(bytes.NewReader(req))
+			sub.ContentLength = int64(len(req))
+			// the responses are joined in a JSON array
+			sub.Header.Del("Accept")
+
+			next.ServeHTTP(&bufferWriter{header: http.Header{}, buf: resps[i]}, sub)
+		}()
+	}
+	wg.Wait()
+
+	out := new(bytes.Buffer)
+	out.WriteByte('[')
+	first := true
+	for _, resp := range resps {
+		// notifications don't have responses
+		b := bytes.TrimSpace(resp.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+		if !first {
+			out.WriteByte(',')
+		}
+		first = false
+		out.Write(b)
+	}
+	out.WriteByte(']')
+
+	w.Header().Set("Content-Type", "application/json")
+	if first {
+		// a batch of notifications
+		return
+	}
+	if _, err := w.Write(out.Bytes()); err != nil {
+		log.Warnf("writing batch response: %s", err)
+	}
+}
+
+// bufferWriter collects the response of a request of a batch
+type bufferWriter struct {
+	header http.Header
+	buf    *bytes.Buffer
+}
+
+func (b *bufferWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferWriter) Write(p []byte) (int, error) {
+	return b.buf.Write(p)
+}
+
+func (b *bufferWriter) WriteHeader(int) {}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+
+	resp := struct {
+		Jsonrpc string      `json:"jsonrpc"`
+		ID      interface{} `json:"id"`
+		Error   interface{} `json:"error"`
+	}{
+		Jsonrpc: "2.0",
+		Error: struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}{code, err.Error()},
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Warnf("writing error response: %s", err)
+	}
+}
@@ -0,0 +1,130 @@
+//stm: #unit
+package rpcconn
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-jsonrpc"
+)
+
+type testHandler struct {
+	lk          sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (h *testHandler) Add(ctx context.Context, a, b int) (int, error) {
+	release, err := Acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	h.lk.Lock()
+	h.inFlight++
+	if h.inFlight > h.maxInFlight {
+		h.maxInFlight = h.inFlight
+	}
+	h.lk.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	h.lk.Lock()
+	h.inFlight--
+	h.lk.Unlock()
+
+	return a + b, nil
+}
+
+type testResponse struct {
+	ID     int
+	Result int
+	Error  *struct {
+		Code    int
+		Message string
+	}
+}
+
+func TestBatch(t *testing.T) {
+	h := &testHandler{}
+	rpcServer := jsonrpc.NewServer()
+	rpcServer.Register("Test", h)
+
+	srv := httptest.NewUnstartedServer(Handler(rpcServer, Config{MaxBatchSize: 3, MaxConnRequests: 1}))
+	srv.Config.ConnContext = ConnContext
+	srv.Start()
+	defer srv.Close()
+
+	post := func(body string) (int, string) {
+		resp, err := http.Post(srv.URL, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close() // nolint:errcheck
+
+		var out json.RawMessage
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			return resp.StatusCode, ""
+		}
+		return resp.StatusCode, string(out)
+	}
+
+	// single request
+	_, body := post(`{"jsonrpc":"2.0","id":1,"method":"Test.Add","params":[1,2]}`)
+	var single testResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &single))
+	require.Equal(t, 3, single.Result)
+
+	// batch, with a notification which doesn't have a response
+	_, body = post(`[
+		{"jsonrpc":"2.0","id":1,"method":"Test.Add","params":[1,1]},
+		{"jsonrpc":"2.0","method":"Test.Add","params":[2,2]},
+		{"jsonrpc":"2.0","id":3,"method":"Test.Add","params":[3,3]}
+	]`)
+	var batch []testResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &batch))
+	require.Len(t, batch, 2)
+	require.Equal(t, 1, batch[0].ID)
+	require.Equal(t, 2, batch[0].Result)
+	require.Equal(t, 3, batch[1].ID)
+	require.Equal(t, 6, batch[1].Result)
+
+	// the requests of the connection were processed one at a time
+	require.Equal(t, 1, h.maxInFlight)
+
+	// too many requests
+	code, body := post(`[
+		{"jsonrpc":"2.0","id":1,"method":"Test.Add","params":[1,1]},
+		{"jsonrpc":"2.0","id":2,"method":"Test.Add","params":[1,1]},
+		{"jsonrpc":"2.0","id":3,"method":"Test.Add","params":[1,1]},
+		{"jsonrpc":"2.0","id":4,"method":"Test.Add","params":[1,1]}
+	]`)
+	require.Equal(t, http.StatusBadRequest, code)
+	var tooMany testResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &tooMany))
+	require.NotNil(t, tooMany.Error)
+	require.Equal(t, rpcInvalidRequest, tooMany.Error.Code)
+
+	code, _ = post(`[]`)
+	require.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestBatchDisabled(t *testing.T) {
+	rpcServer := jsonrpc.NewServer()
+	rpcServer.Register("Test", &testHandler{})
+
+	srv := httptest.NewServer(Handler(rpcServer, Config{}))
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL, "application/json", strings.NewReader(`[{"jsonrpc":"2.0","id":1,"method":"Test.Add","params":[1,1]}]`))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,64 @@
+package tracing
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// APISpanPrefix prefixes the names of the spans of API calls, which are
+// followed by the name of the method
+const APISpanPrefix = "api."
+
+// apiSampler samples the traces of API calls with the rate configured for the
+// method, and all the other traces
+var apiSampler = &methodSampler{
+	def:     tracesdk.AlwaysSample(),
+	methods: map[string]tracesdk.Sampler{},
+}
+
+// SetAPISampling sets the fraction of the API calls traced, by method name,
+// and for the methods without a rate
+func SetAPISampling(defaultRate float64, methodRates map[string]float64) {
+	methods := make(map[string]tracesdk.Sampler, len(methodRates))
+	for m, r := range methodRates {
+		methods[m] = tracesdk.TraceIDRatioBased(r)
+	}
+
+	apiSampler.lk.Lock()
+	defer apiSampler.lk.Unlock()
+
+	apiSampler.def = tracesdk.TraceIDRatioBased(defaultRate)
+	apiSampler.methods = methods
+}
+
+type methodSampler struct {
+	lk      sync.RWMutex
+	def     tracesdk.Sampler
+	methods map[string]tracesdk.Sampler
+}
+
+func (s *methodSampler) ShouldSample(p tracesdk.SamplingParameters) tracesdk.SamplingResult {
+	method := strings.TrimPrefix(p.Name, APISpanPrefix)
+	if method == p.Name {
+		return tracesdk.AlwaysSample().ShouldSample(p)
+	}
+
+	s.lk.RLock()
+	sampler, ok := s.methods[method]
+	if !ok {
+		sampler = s.def
+	}
+	s.lk.RUnlock()
+
+	return sampler.ShouldSample(p)
+}
+
+func (s *methodSampler) Description() string {
+	s.lk.RLock()
+	defer s.lk.RUnlock()
+
+	return fmt.Sprintf("APIMethodSampler{default:%s,methods:%d}", s.def.Description(), len(s.methods))
+}
@@ -82,7 +82,8 @@ func SetupJaegerTracing(serviceName string) *tracesdk.TracerProvider {
 			semconv.SchemaURL,
 			semconv.ServiceNameKey.String(serviceName),
 		)),
-		tracesdk.WithSampler(tracesdk.AlwaysSample()),
+		// API calls are sampled by method, see SetAPISampling
+		tracesdk.WithSampler(tracesdk.ParentBased(apiSampler)),
 	)
 	otel.SetTracerProvider(tp)
 	tracer := tp.Tracer(serviceName)
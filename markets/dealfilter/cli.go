@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"os/exec"
+	"sync"
 
 	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
 	"github.com/filecoin-project/go-fil-markets/storagemarket"
@@ -38,6 +39,52 @@ func CliRetrievalDealFilter(cmd string) dtypes.RetrievalDealFilter {
 	}
 }
 
+// CliFilter runs the storage and retrieval deal filter commands, which can be
+// replaced while the filter is in use. Deals are accepted while the command of
+// their type is empty.
+type CliFilter struct {
+	lk           sync.RWMutex
+	storageCmd   string
+	retrievalCmd string
+}
+
+func NewCliFilter(storageCmd, retrievalCmd string) *CliFilter {
+	return &CliFilter{
+		storageCmd:   storageCmd,
+		retrievalCmd: retrievalCmd,
+	}
+}
+
+// SetCommands replaces the filter commands
+func (f *CliFilter) SetCommands(storageCmd, retrievalCmd string) {
+	f.lk.Lock()
+	defer f.lk.Unlock()
+
+	f.storageCmd, f.retrievalCmd = storageCmd, retrievalCmd
+}
+
+func (f *CliFilter) StorageDealFilter(ctx context.Context, deal storagemarket.MinerDeal) (bool, string, error) {
+	f.lk.RLock()
+	cmd := f.storageCmd
+	f.lk.RUnlock()
+
+	if cmd == "" {
+		return true, "", nil
+	}
+	return CliStorageDealFilter(cmd)(ctx, deal)
+}
+
+func (f *CliFilter) RetrievalDealFilter(ctx context.Context, deal retrievalmarket.ProviderDealState) (bool, string, error) {
+	f.lk.RLock()
+	cmd := f.retrievalCmd
+	f.lk.RUnlock()
+
+	if cmd == "" {
+		return true, "", nil
+	}
+	return CliRetrievalDealFilter(cmd)(ctx, deal)
+}
+
 func runDealFilter(ctx context.Context, cmd string, deal interface{}) (bool, string, error) {
 	j, err := json.MarshalIndent(deal, "", "  ")
 	if err != nil {
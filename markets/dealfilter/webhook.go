@@ -0,0 +1,208 @@
+package dealfilter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+)
+
+var log = logging.Logger("dealfilter")
+
+// Decisions returned by the deal filter webhook
+const (
+	DecisionAccept = "accept"
+	DecisionReject = "reject"
+	// DecisionQueue defers the decision, which is then made by calling
+	// MarketResolveFilterDecision before the queue timeout
+	DecisionQueue = "queue"
+)
+
+// WebhookResponse is the JSON body expected in response to the deal filter
+// webhook requests
+type WebhookResponse struct {
+	Decision string
+	Reason   string
+}
+
+type filterDecision struct {
+	accept bool
+	reason string
+}
+
+type pendingDecision struct {
+	info api.PendingFilterDecision
+	done chan filterDecision
+}
+
+// WebhookFilter evaluates storage deals by POSTing them as JSON to a webhook.
+// Deals queued by the webhook wait for a decision made through Resolve.
+type WebhookFilter struct {
+	url          string
+	client       *http.Client
+	queueTimeout time.Duration
+
+	lk      sync.Mutex
+	pending map[cid.Cid]*pendingDecision
+}
+
+func NewWebhookFilter(url string, timeout, queueTimeout time.Duration) *WebhookFilter {
+	return &WebhookFilter{
+		url:          url,
+		client:       &http.Client{Timeout: timeout},
+		queueTimeout: queueTimeout,
+		pending:      make(map[cid.Cid]*pendingDecision),
+	}
+}
+
+// StorageDealFilter is the dtypes.StorageDealFilter of the webhook
+func (w *WebhookFilter) StorageDealFilter(ctx context.Context, deal storagemarket.MinerDeal) (bool, string, error) {
+	d := struct {
+		storagemarket.MinerDeal
+		DealType string
+	}{
+		MinerDeal: deal,
+		DealType:  "storage",
+	}
+
+	resp, err := w.call(ctx, d)
+	if err != nil {
+		return false, "filter webhook error", err
+	}
+
+	switch strings.ToLower(resp.Decision) {
+	case DecisionAccept:
+		return true, "", nil
+	case DecisionReject:
+		return false, resp.Reason, nil
+	case DecisionQueue:
+		return w.wait(ctx, deal, resp.Reason)
+	default:
+		return false, "filter webhook error", xerrors.Errorf("unknown filter webhook decision %q", resp.Decision)
+	}
+}
+
+func (w *WebhookFilter) call(ctx context.Context, deal interface{}) (*WebhookResponse, error) {
+	j, err := json.Marshal(deal)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(j))
+	if err != nil {
+		return nil, xerrors.Errorf("creating filter webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, xerrors.Errorf("calling filter webhook: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return nil, xerrors.Errorf("filter webhook returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var out WebhookResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, xerrors.Errorf("decoding filter webhook response: %w", err)
+	}
+
+	return &out, nil
+}
+
+// wait queues the deal until a decision is made through Resolve, rejecting it
+// once the queue timeout expires
+func (w *WebhookFilter) wait(ctx context.Context, deal storagemarket.MinerDeal, reason string) (bool, string, error) {
+	now := build.Clock.Now()
+	pd := &pendingDecision{
+		info: api.PendingFilterDecision{
+			ProposalCid:  deal.ProposalCid,
+			Client:       deal.Proposal.Client,
+			PieceCID:     deal.Proposal.PieceCID,
+			PieceSize:    deal.Proposal.PieceSize,
+			VerifiedDeal: deal.Proposal.VerifiedDeal,
+			StartEpoch:   deal.Proposal.StartEpoch,
+			EndEpoch:     deal.Proposal.EndEpoch,
+			Reason:       reason,
+			QueuedAt:     now,
+			Deadline:     now.Add(w.queueTimeout),
+		},
+		done: make(chan filterDecision, 1),
+	}
+
+	w.lk.Lock()
+	if _, ok := w.pending[deal.ProposalCid]; ok {
+		w.lk.Unlock()
+		return false, "deal is already awaiting a filter decision", nil
+	}
+	w.pending[deal.ProposalCid] = pd
+	w.lk.Unlock()
+
+	defer func() {
+		w.lk.Lock()
+		delete(w.pending, deal.ProposalCid)
+		w.lk.Unlock()
+	}()
+
+	log.Infow("storage deal queued for a filter decision", "proposal", deal.ProposalCid, "client", deal.Client, "reason", reason)
+
+	timer := build.Clock.Timer(w.queueTimeout)
+	defer timer.Stop()
+
+	select {
+	case d := <-pd.done:
+		return d.accept, d.reason, nil
+	case <-timer.C:
+		return false, fmt.Sprintf("no filter decision made within %s", w.queueTimeout), nil
+	case <-ctx.Done():
+		return false, "miner error", ctx.Err()
+	}
+}
+
+// Pending returns the deals waiting for a filter decision, from the oldest
+func (w *WebhookFilter) Pending() []api.PendingFilterDecision {
+	w.lk.Lock()
+	out := make([]api.PendingFilterDecision, 0, len(w.pending))
+	for _, pd := range w.pending {
+		out = append(out, pd.info)
+	}
+	w.lk.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].QueuedAt.Before(out[j].QueuedAt)
+	})
+	return out
+}
+
+// Resolve makes the decision for a deal waiting for one
+func (w *WebhookFilter) Resolve(propCid cid.Cid, accept bool, reason string) error {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	pd, ok := w.pending[propCid]
+	if !ok {
+		return xerrors.Errorf("deal %s is not awaiting a filter decision", propCid)
+	}
+	delete(w.pending, propCid)
+
+	pd.done <- filterDecision{accept: accept, reason: reason}
+	return nil
+}
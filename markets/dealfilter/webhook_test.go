@@ -0,0 +1,89 @@
+//stm: #unit
+package dealfilter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+)
+
+func TestWebhookFilter(t *testing.T) {
+	ctx := context.Background()
+
+	var decision WebhookResponse
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			DealType string
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.DealType != "storage" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(decision)
+	}))
+	defer srv.Close()
+
+	deal := storagemarket.MinerDeal{ProposalCid: blocks.NewBlock([]byte("deal")).Cid()}
+	wf := NewWebhookFilter(srv.URL, time.Second, time.Minute)
+
+	decision = WebhookResponse{Decision: DecisionAccept}
+	ok, _, err := wf.StorageDealFilter(ctx, deal)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	decision = WebhookResponse{Decision: DecisionReject, Reason: "no thanks"}
+	ok, reason, err := wf.StorageDealFilter(ctx, deal)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Equal(t, "no thanks", reason)
+
+	decision = WebhookResponse{Decision: "maybe"}
+	_, _, err = wf.StorageDealFilter(ctx, deal)
+	require.Error(t, err)
+
+	// queued deals wait for a decision
+	decision = WebhookResponse{Decision: DecisionQueue, Reason: "manual review"}
+	type result struct {
+		ok     bool
+		reason string
+		err    error
+	}
+	done := make(chan result)
+	go func() {
+		ok, reason, err := wf.StorageDealFilter(ctx, deal)
+		done <- result{ok, reason, err}
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(wf.Pending()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	pending := wf.Pending()
+	require.Equal(t, deal.ProposalCid, pending[0].ProposalCid)
+	require.Equal(t, "manual review", pending[0].Reason)
+
+	require.Error(t, wf.Resolve(blocks.NewBlock([]byte("other")).Cid(), true, ""))
+	require.NoError(t, wf.Resolve(deal.ProposalCid, false, "rejected by operator"))
+
+	res := <-done
+	require.NoError(t, res.err)
+	require.False(t, res.ok)
+	require.Equal(t, "rejected by operator", res.reason)
+	require.Empty(t, wf.Pending())
+
+	// queued deals are rejected after the queue timeout
+	wf = NewWebhookFilter(srv.URL, time.Second, 10*time.Millisecond)
+	ok, _, err = wf.StorageDealFilter(ctx, deal)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Empty(t, wf.Pending())
+}
@@ -0,0 +1,437 @@
+package dealrepair
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin/v8/market"
+	"github.com/filecoin-project/go-state-types/builtin/v8/miner"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var log = logging.Logger("dealrepair")
+
+var recordPrefix = datastore.NewKey("/deals/client/repair")
+
+// API is the set of node methods used by the deal health monitor
+type API interface {
+	ChainHead(context.Context) (*types.TipSet, error)
+	StateMarketStorageDeal(context.Context, abi.DealID, types.TipSetKey) (*api.MarketDeal, error)
+	StateMinerSectors(context.Context, address.Address, *bitfield.BitField, types.TipSetKey) ([]*miner.SectorOnChainInfo, error)
+	StateMinerFaults(context.Context, address.Address, types.TipSetKey) (bitfield.BitField, error)
+	StateMinerInfo(context.Context, address.Address, types.TipSetKey) (api.MinerInfo, error)
+
+	ClientListDeals(context.Context) ([]api.DealInfo, error)
+	ClientQueryAsk(ctx context.Context, p peer.ID, miner address.Address) (*api.StorageAsk, error)
+	ClientStartDeal(context.Context, *api.StartDealParams) (*cid.Cid, error)
+}
+
+// Config specifies how deals are checked and where they are repaired
+type Config struct {
+	CheckInterval time.Duration
+	// FaultThreshold is how long the sector of a deal may stay faulty before
+	// the deal is repaired
+	FaultThreshold time.Duration
+	// BackupProviders receive the replacement deals, the first one accepting
+	// a deal proposal wins
+	BackupProviders []address.Address
+	// Wallet pays for the replacement deals, the client of the original deal
+	// when undefined
+	Wallet address.Address
+}
+
+// monitoredStates are the client states of the deals which are checked: the
+// active deals, and the deals which the client already knows to be slashed or
+// expired, whose end epoch is checked on chain before they are dropped
+var monitoredStates = map[storagemarket.StorageDealStatus]bool{
+	storagemarket.StorageDealActive:  true,
+	storagemarket.StorageDealSlashed: true,
+	storagemarket.StorageDealExpired: true,
+}
+
+// record is the persisted health state of a deal
+type record struct {
+	FaultySince time.Time
+	Replacement *api.DealReplacement
+	// Proposal is the on-chain proposal of the deal, kept so that the deal can
+	// be repaired once it's removed from the market state after being slashed
+	Proposal *market.DealProposal
+}
+
+// Monitor tracks the health of the sectors holding the client's active deals,
+// and proposes replacement deals to backup providers for the deals whose
+// sector is terminated or stays faulty beyond the fault threshold.
+type Monitor struct {
+	api API
+	ds  datastore.Batching
+	cfg Config
+
+	stop    chan struct{}
+	stopped chan struct{}
+
+	lk     sync.Mutex
+	health map[cid.Cid]api.DealHealth
+}
+
+func NewMonitor(a API, ds datastore.Batching, cfg Config) *Monitor {
+	return &Monitor{
+		api:     a,
+		ds:      ds,
+		cfg:     cfg,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+		health:  map[cid.Cid]api.DealHealth{},
+	}
+}
+
+func (m *Monitor) Start(ctx context.Context) error {
+	go m.run()
+	return nil
+}
+
+func (m *Monitor) Stop(ctx context.Context) error {
+	close(m.stop)
+	select {
+	case <-m.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *Monitor) run() {
+	defer close(m.stopped)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-m.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := build.Clock.Ticker(m.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Check(ctx); err != nil {
+			log.Errorw("checking deal health", "error", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Health returns the health of the active deals as of the last check
+func (m *Monitor) Health() []api.DealHealth {
+	m.lk.Lock()
+	out := make([]api.DealHealth, 0, len(m.health))
+	for _, h := range m.health {
+		out = append(out, h)
+	}
+	m.lk.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].DealID < out[j].DealID
+	})
+	return out
+}
+
+// Check checks the health of the active deals, and repairs the failed ones
+func (m *Monitor) Check(ctx context.Context) error {
+	head, err := m.api.ChainHead(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	deals, err := m.api.ClientListDeals(ctx)
+	if err != nil {
+		return xerrors.Errorf("listing deals: %w", err)
+	}
+
+	providers := map[address.Address]*providerSectors{}
+	health := map[cid.Cid]api.DealHealth{}
+	for _, deal := range deals {
+		if !monitoredStates[deal.State] {
+			continue
+		}
+
+		h, err := m.checkDeal(ctx, head, deal, providers)
+		if err != nil {
+			log.Warnw("checking deal health", "proposal", deal.ProposalCid, "deal", deal.DealID, "error", err)
+			h.Error = err.Error()
+		}
+		if h.Status == "" {
+			// the deal has expired
+			continue
+		}
+		health[deal.ProposalCid] = h
+	}
+
+	m.lk.Lock()
+	m.health = health
+	m.lk.Unlock()
+	return nil
+}
+
+// providerSectors caches the sectors of a provider for the duration of a check
+type providerSectors struct {
+	dealSectors map[abi.DealID]abi.SectorNumber
+	faults      bitfield.BitField
+}
+
+func (m *Monitor) sectors(ctx context.Context, head *types.TipSet, provider address.Address, cache map[address.Address]*providerSectors) (*providerSectors, error) {
+	if ps, ok := cache[provider]; ok {
+		return ps, nil
+	}
+
+	// faulty sectors aren't active, so get all the sectors which haven't
+	// expired or been terminated
+	sectors, err := m.api.StateMinerSectors(ctx, provider, nil, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting sectors of %s: %w", provider, err)
+	}
+	faults, err := m.api.StateMinerFaults(ctx, provider, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting faults of %s: %w", provider, err)
+	}
+
+	ps := &providerSectors{
+		dealSectors: map[abi.DealID]abi.SectorNumber{},
+		faults:      faults,
+	}
+	for _, s := range sectors {
+		for _, d := range s.DealIDs {
+			ps.dealSectors[d] = s.SectorNumber
+		}
+	}
+
+	cache[provider] = ps
+	return ps, nil
+}
+
+func (m *Monitor) checkDeal(ctx context.Context, head *types.TipSet, deal api.DealInfo, cache map[address.Address]*providerSectors) (api.DealHealth, error) {
+	h := api.DealHealth{
+		ProposalCid: deal.ProposalCid,
+		DealID:      deal.DealID,
+		Provider:    deal.Provider,
+		PieceCID:    deal.PieceCID,
+		Status:      api.DealHealthUnknown,
+	}
+
+	rec, err := m.getRecord(ctx, deal.ProposalCid)
+	if err != nil {
+		return h, err
+	}
+	h.Replacement = rec.Replacement
+
+	changed := false
+
+	md, err := m.api.StateMarketStorageDeal(ctx, deal.DealID, head.Key())
+	switch {
+	case err == nil:
+		if rec.Proposal == nil {
+			rec.Proposal = &md.Proposal
+			changed = true
+		}
+	case rec.Proposal != nil:
+		// slashed deals are removed from the market state
+		md = &api.MarketDeal{
+			Proposal: *rec.Proposal,
+			State:    market.DealState{SectorStartEpoch: -1, LastUpdatedEpoch: -1, SlashEpoch: head.Height()},
+		}
+	default:
+		return h, xerrors.Errorf("getting market deal: %w", err)
+	}
+	if head.Height() >= md.Proposal.EndEpoch {
+		return api.DealHealth{}, m.ds.Delete(ctx, recordKey(deal.ProposalCid))
+	}
+
+	if md.State.SlashEpoch != -1 {
+		h.Status = api.DealHealthTerminated
+	} else {
+		ps, err := m.sectors(ctx, head, deal.Provider, cache)
+		if err != nil {
+			return h, err
+		}
+
+		sector, ok := ps.dealSectors[deal.DealID]
+		switch {
+		case !ok && md.State.SectorStartEpoch > 0:
+			// the deal was activated, but its sector is gone
+			h.Status = api.DealHealthTerminated
+		case !ok:
+			h.Status = api.DealHealthUnknown
+		default:
+			h.Sector = sector
+			h.Status = api.DealHealthActive
+
+			faulty, err := ps.faults.IsSet(uint64(sector))
+			if err != nil {
+				return h, xerrors.Errorf("checking sector faults: %w", err)
+			}
+			if faulty {
+				h.Status = api.DealHealthFaulty
+			}
+		}
+	}
+
+	if h.Status == api.DealHealthFaulty && rec.FaultySince.IsZero() {
+		rec.FaultySince = build.Clock.Now()
+		changed = true
+	}
+	if h.Status != api.DealHealthFaulty && !rec.FaultySince.IsZero() {
+		rec.FaultySince = time.Time{}
+		changed = true
+	}
+	h.FaultySince = rec.FaultySince
+
+	failed := h.Status == api.DealHealthTerminated ||
+		(h.Status == api.DealHealthFaulty && build.Clock.Since(rec.FaultySince) >= m.cfg.FaultThreshold)
+	if failed && rec.Replacement == nil {
+		rec.Replacement, err = m.repair(ctx, head, deal, md)
+		if err != nil {
+			h.Error = xerrors.Errorf("repairing deal: %w", err).Error()
+		}
+		h.Replacement = rec.Replacement
+		changed = changed || rec.Replacement != nil
+	}
+
+	if !changed {
+		return h, nil
+	}
+	return h, m.putRecord(ctx, deal.ProposalCid, rec)
+}
+
+// repair proposes a deal for the same data to the first backup provider
+// accepting it, lasting until the end of the failed deal
+func (m *Monitor) repair(ctx context.Context, head *types.TipSet, deal api.DealInfo, md *api.MarketDeal) (*api.DealReplacement, error) {
+	if deal.DataRef == nil {
+		return nil, xerrors.Errorf("deal has no data reference")
+	}
+
+	wallet := m.cfg.Wallet
+	if wallet == address.Undef {
+		wallet = md.Proposal.Client
+	}
+
+	duration := md.Proposal.EndEpoch - head.Height()
+	if duration < build.MinDealDuration {
+		duration = build.MinDealDuration
+	}
+
+	var lastErr error = xerrors.Errorf("no backup providers configured")
+	for _, provider := range m.cfg.BackupProviders {
+		if provider == deal.Provider {
+			continue
+		}
+
+		propCid, err := m.propose(ctx, provider, wallet, duration, deal, md)
+		if err != nil {
+			log.Warnw("proposing replacement deal", "proposal", deal.ProposalCid, "provider", provider, "error", err)
+			lastErr = xerrors.Errorf("proposing to %s: %w", provider, err)
+			continue
+		}
+
+		log.Infow("proposed replacement deal", "proposal", deal.ProposalCid, "provider", provider, "replacement", propCid)
+		return &api.DealReplacement{
+			ProposalCid: propCid,
+			Provider:    provider,
+			ProposedAt:  build.Clock.Now(),
+		}, nil
+	}
+
+	return nil, lastErr
+}
+
+func (m *Monitor) propose(ctx context.Context, provider, wallet address.Address, duration abi.ChainEpoch, deal api.DealInfo, md *api.MarketDeal) (cid.Cid, error) {
+	mi, err := m.api.StateMinerInfo(ctx, provider, types.EmptyTSK)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("getting miner info: %w", err)
+	}
+	if mi.PeerId == nil {
+		return cid.Undef, xerrors.Errorf("miner has no peer ID set")
+	}
+
+	ask, err := m.api.ClientQueryAsk(ctx, *mi.PeerId, provider)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("querying ask: %w", err)
+	}
+	if ask.Response == nil {
+		return cid.Undef, xerrors.Errorf("provider returned no ask")
+	}
+
+	price := ask.Response.Price
+	if md.Proposal.VerifiedDeal {
+		price = ask.Response.VerifiedPrice
+	}
+	epochPrice := big.Div(big.Mul(price, big.NewIntUnsigned(uint64(md.Proposal.PieceSize))), big.NewInt(1<<30))
+
+	propCid, err := m.api.ClientStartDeal(ctx, &api.StartDealParams{
+		Data:              deal.DataRef,
+		Wallet:            wallet,
+		Miner:             provider,
+		EpochPrice:        epochPrice,
+		MinBlocksDuration: uint64(duration),
+		FastRetrieval:     true,
+		VerifiedDeal:      md.Proposal.VerifiedDeal,
+	})
+	if err != nil {
+		return cid.Undef, err
+	}
+	return *propCid, nil
+}
+
+func recordKey(propCid cid.Cid) datastore.Key {
+	return recordPrefix.ChildString(propCid.String())
+}
+
+func (m *Monitor) getRecord(ctx context.Context, propCid cid.Cid) (record, error) {
+	var rec record
+	b, err := m.ds.Get(ctx, recordKey(propCid))
+	if err == datastore.ErrNotFound {
+		return rec, nil
+	}
+	if err != nil {
+		return rec, xerrors.Errorf("getting deal health record: %w", err)
+	}
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return rec, xerrors.Errorf("decoding deal health record: %w", err)
+	}
+	return rec, nil
+}
+
+func (m *Monitor) putRecord(ctx context.Context, propCid cid.Cid, rec record) error {
+	if rec.FaultySince.IsZero() && rec.Replacement == nil && rec.Proposal == nil {
+		return m.ds.Delete(ctx, recordKey(propCid))
+	}
+
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return m.ds.Put(ctx, recordKey(propCid), b)
+}
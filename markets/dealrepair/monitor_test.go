@@ -0,0 +1,280 @@
+//stm: #unit
+package dealrepair
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/raulk/clock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-fil-markets/storagemarket"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin/v8/market"
+	"github.com/filecoin-project/go-state-types/builtin/v8/miner"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+type mockAPI struct {
+	head    *types.TipSet
+	deals   []api.DealInfo
+	market  map[abi.DealID]*api.MarketDeal
+	sectors map[address.Address][]*miner.SectorOnChainInfo
+	faults  map[address.Address][]uint64
+
+	started []*api.StartDealParams
+	reject  map[address.Address]bool
+}
+
+func (m *mockAPI) ChainHead(context.Context) (*types.TipSet, error) {
+	return m.head, nil
+}
+
+func (m *mockAPI) StateMarketStorageDeal(ctx context.Context, id abi.DealID, tsk types.TipSetKey) (*api.MarketDeal, error) {
+	md, ok := m.market[id]
+	if !ok {
+		return nil, xerrors.Errorf("deal %d not found", id)
+	}
+	return md, nil
+}
+
+func (m *mockAPI) StateMinerSectors(ctx context.Context, maddr address.Address, bf *bitfield.BitField, tsk types.TipSetKey) ([]*miner.SectorOnChainInfo, error) {
+	return m.sectors[maddr], nil
+}
+
+func (m *mockAPI) StateMinerFaults(ctx context.Context, maddr address.Address, tsk types.TipSetKey) (bitfield.BitField, error) {
+	return bitfield.NewFromSet(m.faults[maddr]), nil
+}
+
+func (m *mockAPI) StateMinerInfo(ctx context.Context, maddr address.Address, tsk types.TipSetKey) (api.MinerInfo, error) {
+	pid := peer.ID("peer-" + maddr.String())
+	return api.MinerInfo{PeerId: &pid}, nil
+}
+
+func (m *mockAPI) ClientListDeals(context.Context) ([]api.DealInfo, error) {
+	return m.deals, nil
+}
+
+func (m *mockAPI) ClientQueryAsk(ctx context.Context, p peer.ID, maddr address.Address) (*api.StorageAsk, error) {
+	return &api.StorageAsk{Response: &storagemarket.StorageAsk{
+		Price:         big.NewInt(1 << 30),
+		VerifiedPrice: big.Zero(),
+	}}, nil
+}
+
+func (m *mockAPI) ClientStartDeal(ctx context.Context, params *api.StartDealParams) (*cid.Cid, error) {
+	if m.reject[params.Miner] {
+		return nil, xerrors.Errorf("deal rejected")
+	}
+	m.started = append(m.started, params)
+	c := blocks.NewBlock([]byte(params.Miner.String())).Cid()
+	return &c, nil
+}
+
+var _ API = &mockAPI{}
+
+func TestMonitor(t *testing.T) {
+	ctx := context.Background()
+
+	oldClock := build.Clock
+	t.Cleanup(func() { build.Clock = oldClock })
+	mc := clock.NewMock()
+	build.Clock = mc
+
+	provider, _ := address.NewIDAddress(1000)
+	backup1, _ := address.NewIDAddress(1001)
+	backup2, _ := address.NewIDAddress(1002)
+	client, _ := address.NewIDAddress(100)
+
+	deal := api.DealInfo{
+		ProposalCid: blocks.NewBlock([]byte("deal")).Cid(),
+		State:       storagemarket.StorageDealActive,
+		Provider:    provider,
+		DataRef:     &storagemarket.DataRef{TransferType: storagemarket.TTGraphsync, Root: blocks.NewBlock([]byte("root")).Cid()},
+		PieceCID:    blocks.NewBlock([]byte("piece")).Cid(),
+		DealID:      5,
+	}
+
+	ma := &mockAPI{
+		head:  mock.TipSet(mock.MkBlock(nil, 1, 1)),
+		deals: []api.DealInfo{deal},
+		market: map[abi.DealID]*api.MarketDeal{
+			5: {
+				Proposal: market.DealProposal{
+					PieceSize: 1 << 30,
+					Client:    client,
+					Provider:  provider,
+					EndEpoch:  build.MinDealDuration * 2,
+				},
+				State: market.DealState{SectorStartEpoch: 1, SlashEpoch: -1},
+			},
+		},
+		sectors: map[address.Address][]*miner.SectorOnChainInfo{
+			provider: {{SectorNumber: 7, DealIDs: []abi.DealID{5}}},
+		},
+		faults: map[address.Address][]uint64{},
+		reject: map[address.Address]bool{backup1: true},
+	}
+
+	m := NewMonitor(ma, dssync.MutexWrap(datastore.NewMapDatastore()), Config{
+		CheckInterval:   time.Minute,
+		FaultThreshold:  time.Hour,
+		BackupProviders: []address.Address{provider, backup1, backup2},
+	})
+
+	// healthy deal
+	require.NoError(t, m.Check(ctx))
+	health := m.Health()
+	require.Len(t, health, 1)
+	require.Equal(t, api.DealHealthActive, health[0].Status)
+	require.Equal(t, abi.SectorNumber(7), health[0].Sector)
+
+	// faulty deal within the fault threshold
+	ma.faults[provider] = []uint64{7}
+	require.NoError(t, m.Check(ctx))
+	health = m.Health()
+	require.Equal(t, api.DealHealthFaulty, health[0].Status)
+	require.Equal(t, mc.Now(), health[0].FaultySince)
+	require.Nil(t, health[0].Replacement)
+
+	mc.Add(30 * time.Minute)
+	require.NoError(t, m.Check(ctx))
+	require.Empty(t, ma.started)
+
+	// faulty beyond the threshold, the first backup provider rejects the deal
+	mc.Add(time.Hour)
+	require.NoError(t, m.Check(ctx))
+	health = m.Health()
+	require.NotNil(t, health[0].Replacement)
+	require.Equal(t, backup2, health[0].Replacement.Provider)
+
+	require.Len(t, ma.started, 1)
+	require.Equal(t, backup2, ma.started[0].Miner)
+	require.Equal(t, client, ma.started[0].Wallet)
+	require.Equal(t, deal.DataRef, ma.started[0].Data)
+	require.Equal(t, big.NewInt(1<<30), ma.started[0].EpochPrice)
+	require.Equal(t, uint64(build.MinDealDuration*2-ma.head.Height()), ma.started[0].MinBlocksDuration)
+
+	// deals are repaired once
+	require.NoError(t, m.Check(ctx))
+	require.Len(t, ma.started, 1)
+
+	// the replacement survives restarts
+	m2 := NewMonitor(ma, m.ds, m.cfg)
+	require.NoError(t, m2.Check(ctx))
+	require.Len(t, ma.started, 1)
+	require.Equal(t, backup2, m2.Health()[0].Replacement.Provider)
+}
+
+func TestMonitorTerminated(t *testing.T) {
+	ctx := context.Background()
+
+	provider, _ := address.NewIDAddress(1000)
+	backup, _ := address.NewIDAddress(1001)
+
+	ma := &mockAPI{
+		head: mock.TipSet(mock.MkBlock(nil, 1, 1)),
+		deals: []api.DealInfo{{
+			ProposalCid: blocks.NewBlock([]byte("deal")).Cid(),
+			State:       storagemarket.StorageDealActive,
+			Provider:    provider,
+			DataRef:     &storagemarket.DataRef{},
+			DealID:      5,
+		}},
+		market: map[abi.DealID]*api.MarketDeal{
+			5: {
+				Proposal: market.DealProposal{EndEpoch: build.MinDealDuration * 2},
+				State:    market.DealState{SectorStartEpoch: 1, SlashEpoch: -1},
+			},
+		},
+		sectors: map[address.Address][]*miner.SectorOnChainInfo{},
+		faults:  map[address.Address][]uint64{},
+	}
+
+	m := NewMonitor(ma, dssync.MutexWrap(datastore.NewMapDatastore()), Config{
+		CheckInterval:   time.Minute,
+		FaultThreshold:  time.Hour,
+		BackupProviders: []address.Address{backup},
+	})
+
+	// the deal's sector is gone, so the deal is repaired right away
+	require.NoError(t, m.Check(ctx))
+	health := m.Health()
+	require.Equal(t, api.DealHealthTerminated, health[0].Status)
+	require.NotNil(t, health[0].Replacement)
+	require.Len(t, ma.started, 1)
+}
+
+func TestMonitorSlashed(t *testing.T) {
+	ctx := context.Background()
+
+	provider, _ := address.NewIDAddress(1000)
+	backup, _ := address.NewIDAddress(1001)
+	client, _ := address.NewIDAddress(100)
+
+	ma := &mockAPI{
+		head: mock.TipSet(mock.MkBlock(nil, 1, 1)),
+		deals: []api.DealInfo{{
+			ProposalCid: blocks.NewBlock([]byte("deal")).Cid(),
+			State:       storagemarket.StorageDealActive,
+			Provider:    provider,
+			DataRef:     &storagemarket.DataRef{},
+			DealID:      5,
+		}},
+		market: map[abi.DealID]*api.MarketDeal{
+			5: {
+				Proposal: market.DealProposal{Client: client, EndEpoch: build.MinDealDuration * 2},
+				State:    market.DealState{SectorStartEpoch: 1, SlashEpoch: -1},
+			},
+		},
+		sectors: map[address.Address][]*miner.SectorOnChainInfo{
+			provider: {{SectorNumber: 7, DealIDs: []abi.DealID{5}}},
+		},
+		faults: map[address.Address][]uint64{},
+	}
+
+	m := NewMonitor(ma, dssync.MutexWrap(datastore.NewMapDatastore()), Config{
+		CheckInterval:   time.Minute,
+		FaultThreshold:  time.Hour,
+		BackupProviders: []address.Address{backup},
+	})
+
+	require.NoError(t, m.Check(ctx))
+	require.Equal(t, api.DealHealthActive, m.Health()[0].Status)
+
+	// the deal is slashed and removed from the market state
+	ma.deals[0].State = storagemarket.StorageDealSlashed
+	delete(ma.market, 5)
+	ma.sectors[provider] = nil
+
+	require.NoError(t, m.Check(ctx))
+	health := m.Health()
+	require.Len(t, health, 1)
+	require.Equal(t, api.DealHealthTerminated, health[0].Status)
+	require.NotNil(t, health[0].Replacement)
+	require.Len(t, ma.started, 1)
+	require.Equal(t, client, ma.started[0].Wallet)
+
+	// slashed deals are dropped once they would have ended
+	ma.head = mock.TipSet(func() *types.BlockHeader {
+		b := mock.MkBlock(nil, 1, 3)
+		b.Height = build.MinDealDuration * 2
+		return b
+	}())
+	require.NoError(t, m.Check(ctx))
+	require.Empty(t, m.Health())
+}
@@ -0,0 +1,260 @@
+package httpretrieval
+
+import (
+	"context"
+	"crypto/subtle"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-blockservice"
+	"github.com/ipfs/go-cid"
+	offline "github.com/ipfs/go-ipfs-exchange-offline"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/ipfs/go-merkledag"
+	"github.com/ipld/go-car"
+	"golang.org/x/time/rate"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/dagstore/mount"
+	"github.com/filecoin-project/go-fil-markets/stores"
+)
+
+var log = logging.Logger("httpretrieval")
+
+const (
+	// PiecePath is the path prefix under which unsealed pieces are served by
+	// piece CID
+	PiecePath = "/piece/"
+	// PayloadPath is the path prefix under which payload DAGs are served as CAR
+	// files by root CID
+	PayloadPath = "/ipfs/"
+)
+
+// writeChunk is the largest write done at once when bandwidth is limited
+const writeChunk = 64 << 10
+
+// PieceReader reads unsealed pieces. FetchUnsealedPiece unseals the piece when
+// it has no unsealed copy, so the server checks IsUnsealed first: unsealing is
+// expensive, and HTTP retrievals bypass the pricing and filters of retrieval
+// deals.
+type PieceReader interface {
+	IsUnsealed(ctx context.Context, pieceCid cid.Cid) (bool, error)
+	FetchUnsealedPiece(ctx context.Context, pieceCid cid.Cid) (mount.Reader, error)
+}
+
+// ShardStore gives access to the blocks of the pieces indexed in the dagstore
+type ShardStore interface {
+	GetPiecesContainingBlock(blockCID cid.Cid) ([]cid.Cid, error)
+	LoadShard(ctx context.Context, pieceCid cid.Cid) (stores.ClosableBlockstore, error)
+}
+
+type Config struct {
+	// AccessTokens which clients must present, either in a bearer
+	// Authorization header or in the token query parameter. When empty,
+	// retrievals are open to anyone. Only pieces which already have an
+	// unsealed copy are served either way.
+	AccessTokens []string
+	// MaxBandwidth caps the bytes per second sent to all clients combined,
+	// 0 means unlimited
+	MaxBandwidth int64
+}
+
+// Server serves piece and payload data over plain HTTP, without going
+// through the data-transfer stack. Data is only served from pieces which are
+// already unsealed, HTTP retrievals never trigger an unseal.
+type Server struct {
+	pieces PieceReader
+	shards ShardStore
+
+	tokens  [][]byte
+	limiter *rate.Limiter
+}
+
+func NewServer(pieces PieceReader, shards ShardStore, cfg Config) *Server {
+	s := &Server{
+		pieces: pieces,
+		shards: shards,
+	}
+
+	for _, t := range cfg.AccessTokens {
+		s.tokens = append(s.tokens, []byte(t))
+	}
+
+	if cfg.MaxBandwidth > 0 {
+		burst := int(cfg.MaxBandwidth)
+		if burst > writeChunk {
+			burst = writeChunk
+		}
+		s.limiter = rate.NewLimiter(rate.Limit(cfg.MaxBandwidth), burst)
+	}
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !s.authorized(r) {
+		http.Error(w, "invalid access token", http.StatusUnauthorized)
+		return
+	}
+
+	if s.limiter != nil {
+		w = &limitedWriter{ResponseWriter: w, ctx: r.Context(), limiter: s.limiter}
+	}
+
+	switch {
+	case strings.HasPrefix(r.URL.Path, PiecePath):
+		c, ok := parseCid(w, strings.TrimPrefix(r.URL.Path, PiecePath))
+		if ok {
+			s.servePiece(w, r, c)
+		}
+	case strings.HasPrefix(r.URL.Path, PayloadPath):
+		c, ok := parseCid(w, strings.TrimPrefix(r.URL.Path, PayloadPath))
+		if ok {
+			s.servePayload(w, r, c)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if len(s.tokens) == 0 {
+		return true
+	}
+
+	token := r.URL.Query().Get("token")
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+
+	for _, t := range s.tokens {
+		if subtle.ConstantTimeCompare(t, []byte(token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func parseCid(w http.ResponseWriter, s string) (cid.Cid, bool) {
+	c, err := cid.Parse(s)
+	if err != nil {
+		http.Error(w, "invalid cid: "+err.Error(), http.StatusBadRequest)
+		return cid.Undef, false
+	}
+	return c, true
+}
+
+// unsealed checks whether the piece has an unsealed copy
+func (s *Server) unsealed(ctx context.Context, pieceCid cid.Cid) bool {
+	ok, err := s.pieces.IsUnsealed(ctx, pieceCid)
+	if err != nil {
+		log.Warnw("checking whether piece is unsealed", "piece", pieceCid, "error", err)
+		return false
+	}
+	return ok
+}
+
+// servePiece serves the unsealed piece, with support for range requests
+func (s *Server) servePiece(w http.ResponseWriter, r *http.Request, pieceCid cid.Cid) {
+	if !s.unsealed(r.Context(), pieceCid) {
+		http.Error(w, "piece not found", http.StatusNotFound)
+		return
+	}
+
+	rd, err := s.pieces.FetchUnsealedPiece(r.Context(), pieceCid)
+	if err != nil {
+		log.Warnw("fetching unsealed piece", "piece", pieceCid, "error", err)
+		http.Error(w, "piece not found", http.StatusNotFound)
+		return
+	}
+	defer rd.Close() //nolint:errcheck
+
+	w.Header().Set("Content-Type", "application/piece")
+	http.ServeContent(w, r, "", time.Time{}, rd)
+}
+
+// servePayload streams the DAG under the root CID as a CAR file, read from the
+// first unsealed piece containing the root block
+func (s *Server) servePayload(w http.ResponseWriter, r *http.Request, root cid.Cid) {
+	ctx := r.Context()
+
+	pieces, err := s.shards.GetPiecesContainingBlock(root)
+	if err != nil || len(pieces) == 0 {
+		http.Error(w, "payload not found", http.StatusNotFound)
+		return
+	}
+
+	var bs stores.ClosableBlockstore
+	for _, p := range pieces {
+		// loading the shard of a sealed piece would unseal it
+		if !s.unsealed(ctx, p) {
+			continue
+		}
+
+		bs, err = s.shards.LoadShard(ctx, p)
+		if err == nil {
+			break
+		}
+		log.Warnw("loading shard", "piece", p, "payload", root, "error", err)
+	}
+	if bs == nil {
+		if err == nil {
+			http.Error(w, "payload not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "loading payload failed", http.StatusInternalServerError)
+		return
+	}
+	defer bs.Close() //nolint:errcheck
+
+	w.Header().Set("Content-Type", "application/vnd.ipld.car")
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	dag := merkledag.NewDAGService(blockservice.New(bs, offline.Exchange(bs)))
+	if err := car.WriteCar(ctx, dag, []cid.Cid{root}, w); err != nil {
+		// the response was already started, all we can do is cut it short
+		log.Warnw("writing payload car", "payload", root, "error", err)
+	}
+}
+
+// limitedWriter throttles writes of a response to the bandwidth allowed by
+// the limiter
+type limitedWriter struct {
+	http.ResponseWriter
+
+	ctx     context.Context
+	limiter *rate.Limiter
+}
+
+func (lw *limitedWriter) Write(p []byte) (int, error) {
+	var written int
+	for len(p) > 0 {
+		n := len(p)
+		if n > lw.limiter.Burst() {
+			n = lw.limiter.Burst()
+		}
+
+		if err := lw.limiter.WaitN(lw.ctx, n); err != nil {
+			return written, xerrors.Errorf("waiting for bandwidth: %w", err)
+		}
+
+		n, err := lw.ResponseWriter.Write(p[:n])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+var _ io.Writer = &limitedWriter{}
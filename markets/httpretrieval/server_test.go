@@ -0,0 +1,218 @@
+//stm: #unit
+package httpretrieval
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	"github.com/ipfs/go-merkledag"
+	"github.com/ipld/go-car"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/dagstore/mount"
+	"github.com/filecoin-project/go-fil-markets/stores"
+)
+
+type pieceReader struct {
+	*bytes.Reader
+}
+
+func (pieceReader) Close() error {
+	return nil
+}
+
+type closableBlockstore struct {
+	bstore.Blockstore
+}
+
+func (closableBlockstore) Close() error {
+	return nil
+}
+
+type mockStore struct {
+	pieceCid cid.Cid
+	piece    []byte
+	sealed   bool
+	unseals  int
+
+	bs    bstore.Blockstore
+	roots map[cid.Cid]cid.Cid
+}
+
+func (m *mockStore) IsUnsealed(ctx context.Context, pieceCid cid.Cid) (bool, error) {
+	if pieceCid != m.pieceCid {
+		return false, xerrors.Errorf("piece %s not found", pieceCid)
+	}
+	return !m.sealed, nil
+}
+
+func (m *mockStore) FetchUnsealedPiece(ctx context.Context, pieceCid cid.Cid) (mount.Reader, error) {
+	if pieceCid != m.pieceCid {
+		return nil, xerrors.Errorf("piece %s not found", pieceCid)
+	}
+	if m.sealed {
+		m.unseals++
+	}
+	return pieceReader{bytes.NewReader(m.piece)}, nil
+}
+
+func (m *mockStore) GetPiecesContainingBlock(blockCID cid.Cid) ([]cid.Cid, error) {
+	p, ok := m.roots[blockCID]
+	if !ok {
+		return nil, xerrors.Errorf("block %s not found", blockCID)
+	}
+	return []cid.Cid{p}, nil
+}
+
+func (m *mockStore) LoadShard(ctx context.Context, pieceCid cid.Cid) (stores.ClosableBlockstore, error) {
+	if m.sealed {
+		m.unseals++
+	}
+	return closableBlockstore{m.bs}, nil
+}
+
+func newMockStore(t *testing.T) (*mockStore, cid.Cid) {
+	ctx := context.Background()
+
+	pieceCid := merkledag.NewRawNode([]byte("piece")).Cid()
+	bs := bstore.NewBlockstore(dssync.MutexWrap(datastore.NewMapDatastore()))
+
+	leaf := merkledag.NewRawNode([]byte("leaf"))
+	root := &merkledag.ProtoNode{}
+	require.NoError(t, root.AddNodeLink("leaf", leaf))
+	require.NoError(t, bs.Put(ctx, leaf))
+	require.NoError(t, bs.Put(ctx, root))
+
+	return &mockStore{
+		pieceCid: pieceCid,
+		piece:    []byte(strings.Repeat("0123456789", 1000)),
+		bs:       bs,
+		roots:    map[cid.Cid]cid.Cid{root.Cid(): pieceCid},
+	}, root.Cid()
+}
+
+func get(t *testing.T, url string, header http.Header) (*http.Response, []byte) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	req.Header = header
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close() //nolint:errcheck
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, body
+}
+
+func TestServePiece(t *testing.T) {
+	ms, _ := newMockStore(t)
+	srv := httptest.NewServer(NewServer(ms, ms, Config{}))
+	defer srv.Close()
+
+	resp, body := get(t, srv.URL+PiecePath+ms.pieceCid.String(), nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, ms.piece, body)
+
+	resp, body = get(t, srv.URL+PiecePath+ms.pieceCid.String(), http.Header{"Range": []string{"bytes=100-199"}})
+	require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	require.Equal(t, ms.piece[100:200], body)
+
+	resp, _ = get(t, srv.URL+PiecePath+merkledag.NewRawNode([]byte("other")).Cid().String(), nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, _ = get(t, srv.URL+PiecePath+"notacid", nil)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestServePayload(t *testing.T) {
+	ms, root := newMockStore(t)
+	srv := httptest.NewServer(NewServer(ms, ms, Config{}))
+	defer srv.Close()
+
+	resp, body := get(t, srv.URL+PayloadPath+root.String(), nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	rd, err := car.NewCarReader(bytes.NewReader(body))
+	require.NoError(t, err)
+	require.Equal(t, []cid.Cid{root}, rd.Header.Roots)
+
+	var blocks int
+	for {
+		_, err := rd.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		blocks++
+	}
+	require.Equal(t, 2, blocks)
+
+	resp, _ = get(t, srv.URL+PayloadPath+ms.pieceCid.String(), nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestSealedPieces(t *testing.T) {
+	ms, root := newMockStore(t)
+	ms.sealed = true
+	srv := httptest.NewServer(NewServer(ms, ms, Config{}))
+	defer srv.Close()
+
+	// pieces without an unsealed copy aren't served, and never unsealed
+	resp, _ := get(t, srv.URL+PiecePath+ms.pieceCid.String(), nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, _ = get(t, srv.URL+PayloadPath+root.String(), nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	require.Zero(t, ms.unseals)
+}
+
+func TestAccessTokens(t *testing.T) {
+	ms, _ := newMockStore(t)
+	srv := httptest.NewServer(NewServer(ms, ms, Config{AccessTokens: []string{"secret"}}))
+	defer srv.Close()
+
+	url := srv.URL + PiecePath + ms.pieceCid.String()
+
+	resp, _ := get(t, url, nil)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, _ = get(t, url, http.Header{"Authorization": []string{"Bearer wrong"}})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, _ = get(t, url, http.Header{"Authorization": []string{"Bearer secret"}})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, _ = get(t, url+"?token=secret", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestMaxBandwidth(t *testing.T) {
+	ms, _ := newMockStore(t)
+	srv := httptest.NewServer(NewServer(ms, ms, Config{MaxBandwidth: 10000}))
+	defer srv.Close()
+
+	// 10000 bytes with a 10000 byte/s limit take the whole burst
+	start := time.Now()
+	resp, body := get(t, srv.URL+PiecePath+ms.pieceCid.String(), nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, ms.piece, body)
+
+	// the burst is spent, the second request has to wait
+	resp, body = get(t, srv.URL+PiecePath+ms.pieceCid.String(), nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, ms.piece, body)
+	require.Greater(t, time.Since(start), 800*time.Millisecond)
+}
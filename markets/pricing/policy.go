@@ -0,0 +1,63 @@
+package pricing
+
+import (
+	"context"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+// PolicyRetrievalPricingFunc prices retrieval deals with the retrieval pricing
+// policy, read on each call so that updates apply to the following deals
+func PolicyRetrievalPricingFunc(getPolicy dtypes.GetRetrievalPricingPolicyFunc) dtypes.RetrievalPricingFunc {
+	return func(ctx context.Context, pricingInput retrievalmarket.PricingInput) (retrievalmarket.Ask, error) {
+		policy, err := getPolicy()
+		if err != nil {
+			return retrievalmarket.Ask{}, xerrors.Errorf("getting retrieval pricing policy: %w", err)
+		}
+
+		return PriceWithPolicy(policy, pricingInput), nil
+	}
+}
+
+// PriceWithPolicy returns the ask of a retrieval priced with the policy
+func PriceWithPolicy(policy dtypes.RetrievalPricingPolicy, pricingInput retrievalmarket.PricingInput) retrievalmarket.Ask {
+	ask := pricingInput.CurrentAsk
+
+	for _, c := range policy.FreeClients {
+		if c == pricingInput.Client {
+			ask.PricePerByte = big.Zero()
+			ask.UnsealPrice = big.Zero()
+			return ask
+		}
+	}
+
+	for _, pp := range policy.PiecePrices {
+		if pp.PieceCID.Equals(pricingInput.PieceCID) {
+			if pp.PricePerByte != nil {
+				ask.PricePerByte = *pp.PricePerByte
+			}
+			if pp.UnsealPrice != nil {
+				ask.UnsealPrice = *pp.UnsealPrice
+			}
+			break
+		}
+	}
+
+	if pricingInput.Unsealed {
+		// don't charge for unsealing if we have an unsealed copy
+		ask.UnsealPrice = big.Zero()
+	} else if policy.SealedPricePercent > 0 {
+		ask.PricePerByte = big.Div(big.Mul(ask.PricePerByte, big.NewIntUnsigned(policy.SealedPricePercent)), big.NewInt(100))
+	}
+
+	if pricingInput.VerifiedDeal && policy.VerifiedDealsFreeTransfer {
+		ask.PricePerByte = big.Zero()
+	}
+
+	return ask
+}
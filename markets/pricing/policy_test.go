@@ -0,0 +1,82 @@
+//stm: #unit
+package pricing
+
+import (
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+func TestPriceWithPolicy(t *testing.T) {
+	piece := blocks.NewBlock([]byte("piece")).Cid()
+	other := blocks.NewBlock([]byte("other")).Cid()
+
+	input := retrievalmarket.PricingInput{
+		PieceCID: other,
+		Client:   peer.ID("client"),
+		CurrentAsk: retrievalmarket.Ask{
+			PricePerByte: big.NewInt(100),
+			UnsealPrice:  big.NewInt(1000),
+		},
+	}
+
+	piecePrice, pieceUnseal := big.NewInt(50), big.NewInt(500)
+	partial := blocks.NewBlock([]byte("partial")).Cid()
+
+	policy := dtypes.RetrievalPricingPolicy{
+		FreeClients:               []peer.ID{"friend"},
+		VerifiedDealsFreeTransfer: true,
+		PiecePrices: []dtypes.RetrievalPiecePrice{
+			{PieceCID: piece, PricePerByte: &piecePrice, UnsealPrice: &pieceUnseal},
+			{PieceCID: partial, UnsealPrice: &pieceUnseal},
+		},
+		SealedPricePercent: 200,
+	}
+
+	// sealed retrievals are priced in percent of the ask
+	ask := PriceWithPolicy(policy, input)
+	require.Equal(t, big.NewInt(200), ask.PricePerByte)
+	require.Equal(t, big.NewInt(1000), ask.UnsealPrice)
+
+	// no unseal price with an unsealed copy
+	unsealed := input
+	unsealed.Unsealed = true
+	ask = PriceWithPolicy(policy, unsealed)
+	require.Equal(t, big.NewInt(100), ask.PricePerByte)
+	require.Equal(t, big.Zero(), ask.UnsealPrice)
+
+	// piece overrides
+	pieceInput := unsealed
+	pieceInput.PieceCID = piece
+	pieceInput.Unsealed = false
+	ask = PriceWithPolicy(policy, pieceInput)
+	require.Equal(t, big.NewInt(100), ask.PricePerByte)
+	require.Equal(t, big.NewInt(500), ask.UnsealPrice)
+
+	// prices which aren't overridden are taken from the ask
+	pieceInput.PieceCID = partial
+	ask = PriceWithPolicy(policy, pieceInput)
+	require.Equal(t, big.NewInt(200), ask.PricePerByte)
+	require.Equal(t, big.NewInt(500), ask.UnsealPrice)
+
+	// free transfer for verified deals
+	verified := input
+	verified.VerifiedDeal = true
+	ask = PriceWithPolicy(policy, verified)
+	require.Equal(t, big.Zero(), ask.PricePerByte)
+	require.Equal(t, big.NewInt(1000), ask.UnsealPrice)
+
+	// free clients
+	friend := input
+	friend.Client = peer.ID("friend")
+	ask = PriceWithPolicy(policy, friend)
+	require.Equal(t, big.Zero(), ask.PricePerByte)
+	require.Equal(t, big.Zero(), ask.UnsealPrice)
+}
@@ -12,7 +12,6 @@ import (
 	"github.com/filecoin-project/go-state-types/abi"
 	paychtypes "github.com/filecoin-project/go-state-types/builtin/v8/paych"
 
-	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/node/impl/full"
 	payapi "github.com/filecoin-project/lotus/node/impl/paych"
@@ -43,15 +42,13 @@ func NewRetrievalClientNode(forceOffChain bool, payAPI payapi.PaychAPI, chainAPI
 func (rcn *retrievalClientNode) GetOrCreatePaymentChannel(ctx context.Context, clientAddress address.Address, minerAddress address.Address, clientFundsAvailable abi.TokenAmount, tok shared.TipSetToken) (address.Address, cid.Cid, error) {
 	// TODO: respect the provided TipSetToken (a serialized TipSetKey) when
 	// querying the chain
-	ci, err := rcn.payAPI.PaychGet(ctx, clientAddress, minerAddress, clientFundsAvailable, api.PaychGetOpts{
-		OffChain: rcn.forceOffChain,
-	})
+	ch, mcid, err := rcn.payAPI.Budget.GetPaych(ctx, clientAddress, minerAddress, clientFundsAvailable, rcn.forceOffChain)
 	if err != nil {
 		log.Errorw("paych get failed", "error", err)
 		return address.Undef, cid.Undef, err
 	}
 
-	return ci.Channel, ci.WaitSentinel, nil
+	return ch, mcid, nil
 }
 
 // Allocate late creates a lane within a payment channel so that calls to
@@ -71,6 +68,7 @@ func (rcn *retrievalClientNode) CreatePaymentVoucher(ctx context.Context, paymen
 	if err != nil {
 		return nil, err
 	}
+	rcn.payAPI.Budget.Touch(paymentChannel)
 	if voucher.Voucher == nil {
 		return nil, retrievalmarket.NewShortfallError(voucher.Shortfall)
 	}
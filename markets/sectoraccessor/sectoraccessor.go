@@ -67,6 +67,9 @@ func (sa *sectorAccessor) UnsealSectorAt(ctx context.Context, sectorID abi.Secto
 		commD = *si.CommD
 	}
 
+	// Retrievals are unsealed ahead of the other unseal requests
+	ctx = sealer.WithUnsealClass(ctx, storiface.UnsealRetrieval)
+
 	// Get a reader for the piece, unsealing the piece if necessary
 	log.Debugf("read piece in sector %d, pieceOffset %d, length %d from miner %d", sectorID, pieceOffset, length, mid)
 	r, unsealed, err := sa.pp.ReadPiece(ctx, ref, storiface.UnpaddedByteIndex(pieceOffset), length, si.Ticket.Value, commD)
@@ -8,10 +8,12 @@ import (
 	"time"
 
 	"github.com/ipfs/go-cid"
+	"github.com/raulk/clock"
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-address"
+	cborutil "github.com/filecoin-project/go-cbor-util"
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/big"
 	"github.com/filecoin-project/go-state-types/builtin"
@@ -21,6 +23,7 @@ import (
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/messagepool"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/storage/ctladdr"
@@ -36,6 +39,11 @@ type dealPublisherAPI interface {
 	StateAccountKey(context.Context, address.Address, types.TipSetKey) (address.Address, error)
 	StateLookupID(context.Context, address.Address, types.TipSetKey) (address.Address, error)
 	StateCall(context.Context, *types.Message, types.TipSetKey) (*api.InvocResult, error)
+
+	StateSearchMsg(ctx context.Context, from types.TipSetKey, msg cid.Cid, limit abi.ChainEpoch, allowReplaced bool) (*api.MsgLookup, error)
+	GasEstimateMessageGas(context.Context, *types.Message, *api.MessageSendSpec, types.TipSetKey) (*types.Message, error)
+	WalletSignMessage(context.Context, address.Address, *types.Message) (*types.SignedMessage, error)
+	MpoolPush(context.Context, *types.SignedMessage) (cid.Cid, error)
 }
 
 // DealPublisher batches deal publishing so that many deals can be included in
@@ -46,6 +54,8 @@ type dealPublisherAPI interface {
 // There is a configurable maximum number of deals that can be included in one
 // message. When the limit is reached the DealPublisher immediately submits a
 // publish message with all deals in the queue.
+// When a publish message doesn't land on chain within the stuck timeout, it is
+// replaced with a message paying a higher gas premium.
 type DealPublisher struct {
 	api dealPublisherAPI
 	as  *ctladdr.AddressSelector
@@ -56,6 +66,7 @@ type DealPublisher struct {
 	maxDealsPerPublishMsg uint64
 	publishPeriod         time.Duration
 	publishSpec           *api.MessageSendSpec
+	stuckTimeout          time.Duration
 
 	lk                      sync.Mutex
 	pending                 []*pendingDeal
@@ -94,6 +105,9 @@ type PublishMsgConfig struct {
 	MaxDealsPerMsg uint64
 	// Minimum start epoch buffer to give time for sealing of sector with deal
 	StartEpochSealingBuffer uint64
+	// The amount of time after which a publish message which hasn't landed
+	// on chain is replaced with a higher gas premium, 0 to disable
+	StuckTimeout time.Duration
 }
 
 func NewDealPublisher(
@@ -133,9 +147,56 @@ func newDealPublisher(
 		publishPeriod:           publishMsgCfg.Period,
 		startEpochSealingBuffer: abi.ChainEpoch(publishMsgCfg.StartEpochSealingBuffer),
 		publishSpec:             publishSpec,
+		stuckTimeout:            publishMsgCfg.StuckTimeout,
+	}
+}
+
+// PublishConfig returns the batching configuration of publish messages
+func (p *DealPublisher) PublishConfig() api.DealPublishConfig {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	return api.DealPublishConfig{
+		Period:         p.publishPeriod,
+		MaxDealsPerMsg: p.maxDealsPerPublishMsg,
 	}
 }
 
+// SetPublishConfig changes the batching configuration of publish messages.
+// The deals already queued are published right away if they reach the new
+// limits, otherwise the current publish period is shortened or extended to
+// the new period.
+func (p *DealPublisher) SetPublishConfig(cfg api.DealPublishConfig) error {
+	if cfg.MaxDealsPerMsg == 0 {
+		return xerrors.Errorf("the maximum number of deals per publish message must be positive")
+	}
+
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	p.publishPeriod = cfg.Period
+	p.maxDealsPerPublishMsg = cfg.MaxDealsPerMsg
+	log.Infow("deal publish config changed", "period", cfg.Period, "maxDealsPerMsg", cfg.MaxDealsPerMsg)
+
+	p.filterCancelledDeals()
+	if len(p.pending) == 0 {
+		return nil
+	}
+
+	if uint64(len(p.pending)) >= p.maxDealsPerPublishMsg || p.publishPeriod == 0 {
+		p.publishAllDeals()
+		return nil
+	}
+
+	// Restart the timer of the current publish period with the new period
+	if p.cancelWaitForMoreDeals != nil {
+		p.cancelWaitForMoreDeals()
+		timer := build.Clock.Timer(build.Clock.Until(p.publishPeriodStart.Add(p.publishPeriod)))
+		p.waitForTimer(timer)
+	}
+	return nil
+}
+
 // PendingDeals returns the list of deals that are queued up to be published
 func (p *DealPublisher) PendingDeals() api.PendingDealInfo {
 	p.lk.Lock()
@@ -158,7 +219,41 @@ func (p *DealPublisher) PendingDeals() api.PendingDealInfo {
 		Deals:              pending,
 		PublishPeriodStart: p.publishPeriodStart,
 		PublishPeriod:      p.publishPeriod,
+		MaxDealsPerMsg:     p.maxDealsPerPublishMsg,
+	}
+}
+
+// ForcePublishPendingDeal publishes a single pending deal without waiting for
+// the publish period to elapse, leaving the other deals in the queue
+func (p *DealPublisher) ForcePublishPendingDeal(propCid cid.Cid) error {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	p.filterCancelledDeals()
+	for i, pd := range p.pending {
+		nd, err := cborutil.AsIpld(&pd.deal)
+		if err != nil {
+			return xerrors.Errorf("computing proposal cid: %w", err)
+		}
+		if nd.Cid() != propCid {
+			continue
+		}
+
+		log.Infow("force publishing deal", "proposal", propCid)
+		p.pending = append(p.pending[:i], p.pending[i+1:]...)
+
+		// If that was the only queued deal, stop waiting for more deals
+		if len(p.pending) == 0 && p.cancelWaitForMoreDeals != nil {
+			p.cancelWaitForMoreDeals()
+			p.cancelWaitForMoreDeals = nil
+			p.publishPeriodStart = time.Time{}
+		}
+
+		go p.publishReady([]*pendingDeal{pd})
+		return nil
 	}
+
+	return xerrors.Errorf("deal %s is not in the publish queue", propCid)
 }
 
 // ForcePublishPendingDeals publishes all pending deals without waiting for
@@ -232,13 +327,19 @@ func (p *DealPublisher) waitForMoreDeals() {
 
 	// Set a timeout to wait for more deals to arrive
 	log.Infof("waiting publish deals queue period of %s before publishing", p.publishPeriod)
-	ctx, cancel := context.WithCancel(p.ctx)
 
 	// Create the timer _before_ taking the current time so publishPeriod+timeout is always >=
 	// the actual timer timeout.
 	timer := build.Clock.Timer(p.publishPeriod)
 
 	p.publishPeriodStart = build.Clock.Now()
+	p.waitForTimer(timer)
+}
+
+// waitForTimer publishes all pending deals when the timer fires, unless the
+// wait is cancelled first
+func (p *DealPublisher) waitForTimer(timer *clock.Timer) {
+	ctx, cancel := context.WithCancel(p.ctx)
 	p.cancelWaitForMoreDeals = cancel
 
 	go func() {
@@ -422,9 +523,82 @@ func (p *DealPublisher) publishDealProposals(deals []market.ClientDealProposal)
 	if err != nil {
 		return cid.Undef, err
 	}
+
+	if p.stuckTimeout > 0 {
+		go p.bumpWhenStuck(smsg)
+	}
 	return smsg.Cid(), nil
 }
 
+// bumpWhenStuck replaces the publish message with a message paying a higher
+// gas premium each time it fails to land on chain within the stuck timeout.
+// The deals waiting for the message find the replacement, as they allow
+// replaced messages.
+func (p *DealPublisher) bumpWhenStuck(smsg *types.SignedMessage) {
+	msgCid := smsg.Cid()
+	msg := smsg.Message
+
+	for {
+		timer := build.Clock.Timer(p.stuckTimeout)
+		select {
+		case <-p.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		lookup, err := p.api.StateSearchMsg(p.ctx, types.EmptyTSK, msgCid, api.LookbackNoLimit, true)
+		if err != nil {
+			log.Warnw("searching deal publish message", "cid", msgCid, "error", err)
+			continue
+		}
+		if lookup != nil {
+			return
+		}
+
+		replacement, err := p.replaceMessage(msg)
+		if err != nil {
+			log.Errorw("replacing stuck deal publish message", "cid", msgCid, "error", err)
+			return
+		}
+
+		log.Warnw("deal publish message stuck, replaced it with a higher gas premium",
+			"cid", msgCid, "replacement", replacement.Cid(), "premium", replacement.Message.GasPremium)
+		msg = replacement.Message
+	}
+}
+
+// replaceMessage pushes a message with the nonce of msg and the minimum gas
+// premium allowed to replace it, within the max publish fee
+func (p *DealPublisher) replaceMessage(msg types.Message) (*types.SignedMessage, error) {
+	minRBF := messagepool.ComputeMinRBF(msg.GasPremium)
+
+	msg.GasFeeCap = big.Zero()
+	msg.GasPremium = big.Zero()
+	est, err := p.api.GasEstimateMessageGas(p.ctx, &msg, p.publishSpec, types.EmptyTSK)
+	if err != nil {
+		return nil, xerrors.Errorf("estimating gas: %w", err)
+	}
+
+	msg.GasPremium = big.Max(est.GasPremium, minRBF)
+	msg.GasFeeCap = big.Max(est.GasFeeCap, msg.GasPremium)
+	messagepool.CapGasFee(func() (abi.TokenAmount, error) {
+		return abi.TokenAmount(config.DefaultDefaultMaxFee), nil
+	}, &msg, p.publishSpec)
+	if msg.GasPremium.LessThan(minRBF) {
+		return nil, xerrors.Errorf("the max publish deals fee doesn't allow a gas premium of %s", minRBF)
+	}
+
+	smsg, err := p.api.WalletSignMessage(p.ctx, msg.From, &msg)
+	if err != nil {
+		return nil, xerrors.Errorf("signing message: %w", err)
+	}
+	if _, err := p.api.MpoolPush(p.ctx, smsg); err != nil {
+		return nil, xerrors.Errorf("pushing message: %w", err)
+	}
+	return smsg, nil
+}
+
 func pieceCids(deals []market.ClientDealProposal) string {
 	cids := make([]string, 0, len(deals))
 	for _, dl := range deals {
@@ -13,6 +13,7 @@ import (
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-address"
+	cborutil "github.com/filecoin-project/go-cbor-util"
 	"github.com/filecoin-project/go-state-types/abi"
 	markettypes "github.com/filecoin-project/go-state-types/builtin/v8/market"
 	"github.com/filecoin-project/go-state-types/crypto"
@@ -232,6 +233,96 @@ func TestForcePublish(t *testing.T) {
 	checkPublishedDeals(t, dpapi, dealsToPublish, []int{2})
 }
 
+func TestForcePublishDeal(t *testing.T) {
+	dpapi := newDPAPI(t)
+
+	dp := newDealPublisher(dpapi, nil, PublishMsgConfig{
+		Period:         time.Hour,
+		MaxDealsPerMsg: 10,
+	}, &api.MessageSendSpec{MaxFee: abi.NewTokenAmount(1)})
+
+	deal := publishDeal(t, dp, 0, false, false)
+	other := publishDeal(t, dp, 0, false, false)
+
+	// Allow a moment for them to be queued
+	build.Clock.Sleep(10 * time.Millisecond)
+
+	require.Error(t, dp.ForcePublishPendingDeal(generateCids(1)[0]))
+
+	nd, err := cborutil.AsIpld(&deal)
+	require.NoError(t, err)
+	require.NoError(t, dp.ForcePublishPendingDeal(nd.Cid()))
+
+	// The other deal is still waiting for the publish period
+	pendingInfo := dp.PendingDeals()
+	require.Len(t, pendingInfo.Deals, 1)
+	require.Equal(t, other.Proposal.PieceCID, pendingInfo.Deals[0].Proposal.PieceCID)
+
+	checkPublishedDeals(t, dpapi, []markettypes.ClientDealProposal{deal}, []int{1})
+}
+
+func TestSetPublishConfig(t *testing.T) {
+	dpapi := newDPAPI(t)
+
+	dp := newDealPublisher(dpapi, nil, PublishMsgConfig{
+		Period:         time.Hour,
+		MaxDealsPerMsg: 10,
+	}, &api.MessageSendSpec{MaxFee: abi.NewTokenAmount(1)})
+
+	var dealsToPublish []markettypes.ClientDealProposal
+	for i := 0; i < 3; i++ {
+		dealsToPublish = append(dealsToPublish, publishDeal(t, dp, 0, false, false))
+	}
+
+	// Allow a moment for them to be queued
+	build.Clock.Sleep(10 * time.Millisecond)
+	require.Len(t, dp.PendingDeals().Deals, 3)
+
+	require.Error(t, dp.SetPublishConfig(api.DealPublishConfig{Period: time.Hour}))
+
+	// The queue is full with the new limit, so the deals are published
+	require.NoError(t, dp.SetPublishConfig(api.DealPublishConfig{Period: time.Hour, MaxDealsPerMsg: 3}))
+	require.Equal(t, api.DealPublishConfig{Period: time.Hour, MaxDealsPerMsg: 3}, dp.PublishConfig())
+	require.Len(t, dp.PendingDeals().Deals, 0)
+
+	checkPublishedDeals(t, dpapi, dealsToPublish, []int{3})
+}
+
+func TestReplaceStuckPublishMsg(t *testing.T) {
+	oldClock := build.Clock
+	t.Cleanup(func() { build.Clock = oldClock })
+	mc := clock.NewMock()
+	build.Clock = mc
+
+	dpapi := newDPAPI(t)
+
+	dp := newDealPublisher(dpapi, nil, PublishMsgConfig{
+		Period:         0,
+		MaxDealsPerMsg: 10,
+		StuckTimeout:   time.Hour,
+	}, &api.MessageSendSpec{MaxFee: abi.NewTokenAmount(1)})
+	t.Cleanup(dp.Shutdown)
+
+	deal := publishDeal(t, dp, 0, false, false)
+	checkPublishedDeals(t, dpapi, []markettypes.ClientDealProposal{deal}, []int{1})
+
+	// The message doesn't land on chain within the stuck timeout, so it gets
+	// replaced with a higher premium
+	var replacement *types.SignedMessage
+	require.Eventually(t, func() bool {
+		mc.Add(time.Hour)
+		select {
+		case replacement = <-dpapi.replacedMsgs:
+			return true
+		default:
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.Equal(t, abi.NewTokenAmount(100), replacement.Message.GasPremium)
+	require.Equal(t, abi.NewTokenAmount(1000), replacement.Message.GasFeeCap)
+}
+
 func publishDeal(t *testing.T, dp *DealPublisher, invalid int, ctxCancelled bool, expired bool) markettypes.ClientDealProposal {
 	ctx, cancel := context.WithCancel(context.Background())
 	t.Cleanup(cancel)
@@ -346,6 +437,7 @@ type dpAPI struct {
 
 	stateMinerInfoCalls chan address.Address
 	pushedMsgs          chan *types.Message
+	replacedMsgs        chan *types.SignedMessage
 }
 
 func newDPAPI(t *testing.T) *dpAPI {
@@ -354,6 +446,7 @@ func newDPAPI(t *testing.T) *dpAPI {
 		worker:              getWorkerActor(t),
 		stateMinerInfoCalls: make(chan address.Address, 128),
 		pushedMsgs:          make(chan *types.Message, 128),
+		replacedMsgs:        make(chan *types.SignedMessage, 128),
 	}
 }
 
@@ -378,9 +471,35 @@ func (d *dpAPI) StateMinerInfo(ctx context.Context, address address.Address, key
 
 func (d *dpAPI) MpoolPushMessage(ctx context.Context, msg *types.Message, spec *api.MessageSendSpec) (*types.SignedMessage, error) {
 	d.pushedMsgs <- msg
+
+	// the gas values the message pool would set
+	out := *msg
+	out.GasPremium = abi.NewTokenAmount(50)
+	out.GasFeeCap = abi.NewTokenAmount(500)
+	return &types.SignedMessage{Message: out}, nil
+}
+
+func (d *dpAPI) StateSearchMsg(ctx context.Context, from types.TipSetKey, msg cid.Cid, limit abi.ChainEpoch, allowReplaced bool) (*api.MsgLookup, error) {
+	// messages never land on chain
+	return nil, nil
+}
+
+func (d *dpAPI) GasEstimateMessageGas(ctx context.Context, msg *types.Message, spec *api.MessageSendSpec, tsk types.TipSetKey) (*types.Message, error) {
+	out := *msg
+	out.GasPremium = abi.NewTokenAmount(100)
+	out.GasFeeCap = abi.NewTokenAmount(1000)
+	return &out, nil
+}
+
+func (d *dpAPI) WalletSignMessage(ctx context.Context, a address.Address, msg *types.Message) (*types.SignedMessage, error) {
 	return &types.SignedMessage{Message: *msg}, nil
 }
 
+func (d *dpAPI) MpoolPush(ctx context.Context, smsg *types.SignedMessage) (cid.Cid, error) {
+	d.replacedMsgs <- smsg
+	return smsg.Cid(), nil
+}
+
 func (d *dpAPI) WalletBalance(ctx context.Context, a address.Address) (types.BigInt, error) {
 	panic("don't call me")
 }
@@ -0,0 +1,339 @@
+package transferlimit
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-graphsync"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"golang.org/x/time/rate"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+)
+
+var log = logging.Logger("transferlimit")
+
+const windowTimeFormat = "15:04"
+
+type requestKey struct {
+	p  peer.ID
+	id graphsync.RequestID
+}
+
+type request struct {
+	admitted bool
+	done     bool
+}
+
+type peerState struct {
+	limiter *rate.Limiter
+	active  int
+	queued  int
+	sent    uint64
+}
+
+type window struct {
+	api.BandwidthWindow
+	start, end int // minutes since midnight
+}
+
+func (w window) contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	if w.start <= w.end {
+		return m >= w.start && m < w.end
+	}
+	return m >= w.start || m < w.end
+}
+
+// Scheduler limits the bandwidth and the number of graphsync responses sent
+// by the node, so that serving retrievals doesn't starve other users of the
+// storage, like WindowPoSt. Bandwidth caps can change with the time of day.
+type Scheduler struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	lk       sync.Mutex
+	limits   api.DataTransferLimits
+	windows  []window
+	limiter  *rate.Limiter
+	peers    map[peer.ID]*peerState
+	requests map[requestKey]*request
+	active   int
+	queued   int
+
+	// closed and replaced when a transfer slot frees up or the limits change
+	changed chan struct{}
+}
+
+func NewScheduler(limits api.DataTransferLimits) (*Scheduler, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Scheduler{
+		ctx:      ctx,
+		cancel:   cancel,
+		limiter:  rate.NewLimiter(rate.Inf, 0),
+		peers:    map[peer.ID]*peerState{},
+		requests: map[requestKey]*request{},
+		changed:  make(chan struct{}),
+	}
+
+	if err := s.SetLimits(limits); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Register hooks the scheduler into the responses sent by the graphsync
+// instance
+func (s *Scheduler) Register(gs graphsync.GraphExchange) {
+	gs.RegisterOutgoingBlockHook(func(p peer.ID, request graphsync.RequestData, block graphsync.BlockData, hookActions graphsync.OutgoingBlockHookActions) {
+		s.admit(requestKey{p, request.ID()})
+		s.throttle(p, block.BlockSizeOnWire())
+	})
+	gs.RegisterCompletedResponseListener(func(p peer.ID, request graphsync.RequestData, status graphsync.ResponseStatusCode) {
+		s.release(requestKey{p, request.ID()})
+	})
+	gs.RegisterRequestorCancelledListener(func(p peer.ID, request graphsync.RequestData) {
+		s.release(requestKey{p, request.ID()})
+	})
+	gs.RegisterNetworkErrorListener(func(p peer.ID, request graphsync.RequestData, err error) {
+		s.release(requestKey{p, request.ID()})
+	})
+}
+
+// Stop releases all the transfers waiting on the scheduler
+func (s *Scheduler) Stop() {
+	s.cancel()
+}
+
+// SetLimits replaces the limits, applying them to the transfers in progress
+func (s *Scheduler) SetLimits(limits api.DataTransferLimits) error {
+	if limits.MaxBandwidth < 0 || limits.MaxBandwidthPerPeer < 0 ||
+		limits.MaxConcurrentTransfers < 0 || limits.MaxConcurrentTransfersPerPeer < 0 {
+		return xerrors.Errorf("limits can't be negative")
+	}
+
+	windows := make([]window, 0, len(limits.Schedule))
+	for _, bw := range limits.Schedule {
+		if bw.MaxBandwidth < 0 || bw.MaxBandwidthPerPeer < 0 {
+			return xerrors.Errorf("window %s-%s: bandwidth can't be negative", bw.Start, bw.End)
+		}
+
+		start, err := time.Parse(windowTimeFormat, bw.Start)
+		if err != nil {
+			return xerrors.Errorf("parsing window start: %w", err)
+		}
+		end, err := time.Parse(windowTimeFormat, bw.End)
+		if err != nil {
+			return xerrors.Errorf("parsing window end: %w", err)
+		}
+
+		windows = append(windows, window{
+			BandwidthWindow: bw,
+			start:           start.Hour()*60 + start.Minute(),
+			end:             end.Hour()*60 + end.Minute(),
+		})
+	}
+
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	s.limits = limits
+	s.windows = windows
+	s.notify()
+	return nil
+}
+
+// Status returns the limits, and the transfers they currently apply to
+func (s *Scheduler) Status() api.DataTransferLimitsStatus {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	bw, peerBw := s.caps()
+	st := api.DataTransferLimitsStatus{
+		Limits:              s.limits,
+		MaxBandwidth:        bw,
+		MaxBandwidthPerPeer: peerBw,
+		Active:              s.active,
+		Queued:              s.queued,
+		Peers:               make([]api.PeerTransferStatus, 0, len(s.peers)),
+	}
+	for p, ps := range s.peers {
+		st.Peers = append(st.Peers, api.PeerTransferStatus{
+			Peer:   p,
+			Active: ps.active,
+			Queued: ps.queued,
+			Sent:   ps.sent,
+		})
+	}
+	sort.Slice(st.Peers, func(i, j int) bool {
+		return st.Peers[i].Peer < st.Peers[j].Peer
+	})
+
+	return st
+}
+
+// caps returns the bandwidth caps in effect at the current time of day
+func (s *Scheduler) caps() (int64, int64) {
+	now := build.Clock.Now()
+	for _, w := range s.windows {
+		if w.contains(now) {
+			return w.MaxBandwidth, w.MaxBandwidthPerPeer
+		}
+	}
+	return s.limits.MaxBandwidth, s.limits.MaxBandwidthPerPeer
+}
+
+func (s *Scheduler) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *Scheduler) peer(p peer.ID) *peerState {
+	ps, ok := s.peers[p]
+	if !ok {
+		ps = &peerState{limiter: rate.NewLimiter(rate.Inf, 0)}
+		s.peers[p] = ps
+	}
+	return ps
+}
+
+// forget drops the state of peers without transfers
+func (s *Scheduler) forget(p peer.ID, ps *peerState) {
+	if ps.active == 0 && ps.queued == 0 {
+		delete(s.peers, p)
+	}
+}
+
+func (s *Scheduler) canStart(ps *peerState) bool {
+	if s.limits.MaxConcurrentTransfers > 0 && s.active >= s.limits.MaxConcurrentTransfers {
+		return false
+	}
+	if s.limits.MaxConcurrentTransfersPerPeer > 0 && ps.active >= s.limits.MaxConcurrentTransfersPerPeer {
+		return false
+	}
+	return true
+}
+
+// admit blocks until the transfer fits within the concurrent transfer limits
+func (s *Scheduler) admit(key requestKey) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	if _, ok := s.requests[key]; ok {
+		return
+	}
+
+	r := &request{}
+	s.requests[key] = r
+	ps := s.peer(key.p)
+
+	s.queued++
+	ps.queued++
+	defer func() {
+		s.queued--
+		ps.queued--
+		s.forget(key.p, ps)
+	}()
+
+	for !r.done {
+		if s.canStart(ps) {
+			r.admitted = true
+			s.active++
+			ps.active++
+			return
+		}
+
+		changed := s.changed
+		s.lk.Unlock()
+		select {
+		case <-changed:
+		case <-s.ctx.Done():
+			s.lk.Lock()
+			return
+		}
+		s.lk.Lock()
+	}
+}
+
+// release frees the slot of a finished transfer
+func (s *Scheduler) release(key requestKey) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	r, ok := s.requests[key]
+	if !ok {
+		return
+	}
+	delete(s.requests, key)
+	r.done = true
+
+	if r.admitted {
+		s.active--
+		if ps, ok := s.peers[key.p]; ok {
+			ps.active--
+			s.forget(key.p, ps)
+		}
+	}
+	s.notify()
+}
+
+// throttle waits until the bandwidth caps allow sending n more bytes to the
+// peer
+func (s *Scheduler) throttle(p peer.ID, n uint64) {
+	if n == 0 {
+		return
+	}
+
+	s.lk.Lock()
+	bw, peerBw := s.caps()
+	setCap(s.limiter, bw)
+	ps, ok := s.peers[p]
+	if ok {
+		setCap(ps.limiter, peerBw)
+		ps.sent += n
+	}
+	s.lk.Unlock()
+
+	if ok {
+		if err := wait(s.ctx, ps.limiter, int(n)); err != nil {
+			log.Debugw("waiting for peer bandwidth", "peer", p, "error", err)
+		}
+	}
+	if err := wait(s.ctx, s.limiter, int(n)); err != nil {
+		log.Debugw("waiting for bandwidth", "error", err)
+	}
+}
+
+func setCap(l *rate.Limiter, bytesPerSec int64) {
+	limit, burst := rate.Inf, 0
+	if bytesPerSec > 0 {
+		limit, burst = rate.Limit(bytesPerSec), int(bytesPerSec)
+	}
+	if l.Limit() != limit {
+		l.SetLimit(limit)
+		l.SetBurst(burst)
+	}
+}
+
+// wait takes n tokens from the limiter, at most a burst at a time
+func wait(ctx context.Context, l *rate.Limiter, n int) error {
+	for n > 0 {
+		chunk := n
+		if l.Limit() != rate.Inf && chunk > l.Burst() {
+			chunk = l.Burst()
+		}
+		if chunk <= 0 {
+			return xerrors.Errorf("limiter has no burst")
+		}
+		if err := l.WaitN(ctx, chunk); err != nil {
+			return err
+		}
+		n -= chunk
+	}
+	return nil
+}
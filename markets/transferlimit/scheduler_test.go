@@ -0,0 +1,148 @@
+//stm: #unit
+package transferlimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-graphsync"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/raulk/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+)
+
+func TestConcurrentTransfers(t *testing.T) {
+	s, err := NewScheduler(api.DataTransferLimits{
+		MaxConcurrentTransfers:        2,
+		MaxConcurrentTransfersPerPeer: 1,
+	})
+	require.NoError(t, err)
+	defer s.Stop()
+
+	p1, p2 := peer.ID("peer1"), peer.ID("peer2")
+	r1 := requestKey{p1, graphsync.NewRequestID()}
+	r2 := requestKey{p1, graphsync.NewRequestID()}
+	r3 := requestKey{p2, graphsync.NewRequestID()}
+	r4 := requestKey{p2, graphsync.NewRequestID()}
+
+	s.admit(r1)
+	s.admit(r1) // further blocks of the same transfer
+
+	// the second transfer to the same peer waits
+	admitted := make(chan requestKey, 3)
+	go func() {
+		s.admit(r2)
+		admitted <- r2
+	}()
+	require.Eventually(t, func() bool { return s.Status().Queued == 1 }, time.Second, time.Millisecond)
+
+	s.admit(r3)
+
+	// no free slot left
+	go func() {
+		s.admit(r4)
+		admitted <- r4
+	}()
+	require.Eventually(t, func() bool { return s.Status().Queued == 2 }, time.Second, time.Millisecond)
+
+	st := s.Status()
+	require.Equal(t, 2, st.Active)
+	require.Len(t, st.Peers, 2)
+	require.Equal(t, api.PeerTransferStatus{Peer: p1, Active: 1, Queued: 1}, st.Peers[0])
+
+	s.release(r1)
+	require.Equal(t, r2, <-admitted)
+
+	// raising the limits lets the waiting transfer start
+	require.NoError(t, s.SetLimits(api.DataTransferLimits{MaxConcurrentTransfers: 3}))
+	require.Equal(t, r4, <-admitted)
+
+	s.release(r2)
+	s.release(r3)
+	s.release(r4)
+	require.Equal(t, api.DataTransferLimitsStatus{
+		Limits: api.DataTransferLimits{MaxConcurrentTransfers: 3},
+		Peers:  []api.PeerTransferStatus{},
+	}, s.Status())
+}
+
+func TestCancelQueuedTransfer(t *testing.T) {
+	s, err := NewScheduler(api.DataTransferLimits{MaxConcurrentTransfers: 1})
+	require.NoError(t, err)
+	defer s.Stop()
+
+	r1 := requestKey{peer.ID("peer1"), graphsync.NewRequestID()}
+	r2 := requestKey{peer.ID("peer2"), graphsync.NewRequestID()}
+
+	s.admit(r1)
+
+	done := make(chan struct{})
+	go func() {
+		s.admit(r2)
+		close(done)
+	}()
+	require.Eventually(t, func() bool { return s.Status().Queued == 1 }, time.Second, time.Millisecond)
+
+	// the requestor gives up while waiting
+	s.release(r2)
+	<-done
+
+	st := s.Status()
+	require.Equal(t, 1, st.Active)
+	require.Equal(t, 0, st.Queued)
+}
+
+func TestBandwidthSchedule(t *testing.T) {
+	oldClock := build.Clock
+	t.Cleanup(func() { build.Clock = oldClock })
+	mc := clock.NewMock()
+	build.Clock = mc
+
+	mc.Set(time.Date(2022, 1, 1, 12, 0, 0, 0, time.Local))
+
+	s, err := NewScheduler(api.DataTransferLimits{
+		MaxBandwidth: 1 << 20,
+		Schedule: []api.BandwidthWindow{
+			{Start: "09:00", End: "17:00", MaxBandwidth: 1 << 10, MaxBandwidthPerPeer: 1 << 8},
+			{Start: "22:00", End: "06:00"},
+		},
+	})
+	require.NoError(t, err)
+	defer s.Stop()
+
+	st := s.Status()
+	require.Equal(t, int64(1<<10), st.MaxBandwidth)
+	require.Equal(t, int64(1<<8), st.MaxBandwidthPerPeer)
+
+	mc.Set(time.Date(2022, 1, 1, 18, 0, 0, 0, time.Local))
+	require.Equal(t, int64(1<<20), s.Status().MaxBandwidth)
+
+	// the window spans midnight
+	mc.Set(time.Date(2022, 1, 2, 1, 0, 0, 0, time.Local))
+	require.Equal(t, int64(0), s.Status().MaxBandwidth)
+
+	_, err = NewScheduler(api.DataTransferLimits{
+		Schedule: []api.BandwidthWindow{{Start: "9am", End: "17:00"}},
+	})
+	require.Error(t, err)
+}
+
+func TestThrottle(t *testing.T) {
+	s, err := NewScheduler(api.DataTransferLimits{MaxBandwidthPerPeer: 10000})
+	require.NoError(t, err)
+	defer s.Stop()
+
+	r := requestKey{peer.ID("peer1"), graphsync.NewRequestID()}
+	s.admit(r)
+
+	// the first second of bandwidth is available right away
+	start := time.Now()
+	s.throttle(r.p, 10000)
+	s.throttle(r.p, 5000)
+	require.Greater(t, time.Since(start), 400*time.Millisecond)
+
+	require.Equal(t, uint64(15000), s.Status().Peers[0].Sent)
+}
@@ -21,6 +21,10 @@ var workMillisecondsDistribution = view.Distribution(
 	130*60_000, 140*60_000, 150*60_000, 160*60_000, 180*60_000, 200*60_000, 220*60_000, 260*60_000, 300*60_000, // PC1 range
 	350*60_000, 400*60_000, 600*60_000, 800*60_000, 1000*60_000, 1300*60_000, 1800*60_000, 4000*60_000, 10000*60_000, // intel PC1 range
 )
+var messageLifecycleSecondsDistribution = view.Distribution(
+	15, 30, 60, 90, 120, 180, 240, 300, 450, 600, 900, 1200, 1800, 2700, 3600, // within an hour
+	2*3600, 3*3600, 4*3600, 6*3600, 8*3600, 12*3600, 16*3600, 24*3600, 36*3600, 48*3600, // finality and long delays
+)
 
 // Global Tags
 var (
@@ -41,6 +45,10 @@ var (
 	MsgValid, _     = tag.NewKey("message_valid")
 	Endpoint, _     = tag.NewKey("endpoint")
 	APIInterface, _ = tag.NewKey("api") // to distinguish between gateway api and full node api endpoint calls
+	MethodGroup, _  = tag.NewKey("method_group")
+
+	// splitstore
+	CompactionPhase, _ = tag.NewKey("compaction_phase")
 
 	// miner
 	TaskType, _       = tag.NewKey("task_type")
@@ -94,6 +102,8 @@ var (
 	MpoolAddTsDuration                  = stats.Float64("mpool/addts_ms", "Duration of addTs in mpool", stats.UnitMilliseconds)
 	MpoolAddDuration                    = stats.Float64("mpool/add_ms", "Duration of Add in mpool", stats.UnitMilliseconds)
 	MpoolPushDuration                   = stats.Float64("mpool/push_ms", "Duration of Push in mpool", stats.UnitMilliseconds)
+	MpoolMessageInclusionDuration       = stats.Float64("mpool/message_inclusion_s", "Time from the push of a local message to its inclusion in a tipset", stats.UnitSeconds)
+	MpoolMessageFinalityDuration        = stats.Float64("mpool/message_finality_s", "Time from the push of a local message to the finality of its inclusion", stats.UnitSeconds)
 	BlockPublished                      = stats.Int64("block/published", "Counter for total locally published blocks", stats.UnitDimensionless)
 	BlockReceived                       = stats.Int64("block/received", "Counter for total received blocks", stats.UnitDimensionless)
 	BlockValidationFailure              = stats.Int64("block/failure", "Counter for block validation failures", stats.UnitDimensionless)
@@ -151,6 +161,33 @@ var (
 	SplitstoreCompactionHot         = stats.Int64("splitstore/hot", "Number of hot blocks in last compaction", stats.UnitDimensionless)
 	SplitstoreCompactionCold        = stats.Int64("splitstore/cold", "Number of cold blocks in last compaction", stats.UnitDimensionless)
 	SplitstoreCompactionDead        = stats.Int64("splitstore/dead", "Number of dead blocks in last compaction", stats.UnitDimensionless)
+	SplitstoreCompactionMoved       = stats.Int64("splitstore/moved", "Number of blocks moved to the coldstore in last compaction", stats.UnitDimensionless)
+	SplitstoreCompactionPhaseTime   = stats.Float64("splitstore/compaction_phase_time", "Compaction phase time in seconds", stats.UnitSeconds)
+	SplitstoreHotstoreSize          = stats.Int64("splitstore/hotstore_size", "Size of the hotstore after last compaction", stats.UnitBytes)
+	SplitstoreColdstoreSize         = stats.Int64("splitstore/coldstore_size", "Size of the coldstore after last compaction", stats.UnitBytes)
+
+	// blockstore gc
+	BlockstoreGCRuns           = stats.Int64("blockstore/gc/runs", "Number of blockstore GC runs", stats.UnitDimensionless)
+	BlockstoreGCAborted        = stats.Int64("blockstore/gc/aborted", "Number of blockstore GC runs stopped early by a limit", stats.UnitDimensionless)
+	BlockstoreGCTimeSeconds    = stats.Float64("blockstore/gc/time", "Blockstore GC run time in seconds", stats.UnitSeconds)
+	BlockstoreGCIterations     = stats.Int64("blockstore/gc/iterations", "Number of blockstore value log GC iterations", stats.UnitDimensionless)
+	BlockstoreGCReclaimedBytes = stats.Int64("blockstore/gc/reclaimed_bytes", "Bytes reclaimed by blockstore GC", stats.UnitBytes)
+	BlockstoreGCProbeLatency   = stats.Float64("blockstore/gc/probe_latency_ms", "Latency of blockstore probe reads during GC", stats.UnitMilliseconds)
+
+	// blockstore scrub
+	BlockstoreScrubPasses        = stats.Int64("blockstore/scrub/passes", "Number of blockstore scrub passes", stats.UnitDimensionless)
+	BlockstoreScrubChecked       = stats.Int64("blockstore/scrub/checked", "Number of blocks verified by the blockstore scrubber", stats.UnitDimensionless)
+	BlockstoreScrubCheckedBytes  = stats.Int64("blockstore/scrub/checked_bytes", "Bytes verified by the blockstore scrubber", stats.UnitBytes)
+	BlockstoreScrubCorrupt       = stats.Int64("blockstore/scrub/corrupt", "Number of corrupt blocks found by the blockstore scrubber", stats.UnitDimensionless)
+	BlockstoreScrubRefetched     = stats.Int64("blockstore/scrub/refetched", "Number of corrupt blocks re-fetched from the network", stats.UnitDimensionless)
+	BlockstoreScrubRefetchFailed = stats.Int64("blockstore/scrub/refetch_failed", "Number of failed attempts to re-fetch corrupt blocks", stats.UnitDimensionless)
+	BlockstoreScrubQuarantined   = stats.Int64("blockstore/scrub/quarantined", "Number of quarantined corrupt blocks", stats.UnitDimensionless)
+
+	// chain snapshots
+	ChainSnapshotRuns        = stats.Int64("chain/snapshot/runs", "Number of scheduled chain snapshots taken", stats.UnitDimensionless)
+	ChainSnapshotFailures    = stats.Int64("chain/snapshot/failures", "Number of failed scheduled chain snapshots", stats.UnitDimensionless)
+	ChainSnapshotTimeSeconds = stats.Float64("chain/snapshot/time", "Time taken to write a chain snapshot in seconds", stats.UnitSeconds)
+	ChainSnapshotSizeBytes   = stats.Int64("chain/snapshot/size_bytes", "Size of the last chain snapshot", stats.UnitBytes)
 
 	// rcmgr
 	RcmgrAllowConn      = stats.Int64("rcmgr/allow_conn", "Number of allowed connections", stats.UnitDimensionless)
@@ -278,6 +315,16 @@ var (
 		Measure:     MpoolPushDuration,
 		Aggregation: defaultMillisecondsDistribution,
 	}
+	MpoolMessageInclusionDurationView = &view.View{
+		Measure:     MpoolMessageInclusionDuration,
+		Aggregation: messageLifecycleSecondsDistribution,
+		TagKeys:     []tag.Key{MethodGroup},
+	}
+	MpoolMessageFinalityDurationView = &view.View{
+		Measure:     MpoolMessageFinalityDuration,
+		Aggregation: messageLifecycleSecondsDistribution,
+		TagKeys:     []tag.Key{MethodGroup},
+	}
 	PeerCountView = &view.View{
 		Measure:     PeerCount,
 		Aggregation: view.LastValue(),
@@ -482,6 +529,97 @@ var (
 		Measure:     SplitstoreCompactionDead,
 		Aggregation: view.Sum(),
 	}
+	SplitstoreCompactionMovedView = &view.View{
+		Measure:     SplitstoreCompactionMoved,
+		Aggregation: view.Sum(),
+	}
+	SplitstoreCompactionPhaseTimeView = &view.View{
+		Measure:     SplitstoreCompactionPhaseTime,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{CompactionPhase},
+	}
+	SplitstoreHotstoreSizeView = &view.View{
+		Measure:     SplitstoreHotstoreSize,
+		Aggregation: view.LastValue(),
+	}
+	SplitstoreColdstoreSizeView = &view.View{
+		Measure:     SplitstoreColdstoreSize,
+		Aggregation: view.LastValue(),
+	}
+
+	// blockstore gc
+	BlockstoreGCRunsView = &view.View{
+		Measure:     BlockstoreGCRuns,
+		Aggregation: view.Count(),
+	}
+	BlockstoreGCAbortedView = &view.View{
+		Measure:     BlockstoreGCAborted,
+		Aggregation: view.Count(),
+	}
+	BlockstoreGCTimeSecondsView = &view.View{
+		Measure:     BlockstoreGCTimeSeconds,
+		Aggregation: view.LastValue(),
+	}
+	BlockstoreGCIterationsView = &view.View{
+		Measure:     BlockstoreGCIterations,
+		Aggregation: view.Count(),
+	}
+	BlockstoreGCReclaimedBytesView = &view.View{
+		Measure:     BlockstoreGCReclaimedBytes,
+		Aggregation: view.LastValue(),
+	}
+	BlockstoreGCProbeLatencyView = &view.View{
+		Measure:     BlockstoreGCProbeLatency,
+		Aggregation: defaultMillisecondsDistribution,
+	}
+
+	// blockstore scrub
+	BlockstoreScrubPassesView = &view.View{
+		Measure:     BlockstoreScrubPasses,
+		Aggregation: view.Count(),
+	}
+	BlockstoreScrubCheckedView = &view.View{
+		Measure:     BlockstoreScrubChecked,
+		Aggregation: view.Count(),
+	}
+	BlockstoreScrubCheckedBytesView = &view.View{
+		Measure:     BlockstoreScrubCheckedBytes,
+		Aggregation: view.Sum(),
+	}
+	BlockstoreScrubCorruptView = &view.View{
+		Measure:     BlockstoreScrubCorrupt,
+		Aggregation: view.Count(),
+	}
+	BlockstoreScrubRefetchedView = &view.View{
+		Measure:     BlockstoreScrubRefetched,
+		Aggregation: view.Count(),
+	}
+	BlockstoreScrubRefetchFailedView = &view.View{
+		Measure:     BlockstoreScrubRefetchFailed,
+		Aggregation: view.Count(),
+	}
+	BlockstoreScrubQuarantinedView = &view.View{
+		Measure:     BlockstoreScrubQuarantined,
+		Aggregation: view.LastValue(),
+	}
+
+	// chain snapshots
+	ChainSnapshotRunsView = &view.View{
+		Measure:     ChainSnapshotRuns,
+		Aggregation: view.Count(),
+	}
+	ChainSnapshotFailuresView = &view.View{
+		Measure:     ChainSnapshotFailures,
+		Aggregation: view.Count(),
+	}
+	ChainSnapshotTimeSecondsView = &view.View{
+		Measure:     ChainSnapshotTimeSeconds,
+		Aggregation: view.LastValue(),
+	}
+	ChainSnapshotSizeBytesView = &view.View{
+		Measure:     ChainSnapshotSizeBytes,
+		Aggregation: view.LastValue(),
+	}
 
 	// graphsync
 	GraphsyncReceivingPeersCountView = &view.View{
@@ -669,6 +807,8 @@ var ChainNodeViews = append([]*view.View{
 	MpoolAddTsDurationView,
 	MpoolAddDurationView,
 	MpoolPushDurationView,
+	MpoolMessageInclusionDurationView,
+	MpoolMessageFinalityDurationView,
 	PubsubPublishMessageView,
 	PubsubDeliverMessageView,
 	PubsubRejectMessageView,
@@ -683,6 +823,27 @@ var ChainNodeViews = append([]*view.View{
 	SplitstoreCompactionHotView,
 	SplitstoreCompactionColdView,
 	SplitstoreCompactionDeadView,
+	SplitstoreCompactionMovedView,
+	SplitstoreCompactionPhaseTimeView,
+	SplitstoreHotstoreSizeView,
+	SplitstoreColdstoreSizeView,
+	BlockstoreGCRunsView,
+	BlockstoreGCAbortedView,
+	BlockstoreGCTimeSecondsView,
+	BlockstoreGCIterationsView,
+	BlockstoreGCReclaimedBytesView,
+	BlockstoreGCProbeLatencyView,
+	BlockstoreScrubPassesView,
+	BlockstoreScrubCheckedView,
+	BlockstoreScrubCheckedBytesView,
+	BlockstoreScrubCorruptView,
+	BlockstoreScrubRefetchedView,
+	BlockstoreScrubRefetchFailedView,
+	BlockstoreScrubQuarantinedView,
+	ChainSnapshotRunsView,
+	ChainSnapshotFailuresView,
+	ChainSnapshotTimeSecondsView,
+	ChainSnapshotSizeBytesView,
 	VMApplyBlocksTotalView,
 	VMApplyMessagesView,
 	VMApplyEarlyView,
@@ -2,11 +2,22 @@ package proxy
 
 import (
 	"context"
+	"encoding/json"
 	"reflect"
+	"strings"
+	"time"
 
+	"go.opencensus.io/metric/metricdata"
+	"go.opencensus.io/stats"
 	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+
+	"github.com/filecoin-project/go-jsonrpc/auth"
 
 	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/lib/rpcconn"
+	"github.com/filecoin-project/lotus/lib/tracing"
 	"github.com/filecoin-project/lotus/metrics"
 )
 
@@ -52,10 +63,29 @@ func proxy(in interface{}, outstr interface{}) {
 
 			rint.Field(f).Set(reflect.MakeFunc(field.Type, func(args []reflect.Value) (results []reflect.Value) {
 				ctx := args[0].Interface().(context.Context)
-				// upsert function name into context
-				ctx, _ = tag.New(ctx, tag.Upsert(metrics.Endpoint, field.Name))
-				stop := metrics.Timer(ctx, metrics.APIRequestDuration)
-				defer stop()
+				// upsert function name into context, and default the blockstore cache
+				// caller to the api, unless the client identified itself
+				ctx, _ = tag.New(ctx, tag.Upsert(metrics.Endpoint, field.Name), tag.Insert(blockstore.CacheCaller, blockstore.CacheCallerAPI))
+
+				ctx, span := trace.StartSpan(ctx, tracing.APISpanPrefix+field.Name)
+				defer span.End()
+				if span.IsRecordingEvents() {
+					addCallAttributes(ctx, span, args[1:])
+				}
+
+				start := time.Now()
+				defer func() {
+					recordDuration(ctx, span, start)
+					setSpanStatus(span, results)
+				}()
+
+				// wait for the limit of the connection of the call
+				release, err := rpcconn.Acquire(ctx)
+				if err != nil {
+					return errorResults(field.Type, err)
+				}
+				defer release()
+
 				// pass tagged ctx back into function call
 				args[0] = reflect.ValueOf(ctx)
 				return fn.Call(args)
@@ -63,3 +93,66 @@ func proxy(in interface{}, outstr interface{}) {
 		}
 	}
 }
+
+// addCallAttributes annotates the span of an API call with the size of the
+// params and the caller
+func addCallAttributes(ctx context.Context, span *trace.Span, params []reflect.Value) {
+	var size int64
+	for _, p := range params {
+		b, err := json.Marshal(p.Interface())
+		if err != nil {
+			// Params which can't be marshalled, like readers, aren't counted
+			continue
+		}
+		size += int64(len(b))
+	}
+
+	attrs := []trace.Attribute{trace.Int64Attribute("params_size", size)}
+	if caller := tracing.CallerFromContext(ctx); caller != "" {
+		attrs = append(attrs, trace.StringAttribute("caller", caller))
+	}
+	var perms []string
+	for _, p := range api.AllPermissions {
+		if auth.HasPerm(ctx, nil, p) {
+			perms = append(perms, string(p))
+		}
+	}
+	if len(perms) > 0 {
+		attrs = append(attrs, trace.StringAttribute("perms", strings.Join(perms, ",")))
+	}
+	span.AddAttributes(attrs...)
+}
+
+// recordDuration records the duration of an API call, with the span context
+// of sampled calls attached as an exemplar
+func recordDuration(ctx context.Context, span *trace.Span, start time.Time) {
+	opts := []stats.Options{stats.WithMeasurements(metrics.APIRequestDuration.M(metrics.SinceInMilliseconds(start)))}
+	if span.IsRecordingEvents() {
+		opts = append(opts, stats.WithAttachments(metricdata.Attachments{
+			metricdata.AttachmentKeySpanContext: span.SpanContext(),
+		}))
+	}
+	_ = stats.RecordWithOptions(ctx, opts...)
+}
+
+// setSpanStatus marks the span of a failed API call, which returns an error as
+// its last result
+func setSpanStatus(span *trace.Span, results []reflect.Value) {
+	if len(results) == 0 {
+		return
+	}
+	if err, ok := results[len(results)-1].Interface().(error); ok && err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: err.Error()})
+	}
+}
+
+// errorResults returns the results of a call of a func type which returns an
+// error as its last result
+func errorResults(ft reflect.Type, err error) []reflect.Value {
+	out := make([]reflect.Value, ft.NumOut())
+	for i := range out {
+		out[i] = reflect.Zero(ft.Out(i))
+	}
+	out[len(out)-1] = reflect.ValueOf(&err).Elem()
+	return out
+}
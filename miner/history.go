@@ -0,0 +1,108 @@
+package miner
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+// number of won elections kept in the history
+const winningHistorySize = 256
+
+// winningHistory keeps the last won elections, and the timings of the
+// production of their blocks
+type winningHistory struct {
+	lk      sync.Mutex
+	records []*api.WinningPoStRecord
+}
+
+func (h *winningHistory) add(r *api.WinningPoStRecord) {
+	h.lk.Lock()
+	defer h.lk.Unlock()
+
+	h.records = append(h.records, r)
+	if len(h.records) > winningHistorySize {
+		h.records = h.records[len(h.records)-winningHistorySize:]
+	}
+}
+
+// submitted records the submission of the block mined at an epoch
+func (h *winningHistory) submitted(epoch abi.ChainEpoch, took time.Duration, err error) {
+	h.lk.Lock()
+	defer h.lk.Unlock()
+
+	for i := len(h.records) - 1; i >= 0; i-- {
+		r := h.records[i]
+		if r.Epoch != epoch {
+			continue
+		}
+
+		r.Submission = took
+		if err != nil {
+			r.State = api.WinningBlockFailed
+			r.Error = err.Error()
+		}
+		return
+	}
+}
+
+func (h *winningHistory) list() []api.WinningPoStRecord {
+	h.lk.Lock()
+	defer h.lk.Unlock()
+
+	out := make([]api.WinningPoStRecord, len(h.records))
+	for i, r := range h.records {
+		out[i] = *r
+	}
+	return out
+}
+
+// WinningPoStHistory returns the elections won since the miner started, with
+// the state of their blocks in the current chain
+func (m *Miner) WinningPoStHistory(ctx context.Context) ([]api.WinningPoStRecord, error) {
+	records := m.winning.list()
+	if len(records) == 0 {
+		return records, nil
+	}
+
+	head, err := m.api.ChainHead(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	for i := range records {
+		r := &records[i]
+		if r.State == api.WinningBlockFailed {
+			continue
+		}
+		if r.Epoch > head.Height() {
+			r.State = api.WinningBlockPending
+			continue
+		}
+
+		ts, err := m.api.ChainGetTipSetByHeight(ctx, r.Epoch, head.Key())
+		if err != nil {
+			return nil, xerrors.Errorf("getting tipset at epoch %d: %w", r.Epoch, err)
+		}
+
+		r.State = api.WinningBlockOrphaned
+		if ts.Height() != r.Epoch {
+			// null round
+			continue
+		}
+		for _, c := range ts.Cids() {
+			if c == r.Block {
+				r.State = api.WinningBlockIncluded
+				break
+			}
+		}
+	}
+
+	return records, nil
+}
@@ -92,6 +92,7 @@ func NewMiner(api v1api.FullNode, epp gen.WinningPoStProver, addr address.Addres
 
 		sf:                sf,
 		minedBlockHeights: arc,
+		winning:           &winningHistory{},
 		evtTypes: [...]journal.EventType{
 			evtTypeBlockMined: j.RegisterEventType("miner", "block_mined"),
 		},
@@ -123,6 +124,9 @@ type Miner struct {
 	// intended to avoid slashings in case of a bug.
 	minedBlockHeights *lru.ARCCache
 
+	// winning holds the last won elections
+	winning *winningHistory
+
 	evtTypes [1]journal.EventType
 	journal  journal.Journal
 }
@@ -296,6 +300,8 @@ minerLoop:
 		onDone(b != nil, h, nil)
 
 		if b != nil {
+			tSubmit := build.Clock.Now()
+
 			m.journal.RecordEvent(m.evtTypes[evtTypeBlockMined], func() interface{} {
 				return map[string]interface{}{
 					"parents":   base.TipSet.Cids(),
@@ -324,6 +330,7 @@ minerLoop:
 			if err := m.sf.MinedBlock(ctx, b.Header, base.TipSet.Height()+base.NullRounds); err != nil {
 				log.Errorf("<!!> SLASH FILTER ERROR: %s", err)
 				if os.Getenv("LOTUS_MINER_NO_SLASHFILTER") != "_yes_i_know_i_can_and_probably_will_lose_all_my_fil_and_power_" {
+					m.winning.submitted(b.Header.Height, build.Clock.Since(tSubmit), xerrors.Errorf("slash filter: %w", err))
 					continue
 				}
 			}
@@ -331,14 +338,17 @@ minerLoop:
 			blkKey := fmt.Sprintf("%d", b.Header.Height)
 			if _, ok := m.minedBlockHeights.Get(blkKey); ok {
 				log.Warnw("Created a block at the same height as another block we've created", "height", b.Header.Height, "miner", b.Header.Miner, "parents", b.Header.Parents)
+				m.winning.submitted(b.Header.Height, build.Clock.Since(tSubmit), xerrors.Errorf("already mined a block at this height"))
 				continue
 			}
 
 			m.minedBlockHeights.Add(blkKey, true)
 
-			if err := m.api.SyncSubmitBlock(ctx, b); err != nil {
+			err := m.api.SyncSubmitBlock(ctx, b)
+			if err != nil {
 				log.Errorf("failed to submit newly mined block: %+v", err)
 			}
+			m.winning.submitted(b.Header.Height, build.Clock.Since(tSubmit), err)
 		} else {
 			base.NullRounds++
 
@@ -427,7 +437,19 @@ func (m *Miner) mineOne(ctx context.Context, base *MiningBase) (minedBlock *type
 	var winner *types.ElectionProof
 	var mbi *api.MiningBaseInfo
 	var rbase types.BeaconEntry
+	// the won election, recorded in the history
+	var rec *api.WinningPoStRecord
 	defer func() {
+		if rec != nil {
+			if minedBlock != nil {
+				rec.Block = minedBlock.Cid()
+			}
+			if err != nil {
+				rec.State = api.WinningBlockFailed
+				rec.Error = err.Error()
+			}
+			m.winning.add(rec)
+		}
 
 		var hasMinPower bool
 
@@ -516,6 +538,16 @@ func (m *Miner) mineOne(ctx context.Context, base *MiningBase) (minedBlock *type
 
 	tTicket := build.Clock.Now()
 
+	rec = &api.WinningPoStRecord{
+		Epoch:      round,
+		Base:       base.TipSet.Key(),
+		NullRounds: base.NullRounds,
+		Started:    tStart,
+		Late:       uint64(tStart.Unix()) > (base.TipSet.MinTimestamp() + uint64(base.NullRounds*builtin.EpochDurationSeconds) + build.PropagationDelaySecs),
+		BaseInfo:   tPowercheck.Sub(tStart),
+		Election:   tTicket.Sub(tPowercheck),
+	}
+
 	buf := new(bytes.Buffer)
 	if err := m.address.MarshalCBOR(buf); err != nil {
 		err = xerrors.Errorf("failed to marshal miner address: %w", err)
@@ -531,6 +563,7 @@ func (m *Miner) mineOne(ctx context.Context, base *MiningBase) (minedBlock *type
 	prand := abi.PoStRandomness(rand)
 
 	tSeed := build.Clock.Now()
+	rec.Randomness = tSeed.Sub(tTicket)
 	nv, err := m.api.StateNetworkVersion(ctx, base.TipSet.Key())
 	if err != nil {
 		return nil, err
@@ -543,6 +576,7 @@ func (m *Miner) mineOne(ctx context.Context, base *MiningBase) (minedBlock *type
 	}
 
 	tProof := build.Clock.Now()
+	rec.Proof = tProof.Sub(tSeed)
 
 	// get pending messages early,
 	msgs, err := m.api.MpoolSelect(context.TODO(), base.TipSet.Key(), ticket.Quality())
@@ -552,6 +586,7 @@ func (m *Miner) mineOne(ctx context.Context, base *MiningBase) (minedBlock *type
 	}
 
 	tPending := build.Clock.Now()
+	rec.Messages = tPending.Sub(tProof)
 
 	// TODO: winning post proof
 	minedBlock, err = m.createBlock(base, m.address, ticket, winner, bvals, postProof, msgs)
@@ -561,6 +596,7 @@ func (m *Miner) mineOne(ctx context.Context, base *MiningBase) (minedBlock *type
 	}
 
 	tCreateBlock := build.Clock.Now()
+	rec.Assembly = tCreateBlock.Sub(tPending)
 	dur := tCreateBlock.Sub(tStart)
 	parentMiners := make([]address.Address, len(base.TipSet.Blocks()))
 	for i, header := range base.TipSet.Blocks() {
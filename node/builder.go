@@ -27,10 +27,13 @@ import (
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/journal"
 	"github.com/filecoin-project/lotus/journal/alerting"
+	"github.com/filecoin-project/lotus/lib/apitls"
 	"github.com/filecoin-project/lotus/lib/lotuslog"
+	"github.com/filecoin-project/lotus/lib/rpcconn"
 	"github.com/filecoin-project/lotus/lib/peermgr"
 	_ "github.com/filecoin-project/lotus/lib/sigs/bls"
 	_ "github.com/filecoin-project/lotus/lib/sigs/secp"
+	"github.com/filecoin-project/lotus/lib/tracing"
 	"github.com/filecoin-project/lotus/markets/storageadapter"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/impl/common"
@@ -89,6 +92,8 @@ const (
 	PstoreAddSelfKeysKey
 	StartListeningKey
 	BootstrapKey
+	RunStaticPeeringKey
+	RunPeerExchangeKey
 
 	// filecoin
 	SetGenesisKey
@@ -104,6 +109,7 @@ const (
 	HandlePaymentChannelManagerKey
 
 	RelayIndexerMessagesKey
+	ServeChainStreamKey
 
 	// miner
 	GetParamsKey
@@ -111,6 +117,7 @@ const (
 	HandleDealsKey
 	HandleRetrievalKey
 	RunSectorServiceKey
+	MirrorMinerEventsKey
 
 	// daemon
 	ExtractApiKey
@@ -175,6 +182,7 @@ func defaults() []Option {
 var LibP2P = Options(
 	// Host config
 	Override(new(dtypes.Bootstrapper), dtypes.Bootstrapper(false)),
+	Override(new(dtypes.DisablePeerDiscovery), dtypes.DisablePeerDiscovery(false)),
 
 	// Host dependencies
 	Override(new(peerstore.Peerstore), lp2p.Peerstore),
@@ -201,11 +209,12 @@ var LibP2P = Options(
 	Override(new(routing.Routing), lp2p.Routing),
 
 	// Services
-	Override(BandwidthReporterKey, lp2p.BandwidthCounter),
+	Override(BandwidthReporterKey, lp2p.BandwidthCounter(config.BandwidthLimits{})),
 	Override(AutoNATSvcKey, lp2p.AutoNATService),
 
 	// Services (pubsub)
 	Override(new(*dtypes.ScoreKeeper), lp2p.ScoreKeeper),
+	Override(new(*lp2p.PeerTracker), lp2p.NewPeerTracker),
 	Override(new(*pubsub.PubSub), lp2p.GossipSub),
 	Override(new(*config.Pubsub), func(bs dtypes.Bootstrapper) *config.Pubsub {
 		return &config.Pubsub{
@@ -216,6 +225,7 @@ var LibP2P = Options(
 	// Services (connection management)
 	Override(ConnectionManagerKey, lp2p.ConnectionManager(50, 200, 20*time.Second, nil)),
 	Override(new(*conngater.BasicConnectionGater), lp2p.ConnGater),
+	Override(new(*lp2p.NetACL), lp2p.NewNetACL),
 	Override(ConnGaterKey, lp2p.ConnGaterOption),
 
 	// Services (resource management)
@@ -251,22 +261,39 @@ func Base() Option {
 
 // Config sets up constructors based on the provided Config
 func ConfigCommon(cfg *config.Common, enableLibp2pNode bool) Option {
-	// setup logging early
+	// setup logging and tracing early
+	if err := lotuslog.SetFormat(cfg.Logging.Format); err != nil {
+		return Error(xerrors.Errorf("invalid logging config: %w", err))
+	}
 	lotuslog.SetLevelsFromConfig(cfg.Logging.SubsystemLevels)
+	tracing.SetAPISampling(cfg.Tracing.DefaultSampleRate, cfg.Tracing.MethodSampleRates)
 
 	return Options(
 		func(s *Settings) error { s.Config = true; return nil },
 		Override(new(dtypes.APIEndpoint), func() (dtypes.APIEndpoint, error) {
 			return multiaddr.NewMultiaddr(cfg.API.ListenAddress)
 		}),
-		Override(SetApiEndpointKey, func(lr repo.LockedRepo, e dtypes.APIEndpoint) error {
+		Override(new(*apitls.Server), modules.APITLS(cfg.API.TLS)),
+		Override(new(rpcconn.Config), rpcconn.Config{
+			MaxBatchSize:    cfg.API.MaxBatchSize,
+			MaxConnRequests: cfg.API.MaxConnRequests,
+		}),
+		Override(SetApiEndpointKey, func(lr repo.LockedRepo, e dtypes.APIEndpoint, tlsSrv *apitls.Server) error {
+			if tlsSrv != nil {
+				e = apitls.Endpoint(e)
+			}
 			return lr.SetAPIEndpoint(e)
 		}),
-		Override(new(paths.URLs), func(e dtypes.APIEndpoint) (paths.URLs, error) {
+		Override(new(paths.URLs), func(e dtypes.APIEndpoint, tlsSrv *apitls.Server) (paths.URLs, error) {
 			ip := cfg.API.RemoteListenAddress
 
+			scheme := "http"
+			if tlsSrv != nil {
+				scheme = "https"
+			}
+
 			var urls paths.URLs
-			urls = append(urls, "http://"+ip+"/remote") // TODO: This makes no assumptions, and probably could...
+			urls = append(urls, scheme+"://"+ip+"/remote") // TODO: This makes no assumptions, and probably could...
 			return urls, nil
 		}),
 		ApplyIf(func(s *Settings) bool { return s.Base }), // apply only if Base has already been applied
@@ -296,6 +323,18 @@ func ConfigCommon(cfg *config.Common, enableLibp2pNode bool) Option {
 				cfg.Libp2p.NoAnnounceAddresses)),
 
 			If(!cfg.Libp2p.DisableNatPortMap, Override(NatPortMapKey, lp2p.NatPortMap)),
+			Override(BandwidthReporterKey, lp2p.BandwidthCounter(cfg.Libp2p.BandwidthLimits)),
+
+			Override(new(dtypes.StaticPeers), modules.ConfigStaticPeers(cfg.Libp2p.StaticPeers)),
+			If(len(cfg.Libp2p.StaticPeers) > 0,
+				Override(RunStaticPeeringKey, lp2p.StaticPeering(time.Duration(cfg.Libp2p.StaticPeersReconnectInterval))),
+			),
+			If(cfg.Libp2p.PeerExchange.EnablePeerExchange,
+				Override(RunPeerExchangeKey, lp2p.PeerExchange(time.Duration(cfg.Libp2p.PeerExchange.Interval), cfg.Libp2p.PeerExchange.Members)),
+			),
+
+			If(cfg.Libp2p.DisableDHT, Override(new(lp2p.BaseIpfsRouting), lp2p.NilRouting)),
+			Override(new(dtypes.DisablePeerDiscovery), dtypes.DisablePeerDiscovery(cfg.Libp2p.DisablePeerDiscovery)),
 		),
 		Override(new(dtypes.MetadataDS), modules.Datastore(cfg.Backup.DisableMetadataLog)),
 	)
@@ -307,6 +346,16 @@ func Repo(r repo.Repo) Option {
 		if err != nil {
 			return err
 		}
+
+		// the migrations may change the config, so they are applied first
+		migrated, err := repo.Migrate(context.TODO(), lr, false)
+		if err != nil {
+			return xerrors.Errorf("migrating repo: %w", err)
+		}
+		for _, m := range migrated {
+			log.Infow("applied repo migration", "version", m.Version, "name", m.Name, "changes", m.Changes)
+		}
+
 		c, err := lr.Config()
 		if err != nil {
 			return err
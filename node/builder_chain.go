@@ -2,6 +2,7 @@ package node
 
 import (
 	"os"
+	"time"
 
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
@@ -10,25 +11,36 @@ import (
 	discoveryimpl "github.com/filecoin-project/go-fil-markets/discovery/impl"
 	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
 	"github.com/filecoin-project/go-fil-markets/storagemarket"
+	"github.com/filecoin-project/go-state-types/abi"
 
 	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/blockstore/gcsched"
+	"github.com/filecoin-project/lotus/blockstore/scrub"
 	"github.com/filecoin-project/lotus/chain"
 	"github.com/filecoin-project/lotus/chain/beacon"
+	"github.com/filecoin-project/lotus/chain/checkpoint"
 	"github.com/filecoin-project/lotus/chain/consensus"
 	"github.com/filecoin-project/lotus/chain/consensus/filcns"
 	"github.com/filecoin-project/lotus/chain/exchange"
 	"github.com/filecoin-project/lotus/chain/gen/slashfilter"
+	"github.com/filecoin-project/lotus/chain/gen/slashfilter/slashsvc"
+	"github.com/filecoin-project/lotus/chain/index"
 	"github.com/filecoin-project/lotus/chain/market"
 	"github.com/filecoin-project/lotus/chain/messagepool"
 	"github.com/filecoin-project/lotus/chain/messagesigner"
+	"github.com/filecoin-project/lotus/chain/snapshotsched"
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	rpcstmgr "github.com/filecoin-project/lotus/chain/stmgr/rpc"
 	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/sub/propstats"
 	"github.com/filecoin-project/lotus/chain/vm"
 	"github.com/filecoin-project/lotus/chain/wallet"
 	ledgerwallet "github.com/filecoin-project/lotus/chain/wallet/ledger"
 	"github.com/filecoin-project/lotus/chain/wallet/remotewallet"
+	"github.com/filecoin-project/lotus/chain/watchlist"
 	"github.com/filecoin-project/lotus/lib/peermgr"
+	"github.com/filecoin-project/lotus/markets/dealrepair"
 	"github.com/filecoin-project/lotus/markets/storageadapter"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/hello"
@@ -38,6 +50,7 @@ import (
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/repo"
 	"github.com/filecoin-project/lotus/paychmgr"
+	"github.com/filecoin-project/lotus/paychmgr/budget"
 	"github.com/filecoin-project/lotus/paychmgr/settler"
 	"github.com/filecoin-project/lotus/storage/sealer/ffiwrapper"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
@@ -56,6 +69,7 @@ var ChainNode = Options(
 	Override(new(modules.Genesis), modules.ErrorGenesis),
 	Override(new(dtypes.AfterGenesisSet), modules.SetGenesis),
 	Override(SetGenesisKey, modules.DoSetGenesis),
+	Override(new(dtypes.DrandClientConfig), dtypes.DrandClientConfig{PersistentCache: true}),
 	Override(new(beacon.Schedule), modules.RandomSchedule),
 
 	// Network bootstrap
@@ -75,6 +89,7 @@ var ChainNode = Options(
 	Override(new(stmgr.Executor), filcns.NewTipSetExecutor()),
 	Override(new(consensus.Consensus), filcns.NewFilecoinExpectedConsensus),
 	Override(new(*store.ChainStore), modules.ChainStore),
+	Override(new(index.Reader), index.DummyIndex),
 	Override(new(*stmgr.StateManager), modules.StateManager),
 	Override(new(dtypes.ChainBitswap), modules.ChainBitswap),
 	Override(new(dtypes.ChainBlockService), modules.ChainBlockService), // todo: unused
@@ -113,6 +128,7 @@ var ChainNode = Options(
 	Override(new(paychmgr.PaychAPI), From(new(modules.PaychAPI))),
 	Override(new(*paychmgr.Store), modules.NewPaychStore),
 	Override(new(*paychmgr.Manager), modules.NewManager),
+	Override(new(*budget.Manager), modules.NewPaychBudgetManager),
 	Override(HandlePaymentChannelManagerKey, modules.HandlePaychManager),
 	Override(SettlePaymentChannelsKey, settler.SettlePaymentChannels),
 
@@ -135,6 +151,10 @@ var ChainNode = Options(
 
 	Override(new(*full.GasPriceCache), full.NewGasPriceCache),
 
+	// Service: Address watchlist
+	Override(new(*watchlist.Store), modules.NewWatchlistStore),
+	Override(new(*watchlist.Watchlist), modules.NewWatchlist),
+
 	Override(RelayIndexerMessagesKey, modules.RelayIndexerMessages),
 
 	// Lite node API
@@ -162,6 +182,7 @@ var ChainNode = Options(
 		Override(RunChainExchangeKey, modules.RunChainExchange),
 		Override(RunPeerMgrKey, modules.RunPeerMgr),
 		Override(HandleIncomingMessagesKey, modules.HandleIncomingMessages),
+		Override(new(*propstats.Tracker), propstats.NewTracker),
 		Override(HandleIncomingBlocksKey, modules.HandleIncomingBlocks),
 	),
 )
@@ -177,6 +198,7 @@ func ConfigFullNode(c interface{}) Option {
 	ipfsMaddr := cfg.Client.IpfsMAddr
 	return Options(
 		ConfigCommon(&cfg.Common, enableLibp2pNode),
+		Override(new(*config.Reloader), modules.FullNodeConfigReloader),
 
 		Override(new(dtypes.UniversalBlockstore), modules.UniversalBlockstore),
 
@@ -202,8 +224,49 @@ func ConfigFullNode(c interface{}) Option {
 			Override(new(dtypes.GCReferenceProtector), modules.NoopGCReferenceProtector),
 		),
 
-		Override(new(dtypes.ChainBlockstore), From(new(dtypes.BasicChainBlockstore))),
-		Override(new(dtypes.StateBlockstore), From(new(dtypes.BasicStateBlockstore))),
+		If(cfg.Sync.ParallelValidation,
+			Override(new(*chain.PipelineConfig), modules.SyncPipeline(&cfg.Sync)),
+		),
+		If(len(cfg.Sync.CheckpointFeed.Authorities) > 0,
+			Override(new(*checkpoint.Feed), modules.CheckpointFeed(&cfg.Sync.CheckpointFeed)),
+		),
+
+		Override(new(*gcsched.Scheduler), modules.BlockstoreGCScheduler(&cfg.Chainstore.BlockstoreGC)),
+		Override(new(*scrub.Scrubber), modules.BlockstoreScrubber(&cfg.Chainstore.Scrub)),
+
+		Override(new(*blockstore.CarMounts), modules.CarMounts),
+		If(cfg.Chainstore.BlockCache.EnableBlockCache,
+			Override(new(*blockstore.BlockCache), modules.BlockCache(&cfg.Chainstore.BlockCache)),
+		),
+		If(cfg.Chainstore.Index.EnableIndex,
+			Override(new(*index.Index), modules.ChainIndex(&cfg.Chainstore.Index)),
+			Override(new(index.Reader), From(new(*index.Index))),
+		),
+		If(cfg.Chainstore.Snapshots.EnableSnapshots,
+			Override(new(*snapshotsched.Scheduler), modules.ChainSnapshotScheduler(&cfg.Chainstore.Snapshots)),
+		),
+
+		Override(new(dtypes.MessageWaitConfig), dtypes.MessageWaitConfig{
+			Confidence: cfg.MessageWait.Confidence,
+			Finality:   abi.ChainEpoch(cfg.MessageWait.Finality),
+		}),
+
+		Override(new(dtypes.DrandClientConfig), dtypes.DrandClientConfig{
+			Endpoints:           cfg.Beacon.Endpoints,
+			HealthCheckInterval: time.Duration(cfg.Beacon.HealthCheckInterval),
+			PersistentCache:     !cfg.Beacon.DisablePersistentCache,
+		}),
+
+		If(cfg.FaultReporter.EnableConsensusFaultReporter,
+			Override(new(*slashsvc.Reporter), modules.ConsensusFaultReporter(&cfg.FaultReporter)),
+		),
+
+		If(cfg.ChainStream.ListenAddress != "",
+			Override(ServeChainStreamKey, modules.ServeChainStream(cfg.ChainStream)),
+		),
+
+		Override(new(dtypes.ChainBlockstore), modules.ChainBlockstore),
+		Override(new(dtypes.StateBlockstore), modules.StateBlockstore),
 
 		If(os.Getenv("LOTUS_ENABLE_CHAINSTORE_FALLBACK") == "1",
 			Override(new(dtypes.ChainBlockstore), modules.FallbackChainBlockstore),
@@ -226,6 +289,10 @@ func ConfigFullNode(c interface{}) Option {
 
 		Override(new(retrievalmarket.RetrievalClient), modules.RetrievalClient(cfg.Client.OffChainRetrieval)),
 
+		If(cfg.Client.DealRepair.Enable,
+			Override(new(*dealrepair.Monitor), modules.DealRepairMonitor(&cfg.Client.DealRepair)),
+		),
+
 		If(cfg.Wallet.RemoteBackend != "",
 			Override(new(*remotewallet.RemoteWallet), remotewallet.SetupRemoteWallet(cfg.Wallet.RemoteBackend)),
 		),
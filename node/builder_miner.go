@@ -18,12 +18,15 @@ import (
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/gen"
 	"github.com/filecoin-project/lotus/chain/gen/slashfilter"
+	"github.com/filecoin-project/lotus/journal"
 	"github.com/filecoin-project/lotus/markets/dagstore"
 	"github.com/filecoin-project/lotus/markets/dealfilter"
+	"github.com/filecoin-project/lotus/markets/httpretrieval"
 	"github.com/filecoin-project/lotus/markets/idxprov"
 	"github.com/filecoin-project/lotus/markets/retrievaladapter"
 	"github.com/filecoin-project/lotus/markets/sectoraccessor"
 	"github.com/filecoin-project/lotus/markets/storageadapter"
+	"github.com/filecoin-project/lotus/markets/transferlimit"
 	"github.com/filecoin-project/lotus/miner"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/impl"
@@ -32,11 +35,14 @@ import (
 	"github.com/filecoin-project/lotus/node/repo"
 	"github.com/filecoin-project/lotus/storage"
 	"github.com/filecoin-project/lotus/storage/ctladdr"
+	"github.com/filecoin-project/lotus/storage/disputer"
+	"github.com/filecoin-project/lotus/storage/notify"
 	"github.com/filecoin-project/lotus/storage/paths"
 	sealing "github.com/filecoin-project/lotus/storage/pipeline"
 	sectorstorage "github.com/filecoin-project/lotus/storage/sealer"
 	"github.com/filecoin-project/lotus/storage/sealer/ffiwrapper"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+	"github.com/filecoin-project/lotus/storage/sealer/workerboot"
 	"github.com/filecoin-project/lotus/storage/sectorblocks"
 	"github.com/filecoin-project/lotus/storage/wdpost"
 )
@@ -60,18 +66,20 @@ func ConfigStorageMiner(c interface{}) Option {
 		return Error(xerrors.Errorf("invalid config from repo, got: %T", c))
 	}
 
-	pricingConfig := cfg.Dealmaking.RetrievalPricing
-	if pricingConfig.Strategy == config.RetrievalPricingExternalMode {
-		if pricingConfig.External == nil {
-			return Error(xerrors.New("retrieval pricing policy has been to set to external but external policy config is nil"))
-		}
+	if err := config.ValidateRetrievalPricing(cfg.Dealmaking.RetrievalPricing); err != nil {
+		return Error(err)
+	}
 
-		if pricingConfig.External.Path == "" {
-			return Error(xerrors.New("retrieval pricing policy has been to set to external but external script path is empty"))
+	var filterWebhook *dealfilter.WebhookFilter
+	if cfg.Dealmaking.FilterWebhook != "" {
+		if cfg.Dealmaking.Filter != "" {
+			return Error(xerrors.New("only one of the deal filter command and the deal filter webhook can be set"))
 		}
-	} else if pricingConfig.Strategy != config.RetrievalPricingDefaultMode {
-		return Error(xerrors.New("retrieval pricing policy must be either default or external"))
+
+		filterWebhook = dealfilter.NewWebhookFilter(cfg.Dealmaking.FilterWebhook, time.Duration(cfg.Dealmaking.FilterWebhookTimeout), time.Duration(cfg.Dealmaking.FilterQueueTimeout))
 	}
+	// the filter commands can be changed with a config reload
+	dealFilter := dealfilter.NewCliFilter(cfg.Dealmaking.Filter, cfg.Dealmaking.RetrievalFilter)
 
 	enableLibp2pNode := cfg.Subsystems.EnableMarkets // we enable libp2p nodes if the storage market subsystem is enabled, otherwise we don't
 
@@ -90,15 +98,26 @@ func ConfigStorageMiner(c interface{}) Option {
 		Override(new(*paths.Local), modules.LocalStorage),
 		Override(new(*paths.Remote), modules.RemoteStorage),
 		Override(new(paths.Store), From(new(*paths.Remote))),
+		Override(new(dtypes.GetRetrievalPricingPolicyFunc), modules.NewGetRetrievalPricingPolicyFunc),
+		Override(new(dtypes.SetRetrievalPricingPolicyFunc), modules.NewSetRetrievalPricingPolicyFunc),
 		Override(new(dtypes.RetrievalPricingFunc), modules.RetrievalPricingFunc(cfg.Dealmaking)),
 
 		If(!cfg.Subsystems.EnableMining,
 			If(cfg.Subsystems.EnableSealing, Error(xerrors.Errorf("sealing can only be enabled on a mining node"))),
 			If(cfg.Subsystems.EnableSectorStorage, Error(xerrors.Errorf("sealing can only be enabled on a mining node"))),
+
+			// Proving service: WindowPoSt over the sector index of the mining node
+			If(cfg.Subsystems.EnableProving,
+				Override(new(storiface.Verifier), ffiwrapper.ProofVerifier),
+				Override(new(*sectorstorage.Manager), modules.SectorStorage),
+				Override(new(sectorstorage.SectorManager), From(new(*sectorstorage.Manager))),
+				Override(new(*wdpost.WindowPoStScheduler), modules.WindowPostScheduler(cfg.Proving)),
+			),
 		),
 		If(cfg.Subsystems.EnableMining,
 			If(!cfg.Subsystems.EnableSealing, Error(xerrors.Errorf("sealing can't be disabled on a mining node yet"))),
 			If(!cfg.Subsystems.EnableSectorStorage, Error(xerrors.Errorf("sealing can't be disabled on a mining node yet"))),
+			If(cfg.Subsystems.EnableProving, Error(xerrors.Errorf("the proving service can't run on a mining node, set ProvingApiInfo to run WindowPoSt in a separate process"))),
 
 			// Sector storage: Proofs
 			Override(new(storiface.Verifier), ffiwrapper.ProofVerifier),
@@ -116,9 +135,17 @@ func ConfigStorageMiner(c interface{}) Option {
 			Override(new(*slashfilter.SlashFilter), modules.NewSlashFilter),
 			Override(new(*miner.Miner), modules.SetupBlockProducer),
 			Override(new(gen.WinningPoStProver), storage.NewWinningPoStProver),
-			Override(new(*storage.Miner), modules.StorageMiner(cfg.Fees)),
-			Override(new(*wdpost.WindowPoStScheduler), modules.WindowPostScheduler(cfg.Fees, cfg.Proving)),
+			Override(new(*storage.Miner), modules.StorageMiner),
+			If(cfg.Subsystems.ProvingApiInfo == "",
+				Override(new(*wdpost.WindowPoStScheduler), modules.WindowPostScheduler(cfg.Proving)),
+			),
 			Override(new(sectorblocks.SectorBuilder), From(new(*storage.Miner))),
+			Override(new(*disputer.Disputer), modules.Disputer(cfg.Proving.Disputer)),
+
+			// Notifications
+			If(len(cfg.Notifications.Webhooks) > 0,
+				Override(new(*notify.Notifier), modules.MinerNotifier(cfg.Notifications)),
+			),
 		),
 
 		If(cfg.Subsystems.EnableSectorStorage,
@@ -129,6 +156,7 @@ func ConfigStorageMiner(c interface{}) Option {
 			Override(new(sectorstorage.Unsealer), From(new(*sectorstorage.Manager))),
 			Override(new(sectorstorage.SectorManager), From(new(*sectorstorage.Manager))),
 			Override(new(storiface.WorkerReturn), From(new(sectorstorage.SectorManager))),
+			Override(new(*workerboot.Registry), modules.WorkerRegistry(cfg.Storage.WorkerTemplates)),
 		),
 
 		If(!cfg.Subsystems.EnableSectorStorage,
@@ -146,6 +174,7 @@ func ConfigStorageMiner(c interface{}) Option {
 			// Markets
 			Override(new(dtypes.StagingBlockstore), modules.StagingBlockstore),
 			Override(new(dtypes.StagingGraphsync), modules.StagingGraphsync(cfg.Dealmaking.SimultaneousTransfersForStorage, cfg.Dealmaking.SimultaneousTransfersForStoragePerClient, cfg.Dealmaking.SimultaneousTransfersForRetrieval)),
+			Override(new(*transferlimit.Scheduler), modules.TransferLimits(cfg.TransferLimits)),
 			Override(new(dtypes.ProviderPieceStore), modules.NewProviderPieceStore),
 			Override(new(*sectorblocks.SectorBlocks), sectorblocks.NewSectorBlocks),
 
@@ -171,6 +200,9 @@ func ConfigStorageMiner(c interface{}) Option {
 			Override(new(retrievalmarket.RetrievalProvider), modules.RetrievalProvider),
 			Override(new(dtypes.RetrievalDealFilter), modules.RetrievalDealFilter(nil)),
 			Override(HandleRetrievalKey, modules.HandleRetrieval),
+			If(cfg.HTTPRetrieval.Enable,
+				Override(new(*httpretrieval.Server), modules.HTTPRetrievalServer(cfg.HTTPRetrieval)),
+			),
 
 			// Markets (storage)
 			Override(new(dtypes.ProviderTransferNetwork), modules.NewProviderTransferNetwork),
@@ -205,23 +237,34 @@ func ConfigStorageMiner(c interface{}) Option {
 			Override(new(dtypes.SetMaxDealStartDelayFunc), modules.NewSetMaxDealStartDelayFunc),
 			Override(new(dtypes.GetMaxDealStartDelayFunc), modules.NewGetMaxDealStartDelayFunc),
 
-			If(cfg.Dealmaking.Filter != "",
-				Override(new(dtypes.StorageDealFilter), modules.BasicDealFilter(cfg.Dealmaking, dealfilter.CliStorageDealFilter(cfg.Dealmaking.Filter))),
-			),
+			Override(new(*dealfilter.CliFilter), dealFilter),
+			Override(new(dtypes.StorageDealFilter), modules.BasicDealFilter(cfg.Dealmaking, dealFilter.StorageDealFilter)),
 
-			If(cfg.Dealmaking.RetrievalFilter != "",
-				Override(new(dtypes.RetrievalDealFilter), modules.RetrievalDealFilter(dealfilter.CliRetrievalDealFilter(cfg.Dealmaking.RetrievalFilter))),
+			If(cfg.Dealmaking.FilterWebhook != "",
+				Override(new(*dealfilter.WebhookFilter), filterWebhook),
+				Override(new(dtypes.StorageDealFilter), modules.BasicDealFilter(cfg.Dealmaking, filterWebhook.StorageDealFilter)),
 			),
+
+			Override(new(dtypes.RetrievalDealFilter), modules.RetrievalDealFilter(dealFilter.RetrievalDealFilter)),
 			Override(new(*storageadapter.DealPublisher), storageadapter.NewDealPublisher(&cfg.Fees, storageadapter.PublishMsgConfig{
 				Period:                  time.Duration(cfg.Dealmaking.PublishMsgPeriod),
 				MaxDealsPerMsg:          cfg.Dealmaking.MaxDealsPerPublishMsg,
 				StartEpochSealingBuffer: cfg.Dealmaking.StartEpochSealingBuffer,
+				StuckTimeout:            time.Duration(cfg.Dealmaking.PublishMsgStuckTimeout),
 			})),
 			Override(new(storagemarket.StorageProviderNode), storageadapter.NewProviderNodeAdapter(&cfg.Fees, &cfg.Dealmaking)),
 		),
 
 		Override(new(sectorstorage.Config), cfg.StorageManager()),
 		Override(new(*ctladdr.AddressSelector), modules.AddressSelector(&cfg.Addresses)),
+
+		// Journal, with the events of the other processes of the miner
+		Override(new(*journal.Broadcaster), modules.MinerJournal),
+		Override(new(journal.Journal), From(new(*journal.Broadcaster))),
+		Override(MirrorMinerEventsKey, modules.MirrorMinerEvents(cfg.Subsystems)),
+
+		Override(new(*config.Reloader), modules.MinerConfigReloader),
+		Override(new(config.GetMinerFeeConfigFunc), modules.MinerFeeConfig),
 	)
 }
 
@@ -12,6 +12,7 @@ import (
 	"github.com/filecoin-project/go-state-types/big"
 	miner5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/miner"
 
+	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/actors/builtin"
 	"github.com/filecoin-project/lotus/chain/actors/policy"
 	"github.com/filecoin-project/lotus/chain/types"
@@ -24,6 +25,9 @@ const (
 	// RetrievalPricingExternal configures the node to use the external retrieval pricing script
 	// configured by the user.
 	RetrievalPricingExternalMode = "external"
+	// RetrievalPricingPolicyMode configures the node to price retrievals with the retrieval
+	// pricing policy, which can be updated at runtime.
+	RetrievalPricingPolicyMode = "policy"
 )
 
 // MaxTraversalLinks configures the maximum number of links to traverse in a DAG while calculating
@@ -45,11 +49,17 @@ func defCommon() Common {
 		API: API{
 			ListenAddress: "/ip4/127.0.0.1/tcp/1234/http",
 			Timeout:       Duration(30 * time.Second),
+			MaxBatchSize:  100,
 		},
 		Logging: Logging{
 			SubsystemLevels: map[string]string{
 				"example-subsystem": "INFO",
 			},
+			Format: "color",
+		},
+		Tracing: Tracing{
+			DefaultSampleRate: 1,
+			MethodSampleRates: map[string]float64{},
 		},
 		Backup: Backup{
 			DisableMetadataLog: true,
@@ -65,6 +75,12 @@ func defCommon() Common {
 			ConnMgrLow:   150,
 			ConnMgrHigh:  180,
 			ConnMgrGrace: Duration(20 * time.Second),
+
+			StaticPeersReconnectInterval: Duration(30 * time.Second),
+
+			PeerExchange: PeerExchange{
+				Interval: Duration(5 * time.Minute),
+			},
 		},
 		Pubsub: Pubsub{
 			Bootstrapper: false,
@@ -87,6 +103,11 @@ func DefaultFullNode() *FullNode {
 		Client: Client{
 			SimultaneousTransfersForStorage:   DefaultSimultaneousTransfers,
 			SimultaneousTransfersForRetrieval: DefaultSimultaneousTransfers,
+			DealRepair: DealRepair{
+				CheckInterval:   Duration(30 * time.Minute),
+				FaultThreshold:  Duration(24 * time.Hour),
+				BackupProviders: []string{},
+			},
 		},
 		Chainstore: Chainstore{
 			EnableSplitstore: false,
@@ -97,6 +118,62 @@ func DefaultFullNode() *FullNode {
 
 				HotStoreFullGCFrequency: 20,
 			},
+			BlockstoreGC: BlockstoreGC{
+				EnableScheduledGC: false,
+				WindowStart:       "02:00",
+				WindowEnd:         "05:00",
+				MaxDuration:       Duration(2 * time.Hour),
+				MaxProbeLatency:   Duration(100 * time.Millisecond),
+			},
+			BlockCache: BlockCache{
+				EnableBlockCache: false,
+				CacheType:        "lru",
+				MaxBytes:         1 << 30,
+				MaxEntries:       0,
+			},
+			Scrub: BlockstoreScrub{
+				EnableScrub:        false,
+				Interval:           Duration(7 * 24 * time.Hour),
+				MaxBlocksPerSecond: 2000,
+				Refetch:            true,
+				RefetchTimeout:     Duration(time.Minute),
+			},
+			Index: ChainIndex{
+				EnableIndex:    false,
+				BackfillEpochs: uint64(builtin.EpochsInDay),
+			},
+			Snapshots: ChainSnapshots{
+				EnableSnapshots:  false,
+				Schedule:         "@daily",
+				Path:             "snapshots",
+				Retain:           3,
+				LatestState:      true,
+				RecentHeaders:    uint64(2 * builtin.EpochsInDay),
+				RecentStateRoots: uint64(build.Finality),
+				Compress:         true,
+			},
+		},
+		MessageWait: MessageWait{
+			Confidence: build.MessageConfidence,
+			Finality:   uint64(build.Finality),
+		},
+		Sync: Sync{
+			ParallelValidation: false,
+			SignatureWorkers:   0,
+			PipelineDepth:      0,
+			CheckpointFeed: CheckpointFeed{
+				Authorities:  []string{},
+				PollInterval: Duration(10 * time.Minute),
+			},
+		},
+		Beacon: Beacon{
+			Endpoints:           []string{},
+			HealthCheckInterval: Duration(time.Minute),
+		},
+		FaultReporter: FaultReporter{
+			EnableConsensusFaultReporter: false,
+			ReporterAddress:              "",
+			AutoSubmit:                   false,
 		},
 	}
 }
@@ -114,6 +191,10 @@ func DefaultStorageMiner() *StorageMiner {
 			FinalizeEarly:             false,
 			MakeNewSectorForDeals:     true,
 
+			SnapMatcherInterval:       Duration(5 * time.Minute),
+			SnapMatcherMaxAvailable:   1,
+			SnapMatcherMinFillPercent: 50,
+
 			CollateralFromMinerBalance: false,
 			AvailableBalanceBuffer:     types.FIL(big.Zero()),
 			DisableCollateralFallback:  false,
@@ -142,6 +223,13 @@ func DefaultStorageMiner() *StorageMiner {
 
 		Proving: ProvingConfig{
 			ParallelCheckLimit: 128,
+			Sender: WdPoStSenderConfig{
+				BumpAfterEpochs: 10,
+				BumpRatio:       1.25,
+			},
+			Disputer: WdPoStDisputerConfig{
+				MaxFee: types.MustParseFIL("0.05"),
+			},
 		},
 
 		Storage: SealerConfig{
@@ -160,6 +248,12 @@ func DefaultStorageMiner() *StorageMiner {
 
 			Assigner: "utilization",
 
+			Priorities: SealingPriorities{
+				SnapDeals: 2048,
+				Deals:     1024,
+				CC:        0,
+			},
+
 			// By default use the hardware resource filtering strategy.
 			ResourceFiltering: sealer.ResourceFilteringHardware,
 		},
@@ -177,6 +271,7 @@ func DefaultStorageMiner() *StorageMiner {
 			ExpectedSealDuration:            Duration(time.Hour * 24),
 			PublishMsgPeriod:                Duration(time.Hour),
 			MaxDealsPerPublishMsg:           8,
+			PublishMsgStuckTimeout:          Duration(time.Hour),
 			MaxProviderCollateralMultiplier: 2,
 
 			SimultaneousTransfersForStorage:          DefaultSimultaneousTransfers,
@@ -185,6 +280,9 @@ func DefaultStorageMiner() *StorageMiner {
 
 			StartEpochSealingBuffer: 480, // 480 epochs buffer == 4 hours from adding deal to sector to sector being sealed
 
+			FilterWebhookTimeout: Duration(30 * time.Second),
+			FilterQueueTimeout:   Duration(time.Hour),
+
 			RetrievalPricing: &RetrievalPricing{
 				Strategy: RetrievalPricingDefaultMode,
 				Default: &RetrievalPricingDefault{
@@ -193,6 +291,11 @@ func DefaultStorageMiner() *StorageMiner {
 				External: &RetrievalPricingExternal{
 					Path: "",
 				},
+				Policy: &RetrievalPricingPolicy{
+					FreeClients:               []string{},
+					VerifiedDealsFreeTransfer: true,
+					PiecePrices:               []RetrievalPiecePrice{},
+				},
 			},
 		},
 
@@ -245,6 +348,13 @@ func DefaultStorageMiner() *StorageMiner {
 			MaxConcurrentUnseals:       5,
 			GCInterval:                 Duration(1 * time.Minute),
 		},
+
+		Notifications: MinerNotificationsConfig{
+			Webhooks:                []NotificationWebhook{},
+			PoStDeadlineWarning:     Duration(30 * time.Minute),
+			ControlBalanceThreshold: types.MustParseFIL("1"),
+			WebhookTimeout:          Duration(10 * time.Second),
+		},
 	}
 
 	cfg.Common.API.ListenAddress = "/ip4/127.0.0.1/tcp/2345/http"
@@ -28,6 +28,77 @@ var Doc = map[string][]DocField{
 
 			Comment: ``,
 		},
+		{
+			Name: "MaxBatchSize",
+			Type: "int",
+
+			Comment: `Maximum number of requests in a JSON-RPC batch request, sent over HTTP.
+Batch requests are rejected when set to 0.`,
+		},
+		{
+			Name: "MaxConnRequests",
+			Type: "int",
+
+			Comment: `Maximum number of requests of a connection processed concurrently,
+including the requests of a batch and the calls over a websocket; the
+other requests of the connection wait for their turn. 0 means no limit.`,
+		},
+		{
+			Name: "TLS",
+			Type: "APITLS",
+
+			Comment: `TLS serves the API over TLS`,
+		},
+	},
+	"APITLS": []DocField{
+		{
+			Name: "CertFile",
+			Type: "string",
+
+			Comment: `Path of the PEM certificate chain of the API. TLS is enabled when the
+certificate and key files are set. The files are loaded again when they
+change, rotating the certificate.`,
+		},
+		{
+			Name: "KeyFile",
+			Type: "string",
+
+			Comment: `Path of the PEM private key of the certificate`,
+		},
+		{
+			Name: "ClientCAFile",
+			Type: "string",
+
+			Comment: `Path of a PEM file with the CAs issuing the client certificates. When
+set, the clients must present a certificate issued by one of them (mTLS).`,
+		},
+	},
+	"AffinityRule": []DocField{
+		{
+			Name: "Tasks",
+			Type: "[]string",
+
+			Comment: `Tasks matched by the rule, by short (PC1, PC2, ...) or full name; all
+the sealing tasks when empty`,
+		},
+		{
+			Name: "MinSector",
+			Type: "uint64",
+
+			Comment: `First sector number matched by the rule`,
+		},
+		{
+			Name: "MaxSector",
+			Type: "uint64",
+
+			Comment: `Last sector number matched by the rule; no upper bound when 0`,
+		},
+		{
+			Name: "Tag",
+			Type: "string",
+
+			Comment: `Tag the workers need to run the matched tasks`,
+		},
 	},
 	"Backup": []DocField{
 		{
@@ -41,6 +112,66 @@ Note that in case of metadata corruption it might be much harder to recover
 your node if metadata log is disabled`,
 		},
 	},
+	"BandwidthLimits": []DocField{
+		{
+			Name: "TotalCap",
+			Type: "int64",
+
+			Comment: `TotalCap is the inbound or outbound rate of the node, in bytes per second,
+above which the protocol groups with a ceiling are throttled in that
+direction; 0 disables throttling`,
+		},
+		{
+			Name: "BitswapCeiling",
+			Type: "int64",
+
+			Comment: `BitswapCeiling is the rate, in bytes per second, bitswap and chain exchange
+are throttled to while the node is above the cap; 0 means no ceiling`,
+		},
+		{
+			Name: "GraphsyncCeiling",
+			Type: "int64",
+
+			Comment: `GraphsyncCeiling is the rate, in bytes per second, graphsync is throttled to
+while the node is above the cap; 0 means no ceiling`,
+		},
+		{
+			Name: "OtherCeiling",
+			Type: "int64",
+
+			Comment: `OtherCeiling is the rate, in bytes per second, the protocols other than
+gossipsub, bitswap, chain exchange and graphsync are throttled to while the
+node is above the cap; 0 means no ceiling`,
+		},
+	},
+	"BandwidthWindow": []DocField{
+		{
+			Name: "Start",
+			Type: "string",
+
+			Comment: `Start and End of the window, in local time formatted as 15:04. A window
+ending before its start spans midnight.`,
+		},
+		{
+			Name: "End",
+			Type: "string",
+
+			Comment: ``,
+		},
+		{
+			Name: "MaxBandwidth",
+			Type: "int64",
+
+			Comment: `MaxBandwidth in bytes per second during the window, 0 means unlimited.`,
+		},
+		{
+			Name: "MaxBandwidthPerPeer",
+			Type: "int64",
+
+			Comment: `MaxBandwidthPerPeer in bytes per second during the window, 0 means
+unlimited.`,
+		},
+	},
 	"BatchFeeConfig": []DocField{
 		{
 			Name: "Base",
@@ -55,6 +186,268 @@ your node if metadata log is disabled`,
 			Comment: ``,
 		},
 	},
+	"Beacon": []DocField{
+		{
+			Name: "Endpoints",
+			Type: "[]string",
+
+			Comment: `Endpoints are drand relays used in addition to the built-in servers of the
+current drand network. http:// and https:// URLs are HTTP relays, grpc:// and
+grpcs:// addresses are gRPC endpoints. Relays are health checked, and
+randomness is fetched from the healthy relays with the lowest latency first.`,
+		},
+		{
+			Name: "HealthCheckInterval",
+			Type: "Duration",
+
+			Comment: `HealthCheckInterval is the time between health checks of the relays, in
+time.Duration string`,
+		},
+		{
+			Name: "DisablePersistentCache",
+			Type: "bool",
+
+			Comment: `DisablePersistentCache disables keeping verified beacon entries of the past
+week in the metadata datastore. The cache lets the node produce and validate
+blocks from rounds it has seen before while the relays are unreachable.`,
+		},
+	},
+	"BlockCache": []DocField{
+		{
+			Name: "EnableBlockCache",
+			Type: "bool",
+
+			Comment: `EnableBlockCache enables an in-memory cache of blocks read from the chain
+and state blockstores.`,
+		},
+		{
+			Name: "CacheType",
+			Type: "string",
+
+			Comment: `CacheType is the type of the cache: "lru" caches are bounded by the total size
+of the cached blocks (MaxBytes), while "arc" caches are bounded by the number
+of cached blocks (MaxEntries).`,
+		},
+		{
+			Name: "MaxBytes",
+			Type: "int64",
+
+			Comment: `MaxBytes is the memory budget of the lru cache, in bytes. It is not used by
+the arc cache, whose memory use depends on the size of the cached blocks.`,
+		},
+		{
+			Name: "MaxEntries",
+			Type: "int",
+
+			Comment: `MaxEntries is the maximum number of blocks held by the arc cache; it also
+bounds the lru cache when set`,
+		},
+	},
+	"BlockstoreGC": []DocField{
+		{
+			Name: "EnableScheduledGC",
+			Type: "bool",
+
+			Comment: `EnableScheduledGC enables daily online garbage collection of the chain
+blockstore within the configured window. GC can also be started manually with
+'lotus chain gc run'. Not supported when the splitstore is enabled, as the
+splitstore collects its hotstore on its own.`,
+		},
+		{
+			Name: "WindowStart",
+			Type: "string",
+
+			Comment: `WindowStart is the local time of day, in HH:MM format, at which scheduled GC runs start`,
+		},
+		{
+			Name: "WindowEnd",
+			Type: "string",
+
+			Comment: `WindowEnd is the local time of day, in HH:MM format, at which scheduled GC runs are
+stopped. The window may cross midnight.`,
+		},
+		{
+			Name: "MaxDuration",
+			Type: "Duration",
+
+			Comment: `MaxDuration limits the run time of a single GC run, in time.Duration string; 0 means
+no limit besides the end of the window for scheduled runs`,
+		},
+		{
+			Name: "MaxProbeLatency",
+			Type: "Duration",
+
+			Comment: `MaxProbeLatency stops a GC run when a probe read from the blockstore takes longer
+than this, in time.Duration string, so that GC doesn't starve the node; 0 disables
+the probe`,
+		},
+		{
+			Name: "Threshold",
+			Type: "float64",
+
+			Comment: `Threshold is the ratio of discardable data in a value log file above which the file
+is rewritten during GC; 0 uses the blockstore default`,
+		},
+	},
+	"BlockstoreScrub": []DocField{
+		{
+			Name: "EnableScrub",
+			Type: "bool",
+
+			Comment: `EnableScrub enables periodic passes of the blockstore scrubber, which verifies
+that the stored blocks hash to their CIDs. Corrupt blocks are removed from the
+blockstore and quarantined in the metadata datastore. Passes can also be started
+manually with 'lotus chain scrub run'. With the splitstore enabled, only the
+coldstore is scrubbed.`,
+		},
+		{
+			Name: "Interval",
+			Type: "Duration",
+
+			Comment: `Interval is the time between the starts of periodic scrub passes, in time.Duration
+string`,
+		},
+		{
+			Name: "MaxBlocksPerSecond",
+			Type: "int",
+
+			Comment: `MaxBlocksPerSecond limits the rate at which blocks are verified, so that scrubbing
+doesn't starve the node; 0 means no limit`,
+		},
+		{
+			Name: "Refetch",
+			Type: "bool",
+
+			Comment: `Refetch enables fetching corrupt blocks from the network over bitswap`,
+		},
+		{
+			Name: "RefetchTimeout",
+			Type: "Duration",
+
+			Comment: `RefetchTimeout limits the time spent fetching a single corrupt block, in
+time.Duration string`,
+		},
+	},
+	"ChainIndex": []DocField{
+		{
+			Name: "EnableIndex",
+			Type: "bool",
+
+			Comment: `EnableIndex maintains an index of the chain in the repo, which maps the heights
+of the chain, the messages included in it and the actors sending or receiving
+them to tipsets. It allows StateSearchMsg and StateWaitMsg to locate messages
+without walking the chain, and enables the ChainIndexActorMessages API.`,
+		},
+		{
+			Name: "BackfillEpochs",
+			Type: "uint64",
+
+			Comment: `BackfillEpochs is the number of epochs below the head indexed when the node
+starts, if not indexed yet`,
+		},
+	},
+	"ChainSnapshots": []DocField{
+		{
+			Name: "EnableSnapshots",
+			Type: "bool",
+
+			Comment: `EnableSnapshots enables a background service taking snapshots of the chain on
+a schedule. The latest snapshot can be found with the ChainSnapshotStatus API
+or 'lotus chain snapshots status'.`,
+		},
+		{
+			Name: "Schedule",
+			Type: "string",
+
+			Comment: `Schedule is a cron expression in local time with the minute, hour, day of
+month, month and day of week fields, e.g. "0 */6 * * *" for every six hours;
+@hourly, @daily, @weekly and @monthly can also be used`,
+		},
+		{
+			Name: "Path",
+			Type: "string",
+
+			Comment: `Path is the directory the snapshots are written to; relative paths are
+resolved from the repo directory`,
+		},
+		{
+			Name: "Retain",
+			Type: "int",
+
+			Comment: `Retain is the number of most recent snapshots kept, older snapshots are
+deleted; 0 keeps all snapshots`,
+		},
+		{
+			Name: "LatestState",
+			Type: "bool",
+
+			Comment: `LatestState takes snapshots of the latest state without the chain history,
+which pruned nodes can be booted from. Otherwise the snapshots include all
+the block headers down to genesis.`,
+		},
+		{
+			Name: "RecentHeaders",
+			Type: "uint64",
+
+			Comment: `RecentHeaders is the number of epochs of block headers included in latest
+state snapshots`,
+		},
+		{
+			Name: "RecentStateRoots",
+			Type: "uint64",
+
+			Comment: `RecentStateRoots is the number of epochs of state roots and messages included
+in the snapshots`,
+		},
+		{
+			Name: "SkipOldMessages",
+			Type: "bool",
+
+			Comment: `SkipOldMessages omits the messages older than RecentStateRoots from full
+snapshots`,
+		},
+		{
+			Name: "Compress",
+			Type: "bool",
+
+			Comment: `Compress compresses the snapshots with zstd`,
+		},
+		{
+			Name: "UploadCmd",
+			Type: "string",
+
+			Comment: `UploadCmd is a shell command run after each snapshot is written, with the path
+and file name of the snapshot in the SNAPSHOT and SNAPSHOT_NAME environment
+variables, e.g. to upload it to an S3 bucket with
+'aws s3 cp "$SNAPSHOT" s3://bucket/'`,
+		},
+		{
+			Name: "RemoveCmd",
+			Type: "string",
+
+			Comment: `RemoveCmd is a shell command run when an uploaded snapshot is deleted as it
+is no longer retained, with the same environment as UploadCmd`,
+		},
+		{
+			Name: "PublicURL",
+			Type: "string",
+
+			Comment: `PublicURL is the location snapshots are uploaded to by UploadCmd; the URL of
+a snapshot reported by the API is PublicURL joined with its file name`,
+		},
+	},
+	"ChainStream": []DocField{
+		{
+			Name: "ListenAddress",
+			Type: "string",
+
+			Comment: `Binding address of the gRPC service streaming the tipsets, messages,
+receipts and actor state changes of the chain to indexers. The service is
+disabled when empty. It is served over TLS with the API TLS config, and the
+calls are authorized with the API tokens.
+Format: multiaddress`,
+		},
+	},
 	"Chainstore": []DocField{
 		{
 			Name: "EnableSplitstore",
@@ -66,9 +459,71 @@ your node if metadata log is disabled`,
 			Name: "Splitstore",
 			Type: "Splitstore",
 
+			Comment: ``,
+		},
+		{
+			Name: "BlockstoreGC",
+			Type: "BlockstoreGC",
+
+			Comment: ``,
+		},
+		{
+			Name: "BlockCache",
+			Type: "BlockCache",
+
+			Comment: ``,
+		},
+		{
+			Name: "Scrub",
+			Type: "BlockstoreScrub",
+
+			Comment: ``,
+		},
+		{
+			Name: "Index",
+			Type: "ChainIndex",
+
+			Comment: ``,
+		},
+		{
+			Name: "Snapshots",
+			Type: "ChainSnapshots",
+
 			Comment: ``,
 		},
 	},
+	"CheckpointFeed": []DocField{
+		{
+			Name: "Authorities",
+			Type: "[]string",
+
+			Comment: `Authorities are the key addresses of the checkpoint authority set. When set,
+signed checkpoints from the authorities are accepted, and the tipsets they
+designate are treated as final. Manual checkpoints must then descend from the
+latest authority checkpoint.`,
+		},
+		{
+			Name: "Threshold",
+			Type: "int",
+
+			Comment: `Threshold is the number of distinct authority signatures a checkpoint needs
+to be accepted; 0 requires all authorities to sign`,
+		},
+		{
+			Name: "URL",
+			Type: "string",
+
+			Comment: `URL of the checkpoint feed, which serves the latest signed checkpoint as JSON;
+when empty, signed checkpoints can only be submitted with
+'lotus sync checkpoint --signed'`,
+		},
+		{
+			Name: "PollInterval",
+			Type: "Duration",
+
+			Comment: `PollInterval is the time between polls of the feed, in time.Duration string`,
+		},
+	},
 	"Client": []DocField{
 		{
 			Name: "UseIpfs",
@@ -116,6 +571,13 @@ and storage providers for retrieval deals`,
 without existing payment channels with available funds will fail instead
 of automatically performing on-chain operations.`,
 		},
+		{
+			Name: "DealRepair",
+			Type: "DealRepair",
+
+			Comment: `Monitors the sectors holding the active storage deals of the client, and
+proposes replacement deals to backup providers for the failed deals.`,
+		},
 	},
 	"Common": []DocField{
 		{
@@ -136,6 +598,12 @@ of automatically performing on-chain operations.`,
 
 			Comment: ``,
 		},
+		{
+			Name: "Tracing",
+			Type: "Tracing",
+
+			Comment: ``,
+		},
 		{
 			Name: "Libp2p",
 			Type: "Libp2p",
@@ -206,6 +674,56 @@ representation, e.g. 1m, 5m, 1h.
 Default value: 1 minute.`,
 		},
 	},
+	"DeadlineFeeConfig": []DocField{
+		{
+			Name: "Deadlines",
+			Type: "[]uint64",
+
+			Comment: ``,
+		},
+		{
+			Name: "MaxFee",
+			Type: "types.FIL",
+
+			Comment: ``,
+		},
+	},
+	"DealRepair": []DocField{
+		{
+			Name: "Enable",
+			Type: "bool",
+
+			Comment: `Enable the deal health monitor`,
+		},
+		{
+			Name: "CheckInterval",
+			Type: "Duration",
+
+			Comment: `How often the health of the active deals is checked`,
+		},
+		{
+			Name: "FaultThreshold",
+			Type: "Duration",
+
+			Comment: `How long the sector of a deal may stay faulty before the deal is
+repaired. Deals slashed or whose sector was terminated are repaired
+right away.`,
+		},
+		{
+			Name: "BackupProviders",
+			Type: "[]string",
+
+			Comment: `Addresses of the storage providers to propose replacement deals to, in
+order of preference`,
+		},
+		{
+			Name: "Wallet",
+			Type: "string",
+
+			Comment: `Address of the wallet paying for the replacement deals, the client
+address of the failed deal when empty`,
+		},
+	},
 	"DealmakingConfig": []DocField{
 		{
 			Name: "ConsiderOnlineStorageDeals",
@@ -276,6 +794,14 @@ deals to be ready to publish before publishing them all as a batch`,
 
 			Comment: `The maximum number of deals to include in a single PublishStorageDeals
 message`,
+		},
+		{
+			Name: "PublishMsgStuckTimeout",
+			Type: "Duration",
+
+			Comment: `The amount of time after which a PublishStorageDeals message which hasn't
+landed on chain is replaced with a message paying a higher gas premium,
+within MaxPublishDealsFee. 0 disables the replacement.`,
 		},
 		{
 			Name: "MaxProviderCollateralMultiplier",
@@ -333,6 +859,29 @@ see https://docs.filecoin.io/mine/lotus/miner-configuration/#using-filters-for-f
 
 			Comment: `A command used for fine-grained evaluation of retrieval deals
 see https://docs.filecoin.io/mine/lotus/miner-configuration/#using-filters-for-fine-grained-storage-and-retrieval-deal-acceptance for more details`,
+		},
+		{
+			Name: "FilterWebhook",
+			Type: "string",
+
+			Comment: `A URL of a webhook used for fine-grained evaluation of storage deals, as an
+alternative to Filter. Deals are POSTed to it as JSON, and it responds with a
+JSON object with a Decision of "accept", "reject" or "queue" and an optional
+Reason. Queued deals wait for a decision made with
+'lotus-miner storage-deals pending-filter'.`,
+		},
+		{
+			Name: "FilterWebhookTimeout",
+			Type: "Duration",
+
+			Comment: `The maximum time to wait for a response of the deal filter webhook`,
+		},
+		{
+			Name: "FilterQueueTimeout",
+			Type: "Duration",
+
+			Comment: `The maximum time a deal queued by the deal filter webhook waits for a
+decision, after which it is rejected`,
 		},
 		{
 			Name: "RetrievalPricing",
@@ -341,6 +890,31 @@ see https://docs.filecoin.io/mine/lotus/miner-configuration/#using-filters-for-f
 			Comment: ``,
 		},
 	},
+	"FaultReporter": []DocField{
+		{
+			Name: "EnableConsensusFaultReporter",
+			Type: "bool",
+
+			Comment: `EnableConsensusFaultReporter enables the service scanning incoming blocks for
+double-fork, time-offset and parent-grinding consensus faults. The evidence of
+the faults is archived in the metadata datastore, and the faults are queued for
+review with 'lotus chain consensus-faults'.`,
+		},
+		{
+			Name: "ReporterAddress",
+			Type: "string",
+
+			Comment: `ReporterAddress is the address sending the ReportConsensusFault messages, and
+receiving the reward for them; faults can't be submitted when it's empty`,
+		},
+		{
+			Name: "AutoSubmit",
+			Type: "bool",
+
+			Comment: `AutoSubmit reports the faults as soon as they are detected, instead of
+queueing them for review`,
+		},
+	},
 	"FeeConfig": []DocField{
 		{
 			Name: "DefaultMaxFee",
@@ -374,6 +948,63 @@ see https://docs.filecoin.io/mine/lotus/miner-configuration/#using-filters-for-f
 
 			Comment: ``,
 		},
+		{
+			Name: "Sync",
+			Type: "Sync",
+
+			Comment: ``,
+		},
+		{
+			Name: "Beacon",
+			Type: "Beacon",
+
+			Comment: ``,
+		},
+		{
+			Name: "FaultReporter",
+			Type: "FaultReporter",
+
+			Comment: ``,
+		},
+		{
+			Name: "MessageWait",
+			Type: "MessageWait",
+
+			Comment: ``,
+		},
+		{
+			Name: "ChainStream",
+			Type: "ChainStream",
+
+			Comment: ``,
+		},
+	},
+	"HTTPRetrievalConfig": []DocField{
+		{
+			Name: "Enable",
+			Type: "bool",
+
+			Comment: `Enable serving unsealed pieces at /piece/<piece cid> and payload CAR files
+at /ipfs/<root cid> on the markets API endpoint, so that clients can
+retrieve data over plain HTTP. Piece requests support byte ranges.
+Only pieces which already have an unsealed copy are served, HTTP
+retrievals never unseal sectors.`,
+		},
+		{
+			Name: "AccessTokens",
+			Type: "[]string",
+
+			Comment: `AccessTokens which clients must send in a bearer Authorization header or
+in the token query parameter. When empty, anyone who can reach the
+endpoint can retrieve data.`,
+		},
+		{
+			Name: "MaxBandwidth",
+			Type: "int64",
+
+			Comment: `MaxBandwidth is the maximum number of bytes per second sent to all HTTP
+retrieval clients combined. 0 means unlimited.`,
+		},
 	},
 	"IndexProviderConfig": []DocField{
 		{
@@ -486,6 +1117,50 @@ count towards this limit.`,
 			Comment: `ConnMgrGrace is a time duration that new connections are immune from being
 closed by the connection manager.`,
 		},
+		{
+			Name: "StaticPeers",
+			Type: "[]string",
+
+			Comment: `StaticPeers are peers the node stays connected to, e.g. the other members
+of a private network. Connections to static peers are protected from the
+connection manager, and are redialed when they drop.
+Format: multiaddress with a peer ID, e.g. /ip4/1.2.3.4/tcp/1234/p2p/12D3K...`,
+		},
+		{
+			Name: "StaticPeersReconnectInterval",
+			Type: "Duration",
+
+			Comment: `StaticPeersReconnectInterval is how often disconnected static peers are
+redialed`,
+		},
+		{
+			Name: "DisableDHT",
+			Type: "bool",
+
+			Comment: `DisableDHT disables the Kademlia DHT, which is used to look up the addresses
+of peers and to discover new peers. On networks without a DHT, peers are
+only found through bootstrap peers, static peers and the peer exchange.`,
+		},
+		{
+			Name: "DisablePeerDiscovery",
+			Type: "bool",
+
+			Comment: `DisablePeerDiscovery stops the node from looking for new peers when it is
+short of peers, so that it only connects to bootstrap peers, static peers,
+peers found through the peer exchange and peers dialing in.`,
+		},
+		{
+			Name: "PeerExchange",
+			Type: "PeerExchange",
+
+			Comment: ``,
+		},
+		{
+			Name: "BandwidthLimits",
+			Type: "BandwidthLimits",
+
+			Comment: ``,
+		},
 	},
 	"Logging": []DocField{
 		{
@@ -494,6 +1169,32 @@ closed by the connection manager.`,
 
 			Comment: `SubsystemLevels specify per-subsystem log levels`,
 		},
+		{
+			Name: "Format",
+			Type: "string",
+
+			Comment: `Format of the log output: "color", "nocolor" or "json" for structured
+logs with one JSON object per line. The GOLOG_LOG_FMT environment
+variable takes precedence. Defaults to "color".`,
+		},
+	},
+	"MessageWait": []DocField{
+		{
+			Name: "Confidence",
+			Type: "uint64",
+
+			Comment: `Confidence is the number of epochs StateWaitMsg waits for after a message was
+executed, when called with the default confidence (api.ConfidenceDefault)`,
+		},
+		{
+			Name: "Finality",
+			Type: "uint64",
+
+			Comment: `Finality is the number of epochs after which tipsets are considered final by
+the message waiting APIs: StateWaitMsg and StateSearchMsg search back this far
+when called with api.LookbackFinality. Devnets can set it below the mainnet
+finality.`,
+		},
 	},
 	"MinerAddressConfig": []DocField{
 		{
@@ -587,6 +1288,35 @@ over the worker address if this flag is set.`,
 			Comment: ``,
 		},
 	},
+	"MinerNotificationsConfig": []DocField{
+		{
+			Name: "Webhooks",
+			Type: "[]NotificationWebhook",
+
+			Comment: `Webhooks notified of the miner events. No checks run when empty.`,
+		},
+		{
+			Name: "PoStDeadlineWarning",
+			Type: "Duration",
+
+			Comment: `PoStDeadlineWarning is the time left in the open proving deadline, with
+partitions still unproven, at which the post-deadline event fires.`,
+		},
+		{
+			Name: "ControlBalanceThreshold",
+			Type: "types.FIL",
+
+			Comment: `ControlBalanceThreshold is the balance of the worker and control
+addresses below which the control-balance event fires. 0 disables the
+event.`,
+		},
+		{
+			Name: "WebhookTimeout",
+			Type: "Duration",
+
+			Comment: `WebhookTimeout is the maximum time to wait for a webhook response.`,
+		},
+	},
 	"MinerSubsystemConfig": []DocField{
 		{
 			Name: "EnableMining",
@@ -612,6 +1342,13 @@ over the worker address if this flag is set.`,
 
 			Comment: ``,
 		},
+		{
+			Name: "EnableProving",
+			Type: "bool",
+
+			Comment: `EnableProving runs the WindowPoSt scheduler on a node with mining
+disabled, making it the proving service of the miner`,
+		},
 		{
 			Name: "SealerApiInfo",
 			Type: "string",
@@ -624,6 +1361,64 @@ over the worker address if this flag is set.`,
 
 			Comment: ``,
 		},
+		{
+			Name: "ProvingApiInfo",
+			Type: "string",
+
+			Comment: `ProvingApiInfo is the API of the proving service of a mining node. When
+set, the mining node leaves WindowPoSt to the proving service, and
+records the events of the service in its journal.`,
+		},
+	},
+	"NotificationWebhook": []DocField{
+		{
+			Name: "URL",
+			Type: "string",
+
+			Comment: `URL the events are POSTed to.`,
+		},
+		{
+			Name: "Format",
+			Type: "string",
+
+			Comment: `Format of the JSON body: "generic" for the event itself, "slack" for a
+Slack incoming webhook, or "pagerduty" for the PagerDuty events API v2.`,
+		},
+		{
+			Name: "RoutingKey",
+			Type: "string",
+
+			Comment: `RoutingKey is the integration key of a pagerduty webhook.`,
+		},
+		{
+			Name: "Events",
+			Type: "[]string",
+
+			Comment: `Events sent to the webhook, out of post-deadline, sectors-faulty,
+worker-disconnected, control-balance and test. All events when empty.`,
+		},
+	},
+	"PeerExchange": []DocField{
+		{
+			Name: "EnablePeerExchange",
+			Type: "bool",
+
+			Comment: `EnablePeerExchange enables serving and requesting peer records; records are
+only served to, and accepted from, static peers and Members`,
+		},
+		{
+			Name: "Interval",
+			Type: "Duration",
+
+			Comment: `Interval is how often peer records are requested from connected members`,
+		},
+		{
+			Name: "Members",
+			Type: "[]string",
+
+			Comment: `Members are the peer IDs of the other members of the network, in addition
+to the static peers`,
+		},
 	},
 	"ProvingConfig": []DocField{
 		{
@@ -717,6 +1512,19 @@ In those cases it may be necessary to set this value to something low (eg 1);
 Note that setting this value lower may result in less efficient gas use - more messages will be sent than needed,
 resulting in more total gas use (but each message will have lower gas limit)`,
 		},
+		{
+			Name: "Sender",
+			Type: "WdPoStSenderConfig",
+
+			Comment: `Sending of the SubmitWindowedPoSt messages`,
+		},
+		{
+			Name: "Disputer",
+			Type: "WdPoStDisputerConfig",
+
+			Comment: `Checking of the WindowPoSts of other miners, which can be disputed for
+some time after their deadline closed`,
+		},
 	},
 	"Pubsub": []DocField{
 		{
@@ -746,7 +1554,75 @@ Type: Array of multiaddress peerinfo strings, must include peerid (/p2p/12D3K...
 			Name: "RemoteTracer",
 			Type: "string",
 
-			Comment: ``,
+			Comment: ``,
+		},
+	},
+	"RemoteProver": []DocField{
+		{
+			Name: "URL",
+			Type: "string",
+
+			Comment: `URL of the prover service, eg. https://prover.example.com`,
+		},
+		{
+			Name: "Token",
+			Type: "string",
+
+			Comment: `Token sent to the prover as bearer token, when set`,
+		},
+		{
+			Name: "Tasks",
+			Type: "[]string",
+
+			Comment: `Tasks dispatched to the prover: PC2 and/or C2. When several provers
+run a task, the first one is used.`,
+		},
+		{
+			Name: "AttestationKey",
+			Type: "string",
+
+			Comment: `Hex encoded HMAC-SHA256 key of the attestations of the results, shared
+with the prover. When set, the results without a valid attestation are
+rejected.`,
+		},
+		{
+			Name: "Fallback",
+			Type: "bool",
+
+			Comment: `Fallback runs the task on the local worker when the prover fails`,
+		},
+		{
+			Name: "PollInterval",
+			Type: "Duration",
+
+			Comment: `Interval between the polls of the status of a job`,
+		},
+		{
+			Name: "Timeout",
+			Type: "Duration",
+
+			Comment: `Timeout of a job, including its time in the queue of the prover and the
+transfer of the sector files; 0 means no timeout`,
+		},
+	},
+	"RetrievalPiecePrice": []DocField{
+		{
+			Name: "PieceCID",
+			Type: "string",
+
+			Comment: ``,
+		},
+		{
+			Name: "PricePerByte",
+			Type: "*types.FIL",
+
+			Comment: `Price per byte of retrievals from the piece; the price of the ask applies when not set`,
+		},
+		{
+			Name: "UnsealPrice",
+			Type: "*types.FIL",
+
+			Comment: `Price to unseal the piece; the unseal price of the ask applies when not set`,
 		},
 	},
 	"RetrievalPricing": []DocField{
@@ -766,6 +1642,12 @@ Type: Array of multiaddress peerinfo strings, must include peerid (/p2p/12D3K...
 			Name: "External",
 			Type: "*RetrievalPricingExternal",
 
+			Comment: ``,
+		},
+		{
+			Name: "Policy",
+			Type: "*RetrievalPricingPolicy",
+
 			Comment: ``,
 		},
 	},
@@ -789,6 +1671,38 @@ default value is true`,
 This parameter is ONLY applicable if the retrieval pricing policy strategy has been configured to "external".`,
 		},
 	},
+	"RetrievalPricingPolicy": []DocField{
+		{
+			Name: "FreeClients",
+			Type: "[]string",
+
+			Comment: `Peer IDs of the clients which retrieve for free.
+This parameter is ONLY applicable if the retrieval pricing policy strategy has been configured to "policy".`,
+		},
+		{
+			Name: "VerifiedDealsFreeTransfer",
+			Type: "bool",
+
+			Comment: `VerifiedDealsFreeTransfer configures zero fees for data transfer for a retrieval deal
+of a payloadCid that belongs to a verified storage deal.
+This parameter is ONLY applicable if the retrieval pricing policy strategy has been configured to "policy".`,
+		},
+		{
+			Name: "PiecePrices",
+			Type: "[]RetrievalPiecePrice",
+
+			Comment: `Prices overriding the ask for retrievals from specific pieces.
+This parameter is ONLY applicable if the retrieval pricing policy strategy has been configured to "policy".`,
+		},
+		{
+			Name: "SealedPricePercent",
+			Type: "uint64",
+
+			Comment: `Price per byte of retrievals which require unsealing a sector, in percent of the price per byte of the
+ask (0 = 100). Unsealing is free when an unsealed copy of the piece exists.
+This parameter is ONLY applicable if the retrieval pricing policy strategy has been configured to "policy".`,
+		},
+	},
 	"SealerConfig": []DocField{
 		{
 			Name: "ParallelFetchLimit",
@@ -851,6 +1765,27 @@ This parameter is ONLY applicable if the retrieval pricing policy strategy has b
 			Comment: `Assigner specifies the worker assigner to use when scheduling tasks.
 "utilization" (default) - assign tasks to workers with lowest utilization.
 "spread" - assign tasks to as many distinct workers as possible.`,
+		},
+		{
+			Name: "Priorities",
+			Type: "SealingPriorities",
+
+			Comment: `Priorities of the sealing tasks by class of sector; they can be changed
+at runtime with 'lotus-miner sealing priorities'`,
+		},
+		{
+			Name: "WorkerTags",
+			Type: "map[string][]string",
+
+			Comment: `WorkerTags are the tags of the workers, by hostname; they can be changed
+at runtime with 'lotus-miner sealing workers tag/untag'`,
+		},
+		{
+			Name: "AffinityRules",
+			Type: "[]AffinityRule",
+
+			Comment: `AffinityRules restrict the sealing tasks they match to the workers with
+their tag. Tasks matching several rules need all their tags.`,
 		},
 		{
 			Name: "DisallowRemoteFinalize",
@@ -876,6 +1811,75 @@ If you see stuck Finalize tasks after enabling this setting, check
 to use when evaluating tasks against this worker. An empty value defaults
 to "hardware".`,
 		},
+		{
+			Name: "RemoteProvers",
+			Type: "[]RemoteProver",
+
+			Comment: `RemoteProvers dispatch the PC2 and C2 tasks of the local worker to
+external compute services, such as GPU farms; the tasks must be allowed
+on the local worker (AllowPreCommit2, AllowCommit). The protocol is
+described in storage/sealer/remoteprover.`,
+		},
+		{
+			Name: "MaxUnsealsPerPath",
+			Type: "int",
+
+			Comment: `MaxUnsealsPerPath limits the number of sectors unsealed at the same time
+from each storage path holding sealed sectors; unseals serving retrievals
+are started before those serving deal transfers. 0 means no limit.`,
+		},
+		{
+			Name: "UnsealedCacheMaxBytes",
+			Type: "int64",
+
+			Comment: `UnsealedCacheMaxBytes is the total size of the unsealed copies created by
+unseals above which the least recently read ones are removed. 0 keeps
+all the unsealed copies.`,
+		},
+		{
+			Name: "RebalanceInterval",
+			Type: "Duration",
+
+			Comment: `RebalanceInterval at which sectors are moved between the local long-term
+storage paths, so that the use of each path follows its weight, and out
+of the paths being drained. 0 only rebalances on demand, with
+'lotus-miner storage rebalance start'.`,
+		},
+		{
+			Name: "RebalanceMaxBandwidth",
+			Type: "int64",
+
+			Comment: `RebalanceMaxBandwidth limits the bytes copied per second when moving
+sectors. 0 means no limit.`,
+		},
+		{
+			Name: "RebalanceMaxMoves",
+			Type: "int",
+
+			Comment: `RebalanceMaxMoves limits the number of sectors moved by a rebalance.
+0 means no limit.`,
+		},
+		{
+			Name: "AllocationPolicy",
+			Type: "string",
+
+			Comment: `AllocationPolicy places the files of a sector in the long-term storage
+paths of the miner depending on the groups of the paths, set with
+'lotus-miner storage attach --groups', e.g. one group per chassis or rack.
+"" (default) - place each file in the best path.
+"same-group" - keep the files of a sector in paths sharing a group, so
+that a failing group only affects the sectors stored in it.
+"spread-groups" - place the files of a sector in paths sharing no group.
+Paths without groups only share a group with themselves.`,
+		},
+		{
+			Name: "WorkerTemplates",
+			Type: "[]WorkerTemplate",
+
+			Comment: `WorkerTemplates configure the workers started with a one-time token from
+'lotus-miner sealing workers bootstrap-token'; workers get the template
+of their token, or the first template their host satisfies.`,
+		},
 	},
 	"SealingConfig": []DocField{
 		{
@@ -929,6 +1933,26 @@ terminated. Value must be between 180-540 days inclusive`,
 
 			Comment: `Period of time that a newly created sector will wait for more deals to be packed in to before it starts to seal.
 Sectors which are fully filled will start sealing immediately`,
+		},
+		{
+			Name: "MaxDealsPerSector",
+			Type: "uint64",
+
+			Comment: `Upper bound on the number of deals packed into a single sector, below the protocol limit (0 = protocol limit)`,
+		},
+		{
+			Name: "TargetSectorFillPercent",
+			Type: "uint64",
+
+			Comment: `Percentage of a sector which, once filled with deals, makes the sector start sealing without waiting for
+WaitDealsDelay (0 = only fully filled sectors start sealing early)`,
+		},
+		{
+			Name: "MaxConcurrentDealsPerClient",
+			Type: "uint64",
+
+			Comment: `Upper bound on how many deals of a single client can be added to sectors at the same time; further deals of the
+client wait for a slot before being assigned to a sector (0 = unlimited)`,
 		},
 		{
 			Name: "AlwaysKeepUnsealedCopy",
@@ -957,6 +1981,32 @@ This is useful for forcing all deals to be assigned as snap deals to sectors mar
 
 			Comment: `After sealing CC sectors, make them available for upgrading with deals`,
 		},
+		{
+			Name: "SnapMatcherEnable",
+			Type: "bool",
+
+			Comment: `Run the SnapDeals matcher, which marks proving CC sectors Available for upgrading with deals when there are
+pending deals which fit them. The matcher picks the sector expiring the earliest among the sectors outliving
+enough of the pending deals`,
+		},
+		{
+			Name: "SnapMatcherInterval",
+			Type: "Duration",
+
+			Comment: `How often the matcher checks the pending deals`,
+		},
+		{
+			Name: "SnapMatcherMaxAvailable",
+			Type: "uint64",
+
+			Comment: `Upper bound on how many sectors can be Available for upgrading when the matcher marks more (0 = unlimited)`,
+		},
+		{
+			Name: "SnapMatcherMinFillPercent",
+			Type: "uint64",
+
+			Comment: `Percentage of a sector the pending deals fitting in its lifetime must fill for the matcher to mark the sector`,
+		},
 		{
 			Name: "CollateralFromMinerBalance",
 			Type: "bool",
@@ -1062,6 +2112,34 @@ submitting proofs to the chain individually`,
 			Comment: ``,
 		},
 	},
+	"SealingPriorities": []DocField{
+		{
+			Name: "SnapDeals",
+			Type: "int",
+
+			Comment: `Priority of the tasks of snap-deals sectors`,
+		},
+		{
+			Name: "Deals",
+			Type: "int",
+
+			Comment: `Priority of the tasks of new sectors with deals`,
+		},
+		{
+			Name: "CC",
+			Type: "int",
+
+			Comment: `Priority of the tasks of committed capacity sectors`,
+		},
+		{
+			Name: "Preempt",
+			Type: "bool",
+
+			Comment: `Preempt lets the tasks of a lower priority, which are waiting for
+resources once their inputs are fetched, give their worker away to tasks
+of a higher priority. Running tasks are never interrupted.`,
+		},
+	},
 	"Splitstore": []DocField{
 		{
 			Name: "ColdStoreType",
@@ -1155,6 +2233,110 @@ Default is 20 (about once a week).`,
 
 			Comment: ``,
 		},
+		{
+			Name: "HTTPRetrieval",
+			Type: "HTTPRetrievalConfig",
+
+			Comment: ``,
+		},
+		{
+			Name: "TransferLimits",
+			Type: "TransferLimitsConfig",
+
+			Comment: ``,
+		},
+		{
+			Name: "Notifications",
+			Type: "MinerNotificationsConfig",
+
+			Comment: ``,
+		},
+	},
+	"Sync": []DocField{
+		{
+			Name: "ParallelValidation",
+			Type: "bool",
+
+			Comment: `ParallelValidation enables the validation pipeline for catch-up sync, which
+fetches the messages of upcoming tipsets and verifies their signatures on a pool
+of workers while earlier tipsets are being executed.`,
+		},
+		{
+			Name: "SignatureWorkers",
+			Type: "int",
+
+			Comment: `SignatureWorkers is the number of workers verifying message signatures ahead of
+execution; 0 means one per CPU`,
+		},
+		{
+			Name: "PipelineDepth",
+			Type: "int",
+
+			Comment: `PipelineDepth is the maximum number of tipsets fetched and verified ahead of
+execution; 0 uses the default`,
+		},
+		{
+			Name: "CheckpointFeed",
+			Type: "CheckpointFeed",
+
+			Comment: ``,
+		},
+	},
+	"Tracing": []DocField{
+		{
+			Name: "DefaultSampleRate",
+			Type: "float64",
+
+			Comment: `DefaultSampleRate is the fraction of the API calls traced, for the
+methods without a rate in MethodSampleRates`,
+		},
+		{
+			Name: "MethodSampleRates",
+			Type: "map[string]float64",
+
+			Comment: `MethodSampleRates sets the fraction of the calls traced for specific API
+methods, by method name, e.g. "StateMinerPower" = 0.1`,
+		},
+	},
+	"TransferLimitsConfig": []DocField{
+		{
+			Name: "MaxBandwidth",
+			Type: "int64",
+
+			Comment: `MaxBandwidth is the maximum number of bytes per second sent by graphsync
+to all peers combined, e.g. to serve retrievals. 0 means unlimited.`,
+		},
+		{
+			Name: "MaxBandwidthPerPeer",
+			Type: "int64",
+
+			Comment: `MaxBandwidthPerPeer is the maximum number of bytes per second sent by
+graphsync to a single peer. 0 means unlimited.`,
+		},
+		{
+			Name: "MaxConcurrentTransfers",
+			Type: "int",
+
+			Comment: `MaxConcurrentTransfers is the maximum number of graphsync responses
+sending data at the same time, further responses wait for a free slot.
+0 means unlimited.`,
+		},
+		{
+			Name: "MaxConcurrentTransfersPerPeer",
+			Type: "int",
+
+			Comment: `MaxConcurrentTransfersPerPeer is the maximum number of graphsync
+responses sending data to a single peer at the same time. 0 means
+unlimited.`,
+		},
+		{
+			Name: "Schedule",
+			Type: "[]BandwidthWindow",
+
+			Comment: `Schedule replaces the bandwidth caps during times of the day, e.g. to
+leave more room for proving reads during business hours. The first
+window covering the current time applies.`,
+		},
 	},
 	"Wallet": []DocField{
 		{
@@ -1173,6 +2355,157 @@ Default is 20 (about once a week).`,
 			Name: "DisableLocal",
 			Type: "bool",
 
+			Comment: ``,
+		},
+	},
+	"WdPoStDisputerConfig": []DocField{
+		{
+			Name: "Enable",
+			Type: "bool",
+
+			Comment: `Check the WindowPoSts of Miners, and of the deadlines holding the
+sectors given as faulty sector evidence with
+'lotus-miner proving dispute evidence', once their deadline closed`,
+		},
+		{
+			Name: "Miners",
+			Type: "[]string",
+
+			Comment: `Miners whose WindowPoSts are checked at every deadline, eg. the miner
+itself after an upgrade of its proving setup`,
+		},
+		{
+			Name: "AutoDispute",
+			Type: "bool",
+
+			Comment: `Send DisputeWindowedPoSt messages for the invalid WindowPoSts found; they
+are only reported otherwise`,
+		},
+		{
+			Name: "From",
+			Type: "string",
+
+			Comment: `Address the DisputeWindowedPoSt messages are sent from; the worker
+address when empty`,
+		},
+		{
+			Name: "MaxFee",
+			Type: "types.FIL",
+
+			Comment: `Maximum fee of a DisputeWindowedPoSt message`,
+		},
+	},
+	"WdPoStSenderConfig": []DocField{
+		{
+			Name: "DeadlineMaxFees",
+			Type: "[]DeadlineFeeConfig",
+
+			Comment: `Maximum fees of the WindowPoSt messages of some deadlines, overriding
+Fees.MaxWindowPoStGasFee for those deadlines`,
+		},
+		{
+			Name: "BumpAfterEpochs",
+			Type: "int",
+
+			Comment: `Number of epochs a WindowPoSt message can wait in the mpool before its
+gas fee cap and premium are bumped. The message is bumped again after the
+same number of epochs until it lands, its deadline closes, or its fees
+reach the maximum fee of its deadline. 0 disables fee bumping.`,
+		},
+		{
+			Name: "BumpRatio",
+			Type: "float64",
+
+			Comment: `Ratio the gas fee cap and premium of a message are multiplied by when it
+is bumped; the mpool requires at least 1.25 to replace a message`,
+		},
+		{
+			Name: "FullNodes",
+			Type: "[]string",
+
+			Comment: `API infos of additional full nodes the WindowPoSt messages are pushed to,
+eg. "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...:/ip4/10.0.0.2/tcp/1234/http",
+so that the messages propagate when the main full node is unhealthy. The
+tokens need the write permission.`,
+		},
+		{
+			Name: "FallbackAddress",
+			Type: "string",
+
+			Comment: `Control address the WindowPoSt messages are sent from when they can't be
+pushed from the address selected for them, eg. because it lacks funds, or
+when they can't be bumped anymore`,
+		},
+	},
+	"WorkerTemplate": []DocField{
+		{
+			Name: "Name",
+			Type: "string",
+
+			Comment: `Name of the template, given to 'lotus-miner sealing workers bootstrap-token'`,
+		},
+		{
+			Name: "MinCPUs",
+			Type: "uint64",
+
+			Comment: `MinCPUs, MinMemory (bytes), MinGPUs and MinStorage (bytes available in
+the storage paths) the host of the worker needs; 0 means no requirement`,
+		},
+		{
+			Name: "MinMemory",
+			Type: "uint64",
+
+			Comment: ``,
+		},
+		{
+			Name: "MinGPUs",
+			Type: "int",
+
+			Comment: ``,
+		},
+		{
+			Name: "MinStorage",
+			Type: "int64",
+
+			Comment: ``,
+		},
+		{
+			Name: "Tasks",
+			Type: "[]string",
+
+			Comment: `Tasks run by the workers, by short name (AP, PC1, PC2, C2, ...); the
+workers keep the tasks of their flags when empty`,
+		},
+		{
+			Name: "Tags",
+			Type: "[]string",
+
+			Comment: `Tags given to the hosts of the workers, see WorkerTags`,
+		},
+		{
+			Name: "Env",
+			Type: "map[string]string",
+
+			Comment: `Env sets environment variables of the workers, such as the resource
+overrides (e.g. PC1_MAX_PARALLELISM)`,
+		},
+		{
+			Name: "NoSwap",
+			Type: "bool",
+
+			Comment: `NoSwap, ParallelFetchLimit and AllocationPolicy set the corresponding
+flags of 'lotus-worker run', unless they are given on the command line`,
+		},
+		{
+			Name: "ParallelFetchLimit",
+			Type: "int",
+
+			Comment: ``,
+		},
+		{
+			Name: "AllocationPolicy",
+			Type: "string",
+
 			Comment: ``,
 		},
 	},
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"bytes"
+	"encoding/hex"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/crypto"
+)
+
+// PolicyDocumentVersion is the current version of the policy document format
+const PolicyDocumentVersion = 1
+
+// MinerPolicy is the operational policy of a miner: the config sections which
+// control how the miner selects addresses, pays for gas, accepts deals, and
+// batches and seals sectors, and which are meant to be kept consistent across
+// a fleet of miners.
+type MinerPolicy struct {
+	Addresses  MinerAddressConfig
+	Fees       MinerFeeConfig
+	Dealmaking DealmakingConfig
+	Sealing    SealingConfig
+}
+
+func PolicyFromConfig(cfg *StorageMiner) MinerPolicy {
+	return MinerPolicy{
+		Addresses:  cfg.Addresses,
+		Fees:       cfg.Fees,
+		Dealmaking: cfg.Dealmaking,
+		Sealing:    cfg.Sealing,
+	}
+}
+
+// ApplyTo replaces the policy sections of the given config with the policy
+func (p *MinerPolicy) ApplyTo(cfg *StorageMiner) {
+	cfg.Addresses = p.Addresses
+	cfg.Fees = p.Fees
+	cfg.Dealmaking = p.Dealmaking
+	cfg.Sealing = p.Sealing
+}
+
+// PolicyDocument is a versioned, optionally signed, export of a miner policy
+type PolicyDocument struct {
+	// Version is the version of the document format
+	Version int
+	// Revision is the revision of the policy, set by the exporter
+	Revision uint64
+	Created  time.Time
+
+	// Signer is the key address which signed the document, and Signature the hex
+	// encoded signature over the document without the Signature field; both are
+	// empty in unsigned documents
+	Signer    string
+	Signature string
+
+	Policy MinerPolicy
+}
+
+func NewPolicyDocument(cfg *StorageMiner, revision uint64) *PolicyDocument {
+	return &PolicyDocument{
+		Version:  PolicyDocumentVersion,
+		Revision: revision,
+		Created:  time.Now().UTC().Truncate(time.Second),
+		Policy:   PolicyFromConfig(cfg),
+	}
+}
+
+// ParsePolicyDocument decodes a policy document, checking that its version is
+// supported. Settings missing from the document take their default values.
+func ParsePolicyDocument(b []byte) (*PolicyDocument, error) {
+	doc, err := decodePolicyDocument(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if doc.Version != PolicyDocumentVersion {
+		return nil, xerrors.Errorf("unsupported policy document version %d (expected %d)", doc.Version, PolicyDocumentVersion)
+	}
+
+	if (doc.Signer == "") != (doc.Signature == "") {
+		return nil, xerrors.Errorf("policy document must specify both signer and signature, or neither")
+	}
+
+	return doc, nil
+}
+
+func decodePolicyDocument(b []byte) (*PolicyDocument, error) {
+	doc := PolicyDocument{
+		Policy: PolicyFromConfig(DefaultStorageMiner()),
+	}
+	if _, err := toml.Decode(string(b), &doc); err != nil {
+		return nil, xerrors.Errorf("decoding policy document: %w", err)
+	}
+
+	return &doc, nil
+}
+
+// Encode returns the toml encoding of the document
+func (d *PolicyDocument) Encode() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := toml.NewEncoder(buf).Encode(d); err != nil {
+		return nil, xerrors.Errorf("encoding policy document: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// SigningBytes returns the bytes covered by the document signature, which is
+// the encoding of the document without the signature itself
+func (d *PolicyDocument) SigningBytes() ([]byte, error) {
+	unsigned := *d
+	unsigned.Signature = ""
+
+	return unsigned.Encode()
+}
+
+// Sign sets the signer of the document and signs it with the given function.
+// The document is normalized through an encoding roundtrip first, so that the
+// signature can be verified on the parsed document.
+func (d *PolicyDocument) Sign(signer address.Address, sign func([]byte) (*crypto.Signature, error)) error {
+	d.Signer = signer.String()
+	d.Signature = ""
+
+	b, err := d.Encode()
+	if err != nil {
+		return err
+	}
+	normalized, err := decodePolicyDocument(b)
+	if err != nil {
+		return err
+	}
+	*d = *normalized
+
+	sb, err := d.SigningBytes()
+	if err != nil {
+		return err
+	}
+
+	sig, err := sign(sb)
+	if err != nil {
+		return err
+	}
+
+	sigb, err := sig.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	d.Signature = hex.EncodeToString(sigb)
+	return nil
+}
+
+// SignedBy returns the signer and signature of a signed document
+func (d *PolicyDocument) SignedBy() (address.Address, *crypto.Signature, error) {
+	signer, err := address.NewFromString(d.Signer)
+	if err != nil {
+		return address.Undef, nil, xerrors.Errorf("parsing signer address: %w", err)
+	}
+
+	sigb, err := hex.DecodeString(d.Signature)
+	if err != nil {
+		return address.Undef, nil, xerrors.Errorf("decoding signature: %w", err)
+	}
+
+	var sig crypto.Signature
+	if err := sig.UnmarshalBinary(sigb); err != nil {
+		return address.Undef, nil, xerrors.Errorf("decoding signature: %w", err)
+	}
+
+	return signer, &sig, nil
+}
+
+// PolicyChange is a single difference between two policies
+type PolicyChange = ConfigChange
+
+// DiffPolicy returns the settings which differ between two policies, sorted by key
+func DiffPolicy(from, to *MinerPolicy) ([]PolicyChange, error) {
+	return DiffConfig(from, to)
+}
@@ -0,0 +1,98 @@
+//stm: #unit
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/crypto"
+)
+
+func TestPolicyRoundtrip(t *testing.T) {
+	cfg := DefaultStorageMiner()
+	cfg.Sealing.BatchPreCommits = false
+	cfg.Dealmaking.Filter = "/usr/bin/deal-filter"
+	cfg.Addresses.PreCommitControl = []string{"f3abc"}
+	cfg.Storage.AllowAddPiece = false
+
+	doc := NewPolicyDocument(cfg, 3)
+	b, err := doc.Encode()
+	require.NoError(t, err)
+
+	parsed, err := ParsePolicyDocument(b)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), parsed.Revision)
+
+	target := DefaultStorageMiner()
+	parsed.Policy.ApplyTo(target)
+	require.Equal(t, cfg.Sealing, target.Sealing)
+	require.Equal(t, cfg.Dealmaking, target.Dealmaking)
+	require.Equal(t, cfg.Addresses, target.Addresses)
+	require.Equal(t, cfg.Fees.MaxPreCommitGasFee.String(), target.Fees.MaxPreCommitGasFee.String())
+
+	// sections outside of the policy aren't touched
+	require.Equal(t, DefaultStorageMiner().Storage, target.Storage)
+}
+
+func TestPolicySigning(t *testing.T) {
+	signer, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+
+	var signed []byte
+	doc := NewPolicyDocument(DefaultStorageMiner(), 1)
+	require.NoError(t, doc.Sign(signer, func(b []byte) (*crypto.Signature, error) {
+		signed = b
+		return &crypto.Signature{Type: crypto.SigTypeSecp256k1, Data: []byte("sig")}, nil
+	}))
+
+	b, err := doc.Encode()
+	require.NoError(t, err)
+
+	parsed, err := ParsePolicyDocument(b)
+	require.NoError(t, err)
+
+	// the signed bytes can be reproduced from the parsed document
+	sb, err := parsed.SigningBytes()
+	require.NoError(t, err)
+	require.Equal(t, signed, sb)
+
+	addr, sig, err := parsed.SignedBy()
+	require.NoError(t, err)
+	require.Equal(t, signer, addr)
+	require.Equal(t, []byte("sig"), sig.Data)
+}
+
+func TestPolicyDiff(t *testing.T) {
+	from := PolicyFromConfig(DefaultStorageMiner())
+
+	changes, err := DiffPolicy(&from, &from)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+
+	cfg := DefaultStorageMiner()
+	cfg.Sealing.BatchPreCommits = false
+	cfg.Dealmaking.Filter = "/usr/bin/deal-filter"
+	to := PolicyFromConfig(cfg)
+
+	changes, err = DiffPolicy(&from, &to)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	require.Equal(t, "Dealmaking.Filter", changes[0].Key)
+	require.Equal(t, `"/usr/bin/deal-filter"`, changes[0].New)
+	require.Equal(t, "Sealing.BatchPreCommits", changes[1].Key)
+	require.Equal(t, "true", changes[1].Old)
+	require.Equal(t, "false", changes[1].New)
+}
+
+func TestPolicyVersion(t *testing.T) {
+	doc := NewPolicyDocument(DefaultStorageMiner(), 0)
+	doc.Version = PolicyDocumentVersion + 1
+
+	b, err := doc.Encode()
+	require.NoError(t, err)
+
+	_, err = ParsePolicyDocument(b)
+	require.Error(t, err)
+}
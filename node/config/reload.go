@@ -0,0 +1,258 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"go.uber.org/multierr"
+	"golang.org/x/xerrors"
+)
+
+// ConfigChange is a single difference between two configs
+type ConfigChange struct {
+	// Key is the dotted config path of the changed setting, eg. Sealing.BatchPreCommits
+	Key string
+	Old string
+	New string
+}
+
+func (c ConfigChange) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Key, c.Old, c.New)
+}
+
+// DiffConfig returns the settings which differ between two configs of the
+// same type, sorted by key
+func DiffConfig(from, to interface{}) ([]ConfigChange, error) {
+	fromVals, err := flattenConfig(from)
+	if err != nil {
+		return nil, err
+	}
+	toVals, err := flattenConfig(to)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]struct{}{}
+	for k := range fromVals {
+		keys[k] = struct{}{}
+	}
+	for k := range toVals {
+		keys[k] = struct{}{}
+	}
+
+	var changes []ConfigChange
+	for k := range keys {
+		if fromVals[k] != toVals[k] {
+			changes = append(changes, ConfigChange{Key: k, Old: fromVals[k], New: toVals[k]})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+
+	return changes, nil
+}
+
+// flattenConfig maps the dotted path of each config setting to its value
+func flattenConfig(cfg interface{}) (map[string]string, error) {
+	buf := new(bytes.Buffer)
+	if err := toml.NewEncoder(buf).Encode(cfg); err != nil {
+		return nil, xerrors.Errorf("encoding config: %w", err)
+	}
+
+	var tree map[string]interface{}
+	if _, err := toml.Decode(buf.String(), &tree); err != nil {
+		return nil, xerrors.Errorf("decoding config: %w", err)
+	}
+
+	out := map[string]string{}
+	var walk func(prefix string, v interface{})
+	walk = func(prefix string, v interface{}) {
+		if m, ok := v.(map[string]interface{}); ok {
+			for k, sub := range m {
+				walk(prefix+"."+k, sub)
+			}
+			return
+		}
+
+		if str, ok := v.(string); ok {
+			out[prefix] = fmt.Sprintf("%q", str)
+			return
+		}
+		out[prefix] = fmt.Sprintf("%v", v)
+	}
+
+	for k, v := range tree {
+		walk(k, v)
+	}
+
+	return out, nil
+}
+
+// GetMinerFeeConfigFunc returns the current fee config of the miner
+type GetMinerFeeConfigFunc func() MinerFeeConfig
+
+// ReloadSection is a config section which can be changed without restarting
+// the node
+type ReloadSection struct {
+	// Key is the dotted config path of the section, eg. Logging or
+	// Dealmaking.Filter
+	Key string
+
+	// Validate checks the reloaded config before any section is applied, optional
+	Validate func(cfg interface{}) error
+	// Apply applies the reloaded config to the running node. It is nil for the
+	// sections which are read from the config when they are used.
+	Apply func(cfg interface{}) error
+}
+
+func (s *ReloadSection) covers(key string) bool {
+	return key == s.Key || strings.HasPrefix(key, s.Key+".")
+}
+
+// ReloadResult reports the changes found by a reload
+type ReloadResult struct {
+	// Applied is set when the changes were applied, which only happens when
+	// none of them requires a restart
+	Applied bool
+	// Changes are the changes of the reloadable sections
+	Changes []ConfigChange
+	// Rejected are the changes which require a restart of the node
+	Rejected []ConfigChange
+}
+
+// Reloader reloads the reloadable sections of the config from the repo, and
+// keeps the config of the running node.
+type Reloader struct {
+	load     func() (interface{}, error)
+	sections []ReloadSection
+
+	lk  sync.RWMutex
+	cur interface{}
+}
+
+func NewReloader(load func() (interface{}, error), sections ...ReloadSection) (*Reloader, error) {
+	cur, err := load()
+	if err != nil {
+		return nil, xerrors.Errorf("loading config: %w", err)
+	}
+
+	return &Reloader{
+		load:     load,
+		sections: sections,
+		cur:      cur,
+	}, nil
+}
+
+// Config returns the config of the running node, as of the last reload
+func (r *Reloader) Config() interface{} {
+	r.lk.RLock()
+	defer r.lk.RUnlock()
+
+	return r.cur
+}
+
+// SetConfig persists a change made through the API with persist, and makes
+// the same change to the config of the running node
+func (r *Reloader) SetConfig(persist func(func(interface{})) error, mutate func(interface{})) error {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	next, err := copyConfig(r.cur)
+	if err != nil {
+		return err
+	}
+
+	if err := persist(mutate); err != nil {
+		return err
+	}
+
+	mutate(next)
+	r.cur = next
+	return nil
+}
+
+// copyConfig deep copies a config by round-tripping it through TOML
+func copyConfig(cfg interface{}) (interface{}, error) {
+	buf := new(bytes.Buffer)
+	if err := toml.NewEncoder(buf).Encode(cfg); err != nil {
+		return nil, xerrors.Errorf("encoding config: %w", err)
+	}
+
+	out := reflect.New(reflect.TypeOf(cfg).Elem()).Interface()
+	if _, err := toml.Decode(buf.String(), out); err != nil {
+		return nil, xerrors.Errorf("decoding config: %w", err)
+	}
+
+	return out, nil
+}
+
+// Reload loads the config and applies the changed sections. Nothing is applied
+// when a setting which requires a restart changed, or when the config of a
+// changed section is invalid.
+func (r *Reloader) Reload() (*ReloadResult, error) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	next, err := r.load()
+	if err != nil {
+		return nil, xerrors.Errorf("loading config: %w", err)
+	}
+
+	changes, err := DiffConfig(r.cur, next)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ReloadResult{}
+	changed := make([]bool, len(r.sections))
+	for _, c := range changes {
+		i := r.section(c.Key)
+		if i < 0 {
+			res.Rejected = append(res.Rejected, c)
+			continue
+		}
+		res.Changes = append(res.Changes, c)
+		changed[i] = true
+	}
+	if len(res.Rejected) > 0 {
+		return res, nil
+	}
+
+	for i, s := range r.sections {
+		if !changed[i] || s.Validate == nil {
+			continue
+		}
+		if err := s.Validate(next); err != nil {
+			return res, xerrors.Errorf("invalid %s config: %w", s.Key, err)
+		}
+	}
+
+	for i, s := range r.sections {
+		if !changed[i] || s.Apply == nil {
+			continue
+		}
+		if aerr := s.Apply(next); aerr != nil {
+			err = multierr.Append(err, xerrors.Errorf("applying %s config: %w", s.Key, aerr))
+		}
+	}
+
+	r.cur = next
+	res.Applied = true
+	return res, err
+}
+
+func (r *Reloader) section(key string) int {
+	for i := range r.sections {
+		if r.sections[i].covers(key) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,121 @@
+//stm: #unit
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestReloader(t *testing.T) {
+	next := DefaultStorageMiner()
+	load := func() (interface{}, error) {
+		cp := *next
+		return &cp, nil
+	}
+
+	var applied []string
+	r, err := NewReloader(load,
+		ReloadSection{
+			Key: "Dealmaking.Filter",
+			Validate: func(raw interface{}) error {
+				if raw.(*StorageMiner).Dealmaking.Filter == "invalid" {
+					return xerrors.New("invalid filter")
+				}
+				return nil
+			},
+			Apply: func(raw interface{}) error {
+				applied = append(applied, raw.(*StorageMiner).Dealmaking.Filter)
+				return nil
+			},
+		},
+		ReloadSection{Key: "Fees.MaxWindowPoStGasFee"},
+	)
+	require.NoError(t, err)
+
+	// nothing changed
+	res, err := r.Reload()
+	require.NoError(t, err)
+	require.True(t, res.Applied)
+	require.Empty(t, res.Changes)
+	require.Empty(t, applied)
+
+	// reloadable changes
+	next.Dealmaking.Filter = "/usr/bin/deal-filter"
+	next.Fees.MaxWindowPoStGasFee = types.MustParseFIL("10")
+	res, err = r.Reload()
+	require.NoError(t, err)
+	require.True(t, res.Applied)
+	require.Len(t, res.Changes, 2)
+	require.Equal(t, "Dealmaking.Filter", res.Changes[0].Key)
+	require.Equal(t, `"/usr/bin/deal-filter"`, res.Changes[0].New)
+	require.Equal(t, []string{"/usr/bin/deal-filter"}, applied)
+	require.Equal(t, types.MustParseFIL("10"), r.Config().(*StorageMiner).Fees.MaxWindowPoStGasFee)
+
+	// a change requiring a restart rejects the reload
+	next.Dealmaking.Filter = "/usr/bin/other-filter"
+	next.Fees.MaxPublishDealsFee = types.MustParseFIL("1")
+	res, err = r.Reload()
+	require.NoError(t, err)
+	require.False(t, res.Applied)
+	require.Len(t, res.Rejected, 1)
+	require.Equal(t, "Fees.MaxPublishDealsFee", res.Rejected[0].Key)
+	require.Len(t, applied, 1)
+	require.Equal(t, "/usr/bin/deal-filter", r.Config().(*StorageMiner).Dealmaking.Filter)
+
+	// an invalid section rejects the reload
+	next.Fees.MaxPublishDealsFee = DefaultStorageMiner().Fees.MaxPublishDealsFee
+	next.Dealmaking.Filter = "invalid"
+	_, err = r.Reload()
+	require.Error(t, err)
+	require.Len(t, applied, 1)
+
+	next.Dealmaking.Filter = ""
+	res, err = r.Reload()
+	require.NoError(t, err)
+	require.True(t, res.Applied)
+	require.Equal(t, []string{"/usr/bin/deal-filter", ""}, applied)
+}
+
+func TestReloaderSetConfig(t *testing.T) {
+	onDisk := DefaultStorageMiner()
+	load := func() (interface{}, error) {
+		cp := *onDisk
+		return &cp, nil
+	}
+	persist := func(mutate func(interface{})) error {
+		mutate(onDisk)
+		return nil
+	}
+
+	r, err := NewReloader(load, ReloadSection{Key: "Dealmaking.ConsiderOnlineStorageDeals"})
+	require.NoError(t, err)
+
+	prev := r.Config().(*StorageMiner)
+	err = r.SetConfig(persist, func(raw interface{}) {
+		raw.(*StorageMiner).Dealmaking.ConsiderOnlineStorageDeals = false
+	})
+	require.NoError(t, err)
+	require.False(t, onDisk.Dealmaking.ConsiderOnlineStorageDeals)
+	require.False(t, r.Config().(*StorageMiner).Dealmaking.ConsiderOnlineStorageDeals)
+	// the config handed out before isn't changed
+	require.True(t, prev.Dealmaking.ConsiderOnlineStorageDeals)
+
+	// the change isn't reported by the next reload
+	res, err := r.Reload()
+	require.NoError(t, err)
+	require.True(t, res.Applied)
+	require.Empty(t, res.Changes)
+
+	// nothing changes when persisting fails
+	err = r.SetConfig(func(func(interface{})) error {
+		return xerrors.New("read-only repo")
+	}, func(raw interface{}) {
+		raw.(*StorageMiner).Dealmaking.ConsiderOnlineStorageDeals = true
+	})
+	require.Error(t, err)
+	require.False(t, r.Config().(*StorageMiner).Dealmaking.ConsiderOnlineStorageDeals)
+}
@@ -5,11 +5,18 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"time"
 
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-state-types/abi"
+
 	"github.com/filecoin-project/lotus/storage/paths"
 	"github.com/filecoin-project/lotus/storage/sealer"
+	"github.com/filecoin-project/lotus/storage/sealer/remoteprover"
+	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+	"github.com/filecoin-project/lotus/storage/sealer/workerboot"
 )
 
 func StorageFromFile(path string, def *paths.StorageConfig) (*paths.StorageConfig, error) {
@@ -52,6 +59,16 @@ func WriteStorageFile(path string, config paths.StorageConfig) error {
 }
 
 func (c *StorageMiner) StorageManager() sealer.Config {
+	var provers []remoteprover.Config
+	for _, p := range c.Storage.RemoteProvers {
+		provers = append(provers, p.ProverConfig())
+	}
+
+	var affinityRules []storiface.AffinityRule
+	for _, r := range c.Storage.AffinityRules {
+		affinityRules = append(affinityRules, r.AffinityRule())
+	}
+
 	return sealer.Config{
 		ParallelFetchLimit:       c.Storage.ParallelFetchLimit,
 		AllowAddPiece:            c.Storage.AllowAddPiece,
@@ -67,8 +84,75 @@ func (c *StorageMiner) StorageManager() sealer.Config {
 
 		Assigner: c.Storage.Assigner,
 
+		Priorities: storiface.SchedPriorities{
+			SnapDeals: c.Storage.Priorities.SnapDeals,
+			Deals:     c.Storage.Priorities.Deals,
+			CC:        c.Storage.Priorities.CC,
+			Preempt:   c.Storage.Priorities.Preempt,
+		},
+
+		WorkerTags:    c.Storage.WorkerTags,
+		AffinityRules: affinityRules,
+
+		RemoteProvers: provers,
+
+		MaxUnsealsPerPath:     c.Storage.MaxUnsealsPerPath,
+		UnsealedCacheMaxBytes: c.Storage.UnsealedCacheMaxBytes,
+
+		RebalanceInterval:     time.Duration(c.Storage.RebalanceInterval),
+		RebalanceMaxBandwidth: c.Storage.RebalanceMaxBandwidth,
+		RebalanceMaxMoves:     c.Storage.RebalanceMaxMoves,
+
+		AllocationPolicy: paths.AllocationPolicy(c.Storage.AllocationPolicy),
+
 		ParallelCheckLimit:        c.Proving.ParallelCheckLimit,
 		DisableBuiltinWindowPoSt:  c.Proving.DisableBuiltinWindowPoSt,
 		DisableBuiltinWinningPoSt: c.Proving.DisableBuiltinWinningPoSt,
 	}
 }
+
+// ProverConfig returns the config of the remote prover
+func (r AffinityRule) AffinityRule() storiface.AffinityRule {
+	out := storiface.AffinityRule{
+		MinSector: abi.SectorNumber(r.MinSector),
+		MaxSector: abi.SectorNumber(r.MaxSector),
+		Tag:       r.Tag,
+	}
+	for _, name := range r.Tasks {
+		tt, err := sealtasks.ParseShort(name)
+		if err != nil {
+			// full task names, which are checked by the sealer
+			tt = sealtasks.TaskType(name)
+		}
+		out.Tasks = append(out.Tasks, tt)
+	}
+	return out
+}
+
+func (t WorkerTemplate) Template() workerboot.Template {
+	return workerboot.Template{
+		Name:               t.Name,
+		MinCPUs:            t.MinCPUs,
+		MinMemory:          t.MinMemory,
+		MinGPUs:            t.MinGPUs,
+		MinStorage:         t.MinStorage,
+		Tasks:              t.Tasks,
+		Tags:               t.Tags,
+		Env:                t.Env,
+		NoSwap:             t.NoSwap,
+		ParallelFetchLimit: t.ParallelFetchLimit,
+		AllocationPolicy:   t.AllocationPolicy,
+	}
+}
+
+func (p RemoteProver) ProverConfig() remoteprover.Config {
+	return remoteprover.Config{
+		URL:            p.URL,
+		Token:          p.Token,
+		Tasks:          p.Tasks,
+		AttestationKey: p.AttestationKey,
+		Fallback:       p.Fallback,
+		PollInterval:   time.Duration(p.PollInterval),
+		Timeout:        time.Duration(p.Timeout),
+	}
+}
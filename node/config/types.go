@@ -16,6 +16,7 @@ type Common struct {
 	API     API
 	Backup  Backup
 	Logging Logging
+	Tracing Tracing
 	Libp2p  Libp2p
 	Pubsub  Pubsub
 }
@@ -27,6 +28,13 @@ type FullNode struct {
 	Wallet     Wallet
 	Fees       FeeConfig
 	Chainstore Chainstore
+	Sync       Sync
+	Beacon     Beacon
+
+	FaultReporter FaultReporter
+
+	MessageWait MessageWait
+	ChainStream ChainStream
 }
 
 // // Common
@@ -44,6 +52,22 @@ type Backup struct {
 type Logging struct {
 	// SubsystemLevels specify per-subsystem log levels
 	SubsystemLevels map[string]string
+
+	// Format of the log output: "color", "nocolor" or "json" for structured
+	// logs with one JSON object per line. The GOLOG_LOG_FMT environment
+	// variable takes precedence. Defaults to "color".
+	Format string
+}
+
+// Tracing is the config of the traces of API calls, exported when a trace
+// exporter is set up with the LOTUS_JAEGER_* environment variables
+type Tracing struct {
+	// DefaultSampleRate is the fraction of the API calls traced, for the
+	// methods without a rate in MethodSampleRates
+	DefaultSampleRate float64
+	// MethodSampleRates sets the fraction of the calls traced for specific API
+	// methods, by method name, e.g. "StateMinerPower" = 0.1
+	MethodSampleRates map[string]float64
 }
 
 // StorageMiner is a miner config
@@ -59,6 +83,10 @@ type StorageMiner struct {
 	Fees          MinerFeeConfig
 	Addresses     MinerAddressConfig
 	DAGStore      DAGStoreConfig
+	HTTPRetrieval HTTPRetrievalConfig
+
+	TransferLimits TransferLimitsConfig
+	Notifications  MinerNotificationsConfig
 }
 
 type DAGStoreConfig struct {
@@ -105,9 +133,16 @@ type MinerSubsystemConfig struct {
 	EnableSealing       bool
 	EnableSectorStorage bool
 	EnableMarkets       bool
+	// EnableProving runs the WindowPoSt scheduler on a node with mining
+	// disabled, making it the proving service of the miner
+	EnableProving bool
 
 	SealerApiInfo      string // if EnableSealing == false
 	SectorIndexApiInfo string // if EnableSectorStorage == false
+	// ProvingApiInfo is the API of the proving service of a mining node. When
+	// set, the mining node leaves WindowPoSt to the proving service, and
+	// records the events of the service in its journal.
+	ProvingApiInfo string
 }
 
 type DealmakingConfig struct {
@@ -137,6 +172,10 @@ type DealmakingConfig struct {
 	// The maximum number of deals to include in a single PublishStorageDeals
 	// message
 	MaxDealsPerPublishMsg uint64
+	// The amount of time after which a PublishStorageDeals message which hasn't
+	// landed on chain is replaced with a message paying a higher gas premium,
+	// within MaxPublishDealsFee. 0 disables the replacement.
+	PublishMsgStuckTimeout Duration
 	// The maximum collateral that the provider will put up against a deal,
 	// as a multiplier of the minimum collateral bound
 	MaxProviderCollateralMultiplier uint64
@@ -163,10 +202,111 @@ type DealmakingConfig struct {
 	// A command used for fine-grained evaluation of retrieval deals
 	// see https://docs.filecoin.io/mine/lotus/miner-configuration/#using-filters-for-fine-grained-storage-and-retrieval-deal-acceptance for more details
 	RetrievalFilter string
+	// A URL of a webhook used for fine-grained evaluation of storage deals, as an
+	// alternative to Filter. Deals are POSTed to it as JSON, and it responds with a
+	// JSON object with a Decision of "accept", "reject" or "queue" and an optional
+	// Reason. Queued deals wait for a decision made with
+	// 'lotus-miner storage-deals pending-filter'.
+	FilterWebhook string
+	// The maximum time to wait for a response of the deal filter webhook
+	FilterWebhookTimeout Duration
+	// The maximum time a deal queued by the deal filter webhook waits for a
+	// decision, after which it is rejected
+	FilterQueueTimeout Duration
 
 	RetrievalPricing *RetrievalPricing
 }
 
+type HTTPRetrievalConfig struct {
+	// Enable serving unsealed pieces at /piece/<piece cid> and payload CAR files
+	// at /ipfs/<root cid> on the markets API endpoint, so that clients can
+	// retrieve data over plain HTTP. Piece requests support byte ranges.
+	// Only pieces which already have an unsealed copy are served, HTTP
+	// retrievals never unseal sectors.
+	Enable bool
+
+	// AccessTokens which clients must send in a bearer Authorization header or
+	// in the token query parameter. When empty, anyone who can reach the
+	// endpoint can retrieve data.
+	AccessTokens []string
+
+	// MaxBandwidth is the maximum number of bytes per second sent to all HTTP
+	// retrieval clients combined. 0 means unlimited.
+	MaxBandwidth int64
+}
+
+type TransferLimitsConfig struct {
+	// MaxBandwidth is the maximum number of bytes per second sent by graphsync
+	// to all peers combined, e.g. to serve retrievals. 0 means unlimited.
+	MaxBandwidth int64
+
+	// MaxBandwidthPerPeer is the maximum number of bytes per second sent by
+	// graphsync to a single peer. 0 means unlimited.
+	MaxBandwidthPerPeer int64
+
+	// MaxConcurrentTransfers is the maximum number of graphsync responses
+	// sending data at the same time, further responses wait for a free slot.
+	// 0 means unlimited.
+	MaxConcurrentTransfers int
+
+	// MaxConcurrentTransfersPerPeer is the maximum number of graphsync
+	// responses sending data to a single peer at the same time. 0 means
+	// unlimited.
+	MaxConcurrentTransfersPerPeer int
+
+	// Schedule replaces the bandwidth caps during times of the day, e.g. to
+	// leave more room for proving reads during business hours. The first
+	// window covering the current time applies.
+	Schedule []BandwidthWindow
+}
+
+type BandwidthWindow struct {
+	// Start and End of the window, in local time formatted as 15:04. A window
+	// ending before its start spans midnight.
+	Start string
+	End   string
+
+	// MaxBandwidth in bytes per second during the window, 0 means unlimited.
+	MaxBandwidth int64
+
+	// MaxBandwidthPerPeer in bytes per second during the window, 0 means
+	// unlimited.
+	MaxBandwidthPerPeer int64
+}
+
+type MinerNotificationsConfig struct {
+	// Webhooks notified of the miner events. No checks run when empty.
+	Webhooks []NotificationWebhook
+
+	// PoStDeadlineWarning is the time left in the open proving deadline, with
+	// partitions still unproven, at which the post-deadline event fires.
+	PoStDeadlineWarning Duration
+
+	// ControlBalanceThreshold is the balance of the worker and control
+	// addresses below which the control-balance event fires. 0 disables the
+	// event.
+	ControlBalanceThreshold types.FIL
+
+	// WebhookTimeout is the maximum time to wait for a webhook response.
+	WebhookTimeout Duration
+}
+
+type NotificationWebhook struct {
+	// URL the events are POSTed to.
+	URL string
+
+	// Format of the JSON body: "generic" for the event itself, "slack" for a
+	// Slack incoming webhook, or "pagerduty" for the PagerDuty events API v2.
+	Format string
+
+	// RoutingKey is the integration key of a pagerduty webhook.
+	RoutingKey string
+
+	// Events sent to the webhook, out of post-deadline, sectors-faulty,
+	// worker-disconnected, control-balance and test. All events when empty.
+	Events []string
+}
+
 type IndexProviderConfig struct {
 
 	// Enable set whether to enable indexing announcement to the network and expose endpoints that
@@ -199,10 +339,11 @@ type IndexProviderConfig struct {
 }
 
 type RetrievalPricing struct {
-	Strategy string // possible values: "default", "external"
+	Strategy string // possible values: "default", "external", "policy"
 
 	Default  *RetrievalPricingDefault
 	External *RetrievalPricingExternal
+	Policy   *RetrievalPricingPolicy
 }
 
 type RetrievalPricingExternal struct {
@@ -211,6 +352,31 @@ type RetrievalPricingExternal struct {
 	Path string
 }
 
+type RetrievalPricingPolicy struct {
+	// Peer IDs of the clients which retrieve for free.
+	// This parameter is ONLY applicable if the retrieval pricing policy strategy has been configured to "policy".
+	FreeClients []string
+	// VerifiedDealsFreeTransfer configures zero fees for data transfer for a retrieval deal
+	// of a payloadCid that belongs to a verified storage deal.
+	// This parameter is ONLY applicable if the retrieval pricing policy strategy has been configured to "policy".
+	VerifiedDealsFreeTransfer bool
+	// Prices overriding the ask for retrievals from specific pieces.
+	// This parameter is ONLY applicable if the retrieval pricing policy strategy has been configured to "policy".
+	PiecePrices []RetrievalPiecePrice
+	// Price per byte of retrievals which require unsealing a sector, in percent of the price per byte of the
+	// ask (0 = 100). Unsealing is free when an unsealed copy of the piece exists.
+	// This parameter is ONLY applicable if the retrieval pricing policy strategy has been configured to "policy".
+	SealedPricePercent uint64
+}
+
+type RetrievalPiecePrice struct {
+	PieceCID string
+	// Price per byte of retrievals from the piece; the price of the ask applies when not set
+	PricePerByte *types.FIL
+	// Price to unseal the piece; the unseal price of the ask applies when not set
+	UnsealPrice *types.FIL
+}
+
 type RetrievalPricingDefault struct {
 	// VerifiedDealsFreeTransfer configures zero fees for data transfer for a retrieval deal
 	// of a payloadCid that belongs to a verified storage deal.
@@ -294,6 +460,66 @@ type ProvingConfig struct {
 	// Note that setting this value lower may result in less efficient gas use - more messages will be sent than needed,
 	// resulting in more total gas use (but each message will have lower gas limit)
 	MaxPartitionsPerRecoveryMessage int
+
+	// Sending of the SubmitWindowedPoSt messages
+	Sender WdPoStSenderConfig
+
+	// Checking of the WindowPoSts of other miners, which can be disputed for
+	// some time after their deadline closed
+	Disputer WdPoStDisputerConfig
+}
+
+type WdPoStSenderConfig struct {
+	// Maximum fees of the WindowPoSt messages of some deadlines, overriding
+	// Fees.MaxWindowPoStGasFee for those deadlines
+	DeadlineMaxFees []DeadlineFeeConfig
+
+	// Number of epochs a WindowPoSt message can wait in the mpool before its
+	// gas fee cap and premium are bumped. The message is bumped again after the
+	// same number of epochs until it lands, its deadline closes, or its fees
+	// reach the maximum fee of its deadline. 0 disables fee bumping.
+	BumpAfterEpochs int
+	// Ratio the gas fee cap and premium of a message are multiplied by when it
+	// is bumped; the mpool requires at least 1.25 to replace a message
+	BumpRatio float64
+
+	// API infos of additional full nodes the WindowPoSt messages are pushed to,
+	// eg. "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...:/ip4/10.0.0.2/tcp/1234/http",
+	// so that the messages propagate when the main full node is unhealthy. The
+	// tokens need the write permission.
+	FullNodes []string
+
+	// Control address the WindowPoSt messages are sent from when they can't be
+	// pushed from the address selected for them, eg. because it lacks funds, or
+	// when they can't be bumped anymore
+	FallbackAddress string
+}
+
+type WdPoStDisputerConfig struct {
+	// Check the WindowPoSts of Miners, and of the deadlines holding the
+	// sectors given as faulty sector evidence with
+	// 'lotus-miner proving dispute evidence', once their deadline closed
+	Enable bool
+
+	// Miners whose WindowPoSts are checked at every deadline, eg. the miner
+	// itself after an upgrade of its proving setup
+	Miners []string
+
+	// Send DisputeWindowedPoSt messages for the invalid WindowPoSts found; they
+	// are only reported otherwise
+	AutoDispute bool
+
+	// Address the DisputeWindowedPoSt messages are sent from; the worker
+	// address when empty
+	From string
+
+	// Maximum fee of a DisputeWindowedPoSt message
+	MaxFee types.FIL
+}
+
+type DeadlineFeeConfig struct {
+	Deadlines []uint64
+	MaxFee    types.FIL
 }
 
 type SealingConfig struct {
@@ -328,6 +554,17 @@ type SealingConfig struct {
 	// Sectors which are fully filled will start sealing immediately
 	WaitDealsDelay Duration
 
+	// Upper bound on the number of deals packed into a single sector, below the protocol limit (0 = protocol limit)
+	MaxDealsPerSector uint64
+
+	// Percentage of a sector which, once filled with deals, makes the sector start sealing without waiting for
+	// WaitDealsDelay (0 = only fully filled sectors start sealing early)
+	TargetSectorFillPercent uint64
+
+	// Upper bound on how many deals of a single client can be added to sectors at the same time; further deals of the
+	// client wait for a slot before being assigned to a sector (0 = unlimited)
+	MaxConcurrentDealsPerClient uint64
+
 	// Whether to keep unsealed copies of deal data regardless of whether the client requested that. This lets the miner
 	// avoid the relatively high cost of unsealing the data later, at the cost of more storage space
 	AlwaysKeepUnsealedCopy bool
@@ -343,6 +580,17 @@ type SealingConfig struct {
 	// After sealing CC sectors, make them available for upgrading with deals
 	MakeCCSectorsAvailable bool
 
+	// Run the SnapDeals matcher, which marks proving CC sectors Available for upgrading with deals when there are
+	// pending deals which fit them. The matcher picks the sector expiring the earliest among the sectors outliving
+	// enough of the pending deals
+	SnapMatcherEnable bool
+	// How often the matcher checks the pending deals
+	SnapMatcherInterval Duration
+	// Upper bound on how many sectors can be Available for upgrading when the matcher marks more (0 = unlimited)
+	SnapMatcherMaxAvailable uint64
+	// Percentage of a sector the pending deals fitting in its lifetime must fill for the matcher to mark the sector
+	SnapMatcherMinFillPercent uint64
+
 	// Whether to use available miner balance for sector collateral instead of sending it with each message
 	CollateralFromMinerBalance bool
 	// Minimum available balance to keep in the miner actor before sending it with messages
@@ -406,6 +654,17 @@ type SealerConfig struct {
 	// "spread" - assign tasks to as many distinct workers as possible.
 	Assigner string
 
+	// Priorities of the sealing tasks by class of sector; they can be changed
+	// at runtime with 'lotus-miner sealing priorities'
+	Priorities SealingPriorities
+
+	// WorkerTags are the tags of the workers, by hostname; they can be changed
+	// at runtime with 'lotus-miner sealing workers tag/untag'
+	WorkerTags map[string][]string
+	// AffinityRules restrict the sealing tasks they match to the workers with
+	// their tag. Tasks matching several rules need all their tags.
+	AffinityRules []AffinityRule
+
 	// DisallowRemoteFinalize when set to true will force all Finalize tasks to
 	// run on workers with local access to both long-term storage and the sealing
 	// path containing the sector.
@@ -423,6 +682,122 @@ type SealerConfig struct {
 	// to use when evaluating tasks against this worker. An empty value defaults
 	// to "hardware".
 	ResourceFiltering sealer.ResourceFilteringStrategy
+
+	// RemoteProvers dispatch the PC2 and C2 tasks of the local worker to
+	// external compute services, such as GPU farms; the tasks must be allowed
+	// on the local worker (AllowPreCommit2, AllowCommit). The protocol is
+	// described in storage/sealer/remoteprover.
+	RemoteProvers []RemoteProver
+
+	// MaxUnsealsPerPath limits the number of sectors unsealed at the same time
+	// from each storage path holding sealed sectors; unseals serving retrievals
+	// are started before those serving deal transfers. 0 means no limit.
+	MaxUnsealsPerPath int
+	// UnsealedCacheMaxBytes is the total size of the unsealed copies created by
+	// unseals above which the least recently read ones are removed. 0 keeps
+	// all the unsealed copies.
+	UnsealedCacheMaxBytes int64
+
+	// RebalanceInterval at which sectors are moved between the local long-term
+	// storage paths, so that the use of each path follows its weight, and out
+	// of the paths being drained. 0 only rebalances on demand, with
+	// 'lotus-miner storage rebalance start'.
+	RebalanceInterval Duration
+	// RebalanceMaxBandwidth limits the bytes copied per second when moving
+	// sectors. 0 means no limit.
+	RebalanceMaxBandwidth int64
+	// RebalanceMaxMoves limits the number of sectors moved by a rebalance.
+	// 0 means no limit.
+	RebalanceMaxMoves int
+
+	// AllocationPolicy places the files of a sector in the long-term storage
+	// paths of the miner depending on the groups of the paths, set with
+	// 'lotus-miner storage attach --groups', e.g. one group per chassis or rack.
+	// "" (default) - place each file in the best path.
+	// "same-group" - keep the files of a sector in paths sharing a group, so
+	// that a failing group only affects the sectors stored in it.
+	// "spread-groups" - place the files of a sector in paths sharing no group.
+	// Paths without groups only share a group with themselves.
+	AllocationPolicy string
+
+	// WorkerTemplates configure the workers started with a one-time token from
+	// 'lotus-miner sealing workers bootstrap-token'; workers get the template
+	// of their token, or the first template their host satisfies.
+	WorkerTemplates []WorkerTemplate
+}
+
+type SealingPriorities struct {
+	// Priority of the tasks of snap-deals sectors
+	SnapDeals int
+	// Priority of the tasks of new sectors with deals
+	Deals int
+	// Priority of the tasks of committed capacity sectors
+	CC int
+	// Preempt lets the tasks of a lower priority, which are waiting for
+	// resources once their inputs are fetched, give their worker away to tasks
+	// of a higher priority. Running tasks are never interrupted.
+	Preempt bool
+}
+
+type AffinityRule struct {
+	// Tasks matched by the rule, by short (PC1, PC2, ...) or full name; all
+	// the sealing tasks when empty
+	Tasks []string
+	// First sector number matched by the rule
+	MinSector uint64
+	// Last sector number matched by the rule; no upper bound when 0
+	MaxSector uint64
+	// Tag the workers need to run the matched tasks
+	Tag string
+}
+
+type WorkerTemplate struct {
+	// Name of the template, given to 'lotus-miner sealing workers bootstrap-token'
+	Name string
+
+	// MinCPUs, MinMemory (bytes), MinGPUs and MinStorage (bytes available in
+	// the storage paths) the host of the worker needs; 0 means no requirement
+	MinCPUs    uint64
+	MinMemory  uint64
+	MinGPUs    int
+	MinStorage int64
+
+	// Tasks run by the workers, by short name (AP, PC1, PC2, C2, ...); the
+	// workers keep the tasks of their flags when empty
+	Tasks []string
+	// Tags given to the hosts of the workers, see WorkerTags
+	Tags []string
+	// Env sets environment variables of the workers, such as the resource
+	// overrides (e.g. PC1_MAX_PARALLELISM)
+	Env map[string]string
+
+	// NoSwap, ParallelFetchLimit and AllocationPolicy set the corresponding
+	// flags of 'lotus-worker run', unless they are given on the command line
+	NoSwap             bool
+	ParallelFetchLimit int
+	AllocationPolicy   string
+}
+
+// RemoteProver is an external service running PC2 and C2 tasks
+type RemoteProver struct {
+	// URL of the prover service, eg. https://prover.example.com
+	URL string
+	// Token sent to the prover as bearer token, when set
+	Token string
+	// Tasks dispatched to the prover: PC2 and/or C2. When several provers
+	// run a task, the first one is used.
+	Tasks []string
+	// Hex encoded HMAC-SHA256 key of the attestations of the results, shared
+	// with the prover. When set, the results without a valid attestation are
+	// rejected.
+	AttestationKey string
+	// Fallback runs the task on the local worker when the prover fails
+	Fallback bool
+	// Interval between the polls of the status of a job
+	PollInterval Duration
+	// Timeout of a job, including its time in the queue of the prover and the
+	// transfer of the sector files; 0 means no timeout
+	Timeout Duration
 }
 
 type BatchFeeConfig struct {
@@ -469,6 +844,30 @@ type API struct {
 	ListenAddress       string
 	RemoteListenAddress string
 	Timeout             Duration
+
+	// Maximum number of requests in a JSON-RPC batch request, sent over HTTP.
+	// Batch requests are rejected when set to 0.
+	MaxBatchSize int
+	// Maximum number of requests of a connection processed concurrently,
+	// including the requests of a batch and the calls over a websocket; the
+	// other requests of the connection wait for their turn. 0 means no limit.
+	MaxConnRequests int
+
+	// TLS serves the API over TLS
+	TLS APITLS
+}
+
+// APITLS configures TLS on the API listener
+type APITLS struct {
+	// Path of the PEM certificate chain of the API. TLS is enabled when the
+	// certificate and key files are set. The files are loaded again when they
+	// change, rotating the certificate.
+	CertFile string
+	// Path of the PEM private key of the certificate
+	KeyFile string
+	// Path of a PEM file with the CAs issuing the client certificates. When
+	// set, the clients must present a certificate issued by one of them (mTLS).
+	ClientCAFile string
 }
 
 // Libp2p contains configs for libp2p
@@ -502,6 +901,62 @@ type Libp2p struct {
 	// ConnMgrGrace is a time duration that new connections are immune from being
 	// closed by the connection manager.
 	ConnMgrGrace Duration
+
+	// StaticPeers are peers the node stays connected to, e.g. the other members
+	// of a private network. Connections to static peers are protected from the
+	// connection manager, and are redialed when they drop.
+	// Format: multiaddress with a peer ID, e.g. /ip4/1.2.3.4/tcp/1234/p2p/12D3K...
+	StaticPeers []string
+	// StaticPeersReconnectInterval is how often disconnected static peers are
+	// redialed
+	StaticPeersReconnectInterval Duration
+
+	// DisableDHT disables the Kademlia DHT, which is used to look up the addresses
+	// of peers and to discover new peers. On networks without a DHT, peers are
+	// only found through bootstrap peers, static peers and the peer exchange.
+	DisableDHT bool
+	// DisablePeerDiscovery stops the node from looking for new peers when it is
+	// short of peers, so that it only connects to bootstrap peers, static peers,
+	// peers found through the peer exchange and peers dialing in.
+	DisablePeerDiscovery bool
+
+	PeerExchange PeerExchange
+
+	BandwidthLimits BandwidthLimits
+}
+
+// BandwidthLimits throttle the non-critical protocols while the node is short of
+// bandwidth, to protect block propagation on constrained links. Gossipsub,
+// which carries blocks and messages, is never throttled.
+type BandwidthLimits struct {
+	// TotalCap is the inbound or outbound rate of the node, in bytes per second,
+	// above which the protocol groups with a ceiling are throttled in that
+	// direction; 0 disables throttling
+	TotalCap int64
+	// BitswapCeiling is the rate, in bytes per second, bitswap and chain exchange
+	// are throttled to while the node is above the cap; 0 means no ceiling
+	BitswapCeiling int64
+	// GraphsyncCeiling is the rate, in bytes per second, graphsync is throttled to
+	// while the node is above the cap; 0 means no ceiling
+	GraphsyncCeiling int64
+	// OtherCeiling is the rate, in bytes per second, the protocols other than
+	// gossipsub, bitswap, chain exchange and graphsync are throttled to while the
+	// node is above the cap; 0 means no ceiling
+	OtherCeiling int64
+}
+
+// PeerExchange is a protocol through which the members of a closed network
+// share the signed peer records of the members they are connected to, so that
+// members can find each other without a DHT
+type PeerExchange struct {
+	// EnablePeerExchange enables serving and requesting peer records; records are
+	// only served to, and accepted from, static peers and Members
+	EnablePeerExchange bool
+	// Interval is how often peer records are requested from connected members
+	Interval Duration
+	// Members are the peer IDs of the other members of the network, in addition
+	// to the static peers
+	Members []string
 }
 
 type Pubsub struct {
@@ -518,9 +973,216 @@ type Pubsub struct {
 	RemoteTracer          string
 }
 
+type Sync struct {
+	// ParallelValidation enables the validation pipeline for catch-up sync, which
+	// fetches the messages of upcoming tipsets and verifies their signatures on a pool
+	// of workers while earlier tipsets are being executed.
+	ParallelValidation bool
+	// SignatureWorkers is the number of workers verifying message signatures ahead of
+	// execution; 0 means one per CPU
+	SignatureWorkers int
+	// PipelineDepth is the maximum number of tipsets fetched and verified ahead of
+	// execution; 0 uses the default
+	PipelineDepth int
+
+	CheckpointFeed CheckpointFeed
+}
+
+type Beacon struct {
+	// Endpoints are drand relays used in addition to the built-in servers of the
+	// current drand network. http:// and https:// URLs are HTTP relays, grpc:// and
+	// grpcs:// addresses are gRPC endpoints. Relays are health checked, and
+	// randomness is fetched from the healthy relays with the lowest latency first.
+	Endpoints []string
+	// HealthCheckInterval is the time between health checks of the relays, in
+	// time.Duration string
+	HealthCheckInterval Duration
+	// DisablePersistentCache disables keeping verified beacon entries of the past
+	// week in the metadata datastore. The cache lets the node produce and validate
+	// blocks from rounds it has seen before while the relays are unreachable.
+	DisablePersistentCache bool
+}
+
+type FaultReporter struct {
+	// EnableConsensusFaultReporter enables the service scanning incoming blocks for
+	// double-fork, time-offset and parent-grinding consensus faults. The evidence of
+	// the faults is archived in the metadata datastore, and the faults are queued for
+	// review with 'lotus chain consensus-faults'.
+	EnableConsensusFaultReporter bool
+	// ReporterAddress is the address sending the ReportConsensusFault messages, and
+	// receiving the reward for them; faults can't be submitted when it's empty
+	ReporterAddress string
+	// AutoSubmit reports the faults as soon as they are detected, instead of
+	// queueing them for review
+	AutoSubmit bool
+}
+
+type CheckpointFeed struct {
+	// Authorities are the key addresses of the checkpoint authority set. When set,
+	// signed checkpoints from the authorities are accepted, and the tipsets they
+	// designate are treated as final. Manual checkpoints must then descend from the
+	// latest authority checkpoint.
+	Authorities []string
+	// Threshold is the number of distinct authority signatures a checkpoint needs
+	// to be accepted; 0 requires all authorities to sign
+	Threshold int
+	// URL of the checkpoint feed, which serves the latest signed checkpoint as JSON;
+	// when empty, signed checkpoints can only be submitted with
+	// 'lotus sync checkpoint --signed'
+	URL string
+	// PollInterval is the time between polls of the feed, in time.Duration string
+	PollInterval Duration
+}
+
 type Chainstore struct {
 	EnableSplitstore bool
 	Splitstore       Splitstore
+
+	BlockstoreGC BlockstoreGC
+
+	BlockCache BlockCache
+
+	Scrub BlockstoreScrub
+
+	Index ChainIndex
+
+	Snapshots ChainSnapshots
+}
+
+// MessageWait configures the defaults of the APIs waiting for messages
+type MessageWait struct {
+	// Confidence is the number of epochs StateWaitMsg waits for after a message was
+	// executed, when called with the default confidence (api.ConfidenceDefault)
+	Confidence uint64
+	// Finality is the number of epochs after which tipsets are considered final by
+	// the message waiting APIs: StateWaitMsg and StateSearchMsg search back this far
+	// when called with api.LookbackFinality. Devnets can set it below the mainnet
+	// finality.
+	Finality uint64
+}
+
+// ChainStream configures the gRPC service streaming chain data
+type ChainStream struct {
+	// Binding address of the gRPC service streaming the tipsets, messages,
+	// receipts and actor state changes of the chain to indexers. The service is
+	// disabled when empty. It is served over TLS with the API TLS config, and the
+	// calls are authorized with the API tokens.
+	// Format: multiaddress
+	ListenAddress string
+}
+
+type ChainIndex struct {
+	// EnableIndex maintains an index of the chain in the repo, which maps the heights
+	// of the chain, the messages included in it and the actors sending or receiving
+	// them to tipsets. It allows StateSearchMsg and StateWaitMsg to locate messages
+	// without walking the chain, and enables the ChainIndexActorMessages API.
+	EnableIndex bool
+	// BackfillEpochs is the number of epochs below the head indexed when the node
+	// starts, if not indexed yet
+	BackfillEpochs uint64
+}
+
+type ChainSnapshots struct {
+	// EnableSnapshots enables a background service taking snapshots of the chain on
+	// a schedule. The latest snapshot can be found with the ChainSnapshotStatus API
+	// or 'lotus chain snapshots status'.
+	EnableSnapshots bool
+	// Schedule is a cron expression in local time with the minute, hour, day of
+	// month, month and day of week fields, e.g. "0 */6 * * *" for every six hours;
+	// @hourly, @daily, @weekly and @monthly can also be used
+	Schedule string
+	// Path is the directory the snapshots are written to; relative paths are
+	// resolved from the repo directory
+	Path string
+	// Retain is the number of most recent snapshots kept, older snapshots are
+	// deleted; 0 keeps all snapshots
+	Retain int
+	// LatestState takes snapshots of the latest state without the chain history,
+	// which pruned nodes can be booted from. Otherwise the snapshots include all
+	// the block headers down to genesis.
+	LatestState bool
+	// RecentHeaders is the number of epochs of block headers included in latest
+	// state snapshots
+	RecentHeaders uint64
+	// RecentStateRoots is the number of epochs of state roots and messages included
+	// in the snapshots
+	RecentStateRoots uint64
+	// SkipOldMessages omits the messages older than RecentStateRoots from full
+	// snapshots
+	SkipOldMessages bool
+	// Compress compresses the snapshots with zstd
+	Compress bool
+	// UploadCmd is a shell command run after each snapshot is written, with the path
+	// and file name of the snapshot in the SNAPSHOT and SNAPSHOT_NAME environment
+	// variables, e.g. to upload it to an S3 bucket with
+	// 'aws s3 cp "$SNAPSHOT" s3://bucket/'
+	UploadCmd string
+	// RemoveCmd is a shell command run when an uploaded snapshot is deleted as it
+	// is no longer retained, with the same environment as UploadCmd
+	RemoveCmd string
+	// PublicURL is the location snapshots are uploaded to by UploadCmd; the URL of
+	// a snapshot reported by the API is PublicURL joined with its file name
+	PublicURL string
+}
+
+type BlockstoreScrub struct {
+	// EnableScrub enables periodic passes of the blockstore scrubber, which verifies
+	// that the stored blocks hash to their CIDs. Corrupt blocks are removed from the
+	// blockstore and quarantined in the metadata datastore. Passes can also be started
+	// manually with 'lotus chain scrub run'. With the splitstore enabled, only the
+	// coldstore is scrubbed.
+	EnableScrub bool
+	// Interval is the time between the starts of periodic scrub passes, in time.Duration
+	// string
+	Interval Duration
+	// MaxBlocksPerSecond limits the rate at which blocks are verified, so that scrubbing
+	// doesn't starve the node; 0 means no limit
+	MaxBlocksPerSecond int
+	// Refetch enables fetching corrupt blocks from the network over bitswap
+	Refetch bool
+	// RefetchTimeout limits the time spent fetching a single corrupt block, in
+	// time.Duration string
+	RefetchTimeout Duration
+}
+
+type BlockCache struct {
+	// EnableBlockCache enables an in-memory cache of blocks read from the chain
+	// and state blockstores.
+	EnableBlockCache bool
+	// CacheType is the type of the cache: "lru" caches are bounded by the total size
+	// of the cached blocks (MaxBytes), while "arc" caches are bounded by the number
+	// of cached blocks (MaxEntries).
+	CacheType string
+	// MaxBytes is the memory budget of the lru cache, in bytes. It is not used by
+	// the arc cache, whose memory use depends on the size of the cached blocks.
+	MaxBytes int64
+	// MaxEntries is the maximum number of blocks held by the arc cache; it also
+	// bounds the lru cache when set
+	MaxEntries int
+}
+
+type BlockstoreGC struct {
+	// EnableScheduledGC enables daily online garbage collection of the chain
+	// blockstore within the configured window. GC can also be started manually with
+	// 'lotus chain gc run'. Not supported when the splitstore is enabled, as the
+	// splitstore collects its hotstore on its own.
+	EnableScheduledGC bool
+	// WindowStart is the local time of day, in HH:MM format, at which scheduled GC runs start
+	WindowStart string
+	// WindowEnd is the local time of day, in HH:MM format, at which scheduled GC runs are
+	// stopped. The window may cross midnight.
+	WindowEnd string
+
+	// MaxDuration limits the run time of a single GC run, in time.Duration string; 0 means
+	// no limit besides the end of the window for scheduled runs
+	MaxDuration Duration
+	// MaxProbeLatency stops a GC run when a probe read from the blockstore takes longer
+	// than this, in time.Duration string, so that GC doesn't starve the node; 0 disables
+	// the probe
+	MaxProbeLatency Duration
+	// Threshold is the ratio of discardable data in a value log file above which the file
+	// is rewritten during GC; 0 uses the blockstore default
+	Threshold float64
 }
 
 type Splitstore struct {
@@ -560,6 +1222,27 @@ type Client struct {
 	// without existing payment channels with available funds will fail instead
 	// of automatically performing on-chain operations.
 	OffChainRetrieval bool
+
+	// Monitors the sectors holding the active storage deals of the client, and
+	// proposes replacement deals to backup providers for the failed deals.
+	DealRepair DealRepair
+}
+
+type DealRepair struct {
+	// Enable the deal health monitor
+	Enable bool
+	// How often the health of the active deals is checked
+	CheckInterval Duration
+	// How long the sector of a deal may stay faulty before the deal is
+	// repaired. Deals slashed or whose sector was terminated are repaired
+	// right away.
+	FaultThreshold Duration
+	// Addresses of the storage providers to propose replacement deals to, in
+	// order of preference
+	BackupProviders []string
+	// Address of the wallet paying for the replacement deals, the client
+	// address of the failed deal when empty
+	Wallet string
 }
 
 type Wallet struct {
@@ -0,0 +1,420 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/multiformats/go-multiaddr"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	miner5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/miner"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/lib/apitls"
+	"github.com/filecoin-project/lotus/lib/lotuslog"
+	"github.com/filecoin-project/lotus/storage/paths"
+	"github.com/filecoin-project/lotus/storage/sealer/remoteprover"
+)
+
+// FieldMigration describes a config field which was renamed or removed
+type FieldMigration struct {
+	// Old is the dotted config path of the field
+	Old string
+	// New is the dotted config path of the field which replaces it, in the same
+	// table; empty for removed fields
+	New string
+	// Note explains why a field was removed
+	Note string
+}
+
+func (m FieldMigration) String() string {
+	if m.New == "" {
+		return fmt.Sprintf("%s was removed: %s", m.Old, m.Note)
+	}
+	return fmt.Sprintf("%s was renamed to %s", m.Old, m.New)
+}
+
+// FieldMigrations are the renamed and removed fields of the node configs
+var FieldMigrations = []FieldMigration{
+	{Old: "Client.SimultaneousTransfers", New: "Client.SimultaneousTransfersForStorage"},
+	{Old: "Dealmaking.SimultaneousTransfers", New: "Dealmaking.SimultaneousTransfersForStorage"},
+	{Old: "Chainstore.Splitstore.EnableColdStoreAutoPrune", Note: "the coldstore is no longer pruned, use the discard coldstore to keep only the hotstore"},
+	{Old: "Chainstore.Splitstore.ColdStoreFullGCFrequency", Note: "the coldstore is no longer garbage collected"},
+	{Old: "Chainstore.Splitstore.ColdStoreRetention", Note: "the coldstore is no longer pruned"},
+}
+
+func findMigration(key string) *FieldMigration {
+	for i := range FieldMigrations {
+		if FieldMigrations[i].Old == key {
+			return &FieldMigrations[i]
+		}
+	}
+	return nil
+}
+
+// ConfigIssue is a problem found in a config file
+type ConfigIssue struct {
+	// Key is the dotted config path of the setting
+	Key     string
+	Message string
+	// Error is set for the issues which prevent the node from starting, or from
+	// working as configured; the other issues are warnings
+	Error bool
+	// Migratable is set for the deprecated fields which MigrateConfig rewrites
+	Migratable bool
+}
+
+func (i ConfigIssue) String() string {
+	return fmt.Sprintf("%s: %s", i.Key, i.Message)
+}
+
+// ValidateConfig decodes a config file into def, the default config of the
+// node type, and checks it for unknown and deprecated fields and for settings
+// which are inconsistent with each other. Values set with environment
+// variables are not taken into account.
+func ValidateConfig(b []byte, def interface{}) ([]ConfigIssue, error) {
+	md, err := toml.Decode(string(b), def)
+	if err != nil {
+		return nil, xerrors.Errorf("decoding config: %w", err)
+	}
+
+	var issues []ConfigIssue
+	var unknownTables []string
+	for _, k := range md.Undecoded() {
+		key := k.String()
+
+		// the fields of unknown tables are covered by the table
+		inUnknown := false
+		for _, t := range unknownTables {
+			if strings.HasPrefix(key, t+".") {
+				inUnknown = true
+				break
+			}
+		}
+		if inUnknown {
+			continue
+		}
+
+		if m := findMigration(key); m != nil {
+			issues = append(issues, ConfigIssue{Key: key, Message: "deprecated, " + m.String(), Migratable: true})
+			continue
+		}
+
+		if md.Type(k...) == "Hash" {
+			unknownTables = append(unknownTables, key)
+			issues = append(issues, ConfigIssue{Key: key, Message: "unknown table"})
+			continue
+		}
+		issues = append(issues, ConfigIssue{Key: key, Message: "unknown field"})
+	}
+
+	switch cfg := def.(type) {
+	case *FullNode:
+		issues = append(issues, checkCommon(&cfg.Common)...)
+		issues = append(issues, checkFullNode(cfg)...)
+	case *StorageMiner:
+		issues = append(issues, checkCommon(&cfg.Common)...)
+		issues = append(issues, checkStorageMiner(cfg)...)
+	}
+
+	return issues, nil
+}
+
+// ValidateRetrievalPricing checks that the retrieval pricing strategy is known
+// and configured
+func ValidateRetrievalPricing(pricingConfig *RetrievalPricing) error {
+	if pricingConfig.Strategy == RetrievalPricingExternalMode {
+		if pricingConfig.External == nil {
+			return xerrors.New("retrieval pricing policy has been to set to external but external policy config is nil")
+		}
+
+		if pricingConfig.External.Path == "" {
+			return xerrors.New("retrieval pricing policy has been to set to external but external script path is empty")
+		}
+	} else if pricingConfig.Strategy == RetrievalPricingPolicyMode {
+		if pricingConfig.Policy == nil {
+			return xerrors.New("retrieval pricing policy has been to set to policy but the policy config is nil")
+		}
+	} else if pricingConfig.Strategy != RetrievalPricingDefaultMode {
+		return xerrors.New("retrieval pricing policy must be either default, external or policy")
+	}
+
+	return nil
+}
+
+func checkCommon(cfg *Common) []ConfigIssue {
+	var issues []ConfigIssue
+	fail := func(key, format string, args ...interface{}) {
+		issues = append(issues, ConfigIssue{Key: key, Message: fmt.Sprintf(format, args...), Error: true})
+	}
+
+	if _, err := multiaddr.NewMultiaddr(cfg.API.ListenAddress); err != nil {
+		fail("API.ListenAddress", "invalid multiaddr: %s", err)
+	}
+
+	if cfg.API.MaxBatchSize < 0 {
+		fail("API.MaxBatchSize", "can't be negative")
+	}
+	if cfg.API.MaxConnRequests < 0 {
+		fail("API.MaxConnRequests", "can't be negative")
+	}
+
+	tf := apitls.Files{CertFile: cfg.API.TLS.CertFile, KeyFile: cfg.API.TLS.KeyFile, ClientCAFile: cfg.API.TLS.ClientCAFile}
+	if tf.Enabled() {
+		if err := apitls.Validate(tf); err != nil {
+			fail("API.TLS", "%s", err)
+		}
+	} else if tf.ClientCAFile != "" {
+		fail("API.TLS.ClientCAFile", "client certificates require CertFile and KeyFile to be set")
+	}
+
+	if cfg.Logging.Format != "" {
+		if _, err := lotuslog.ParseFormat(cfg.Logging.Format); err != nil {
+			fail("Logging.Format", "%s", err)
+		}
+	}
+	for sys, level := range cfg.Logging.SubsystemLevels {
+		if _, err := logging.LevelFromString(level); err != nil {
+			fail("Logging.SubsystemLevels."+sys, "%s", err)
+		}
+	}
+
+	return issues
+}
+
+func checkFullNode(cfg *FullNode) []ConfigIssue {
+	var issues []ConfigIssue
+	fail := func(key, format string, args ...interface{}) {
+		issues = append(issues, ConfigIssue{Key: key, Message: fmt.Sprintf(format, args...), Error: true})
+	}
+
+	if cfg.Chainstore.EnableSplitstore {
+		switch cfg.Chainstore.Splitstore.ColdStoreType {
+		case "universal", "discard":
+		default:
+			fail("Chainstore.Splitstore.ColdStoreType", "unknown coldstore type %q, expected universal or discard", cfg.Chainstore.Splitstore.ColdStoreType)
+		}
+
+		if cfg.Chainstore.Splitstore.HotStoreType != "badger" {
+			fail("Chainstore.Splitstore.HotStoreType", "unknown hotstore type %q, expected badger", cfg.Chainstore.Splitstore.HotStoreType)
+		}
+	}
+
+	if cfg.ChainStream.ListenAddress != "" {
+		if _, err := multiaddr.NewMultiaddr(cfg.ChainStream.ListenAddress); err != nil {
+			fail("ChainStream.ListenAddress", "invalid multiaddr: %s", err)
+		}
+	}
+
+	return issues
+}
+
+func checkStorageMiner(cfg *StorageMiner) []ConfigIssue {
+	var issues []ConfigIssue
+	fail := func(key, format string, args ...interface{}) {
+		issues = append(issues, ConfigIssue{Key: key, Message: fmt.Sprintf(format, args...), Error: true})
+	}
+	warn := func(key, format string, args ...interface{}) {
+		issues = append(issues, ConfigIssue{Key: key, Message: fmt.Sprintf(format, args...)})
+	}
+
+	ss := cfg.Subsystems
+	if ss.EnableMining && (!ss.EnableSealing || !ss.EnableSectorStorage) {
+		fail("Subsystems.EnableMining", "sealing and sector storage can't be disabled on a mining node")
+	}
+	if !ss.EnableMining && (ss.EnableSealing || ss.EnableSectorStorage) {
+		fail("Subsystems.EnableMining", "sealing and sector storage can only be enabled on a mining node")
+	}
+	if ss.EnableMining && ss.EnableProving {
+		fail("Subsystems.EnableProving", "the proving service can't run on a mining node, set ProvingApiInfo to run WindowPoSt in a separate process")
+	}
+	if !ss.EnableMining && ss.ProvingApiInfo != "" {
+		warn("Subsystems.ProvingApiInfo", "only used by mining nodes")
+	}
+
+	if cfg.Dealmaking.Filter != "" && cfg.Dealmaking.FilterWebhook != "" {
+		fail("Dealmaking.FilterWebhook", "only one of the deal filter command and the deal filter webhook can be set")
+	}
+	if err := ValidateRetrievalPricing(cfg.Dealmaking.RetrievalPricing); err != nil {
+		fail("Dealmaking.RetrievalPricing.Strategy", "%s", err)
+	}
+
+	// batching limits of the actors at the newest network version
+	nv := build.NewestNetworkVersion
+	sc := cfg.Sealing
+	if sc.BatchPreCommits && sc.MaxPreCommitBatch > miner5.PreCommitSectorBatchMaxSize {
+		fail("Sealing.MaxPreCommitBatch", "at most %d sectors can be precommitted in a batch at network version %d", miner5.PreCommitSectorBatchMaxSize, nv)
+	}
+	if sc.AggregateCommits {
+		if sc.MaxCommitBatch > miner5.MaxAggregatedSectors {
+			fail("Sealing.MaxCommitBatch", "at most %d sectors can be proven in an aggregate at network version %d", miner5.MaxAggregatedSectors, nv)
+		}
+		if sc.MinCommitBatch > sc.MaxCommitBatch {
+			fail("Sealing.MinCommitBatch", "greater than MaxCommitBatch (%d)", sc.MaxCommitBatch)
+		}
+		if sc.MinCommitBatch < miner5.MinAggregatedSectors {
+			warn("Sealing.MinCommitBatch", "less than %d sectors are never aggregated at network version %d, the commits are sent individually", miner5.MinAggregatedSectors, nv)
+		}
+	}
+	if sc.TerminateBatchMin > sc.TerminateBatchMax {
+		fail("Sealing.TerminateBatchMin", "greater than TerminateBatchMax (%d)", sc.TerminateBatchMax)
+	}
+	if sc.MaxSealingSectors > 0 && sc.MaxSealingSectorsForDeals > sc.MaxSealingSectors {
+		warn("Sealing.MaxSealingSectorsForDeals", "greater than MaxSealingSectors (%d), which also limits the deal sectors", sc.MaxSealingSectors)
+	}
+
+	for i, p := range cfg.Storage.RemoteProvers {
+		if _, err := remoteprover.NewProver(p.ProverConfig()); err != nil {
+			fail(fmt.Sprintf("Storage.RemoteProvers[%d]", i), "%s", err)
+		}
+	}
+
+	for i, r := range cfg.Storage.AffinityRules {
+		if err := r.AffinityRule().Validate(); err != nil {
+			fail(fmt.Sprintf("Storage.AffinityRules[%d]", i), "%s", err)
+		}
+	}
+
+	if err := paths.AllocationPolicy(cfg.Storage.AllocationPolicy).Validate(); err != nil {
+		fail("Storage.AllocationPolicy", "%s", err)
+	}
+
+	for i, t := range cfg.Storage.WorkerTemplates {
+		if err := t.Template().Validate(); err != nil {
+			fail(fmt.Sprintf("Storage.WorkerTemplates[%d]", i), "%s", err)
+		}
+		if err := paths.AllocationPolicy(t.AllocationPolicy).Validate(); err != nil {
+			fail(fmt.Sprintf("Storage.WorkerTemplates[%d].AllocationPolicy", i), "%s", err)
+		}
+	}
+
+	ps := cfg.Proving.Sender
+	if ps.BumpAfterEpochs < 0 {
+		fail("Proving.Sender.BumpAfterEpochs", "negative")
+	}
+	// messagepool.ReplaceByFeeRatioDefault, the mpool imports this package
+	if ps.BumpAfterEpochs > 0 && ps.BumpRatio < 1.25 {
+		fail("Proving.Sender.BumpRatio", "less than 1.25, the mpool doesn't replace the messages")
+	}
+	for i, df := range ps.DeadlineMaxFees {
+		for _, dl := range df.Deadlines {
+			if dl >= miner5.WPoStPeriodDeadlines {
+				fail(fmt.Sprintf("Proving.Sender.DeadlineMaxFees[%d]", i), "deadline %d out of range, there are %d deadlines", dl, miner5.WPoStPeriodDeadlines)
+			}
+		}
+	}
+	if ps.FallbackAddress != "" {
+		if _, err := address.NewFromString(ps.FallbackAddress); err != nil {
+			fail("Proving.Sender.FallbackAddress", "invalid address %q: %s", ps.FallbackAddress, err)
+		}
+	}
+
+	dc := cfg.Proving.Disputer
+	for _, a := range dc.Miners {
+		if _, err := address.NewFromString(a); err != nil {
+			fail("Proving.Disputer.Miners", "invalid address %q: %s", a, err)
+		}
+	}
+	if dc.From != "" {
+		if _, err := address.NewFromString(dc.From); err != nil {
+			fail("Proving.Disputer.From", "invalid address %q: %s", dc.From, err)
+		}
+	}
+	if !dc.Enable && (len(dc.Miners) > 0 || dc.AutoDispute) {
+		warn("Proving.Disputer.Enable", "the disputer is disabled, Miners and AutoDispute are ignored")
+	}
+
+	for key, addrs := range map[string][]string{
+		"Addresses.PreCommitControl":   cfg.Addresses.PreCommitControl,
+		"Addresses.CommitControl":      cfg.Addresses.CommitControl,
+		"Addresses.TerminateControl":   cfg.Addresses.TerminateControl,
+		"Addresses.DealPublishControl": cfg.Addresses.DealPublishControl,
+	} {
+		for _, a := range addrs {
+			if _, err := address.NewFromString(a); err != nil {
+				fail(key, "invalid address %q: %s", a, err)
+			}
+		}
+	}
+
+	return issues
+}
+
+var tableRx = regexp.MustCompile(`^\[\[?\s*([^\]]+?)\s*\]\]?`)
+
+// MigrateConfig rewrites the deprecated fields of a config file: renamed
+// fields get their new name, and removed fields are commented out. The rest of
+// the file, including its comments, is kept as is.
+func MigrateConfig(b []byte) ([]byte, []FieldMigration, error) {
+	var tree map[string]interface{}
+	if _, err := toml.Decode(string(b), &tree); err != nil {
+		return nil, nil, xerrors.Errorf("decoding config: %w", err)
+	}
+
+	var out []string
+	var applied []FieldMigration
+	var table string
+	for _, line := range strings.Split(string(b), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if m := tableRx.FindStringSubmatch(trimmed); m != nil {
+			table = m[1]
+			out = append(out, line)
+			continue
+		}
+
+		eq := strings.Index(trimmed, "=")
+		if trimmed == "" || trimmed[0] == '#' || eq < 0 {
+			out = append(out, line)
+			continue
+		}
+
+		key := strings.TrimSpace(trimmed[:eq])
+		if table != "" {
+			key = table + "." + key
+		}
+		m := findMigration(key)
+		if m == nil {
+			out = append(out, line)
+			continue
+		}
+
+		pad := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if m.New == "" {
+			out = append(out, pad+"# Removed: "+m.Note, pad+"#"+trimmed)
+			applied = append(applied, *m)
+			continue
+		}
+
+		if isSet(tree, m.New) {
+			return nil, nil, xerrors.Errorf("both %s and %s are set, remove %s", m.Old, m.New, m.Old)
+		}
+
+		oldName := m.Old[strings.LastIndex(m.Old, ".")+1:]
+		newName := m.New[strings.LastIndex(m.New, ".")+1:]
+		out = append(out, pad+newName+strings.TrimPrefix(trimmed, oldName))
+		applied = append(applied, *m)
+	}
+
+	return []byte(strings.Join(out, "\n")), applied, nil
+}
+
+func isSet(tree map[string]interface{}, key string) bool {
+	parts := strings.Split(key, ".")
+	for i, p := range parts {
+		v, ok := tree[p]
+		if !ok {
+			return false
+		}
+		if i == len(parts)-1 {
+			return true
+		}
+		if tree, ok = v.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+//stm: #unit
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfig(t *testing.T) {
+	issues, err := ValidateConfig([]byte(`
+[Dealmaking]
+  SimultaneousTransfers = 10
+  Filter = "/bin/true"
+  FilterWebhook = "http://localhost"
+
+[Sealing]
+  AggregateCommits = true
+  MinCommitBatch = 20
+  MaxCommitBatch = 10
+  Unknown = 1
+
+[Proving.Sender]
+  BumpRatio = 1.1
+
+[Nonexistent]
+  Foo = 1
+`), DefaultStorageMiner())
+	require.NoError(t, err)
+
+	byKey := map[string]ConfigIssue{}
+	for _, issue := range issues {
+		byKey[issue.Key] = issue
+	}
+	require.Len(t, byKey, 6)
+
+	require.True(t, byKey["Dealmaking.SimultaneousTransfers"].Migratable)
+	require.False(t, byKey["Dealmaking.SimultaneousTransfers"].Error)
+	require.Equal(t, "unknown field", byKey["Sealing.Unknown"].Message)
+	require.Equal(t, "unknown table", byKey["Nonexistent"].Message)
+	require.True(t, byKey["Dealmaking.FilterWebhook"].Error)
+	require.True(t, byKey["Sealing.MinCommitBatch"].Error)
+	require.True(t, byKey["Proving.Sender.BumpRatio"].Error)
+
+	issues, err = ValidateConfig([]byte(""), DefaultFullNode())
+	require.NoError(t, err)
+	require.Empty(t, issues)
+
+	_, err = ValidateConfig([]byte("[Sealing"), DefaultStorageMiner())
+	require.Error(t, err)
+}
+
+func TestMigrateConfig(t *testing.T) {
+	in := `# my miner
+[Dealmaking]
+  # limit the transfers
+  SimultaneousTransfers = 10 # comment
+
+[Client]
+  SimultaneousTransfers = 5
+
+[Chainstore.Splitstore]
+  ColdStoreType = "universal"
+  EnableColdStoreAutoPrune = true
+`
+	out, applied, err := MigrateConfig([]byte(in))
+	require.NoError(t, err)
+	require.Len(t, applied, 3)
+	require.Equal(t, `# my miner
+[Dealmaking]
+  # limit the transfers
+  SimultaneousTransfersForStorage = 10 # comment
+
+[Client]
+  SimultaneousTransfersForStorage = 5
+
+[Chainstore.Splitstore]
+  ColdStoreType = "universal"
+  # Removed: the coldstore is no longer pruned, use the discard coldstore to keep only the hotstore
+  #EnableColdStoreAutoPrune = true
+`, string(out))
+
+	issues, err := ValidateConfig(out, DefaultFullNode())
+	require.NoError(t, err)
+	for _, issue := range issues {
+		require.False(t, issue.Migratable, issue.String())
+	}
+
+	_, _, err = MigrateConfig([]byte(`
+[Client]
+  SimultaneousTransfers = 5
+  SimultaneousTransfersForStorage = 5
+`))
+	require.Error(t, err)
+}
@@ -0,0 +1,426 @@
+// Package chainstream serves the ChainStream gRPC service, which streams chain
+// data to indexers and other consumers of the full chain with less overhead
+// than the JSON-RPC API.
+package chainstream
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-jsonrpc/auth"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	pb "github.com/filecoin-project/lotus/api/chainstream"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+var log = logging.Logger("chainstream")
+
+// FullNode is the part of the full node API the streams are served from
+type FullNode interface {
+	ChainNotify(context.Context) (<-chan []*api.HeadChange, error)
+	ChainGetTipSetAfterHeight(context.Context, abi.ChainEpoch, types.TipSetKey) (*types.TipSet, error)
+	ChainGetMessagesInTipset(context.Context, types.TipSetKey) ([]api.Message, error)
+	ChainGetParentMessages(context.Context, cid.Cid) ([]api.Message, error)
+	ChainGetParentReceipts(context.Context, cid.Cid) ([]*types.MessageReceipt, error)
+	StateGetActor(context.Context, address.Address, types.TipSetKey) (*types.Actor, error)
+	StateLookupID(context.Context, address.Address, types.TipSetKey) (address.Address, error)
+	StateAccountKey(context.Context, address.Address, types.TipSetKey) (address.Address, error)
+	AuthVerify(ctx context.Context, token string) ([]auth.Permission, error)
+}
+
+type Server struct {
+	pb.UnimplementedChainStreamServer
+
+	api FullNode
+}
+
+// NewGRPCServer returns a gRPC server with the ChainStream service. The calls
+// are authorized with the API tokens of the node.
+func NewGRPCServer(a FullNode, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts, grpc.StreamInterceptor(authorize(a.AuthVerify)))
+
+	srv := grpc.NewServer(opts...)
+	pb.RegisterChainStreamServer(srv, &Server{api: a})
+	return srv
+}
+
+func authorize(verify func(ctx context.Context, token string) ([]auth.Permission, error)) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		md, _ := metadata.FromIncomingContext(ss.Context())
+
+		var token string
+		if v := md.Get("authorization"); len(v) > 0 {
+			token = strings.TrimPrefix(v[0], "Bearer ")
+		}
+		if token == "" {
+			return status.Error(codes.Unauthenticated, "missing API token")
+		}
+
+		perms, err := verify(ss.Context(), token)
+		if err != nil {
+			log.Warnw("chain stream token verification failed", "method", info.FullMethod, "error", err)
+			return status.Error(codes.Unauthenticated, "invalid API token")
+		}
+		for _, p := range perms {
+			if p == api.PermRead {
+				return handler(srv, ss)
+			}
+		}
+		return status.Error(codes.PermissionDenied, "missing read permission")
+	}
+}
+
+// follow calls cb with the tipsets from the start height to the current head,
+// or with the current head when start is 0, then with the changes of the chain
+// until the stream is done
+func (s *Server) follow(ctx context.Context, start int64, cb func(pb.UpdateType, *types.TipSet) error) error {
+	if start < 0 {
+		return status.Error(codes.InvalidArgument, "start height can't be negative")
+	}
+
+	notifs, err := s.api.ChainNotify(ctx)
+	if err != nil {
+		return status.Errorf(codes.Internal, "subscribing to chain changes: %s", err)
+	}
+
+	var head *types.TipSet
+	select {
+	case <-ctx.Done():
+		return nil
+	case changes, ok := <-notifs:
+		if !ok || len(changes) != 1 || changes[0].Type != store.HCCurrent {
+			return status.Error(codes.Internal, "unexpected first chain notification")
+		}
+		head = changes[0].Val
+	}
+
+	if start > int64(head.Height()) {
+		return status.Errorf(codes.InvalidArgument, "start height %d is above the head %d", start, head.Height())
+	}
+
+	if start > 0 {
+		for h := abi.ChainEpoch(start); h < head.Height(); {
+			ts, err := s.api.ChainGetTipSetAfterHeight(ctx, h, head.Key())
+			if err != nil {
+				return status.Errorf(codes.Internal, "getting tipset at height %d: %s", h, err)
+			}
+			if ts.Height() >= head.Height() {
+				break
+			}
+
+			if err := cb(pb.UpdateType_APPLY, ts); err != nil {
+				return err
+			}
+			h = ts.Height() + 1
+		}
+	}
+
+	if err := cb(pb.UpdateType_APPLY, head); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case changes, ok := <-notifs:
+			if !ok {
+				return status.Error(codes.Unavailable, "chain notifications closed")
+			}
+
+			for _, c := range changes {
+				typ := pb.UpdateType_APPLY
+				if c.Type == store.HCRevert {
+					typ = pb.UpdateType_REVERT
+				}
+
+				if err := cb(typ, c.Val); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func (s *Server) Tipsets(req *pb.TipsetsRequest, stream pb.ChainStream_TipsetsServer) error {
+	return s.follow(stream.Context(), req.GetStartHeight(), func(typ pb.UpdateType, ts *types.TipSet) error {
+		up := &pb.TipsetUpdate{
+			Type:            typ,
+			Height:          int64(ts.Height()),
+			ParentStateRoot: ts.ParentState().String(),
+			ParentWeight:    ts.ParentWeight().String(),
+			ParentBaseFee:   ts.Blocks()[0].ParentBaseFee.String(),
+			Timestamp:       ts.MinTimestamp(),
+		}
+
+		for _, bh := range ts.Blocks() {
+			b := &pb.Block{
+				Cid:   bh.Cid().String(),
+				Miner: bh.Miner.String(),
+			}
+			if bh.ElectionProof != nil {
+				b.WinCount = bh.ElectionProof.WinCount
+			}
+			if req.GetIncludeRaw() {
+				raw, err := bh.Serialize()
+				if err != nil {
+					return status.Errorf(codes.Internal, "serializing block header: %s", err)
+				}
+				b.Raw = raw
+			}
+			up.Blocks = append(up.Blocks, b)
+		}
+
+		return stream.Send(up)
+	})
+}
+
+func (s *Server) Messages(req *pb.MessagesRequest, stream pb.ChainStream_MessagesServer) error {
+	ctx := stream.Context()
+
+	filter, err := s.messageFilter(ctx, req.GetFilter())
+	if err != nil {
+		return err
+	}
+
+	return s.follow(ctx, req.GetStartHeight(), func(typ pb.UpdateType, ts *types.TipSet) error {
+		msgs, err := s.api.ChainGetMessagesInTipset(ctx, ts.Key())
+		if err != nil {
+			return status.Errorf(codes.Internal, "getting messages of tipset %s: %s", ts.Key(), err)
+		}
+
+		up := &pb.MessageUpdate{Type: typ, Tipset: tipsetRef(ts)}
+		for _, m := range msgs {
+			if filter.matches(m.Message) {
+				up.Messages = append(up.Messages, toMessage(m.Cid, m.Message))
+			}
+		}
+
+		return stream.Send(up)
+	})
+}
+
+func (s *Server) Receipts(req *pb.ReceiptsRequest, stream pb.ChainStream_ReceiptsServer) error {
+	ctx := stream.Context()
+
+	filter, err := s.messageFilter(ctx, req.GetFilter())
+	if err != nil {
+		return err
+	}
+
+	return s.follow(ctx, req.GetStartHeight(), func(typ pb.UpdateType, ts *types.TipSet) error {
+		up := &pb.ReceiptUpdate{Type: typ, Tipset: tipsetRef(ts)}
+
+		// genesis doesn't execute messages
+		if ts.Height() > 0 {
+			msgs, err := s.api.ChainGetParentMessages(ctx, ts.Cids()[0])
+			if err != nil {
+				return status.Errorf(codes.Internal, "getting parent messages of tipset %s: %s", ts.Key(), err)
+			}
+			rcpts, err := s.api.ChainGetParentReceipts(ctx, ts.Cids()[0])
+			if err != nil {
+				return status.Errorf(codes.Internal, "getting parent receipts of tipset %s: %s", ts.Key(), err)
+			}
+			if len(msgs) != len(rcpts) {
+				return status.Errorf(codes.Internal, "tipset %s has %d parent messages but %d receipts", ts.Key(), len(msgs), len(rcpts))
+			}
+
+			for i, m := range msgs {
+				r := rcpts[i]
+				if req.GetFailedOnly() && r.ExitCode.IsSuccess() {
+					continue
+				}
+				if !filter.matches(m.Message) {
+					continue
+				}
+
+				up.Receipts = append(up.Receipts, &pb.Receipt{
+					Message:  toMessage(m.Cid, m.Message),
+					ExitCode: int64(r.ExitCode),
+					Return:   r.Return,
+					GasUsed:  r.GasUsed,
+				})
+			}
+		}
+
+		return stream.Send(up)
+	})
+}
+
+func (s *Server) Events(req *pb.EventsRequest, stream pb.ChainStream_EventsServer) error {
+	ctx := stream.Context()
+
+	if len(req.GetActors()) == 0 {
+		return status.Error(codes.InvalidArgument, "at least one actor is required")
+	}
+
+	actors := make([]address.Address, len(req.GetActors()))
+	for i, as := range req.GetActors() {
+		a, err := address.NewFromString(as)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "parsing actor address %q: %s", as, err)
+		}
+		actors[i] = a
+	}
+
+	return s.follow(ctx, req.GetStartHeight(), func(typ pb.UpdateType, ts *types.TipSet) error {
+		up := &pb.EventUpdate{Type: typ, Tipset: tipsetRef(ts)}
+
+		if ts.Height() > 0 {
+			for i, a := range actors {
+				cur, err := s.actor(ctx, a, ts.Key())
+				if err != nil {
+					return err
+				}
+				prev, err := s.actor(ctx, a, ts.Parents())
+				if err != nil {
+					return err
+				}
+				if sameActor(cur, prev) {
+					continue
+				}
+
+				change := &pb.ActorChange{Actor: req.GetActors()[i]}
+				if cur != nil {
+					change.Head = cur.Head.String()
+					change.Code = cur.Code.String()
+					change.Nonce = cur.Nonce
+					change.Balance = cur.Balance.String()
+				}
+				up.Changes = append(up.Changes, change)
+			}
+		}
+
+		return stream.Send(up)
+	})
+}
+
+// actor returns the actor in the state of the tipset, nil when it doesn't exist
+func (s *Server) actor(ctx context.Context, a address.Address, tsk types.TipSetKey) (*types.Actor, error) {
+	act, err := s.api.StateGetActor(ctx, a, tsk)
+	if xerrors.Is(err, types.ErrActorNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "getting actor %s at %s: %s", a, tsk, err)
+	}
+	return act, nil
+}
+
+func sameActor(a, b *types.Actor) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Head == b.Head && a.Code == b.Code && a.Nonce == b.Nonce && a.Balance.Equals(b.Balance)
+}
+
+type messageFilter struct {
+	from, to map[address.Address]struct{}
+	methods  map[abi.MethodNum]struct{}
+}
+
+func (s *Server) messageFilter(ctx context.Context, f *pb.MessageFilter) (*messageFilter, error) {
+	from, err := s.addressSet(ctx, f.GetFrom())
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.addressSet(ctx, f.GetTo())
+	if err != nil {
+		return nil, err
+	}
+
+	mf := &messageFilter{from: from, to: to}
+	if len(f.GetMethods()) > 0 {
+		mf.methods = map[abi.MethodNum]struct{}{}
+		for _, m := range f.GetMethods() {
+			mf.methods[abi.MethodNum(m)] = struct{}{}
+		}
+	}
+
+	return mf, nil
+}
+
+// addressSet parses the addresses, adding the ID or the robust address of
+// each address so that messages match either form
+func (s *Server) addressSet(ctx context.Context, addrs []string) (map[address.Address]struct{}, error) {
+	if len(addrs) == 0 {
+		return nil, nil
+	}
+
+	out := map[address.Address]struct{}{}
+	for _, as := range addrs {
+		a, err := address.NewFromString(as)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "parsing address %q: %s", as, err)
+		}
+		out[a] = struct{}{}
+
+		// the other form is only known for existing actors, and the robust
+		// address of an ID address only for accounts
+		var other address.Address
+		if a.Protocol() == address.ID {
+			other, err = s.api.StateAccountKey(ctx, a, types.EmptyTSK)
+		} else {
+			other, err = s.api.StateLookupID(ctx, a, types.EmptyTSK)
+		}
+		if err == nil {
+			out[other] = struct{}{}
+		}
+	}
+
+	return out, nil
+}
+
+func (f *messageFilter) matches(m *types.Message) bool {
+	if f.from != nil {
+		if _, ok := f.from[m.From]; !ok {
+			return false
+		}
+	}
+	if f.to != nil {
+		if _, ok := f.to[m.To]; !ok {
+			return false
+		}
+	}
+	if f.methods != nil {
+		if _, ok := f.methods[m.Method]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func tipsetRef(ts *types.TipSet) *pb.TipsetRef {
+	ref := &pb.TipsetRef{Height: int64(ts.Height())}
+	for _, c := range ts.Cids() {
+		ref.Cids = append(ref.Cids, c.String())
+	}
+	return ref
+}
+
+func toMessage(c cid.Cid, m *types.Message) *pb.Message {
+	return &pb.Message{
+		Cid:        c.String(),
+		From:       m.From.String(),
+		To:         m.To.String(),
+		Nonce:      m.Nonce,
+		Value:      m.Value.String(),
+		GasLimit:   m.GasLimit,
+		GasFeeCap:  m.GasFeeCap.String(),
+		GasPremium: m.GasPremium.String(),
+		Method:     uint64(m.Method),
+		Params:     m.Params,
+	}
+}
@@ -0,0 +1,212 @@
+//stm: #unit
+package chainstream
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-jsonrpc/auth"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	pb "github.com/filecoin-project/lotus/api/chainstream"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+type fakeNode struct {
+	chain []*types.TipSet
+	msgs  map[types.TipSetKey][]api.Message
+
+	subs chan chan []*api.HeadChange
+}
+
+func (f *fakeNode) ChainNotify(ctx context.Context) (<-chan []*api.HeadChange, error) {
+	ch := make(chan []*api.HeadChange, 16)
+	ch <- []*api.HeadChange{{Type: store.HCCurrent, Val: f.chain[len(f.chain)-1]}}
+	f.subs <- ch
+	return ch, nil
+}
+
+func (f *fakeNode) ChainGetTipSetAfterHeight(ctx context.Context, h abi.ChainEpoch, tsk types.TipSetKey) (*types.TipSet, error) {
+	for _, ts := range f.chain {
+		if ts.Height() >= h {
+			return ts, nil
+		}
+	}
+	return nil, xerrors.Errorf("no tipset after %d", h)
+}
+
+func (f *fakeNode) ChainGetMessagesInTipset(ctx context.Context, tsk types.TipSetKey) ([]api.Message, error) {
+	return f.msgs[tsk], nil
+}
+
+func (f *fakeNode) ChainGetParentMessages(ctx context.Context, c cid.Cid) ([]api.Message, error) {
+	return nil, nil
+}
+
+func (f *fakeNode) ChainGetParentReceipts(ctx context.Context, c cid.Cid) ([]*types.MessageReceipt, error) {
+	return nil, nil
+}
+
+func (f *fakeNode) StateGetActor(ctx context.Context, a address.Address, tsk types.TipSetKey) (*types.Actor, error) {
+	return nil, xerrors.Errorf("loading actor: %w", types.ErrActorNotFound)
+}
+
+func (f *fakeNode) StateLookupID(ctx context.Context, a address.Address, tsk types.TipSetKey) (address.Address, error) {
+	return address.Undef, types.ErrActorNotFound
+}
+
+func (f *fakeNode) StateAccountKey(ctx context.Context, a address.Address, tsk types.TipSetKey) (address.Address, error) {
+	return address.Undef, types.ErrActorNotFound
+}
+
+func (f *fakeNode) AuthVerify(ctx context.Context, token string) ([]auth.Permission, error) {
+	switch token {
+	case "reader":
+		return []auth.Permission{api.PermRead}, nil
+	case "none":
+		return []auth.Permission{}, nil
+	default:
+		return nil, xerrors.New("bad token")
+	}
+}
+
+func setup(t *testing.T) (*fakeNode, pb.ChainStreamClient) {
+	ts0 := mock.TipSet(mock.MkBlock(nil, 1, 0))
+	ts1 := mock.TipSet(mock.MkBlock(ts0, 1, 1))
+	ts2 := mock.TipSet(mock.MkBlock(ts1, 1, 2))
+	ts3 := mock.TipSet(mock.MkBlock(ts2, 1, 3))
+
+	f := &fakeNode{
+		chain: []*types.TipSet{ts0, ts1, ts2, ts3},
+		msgs:  map[types.TipSetKey][]api.Message{},
+		subs:  make(chan chan []*api.HeadChange, 1),
+	}
+
+	l := bufconn.Listen(1 << 20)
+	srv := NewGRPCServer(f)
+	go srv.Serve(l) // nolint:errcheck
+	t.Cleanup(srv.Stop)
+
+	cc, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cc.Close() })
+
+	return f, pb.NewChainStreamClient(cc)
+}
+
+func withToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+func TestTipsets(t *testing.T) {
+	f, c := setup(t)
+
+	ctx, cancel := context.WithCancel(withToken(context.Background(), "reader"))
+	defer cancel()
+
+	stream, err := c.Tipsets(ctx, &pb.TipsetsRequest{StartHeight: 1, IncludeRaw: true})
+	require.NoError(t, err)
+	sub := <-f.subs
+
+	for h := int64(1); h <= 3; h++ {
+		up, err := stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, pb.UpdateType_APPLY, up.Type)
+		require.Equal(t, h, up.Height)
+		require.Len(t, up.Blocks, 1)
+		require.Equal(t, f.chain[h].Cids()[0].String(), up.Blocks[0].Cid)
+
+		var bh types.BlockHeader
+		require.NoError(t, bh.UnmarshalCBOR(bytes.NewReader(up.Blocks[0].Raw)))
+		require.Equal(t, f.chain[h].Cids()[0], bh.Cid())
+	}
+
+	// reorg
+	alt := mock.TipSet(mock.MkBlock(f.chain[2], 2, 4))
+	sub <- []*api.HeadChange{
+		{Type: store.HCRevert, Val: f.chain[3]},
+		{Type: store.HCApply, Val: alt},
+	}
+
+	up, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, pb.UpdateType_REVERT, up.Type)
+	require.Equal(t, f.chain[3].Cids()[0].String(), up.Blocks[0].Cid)
+
+	up, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, pb.UpdateType_APPLY, up.Type)
+	require.Equal(t, alt.Cids()[0].String(), up.Blocks[0].Cid)
+}
+
+func TestMessagesFilter(t *testing.T) {
+	f, c := setup(t)
+
+	m1 := mock.UnsignedMessage(mock.Address(100), mock.Address(200), 0)
+	m2 := mock.UnsignedMessage(mock.Address(100), mock.Address(300), 1)
+	f.msgs[f.chain[3].Key()] = []api.Message{
+		{Cid: m1.Cid(), Message: m1},
+		{Cid: m2.Cid(), Message: m2},
+	}
+
+	ctx, cancel := context.WithCancel(withToken(context.Background(), "reader"))
+	defer cancel()
+
+	stream, err := c.Messages(ctx, &pb.MessagesRequest{
+		Filter: &pb.MessageFilter{To: []string{mock.Address(300).String()}},
+	})
+	require.NoError(t, err)
+	<-f.subs
+
+	up, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, int64(3), up.Tipset.Height)
+	require.Len(t, up.Messages, 1)
+	require.Equal(t, m2.Cid().String(), up.Messages[0].Cid)
+	require.Equal(t, uint64(1), up.Messages[0].Nonce)
+}
+
+func TestAuthorization(t *testing.T) {
+	_, c := setup(t)
+
+	for token, code := range map[string]codes.Code{
+		"":     codes.Unauthenticated,
+		"bad":  codes.Unauthenticated,
+		"none": codes.PermissionDenied,
+	} {
+		ctx := context.Background()
+		if token != "" {
+			ctx = withToken(ctx, token)
+		}
+
+		stream, err := c.Tipsets(ctx, &pb.TipsetsRequest{})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		require.Equal(t, code, status.Code(err), token)
+	}
+
+	stream, err := c.Events(withToken(context.Background(), "reader"), &pb.EventsRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,25 @@
+package client
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/markets/dealrepair"
+)
+
+// DealHealthAPI is kept apart from API, which the deal health monitor itself
+// depends on
+type DealHealthAPI struct {
+	fx.In
+
+	DealRepair *dealrepair.Monitor `optional:"true"`
+}
+
+func (a *DealHealthAPI) ClientDealHealth(ctx context.Context) ([]api.DealHealth, error) {
+	if a.DealRepair == nil {
+		return []api.DealHealth{}, nil
+	}
+	return a.DealRepair.Health(), nil
+}
@@ -15,9 +15,15 @@ import (
 	apitypes "github.com/filecoin-project/lotus/api/types"
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/journal/alerting"
+	"github.com/filecoin-project/lotus/lib/apitls"
+	"github.com/filecoin-project/lotus/lib/rpcconn"
+	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/repo"
 )
 
+var log = logging.Logger("common")
+
 var session = uuid.New()
 
 type CommonAPI struct {
@@ -26,10 +32,20 @@ type CommonAPI struct {
 	Alerting     *alerting.Alerting
 	APISecret    *dtypes.APIAlg
 	ShutdownChan dtypes.ShutdownChan
+	Repo         repo.LockedRepo   `optional:"true"`
+	Reloader     *config.Reloader  `optional:"true"`
+	MetadataDS   dtypes.MetadataDS `optional:"true"`
+	APITLS       *apitls.Server    `optional:"true"`
+	RPCConn      rpcconn.Config    `optional:"true"`
 }
 
 type jwtPayload struct {
 	Allow []auth.Permission
+
+	// ID is set for the tokens created with AuthTokenCreate
+	ID *uuid.UUID `json:",omitempty"`
+	// Expiry is the unix time after which the token isn't valid, when set
+	Expiry int64 `json:",omitempty"`
 }
 
 func (a *CommonAPI) AuthVerify(ctx context.Context, token string) ([]auth.Permission, error) {
@@ -38,6 +54,12 @@ func (a *CommonAPI) AuthVerify(ctx context.Context, token string) ([]auth.Permis
 		return nil, xerrors.Errorf("JWT Verification failed: %w", err)
 	}
 
+	if payload.ID != nil {
+		if err := a.verifyToken(ctx, &payload); err != nil {
+			return nil, err
+		}
+	}
+
 	return payload.Allow, nil
 }
 
@@ -0,0 +1,35 @@
+package common
+
+import (
+	"context"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+func (a *CommonAPI) ConfigReload(ctx context.Context) (api.ConfigReloadResult, error) {
+	if a.Reloader == nil {
+		return api.ConfigReloadResult{}, xerrors.Errorf("config reload is not supported by this node")
+	}
+
+	res, err := a.Reloader.Reload()
+	if res == nil {
+		return api.ConfigReloadResult{}, err
+	}
+
+	return api.ConfigReloadResult{
+		Applied:  res.Applied,
+		Changes:  configChanges(res.Changes),
+		Rejected: configChanges(res.Rejected),
+	}, err
+}
+
+func configChanges(changes []config.ConfigChange) []api.ConfigChange {
+	out := make([]api.ConfigChange, len(changes))
+	for i, c := range changes {
+		out[i] = api.ConfigChange{Key: c.Key, Old: c.Old, New: c.New}
+	}
+	return out
+}
@@ -0,0 +1,111 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+// logTailBuffer is the number of entries buffered for a LogTail client
+const logTailBuffer = 256
+
+func (a *CommonAPI) LogPersistLevel(ctx context.Context, subsystem, level string) error {
+	if a.Repo == nil {
+		return xerrors.Errorf("the node has no repo to persist the log level in")
+	}
+	if err := logging.SetLogLevel(subsystem, level); err != nil {
+		return err
+	}
+
+	var cfgErr error
+	err := a.Repo.SetConfig(func(raw interface{}) {
+		var common *config.Common
+		switch cfg := raw.(type) {
+		case *config.FullNode:
+			common = &cfg.Common
+		case *config.StorageMiner:
+			common = &cfg.Common
+		default:
+			cfgErr = xerrors.Errorf("unexpected config type %T", raw)
+			return
+		}
+
+		if common.Logging.SubsystemLevels == nil {
+			common.Logging.SubsystemLevels = map[string]string{}
+		}
+		common.Logging.SubsystemLevels[subsystem] = level
+	})
+	if err != nil {
+		return xerrors.Errorf("saving config: %w", err)
+	}
+	return cfgErr
+}
+
+func (a *CommonAPI) LogTail(ctx context.Context, subsystem, level string) (<-chan api.LogEntry, error) {
+	lvl := logging.LevelDebug
+	if level != "" {
+		var err error
+		if lvl, err = logging.LevelFromString(level); err != nil {
+			return nil, err
+		}
+	}
+
+	pr := logging.NewPipeReader(logging.PipeFormat(logging.JSONOutput), logging.PipeLevel(lvl))
+	go func() {
+		<-ctx.Done()
+		_ = pr.Close()
+	}()
+
+	out := make(chan api.LogEntry, logTailBuffer)
+	go func() {
+		defer close(out)
+
+		// the pipe is synchronous, so it is drained even when the client is
+		// slow, dropping entries rather than blocking the loggers
+		dec := json.NewDecoder(pr)
+		for {
+			var raw map[string]interface{}
+			if err := dec.Decode(&raw); err != nil {
+				return
+			}
+
+			e := logEntry(raw)
+			if subsystem != "" && e.Subsystem != subsystem {
+				continue
+			}
+
+			select {
+			case out <- e:
+			default:
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// logEntry converts a JSON encoded entry of the loggers
+func logEntry(raw map[string]interface{}) api.LogEntry {
+	var e api.LogEntry
+	str := func(key string) string {
+		s, _ := raw[key].(string)
+		delete(raw, key)
+		return s
+	}
+
+	e.Time, _ = time.Parse("2006-01-02T15:04:05.000Z0700", str("ts"))
+	e.Level = str("level")
+	e.Subsystem = str("logger")
+	e.Caller = str("caller")
+	e.Message = str("msg")
+	if len(raw) > 0 {
+		e.Fields = raw
+	}
+	return e
+}
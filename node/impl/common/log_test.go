@@ -0,0 +1,44 @@
+//stm: #unit
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogTail(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logging.SetupLogging(logging.GetConfig())
+	l := logging.Logger("logtail-test")
+	other := logging.Logger("logtail-other")
+	require.NoError(t, logging.SetLogLevel("logtail-test", "debug"))
+
+	a := &CommonAPI{}
+	entries, err := a.LogTail(ctx, "logtail-test", "info")
+	require.NoError(t, err)
+
+	other.Warn("not tailed")
+	l.Debug("below the level")
+	l.Infow("tailed", "key", "value")
+
+	select {
+	case e := <-entries:
+		require.Equal(t, "tailed", e.Message)
+		require.Equal(t, "info", e.Level)
+		require.Equal(t, "logtail-test", e.Subsystem)
+		require.Equal(t, "value", e.Fields["key"])
+		require.False(t, e.Time.IsZero())
+	case <-time.After(5 * time.Second):
+		t.Fatal("no entry tailed")
+	}
+
+	cancel()
+	for range entries {
+	}
+}
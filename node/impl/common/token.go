@@ -0,0 +1,152 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/google/uuid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-jsonrpc/auth"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+// the tokens created with AuthTokenCreate are stored in the metadata datastore
+// until they are revoked
+var tokenPrefix = datastore.NewKey("/auth/tokens")
+
+func tokenKey(id uuid.UUID) datastore.Key {
+	return tokenPrefix.ChildString(id.String())
+}
+
+// scopePermissions returns the permissions up to the scope
+func scopePermissions(scope auth.Permission) ([]auth.Permission, error) {
+	for i, p := range api.AllPermissions {
+		if p == scope {
+			return api.AllPermissions[:i+1], nil
+		}
+	}
+	return nil, xerrors.Errorf("unknown scope %q, expected one of: %s", scope, api.AllPermissions)
+}
+
+func (a *CommonAPI) AuthTokenCreate(ctx context.Context, scope auth.Permission, label string, ttl time.Duration) (api.NewAPIToken, error) {
+	if a.MetadataDS == nil {
+		return api.NewAPIToken{}, xerrors.Errorf("API tokens are not supported by this node")
+	}
+	if ttl < 0 {
+		return api.NewAPIToken{}, xerrors.Errorf("negative ttl %s", ttl)
+	}
+
+	perms, err := scopePermissions(scope)
+	if err != nil {
+		return api.NewAPIToken{}, err
+	}
+
+	info := api.APIToken{
+		ID:      uuid.New(),
+		Label:   label,
+		Scope:   scope,
+		Created: time.Now().UTC().Truncate(time.Second),
+	}
+	if ttl > 0 {
+		info.Expiry = info.Created.Add(ttl)
+	}
+
+	b, err := json.Marshal(&info)
+	if err != nil {
+		return api.NewAPIToken{}, err
+	}
+	if err := a.MetadataDS.Put(ctx, tokenKey(info.ID), b); err != nil {
+		return api.NewAPIToken{}, xerrors.Errorf("storing API token: %w", err)
+	}
+
+	p := jwtPayload{
+		Allow: perms,
+		ID:    &info.ID,
+	}
+	if ttl > 0 {
+		p.Expiry = info.Expiry.Unix()
+	}
+
+	token, err := jwt.Sign(&p, (*jwt.HMACSHA)(a.APISecret))
+	if err != nil {
+		return api.NewAPIToken{}, err
+	}
+
+	log.Infow("created API token", "id", info.ID, "label", label, "scope", scope, "expiry", info.Expiry)
+
+	return api.NewAPIToken{Info: info, Token: string(token)}, nil
+}
+
+func (a *CommonAPI) AuthTokenList(ctx context.Context) ([]api.APIToken, error) {
+	if a.MetadataDS == nil {
+		return nil, xerrors.Errorf("API tokens are not supported by this node")
+	}
+
+	res, err := a.MetadataDS.Query(ctx, query.Query{Prefix: tokenPrefix.String()})
+	if err != nil {
+		return nil, xerrors.Errorf("listing API tokens: %w", err)
+	}
+	defer res.Close() // nolint:errcheck
+
+	out := []api.APIToken{}
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("listing API tokens: %w", r.Error)
+		}
+
+		var info api.APIToken
+		if err := json.Unmarshal(r.Value, &info); err != nil {
+			return nil, xerrors.Errorf("decoding API token %s: %w", r.Key, err)
+		}
+		out = append(out, info)
+	}
+	return out, nil
+}
+
+func (a *CommonAPI) AuthTokenRevoke(ctx context.Context, id uuid.UUID) error {
+	if a.MetadataDS == nil {
+		return xerrors.Errorf("API tokens are not supported by this node")
+	}
+
+	has, err := a.MetadataDS.Has(ctx, tokenKey(id))
+	if err != nil {
+		return err
+	}
+	if !has {
+		return xerrors.Errorf("API token %s not found", id)
+	}
+
+	if err := a.MetadataDS.Delete(ctx, tokenKey(id)); err != nil {
+		return xerrors.Errorf("revoking API token: %w", err)
+	}
+
+	log.Infow("revoked API token", "id", id)
+	return nil
+}
+
+// verifyToken checks that a token created with AuthTokenCreate wasn't revoked,
+// and didn't expire
+func (a *CommonAPI) verifyToken(ctx context.Context, p *jwtPayload) error {
+	if p.Expiry != 0 && time.Now().Unix() >= p.Expiry {
+		return xerrors.Errorf("API token %s expired", p.ID)
+	}
+
+	if a.MetadataDS == nil {
+		return xerrors.Errorf("API tokens are not supported by this node")
+	}
+
+	has, err := a.MetadataDS.Has(ctx, tokenKey(*p.ID))
+	if err != nil {
+		return xerrors.Errorf("looking up API token: %w", err)
+	}
+	if !has {
+		return xerrors.Errorf("API token %s was revoked", p.ID)
+	}
+	return nil
+}
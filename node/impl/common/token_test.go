@@ -0,0 +1,75 @@
+//stm: #unit
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-jsonrpc/auth"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+func TestAuthTokens(t *testing.T) {
+	ctx := context.Background()
+
+	a := &CommonAPI{
+		APISecret:  (*dtypes.APIAlg)(jwt.NewHS256([]byte("secret"))),
+		MetadataDS: dssync.MutexWrap(datastore.NewMapDatastore()),
+	}
+
+	_, err := a.AuthTokenCreate(ctx, "root", "", 0)
+	require.Error(t, err)
+
+	t1, err := a.AuthTokenCreate(ctx, api.PermSign, "signer", 0)
+	require.NoError(t, err)
+	require.True(t, t1.Info.Expiry.IsZero())
+
+	perms, err := a.AuthVerify(ctx, t1.Token)
+	require.NoError(t, err)
+	require.Equal(t, []auth.Permission{api.PermRead, api.PermWrite, api.PermSign}, perms)
+
+	t2, err := a.AuthTokenCreate(ctx, api.PermRead, "reader", time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, t2.Info.Created.Add(time.Hour), t2.Info.Expiry)
+
+	tokens, err := a.AuthTokenList(ctx)
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+
+	// revoked
+	require.NoError(t, a.AuthTokenRevoke(ctx, t1.Info.ID))
+	require.Error(t, a.AuthTokenRevoke(ctx, t1.Info.ID))
+
+	_, err = a.AuthVerify(ctx, t1.Token)
+	require.Error(t, err)
+
+	perms, err = a.AuthVerify(ctx, t2.Token)
+	require.NoError(t, err)
+	require.Equal(t, []auth.Permission{api.PermRead}, perms)
+
+	tokens, err = a.AuthTokenList(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []api.APIToken{t2.Info}, tokens)
+
+	// expired
+	p := jwtPayload{Allow: api.AllPermissions, ID: &t2.Info.ID, Expiry: time.Now().Add(-time.Minute).Unix()}
+	expired, err := jwt.Sign(&p, (*jwt.HMACSHA)(a.APISecret))
+	require.NoError(t, err)
+	_, err = a.AuthVerify(ctx, string(expired))
+	require.Error(t, err)
+
+	// tokens created with AuthNew aren't stored
+	legacy, err := a.AuthNew(ctx, api.AllPermissions)
+	require.NoError(t, err)
+	perms, err = a.AuthVerify(ctx, string(legacy))
+	require.NoError(t, err)
+	require.Equal(t, api.AllPermissions, perms)
+}
@@ -26,6 +26,7 @@ type FullNodeAPI struct {
 	net.NetAPI
 	full.ChainAPI
 	client.API
+	client.DealHealthAPI
 	full.MpoolAPI
 	full.GasAPI
 	market.MarketAPI
@@ -34,9 +35,12 @@ type FullNodeAPI struct {
 	full.MsigAPI
 	full.WalletAPI
 	full.SyncAPI
+	full.WatchlistAPI
+	full.HealthAPI
 
 	DS          dtypes.MetadataDS
 	NetworkName dtypes.NetworkName
+	ReadOnly    dtypes.ReadOnlyAPI `optional:"true"`
 }
 
 func (n *FullNodeAPI) CreateBackup(ctx context.Context, fpath string) error {
@@ -6,6 +6,7 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -29,8 +30,17 @@ import (
 
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/blockstore/gcsched"
+	"github.com/filecoin-project/lotus/blockstore/scrub"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain"
+	"github.com/filecoin-project/lotus/chain/gen/slashfilter/slashsvc"
+	"github.com/filecoin-project/lotus/chain/index"
+	"github.com/filecoin-project/lotus/chain/snapshotsched"
+	"github.com/filecoin-project/lotus/chain/state"
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/sub/propstats"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/chain/vm"
 	"github.com/filecoin-project/lotus/lib/oldpath"
@@ -87,12 +97,112 @@ type ChainAPI struct {
 
 	// BaseBlockstore is the underlying blockstore
 	BaseBlockstore dtypes.BaseBlockstore
+
+	BlockstoreGC *gcsched.Scheduler
+	Scrubber     *scrub.Scrubber
+	BlockCache   *blockstore.BlockCache `optional:"true"`
+	CarMounts    *blockstore.CarMounts
+
+	Syncer  *chain.Syncer
+	Bitswap dtypes.ChainBitswap
+	Index   *index.Index `optional:"true"`
+
+	Snapshots *snapshotsched.Scheduler `optional:"true"`
+	PropStats *propstats.Tracker       `optional:"true"`
+
+	FaultReporter *slashsvc.Reporter `optional:"true"`
 }
 
 func (m *ChainModule) ChainNotify(ctx context.Context) (<-chan []*api.HeadChange, error) {
 	return m.Chain.SubHeadChanges(ctx), nil
 }
 
+func (a *ChainAPI) ChainNotifyDetailed(ctx context.Context) (<-chan []*api.DetailedHeadChange, error) {
+	sub := a.Chain.SubHeadChanges(ctx)
+
+	out := make(chan []*api.DetailedHeadChange, 16)
+	go func() {
+		defer close(out)
+
+		for changes := range sub {
+			detailed := make([]*api.DetailedHeadChange, 0, len(changes))
+			for _, hc := range changes {
+				d := &api.DetailedHeadChange{HeadChange: *hc}
+				if hc.Type != store.HCCurrent {
+					if err := a.headChangeEffects(ctx, d); err != nil {
+						log.Warnw("computing head change effects", "tipset", hc.Val.Key(), "height", hc.Val.Height(), "error", err)
+						d.Error = err.Error()
+					}
+				}
+				detailed = append(detailed, d)
+			}
+
+			select {
+			case out <- detailed:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// headChangeEffects fills in the messages executed and the actors changed by the
+// state transition from the parent state of the tipset's parent to the parent
+// state of the tipset
+func (a *ChainAPI) headChangeEffects(ctx context.Context, d *api.DetailedHeadChange) error {
+	ts := d.Val
+	if ts.Height() == 0 {
+		return nil
+	}
+
+	pts, err := a.Chain.LoadTipSet(ctx, ts.Parents())
+	if err != nil {
+		return xerrors.Errorf("loading parent tipset: %w", err)
+	}
+
+	msgs, err := a.Chain.MessagesForTipset(ctx, pts)
+	if err != nil {
+		return xerrors.Errorf("loading parent messages: %w", err)
+	}
+	for _, m := range msgs {
+		d.Messages = append(d.Messages, m.Cid())
+	}
+
+	if pts.ParentState() == ts.ParentState() {
+		return nil
+	}
+
+	cst := a.Chain.ActorStore(ctx)
+	oldTree, err := state.LoadStateTree(cst, pts.ParentState())
+	if err != nil {
+		return xerrors.Errorf("loading parent state tree: %w", err)
+	}
+	newTree, err := state.LoadStateTree(cst, ts.ParentState())
+	if err != nil {
+		return xerrors.Errorf("loading state tree: %w", err)
+	}
+
+	changed, err := state.Diff(ctx, oldTree, newTree)
+	if err != nil {
+		return xerrors.Errorf("diffing state trees: %w", err)
+	}
+
+	for s := range changed {
+		addr, err := address.NewFromString(s)
+		if err != nil {
+			return xerrors.Errorf("parsing changed actor address %q: %w", s, err)
+		}
+		d.Actors = append(d.Actors, addr)
+	}
+	sort.Slice(d.Actors, func(i, j int) bool {
+		return d.Actors[i].String() < d.Actors[j].String()
+	})
+
+	return nil
+}
+
 func (m *ChainModule) ChainHead(context.Context) (*types.TipSet, error) {
 	return m.Chain.GetHeaviestTipSet(), nil
 }
@@ -109,6 +219,27 @@ func (m *ChainModule) ChainGetPath(ctx context.Context, from, to types.TipSetKey
 	return m.Chain.GetPath(ctx, from, to)
 }
 
+func (a *ChainAPI) ChainIndexActorMessages(ctx context.Context, addr address.Address, from, to abi.ChainEpoch, limit int) ([]api.IndexedMessage, error) {
+	if a.Index == nil {
+		return nil, xerrors.Errorf("chain index not enabled")
+	}
+
+	infos, err := a.Index.ActorMessages(ctx, addr, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]api.IndexedMessage, len(infos))
+	for i, info := range infos {
+		out[i] = api.IndexedMessage{
+			Cid:    info.Message,
+			TipSet: info.TipSet,
+			Height: info.Height,
+		}
+	}
+	return out, nil
+}
+
 func (m *ChainModule) ChainGetBlockMessages(ctx context.Context, msg cid.Cid) (*api.BlockMessages, error) {
 	b, err := m.Chain.GetBlock(ctx, msg)
 	if err != nil {
@@ -592,12 +723,60 @@ func (a *ChainAPI) ChainExport(ctx context.Context, nroots abi.ChainEpoch, skipo
 	if err != nil {
 		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
 	}
+
+	return exportStream(ctx, func(w io.Writer) error {
+		return a.Chain.Export(ctx, ts, nroots, skipoldmsgs, w)
+	}), nil
+}
+
+func (a *ChainAPI) ChainExportLatestState(ctx context.Context, nheaders abi.ChainEpoch, tsk types.TipSetKey) (<-chan []byte, error) {
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+	if nheaders < build.Finality {
+		return nil, xerrors.Errorf("the snapshot must include the headers of at least %d epochs", build.Finality)
+	}
+
+	return exportStream(ctx, func(w io.Writer) error {
+		return a.Chain.ExportLatestState(ctx, ts, nheaders, build.Finality, w)
+	}), nil
+}
+
+func (a *ChainAPI) ChainExportRange(ctx context.Context, from, to abi.ChainEpoch, tsk types.TipSetKey, opts api.ChainExportRangeOpts) (<-chan []byte, error) {
+	head, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+	if to < from {
+		return nil, xerrors.Errorf("invalid range [%d, %d]", from, to)
+	}
+	if to > head.Height() {
+		return nil, xerrors.Errorf("range end %d is above the tipset at height %d", to, head.Height())
+	}
+
+	ts, err := a.Chain.GetTipsetByHeight(ctx, to, head, true)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset at height %d: %w", to, err)
+	}
+	if ts.Height() < from {
+		return nil, xerrors.Errorf("no tipsets in the range [%d, %d]", from, to)
+	}
+
+	return exportStream(ctx, func(w io.Writer) error {
+		return a.Chain.ExportRange(ctx, ts, from, opts, w)
+	}), nil
+}
+
+// exportStream streams the CAR written by export, ending the stream with an
+// empty slice once the whole CAR has been written.
+func exportStream(ctx context.Context, export func(w io.Writer) error) <-chan []byte {
 	r, w := io.Pipe()
 	out := make(chan []byte)
 	go func() {
 		bw := bufio.NewWriterSize(w, 1<<20)
 
-		err := a.Chain.Export(ctx, ts, nroots, skipoldmsgs, bw)
+		err := export(bw)
 		bw.Flush()            //nolint:errcheck // it is a write to a pipe
 		w.CloseWithError(err) //nolint:errcheck // it is a pipe
 	}()
@@ -633,7 +812,7 @@ func (a *ChainAPI) ChainExport(ctx context.Context, nroots abi.ChainEpoch, skipo
 		}
 	}()
 
-	return out, nil
+	return out
 }
 
 func (a *ChainAPI) ChainCheckBlockstore(ctx context.Context) error {
@@ -653,3 +832,167 @@ func (a *ChainAPI) ChainBlockstoreInfo(ctx context.Context) (map[string]interfac
 
 	return info.Info(), nil
 }
+
+func (a *ChainAPI) ChainBlockstoreGC(ctx context.Context, opts api.BlockstoreGCOpts) error {
+	return a.BlockstoreGC.Run(opts)
+}
+
+func (a *ChainAPI) ChainBlockstoreGCStatus(ctx context.Context) (api.BlockstoreGCStatus, error) {
+	return a.BlockstoreGC.Status(), nil
+}
+
+func (a *ChainAPI) ChainSnapshotStatus(ctx context.Context) (api.ChainSnapshotStatus, error) {
+	if a.Snapshots == nil {
+		return api.ChainSnapshotStatus{}, xerrors.Errorf("scheduled snapshots not enabled")
+	}
+
+	return a.Snapshots.Status(), nil
+}
+
+func (a *ChainAPI) ChainConsensusFaults(ctx context.Context) ([]api.ConsensusFault, error) {
+	if a.FaultReporter == nil {
+		return nil, xerrors.Errorf("consensus fault reporter not enabled")
+	}
+
+	return a.FaultReporter.Faults(ctx)
+}
+
+func (a *ChainAPI) ChainConsensusFaultSubmit(ctx context.Context, block cid.Cid) (cid.Cid, error) {
+	if a.FaultReporter == nil {
+		return cid.Undef, xerrors.Errorf("consensus fault reporter not enabled")
+	}
+
+	return a.FaultReporter.Submit(ctx, block)
+}
+
+func (a *ChainAPI) ChainConsensusFaultDismiss(ctx context.Context, block cid.Cid) error {
+	if a.FaultReporter == nil {
+		return xerrors.Errorf("consensus fault reporter not enabled")
+	}
+
+	return a.FaultReporter.Dismiss(ctx, block)
+}
+
+func (a *ChainAPI) ChainBlockPropagation(ctx context.Context, from abi.ChainEpoch) ([]api.BlockPropagation, error) {
+	if a.PropStats == nil {
+		return nil, xerrors.Errorf("block propagation is only tracked by full nodes")
+	}
+
+	blocks := a.PropStats.Blocks(from)
+
+	head := a.Chain.GetHeaviestTipSet()
+	canonical := make(map[abi.ChainEpoch]*types.TipSet)
+	for i, b := range blocks {
+		if b.Height >= head.Height() {
+			continue
+		}
+
+		ts, ok := canonical[b.Height]
+		if !ok {
+			var err error
+			ts, err = a.Chain.GetTipsetByHeight(ctx, b.Height, head, true)
+			if err != nil {
+				return nil, xerrors.Errorf("loading tipset at height %d: %w", b.Height, err)
+			}
+			canonical[b.Height] = ts
+		}
+
+		blocks[i].Orphaned = true
+		if ts.Height() == b.Height {
+			for _, c := range ts.Cids() {
+				if c == b.Cid {
+					blocks[i].Orphaned = false
+					break
+				}
+			}
+		}
+	}
+
+	return blocks, nil
+}
+
+func (a *ChainAPI) ChainBlockstoreScrub(ctx context.Context) error {
+	return a.Scrubber.Run()
+}
+
+func (a *ChainAPI) ChainBlockstoreScrubStatus(ctx context.Context) (api.BlockstoreScrubStatus, error) {
+	return a.Scrubber.Status(), nil
+}
+
+func (a *ChainAPI) ChainBlockstoreQuarantine(ctx context.Context) ([]cid.Cid, error) {
+	return a.Scrubber.Quarantined(ctx)
+}
+
+func (a *ChainAPI) ChainBlockstoreCacheFlush(ctx context.Context) error {
+	if a.BlockCache == nil {
+		return xerrors.Errorf("block cache not enabled")
+	}
+
+	a.BlockCache.Flush()
+	return nil
+}
+
+func (a *ChainAPI) ChainBlockstoreMount(ctx context.Context, name string, path string) (api.BlockstoreMount, error) {
+	mnt, err := a.CarMounts.Mount(name, path)
+	if err != nil {
+		return api.BlockstoreMount{}, err
+	}
+
+	return toAPIMount(mnt), nil
+}
+
+func (a *ChainAPI) ChainBlockstoreUnmount(ctx context.Context, name string) error {
+	return a.CarMounts.Unmount(name)
+}
+
+func (a *ChainAPI) ChainBlockstoreMounts(ctx context.Context) ([]api.BlockstoreMount, error) {
+	mnts := a.CarMounts.List()
+
+	out := make([]api.BlockstoreMount, len(mnts))
+	for i, mnt := range mnts {
+		out[i] = toAPIMount(mnt)
+	}
+	return out, nil
+}
+
+func (a *ChainAPI) ChainBackfill(ctx context.Context, spec api.BackfillSpec) (<-chan api.BackfillProgress, error) {
+	if spec.From > spec.To {
+		return nil, xerrors.Errorf("invalid range: from (%d) is above to (%d)", spec.From, spec.To)
+	}
+
+	out := make(chan api.BackfillProgress)
+	go func() {
+		defer close(out)
+
+		send := func(p api.BackfillProgress) {
+			select {
+			case out <- p:
+			case <-ctx.Done():
+			}
+		}
+
+		var last api.BackfillProgress
+		err := a.Syncer.Backfill(ctx, spec, a.Bitswap, func(p api.BackfillProgress) {
+			last = p
+			send(p)
+		})
+
+		last.Done = true
+		if err != nil {
+			log.Warnw("chain backfill failed", "from", spec.From, "to", spec.To, "error", err)
+			last.Error = err.Error()
+		}
+		send(last)
+	}()
+
+	return out, nil
+}
+
+func toAPIMount(mnt blockstore.CarMount) api.BlockstoreMount {
+	return api.BlockstoreMount{
+		Name:      mnt.Name,
+		Path:      mnt.Path,
+		Roots:     mnt.Roots,
+		MountedAt: mnt.MountedAt,
+	}
+}
@@ -0,0 +1,167 @@
+package full
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/host"
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore/gcsched"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/messagepool"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/node/repo"
+	"github.com/filecoin-project/lotus/storage/sealer/fsutil"
+)
+
+// Thresholds of the health checks
+const (
+	// epochs the head is behind the wall clock
+	SyncLagWarn = 5
+	SyncLagCrit = 20
+
+	// epochs a local message has been pending inclusion
+	MpoolStuckWarn = 10
+	MpoolStuckCrit = 120
+
+	// fraction of the repo filesystem available
+	DiskAvailableWarn = 0.1
+	DiskAvailableCrit = 0.03
+
+	// time since the last run of a scheduled blockstore GC
+	GCBacklogWarn = 48 * time.Hour
+
+	// connected peers
+	PeersWarn = 5
+)
+
+type HealthAPI struct {
+	fx.In
+
+	Chain        *store.ChainStore
+	Mpool        *messagepool.MessagePool `optional:"true"`
+	Repo         repo.LockedRepo
+	BlockstoreGC *gcsched.Scheduler
+	Host         host.Host
+}
+
+func (a *HealthAPI) NodeHealth(ctx context.Context) (api.NodeHealth, error) {
+	h := api.NodeHealth{
+		Sync:  SyncHealth(a.Chain.GetHeaviestTipSet()),
+		Peers: PeersHealth(len(a.Host.Network().Peers())),
+	}
+
+	if a.Mpool != nil {
+		h.Mpool = mpoolHealth(a.Mpool.PendingLocalPushes())
+	}
+
+	bh, err := a.blockstoreHealth()
+	if err != nil {
+		return api.NodeHealth{}, xerrors.Errorf("checking blockstore health: %w", err)
+	}
+	h.Blockstore = bh
+
+	h.Compute()
+	return h, nil
+}
+
+func (a *HealthAPI) blockstoreHealth() (*api.BlockstoreHealth, error) {
+	st, err := fsutil.Statfs(a.Repo.Path())
+	if err != nil {
+		return nil, err
+	}
+
+	gc := a.BlockstoreGC.Status()
+	h := &api.BlockstoreHealth{
+		HealthStatus: api.HealthStatus{Level: api.HealthOK},
+		Available:    st.FSAvailable,
+		Capacity:     st.Capacity,
+		LastGC:       gc.End,
+		GCError:      gc.Error,
+	}
+
+	var avail float64
+	if st.Capacity > 0 {
+		avail = float64(st.FSAvailable) / float64(st.Capacity)
+	}
+	switch {
+	case avail < DiskAvailableCrit:
+		h.HealthStatus = api.HealthStatus{Level: api.HealthCrit, Message: fmt.Sprintf("%.1f%% of the repo filesystem available", avail*100)}
+	case avail < DiskAvailableWarn:
+		h.HealthStatus = api.HealthStatus{Level: api.HealthWarn, Message: fmt.Sprintf("%.1f%% of the repo filesystem available", avail*100)}
+	case gc.Error != "":
+		h.HealthStatus = api.HealthStatus{Level: api.HealthWarn, Message: "last blockstore GC failed: " + gc.Error}
+	case gc.Scheduled && !gc.Running && !gc.End.IsZero() && build.Clock.Since(gc.End) > GCBacklogWarn:
+		h.HealthStatus = api.HealthStatus{Level: api.HealthWarn, Message: fmt.Sprintf("no blockstore GC run since %s", gc.End.Format(time.RFC3339))}
+	}
+	return h, nil
+}
+
+// SyncHealth returns the health of the sync, from the lag of the head behind
+// the wall clock
+func SyncHealth(head *types.TipSet) *api.SyncHealth {
+	lag := int64(build.Clock.Since(time.Unix(int64(head.MinTimestamp()), 0)) / (time.Duration(build.BlockDelaySecs) * time.Second))
+	if lag < 0 {
+		lag = 0
+	}
+
+	h := &api.SyncHealth{
+		HealthStatus: api.HealthStatus{Level: api.HealthOK},
+		Height:       head.Height(),
+		LagEpochs:    lag,
+	}
+	switch {
+	case lag >= SyncLagCrit:
+		h.HealthStatus = api.HealthStatus{Level: api.HealthCrit, Message: fmt.Sprintf("head is %d epochs behind", lag)}
+	case lag >= SyncLagWarn:
+		h.HealthStatus = api.HealthStatus{Level: api.HealthWarn, Message: fmt.Sprintf("head is %d epochs behind", lag)}
+	}
+	return h
+}
+
+// PeersHealth returns the health of the connectivity, from the number of
+// connected peers
+func PeersHealth(connected int) *api.PeersHealth {
+	h := &api.PeersHealth{
+		HealthStatus: api.HealthStatus{Level: api.HealthOK},
+		Connected:    connected,
+	}
+	switch {
+	case connected == 0:
+		h.HealthStatus = api.HealthStatus{Level: api.HealthCrit, Message: "no connected peers"}
+	case connected < PeersWarn:
+		h.HealthStatus = api.HealthStatus{Level: api.HealthWarn, Message: fmt.Sprintf("only %d connected peers", connected)}
+	}
+	return h
+}
+
+func mpoolHealth(pushes []time.Time) *api.MpoolHealth {
+	epoch := time.Duration(build.BlockDelaySecs) * time.Second
+
+	h := &api.MpoolHealth{
+		HealthStatus: api.HealthStatus{Level: api.HealthOK},
+		LocalPending: len(pushes),
+	}
+	for _, p := range pushes {
+		age := build.Clock.Since(p)
+		if age > h.OldestLocal {
+			h.OldestLocal = age
+		}
+		if age > MpoolStuckWarn*epoch {
+			h.StuckLocal++
+		}
+	}
+
+	switch {
+	case h.OldestLocal > MpoolStuckCrit*epoch:
+		h.HealthStatus = api.HealthStatus{Level: api.HealthCrit, Message: fmt.Sprintf("local message pending for %s", h.OldestLocal.Round(time.Second))}
+	case h.StuckLocal > 0:
+		h.HealthStatus = api.HealthStatus{Level: api.HealthWarn, Message: fmt.Sprintf("%d local messages pending for over %d epochs", h.StuckLocal, MpoolStuckWarn)}
+	}
+	return h
+}
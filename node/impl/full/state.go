@@ -21,6 +21,7 @@ import (
 	"github.com/filecoin-project/go-state-types/cbor"
 	"github.com/filecoin-project/go-state-types/crypto"
 	"github.com/filecoin-project/go-state-types/dline"
+	"github.com/filecoin-project/go-state-types/exitcode"
 	"github.com/filecoin-project/go-state-types/network"
 	market2 "github.com/filecoin-project/specs-actors/v2/actors/builtin/market"
 	market5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/market"
@@ -79,6 +80,8 @@ type StateModule struct {
 
 	StateManager *stmgr.StateManager
 	Chain        *store.ChainStore
+
+	WaitConfig dtypes.MessageWaitConfig `optional:"true"`
 }
 
 var _ StateModuleAPI = (*StateModule)(nil)
@@ -589,7 +592,27 @@ func (a *StateAPI) MinerCreateBlock(ctx context.Context, bt *api.BlockTemplate)
 	return &out, nil
 }
 
+// waitParams resolves the api.ConfidenceDefault and api.LookbackFinality
+// placeholders to the values configured on the node
+func (m *StateModule) waitParams(confidence uint64, lookbackLimit abi.ChainEpoch) (uint64, abi.ChainEpoch) {
+	if confidence == api.ConfidenceDefault {
+		confidence = build.MessageConfidence
+		if m.WaitConfig.Confidence > 0 {
+			confidence = m.WaitConfig.Confidence
+		}
+	}
+	if lookbackLimit == api.LookbackFinality {
+		lookbackLimit = build.Finality
+		if m.WaitConfig.Finality > 0 {
+			lookbackLimit = m.WaitConfig.Finality
+		}
+	}
+	return confidence, lookbackLimit
+}
+
 func (m *StateModule) StateWaitMsg(ctx context.Context, msg cid.Cid, confidence uint64, lookbackLimit abi.ChainEpoch, allowReplaced bool) (*api.MsgLookup, error) {
+	confidence, lookbackLimit = m.waitParams(confidence, lookbackLimit)
+
 	ts, recpt, found, err := m.StateManager.WaitForMessage(ctx, msg, confidence, lookbackLimit, allowReplaced)
 	if err != nil {
 		return nil, err
@@ -631,6 +654,8 @@ func (m *StateModule) StateSearchMsg(ctx context.Context, tsk types.TipSetKey, m
 		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
 	}
 
+	_, lookbackLimit = m.waitParams(0, lookbackLimit)
+
 	ts, recpt, found, err := m.StateManager.SearchForMessage(ctx, fromTs, msg, lookbackLimit, allowReplaced)
 	if err != nil {
 		return nil, err
@@ -1151,6 +1176,211 @@ func (m *StateModule) MsigGetPending(ctx context.Context, addr address.Address,
 	return out, nil
 }
 
+// maxMsigUnlocks caps the number of points of the unlock projection of
+// MsigGetReport, the step is raised as needed
+const maxMsigUnlocks = 1000
+
+func (a *StateAPI) MsigGetReport(ctx context.Context, addr address.Address, lookback abi.ChainEpoch, unlockStep abi.ChainEpoch, tsk types.TipSetKey) (*api.MsigReport, error) {
+	ts, err := a.Chain.GetTipSetFromKey(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+
+	idAddr, err := a.StateManager.LookupID(ctx, addr, ts)
+	if err != nil {
+		return nil, xerrors.Errorf("looking up multisig ID: %w", err)
+	}
+	act, err := a.StateManager.LoadActor(ctx, idAddr, ts)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load multisig actor: %w", err)
+	}
+	msas, err := multisig.Load(a.Chain.ActorStore(ctx), act)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load multisig actor state: %w", err)
+	}
+
+	rep := &api.MsigReport{
+		Address: addr,
+		ID:      idAddr,
+		Height:  ts.Height(),
+		Balance: act.Balance,
+	}
+
+	rep.Locked, err = msas.LockedBalance(ts.Height())
+	if err != nil {
+		return nil, xerrors.Errorf("failed to compute locked multisig balance: %w", err)
+	}
+	rep.Spendable = types.BigSub(act.Balance, rep.Locked)
+
+	if rep.Vesting.InitialBalance, err = msas.InitialBalance(); err != nil {
+		return nil, xerrors.Errorf("failed to load multisig initial balance: %w", err)
+	}
+	if rep.Vesting.StartEpoch, err = msas.StartEpoch(); err != nil {
+		return nil, xerrors.Errorf("failed to load multisig start epoch: %w", err)
+	}
+	if rep.Vesting.UnlockDuration, err = msas.UnlockDuration(); err != nil {
+		return nil, xerrors.Errorf("failed to load multisig unlock duration: %w", err)
+	}
+	if rep.Threshold, err = msas.Threshold(); err != nil {
+		return nil, xerrors.Errorf("failed to load multisig threshold: %w", err)
+	}
+	if rep.Signers, err = msas.Signers(); err != nil {
+		return nil, xerrors.Errorf("failed to load multisig signers: %w", err)
+	}
+
+	rep.Pending, err = a.MsigGetPending(ctx, idAddr, ts.Key())
+	if err != nil {
+		return nil, err
+	}
+
+	rep.Executed, err = a.msigExecuted(ctx, addr, idAddr, ts, lookback)
+	if err != nil {
+		return nil, xerrors.Errorf("loading executed transactions: %w", err)
+	}
+
+	// Project the unlocks, assuming no further spending
+	end := rep.Vesting.StartEpoch + rep.Vesting.UnlockDuration
+	if unlockStep <= 0 {
+		unlockStep = builtin.EpochsInDay
+	}
+	if (end-ts.Height())/unlockStep > maxMsigUnlocks {
+		unlockStep = (end - ts.Height()) / maxMsigUnlocks
+	}
+	rep.Unlocks = []api.MsigUnlock{}
+	for epoch := ts.Height() + unlockStep; epoch-unlockStep < end; epoch += unlockStep {
+		if epoch > end {
+			epoch = end
+		}
+		locked, err := msas.LockedBalance(epoch)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to compute locked multisig balance at %d: %w", epoch, err)
+		}
+		rep.Unlocks = append(rep.Unlocks, api.MsigUnlock{
+			Epoch:     epoch,
+			Locked:    locked,
+			Spendable: types.BigSub(act.Balance, locked),
+		})
+	}
+
+	return rep, nil
+}
+
+// msigExecuted returns the transactions executed by the multisig in the
+// lookback period, newest first
+func (a *StateAPI) msigExecuted(ctx context.Context, addr, idAddr address.Address, ts *types.TipSet, lookback abi.ChainEpoch) ([]api.MsigExecutedTransaction, error) {
+	robust := addr
+	if addr.Protocol() == address.ID {
+		robust, _ = a.StateLookupRobustAddress(ctx, idAddr, ts.Key())
+	}
+
+	out := []api.MsigExecutedTransaction{}
+
+	// messages of a tipset are executed in its child, which holds the receipts
+	child := ts
+	for child.Height() > 0 && child.Height() > ts.Height()-lookback {
+		parent, err := a.Chain.LoadTipSet(ctx, child.Parents())
+		if err != nil {
+			return nil, xerrors.Errorf("loading parent tipset: %w", err)
+		}
+
+		msgs, err := a.Chain.MessagesForTipset(ctx, parent)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to get messages for tipset (%s): %w", parent.Key(), err)
+		}
+
+		for i := len(msgs) - 1; i >= 0; i-- {
+			msg := msgs[i].VMMessage()
+			if msg.To != idAddr && msg.To != robust {
+				continue
+			}
+			if msg.Method != multisig.Methods.Propose && msg.Method != multisig.Methods.Approve {
+				continue
+			}
+
+			rct, err := a.Chain.GetParentReceipt(ctx, child.Blocks()[0], i)
+			if err != nil {
+				return nil, xerrors.Errorf("loading receipt of %s: %w", msgs[i].Cid(), err)
+			}
+			if rct.ExitCode != exitcode.Ok {
+				continue
+			}
+
+			tx, ok, err := a.msigExecutedTxn(ctx, idAddr, parent, msg, rct)
+			if err != nil {
+				return nil, xerrors.Errorf("decoding %s: %w", msgs[i].Cid(), err)
+			}
+			if !ok {
+				continue
+			}
+			tx.Message = msgs[i].Cid()
+			tx.Approver = msg.From
+			tx.Epoch = parent.Height()
+			out = append(out, tx)
+		}
+
+		child = parent
+	}
+
+	return out, nil
+}
+
+// msigExecutedTxn decodes the transaction executed by a successful proposal
+// or approval, returning false if it only recorded an approval
+func (a *StateAPI) msigExecutedTxn(ctx context.Context, idAddr address.Address, ts *types.TipSet, msg *types.Message, rct *types.MessageReceipt) (api.MsigExecutedTransaction, bool, error) {
+	var tx api.MsigExecutedTransaction
+
+	if msg.Method == multisig.Methods.Propose {
+		var params multisig.ProposeParams
+		if err := params.UnmarshalCBOR(bytes.NewReader(msg.Params)); err != nil {
+			return tx, false, xerrors.Errorf("decoding propose params: %w", err)
+		}
+		var ret multisig.ProposeReturn
+		if err := ret.UnmarshalCBOR(bytes.NewReader(rct.Return)); err != nil {
+			return tx, false, xerrors.Errorf("decoding propose return: %w", err)
+		}
+		if !ret.Applied {
+			return tx, false, nil
+		}
+
+		tx.ID = int64(ret.TxnID)
+		tx.To, tx.Value, tx.Method, tx.Params = params.To, params.Value, params.Method, params.Params
+		tx.ExitCode = ret.Code
+	} else {
+		var params multisig.TxnIDParams
+		if err := params.UnmarshalCBOR(bytes.NewReader(msg.Params)); err != nil {
+			return tx, false, xerrors.Errorf("decoding approve params: %w", err)
+		}
+		var ret multisig.ApproveReturn
+		if err := ret.UnmarshalCBOR(bytes.NewReader(rct.Return)); err != nil {
+			return tx, false, xerrors.Errorf("decoding approve return: %w", err)
+		}
+		if !ret.Applied {
+			return tx, false, nil
+		}
+
+		// The transaction was pending in the state the approval applied to
+		pending, err := a.MsigGetPending(ctx, idAddr, ts.Key())
+		if err != nil {
+			return tx, false, err
+		}
+		tx.ID = int64(params.ID)
+		for _, p := range pending {
+			if p.ID == tx.ID {
+				tx.To, tx.Value, tx.Method, tx.Params = p.To, p.Value, p.Method, p.Params
+			}
+		}
+		tx.ExitCode = ret.Code
+	}
+
+	if tx.Method != 0 && tx.To != address.Undef {
+		if dp, err := a.StateDecodeParams(ctx, tx.To, tx.Method, tx.Params, ts.Key()); err == nil {
+			tx.DecodedParams, _ = json.Marshal(dp)
+		}
+	}
+
+	return tx, true, nil
+}
+
 var initialPledgeNum = types.NewInt(110)
 var initialPledgeDen = types.NewInt(100)
 
@@ -0,0 +1,32 @@
+//stm: #unit
+package full
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+func TestWaitParams(t *testing.T) {
+	var m StateModule
+
+	conf, limit := m.waitParams(api.ConfidenceDefault, api.LookbackFinality)
+	require.Equal(t, build.MessageConfidence, conf)
+	require.Equal(t, build.Finality, limit)
+
+	m.WaitConfig = dtypes.MessageWaitConfig{Confidence: 1, Finality: 20}
+
+	conf, limit = m.waitParams(api.ConfidenceDefault, api.LookbackFinality)
+	require.Equal(t, uint64(1), conf)
+	require.Equal(t, abi.ChainEpoch(20), limit)
+
+	conf, limit = m.waitParams(3, api.LookbackNoLimit)
+	require.Equal(t, uint64(3), conf)
+	require.Equal(t, api.LookbackNoLimit, limit)
+}
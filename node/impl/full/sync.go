@@ -12,6 +12,7 @@ import (
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain"
+	"github.com/filecoin-project/lotus/chain/checkpoint"
 	"github.com/filecoin-project/lotus/chain/gen/slashfilter"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/chain/vm"
@@ -21,10 +22,11 @@ import (
 type SyncAPI struct {
 	fx.In
 
-	SlashFilter *slashfilter.SlashFilter `optional:"true"`
-	Syncer      *chain.Syncer
-	PubSub      *pubsub.PubSub
-	NetName     dtypes.NetworkName
+	SlashFilter    *slashfilter.SlashFilter `optional:"true"`
+	Syncer         *chain.Syncer
+	PubSub         *pubsub.PubSub
+	NetName        dtypes.NetworkName
+	CheckpointFeed *checkpoint.Feed `optional:"true"`
 }
 
 func (a *SyncAPI) SyncState(ctx context.Context) (*api.SyncState, error) {
@@ -45,11 +47,16 @@ func (a *SyncAPI) SyncState(ctx context.Context) (*api.SyncState, error) {
 			Start:    ss.Start,
 			End:      ss.End,
 			Message:  ss.Message,
+			Timings:  ss.Timings,
 		})
 	}
 	return out, nil
 }
 
+func (a *SyncAPI) SyncSubscribe(ctx context.Context) (<-chan api.SyncProgress, error) {
+	return a.Syncer.SubscribeProgress(ctx)
+}
+
 func (a *SyncAPI) SyncSubmitBlock(ctx context.Context, blk *types.BlockMsg) error {
 	parent, err := a.Syncer.ChainStore().GetBlock(ctx, blk.Header.Parents[0])
 	if err != nil {
@@ -105,10 +112,33 @@ func (a *SyncAPI) SyncIncomingBlocks(ctx context.Context) (<-chan *types.BlockHe
 }
 
 func (a *SyncAPI) SyncCheckpoint(ctx context.Context, tsk types.TipSetKey) error {
+	if a.CheckpointFeed != nil {
+		if err := a.CheckpointFeed.CheckDescendant(ctx, tsk); err != nil {
+			return xerrors.Errorf("refusing manual checkpoint: %w", err)
+		}
+	}
+
 	log.Warnf("Marking tipset %s as checkpoint", tsk)
 	return a.Syncer.SyncCheckpoint(ctx, tsk)
 }
 
+func (a *SyncAPI) SyncCheckpointSigned(ctx context.Context, cp *api.SignedCheckpoint) error {
+	if a.CheckpointFeed == nil {
+		return xerrors.Errorf("no checkpoint authorities configured")
+	}
+
+	log.Warnf("Applying signed checkpoint of tipset %s at height %d", cp.TipSet, cp.Height)
+	return a.CheckpointFeed.Apply(ctx, cp)
+}
+
+func (a *SyncAPI) SyncCheckpointFeedStatus(ctx context.Context) (api.CheckpointFeedStatus, error) {
+	if a.CheckpointFeed == nil {
+		return api.CheckpointFeedStatus{}, nil
+	}
+
+	return a.CheckpointFeed.Status(), nil
+}
+
 func (a *SyncAPI) SyncMarkBad(ctx context.Context, bcid cid.Cid) error {
 	log.Warnf("Marking block %s as bad", bcid)
 	a.Syncer.MarkBad(bcid)
@@ -154,3 +184,7 @@ func (a *SyncAPI) SyncValidateTipset(ctx context.Context, tsk types.TipSetKey) (
 
 	return true, nil
 }
+
+func (a *SyncAPI) SyncForks(ctx context.Context) (api.ForkStatus, error) {
+	return a.Syncer.Forks(ctx)
+}
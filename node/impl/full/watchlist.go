@@ -0,0 +1,30 @@
+package full
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/watchlist"
+)
+
+type WatchlistAPI struct {
+	fx.In
+
+	Watchlist *watchlist.Watchlist
+}
+
+func (a *WatchlistAPI) WatchlistAdd(ctx context.Context, entry api.WatchlistEntry) error {
+	return a.Watchlist.Add(ctx, entry)
+}
+
+func (a *WatchlistAPI) WatchlistRemove(ctx context.Context, addr address.Address) error {
+	return a.Watchlist.Remove(ctx, addr)
+}
+
+func (a *WatchlistAPI) WatchlistList(ctx context.Context) ([]api.WatchlistStatus, error) {
+	return a.Watchlist.List(), nil
+}
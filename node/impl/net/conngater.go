@@ -133,3 +133,84 @@ func (a *NetAPI) NetBlockList(ctx context.Context) (result api.NetBlockList, err
 	}
 	return
 }
+
+func (a *NetAPI) NetAllowAdd(ctx context.Context, acl api.NetBlockList) error {
+	for _, p := range acl.Peers {
+		err := a.ACL.AllowPeer(p)
+		if err != nil {
+			return xerrors.Errorf("error allowing peer %s: %w", p, err)
+		}
+	}
+
+	for _, addr := range acl.IPAddrs {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return xerrors.Errorf("error parsing IP address %s", addr)
+		}
+
+		err := a.ACL.AllowAddr(ip)
+		if err != nil {
+			return xerrors.Errorf("error allowing IP address %s: %w", addr, err)
+		}
+	}
+
+	for _, subnet := range acl.IPSubnets {
+		_, cidr, err := net.ParseCIDR(subnet)
+		if err != nil {
+			return xerrors.Errorf("error parsing subnet %s: %w", subnet, err)
+		}
+
+		err = a.ACL.AllowSubnet(cidr)
+		if err != nil {
+			return xerrors.Errorf("error allowing subnet %s: %w", subnet, err)
+		}
+	}
+
+	return nil
+}
+
+func (a *NetAPI) NetAllowRemove(ctx context.Context, acl api.NetBlockList) error {
+	for _, p := range acl.Peers {
+		err := a.ACL.DisallowPeer(p)
+		if err != nil {
+			return xerrors.Errorf("error disallowing peer %s: %w", p, err)
+		}
+	}
+
+	for _, addr := range acl.IPAddrs {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return xerrors.Errorf("error parsing IP address %s", addr)
+		}
+
+		err := a.ACL.DisallowAddr(ip)
+		if err != nil {
+			return xerrors.Errorf("error disallowing IP address %s: %w", addr, err)
+		}
+	}
+
+	for _, subnet := range acl.IPSubnets {
+		_, cidr, err := net.ParseCIDR(subnet)
+		if err != nil {
+			return xerrors.Errorf("error parsing subnet %s: %w", subnet, err)
+		}
+
+		err = a.ACL.DisallowSubnet(cidr)
+		if err != nil {
+			return xerrors.Errorf("error disallowing subnet %s: %w", subnet, err)
+		}
+	}
+
+	return nil
+}
+
+func (a *NetAPI) NetAllowList(ctx context.Context) (result api.NetBlockList, err error) {
+	result.Peers = a.ACL.AllowedPeers()
+	for _, ip := range a.ACL.AllowedAddrs() {
+		result.IPAddrs = append(result.IPAddrs, ip.String())
+	}
+	for _, subnet := range a.ACL.AllowedSubnets() {
+		result.IPSubnets = append(result.IPSubnets, subnet.String())
+	}
+	return
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ipfs/go-bitswap/decision"
 	"github.com/libp2p/go-libp2p-core/host"
 	"github.com/libp2p/go-libp2p-core/metrics"
 	"github.com/libp2p/go-libp2p-core/network"
@@ -33,7 +34,12 @@ type NetAPI struct {
 	ConnGater       *conngater.BasicConnectionGater
 	ResourceManager network.ResourceManager
 	Reporter        metrics.Reporter
+	Limiter         *lp2p.BandwidthLimiter
 	Sk              *dtypes.ScoreKeeper
+	Tracker         *lp2p.PeerTracker
+	ACL             *lp2p.NetACL
+
+	Bitswap dtypes.ChainBitswap `optional:"true"`
 }
 
 func (a *NetAPI) ID(context.Context) (peer.ID, error) {
@@ -107,6 +113,49 @@ func (a *NetAPI) NetPeerInfo(_ context.Context, p peer.ID) (*api.ExtendedPeerInf
 	return info, nil
 }
 
+func (a *NetAPI) NetPeerInfoDetailed(ctx context.Context, p peer.ID) (*api.PeerInfoDetailed, error) {
+	info, err := a.NetPeerInfo(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &api.PeerInfoDetailed{
+		ExtendedPeerInfo: *info,
+		Connected:        a.Host.Network().Connectedness(p) == network.Connected,
+		Protected:        a.ACL.IsProtected(p),
+	}
+
+	var addrs []ma.Multiaddr
+	for _, conn := range a.Host.Network().ConnsToPeer(p) {
+		addrs = append(addrs, conn.RemoteMultiaddr())
+	}
+	out.Allowed = a.ACL.IsAllowed(p, addrs...)
+
+	if score, ok := a.Sk.Get()[p]; ok {
+		out.PubsubScore = score
+	}
+	if st, ok := a.Tracker.Get(p); ok {
+		out.Propagation = &st
+	}
+
+	// the bitswap ledger is only exposed by the bitswap implementation of the
+	// exchange interface
+	if bs, ok := a.Bitswap.(interface {
+		LedgerForPeer(peer.ID) *decision.Receipt
+	}); ok {
+		if r := bs.LedgerForPeer(p); r != nil {
+			out.Bitswap = &api.BitswapLedger{
+				Value:     r.Value,
+				Sent:      r.Sent,
+				Recv:      r.Recv,
+				Exchanged: r.Exchanged,
+			}
+		}
+	}
+
+	return out, nil
+}
+
 func (a *NetAPI) NetConnect(ctx context.Context, p peer.AddrInfo) error {
 	if swrm, ok := a.Host.Network().(*swarm.Swarm); ok {
 		swrm.Backoff().Clear(p.ID)
@@ -191,4 +240,8 @@ func (a *NetAPI) NetBandwidthStatsByProtocol(ctx context.Context) (map[protocol.
 	return a.Reporter.GetBandwidthByProtocol(), nil
 }
 
+func (a *NetAPI) NetBandwidthStatsByProtocolGroup(ctx context.Context) (map[string]api.ProtocolGroupBandwidth, error) {
+	return a.Limiter.GroupStats(), nil
+}
+
 var _ api.Net = &NetAPI{}
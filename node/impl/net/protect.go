@@ -4,32 +4,35 @@ import (
 	"context"
 
 	"github.com/libp2p/go-libp2p-core/peer"
-)
+	"golang.org/x/xerrors"
 
-const apiProtectTag = "api"
+	"github.com/filecoin-project/lotus/node/modules/lp2p"
+)
 
 func (a *NetAPI) NetProtectAdd(ctx context.Context, peers []peer.ID) error {
+	if err := a.ACL.Protect(ctx, peers...); err != nil {
+		return xerrors.Errorf("persisting protected peers: %w", err)
+	}
+
 	for _, p := range peers {
-		a.Host.ConnManager().Protect(p, apiProtectTag)
+		a.Host.ConnManager().Protect(p, lp2p.ProtectTagAPI)
 	}
 
 	return nil
 }
 
 func (a *NetAPI) NetProtectRemove(ctx context.Context, peers []peer.ID) error {
+	if err := a.ACL.Unprotect(ctx, peers...); err != nil {
+		return xerrors.Errorf("removing protected peers: %w", err)
+	}
+
 	for _, p := range peers {
-		a.Host.ConnManager().Unprotect(p, apiProtectTag)
+		a.Host.ConnManager().Unprotect(p, lp2p.ProtectTagAPI)
 	}
 
 	return nil
 }
 
-func (a *NetAPI) NetProtectList(ctx context.Context) (result []peer.ID, err error) {
-	for _, conn := range a.Host.Network().Conns() {
-		if a.Host.ConnManager().IsProtected(conn.RemotePeer(), apiProtectTag) {
-			result = append(result, conn.RemotePeer())
-		}
-	}
-
-	return
+func (a *NetAPI) NetProtectList(ctx context.Context) ([]peer.ID, error) {
+	return a.ACL.Protected(), nil
 }
@@ -13,12 +13,14 @@ import (
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/paychmgr"
+	"github.com/filecoin-project/lotus/paychmgr/budget"
 )
 
 type PaychAPI struct {
 	fx.In
 
 	PaychMgr *paychmgr.Manager
+	Budget   *budget.Manager
 }
 
 func (a *PaychAPI) PaychGet(ctx context.Context, from, to address.Address, amt types.BigInt, opts api.PaychGetOpts) (*api.ChannelInfo, error) {
@@ -174,3 +176,19 @@ func (a *PaychAPI) PaychVoucherList(ctx context.Context, pch address.Address) ([
 func (a *PaychAPI) PaychVoucherSubmit(ctx context.Context, ch address.Address, sv *paychtypes.SignedVoucher, secret []byte, proof []byte) (cid.Cid, error) {
 	return a.PaychMgr.SubmitVoucher(ctx, ch, sv, secret, proof)
 }
+
+func (a *PaychAPI) PaychExport(ctx context.Context, ch address.Address) (*api.PaychBundle, error) {
+	return a.PaychMgr.ExportChannel(ctx, ch)
+}
+
+func (a *PaychAPI) PaychImport(ctx context.Context, bundle *api.PaychBundle) error {
+	return a.PaychMgr.ImportChannel(ctx, bundle)
+}
+
+func (a *PaychAPI) PaychBudget(ctx context.Context) (*api.PaychBudgetStatus, error) {
+	return a.Budget.Status(ctx)
+}
+
+func (a *PaychAPI) PaychSetBudget(ctx context.Context, b api.PaychBudget) error {
+	return a.Budget.SetBudget(ctx, b)
+}
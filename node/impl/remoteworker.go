@@ -12,6 +12,7 @@ import (
 
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/api/client"
+	"github.com/filecoin-project/lotus/lib/apitls"
 	"github.com/filecoin-project/lotus/storage/sealer"
 )
 
@@ -33,7 +34,11 @@ func connectRemoteWorker(ctx context.Context, fa api.Common, url string) (*remot
 	headers := http.Header{}
 	headers.Add("Authorization", "Bearer "+string(token))
 
-	wapi, closer, err := client.NewWorkerRPCV0(context.TODO(), url, headers)
+	if err := apitls.SetupClient(); err != nil {
+		return nil, err
+	}
+
+	wapi, closer, err := client.NewWorkerRPCV0(context.TODO(), apitls.RPCURL(url), headers)
 	if err != nil {
 		return nil, xerrors.Errorf("creating jsonrpc client: %w", err)
 	}
@@ -1,10 +1,15 @@
 package impl
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash"
+	"io"
 	"net/http"
 	"os"
 	"sort"
@@ -41,19 +46,30 @@ import (
 	"github.com/filecoin-project/lotus/chain/actors/builtin"
 	"github.com/filecoin-project/lotus/chain/gen"
 	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/lib/apitls"
+	"github.com/filecoin-project/lotus/lib/httpreader"
+	"github.com/filecoin-project/lotus/lib/rpcconn"
 	mktsdagstore "github.com/filecoin-project/lotus/markets/dagstore"
+	"github.com/filecoin-project/lotus/markets/dealfilter"
+	"github.com/filecoin-project/lotus/markets/httpretrieval"
 	"github.com/filecoin-project/lotus/markets/storageadapter"
+	"github.com/filecoin-project/lotus/markets/transferlimit"
 	"github.com/filecoin-project/lotus/miner"
+	"github.com/filecoin-project/lotus/node/impl/full"
 	"github.com/filecoin-project/lotus/node/modules"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/storage"
 	"github.com/filecoin-project/lotus/storage/ctladdr"
+	"github.com/filecoin-project/lotus/storage/disputer"
+	"github.com/filecoin-project/lotus/storage/notify"
 	"github.com/filecoin-project/lotus/storage/paths"
 	sealing "github.com/filecoin-project/lotus/storage/pipeline"
 	"github.com/filecoin-project/lotus/storage/pipeline/sealiface"
 	"github.com/filecoin-project/lotus/storage/sealer"
 	"github.com/filecoin-project/lotus/storage/sealer/fsutil"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+	"github.com/filecoin-project/lotus/storage/sealer/workerboot"
 	"github.com/filecoin-project/lotus/storage/sectorblocks"
 	"github.com/filecoin-project/lotus/storage/wdpost"
 )
@@ -79,10 +95,14 @@ type StorageMinerAPI struct {
 	StagingGraphsync  dtypes.StagingGraphsync           `optional:"true"`
 	Transport         dtypes.ProviderTransport          `optional:"true"`
 	DealPublisher     *storageadapter.DealPublisher     `optional:"true"`
+	DealFilterWebhook *dealfilter.WebhookFilter         `optional:"true"`
 	SectorBlocks      *sectorblocks.SectorBlocks        `optional:"true"`
 	Host              host.Host                         `optional:"true"`
 	DAGStore          *dagstore.DAGStore                `optional:"true"`
 	DAGStoreWrapper   *mktsdagstore.Wrapper             `optional:"true"`
+	HTTPRetrieval     *httpretrieval.Server             `optional:"true"`
+	TransferLimits    *transferlimit.Scheduler          `optional:"true"`
+	WorkerRegistry    *workerboot.Registry              `optional:"true"`
 
 	// Miner / storage
 	Miner       *storage.Miner       `optional:"true"`
@@ -93,11 +113,17 @@ type StorageMinerAPI struct {
 	storiface.WorkerReturn `optional:"true"`
 	AddrSel                *ctladdr.AddressSelector
 
-	WdPoSt *wdpost.WindowPoStScheduler `optional:"true"`
+	WdPoSt   *wdpost.WindowPoStScheduler `optional:"true"`
+	Disputer *disputer.Disputer          `optional:"true"`
+	Notifier *notify.Notifier            `optional:"true"`
+	Journal  *journal.Broadcaster        `optional:"true"`
 
 	Epp gen.WinningPoStProver `optional:"true"`
 	DS  dtypes.MetadataDS
 
+	APITLS  *apitls.Server `optional:"true"`
+	RPCConn rpcconn.Config `optional:"true"`
+
 	// StorageService is populated when we're not the main storage node (e.g. we're a markets node)
 	StorageService modules.MinerStorageService `optional:"true"`
 
@@ -119,6 +145,8 @@ type StorageMinerAPI struct {
 	GetSealingConfigFunc                        dtypes.GetSealingConfigFunc                        `optional:"true"`
 	GetExpectedSealDurationFunc                 dtypes.GetExpectedSealDurationFunc                 `optional:"true"`
 	SetExpectedSealDurationFunc                 dtypes.SetExpectedSealDurationFunc                 `optional:"true"`
+	GetRetrievalPricingPolicyFunc               dtypes.GetRetrievalPricingPolicyFunc               `optional:"true"`
+	SetRetrievalPricingPolicyFunc               dtypes.SetRetrievalPricingPolicyFunc               `optional:"true"`
 }
 
 var _ api.StorageMiner = &StorageMinerAPI{}
@@ -165,6 +193,14 @@ func (sm *StorageMinerAPI) MiningBase(ctx context.Context) (*types.TipSet, error
 	return mb.TipSet, nil
 }
 
+func (sm *StorageMinerAPI) WinningPoStHistory(ctx context.Context) ([]api.WinningPoStRecord, error) {
+	if sm.BlockMiner == nil {
+		return nil, xerrors.Errorf("block producer not running on this node")
+	}
+
+	return sm.BlockMiner.WinningPoStHistory(ctx)
+}
+
 func (sm *StorageMinerAPI) ActorSectorSize(ctx context.Context, addr address.Address) (abi.SectorSize, error) {
 	mi, err := sm.Full.StateMinerInfo(ctx, addr, types.EmptyTSK)
 	if err != nil {
@@ -342,6 +378,26 @@ func (sm *StorageMinerAPI) StorageStat(ctx context.Context, id storiface.ID) (fs
 	return sm.RemoteStore.FsStat(ctx, id)
 }
 
+func (sm *StorageMinerAPI) StorageUnsealQueue(ctx context.Context) (storiface.UnsealQueue, error) {
+	return sm.StorageMgr.UnsealQueue(ctx)
+}
+
+func (sm *StorageMinerAPI) StorageRebalance(ctx context.Context, dryRun bool) ([]storiface.RebalanceMove, error) {
+	return sm.StorageMgr.StorageRebalance(ctx, dryRun)
+}
+
+func (sm *StorageMinerAPI) StorageRebalanceStatus(ctx context.Context) (storiface.RebalanceStatus, error) {
+	return sm.StorageMgr.StorageRebalanceStatus(ctx)
+}
+
+func (sm *StorageMinerAPI) StorageSetDrain(ctx context.Context, id storiface.ID, drain bool) error {
+	return sm.StorageMgr.StorageSetDrain(ctx, id, drain)
+}
+
+func (sm *StorageMinerAPI) SectorsPackingStatus(ctx context.Context) (api.DealPackingStatus, error) {
+	return sm.Miner.DealPackingStatus(ctx)
+}
+
 func (sm *StorageMinerAPI) SectorStartSealing(ctx context.Context, number abi.SectorNumber) error {
 	return sm.Miner.StartPackingSector(number)
 }
@@ -393,6 +449,10 @@ func (sm *StorageMinerAPI) SectorTerminatePending(ctx context.Context) ([]abi.Se
 	return sm.Miner.TerminatePending(ctx)
 }
 
+func (sm *StorageMinerAPI) SectorTerminateEstimate(ctx context.Context, sectors []abi.SectorNumber, epoch abi.ChainEpoch) (*api.TerminateEstimate, error) {
+	return sm.Miner.TerminateEstimate(ctx, sectors, epoch)
+}
+
 func (sm *StorageMinerAPI) SectorPreCommitFlush(ctx context.Context) ([]sealiface.PreCommitBatchRes, error) {
 	return sm.Miner.SectorPreCommitFlush(ctx)
 }
@@ -409,6 +469,14 @@ func (sm *StorageMinerAPI) SectorAbortUpgrade(ctx context.Context, number abi.Se
 	return sm.Miner.SectorAbortUpgrade(number)
 }
 
+func (sm *StorageMinerAPI) SectorsSnapList(ctx context.Context) ([]api.SnapSectorInfo, error) {
+	return sm.Miner.SnapSectors()
+}
+
+func (sm *StorageMinerAPI) SectorSnapRetry(ctx context.Context, number abi.SectorNumber) error {
+	return sm.Miner.RetrySnapUpgrade(number)
+}
+
 func (sm *StorageMinerAPI) SectorCommitFlush(ctx context.Context) ([]sealiface.CommitBatchRes, error) {
 	return sm.Miner.CommitFlush(ctx)
 }
@@ -417,6 +485,22 @@ func (sm *StorageMinerAPI) SectorCommitPending(ctx context.Context) ([]abi.Secto
 	return sm.Miner.CommitPending(ctx)
 }
 
+func (sm *StorageMinerAPI) SectorPreCommitBatchPlan(ctx context.Context) (sealiface.BatchPlan, error) {
+	return sm.Miner.SectorPreCommitBatchPlan(ctx)
+}
+
+func (sm *StorageMinerAPI) SectorPreCommitSetBatchMode(ctx context.Context, mode sealiface.BatchMode) error {
+	return sm.Miner.SectorPreCommitSetBatchMode(mode)
+}
+
+func (sm *StorageMinerAPI) SectorCommitBatchPlan(ctx context.Context) (sealiface.BatchPlan, error) {
+	return sm.Miner.CommitBatchPlan(ctx)
+}
+
+func (sm *StorageMinerAPI) SectorCommitSetBatchMode(ctx context.Context, mode sealiface.BatchMode) error {
+	return sm.Miner.CommitSetBatchMode(mode)
+}
+
 func (sm *StorageMinerAPI) SectorMatchPendingPiecesToOpenSectors(ctx context.Context) error {
 	return sm.Miner.SectorMatchPendingPiecesToOpenSectors(ctx)
 }
@@ -436,6 +520,54 @@ func (sm *StorageMinerAPI) ComputeWindowPoSt(ctx context.Context, dlIdx uint64,
 	return sm.WdPoSt.ComputePoSt(ctx, dlIdx, ts)
 }
 
+func (sm *StorageMinerAPI) WindowPoStDryRun(ctx context.Context, dlIdx uint64) (api.WdPoStDryRun, error) {
+	if sm.WdPoSt == nil {
+		return api.WdPoStDryRun{}, xerrors.Errorf("window post scheduler not running on this node")
+	}
+
+	return sm.WdPoSt.DryRun(ctx, dlIdx)
+}
+
+func (sm *StorageMinerAPI) WindowPoStSenderStatus(ctx context.Context) ([]api.WdPoStMessage, error) {
+	if sm.WdPoSt == nil {
+		return nil, xerrors.Errorf("window post scheduler not running on this node")
+	}
+
+	return sm.WdPoSt.SenderStatus(), nil
+}
+
+func (sm *StorageMinerAPI) ProvingDisputeCheck(ctx context.Context, maddr address.Address, dlIdx uint64) ([]api.DisputablePoSt, error) {
+	if sm.Disputer == nil {
+		return nil, xerrors.Errorf("disputer not running on this node")
+	}
+
+	return sm.Disputer.Check(ctx, maddr, dlIdx)
+}
+
+func (sm *StorageMinerAPI) ProvingDispute(ctx context.Context, maddr address.Address, dlIdx uint64, postIdx uint64) (cid.Cid, error) {
+	if sm.Disputer == nil {
+		return cid.Undef, xerrors.Errorf("disputer not running on this node")
+	}
+
+	return sm.Disputer.Dispute(ctx, maddr, dlIdx, postIdx)
+}
+
+func (sm *StorageMinerAPI) ProvingDisputeEvidence(ctx context.Context, maddr address.Address, sectors []abi.SectorNumber) error {
+	if sm.Disputer == nil {
+		return xerrors.Errorf("disputer not running on this node")
+	}
+
+	return sm.Disputer.AddEvidence(ctx, maddr, sectors)
+}
+
+func (sm *StorageMinerAPI) ProvingDisputeStatus(ctx context.Context) ([]api.DisputablePoSt, error) {
+	if sm.Disputer == nil {
+		return nil, xerrors.Errorf("disputer not running on this node")
+	}
+
+	return sm.Disputer.Status(), nil
+}
+
 func (sm *StorageMinerAPI) ComputeDataCid(ctx context.Context, pieceSize abi.UnpaddedPieceSize, pieceData storiface.Data) (abi.PieceInfo, error) {
 	return sm.StorageMgr.DataCid(ctx, pieceSize, pieceData)
 }
@@ -459,6 +591,29 @@ func (sm *StorageMinerAPI) SealingAbort(ctx context.Context, call storiface.Call
 	return sm.StorageMgr.Abort(ctx, call)
 }
 
+func (sm *StorageMinerAPI) SealingSchedPriorities(ctx context.Context) (storiface.SchedPriorities, error) {
+	return sm.StorageMgr.SchedPriorities(ctx)
+}
+
+func (sm *StorageMinerAPI) SealingSetSchedPriorities(ctx context.Context, p storiface.SchedPriorities) error {
+	return sm.StorageMgr.SetSchedPriorities(ctx, p)
+}
+
+func (sm *StorageMinerAPI) SealingWorkersTag(ctx context.Context, hostname string, tags []string) error {
+	return sm.StorageMgr.TagWorkers(ctx, hostname, tags)
+}
+
+func (sm *StorageMinerAPI) SealingWorkersUntag(ctx context.Context, hostname string, tags []string) error {
+	return sm.StorageMgr.UntagWorkers(ctx, hostname, tags)
+}
+
+func (sm *StorageMinerAPI) SealingWorkersBootstrapToken(ctx context.Context, template string, ttl time.Duration) (string, error) {
+	if sm.WorkerRegistry == nil {
+		return "", xerrors.Errorf("worker registration is only available on nodes with sector storage")
+	}
+	return sm.WorkerRegistry.CreateToken(ctx, template, ttl)
+}
+
 func (sm *StorageMinerAPI) MarketImportDealData(ctx context.Context, propCid cid.Cid, path string) error {
 	fi, err := os.Open(path)
 	if err != nil {
@@ -527,6 +682,53 @@ func (sm *StorageMinerAPI) MarketGetDealUpdates(ctx context.Context) (<-chan sto
 	return results, nil
 }
 
+// dealEventTypes maps the storage provider events to the deal lifecycle
+// transitions they mark
+var dealEventTypes = map[storagemarket.ProviderEvent]api.MarketDealEventType{
+	storagemarket.ProviderEventOpen:          api.MarketDealEventProposalReceived,
+	storagemarket.ProviderEventDealAccepted:  api.MarketDealEventAccepted,
+	storagemarket.ProviderEventDealPublished: api.MarketDealEventPublished,
+	storagemarket.ProviderEventDealActivated: api.MarketDealEventSealed,
+	storagemarket.ProviderEventFinalized:     api.MarketDealEventActive,
+	storagemarket.ProviderEventDealSlashed:   api.MarketDealEventSlashed,
+	storagemarket.ProviderEventDealExpired:   api.MarketDealEventExpired,
+	storagemarket.ProviderEventFailed:        api.MarketDealEventFailed,
+}
+
+func (sm *StorageMinerAPI) MarketSubscribeDealEvents(ctx context.Context) (<-chan api.MarketDealEvent, error) {
+	results := make(chan api.MarketDealEvent)
+	unsub := sm.StorageProvider.SubscribeToEvents(func(evt storagemarket.ProviderEvent, deal storagemarket.MinerDeal) {
+		typ, ok := dealEventTypes[evt]
+		if !ok {
+			return
+		}
+
+		de := api.MarketDealEvent{
+			Type:        typ,
+			ProposalCid: deal.ProposalCid,
+			Client:      deal.Proposal.Client,
+			PieceCID:    deal.Proposal.PieceCID,
+			DealID:      deal.DealID,
+			State:       deal.State,
+			Time:        build.Clock.Now(),
+		}
+		if typ == api.MarketDealEventFailed {
+			de.Error = deal.Message
+		}
+
+		select {
+		case results <- de:
+		case <-ctx.Done():
+		}
+	})
+	go func() {
+		<-ctx.Done()
+		unsub()
+		close(results)
+	}()
+	return results, nil
+}
+
 func (sm *StorageMinerAPI) MarketListIncompleteDeals(ctx context.Context) ([]storagemarket.MinerDeal, error) {
 	return sm.StorageProvider.ListLocalDeals()
 }
@@ -770,6 +972,46 @@ func (sm *StorageMinerAPI) MarketRetryPublishDeal(ctx context.Context, propcid c
 	return sm.StorageProvider.RetryDealPublishing(propcid)
 }
 
+func (sm *StorageMinerAPI) MarketPublishPendingDeal(ctx context.Context, propcid cid.Cid) error {
+	return sm.DealPublisher.ForcePublishPendingDeal(propcid)
+}
+
+func (sm *StorageMinerAPI) MarketGetPublishConfig(ctx context.Context) (api.DealPublishConfig, error) {
+	return sm.DealPublisher.PublishConfig(), nil
+}
+
+func (sm *StorageMinerAPI) MarketSetPublishConfig(ctx context.Context, cfg api.DealPublishConfig) error {
+	return sm.DealPublisher.SetPublishConfig(cfg)
+}
+
+func (sm *StorageMinerAPI) MarketDataTransferLimits(ctx context.Context) (api.DataTransferLimitsStatus, error) {
+	if sm.TransferLimits == nil {
+		return api.DataTransferLimitsStatus{}, xerrors.Errorf("data transfer limits are not available")
+	}
+	return sm.TransferLimits.Status(), nil
+}
+
+func (sm *StorageMinerAPI) MarketSetDataTransferLimits(ctx context.Context, limits api.DataTransferLimits) error {
+	if sm.TransferLimits == nil {
+		return xerrors.Errorf("data transfer limits are not available")
+	}
+	return sm.TransferLimits.SetLimits(limits)
+}
+
+func (sm *StorageMinerAPI) MarketPendingFilterDecisions(ctx context.Context) ([]api.PendingFilterDecision, error) {
+	if sm.DealFilterWebhook == nil {
+		return []api.PendingFilterDecision{}, nil
+	}
+	return sm.DealFilterWebhook.Pending(), nil
+}
+
+func (sm *StorageMinerAPI) MarketResolveFilterDecision(ctx context.Context, propcid cid.Cid, accept bool, reason string) error {
+	if sm.DealFilterWebhook == nil {
+		return xerrors.Errorf("the deal filter webhook is not enabled")
+	}
+	return sm.DealFilterWebhook.Resolve(propcid, accept, reason)
+}
+
 func (sm *StorageMinerAPI) MarketPublishPendingDeals(ctx context.Context) error {
 	sm.DealPublisher.ForcePublishPendingDeals()
 	return nil
@@ -1190,6 +1432,54 @@ func (sm *StorageMinerAPI) DealsImportData(ctx context.Context, deal cid.Cid, fn
 	return sm.StorageProvider.ImportDataForDeal(ctx, deal, fi)
 }
 
+func (sm *StorageMinerAPI) DealsImportDataFromURL(ctx context.Context, deal cid.Cid, src api.DealDataURL) error {
+	var want []byte
+	if src.SHA256 != "" {
+		var err error
+		want, err = hex.DecodeString(src.SHA256)
+		if err != nil || len(want) != sha256.Size {
+			return xerrors.Errorf("invalid sha256 digest %q", src.SHA256)
+		}
+	}
+
+	hr := httpreader.NewResumableReader(ctx, src.URL, src.Headers)
+	defer hr.Close() //nolint:errcheck
+
+	var r io.Reader = hr
+	if want != nil {
+		r = &digestReader{r: hr, h: sha256.New(), want: want}
+	}
+
+	return sm.StorageProvider.ImportDataForDeal(ctx, deal, r)
+}
+
+// digestReader fails reading at EOF when the data doesn't match the expected
+// digest
+type digestReader struct {
+	r    io.Reader
+	h    hash.Hash
+	want []byte
+}
+
+func (d *digestReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	d.h.Write(p[:n]) //nolint:errcheck
+	if err == io.EOF {
+		if got := d.h.Sum(nil); !bytes.Equal(got, d.want) {
+			return n, xerrors.Errorf("data digest mismatch: expected sha256 %x, got %x", d.want, got)
+		}
+	}
+	return n, err
+}
+
+func (sm *StorageMinerAPI) DealsRetrievalPricingPolicy(ctx context.Context) (dtypes.RetrievalPricingPolicy, error) {
+	return sm.GetRetrievalPricingPolicyFunc()
+}
+
+func (sm *StorageMinerAPI) DealsSetRetrievalPricingPolicy(ctx context.Context, policy dtypes.RetrievalPricingPolicy) error {
+	return sm.SetRetrievalPricingPolicyFunc(policy)
+}
+
 func (sm *StorageMinerAPI) DealsPieceCidBlocklist(ctx context.Context) ([]cid.Cid, error) {
 	return sm.StorageDealPieceCidBlocklistConfigFunc()
 }
@@ -1279,3 +1569,169 @@ func (sm *StorageMinerAPI) ComputeProof(ctx context.Context, ssi []builtin.Exten
 func (sm *StorageMinerAPI) RuntimeSubsystems(context.Context) (res api.MinerSubsystems, err error) {
 	return sm.EnabledSubsystems, nil
 }
+
+func (sm *StorageMinerAPI) NotificationsTest(ctx context.Context, event string) error {
+	if sm.Notifier == nil {
+		return xerrors.Errorf("no notification webhooks are configured")
+	}
+
+	t, err := notify.ParseEventType(event)
+	if err != nil {
+		return err
+	}
+	return sm.Notifier.Test(ctx, t)
+}
+
+func (sm *StorageMinerAPI) MinerEvents(ctx context.Context) (<-chan api.MinerEvent, error) {
+	if sm.Journal == nil {
+		return nil, xerrors.Errorf("miner events are not available")
+	}
+
+	evts := sm.Journal.Subscribe(ctx)
+	out := make(chan api.MinerEvent, 16)
+	go func() {
+		defer close(out)
+
+		for evt := range evts {
+			data, err := json.Marshal(evt.Data)
+			if err != nil {
+				log.Warnw("marshaling miner event", "type", evt.EventType, "error", err)
+				continue
+			}
+
+			select {
+			case out <- api.MinerEvent{
+				System:     evt.System,
+				Event:      evt.Event,
+				Timestamp:  evt.Timestamp,
+				Data:       data,
+				Subsystems: sm.EnabledSubsystems,
+			}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// Epochs before the close of the current deadline at which unproven
+// partitions are reported
+const (
+	DeadlineUnprovenWarn = 30
+	DeadlineUnprovenCrit = 10
+)
+
+func (sm *StorageMinerAPI) NodeHealth(ctx context.Context) (api.NodeHealth, error) {
+	head, err := sm.Full.ChainHead(ctx)
+	if err != nil {
+		return api.NodeHealth{}, xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	peers, err := sm.NetPeers(ctx)
+	if err != nil {
+		return api.NodeHealth{}, xerrors.Errorf("listing peers: %w", err)
+	}
+
+	h := api.NodeHealth{
+		Sync:  full.SyncHealth(head),
+		Peers: full.PeersHealth(len(peers)),
+	}
+
+	if sm.StorageMgr != nil {
+		h.Workers = workersHealth(sm.StorageMgr.WorkerStats(ctx))
+	}
+
+	if sm.Miner != nil && sm.WdPoSt != nil {
+		h.Deadline, err = sm.deadlineHealth(ctx, head)
+		if err != nil {
+			return api.NodeHealth{}, xerrors.Errorf("checking deadline health: %w", err)
+		}
+	}
+
+	h.Compute()
+	return h, nil
+}
+
+func workersHealth(stats map[uuid.UUID]storiface.WorkerStats) *api.WorkersHealth {
+	h := &api.WorkersHealth{
+		HealthStatus: api.HealthStatus{Level: api.HealthOK},
+		Workers:      len(stats),
+	}
+	for _, st := range stats {
+		if !st.Enabled {
+			h.Disabled++
+		}
+	}
+
+	switch {
+	case h.Workers == h.Disabled:
+		h.HealthStatus = api.HealthStatus{Level: api.HealthCrit, Message: "no enabled workers"}
+	case h.Disabled > 0:
+		h.HealthStatus = api.HealthStatus{Level: api.HealthWarn, Message: fmt.Sprintf("%d of %d workers disabled", h.Disabled, h.Workers)}
+	}
+	return h
+}
+
+func (sm *StorageMinerAPI) deadlineHealth(ctx context.Context, head *types.TipSet) (*api.DeadlineHealth, error) {
+	maddr := sm.Miner.Address()
+
+	di, err := sm.Full.StateMinerProvingDeadline(ctx, maddr, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting proving deadline: %w", err)
+	}
+	dls, err := sm.Full.StateMinerDeadlines(ctx, maddr, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting deadlines: %w", err)
+	}
+	if di.Index >= uint64(len(dls)) {
+		return nil, xerrors.Errorf("deadline %d out of range", di.Index)
+	}
+	parts, err := sm.Full.StateMinerPartitions(ctx, maddr, di.Index, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting partitions: %w", err)
+	}
+
+	h := &api.DeadlineHealth{
+		HealthStatus: api.HealthStatus{Level: api.HealthOK},
+		Index:        di.Index,
+		Open:         di.Open,
+		Close:        di.Close,
+	}
+	for i, part := range parts {
+		live, err := part.LiveSectors.Count()
+		if err != nil {
+			return nil, err
+		}
+		faulty, err := part.FaultySectors.Count()
+		if err != nil {
+			return nil, err
+		}
+		h.FaultySectors += faulty
+		if live == 0 {
+			continue
+		}
+
+		h.Partitions++
+		proven, err := dls[di.Index].PostSubmissions.IsSet(uint64(i))
+		if err != nil {
+			return nil, err
+		}
+		if proven {
+			h.Proven++
+		}
+	}
+
+	left := di.Close - di.CurrentEpoch
+	unproven := h.Partitions - h.Proven
+	switch {
+	case unproven > 0 && left <= DeadlineUnprovenCrit:
+		h.HealthStatus = api.HealthStatus{Level: api.HealthCrit, Message: fmt.Sprintf("%d partitions unproven with %d epochs left in deadline %d", unproven, left, di.Index)}
+	case unproven > 0 && left <= DeadlineUnprovenWarn:
+		h.HealthStatus = api.HealthStatus{Level: api.HealthWarn, Message: fmt.Sprintf("%d partitions unproven with %d epochs left in deadline %d", unproven, left, di.Index)}
+	case h.FaultySectors > 0:
+		h.HealthStatus = api.HealthStatus{Level: api.HealthWarn, Message: fmt.Sprintf("%d faulty sectors in deadline %d", h.FaultySectors, di.Index)}
+	}
+	return h, nil
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	bstore "github.com/ipfs/go-ipfs-blockstore"
 	"go.uber.org/fx"
@@ -12,6 +13,8 @@ import (
 
 	"github.com/filecoin-project/lotus/blockstore"
 	badgerbs "github.com/filecoin-project/lotus/blockstore/badger"
+	"github.com/filecoin-project/lotus/blockstore/gcsched"
+	"github.com/filecoin-project/lotus/blockstore/scrub"
 	"github.com/filecoin-project/lotus/blockstore/splitstore"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
@@ -129,6 +132,70 @@ func ChainSplitBlockstore(_ fx.Lifecycle, _ helpers.MetricsCtx, bs dtypes.SplitB
 	return bs, nil
 }
 
+func BlockCache(cfg *config.BlockCache) func(lc fx.Lifecycle) (*blockstore.BlockCache, error) {
+	return func(lc fx.Lifecycle) (*blockstore.BlockCache, error) {
+		bc, err := blockstore.NewBlockCache("chain", cfg.CacheType, cfg.MaxBytes, cfg.MaxEntries)
+		if err != nil {
+			return nil, xerrors.Errorf("creating block cache: %w", err)
+		}
+
+		lc.Append(fx.Hook{
+			OnStart: bc.Start,
+			OnStop:  bc.Stop,
+		})
+
+		return bc, nil
+	}
+}
+
+func CarMounts(lc fx.Lifecycle) *blockstore.CarMounts {
+	m := blockstore.NewCarMounts()
+	lc.Append(fx.Hook{
+		OnStop: func(_ context.Context) error {
+			return m.Close()
+		},
+	})
+	return m
+}
+
+type ChainBlockstoreParams struct {
+	fx.In
+
+	Basic  dtypes.BasicChainBlockstore
+	Mounts *blockstore.CarMounts
+	Cache  *blockstore.BlockCache `optional:"true"`
+}
+
+// ChainBlockstore layers the mounted CAR files and the block cache, when
+// enabled, on top of the basic chain blockstore. Reads made by sampled API calls
+// are traced.
+func ChainBlockstore(p ChainBlockstoreParams) dtypes.ChainBlockstore {
+	bs := p.Mounts.Overlay(p.Basic)
+	if p.Cache != nil {
+		bs = blockstore.NewCachedBlockstore(bs, p.Cache)
+	}
+	return blockstore.NewTracedBlockstore(bs)
+}
+
+type StateBlockstoreParams struct {
+	fx.In
+
+	Basic  dtypes.BasicStateBlockstore
+	Mounts *blockstore.CarMounts
+	Cache  *blockstore.BlockCache `optional:"true"`
+}
+
+// StateBlockstore layers the mounted CAR files and the block cache, when
+// enabled, on top of the basic state blockstore. Reads made by sampled API calls
+// are traced.
+func StateBlockstore(p StateBlockstoreParams) dtypes.StateBlockstore {
+	bs := p.Mounts.Overlay(p.Basic)
+	if p.Cache != nil {
+		bs = blockstore.NewCachedBlockstore(bs, p.Cache)
+	}
+	return blockstore.NewTracedBlockstore(bs)
+}
+
 func FallbackChainBlockstore(cbs dtypes.BasicChainBlockstore) dtypes.ChainBlockstore {
 	return &blockstore.FallbackStore{Blockstore: cbs}
 }
@@ -147,3 +214,48 @@ func InitFallbackBlockstores(cbs dtypes.ChainBlockstore, sbs dtypes.StateBlockst
 	}
 	return nil
 }
+
+func BlockstoreGCScheduler(cfg *config.BlockstoreGC) func(lc fx.Lifecycle, bs dtypes.BaseBlockstore) (*gcsched.Scheduler, error) {
+	return func(lc fx.Lifecycle, bs dtypes.BaseBlockstore) (*gcsched.Scheduler, error) {
+		s, err := gcsched.NewScheduler(bs, gcsched.Config{
+			Scheduled:       cfg.EnableScheduledGC,
+			WindowStart:     cfg.WindowStart,
+			WindowEnd:       cfg.WindowEnd,
+			MaxDuration:     time.Duration(cfg.MaxDuration),
+			MaxProbeLatency: time.Duration(cfg.MaxProbeLatency),
+			Threshold:       cfg.Threshold,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		lc.Append(fx.Hook{
+			OnStart: s.Start,
+			OnStop:  s.Stop,
+		})
+
+		return s, nil
+	}
+}
+
+func BlockstoreScrubber(cfg *config.BlockstoreScrub) func(lc fx.Lifecycle, bs dtypes.UniversalBlockstore, ds dtypes.MetadataDS, rem dtypes.ChainBitswap) *scrub.Scrubber {
+	return func(lc fx.Lifecycle, bs dtypes.UniversalBlockstore, ds dtypes.MetadataDS, rem dtypes.ChainBitswap) *scrub.Scrubber {
+		scfg := scrub.Config{
+			MaxBlocksPerSecond: cfg.MaxBlocksPerSecond,
+			Refetch:            cfg.Refetch,
+			RefetchTimeout:     time.Duration(cfg.RefetchTimeout),
+		}
+		if cfg.EnableScrub {
+			scfg.Interval = time.Duration(cfg.Interval)
+		}
+
+		s := scrub.NewScrubber(bs, ds, rem, scfg)
+
+		lc.Append(fx.Hook{
+			OnStart: s.Start,
+			OnStop:  s.Stop,
+		})
+
+		return s
+	}
+}
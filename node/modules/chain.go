@@ -26,6 +26,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/vm"
 	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/modules/helpers"
 )
@@ -144,6 +145,7 @@ type SyncerParams struct {
 	Beacon       beacon.Schedule
 	Gent         chain.Genesis
 	Consensus    consensus.Consensus
+	Pipeline     *chain.PipelineConfig `optional:"true"`
 }
 
 func NewSyncer(params SyncerParams) (*chain.Syncer, error) {
@@ -161,6 +163,10 @@ func NewSyncer(params SyncerParams) (*chain.Syncer, error) {
 		return nil, err
 	}
 
+	if params.Pipeline != nil {
+		syncer.SetPipeline(*params.Pipeline)
+	}
+
 	lc.Append(fx.Hook{
 		OnStart: func(_ context.Context) error {
 			syncer.Start()
@@ -181,3 +187,12 @@ func NewSlashFilter(ds dtypes.MetadataDS) *slashfilter.SlashFilter {
 func UpgradeSchedule() stmgr.UpgradeSchedule {
 	return filcns.DefaultUpgradeSchedule()
 }
+
+func SyncPipeline(cfg *config.Sync) func() *chain.PipelineConfig {
+	return func() *chain.PipelineConfig {
+		return &chain.PipelineConfig{
+			SignatureWorkers: cfg.SignatureWorkers,
+			Depth:            cfg.PipelineDepth,
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package modules
+
+import (
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/index"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+func ChainIndex(cfg *config.ChainIndex) func(lc fx.Lifecycle, mctx helpers.MetricsCtx, r repo.LockedRepo, cs *store.ChainStore) (*index.Index, error) {
+	return func(lc fx.Lifecycle, mctx helpers.MetricsCtx, r repo.LockedRepo, cs *store.ChainStore) (*index.Index, error) {
+		ds, err := r.Datastore(helpers.LifecycleCtx(mctx, lc), "/chainindex")
+		if err != nil {
+			return nil, xerrors.Errorf("opening chain index datastore: %w", err)
+		}
+
+		idx := index.NewIndex(cs, ds, abi.ChainEpoch(cfg.BackfillEpochs))
+		cs.SetHeightIndex(idx.HeightIndex())
+		lc.Append(fx.Hook{
+			OnStart: idx.Start,
+			OnStop:  idx.Stop,
+		})
+
+		return idx, nil
+	}
+}
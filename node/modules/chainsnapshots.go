@@ -0,0 +1,47 @@
+package modules
+
+import (
+	"path/filepath"
+
+	"go.uber.org/fx"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/chain/snapshotsched"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/repo"
+)
+
+func ChainSnapshotScheduler(cfg *config.ChainSnapshots) func(lc fx.Lifecycle, r repo.LockedRepo, cs *store.ChainStore) (*snapshotsched.Scheduler, error) {
+	return func(lc fx.Lifecycle, r repo.LockedRepo, cs *store.ChainStore) (*snapshotsched.Scheduler, error) {
+		path := cfg.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(r.Path(), path)
+		}
+
+		s, err := snapshotsched.NewScheduler(cs, snapshotsched.Config{
+			Schedule:         cfg.Schedule,
+			Path:             path,
+			Retain:           cfg.Retain,
+			LatestState:      cfg.LatestState,
+			RecentHeaders:    abi.ChainEpoch(cfg.RecentHeaders),
+			RecentStateRoots: abi.ChainEpoch(cfg.RecentStateRoots),
+			SkipOldMessages:  cfg.SkipOldMessages,
+			Compress:         cfg.Compress,
+			UploadCmd:        cfg.UploadCmd,
+			RemoveCmd:        cfg.RemoveCmd,
+			PublicURL:        cfg.PublicURL,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		lc.Append(fx.Hook{
+			OnStart: s.Start,
+			OnStop:  s.Stop,
+		})
+
+		return s, nil
+	}
+}
@@ -0,0 +1,69 @@
+package modules
+
+import (
+	"context"
+
+	"github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/filecoin-project/lotus/lib/apitls"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/impl/chainstream"
+	"github.com/filecoin-project/lotus/node/impl/common"
+	"github.com/filecoin-project/lotus/node/impl/full"
+)
+
+type ChainStreamAPI struct {
+	fx.In
+
+	full.ChainAPI
+	full.StateAPI
+	common.CommonAPI
+}
+
+var _ chainstream.FullNode = &ChainStreamAPI{}
+
+// ServeChainStream serves the ChainStream gRPC service on the configured
+// address, with the TLS config of the API
+func ServeChainStream(cfg config.ChainStream) func(lc fx.Lifecycle, a ChainStreamAPI, tlsSrv *apitls.Server) error {
+	return func(lc fx.Lifecycle, a ChainStreamAPI, tlsSrv *apitls.Server) error {
+		maddr, err := multiaddr.NewMultiaddr(cfg.ListenAddress)
+		if err != nil {
+			return xerrors.Errorf("parsing chain stream listen address: %w", err)
+		}
+
+		var opts []grpc.ServerOption
+		if tlsCfg := tlsSrv.ServerConfig(); tlsCfg != nil {
+			opts = append(opts, grpc.Creds(credentials.NewTLS(tlsCfg)))
+		}
+		srv := chainstream.NewGRPCServer(&a, opts...)
+
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				lst, err := manet.Listen(maddr)
+				if err != nil {
+					return xerrors.Errorf("listening on chain stream address: %w", err)
+				}
+
+				log.Infow("serving chain streams", "address", maddr)
+				go func() {
+					if err := srv.Serve(manet.NetListener(lst)); err != nil {
+						log.Errorf("chain stream server failed: %s", err)
+					}
+				}()
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				// the streams don't end, so they are closed right away
+				srv.Stop()
+				return nil
+			},
+		})
+
+		return nil
+	}
+}
@@ -0,0 +1,63 @@
+package modules
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/chain"
+	"github.com/filecoin-project/lotus/chain/checkpoint"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/impl/full"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+type CheckpointFeedAPI struct {
+	fx.In
+
+	full.ChainModuleAPI
+	Syncer *chain.Syncer
+}
+
+var _ checkpoint.ChainAPI = &CheckpointFeedAPI{}
+
+func (a *CheckpointFeedAPI) SyncCheckpoint(ctx context.Context, tsk types.TipSetKey) error {
+	return a.Syncer.SyncCheckpoint(ctx, tsk)
+}
+
+func CheckpointFeed(cfg *config.CheckpointFeed) func(lc fx.Lifecycle, api CheckpointFeedAPI, ds dtypes.MetadataDS, nn dtypes.NetworkName) (*checkpoint.Feed, error) {
+	return func(lc fx.Lifecycle, api CheckpointFeedAPI, ds dtypes.MetadataDS, nn dtypes.NetworkName) (*checkpoint.Feed, error) {
+		fcfg := checkpoint.Config{
+			URL:          cfg.URL,
+			PollInterval: time.Duration(cfg.PollInterval),
+			Threshold:    cfg.Threshold,
+		}
+		for _, s := range cfg.Authorities {
+			a, err := address.NewFromString(s)
+			if err != nil {
+				return nil, xerrors.Errorf("parsing checkpoint authority %q: %w", s, err)
+			}
+			fcfg.Authorities = append(fcfg.Authorities, a)
+		}
+		if fcfg.Threshold == 0 {
+			fcfg.Threshold = len(fcfg.Authorities)
+		}
+
+		f, err := checkpoint.NewFeed(&api, ds, string(nn), fcfg)
+		if err != nil {
+			return nil, xerrors.Errorf("creating checkpoint feed: %w", err)
+		}
+
+		lc.Append(fx.Hook{
+			OnStart: f.Start,
+			OnStop:  f.Stop,
+		})
+
+		return f, nil
+	}
+}
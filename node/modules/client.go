@@ -13,6 +13,7 @@ import (
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-data-transfer/channelmonitor"
 	dtimpl "github.com/filecoin-project/go-data-transfer/impl"
 	dtnet "github.com/filecoin-project/go-data-transfer/network"
@@ -32,10 +33,12 @@ import (
 	"github.com/filecoin-project/lotus/chain/market"
 	"github.com/filecoin-project/lotus/journal"
 	"github.com/filecoin-project/lotus/markets"
+	"github.com/filecoin-project/lotus/markets/dealrepair"
 	marketevents "github.com/filecoin-project/lotus/markets/loggers"
 	"github.com/filecoin-project/lotus/markets/retrievaladapter"
 	"github.com/filecoin-project/lotus/markets/storageadapter"
 	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/impl/client"
 	"github.com/filecoin-project/lotus/node/impl/full"
 	payapi "github.com/filecoin-project/lotus/node/impl/paych"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
@@ -89,6 +92,39 @@ func ClientImportMgr(ds dtypes.MetadataDS, r repo.LockedRepo) (dtypes.ClientImpo
 	return imports.NewManager(ns, dir), nil
 }
 
+func DealRepairMonitor(cfg *config.DealRepair) func(lc fx.Lifecycle, api client.API, ds dtypes.MetadataDS) (*dealrepair.Monitor, error) {
+	return func(lc fx.Lifecycle, api client.API, ds dtypes.MetadataDS) (*dealrepair.Monitor, error) {
+		mcfg := dealrepair.Config{
+			CheckInterval:  time.Duration(cfg.CheckInterval),
+			FaultThreshold: time.Duration(cfg.FaultThreshold),
+		}
+		for _, s := range cfg.BackupProviders {
+			a, err := address.NewFromString(s)
+			if err != nil {
+				return nil, xerrors.Errorf("parsing backup provider %q: %w", s, err)
+			}
+			mcfg.BackupProviders = append(mcfg.BackupProviders, a)
+		}
+		if cfg.Wallet != "" {
+			a, err := address.NewFromString(cfg.Wallet)
+			if err != nil {
+				return nil, xerrors.Errorf("parsing deal repair wallet: %w", err)
+			}
+			mcfg.Wallet = a
+		}
+		if mcfg.CheckInterval <= 0 {
+			return nil, xerrors.Errorf("deal repair check interval must be positive")
+		}
+
+		m := dealrepair.NewMonitor(&api, ds, mcfg)
+		lc.Append(fx.Hook{
+			OnStart: m.Start,
+			OnStop:  m.Stop,
+		})
+		return m, nil
+	}
+}
+
 // TODO this should be removed.
 func ClientBlockstore() dtypes.ClientBlockstore {
 	// in most cases this is now unused in normal operations -- however, it's important to preserve for the IPFS use case
@@ -26,6 +26,7 @@ import (
 	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/lib/addrutil"
+	"github.com/filecoin-project/lotus/lib/apitls"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/repo"
@@ -181,12 +182,46 @@ func APISecret(keystore types.KeyStore, lr repo.LockedRepo) (*dtypes.APIAlg, err
 	return (*dtypes.APIAlg)(jwt.NewHS256(key.PrivateKey)), nil
 }
 
+func apiTLSFiles(cfg config.APITLS) apitls.Files {
+	return apitls.Files{
+		CertFile:     cfg.CertFile,
+		KeyFile:      cfg.KeyFile,
+		ClientCAFile: cfg.ClientCAFile,
+	}
+}
+
+// APITLS returns the TLS config of the API listener, which is nil when the API
+// is served without TLS
+func APITLS(cfg config.APITLS) func() (*apitls.Server, error) {
+	return func() (*apitls.Server, error) {
+		f := apiTLSFiles(cfg)
+		if !f.Enabled() {
+			if f.ClientCAFile != "" {
+				return nil, xerrors.Errorf("API.TLS.ClientCAFile requires CertFile and KeyFile to be set")
+			}
+			return nil, nil
+		}
+
+		srv, err := apitls.NewServer(f)
+		if err != nil {
+			return nil, xerrors.Errorf("loading API TLS config: %w", err)
+		}
+		return srv, nil
+	}
+}
+
 func ConfigBootstrap(peers []string) func() (dtypes.BootstrapPeers, error) {
 	return func() (dtypes.BootstrapPeers, error) {
 		return addrutil.ParseAddresses(context.TODO(), peers)
 	}
 }
 
+func ConfigStaticPeers(peers []string) func() (dtypes.StaticPeers, error) {
+	return func() (dtypes.StaticPeers, error) {
+		return addrutil.ParseAddresses(context.TODO(), peers)
+	}
+}
+
 func BuiltinBootstrap() (dtypes.BootstrapPeers, error) {
 	return build.BuiltinBootstrap()
 }
@@ -205,22 +240,17 @@ func DrandBootstrap(ds dtypes.DrandSchedule) (dtypes.DrandBootstrap, error) {
 	return res, nil
 }
 
-func NewDefaultMaxFeeFunc(r repo.LockedRepo) dtypes.DefaultMaxFeeFunc {
+func NewDefaultMaxFeeFunc(rl *config.Reloader) dtypes.DefaultMaxFeeFunc {
 	return func() (out abi.TokenAmount, err error) {
-		err = readNodeCfg(r, func(cfg *config.FullNode) {
+		err = readNodeCfg(rl, func(cfg *config.FullNode) {
 			out = abi.TokenAmount(cfg.Fees.DefaultMaxFee)
 		})
 		return
 	}
 }
 
-func readNodeCfg(r repo.LockedRepo, accessor func(node *config.FullNode)) error {
-	raw, err := r.Config()
-	if err != nil {
-		return err
-	}
-
-	cfg, ok := raw.(*config.FullNode)
+func readNodeCfg(rl *config.Reloader, accessor func(node *config.FullNode)) error {
+	cfg, ok := rl.Config().(*config.FullNode)
 	if !ok {
 		return xerrors.New("expected config.FullNode")
 	}
@@ -8,3 +8,7 @@ import (
 type APIAlg jwt.HMACSHA
 
 type APIEndpoint multiaddr.Multiaddr
+
+// ReadOnlyAPI is set when the node API rejects the methods needing more than
+// the read permission, whatever the permissions of the caller
+type ReadOnlyAPI bool
@@ -1,6 +1,10 @@
 package dtypes
 
-import "github.com/filecoin-project/go-state-types/abi"
+import (
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+)
 
 type DrandSchedule []DrandPoint
 
@@ -14,3 +18,15 @@ type DrandConfig struct {
 	Relays        []string
 	ChainInfoJSON string
 }
+
+// DrandClientConfig configures how the drand beacons reach the drand network
+type DrandClientConfig struct {
+	// Endpoints are relays used in addition to the servers of the latest drand
+	// network of the schedule
+	Endpoints []string
+	// HealthCheckInterval is the time between health checks of the relays
+	HealthCheckInterval time.Duration
+	// PersistentCache enables persisting verified entries in the metadata
+	// datastore
+	PersistentCache bool
+}
@@ -5,4 +5,9 @@ import "github.com/libp2p/go-libp2p-core/peer"
 type BootstrapPeers []peer.AddrInfo
 type DrandBootstrap []peer.AddrInfo
 
+// StaticPeers are the peers the node stays connected to
+type StaticPeers []peer.AddrInfo
+
 type Bootstrapper bool
+
+type DisablePeerDiscovery bool
@@ -1,4 +1,13 @@
 package dtypes
 
+import "github.com/filecoin-project/go-state-types/abi"
+
 type NetworkName string
 type AfterGenesisSet struct{}
+
+// MessageWaitConfig holds the confidence and finality used by the message
+// waiting APIs when callers ask for the node defaults
+type MessageWaitConfig struct {
+	Confidence uint64
+	Finality   abi.ChainEpoch
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-fil-markets/retrievalmarket"
@@ -97,3 +98,34 @@ type StorageDealFilter func(ctx context.Context, deal storagemarket.MinerDeal) (
 type RetrievalDealFilter func(ctx context.Context, deal retrievalmarket.ProviderDealState) (bool, string, error)
 
 type RetrievalPricingFunc func(ctx context.Context, dealPricingParams retrievalmarket.PricingInput) (retrievalmarket.Ask, error)
+
+// RetrievalPricingPolicy prices retrieval deals when the retrieval pricing
+// strategy is "policy"
+type RetrievalPricingPolicy struct {
+	// FreeClients are the clients which retrieve for free
+	FreeClients []peer.ID
+	// VerifiedDealsFreeTransfer waives the transfer price of payloads which
+	// belong to a verified storage deal
+	VerifiedDealsFreeTransfer bool
+	// PiecePrices override the ask for retrievals from specific pieces
+	PiecePrices []RetrievalPiecePrice
+	// SealedPricePercent is the price per byte of retrievals which require
+	// unsealing, in percent of the price per byte of the ask (0 = 100)
+	SealedPricePercent uint64
+}
+
+// RetrievalPiecePrice is the price of retrievals from a piece; the prices which
+// aren't set are taken from the ask
+type RetrievalPiecePrice struct {
+	PieceCID     cid.Cid
+	PricePerByte *abi.TokenAmount `json:",omitempty"`
+	UnsealPrice  *abi.TokenAmount `json:",omitempty"`
+}
+
+// GetRetrievalPricingPolicyFunc is a function which reads the retrieval
+// pricing policy from the miner config
+type GetRetrievalPricingPolicyFunc func() (RetrievalPricingPolicy, error)
+
+// SetRetrievalPricingPolicyFunc is a function which is used to set the
+// retrieval pricing policy
+type SetRetrievalPricingPolicyFunc func(RetrievalPricingPolicy) error
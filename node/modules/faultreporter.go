@@ -0,0 +1,41 @@
+package modules
+
+import (
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/lotus/chain"
+	"github.com/filecoin-project/lotus/chain/gen/slashfilter/slashsvc"
+	"github.com/filecoin-project/lotus/chain/store"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/impl/full"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+func ConsensusFaultReporter(cfg *config.FaultReporter) func(lc fx.Lifecycle, ds dtypes.MetadataDS, cs *store.ChainStore, syncer *chain.Syncer, mpool full.MpoolAPI) (*slashsvc.Reporter, error) {
+	return func(lc fx.Lifecycle, ds dtypes.MetadataDS, cs *store.ChainStore, syncer *chain.Syncer, mpool full.MpoolAPI) (*slashsvc.Reporter, error) {
+		from := address.Undef
+		if cfg.ReporterAddress != "" {
+			var err error
+			if from, err = address.NewFromString(cfg.ReporterAddress); err != nil {
+				return nil, xerrors.Errorf("parsing consensus fault reporter address: %w", err)
+			}
+		}
+
+		r, err := slashsvc.NewReporter(namespace.Wrap(ds, datastore.NewKey("/faultreporter")), cs, syncer, &mpool, from, cfg.AutoSubmit)
+		if err != nil {
+			return nil, err
+		}
+
+		lc.Append(fx.Hook{
+			OnStart: r.Start,
+			OnStop:  r.Stop,
+		})
+
+		return r, nil
+	}
+}
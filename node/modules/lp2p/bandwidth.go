@@ -0,0 +1,158 @@
+package lp2p
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/metrics"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+	"golang.org/x/time/rate"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+// The groups of protocols bandwidth is accounted, and limited, by
+const (
+	BandwidthGroupGossipsub = "gossipsub"
+	// bitswap and chain exchange
+	BandwidthGroupBitswap   = "bitswap"
+	BandwidthGroupGraphsync = "graphsync"
+	BandwidthGroupOther     = "other"
+)
+
+// ProtocolGroup returns the bandwidth group of a protocol
+func ProtocolGroup(proto protocol.ID) string {
+	p := string(proto)
+	switch {
+	case strings.HasPrefix(p, "/meshsub/"), strings.HasPrefix(p, "/floodsub/"):
+		return BandwidthGroupGossipsub
+	case strings.HasPrefix(p, "/ipfs/bitswap"), strings.HasPrefix(p, "/fil/chain/xchg/"):
+		return BandwidthGroupBitswap
+	case strings.HasPrefix(p, "/ipfs/graphsync/"):
+		return BandwidthGroupGraphsync
+	default:
+		return BandwidthGroupOther
+	}
+}
+
+// BandwidthLimiter is a bandwidth reporter which, while the inbound or outbound
+// rate of the node exceeds the cap, throttles the reads and writes of the
+// streams of the non-critical protocol groups down to their ceilings. The
+// reporter is called by the streams as they are read and written, so blocking
+// in it delays the next read or write of the stream. Gossipsub is never
+// throttled, so that blocks keep propagating on constrained links.
+type BandwidthLimiter struct {
+	metrics.Reporter
+
+	cap    float64
+	groups map[string]*groupLimiter
+}
+
+type groupLimiter struct {
+	ceiling int64
+	in, out *rate.Limiter
+
+	// total time reads and writes were delayed, in nanoseconds
+	delay int64
+}
+
+func NewBandwidthLimiter(reporter metrics.Reporter, limits config.BandwidthLimits) *BandwidthLimiter {
+	bl := &BandwidthLimiter{
+		Reporter: reporter,
+		cap:      float64(limits.TotalCap),
+		groups:   make(map[string]*groupLimiter),
+	}
+
+	for group, ceiling := range map[string]int64{
+		BandwidthGroupBitswap:   limits.BitswapCeiling,
+		BandwidthGroupGraphsync: limits.GraphsyncCeiling,
+		BandwidthGroupOther:     limits.OtherCeiling,
+	} {
+		if ceiling <= 0 {
+			continue
+		}
+		// allow bursts of a second worth of data
+		bl.groups[group] = &groupLimiter{
+			ceiling: ceiling,
+			in:      rate.NewLimiter(rate.Limit(ceiling), int(ceiling)),
+			out:     rate.NewLimiter(rate.Limit(ceiling), int(ceiling)),
+		}
+	}
+
+	return bl
+}
+
+func (bl *BandwidthLimiter) LogSentMessageStream(size int64, proto protocol.ID, p peer.ID) {
+	bl.Reporter.LogSentMessageStream(size, proto, p)
+	bl.throttle(size, proto, true)
+}
+
+func (bl *BandwidthLimiter) LogRecvMessageStream(size int64, proto protocol.ID, p peer.ID) {
+	bl.Reporter.LogRecvMessageStream(size, proto, p)
+	bl.throttle(size, proto, false)
+}
+
+func (bl *BandwidthLimiter) throttle(size int64, proto protocol.ID, out bool) {
+	if bl.cap <= 0 || size <= 0 {
+		return
+	}
+
+	gl, ok := bl.groups[ProtocolGroup(proto)]
+	if !ok {
+		return
+	}
+
+	totals := bl.Reporter.GetBandwidthTotals()
+	total, lim := totals.RateIn, gl.in
+	if out {
+		total, lim = totals.RateOut, gl.out
+	}
+	if total <= bl.cap {
+		return
+	}
+
+	if size > int64(lim.Burst()) {
+		size = int64(lim.Burst())
+	}
+	if d := lim.ReserveN(time.Now(), int(size)).Delay(); d > 0 {
+		atomic.AddInt64(&gl.delay, int64(d))
+		time.Sleep(d)
+	}
+}
+
+// GroupStats returns the bandwidth used by each protocol group, along with its
+// ceiling and whether it is being throttled
+func (bl *BandwidthLimiter) GroupStats() map[string]api.ProtocolGroupBandwidth {
+	out := map[string]api.ProtocolGroupBandwidth{
+		BandwidthGroupGossipsub: {},
+		BandwidthGroupBitswap:   {},
+		BandwidthGroupGraphsync: {},
+		BandwidthGroupOther:     {},
+	}
+
+	for proto, s := range bl.Reporter.GetBandwidthByProtocol() {
+		group := ProtocolGroup(proto)
+		gs := out[group]
+		gs.TotalIn += s.TotalIn
+		gs.TotalOut += s.TotalOut
+		gs.RateIn += s.RateIn
+		gs.RateOut += s.RateOut
+		out[group] = gs
+	}
+
+	totals := bl.Reporter.GetBandwidthTotals()
+	overCap := bl.cap > 0 && (totals.RateIn > bl.cap || totals.RateOut > bl.cap)
+
+	for group, gl := range bl.groups {
+		gs := out[group]
+		gs.Ceiling = gl.ceiling
+		gs.Throttled = overCap
+		gs.Delay = time.Duration(atomic.LoadInt64(&gl.delay))
+		out[group] = gs
+	}
+
+	return out
+}
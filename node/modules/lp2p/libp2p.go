@@ -67,8 +67,8 @@ func genLibp2pKey() (crypto.PrivKey, error) {
 
 // Misc options
 
-func ConnectionManager(low, high uint, grace time.Duration, protected []string) func() (opts Libp2pOpts, err error) {
-	return func() (Libp2pOpts, error) {
+func ConnectionManager(low, high uint, grace time.Duration, protected []string) func(acl *NetACL) (opts Libp2pOpts, err error) {
+	return func(acl *NetACL) (Libp2pOpts, error) {
 		cm, err := connmgr.NewConnManager(int(low), int(high), connmgr.WithGracePeriod(grace))
 		if err != nil {
 			return Libp2pOpts{}, err
@@ -92,6 +92,8 @@ func ConnectionManager(low, high uint, grace time.Duration, protected []string)
 			cm.Protect(inf.ID, "bootstrap")
 		}
 
+		acl.setConnManager(cm)
+
 		return Libp2pOpts{
 			Opts: []libp2p.Option{libp2p.ConnectionManager(cm)},
 		}, nil
@@ -0,0 +1,272 @@
+package lp2p
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/libp2p/go-libp2p-core/connmgr"
+	"github.com/libp2p/go-libp2p-core/control"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p/p2p/net/conngater"
+	ma "github.com/multiformats/go-multiaddr"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+const (
+	// ProtectTagAPI tags the peers protected with the NetProtectAdd API
+	ProtectTagAPI = "api"
+	// ProtectTagAllowList tags the peers connected from allowed addresses
+	ProtectTagAllowList = "allowlist"
+
+	allowListNamespace = "/lotus/net/allow"
+	protectedNamespace = "/lotus/net/protected"
+)
+
+// NetACL keeps the peers protected from the connection manager and the allow
+// list of peers, IP addresses and subnets which may connect to the node even
+// when blocked by the connection gater. Connections from allowed addresses are
+// also protected. Both lists are persisted in the metadata datastore.
+type NetACL struct {
+	// the allow list rules are kept in a connection gater, where the allowed
+	// peers and addresses are "blocked"
+	allow *conngater.BasicConnectionGater
+
+	ds datastore.Datastore
+
+	lk        sync.Mutex
+	protected map[peer.ID]struct{}
+	// peers protected because they connected from an allowed address
+	allowProtected map[peer.ID]struct{}
+	cm             connmgr.ConnManager
+}
+
+func NewNetACL(mds dtypes.MetadataDS) (*NetACL, error) {
+	allow, err := conngater.NewBasicConnectionGater(namespace.Wrap(mds, datastore.NewKey(allowListNamespace)))
+	if err != nil {
+		return nil, xerrors.Errorf("loading allow list: %w", err)
+	}
+
+	acl := &NetACL{
+		allow:          allow,
+		ds:             namespace.Wrap(mds, datastore.NewKey(protectedNamespace)),
+		protected:      make(map[peer.ID]struct{}),
+		allowProtected: make(map[peer.ID]struct{}),
+	}
+
+	res, err := acl.ds.Query(context.TODO(), query.Query{})
+	if err != nil {
+		return nil, xerrors.Errorf("querying protected peers: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("loading protected peers: %w", r.Error)
+		}
+		acl.protected[peer.ID(r.Value)] = struct{}{}
+	}
+
+	return acl, nil
+}
+
+// setConnManager protects the persisted peers in the connection manager of the
+// host, which the allowed peers are protected in as they connect
+func (acl *NetACL) setConnManager(cm connmgr.ConnManager) {
+	acl.lk.Lock()
+	defer acl.lk.Unlock()
+
+	acl.cm = cm
+	for p := range acl.protected {
+		cm.Protect(p, ProtectTagAPI)
+	}
+}
+
+// Protect persists the protection of the peers from the connection manager
+func (acl *NetACL) Protect(ctx context.Context, peers ...peer.ID) error {
+	acl.lk.Lock()
+	defer acl.lk.Unlock()
+
+	for _, p := range peers {
+		if err := acl.ds.Put(ctx, protectedKey(p), []byte(p)); err != nil {
+			return xerrors.Errorf("persisting protected peer %s: %w", p, err)
+		}
+		acl.protected[p] = struct{}{}
+	}
+
+	return nil
+}
+
+func (acl *NetACL) Unprotect(ctx context.Context, peers ...peer.ID) error {
+	acl.lk.Lock()
+	defer acl.lk.Unlock()
+
+	for _, p := range peers {
+		if err := acl.ds.Delete(ctx, protectedKey(p)); err != nil {
+			return xerrors.Errorf("removing protected peer %s: %w", p, err)
+		}
+		delete(acl.protected, p)
+	}
+
+	return nil
+}
+
+// Protected lists the protected peers, connected or not
+func (acl *NetACL) Protected() []peer.ID {
+	acl.lk.Lock()
+	defer acl.lk.Unlock()
+
+	out := make([]peer.ID, 0, len(acl.protected))
+	for p := range acl.protected {
+		out = append(out, p)
+	}
+	return out
+}
+
+func (acl *NetACL) IsProtected(p peer.ID) bool {
+	acl.lk.Lock()
+	defer acl.lk.Unlock()
+
+	_, ok := acl.protected[p]
+	return ok
+}
+
+func (acl *NetACL) AllowPeer(p peer.ID) error {
+	return acl.allow.BlockPeer(p)
+}
+
+func (acl *NetACL) AllowAddr(ip net.IP) error {
+	return acl.allow.BlockAddr(ip)
+}
+
+func (acl *NetACL) AllowSubnet(ipnet *net.IPNet) error {
+	return acl.allow.BlockSubnet(ipnet)
+}
+
+func (acl *NetACL) DisallowPeer(p peer.ID) error {
+	if err := acl.allow.UnblockPeer(p); err != nil {
+		return err
+	}
+	acl.resetAllowProtection()
+	return nil
+}
+
+func (acl *NetACL) DisallowAddr(ip net.IP) error {
+	if err := acl.allow.UnblockAddr(ip); err != nil {
+		return err
+	}
+	acl.resetAllowProtection()
+	return nil
+}
+
+func (acl *NetACL) DisallowSubnet(ipnet *net.IPNet) error {
+	if err := acl.allow.UnblockSubnet(ipnet); err != nil {
+		return err
+	}
+	acl.resetAllowProtection()
+	return nil
+}
+
+func (acl *NetACL) AllowedPeers() []peer.ID {
+	return acl.allow.ListBlockedPeers()
+}
+
+func (acl *NetACL) AllowedAddrs() []net.IP {
+	return acl.allow.ListBlockedAddrs()
+}
+
+func (acl *NetACL) AllowedSubnets() []*net.IPNet {
+	return acl.allow.ListBlockedSubnets()
+}
+
+// IsAllowed returns whether the peer or any of the addresses is in the allow list
+func (acl *NetACL) IsAllowed(p peer.ID, addrs ...ma.Multiaddr) bool {
+	if p != "" && !acl.allow.InterceptPeerDial(p) {
+		return true
+	}
+	for _, a := range addrs {
+		if acl.allowsAddr(a) {
+			return true
+		}
+	}
+	return false
+}
+
+func (acl *NetACL) allowsAddr(a ma.Multiaddr) bool {
+	// the address is "blocked" by the allow list rules
+	return !acl.allow.InterceptAddrDial("", a)
+}
+
+func (acl *NetACL) protectAllowed(p peer.ID) {
+	acl.lk.Lock()
+	defer acl.lk.Unlock()
+
+	if acl.cm == nil {
+		return
+	}
+	if _, ok := acl.allowProtected[p]; ok {
+		return
+	}
+
+	acl.cm.Protect(p, ProtectTagAllowList)
+	acl.allowProtected[p] = struct{}{}
+}
+
+// resetAllowProtection drops the protection of the peers which connected from
+// allowed addresses, as they may no longer be allowed; still allowed peers are
+// protected again when they reconnect
+func (acl *NetACL) resetAllowProtection() {
+	acl.lk.Lock()
+	defer acl.lk.Unlock()
+
+	if acl.cm == nil {
+		return
+	}
+	for p := range acl.allowProtected {
+		acl.cm.Unprotect(p, ProtectTagAllowList)
+	}
+	acl.allowProtected = make(map[peer.ID]struct{})
+}
+
+func protectedKey(p peer.ID) datastore.Key {
+	return datastore.NewKey(p.String())
+}
+
+// aclGater accepts the connections in the allow list, and gates the others
+// with the connection gater
+type aclGater struct {
+	*conngater.BasicConnectionGater
+	acl *NetACL
+}
+
+var _ connmgr.ConnectionGater = (*aclGater)(nil)
+
+func (g *aclGater) InterceptPeerDial(p peer.ID) bool {
+	return g.acl.IsAllowed(p) || g.BasicConnectionGater.InterceptPeerDial(p)
+}
+
+func (g *aclGater) InterceptAddrDial(p peer.ID, a ma.Multiaddr) bool {
+	return g.acl.IsAllowed(p, a) || g.BasicConnectionGater.InterceptAddrDial(p, a)
+}
+
+func (g *aclGater) InterceptAccept(cma network.ConnMultiaddrs) bool {
+	return g.acl.allowsAddr(cma.RemoteMultiaddr()) || g.BasicConnectionGater.InterceptAccept(cma)
+}
+
+func (g *aclGater) InterceptSecured(dir network.Direction, p peer.ID, cma network.ConnMultiaddrs) bool {
+	if g.acl.IsAllowed(p, cma.RemoteMultiaddr()) {
+		g.acl.protectAllowed(p)
+		return true
+	}
+	return g.BasicConnectionGater.InterceptSecured(dir, p, cma)
+}
+
+func (g *aclGater) InterceptUpgraded(c network.Conn) (bool, control.DisconnectReason) {
+	return g.BasicConnectionGater.InterceptUpgraded(c)
+}
@@ -0,0 +1,117 @@
+package lp2p
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+// PeerTracker is a pubsub tracer which records how well each peer propagates
+// blocks and messages. Stats are dropped when a peer leaves pubsub.
+type PeerTracker struct {
+	blocksTopic, msgsTopic string
+
+	lk    sync.Mutex
+	peers map[peer.ID]*api.PeerPropagationStats
+}
+
+var _ pubsub.RawTracer = (*PeerTracker)(nil)
+
+func NewPeerTracker(nn dtypes.NetworkName) *PeerTracker {
+	return &PeerTracker{
+		blocksTopic: build.BlocksTopic(nn),
+		msgsTopic:   build.MessagesTopic(nn),
+		peers:       make(map[peer.ID]*api.PeerPropagationStats),
+	}
+}
+
+// Get returns the propagation stats of a peer, if it is known to pubsub
+func (t *PeerTracker) Get(p peer.ID) (api.PeerPropagationStats, bool) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	st, ok := t.peers[p]
+	if !ok {
+		return api.PeerPropagationStats{}, false
+	}
+	return *st, true
+}
+
+// record applies update to the stats of the topic of msg from the peer it was
+// received from, if the topic is tracked
+func (t *PeerTracker) record(msg *pubsub.Message, update func(st *api.PubsubTopicStats)) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	st, ok := t.peers[msg.ReceivedFrom]
+	if !ok {
+		return
+	}
+
+	switch msg.GetTopic() {
+	case t.blocksTopic:
+		update(&st.Blocks)
+	case t.msgsTopic:
+		update(&st.Messages)
+	}
+}
+
+func (t *PeerTracker) AddPeer(p peer.ID, proto protocol.ID) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	if _, ok := t.peers[p]; !ok {
+		t.peers[p] = &api.PeerPropagationStats{}
+	}
+}
+
+func (t *PeerTracker) RemovePeer(p peer.ID) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	delete(t.peers, p)
+}
+
+func (t *PeerTracker) DeliverMessage(msg *pubsub.Message) {
+	t.record(msg, func(st *api.PubsubTopicStats) {
+		st.FirstDeliveries++
+		st.LastDelivery = time.Now()
+	})
+}
+
+func (t *PeerTracker) RejectMessage(msg *pubsub.Message, reason string) {
+	switch reason {
+	case pubsub.RejectValidationIgnored, pubsub.RejectValidationQueueFull, pubsub.RejectValidationThrottled:
+		t.record(msg, func(st *api.PubsubTopicStats) {
+			st.Ignored++
+		})
+	default:
+		t.record(msg, func(st *api.PubsubTopicStats) {
+			st.Rejected++
+		})
+	}
+}
+
+func (t *PeerTracker) DuplicateMessage(msg *pubsub.Message) {
+	t.record(msg, func(st *api.PubsubTopicStats) {
+		st.Duplicates++
+	})
+}
+
+func (t *PeerTracker) Join(topic string)                        {}
+func (t *PeerTracker) Leave(topic string)                       {}
+func (t *PeerTracker) Graft(p peer.ID, topic string)            {}
+func (t *PeerTracker) Prune(p peer.ID, topic string)            {}
+func (t *PeerTracker) ValidateMessage(msg *pubsub.Message)      {}
+func (t *PeerTracker) ThrottlePeer(p peer.ID)                   {}
+func (t *PeerTracker) RecvRPC(rpc *pubsub.RPC)                  {}
+func (t *PeerTracker) SendRPC(rpc *pubsub.RPC, p peer.ID)       {}
+func (t *PeerTracker) DropRPC(rpc *pubsub.RPC, p peer.ID)       {}
+func (t *PeerTracker) UndeliverableMessage(msg *pubsub.Message) {}
@@ -0,0 +1,255 @@
+package lp2p
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	"github.com/libp2p/go-libp2p-core/protocol"
+	"github.com/libp2p/go-libp2p-core/record"
+	"github.com/libp2p/go-msgio"
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+)
+
+const (
+	pexTimeout       = 30 * time.Second
+	pexMaxRecords    = 256
+	pexMaxRecordSize = 16 << 10
+)
+
+// peerExchange serves the signed peer records of the node and of the members it
+// is connected to, and periodically requests them from the connected members.
+// Only members may request records, and only the records of members are
+// accepted, so that the peer set of a closed network is shared between its
+// members without a DHT. Records are signed by the peers they describe, so a
+// member can't advertise forged addresses for other members.
+type peerExchange struct {
+	h        host.Host
+	cab      peerstore.CertifiedAddrBook
+	proto    protocol.ID
+	members  map[peer.ID]struct{}
+	interval time.Duration
+}
+
+// PeerExchange runs the peer exchange between the static peers and the members
+func PeerExchange(interval time.Duration, members []string) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, h host.Host, nn dtypes.NetworkName, static dtypes.StaticPeers) error {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, h host.Host, nn dtypes.NetworkName, static dtypes.StaticPeers) error {
+		if interval <= 0 {
+			return xerrors.Errorf("invalid peer exchange interval %s", interval)
+		}
+
+		cab, ok := peerstore.GetCertifiedAddrBook(h.Peerstore())
+		if !ok {
+			return xerrors.Errorf("peer exchange requires a peerstore supporting signed peer records")
+		}
+
+		px := &peerExchange{
+			h:        h,
+			cab:      cab,
+			proto:    build.PeerExchangeProtocolName(nn),
+			members:  make(map[peer.ID]struct{}),
+			interval: interval,
+		}
+		for _, pi := range static {
+			px.members[pi.ID] = struct{}{}
+		}
+		for _, m := range members {
+			p, err := peer.Decode(m)
+			if err != nil {
+				return xerrors.Errorf("parsing peer exchange member %q: %w", m, err)
+			}
+			px.members[p] = struct{}{}
+		}
+
+		ctx, cancel := context.WithCancel(helpers.LifecycleCtx(mctx, lc))
+		done := make(chan struct{})
+
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				h.SetStreamHandler(px.proto, px.handleStream)
+				go func() {
+					defer close(done)
+					px.run(ctx)
+				}()
+				return nil
+			},
+			OnStop: func(stopCtx context.Context) error {
+				h.RemoveStreamHandler(px.proto)
+				cancel()
+				select {
+				case <-done:
+				case <-stopCtx.Done():
+				}
+				return nil
+			},
+		})
+
+		return nil
+	}
+}
+
+func (px *peerExchange) isMember(p peer.ID) bool {
+	_, ok := px.members[p]
+	return ok
+}
+
+func (px *peerExchange) handleStream(s network.Stream) {
+	defer s.Close() //nolint:errcheck
+
+	if !px.isMember(s.Conn().RemotePeer()) {
+		log.Debugw("refusing peer exchange with non-member", "peer", s.Conn().RemotePeer())
+		_ = s.Reset()
+		return
+	}
+
+	_ = s.SetWriteDeadline(time.Now().Add(pexTimeout))
+
+	w := msgio.NewVarintWriter(s)
+	for _, rec := range px.records() {
+		if err := w.WriteMsg(rec); err != nil {
+			log.Debugw("failed to send peer record", "peer", s.Conn().RemotePeer(), "error", err)
+			_ = s.Reset()
+			return
+		}
+	}
+}
+
+// records returns the signed peer records of the node and of the connected
+// members
+func (px *peerExchange) records() [][]byte {
+	peers := []peer.ID{px.h.ID()}
+	for p := range px.members {
+		if px.h.Network().Connectedness(p) == network.Connected {
+			peers = append(peers, p)
+		}
+	}
+
+	var out [][]byte
+	for _, p := range peers {
+		env := px.cab.GetPeerRecord(p)
+		if env == nil {
+			continue
+		}
+		b, err := env.Marshal()
+		if err != nil {
+			log.Warnw("failed to marshal peer record", "peer", p, "error", err)
+			continue
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+func (px *peerExchange) run(ctx context.Context) {
+	tick := build.Clock.Ticker(px.interval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-tick.C:
+			px.exchangeAll(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// exchangeAll requests the peer records of the connected members, and connects
+// to the members it learns about
+func (px *peerExchange) exchangeAll(ctx context.Context) {
+	found := make(map[peer.ID]struct{})
+	for p := range px.members {
+		if px.h.Network().Connectedness(p) != network.Connected {
+			continue
+		}
+
+		peers, err := px.exchange(ctx, p)
+		if err != nil {
+			log.Debugw("peer exchange failed", "peer", p, "error", err)
+			continue
+		}
+		for _, fp := range peers {
+			found[fp] = struct{}{}
+		}
+	}
+
+	var wg sync.WaitGroup
+	for p := range found {
+		if px.h.Network().Connectedness(p) == network.Connected {
+			continue
+		}
+
+		wg.Add(1)
+		go func(p peer.ID) {
+			defer wg.Done()
+
+			cctx, cancel := context.WithTimeout(ctx, pexTimeout)
+			defer cancel()
+
+			if err := px.h.Connect(cctx, peer.AddrInfo{ID: p}); err != nil {
+				log.Debugw("failed to connect to peer found through peer exchange", "peer", p, "error", err)
+			}
+		}(p)
+	}
+	wg.Wait()
+}
+
+// exchange requests the peer records of a member, and adds the addresses of the
+// members in them to the peerstore
+func (px *peerExchange) exchange(ctx context.Context, p peer.ID) ([]peer.ID, error) {
+	ctx, cancel := context.WithTimeout(ctx, pexTimeout)
+	defer cancel()
+
+	s, err := px.h.NewStream(ctx, p, px.proto)
+	if err != nil {
+		return nil, xerrors.Errorf("opening stream: %w", err)
+	}
+	defer s.Close() //nolint:errcheck
+
+	_ = s.CloseWrite()
+	_ = s.SetReadDeadline(time.Now().Add(pexTimeout))
+
+	var out []peer.ID
+	r := msgio.NewVarintReaderSize(s, pexMaxRecordSize)
+	for i := 0; i < pexMaxRecords; i++ {
+		msg, err := r.ReadMsg()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return out, xerrors.Errorf("reading peer record: %w", err)
+		}
+
+		env, rec, err := record.ConsumeEnvelope(msg, peer.PeerRecordEnvelopeDomain)
+		r.ReleaseMsg(msg)
+		if err != nil {
+			log.Debugw("invalid peer record", "from", p, "error", err)
+			continue
+		}
+
+		prec, ok := rec.(*peer.PeerRecord)
+		if !ok || prec.PeerID == px.h.ID() || !px.isMember(prec.PeerID) {
+			continue
+		}
+
+		// the certified address book checks that the record is signed by the
+		// peer it describes
+		if _, err := px.cab.ConsumePeerRecord(env, peerstore.AddressTTL); err != nil {
+			log.Debugw("rejected peer record", "from", p, "peer", prec.PeerID, "error", err)
+			continue
+		}
+		out = append(out, prec.PeerID)
+	}
+
+	return out, nil
+}
@@ -59,6 +59,7 @@ type GossipIn struct {
 	Db   dtypes.DrandBootstrap
 	Cfg  *config.Pubsub
 	Sk   *dtypes.ScoreKeeper
+	Pt   *PeerTracker
 	Dr   dtypes.DrandSchedule
 }
 
@@ -292,6 +293,7 @@ func GossipSub(in GossipIn) (service *pubsub.PubSub, err error) {
 			},
 		),
 		pubsub.WithPeerScoreInspect(in.Sk.Update, 10*time.Second),
+		pubsub.WithRawTracer(in.Pt),
 	}
 
 	// enable Peer eXchange on bootstrappers
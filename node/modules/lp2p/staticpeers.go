@@ -0,0 +1,149 @@
+package lp2p
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	"github.com/libp2p/go-libp2p/p2p/net/swarm"
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+)
+
+// ProtectTagStatic tags the connections to static peers in the connection manager
+const ProtectTagStatic = "static"
+
+const staticPeerConnTimeout = 30 * time.Second
+
+type staticPeering struct {
+	h        host.Host
+	peers    map[peer.ID]peer.AddrInfo
+	interval time.Duration
+
+	// redial is signalled when a static peer disconnects
+	redial chan struct{}
+}
+
+// StaticPeering keeps the node connected to the static peers, redialing them
+// every interval and as soon as they disconnect
+func StaticPeering(interval time.Duration) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, h host.Host, static dtypes.StaticPeers) error {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, h host.Host, static dtypes.StaticPeers) error {
+		if interval <= 0 {
+			return xerrors.Errorf("invalid static peers reconnect interval %s", interval)
+		}
+		if len(static) == 0 {
+			return nil
+		}
+
+		sp := &staticPeering{
+			h:        h,
+			peers:    make(map[peer.ID]peer.AddrInfo, len(static)),
+			interval: interval,
+			redial:   make(chan struct{}, 1),
+		}
+		for _, pi := range static {
+			if existing, ok := sp.peers[pi.ID]; ok {
+				pi.Addrs = append(existing.Addrs, pi.Addrs...)
+			}
+			sp.peers[pi.ID] = pi
+		}
+
+		for id, pi := range sp.peers {
+			h.Peerstore().AddAddrs(id, pi.Addrs, peerstore.PermanentAddrTTL)
+			h.ConnManager().Protect(id, ProtectTagStatic)
+		}
+
+		notifee := &network.NotifyBundle{
+			DisconnectedF: func(n network.Network, c network.Conn) {
+				if _, ok := sp.peers[c.RemotePeer()]; !ok {
+					return
+				}
+				select {
+				case sp.redial <- struct{}{}:
+				default:
+				}
+			},
+		}
+
+		ctx, cancel := context.WithCancel(helpers.LifecycleCtx(mctx, lc))
+		done := make(chan struct{})
+
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				h.Network().Notify(notifee)
+				go func() {
+					defer close(done)
+					sp.run(ctx)
+				}()
+				return nil
+			},
+			OnStop: func(stopCtx context.Context) error {
+				h.Network().StopNotify(notifee)
+				cancel()
+				select {
+				case <-done:
+				case <-stopCtx.Done():
+				}
+				return nil
+			},
+		})
+
+		return nil
+	}
+}
+
+func (sp *staticPeering) run(ctx context.Context) {
+	tick := build.Clock.Ticker(sp.interval)
+	defer tick.Stop()
+
+	sp.connect(ctx, false)
+	for {
+		select {
+		case <-tick.C:
+			sp.connect(ctx, true)
+		case <-sp.redial:
+			sp.connect(ctx, false)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// connect dials the disconnected static peers; when clearBackoff is set, the
+// dial backoff of the peers is cleared first, so that static peers which were
+// unreachable for a while are still redialed every interval
+func (sp *staticPeering) connect(ctx context.Context, clearBackoff bool) {
+	var wg sync.WaitGroup
+	for _, pi := range sp.peers {
+		if sp.h.Network().Connectedness(pi.ID) == network.Connected {
+			continue
+		}
+
+		if clearBackoff {
+			if swrm, ok := sp.h.Network().(*swarm.Swarm); ok {
+				swrm.Backoff().Clear(pi.ID)
+			}
+		}
+
+		wg.Add(1)
+		go func(pi peer.AddrInfo) {
+			defer wg.Done()
+
+			cctx, cancel := context.WithTimeout(ctx, staticPeerConnTimeout)
+			defer cancel()
+
+			if err := sp.h.Connect(cctx, pi); err != nil {
+				log.Warnw("failed to connect to static peer", "peer", pi.ID, "error", err)
+			}
+		}(pi)
+	}
+	wg.Wait()
+}
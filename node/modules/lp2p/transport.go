@@ -6,6 +6,8 @@ import (
 	noise "github.com/libp2p/go-libp2p/p2p/security/noise"
 	tls "github.com/libp2p/go-libp2p/p2p/security/tls"
 	libp2pquic "github.com/libp2p/go-libp2p/p2p/transport/quic"
+
+	"github.com/filecoin-project/lotus/node/config"
 )
 
 var DefaultTransports = simpleOpt(libp2p.DefaultTransports)
@@ -31,8 +33,10 @@ func Security(enabled, preferTLS bool) interface{} {
 	}
 }
 
-func BandwidthCounter() (opts Libp2pOpts, reporter metrics.Reporter) {
-	reporter = metrics.NewBandwidthCounter()
-	opts.Opts = append(opts.Opts, libp2p.BandwidthReporter(reporter))
-	return opts, reporter
+func BandwidthCounter(limits config.BandwidthLimits) func() (opts Libp2pOpts, reporter metrics.Reporter, bl *BandwidthLimiter) {
+	return func() (opts Libp2pOpts, reporter metrics.Reporter, bl *BandwidthLimiter) {
+		bl = NewBandwidthLimiter(metrics.NewBandwidthCounter(), limits)
+		opts.Opts = append(opts.Opts, libp2p.BandwidthReporter(bl))
+		return opts, bl, bl
+	}
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
 	"github.com/filecoin-project/lotus/node/modules/helpers"
 	"github.com/filecoin-project/lotus/paychmgr"
+	"github.com/filecoin-project/lotus/paychmgr/budget"
 )
 
 func NewManager(mctx helpers.MetricsCtx, lc fx.Lifecycle, sm stmgr.StateManagerAPI, pchstore *paychmgr.Store, api paychmgr.PaychAPI) *paychmgr.Manager {
@@ -21,6 +22,28 @@ func NewManager(mctx helpers.MetricsCtx, lc fx.Lifecycle, sm stmgr.StateManagerA
 	return paychmgr.NewManager(ctx, shutdown, sm, pchstore, api)
 }
 
+// NewPaychBudgetManager creates the manager of the payment channels paying for
+// retrievals
+func NewPaychBudgetManager(lc fx.Lifecycle, pm *paychmgr.Manager, ds dtypes.MetadataDS) (*budget.Manager, error) {
+	bm, err := budget.NewManager(pm, ds)
+	if err != nil {
+		return nil, err
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			bm.Start()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			bm.Stop()
+			return nil
+		},
+	})
+
+	return bm, nil
+}
+
 func NewPaychStore(ds dtypes.MetadataDS) *paychmgr.Store {
 	ds = namespace.Wrap(ds, datastore.NewKey("/paych/"))
 	return paychmgr.NewStore(ds)
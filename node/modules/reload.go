@@ -0,0 +1,264 @@
+package modules
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	logging "github.com/ipfs/go-log/v2"
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/lib/apitls"
+	"github.com/filecoin-project/lotus/lib/lotuslog"
+	"github.com/filecoin-project/lotus/markets/dealfilter"
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/repo"
+	"github.com/filecoin-project/lotus/storage/ctladdr"
+)
+
+// FullNodeConfigReloader reloads the config of the full node on SIGHUP, and
+// with the ConfigReload API
+func FullNodeConfigReloader(lc fx.Lifecycle, r repo.LockedRepo, tlsSrv *apitls.Server) (*config.Reloader, error) {
+	sections := append(commonReloadSections(tlsSrv),
+		// read from the reloader when messages are pushed, see NewDefaultMaxFeeFunc
+		config.ReloadSection{Key: "Fees.DefaultMaxFee"},
+	)
+
+	return newConfigReloader(lc, r, sections)
+}
+
+type MinerConfigReloaderParams struct {
+	fx.In
+
+	Lifecycle  fx.Lifecycle
+	Repo       repo.LockedRepo
+	AddrSel    *ctladdr.AddressSelector `optional:"true"`
+	DealFilter *dealfilter.CliFilter    `optional:"true"`
+	APITLS     *apitls.Server           `optional:"true"`
+}
+
+// MinerConfigReloader reloads the config of the miner on SIGHUP, and with the
+// ConfigReload API
+func MinerConfigReloader(params MinerConfigReloaderParams) (*config.Reloader, error) {
+	sections := commonReloadSections(params.APITLS)
+
+	// read from the reloader when messages are sent, see MinerFeeConfig;
+	// the deal fees are set up when the markets start
+	for _, key := range []string{
+		"MaxPreCommitGasFee",
+		"MaxCommitGasFee",
+		"MaxPreCommitBatchGasFee",
+		"MaxCommitBatchGasFee",
+		"MaxTerminateGasFee",
+		"MaxWindowPoStGasFee",
+	} {
+		sections = append(sections, config.ReloadSection{Key: "Fees." + key})
+	}
+
+	sections = append(sections, config.ReloadSection{
+		Key: "Addresses",
+		Validate: func(raw interface{}) error {
+			_, err := parseAddressConfig(&raw.(*config.StorageMiner).Addresses)
+			return err
+		},
+		Apply: func(raw interface{}) error {
+			if params.AddrSel == nil {
+				return nil
+			}
+
+			ac, err := parseAddressConfig(&raw.(*config.StorageMiner).Addresses)
+			if err != nil {
+				return err
+			}
+			params.AddrSel.SetConfig(ac)
+			return nil
+		},
+	})
+
+	validateFilters := func(raw interface{}) error {
+		dc := raw.(*config.StorageMiner).Dealmaking
+		if dc.Filter != "" && dc.FilterWebhook != "" {
+			return xerrors.Errorf("Filter and FilterWebhook can't both be set")
+		}
+		return nil
+	}
+	applyFilters := func(raw interface{}) error {
+		if params.DealFilter == nil {
+			return nil
+		}
+
+		dc := raw.(*config.StorageMiner).Dealmaking
+		params.DealFilter.SetCommands(dc.Filter, dc.RetrievalFilter)
+		return nil
+	}
+	sections = append(sections,
+		config.ReloadSection{Key: "Dealmaking.Filter", Validate: validateFilters, Apply: applyFilters},
+		config.ReloadSection{Key: "Dealmaking.RetrievalFilter", Apply: applyFilters},
+	)
+
+	// read from the reloader when they are used; the changes made with the
+	// API are persisted and made to the running config, see mutateDealmakingCfg
+	for _, key := range []string{
+		"Dealmaking.ConsiderOnlineStorageDeals",
+		"Dealmaking.ConsiderOfflineStorageDeals",
+		"Dealmaking.ConsiderOnlineRetrievalDeals",
+		"Dealmaking.ConsiderOfflineRetrievalDeals",
+		"Dealmaking.ConsiderVerifiedStorageDeals",
+		"Dealmaking.ConsiderUnverifiedStorageDeals",
+		"Dealmaking.PieceCidBlocklist",
+		"Dealmaking.ExpectedSealDuration",
+		"Dealmaking.MaxDealStartDelay",
+		"Sealing",
+	} {
+		sections = append(sections, config.ReloadSection{Key: key})
+	}
+
+	return newConfigReloader(params.Lifecycle, params.Repo, sections)
+}
+
+// MinerFeeConfig returns the fee config of the miner, as of the last config reload
+func MinerFeeConfig(r *config.Reloader) config.GetMinerFeeConfigFunc {
+	return func() config.MinerFeeConfig {
+		return r.Config().(*config.StorageMiner).Fees
+	}
+}
+
+func commonReloadSections(tlsSrv *apitls.Server) []config.ReloadSection {
+	return append(loggingReloadSections(), apiTLSReloadSection(tlsSrv))
+}
+
+// apiTLSReloadSection switches the API listener to other TLS files; the
+// changes of the files themselves are picked up by the listener
+func apiTLSReloadSection(tlsSrv *apitls.Server) config.ReloadSection {
+	return config.ReloadSection{
+		Key: "API.TLS",
+		Validate: func(raw interface{}) error {
+			f := apiTLSFiles(commonConfig(raw).API.TLS)
+			switch {
+			case tlsSrv == nil && f.Enabled():
+				return xerrors.Errorf("enabling TLS on the API requires a restart")
+			case tlsSrv != nil && !f.Enabled():
+				return xerrors.Errorf("disabling TLS on the API requires a restart")
+			case tlsSrv == nil:
+				return nil
+			}
+			return apitls.Validate(f)
+		},
+		Apply: func(raw interface{}) error {
+			if tlsSrv == nil {
+				return nil
+			}
+			return tlsSrv.Update(apiTLSFiles(commonConfig(raw).API.TLS))
+		},
+	}
+}
+
+func loggingReloadSections() []config.ReloadSection {
+	return []config.ReloadSection{
+		{
+			Key: "Logging.Format",
+			Validate: func(raw interface{}) error {
+				format := commonConfig(raw).Logging.Format
+				if format == "" {
+					return nil
+				}
+				_, err := lotuslog.ParseFormat(format)
+				return err
+			},
+			Apply: func(raw interface{}) error {
+				lcfg := commonConfig(raw).Logging
+				if err := lotuslog.SetFormat(lcfg.Format); err != nil {
+					return err
+				}
+				// setting the format resets the levels
+				lotuslog.SetLevelsFromConfig(lcfg.SubsystemLevels)
+				return nil
+			},
+		},
+		{
+			Key: "Logging.SubsystemLevels",
+			Validate: func(raw interface{}) error {
+				for sys, level := range commonConfig(raw).Logging.SubsystemLevels {
+					if _, err := logging.LevelFromString(level); err != nil {
+						return xerrors.Errorf("level of %s: %w", sys, err)
+					}
+				}
+				return nil
+			},
+			Apply: func(raw interface{}) error {
+				lotuslog.SetLevelsFromConfig(commonConfig(raw).Logging.SubsystemLevels)
+				return nil
+			},
+		},
+	}
+}
+
+func commonConfig(raw interface{}) *config.Common {
+	switch cfg := raw.(type) {
+	case *config.FullNode:
+		return &cfg.Common
+	case *config.StorageMiner:
+		return &cfg.Common
+	default:
+		panic(xerrors.Errorf("unexpected config type %T", raw))
+	}
+}
+
+func newConfigReloader(lc fx.Lifecycle, r repo.LockedRepo, sections []config.ReloadSection) (*config.Reloader, error) {
+	rl, err := config.NewReloader(r.Config, sections...)
+	if err != nil {
+		return nil, err
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			signal.Notify(sigCh, syscall.SIGHUP)
+			go func() {
+				for {
+					select {
+					case <-sigCh:
+						log.Info("received SIGHUP, reloading config")
+						reloadConfig(rl)
+					case <-done:
+						return
+					}
+				}
+			}()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			signal.Stop(sigCh)
+			close(done)
+			return nil
+		},
+	})
+
+	return rl, nil
+}
+
+func reloadConfig(rl *config.Reloader) {
+	res, err := rl.Reload()
+	if err != nil && (res == nil || !res.Applied) {
+		log.Errorf("reloading config: %+v", err)
+		return
+	}
+
+	if !res.Applied {
+		for _, c := range res.Rejected {
+			log.Errorw("config change requires a restart", "key", c.Key, "old", c.Old, "new", c.New)
+		}
+		log.Errorf("config not reloaded, %d changes require a restart", len(res.Rejected))
+		return
+	}
+
+	for _, c := range res.Changes {
+		log.Infow("config reloaded", "key", c.Key, "old", c.Old, "new", c.New)
+	}
+	if err != nil {
+		log.Errorf("reloading config: %+v", err)
+	}
+}
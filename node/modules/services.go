@@ -29,6 +29,7 @@ import (
 	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/chain/store"
 	"github.com/filecoin-project/lotus/chain/sub"
+	"github.com/filecoin-project/lotus/chain/sub/propstats"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/journal"
 	"github.com/filecoin-project/lotus/journal/fsjournal"
@@ -145,7 +146,8 @@ func HandleIncomingBlocks(mctx helpers.MetricsCtx,
 	chain *store.ChainStore,
 	cns consensus.Consensus,
 	h host.Host,
-	nn dtypes.NetworkName) {
+	nn dtypes.NetworkName,
+	props *propstats.Tracker) {
 	ctx := helpers.LifecycleCtx(mctx, lc)
 
 	v := sub.NewBlockValidator(
@@ -153,7 +155,7 @@ func HandleIncomingBlocks(mctx helpers.MetricsCtx,
 		func(p peer.ID) {
 			ps.BlacklistPeer(p)
 			h.ConnManager().TagPeer(p, "badblock", -1000)
-		})
+		}, props)
 
 	if err := ps.RegisterTopicValidator(build.BlocksTopic(nn), v.Validate); err != nil {
 		panic(err)
@@ -166,7 +168,7 @@ func HandleIncomingBlocks(mctx helpers.MetricsCtx,
 		panic(err)
 	}
 
-	go sub.HandleIncomingBlocks(ctx, blocksub, s, bserv, h.ConnManager())
+	go sub.HandleIncomingBlocks(ctx, blocksub, s, bserv, h.ConnManager(), props)
 }
 
 func HandleIncomingMessages(mctx helpers.MetricsCtx, lc fx.Lifecycle, ps *pubsub.PubSub, stmgr *stmgr.StateManager, mpool *messagepool.MessagePool, h host.Host, nn dtypes.NetworkName, bootstrapper dtypes.Bootstrapper) {
@@ -249,9 +251,11 @@ func RetrievalResolver(l *discoveryimpl.Local) discovery.PeerResolver {
 type RandomBeaconParams struct {
 	fx.In
 
-	PubSub      *pubsub.PubSub `optional:"true"`
-	Cs          *store.ChainStore
-	DrandConfig dtypes.DrandSchedule
+	PubSub       *pubsub.PubSub `optional:"true"`
+	Cs           *store.ChainStore
+	Ds           dtypes.MetadataDS
+	DrandConfig  dtypes.DrandSchedule
+	ClientConfig dtypes.DrandClientConfig
 }
 
 func BuiltinDrandConfig() dtypes.DrandSchedule {
@@ -264,12 +268,28 @@ func RandomSchedule(lc fx.Lifecycle, mctx helpers.MetricsCtx, p RandomBeaconPara
 		return nil, err
 	}
 
+	var ds datastore.Batching
+	if p.ClientConfig.PersistentCache {
+		ds = namespace.Wrap(p.Ds, datastore.NewKey("/drand"))
+	}
+
 	shd := beacon.Schedule{}
-	for _, dc := range p.DrandConfig {
-		bc, err := drand.NewDrandBeacon(gen.Timestamp, build.BlockDelaySecs, p.PubSub, dc.Config)
+	for i, dc := range p.DrandConfig {
+		cfg := dc.Config
+		// extra endpoints serve the current drand network
+		if i == len(p.DrandConfig)-1 && len(p.ClientConfig.Endpoints) > 0 {
+			cfg.Servers = append(append([]string{}, cfg.Servers...), p.ClientConfig.Endpoints...)
+		}
+
+		bc, err := drand.NewDrandBeacon(gen.Timestamp, build.BlockDelaySecs, p.PubSub, cfg, p.ClientConfig, ds)
 		if err != nil {
 			return nil, xerrors.Errorf("creating drand beacon: %w", err)
 		}
+		lc.Append(fx.Hook{
+			OnStop: func(context.Context) error {
+				return bc.Close()
+			},
+		})
 		shd = append(shd, beacon.BeaconPoint{Start: dc.Start, Beacon: bc})
 	}
 
@@ -18,6 +18,7 @@ import (
 	gsnet "github.com/ipfs/go-graphsync/network"
 	"github.com/ipfs/go-graphsync/storeutil"
 	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/peer"
 	"go.uber.org/fx"
 	"go.uber.org/multierr"
 	"golang.org/x/xerrors"
@@ -45,6 +46,7 @@ import (
 	provider "github.com/filecoin-project/index-provider"
 
 	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/api/client"
 	"github.com/filecoin-project/lotus/api/v0api"
 	"github.com/filecoin-project/lotus/api/v1api"
 	"github.com/filecoin-project/lotus/blockstore"
@@ -53,12 +55,15 @@ import (
 	"github.com/filecoin-project/lotus/chain/gen"
 	"github.com/filecoin-project/lotus/chain/gen/slashfilter"
 	"github.com/filecoin-project/lotus/chain/types"
+	cliutil "github.com/filecoin-project/lotus/cli/util"
 	"github.com/filecoin-project/lotus/journal"
 	"github.com/filecoin-project/lotus/markets"
 	"github.com/filecoin-project/lotus/markets/dagstore"
+	"github.com/filecoin-project/lotus/markets/httpretrieval"
 	"github.com/filecoin-project/lotus/markets/idxprov"
 	marketevents "github.com/filecoin-project/lotus/markets/loggers"
 	"github.com/filecoin-project/lotus/markets/pricing"
+	"github.com/filecoin-project/lotus/markets/transferlimit"
 	lotusminer "github.com/filecoin-project/lotus/miner"
 	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules/dtypes"
@@ -66,11 +71,14 @@ import (
 	"github.com/filecoin-project/lotus/node/repo"
 	"github.com/filecoin-project/lotus/storage"
 	"github.com/filecoin-project/lotus/storage/ctladdr"
+	"github.com/filecoin-project/lotus/storage/disputer"
+	"github.com/filecoin-project/lotus/storage/notify"
 	"github.com/filecoin-project/lotus/storage/paths"
 	sealing "github.com/filecoin-project/lotus/storage/pipeline"
 	"github.com/filecoin-project/lotus/storage/pipeline/sealiface"
 	"github.com/filecoin-project/lotus/storage/sealer"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+	"github.com/filecoin-project/lotus/storage/sealer/workerboot"
 	"github.com/filecoin-project/lotus/storage/wdpost"
 )
 
@@ -159,47 +167,59 @@ func AddressSelector(addrConf *config.MinerAddressConfig) func() (*ctladdr.Addre
 			return as, nil
 		}
 
-		as.DisableOwnerFallback = addrConf.DisableOwnerFallback
-		as.DisableWorkerFallback = addrConf.DisableWorkerFallback
+		ac, err := parseAddressConfig(addrConf)
+		if err != nil {
+			return nil, err
+		}
+		as.SetConfig(ac)
 
-		for _, s := range addrConf.PreCommitControl {
-			addr, err := address.NewFromString(s)
-			if err != nil {
-				return nil, xerrors.Errorf("parsing precommit control address: %w", err)
-			}
+		return as, nil
+	}
+}
+
+func parseAddressConfig(addrConf *config.MinerAddressConfig) (api.AddressConfig, error) {
+	ac := api.AddressConfig{
+		DisableOwnerFallback:  addrConf.DisableOwnerFallback,
+		DisableWorkerFallback: addrConf.DisableWorkerFallback,
+	}
 
-			as.PreCommitControl = append(as.PreCommitControl, addr)
+	for _, s := range addrConf.PreCommitControl {
+		addr, err := address.NewFromString(s)
+		if err != nil {
+			return api.AddressConfig{}, xerrors.Errorf("parsing precommit control address: %w", err)
 		}
 
-		for _, s := range addrConf.CommitControl {
-			addr, err := address.NewFromString(s)
-			if err != nil {
-				return nil, xerrors.Errorf("parsing commit control address: %w", err)
-			}
+		ac.PreCommitControl = append(ac.PreCommitControl, addr)
+	}
 
-			as.CommitControl = append(as.CommitControl, addr)
+	for _, s := range addrConf.CommitControl {
+		addr, err := address.NewFromString(s)
+		if err != nil {
+			return api.AddressConfig{}, xerrors.Errorf("parsing commit control address: %w", err)
 		}
 
-		for _, s := range addrConf.TerminateControl {
-			addr, err := address.NewFromString(s)
-			if err != nil {
-				return nil, xerrors.Errorf("parsing terminate control address: %w", err)
-			}
+		ac.CommitControl = append(ac.CommitControl, addr)
+	}
 
-			as.TerminateControl = append(as.TerminateControl, addr)
+	for _, s := range addrConf.TerminateControl {
+		addr, err := address.NewFromString(s)
+		if err != nil {
+			return api.AddressConfig{}, xerrors.Errorf("parsing terminate control address: %w", err)
 		}
 
-		for _, s := range addrConf.DealPublishControl {
-			addr, err := address.NewFromString(s)
-			if err != nil {
-				return nil, xerrors.Errorf("parsing deal publishing control address: %w", err)
-			}
+		ac.TerminateControl = append(ac.TerminateControl, addr)
+	}
 
-			as.DealPublishControl = append(as.DealPublishControl, addr)
+	for _, s := range addrConf.DealPublishControl {
+		addr, err := address.NewFromString(s)
+		if err != nil {
+			return api.AddressConfig{}, xerrors.Errorf("parsing deal publishing control address: %w", err)
 		}
 
-		return as, nil
+		ac.DealPublishControl = append(ac.DealPublishControl, addr)
 	}
+
+	return ac, nil
 }
 
 type StorageMinerParams struct {
@@ -216,46 +236,60 @@ type StorageMinerParams struct {
 	GetSealingConfigFn dtypes.GetSealingConfigFunc
 	Journal            journal.Journal
 	AddrSel            *ctladdr.AddressSelector
+	GetFeeConfig       config.GetMinerFeeConfigFunc
 	Maddr              dtypes.MinerAddress
 }
 
-func StorageMiner(fc config.MinerFeeConfig) func(params StorageMinerParams) (*storage.Miner, error) {
-	return func(params StorageMinerParams) (*storage.Miner, error) {
-		var (
-			ds     = params.MetadataDS
-			mctx   = params.MetricsCtx
-			lc     = params.Lifecycle
-			api    = params.API
-			sealer = params.Sealer
-			sc     = params.SectorIDCounter
-			verif  = params.Verifier
-			prover = params.Prover
-			gsd    = params.GetSealingConfigFn
-			j      = params.Journal
-			as     = params.AddrSel
-			maddr  = address.Address(params.Maddr)
-		)
+func StorageMiner(params StorageMinerParams) (*storage.Miner, error) {
+	var (
+		ds     = params.MetadataDS
+		mctx   = params.MetricsCtx
+		lc     = params.Lifecycle
+		api    = params.API
+		sealer = params.Sealer
+		sc     = params.SectorIDCounter
+		verif  = params.Verifier
+		prover = params.Prover
+		gsd    = params.GetSealingConfigFn
+		j      = params.Journal
+		as     = params.AddrSel
+		fc     = params.GetFeeConfig
+		maddr  = address.Address(params.Maddr)
+	)
 
-		ctx := helpers.LifecycleCtx(mctx, lc)
+	ctx := helpers.LifecycleCtx(mctx, lc)
 
-		sm, err := storage.NewMiner(api, maddr, ds, sealer, sc, verif, prover, gsd, fc, j, as)
-		if err != nil {
-			return nil, err
-		}
+	sm, err := storage.NewMiner(api, maddr, ds, sealer, sc, verif, prover, gsd, fc, j, as)
+	if err != nil {
+		return nil, err
+	}
 
-		lc.Append(fx.Hook{
-			OnStart: func(context.Context) error {
-				return sm.Run(ctx)
-			},
-			OnStop: sm.Stop,
-		})
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			return sm.Run(ctx)
+		},
+		OnStop: sm.Stop,
+	})
 
-		return sm, nil
-	}
+	return sm, nil
 }
 
-func WindowPostScheduler(fc config.MinerFeeConfig, pc config.ProvingConfig) func(params StorageMinerParams) (*wdpost.WindowPoStScheduler, error) {
-	return func(params StorageMinerParams) (*wdpost.WindowPoStScheduler, error) {
+type WindowPoStSchedulerParams struct {
+	fx.In
+
+	Lifecycle    fx.Lifecycle
+	MetricsCtx   helpers.MetricsCtx
+	API          v1api.FullNode
+	Sealer       sealer.SectorManager
+	Verifier     storiface.Verifier
+	Journal      journal.Journal
+	AddrSel      *ctladdr.AddressSelector
+	GetFeeConfig config.GetMinerFeeConfigFunc
+	Maddr        dtypes.MinerAddress
+}
+
+func WindowPostScheduler(pc config.ProvingConfig) func(params WindowPoStSchedulerParams) (*wdpost.WindowPoStScheduler, error) {
+	return func(params WindowPoStSchedulerParams) (*wdpost.WindowPoStScheduler, error) {
 		var (
 			mctx   = params.MetricsCtx
 			lc     = params.Lifecycle
@@ -264,12 +298,33 @@ func WindowPostScheduler(fc config.MinerFeeConfig, pc config.ProvingConfig) func
 			verif  = params.Verifier
 			j      = params.Journal
 			as     = params.AddrSel
+			fc     = params.GetFeeConfig
 			maddr  = address.Address(params.Maddr)
 		)
 
 		ctx := helpers.LifecycleCtx(mctx, lc)
 
-		fps, err := wdpost.NewWindowedPoStScheduler(api, fc, pc, as, sealer, verif, sealer, j, maddr)
+		var nodes []wdpost.PushAPI
+		for i, info := range pc.Sender.FullNodes {
+			ainfo := cliutil.ParseApiInfo(info)
+			addr, err := ainfo.DialArgs("v1")
+			if err != nil {
+				return nil, xerrors.Errorf("parsing Proving.Sender.FullNodes[%d]: %w", i, err)
+			}
+			node, closer, err := client.NewFullNodeRPCV1(ctx, addr, ainfo.AuthHeader())
+			if err != nil {
+				return nil, xerrors.Errorf("connecting to Proving.Sender.FullNodes[%d]: %w", i, err)
+			}
+			lc.Append(fx.Hook{
+				OnStop: func(context.Context) error {
+					closer()
+					return nil
+				},
+			})
+			nodes = append(nodes, node)
+		}
+
+		fps, err := wdpost.NewWindowedPoStScheduler(api, fc, pc, as, sealer, verif, sealer, j, maddr, nodes)
 
 		if err != nil {
 			return nil, err
@@ -621,10 +676,9 @@ func StorageProvider(minerAddress dtypes.MinerAddress,
 
 	dir := filepath.Join(r.Path(), StagingAreaDirName)
 
-	// migrate temporary files that were created directly under the repo, by
-	// moving them to the new directory and symlinking them.
-	oldDir := r.Path()
-	if err := migrateDealStaging(oldDir, dir); err != nil {
+	// staged deals created directly under the repo are moved by the
+	// deal-staging-dir repo migration
+	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil, xerrors.Errorf("failed to make deal staging directory %w", err)
 	}
 
@@ -690,13 +744,16 @@ func RetrievalNetwork(h host.Host) rmnet.RetrievalMarketNetwork {
 
 // RetrievalPricingFunc configures the pricing function to use for retrieval deals.
 func RetrievalPricingFunc(cfg config.DealmakingConfig) func(_ dtypes.ConsiderOnlineRetrievalDealsConfigFunc,
-	_ dtypes.ConsiderOfflineRetrievalDealsConfigFunc) dtypes.RetrievalPricingFunc {
+	_ dtypes.ConsiderOfflineRetrievalDealsConfigFunc, _ dtypes.GetRetrievalPricingPolicyFunc) dtypes.RetrievalPricingFunc {
 
 	return func(_ dtypes.ConsiderOnlineRetrievalDealsConfigFunc,
-		_ dtypes.ConsiderOfflineRetrievalDealsConfigFunc) dtypes.RetrievalPricingFunc {
+		_ dtypes.ConsiderOfflineRetrievalDealsConfigFunc, policyFunc dtypes.GetRetrievalPricingPolicyFunc) dtypes.RetrievalPricingFunc {
 		if cfg.RetrievalPricing.Strategy == config.RetrievalPricingExternalMode {
 			return pricing.ExternalRetrievalPricingFunc(cfg.RetrievalPricing.External.Path)
 		}
+		if cfg.RetrievalPricing.Strategy == config.RetrievalPricingPolicyMode {
+			return pricing.PolicyRetrievalPricingFunc(policyFunc)
+		}
 
 		return retrievalimpl.DefaultPricingFunc(cfg.RetrievalPricing.Default.VerifiedDealsFreeTransfer)
 	}
@@ -733,12 +790,148 @@ func RetrievalProvider(
 	)
 }
 
+// HTTPRetrievalServer creates the handler serving pieces and payloads over HTTP
+// on the markets API endpoint
+func HTTPRetrievalServer(cfg config.HTTPRetrievalConfig) func(mapi dagstore.MinerAPI, dagStore *dagstore.Wrapper) *httpretrieval.Server {
+	return func(mapi dagstore.MinerAPI, dagStore *dagstore.Wrapper) *httpretrieval.Server {
+		return httpretrieval.NewServer(mapi, dagStore, httpretrieval.Config{
+			AccessTokens: cfg.AccessTokens,
+			MaxBandwidth: cfg.MaxBandwidth,
+		})
+	}
+}
+
+// TransferLimits limits the data sent by the markets graphsync instance
+func TransferLimits(cfg config.TransferLimitsConfig) func(lc fx.Lifecycle, gs dtypes.StagingGraphsync) (*transferlimit.Scheduler, error) {
+	return func(lc fx.Lifecycle, gs dtypes.StagingGraphsync) (*transferlimit.Scheduler, error) {
+		limits := api.DataTransferLimits{
+			MaxBandwidth:                  cfg.MaxBandwidth,
+			MaxBandwidthPerPeer:           cfg.MaxBandwidthPerPeer,
+			MaxConcurrentTransfers:        cfg.MaxConcurrentTransfers,
+			MaxConcurrentTransfersPerPeer: cfg.MaxConcurrentTransfersPerPeer,
+		}
+		for _, w := range cfg.Schedule {
+			limits.Schedule = append(limits.Schedule, api.BandwidthWindow{
+				Start:               w.Start,
+				End:                 w.End,
+				MaxBandwidth:        w.MaxBandwidth,
+				MaxBandwidthPerPeer: w.MaxBandwidthPerPeer,
+			})
+		}
+
+		s, err := transferlimit.NewScheduler(limits)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid transfer limits: %w", err)
+		}
+		s.Register(gs)
+
+		lc.Append(fx.Hook{
+			OnStop: func(context.Context) error {
+				s.Stop()
+				return nil
+			},
+		})
+
+		return s, nil
+	}
+}
+
+type MinerNotifierParams struct {
+	fx.In
+
+	Lifecycle  fx.Lifecycle
+	MetricsCtx helpers.MetricsCtx
+	API        v1api.FullNode
+	Maddr      dtypes.MinerAddress
+	Manager    *sealer.Manager `optional:"true"`
+}
+
+// MinerNotifier runs the checks of the miner events notified to the webhooks
+func MinerNotifier(cfg config.MinerNotificationsConfig) func(params MinerNotifierParams) (*notify.Notifier, error) {
+	return func(params MinerNotifierParams) (*notify.Notifier, error) {
+		ncfg := notify.Config{
+			PoStDeadlineWarning:     time.Duration(cfg.PoStDeadlineWarning),
+			ControlBalanceThreshold: abi.TokenAmount(cfg.ControlBalanceThreshold),
+			Timeout:                 time.Duration(cfg.WebhookTimeout),
+		}
+		for _, w := range cfg.Webhooks {
+			webhook := notify.Webhook{
+				URL:        w.URL,
+				Format:     w.Format,
+				RoutingKey: w.RoutingKey,
+			}
+			for _, name := range w.Events {
+				t, err := notify.ParseEventType(name)
+				if err != nil {
+					return nil, xerrors.Errorf("webhook %s: %w", w.URL, err)
+				}
+				webhook.Events = append(webhook.Events, t)
+			}
+			ncfg.Webhooks = append(ncfg.Webhooks, webhook)
+		}
+
+		var workers notify.WorkerStatser
+		if params.Manager != nil {
+			workers = params.Manager
+		}
+
+		n, err := notify.NewNotifier(ncfg, address.Address(params.Maddr), params.API, workers)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid notifications config: %w", err)
+		}
+
+		ctx := helpers.LifecycleCtx(params.MetricsCtx, params.Lifecycle)
+		params.Lifecycle.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				go n.Run(ctx)
+				return nil
+			},
+			OnStop: n.Stop,
+		})
+
+		return n, nil
+	}
+}
+
+// Disputer checks the WindowPoSts of the watched miners when it is enabled;
+// the checks and disputes of the API are available either way
+func Disputer(cfg config.WdPoStDisputerConfig) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, ds dtypes.MetadataDS, api v1api.FullNode, maddr dtypes.MinerAddress) (*disputer.Disputer, error) {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, ds dtypes.MetadataDS, api v1api.FullNode, maddr dtypes.MinerAddress) (*disputer.Disputer, error) {
+		d, err := disputer.NewDisputer(api, ds, cfg, address.Address(maddr))
+		if err != nil {
+			return nil, xerrors.Errorf("invalid disputer config: %w", err)
+		}
+
+		if cfg.Enable {
+			ctx := helpers.LifecycleCtx(mctx, lc)
+			lc.Append(fx.Hook{
+				OnStart: func(context.Context) error {
+					go d.Run(ctx)
+					return nil
+				},
+			})
+		}
+
+		return d, nil
+	}
+}
+
 var WorkerCallsPrefix = datastore.NewKey("/worker/calls")
 var ManagerWorkPrefix = datastore.NewKey("/stmgr/calls")
 
-func LocalStorage(mctx helpers.MetricsCtx, lc fx.Lifecycle, ls paths.LocalStorage, si paths.SectorIndex, urls paths.URLs) (*paths.Local, error) {
+func LocalStorage(mctx helpers.MetricsCtx, lc fx.Lifecycle, ls paths.LocalStorage, si paths.SectorIndex, urls paths.URLs, sc sealer.Config) (*paths.Local, error) {
 	ctx := helpers.LifecycleCtx(mctx, lc)
-	return paths.NewLocal(ctx, ls, si, urls)
+
+	lstor, err := paths.NewLocal(ctx, ls, si, urls)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lstor.SetAllocationPolicy(sc.AllocationPolicy); err != nil {
+		return nil, xerrors.Errorf("setting storage allocation policy: %w", err)
+	}
+
+	return lstor, nil
 }
 
 func RemoteStorage(lstor *paths.Local, si paths.SectorIndex, sa sealer.StorageAuth, sc sealer.Config) *paths.Remote {
@@ -763,6 +956,34 @@ func SectorStorage(mctx helpers.MetricsCtx, lc fx.Lifecycle, lstor *paths.Local,
 	return sst, nil
 }
 
+// WorkerRegistry registers the workers started with a one-time token; each of
+// them gets its own API token, which can be revoked
+func WorkerRegistry(templates []config.WorkerTemplate) func(mctx helpers.MetricsCtx, ds dtypes.MetadataDS, ca v0api.Common, sm *sealer.Manager) (*workerboot.Registry, error) {
+	return func(mctx helpers.MetricsCtx, ds dtypes.MetadataDS, ca v0api.Common, sm *sealer.Manager) (*workerboot.Registry, error) {
+		var tmpls []workerboot.Template
+		for _, t := range templates {
+			tmpls = append(tmpls, t.Template())
+		}
+
+		newToken := func(ctx context.Context, hostname string) (string, error) {
+			t, err := ca.AuthTokenCreate(ctx, api.PermAdmin, "worker "+hostname, 0)
+			if err != nil {
+				return "", err
+			}
+			return t.Token, nil
+		}
+
+		r, err := workerboot.NewRegistry(ds, tmpls, newToken, sm.TagWorkers)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.RestoreTags(mctx); err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+}
+
 func StorageAuth(ctx helpers.MetricsCtx, ca v0api.Common) (sealer.StorageAuth, error) {
 	token, err := ca.AuthNew(ctx, []auth.Permission{"admin"})
 	if err != nil {
@@ -786,9 +1007,9 @@ func StorageAuthWithURL(apiInfo string) func(ctx helpers.MetricsCtx, ca v0api.Co
 	}
 }
 
-func NewConsiderOnlineStorageDealsConfigFunc(r repo.LockedRepo) (dtypes.ConsiderOnlineStorageDealsConfigFunc, error) {
+func NewConsiderOnlineStorageDealsConfigFunc(rl *config.Reloader) (dtypes.ConsiderOnlineStorageDealsConfigFunc, error) {
 	return func() (out bool, err error) {
-		err = readDealmakingCfg(r, func(c config.DealmakingConfiger) {
+		err = readDealmakingCfg(rl, func(c config.DealmakingConfiger) {
 			cfg := c.GetDealmakingConfig()
 			out = cfg.ConsiderOnlineStorageDeals
 		})
@@ -796,9 +1017,9 @@ func NewConsiderOnlineStorageDealsConfigFunc(r repo.LockedRepo) (dtypes.Consider
 	}, nil
 }
 
-func NewSetConsideringOnlineStorageDealsFunc(r repo.LockedRepo) (dtypes.SetConsiderOnlineStorageDealsConfigFunc, error) {
+func NewSetConsideringOnlineStorageDealsFunc(r repo.LockedRepo, rl *config.Reloader) (dtypes.SetConsiderOnlineStorageDealsConfigFunc, error) {
 	return func(b bool) (err error) {
-		err = mutateDealmakingCfg(r, func(c config.DealmakingConfiger) {
+		err = mutateDealmakingCfg(r, rl, func(c config.DealmakingConfiger) {
 			cfg := c.GetDealmakingConfig()
 			cfg.ConsiderOnlineStorageDeals = b
 			c.SetDealmakingConfig(cfg)
@@ -807,9 +1028,9 @@ func NewSetConsideringOnlineStorageDealsFunc(r repo.LockedRepo) (dtypes.SetConsi
 	}, nil
 }
 
-func NewConsiderOnlineRetrievalDealsConfigFunc(r repo.LockedRepo) (dtypes.ConsiderOnlineRetrievalDealsConfigFunc, error) {
+func NewConsiderOnlineRetrievalDealsConfigFunc(rl *config.Reloader) (dtypes.ConsiderOnlineRetrievalDealsConfigFunc, error) {
 	return func() (out bool, err error) {
-		err = readDealmakingCfg(r, func(c config.DealmakingConfiger) {
+		err = readDealmakingCfg(rl, func(c config.DealmakingConfiger) {
 			cfg := c.GetDealmakingConfig()
 			out = cfg.ConsiderOnlineRetrievalDeals
 		})
@@ -817,9 +1038,9 @@ func NewConsiderOnlineRetrievalDealsConfigFunc(r repo.LockedRepo) (dtypes.Consid
 	}, nil
 }
 
-func NewSetConsiderOnlineRetrievalDealsConfigFunc(r repo.LockedRepo) (dtypes.SetConsiderOnlineRetrievalDealsConfigFunc, error) {
+func NewSetConsiderOnlineRetrievalDealsConfigFunc(r repo.LockedRepo, rl *config.Reloader) (dtypes.SetConsiderOnlineRetrievalDealsConfigFunc, error) {
 	return func(b bool) (err error) {
-		err = mutateDealmakingCfg(r, func(c config.DealmakingConfiger) {
+		err = mutateDealmakingCfg(r, rl, func(c config.DealmakingConfiger) {
 			cfg := c.GetDealmakingConfig()
 			cfg.ConsiderOnlineRetrievalDeals = b
 			c.SetDealmakingConfig(cfg)
@@ -828,9 +1049,9 @@ func NewSetConsiderOnlineRetrievalDealsConfigFunc(r repo.LockedRepo) (dtypes.Set
 	}, nil
 }
 
-func NewStorageDealPieceCidBlocklistConfigFunc(r repo.LockedRepo) (dtypes.StorageDealPieceCidBlocklistConfigFunc, error) {
+func NewStorageDealPieceCidBlocklistConfigFunc(rl *config.Reloader) (dtypes.StorageDealPieceCidBlocklistConfigFunc, error) {
 	return func() (out []cid.Cid, err error) {
-		err = readDealmakingCfg(r, func(c config.DealmakingConfiger) {
+		err = readDealmakingCfg(rl, func(c config.DealmakingConfiger) {
 			cfg := c.GetDealmakingConfig()
 			out = cfg.PieceCidBlocklist
 		})
@@ -838,9 +1059,9 @@ func NewStorageDealPieceCidBlocklistConfigFunc(r repo.LockedRepo) (dtypes.Storag
 	}, nil
 }
 
-func NewSetStorageDealPieceCidBlocklistConfigFunc(r repo.LockedRepo) (dtypes.SetStorageDealPieceCidBlocklistConfigFunc, error) {
+func NewSetStorageDealPieceCidBlocklistConfigFunc(r repo.LockedRepo, rl *config.Reloader) (dtypes.SetStorageDealPieceCidBlocklistConfigFunc, error) {
 	return func(blocklist []cid.Cid) (err error) {
-		err = mutateDealmakingCfg(r, func(c config.DealmakingConfiger) {
+		err = mutateDealmakingCfg(r, rl, func(c config.DealmakingConfiger) {
 			cfg := c.GetDealmakingConfig()
 			cfg.PieceCidBlocklist = blocklist
 			c.SetDealmakingConfig(cfg)
@@ -849,9 +1070,94 @@ func NewSetStorageDealPieceCidBlocklistConfigFunc(r repo.LockedRepo) (dtypes.Set
 	}, nil
 }
 
-func NewConsiderOfflineStorageDealsConfigFunc(r repo.LockedRepo) (dtypes.ConsiderOfflineStorageDealsConfigFunc, error) {
+func NewGetRetrievalPricingPolicyFunc(rl *config.Reloader) (dtypes.GetRetrievalPricingPolicyFunc, error) {
+	return func() (out dtypes.RetrievalPricingPolicy, err error) {
+		var policy config.RetrievalPricingPolicy
+		err = readDealmakingCfg(rl, func(c config.DealmakingConfiger) {
+			cfg := c.GetDealmakingConfig()
+			if cfg.RetrievalPricing != nil && cfg.RetrievalPricing.Policy != nil {
+				policy = *cfg.RetrievalPricing.Policy
+			}
+		})
+		if err != nil {
+			return
+		}
+		return fromRetrievalPricingPolicyConfig(policy)
+	}, nil
+}
+
+func NewSetRetrievalPricingPolicyFunc(r repo.LockedRepo, rl *config.Reloader) (dtypes.SetRetrievalPricingPolicyFunc, error) {
+	return func(policy dtypes.RetrievalPricingPolicy) (err error) {
+		pcfg := toRetrievalPricingPolicyConfig(policy)
+		err = mutateDealmakingCfg(r, rl, func(c config.DealmakingConfiger) {
+			cfg := c.GetDealmakingConfig()
+			if cfg.RetrievalPricing == nil {
+				cfg.RetrievalPricing = &config.RetrievalPricing{Strategy: config.RetrievalPricingDefaultMode}
+			}
+			cfg.RetrievalPricing.Policy = pcfg
+			c.SetDealmakingConfig(cfg)
+		})
+		return
+	}, nil
+}
+
+func fromRetrievalPricingPolicyConfig(cfg config.RetrievalPricingPolicy) (dtypes.RetrievalPricingPolicy, error) {
+	out := dtypes.RetrievalPricingPolicy{
+		FreeClients:               make([]peer.ID, 0, len(cfg.FreeClients)),
+		VerifiedDealsFreeTransfer: cfg.VerifiedDealsFreeTransfer,
+		PiecePrices:               make([]dtypes.RetrievalPiecePrice, 0, len(cfg.PiecePrices)),
+		SealedPricePercent:        cfg.SealedPricePercent,
+	}
+
+	for _, s := range cfg.FreeClients {
+		p, err := peer.Decode(s)
+		if err != nil {
+			return dtypes.RetrievalPricingPolicy{}, xerrors.Errorf("parsing free client %q: %w", s, err)
+		}
+		out.FreeClients = append(out.FreeClients, p)
+	}
+
+	for _, pp := range cfg.PiecePrices {
+		c, err := cid.Decode(pp.PieceCID)
+		if err != nil {
+			return dtypes.RetrievalPricingPolicy{}, xerrors.Errorf("parsing piece cid %q: %w", pp.PieceCID, err)
+		}
+		out.PiecePrices = append(out.PiecePrices, dtypes.RetrievalPiecePrice{
+			PieceCID:     c,
+			PricePerByte: (*abi.TokenAmount)(pp.PricePerByte),
+			UnsealPrice:  (*abi.TokenAmount)(pp.UnsealPrice),
+		})
+	}
+
+	return out, nil
+}
+
+func toRetrievalPricingPolicyConfig(policy dtypes.RetrievalPricingPolicy) *config.RetrievalPricingPolicy {
+	out := &config.RetrievalPricingPolicy{
+		FreeClients:               make([]string, 0, len(policy.FreeClients)),
+		VerifiedDealsFreeTransfer: policy.VerifiedDealsFreeTransfer,
+		PiecePrices:               make([]config.RetrievalPiecePrice, 0, len(policy.PiecePrices)),
+		SealedPricePercent:        policy.SealedPricePercent,
+	}
+
+	for _, p := range policy.FreeClients {
+		out.FreeClients = append(out.FreeClients, p.String())
+	}
+
+	for _, pp := range policy.PiecePrices {
+		out.PiecePrices = append(out.PiecePrices, config.RetrievalPiecePrice{
+			PieceCID:     pp.PieceCID.String(),
+			PricePerByte: (*types.FIL)(pp.PricePerByte),
+			UnsealPrice:  (*types.FIL)(pp.UnsealPrice),
+		})
+	}
+
+	return out
+}
+
+func NewConsiderOfflineStorageDealsConfigFunc(rl *config.Reloader) (dtypes.ConsiderOfflineStorageDealsConfigFunc, error) {
 	return func() (out bool, err error) {
-		err = readDealmakingCfg(r, func(c config.DealmakingConfiger) {
+		err = readDealmakingCfg(rl, func(c config.DealmakingConfiger) {
 			cfg := c.GetDealmakingConfig()
 			out = cfg.ConsiderOfflineStorageDeals
 		})
@@ -859,9 +1165,9 @@ func NewConsiderOfflineStorageDealsConfigFunc(r repo.LockedRepo) (dtypes.Conside
 	}, nil
 }
 
-func NewSetConsideringOfflineStorageDealsFunc(r repo.LockedRepo) (dtypes.SetConsiderOfflineStorageDealsConfigFunc, error) {
+func NewSetConsideringOfflineStorageDealsFunc(r repo.LockedRepo, rl *config.Reloader) (dtypes.SetConsiderOfflineStorageDealsConfigFunc, error) {
 	return func(b bool) (err error) {
-		err = mutateDealmakingCfg(r, func(c config.DealmakingConfiger) {
+		err = mutateDealmakingCfg(r, rl, func(c config.DealmakingConfiger) {
 			cfg := c.GetDealmakingConfig()
 			cfg.ConsiderOfflineStorageDeals = b
 			c.SetDealmakingConfig(cfg)
@@ -870,9 +1176,9 @@ func NewSetConsideringOfflineStorageDealsFunc(r repo.LockedRepo) (dtypes.SetCons
 	}, nil
 }
 
-func NewConsiderOfflineRetrievalDealsConfigFunc(r repo.LockedRepo) (dtypes.ConsiderOfflineRetrievalDealsConfigFunc, error) {
+func NewConsiderOfflineRetrievalDealsConfigFunc(rl *config.Reloader) (dtypes.ConsiderOfflineRetrievalDealsConfigFunc, error) {
 	return func() (out bool, err error) {
-		err = readDealmakingCfg(r, func(c config.DealmakingConfiger) {
+		err = readDealmakingCfg(rl, func(c config.DealmakingConfiger) {
 			cfg := c.GetDealmakingConfig()
 			out = cfg.ConsiderOfflineRetrievalDeals
 		})
@@ -880,9 +1186,9 @@ func NewConsiderOfflineRetrievalDealsConfigFunc(r repo.LockedRepo) (dtypes.Consi
 	}, nil
 }
 
-func NewSetConsiderOfflineRetrievalDealsConfigFunc(r repo.LockedRepo) (dtypes.SetConsiderOfflineRetrievalDealsConfigFunc, error) {
+func NewSetConsiderOfflineRetrievalDealsConfigFunc(r repo.LockedRepo, rl *config.Reloader) (dtypes.SetConsiderOfflineRetrievalDealsConfigFunc, error) {
 	return func(b bool) (err error) {
-		err = mutateDealmakingCfg(r, func(c config.DealmakingConfiger) {
+		err = mutateDealmakingCfg(r, rl, func(c config.DealmakingConfiger) {
 			cfg := c.GetDealmakingConfig()
 			cfg.ConsiderOfflineRetrievalDeals = b
 			c.SetDealmakingConfig(cfg)
@@ -891,9 +1197,9 @@ func NewSetConsiderOfflineRetrievalDealsConfigFunc(r repo.LockedRepo) (dtypes.Se
 	}, nil
 }
 
-func NewConsiderVerifiedStorageDealsConfigFunc(r repo.LockedRepo) (dtypes.ConsiderVerifiedStorageDealsConfigFunc, error) {
+func NewConsiderVerifiedStorageDealsConfigFunc(rl *config.Reloader) (dtypes.ConsiderVerifiedStorageDealsConfigFunc, error) {
 	return func() (out bool, err error) {
-		err = readDealmakingCfg(r, func(c config.DealmakingConfiger) {
+		err = readDealmakingCfg(rl, func(c config.DealmakingConfiger) {
 			cfg := c.GetDealmakingConfig()
 			out = cfg.ConsiderVerifiedStorageDeals
 		})
@@ -901,9 +1207,9 @@ func NewConsiderVerifiedStorageDealsConfigFunc(r repo.LockedRepo) (dtypes.Consid
 	}, nil
 }
 
-func NewSetConsideringVerifiedStorageDealsFunc(r repo.LockedRepo) (dtypes.SetConsiderVerifiedStorageDealsConfigFunc, error) {
+func NewSetConsideringVerifiedStorageDealsFunc(r repo.LockedRepo, rl *config.Reloader) (dtypes.SetConsiderVerifiedStorageDealsConfigFunc, error) {
 	return func(b bool) (err error) {
-		err = mutateDealmakingCfg(r, func(c config.DealmakingConfiger) {
+		err = mutateDealmakingCfg(r, rl, func(c config.DealmakingConfiger) {
 			cfg := c.GetDealmakingConfig()
 			cfg.ConsiderVerifiedStorageDeals = b
 			c.SetDealmakingConfig(cfg)
@@ -912,9 +1218,9 @@ func NewSetConsideringVerifiedStorageDealsFunc(r repo.LockedRepo) (dtypes.SetCon
 	}, nil
 }
 
-func NewConsiderUnverifiedStorageDealsConfigFunc(r repo.LockedRepo) (dtypes.ConsiderUnverifiedStorageDealsConfigFunc, error) {
+func NewConsiderUnverifiedStorageDealsConfigFunc(rl *config.Reloader) (dtypes.ConsiderUnverifiedStorageDealsConfigFunc, error) {
 	return func() (out bool, err error) {
-		err = readDealmakingCfg(r, func(c config.DealmakingConfiger) {
+		err = readDealmakingCfg(rl, func(c config.DealmakingConfiger) {
 			cfg := c.GetDealmakingConfig()
 			out = cfg.ConsiderUnverifiedStorageDeals
 		})
@@ -922,9 +1228,9 @@ func NewConsiderUnverifiedStorageDealsConfigFunc(r repo.LockedRepo) (dtypes.Cons
 	}, nil
 }
 
-func NewSetConsideringUnverifiedStorageDealsFunc(r repo.LockedRepo) (dtypes.SetConsiderUnverifiedStorageDealsConfigFunc, error) {
+func NewSetConsideringUnverifiedStorageDealsFunc(r repo.LockedRepo, rl *config.Reloader) (dtypes.SetConsiderUnverifiedStorageDealsConfigFunc, error) {
 	return func(b bool) (err error) {
-		err = mutateDealmakingCfg(r, func(c config.DealmakingConfiger) {
+		err = mutateDealmakingCfg(r, rl, func(c config.DealmakingConfiger) {
 			cfg := c.GetDealmakingConfig()
 			cfg.ConsiderUnverifiedStorageDeals = b
 			c.SetDealmakingConfig(cfg)
@@ -933,9 +1239,9 @@ func NewSetConsideringUnverifiedStorageDealsFunc(r repo.LockedRepo) (dtypes.SetC
 	}, nil
 }
 
-func NewSetSealConfigFunc(r repo.LockedRepo) (dtypes.SetSealingConfigFunc, error) {
+func NewSetSealConfigFunc(r repo.LockedRepo, rl *config.Reloader) (dtypes.SetSealingConfigFunc, error) {
 	return func(cfg sealiface.Config) (err error) {
-		err = mutateSealingCfg(r, func(c config.SealingConfiger) {
+		err = mutateSealingCfg(r, rl, func(c config.SealingConfiger) {
 			newCfg := config.SealingConfig{
 				MaxWaitDealsSectors:             cfg.MaxWaitDealsSectors,
 				MaxSealingSectors:               cfg.MaxSealingSectors,
@@ -944,11 +1250,19 @@ func NewSetSealConfigFunc(r repo.LockedRepo) (dtypes.SetSealingConfigFunc, error
 				MaxUpgradingSectors:             cfg.MaxUpgradingSectors,
 				CommittedCapacitySectorLifetime: config.Duration(cfg.CommittedCapacitySectorLifetime),
 				WaitDealsDelay:                  config.Duration(cfg.WaitDealsDelay),
+				MaxDealsPerSector:               cfg.MaxDealsPerSector,
+				TargetSectorFillPercent:         cfg.TargetSectorFillPercent,
+				MaxConcurrentDealsPerClient:     cfg.MaxConcurrentDealsPerClient,
 				MakeNewSectorForDeals:           cfg.MakeNewSectorForDeals,
 				MakeCCSectorsAvailable:          cfg.MakeCCSectorsAvailable,
 				AlwaysKeepUnsealedCopy:          cfg.AlwaysKeepUnsealedCopy,
 				FinalizeEarly:                   cfg.FinalizeEarly,
 
+				SnapMatcherEnable:         cfg.SnapMatcherEnable,
+				SnapMatcherInterval:       config.Duration(cfg.SnapMatcherInterval),
+				SnapMatcherMaxAvailable:   cfg.SnapMatcherMaxAvailable,
+				SnapMatcherMinFillPercent: cfg.SnapMatcherMinFillPercent,
+
 				CollateralFromMinerBalance: cfg.CollateralFromMinerBalance,
 				AvailableBalanceBuffer:     types.FIL(cfg.AvailableBalanceBuffer),
 				DisableCollateralFallback:  cfg.DisableCollateralFallback,
@@ -987,10 +1301,18 @@ func ToSealingConfig(dealmakingCfg config.DealmakingConfig, sealingCfg config.Se
 		MakeNewSectorForDeals:           sealingCfg.MakeNewSectorForDeals,
 		CommittedCapacitySectorLifetime: time.Duration(sealingCfg.CommittedCapacitySectorLifetime),
 		WaitDealsDelay:                  time.Duration(sealingCfg.WaitDealsDelay),
+		MaxDealsPerSector:               sealingCfg.MaxDealsPerSector,
+		TargetSectorFillPercent:         sealingCfg.TargetSectorFillPercent,
+		MaxConcurrentDealsPerClient:     sealingCfg.MaxConcurrentDealsPerClient,
 		MakeCCSectorsAvailable:          sealingCfg.MakeCCSectorsAvailable,
 		AlwaysKeepUnsealedCopy:          sealingCfg.AlwaysKeepUnsealedCopy,
 		FinalizeEarly:                   sealingCfg.FinalizeEarly,
 
+		SnapMatcherEnable:         sealingCfg.SnapMatcherEnable,
+		SnapMatcherInterval:       time.Duration(sealingCfg.SnapMatcherInterval),
+		SnapMatcherMaxAvailable:   sealingCfg.SnapMatcherMaxAvailable,
+		SnapMatcherMinFillPercent: sealingCfg.SnapMatcherMinFillPercent,
+
 		CollateralFromMinerBalance: sealingCfg.CollateralFromMinerBalance,
 		AvailableBalanceBuffer:     types.BigInt(sealingCfg.AvailableBalanceBuffer),
 		DisableCollateralFallback:  sealingCfg.DisableCollateralFallback,
@@ -1014,9 +1336,9 @@ func ToSealingConfig(dealmakingCfg config.DealmakingConfig, sealingCfg config.Se
 	}
 }
 
-func NewGetSealConfigFunc(r repo.LockedRepo) (dtypes.GetSealingConfigFunc, error) {
+func NewGetSealConfigFunc(rl *config.Reloader) (dtypes.GetSealingConfigFunc, error) {
 	return func() (out sealiface.Config, err error) {
-		err = readSealingCfg(r, func(dc config.DealmakingConfiger, sc config.SealingConfiger) {
+		err = readSealingCfg(rl, func(dc config.DealmakingConfiger, sc config.SealingConfiger) {
 			scfg := sc.GetSealingConfig()
 			dcfg := dc.GetDealmakingConfig()
 			out = ToSealingConfig(dcfg, scfg)
@@ -1025,9 +1347,9 @@ func NewGetSealConfigFunc(r repo.LockedRepo) (dtypes.GetSealingConfigFunc, error
 	}, nil
 }
 
-func NewSetExpectedSealDurationFunc(r repo.LockedRepo) (dtypes.SetExpectedSealDurationFunc, error) {
+func NewSetExpectedSealDurationFunc(r repo.LockedRepo, rl *config.Reloader) (dtypes.SetExpectedSealDurationFunc, error) {
 	return func(delay time.Duration) (err error) {
-		err = mutateDealmakingCfg(r, func(c config.DealmakingConfiger) {
+		err = mutateDealmakingCfg(r, rl, func(c config.DealmakingConfiger) {
 			cfg := c.GetDealmakingConfig()
 			cfg.ExpectedSealDuration = config.Duration(delay)
 			c.SetDealmakingConfig(cfg)
@@ -1036,9 +1358,9 @@ func NewSetExpectedSealDurationFunc(r repo.LockedRepo) (dtypes.SetExpectedSealDu
 	}, nil
 }
 
-func NewGetExpectedSealDurationFunc(r repo.LockedRepo) (dtypes.GetExpectedSealDurationFunc, error) {
+func NewGetExpectedSealDurationFunc(rl *config.Reloader) (dtypes.GetExpectedSealDurationFunc, error) {
 	return func() (out time.Duration, err error) {
-		err = readDealmakingCfg(r, func(c config.DealmakingConfiger) {
+		err = readDealmakingCfg(rl, func(c config.DealmakingConfiger) {
 			cfg := c.GetDealmakingConfig()
 			out = time.Duration(cfg.ExpectedSealDuration)
 		})
@@ -1046,9 +1368,9 @@ func NewGetExpectedSealDurationFunc(r repo.LockedRepo) (dtypes.GetExpectedSealDu
 	}, nil
 }
 
-func NewSetMaxDealStartDelayFunc(r repo.LockedRepo) (dtypes.SetMaxDealStartDelayFunc, error) {
+func NewSetMaxDealStartDelayFunc(r repo.LockedRepo, rl *config.Reloader) (dtypes.SetMaxDealStartDelayFunc, error) {
 	return func(delay time.Duration) (err error) {
-		err = mutateDealmakingCfg(r, func(c config.DealmakingConfiger) {
+		err = mutateDealmakingCfg(r, rl, func(c config.DealmakingConfiger) {
 			cfg := c.GetDealmakingConfig()
 			cfg.MaxDealStartDelay = config.Duration(delay)
 			c.SetDealmakingConfig(cfg)
@@ -1057,9 +1379,9 @@ func NewSetMaxDealStartDelayFunc(r repo.LockedRepo) (dtypes.SetMaxDealStartDelay
 	}, nil
 }
 
-func NewGetMaxDealStartDelayFunc(r repo.LockedRepo) (dtypes.GetMaxDealStartDelayFunc, error) {
+func NewGetMaxDealStartDelayFunc(rl *config.Reloader) (dtypes.GetMaxDealStartDelayFunc, error) {
 	return func() (out time.Duration, err error) {
-		err = readDealmakingCfg(r, func(c config.DealmakingConfiger) {
+		err = readDealmakingCfg(rl, func(c config.DealmakingConfiger) {
 			cfg := c.GetDealmakingConfig()
 			out = time.Duration(cfg.MaxDealStartDelay)
 		})
@@ -1067,11 +1389,8 @@ func NewGetMaxDealStartDelayFunc(r repo.LockedRepo) (dtypes.GetMaxDealStartDelay
 	}, nil
 }
 
-func readSealingCfg(r repo.LockedRepo, accessor func(config.DealmakingConfiger, config.SealingConfiger)) error {
-	raw, err := r.Config()
-	if err != nil {
-		return err
-	}
+func readSealingCfg(rl *config.Reloader, accessor func(config.DealmakingConfiger, config.SealingConfiger)) error {
+	raw := rl.Config()
 
 	scfg, ok := raw.(config.SealingConfiger)
 	if !ok {
@@ -1088,10 +1407,10 @@ func readSealingCfg(r repo.LockedRepo, accessor func(config.DealmakingConfiger,
 	return nil
 }
 
-func mutateSealingCfg(r repo.LockedRepo, mutator func(config.SealingConfiger)) error {
+func mutateSealingCfg(r repo.LockedRepo, rl *config.Reloader, mutator func(config.SealingConfiger)) error {
 	var typeErr error
 
-	setConfigErr := r.SetConfig(func(raw interface{}) {
+	setConfigErr := rl.SetConfig(r.SetConfig, func(raw interface{}) {
 		cfg, ok := raw.(config.SealingConfiger)
 		if !ok {
 			typeErr = errors.New("expected config with sealing config trait")
@@ -1104,11 +1423,8 @@ func mutateSealingCfg(r repo.LockedRepo, mutator func(config.SealingConfiger)) e
 	return multierr.Combine(typeErr, setConfigErr)
 }
 
-func readDealmakingCfg(r repo.LockedRepo, accessor func(config.DealmakingConfiger)) error {
-	raw, err := r.Config()
-	if err != nil {
-		return err
-	}
+func readDealmakingCfg(rl *config.Reloader, accessor func(config.DealmakingConfiger)) error {
+	raw := rl.Config()
 
 	cfg, ok := raw.(config.DealmakingConfiger)
 	if !ok {
@@ -1120,10 +1436,10 @@ func readDealmakingCfg(r repo.LockedRepo, accessor func(config.DealmakingConfige
 	return nil
 }
 
-func mutateDealmakingCfg(r repo.LockedRepo, mutator func(config.DealmakingConfiger)) error {
+func mutateDealmakingCfg(r repo.LockedRepo, rl *config.Reloader, mutator func(config.DealmakingConfiger)) error {
 	var typeErr error
 
-	setConfigErr := r.SetConfig(func(raw interface{}) {
+	setConfigErr := rl.SetConfig(r.SetConfig, func(raw interface{}) {
 		cfg, ok := raw.(config.DealmakingConfiger)
 		if !ok {
 			typeErr = errors.New("expected config with dealmaking config trait")
@@ -1136,59 +1452,6 @@ func mutateDealmakingCfg(r repo.LockedRepo, mutator func(config.DealmakingConfig
 	return multierr.Combine(typeErr, setConfigErr)
 }
 
-func migrateDealStaging(oldPath, newPath string) error {
-	dirInfo, err := os.Stat(newPath)
-	if err == nil {
-		if !dirInfo.IsDir() {
-			return xerrors.Errorf("%s is not a directory", newPath)
-		}
-		// The newPath exists already, below migration has already occurred.
-		return nil
-	}
-
-	// if the directory doesn't exist, create it
-	if os.IsNotExist(err) {
-		if err := os.MkdirAll(newPath, 0755); err != nil {
-			return xerrors.Errorf("failed to mk directory %s for deal staging: %w", newPath, err)
-		}
-	} else { // if we failed for other reasons, abort.
-		return err
-	}
-
-	// if this is the first time we created the directory, symlink all staged deals into it. "Migration"
-	// get a list of files in the miner repo
-	dirEntries, err := os.ReadDir(oldPath)
-	if err != nil {
-		return xerrors.Errorf("failed to list directory %s for deal staging: %w", oldPath, err)
-	}
-
-	for _, entry := range dirEntries {
-		// ignore directories, they are not the deals.
-		if entry.IsDir() {
-			continue
-		}
-		// the FileStore from fil-storage-market creates temporary staged deal files with the pattern "fstmp"
-		// https://github.com/filecoin-project/go-fil-markets/blob/00ff81e477d846ac0cb58a0c7d1c2e9afb5ee1db/filestore/filestore.go#L69
-		name := entry.Name()
-		if strings.Contains(name, "fstmp") {
-			// from the miner repo
-			oldPath := filepath.Join(oldPath, name)
-			// to its subdir "deal-staging"
-			newPath := filepath.Join(newPath, name)
-			// create a symbolic link in the new deal staging directory to preserve existing staged deals.
-			// all future staged deals will be created here.
-			if err := os.Rename(oldPath, newPath); err != nil {
-				return xerrors.Errorf("failed to move %s to %s: %w", oldPath, newPath, err)
-			}
-			if err := os.Symlink(newPath, oldPath); err != nil {
-				return xerrors.Errorf("failed to symlink %s to %s: %w", oldPath, newPath, err)
-			}
-			log.Infow("symlinked staged deal", "from", oldPath, "to", newPath)
-		}
-	}
-	return nil
-}
-
 func ExtractEnabledMinerSubsystems(cfg config.MinerSubsystemConfig) (res api.MinerSubsystems) {
 	if cfg.EnableMining {
 		res = append(res, api.SubsystemMining)
@@ -1202,5 +1465,8 @@ func ExtractEnabledMinerSubsystems(cfg config.MinerSubsystemConfig) (res api.Min
 	if cfg.EnableMarkets {
 		res = append(res, api.SubsystemMarkets)
 	}
+	if cfg.EnableProving || (cfg.EnableMining && cfg.ProvingApiInfo == "") {
+		res = append(res, api.SubsystemProving)
+	}
 	return res
 }
@@ -0,0 +1,52 @@
+//stm: #unit
+package modules
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/node/config"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+)
+
+func TestRetrievalPricingPolicyRoundTrip(t *testing.T) {
+	piece := blocks.NewBlock([]byte("piece")).Cid()
+	unseal := big.NewInt(500)
+
+	// only the unseal price of the piece is overridden
+	policy := dtypes.RetrievalPricingPolicy{
+		PiecePrices: []dtypes.RetrievalPiecePrice{
+			{PieceCID: piece, UnsealPrice: &unseal},
+		},
+	}
+
+	// through the JSON API
+	b, err := json.Marshal(policy)
+	require.NoError(t, err)
+	var decoded dtypes.RetrievalPricingPolicy
+	require.NoError(t, json.Unmarshal(b, &decoded))
+
+	// and through the config file
+	var buf bytes.Buffer
+	require.NoError(t, toml.NewEncoder(&buf).Encode(toRetrievalPricingPolicyConfig(decoded)))
+	var cfg config.RetrievalPricingPolicy
+	_, err = toml.Decode(buf.String(), &cfg)
+	require.NoError(t, err)
+
+	out, err := fromRetrievalPricingPolicyConfig(cfg)
+	require.NoError(t, err)
+	require.Len(t, out.PiecePrices, 1)
+
+	pp := out.PiecePrices[0]
+	require.Equal(t, piece, pp.PieceCID)
+	require.Nil(t, pp.PricePerByte)
+	require.NotNil(t, pp.UnsealPrice)
+	require.Equal(t, unseal, *pp.UnsealPrice)
+}
@@ -2,6 +2,8 @@ package modules
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
@@ -9,7 +11,10 @@ import (
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/api/client"
 	cliutil "github.com/filecoin-project/lotus/cli/util"
+	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/node/modules/helpers"
+	"github.com/filecoin-project/lotus/node/repo"
 	"github.com/filecoin-project/lotus/storage/sectorblocks"
 )
 
@@ -68,3 +73,114 @@ func ConnectStorageService(apiInfo string) func(mctx helpers.MetricsCtx, lc fx.L
 		return connectMinerService(apiInfo)(mctx, lc)
 	}
 }
+
+// MinerJournal is the journal of miner processes, which the other processes of
+// the miner subscribe to with the MinerEvents API
+func MinerJournal(lr repo.LockedRepo, lc fx.Lifecycle, disabled journal.DisabledEvents) (*journal.Broadcaster, error) {
+	j, err := OpenFilesystemJournal(lr, lc, disabled)
+	if err != nil {
+		return nil, err
+	}
+	return journal.NewBroadcaster(j), nil
+}
+
+// MirroredEvent is the journal entry of an event recorded by another process
+// of the miner
+type MirroredEvent struct {
+	// Source is the API endpoint of the process
+	Source     string
+	Subsystems api.MinerSubsystems
+	Timestamp  time.Time
+	Data       json.RawMessage
+}
+
+const mirrorRetryInterval = 30 * time.Second
+
+// MirrorMinerEvents records the journal events of the miner services this
+// process is connected to, so that the journal of each process shows the
+// events of the whole miner
+func MirrorMinerEvents(cfg config.MinerSubsystemConfig) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, j *journal.Broadcaster) {
+	var apiInfos []string
+	add := func(ai string) {
+		for _, s := range apiInfos {
+			if s == ai {
+				return
+			}
+		}
+		apiInfos = append(apiInfos, ai)
+	}
+	if !cfg.EnableSealing {
+		add(cfg.SealerApiInfo)
+	}
+	if !cfg.EnableSectorStorage {
+		add(cfg.SectorIndexApiInfo)
+	}
+	if cfg.EnableMining && cfg.ProvingApiInfo != "" {
+		add(cfg.ProvingApiInfo)
+	}
+
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, j *journal.Broadcaster) {
+		ctx := helpers.LifecycleCtx(mctx, lc)
+
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				for _, ai := range apiInfos {
+					go mirrorMinerEvents(ctx, ai, j)
+				}
+				return nil
+			},
+		})
+	}
+}
+
+func mirrorMinerEvents(ctx context.Context, apiInfo string, j *journal.Broadcaster) {
+	info := cliutil.ParseApiInfo(apiInfo)
+	addr, err := info.DialArgs("v0")
+	if err != nil {
+		log.Errorf("not mirroring miner events: could not get DialArgs: %s", err)
+		return
+	}
+
+	for {
+		err := func() error {
+			mapi, closer, err := client.NewStorageMinerRPCV0(ctx, addr, info.AuthHeader())
+			if err != nil {
+				return err
+			}
+			defer closer()
+
+			evts, err := mapi.MinerEvents(ctx)
+			if err != nil {
+				return xerrors.Errorf("subscribing to miner events: %w", err)
+			}
+
+			log.Infow("mirroring miner events", "source", addr)
+			for evt := range evts {
+				evt := evt
+				j.RecordMirrored(j.RegisterEventType(evt.System, evt.Event), func() interface{} {
+					return MirroredEvent{
+						Source:     addr,
+						Subsystems: evt.Subsystems,
+						Timestamp:  evt.Timestamp,
+						Data:       evt.Data,
+					}
+				})
+			}
+			return nil
+		}()
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Warnw("mirroring miner events failed", "source", addr, "error", err)
+		} else {
+			log.Warnw("miner events subscription closed", "source", addr)
+		}
+
+		select {
+		case <-time.After(mirrorRetryInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
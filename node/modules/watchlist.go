@@ -0,0 +1,46 @@
+package modules
+
+import (
+	"context"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"go.uber.org/fx"
+
+	"github.com/filecoin-project/lotus/chain/watchlist"
+	"github.com/filecoin-project/lotus/journal/alerting"
+	"github.com/filecoin-project/lotus/node/impl/full"
+	"github.com/filecoin-project/lotus/node/modules/dtypes"
+	"github.com/filecoin-project/lotus/node/modules/helpers"
+)
+
+type WatchlistAPI struct {
+	fx.In
+
+	full.ChainModuleAPI
+	full.StateModuleAPI
+}
+
+var _ watchlist.WatchlistAPI = &WatchlistAPI{}
+
+func NewWatchlistStore(ds dtypes.MetadataDS) *watchlist.Store {
+	ds = namespace.Wrap(ds, datastore.NewKey("/watchlist/"))
+	return watchlist.NewStore(ds)
+}
+
+func NewWatchlist(mctx helpers.MetricsCtx, lc fx.Lifecycle, str *watchlist.Store, al *alerting.Alerting, api WatchlistAPI) *watchlist.Watchlist {
+	ctx := helpers.LifecycleCtx(mctx, lc)
+	ctx, shutdown := context.WithCancel(ctx)
+
+	w := watchlist.NewWatchlist(ctx, shutdown, str, al, &api)
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			return w.Start()
+		},
+		OnStop: func(context.Context) error {
+			return w.Stop()
+		},
+	})
+
+	return w
+}
@@ -12,6 +12,9 @@ func BadgerBlockstoreOptions(domain BlockstoreDomain, path string, readonly bool
 	// in order to shorten keys, but it'll require a migration.
 	opts.Prefix = "/blocks/"
 
+	// The domain names the blockstore in its metrics.
+	opts.Name = string(domain)
+
 	// Blockstore values are immutable; therefore we do not expect any
 	// conflicts to emerge.
 	opts.DetectConflicts = false
@@ -159,6 +159,33 @@ func (markets) APIInfoEnvVars() (primary string, fallbacks []string, deprecated
 	return "MARKETS_API_INFO", []string{"MINER_API_INFO"}, nil
 }
 
+var Proving proving
+
+type proving struct{}
+
+func (proving) Type() string {
+	return "Proving"
+}
+
+func (proving) Config() interface{} {
+	return config.DefaultStorageMiner()
+}
+
+func (proving) APIFlags() []string {
+	// support split proving-miner and monolith deployments.
+	return []string{"proving-api-url", "miner-api-url"}
+}
+
+func (proving) RepoFlags() []string {
+	// support split proving-miner and monolith deployments.
+	return []string{"proving-repo", "miner-repo"}
+}
+
+func (proving) APIInfoEnvVars() (primary string, fallbacks []string, deprecated []string) {
+	// support split proving-miner and monolith deployments.
+	return "PROVING_API_INFO", []string{"MINER_API_INFO"}, nil
+}
+
 type worker struct {
 }
 
@@ -238,6 +265,10 @@ func (fsr *FsRepo) SetConfigPath(cfgPath string) {
 	fsr.configPath = cfgPath
 }
 
+func (fsr *FsRepo) ConfigPath() string {
+	return fsr.configPath
+}
+
 func (fsr *FsRepo) Exists() (bool, error) {
 	_, err := os.Stat(filepath.Join(fsr.path, fsDatastore))
 	notexist := os.IsNotExist(err)
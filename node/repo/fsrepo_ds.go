@@ -23,6 +23,8 @@ var fsDatastores = map[string]dsCtor{
 	"staging": badgerDs, // miner specific
 
 	"client": badgerDs, // client specific
+
+	"chainindex": levelDs, // chain index, full node specific
 }
 
 func badgerDs(path string, readonly bool) (datastore.Batching, error) {
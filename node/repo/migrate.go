@@ -0,0 +1,255 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const fsMigrations = "migrations.json"
+
+// migration is a versioned change of the layout of a repo. Migrations replace
+// the upgrade code paths run on every start of the node: each of them is
+// applied once, and recorded in the repo.
+type migration struct {
+	// Version identifies the migration; migrations are applied in version order
+	Version int
+	Name    string
+	// RepoTypes are the types of the repos the migration applies to
+	RepoTypes []RepoType
+
+	// Plan describes the changes the migration would make, without making them
+	Plan func(ctx context.Context, lr *fsLockedRepo) ([]string, error)
+	// Apply makes the changes, and returns the state needed by Revert to undo
+	// them
+	Apply func(ctx context.Context, lr *fsLockedRepo) (json.RawMessage, error)
+	// Revert undoes the changes made by Apply
+	Revert func(ctx context.Context, lr *fsLockedRepo, undo json.RawMessage) error
+}
+
+func (m *migration) appliesTo(t RepoType) bool {
+	for _, rt := range m.RepoTypes {
+		if rt.Type() == t.Type() {
+			return true
+		}
+	}
+	return false
+}
+
+// AppliedMigration is the record of a migration applied to a repo
+type AppliedMigration struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time
+	// Changes are the changes made by the migration
+	Changes []string
+	// Undo is the state needed to revert the migration
+	Undo json.RawMessage `json:",omitempty"`
+}
+
+// MigrationReport describes a migration which was, or in a dry run would be,
+// applied or reverted
+type MigrationReport struct {
+	Version int
+	Name    string
+	Changes []string
+}
+
+// MigrationStatus lists the migrations of a repo
+type MigrationStatus struct {
+	Applied []AppliedMigration
+	Pending []MigrationReport
+}
+
+// Migrations returns the applied migrations of the repo, and the changes the
+// pending ones would make. Only filesystem repos have migrations.
+func Migrations(ctx context.Context, lr LockedRepo) (*MigrationStatus, error) {
+	fsr, ok := lr.(*fsLockedRepo)
+	if !ok {
+		return &MigrationStatus{}, nil
+	}
+
+	applied, err := fsr.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	pending := fsr.pendingMigrations(applied)
+
+	st := &MigrationStatus{Applied: applied}
+	for _, m := range pending {
+		changes, err := m.Plan(ctx, fsr)
+		if err != nil {
+			return nil, xerrors.Errorf("planning migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		st.Pending = append(st.Pending, MigrationReport{Version: m.Version, Name: m.Name, Changes: changes})
+	}
+	return st, nil
+}
+
+// Migrate applies the pending migrations of the repo. With dryRun, nothing is
+// changed and the changes the migrations would make are reported.
+func Migrate(ctx context.Context, lr LockedRepo, dryRun bool) ([]MigrationReport, error) {
+	if dryRun {
+		st, err := Migrations(ctx, lr)
+		if err != nil {
+			return nil, err
+		}
+		return st.Pending, nil
+	}
+
+	fsr, ok := lr.(*fsLockedRepo)
+	if !ok {
+		return nil, nil
+	}
+	if fsr.readonly {
+		return nil, xerrors.Errorf("can't migrate a repo opened read-only")
+	}
+
+	applied, err := fsr.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	pending := fsr.pendingMigrations(applied)
+
+	var out []MigrationReport
+	for _, m := range pending {
+		changes, err := m.Plan(ctx, fsr)
+		if err != nil {
+			return out, xerrors.Errorf("planning migration %d (%s): %w", m.Version, m.Name, err)
+		}
+
+		var undo json.RawMessage
+		if len(changes) > 0 {
+			log.Infow("applying repo migration", "version", m.Version, "name", m.Name, "changes", len(changes))
+
+			undo, err = m.Apply(ctx, fsr)
+			if err != nil {
+				return out, xerrors.Errorf("applying migration %d (%s): %w", m.Version, m.Name, err)
+			}
+		}
+
+		// migrations without changes are recorded as well, so that they
+		// aren't planned on each start
+		applied = append(applied, AppliedMigration{
+			Version:   m.Version,
+			Name:      m.Name,
+			AppliedAt: time.Now(),
+			Changes:   changes,
+			Undo:      undo,
+		})
+		if err := fsr.setAppliedMigrations(applied); err != nil {
+			return out, xerrors.Errorf("recording migration %d (%s): %w", m.Version, m.Name, err)
+		}
+
+		if len(changes) > 0 {
+			out = append(out, MigrationReport{Version: m.Version, Name: m.Name, Changes: changes})
+		}
+	}
+
+	return out, nil
+}
+
+// RollbackMigration reverts the most recently applied migration of the repo,
+// which is applied again by the next Migrate. With dryRun, nothing is changed.
+func RollbackMigration(ctx context.Context, lr LockedRepo, dryRun bool) (*MigrationReport, error) {
+	fsr, ok := lr.(*fsLockedRepo)
+	if !ok {
+		return nil, xerrors.Errorf("repo of type %T doesn't have migrations", lr)
+	}
+	if fsr.readonly && !dryRun {
+		return nil, xerrors.Errorf("can't roll back a migration of a repo opened read-only")
+	}
+
+	applied, err := fsr.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+	if len(applied) == 0 {
+		return nil, xerrors.Errorf("no migrations were applied to the repo")
+	}
+
+	last := applied[len(applied)-1]
+	m := findMigration(last.Version)
+	if m == nil {
+		return nil, xerrors.Errorf("migration %d (%s) is unknown to this version", last.Version, last.Name)
+	}
+
+	rep := &MigrationReport{Version: last.Version, Name: last.Name, Changes: last.Changes}
+	if dryRun {
+		return rep, nil
+	}
+
+	if len(last.Changes) > 0 {
+		if err := m.Revert(ctx, fsr, last.Undo); err != nil {
+			return nil, xerrors.Errorf("reverting migration %d (%s): %w", m.Version, m.Name, err)
+		}
+	}
+
+	if err := fsr.setAppliedMigrations(applied[:len(applied)-1]); err != nil {
+		return nil, xerrors.Errorf("recording rollback of migration %d (%s): %w", m.Version, m.Name, err)
+	}
+	return rep, nil
+}
+
+func findMigration(version int) *migration {
+	for i := range migrations {
+		if migrations[i].Version == version {
+			return &migrations[i]
+		}
+	}
+	return nil
+}
+
+func (fsr *fsLockedRepo) pendingMigrations(applied []AppliedMigration) []*migration {
+	done := map[int]bool{}
+	for _, a := range applied {
+		done[a.Version] = true
+	}
+
+	var out []*migration
+	for i := range migrations {
+		m := &migrations[i]
+		if done[m.Version] || !m.appliesTo(fsr.repoType) {
+			continue
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func (fsr *fsLockedRepo) appliedMigrations() ([]AppliedMigration, error) {
+	b, err := ioutil.ReadFile(fsr.join(fsMigrations))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("reading applied migrations: %w", err)
+	}
+
+	var applied []AppliedMigration
+	if err := json.Unmarshal(b, &applied); err != nil {
+		return nil, xerrors.Errorf("decoding applied migrations: %w", err)
+	}
+	return applied, nil
+}
+
+func (fsr *fsLockedRepo) setAppliedMigrations(applied []AppliedMigration) error {
+	b, err := json.MarshalIndent(applied, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write the record atomically, a partial record would make the migrations
+	// run again
+	tmp := fsr.join(fsMigrations + ".tmp")
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fsr.join(fsMigrations))
+}
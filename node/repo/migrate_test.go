@@ -0,0 +1,119 @@
+//stm: #unit
+package repo
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestMigrateKeystorePermissions(t *testing.T) {
+	ctx := context.Background()
+
+	r := genFsRepo(t)
+	lr, err := r.Lock(FullNode)
+	require.NoError(t, err)
+	defer lr.Close() // nolint:errcheck
+
+	ks, err := lr.KeyStore()
+	require.NoError(t, err)
+	require.NoError(t, ks.Put("test", types.KeyInfo{Type: "secp256k1", PrivateKey: []byte("key")}))
+
+	files, err := ioutil.ReadDir(filepath.Join(r.path, fsKeystore))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	keyPath := filepath.Join(r.path, fsKeystore, files[0].Name())
+	require.NoError(t, os.Chmod(keyPath, 0644))
+
+	mode := func() os.FileMode {
+		fi, err := os.Stat(keyPath)
+		require.NoError(t, err)
+		return fi.Mode().Perm()
+	}
+
+	// dry run
+	reports, err := Migrate(ctx, lr, true)
+	require.NoError(t, err)
+	require.Len(t, reports, 2)
+	require.Equal(t, "keystore-permissions", reports[0].Name)
+	require.Len(t, reports[0].Changes, 1)
+	require.Equal(t, os.FileMode(0644), mode())
+
+	st, err := Migrations(ctx, lr)
+	require.NoError(t, err)
+	require.Empty(t, st.Applied)
+	require.Len(t, st.Pending, 2)
+
+	// apply, only the migration making changes is reported
+	reports, err = Migrate(ctx, lr, false)
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	require.Equal(t, os.FileMode(0600), mode())
+
+	st, err = Migrations(ctx, lr)
+	require.NoError(t, err)
+	require.Len(t, st.Applied, 2)
+	require.Empty(t, st.Pending)
+
+	_, err = ks.Get("test")
+	require.NoError(t, err)
+
+	// the config migration didn't change anything
+	rep, err := RollbackMigration(ctx, lr, false)
+	require.NoError(t, err)
+	require.Equal(t, "config-deprecated-fields", rep.Name)
+
+	// dry run of the rollback
+	rep, err = RollbackMigration(ctx, lr, true)
+	require.NoError(t, err)
+	require.Equal(t, "keystore-permissions", rep.Name)
+	require.Equal(t, os.FileMode(0600), mode())
+
+	rep, err = RollbackMigration(ctx, lr, false)
+	require.NoError(t, err)
+	require.Equal(t, "keystore-permissions", rep.Name)
+	require.Equal(t, os.FileMode(0644), mode())
+
+	_, err = RollbackMigration(ctx, lr, false)
+	require.Error(t, err)
+
+	// migrate again
+	reports, err = Migrate(ctx, lr, false)
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	require.Equal(t, os.FileMode(0600), mode())
+}
+
+func TestMigrateConfigFields(t *testing.T) {
+	ctx := context.Background()
+
+	r := genFsRepo(t)
+	cfg := []byte("[Client]\n  SimultaneousTransfers = 10\n")
+	require.NoError(t, ioutil.WriteFile(r.configPath, cfg, 0644))
+
+	lr, err := r.Lock(FullNode)
+	require.NoError(t, err)
+	defer lr.Close() // nolint:errcheck
+
+	reports, err := Migrate(ctx, lr, false)
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	require.Equal(t, "config-deprecated-fields", reports[0].Name)
+
+	b, err := ioutil.ReadFile(r.configPath)
+	require.NoError(t, err)
+	require.Contains(t, string(b), "SimultaneousTransfersForStorage = 10")
+
+	_, err = RollbackMigration(ctx, lr, false)
+	require.NoError(t, err)
+
+	b, err = ioutil.ReadFile(r.configPath)
+	require.NoError(t, err)
+	require.Equal(t, cfg, b)
+}
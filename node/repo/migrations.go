@@ -0,0 +1,291 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+// migrations of the repos, in version order. Released migrations must not be
+// changed, a new version has to be added instead.
+var migrations = []migration{
+	{
+		Version:   1,
+		Name:      "keystore-permissions",
+		RepoTypes: []RepoType{FullNode, StorageMiner, Worker, Wallet},
+		Plan:      planKeystorePermissions,
+		Apply:     applyKeystorePermissions,
+		Revert:    revertKeystorePermissions,
+	},
+	{
+		Version:   2,
+		Name:      "config-deprecated-fields",
+		RepoTypes: []RepoType{FullNode, StorageMiner},
+		Plan:      planConfigFields,
+		Apply:     applyConfigFields,
+		Revert:    revertConfigFields,
+	},
+	{
+		Version:   3,
+		Name:      "deal-staging-dir",
+		RepoTypes: []RepoType{StorageMiner},
+		Plan:      planDealStaging,
+		Apply:     applyDealStaging,
+		Revert:    revertDealStaging,
+	},
+}
+
+// keystore-permissions: the keystore directory and the key files are only
+// accessible to their owner, keys readable by others fail to load
+
+const (
+	keystoreDirMode = 0700
+	keyFileMode     = 0600
+)
+
+// keystoreModes returns the paths in the keystore with the wrong permissions,
+// with their current permissions
+func keystoreModes(lr *fsLockedRepo) (map[string]os.FileMode, error) {
+	dir := lr.join(fsKeystore)
+	fi, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[string]os.FileMode{}
+	if fi.Mode().Perm() != keystoreDirMode {
+		out[fsKeystore] = fi.Mode().Perm()
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, xerrors.Errorf("reading keystore dir: %w", err)
+	}
+	for _, e := range entries {
+		if !e.Mode().IsRegular() {
+			continue
+		}
+		if e.Mode().Perm()&0077 != 0 {
+			out[filepath.Join(fsKeystore, e.Name())] = e.Mode().Perm()
+		}
+	}
+	return out, nil
+}
+
+func sortedKeys(m map[string]os.FileMode) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func planKeystorePermissions(_ context.Context, lr *fsLockedRepo) ([]string, error) {
+	modes, err := keystoreModes(lr)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []string
+	for _, p := range sortedKeys(modes) {
+		want := os.FileMode(keyFileMode)
+		if p == fsKeystore {
+			want = keystoreDirMode
+		}
+		changes = append(changes, fmt.Sprintf("change the permissions of %s from %s to %s", p, modes[p], want))
+	}
+	return changes, nil
+}
+
+func applyKeystorePermissions(_ context.Context, lr *fsLockedRepo) (json.RawMessage, error) {
+	modes, err := keystoreModes(lr)
+	if err != nil {
+		return nil, err
+	}
+
+	for p := range modes {
+		want := os.FileMode(keyFileMode)
+		if p == fsKeystore {
+			want = keystoreDirMode
+		}
+		if err := os.Chmod(lr.join(p), want); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(modes)
+}
+
+func revertKeystorePermissions(_ context.Context, lr *fsLockedRepo, undo json.RawMessage) error {
+	var modes map[string]os.FileMode
+	if err := json.Unmarshal(undo, &modes); err != nil {
+		return err
+	}
+
+	for p, mode := range modes {
+		if err := os.Chmod(lr.join(p), mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// config-deprecated-fields: renamed config fields get their new name, removed
+// ones are commented out, see config.FieldMigrations
+
+func readConfigFile(lr *fsLockedRepo) ([]byte, error) {
+	b, err := ioutil.ReadFile(lr.configPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return b, err
+}
+
+func planConfigFields(_ context.Context, lr *fsLockedRepo) ([]string, error) {
+	b, err := readConfigFile(lr)
+	if err != nil || b == nil {
+		return nil, err
+	}
+
+	_, applied, err := config.MigrateConfig(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []string
+	for _, m := range applied {
+		changes = append(changes, m.String())
+	}
+	return changes, nil
+}
+
+func applyConfigFields(_ context.Context, lr *fsLockedRepo) (json.RawMessage, error) {
+	lr.configLk.Lock()
+	defer lr.configLk.Unlock()
+
+	b, err := readConfigFile(lr)
+	if err != nil || b == nil {
+		return nil, err
+	}
+
+	migrated, _, err := config.MigrateConfig(b)
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(lr.configPath, migrated, 0644); err != nil {
+		return nil, err
+	}
+
+	// the previous config file
+	return json.Marshal(b)
+}
+
+func revertConfigFields(_ context.Context, lr *fsLockedRepo, undo json.RawMessage) error {
+	lr.configLk.Lock()
+	defer lr.configLk.Unlock()
+
+	var b []byte
+	if err := json.Unmarshal(undo, &b); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(lr.configPath, b, 0644)
+}
+
+// deal-staging-dir: the deals staged by the storage market, which used to be
+// written to the root of the repo, are moved to the deal staging directory
+// and symlinked from their old path
+
+// same as modules.StagingAreaDirName
+const fsDealStaging = "deal-staging"
+
+func stagedDeals(lr *fsLockedRepo) ([]string, error) {
+	entries, err := ioutil.ReadDir(lr.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for _, e := range entries {
+		// the FileStore from fil-storage-market creates temporary staged deal files with the pattern "fstmp"
+		// https://github.com/filecoin-project/go-fil-markets/blob/00ff81e477d846ac0cb58a0c7d1c2e9afb5ee1db/filestore/filestore.go#L69
+		if e.Mode().IsRegular() && strings.Contains(e.Name(), "fstmp") {
+			out = append(out, e.Name())
+		}
+	}
+	return out, nil
+}
+
+func planDealStaging(_ context.Context, lr *fsLockedRepo) ([]string, error) {
+	names, err := stagedDeals(lr)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []string
+	for _, name := range names {
+		changes = append(changes, fmt.Sprintf("move staged deal %s to %s, and symlink it", name, fsDealStaging))
+	}
+	return changes, nil
+}
+
+func applyDealStaging(_ context.Context, lr *fsLockedRepo) (json.RawMessage, error) {
+	names, err := stagedDeals(lr)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := lr.join(fsDealStaging)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, xerrors.Errorf("failed to mk directory %s for deal staging: %w", dir, err)
+	}
+
+	var moved []string
+	for _, name := range names {
+		oldPath, newPath := lr.join(name), filepath.Join(dir, name)
+		if err := os.Rename(oldPath, newPath); err != nil {
+			return nil, xerrors.Errorf("failed to move %s to %s: %w", oldPath, newPath, err)
+		}
+		if err := os.Symlink(newPath, oldPath); err != nil {
+			return nil, xerrors.Errorf("failed to symlink %s to %s: %w", oldPath, newPath, err)
+		}
+		moved = append(moved, name)
+	}
+
+	return json.Marshal(moved)
+}
+
+func revertDealStaging(_ context.Context, lr *fsLockedRepo, undo json.RawMessage) error {
+	var moved []string
+	if err := json.Unmarshal(undo, &moved); err != nil {
+		return err
+	}
+
+	for _, name := range moved {
+		oldPath, newPath := lr.join(name), lr.join(fsDealStaging, name)
+		if _, err := os.Stat(newPath); os.IsNotExist(err) {
+			// the deal was processed since
+			_ = os.Remove(oldPath)
+			continue
+		}
+		if err := os.Remove(oldPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err := os.Rename(newPath, oldPath); err != nil {
+			return xerrors.Errorf("failed to move %s to %s: %w", newPath, oldPath, err)
+		}
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package node
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"net"
 	"net/http"
@@ -23,11 +24,17 @@ import (
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/api/v0api"
 	"github.com/filecoin-project/lotus/api/v1api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/lib/rpccbor"
+	"github.com/filecoin-project/lotus/lib/rpcconn"
 	"github.com/filecoin-project/lotus/lib/rpcenc"
+	"github.com/filecoin-project/lotus/lib/tracing"
+	"github.com/filecoin-project/lotus/markets/httpretrieval"
 	"github.com/filecoin-project/lotus/metrics"
 	"github.com/filecoin-project/lotus/metrics/proxy"
 	"github.com/filecoin-project/lotus/node/impl"
 	"github.com/filecoin-project/lotus/node/impl/client"
+	"github.com/filecoin-project/lotus/storage/sealer/workerboot"
 )
 
 var rpclog = logging.Logger("rpc")
@@ -38,16 +45,24 @@ var rpclog = logging.Logger("rpc")
 // It returns the stop function to be called to terminate the endpoint.
 //
 // The supplied ID is used in tracing, by inserting a tag in the context.
-func ServeRPC(h http.Handler, id string, addr multiaddr.Multiaddr) (StopFunc, error) {
+//
+// The endpoint is served over TLS when tlsCfg isn't nil.
+func ServeRPC(h http.Handler, id string, addr multiaddr.Multiaddr, tlsCfg *tls.Config) (StopFunc, error) {
 	// Start listening to the addr; if invalid or occupied, we will fail early.
 	lst, err := manet.Listen(addr)
 	if err != nil {
 		return nil, xerrors.Errorf("could not listen: %w", err)
 	}
 
+	nl := manet.NetListener(lst)
+	if tlsCfg != nil {
+		nl = tls.NewListener(nl, tlsCfg)
+	}
+
 	// Instantiate the server and start listening.
 	srv := &http.Server{
-		Handler: h,
+		Handler:     tracing.CallerHandler(h),
+		ConnContext: rpcconn.ConnContext,
 		BaseContext: func(listener net.Listener) context.Context {
 			ctx, _ := tag.New(context.Background(), tag.Upsert(metrics.APIInterface, id))
 			return ctx
@@ -55,7 +70,7 @@ func ServeRPC(h http.Handler, id string, addr multiaddr.Multiaddr) (StopFunc, er
 	}
 
 	go func() {
-		err = srv.Serve(manet.NetListener(lst))
+		err = srv.Serve(nl)
 		if err != http.ErrServerClosed {
 			rpclog.Warnf("rpc server failed: %s", err)
 		}
@@ -64,27 +79,48 @@ func ServeRPC(h http.Handler, id string, addr multiaddr.Multiaddr) (StopFunc, er
 	return srv.Shutdown, err
 }
 
+// APITLSConfig returns the TLS config of the API of a node, nil when the API is
+// served without TLS
+func APITLSConfig(a api.Common) *tls.Config {
+	switch a := a.(type) {
+	case *impl.FullNodeAPI:
+		return a.APITLS.ServerConfig()
+	case *impl.StorageMinerAPI:
+		return a.APITLS.ServerConfig()
+	default:
+		return nil
+	}
+}
+
 // FullNodeHandler returns a full node handler, to be mounted as-is on the server.
 func FullNodeHandler(a v1api.FullNode, permissioned bool, opts ...jsonrpc.ServerOption) (http.Handler, error) {
 	m := mux.NewRouter()
 
+	connCfg := a.(*impl.FullNodeAPI).RPCConn
+
 	serveRpc := func(path string, hnd interface{}) {
 		rpcServer := jsonrpc.NewServer(opts...)
 		rpcServer.Register("Filecoin", hnd)
 		rpcServer.AliasMethod("rpc.discover", "Filecoin.Discover")
 
-		var handler http.Handler = rpcServer
+		handler := rpcconn.Handler(rpccbor.Handler(rpcServer, "Filecoin", hnd), connCfg)
+		handler = cacheCallerHandler(handler)
 		if permissioned {
-			handler = &auth.Handler{Verify: a.AuthVerify, Next: rpcServer.ServeHTTP}
+			handler = &auth.Handler{Verify: a.AuthVerify, Next: handler.ServeHTTP}
 		}
 
 		m.Handle(path, handler)
 	}
 
+	readOnly := a.(*impl.FullNodeAPI).ReadOnly
+
 	fnapi := proxy.MetricedFullAPI(a)
 	if permissioned {
 		fnapi = api.PermissionedFullAPI(fnapi)
 	}
+	if readOnly {
+		fnapi = api.ReadOnlyFullAPI(fnapi)
+	}
 
 	serveRpc("/rpc/v1", fnapi)
 	serveRpc("/rpc/v0", &v0api.WrapperV1Full{FullNode: fnapi})
@@ -92,6 +128,11 @@ func FullNodeHandler(a v1api.FullNode, permissioned bool, opts ...jsonrpc.Server
 	// Import handler
 	handleImportFunc := handleImport(a.(*impl.FullNodeAPI))
 	handleExportFunc := handleExport(a.(*impl.FullNodeAPI))
+	if readOnly {
+		handleImportFunc = func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, api.ErrReadOnly.Error(), http.StatusForbidden)
+		}
+	}
 	if permissioned {
 		importAH := &auth.Handler{
 			Verify: a.AuthVerify,
@@ -152,10 +193,21 @@ func MinerHandler(a api.StorageMiner, permissioned bool) (http.Handler, error) {
 		rootMux.PathPrefix("/remote").Handler(hnd)
 	}
 
+	// http retrieval, authenticated with its own access tokens
+	if hr := a.(*impl.StorageMinerAPI).HTTPRetrieval; hr != nil {
+		rootMux.PathPrefix(httpretrieval.PiecePath).Handler(hr)
+		rootMux.PathPrefix(httpretrieval.PayloadPath).Handler(hr)
+	}
+
+	// worker registration, authenticated with one-time tokens
+	if wr := a.(*impl.StorageMinerAPI).WorkerRegistry; wr != nil {
+		rootMux.Path(workerboot.RegisterPath).Handler(wr)
+	}
+
 	// local APIs
 	{
 		m := mux.NewRouter()
-		m.Handle("/rpc/v0", rpcServer)
+		m.Handle("/rpc/v0", rpcconn.Handler(rpcServer, a.(*impl.StorageMinerAPI).RPCConn))
 		m.Handle("/rpc/streams/v0/push/{uuid}", readerHandler)
 		// debugging
 		m.Handle("/debug/metrics", metrics.Exporter())
@@ -256,3 +308,14 @@ func handleFractionOpt(name string, setter func(int)) http.HandlerFunc {
 		setter(fr)
 	}
 }
+
+// cacheCallerHandler tags the requests of the clients which identify
+// themselves as the sealing caller, for the block cache metrics
+func cacheCallerHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(blockstore.CacheCallerHeader) == blockstore.CacheCallerSealing {
+			r = r.WithContext(blockstore.WithCacheCaller(r.Context(), blockstore.CacheCallerSealing))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
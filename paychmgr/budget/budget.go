@@ -0,0 +1,425 @@
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin/v8/paych"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/paychmgr"
+)
+
+var log = logging.Logger("paychbudget")
+
+var (
+	budgetKey       = datastore.NewKey("/paych/budget")
+	collectedPrefix = datastore.NewKey("/paych/budget/collected")
+)
+
+const maintainInterval = 10 * time.Minute
+
+// settleDelay is the approximate time between settling a channel and being
+// able to collect it
+var settleDelay = time.Duration(paych.SettleDelay) * time.Duration(build.BlockDelaySecs) * time.Second
+
+// PaychManager is the subset of the payment channel manager used to manage
+// the channels within the budget
+type PaychManager interface {
+	GetPaych(ctx context.Context, from, to address.Address, amt types.BigInt, opts paychmgr.GetOpts) (address.Address, cid.Cid, error)
+	AvailableFundsByFromTo(ctx context.Context, from address.Address, to address.Address) (*api.ChannelAvailableFunds, error)
+	ListChannels(ctx context.Context) ([]address.Address, error)
+	GetChannelInfo(ctx context.Context, addr address.Address) (*paychmgr.ChannelInfo, error)
+	Settle(ctx context.Context, addr address.Address) (cid.Cid, error)
+	Collect(ctx context.Context, addr address.Address) (cid.Cid, error)
+}
+
+// Manager creates, funds, settles and collects the payment channels paying
+// for retrievals, within the configured budget
+type Manager struct {
+	pm PaychManager
+	ds datastore.Batching
+
+	lk        sync.Mutex
+	budget    api.PaychBudget
+	activity  map[address.Address]time.Time
+	settledAt map[address.Address]time.Time
+
+	// serializes the budget checks with the funding of channels
+	fundLk sync.Mutex
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewManager(pm PaychManager, ds datastore.Batching) (*Manager, error) {
+	m := &Manager{
+		pm: pm,
+		ds: ds,
+		budget: api.PaychBudget{
+			MaxTotal:      big.Zero(),
+			MaxPerChannel: big.Zero(),
+			TopUp:         big.Zero(),
+		},
+		activity:  map[address.Address]time.Time{},
+		settledAt: map[address.Address]time.Time{},
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	b, err := ds.Get(context.TODO(), budgetKey)
+	switch {
+	case err == datastore.ErrNotFound:
+	case err != nil:
+		return nil, xerrors.Errorf("loading payment channel budget: %w", err)
+	default:
+		if err := json.Unmarshal(b, &m.budget); err != nil {
+			return nil, xerrors.Errorf("decoding payment channel budget: %w", err)
+		}
+	}
+
+	return m, nil
+}
+
+func (m *Manager) Start() {
+	go m.run()
+}
+
+func (m *Manager) Stop() {
+	close(m.stop)
+	<-m.done
+}
+
+func (m *Manager) run() {
+	defer close(m.done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ticker := build.Clock.Ticker(maintainInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.maintain(ctx)
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// Budget returns the current budget
+func (m *Manager) Budget() api.PaychBudget {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	return m.budget
+}
+
+// SetBudget validates and persists the budget, which applies to the following
+// retrievals
+func (m *Manager) SetBudget(ctx context.Context, b api.PaychBudget) error {
+	for _, amt := range []*types.BigInt{&b.MaxTotal, &b.MaxPerChannel, &b.TopUp} {
+		if amt.Int == nil {
+			*amt = big.Zero()
+		}
+		if amt.LessThan(big.Zero()) {
+			return xerrors.Errorf("budget amounts can't be negative")
+		}
+	}
+	if b.SettleAfter < 0 {
+		return xerrors.Errorf("settle delay can't be negative")
+	}
+
+	bb, err := json.Marshal(&b)
+	if err != nil {
+		return xerrors.Errorf("encoding payment channel budget: %w", err)
+	}
+	if err := m.ds.Put(ctx, budgetKey, bb); err != nil {
+		return xerrors.Errorf("saving payment channel budget: %w", err)
+	}
+
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	m.budget = b
+	return nil
+}
+
+// Touch records retrieval activity on the channel, which delays its
+// settlement
+func (m *Manager) Touch(ch address.Address) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	m.activity[ch] = build.Clock.Now()
+}
+
+// GetPaych returns the channel from the client to the provider, reserving amt
+// for a retrieval. With the budget enabled, channels which allocated all their
+// lanes are replaced, and channels lacking funds are topped up within the
+// budget.
+func (m *Manager) GetPaych(ctx context.Context, from, to address.Address, amt types.BigInt, offChain bool) (address.Address, cid.Cid, error) {
+	b := m.Budget()
+	if b.Enable && !offChain {
+		if err := m.fund(ctx, b, from, to, amt); err != nil {
+			return address.Undef, cid.Undef, err
+		}
+	}
+
+	ch, mcid, err := m.pm.GetPaych(ctx, from, to, amt, paychmgr.GetOpts{
+		Reserve:  true,
+		OffChain: offChain,
+	})
+	if err != nil {
+		return address.Undef, cid.Undef, err
+	}
+
+	if ch != address.Undef {
+		m.Touch(ch)
+	}
+	return ch, mcid, nil
+}
+
+// fund makes enough funds available in the channel from the client to the
+// provider for the amount to be reserved without another message
+func (m *Manager) fund(ctx context.Context, b api.PaychBudget, from, to address.Address, amt types.BigInt) error {
+	m.fundLk.Lock()
+	defer m.fundLk.Unlock()
+
+	funds, err := m.pm.AvailableFundsByFromTo(ctx, from, to)
+	if err != nil {
+		return xerrors.Errorf("getting available funds: %w", err)
+	}
+
+	if funds.Channel != nil && b.MaxLanes > 0 {
+		ci, err := m.pm.GetChannelInfo(ctx, *funds.Channel)
+		if err != nil {
+			return xerrors.Errorf("getting channel info: %w", err)
+		}
+
+		if ci.NextLane >= b.MaxLanes {
+			log.Infow("settling payment channel which allocated all its lanes", "channel", *funds.Channel, "lanes", ci.NextLane)
+			if _, err := m.pm.Settle(ctx, *funds.Channel); err != nil {
+				return xerrors.Errorf("settling channel %s: %w", *funds.Channel, err)
+			}
+			m.markSettled(*funds.Channel)
+
+			// the next funds request creates a new channel
+			funds = &api.ChannelAvailableFunds{
+				ConfirmedAmt:        big.Zero(),
+				PendingAmt:          big.Zero(),
+				NonReservedAmt:      big.Zero(),
+				PendingAvailableAmt: big.Zero(),
+			}
+		}
+	}
+
+	need := big.Sub(amt, big.Add(funds.NonReservedAmt, funds.PendingAvailableAmt))
+	if need.LessThanEqual(big.Zero()) {
+		return nil
+	}
+	add := big.Max(need, b.TopUp)
+
+	// stay within the budget
+	if b.MaxPerChannel.GreaterThan(big.Zero()) {
+		room := big.Sub(b.MaxPerChannel, big.Add(funds.ConfirmedAmt, funds.PendingAmt))
+		add = big.Min(add, room)
+	}
+	if b.MaxTotal.GreaterThan(big.Zero()) {
+		locked, err := m.locked(ctx)
+		if err != nil {
+			return err
+		}
+		add = big.Min(add, big.Sub(b.MaxTotal, locked))
+	}
+	if add.LessThan(need) {
+		return xerrors.Errorf("adding %s to the payment channel from %s to %s would exceed the payment channel budget",
+			types.FIL(need), from, to)
+	}
+
+	ch, _, err := m.pm.GetPaych(ctx, from, to, add, paychmgr.GetOpts{})
+	if err != nil {
+		return xerrors.Errorf("adding funds to the payment channel: %w", err)
+	}
+	log.Infow("topped up payment channel", "from", from, "to", to, "channel", ch, "amount", types.FIL(add))
+
+	return nil
+}
+
+// locked returns the funds of the active outbound channels
+func (m *Manager) locked(ctx context.Context) (types.BigInt, error) {
+	chs, err := m.pm.ListChannels(ctx)
+	if err != nil {
+		return big.Zero(), xerrors.Errorf("listing channels: %w", err)
+	}
+
+	locked := big.Zero()
+	for _, ch := range chs {
+		ci, err := m.pm.GetChannelInfo(ctx, ch)
+		if err != nil {
+			return big.Zero(), xerrors.Errorf("getting channel info: %w", err)
+		}
+		if ci.Direction != paychmgr.DirOutbound || ci.Settling {
+			continue
+		}
+		locked = big.Add(locked, orZero(ci.Amount))
+		locked = big.Add(locked, orZero(ci.PendingAmount))
+	}
+	return locked, nil
+}
+
+func orZero(v types.BigInt) types.BigInt {
+	if v.Int == nil {
+		return big.Zero()
+	}
+	return v
+}
+
+func (m *Manager) markSettled(ch address.Address) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	m.settledAt[ch] = build.Clock.Now()
+}
+
+func (m *Manager) collected(ctx context.Context, ch address.Address) (bool, error) {
+	return m.ds.Has(ctx, collectedPrefix.ChildString(ch.String()))
+}
+
+// maintain settles the idle outbound channels, and collects the settled ones
+func (m *Manager) maintain(ctx context.Context) {
+	b := m.Budget()
+	if !b.Enable || b.SettleAfter == 0 {
+		return
+	}
+
+	chs, err := m.pm.ListChannels(ctx)
+	if err != nil {
+		log.Errorw("listing payment channels", "error", err)
+		return
+	}
+
+	now := build.Clock.Now()
+	for _, ch := range chs {
+		ci, err := m.pm.GetChannelInfo(ctx, ch)
+		if err != nil {
+			log.Errorw("getting payment channel info", "channel", ch, "error", err)
+			continue
+		}
+		if ci.Direction != paychmgr.DirOutbound {
+			continue
+		}
+
+		done, err := m.collected(ctx, ch)
+		if err != nil {
+			log.Errorw("checking if payment channel was collected", "channel", ch, "error", err)
+			continue
+		}
+		if done {
+			continue
+		}
+
+		m.lk.Lock()
+		last, active := m.activity[ch]
+		settledAt, settled := m.settledAt[ch]
+		if !active {
+			// channels are idle from the time they are first seen
+			m.activity[ch] = now
+		}
+		if ci.Settling && !settled {
+			m.settledAt[ch] = now
+		}
+		m.lk.Unlock()
+
+		switch {
+		case !ci.Settling:
+			if !active || now.Sub(last) < b.SettleAfter {
+				continue
+			}
+
+			log.Infow("settling idle payment channel", "channel", ch, "idle", now.Sub(last))
+			if _, err := m.pm.Settle(ctx, ch); err != nil {
+				log.Errorw("settling payment channel", "channel", ch, "error", err)
+				continue
+			}
+			m.markSettled(ch)
+		case settled && now.Sub(settledAt) >= settleDelay:
+			log.Infow("collecting settled payment channel", "channel", ch)
+			if _, err := m.pm.Collect(ctx, ch); err != nil {
+				log.Errorw("collecting payment channel", "channel", ch, "error", err)
+				continue
+			}
+			if err := m.ds.Put(ctx, collectedPrefix.ChildString(ch.String()), []byte{}); err != nil {
+				log.Errorw("recording collected payment channel", "channel", ch, "error", err)
+			}
+		}
+	}
+}
+
+// Status returns the budget, and the outbound channels it applies to
+func (m *Manager) Status(ctx context.Context) (*api.PaychBudgetStatus, error) {
+	chs, err := m.pm.ListChannels(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("listing channels: %w", err)
+	}
+
+	st := &api.PaychBudgetStatus{
+		Budget:   m.Budget(),
+		Locked:   big.Zero(),
+		Channels: []api.PaychBudgetChannel{},
+	}
+
+	for _, ch := range chs {
+		ci, err := m.pm.GetChannelInfo(ctx, ch)
+		if err != nil {
+			return nil, xerrors.Errorf("getting channel info: %w", err)
+		}
+		if ci.Direction != paychmgr.DirOutbound {
+			continue
+		}
+
+		done, err := m.collected(ctx, ch)
+		if err != nil {
+			return nil, xerrors.Errorf("checking if channel was collected: %w", err)
+		}
+
+		amt := big.Add(orZero(ci.Amount), orZero(ci.PendingAmount))
+		if !ci.Settling {
+			st.Locked = big.Add(st.Locked, amt)
+		}
+
+		m.lk.Lock()
+		last := m.activity[ch]
+		m.lk.Unlock()
+
+		st.Channels = append(st.Channels, api.PaychBudgetChannel{
+			Channel:      ch,
+			To:           ci.Target,
+			Amount:       amt,
+			Lanes:        ci.NextLane,
+			LastActivity: last,
+			Settling:     ci.Settling,
+			Collected:    done,
+		})
+	}
+
+	sort.Slice(st.Channels, func(i, j int) bool {
+		return st.Channels[i].Channel.String() < st.Channels[j].Channel.String()
+	})
+
+	return st, nil
+}
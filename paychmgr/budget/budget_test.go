@@ -0,0 +1,262 @@
+//stm: #unit
+package budget
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/raulk/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/paychmgr"
+)
+
+type fundsCall struct {
+	amt     types.BigInt
+	reserve bool
+}
+
+// mockManager tracks a single outbound channel, funded right away
+type mockManager struct {
+	from, to address.Address
+	next     uint64
+
+	ch       *paychmgr.ChannelInfo
+	reserved types.BigInt
+	settling []*paychmgr.ChannelInfo
+
+	calls     []fundsCall
+	collected []address.Address
+}
+
+func (m *mockManager) GetPaych(ctx context.Context, from, to address.Address, amt types.BigInt, opts paychmgr.GetOpts) (address.Address, cid.Cid, error) {
+	m.calls = append(m.calls, fundsCall{amt: amt, reserve: opts.Reserve})
+
+	if m.ch == nil {
+		m.next++
+		addr, _ := address.NewIDAddress(100 + m.next)
+		m.ch = &paychmgr.ChannelInfo{
+			Channel:   &addr,
+			Control:   from,
+			Target:    to,
+			Direction: paychmgr.DirOutbound,
+			Amount:    big.Zero(),
+		}
+		m.reserved = big.Zero()
+	}
+
+	avail := big.Sub(m.ch.Amount, m.reserved)
+	if opts.Reserve {
+		if avail.LessThan(amt) {
+			m.ch.Amount = big.Add(m.ch.Amount, big.Sub(amt, avail))
+		}
+		m.reserved = big.Add(m.reserved, amt)
+	} else {
+		m.ch.Amount = big.Add(m.ch.Amount, amt)
+	}
+
+	return *m.ch.Channel, cid.Undef, nil
+}
+
+func (m *mockManager) AvailableFundsByFromTo(ctx context.Context, from address.Address, to address.Address) (*api.ChannelAvailableFunds, error) {
+	if m.ch == nil {
+		return &api.ChannelAvailableFunds{
+			ConfirmedAmt:        big.Zero(),
+			PendingAmt:          big.Zero(),
+			NonReservedAmt:      big.Zero(),
+			PendingAvailableAmt: big.Zero(),
+		}, nil
+	}
+	return &api.ChannelAvailableFunds{
+		Channel:             m.ch.Channel,
+		ConfirmedAmt:        m.ch.Amount,
+		PendingAmt:          big.Zero(),
+		NonReservedAmt:      big.Sub(m.ch.Amount, m.reserved),
+		PendingAvailableAmt: big.Zero(),
+	}, nil
+}
+
+func (m *mockManager) channels() []*paychmgr.ChannelInfo {
+	chs := m.settling
+	if m.ch != nil {
+		chs = append(chs, m.ch)
+	}
+	return chs
+}
+
+func (m *mockManager) ListChannels(ctx context.Context) ([]address.Address, error) {
+	var out []address.Address
+	for _, ci := range m.channels() {
+		out = append(out, *ci.Channel)
+	}
+	return out, nil
+}
+
+func (m *mockManager) GetChannelInfo(ctx context.Context, addr address.Address) (*paychmgr.ChannelInfo, error) {
+	for _, ci := range m.channels() {
+		if *ci.Channel == addr {
+			return ci, nil
+		}
+	}
+	return nil, paychmgr.ErrChannelNotTracked
+}
+
+func (m *mockManager) Settle(ctx context.Context, addr address.Address) (cid.Cid, error) {
+	ci, err := m.GetChannelInfo(ctx, addr)
+	if err != nil {
+		return cid.Undef, err
+	}
+	ci.Settling = true
+	m.settling = append(m.settling, ci)
+	if m.ch == ci {
+		m.ch = nil
+	}
+	return cid.Undef, nil
+}
+
+func (m *mockManager) Collect(ctx context.Context, addr address.Address) (cid.Cid, error) {
+	m.collected = append(m.collected, addr)
+	return cid.Undef, nil
+}
+
+var _ PaychManager = &mockManager{}
+
+func TestBudgetDisabled(t *testing.T) {
+	ctx := context.Background()
+
+	from, _ := address.NewIDAddress(1)
+	to, _ := address.NewIDAddress(2)
+
+	mm := &mockManager{}
+	m, err := NewManager(mm, dssync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, err)
+
+	_, _, err = m.GetPaych(ctx, from, to, big.NewInt(10), false)
+	require.NoError(t, err)
+	require.Equal(t, []fundsCall{{amt: big.NewInt(10), reserve: true}}, mm.calls)
+}
+
+func TestBudgetTopUp(t *testing.T) {
+	ctx := context.Background()
+
+	from, _ := address.NewIDAddress(1)
+	to, _ := address.NewIDAddress(2)
+
+	mm := &mockManager{}
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	m, err := NewManager(mm, ds)
+	require.NoError(t, err)
+
+	require.NoError(t, m.SetBudget(ctx, api.PaychBudget{
+		Enable:        true,
+		MaxTotal:      big.NewInt(250),
+		MaxPerChannel: big.NewInt(200),
+		TopUp:         big.NewInt(100),
+	}))
+
+	// the budget persists
+	m2, err := NewManager(mm, ds)
+	require.NoError(t, err)
+	require.Equal(t, m.Budget(), m2.Budget())
+
+	// the channel is created with the top-up amount
+	_, _, err = m.GetPaych(ctx, from, to, big.NewInt(10), false)
+	require.NoError(t, err)
+	require.Equal(t, []fundsCall{
+		{amt: big.NewInt(100)},
+		{amt: big.NewInt(10), reserve: true},
+	}, mm.calls)
+
+	// the following retrievals use the available funds
+	mm.calls = nil
+	_, _, err = m.GetPaych(ctx, from, to, big.NewInt(80), false)
+	require.NoError(t, err)
+	require.Equal(t, []fundsCall{{amt: big.NewInt(80), reserve: true}}, mm.calls)
+
+	// the top-up is capped by the per-channel budget
+	mm.calls = nil
+	_, _, err = m.GetPaych(ctx, from, to, big.NewInt(50), false)
+	require.NoError(t, err)
+	require.Equal(t, []fundsCall{
+		{amt: big.NewInt(100)},
+		{amt: big.NewInt(50), reserve: true},
+	}, mm.calls)
+	require.Equal(t, big.NewInt(200), mm.ch.Amount)
+
+	// over the budget
+	mm.calls = nil
+	_, _, err = m.GetPaych(ctx, from, to, big.NewInt(100), false)
+	require.Error(t, err)
+	require.Empty(t, mm.calls)
+
+	st, err := m.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(200), st.Locked)
+	require.Len(t, st.Channels, 1)
+}
+
+func TestBudgetSettle(t *testing.T) {
+	ctx := context.Background()
+
+	oldClock := build.Clock
+	t.Cleanup(func() { build.Clock = oldClock })
+	mc := clock.NewMock()
+	build.Clock = mc
+
+	from, _ := address.NewIDAddress(1)
+	to, _ := address.NewIDAddress(2)
+
+	mm := &mockManager{}
+	m, err := NewManager(mm, dssync.MutexWrap(datastore.NewMapDatastore()))
+	require.NoError(t, err)
+
+	require.NoError(t, m.SetBudget(ctx, api.PaychBudget{
+		Enable:      true,
+		MaxLanes:    2,
+		SettleAfter: time.Hour,
+	}))
+
+	ch1, _, err := m.GetPaych(ctx, from, to, big.NewInt(10), false)
+	require.NoError(t, err)
+	mm.ch.NextLane = 2
+
+	// the channel allocated all its lanes, a new one replaces it
+	ch2, _, err := m.GetPaych(ctx, from, to, big.NewInt(10), false)
+	require.NoError(t, err)
+	require.NotEqual(t, ch1, ch2)
+	require.Len(t, mm.settling, 1)
+
+	// the replacement channel is settled once idle
+	mc.Add(30 * time.Minute)
+	m.maintain(ctx)
+	require.Len(t, mm.settling, 1)
+
+	mc.Add(time.Hour)
+	m.maintain(ctx)
+	require.Len(t, mm.settling, 2)
+
+	// and both are collected after the settlement period
+	mc.Add(settleDelay)
+	m.maintain(ctx)
+	require.ElementsMatch(t, []address.Address{ch1, ch2}, mm.collected)
+
+	// only once
+	mc.Add(time.Hour)
+	m.maintain(ctx)
+	require.Len(t, mm.collected, 2)
+
+	st, err := m.Status(ctx)
+	require.NoError(t, err)
+	require.True(t, st.Channels[0].Collected)
+	require.True(t, st.Locked.IsZero())
+}
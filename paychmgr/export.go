@@ -0,0 +1,193 @@
+package paychmgr
+
+import (
+	"context"
+	"encoding/json"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/paych"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/lib/sigs"
+)
+
+// ExportChannel returns the local state of the channel and all its vouchers,
+// signed by the local address on the channel
+func (pm *Manager) ExportChannel(ctx context.Context, ch address.Address) (*api.PaychBundle, error) {
+	ci, err := pm.store.ByAddress(ctx, ch)
+	if err != nil {
+		return nil, err
+	}
+
+	_, st, err := pm.sa.loadPaychActorState(ctx, ch)
+	if err != nil {
+		return nil, xerrors.Errorf("loading channel state: %w", err)
+	}
+
+	b := &api.PaychBundle{
+		Channel:   ch,
+		Control:   ci.Control,
+		Target:    ci.Target,
+		Direction: api.PCHDir(ci.Direction),
+		NextLane:  ci.NextLane,
+		Amount:    ci.Amount,
+		Available: ci.AvailableAmount,
+		Lanes:     []api.PaychLaneState{},
+		Vouchers:  make([]api.PaychBundleVoucher, 0, len(ci.Vouchers)),
+	}
+	if b.Amount.Int == nil {
+		b.Amount = big.Zero()
+	}
+	if b.Available.Int == nil {
+		b.Available = big.Zero()
+	}
+
+	err = st.ForEachLaneState(func(idx uint64, ls paych.LaneState) error {
+		redeemed, err := ls.Redeemed()
+		if err != nil {
+			return err
+		}
+		nonce, err := ls.Nonce()
+		if err != nil {
+			return err
+		}
+		b.Lanes = append(b.Lanes, api.PaychLaneState{Lane: idx, Redeemed: redeemed, Nonce: nonce})
+		return nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("loading lane states: %w", err)
+	}
+
+	for _, vi := range ci.Vouchers {
+		b.Vouchers = append(b.Vouchers, api.PaychBundleVoucher{
+			Voucher:   vi.Voucher,
+			Submitted: vi.Submitted,
+		})
+	}
+
+	key, err := pm.pchapi.StateAccountKey(ctx, ci.Control, types.EmptyTSK)
+	if err != nil {
+		return nil, xerrors.Errorf("resolving control address: %w", err)
+	}
+	data, err := bundleSigningBytes(b)
+	if err != nil {
+		return nil, err
+	}
+	b.Signature, err = pm.pchapi.WalletSign(ctx, key, data)
+	if err != nil {
+		return nil, xerrors.Errorf("signing bundle: %w", err)
+	}
+
+	return b, nil
+}
+
+// ImportChannel checks that the bundle was signed by an address of the local
+// wallet, and merges it into the local state of the channel
+func (pm *Manager) ImportChannel(ctx context.Context, b *api.PaychBundle) error {
+	if b.Signature == nil {
+		return xerrors.Errorf("bundle is not signed")
+	}
+
+	var dir uint64
+	switch b.Direction {
+	case api.PCHInbound:
+		dir = DirInbound
+	case api.PCHOutbound:
+		dir = DirOutbound
+	default:
+		return xerrors.Errorf("unknown channel direction %d", b.Direction)
+	}
+
+	key, err := pm.pchapi.StateAccountKey(ctx, b.Control, types.EmptyTSK)
+	if err != nil {
+		return xerrors.Errorf("resolving control address: %w", err)
+	}
+	has, err := pm.pchapi.WalletHas(ctx, key)
+	if err != nil {
+		return err
+	}
+	if !has {
+		return xerrors.Errorf("wallet does not have key for address %s", b.Control)
+	}
+
+	data, err := bundleSigningBytes(b)
+	if err != nil {
+		return err
+	}
+	if err := sigs.Verify(b.Signature, key, data); err != nil {
+		return xerrors.Errorf("verifying bundle signature: %w", err)
+	}
+
+	// Make sure the bundle matches the channel on chain
+	stateCi, err := pm.sa.loadStateChannelInfo(ctx, b.Channel, dir)
+	if err != nil {
+		return xerrors.Errorf("loading channel state: %w", err)
+	}
+	target, err := pm.pchapi.StateAccountKey(ctx, b.Target, types.EmptyTSK)
+	if err != nil {
+		return xerrors.Errorf("resolving target address: %w", err)
+	}
+	if stateCi.Control != key || stateCi.Target != target {
+		return xerrors.Errorf("bundle addresses don't match the channel on chain")
+	}
+
+	for _, v := range b.Vouchers {
+		if v.Voucher == nil || v.Voucher.ChannelAddr != b.Channel {
+			return xerrors.Errorf("bundle has a voucher for another channel")
+		}
+	}
+
+	// Need to take an exclusive lock here so that channel operations can't run
+	// in parallel (see channelLock)
+	pm.lk.Lock()
+	defer pm.lk.Unlock()
+
+	ci, err := pm.store.ByAddress(ctx, b.Channel)
+	switch err {
+	case nil:
+		if ci.Direction != dir {
+			return xerrors.Errorf("channel is tracked with another direction")
+		}
+	case ErrChannelNotTracked:
+		ci = stateCi
+		ci.Amount = b.Amount
+		ci.AvailableAmount = b.Available
+		ci.PendingAmount = big.Zero()
+		ci.PendingAvailableAmount = big.Zero()
+	default:
+		return err
+	}
+
+	for _, v := range b.Vouchers {
+		vi, err := ci.infoForVoucher(v.Voucher)
+		if err != nil {
+			return err
+		}
+		if vi == nil {
+			ci.Vouchers = append(ci.Vouchers, &VoucherInfo{Voucher: v.Voucher, Submitted: v.Submitted})
+			continue
+		}
+		vi.Submitted = vi.Submitted || v.Submitted
+	}
+	if b.NextLane > ci.NextLane {
+		ci.NextLane = b.NextLane
+	}
+
+	return pm.store.putChannelInfo(ctx, ci)
+}
+
+// bundleSigningBytes returns the bytes of the bundle covered by its signature
+func bundleSigningBytes(b *api.PaychBundle) ([]byte, error) {
+	unsigned := *b
+	unsigned.Signature = nil
+
+	data, err := json.Marshal(&unsigned)
+	if err != nil {
+		return nil, xerrors.Errorf("serializing bundle: %w", err)
+	}
+	return data, nil
+}
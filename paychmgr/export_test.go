@@ -0,0 +1,104 @@
+//stm: #unit
+package paychmgr
+
+import (
+	"context"
+	"testing"
+
+	ds "github.com/ipfs/go-datastore"
+	ds_sync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	paychtypes "github.com/filecoin-project/go-state-types/builtin/v8/paych"
+	tutils "github.com/filecoin-project/specs-actors/v2/support/testing"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/paych"
+	paychmock "github.com/filecoin-project/lotus/chain/actors/builtin/paych/mock"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+func TestExportImportChannel(t *testing.T) {
+	ctx := context.Background()
+
+	fromKeyPrivate, fromKeyPublic := testGenerateKeyPair(t)
+
+	ch := tutils.NewIDAddr(t, 100)
+	from := tutils.NewSECP256K1Addr(t, string(fromKeyPublic))
+	to := tutils.NewSECP256K1Addr(t, "secpTo")
+	fromAcct := tutils.NewActorAddr(t, "fromAct")
+	toAcct := tutils.NewActorAddr(t, "toAct")
+
+	newMgr := func(hasKey bool) *Manager {
+		mock := newMockManagerAPI()
+		mock.setAccountAddress(fromAcct, from)
+		mock.setAccountAddress(toAcct, to)
+		mock.setPaychState(ch, &types.Actor{Balance: big.NewInt(20)}, paychmock.NewMockPayChState(fromAcct, toAcct, abi.ChainEpoch(0), map[uint64]paych.LaneState{
+			5: paychmock.NewMockLaneState(big.NewInt(2), 1),
+		}))
+		if hasKey {
+			mock.addSigningKey(fromKeyPrivate)
+			mock.addWalletAddress(from)
+		}
+
+		mgr, err := newManager(NewStore(ds_sync.MutexWrap(ds.NewMapDatastore())), mock)
+		require.NoError(t, err)
+		return mgr
+	}
+
+	src := newMgr(true)
+	require.NoError(t, src.store.putChannelInfo(ctx, &ChannelInfo{
+		Channel:         &ch,
+		Control:         from,
+		Target:          to,
+		Direction:       DirOutbound,
+		Amount:          big.NewInt(20),
+		AvailableAmount: big.NewInt(5),
+	}))
+
+	for i := 0; i < 2; i++ {
+		lane, err := src.AllocateLane(ctx, ch)
+		require.NoError(t, err)
+		res, err := src.CreateVoucher(ctx, ch, paychtypes.SignedVoucher{Amount: big.NewInt(5), Lane: lane})
+		require.NoError(t, err)
+		require.NotNil(t, res.Voucher)
+	}
+
+	bundle, err := src.ExportChannel(ctx, ch)
+	require.NoError(t, err)
+	require.Equal(t, api.PCHOutbound, bundle.Direction)
+	require.Equal(t, uint64(2), bundle.NextLane)
+	require.Len(t, bundle.Vouchers, 2)
+	require.Equal(t, []api.PaychLaneState{{Lane: 5, Redeemed: big.NewInt(2), Nonce: 1}}, bundle.Lanes)
+
+	// the bundle is imported into a node with the same key
+	dst := newMgr(true)
+	require.NoError(t, dst.ImportChannel(ctx, bundle))
+
+	ci, err := dst.GetChannelInfo(ctx, ch)
+	require.NoError(t, err)
+	require.Equal(t, from, ci.Control)
+	require.Equal(t, uint64(6), ci.NextLane) // past the lanes on chain
+	require.Equal(t, big.NewInt(5), ci.AvailableAmount)
+
+	vouchers, err := dst.ListVouchers(ctx, ch)
+	require.NoError(t, err)
+	require.Len(t, vouchers, 2)
+
+	// importing again doesn't duplicate vouchers
+	require.NoError(t, dst.ImportChannel(ctx, bundle))
+	vouchers, err = dst.ListVouchers(ctx, ch)
+	require.NoError(t, err)
+	require.Len(t, vouchers, 2)
+
+	// a tampered bundle is rejected
+	bundle.NextLane = 10
+	require.Error(t, dst.ImportChannel(ctx, bundle))
+	bundle.NextLane = 2
+
+	// so is a bundle for a key the wallet doesn't have
+	other := newMgr(false)
+	require.Error(t, other.ImportChannel(ctx, bundle))
+}
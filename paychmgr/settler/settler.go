@@ -3,6 +3,7 @@ package settler
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	logging "github.com/ipfs/go-log/v2"
@@ -15,7 +16,9 @@ import (
 
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/paych"
 	"github.com/filecoin-project/lotus/chain/events"
+	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/node/impl/full"
 	payapi "github.com/filecoin-project/lotus/node/impl/paych"
@@ -25,6 +28,11 @@ import (
 
 var log = logging.Logger("payment-channel-settler")
 
+// watchInterval is how often the state of inbound channels is checked for
+// settlements missed by the message matcher, e.g. sent while the node was
+// offline or through another actor
+var watchInterval = 10 * time.Minute
+
 // API are the dependencies need to run the payment channel settler
 type API struct {
 	fx.In
@@ -32,6 +40,8 @@ type API struct {
 	full.ChainAPI
 	full.StateAPI
 	payapi.PaychAPI
+
+	StateManager stmgr.StateManagerAPI
 }
 
 type settlerAPI interface {
@@ -41,11 +51,21 @@ type settlerAPI interface {
 	PaychVoucherList(context.Context, address.Address) ([]*paychtypes.SignedVoucher, error)
 	PaychVoucherSubmit(context.Context, address.Address, *paychtypes.SignedVoucher, []byte, []byte) (cid.Cid, error)
 	StateWaitMsg(ctx context.Context, cid cid.Cid, confidence uint64, limit abi.ChainEpoch, allowReplaced bool) (*api.MsgLookup, error)
+	ChainHead(context.Context) (*types.TipSet, error)
+}
+
+type paychStateAPI interface {
+	GetPaychState(ctx context.Context, addr address.Address, ts *types.TipSet) (*types.Actor, paych.State, error)
 }
 
 type paymentChannelSettler struct {
 	ctx context.Context
 	api settlerAPI
+	sm  paychStateAPI
+
+	// serializes voucher submissions, so that the same voucher isn't
+	// submitted twice
+	submitLk sync.Mutex
 }
 
 // SettlePaymentChannels checks the chain for events related to payment channels settling and
@@ -54,21 +74,27 @@ func SettlePaymentChannels(mctx helpers.MetricsCtx, lc fx.Lifecycle, papi API) e
 	ctx := helpers.LifecycleCtx(mctx, lc)
 	lc.Append(fx.Hook{
 		OnStart: func(context.Context) error {
-			pcs := newPaymentChannelSettler(ctx, &papi)
+			pcs := newPaymentChannelSettler(ctx, &papi, papi.StateManager)
 			ev, err := events.NewEvents(ctx, &papi)
 			if err != nil {
 				return err
 			}
-			return ev.Called(ctx, pcs.check, pcs.messageHandler, pcs.revertHandler, int(build.MessageConfidence+1), events.NoTimeout, pcs.matcher)
+			if err := ev.Called(ctx, pcs.check, pcs.messageHandler, pcs.revertHandler, int(build.MessageConfidence+1), events.NoTimeout, pcs.matcher); err != nil {
+				return err
+			}
+
+			go pcs.watch()
+			return nil
 		},
 	})
 	return nil
 }
 
-func newPaymentChannelSettler(ctx context.Context, api settlerAPI) *paymentChannelSettler {
+func newPaymentChannelSettler(ctx context.Context, api settlerAPI, sm paychStateAPI) *paymentChannelSettler {
 	return &paymentChannelSettler{
 		ctx: ctx,
 		api: api,
+		sm:  sm,
 	}
 }
 
@@ -82,17 +108,13 @@ func (pcs *paymentChannelSettler) messageHandler(msg *types.Message, rec *types.
 		return true, nil
 	}
 
-	bestByLane, err := paychmgr.BestSpendableByLane(pcs.ctx, pcs.api, msg.To)
+	submitted, err := pcs.submitBestVouchers(msg.To)
 	if err != nil {
 		return true, err
 	}
 	var wg sync.WaitGroup
-	wg.Add(len(bestByLane))
-	for _, voucher := range bestByLane {
-		submitMessageCID, err := pcs.api.PaychVoucherSubmit(pcs.ctx, msg.To, voucher, nil, nil)
-		if err != nil {
-			return true, err
-		}
+	wg.Add(len(submitted))
+	for submitMessageCID, voucher := range submitted {
 		go func(voucher *paychtypes.SignedVoucher, submitMessageCID cid.Cid) {
 			defer wg.Done()
 			msgLookup, err := pcs.api.StateWaitMsg(pcs.ctx, submitMessageCID, build.MessageConfidence, api.LookbackNoLimit, true)
@@ -109,6 +131,96 @@ func (pcs *paymentChannelSettler) messageHandler(msg *types.Message, rec *types.
 	return true, nil
 }
 
+// submitBestVouchers submits the best spendable voucher of each lane of the
+// channel, returning the vouchers by message CID
+func (pcs *paymentChannelSettler) submitBestVouchers(ch address.Address) (map[cid.Cid]*paychtypes.SignedVoucher, error) {
+	pcs.submitLk.Lock()
+	defer pcs.submitLk.Unlock()
+
+	bestByLane, err := paychmgr.BestSpendableByLane(pcs.ctx, pcs.api, ch)
+	if err != nil {
+		return nil, err
+	}
+
+	submitted := make(map[cid.Cid]*paychtypes.SignedVoucher, len(bestByLane))
+	for _, voucher := range bestByLane {
+		submitMessageCID, err := pcs.api.PaychVoucherSubmit(pcs.ctx, ch, voucher, nil, nil)
+		if err != nil {
+			return submitted, err
+		}
+		submitted[submitMessageCID] = voucher
+	}
+	return submitted, nil
+}
+
+// watch periodically checks the state of inbound channels until the node
+// shuts down
+func (pcs *paymentChannelSettler) watch() {
+	ticker := build.Clock.Ticker(watchInterval)
+	defer ticker.Stop()
+
+	for {
+		pcs.checkSettling()
+
+		select {
+		case <-ticker.C:
+		case <-pcs.ctx.Done():
+			return
+		}
+	}
+}
+
+// checkSettling submits the best vouchers of inbound channels which are
+// settling, before their settlement window closes
+func (pcs *paymentChannelSettler) checkSettling() {
+	head, err := pcs.api.ChainHead(pcs.ctx)
+	if err != nil {
+		log.Errorf("getting chain head: %s", err)
+		return
+	}
+
+	chs, err := pcs.api.PaychList(pcs.ctx)
+	if err != nil {
+		log.Errorf("listing payment channels: %s", err)
+		return
+	}
+
+	for _, ch := range chs {
+		status, err := pcs.api.PaychStatus(pcs.ctx, ch)
+		if err != nil {
+			log.Errorf("getting status of payment channel %s: %s", ch, err)
+			continue
+		}
+		if status.Direction != api.PCHInbound {
+			continue
+		}
+
+		_, st, err := pcs.sm.GetPaychState(pcs.ctx, ch, head)
+		if err != nil {
+			// the channel may have been collected
+			log.Debugf("loading state of payment channel %s: %s", ch, err)
+			continue
+		}
+		settlingAt, err := st.SettlingAt()
+		if err != nil {
+			log.Errorf("getting settlement height of payment channel %s: %s", ch, err)
+			continue
+		}
+		if settlingAt == 0 || head.Height() >= settlingAt {
+			continue
+		}
+
+		submitted, err := pcs.submitBestVouchers(ch)
+		if err != nil {
+			log.Errorf("submitting vouchers of settling payment channel %s: %s", ch, err)
+			continue
+		}
+		if len(submitted) > 0 {
+			log.Infow("submitted vouchers of settling payment channel", "channel", ch, "vouchers", len(submitted), "settlingAt", settlingAt)
+		}
+	}
+}
+
 func (pcs *paymentChannelSettler) revertHandler(ctx context.Context, ts *types.TipSet) error {
 	return nil
 }
@@ -0,0 +1,127 @@
+//stm: #unit
+package settler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin/v8/paych"
+
+	"github.com/filecoin-project/lotus/api"
+	paychstate "github.com/filecoin-project/lotus/chain/actors/builtin/paych"
+	paychmock "github.com/filecoin-project/lotus/chain/actors/builtin/paych/mock"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+)
+
+type mockSettlerAPI struct {
+	head       *types.TipSet
+	channels   map[address.Address]*api.PaychStatus
+	settlingAt map[address.Address]abi.ChainEpoch
+	vouchers   map[address.Address][]*paych.SignedVoucher
+
+	submitted map[address.Address][]*paych.SignedVoucher
+}
+
+func (m *mockSettlerAPI) PaychList(ctx context.Context) ([]address.Address, error) {
+	var out []address.Address
+	for ch := range m.channels {
+		out = append(out, ch)
+	}
+	return out, nil
+}
+
+func (m *mockSettlerAPI) PaychStatus(ctx context.Context, ch address.Address) (*api.PaychStatus, error) {
+	return m.channels[ch], nil
+}
+
+func (m *mockSettlerAPI) PaychVoucherCheckSpendable(ctx context.Context, ch address.Address, sv *paych.SignedVoucher, secret []byte, proof []byte) (bool, error) {
+	// a voucher can't redeem less than the vouchers already submitted on its lane
+	for _, s := range m.submitted[ch] {
+		if s.Lane == sv.Lane && !sv.Amount.GreaterThan(s.Amount) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (m *mockSettlerAPI) PaychVoucherList(ctx context.Context, ch address.Address) ([]*paych.SignedVoucher, error) {
+	return m.vouchers[ch], nil
+}
+
+func (m *mockSettlerAPI) PaychVoucherSubmit(ctx context.Context, ch address.Address, sv *paych.SignedVoucher, secret []byte, proof []byte) (cid.Cid, error) {
+	m.submitted[ch] = append(m.submitted[ch], sv)
+	return cid.NewCidV1(cid.Raw, []byte(ch.String()+sv.Amount.String())), nil
+}
+
+func (m *mockSettlerAPI) StateWaitMsg(ctx context.Context, c cid.Cid, confidence uint64, limit abi.ChainEpoch, allowReplaced bool) (*api.MsgLookup, error) {
+	return &api.MsgLookup{}, nil
+}
+
+func (m *mockSettlerAPI) ChainHead(ctx context.Context) (*types.TipSet, error) {
+	return m.head, nil
+}
+
+func (m *mockSettlerAPI) GetPaychState(ctx context.Context, ch address.Address, ts *types.TipSet) (*types.Actor, paychstate.State, error) {
+	st := m.channels[ch]
+	return &types.Actor{}, paychmock.NewMockPayChState(st.ControlAddr, st.ControlAddr, m.settlingAt[ch], nil), nil
+}
+
+func TestCheckSettling(t *testing.T) {
+	ctx := context.Background()
+
+	miner, _ := address.NewIDAddress(1000)
+	head := mock.TipSet(mock.MkBlock(nil, 1, 1))
+
+	settling, _ := address.NewIDAddress(100)
+	closed, _ := address.NewIDAddress(101)
+	open, _ := address.NewIDAddress(102)
+	outbound, _ := address.NewIDAddress(103)
+
+	vouchers := func(amts ...int64) []*paych.SignedVoucher {
+		var out []*paych.SignedVoucher
+		for _, amt := range amts {
+			out = append(out, &paych.SignedVoucher{Lane: 0, Amount: big.NewInt(amt)})
+		}
+		return out
+	}
+
+	m := &mockSettlerAPI{
+		head: head,
+		channels: map[address.Address]*api.PaychStatus{
+			settling: {ControlAddr: miner, Direction: api.PCHInbound},
+			closed:   {ControlAddr: miner, Direction: api.PCHInbound},
+			open:     {ControlAddr: miner, Direction: api.PCHInbound},
+			outbound: {ControlAddr: miner, Direction: api.PCHOutbound},
+		},
+		settlingAt: map[address.Address]abi.ChainEpoch{
+			settling: head.Height() + 10,
+			closed:   head.Height(),
+			outbound: head.Height() + 10,
+		},
+		vouchers: map[address.Address][]*paych.SignedVoucher{
+			settling: vouchers(10, 30, 20),
+			closed:   vouchers(10),
+			open:     vouchers(10),
+			outbound: vouchers(10),
+		},
+		submitted: map[address.Address][]*paych.SignedVoucher{},
+	}
+
+	pcs := newPaymentChannelSettler(ctx, m, m)
+	pcs.checkSettling()
+
+	// only the best voucher of the settling inbound channel is submitted
+	require.Len(t, m.submitted, 1)
+	require.Equal(t, []*paych.SignedVoucher{m.vouchers[settling][1]}, m.submitted[settling])
+
+	// and only once
+	pcs.checkSettling()
+	require.Len(t, m.submitted[settling], 1)
+}
@@ -35,7 +35,9 @@ def generate_lotus_cli(prog):
                     sub_cmd = line
                     if ' ' in line:
                         gap_pos = sub_cmd.index('  ')
-                    sub_cmd = cur_cmd + ' ' + sub_cmd[:gap_pos]
+                    # commands with aliases are listed as "name, alias"
+                    sub_cmd = sub_cmd[:gap_pos].split(',')[0]
+                    sub_cmd = cur_cmd + ' ' + sub_cmd
                     get_cmd_recursively(sub_cmd)
             except Exception as e:
                 print('Fail to deal with "%s" with error:\n%s' % (line, e))
@@ -2,6 +2,7 @@ package ctladdr
 
 import (
 	"context"
+	"sync"
 
 	logging "github.com/ipfs/go-log/v2"
 
@@ -24,26 +25,45 @@ type addrSelectApi interface {
 }
 
 type AddressSelector struct {
+	// lk guards the config, which can be replaced with SetConfig while the
+	// selector is in use
+	lk sync.RWMutex
 	api.AddressConfig
 }
 
+// SetConfig replaces the address config of the selector
+func (as *AddressSelector) SetConfig(cfg api.AddressConfig) {
+	as.lk.Lock()
+	defer as.lk.Unlock()
+
+	as.AddressConfig = cfg
+}
+
+func (as *AddressSelector) config() api.AddressConfig {
+	as.lk.RLock()
+	defer as.lk.RUnlock()
+
+	return as.AddressConfig
+}
+
 func (as *AddressSelector) AddressFor(ctx context.Context, a addrSelectApi, mi api.MinerInfo, use api.AddrUse, goodFunds, minFunds abi.TokenAmount) (address.Address, abi.TokenAmount, error) {
 	if as == nil {
 		// should only happen in some tests
 		log.Warnw("smart address selection disabled, using worker address")
 		return mi.Worker, big.Zero(), nil
 	}
+	cfg := as.config()
 
 	var addrs []address.Address
 	switch use {
 	case api.PreCommitAddr:
-		addrs = append(addrs, as.PreCommitControl...)
+		addrs = append(addrs, cfg.PreCommitControl...)
 	case api.CommitAddr:
-		addrs = append(addrs, as.CommitControl...)
+		addrs = append(addrs, cfg.CommitControl...)
 	case api.TerminateSectorsAddr:
-		addrs = append(addrs, as.TerminateControl...)
+		addrs = append(addrs, cfg.TerminateControl...)
 	case api.DealPublishAddr:
-		addrs = append(addrs, as.DealPublishControl...)
+		addrs = append(addrs, cfg.DealPublishControl...)
 	default:
 		defaultCtl := map[address.Address]struct{}{}
 		for _, a := range mi.ControlAddresses {
@@ -52,10 +72,10 @@ func (as *AddressSelector) AddressFor(ctx context.Context, a addrSelectApi, mi a
 		delete(defaultCtl, mi.Owner)
 		delete(defaultCtl, mi.Worker)
 
-		configCtl := append([]address.Address{}, as.PreCommitControl...)
-		configCtl = append(configCtl, as.CommitControl...)
-		configCtl = append(configCtl, as.TerminateControl...)
-		configCtl = append(configCtl, as.DealPublishControl...)
+		configCtl := append([]address.Address{}, cfg.PreCommitControl...)
+		configCtl = append(configCtl, cfg.CommitControl...)
+		configCtl = append(configCtl, cfg.TerminateControl...)
+		configCtl = append(configCtl, cfg.DealPublishControl...)
 
 		for _, addr := range configCtl {
 			if addr.Protocol() != address.ID {
@@ -75,10 +95,10 @@ func (as *AddressSelector) AddressFor(ctx context.Context, a addrSelectApi, mi a
 		}
 	}
 
-	if len(addrs) == 0 || !as.DisableWorkerFallback {
+	if len(addrs) == 0 || !cfg.DisableWorkerFallback {
 		addrs = append(addrs, mi.Worker)
 	}
-	if !as.DisableOwnerFallback {
+	if !cfg.DisableOwnerFallback {
 		addrs = append(addrs, mi.Owner)
 	}
 
@@ -0,0 +1,517 @@
+package disputer
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/go-state-types/builtin/v8/miner"
+	"github.com/filecoin-project/go-state-types/dline"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/actors"
+	lminer "github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+var log = logging.Logger("disputer")
+
+// the WindowPoSts of a deadline are checked once the chain is this many epochs
+// past its close, so that they aren't checked on a fork
+const confidence = 10
+
+// the faulty sectors given as evidence, by miner ID address and deadline
+var evidencePrefix = datastore.NewKey("/disputer/evidence")
+
+type API interface {
+	ChainHead(context.Context) (*types.TipSet, error)
+	StateLookupID(context.Context, address.Address, types.TipSetKey) (address.Address, error)
+	StateMinerInfo(context.Context, address.Address, types.TipSetKey) (api.MinerInfo, error)
+	StateMinerProvingDeadline(context.Context, address.Address, types.TipSetKey) (*dline.Info, error)
+	StateMinerDeadlines(context.Context, address.Address, types.TipSetKey) ([]api.Deadline, error)
+	StateSectorPartition(ctx context.Context, maddr address.Address, sectorNumber abi.SectorNumber, tok types.TipSetKey) (*lminer.SectorLocation, error)
+	StateCall(context.Context, *types.Message, types.TipSetKey) (*api.InvocResult, error)
+	MpoolPushMessage(ctx context.Context, msg *types.Message, spec *api.MessageSendSpec) (*types.SignedMessage, error)
+}
+
+type minerDeadline struct {
+	miner address.Address
+	index uint64
+}
+
+// Disputer checks the WindowPoSts submitted by the watched miners once their
+// deadline closed, by simulating a DisputeWindowedPoSt message for each of
+// them, and disputes the invalid ones when AutoDispute is set. The watched
+// miners are the configured ones, at every deadline, and the miners with faulty
+// sector evidence, at the deadlines of the evidence.
+type Disputer struct {
+	api   API
+	ds    datastore.Batching
+	cfg   config.WdPoStDisputerConfig
+	actor address.Address
+
+	miners []address.Address
+	from   address.Address
+
+	lk sync.Mutex
+	// close epoch of the last checked occurrence of each watched deadline
+	checked map[minerDeadline]abi.ChainEpoch
+	// the WindowPoSts found by the checks, until their dispute window ends
+	found map[minerDeadline][]api.DisputablePoSt
+}
+
+func NewDisputer(a API, ds datastore.Batching, cfg config.WdPoStDisputerConfig, actor address.Address) (*Disputer, error) {
+	d := &Disputer{
+		api:     a,
+		ds:      ds,
+		cfg:     cfg,
+		actor:   actor,
+		checked: map[minerDeadline]abi.ChainEpoch{},
+		found:   map[minerDeadline][]api.DisputablePoSt{},
+	}
+
+	for _, m := range cfg.Miners {
+		maddr, err := address.NewFromString(m)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing miner address %q: %w", m, err)
+		}
+		d.miners = append(d.miners, maddr)
+	}
+	if cfg.From != "" {
+		from, err := address.NewFromString(cfg.From)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing from address %q: %w", cfg.From, err)
+		}
+		d.from = from
+	}
+
+	return d, nil
+}
+
+// Run checks the watched deadlines at each epoch until the context is done
+func (d *Disputer) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(build.BlockDelaySecs) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.poll(ctx); err != nil {
+				log.Warnw("checking window posts", "error", err)
+			}
+		}
+	}
+}
+
+// poll checks the last occurrence of the watched deadlines once the chain is
+// far enough past their close, and while their WindowPoSts can be disputed
+func (d *Disputer) poll(ctx context.Context) error {
+	ts, err := d.api.ChainHead(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	targets, err := d.targets(ctx, ts.Key())
+	if err != nil {
+		return err
+	}
+
+	for maddr, dls := range targets {
+		di, err := d.api.StateMinerProvingDeadline(ctx, maddr, ts.Key())
+		if err != nil {
+			log.Warnw("getting proving deadline", "miner", maddr, "error", err)
+			continue
+		}
+
+		for dl := uint64(0); dl < di.WPoStPeriodDeadlines; dl++ {
+			if dls != nil && !dls[dl] {
+				continue
+			}
+
+			closeEpoch := lastClose(di, dl)
+			if ts.Height() < closeEpoch+confidence || ts.Height() >= closeEpoch+miner.WPoStDisputeWindow {
+				continue
+			}
+
+			key := minerDeadline{miner: maddr, index: dl}
+			d.lk.Lock()
+			done := d.checked[key] == closeEpoch
+			d.lk.Unlock()
+			if done {
+				continue
+			}
+
+			posts, err := d.check(ctx, maddr, dl, di, ts)
+			if err != nil {
+				log.Warnw("checking window posts", "miner", maddr, "deadline", dl, "error", err)
+				continue
+			}
+
+			for i, p := range posts {
+				if p.Valid {
+					continue
+				}
+
+				log.Warnw("found invalid window post", "miner", maddr, "deadline", dl, "postIndex", p.PoStIndex, "evidence", p.Evidence)
+				if !d.cfg.AutoDispute {
+					continue
+				}
+
+				c, err := d.send(ctx, maddr, dl, p.PoStIndex)
+				if err != nil {
+					log.Errorw("disputing window post", "miner", maddr, "deadline", dl, "postIndex", p.PoStIndex, "error", err)
+					posts[i].Error = err.Error()
+					continue
+				}
+				posts[i].Dispute = &c
+			}
+
+			d.lk.Lock()
+			d.checked[key] = closeEpoch
+			d.found[key] = posts
+			d.lk.Unlock()
+		}
+	}
+
+	d.lk.Lock()
+	for key, posts := range d.found {
+		if len(posts) == 0 || ts.Height() >= posts[0].DisputeEnd {
+			delete(d.found, key)
+		}
+	}
+	d.lk.Unlock()
+
+	return nil
+}
+
+// targets returns the watched miners, with the deadlines to check, or nil for
+// all of them
+func (d *Disputer) targets(ctx context.Context, tsk types.TipSetKey) (map[address.Address]map[uint64]bool, error) {
+	out := map[address.Address]map[uint64]bool{}
+
+	evidence, err := d.allEvidence(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for key := range evidence {
+		if out[key.miner] == nil {
+			out[key.miner] = map[uint64]bool{}
+		}
+		out[key.miner][key.index] = true
+	}
+
+	for _, m := range d.miners {
+		maddr, err := d.api.StateLookupID(ctx, m, tsk)
+		if err != nil {
+			log.Warnw("looking up miner", "miner", m, "error", err)
+			continue
+		}
+		out[maddr] = nil
+	}
+
+	return out, nil
+}
+
+// Check simulates disputing each of the WindowPoSts submitted by a miner at
+// the last occurrence of a deadline
+func (d *Disputer) Check(ctx context.Context, maddr address.Address, dlIdx uint64) ([]api.DisputablePoSt, error) {
+	ts, err := d.api.ChainHead(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	maddr, err = d.api.StateLookupID(ctx, maddr, ts.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("looking up miner: %w", err)
+	}
+
+	di, err := d.api.StateMinerProvingDeadline(ctx, maddr, ts.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting proving deadline: %w", err)
+	}
+	if dlIdx >= di.WPoStPeriodDeadlines {
+		return nil, xerrors.Errorf("deadline %d out of range, there are %d deadlines", dlIdx, di.WPoStPeriodDeadlines)
+	}
+
+	return d.check(ctx, maddr, dlIdx, di, ts)
+}
+
+func (d *Disputer) check(ctx context.Context, maddr address.Address, dlIdx uint64, di *dline.Info, ts *types.TipSet) ([]api.DisputablePoSt, error) {
+	dls, err := d.api.StateMinerDeadlines(ctx, maddr, ts.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting deadlines: %w", err)
+	}
+	if dlIdx >= uint64(len(dls)) {
+		return nil, xerrors.Errorf("deadline %d out of range, there are %d deadlines", dlIdx, len(dls))
+	}
+
+	evidence, err := d.evidence(ctx, minerDeadline{miner: maddr, index: dlIdx})
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := d.sender(ctx, ts.Key())
+	if err != nil {
+		return nil, err
+	}
+
+	closeEpoch := lastClose(di, dlIdx)
+
+	var out []api.DisputablePoSt
+	for i := uint64(0); i < dls[dlIdx].DisputableProofCount; i++ {
+		p := api.DisputablePoSt{
+			Miner:      maddr,
+			Deadline:   dlIdx,
+			PoStIndex:  i,
+			Close:      closeEpoch,
+			DisputeEnd: closeEpoch + miner.WPoStDisputeWindow,
+			Valid:      true,
+			Evidence:   evidence,
+		}
+
+		msg, err := disputeMessage(maddr, from, dlIdx, i)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := d.api.StateCall(ctx, msg, ts.Key())
+		if err != nil {
+			return nil, xerrors.Errorf("simulating dispute of window post %d: %w", i, err)
+		}
+		if res.MsgRct.ExitCode == 0 {
+			// the dispute succeeds, the proof is invalid
+			p.Valid = false
+		}
+
+		out = append(out, p)
+	}
+
+	return out, nil
+}
+
+// Dispute sends a DisputeWindowedPoSt message for a WindowPoSt, once a
+// simulation showed that the dispute succeeds
+func (d *Disputer) Dispute(ctx context.Context, maddr address.Address, dlIdx uint64, postIdx uint64) (cid.Cid, error) {
+	posts, err := d.Check(ctx, maddr, dlIdx)
+	if err != nil {
+		return cid.Undef, err
+	}
+	if postIdx >= uint64(len(posts)) {
+		return cid.Undef, xerrors.Errorf("window post %d out of range, deadline %d has %d disputable window posts", postIdx, dlIdx, len(posts))
+	}
+
+	p := posts[postIdx]
+	if p.Valid {
+		return cid.Undef, xerrors.Errorf("the dispute of window post %d of deadline %d would fail, the proof is valid", postIdx, dlIdx)
+	}
+
+	c, err := d.send(ctx, p.Miner, dlIdx, postIdx)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	posts[postIdx].Dispute = &c
+	d.lk.Lock()
+	d.found[minerDeadline{miner: p.Miner, index: dlIdx}] = posts
+	d.lk.Unlock()
+
+	return c, nil
+}
+
+func (d *Disputer) send(ctx context.Context, maddr address.Address, dlIdx uint64, postIdx uint64) (cid.Cid, error) {
+	from, err := d.sender(ctx, types.EmptyTSK)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	msg, err := disputeMessage(maddr, from, dlIdx, postIdx)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	sm, err := d.api.MpoolPushMessage(ctx, msg, &api.MessageSendSpec{MaxFee: abi.TokenAmount(d.cfg.MaxFee)})
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("pushing dispute message: %w", err)
+	}
+
+	log.Warnw("disputed window post", "miner", maddr, "deadline", dlIdx, "postIndex", postIdx, "cid", sm.Cid())
+	return sm.Cid(), nil
+}
+
+// sender returns the address the dispute messages are sent from
+func (d *Disputer) sender(ctx context.Context, tsk types.TipSetKey) (address.Address, error) {
+	if d.from != address.Undef {
+		return d.from, nil
+	}
+
+	mi, err := d.api.StateMinerInfo(ctx, d.actor, tsk)
+	if err != nil {
+		return address.Undef, xerrors.Errorf("getting miner info: %w", err)
+	}
+	return mi.Worker, nil
+}
+
+// Status returns the WindowPoSts found by the checks which can still be
+// disputed
+func (d *Disputer) Status() []api.DisputablePoSt {
+	d.lk.Lock()
+	defer d.lk.Unlock()
+
+	var out []api.DisputablePoSt
+	for _, posts := range d.found {
+		out = append(out, posts...)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Close != out[j].Close {
+			return out[i].Close < out[j].Close
+		}
+		if out[i].Miner != out[j].Miner {
+			return out[i].Miner.String() < out[j].Miner.String()
+		}
+		return out[i].PoStIndex < out[j].PoStIndex
+	})
+	return out
+}
+
+// AddEvidence records sectors of a miner known to be faulty, by the deadline
+// they are proven in
+func (d *Disputer) AddEvidence(ctx context.Context, maddr address.Address, sectors []abi.SectorNumber) error {
+	maddr, err := d.api.StateLookupID(ctx, maddr, types.EmptyTSK)
+	if err != nil {
+		return xerrors.Errorf("looking up miner: %w", err)
+	}
+
+	byDeadline := map[uint64][]abi.SectorNumber{}
+	for _, s := range sectors {
+		loc, err := d.api.StateSectorPartition(ctx, maddr, s, types.EmptyTSK)
+		if err != nil {
+			return xerrors.Errorf("finding sector %d: %w", s, err)
+		}
+		byDeadline[loc.Deadline] = append(byDeadline[loc.Deadline], s)
+	}
+
+	d.lk.Lock()
+	defer d.lk.Unlock()
+
+	for dl, sectors := range byDeadline {
+		key := minerDeadline{miner: maddr, index: dl}
+		prev, err := d.evidence(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		have := map[abi.SectorNumber]struct{}{}
+		for _, s := range prev {
+			have[s] = struct{}{}
+		}
+		for _, s := range sectors {
+			if _, ok := have[s]; !ok {
+				prev = append(prev, s)
+				have[s] = struct{}{}
+			}
+		}
+		sort.Slice(prev, func(i, j int) bool { return prev[i] < prev[j] })
+
+		b, err := json.Marshal(prev)
+		if err != nil {
+			return err
+		}
+		if err := d.ds.Put(ctx, evidenceKey(key), b); err != nil {
+			return xerrors.Errorf("storing evidence: %w", err)
+		}
+
+		// the deadline is checked again with the new evidence
+		delete(d.checked, key)
+	}
+
+	return nil
+}
+
+func (d *Disputer) evidence(ctx context.Context, key minerDeadline) ([]abi.SectorNumber, error) {
+	b, err := d.ds.Get(ctx, evidenceKey(key))
+	if err == datastore.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("getting evidence: %w", err)
+	}
+
+	var out []abi.SectorNumber
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, xerrors.Errorf("decoding evidence: %w", err)
+	}
+	return out, nil
+}
+
+func (d *Disputer) allEvidence(ctx context.Context) (map[minerDeadline]struct{}, error) {
+	res, err := d.ds.Query(ctx, query.Query{Prefix: evidencePrefix.String(), KeysOnly: true})
+	if err != nil {
+		return nil, xerrors.Errorf("listing evidence: %w", err)
+	}
+	defer res.Close() // nolint:errcheck
+
+	out := map[minerDeadline]struct{}{}
+	for e := range res.Next() {
+		if e.Error != nil {
+			return nil, xerrors.Errorf("listing evidence: %w", e.Error)
+		}
+
+		k := datastore.NewKey(e.Key)
+		maddr, err := address.NewFromString(k.Parent().BaseNamespace())
+		if err != nil {
+			return nil, xerrors.Errorf("parsing evidence key %s: %w", e.Key, err)
+		}
+		dl, err := strconv.ParseUint(k.BaseNamespace(), 10, 64)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing evidence key %s: %w", e.Key, err)
+		}
+		out[minerDeadline{miner: maddr, index: dl}] = struct{}{}
+	}
+	return out, nil
+}
+
+func evidenceKey(key minerDeadline) datastore.Key {
+	return evidencePrefix.ChildString(key.miner.String()).ChildString(strconv.FormatUint(key.index, 10))
+}
+
+// lastClose returns the close epoch of the last occurrence of a deadline which
+// closed
+func lastClose(di *dline.Info, dlIdx uint64) abi.ChainEpoch {
+	shift := abi.ChainEpoch((dlIdx+di.WPoStPeriodDeadlines-di.Index)%di.WPoStPeriodDeadlines) * di.WPoStChallengeWindow
+	return di.Close + shift - di.WPoStProvingPeriod
+}
+
+func disputeMessage(maddr, from address.Address, dlIdx uint64, postIdx uint64) (*types.Message, error) {
+	params, aerr := actors.SerializeParams(&miner.DisputeWindowedPoStParams{
+		Deadline:  dlIdx,
+		PoStIndex: postIdx,
+	})
+	if aerr != nil {
+		return nil, xerrors.Errorf("failed to serialize params: %w", aerr)
+	}
+
+	return &types.Message{
+		To:     maddr,
+		From:   from,
+		Value:  big.Zero(),
+		Method: builtin.MethodsMiner.DisputeWindowedPoSt,
+		Params: params,
+	}, nil
+}
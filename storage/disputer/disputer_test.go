@@ -0,0 +1,153 @@
+//stm: #unit
+package disputer
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin/v8/miner"
+	"github.com/filecoin-project/go-state-types/dline"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	tutils "github.com/filecoin-project/specs-actors/v6/support/testing"
+
+	"github.com/filecoin-project/lotus/api"
+	lminer "github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+type testAPI struct {
+	API
+
+	t      *testing.T
+	height abi.ChainEpoch
+	worker address.Address
+	// number of disputable proofs by deadline
+	proofs map[uint64]uint64
+	// the disputes of these proofs succeed
+	invalid map[uint64]uint64
+	pushed  []*types.Message
+}
+
+func (a *testAPI) ChainHead(ctx context.Context) (*types.TipSet, error) {
+	c, err := cid.Decode("QmbFMke1KXqnYyBBWxB74N4c5SBnJMVAiMNRcGu6x1AwQH")
+	require.NoError(a.t, err)
+	return types.NewTipSet([]*types.BlockHeader{{
+		Miner:                 tutils.NewIDAddr(a.t, 1000),
+		Height:                a.height,
+		ParentStateRoot:       c,
+		ParentMessageReceipts: c,
+		Messages:              c,
+	}})
+}
+
+func (a *testAPI) StateLookupID(ctx context.Context, addr address.Address, tsk types.TipSetKey) (address.Address, error) {
+	return addr, nil
+}
+
+func (a *testAPI) StateMinerInfo(ctx context.Context, maddr address.Address, tsk types.TipSetKey) (api.MinerInfo, error) {
+	return api.MinerInfo{Worker: a.worker}, nil
+}
+
+func (a *testAPI) StateMinerProvingDeadline(ctx context.Context, maddr address.Address, tsk types.TipSetKey) (*dline.Info, error) {
+	periodStart := a.height / miner.WPoStProvingPeriod * miner.WPoStProvingPeriod
+	idx := uint64((a.height - periodStart) / miner.WPoStChallengeWindow)
+	return dline.NewInfo(periodStart, idx, a.height, miner.WPoStPeriodDeadlines, miner.WPoStProvingPeriod, miner.WPoStChallengeWindow, miner.WPoStChallengeLookback, miner.FaultDeclarationCutoff), nil
+}
+
+func (a *testAPI) StateMinerDeadlines(ctx context.Context, maddr address.Address, tsk types.TipSetKey) ([]api.Deadline, error) {
+	out := make([]api.Deadline, miner.WPoStPeriodDeadlines)
+	for dl, n := range a.proofs {
+		out[dl].DisputableProofCount = n
+	}
+	return out, nil
+}
+
+func (a *testAPI) StateSectorPartition(ctx context.Context, maddr address.Address, sectorNumber abi.SectorNumber, tok types.TipSetKey) (*lminer.SectorLocation, error) {
+	return &lminer.SectorLocation{Deadline: uint64(sectorNumber) % miner.WPoStPeriodDeadlines}, nil
+}
+
+func (a *testAPI) StateCall(ctx context.Context, msg *types.Message, tsk types.TipSetKey) (*api.InvocResult, error) {
+	var params miner.DisputeWindowedPoStParams
+	require.NoError(a.t, params.UnmarshalCBOR(bytes.NewReader(msg.Params)))
+	require.Equal(a.t, a.worker, msg.From)
+
+	res := &api.InvocResult{MsgRct: &types.MessageReceipt{ExitCode: exitcode.ErrIllegalArgument}}
+	if post, ok := a.invalid[params.Deadline]; ok && post == params.PoStIndex {
+		res.MsgRct.ExitCode = exitcode.Ok
+	}
+	return res, nil
+}
+
+func (a *testAPI) MpoolPushMessage(ctx context.Context, msg *types.Message, spec *api.MessageSendSpec) (*types.SignedMessage, error) {
+	a.pushed = append(a.pushed, msg)
+	return &types.SignedMessage{Message: *msg}, nil
+}
+
+func TestDisputer(t *testing.T) {
+	ctx := context.Background()
+
+	actor := tutils.NewIDAddr(t, 1000)
+	target := tutils.NewIDAddr(t, 1001)
+
+	a := &testAPI{
+		t:       t,
+		worker:  tutils.NewIDAddr(t, 101),
+		proofs:  map[uint64]uint64{3: 2, 5: 1},
+		invalid: map[uint64]uint64{3: 1, 5: 0},
+	}
+
+	d, err := NewDisputer(a, dssync.MutexWrap(datastore.NewMapDatastore()), config.WdPoStDisputerConfig{AutoDispute: true}, actor)
+	require.NoError(t, err)
+
+	// deadline 3 of the second proving period closes at this epoch
+	closeEpoch := miner.WPoStProvingPeriod + 4*miner.WPoStChallengeWindow
+	a.height = closeEpoch
+
+	// only the deadlines with evidence are watched
+	require.NoError(t, d.AddEvidence(ctx, target, []abi.SectorNumber{3, 51}))
+
+	a.height = closeEpoch + confidence - 1
+	require.NoError(t, d.poll(ctx))
+	require.Empty(t, d.Status())
+
+	a.height = closeEpoch + confidence
+	require.NoError(t, d.poll(ctx))
+
+	st := d.Status()
+	require.Len(t, st, 2)
+	require.True(t, st[0].Valid)
+	require.False(t, st[1].Valid)
+	require.Equal(t, closeEpoch, st[1].Close)
+	require.Equal(t, closeEpoch+miner.WPoStDisputeWindow, st[1].DisputeEnd)
+	require.Equal(t, []abi.SectorNumber{3, 51}, st[1].Evidence)
+	require.NotNil(t, st[1].Dispute)
+
+	require.Len(t, a.pushed, 1)
+	require.Equal(t, target, a.pushed[0].To)
+	require.Equal(t, a.worker, a.pushed[0].From)
+
+	// the deadline isn't checked again in the same proving period
+	a.height++
+	require.NoError(t, d.poll(ctx))
+	require.Len(t, a.pushed, 1)
+
+	// the valid proofs can't be disputed
+	_, err = d.Dispute(ctx, target, 3, 0)
+	require.Error(t, err)
+	_, err = d.Dispute(ctx, target, 3, 2)
+	require.Error(t, err)
+
+	// the results are dropped once the dispute window ends
+	a.height = closeEpoch + miner.WPoStDisputeWindow
+	require.NoError(t, d.poll(ctx))
+	require.Empty(t, d.Status())
+}
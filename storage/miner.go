@@ -48,7 +48,7 @@ var log = logging.Logger("storageminer")
 // Miner#Run starts the sealing FSM.
 type Miner struct {
 	api     fullNodeFilteredAPI
-	feeCfg  config.MinerFeeConfig
+	feeCfg  config.GetMinerFeeConfigFunc
 	sealer  sealer.SectorManager
 	ds      datastore.Batching
 	sc      pipeline.SectorIDCounter
@@ -138,7 +138,7 @@ func NewMiner(api fullNodeFilteredAPI,
 	verif storiface.Verifier,
 	prover storiface.Prover,
 	gsd dtypes.GetSealingConfigFunc,
-	feeCfg config.MinerFeeConfig,
+	feeCfg config.GetMinerFeeConfigFunc,
 	journal journal.Journal,
 	as *ctladdr.AddressSelector) (*Miner, error) {
 	m := &Miner{
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	"golang.org/x/xerrors"
@@ -59,6 +60,10 @@ func (m *Miner) SectorPreCommitFlush(ctx context.Context) ([]sealiface.PreCommit
 	return m.sealing.SectorPreCommitFlush(ctx)
 }
 
+func (m *Miner) DealPackingStatus(ctx context.Context) (api.DealPackingStatus, error) {
+	return m.sealing.PackingStatus(ctx)
+}
+
 func (m *Miner) SectorPreCommitPending(ctx context.Context) ([]abi.SectorID, error) {
 	return m.sealing.SectorPreCommitPending(ctx)
 }
@@ -71,6 +76,52 @@ func (m *Miner) CommitPending(ctx context.Context) ([]abi.SectorID, error) {
 	return m.sealing.CommitPending(ctx)
 }
 
+func (m *Miner) SnapSectors() ([]api.SnapSectorInfo, error) {
+	sectors, err := m.sealing.SnapSectors()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]api.SnapSectorInfo, 0, len(sectors))
+	for _, s := range sectors {
+		si := api.SnapSectorInfo{
+			SectorID: s.SectorNumber,
+			State:    api.SectorState(s.State),
+			LastErr:  s.LastErr,
+		}
+		for _, p := range s.Pieces {
+			if p.DealInfo != nil {
+				si.Deals = append(si.Deals, p.DealInfo.DealID)
+			}
+		}
+		if len(s.Log) > 0 {
+			si.Updated = time.Unix(int64(s.Log[len(s.Log)-1].Timestamp), 0)
+		}
+		out = append(out, si)
+	}
+	return out, nil
+}
+
+func (m *Miner) RetrySnapUpgrade(id abi.SectorNumber) error {
+	return m.sealing.RetrySnapUpgrade(id)
+}
+
+func (m *Miner) SectorPreCommitBatchPlan(ctx context.Context) (sealiface.BatchPlan, error) {
+	return m.sealing.SectorPreCommitBatchPlan(ctx)
+}
+
+func (m *Miner) SectorPreCommitSetBatchMode(mode sealiface.BatchMode) error {
+	return m.sealing.SectorPreCommitSetBatchMode(mode)
+}
+
+func (m *Miner) CommitBatchPlan(ctx context.Context) (sealiface.BatchPlan, error) {
+	return m.sealing.CommitBatchPlan(ctx)
+}
+
+func (m *Miner) CommitSetBatchMode(mode sealiface.BatchMode) error {
+	return m.sealing.CommitSetBatchMode(mode)
+}
+
 func (m *Miner) SectorMatchPendingPiecesToOpenSectors(ctx context.Context) error {
 	return m.sealing.MatchPendingPiecesToOpenSectors(ctx)
 }
@@ -145,6 +196,8 @@ func (m *Miner) SectorsStatus(ctx context.Context, sid abi.SectorNumber, showOnC
 		Retries:              info.InvalidProofs,
 		ToUpgrade:            false,
 		ReplicaUpdateMessage: info.ReplicaUpdateMessage,
+		TerminateMessage:     info.TerminateMessage,
+		TerminatedAt:         info.TerminatedAt,
 
 		LastErr: info.LastErr,
 		Log:     log,
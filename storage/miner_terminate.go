@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"context"
+	"sort"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin/v8/miner"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	lminer "github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/power"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/reward"
+	"github.com/filecoin-project/lotus/chain/store"
+)
+
+// TerminateEstimate computes the penalties paid when terminating the given
+// sectors at an epoch. Epoch 0 means the current chain head; for future epochs
+// the current reward and network power estimates are used.
+func (m *Miner) TerminateEstimate(ctx context.Context, sectors []abi.SectorNumber, epoch abi.ChainEpoch) (*api.TerminateEstimate, error) {
+	head, err := m.api.ChainHead(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("getting chain head: %w", err)
+	}
+	if epoch == 0 {
+		epoch = head.Height()
+	}
+	if epoch < head.Height() {
+		return nil, xerrors.Errorf("epoch %d is before the current chain head %d", epoch, head.Height())
+	}
+
+	mi, err := m.api.StateMinerInfo(ctx, m.maddr, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting miner info: %w", err)
+	}
+
+	stor := store.ActorStore(ctx, blockstore.NewAPIBlockstore(m.api))
+
+	ract, err := m.api.StateGetActor(ctx, reward.Address, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("loading reward actor: %w", err)
+	}
+	rst, err := reward.Load(stor, ract)
+	if err != nil {
+		return nil, xerrors.Errorf("loading reward actor state: %w", err)
+	}
+	rewardEstimate, err := rst.ThisEpochRewardSmoothed()
+	if err != nil {
+		return nil, xerrors.Errorf("getting reward estimate: %w", err)
+	}
+
+	pact, err := m.api.StateGetActor(ctx, power.Address, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("loading power actor: %w", err)
+	}
+	pst, err := power.Load(stor, pact)
+	if err != nil {
+		return nil, xerrors.Errorf("loading power actor state: %w", err)
+	}
+	powerEstimate, err := pst.TotalPowerSmoothed()
+	if err != nil {
+		return nil, xerrors.Errorf("getting network power estimate: %w", err)
+	}
+
+	faults, err := m.api.StateMinerFaults(ctx, m.maddr, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting faulty sectors: %w", err)
+	}
+
+	avail, err := m.api.StateMinerAvailableBalance(ctx, m.maddr, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting available balance: %w", err)
+	}
+
+	out := &api.TerminateEstimate{
+		Epoch:            epoch,
+		Head:             head.Height(),
+		Penalty:          big.Zero(),
+		Pledge:           big.Zero(),
+		QAPower:          big.Zero(),
+		AvailableBalance: avail,
+	}
+
+	partitions := map[lminer.SectorLocation]uint64{}
+	for _, sn := range sectors {
+		si, err := m.api.StateSectorGetInfo(ctx, m.maddr, sn, head.Key())
+		if err != nil {
+			return nil, xerrors.Errorf("getting sector %d info: %w", sn, err)
+		}
+		if si == nil {
+			return nil, xerrors.Errorf("sector %d not found on chain", sn)
+		}
+
+		loc, err := m.api.StateSectorPartition(ctx, m.maddr, sn, head.Key())
+		if err != nil {
+			return nil, xerrors.Errorf("finding sector %d partition: %w", sn, err)
+		}
+
+		est := api.SectorTerminateEstimate{
+			Number:     sn,
+			Activation: si.Activation,
+			Expiration: si.Expiration,
+			Age:        epoch - si.Activation,
+			QAPower:    lminer.QAPowerForSector(mi.SectorSize, si),
+			Pledge:     si.InitialPledge,
+			Penalty:    big.Zero(),
+		}
+
+		if loc == nil || si.Expiration <= epoch {
+			// the sector will be gone by then, nothing to terminate
+			est.Expired = true
+			out.Sectors = append(out.Sectors, est)
+			continue
+		}
+
+		est.Deadline = loc.Deadline
+		est.Partition = loc.Partition
+		est.Faulty, err = faults.IsSet(uint64(sn))
+		if err != nil {
+			return nil, xerrors.Errorf("checking sector %d faults: %w", sn, err)
+		}
+		est.Penalty = lminer.TerminationPenalty(mi.SectorSize, epoch, si, rewardEstimate, powerEstimate)
+
+		out.Penalty = big.Add(out.Penalty, est.Penalty)
+		out.Pledge = big.Add(out.Pledge, est.Pledge)
+		if !est.Faulty {
+			// faulty sectors don't have active power
+			out.QAPower = big.Add(out.QAPower, est.QAPower)
+		}
+		out.Sectors = append(out.Sectors, est)
+		partitions[*loc]++
+	}
+
+	cfg, err := m.getSealConfig()
+	if err != nil {
+		return nil, xerrors.Errorf("getting sealing config: %w", err)
+	}
+	out.Messages = terminateMessages(partitions, cfg.TerminateBatchMax)
+
+	return out, nil
+}
+
+// terminateMessages returns the number of messages the termination batcher
+// needs to terminate the given number of sectors in each partition
+func terminateMessages(partitions map[lminer.SectorLocation]uint64, batchMax uint64) int {
+	limit := uint64(miner.AddressedSectorsMax)
+	if batchMax > 0 && batchMax < limit {
+		limit = batchMax
+	}
+
+	locs := make([]lminer.SectorLocation, 0, len(partitions))
+	for loc := range partitions {
+		locs = append(locs, loc)
+	}
+	sort.Slice(locs, func(i, j int) bool {
+		if locs[i].Deadline != locs[j].Deadline {
+			return locs[i].Deadline < locs[j].Deadline
+		}
+		return locs[i].Partition < locs[j].Partition
+	})
+
+	var msgs int
+	var total uint64
+	var decls int
+	for _, loc := range locs {
+		n := partitions[loc]
+		for n > 0 {
+			if msgs == 0 || total >= limit || decls >= miner.DeclarationsMax {
+				msgs++
+				total, decls = 0, 0
+			}
+
+			take := n
+			if take > limit-total {
+				take = limit - total
+			}
+			total += take
+			n -= take
+			decls++
+		}
+	}
+
+	return msgs
+}
@@ -0,0 +1,267 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/dline"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+// NotifierAPI is the chain API used by the checks
+type NotifierAPI interface {
+	ChainHead(context.Context) (*types.TipSet, error)
+	StateGetActor(context.Context, address.Address, types.TipSetKey) (*types.Actor, error)
+	StateMinerInfo(context.Context, address.Address, types.TipSetKey) (api.MinerInfo, error)
+	StateMinerProvingDeadline(context.Context, address.Address, types.TipSetKey) (*dline.Info, error)
+	StateMinerDeadlines(context.Context, address.Address, types.TipSetKey) ([]api.Deadline, error)
+	StateMinerPartitions(context.Context, address.Address, uint64, types.TipSetKey) ([]api.Partition, error)
+	StateMinerFaults(context.Context, address.Address, types.TipSetKey) (bitfield.BitField, error)
+}
+
+// WorkerStatser lists the workers connected to the miner
+type WorkerStatser interface {
+	WorkerStats(context.Context) map[uuid.UUID]storiface.WorkerStats
+}
+
+// checkState remembers what the previous checks saw, so that each occurrence
+// of a condition fires a single event
+type checkState struct {
+	// open epoch of the last deadline notified about
+	notifiedDeadline abi.ChainEpoch
+	// faults seen by the last check, nil before the first one
+	faults *bitfield.BitField
+	// workers seen by the last check, nil before the first one
+	connected map[uuid.UUID]string
+	// addresses below the balance threshold
+	lowBalance map[address.Address]bool
+}
+
+func newCheckState() checkState {
+	return checkState{
+		notifiedDeadline: -1,
+		lowBalance:       map[address.Address]bool{},
+	}
+}
+
+func (n *Notifier) check(ctx context.Context) {
+	head, err := n.api.ChainHead(ctx)
+	if err != nil {
+		log.Errorf("getting chain head: %+v", err)
+		return
+	}
+
+	var events []Event
+	collect := func(name string, evs []Event, err error) {
+		if err != nil {
+			log.Errorw("notification check failed", "check", name, "error", err)
+			return
+		}
+		events = append(events, evs...)
+	}
+
+	evs, err := n.checkDeadline(ctx, head)
+	collect("post-deadline", evs, err)
+	evs, err = n.checkFaults(ctx, head)
+	collect("sectors-faulty", evs, err)
+	if n.workers != nil {
+		collect("worker-disconnected", n.checkWorkers(ctx), nil)
+	}
+	evs, err = n.checkBalances(ctx, head)
+	collect("control-balance", evs, err)
+
+	for _, ev := range events {
+		if err := n.Fire(ctx, ev); err != nil {
+			log.Errorw("notifying event", "type", ev.Type, "error", err)
+		}
+	}
+}
+
+func (n *Notifier) checkDeadline(ctx context.Context, head *types.TipSet) ([]Event, error) {
+	di, err := n.api.StateMinerProvingDeadline(ctx, n.maddr, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting proving deadline: %w", err)
+	}
+	if di.Open == n.notifiedDeadline {
+		return nil, nil
+	}
+
+	left := time.Duration(di.Close-di.CurrentEpoch) * time.Duration(build.BlockDelaySecs) * time.Second
+	if left > n.cfg.PoStDeadlineWarning {
+		return nil, nil
+	}
+
+	dls, err := n.api.StateMinerDeadlines(ctx, n.maddr, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting deadlines: %w", err)
+	}
+	if di.Index >= uint64(len(dls)) {
+		return nil, xerrors.Errorf("deadline %d out of range", di.Index)
+	}
+	parts, err := n.api.StateMinerPartitions(ctx, n.maddr, di.Index, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting partitions: %w", err)
+	}
+
+	var unproven []uint64
+	for i, part := range parts {
+		live, err := part.LiveSectors.Count()
+		if err != nil {
+			return nil, err
+		}
+		if live == 0 {
+			continue
+		}
+		proven, err := dls[di.Index].PostSubmissions.IsSet(uint64(i))
+		if err != nil {
+			return nil, err
+		}
+		if !proven {
+			unproven = append(unproven, uint64(i))
+		}
+	}
+	if len(unproven) == 0 {
+		return nil, nil
+	}
+
+	n.notifiedDeadline = di.Open
+	return []Event{{
+		Type:     EventPoStDeadline,
+		Severity: SeverityCritical,
+		Summary:  fmt.Sprintf("WindowPoSt not submitted for %d partitions of deadline %d, which closes in %s", len(unproven), di.Index, left),
+		Details: map[string]interface{}{
+			"Deadline":   di.Index,
+			"Open":       di.Open,
+			"Close":      di.Close,
+			"Partitions": unproven,
+		},
+		Key: fmt.Sprintf("%s/%d", EventPoStDeadline, di.Open),
+	}}, nil
+}
+
+func (n *Notifier) checkFaults(ctx context.Context, head *types.TipSet) ([]Event, error) {
+	faults, err := n.api.StateMinerFaults(ctx, n.maddr, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting faults: %w", err)
+	}
+
+	prev := n.faults
+	n.faults = &faults
+	if prev == nil {
+		// the faults present at startup are the baseline
+		return nil, nil
+	}
+
+	added, err := bitfield.SubtractBitField(faults, *prev)
+	if err != nil {
+		return nil, err
+	}
+	count, err := added.Count()
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	total, err := faults.Count()
+	if err != nil {
+		return nil, err
+	}
+
+	sectors, err := added.All(count)
+	if err != nil {
+		return nil, err
+	}
+	return []Event{{
+		Type:     EventSectorsFaulty,
+		Severity: SeverityCritical,
+		Summary:  fmt.Sprintf("%d sectors became faulty, %d faulty sectors in total", count, total),
+		Details: map[string]interface{}{
+			"Sectors": sectors,
+			"Total":   total,
+		},
+		Key: fmt.Sprintf("%s/%d", EventSectorsFaulty, head.Height()),
+	}}, nil
+}
+
+func (n *Notifier) checkWorkers(ctx context.Context) []Event {
+	stats := n.workers.WorkerStats(ctx)
+
+	cur := make(map[uuid.UUID]string, len(stats))
+	for id, st := range stats {
+		cur[id] = st.Info.Hostname
+	}
+
+	prev := n.connected
+	n.connected = cur
+
+	var events []Event
+	for id, hostname := range prev {
+		if _, ok := cur[id]; ok {
+			continue
+		}
+		events = append(events, Event{
+			Type:     EventWorkerDisconnected,
+			Severity: SeverityWarning,
+			Summary:  fmt.Sprintf("worker %s (%s) disconnected", hostname, id),
+			Details: map[string]interface{}{
+				"Worker":   id,
+				"Hostname": hostname,
+			},
+			Key: fmt.Sprintf("%s/%s", EventWorkerDisconnected, id),
+		})
+	}
+	return events
+}
+
+func (n *Notifier) checkBalances(ctx context.Context, head *types.TipSet) ([]Event, error) {
+	if n.cfg.ControlBalanceThreshold.Nil() || n.cfg.ControlBalanceThreshold.IsZero() {
+		return nil, nil
+	}
+
+	mi, err := n.api.StateMinerInfo(ctx, n.maddr, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting miner info: %w", err)
+	}
+
+	var events []Event
+	for _, addr := range append([]address.Address{mi.Worker}, mi.ControlAddresses...) {
+		act, err := n.api.StateGetActor(ctx, addr, head.Key())
+		if err != nil {
+			return nil, xerrors.Errorf("getting actor %s: %w", addr, err)
+		}
+
+		if !act.Balance.LessThan(n.cfg.ControlBalanceThreshold) {
+			delete(n.lowBalance, addr)
+			continue
+		}
+		if n.lowBalance[addr] {
+			continue
+		}
+		n.lowBalance[addr] = true
+
+		events = append(events, Event{
+			Type:     EventControlBalance,
+			Severity: SeverityWarning,
+			Summary:  fmt.Sprintf("balance of %s is %s, below %s", addr, types.FIL(act.Balance), types.FIL(n.cfg.ControlBalanceThreshold)),
+			Details: map[string]interface{}{
+				"Address":   addr,
+				"Balance":   types.FIL(act.Balance),
+				"Threshold": types.FIL(n.cfg.ControlBalanceThreshold),
+			},
+			Key: fmt.Sprintf("%s/%s", EventControlBalance, addr),
+		})
+	}
+	return events, nil
+}
@@ -0,0 +1,298 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"go.uber.org/multierr"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/build"
+)
+
+var log = logging.Logger("notify")
+
+// EventType identifies the condition an event notifies about
+type EventType string
+
+const (
+	// EventPoStDeadline fires when partitions of the open proving deadline
+	// are still unproven with less than the warning time left
+	EventPoStDeadline EventType = "post-deadline"
+	// EventSectorsFaulty fires when sectors of the miner become faulty
+	EventSectorsFaulty EventType = "sectors-faulty"
+	// EventWorkerDisconnected fires when a worker disconnects from the miner
+	EventWorkerDisconnected EventType = "worker-disconnected"
+	// EventControlBalance fires when the balance of the worker or of a control
+	// address drops below the threshold
+	EventControlBalance EventType = "control-balance"
+	// EventTest is fired on request, to check the delivery of the webhooks
+	EventTest EventType = "test"
+)
+
+// EventTypes are all the event types, in the order they are documented
+var EventTypes = []EventType{EventPoStDeadline, EventSectorsFaulty, EventWorkerDisconnected, EventControlBalance, EventTest}
+
+// Severity of an event, using the PagerDuty severity names
+type Severity string
+
+const (
+	SeverityCritical Severity = "critical"
+	SeverityWarning  Severity = "warning"
+	SeverityInfo     Severity = "info"
+)
+
+// Event is the body POSTed to the webhooks with the generic format
+type Event struct {
+	Type     EventType
+	Severity Severity
+	Miner    address.Address
+	Summary  string
+	Details  map[string]interface{} `json:",omitempty"`
+	Time     time.Time
+
+	// Key identifies the occurrence of the condition, so that the PagerDuty
+	// alerts of a single occurrence are grouped
+	Key string `json:"-"`
+}
+
+// Webhook formats
+const (
+	FormatGeneric   = "generic"
+	FormatSlack     = "slack"
+	FormatPagerDuty = "pagerduty"
+)
+
+// Webhook is an endpoint notified of the miner events
+type Webhook struct {
+	URL    string
+	Format string
+	// RoutingKey is the integration key of a PagerDuty webhook
+	RoutingKey string
+	// Events sent to the webhook, all of them when empty
+	Events []EventType
+}
+
+func (w *Webhook) wants(t EventType) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+type Config struct {
+	Webhooks []Webhook
+
+	// PoStDeadlineWarning is the time left in an open deadline with unproven
+	// partitions at which EventPoStDeadline fires
+	PoStDeadlineWarning time.Duration
+	// ControlBalanceThreshold is the balance below which EventControlBalance
+	// fires for the worker and control addresses
+	ControlBalanceThreshold abi.TokenAmount
+	// Timeout of the webhook requests
+	Timeout time.Duration
+}
+
+// ParseEventType checks that the name is one of the event types
+func ParseEventType(name string) (EventType, error) {
+	for _, t := range EventTypes {
+		if string(t) == name {
+			return t, nil
+		}
+	}
+	return "", xerrors.Errorf("unknown event %q", name)
+}
+
+// Notifier runs the checks of the miner conditions, and notifies the webhooks
+// of the events they fire
+type Notifier struct {
+	cfg     Config
+	maddr   address.Address
+	api     NotifierAPI
+	workers WorkerStatser
+	client  *http.Client
+
+	// check state, only accessed from the check loop
+	checkState
+
+	stopping chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewNotifier(cfg Config, maddr address.Address, api NotifierAPI, workers WorkerStatser) (*Notifier, error) {
+	for i, w := range cfg.Webhooks {
+		switch w.Format {
+		case "":
+			cfg.Webhooks[i].Format = FormatGeneric
+		case FormatGeneric, FormatSlack:
+		case FormatPagerDuty:
+			if w.RoutingKey == "" {
+				return nil, xerrors.Errorf("pagerduty webhook %s needs a routing key", w.URL)
+			}
+		default:
+			return nil, xerrors.Errorf("unknown format %q of webhook %s", w.Format, w.URL)
+		}
+	}
+
+	return &Notifier{
+		cfg:        cfg,
+		maddr:      maddr,
+		api:        api,
+		workers:    workers,
+		client:     &http.Client{Timeout: cfg.Timeout},
+		checkState: newCheckState(),
+		stopping:   make(chan struct{}),
+		done:       make(chan struct{}),
+	}, nil
+}
+
+// Run runs the checks every epoch until Stop is called
+func (n *Notifier) Run(ctx context.Context) {
+	defer close(n.done)
+
+	ticker := build.Clock.Ticker(time.Duration(build.BlockDelaySecs) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		n.check(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-n.stopping:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (n *Notifier) Stop(ctx context.Context) error {
+	n.stopOnce.Do(func() {
+		close(n.stopping)
+	})
+
+	select {
+	case <-n.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Fire sends the event to the webhooks subscribed to its type
+func (n *Notifier) Fire(ctx context.Context, ev Event) error {
+	if ev.Miner == address.Undef {
+		ev.Miner = n.maddr
+	}
+	if ev.Time.IsZero() {
+		ev.Time = build.Clock.Now()
+	}
+	if ev.Key == "" {
+		ev.Key = fmt.Sprintf("%s/%s", ev.Type, ev.Time.Format(time.RFC3339))
+	}
+
+	log.Warnw("miner event", "type", ev.Type, "severity", ev.Severity, "summary", ev.Summary)
+
+	var err error
+	for _, w := range n.cfg.Webhooks {
+		if !w.wants(ev.Type) {
+			continue
+		}
+		if werr := n.send(ctx, w, ev); werr != nil {
+			err = multierr.Append(err, xerrors.Errorf("notifying %s: %w", w.URL, werr))
+		}
+	}
+	return err
+}
+
+// Test fires a test event of the given type, to the webhooks subscribed to it
+func (n *Notifier) Test(ctx context.Context, t EventType) error {
+	return n.Fire(ctx, Event{
+		Type:     t,
+		Severity: SeverityInfo,
+		Summary:  fmt.Sprintf("test %s notification of miner %s", t, n.maddr),
+		Key:      fmt.Sprintf("test/%s", t),
+	})
+}
+
+func (n *Notifier) send(ctx context.Context, w Webhook, ev Event) error {
+	body, err := formatEvent(w, ev)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return xerrors.Errorf("creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return xerrors.Errorf("webhook returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+func formatEvent(w Webhook, ev Event) ([]byte, error) {
+	switch w.Format {
+	case FormatSlack:
+		return json.Marshal(struct {
+			Text string `json:"text"`
+		}{
+			Text: fmt.Sprintf("[%s] %s: %s", strings.ToUpper(string(ev.Severity)), ev.Miner, ev.Summary),
+		})
+	case FormatPagerDuty:
+		type payload struct {
+			Summary       string                 `json:"summary"`
+			Source        string                 `json:"source"`
+			Severity      Severity               `json:"severity"`
+			Timestamp     string                 `json:"timestamp"`
+			Component     string                 `json:"component"`
+			CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+		}
+		return json.Marshal(struct {
+			RoutingKey  string  `json:"routing_key"`
+			EventAction string  `json:"event_action"`
+			DedupKey    string  `json:"dedup_key"`
+			Payload     payload `json:"payload"`
+		}{
+			RoutingKey:  w.RoutingKey,
+			EventAction: "trigger",
+			DedupKey:    fmt.Sprintf("%s/%s", ev.Miner, ev.Key),
+			Payload: payload{
+				Summary:       ev.Summary,
+				Source:        ev.Miner.String(),
+				Severity:      ev.Severity,
+				Timestamp:     ev.Time.Format(time.RFC3339),
+				Component:     string(ev.Type),
+				CustomDetails: ev.Details,
+			},
+		})
+	default:
+		return json.Marshal(ev)
+	}
+}
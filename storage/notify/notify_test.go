@@ -0,0 +1,217 @@
+//stm: #unit
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/dline"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/mock"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+type recorder struct {
+	lk     sync.Mutex
+	bodies map[string][]map[string]interface{}
+}
+
+func (r *recorder) handler(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		var body map[string]interface{}
+		_ = json.Unmarshal(b, &body)
+
+		r.lk.Lock()
+		r.bodies[name] = append(r.bodies[name], body)
+		r.lk.Unlock()
+	}
+}
+
+func TestFire(t *testing.T) {
+	rec := &recorder{bodies: map[string][]map[string]interface{}{}}
+	mux := http.NewServeMux()
+	mux.Handle("/generic", rec.handler("generic"))
+	mux.Handle("/slack", rec.handler("slack"))
+	mux.Handle("/pagerduty", rec.handler("pagerduty"))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	maddr, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+
+	n, err := NewNotifier(Config{
+		Webhooks: []Webhook{
+			{URL: srv.URL + "/generic"},
+			{URL: srv.URL + "/slack", Format: FormatSlack, Events: []EventType{EventSectorsFaulty}},
+			{URL: srv.URL + "/pagerduty", Format: FormatPagerDuty, RoutingKey: "key"},
+		},
+		Timeout: time.Second,
+	}, maddr, nil, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, n.Test(context.Background(), EventTest))
+	require.Len(t, rec.bodies["generic"], 1)
+	require.Equal(t, "test", rec.bodies["generic"][0]["Type"])
+	require.Equal(t, maddr.String(), rec.bodies["generic"][0]["Miner"])
+	require.Len(t, rec.bodies["slack"], 0)
+	require.Len(t, rec.bodies["pagerduty"], 1)
+	require.Equal(t, "key", rec.bodies["pagerduty"][0]["routing_key"])
+	require.Equal(t, "trigger", rec.bodies["pagerduty"][0]["event_action"])
+
+	require.NoError(t, n.Test(context.Background(), EventSectorsFaulty))
+	require.Len(t, rec.bodies["slack"], 1)
+	require.Contains(t, rec.bodies["slack"][0]["text"], "test sectors-faulty notification")
+
+	_, err = NewNotifier(Config{Webhooks: []Webhook{{URL: srv.URL, Format: FormatPagerDuty}}}, maddr, nil, nil)
+	require.Error(t, err)
+	_, err = ParseEventType("unknown")
+	require.Error(t, err)
+}
+
+type fakeAPI struct {
+	head    *types.TipSet
+	di      *dline.Info
+	proven  bool
+	faults  []uint64
+	balance abi.TokenAmount
+	worker  address.Address
+}
+
+func (f *fakeAPI) ChainHead(context.Context) (*types.TipSet, error) {
+	return f.head, nil
+}
+
+func (f *fakeAPI) StateGetActor(context.Context, address.Address, types.TipSetKey) (*types.Actor, error) {
+	return &types.Actor{Balance: f.balance}, nil
+}
+
+func (f *fakeAPI) StateMinerInfo(context.Context, address.Address, types.TipSetKey) (api.MinerInfo, error) {
+	return api.MinerInfo{Worker: f.worker}, nil
+}
+
+func (f *fakeAPI) StateMinerProvingDeadline(context.Context, address.Address, types.TipSetKey) (*dline.Info, error) {
+	return f.di, nil
+}
+
+func (f *fakeAPI) StateMinerDeadlines(context.Context, address.Address, types.TipSetKey) ([]api.Deadline, error) {
+	dl := api.Deadline{PostSubmissions: bitfield.New()}
+	if f.proven {
+		dl.PostSubmissions = bitfield.NewFromSet([]uint64{0})
+	}
+	return []api.Deadline{dl}, nil
+}
+
+func (f *fakeAPI) StateMinerPartitions(context.Context, address.Address, uint64, types.TipSetKey) ([]api.Partition, error) {
+	return []api.Partition{{LiveSectors: bitfield.NewFromSet([]uint64{1, 2})}}, nil
+}
+
+func (f *fakeAPI) StateMinerFaults(context.Context, address.Address, types.TipSetKey) (bitfield.BitField, error) {
+	return bitfield.NewFromSet(f.faults), nil
+}
+
+type fakeWorkers map[uuid.UUID]storiface.WorkerStats
+
+func (f fakeWorkers) WorkerStats(context.Context) map[uuid.UUID]storiface.WorkerStats {
+	return f
+}
+
+func TestChecks(t *testing.T) {
+	ctx := context.Background()
+
+	maddr, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+	worker, err := address.NewIDAddress(1001)
+	require.NoError(t, err)
+
+	fapi := &fakeAPI{
+		head:    mock.TipSet(mock.MkBlock(nil, 1, 1)),
+		di:      &dline.Info{CurrentEpoch: 100, Open: 60, Close: 120},
+		faults:  []uint64{1},
+		balance: big.NewInt(10),
+		worker:  worker,
+	}
+	workers := fakeWorkers{
+		uuid.New(): storiface.WorkerStats{Info: storiface.WorkerInfo{Hostname: "w1"}},
+		uuid.New(): storiface.WorkerStats{Info: storiface.WorkerInfo{Hostname: "w2"}},
+	}
+
+	n, err := NewNotifier(Config{
+		PoStDeadlineWarning:     time.Duration(30*build.BlockDelaySecs) * time.Second,
+		ControlBalanceThreshold: big.NewInt(100),
+	}, maddr, fapi, workers)
+	require.NoError(t, err)
+
+	// the deadline closes in 20 epochs with an unproven partition, the
+	// balance is low and the faults and workers are the baseline
+	evs, err := n.checkDeadline(ctx, fapi.head)
+	require.NoError(t, err)
+	require.Len(t, evs, 1)
+	require.Equal(t, EventPoStDeadline, evs[0].Type)
+
+	evs, err = n.checkFaults(ctx, fapi.head)
+	require.NoError(t, err)
+	require.Empty(t, evs)
+	require.Empty(t, n.checkWorkers(ctx))
+
+	evs, err = n.checkBalances(ctx, fapi.head)
+	require.NoError(t, err)
+	require.Len(t, evs, 1)
+	require.Equal(t, EventControlBalance, evs[0].Type)
+
+	// nothing changed, nothing fires again
+	evs, err = n.checkDeadline(ctx, fapi.head)
+	require.NoError(t, err)
+	require.Empty(t, evs)
+	evs, err = n.checkBalances(ctx, fapi.head)
+	require.NoError(t, err)
+	require.Empty(t, evs)
+
+	// new faults and a disconnected worker
+	fapi.faults = []uint64{1, 5, 6}
+	evs, err = n.checkFaults(ctx, fapi.head)
+	require.NoError(t, err)
+	require.Len(t, evs, 1)
+	require.Equal(t, []uint64{5, 6}, evs[0].Details["Sectors"])
+
+	for id := range workers {
+		delete(workers, id)
+		break
+	}
+	evs = n.checkWorkers(ctx)
+	require.Len(t, evs, 1)
+	require.Equal(t, EventWorkerDisconnected, evs[0].Type)
+
+	// the balance recovers, then drops again
+	fapi.balance = big.NewInt(1000)
+	evs, err = n.checkBalances(ctx, fapi.head)
+	require.NoError(t, err)
+	require.Empty(t, evs)
+	fapi.balance = big.NewInt(1)
+	evs, err = n.checkBalances(ctx, fapi.head)
+	require.NoError(t, err)
+	require.Len(t, evs, 1)
+
+	// a proven deadline doesn't fire
+	fapi.di = &dline.Info{CurrentEpoch: 160, Open: 120, Close: 180}
+	fapi.proven = true
+	evs, err = n.checkDeadline(ctx, fapi.head)
+	require.NoError(t, err)
+	require.Empty(t, evs)
+}
@@ -0,0 +1,86 @@
+package paths
+
+import (
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+// AllocationPolicy places the files of a sector in long-term storage paths
+// depending on the groups of the paths holding its other files. A path without
+// groups only shares a group with itself.
+type AllocationPolicy string
+
+const (
+	// AllocAny places each file in the best path
+	AllocAny AllocationPolicy = ""
+	// AllocSameGroup places the files of a sector in paths sharing a group,
+	// so that the failure of the hardware behind a group only affects the
+	// sectors stored in the group
+	AllocSameGroup AllocationPolicy = "same-group"
+	// AllocSpreadGroups places the files of a sector in paths sharing no
+	// group, so that the failure of the hardware behind a group never takes
+	// all the files of a sector
+	AllocSpreadGroups AllocationPolicy = "spread-groups"
+)
+
+func (p AllocationPolicy) Validate() error {
+	switch p {
+	case AllocAny, AllocSameGroup, AllocSpreadGroups:
+		return nil
+	default:
+		return xerrors.Errorf("unknown allocation policy '%s', expected '%s' or '%s'", p, AllocSameGroup, AllocSpreadGroups)
+	}
+}
+
+// placement is a path holding a file of a sector
+type placement struct {
+	id     storiface.ID
+	groups []storiface.Group
+}
+
+func (a placement) shares(b placement) bool {
+	if a.id == b.id {
+		return true
+	}
+	for _, ga := range a.groups {
+		for _, gb := range b.groups {
+			if ga == gb {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// allows returns whether a file of a sector can be placed in the candidate
+// path, given the paths holding the other files of the sector
+func (p AllocationPolicy) allows(candidate placement, placed []placement) bool {
+	for _, other := range placed {
+		switch p {
+		case AllocSameGroup:
+			if !candidate.shares(other) {
+				return false
+			}
+		case AllocSpreadGroups:
+			if candidate.shares(other) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// SetAllocationPolicy sets the policy placing the files of sectors allocated
+// in the local long-term storage paths
+func (st *Local) SetAllocationPolicy(p AllocationPolicy) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	st.localLk.Lock()
+	defer st.localLk.Unlock()
+
+	st.allocPolicy = p
+	return nil
+}
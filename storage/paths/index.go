@@ -45,6 +45,9 @@ type SectorIndex interface { // part of storage-miner api
 	StorageGetLocks(ctx context.Context) (storiface.SectorLocks, error)
 
 	StorageList(ctx context.Context) (map[storiface.ID][]storiface.Decl, error)
+
+	StorageHealth(ctx context.Context) ([]storiface.PathHealth, error)
+	StorageAtRiskSectors(ctx context.Context) ([]storiface.AtRiskSector, error)
 }
 
 type declMeta struct {
@@ -58,6 +61,8 @@ type storageEntry struct {
 
 	lastHeartbeat time.Time
 	heartbeatErr  error
+
+	io storiface.PathIOStats
 }
 
 type Index struct {
@@ -65,8 +70,9 @@ type Index struct {
 	lk sync.RWMutex
 
 	// optional
-	alerting   *alerting.Alerting
-	pathAlerts map[storiface.ID]alerting.AlertType
+	alerting     *alerting.Alerting
+	pathAlerts   map[storiface.ID]alerting.AlertType
+	healthAlerts map[storiface.ID]alerting.AlertType
 
 	sectors map[storiface.Decl][]*declMeta
 	stores  map[storiface.ID]*storageEntry
@@ -78,8 +84,9 @@ func NewIndex(al *alerting.Alerting) *Index {
 			locks: map[abi.SectorID]*sectorLock{},
 		},
 
-		alerting:   al,
-		pathAlerts: map[storiface.ID]alerting.AlertType{},
+		alerting:     al,
+		pathAlerts:   map[storiface.ID]alerting.AlertType{},
+		healthAlerts: map[storiface.ID]alerting.AlertType{},
 
 		sectors: map[storiface.Decl][]*declMeta{},
 		stores:  map[storiface.ID]*storageEntry{},
@@ -205,6 +212,7 @@ func (i *Index) StorageAttach(ctx context.Context, si storiface.StorageInfo, st
 		i.stores[si.ID].info.AllowTo = si.AllowTo
 		i.stores[si.ID].info.AllowTypes = allow
 		i.stores[si.ID].info.DenyTypes = deny
+		i.stores[si.ID].info.Drain = si.Drain
 
 		return nil
 	}
@@ -233,6 +241,9 @@ func (i *Index) StorageReportHealth(ctx context.Context, id storiface.ID, report
 		ent.heartbeatErr = nil
 	}
 	ent.lastHeartbeat = time.Now()
+	ent.io = report.IO
+
+	i.updateHealthAlert(id, ent)
 
 	if report.Stat.Capacity > 0 {
 		ctx, _ = tag.New(ctx, tag.Upsert(metrics.StorageID, string(id)))
@@ -509,6 +520,10 @@ func (i *Index) StorageBestAlloc(ctx context.Context, allocate storiface.SectorF
 		if (pathType == storiface.PathStorage) && !p.info.CanStore {
 			continue
 		}
+		if p.info.Drain {
+			log.Debugf("not allocating on %s, draining", p.info.ID)
+			continue
+		}
 
 		if spaceReq > uint64(p.fsi.Available) {
 			log.Debugf("not allocating on %s, out of space (available: %d, need: %d)", p.info.ID, p.fsi.Available, spaceReq)
@@ -0,0 +1,172 @@
+package paths
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+var (
+	// AtRiskErrorRate is the share of failed sector reads above which the
+	// sectors in a path are at risk
+	AtRiskErrorRate = 0.01
+	// AtRiskErrorWindow is how long a read error keeps a path at risk
+	AtRiskErrorWindow = 24 * time.Hour
+	// AtRiskReadLatency is the average read latency above which the sectors in
+	// a path are at risk
+	AtRiskReadLatency = 30 * time.Second
+	// AtRiskMinReads is the number of reads needed to judge the latency of a path
+	AtRiskMinReads uint64 = 5
+)
+
+// provenFileTypes are the file types read by PoSt
+const provenFileTypes = storiface.FTSealed | storiface.FTCache | storiface.FTUpdate | storiface.FTUpdateCache
+
+// pathRisks returns the reasons why the sectors in a path are predicted to
+// fail their proofs
+func pathRisks(now time.Time, io storiface.PathIOStats, lastHeartbeat time.Time, heartbeatErr error) []string {
+	var out []string
+
+	if heartbeatErr != nil {
+		out = append(out, fmt.Sprintf("heartbeat error: %s", heartbeatErr))
+	} else if now.Sub(lastHeartbeat) > SkippedHeartbeatThresh {
+		out = append(out, fmt.Sprintf("no heartbeat for %s", now.Sub(lastHeartbeat).Truncate(time.Second)))
+	}
+
+	if io.ReadErrors > 0 && now.Sub(io.LastErrTime) < AtRiskErrorWindow {
+		if io.Reads == 0 || float64(io.ReadErrors)/float64(io.Reads) >= AtRiskErrorRate {
+			out = append(out, fmt.Sprintf("%d/%d reads failed, last: %s", io.ReadErrors, io.Reads, io.LastErr))
+		}
+	}
+
+	if io.Reads >= AtRiskMinReads && io.ReadLatency > AtRiskReadLatency {
+		out = append(out, fmt.Sprintf("slow reads, %s on average", io.ReadLatency.Truncate(time.Millisecond)))
+	}
+
+	return out
+}
+
+// StorageHealth returns the health of all the storage paths
+func (i *Index) StorageHealth(ctx context.Context) ([]storiface.PathHealth, error) {
+	i.lk.RLock()
+	defer i.lk.RUnlock()
+
+	now := time.Now()
+
+	out := make([]storiface.PathHealth, 0, len(i.stores))
+	for id, ent := range i.stores {
+		h := storiface.PathHealth{
+			ID:            id,
+			IO:            ent.io,
+			LastHeartbeat: ent.lastHeartbeat,
+			AtRisk:        pathRisks(now, ent.io, ent.lastHeartbeat, ent.heartbeatErr),
+		}
+		if ent.heartbeatErr != nil {
+			h.HeartbeatErr = ent.heartbeatErr.Error()
+		}
+		out = append(out, h)
+	}
+
+	sort.Slice(out, func(a, b int) bool {
+		return out[a].ID < out[b].ID
+	})
+
+	return out, nil
+}
+
+// StorageAtRiskSectors returns the sectors with files read by PoSt in storage
+// paths at risk
+func (i *Index) StorageAtRiskSectors(ctx context.Context) ([]storiface.AtRiskSector, error) {
+	i.lk.RLock()
+	defer i.lk.RUnlock()
+
+	now := time.Now()
+
+	risks := map[storiface.ID][]string{}
+	for id, ent := range i.stores {
+		if r := pathRisks(now, ent.io, ent.lastHeartbeat, ent.heartbeatErr); len(r) > 0 {
+			risks[id] = r
+		}
+	}
+	if len(risks) == 0 {
+		return nil, nil
+	}
+
+	var out []storiface.AtRiskSector
+	for decl, metas := range i.sectors {
+		if decl.SectorFileType&provenFileTypes == 0 {
+			continue
+		}
+
+		var healthy int
+		for _, m := range metas {
+			if _, ok := risks[m.storage]; !ok {
+				healthy++
+			}
+		}
+
+		for _, m := range metas {
+			r, ok := risks[m.storage]
+			if !ok {
+				continue
+			}
+
+			out = append(out, storiface.AtRiskSector{
+				Sector:        decl.SectorID,
+				FileType:      decl.SectorFileType,
+				Storage:       m.storage,
+				Reasons:       r,
+				HealthyCopies: healthy,
+			})
+		}
+	}
+
+	sort.Slice(out, func(a, b int) bool {
+		if out[a].Sector != out[b].Sector {
+			if out[a].Sector.Miner != out[b].Sector.Miner {
+				return out[a].Sector.Miner < out[b].Sector.Miner
+			}
+			return out[a].Sector.Number < out[b].Sector.Number
+		}
+		if out[a].FileType != out[b].FileType {
+			return out[a].FileType < out[b].FileType
+		}
+		return out[a].Storage < out[b].Storage
+	})
+
+	return out, nil
+}
+
+// updateHealthAlert raises the health alert of a path when it becomes at risk,
+// and resolves it when it recovers; must be called with i.lk held
+func (i *Index) updateHealthAlert(id storiface.ID, ent *storageEntry) {
+	if i.alerting == nil {
+		return
+	}
+
+	alert, ok := i.healthAlerts[id]
+	if !ok {
+		alert = i.alerting.AddAlertType("sector-index", "pathhealth-"+string(id))
+		i.healthAlerts[id] = alert
+	}
+
+	risks := pathRisks(time.Now(), ent.io, ent.lastHeartbeat, ent.heartbeatErr)
+	raised := i.alerting.IsRaised(alert)
+
+	switch {
+	case len(risks) > 0 && !raised:
+		log.Warnw("sectors in storage path at risk", "path", id, "reasons", risks)
+		i.alerting.Raise(alert, map[string]interface{}{
+			"message": "sectors in storage path at risk of failing proofs",
+			"path":    string(id),
+			"reasons": risks,
+		})
+	case len(risks) == 0 && raised:
+		i.alerting.Resolve(alert, map[string]string{
+			"message": "storage path is healthy",
+		})
+	}
+}
@@ -3,6 +3,7 @@ package paths
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	logging "github.com/ipfs/go-log/v2"
@@ -10,6 +11,8 @@ import (
 
 	"github.com/filecoin-project/go-state-types/abi"
 
+	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/journal/alerting"
 	"github.com/filecoin-project/lotus/storage/sealer/fsutil"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
 )
@@ -152,3 +155,88 @@ func TestFindAllow(t *testing.T) {
 		}
 	}
 }
+
+func TestStorageAtRisk(t *testing.T) {
+	ctx := context.Background()
+
+	al := alerting.NewAlertingSystem(journal.NilJournal())
+	i := NewIndex(al)
+	stor1 := newTestStorage()
+	stor2 := newTestStorage()
+
+	require.NoError(t, i.StorageAttach(ctx, stor1, bigFsStat))
+	require.NoError(t, i.StorageAttach(ctx, stor2, bigFsStat))
+
+	s1 := abi.SectorID{Miner: 12, Number: 34}
+	s2 := abi.SectorID{Miner: 12, Number: 35}
+
+	require.NoError(t, i.StorageDeclareSector(ctx, stor1.ID, s1, storiface.FTSealed, true))
+	require.NoError(t, i.StorageDeclareSector(ctx, stor1.ID, s1, storiface.FTUnsealed, true))
+	require.NoError(t, i.StorageDeclareSector(ctx, stor1.ID, s2, storiface.FTSealed, true))
+	require.NoError(t, i.StorageDeclareSector(ctx, stor2.ID, s2, storiface.FTSealed, false))
+
+	sectors, err := i.StorageAtRiskSectors(ctx)
+	require.NoError(t, err)
+	require.Empty(t, sectors)
+
+	// a few reads failed recently
+	require.NoError(t, i.StorageReportHealth(ctx, stor1.ID, storiface.HealthReport{
+		Stat: bigFsStat,
+		IO: storiface.PathIOStats{
+			Reads:       10,
+			ReadErrors:  2,
+			ReadLatency: time.Second,
+			LastErr:     "input/output error",
+			LastErrTime: time.Now(),
+		},
+	}))
+
+	health, err := i.StorageHealth(ctx)
+	require.NoError(t, err)
+	require.Len(t, health, 2)
+	for _, h := range health {
+		if h.ID == stor1.ID {
+			require.Len(t, h.AtRisk, 1)
+		} else {
+			require.Empty(t, h.AtRisk)
+		}
+	}
+	require.True(t, al.IsRaised(i.healthAlerts[stor1.ID]))
+
+	// unsealed files aren't proven
+	sectors, err = i.StorageAtRiskSectors(ctx)
+	require.NoError(t, err)
+	require.Len(t, sectors, 2)
+	require.Equal(t, s1, sectors[0].Sector)
+	require.Equal(t, 0, sectors[0].HealthyCopies)
+	require.Equal(t, s2, sectors[1].Sector)
+	require.Equal(t, 1, sectors[1].HealthyCopies)
+
+	// old errors are forgotten
+	require.NoError(t, i.StorageReportHealth(ctx, stor1.ID, storiface.HealthReport{
+		Stat: bigFsStat,
+		IO: storiface.PathIOStats{
+			Reads:       10,
+			ReadErrors:  2,
+			LastErrTime: time.Now().Add(-AtRiskErrorWindow),
+		},
+	}))
+
+	sectors, err = i.StorageAtRiskSectors(ctx)
+	require.NoError(t, err)
+	require.Empty(t, sectors)
+	require.False(t, al.IsRaised(i.healthAlerts[stor1.ID]))
+}
+
+func TestPathRisks(t *testing.T) {
+	now := time.Now()
+
+	require.Empty(t, pathRisks(now, storiface.PathIOStats{Reads: 1000, ReadErrors: 1, LastErrTime: now}, now, nil))
+	require.Len(t, pathRisks(now, storiface.PathIOStats{Reads: 100, ReadErrors: 1, LastErrTime: now}, now, nil), 1)
+
+	// the latency of a few reads isn't judged
+	require.Empty(t, pathRisks(now, storiface.PathIOStats{Reads: 1, ReadLatency: time.Minute}, now, nil))
+	require.Len(t, pathRisks(now, storiface.PathIOStats{Reads: 10, ReadLatency: time.Minute}, now, nil), 1)
+
+	require.Len(t, pathRisks(now, storiface.PathIOStats{}, now.Add(-time.Hour), nil), 1)
+}
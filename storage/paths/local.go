@@ -68,6 +68,10 @@ type LocalStorageMeta struct {
 	// - "update-cache"
 	// Any other value will generate a warning and be ignored.
 	DenyTypes []string
+
+	// Drain marks a path being decommissioned; no new sectors are stored in
+	// it, and the storage rebalancer moves its sectors to the other paths
+	Drain bool
 }
 
 // StorageConfig .lotusstorage/storage.json
@@ -99,6 +103,8 @@ type Local struct {
 
 	paths map[storiface.ID]*path
 
+	allocPolicy AllocationPolicy
+
 	localLk sync.RWMutex
 }
 
@@ -108,6 +114,35 @@ type path struct {
 
 	reserved     int64
 	reservations map[abi.SectorID]storiface.SectorFileType
+
+	ioLk sync.Mutex
+	io   storiface.PathIOStats
+}
+
+// recordRead updates the read statistics of the path with a sector read
+func (p *path) recordRead(took time.Duration, err error) {
+	p.ioLk.Lock()
+	defer p.ioLk.Unlock()
+
+	p.io.Reads++
+	if p.io.Reads == 1 {
+		p.io.ReadLatency = took
+	} else {
+		p.io.ReadLatency += (took - p.io.ReadLatency) / 10
+	}
+
+	if err != nil {
+		p.io.ReadErrors++
+		p.io.LastErr = err.Error()
+		p.io.LastErrTime = time.Now()
+	}
+}
+
+func (p *path) ioStats() storiface.PathIOStats {
+	p.ioLk.Lock()
+	defer p.ioLk.Unlock()
+
+	return p.io
 }
 
 func (p *path) stat(ls LocalStorage) (fsutil.FsStat, error) {
@@ -244,6 +279,7 @@ func (st *Local) OpenPath(ctx context.Context, p string) error {
 		AllowTo:    meta.AllowTo,
 		AllowTypes: meta.AllowTypes,
 		DenyTypes:  meta.DenyTypes,
+		Drain:      meta.Drain,
 	}, fst)
 	if err != nil {
 		return xerrors.Errorf("declaring storage in index: %w", err)
@@ -312,6 +348,7 @@ func (st *Local) Redeclare(ctx context.Context) error {
 			AllowTo:    meta.AllowTo,
 			AllowTypes: meta.AllowTypes,
 			DenyTypes:  meta.DenyTypes,
+			Drain:      meta.Drain,
 		}, fst)
 		if err != nil {
 			return xerrors.Errorf("redeclaring storage in index: %w", err)
@@ -379,7 +416,7 @@ func (st *Local) reportStorage(ctx context.Context) {
 	toReport := map[storiface.ID]storiface.HealthReport{}
 	for id, p := range st.paths {
 		stat, err := p.stat(st.localStorage)
-		r := storiface.HealthReport{Stat: stat}
+		r := storiface.HealthReport{Stat: stat, IO: p.ioStats()}
 		if err != nil {
 			r.Err = err.Error()
 		}
@@ -473,6 +510,10 @@ func (st *Local) AcquireSector(ctx context.Context, sid storiface.SectorRef, exi
 	var out storiface.SectorPaths
 	var storageIDs storiface.SectorPaths
 
+	// paths of the sector files, for the allocation policy
+	applyPolicy := pathType == storiface.PathStorage && st.allocPolicy != AllocAny
+	var placed []placement
+
 	for _, fileType := range storiface.PathTypes {
 		if fileType&existing == 0 {
 			continue
@@ -498,6 +539,14 @@ func (st *Local) AcquireSector(ctx context.Context, sid storiface.SectorRef, exi
 			storiface.SetPathByType(&out, fileType, spath)
 			storiface.SetPathByType(&storageIDs, fileType, string(info.ID))
 
+			if applyPolicy {
+				pi, err := st.index.StorageInfo(ctx, info.ID)
+				if err != nil {
+					return storiface.SectorPaths{}, storiface.SectorPaths{}, xerrors.Errorf("getting storage info of %s: %w", info.ID, err)
+				}
+				placed = append(placed, placement{id: info.ID, groups: pi.Groups})
+			}
+
 			existing ^= fileType
 			break
 		}
@@ -515,6 +564,7 @@ func (st *Local) AcquireSector(ctx context.Context, sid storiface.SectorRef, exi
 
 		var best string
 		var bestID storiface.ID
+		var bestGroups []storiface.Group
 
 		for _, si := range sis {
 			p, ok := st.paths[si.ID]
@@ -538,19 +588,41 @@ func (st *Local) AcquireSector(ctx context.Context, sid storiface.SectorRef, exi
 				continue
 			}
 
+			if applyPolicy && !st.allocPolicy.allows(placement{id: si.ID, groups: si.Groups}, placed) {
+				continue
+			}
+
 			// TODO: Check free space
 
-			best = p.sectorPath(sid.ID, fileType)
-			bestID = si.ID
-			break
+			spath := p.sectorPath(sid.ID, fileType)
+			if best == "" {
+				best = spath
+				bestID = si.ID
+				bestGroups = si.Groups
+			}
+
+			// prefer paths which already have the sector file, eg. the cache of
+			// an interrupted PC1 which can be resumed
+			if _, err := os.Stat(spath); err == nil {
+				best = spath
+				bestID = si.ID
+				bestGroups = si.Groups
+				break
+			}
 		}
 
 		if best == "" {
+			if applyPolicy {
+				return storiface.SectorPaths{}, storiface.SectorPaths{}, xerrors.Errorf("couldn't find a suitable path for a sector with the '%s' allocation policy", st.allocPolicy)
+			}
 			return storiface.SectorPaths{}, storiface.SectorPaths{}, xerrors.Errorf("couldn't find a suitable path for a sector")
 		}
 
 		storiface.SetPathByType(&out, fileType, best)
 		storiface.SetPathByType(&storageIDs, fileType, string(bestID))
+		if applyPolicy {
+			placed = append(placed, placement{id: bestID, groups: bestGroups})
+		}
 		allocate ^= fileType
 	}
 
@@ -758,18 +830,21 @@ func (st *Local) GenerateSingleVanillaProof(ctx context.Context, minerID abi.Act
 
 	var cache string
 	var sealed string
+	var storageID storiface.ID
 	if si.Update {
-		src, _, err := st.AcquireSector(ctx, sr, storiface.FTUpdate|storiface.FTUpdateCache, storiface.FTNone, storiface.PathStorage, storiface.AcquireMove)
+		src, ids, err := st.AcquireSector(ctx, sr, storiface.FTUpdate|storiface.FTUpdateCache, storiface.FTNone, storiface.PathStorage, storiface.AcquireMove)
 		if err != nil {
 			return nil, xerrors.Errorf("acquire sector: %w", err)
 		}
 		cache, sealed = src.UpdateCache, src.Update
+		storageID = storiface.ID(ids.Update)
 	} else {
-		src, _, err := st.AcquireSector(ctx, sr, storiface.FTSealed|storiface.FTCache, storiface.FTNone, storiface.PathStorage, storiface.AcquireMove)
+		src, ids, err := st.AcquireSector(ctx, sr, storiface.FTSealed|storiface.FTCache, storiface.FTNone, storiface.PathStorage, storiface.AcquireMove)
 		if err != nil {
 			return nil, xerrors.Errorf("acquire sector: %w", err)
 		}
 		cache, sealed = src.Cache, src.Sealed
+		storageID = storiface.ID(ids.Sealed)
 	}
 
 	if sealed == "" || cache == "" {
@@ -787,7 +862,16 @@ func (st *Local) GenerateSingleVanillaProof(ctx context.Context, minerID abi.Act
 		SealedSectorPath: sealed,
 	}
 
-	return ffi.GenerateSingleVanillaProof(psi, si.Challenge)
+	start := time.Now()
+	vanilla, err := ffi.GenerateSingleVanillaProof(psi, si.Challenge)
+
+	st.localLk.RLock()
+	if p, ok := st.paths[storageID]; ok {
+		p.recordRead(time.Since(start), err)
+	}
+	st.localLk.RUnlock()
+
+	return vanilla, err
 }
 
 var _ Store = &Local{}
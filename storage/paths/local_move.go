@@ -0,0 +1,300 @@
+package paths
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/time/rate"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+// copyChunk is the size of the writes throttled when moving sector files
+const copyChunk = 1 << 20
+
+// moveLockWait is how long a move waits for the reads of the moved files to
+// finish before the copies replace them
+var moveLockWait = time.Minute
+
+// SetDrain sets the drain flag of a local path, persisted in its metadata file
+func (st *Local) SetDrain(ctx context.Context, id storiface.ID, drain bool) error {
+	st.localLk.Lock()
+	defer st.localLk.Unlock()
+
+	p, ok := st.paths[id]
+	if !ok {
+		return xerrors.Errorf("path %s not found", id)
+	}
+
+	metaPath := filepath.Join(p.local, MetaFile)
+	mb, err := ioutil.ReadFile(metaPath)
+	if err != nil {
+		return xerrors.Errorf("reading storage metadata for %s: %w", p.local, err)
+	}
+
+	var meta LocalStorageMeta
+	if err := json.Unmarshal(mb, &meta); err != nil {
+		return xerrors.Errorf("unmarshalling storage metadata for %s: %w", p.local, err)
+	}
+
+	meta.Drain = drain
+
+	mb, err = json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return xerrors.Errorf("marshaling storage metadata: %w", err)
+	}
+	if err := ioutil.WriteFile(metaPath, mb, 0644); err != nil { // nolint
+		return xerrors.Errorf("writing storage metadata for %s: %w", p.local, err)
+	}
+
+	info, err := st.index.StorageInfo(ctx, id)
+	if err != nil {
+		return xerrors.Errorf("getting storage info: %w", err)
+	}
+	info.Drain = drain
+
+	fst, err := p.stat(st.localStorage)
+	if err != nil {
+		return err
+	}
+
+	if err := st.index.StorageAttach(ctx, info, fst); err != nil {
+		return xerrors.Errorf("redeclaring storage in index: %w", err)
+	}
+
+	return nil
+}
+
+// SectorDiskUsage returns the disk space used by the files of a sector in a
+// local path
+func (st *Local) SectorDiskUsage(id storiface.ID, sid abi.SectorID, types storiface.SectorFileType) (int64, error) {
+	st.localLk.RLock()
+	defer st.localLk.RUnlock()
+
+	p, ok := st.paths[id]
+	if !ok {
+		return 0, xerrors.Errorf("path %s not found", id)
+	}
+
+	var used int64
+	for _, fileType := range storiface.PathTypes {
+		if fileType&types == 0 {
+			continue
+		}
+
+		u, err := st.localStorage.DiskUsage(p.sectorPath(sid, fileType))
+		if err != nil {
+			return 0, xerrors.Errorf("getting disk usage of %s: %w", fileType, err)
+		}
+		used += u
+	}
+	return used, nil
+}
+
+// MoveSector copies the files of a sector between two local paths, then
+// replaces the source files with the copies. The copy is throttled by limiter
+// when it isn't nil, progress is called with the number of bytes copied.
+func (st *Local) MoveSector(ctx context.Context, sid abi.SectorID, types storiface.SectorFileType, from, to storiface.ID, limiter *rate.Limiter, progress func(int64)) error {
+	st.localLk.RLock()
+	src, srcOk := st.paths[from]
+	dst, dstOk := st.paths[to]
+	st.localLk.RUnlock()
+
+	if !srcOk || !dstOk {
+		return xerrors.Errorf("moving sector %d from %s to %s: path not found", sid, from, to)
+	}
+
+	// the files aren't removed while they're copied, but can still be read
+	rctx, cancel := context.WithCancel(ctx)
+	locked, err := st.index.StorageTryLock(rctx, sid, types, storiface.FTNone)
+	if err != nil {
+		cancel()
+		return xerrors.Errorf("acquiring read lock: %w", err)
+	}
+	if !locked {
+		cancel()
+		return xerrors.Errorf("sector %d files in use", sid)
+	}
+
+	temps := map[storiface.SectorFileType]string{}
+	removeTemps := func() {
+		for _, t := range temps {
+			if err := os.RemoveAll(t); err != nil {
+				log.Errorw("removing moved sector temp files", "path", t, "error", err)
+			}
+		}
+	}
+
+	var copied int64
+	for _, fileType := range storiface.PathTypes {
+		if fileType&types == 0 {
+			continue
+		}
+
+		temp, err := tempFetchDest(dst.sectorPath(sid, fileType), true)
+		if err != nil {
+			cancel()
+			removeTemps()
+			return err
+		}
+		temps[fileType] = temp
+
+		err = copySectorFiles(ctx, src.sectorPath(sid, fileType), temp, limiter, func(n int64) {
+			copied += n
+			if progress != nil {
+				progress(copied)
+			}
+		})
+		if err != nil {
+			cancel()
+			removeTemps()
+			return xerrors.Errorf("copying sector %d (%s): %w", sid, fileType, err)
+		}
+	}
+	cancel()
+
+	// the copies replace the source files while nothing reads them
+	wctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// the read lock is released in the background, and reads can be in progress
+	deadline := time.Now().Add(moveLockWait)
+	for {
+		locked, err = st.index.StorageTryLock(wctx, sid, storiface.FTNone, types)
+		if err != nil {
+			removeTemps()
+			return xerrors.Errorf("acquiring write lock: %w", err)
+		}
+		if locked {
+			break
+		}
+		if time.Now().After(deadline) {
+			removeTemps()
+			return xerrors.Errorf("sector %d files in use", sid)
+		}
+
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+			removeTemps()
+			return ctx.Err()
+		}
+	}
+
+	for _, fileType := range storiface.PathTypes {
+		if fileType&types == 0 {
+			continue
+		}
+
+		if err := move(temps[fileType], dst.sectorPath(sid, fileType)); err != nil {
+			removeTemps()
+			return xerrors.Errorf("moving sector %d (%s) copy in place: %w", sid, fileType, err)
+		}
+
+		if err := st.index.StorageDeclareSector(ctx, to, sid, fileType, true); err != nil {
+			return xerrors.Errorf("declare sector %d(t:%d) -> %s: %w", sid, fileType, to, err)
+		}
+		if err := st.index.StorageDropSector(ctx, from, sid, fileType); err != nil {
+			return xerrors.Errorf("dropping source sector from index: %w", err)
+		}
+		if err := os.RemoveAll(src.sectorPath(sid, fileType)); err != nil {
+			log.Errorw("removing moved sector source files", "sector", sid, "type", fileType, "path", from, "error", err)
+		}
+		delete(temps, fileType)
+	}
+
+	st.reportStorage(ctx) // report space use changes
+
+	return nil
+}
+
+// copySectorFiles copies a sector file, or a directory of sector files
+func copySectorFiles(ctx context.Context, from, to string, limiter *rate.Limiter, progress func(int64)) error {
+	fi, err := os.Stat(from)
+	if err != nil {
+		return err
+	}
+
+	if fi.IsDir() {
+		if err := os.MkdirAll(to, 0755); err != nil { // nolint
+			return err
+		}
+
+		ents, err := ioutil.ReadDir(from)
+		if err != nil {
+			return err
+		}
+		for _, ent := range ents {
+			if err := copySectorFiles(ctx, filepath.Join(from, ent.Name()), filepath.Join(to, ent.Name()), limiter, progress); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close() // nolint
+
+	out, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fi.Mode())
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, copyChunk)
+	for {
+		if ctx.Err() != nil {
+			_ = out.Close()
+			return ctx.Err()
+		}
+
+		n, rerr := in.Read(buf)
+		if n > 0 {
+			if limiter != nil {
+				if err := waitN(ctx, limiter, n); err != nil {
+					_ = out.Close()
+					return err
+				}
+			}
+			if _, err := out.Write(buf[:n]); err != nil {
+				_ = out.Close()
+				return err
+			}
+			progress(int64(n))
+		}
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			_ = out.Close()
+			return rerr
+		}
+	}
+
+	return out.Close()
+}
+
+// waitN waits for n tokens, in bursts the limiter allows
+func waitN(ctx context.Context, limiter *rate.Limiter, n int) error {
+	for n > 0 {
+		c := n
+		if c > limiter.Burst() {
+			c = limiter.Burst()
+		}
+		if err := limiter.WaitN(ctx, c); err != nil {
+			return err
+		}
+		n -= c
+	}
+	return nil
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 
+	"github.com/filecoin-project/go-state-types/abi"
+
 	"github.com/filecoin-project/lotus/storage/sealer/fsutil"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
 )
@@ -94,3 +96,137 @@ func TestLocalStorage(t *testing.T) {
 
 	// TODO: put more things here
 }
+
+func TestLocalMoveSector(t *testing.T) {
+	ctx := context.TODO()
+
+	tstor := &TestingLocalStorage{
+		root: t.TempDir(),
+	}
+
+	index := NewIndex(nil)
+
+	st, err := NewLocal(ctx, tstor, index, nil)
+	require.NoError(t, err)
+
+	ids := map[string]storiface.ID{}
+	for _, p := range []string{"1", "2"} {
+		require.NoError(t, tstor.init(p))
+		require.NoError(t, st.OpenPath(ctx, filepath.Join(tstor.root, p)))
+	}
+	local, err := st.Local(ctx)
+	require.NoError(t, err)
+	for _, p := range local {
+		ids[filepath.Base(p.LocalPath)] = p.ID
+	}
+
+	sid := abi.SectorID{Miner: 1000, Number: 1}
+	name := storiface.SectorName(sid)
+
+	src := filepath.Join(tstor.root, "1")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, storiface.FTSealed.String()), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(src, storiface.FTCache.String(), name), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(src, storiface.FTSealed.String(), name), []byte("sealed"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(src, storiface.FTCache.String(), name, "p_aux"), []byte("aux"), 0644))
+
+	types := storiface.FTSealed | storiface.FTCache
+	require.NoError(t, index.StorageDeclareSector(ctx, ids["1"], sid, storiface.FTSealed, true))
+	require.NoError(t, index.StorageDeclareSector(ctx, ids["1"], sid, storiface.FTCache, true))
+
+	var copied int64
+	require.NoError(t, st.MoveSector(ctx, sid, types, ids["1"], ids["2"], nil, func(n int64) {
+		copied = n
+	}))
+	require.Equal(t, int64(len("sealed")+len("aux")), copied)
+
+	dst := filepath.Join(tstor.root, "2")
+	b, err := ioutil.ReadFile(filepath.Join(dst, storiface.FTCache.String(), name, "p_aux"))
+	require.NoError(t, err)
+	require.Equal(t, "aux", string(b))
+	_, err = os.Stat(filepath.Join(src, storiface.FTSealed.String(), name))
+	require.True(t, os.IsNotExist(err))
+
+	found, err := index.StorageFindSector(ctx, sid, storiface.FTSealed, 0, false)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	require.Equal(t, ids["2"], found[0].ID)
+
+	// no new sectors in draining paths
+	require.NoError(t, st.SetDrain(ctx, ids["2"], true))
+	best, err := index.StorageBestAlloc(ctx, storiface.FTSealed, 1, storiface.PathStorage)
+	require.NoError(t, err)
+	require.Len(t, best, 1)
+	require.Equal(t, ids["1"], best[0].ID)
+
+	mb, err := ioutil.ReadFile(filepath.Join(dst, MetaFile))
+	require.NoError(t, err)
+	var meta LocalStorageMeta
+	require.NoError(t, json.Unmarshal(mb, &meta))
+	require.True(t, meta.Drain)
+}
+
+func TestLocalAllocationPolicy(t *testing.T) {
+	ctx := context.TODO()
+
+	for _, tc := range []struct {
+		policy AllocationPolicy
+		groups map[string][]string
+		fails  bool
+	}{
+		{policy: AllocSameGroup, groups: map[string][]string{"1": {"a"}, "2": {"b"}, "3": {"a"}}},
+		{policy: AllocSpreadGroups, groups: map[string][]string{"1": {"a"}, "2": {"a"}, "3": {"b"}}},
+		{policy: AllocSpreadGroups, groups: map[string][]string{"1": {"a"}, "2": {"a"}}, fails: true},
+	} {
+		tc := tc
+		t.Run(string(tc.policy), func(t *testing.T) {
+			tstor := &TestingLocalStorage{
+				root: t.TempDir(),
+			}
+
+			index := NewIndex(nil)
+
+			st, err := NewLocal(ctx, tstor, index, nil)
+			require.NoError(t, err)
+			require.NoError(t, st.SetAllocationPolicy(tc.policy))
+
+			for p, groups := range tc.groups {
+				require.NoError(t, tstor.init(p))
+
+				// set the groups in the metadata before the path is opened
+				metaFile := filepath.Join(tstor.root, p, MetaFile)
+				mb, err := ioutil.ReadFile(metaFile)
+				require.NoError(t, err)
+				var meta LocalStorageMeta
+				require.NoError(t, json.Unmarshal(mb, &meta))
+				meta.Groups = groups
+				mb, err = json.Marshal(meta)
+				require.NoError(t, err)
+				require.NoError(t, ioutil.WriteFile(metaFile, mb, 0644))
+
+				require.NoError(t, st.OpenPath(ctx, filepath.Join(tstor.root, p)))
+			}
+
+			sid := storiface.SectorRef{
+				ID:        abi.SectorID{Miner: 1000, Number: 1},
+				ProofType: abi.RegisteredSealProof_StackedDrg2KiBV1,
+			}
+
+			_, ids, err := st.AcquireSector(ctx, sid, storiface.FTNone, storiface.FTSealed|storiface.FTCache, storiface.PathStorage, storiface.AcquireMove)
+			if tc.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			sealed, err := index.StorageInfo(ctx, storiface.ID(ids.Sealed))
+			require.NoError(t, err)
+			cache, err := index.StorageInfo(ctx, storiface.ID(ids.Cache))
+			require.NoError(t, err)
+
+			shared := placement{id: sealed.ID, groups: sealed.Groups}.shares(placement{id: cache.ID, groups: cache.Groups})
+			require.Equal(t, tc.policy == AllocSameGroup, shared)
+		})
+	}
+
+	require.Error(t, AllocationPolicy("rack").Validate())
+}
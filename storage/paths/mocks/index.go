@@ -39,6 +39,21 @@ func (m *MockSectorIndex) EXPECT() *MockSectorIndexMockRecorder {
 	return m.recorder
 }
 
+// StorageAtRiskSectors mocks base method.
+func (m *MockSectorIndex) StorageAtRiskSectors(arg0 context.Context) ([]storiface.AtRiskSector, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StorageAtRiskSectors", arg0)
+	ret0, _ := ret[0].([]storiface.AtRiskSector)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StorageAtRiskSectors indicates an expected call of StorageAtRiskSectors.
+func (mr *MockSectorIndexMockRecorder) StorageAtRiskSectors(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StorageAtRiskSectors", reflect.TypeOf((*MockSectorIndex)(nil).StorageAtRiskSectors), arg0)
+}
+
 // StorageAttach mocks base method.
 func (m *MockSectorIndex) StorageAttach(arg0 context.Context, arg1 storiface.StorageInfo, arg2 fsutil.FsStat) error {
 	m.ctrl.T.Helper()
@@ -126,6 +141,21 @@ func (mr *MockSectorIndexMockRecorder) StorageGetLocks(arg0 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StorageGetLocks", reflect.TypeOf((*MockSectorIndex)(nil).StorageGetLocks), arg0)
 }
 
+// StorageHealth mocks base method.
+func (m *MockSectorIndex) StorageHealth(arg0 context.Context) ([]storiface.PathHealth, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StorageHealth", arg0)
+	ret0, _ := ret[0].([]storiface.PathHealth)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StorageHealth indicates an expected call of StorageHealth.
+func (mr *MockSectorIndexMockRecorder) StorageHealth(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StorageHealth", reflect.TypeOf((*MockSectorIndex)(nil).StorageHealth), arg0)
+}
+
 // StorageInfo mocks base method.
 func (m *MockSectorIndex) StorageInfo(arg0 context.Context, arg1 storiface.ID) (storiface.StorageInfo, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,94 @@
+package sealing
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/storage/pipeline/sealiface"
+)
+
+// batchPlanInput is the state of a batcher which its plan is made from
+type batchPlanInput struct {
+	now  time.Time
+	mode sealiface.BatchMode
+
+	pending int
+	cutoff  time.Time
+	// sendAt is when the batcher timer fires next
+	sendAt time.Time
+
+	minBatch, maxBatch int
+	// noBatch is the reason why the sectors can't be batched at all, if any;
+	// it takes precedence over the mode
+	noBatch string
+
+	baseFee abi.TokenAmount
+	// batchAboveBaseFee is the base fee below which individual messages are
+	// cheaper than batches, zero to always batch
+	batchAboveBaseFee abi.TokenAmount
+
+	batchFee      func(nSectors int) abi.TokenAmount
+	individualFee abi.TokenAmount
+}
+
+// planBatch decides how, and when, the pending sectors of a batcher are sent.
+// The batchers follow the plan when they process the pending sectors.
+func planBatch(in batchPlanInput) sealiface.BatchPlan {
+	plan := sealiface.BatchPlan{
+		Now:     in.now,
+		Mode:    in.mode,
+		Pending: in.pending,
+		SendAt:  in.sendAt,
+		Cutoff:  in.cutoff,
+		BaseFee: in.baseFee,
+		MaxFee:  big.Zero(),
+	}
+
+	if in.pending == 0 {
+		plan.Reason = "no pending sectors"
+		return plan
+	}
+
+	switch {
+	case in.noBatch != "":
+		plan.Individual = true
+		plan.Reason = in.noBatch
+	case in.mode == sealiface.BatchIndividual:
+		plan.Individual = true
+		plan.Reason = "individual messages forced"
+	case in.mode == sealiface.BatchAlways:
+		plan.Reason = "batching forced"
+	case in.pending < in.minBatch:
+		plan.Individual = true
+		plan.Reason = fmt.Sprintf("%d pending sectors, below the minimum batch size of %d", in.pending, in.minBatch)
+	case !in.batchAboveBaseFee.IsZero() && in.baseFee.LessThan(in.batchAboveBaseFee):
+		plan.Individual = true
+		plan.Reason = fmt.Sprintf("base fee %s is below the batching threshold of %s", in.baseFee, in.batchAboveBaseFee)
+	case in.batchAboveBaseFee.IsZero():
+		plan.Reason = "batching enabled"
+	default:
+		plan.Reason = fmt.Sprintf("base fee %s is above the batching threshold of %s", in.baseFee, in.batchAboveBaseFee)
+	}
+
+	if plan.Individual {
+		plan.BatchSize = 1
+		plan.MaxFee = big.Mul(in.individualFee, big.NewInt(int64(in.pending)))
+	} else {
+		plan.BatchSize = in.pending
+		if in.maxBatch > 0 && plan.BatchSize > in.maxBatch {
+			plan.BatchSize = in.maxBatch
+		}
+		plan.MaxFee = in.batchFee(plan.BatchSize)
+	}
+
+	if in.maxBatch > 0 && in.pending >= in.maxBatch {
+		// full batches are sent as soon as they fill up
+		plan.SendAt = in.now
+		plan.Reason += ", batch full"
+	}
+
+	return plan
+}
@@ -0,0 +1,91 @@
+//stm: #unit
+package sealing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+
+	"github.com/filecoin-project/lotus/storage/pipeline/sealiface"
+)
+
+func TestPlanBatch(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+
+	in := func(pending int, baseFee int64, mode sealiface.BatchMode) batchPlanInput {
+		return batchPlanInput{
+			now:     now,
+			mode:    mode,
+			pending: pending,
+			sendAt:  now.Add(time.Hour),
+
+			minBatch: 4,
+			maxBatch: 10,
+
+			baseFee:           big.NewInt(baseFee),
+			batchAboveBaseFee: big.NewInt(100),
+
+			batchFee: func(n int) abi.TokenAmount {
+				return big.NewInt(int64(1000 + 10*n))
+			},
+			individualFee: big.NewInt(50),
+		}
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		plan := planBatch(in(0, 200, sealiface.BatchAuto))
+		require.Equal(t, 0, plan.BatchSize)
+		require.Equal(t, "no pending sectors", plan.Reason)
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		plan := planBatch(in(5, 200, sealiface.BatchAuto))
+		require.False(t, plan.Individual)
+		require.Equal(t, 5, plan.BatchSize)
+		require.Equal(t, now.Add(time.Hour), plan.SendAt)
+		require.Equal(t, big.NewInt(1050), plan.MaxFee)
+	})
+
+	t.Run("full", func(t *testing.T) {
+		plan := planBatch(in(12, 200, sealiface.BatchAuto))
+		require.False(t, plan.Individual)
+		require.Equal(t, 10, plan.BatchSize)
+		require.Equal(t, now, plan.SendAt)
+	})
+
+	t.Run("below-min", func(t *testing.T) {
+		plan := planBatch(in(3, 200, sealiface.BatchAuto))
+		require.True(t, plan.Individual)
+		require.Equal(t, big.NewInt(150), plan.MaxFee)
+
+		plan = planBatch(in(3, 200, sealiface.BatchAlways))
+		require.False(t, plan.Individual)
+		require.Equal(t, 3, plan.BatchSize)
+	})
+
+	t.Run("low-basefee", func(t *testing.T) {
+		plan := planBatch(in(5, 50, sealiface.BatchAuto))
+		require.True(t, plan.Individual)
+		require.Equal(t, 1, plan.BatchSize)
+
+		plan = planBatch(in(5, 50, sealiface.BatchAlways))
+		require.False(t, plan.Individual)
+	})
+
+	t.Run("forced-individual", func(t *testing.T) {
+		plan := planBatch(in(5, 200, sealiface.BatchIndividual))
+		require.True(t, plan.Individual)
+	})
+
+	t.Run("no-batch", func(t *testing.T) {
+		i := in(5, 200, sealiface.BatchAlways)
+		i.noBatch = "blacked out"
+		plan := planBatch(i)
+		require.True(t, plan.Individual)
+		require.Equal(t, "blacked out", plan.Reason)
+	})
+}
@@ -3,6 +3,7 @@ package sealing
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"sort"
 	"sync"
 	"time"
@@ -56,7 +57,7 @@ type CommitBatcher struct {
 	maddr     address.Address
 	mctx      context.Context
 	addrSel   AddrSel
-	feeCfg    config.MinerFeeConfig
+	feeCfg    config.GetMinerFeeConfigFunc
 	getConfig GetSealingConfigFunc
 	prover    storiface.Prover
 
@@ -64,12 +65,15 @@ type CommitBatcher struct {
 	todo    map[abi.SectorNumber]AggregateInput
 	waiting map[abi.SectorNumber][]chan sealiface.CommitBatchRes
 
+	mode   sealiface.BatchMode
+	sendAt time.Time
+
 	notify, stop, stopped chan struct{}
 	force                 chan chan []sealiface.CommitBatchRes
 	lk                    sync.Mutex
 }
 
-func NewCommitBatcher(mctx context.Context, maddr address.Address, api CommitBatcherApi, addrSel AddrSel, feeCfg config.MinerFeeConfig, getConfig GetSealingConfigFunc, prov storiface.Prover) *CommitBatcher {
+func NewCommitBatcher(mctx context.Context, maddr address.Address, api CommitBatcherApi, addrSel AddrSel, feeCfg config.GetMinerFeeConfigFunc, getConfig GetSealingConfigFunc, prov storiface.Prover) *CommitBatcher {
 	b := &CommitBatcher{
 		api:       api,
 		maddr:     maddr,
@@ -83,6 +87,8 @@ func NewCommitBatcher(mctx context.Context, maddr address.Address, api CommitBat
 		todo:    map[abi.SectorNumber]AggregateInput{},
 		waiting: map[abi.SectorNumber][]chan sealiface.CommitBatchRes{},
 
+		mode: sealiface.BatchAuto,
+
 		notify:  make(chan struct{}, 1),
 		force:   make(chan chan []sealiface.CommitBatchRes),
 		stop:    make(chan struct{}),
@@ -142,16 +148,40 @@ func (b *CommitBatcher) run() {
 	}
 }
 
-func (b *CommitBatcher) batchWait(maxWait, slack time.Duration) time.Duration {
+func (b *CommitBatcher) batchWait(maxWait, slack time.Duration) (wait time.Duration) {
 	now := time.Now()
 
 	b.lk.Lock()
 	defer b.lk.Unlock()
+	defer func() {
+		b.sendAt = now.Add(wait)
+	}()
 
 	if len(b.todo) == 0 {
 		return maxWait
 	}
 
+	cutoff := b.cutoff()
+	if cutoff.IsZero() {
+		return maxWait
+	}
+
+	cutoff = cutoff.Add(-slack)
+	if cutoff.Before(now) {
+		return time.Nanosecond // can't return 0
+	}
+
+	wait = cutoff.Sub(now)
+	if wait > maxWait {
+		wait = maxWait
+	}
+
+	return wait
+}
+
+// cutoff returns the earliest cutoff of the pending sectors, must be called
+// with b.lk held
+func (b *CommitBatcher) cutoff() time.Time {
 	var cutoff time.Time
 	for sn := range b.todo {
 		sectorCutoff := b.cutoffs[sn]
@@ -165,22 +195,42 @@ func (b *CommitBatcher) batchWait(maxWait, slack time.Duration) time.Duration {
 			cutoff = sectorCutoff
 		}
 	}
+	return cutoff
+}
 
-	if cutoff.IsZero() {
-		return maxWait
-	}
-
-	cutoff = cutoff.Add(-slack)
-	if cutoff.Before(now) {
-		return time.Nanosecond // can't return 0
-	}
-
-	wait := cutoff.Sub(now)
-	if wait > maxWait {
-		wait = maxWait
-	}
+// plan must be called with b.lk held
+func (b *CommitBatcher) plan(cfg sealiface.Config, ts *types.TipSet) sealiface.BatchPlan {
+	var noBatch string
+	switch {
+	case len(b.todo) < miner.MinAggregatedSectors:
+		noBatch = fmt.Sprintf("%d pending sectors, aggregates need at least %d", len(b.todo), miner.MinAggregatedSectors)
+	case nv16BlackedOut(ts.Height()):
+		noBatch = "aggregation is blacked out around the nv16 upgrade"
+	}
+
+	feeCfg := b.feeCfg()
+	return planBatch(batchPlanInput{
+		now:     time.Now(),
+		mode:    b.mode,
+		pending: len(b.todo),
+		cutoff:  b.cutoff(),
+		sendAt:  b.sendAt,
+
+		minBatch: cfg.MinCommitBatch,
+		maxBatch: cfg.MaxCommitBatch,
+		noBatch:  noBatch,
+
+		baseFee:           ts.MinTicketBlock().ParentBaseFee,
+		batchAboveBaseFee: cfg.AggregateAboveBaseFee,
+
+		batchFee:      feeCfg.MaxCommitBatchGasFee.FeeForSectors,
+		individualFee: big.Int(feeCfg.MaxCommitGasFee),
+	})
+}
 
-	return wait
+func nv16BlackedOut(height abi.ChainEpoch) bool {
+	const nv16BlackoutWindow = abi.ChainEpoch(20) // a magik number
+	return height <= build.UpgradeSkyrHeight && build.UpgradeSkyrHeight-height < nv16BlackoutWindow
 }
 
 func (b *CommitBatcher) maybeStartBatch(notif bool) ([]sealiface.CommitBatchRes, error) {
@@ -208,22 +258,7 @@ func (b *CommitBatcher) maybeStartBatch(notif bool) ([]sealiface.CommitBatchRes,
 		return nil, err
 	}
 
-	blackedOut := func() bool {
-		const nv16BlackoutWindow = abi.ChainEpoch(20) // a magik number
-		if ts.Height() <= build.UpgradeSkyrHeight && build.UpgradeSkyrHeight-ts.Height() < nv16BlackoutWindow {
-			return true
-		}
-		return false
-	}
-
-	individual := (total < cfg.MinCommitBatch) || (total < miner.MinAggregatedSectors) || blackedOut()
-
-	if !individual && !cfg.AggregateAboveBaseFee.Equals(big.Zero()) {
-		if ts.MinTicketBlock().ParentBaseFee.LessThan(cfg.AggregateAboveBaseFee) {
-			individual = true
-		}
-	}
-
+	individual := b.plan(cfg, ts).Individual
 	if individual {
 		res, err = b.processIndividually(cfg)
 	} else {
@@ -345,7 +380,8 @@ func (b *CommitBatcher) processBatch(cfg sealiface.Config) ([]sealiface.CommitBa
 		return []sealiface.CommitBatchRes{res}, xerrors.Errorf("couldn't get miner info: %w", err)
 	}
 
-	maxFee := b.feeCfg.MaxCommitBatchGasFee.FeeForSectors(len(infos))
+	feeCfg := b.feeCfg()
+	maxFee := feeCfg.MaxCommitBatchGasFee.FeeForSectors(len(infos))
 
 	aggFeeRaw, err := policy.AggregateProveCommitNetworkFee(nv, len(infos), ts.MinTicketBlock().ParentBaseFee)
 	if err != nil {
@@ -456,14 +492,14 @@ func (b *CommitBatcher) processSingle(cfg sealiface.Config, mi api.MinerInfo, av
 		}
 	}
 
-	goodFunds := big.Add(collateral, big.Int(b.feeCfg.MaxCommitGasFee))
+	goodFunds := big.Add(collateral, big.Int(b.feeCfg().MaxCommitGasFee))
 
 	from, _, err := b.addrSel(b.mctx, mi, api.CommitAddr, goodFunds, collateral)
 	if err != nil {
 		return cid.Undef, xerrors.Errorf("no good address to send commit message from: %w", err)
 	}
 
-	mcid, err := sendMsg(b.mctx, b.api, from, b.maddr, builtin.MethodsMiner.ProveCommitSector, collateral, big.Int(b.feeCfg.MaxCommitGasFee), enc.Bytes())
+	mcid, err := sendMsg(b.mctx, b.api, from, b.maddr, builtin.MethodsMiner.ProveCommitSector, collateral, big.Int(b.feeCfg().MaxCommitGasFee), enc.Bytes())
 	if err != nil {
 		return cid.Undef, xerrors.Errorf("pushing message to mpool: %w", err)
 	}
@@ -544,6 +580,37 @@ func (b *CommitBatcher) Pending(ctx context.Context) ([]abi.SectorID, error) {
 	return res, nil
 }
 
+// Plan returns how the pending sectors will be sent
+func (b *CommitBatcher) Plan(ctx context.Context) (sealiface.BatchPlan, error) {
+	cfg, err := b.getConfig()
+	if err != nil {
+		return sealiface.BatchPlan{}, xerrors.Errorf("getting config: %w", err)
+	}
+
+	ts, err := b.api.ChainHead(ctx)
+	if err != nil {
+		return sealiface.BatchPlan{}, xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	b.lk.Lock()
+	defer b.lk.Unlock()
+
+	return b.plan(cfg, ts), nil
+}
+
+// SetMode overrides the choice between aggregates and individual messages
+func (b *CommitBatcher) SetMode(mode sealiface.BatchMode) error {
+	if !mode.Valid() {
+		return xerrors.Errorf("unknown batching mode %q", mode)
+	}
+
+	b.lk.Lock()
+	defer b.lk.Unlock()
+
+	b.mode = mode
+	return nil
+}
+
 func (b *CommitBatcher) Stop(ctx context.Context) error {
 	close(b.stop)
 
@@ -364,7 +364,7 @@ func TestCommitBatcher(t *testing.T) {
 			// create them mocks
 			pcapi := mocks.NewMockCommitBatcherApi(mockCtrl)
 
-			pcb := pipeline.NewCommitBatcher(ctx, t0123, pcapi, as, fc, cfg, &fakeProver{})
+			pcb := pipeline.NewCommitBatcher(ctx, t0123, pcapi, as, feeCfg, cfg, &fakeProver{})
 
 			var promises []promise
 
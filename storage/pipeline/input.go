@@ -8,6 +8,7 @@ import (
 	"github.com/ipfs/go-cid"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-commp-utils/zerocomm"
 	"github.com/filecoin-project/go-padreader"
 	"github.com/filecoin-project/go-state-types/abi"
@@ -71,6 +72,7 @@ func (m *Sealing) handleWaitDeals(ctx statemachine.Context, sector SectorInfo) e
 		// (note that m.assignedPieces[sid] will always be empty here)
 		m.openSectors[sid].used = used
 	}
+	m.openSectors[sid].deals = len(sector.dealIDs())
 
 	go func() {
 		defer m.inputLk.Unlock()
@@ -98,7 +100,12 @@ func (m *Sealing) maybeStartSealing(ctx statemachine.Context, sector SectorInfo,
 		return false, xerrors.Errorf("getting sector size")
 	}
 
-	maxDeals, err := getDealPerSectorLimit(ssize)
+	cfg, err := m.getConfig()
+	if err != nil {
+		return false, xerrors.Errorf("getting storage config: %w", err)
+	}
+
+	maxDeals, err := dealPerSectorLimit(cfg, ssize)
 	if err != nil {
 		return false, xerrors.Errorf("getting per-sector deal limit: %w", err)
 	}
@@ -115,12 +122,12 @@ func (m *Sealing) maybeStartSealing(ctx statemachine.Context, sector SectorInfo,
 		return true, ctx.Send(SectorStartPacking{})
 	}
 
-	if sector.CreationTime != 0 {
-		cfg, err := m.getConfig()
-		if err != nil {
-			return false, xerrors.Errorf("getting storage config: %w", err)
-		}
+	if cfg.TargetSectorFillPercent > 0 && uint64(used.Padded())*100 >= uint64(ssize)*cfg.TargetSectorFillPercent {
+		log.Infow("starting to seal deal sector", "sector", sector.SectorNumber, "trigger", "fill-target")
+		return true, ctx.Send(SectorStartPacking{})
+	}
 
+	if sector.CreationTime != 0 {
 		sealTime := time.Unix(sector.CreationTime, 0).Add(cfg.WaitDealsDelay)
 
 		// check deal age, start sealing when the deal closest to starting is within slack time
@@ -184,11 +191,23 @@ func (m *Sealing) handleAddPiece(ctx statemachine.Context, sector SectorInfo) er
 		offset += p.Piece.Size.Unpadded()
 	}
 
-	maxDeals, err := getDealPerSectorLimit(ssize)
+	cfg, err := m.getConfig()
+	if err != nil {
+		return xerrors.Errorf("getting storage config: %w", err)
+	}
+
+	maxDeals, err := dealPerSectorLimit(cfg, ssize)
 	if err != nil {
 		return xerrors.Errorf("getting per-sector deal limit: %w", err)
 	}
 
+	// the sector gets deals with the pieces
+	dealClass := sealer.PriorityDeals
+	if sector.CCUpdate {
+		dealClass = sealer.PrioritySnapDeals
+	}
+	dealCtx := sealer.WithPriorityClass(ctx.Context(), dealClass)
+
 	for i, piece := range pending {
 		m.inputLk.Lock()
 		deal, ok := m.pendingPieces[piece]
@@ -216,7 +235,7 @@ func (m *Sealing) handleAddPiece(ctx statemachine.Context, sector SectorInfo) er
 		for _, p := range pads {
 			expectCid := zerocomm.ZeroPieceCommitment(p.Unpadded())
 
-			ppi, err := m.sealer.AddPiece(sealer.WithPriority(ctx.Context(), DealSectorPriority),
+			ppi, err := m.sealer.AddPiece(dealCtx,
 				m.minerSector(sector.SectorType, sector.SectorNumber),
 				pieceSizes,
 				p.Unpadded(),
@@ -238,7 +257,7 @@ func (m *Sealing) handleAddPiece(ctx statemachine.Context, sector SectorInfo) er
 			})
 		}
 
-		ppi, err := m.sealer.AddPiece(sealer.WithPriority(ctx.Context(), DealSectorPriority),
+		ppi, err := m.sealer.AddPiece(dealCtx,
 			m.minerSector(sector.SectorType, sector.SectorNumber),
 			pieceSizes,
 			deal.size,
@@ -415,6 +434,24 @@ func (m *Sealing) updateInput(ctx context.Context, sp abi.RegisteredSealProof) e
 		return err
 	}
 
+	cfg, err := m.getConfig()
+	if err != nil {
+		return xerrors.Errorf("getting storage config: %w", err)
+	}
+
+	maxDeals, err := dealPerSectorLimit(cfg, ssize)
+	if err != nil {
+		return xerrors.Errorf("getting per-sector deal limit: %w", err)
+	}
+
+	// deals of each client which are being added to sectors
+	inFlight := map[address.Address]uint64{}
+	for _, piece := range m.pendingPieces {
+		if piece.assigned && !piece.done() {
+			inFlight[piece.deal.DealProposal.Client]++
+		}
+	}
+
 	type match struct {
 		sector abi.SectorID
 		deal   cid.Cid
@@ -433,9 +470,17 @@ func (m *Sealing) updateInput(ctx context.Context, sp abi.RegisteredSealProof) e
 			continue // already assigned to a sector, skip
 		}
 
+		if cfg.MaxConcurrentDealsPerClient > 0 && inFlight[piece.deal.DealProposal.Client] >= cfg.MaxConcurrentDealsPerClient {
+			continue // client at its concurrency limit, wait for its deals being added to finish
+		}
+
 		toAssign[proposalCid] = struct{}{}
 
 		for id, sector := range m.openSectors {
+			if sector.deals >= maxDeals {
+				continue
+			}
+
 			avail := abi.PaddedPieceSize(ssize).Unpadded() - sector.used
 			// check that sector lifetime is long enough to fit deal using latest expiration from on chain
 
@@ -488,7 +533,14 @@ func (m *Sealing) updateInput(ctx context.Context, sp abi.RegisteredSealProof) e
 
 		avail := abi.PaddedPieceSize(ssize).Unpadded() - m.openSectors[mt.sector].used
 
-		if mt.size > avail {
+		if mt.size > avail || m.openSectors[mt.sector].deals >= maxDeals {
+			continue
+		}
+
+		client := m.pendingPieces[mt.deal].deal.DealProposal.Client
+		if cfg.MaxConcurrentDealsPerClient > 0 && inFlight[client] >= cfg.MaxConcurrentDealsPerClient {
+			// the client reached its limit with the deals assigned in this round
+			delete(toAssign, mt.deal)
 			continue
 		}
 
@@ -498,6 +550,8 @@ func (m *Sealing) updateInput(ctx context.Context, sp abi.RegisteredSealProof) e
 		}
 
 		m.openSectors[mt.sector].used += mt.padding + mt.size
+		m.openSectors[mt.sector].deals++
+		inFlight[client]++
 
 		m.pendingPieces[mt.deal].assigned = true
 		delete(toAssign, mt.deal)
@@ -715,6 +769,82 @@ func (m *Sealing) StartPacking(sid abi.SectorNumber) error {
 	return m.sectors.Send(uint64(sid), SectorStartPacking{})
 }
 
+// PackingStatus returns the deal packing limits and the state of the sectors
+// open for deals and of the deals waiting to be added to sectors
+func (m *Sealing) PackingStatus(ctx context.Context) (api.DealPackingStatus, error) {
+	sp, err := m.currentSealProof(ctx)
+	if err != nil {
+		return api.DealPackingStatus{}, xerrors.Errorf("getting current seal proof type: %w", err)
+	}
+
+	ssize, err := sp.SectorSize()
+	if err != nil {
+		return api.DealPackingStatus{}, err
+	}
+
+	cfg, err := m.getConfig()
+	if err != nil {
+		return api.DealPackingStatus{}, xerrors.Errorf("getting storage config: %w", err)
+	}
+
+	maxDeals, err := dealPerSectorLimit(cfg, ssize)
+	if err != nil {
+		return api.DealPackingStatus{}, xerrors.Errorf("getting per-sector deal limit: %w", err)
+	}
+
+	out := api.DealPackingStatus{
+		SectorSize:                  ssize,
+		MaxDealsPerSector:           maxDeals,
+		TargetSectorFillPercent:     cfg.TargetSectorFillPercent,
+		MaxConcurrentDealsPerClient: cfg.MaxConcurrentDealsPerClient,
+		WaitDealsDelay:              cfg.WaitDealsDelay,
+	}
+
+	m.inputLk.Lock()
+	for _, s := range m.openSectors {
+		out.OpenSectors = append(out.OpenSectors, api.PackingSector{
+			Sector:   s.number,
+			CCUpdate: s.ccUpdate,
+			Used:     s.used.Padded(),
+			Deals:    s.deals,
+		})
+	}
+
+	clients := map[address.Address]*api.ClientPackingStatus{}
+	for _, piece := range m.pendingPieces {
+		if piece.done() {
+			continue
+		}
+
+		c, ok := clients[piece.deal.DealProposal.Client]
+		if !ok {
+			c = &api.ClientPackingStatus{Client: piece.deal.DealProposal.Client}
+			clients[c.Client] = c
+		}
+
+		if piece.assigned {
+			c.Adding++
+		} else {
+			c.Waiting++
+			out.WaitingDeals++
+		}
+	}
+	m.inputLk.Unlock()
+
+	for _, c := range clients {
+		out.Clients = append(out.Clients, *c)
+	}
+
+	sort.Slice(out.OpenSectors, func(i, j int) bool {
+		return out.OpenSectors[i].Sector < out.OpenSectors[j].Sector
+	})
+	sort.Slice(out.Clients, func(i, j int) bool {
+		return out.Clients[i].Client.String() < out.Clients[j].Client.String()
+	})
+
+	return out, nil
+}
+
 func (m *Sealing) AbortUpgrade(sid abi.SectorNumber) error {
 	m.startupWait.Wait()
 
@@ -45,19 +45,22 @@ type PreCommitBatcher struct {
 	maddr     address.Address
 	mctx      context.Context
 	addrSel   AddrSel
-	feeCfg    config.MinerFeeConfig
+	feeCfg    config.GetMinerFeeConfigFunc
 	getConfig GetSealingConfigFunc
 
 	cutoffs map[abi.SectorNumber]time.Time
 	todo    map[abi.SectorNumber]*preCommitEntry
 	waiting map[abi.SectorNumber][]chan sealiface.PreCommitBatchRes
 
+	mode   sealiface.BatchMode
+	sendAt time.Time
+
 	notify, stop, stopped chan struct{}
 	force                 chan chan []sealiface.PreCommitBatchRes
 	lk                    sync.Mutex
 }
 
-func NewPreCommitBatcher(mctx context.Context, maddr address.Address, api PreCommitBatcherApi, addrSel AddrSel, feeCfg config.MinerFeeConfig, getConfig GetSealingConfigFunc) *PreCommitBatcher {
+func NewPreCommitBatcher(mctx context.Context, maddr address.Address, api PreCommitBatcherApi, addrSel AddrSel, feeCfg config.GetMinerFeeConfigFunc, getConfig GetSealingConfigFunc) *PreCommitBatcher {
 	b := &PreCommitBatcher{
 		api:       api,
 		maddr:     maddr,
@@ -70,6 +73,8 @@ func NewPreCommitBatcher(mctx context.Context, maddr address.Address, api PreCom
 		todo:    map[abi.SectorNumber]*preCommitEntry{},
 		waiting: map[abi.SectorNumber][]chan sealiface.PreCommitBatchRes{},
 
+		mode: sealiface.BatchAuto,
+
 		notify:  make(chan struct{}, 1),
 		force:   make(chan chan []sealiface.PreCommitBatchRes),
 		stop:    make(chan struct{}),
@@ -128,16 +133,40 @@ func (b *PreCommitBatcher) run() {
 	}
 }
 
-func (b *PreCommitBatcher) batchWait(maxWait, slack time.Duration) time.Duration {
+func (b *PreCommitBatcher) batchWait(maxWait, slack time.Duration) (wait time.Duration) {
 	now := time.Now()
 
 	b.lk.Lock()
 	defer b.lk.Unlock()
+	defer func() {
+		b.sendAt = now.Add(wait)
+	}()
 
 	if len(b.todo) == 0 {
 		return maxWait
 	}
 
+	cutoff := b.cutoff()
+	if cutoff.IsZero() {
+		return maxWait
+	}
+
+	cutoff = cutoff.Add(-slack)
+	if cutoff.Before(now) {
+		return time.Nanosecond // can't return 0
+	}
+
+	wait = cutoff.Sub(now)
+	if wait > maxWait {
+		wait = maxWait
+	}
+
+	return wait
+}
+
+// cutoff returns the earliest cutoff of the pending sectors, must be called
+// with b.lk held
+func (b *PreCommitBatcher) cutoff() time.Time {
 	var cutoff time.Time
 	for sn := range b.todo {
 		sectorCutoff := b.cutoffs[sn]
@@ -151,22 +180,32 @@ func (b *PreCommitBatcher) batchWait(maxWait, slack time.Duration) time.Duration
 			cutoff = sectorCutoff
 		}
 	}
+	return cutoff
+}
 
-	if cutoff.IsZero() {
-		return maxWait
+// plan must be called with b.lk held
+func (b *PreCommitBatcher) plan(cfg sealiface.Config, ts *types.TipSet, nv network.Version) sealiface.BatchPlan {
+	aboveBaseFee := cfg.BatchPreCommitAboveBaseFee
+	if nv < network.Version14 {
+		aboveBaseFee = big.Zero()
 	}
 
-	cutoff = cutoff.Add(-slack)
-	if cutoff.Before(now) {
-		return time.Nanosecond // can't return 0
-	}
+	feeCfg := b.feeCfg()
+	return planBatch(batchPlanInput{
+		now:     time.Now(),
+		mode:    b.mode,
+		pending: len(b.todo),
+		cutoff:  b.cutoff(),
+		sendAt:  b.sendAt,
 
-	wait := cutoff.Sub(now)
-	if wait > maxWait {
-		wait = maxWait
-	}
+		maxBatch: cfg.MaxPreCommitBatch,
 
-	return wait
+		baseFee:           ts.MinTicketBlock().ParentBaseFee,
+		batchAboveBaseFee: aboveBaseFee,
+
+		batchFee:      feeCfg.MaxPreCommitBatchGasFee.FeeForSectors,
+		individualFee: big.Int(feeCfg.MaxPreCommitGasFee),
+	})
 }
 
 func (b *PreCommitBatcher) maybeStartBatch(notif bool) ([]sealiface.PreCommitBatchRes, error) {
@@ -198,10 +237,7 @@ func (b *PreCommitBatcher) maybeStartBatch(notif bool) ([]sealiface.PreCommitBat
 		return nil, xerrors.Errorf("couldn't get network version: %w", err)
 	}
 
-	individual := false
-	if !cfg.BatchPreCommitAboveBaseFee.Equals(big.Zero()) && ts.MinTicketBlock().ParentBaseFee.LessThan(cfg.BatchPreCommitAboveBaseFee) && nv >= network.Version14 {
-		individual = true
-	}
+	individual := b.plan(cfg, ts, nv).Individual
 
 	// todo support multiple batches
 	var res []sealiface.PreCommitBatchRes
@@ -294,14 +330,14 @@ func (b *PreCommitBatcher) processSingle(cfg sealiface.Config, mi api.MinerInfo,
 		}
 	}
 
-	goodFunds := big.Add(deposit, big.Int(b.feeCfg.MaxPreCommitGasFee))
+	goodFunds := big.Add(deposit, big.Int(b.feeCfg().MaxPreCommitGasFee))
 
 	from, _, err := b.addrSel(b.mctx, mi, api.PreCommitAddr, goodFunds, deposit)
 	if err != nil {
 		return cid.Undef, xerrors.Errorf("no good address to send precommit message from: %w", err)
 	}
 
-	mcid, err := sendMsg(b.mctx, b.api, from, b.maddr, builtin.MethodsMiner.PreCommitSector, deposit, big.Int(b.feeCfg.MaxPreCommitGasFee), enc.Bytes())
+	mcid, err := sendMsg(b.mctx, b.api, from, b.maddr, builtin.MethodsMiner.PreCommitSector, deposit, big.Int(b.feeCfg().MaxPreCommitGasFee), enc.Bytes())
 	if err != nil {
 		return cid.Undef, xerrors.Errorf("pushing message to mpool: %w", err)
 	}
@@ -335,7 +371,8 @@ func (b *PreCommitBatcher) processBatch(cfg sealiface.Config, tsk types.TipSetKe
 		return []sealiface.PreCommitBatchRes{res}, xerrors.Errorf("couldn't get miner info: %w", err)
 	}
 
-	maxFee := b.feeCfg.MaxPreCommitBatchGasFee.FeeForSectors(len(params.Sectors))
+	feeCfg := b.feeCfg()
+	maxFee := feeCfg.MaxPreCommitBatchGasFee.FeeForSectors(len(params.Sectors))
 
 	aggFeeRaw, err := policy.AggregatePreCommitNetworkFee(nv, len(params.Sectors), bf)
 	if err != nil {
@@ -452,6 +489,42 @@ func (b *PreCommitBatcher) Pending(ctx context.Context) ([]abi.SectorID, error)
 	return res, nil
 }
 
+// Plan returns how the pending sectors will be sent
+func (b *PreCommitBatcher) Plan(ctx context.Context) (sealiface.BatchPlan, error) {
+	cfg, err := b.getConfig()
+	if err != nil {
+		return sealiface.BatchPlan{}, xerrors.Errorf("getting config: %w", err)
+	}
+
+	ts, err := b.api.ChainHead(ctx)
+	if err != nil {
+		return sealiface.BatchPlan{}, xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	nv, err := b.api.StateNetworkVersion(ctx, ts.Key())
+	if err != nil {
+		return sealiface.BatchPlan{}, xerrors.Errorf("getting network version: %w", err)
+	}
+
+	b.lk.Lock()
+	defer b.lk.Unlock()
+
+	return b.plan(cfg, ts, nv), nil
+}
+
+// SetMode overrides the choice between batches and individual messages
+func (b *PreCommitBatcher) SetMode(mode sealiface.BatchMode) error {
+	if !mode.Valid() {
+		return xerrors.Errorf("unknown batching mode %q", mode)
+	}
+
+	b.lk.Lock()
+	defer b.lk.Unlock()
+
+	b.mode = mode
+	return nil
+}
+
 func (b *PreCommitBatcher) Stop(ctx context.Context) error {
 	close(b.stop)
 
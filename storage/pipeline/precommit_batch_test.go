@@ -35,6 +35,10 @@ var fc = config.MinerFeeConfig{
 	MaxCommitBatchGasFee:    config.BatchFeeConfig{Base: types.FIL(types.FromFil(3)), PerSector: types.FIL(types.FromFil(1))},
 }
 
+func feeCfg() config.MinerFeeConfig {
+	return fc
+}
+
 func TestPrecommitBatcher(t *testing.T) {
 	//stm: @CHAIN_STATE_MINER_CALCULATE_DEADLINE_001
 	t0123, err := address.NewFromString("t0123")
@@ -264,7 +268,7 @@ func TestPrecommitBatcher(t *testing.T) {
 			// create them mocks
 			pcapi := mocks.NewMockPreCommitBatcherApi(mockCtrl)
 
-			pcb := pipeline.NewPreCommitBatcher(ctx, t0123, pcapi, as, fc, cfg)
+			pcb := pipeline.NewPreCommitBatcher(ctx, t0123, pcapi, as, feeCfg, cfg)
 
 			var promises []promise
 
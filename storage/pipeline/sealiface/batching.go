@@ -1,6 +1,8 @@
 package sealiface
 
 import (
+	"time"
+
 	"github.com/ipfs/go-cid"
 
 	"github.com/filecoin-project/go-state-types/abi"
@@ -21,3 +23,47 @@ type PreCommitBatchRes struct {
 	Msg   *cid.Cid
 	Error string // if set, means that all sectors are failed, implies Msg==nil
 }
+
+// BatchMode overrides the choice between batched and individual messages made
+// by a batcher
+type BatchMode string
+
+const (
+	// BatchAuto follows the batching config
+	BatchAuto       BatchMode = "auto"
+	BatchAlways     BatchMode = "batch"
+	BatchIndividual BatchMode = "individual"
+)
+
+func (m BatchMode) Valid() bool {
+	switch m {
+	case BatchAuto, BatchAlways, BatchIndividual:
+		return true
+	default:
+		return false
+	}
+}
+
+// BatchPlan is how a batcher will send its pending sectors, as of Now
+type BatchPlan struct {
+	Now  time.Time
+	Mode BatchMode
+
+	Pending int
+	// Individual is true when the sectors will be sent in individual messages
+	Individual bool
+	// BatchSize is the number of sectors in the next message
+	BatchSize int
+	// SendAt is when the pending sectors will be sent, unless the batch fills
+	// up before
+	SendAt time.Time
+	// Cutoff is the earliest time by which one of the pending sectors must
+	// land on chain
+	Cutoff time.Time
+
+	BaseFee abi.TokenAmount
+	// MaxFee is the fee cap of the next message(s) from the fee config
+	MaxFee abi.TokenAmount
+
+	Reason string
+}
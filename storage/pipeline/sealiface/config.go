@@ -26,8 +26,23 @@ type Config struct {
 
 	MakeCCSectorsAvailable bool
 
+	SnapMatcherEnable   bool
+	SnapMatcherInterval time.Duration
+	// 0 = no limit
+	SnapMatcherMaxAvailable   uint64
+	SnapMatcherMinFillPercent uint64
+
 	WaitDealsDelay time.Duration
 
+	// 0 = protocol limit
+	MaxDealsPerSector uint64
+
+	// 0 = only seal full sectors early
+	TargetSectorFillPercent uint64
+
+	// 0 = no limit
+	MaxConcurrentDealsPerClient uint64
+
 	CommittedCapacitySectorLifetime time.Duration
 
 	StartEpochSealingBuffer abi.ChainEpoch
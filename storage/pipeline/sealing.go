@@ -77,7 +77,7 @@ type Sealing struct {
 	Api      SealingAPI
 	DealInfo *CurrentDealInfoManager
 
-	feeCfg config.MinerFeeConfig
+	feeCfg config.GetMinerFeeConfigFunc
 	events Events
 
 	startupWait sync.WaitGroup
@@ -113,6 +113,7 @@ type Sealing struct {
 
 type openSector struct {
 	used     abi.UnpaddedPieceSize // change to bitfield/rle when AddPiece gains offset support to better fill sectors
+	deals    int                   // number of deals in the sector, including the ones being added
 	number   abi.SectorNumber
 	ccUpdate bool
 
@@ -149,7 +150,17 @@ type pendingPiece struct {
 	accepted func(abi.SectorNumber, abi.UnpaddedPieceSize, error)
 }
 
-func New(mctx context.Context, api SealingAPI, fc config.MinerFeeConfig, events Events, maddr address.Address, ds datastore.Batching, sealer sealer.SectorManager, sc SectorIDCounter, verif storiface.Verifier, prov storiface.Prover, pcp PreCommitPolicy, gc GetSealingConfigFunc, notifee SectorStateNotifee, as AddrSel) *Sealing {
+// done returns whether the piece was added to a sector, or failed to be
+func (pp *pendingPiece) done() bool {
+	select {
+	case <-pp.doneCh:
+		return true
+	default:
+		return false
+	}
+}
+
+func New(mctx context.Context, api SealingAPI, fc config.GetMinerFeeConfigFunc, events Events, maddr address.Address, ds datastore.Batching, sealer sealer.SectorManager, sc SectorIDCounter, verif storiface.Verifier, prov storiface.Prover, pcp PreCommitPolicy, gc GetSealingConfigFunc, notifee SectorStateNotifee, as AddrSel) *Sealing {
 	s := &Sealing{
 		Api:      api,
 		DealInfo: &CurrentDealInfoManager{api},
@@ -197,6 +208,8 @@ func (m *Sealing) Run(ctx context.Context) error {
 		return xerrors.Errorf("failed load sector states: %w", err)
 	}
 
+	go m.runSnapMatcher(ctx)
+
 	return nil
 }
 
@@ -247,6 +260,22 @@ func (m *Sealing) CommitPending(ctx context.Context) ([]abi.SectorID, error) {
 	return m.commiter.Pending(ctx)
 }
 
+func (m *Sealing) SectorPreCommitBatchPlan(ctx context.Context) (sealiface.BatchPlan, error) {
+	return m.precommiter.Plan(ctx)
+}
+
+func (m *Sealing) SectorPreCommitSetBatchMode(mode sealiface.BatchMode) error {
+	return m.precommiter.SetMode(mode)
+}
+
+func (m *Sealing) CommitBatchPlan(ctx context.Context) (sealiface.BatchPlan, error) {
+	return m.commiter.Plan(ctx)
+}
+
+func (m *Sealing) CommitSetBatchMode(mode sealiface.BatchMode) error {
+	return m.commiter.SetMode(mode)
+}
+
 func (m *Sealing) currentSealProof(ctx context.Context) (abi.RegisteredSealProof, error) {
 	mi, err := m.Api.StateMinerInfo(ctx, m.maddr, types.EmptyTSK)
 	if err != nil {
@@ -290,3 +319,17 @@ func getDealPerSectorLimit(size abi.SectorSize) (int, error) {
 	}
 	return 512, nil
 }
+
+// dealPerSectorLimit returns the protocol limit on the number of deals in a
+// sector, lowered to the configured MaxDealsPerSector
+func dealPerSectorLimit(cfg sealiface.Config, size abi.SectorSize) (int, error) {
+	maxDeals, err := getDealPerSectorLimit(size)
+	if err != nil {
+		return 0, err
+	}
+
+	if cfg.MaxDealsPerSector > 0 && cfg.MaxDealsPerSector < uint64(maxDeals) {
+		maxDeals = int(cfg.MaxDealsPerSector)
+	}
+	return maxDeals, nil
+}
@@ -191,3 +191,18 @@ func IsUpgradeState(st SectorState) bool {
 		return false
 	}
 }
+
+// IsSnapState is true for the states of the SnapDeals pipeline, from the CC
+// sector being Available to the release of its sector key
+func IsSnapState(st SectorState) bool {
+	switch st {
+	case Available,
+		ReplicaUpdateWait,
+		FinalizeReplicaUpdate,
+		UpdateActivating,
+		ReleaseSectorKey:
+		return true
+	default:
+		return IsUpgradeState(st)
+	}
+}
@@ -0,0 +1,168 @@
+package sealing
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	market7 "github.com/filecoin-project/specs-actors/v7/actors/builtin/market"
+
+	"github.com/filecoin-project/lotus/storage/pipeline/sealiface"
+)
+
+const defaultSnapMatcherInterval = 5 * time.Minute
+
+type snapCandidate struct {
+	number     abi.SectorNumber
+	expiration abi.ChainEpoch
+}
+
+type snapPiece struct {
+	size abi.UnpaddedPieceSize
+	end  abi.ChainEpoch
+}
+
+// snapCandidates returns the CC sectors which the pending pieces fit, earliest
+// expiration first, so that the sectors living longer are kept for longer
+// deals. A sector fits the pieces ending before it expires, which must add up
+// to at least minFill bytes.
+func snapCandidates(sectors []snapCandidate, pieces []snapPiece, capacity, minFill abi.UnpaddedPieceSize) []abi.SectorNumber {
+	sort.Slice(sectors, func(i, j int) bool {
+		if sectors[i].expiration != sectors[j].expiration {
+			return sectors[i].expiration < sectors[j].expiration
+		}
+		return sectors[i].number < sectors[j].number
+	})
+
+	var out []abi.SectorNumber
+	for _, s := range sectors {
+		var fit abi.UnpaddedPieceSize
+		for _, p := range pieces {
+			if p.end <= s.expiration {
+				fit += p.size
+			}
+		}
+		if fit > capacity {
+			fit = capacity
+		}
+
+		if fit > 0 && fit >= minFill {
+			out = append(out, s.number)
+		}
+	}
+	return out
+}
+
+// runSnapMatcher periodically marks proving CC sectors Available for upgrading
+// when there are pending deals which fit them
+func (m *Sealing) runSnapMatcher(ctx context.Context) {
+	m.startupWait.Wait()
+
+	for {
+		interval := defaultSnapMatcherInterval
+
+		cfg, err := m.getConfig()
+		if err != nil {
+			log.Errorw("snap matcher: getting config", "error", err)
+		} else {
+			if cfg.SnapMatcherInterval > 0 {
+				interval = cfg.SnapMatcherInterval
+			}
+			if cfg.SnapMatcherEnable {
+				if err := m.matchSnapSectors(ctx, cfg); err != nil {
+					log.Errorw("snap matcher", "error", err)
+				}
+			}
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *Sealing) matchSnapSectors(ctx context.Context, cfg sealiface.Config) error {
+	var pieces []snapPiece
+
+	m.inputLk.Lock()
+	available := uint64(len(m.available))
+	for _, pp := range m.pendingPieces {
+		if pp.assigned {
+			continue
+		}
+		pieces = append(pieces, snapPiece{
+			size: pp.size,
+			end:  pp.deal.DealProposal.EndEpoch,
+		})
+	}
+	m.inputLk.Unlock()
+
+	if len(pieces) == 0 {
+		return nil
+	}
+	if cfg.SnapMatcherMaxAvailable > 0 && available >= cfg.SnapMatcherMaxAvailable {
+		log.Debugw("snap matcher: enough sectors available", "available", available, "max", cfg.SnapMatcherMaxAvailable)
+		return nil
+	}
+
+	sp, err := m.currentSealProof(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting seal proof type: %w", err)
+	}
+	ssize, err := sp.SectorSize()
+	if err != nil {
+		return xerrors.Errorf("getting sector size: %w", err)
+	}
+
+	ts, err := m.Api.ChainHead(ctx)
+	if err != nil {
+		return xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	sectors, err := m.ListSectors()
+	if err != nil {
+		return xerrors.Errorf("listing sectors: %w", err)
+	}
+
+	var candidates []snapCandidate
+	for _, s := range sectors {
+		if s.State != Proving || s.hasDeals() {
+			continue
+		}
+
+		info, err := m.Api.StateSectorGetInfo(ctx, m.maddr, s.SectorNumber, ts.Key())
+		if err != nil {
+			log.Errorw("snap matcher: getting sector info", "sector", s.SectorNumber, "error", err)
+			continue
+		}
+		if info == nil || info.Expiration-ts.Height() < market7.DealMinDuration {
+			continue
+		}
+
+		candidates = append(candidates, snapCandidate{
+			number:     s.SectorNumber,
+			expiration: info.Expiration,
+		})
+	}
+
+	capacity := abi.PaddedPieceSize(ssize).Unpadded()
+	minFill := abi.UnpaddedPieceSize(uint64(capacity) * cfg.SnapMatcherMinFillPercent / 100)
+
+	for _, sn := range snapCandidates(candidates, pieces, capacity, minFill) {
+		if err := m.MarkForSnapUpgrade(ctx, sn); err != nil {
+			log.Warnw("snap matcher: can't mark sector for upgrade", "sector", sn, "error", err)
+			continue
+		}
+
+		log.Infow("snap matcher: marked sector for upgrade", "sector", sn, "pieces", len(pieces), "candidates", len(candidates))
+		return nil
+	}
+
+	log.Debugw("snap matcher: no sector fits the pending deals", "pieces", len(pieces), "candidates", len(candidates))
+	return nil
+}
@@ -0,0 +1,34 @@
+//stm: #unit
+package sealing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+func TestSnapCandidates(t *testing.T) {
+	sectors := []snapCandidate{
+		{number: 1, expiration: 3000},
+		{number: 2, expiration: 1000},
+		{number: 3, expiration: 2000},
+	}
+
+	pieces := []snapPiece{
+		{size: 400, end: 1500},
+		{size: 400, end: 2500},
+	}
+
+	// sector 2 expires before all the deals end
+	require.Equal(t, []abi.SectorNumber{3, 1}, snapCandidates(sectors, pieces, 1000, 0))
+
+	// only sector 1 outlives enough deals
+	require.Equal(t, []abi.SectorNumber{1}, snapCandidates(sectors, pieces, 1000, 500))
+
+	// the deals fitting a sector are capped to its capacity
+	require.Empty(t, snapCandidates(sectors, pieces, 600, 700))
+
+	require.Empty(t, snapCandidates(sectors, nil, 1000, 0))
+}
@@ -155,11 +155,18 @@ func (t *SectorInfo) sealingCtx(ctx context.Context) context.Context {
 	// TODO: can also take start epoch into account to give priority to sectors
 	//  we need sealed sooner
 
-	if t.hasDeals() {
-		return sealer.WithPriority(ctx, DealSectorPriority)
-	}
+	return sealer.WithPriorityClass(ctx, t.priorityClass())
+}
 
-	return ctx
+func (t *SectorInfo) priorityClass() sealer.PriorityClass {
+	switch {
+	case t.CCUpdate:
+		return sealer.PrioritySnapDeals
+	case t.hasDeals():
+		return sealer.PriorityDeals
+	default:
+		return sealer.PriorityCC
+	}
 }
 
 // Returns list of offset/length tuples of sector data ranges which clients
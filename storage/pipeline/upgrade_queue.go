@@ -2,6 +2,7 @@ package sealing
 
 import (
 	"context"
+	"time"
 
 	"golang.org/x/xerrors"
 
@@ -75,3 +76,60 @@ func (m *Sealing) sectorActive(ctx context.Context, tsk types.TipSetKey, sector
 
 	return false, nil
 }
+
+// SnapSectors returns the sectors in the SnapDeals pipeline
+func (m *Sealing) SnapSectors() ([]SectorInfo, error) {
+	sectors, err := m.ListSectors()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []SectorInfo
+	for _, s := range sectors {
+		if IsSnapState(s.State) {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+// RetrySnapUpgrade retries the failed step of a sector in a failed SnapDeals
+// state without waiting for its handler, which may have stopped on an error
+func (m *Sealing) RetrySnapUpgrade(id abi.SectorNumber) error {
+	m.startupWait.Wait()
+
+	si, err := m.GetSectorInfo(id)
+	if err != nil {
+		return xerrors.Errorf("getting sector info: %w", err)
+	}
+
+	var retry interface{}
+	switch si.State {
+	case SnapDealsAddPieceFailed:
+		retry = SectorRetryWaitDeals{}
+	case ReplicaUpdateFailed:
+		if si.ReplicaUpdateMessage != nil {
+			retry = SectorRetrySubmitReplicaUpdateWait{}
+		} else {
+			retry = SectorRetryReplicaUpdate{}
+		}
+	case FinalizeReplicaUpdateFailed:
+		retry = SectorRetryFinalize{}
+	case ReleaseSectorKeyFailed:
+		retry = SectorUpdateActive{}
+	default:
+		return xerrors.Errorf("sector %d is in state %s, not a failed SnapDeals state", id, si.State)
+	}
+
+	// the handlers of failed states retry on their own after a cooldown, don't
+	// race them
+	if len(si.Log) > 0 {
+		retryStart := time.Unix(int64(si.Log[len(si.Log)-1].Timestamp), 0).Add(minRetryTime)
+		if time.Now().Before(retryStart) {
+			return xerrors.Errorf("sector %d is waiting to be retried automatically in %s", id, time.Until(retryStart).Truncate(time.Second))
+		}
+	}
+
+	log.Infow("retrying upgrade of sector", "sector", id, "state", si.State, "trigger", "user")
+	return m.sectors.Send(uint64(id), retry)
+}
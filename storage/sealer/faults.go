@@ -11,6 +11,7 @@ import (
 
 	ffi "github.com/filecoin-project/filecoin-ffi"
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/proof"
 
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
 )
@@ -20,6 +21,7 @@ var PostCheckTimeout = 160 * time.Second
 // FaultTracker TODO: Track things more actively
 type FaultTracker interface {
 	CheckProvable(ctx context.Context, pp abi.RegisteredPoStProof, sectors []storiface.SectorRef, rg storiface.RGetter) (map[abi.SectorID]string, error)
+	CheckPoStVanilla(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.ExtendedSectorInfo, randomness abi.PoStRandomness) ([]storiface.VanillaCheck, error)
 }
 
 // CheckProvable returns unprovable sectors
@@ -130,4 +132,81 @@ func (m *Manager) CheckProvable(ctx context.Context, pp abi.RegisteredPoStProof,
 	return bad, nil
 }
 
+// CheckPoStVanilla generates the vanilla proofs of the WindowPoSt challenges of
+// the sectors for the randomness, without computing the snark
+func (m *Manager) CheckPoStVanilla(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.ExtendedSectorInfo, randomness abi.PoStRandomness) ([]storiface.VanillaCheck, error) {
+	if len(sectorInfo) == 0 {
+		return nil, nil
+	}
+
+	randomness = append(abi.PoStRandomness{}, randomness...)
+	randomness[31] &= 0x3f
+
+	ppt, err := sectorInfo[0].SealProof.RegisteredWindowPoStProof()
+	if err != nil {
+		return nil, err
+	}
+
+	sectorInfo = dedupeSectorInfo(sectorInfo)
+	sectorNums := make([]abi.SectorNumber, len(sectorInfo))
+	for i, s := range sectorInfo {
+		sectorNums[i] = s.SectorNumber
+	}
+
+	ch, err := ffi.GeneratePoStFallbackSectorChallenges(ppt, minerID, randomness, sectorNums)
+	if err != nil {
+		return nil, xerrors.Errorf("generating fallback challenges: %w", err)
+	}
+
+	limit := m.parallelCheckLimit
+	if limit <= 0 {
+		limit = len(sectorInfo)
+	}
+	throttle := make(chan struct{}, limit)
+
+	out := make([]storiface.VanillaCheck, len(sectorInfo))
+
+	var wg sync.WaitGroup
+	wg.Add(len(sectorInfo))
+
+	for i, s := range sectorInfo {
+		select {
+		case throttle <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		go func(i int, s proof.ExtendedSectorInfo) {
+			defer wg.Done()
+			defer func() {
+				<-throttle
+			}()
+
+			vctx, cancel := context.WithTimeout(ctx, PostCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			_, err := m.storage.GenerateSingleVanillaProof(vctx, minerID, storiface.PostSectorChallenge{
+				SealProof:    s.SealProof,
+				SectorNumber: s.SectorNumber,
+				SealedCID:    s.SealedCID,
+				Challenge:    ch.Challenges[s.SectorNumber],
+				Update:       s.SectorKey != nil,
+			}, ppt)
+
+			out[i] = storiface.VanillaCheck{
+				Sector:  s.SectorNumber,
+				Elapsed: time.Since(start),
+			}
+			if err != nil {
+				out[i].Err = err.Error()
+			}
+		}(i, s)
+	}
+
+	wg.Wait()
+
+	return out, nil
+}
+
 var _ FaultTracker = &Manager{}
@@ -0,0 +1,109 @@
+package ffiwrapper
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+// PC1CheckpointFile is written to the cache directory of a sector when PC1
+// starts, and removed when it succeeds. It records the inputs of the layers
+// computed in the cache, so that an interrupted PC1 with the same inputs can
+// keep them: the proofs skip the layers which are already on disk.
+const PC1CheckpointFile = "pc1-checkpoint.json"
+
+const layerPrefix, layerSuffix = "sc-02-data-layer-", ".dat"
+
+type pc1Checkpoint struct {
+	Ticket abi.SealRandomness
+	Pieces []abi.PieceInfo
+}
+
+func (c pc1Checkpoint) matches(ticket abi.SealRandomness, pieces []abi.PieceInfo) bool {
+	if !bytes.Equal(c.Ticket, ticket) || len(c.Pieces) != len(pieces) {
+		return false
+	}
+	for i, p := range pieces {
+		if c.Pieces[i].Size != p.Size || !c.Pieces[i].PieceCID.Equals(p.PieceCID) {
+			return false
+		}
+	}
+	return true
+}
+
+func writePC1Checkpoint(cache string, ticket abi.SealRandomness, pieces []abi.PieceInfo) error {
+	b, err := json.Marshal(pc1Checkpoint{Ticket: ticket, Pieces: pieces})
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(cache, PC1CheckpointFile), b, 0644) // nolint:gosec
+}
+
+// resumePC1 prepares the existing cache directory of a sector to resume PC1,
+// and returns the number of layers kept. The cache can only be resumed when
+// its checkpoint matches the inputs; the last layer found is dropped, as it
+// may be incomplete, and so are the layers following a missing or truncated
+// one. The cache must be removed when no layers are kept.
+func resumePC1(cache string, ssize abi.SectorSize, ticket abi.SealRandomness, pieces []abi.PieceInfo) (int, error) {
+	b, err := ioutil.ReadFile(filepath.Join(cache, PC1CheckpointFile))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, xerrors.Errorf("reading checkpoint: %w", err)
+	}
+
+	var cp pc1Checkpoint
+	if err := json.Unmarshal(b, &cp); err != nil {
+		log.Warnw("invalid PC1 checkpoint", "cache", cache, "error", err)
+		return 0, nil
+	}
+	if !cp.matches(ticket, pieces) {
+		return 0, nil
+	}
+
+	files, err := ioutil.ReadDir(cache)
+	if err != nil {
+		return 0, xerrors.Errorf("reading cache dir: %w", err)
+	}
+
+	layers := map[int]os.FileInfo{}
+	for _, f := range files {
+		name := f.Name()
+		if !strings.HasPrefix(name, layerPrefix) || !strings.HasSuffix(name, layerSuffix) {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, layerPrefix), layerSuffix))
+		if err != nil {
+			continue
+		}
+		layers[n] = f
+	}
+
+	complete := 0
+	for f, ok := layers[complete+1]; ok && f.Size() == int64(ssize); f, ok = layers[complete+1] {
+		complete++
+	}
+	if complete > 0 {
+		complete-- // the last layer may still have been written
+	}
+
+	for n, f := range layers {
+		if n <= complete {
+			continue
+		}
+		if err := os.Remove(filepath.Join(cache, f.Name())); err != nil {
+			return 0, xerrors.Errorf("removing layer %d: %w", n, err)
+		}
+	}
+
+	return complete, nil
+}
@@ -0,0 +1,88 @@
+//stm: #unit
+package ffiwrapper
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+func TestResumePC1(t *testing.T) {
+	const ssize = abi.SectorSize(16)
+
+	pieceCid, err := cid.Decode("baga6ea4seaqeyz3uzvwpvo3r3imzu6fakhrdyyitlofvdmefgtqtqmlsxrejqbq")
+	require.NoError(t, err)
+	ticket := abi.SealRandomness{1, 2, 3}
+	pieces := []abi.PieceInfo{{Size: abi.PaddedPieceSize(ssize), PieceCID: pieceCid}}
+
+	setup := func(t *testing.T, layerSizes ...int) string {
+		cache := t.TempDir()
+		require.NoError(t, writePC1Checkpoint(cache, ticket, pieces))
+		for i, size := range layerSizes {
+			if size < 0 {
+				continue
+			}
+			name := filepath.Join(cache, fmt.Sprintf("%s%d%s", layerPrefix, i+1, layerSuffix))
+			require.NoError(t, ioutil.WriteFile(name, make([]byte, size), 0644))
+		}
+		return cache
+	}
+	layerFiles := func(t *testing.T, cache string) []string {
+		files, err := filepath.Glob(filepath.Join(cache, layerPrefix+"*"))
+		require.NoError(t, err)
+		for i := range files {
+			files[i] = filepath.Base(files[i])
+		}
+		return files
+	}
+
+	t.Run("resume", func(t *testing.T) {
+		cache := setup(t, 16, 16, 16)
+
+		layers, err := resumePC1(cache, ssize, ticket, pieces)
+		require.NoError(t, err)
+		require.Equal(t, 2, layers)
+		require.Equal(t, []string{"sc-02-data-layer-1.dat", "sc-02-data-layer-2.dat"}, layerFiles(t, cache))
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		cache := setup(t, 16, 16, 8, 16)
+
+		layers, err := resumePC1(cache, ssize, ticket, pieces)
+		require.NoError(t, err)
+		require.Equal(t, 1, layers)
+		require.Equal(t, []string{"sc-02-data-layer-1.dat"}, layerFiles(t, cache))
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		cache := setup(t, 16, -1, 16)
+
+		layers, err := resumePC1(cache, ssize, ticket, pieces)
+		require.NoError(t, err)
+		require.Equal(t, 0, layers)
+	})
+
+	t.Run("other-inputs", func(t *testing.T) {
+		cache := setup(t, 16, 16)
+
+		layers, err := resumePC1(cache, ssize, abi.SealRandomness{4}, pieces)
+		require.NoError(t, err)
+		require.Equal(t, 0, layers)
+	})
+
+	t.Run("no-checkpoint", func(t *testing.T) {
+		cache := setup(t, 16, 16)
+		require.NoError(t, os.Remove(filepath.Join(cache, PC1CheckpointFile)))
+
+		layers, err := resumePC1(cache, ssize, ticket, pieces)
+		require.NoError(t, err)
+		require.Equal(t, 0, layers)
+	})
+}
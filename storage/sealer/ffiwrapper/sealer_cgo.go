@@ -13,6 +13,7 @@ import (
 	"io"
 	"math/bits"
 	"os"
+	"path/filepath"
 	"runtime"
 
 	"github.com/detailyang/go-fallocate"
@@ -720,30 +721,44 @@ func (sb *Sealer) SealPreCommit1(ctx context.Context, sector storiface.SectorRef
 		return nil, err
 	}
 
+	ssize, err := sector.ProofType.SectorSize()
+	if err != nil {
+		return nil, err
+	}
+
 	if err := os.Mkdir(paths.Cache, 0755); err != nil { // nolint
 		if os.IsExist(err) {
-			log.Warnf("existing cache in %s; removing", paths.Cache)
-
-			if err := os.RemoveAll(paths.Cache); err != nil {
-				return nil, xerrors.Errorf("remove existing sector cache from %s (sector %d): %w", paths.Cache, sector, err)
+			layers, err := resumePC1(paths.Cache, ssize, ticket, pieces)
+			if err != nil {
+				return nil, xerrors.Errorf("checking PC1 checkpoint in %s (sector %d): %w", paths.Cache, sector, err)
 			}
 
-			if err := os.Mkdir(paths.Cache, 0755); err != nil { // nolint:gosec
-				return nil, xerrors.Errorf("mkdir cache path after cleanup: %w", err)
+			if layers > 0 {
+				log.Infow("resuming interrupted PC1", "sector", sector.ID, "cache", paths.Cache, "layers", layers)
+			} else {
+				log.Warnf("existing cache in %s; removing", paths.Cache)
+
+				if err := os.RemoveAll(paths.Cache); err != nil {
+					return nil, xerrors.Errorf("remove existing sector cache from %s (sector %d): %w", paths.Cache, sector, err)
+				}
+
+				if err := os.Mkdir(paths.Cache, 0755); err != nil { // nolint:gosec
+					return nil, xerrors.Errorf("mkdir cache path after cleanup: %w", err)
+				}
 			}
 		} else {
 			return nil, err
 		}
 	}
 
+	if err := writePC1Checkpoint(paths.Cache, ticket, pieces); err != nil {
+		return nil, xerrors.Errorf("writing PC1 checkpoint: %w", err)
+	}
+
 	var sum abi.UnpaddedPieceSize
 	for _, piece := range pieces {
 		sum += piece.Size.Unpadded()
 	}
-	ssize, err := sector.ProofType.SectorSize()
-	if err != nil {
-		return nil, err
-	}
 	ussize := abi.PaddedPieceSize(ssize).Unpadded()
 	if sum != ussize {
 		return nil, xerrors.Errorf("aggregated piece sizes don't match sector size: %d != %d (%d)", sum, ussize, int64(ussize-sum))
@@ -764,6 +779,10 @@ func (sb *Sealer) SealPreCommit1(ctx context.Context, sector storiface.SectorRef
 		return nil, xerrors.Errorf("presealing sector %d (%s): %w", sector.ID.Number, paths.Unsealed, err)
 	}
 
+	if err := os.Remove(filepath.Join(paths.Cache, PC1CheckpointFile)); err != nil {
+		return nil, xerrors.Errorf("removing PC1 checkpoint: %w", err)
+	}
+
 	p1odec := map[string]interface{}{}
 	if err := json.Unmarshal(p1o, &p1odec); err != nil {
 		return nil, xerrors.Errorf("unmarshaling pc1 output: %w", err)
@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/hashicorp/go-multierror"
@@ -21,6 +22,7 @@ import (
 	"github.com/filecoin-project/lotus/storage/paths"
 	"github.com/filecoin-project/lotus/storage/sealer/ffiwrapper"
 	"github.com/filecoin-project/lotus/storage/sealer/fsutil"
+	"github.com/filecoin-project/lotus/storage/sealer/remoteprover"
 	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
 )
@@ -80,6 +82,15 @@ type Manager struct {
 
 	results map[WorkID]result
 	waitRes map[WorkID]chan struct{}
+
+	// hosts of the workers which started PC1 on sectors, where the
+	// interrupted PC1 can be resumed
+	pc1Lk    sync.Mutex
+	pc1Hosts map[abi.SectorID]string
+
+	unseals *unsealQueue
+
+	rebalance *rebalancer
 }
 
 var _ storiface.ProverPoSt = &Manager{}
@@ -129,6 +140,38 @@ type Config struct {
 	DisallowRemoteFinalize bool
 
 	Assigner string
+
+	// Priorities of the sealing tasks by class of sector
+	Priorities storiface.SchedPriorities
+
+	// WorkerTags are the tags of the workers by hostname, which the workers
+	// must have to run the tasks matched by the AffinityRules
+	WorkerTags    map[string][]string
+	AffinityRules []storiface.AffinityRule
+
+	// RemoteProvers of the tasks of the local worker
+	RemoteProvers []remoteprover.Config
+
+	// MaxUnsealsPerPath is the number of sectors unsealed at the same time
+	// from a storage path, 0 for no limit
+	MaxUnsealsPerPath int
+	// UnsealedCacheMaxBytes is the size of the unsealed copies created by
+	// unseals above which the least recently used ones are removed, 0 for no
+	// limit
+	UnsealedCacheMaxBytes int64
+
+	// RebalanceInterval at which sectors are moved between the local storage
+	// paths, 0 to only rebalance on demand
+	RebalanceInterval time.Duration
+	// RebalanceMaxBandwidth is the number of bytes copied per second when
+	// moving sectors, 0 for no limit
+	RebalanceMaxBandwidth int64
+	// RebalanceMaxMoves is the number of sectors moved in a run, 0 for no limit
+	RebalanceMaxMoves int
+
+	// AllocationPolicy places the files of a sector in the local long-term
+	// storage paths depending on the groups of the paths
+	AllocationPolicy paths.AllocationPolicy
 }
 
 type StorageAuth http.Header
@@ -146,6 +189,29 @@ func New(ctx context.Context, lstor *paths.Local, stor paths.Store, ls paths.Loc
 	if err != nil {
 		return nil, err
 	}
+	sh.priorities = sc.Priorities
+
+	for i, r := range sc.AffinityRules {
+		if err := r.Validate(); err != nil {
+			return nil, xerrors.Errorf("affinity rule %d: %w", i, err)
+		}
+	}
+	sh.affinityRules = sc.AffinityRules
+	for host, tags := range sc.WorkerTags {
+		sh.workerTags[host] = map[string]struct{}{}
+		for _, tag := range tags {
+			sh.workerTags[host][tag] = struct{}{}
+		}
+	}
+
+	var provers []*remoteprover.Prover
+	for _, pcfg := range sc.RemoteProvers {
+		p, err := remoteprover.NewProver(pcfg)
+		if err != nil {
+			return nil, xerrors.Errorf("creating remote prover: %w", err)
+		}
+		provers = append(provers, p)
+	}
 
 	m := &Manager{
 		ls:         ls,
@@ -170,12 +236,22 @@ func New(ctx context.Context, lstor *paths.Local, stor paths.Store, ls paths.Loc
 		callRes:    map[storiface.CallID]chan result{},
 		results:    map[WorkID]result{},
 		waitRes:    map[WorkID]chan struct{}{},
+
+		pc1Hosts: map[abi.SectorID]string{},
+
+		unseals: newUnsealQueue(sc.MaxUnsealsPerPath, sc.UnsealedCacheMaxBytes),
+
+		rebalance: newRebalancer(ctx, sc.RebalanceInterval, sc.RebalanceMaxBandwidth, sc.RebalanceMaxMoves),
 	}
 
 	m.setupWorkTracker()
 
 	go m.sched.runSched()
 
+	if sc.RebalanceInterval > 0 {
+		go m.runRebalanceLoop()
+	}
+
 	localTasks := []sealtasks.TaskType{
 		sealtasks.TTCommit1, sealtasks.TTProveReplicaUpdate1, sealtasks.TTFinalize, sealtasks.TTFetch, sealtasks.TTFinalizeReplicaUpdate,
 	}
@@ -207,6 +283,7 @@ func New(ctx context.Context, lstor *paths.Local, stor paths.Store, ls paths.Loc
 	wcfg := WorkerConfig{
 		IgnoreResourceFiltering: sc.ResourceFiltering == ResourceFilteringDisabled,
 		TaskTypes:               localTasks,
+		RemoteProvers:           provers,
 	}
 	worker := NewLocalWorker(wcfg, stor, lstor, si, m, wss)
 	err = m.AddWorker(ctx, worker)
@@ -287,6 +364,12 @@ func (m *Manager) SectorsUnsealPiece(ctx context.Context, sector storiface.Secto
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	release, err := m.unseals.acquire(ctx, sector.ID, unsealClass(ctx), m.sealedPath(ctx, sector.ID))
+	if err != nil {
+		return xerrors.Errorf("waiting in the unseal queue: %w", err)
+	}
+	defer release()
+
 	log.Debugf("acquire unseal sector lock for sector %d", sector.ID)
 	if err := m.index.StorageLock(ctx, sector.ID, storiface.FTSealed|storiface.FTCache|storiface.FTUpdate|storiface.FTUpdateCache, storiface.FTUnsealed); err != nil {
 		return xerrors.Errorf("acquiring unseal sector lock: %w", err)
@@ -315,6 +398,13 @@ func (m *Manager) SectorsUnsealPiece(ctx context.Context, sector storiface.Secto
 		return xerrors.Errorf("getting sector size: %w", err)
 	}
 
+	// unsealed copies of sectors which didn't have one are tracked by the cache
+	// of unsealed copies, the others are managed by the sealing pipeline
+	existing, err := m.index.StorageFindSector(ctx, sector.ID, storiface.FTUnsealed, 0, false)
+	if err != nil {
+		return xerrors.Errorf("finding unsealed copies: %w", err)
+	}
+
 	// selector will schedule the Unseal task on a worker that either already has the sealed sector files or has space in
 	// one of it's sealing scratch spaces to store them after fetching them from another worker.
 	selector := newExistingSelector(m.index, sector.ID, storiface.FTSealed|storiface.FTCache, true)
@@ -337,6 +427,13 @@ func (m *Manager) SectorsUnsealPiece(ctx context.Context, sector storiface.Secto
 		return xerrors.Errorf("worker UnsealPiece call: %s", err)
 	}
 
+	if len(existing) == 0 {
+		m.unseals.cacheAdd(sector.ID, ssize)
+		m.evictUnsealed(ctx, sector.ID)
+	} else {
+		m.unseals.cacheTouch(sector.ID)
+	}
+
 	return nil
 }
 
@@ -430,9 +527,21 @@ func (m *Manager) SealPreCommit1(ctx context.Context, sector storiface.SectorRef
 
 	// TODO: also consider where the unsealed data sits
 
-	selector := newAllocSelector(m.index, storiface.FTCache|storiface.FTSealed, storiface.PathSealing)
+	m.pc1Lk.Lock()
+	host := m.pc1Hosts[sector.ID]
+	m.pc1Lk.Unlock()
+
+	// workers keep the layers of an interrupted PC1 in the sector cache, so
+	// prefer the worker which started it
+	selector := newHostSelector(newAllocSelector(m.index, storiface.FTCache|storiface.FTSealed, storiface.PathSealing), host)
 
 	err = m.sched.Schedule(ctx, sector, sealtasks.TTPreCommit1, selector, m.schedFetch(sector, storiface.FTUnsealed, storiface.PathSealing, storiface.AcquireMove), func(ctx context.Context, w Worker) error {
+		if tw, ok := w.(*trackedWorker); ok {
+			m.pc1Lk.Lock()
+			m.pc1Hosts[sector.ID] = tw.workerInfo.Hostname
+			m.pc1Lk.Unlock()
+		}
+
 		err := m.startWork(ctx, w, wk)(w.SealPreCommit1(ctx, sector, ticket, pieces))
 		if err != nil {
 			return err
@@ -445,6 +554,12 @@ func (m *Manager) SealPreCommit1(ctx context.Context, sector storiface.SectorRef
 		return nil, err
 	}
 
+	if waitErr == nil {
+		m.pc1Lk.Lock()
+		delete(m.pc1Hosts, sector.ID)
+		m.pc1Lk.Unlock()
+	}
+
 	return out, waitErr
 }
 
@@ -879,6 +994,8 @@ func (m *Manager) Remove(ctx context.Context, sector storiface.SectorRef) error
 		err = multierror.Append(err, xerrors.Errorf("removing sector (unsealed): %w", rerr))
 	}
 
+	m.unseals.cacheRemove(sector.ID)
+
 	return err
 }
 
@@ -1168,6 +1285,25 @@ func (m *Manager) SchedDiag(ctx context.Context, doSched bool) (interface{}, err
 	return i, nil
 }
 
+func (m *Manager) SchedPriorities(ctx context.Context) (storiface.SchedPriorities, error) {
+	return m.sched.Priorities(), nil
+}
+
+func (m *Manager) SetSchedPriorities(ctx context.Context, p storiface.SchedPriorities) error {
+	m.sched.SetPriorities(p)
+	return nil
+}
+
+func (m *Manager) TagWorkers(ctx context.Context, hostname string, tags []string) error {
+	m.sched.TagWorkers(hostname, tags...)
+	return nil
+}
+
+func (m *Manager) UntagWorkers(ctx context.Context, hostname string, tags []string) error {
+	m.sched.UntagWorkers(hostname, tags...)
+	return nil
+}
+
 func (m *Manager) Close(ctx context.Context) error {
 	m.windowPoStSched.schedClose()
 	m.winningPoStSched.schedClose()
@@ -131,6 +131,10 @@ func newTestMgr(ctx context.Context, t *testing.T, ds datastore.Datastore) (*Man
 		callRes:    map[storiface.CallID]chan result{},
 		results:    map[WorkID]result{},
 		waitRes:    map[WorkID]chan struct{}{},
+
+		pc1Hosts:  map[abi.SectorID]string{},
+		unseals:   newUnsealQueue(0, 0),
+		rebalance: newRebalancer(ctx, 0, 0, 0),
 	}
 
 	m.setupWorkTracker()
@@ -538,6 +538,23 @@ func (mgr *SectorMgr) CheckProvable(ctx context.Context, pp abi.RegisteredPoStPr
 	return bad, nil
 }
 
+func (mgr *SectorMgr) CheckPoStVanilla(ctx context.Context, minerID abi.ActorID, sectorInfo []prooftypes.ExtendedSectorInfo, randomness abi.PoStRandomness) ([]storiface.VanillaCheck, error) {
+	var out []storiface.VanillaCheck
+	for _, s := range sectorInfo {
+		c := storiface.VanillaCheck{Sector: s.SectorNumber}
+
+		mgr.lk.Lock()
+		ss, found := mgr.sectors[abi.SectorID{Miner: minerID, Number: s.SectorNumber}]
+		if !found || ss.failed {
+			c.Err = "mock fail"
+		}
+		mgr.lk.Unlock()
+
+		out = append(out, c)
+	}
+	return out, nil
+}
+
 var _ storiface.WorkerReturn = &SectorMgr{}
 
 func (mgr *SectorMgr) ReturnDataCid(ctx context.Context, callID storiface.CallID, pi abi.PieceInfo, err *storiface.CallError) error {
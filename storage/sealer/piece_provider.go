@@ -33,6 +33,12 @@ type PieceProvider interface {
 
 var _ PieceProvider = &pieceProvider{}
 
+// unsealedReader is implemented by unsealers which track the reads from the
+// unsealed copies they create
+type unsealedReader interface {
+	UnsealedRead(sector abi.SectorID)
+}
+
 type pieceProvider struct {
 	storage *paths.Remote
 	index   paths.SectorIndex
@@ -176,6 +182,12 @@ func (p *pieceProvider) ReadPiece(ctx context.Context, sector storiface.SectorRe
 
 	var uns bool
 
+	if r != nil {
+		if ur, ok := p.uns.(unsealedReader); ok {
+			ur.UnsealedRead(sector.ID)
+		}
+	}
+
 	if r == nil {
 		// a nil reader means that none of the workers has an unsealed sector file
 		// containing the unsealed piece.
@@ -0,0 +1,357 @@
+package sealer
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+// rebalanceBurst is the number of bytes copied at once when the bandwidth of
+// the rebalancer is limited
+const rebalanceBurst = 1 << 20
+
+type rebalancePath struct {
+	id       storiface.ID
+	capacity int64
+	used     int64
+	weight   uint64
+	drain    bool
+}
+
+type rebalanceSector struct {
+	sector   abi.SectorID
+	fileType storiface.SectorFileType
+	path     storiface.ID
+	size     int64
+}
+
+// planRebalance returns the moves bringing the use of each path closer to its
+// target, the share of the used bytes proportional to its weighted capacity.
+// Draining paths have no target, and sectors are never moved to them. Other
+// sectors only move from a path above its target to a path below its target
+// by at least the sector size, so that a move is never reversed by the next
+// plan.
+func planRebalance(paths []rebalancePath, sectors []rebalanceSector, maxMoves int) []storiface.RebalanceMove {
+	var total int64
+	var weighted float64
+	for _, p := range paths {
+		total += p.used
+		if !p.drain {
+			weighted += float64(p.weight) * float64(p.capacity)
+		}
+	}
+
+	target := map[storiface.ID]int64{}
+	used := map[storiface.ID]int64{}
+	for _, p := range paths {
+		used[p.id] = p.used
+		if !p.drain && weighted > 0 {
+			target[p.id] = int64(float64(total) * float64(p.weight) * float64(p.capacity) / weighted)
+		}
+	}
+
+	bySource := map[storiface.ID][]rebalanceSector{}
+	for _, s := range sectors {
+		bySource[s.path] = append(bySource[s.path], s)
+	}
+	for _, ss := range bySource {
+		sort.Slice(ss, func(i, j int) bool {
+			return ss[i].sector.Number < ss[j].sector.Number
+		})
+	}
+
+	var out []storiface.RebalanceMove
+	stuck := map[storiface.ID]bool{}
+
+	for maxMoves <= 0 || len(out) < maxMoves {
+		// the path the most above its target
+		var src *rebalancePath
+		var excess int64
+		for i := range paths {
+			p := &paths[i]
+			if stuck[p.id] || len(bySource[p.id]) == 0 {
+				continue
+			}
+			if e := used[p.id] - target[p.id]; e > excess {
+				src, excess = p, e
+			}
+		}
+		if src == nil {
+			break
+		}
+
+		moved := false
+		for i, s := range bySource[src.id] {
+			if !src.drain && s.size > excess {
+				continue
+			}
+
+			// the path the most below its target with room for the sector;
+			// sectors leave draining paths even when it's above its target
+			var dst *rebalancePath
+			var deficit int64
+			for j := range paths {
+				p := &paths[j]
+				if p.drain || p.id == src.id || p.capacity-used[p.id] < s.size {
+					continue
+				}
+				d := target[p.id] - used[p.id]
+				if d < s.size && !src.drain {
+					continue
+				}
+				if dst == nil || d > deficit {
+					dst, deficit = p, d
+				}
+			}
+			if dst == nil {
+				continue
+			}
+
+			out = append(out, storiface.RebalanceMove{
+				Sector:   s.sector,
+				FileType: s.fileType,
+				From:     src.id,
+				To:       dst.id,
+				Size:     s.size,
+			})
+			used[src.id] -= s.size
+			used[dst.id] += s.size
+			bySource[src.id] = append(bySource[src.id][:i:i], bySource[src.id][i+1:]...)
+			moved = true
+			break
+		}
+
+		if !moved {
+			stuck[src.id] = true
+		}
+	}
+
+	return out
+}
+
+type rebalancer struct {
+	ctx context.Context
+
+	interval time.Duration
+	maxMoves int
+	maxBW    int64
+	limiter  *rate.Limiter
+
+	lk     sync.Mutex
+	status storiface.RebalanceStatus
+}
+
+func newRebalancer(ctx context.Context, interval time.Duration, maxBandwidth int64, maxMoves int) *rebalancer {
+	r := &rebalancer{
+		ctx:      ctx,
+		interval: interval,
+		maxMoves: maxMoves,
+		maxBW:    maxBandwidth,
+	}
+
+	if maxBandwidth > 0 {
+		burst := rebalanceBurst
+		if maxBandwidth < rebalanceBurst {
+			burst = int(maxBandwidth)
+		}
+		r.limiter = rate.NewLimiter(rate.Limit(maxBandwidth), burst)
+	}
+
+	return r
+}
+
+// rebalancePlan lists the sealed sectors in the local long-term storage paths,
+// and plans their moves
+func (m *Manager) rebalancePlan(ctx context.Context) ([]storiface.RebalanceMove, error) {
+	local, err := m.localStore.Local(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("getting local paths: %w", err)
+	}
+
+	decls, err := m.index.StorageList(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("listing sectors: %w", err)
+	}
+
+	var paths []rebalancePath
+	var sectors []rebalanceSector
+
+	for _, lp := range local {
+		if !lp.CanStore {
+			continue
+		}
+
+		info, err := m.index.StorageInfo(ctx, lp.ID)
+		if err != nil {
+			return nil, xerrors.Errorf("getting storage info of %s: %w", lp.ID, err)
+		}
+		st, err := m.localStore.FsStat(ctx, lp.ID)
+		if err != nil {
+			return nil, xerrors.Errorf("getting storage stat of %s: %w", lp.ID, err)
+		}
+
+		p := rebalancePath{
+			id:       lp.ID,
+			capacity: st.Capacity,
+			used:     st.Capacity - st.FSAvailable,
+			weight:   lp.Weight,
+			drain:    info.Drain,
+		}
+		if st.Max > 0 {
+			p.capacity, p.used = st.Max, st.Used
+		}
+		paths = append(paths, p)
+
+		for _, d := range decls[lp.ID] {
+			for _, set := range []storiface.SectorFileType{storiface.FTSealed | storiface.FTCache, storiface.FTUpdate | storiface.FTUpdateCache} {
+				if d.SectorFileType&set != set {
+					continue
+				}
+
+				fileType := set
+				if p.drain && d.SectorFileType.Has(storiface.FTUnsealed) && set == storiface.FTSealed|storiface.FTCache {
+					// nothing is left in draining paths
+					fileType |= storiface.FTUnsealed
+				}
+
+				size, err := m.localStore.SectorDiskUsage(lp.ID, d.SectorID, fileType)
+				if err != nil {
+					log.Warnw("rebalance: getting sector disk usage", "sector", d.SectorID, "path", lp.ID, "error", err)
+					continue
+				}
+
+				sectors = append(sectors, rebalanceSector{
+					sector:   d.SectorID,
+					fileType: fileType,
+					path:     lp.ID,
+					size:     size,
+				})
+			}
+		}
+	}
+
+	return planRebalance(paths, sectors, m.rebalance.maxMoves), nil
+}
+
+// StorageRebalance plans the moves of sectors between local storage paths, and
+// starts them unless dryRun is set
+func (m *Manager) StorageRebalance(ctx context.Context, dryRun bool) ([]storiface.RebalanceMove, error) {
+	r := m.rebalance
+
+	r.lk.Lock()
+	running := r.status.Running
+	r.lk.Unlock()
+	if running && !dryRun {
+		return nil, xerrors.Errorf("rebalance already running")
+	}
+
+	moves, err := m.rebalancePlan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun || len(moves) == 0 {
+		return moves, nil
+	}
+
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	if r.status.Running {
+		return nil, xerrors.Errorf("rebalance already running")
+	}
+	r.status.Running = true
+	r.status.Started = time.Now()
+	r.status.Moves = append([]storiface.RebalanceMove(nil), moves...)
+
+	go m.runRebalance()
+
+	return moves, nil
+}
+
+func (m *Manager) runRebalance() {
+	r := m.rebalance
+
+	r.lk.Lock()
+	n := len(r.status.Moves)
+	r.lk.Unlock()
+
+	for i := 0; i < n; i++ {
+		r.lk.Lock()
+		mv := r.status.Moves[i]
+		r.status.Moves[i].Started = time.Now()
+		r.lk.Unlock()
+
+		log.Infow("rebalance: moving sector", "sector", mv.Sector, "types", mv.FileType, "from", mv.From, "to", mv.To, "size", mv.Size)
+
+		err := m.localStore.MoveSector(r.ctx, mv.Sector, mv.FileType, mv.From, mv.To, r.limiter, func(copied int64) {
+			r.lk.Lock()
+			r.status.Moves[i].Copied = copied
+			r.lk.Unlock()
+		})
+
+		r.lk.Lock()
+		r.status.Moves[i].Done = time.Now()
+		if err != nil {
+			log.Errorw("rebalance: moving sector", "sector", mv.Sector, "error", err)
+			r.status.Moves[i].Err = err.Error()
+		}
+		r.lk.Unlock()
+
+		if r.ctx.Err() != nil {
+			break
+		}
+	}
+
+	r.lk.Lock()
+	r.status.Running = false
+	r.lk.Unlock()
+}
+
+// runRebalanceLoop rebalances the local storage paths at the configured
+// interval
+func (m *Manager) runRebalanceLoop() {
+	r := m.rebalance
+
+	for {
+		select {
+		case <-time.After(r.interval):
+		case <-r.ctx.Done():
+			return
+		}
+
+		moves, err := m.StorageRebalance(r.ctx, false)
+		if err != nil {
+			log.Debugw("rebalance: not started", "error", err)
+			continue
+		}
+		if len(moves) > 0 {
+			log.Infow("rebalance: started", "moves", len(moves))
+		}
+	}
+}
+
+func (m *Manager) StorageRebalanceStatus(ctx context.Context) (storiface.RebalanceStatus, error) {
+	r := m.rebalance
+
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	out := r.status
+	out.MaxBandwidth = r.maxBW
+	out.Interval = r.interval
+	out.Moves = append([]storiface.RebalanceMove(nil), r.status.Moves...)
+
+	return out, nil
+}
+
+func (m *Manager) StorageSetDrain(ctx context.Context, id storiface.ID, drain bool) error {
+	return m.localStore.SetDrain(ctx, id, drain)
+}
@@ -0,0 +1,86 @@
+//stm: #unit
+package sealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+func TestPlanRebalance(t *testing.T) {
+	const ft = storiface.FTSealed | storiface.FTCache
+
+	sectors := func(path storiface.ID, first abi.SectorNumber, n int) []rebalanceSector {
+		var out []rebalanceSector
+		for i := 0; i < n; i++ {
+			out = append(out, rebalanceSector{
+				sector:   abi.SectorID{Miner: 1000, Number: first + abi.SectorNumber(i)},
+				fileType: ft,
+				path:     path,
+				size:     10,
+			})
+		}
+		return out
+	}
+
+	t.Run("balanced", func(t *testing.T) {
+		paths := []rebalancePath{
+			{id: "a", capacity: 100, used: 50, weight: 10},
+			{id: "b", capacity: 100, used: 50, weight: 10},
+		}
+		require.Empty(t, planRebalance(paths, append(sectors("a", 0, 5), sectors("b", 5, 5)...), 0))
+	})
+
+	t.Run("uneven", func(t *testing.T) {
+		paths := []rebalancePath{
+			{id: "a", capacity: 100, used: 80, weight: 10},
+			{id: "b", capacity: 100, used: 20, weight: 10},
+		}
+		moves := planRebalance(paths, append(sectors("a", 0, 8), sectors("b", 8, 2)...), 0)
+		require.Len(t, moves, 3)
+		for _, mv := range moves {
+			require.Equal(t, storiface.ID("a"), mv.From)
+			require.Equal(t, storiface.ID("b"), mv.To)
+		}
+
+		// capped
+		require.Len(t, planRebalance(paths, append(sectors("a", 0, 8), sectors("b", 8, 2)...), 1), 1)
+	})
+
+	t.Run("weights", func(t *testing.T) {
+		paths := []rebalancePath{
+			{id: "a", capacity: 100, used: 40, weight: 10},
+			{id: "b", capacity: 100, used: 40, weight: 30},
+		}
+		// targets are 20 and 60
+		moves := planRebalance(paths, append(sectors("a", 0, 4), sectors("b", 4, 4)...), 0)
+		require.Len(t, moves, 2)
+		require.Equal(t, storiface.ID("b"), moves[0].To)
+	})
+
+	t.Run("drain", func(t *testing.T) {
+		paths := []rebalancePath{
+			{id: "a", capacity: 100, used: 30, weight: 10, drain: true},
+			{id: "b", capacity: 100, used: 30, weight: 10},
+			{id: "c", capacity: 100, used: 30, weight: 10},
+		}
+		moves := planRebalance(paths, append(append(sectors("a", 0, 3), sectors("b", 3, 3)...), sectors("c", 6, 3)...), 0)
+		require.Len(t, moves, 3)
+		for _, mv := range moves {
+			require.Equal(t, storiface.ID("a"), mv.From)
+			require.NotEqual(t, storiface.ID("a"), mv.To)
+		}
+	})
+
+	t.Run("no-room", func(t *testing.T) {
+		paths := []rebalancePath{
+			{id: "a", capacity: 100, used: 50, weight: 10, drain: true},
+			{id: "b", capacity: 60, used: 55, weight: 10},
+		}
+		require.Empty(t, planRebalance(paths, sectors("a", 0, 5), 0))
+	})
+}
@@ -0,0 +1,108 @@
+package remoteprover
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+type client struct {
+	url    string
+	header http.Header
+}
+
+func (c *client) request(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.url+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = c.header.Clone()
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		_ = resp.Body.Close()
+		return nil, xerrors.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return resp, nil
+}
+
+func (c *client) do(ctx context.Context, method, path string, body io.Reader, out interface{}) error {
+	resp, err := c.request(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return xerrors.Errorf("decoding response of %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+func jobPath(id string, elems ...string) string {
+	p := "/v0/jobs/" + url.PathEscape(id)
+	for _, e := range elems {
+		p += "/" + url.PathEscape(e)
+	}
+	return p
+}
+
+func (c *client) createJob(ctx context.Context, req JobRequest) (*Job, error) {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var job Job
+	if err := c.do(ctx, http.MethodPost, "/v0/jobs", bytes.NewReader(b), &job); err != nil {
+		return nil, err
+	}
+	if job.ID == "" {
+		return nil, xerrors.Errorf("prover returned a job without ID")
+	}
+	return &job, nil
+}
+
+func (c *client) upload(ctx context.Context, id, name string, r io.Reader) error {
+	return c.do(ctx, http.MethodPut, jobPath(id, "artifacts", name), r, nil)
+}
+
+func (c *client) start(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodPost, jobPath(id, "start"), nil, nil)
+}
+
+func (c *client) job(ctx context.Context, id string) (*Job, error) {
+	var job Job
+	if err := c.do(ctx, http.MethodGet, jobPath(id), nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (c *client) download(ctx context.Context, id, name string) (io.ReadCloser, error) {
+	resp, err := c.request(ctx, http.MethodGet, jobPath(id, "artifacts", name), nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (c *client) delete(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, jobPath(id), nil, nil)
+}
@@ -0,0 +1,123 @@
+// Package remoteprover dispatches the PreCommit2 and Commit2 tasks of a worker
+// to external compute services, such as GPU farms or proving services.
+//
+// # Protocol
+//
+// A prover is an HTTP service. Requests carry the configured token as a bearer
+// token in the Authorization header, and the bodies are JSON unless noted:
+//
+//	POST   /v0/jobs                        create a job: JobRequest -> Job
+//	PUT    /v0/jobs/{id}/artifacts/{name}  upload an input artifact (raw bytes)
+//	POST   /v0/jobs/{id}/start             queue the job, once its inputs are uploaded
+//	GET    /v0/jobs/{id}                   status of the job: Job
+//	GET    /v0/jobs/{id}/artifacts/{name}  download an output artifact (raw bytes)
+//	DELETE /v0/jobs/{id}                   cancel the job and drop its artifacts
+//
+// A PreCommit2 job takes the PreCommit1 output as input, and the sealed file
+// ("sealed") and a tar archive of the cache directory ("cache") as input
+// artifacts. Its output is the JSON encoded storiface.SectorCids, with the
+// updated "sealed" and "cache" artifacts. A Commit2 job takes the Commit1
+// output as input, without artifacts, and its output is the proof.
+//
+// The jobs are queued by the prover, which runs them at its own pace; the
+// client polls the status of the job until it is done or failed, and deletes
+// it once the outputs are downloaded.
+//
+// # Attestation
+//
+// The result of a job carries the SHA256 of each output artifact, which the
+// client checks when downloading them, and an attestation: the HMAC-SHA256,
+// keyed with a secret shared by the prover and the miner, of the job, its
+// input and its result (see Attest). When a key is configured, results without
+// a valid attestation are rejected.
+package remoteprover
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"sort"
+
+	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+const (
+	ArtifactSealed = "sealed"
+	ArtifactCache  = "cache"
+)
+
+type JobState string
+
+const (
+	// JobCreated jobs wait for their input artifacts to be uploaded
+	JobCreated JobState = "created"
+	JobQueued  JobState = "queued"
+	JobRunning JobState = "running"
+	JobDone    JobState = "done"
+	JobFailed  JobState = "failed"
+)
+
+type JobRequest struct {
+	Task   sealtasks.TaskType
+	Sector storiface.SectorRef
+	Input  []byte
+}
+
+type Job struct {
+	ID    string
+	State JobState
+
+	// QueuePosition of a queued job, 0 being the next one to run
+	QueuePosition int `json:",omitempty"`
+
+	// Error of a failed job
+	Error string `json:",omitempty"`
+	// Result of a done job
+	Result *Result `json:",omitempty"`
+}
+
+type Result struct {
+	Output []byte
+
+	// Artifacts are the SHA256 of the output artifacts, by name
+	Artifacts map[string][]byte `json:",omitempty"`
+
+	Attestation []byte `json:",omitempty"`
+}
+
+// Attest returns the attestation of the result of a job, which is the
+// HMAC-SHA256 of the length prefixed (uint64, big endian) job ID, task, sector
+// ("miner/number/proof type"), SHA256 of the input, output, and the names and
+// SHA256 of the output artifacts sorted by name.
+func Attest(key []byte, jobID string, req JobRequest, res Result) []byte {
+	h := hmac.New(sha256.New, key)
+
+	writeField(h, []byte(jobID))
+	writeField(h, []byte(req.Task))
+	writeField(h, []byte(fmt.Sprintf("%d/%d/%d", req.Sector.ID.Miner, req.Sector.ID.Number, req.Sector.ProofType)))
+	in := sha256.Sum256(req.Input)
+	writeField(h, in[:])
+	writeField(h, res.Output)
+
+	names := make([]string, 0, len(res.Artifacts))
+	for name := range res.Artifacts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeField(h, []byte(name))
+		writeField(h, res.Artifacts[name])
+	}
+
+	return h.Sum(nil)
+}
+
+func writeField(h hash.Hash, b []byte) {
+	var l [8]byte
+	binary.BigEndian.PutUint64(l[:], uint64(len(b)))
+	_, _ = h.Write(l[:])
+	_, _ = h.Write(b)
+}
@@ -0,0 +1,225 @@
+package remoteprover
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
+)
+
+var log = logging.Logger("remoteprover")
+
+// Tasks are the tasks which can be dispatched to a prover
+var Tasks = []sealtasks.TaskType{sealtasks.TTPreCommit2, sealtasks.TTCommit2}
+
+const defaultPollInterval = 10 * time.Second
+
+type Config struct {
+	// URL of the prover service
+	URL string
+	// Token sent as bearer token to the prover, when set
+	Token string
+	// Tasks dispatched to the prover, by short name (PC2, C2)
+	Tasks []string
+	// AttestationKey is the hex encoded key of the attestations of the results;
+	// the results aren't checked when empty
+	AttestationKey string
+	// Fallback runs the task locally when the prover fails
+	Fallback bool
+	// PollInterval of the status of the jobs, 10s when 0
+	PollInterval time.Duration
+	// Timeout of the jobs, including their time in the queue and the transfer
+	// of the artifacts; 0 means no timeout
+	Timeout time.Duration
+}
+
+// Prover is a remote prover service
+type Prover struct {
+	c *client
+
+	tasks        map[sealtasks.TaskType]struct{}
+	key          []byte
+	fallback     bool
+	pollInterval time.Duration
+	timeout      time.Duration
+}
+
+func NewProver(cfg Config) (*Prover, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, xerrors.Errorf("parsing prover URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, xerrors.Errorf("prover URL %q: expected an http or https URL", cfg.URL)
+	}
+
+	p := &Prover{
+		c: &client{
+			url:    strings.TrimSuffix(cfg.URL, "/"),
+			header: http.Header{},
+		},
+		tasks:        map[sealtasks.TaskType]struct{}{},
+		fallback:     cfg.Fallback,
+		pollInterval: cfg.PollInterval,
+		timeout:      cfg.Timeout,
+	}
+	if cfg.Token != "" {
+		p.c.header.Set("Authorization", "Bearer "+cfg.Token)
+	}
+	if p.pollInterval <= 0 {
+		p.pollInterval = defaultPollInterval
+	}
+
+	if len(cfg.Tasks) == 0 {
+		return nil, xerrors.Errorf("prover %s: no tasks", cfg.URL)
+	}
+	for _, name := range cfg.Tasks {
+		tt, err := parseTask(name)
+		if err != nil {
+			return nil, xerrors.Errorf("prover %s: %w", cfg.URL, err)
+		}
+		p.tasks[tt] = struct{}{}
+	}
+
+	if cfg.AttestationKey != "" {
+		if p.key, err = hex.DecodeString(cfg.AttestationKey); err != nil {
+			return nil, xerrors.Errorf("prover %s: decoding attestation key: %w", cfg.URL, err)
+		}
+	}
+
+	return p, nil
+}
+
+func parseTask(name string) (sealtasks.TaskType, error) {
+	for _, tt := range Tasks {
+		if strings.EqualFold(name, tt.Short()) {
+			return tt, nil
+		}
+	}
+	return "", xerrors.Errorf("task %q can't be dispatched to a prover, expected PC2 or C2", name)
+}
+
+func (p *Prover) String() string {
+	return p.c.url
+}
+
+// run runs a job on the prover: it uploads the input artifacts returned by the
+// inputs functions, waits for the result, checks its attestation, and passes
+// the output artifacts to download
+func (p *Prover) run(ctx context.Context, req JobRequest, inputs map[string]func() (io.ReadCloser, error), outputs []string, download func(name string, r io.Reader) error) (*Result, error) {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	job, err := p.c.createJob(ctx, req)
+	if err != nil {
+		return nil, xerrors.Errorf("creating job: %w", err)
+	}
+	defer func() {
+		// drop the artifacts of the job, or cancel it
+		if err := p.c.delete(context.Background(), job.ID); err != nil {
+			log.Warnw("deleting job", "prover", p, "job", job.ID, "error", err)
+		}
+	}()
+
+	for name, open := range inputs {
+		r, err := open()
+		if err != nil {
+			return nil, xerrors.Errorf("opening %s artifact: %w", name, err)
+		}
+		err = p.c.upload(ctx, job.ID, name, r)
+		_ = r.Close()
+		if err != nil {
+			return nil, xerrors.Errorf("uploading %s artifact: %w", name, err)
+		}
+	}
+
+	if err := p.c.start(ctx, job.ID); err != nil {
+		return nil, xerrors.Errorf("starting job: %w", err)
+	}
+	log.Infow("job started", "prover", p, "job", job.ID, "task", req.Task.Short(), "sector", req.Sector.ID)
+
+	res, err := p.wait(ctx, job.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.key != nil && !hmac.Equal(res.Attestation, Attest(p.key, job.ID, req, *res)) {
+		return nil, xerrors.Errorf("job %s: invalid result attestation", job.ID)
+	}
+
+	for _, name := range outputs {
+		digest, ok := res.Artifacts[name]
+		if !ok {
+			return nil, xerrors.Errorf("job %s: missing %s artifact", job.ID, name)
+		}
+
+		if err := p.download(ctx, job.ID, name, digest, download); err != nil {
+			return nil, xerrors.Errorf("downloading %s artifact: %w", name, err)
+		}
+	}
+
+	return res, nil
+}
+
+func (p *Prover) wait(ctx context.Context, id string) (*Result, error) {
+	for {
+		job, err := p.c.job(ctx, id)
+		if err != nil {
+			return nil, xerrors.Errorf("getting job status: %w", err)
+		}
+
+		switch job.State {
+		case JobDone:
+			if job.Result == nil {
+				return nil, xerrors.Errorf("job %s done without result", id)
+			}
+			return job.Result, nil
+		case JobFailed:
+			return nil, xerrors.Errorf("job %s failed: %s", id, job.Error)
+		case JobQueued:
+			log.Debugw("job queued", "prover", p, "job", id, "position", job.QueuePosition)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, xerrors.Errorf("waiting for job %s: %w", id, ctx.Err())
+		case <-time.After(p.pollInterval):
+		}
+	}
+}
+
+func (p *Prover) download(ctx context.Context, id, name string, digest []byte, download func(name string, r io.Reader) error) error {
+	rc, err := p.c.download(ctx, id, name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close() // nolint
+
+	h := sha256.New()
+	if err := download(name, io.TeeReader(rc, h)); err != nil {
+		return err
+	}
+	// read what the consumer left, eg. the padding of a tar archive
+	if _, err := io.Copy(h, rc); err != nil {
+		return err
+	}
+
+	if !bytes.Equal(h.Sum(nil), digest) {
+		return xerrors.Errorf("digest mismatch")
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+//stm: #unit
+package remoteprover
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+var testCid, _ = cid.Decode("bafyreicmaj5hhoy5mgqvamfhgexxyergw7hdeshizghodwkjg6qmpoco7i")
+
+type testJob struct {
+	req     JobRequest
+	inputs  map[string][]byte
+	outputs map[string][]byte
+	job     Job
+}
+
+// testProver is a prover service running the jobs when they are started
+type testProver struct {
+	key  []byte
+	fail bool
+
+	lk      sync.Mutex
+	jobs    map[string]*testJob
+	deleted []string
+}
+
+func (p *testProver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v0/jobs"), "/")
+	if len(parts) == 1 {
+		var req JobRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		id := fmt.Sprint(len(p.jobs) + 1)
+		p.jobs[id] = &testJob{req: req, inputs: map[string][]byte{}, job: Job{ID: id, State: JobCreated}}
+		_ = json.NewEncoder(w).Encode(p.jobs[id].job)
+		return
+	}
+
+	j, ok := p.jobs[parts[1]]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		_ = json.NewEncoder(w).Encode(j.job)
+	case len(parts) == 2 && r.Method == http.MethodDelete:
+		p.deleted = append(p.deleted, j.job.ID)
+	case len(parts) == 3 && parts[2] == "start":
+		p.run(j)
+	case len(parts) == 4 && r.Method == http.MethodPut:
+		j.inputs[parts[3]], _ = ioutil.ReadAll(r.Body)
+	case len(parts) == 4 && r.Method == http.MethodGet:
+		_, _ = w.Write(j.outputs[parts[3]])
+	default:
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+	}
+}
+
+func (p *testProver) run(j *testJob) {
+	if p.fail {
+		j.job.State, j.job.Error = JobFailed, "out of GPUs"
+		return
+	}
+
+	res := Result{Artifacts: map[string][]byte{}}
+	switch j.req.Task {
+	case sealtasks.TTCommit2:
+		res.Output = append([]byte("proof-"), j.req.Input...)
+	case sealtasks.TTPreCommit2:
+		res.Output, _ = json.Marshal(storiface.SectorCids{Sealed: testCid, Unsealed: testCid})
+
+		var cache bytes.Buffer
+		tw := tar.NewWriter(&cache)
+		_ = tw.WriteHeader(&tar.Header{Name: "p_aux", Size: 3, Mode: 0644})
+		_, _ = tw.Write([]byte("aux"))
+		_ = tw.Close()
+
+		j.outputs = map[string][]byte{
+			ArtifactSealed: append(j.inputs[ArtifactSealed], "-pc2"...),
+			ArtifactCache:  cache.Bytes(),
+		}
+	}
+	for name, b := range j.outputs {
+		digest := sha256.Sum256(b)
+		res.Artifacts[name] = digest[:]
+	}
+	res.Attestation = Attest(p.key, j.job.ID, j.req, res)
+
+	j.job.State, j.job.Result = JobDone, &res
+}
+
+func newTestProver(t *testing.T, key []byte, cfg Config) (*testProver, *Prover) {
+	tp := &testProver{key: key, jobs: map[string]*testJob{}}
+	srv := httptest.NewServer(tp)
+	t.Cleanup(srv.Close)
+
+	cfg.URL = srv.URL
+	cfg.PollInterval = time.Millisecond
+	p, err := NewProver(cfg)
+	require.NoError(t, err)
+	return tp, p
+}
+
+type localStorage struct {
+	storiface.Storage
+}
+
+func (localStorage) SealPreCommit2(ctx context.Context, sector storiface.SectorRef, pc1o storiface.PreCommit1Out) (storiface.SectorCids, error) {
+	return storiface.SectorCids{}, xerrors.New("local PreCommit2")
+}
+
+func (localStorage) SealCommit2(ctx context.Context, sector storiface.SectorRef, c1o storiface.Commit1Out) (storiface.Proof, error) {
+	return storiface.Proof("local-proof"), nil
+}
+
+type testSectors struct {
+	paths storiface.SectorPaths
+}
+
+func (s *testSectors) AcquireSector(ctx context.Context, id storiface.SectorRef, existing storiface.SectorFileType, allocate storiface.SectorFileType, ptype storiface.PathType) (storiface.SectorPaths, func(), error) {
+	return s.paths, func() {}, nil
+}
+
+var testSector = storiface.SectorRef{
+	ID:        abi.SectorID{Miner: 1000, Number: 1},
+	ProofType: abi.RegisteredSealProof_StackedDrg2KiBV1_1,
+}
+
+func TestCommit2(t *testing.T) {
+	key := []byte("secret")
+	tp, p := newTestProver(t, key, Config{Tasks: []string{"C2"}, AttestationKey: hex.EncodeToString(key)})
+
+	s := Wrap(localStorage{}, nil, []*Prover{p})
+
+	proof, err := s.SealCommit2(context.Background(), testSector, []byte("c1o"))
+	require.NoError(t, err)
+	require.Equal(t, storiface.Proof("proof-c1o"), proof)
+	require.Equal(t, []string{"1"}, tp.deleted)
+
+	// the tasks not dispatched to the prover run locally
+	_, err = s.SealPreCommit2(context.Background(), testSector, nil)
+	require.EqualError(t, err, "local PreCommit2")
+}
+
+func TestAttestation(t *testing.T) {
+	key := hex.EncodeToString([]byte("secret"))
+
+	_, p := newTestProver(t, []byte("other"), Config{Tasks: []string{"C2"}, AttestationKey: key})
+	_, err := Wrap(localStorage{}, nil, []*Prover{p}).SealCommit2(context.Background(), testSector, []byte("c1o"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid result attestation")
+
+	_, p = newTestProver(t, []byte("other"), Config{Tasks: []string{"C2"}, AttestationKey: key, Fallback: true})
+	proof, err := Wrap(localStorage{}, nil, []*Prover{p}).SealCommit2(context.Background(), testSector, []byte("c1o"))
+	require.NoError(t, err)
+	require.Equal(t, storiface.Proof("local-proof"), proof)
+}
+
+func TestFailedJob(t *testing.T) {
+	tp, p := newTestProver(t, nil, Config{Tasks: []string{"C2"}})
+	tp.fail = true
+
+	_, err := Wrap(localStorage{}, nil, []*Prover{p}).SealCommit2(context.Background(), testSector, []byte("c1o"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "out of GPUs")
+	require.Equal(t, []string{"1"}, tp.deleted)
+}
+
+func TestPreCommit2(t *testing.T) {
+	dir := t.TempDir()
+	sp := &testSectors{paths: storiface.SectorPaths{
+		ID:     testSector.ID,
+		Sealed: filepath.Join(dir, "sealed"),
+		Cache:  filepath.Join(dir, "cache"),
+	}}
+	require.NoError(t, os.Mkdir(sp.paths.Cache, 0755))
+	require.NoError(t, ioutil.WriteFile(sp.paths.Sealed, []byte("sealed"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(sp.paths.Cache, "layer-1"), []byte("layer"), 0644))
+
+	tp, p := newTestProver(t, nil, Config{Tasks: []string{"pc2"}})
+
+	cids, err := Wrap(localStorage{}, sp, []*Prover{p}).SealPreCommit2(context.Background(), testSector, []byte("pc1o"))
+	require.NoError(t, err)
+	require.Equal(t, storiface.SectorCids{Sealed: testCid, Unsealed: testCid}, cids)
+
+	// the inputs were uploaded
+	j := tp.jobs["1"]
+	require.Equal(t, []byte("sealed"), j.inputs[ArtifactSealed])
+	hdr, err := tar.NewReader(bytes.NewReader(j.inputs[ArtifactCache])).Next()
+	require.NoError(t, err)
+	require.Equal(t, "layer-1", hdr.Name)
+
+	// and the sector files replaced with the outputs
+	b, err := ioutil.ReadFile(sp.paths.Sealed)
+	require.NoError(t, err)
+	require.Equal(t, "sealed-pc2", string(b))
+	b, err = ioutil.ReadFile(filepath.Join(sp.paths.Cache, "p_aux"))
+	require.NoError(t, err)
+	require.Equal(t, "aux", string(b))
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+}
+
+func TestNewProver(t *testing.T) {
+	_, err := NewProver(Config{URL: "http://prover", Tasks: []string{"PC1"}})
+	require.Error(t, err)
+
+	_, err = NewProver(Config{URL: "http://prover", Tasks: []string{"C2"}, AttestationKey: "nothex"})
+	require.Error(t, err)
+
+	_, err = NewProver(Config{URL: "prover:1234", Tasks: []string{"C2"}})
+	require.Error(t, err)
+
+	_, err = NewProver(Config{URL: "http://prover"})
+	require.Error(t, err)
+}
@@ -0,0 +1,169 @@
+package remoteprover
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/storage/sealer/ffiwrapper"
+	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+	"github.com/filecoin-project/lotus/storage/sealer/tarutil"
+)
+
+// suffix of the outputs of a remote job while they are downloaded
+const downloadSuffix = ".remote"
+
+type sealer struct {
+	storiface.Storage
+
+	sectors ffiwrapper.SectorProvider
+	provers map[sealtasks.TaskType]*Prover
+}
+
+// Wrap returns a storage running the tasks of the provers on them, and the
+// other tasks on local; when several provers run a task, the first one is
+// used. The sector files of the PreCommit2 jobs are acquired from sectors.
+func Wrap(local storiface.Storage, sectors ffiwrapper.SectorProvider, provers []*Prover) storiface.Storage {
+	if len(provers) == 0 {
+		return local
+	}
+
+	s := &sealer{
+		Storage: local,
+		sectors: sectors,
+		provers: map[sealtasks.TaskType]*Prover{},
+	}
+	for _, p := range provers {
+		for tt := range p.tasks {
+			if _, ok := s.provers[tt]; !ok {
+				s.provers[tt] = p
+			}
+		}
+	}
+	return s
+}
+
+func (s *sealer) SealPreCommit2(ctx context.Context, sector storiface.SectorRef, pc1o storiface.PreCommit1Out) (storiface.SectorCids, error) {
+	p, ok := s.provers[sealtasks.TTPreCommit2]
+	if !ok {
+		return s.Storage.SealPreCommit2(ctx, sector, pc1o)
+	}
+
+	cids, err := s.remotePreCommit2(ctx, p, sector, pc1o)
+	if err == nil {
+		return cids, nil
+	}
+	if !p.fallback || ctx.Err() != nil {
+		return storiface.SectorCids{}, xerrors.Errorf("remote PreCommit2 (prover %s): %w", p, err)
+	}
+
+	log.Warnw("remote PreCommit2 failed, running it locally", "prover", p, "sector", sector.ID, "error", err)
+	return s.Storage.SealPreCommit2(ctx, sector, pc1o)
+}
+
+func (s *sealer) remotePreCommit2(ctx context.Context, p *Prover, sector storiface.SectorRef, pc1o storiface.PreCommit1Out) (storiface.SectorCids, error) {
+	paths, done, err := s.sectors.AcquireSector(ctx, sector, storiface.FTSealed|storiface.FTCache, 0, storiface.PathSealing)
+	if err != nil {
+		return storiface.SectorCids{}, xerrors.Errorf("acquiring sector paths: %w", err)
+	}
+	defer done()
+
+	sealedTmp, cacheTmp := paths.Sealed+downloadSuffix, paths.Cache+downloadSuffix
+	defer func() {
+		_ = os.RemoveAll(sealedTmp)
+		_ = os.RemoveAll(cacheTmp)
+	}()
+
+	inputs := map[string]func() (io.ReadCloser, error){
+		ArtifactSealed: func() (io.ReadCloser, error) {
+			return os.Open(paths.Sealed)
+		},
+		ArtifactCache: func() (io.ReadCloser, error) {
+			pr, pw := io.Pipe()
+			go func() {
+				pw.CloseWithError(tarutil.TarDirectory(paths.Cache, pw, make([]byte, 1<<20)))
+			}()
+			return pr, nil
+		},
+	}
+
+	// the outputs are downloaded next to the sector files, which are only
+	// replaced once the result is checked
+	download := func(name string, r io.Reader) error {
+		switch name {
+		case ArtifactSealed:
+			f, err := os.Create(sealedTmp)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, r); err != nil {
+				_ = f.Close()
+				return err
+			}
+			return f.Close()
+		case ArtifactCache:
+			if err := os.RemoveAll(cacheTmp); err != nil {
+				return err
+			}
+			return tarutil.ExtractTar(r, cacheTmp, make([]byte, 1<<20))
+		default:
+			return xerrors.Errorf("unexpected artifact %s", name)
+		}
+	}
+
+	res, err := p.run(ctx, JobRequest{Task: sealtasks.TTPreCommit2, Sector: sector, Input: pc1o}, inputs, []string{ArtifactSealed, ArtifactCache}, download)
+	if err != nil {
+		return storiface.SectorCids{}, err
+	}
+
+	var cids storiface.SectorCids
+	if err := json.Unmarshal(res.Output, &cids); err != nil {
+		return storiface.SectorCids{}, xerrors.Errorf("decoding output: %w", err)
+	}
+	if !cids.Sealed.Defined() || !cids.Unsealed.Defined() {
+		return storiface.SectorCids{}, xerrors.Errorf("output without sector CIDs")
+	}
+
+	if err := os.Rename(sealedTmp, paths.Sealed); err != nil {
+		return storiface.SectorCids{}, xerrors.Errorf("replacing sealed file: %w", err)
+	}
+	files, err := ioutil.ReadDir(cacheTmp)
+	if err != nil {
+		return storiface.SectorCids{}, xerrors.Errorf("reading cache output: %w", err)
+	}
+	for _, f := range files {
+		if err := os.Rename(filepath.Join(cacheTmp, f.Name()), filepath.Join(paths.Cache, f.Name())); err != nil {
+			return storiface.SectorCids{}, xerrors.Errorf("moving cache file %s: %w", f.Name(), err)
+		}
+	}
+
+	return cids, nil
+}
+
+func (s *sealer) SealCommit2(ctx context.Context, sector storiface.SectorRef, c1o storiface.Commit1Out) (storiface.Proof, error) {
+	p, ok := s.provers[sealtasks.TTCommit2]
+	if !ok {
+		return s.Storage.SealCommit2(ctx, sector, c1o)
+	}
+
+	res, err := p.run(ctx, JobRequest{Task: sealtasks.TTCommit2, Sector: sector, Input: c1o}, nil, nil, nil)
+	if err == nil {
+		if len(res.Output) == 0 {
+			err = xerrors.Errorf("empty proof")
+		} else {
+			return res.Output, nil
+		}
+	}
+	if !p.fallback || ctx.Err() != nil {
+		return nil, xerrors.Errorf("remote Commit2 (prover %s): %w", p, err)
+	}
+
+	log.Warnw("remote Commit2 failed, running it locally", "prover", p, "sector", sector.ID, "error", err)
+	return s.Storage.SealCommit2(ctx, sector, c1o)
+}
@@ -68,6 +68,15 @@ type Scheduler struct {
 
 	workTracker *workTracker
 
+	prioLk     sync.Mutex
+	priorities storiface.SchedPriorities
+	prioChange chan struct{}
+	preempt    chan *preemptRequest
+
+	affinityLk    sync.RWMutex
+	affinityRules []storiface.AffinityRule
+	workerTags    map[string]map[string]struct{} // by hostname
+
 	info chan func(interface{})
 
 	closing  chan struct{}
@@ -126,6 +135,9 @@ type WorkerRequest struct {
 	prepare WorkerAction
 	work    WorkerAction
 
+	class     PriorityClass // the Priority follows the class when set
+	preempted bool          // set by the sh.runSched goroutine
+
 	start time.Time
 
 	index int // The index of the item in the heap.
@@ -168,6 +180,11 @@ func newScheduler(assigner string) (*Scheduler, error) {
 			prepared: map[uuid.UUID]trackedWork{},
 		},
 
+		prioChange: make(chan struct{}, 1),
+		preempt:    make(chan *preemptRequest),
+
+		workerTags: map[string]map[string]struct{}{},
+
 		info: make(chan func(interface{})),
 
 		closing: make(chan struct{}),
@@ -177,17 +194,20 @@ func newScheduler(assigner string) (*Scheduler, error) {
 
 func (sh *Scheduler) Schedule(ctx context.Context, sector storiface.SectorRef, taskType sealtasks.TaskType, sel WorkerSelector, prepare WorkerAction, work WorkerAction) error {
 	ret := make(chan workerResponse)
+	priority, class := sh.requestPriority(ctx)
 
 	select {
 	case sh.schedule <- &WorkerRequest{
 		Sector:   sector,
 		TaskType: taskType,
-		Priority: getPriority(ctx),
+		Priority: priority,
 		Sel:      sel,
 
 		prepare: prepare,
 		work:    work,
 
+		class: class,
+
 		start: time.Now(),
 
 		ret: ret,
@@ -228,11 +248,18 @@ type SchedDiagRequestInfo struct {
 	Sector   abi.SectorID
 	TaskType sealtasks.TaskType
 	Priority int
+
+	// Tags required by the affinity rules
+	Tags []string `json:",omitempty"`
 }
 
 type SchedDiagInfo struct {
 	Requests    []SchedDiagRequestInfo
 	OpenWindows []string
+
+	// WorkerTags by hostname
+	WorkerTags    map[string][]string      `json:",omitempty"`
+	AffinityRules []storiface.AffinityRule `json:",omitempty"`
 }
 
 func (sh *Scheduler) runSched() {
@@ -261,6 +288,13 @@ func (sh *Scheduler) runSched() {
 		case req := <-sh.windowRequests:
 			sh.OpenWindows = append(sh.OpenWindows, req)
 			doSched = true
+		case <-sh.prioChange:
+			sh.updatePriorities()
+			doSched = true
+		case preq := <-sh.preempt:
+			p := sh.maybePreempt(preq)
+			preq.done <- p
+			doSched = p
 		case ireq := <-sh.info:
 			ireq(sh.diag())
 
@@ -332,9 +366,15 @@ func (sh *Scheduler) diag() SchedDiagInfo {
 			Sector:   task.Sector.ID,
 			TaskType: task.TaskType,
 			Priority: task.Priority,
+			Tags:     sh.requiredTags(task),
 		})
 	}
 
+	out.WorkerTags = sh.allTags()
+	sh.affinityLk.RLock()
+	out.AffinityRules = sh.affinityRules
+	sh.affinityLk.RUnlock()
+
 	sh.workersLk.RLock()
 	defer sh.workersLk.RUnlock()
 
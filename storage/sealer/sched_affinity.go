@@ -0,0 +1,102 @@
+package sealer
+
+import "sort"
+
+// requiredTags returns the tags a worker must have to run req
+func (sh *Scheduler) requiredTags(req *WorkerRequest) []string {
+	sh.affinityLk.RLock()
+	defer sh.affinityLk.RUnlock()
+
+	var tags []string
+	for _, r := range sh.affinityRules {
+		if r.Matches(req.TaskType, req.Sector.ID.Number) {
+			tags = append(tags, r.Tag)
+		}
+	}
+	return tags
+}
+
+// affinityOk is true when the worker has the tags of all the affinity rules
+// matching req
+func (sh *Scheduler) affinityOk(req *WorkerRequest, w *WorkerHandle) bool {
+	sh.affinityLk.RLock()
+	defer sh.affinityLk.RUnlock()
+
+	for _, r := range sh.affinityRules {
+		if !r.Matches(req.TaskType, req.Sector.ID.Number) {
+			continue
+		}
+		if _, ok := sh.workerTags[w.Info.Hostname][r.Tag]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// HostTags returns the tags of the workers on hostname
+func (sh *Scheduler) HostTags(hostname string) []string {
+	sh.affinityLk.RLock()
+	defer sh.affinityLk.RUnlock()
+
+	return sortedTags(sh.workerTags[hostname])
+}
+
+func (sh *Scheduler) allTags() map[string][]string {
+	sh.affinityLk.RLock()
+	defer sh.affinityLk.RUnlock()
+
+	out := make(map[string][]string, len(sh.workerTags))
+	for host, tags := range sh.workerTags {
+		out[host] = sortedTags(tags)
+	}
+	return out
+}
+
+func sortedTags(tags map[string]struct{}) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	out := make([]string, 0, len(tags))
+	for tag := range tags {
+		out = append(out, tag)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// TagWorkers adds tags to the workers on hostname
+func (sh *Scheduler) TagWorkers(hostname string, tags ...string) {
+	sh.affinityLk.Lock()
+	if sh.workerTags[hostname] == nil {
+		sh.workerTags[hostname] = map[string]struct{}{}
+	}
+	for _, tag := range tags {
+		sh.workerTags[hostname][tag] = struct{}{}
+	}
+	sh.affinityLk.Unlock()
+
+	sh.tagsChanged()
+}
+
+// UntagWorkers removes tags from the workers on hostname
+func (sh *Scheduler) UntagWorkers(hostname string, tags ...string) {
+	sh.affinityLk.Lock()
+	for _, tag := range tags {
+		delete(sh.workerTags[hostname], tag)
+	}
+	if len(sh.workerTags[hostname]) == 0 {
+		delete(sh.workerTags, hostname)
+	}
+	sh.affinityLk.Unlock()
+
+	sh.tagsChanged()
+}
+
+func (sh *Scheduler) tagsChanged() {
+	// the tasks waiting for tagged workers may be schedulable now
+	select {
+	case sh.workerChange <- struct{}{}:
+	case <-sh.closing:
+	}
+}
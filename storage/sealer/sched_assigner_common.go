@@ -88,6 +88,10 @@ func (a *AssignerCommon) TrySched(sh *Scheduler) {
 					continue
 				}
 
+				if !sh.affinityOk(task, worker) {
+					continue
+				}
+
 				rpcCtx, cancel := context.WithTimeout(task.Ctx, SelectorTimeout)
 				ok, preferred, err := task.Sel.Ok(rpcCtx, task.TaskType, task.Sector.ProofType, worker)
 				cancel()
@@ -0,0 +1,169 @@
+package sealer
+
+import (
+	"context"
+	"sort"
+
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+// PriorityClass is the class of the sector of a task, which sets the priority
+// of the task according to the scheduler priorities; priorities set with
+// WithPriority take precedence over the class
+type PriorityClass string
+
+const (
+	PriorityCC        PriorityClass = "cc"
+	PriorityDeals     PriorityClass = "deals"
+	PrioritySnapDeals PriorityClass = "snap-deals"
+)
+
+type schedPrioClassCtxKey int
+
+var SchedPriorityClassKey schedPrioClassCtxKey
+
+func WithPriorityClass(ctx context.Context, class PriorityClass) context.Context {
+	return context.WithValue(ctx, SchedPriorityClassKey, class)
+}
+
+func (c PriorityClass) priority(p storiface.SchedPriorities) int {
+	switch c {
+	case PrioritySnapDeals:
+		return p.SnapDeals
+	case PriorityDeals:
+		return p.Deals
+	case PriorityCC:
+		return p.CC
+	default:
+		return DefaultSchedPriority
+	}
+}
+
+// requestPriority returns the priority of a task scheduled with ctx, and its
+// class when the priority follows the class
+func (sh *Scheduler) requestPriority(ctx context.Context) (int, PriorityClass) {
+	if _, ok := ctx.Value(SchedPriorityKey).(int); ok {
+		return getPriority(ctx), ""
+	}
+
+	if class, ok := ctx.Value(SchedPriorityClassKey).(PriorityClass); ok {
+		return class.priority(sh.Priorities()), class
+	}
+
+	return DefaultSchedPriority, ""
+}
+
+func (sh *Scheduler) Priorities() storiface.SchedPriorities {
+	sh.prioLk.Lock()
+	defer sh.prioLk.Unlock()
+
+	return sh.priorities
+}
+
+// SetPriorities changes the priorities of the tasks, including the tasks
+// waiting in the scheduler queue
+func (sh *Scheduler) SetPriorities(p storiface.SchedPriorities) {
+	sh.prioLk.Lock()
+	sh.priorities = p
+	sh.prioLk.Unlock()
+
+	select {
+	case sh.prioChange <- struct{}{}:
+	default: // there is a notification pending already
+	}
+}
+
+// updatePriorities applies the current priorities to the queued tasks, must
+// be called from the sh.runSched goroutine
+func (sh *Scheduler) updatePriorities() {
+	p := sh.Priorities()
+
+	for _, req := range *sh.SchedQueue {
+		if req.class != "" {
+			req.Priority = req.class.priority(p)
+		}
+	}
+	sort.Sort(sh.SchedQueue)
+}
+
+type preemptRequest struct {
+	wid  storiface.WorkerID
+	req  *WorkerRequest
+	done chan bool
+}
+
+// preempted is called by the workers once the prepare step of a task is done,
+// which is the last point at which the task can give its worker away without
+// losing work. It returns true when the task was queued again to let a waiting
+// task of a higher priority use the worker.
+func (sh *Scheduler) preempted(wid storiface.WorkerID, req *WorkerRequest) bool {
+	if req.preempted || !sh.Priorities().Preempt {
+		return false
+	}
+
+	preq := &preemptRequest{
+		wid:  wid,
+		req:  req,
+		done: make(chan bool, 1),
+	}
+
+	select {
+	case sh.preempt <- preq:
+	case <-req.Ctx.Done():
+		return false
+	case <-sh.closing:
+		return false
+	}
+
+	select {
+	case p := <-preq.done:
+		return p
+	case <-sh.closing:
+		return false
+	}
+}
+
+// maybePreempt queues the task of preq again if a waiting task of the same
+// type and a higher priority can run on its worker, must be called from the
+// sh.runSched goroutine. Tasks are only preempted once, so that they can't be
+// starved.
+func (sh *Scheduler) maybePreempt(preq *preemptRequest) bool {
+	req := preq.req
+	if req.preempted || !sh.Priorities().Preempt {
+		return false
+	}
+
+	sh.workersLk.RLock()
+	defer sh.workersLk.RUnlock()
+
+	worker, ok := sh.Workers[preq.wid]
+	if !ok || !worker.Enabled {
+		return false
+	}
+
+	for _, waiting := range *sh.SchedQueue {
+		if waiting.Priority <= req.Priority || waiting.TaskType != req.TaskType || !sh.affinityOk(waiting, worker) {
+			continue
+		}
+
+		rpcCtx, cancel := context.WithTimeout(waiting.Ctx, SelectorTimeout)
+		ok, _, err := waiting.Sel.Ok(rpcCtx, waiting.TaskType, waiting.Sector.ProofType, worker)
+		cancel()
+		if err != nil {
+			log.Errorf("preempt: selector error: %+v", err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		log.Infow("preempting task", "sector", req.Sector.ID, "task", req.TaskType.Short(), "priority", req.Priority,
+			"waitingSector", waiting.Sector.ID, "waitingPriority", waiting.Priority)
+
+		req.preempted = true
+		sh.SchedQueue.Push(req)
+		return true
+	}
+
+	return false
+}
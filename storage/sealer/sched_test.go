@@ -39,6 +39,107 @@ func TestWithPriority(t *testing.T) {
 	require.Equal(t, 2222, getPriority(ctx))
 }
 
+func TestPriorityClass(t *testing.T) {
+	sh, err := newScheduler("")
+	require.NoError(t, err)
+	sh.priorities = storiface.SchedPriorities{SnapDeals: 20, Deals: 10, CC: 1}
+
+	ctx := context.Background()
+
+	p, class := sh.requestPriority(ctx)
+	require.Equal(t, DefaultSchedPriority, p)
+	require.Equal(t, PriorityClass(""), class)
+
+	p, class = sh.requestPriority(WithPriorityClass(ctx, PriorityDeals))
+	require.Equal(t, 10, p)
+	require.Equal(t, PriorityDeals, class)
+
+	// explicit priorities take precedence
+	p, class = sh.requestPriority(WithPriority(WithPriorityClass(ctx, PriorityDeals), 2222))
+	require.Equal(t, 2222, p)
+	require.Equal(t, PriorityClass(""), class)
+
+	sh.SchedQueue.Push(&WorkerRequest{TaskType: sealtasks.TTPreCommit1, Priority: 20, class: PrioritySnapDeals})
+	sh.SchedQueue.Push(&WorkerRequest{TaskType: sealtasks.TTPreCommit1, Priority: 1, class: PriorityCC})
+	sh.SchedQueue.Push(&WorkerRequest{TaskType: sealtasks.TTPreCommit1, Priority: 5})
+
+	sh.SetPriorities(storiface.SchedPriorities{SnapDeals: 2, Deals: 10, CC: 30})
+	<-sh.prioChange
+	sh.updatePriorities()
+
+	var prios []int
+	for _, req := range *sh.SchedQueue {
+		prios = append(prios, req.Priority)
+	}
+	require.Equal(t, []int{30, 5, 2}, prios)
+}
+
+func TestPreempt(t *testing.T) {
+	sh, err := newScheduler("")
+	require.NoError(t, err)
+
+	wid := storiface.WorkerID(uuid.New())
+	sh.Workers[wid] = &WorkerHandle{Enabled: true}
+
+	running := &WorkerRequest{TaskType: sealtasks.TTPreCommit2, Priority: 1, Ctx: context.Background()}
+	preq := &preemptRequest{wid: wid, req: running}
+
+	// preemption disabled
+	sh.SchedQueue.Push(&WorkerRequest{TaskType: sealtasks.TTPreCommit2, Priority: 10, Sel: slowishSelector(true), Ctx: context.Background()})
+	require.False(t, sh.maybePreempt(preq))
+
+	sh.priorities.Preempt = true
+
+	// the waiting tasks of other types, lower priorities, or which can't run on the worker, don't preempt
+	sh.SchedQueue = &RequestQueue{}
+	sh.SchedQueue.Push(&WorkerRequest{TaskType: sealtasks.TTPreCommit1, Priority: 10, Sel: slowishSelector(true), Ctx: context.Background()})
+	sh.SchedQueue.Push(&WorkerRequest{TaskType: sealtasks.TTPreCommit2, Priority: 1, Sel: slowishSelector(true), Ctx: context.Background()})
+	sh.SchedQueue.Push(&WorkerRequest{TaskType: sealtasks.TTPreCommit2, Priority: 10, Sel: slowishSelector(false), Ctx: context.Background()})
+	require.False(t, sh.maybePreempt(preq))
+
+	sh.SchedQueue.Push(&WorkerRequest{TaskType: sealtasks.TTPreCommit2, Priority: 10, Sel: slowishSelector(true), Ctx: context.Background()})
+	require.True(t, sh.maybePreempt(preq))
+	require.Equal(t, 5, sh.SchedQueue.Len())
+
+	// tasks are only preempted once
+	require.False(t, sh.maybePreempt(preq))
+}
+
+func TestAffinity(t *testing.T) {
+	sh, err := newScheduler("")
+	require.NoError(t, err)
+	sh.affinityRules = []storiface.AffinityRule{
+		{Tasks: []sealtasks.TaskType{sealtasks.TTPreCommit2}, Tag: "gpu"},
+		{MinSector: 100, MaxSector: 199, Tag: "nvme-pool-1"},
+	}
+	sh.TagWorkers("gpu-host", "gpu")
+	sh.TagWorkers("nvme-host", "nvme-pool-1")
+	sh.TagWorkers("both", "gpu", "nvme-pool-1")
+
+	req := func(tt sealtasks.TaskType, sector abi.SectorNumber) *WorkerRequest {
+		return &WorkerRequest{TaskType: tt, Sector: storiface.SectorRef{ID: abi.SectorID{Number: sector}}}
+	}
+	worker := func(host string) *WorkerHandle {
+		return &WorkerHandle{Info: storiface.WorkerInfo{Hostname: host}}
+	}
+
+	require.True(t, sh.affinityOk(req(sealtasks.TTPreCommit1, 1), worker("other")))
+	require.False(t, sh.affinityOk(req(sealtasks.TTPreCommit2, 1), worker("other")))
+	require.True(t, sh.affinityOk(req(sealtasks.TTPreCommit2, 1), worker("gpu-host")))
+	require.False(t, sh.affinityOk(req(sealtasks.TTPreCommit1, 150), worker("gpu-host")))
+	require.True(t, sh.affinityOk(req(sealtasks.TTPreCommit1, 150), worker("nvme-host")))
+	require.False(t, sh.affinityOk(req(sealtasks.TTPreCommit2, 150), worker("nvme-host")))
+	require.True(t, sh.affinityOk(req(sealtasks.TTPreCommit2, 150), worker("both")))
+	require.Equal(t, []string{"gpu", "nvme-pool-1"}, sh.requiredTags(req(sealtasks.TTPreCommit2, 150)))
+
+	sh.UntagWorkers("both", "gpu")
+	require.False(t, sh.affinityOk(req(sealtasks.TTPreCommit2, 150), worker("both")))
+	require.Equal(t, []string{"nvme-pool-1"}, sh.HostTags("both"))
+
+	sh.UntagWorkers("both", "nvme-pool-1")
+	require.Equal(t, map[string][]string{"gpu-host": {"gpu"}, "nvme-host": {"nvme-pool-1"}}, sh.allTags())
+}
+
 var decentWorkerResources = storiface.WorkerResources{
 	MemPhysical: 128 << 30,
 	MemSwap:     200 << 30,
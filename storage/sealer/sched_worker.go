@@ -462,6 +462,21 @@ func (sw *schedWorker) startProcessingTask(req *WorkerRequest) error {
 		tw := sh.workTracker.worker(sw.wid, w.Info, w.workerRpc)
 		tw.start()
 		err := req.prepare(req.Ctx, tw)
+
+		if err == nil && sh.preempted(sw.wid, req) {
+			// the task is back in the scheduler queue
+			w.lk.Lock()
+			w.preparing.Free(req.SealTask(), w.Info.Resources, needRes)
+			w.lk.Unlock()
+
+			select {
+			case sw.taskDone <- struct{}{}:
+			case <-sh.closing:
+			default: // there is a notification pending already
+			}
+			return
+		}
+
 		w.lk.Lock()
 
 		if err != nil {
@@ -115,6 +115,17 @@ func (a TaskType) Short() string {
 	return n
 }
 
+// ParseShort returns the task type with the short name s, case insensitive
+func ParseShort(s string) (TaskType, error) {
+	for tt, n := range shortNames {
+		if strings.EqualFold(s, n) {
+			return tt, nil
+		}
+	}
+
+	return "", xerrors.Errorf("unknown task type %q", s)
+}
+
 type SealTaskType struct {
 	TaskType
 	abi.RegisteredSealProof
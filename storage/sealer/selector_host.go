@@ -0,0 +1,38 @@
+package sealer
+
+import (
+	"context"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
+)
+
+// hostSelector prefers the workers on a host over the other workers accepted
+// by the wrapped selector, eg. the host where an interrupted task can resume
+type hostSelector struct {
+	WorkerSelector
+	host string
+}
+
+func newHostSelector(sel WorkerSelector, host string) WorkerSelector {
+	if host == "" {
+		return sel
+	}
+
+	return &hostSelector{
+		WorkerSelector: sel,
+		host:           host,
+	}
+}
+
+func (s *hostSelector) Ok(ctx context.Context, task sealtasks.TaskType, spt abi.RegisteredSealProof, whnd *WorkerHandle) (bool, bool, error) {
+	ok, preferred, err := s.WorkerSelector.Ok(ctx, task, spt, whnd)
+	if err != nil || !ok {
+		return ok, preferred, err
+	}
+
+	return true, preferred || whnd.Info.Hostname == s.host, nil
+}
+
+var _ WorkerSelector = &hostSelector{}
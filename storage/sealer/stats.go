@@ -41,6 +41,8 @@ func (m *Manager) WorkerStats(ctx context.Context) map[uuid.UUID]storiface.Worke
 			CpuUse:     handle.active.cpuUse,
 
 			TaskCounts: map[string]int{},
+
+			Tags: m.sched.HostTags(handle.Info.Hostname),
 		}
 
 		for tt, count := range handle.active.taskCounters {
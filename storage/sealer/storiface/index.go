@@ -2,6 +2,7 @@ package storiface
 
 import (
 	"strings"
+	"time"
 
 	"github.com/filecoin-project/go-state-types/abi"
 
@@ -85,11 +86,53 @@ type StorageInfo struct {
 	// - "update-cache"
 	// Any other value will generate a warning and be ignored.
 	DenyTypes []string
+
+	// Drain is true when the path is being decommissioned
+	Drain bool
 }
 
 type HealthReport struct {
 	Stat fsutil.FsStat
 	Err  string
+
+	IO PathIOStats
+}
+
+// PathIOStats are the statistics of the sector reads from a storage path, since
+// the start of the process the path is attached to
+type PathIOStats struct {
+	Reads      uint64
+	ReadErrors uint64
+	// ReadLatency is the moving average of the time taken by the reads
+	ReadLatency time.Duration
+
+	LastErr     string
+	LastErrTime time.Time
+}
+
+// PathHealth is the health of a storage path as seen by the sector index
+type PathHealth struct {
+	ID ID
+	IO PathIOStats
+
+	LastHeartbeat time.Time
+	HeartbeatErr  string
+
+	// AtRisk lists the reasons why the sectors in the path are predicted to
+	// fail their proofs, empty for healthy paths
+	AtRisk []string
+}
+
+// AtRiskSector is a sector with files in a storage path at risk
+type AtRiskSector struct {
+	Sector   abi.SectorID
+	FileType SectorFileType
+	Storage  ID
+	Reasons  []string
+
+	// HealthyCopies is the number of copies of the files in paths which aren't
+	// at risk
+	HealthyCopies int
 }
 
 type SectorStorageInfo struct {
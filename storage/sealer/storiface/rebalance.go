@@ -0,0 +1,37 @@
+package storiface
+
+import (
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+// RebalanceMove is a move of the sealed files of a sector between two storage
+// paths by the storage rebalancer
+type RebalanceMove struct {
+	Sector   abi.SectorID
+	FileType SectorFileType
+	From     ID
+	To       ID
+	// Size is the number of bytes to copy
+	Size int64
+
+	Copied  int64
+	Started time.Time
+	Done    time.Time
+	Err     string
+}
+
+type RebalanceStatus struct {
+	Running bool
+	Started time.Time
+
+	// MaxBandwidth is the number of bytes copied per second, 0 when unlimited
+	MaxBandwidth int64
+	// Interval at which the rebalancer runs in the background, 0 when it only
+	// runs on demand
+	Interval time.Duration
+
+	// Moves of the current or last run
+	Moves []RebalanceMove
+}
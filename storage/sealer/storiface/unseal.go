@@ -0,0 +1,51 @@
+package storiface
+
+import (
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+// UnsealClass is the class of an unseal request, which sets its priority in
+// the unseal queue
+type UnsealClass string
+
+const (
+	// UnsealRetrieval requests serve retrievals, they are unsealed first
+	UnsealRetrieval UnsealClass = "retrieval"
+	// UnsealTransfer requests serve deal data transfers, this is the class of
+	// requests which aren't marked as retrievals
+	UnsealTransfer UnsealClass = "transfer"
+)
+
+type UnsealJob struct {
+	Sector abi.SectorID
+	Class  UnsealClass
+	// Path is the storage path the sector is unsealed from
+	Path ID
+
+	Queued  time.Time
+	Started time.Time
+}
+
+// UnsealedCopy is an unsealed copy of a sector created by an unseal request
+type UnsealedCopy struct {
+	Sector   abi.SectorID
+	Size     abi.SectorSize
+	LastUsed time.Time
+}
+
+type UnsealQueue struct {
+	// MaxPerPath is the number of sectors unsealed at the same time from a
+	// storage path, 0 when unlimited
+	MaxPerPath int
+
+	Running []UnsealJob
+	Waiting []UnsealJob
+
+	// CacheMaxBytes is the size above which the least recently used unsealed
+	// copies are removed, 0 when unlimited
+	CacheMaxBytes int64
+	CacheBytes    int64
+	Cached        []UnsealedCopy
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/proof"
@@ -76,6 +77,9 @@ type WorkerStats struct {
 	CpuUse     uint64  // nolint
 
 	TaskCounts map[string]int
+
+	// Tags of the worker, set on its hostname
+	Tags []string `json:",omitempty"`
 }
 
 const (
@@ -103,6 +107,61 @@ type WorkerJob struct {
 	Hostname string `json:",omitempty"` // optional, set for ret-wait jobs
 }
 
+// SchedPriorities are the scheduling priorities of the sealing tasks by class
+// of sector, larger values more important
+type SchedPriorities struct {
+	SnapDeals int
+	Deals     int
+	CC        int
+
+	// Preempt makes the prepared tasks yield their worker to waiting tasks of a
+	// higher priority, before they start running
+	Preempt bool
+}
+
+// AffinityRule restricts the sealing tasks it matches to the workers with its
+// tag; tasks matching several rules need all their tags
+type AffinityRule struct {
+	// Tasks matched by the rule, all tasks when empty
+	Tasks []sealtasks.TaskType
+	// MinSector and MaxSector are the range of sector numbers matched by the
+	// rule, inclusive; MaxSector 0 means no upper bound
+	MinSector abi.SectorNumber
+	MaxSector abi.SectorNumber
+
+	Tag string
+}
+
+func (r AffinityRule) Validate() error {
+	if r.Tag == "" {
+		return xerrors.Errorf("no tag")
+	}
+	if r.MaxSector != 0 && r.MaxSector < r.MinSector {
+		return xerrors.Errorf("empty sector range %d-%d", r.MinSector, r.MaxSector)
+	}
+	for _, tt := range r.Tasks {
+		if tt.Short() == "UNK" {
+			return xerrors.Errorf("unknown task type %q", tt)
+		}
+	}
+	return nil
+}
+
+func (r AffinityRule) Matches(task sealtasks.TaskType, sector abi.SectorNumber) bool {
+	if sector < r.MinSector || (r.MaxSector != 0 && sector > r.MaxSector) {
+		return false
+	}
+	if len(r.Tasks) == 0 {
+		return true
+	}
+	for _, tt := range r.Tasks {
+		if tt == task {
+			return true
+		}
+	}
+	return false
+}
+
 type CallID struct {
 	Sector abi.SectorID
 	ID     uuid.UUID
@@ -153,6 +212,15 @@ type PostSectorChallenge struct {
 	Update       bool
 }
 
+// VanillaCheck is the result of the generation of the vanilla proof of the
+// PoSt challenges of a sector
+type VanillaCheck struct {
+	Sector  abi.SectorNumber
+	Elapsed time.Duration
+	// Err is set when the proof couldn't be generated
+	Err string `json:",omitempty"`
+}
+
 type FallbackChallenges struct {
 	Sectors    []abi.SectorNumber
 	Challenges map[abi.SectorNumber][]uint64
@@ -0,0 +1,310 @@
+package sealer
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+type unsealClassCtxKey int
+
+var UnsealClassKey unsealClassCtxKey
+
+// WithUnsealClass sets the class of the unseal requests made with ctx
+func WithUnsealClass(ctx context.Context, class storiface.UnsealClass) context.Context {
+	return context.WithValue(ctx, UnsealClassKey, class)
+}
+
+func unsealClass(ctx context.Context) storiface.UnsealClass {
+	if class, ok := ctx.Value(UnsealClassKey).(storiface.UnsealClass); ok {
+		return class
+	}
+	return storiface.UnsealTransfer
+}
+
+func unsealPriority(class storiface.UnsealClass) int {
+	if class == storiface.UnsealRetrieval {
+		return 1
+	}
+	return 0
+}
+
+type unsealRequest struct {
+	job   storiface.UnsealJob
+	ready chan struct{}
+}
+
+// unsealQueue orders the unseal requests by class, limits the number of
+// sectors unsealed at the same time from each storage path, and tracks the
+// unsealed copies created by the requests
+type unsealQueue struct {
+	lk sync.Mutex
+
+	maxPerPath int
+	perPath    map[storiface.ID]int
+	running    []*unsealRequest
+	waiting    []*unsealRequest
+
+	cacheMax   int64
+	cacheBytes int64
+	cached     map[abi.SectorID]*storiface.UnsealedCopy
+}
+
+func newUnsealQueue(maxPerPath int, cacheMax int64) *unsealQueue {
+	return &unsealQueue{
+		maxPerPath: maxPerPath,
+		perPath:    map[storiface.ID]int{},
+		cacheMax:   cacheMax,
+		cached:     map[abi.SectorID]*storiface.UnsealedCopy{},
+	}
+}
+
+// acquire waits for the turn of the sector to be unsealed from path, and
+// returns the function releasing its slot
+func (q *unsealQueue) acquire(ctx context.Context, sector abi.SectorID, class storiface.UnsealClass, path storiface.ID) (func(), error) {
+	req := &unsealRequest{
+		job: storiface.UnsealJob{
+			Sector: sector,
+			Class:  class,
+			Path:   path,
+			Queued: time.Now(),
+		},
+		ready: make(chan struct{}),
+	}
+
+	q.lk.Lock()
+	q.waiting = append(q.waiting, req)
+	q.dispatch()
+	q.lk.Unlock()
+
+	release := func() {
+		q.lk.Lock()
+		defer q.lk.Unlock()
+
+		q.finish(req)
+		q.dispatch()
+	}
+
+	select {
+	case <-req.ready:
+		return release, nil
+	case <-ctx.Done():
+	}
+
+	q.lk.Lock()
+	defer q.lk.Unlock()
+
+	select {
+	case <-req.ready: // started in the meantime
+		q.finish(req)
+	default:
+		for i, w := range q.waiting {
+			if w == req {
+				q.waiting = append(q.waiting[:i], q.waiting[i+1:]...)
+				break
+			}
+		}
+	}
+	q.dispatch()
+
+	return nil, ctx.Err()
+}
+
+// dispatch starts the waiting requests with a free slot on their path, the
+// higher classes first, must be called with q.lk held
+func (q *unsealQueue) dispatch() {
+	// stable, the requests of a class are started in order of arrival
+	sort.SliceStable(q.waiting, func(i, j int) bool {
+		return unsealPriority(q.waiting[i].job.Class) > unsealPriority(q.waiting[j].job.Class)
+	})
+
+	var waiting []*unsealRequest
+	for _, req := range q.waiting {
+		if q.maxPerPath > 0 && q.perPath[req.job.Path] >= q.maxPerPath {
+			waiting = append(waiting, req)
+			continue
+		}
+
+		q.perPath[req.job.Path]++
+		req.job.Started = time.Now()
+		q.running = append(q.running, req)
+		close(req.ready)
+	}
+	q.waiting = waiting
+}
+
+// finish must be called with q.lk held
+func (q *unsealQueue) finish(req *unsealRequest) {
+	for i, r := range q.running {
+		if r == req {
+			q.running = append(q.running[:i], q.running[i+1:]...)
+			break
+		}
+	}
+
+	q.perPath[req.job.Path]--
+	if q.perPath[req.job.Path] <= 0 {
+		delete(q.perPath, req.job.Path)
+	}
+}
+
+// cacheAdd tracks the unsealed copy of a sector created by an unseal request
+func (q *unsealQueue) cacheAdd(sector abi.SectorID, size abi.SectorSize) {
+	q.lk.Lock()
+	defer q.lk.Unlock()
+
+	if c, ok := q.cached[sector]; ok {
+		c.LastUsed = time.Now()
+		return
+	}
+
+	q.cached[sector] = &storiface.UnsealedCopy{
+		Sector:   sector,
+		Size:     size,
+		LastUsed: time.Now(),
+	}
+	q.cacheBytes += int64(size)
+}
+
+func (q *unsealQueue) cacheTouch(sector abi.SectorID) {
+	q.lk.Lock()
+	defer q.lk.Unlock()
+
+	if c, ok := q.cached[sector]; ok {
+		c.LastUsed = time.Now()
+	}
+}
+
+func (q *unsealQueue) cacheRemove(sector abi.SectorID) {
+	q.lk.Lock()
+	defer q.lk.Unlock()
+
+	if c, ok := q.cached[sector]; ok {
+		q.cacheBytes -= int64(c.Size)
+		delete(q.cached, sector)
+	}
+}
+
+// cacheEvictable returns the unsealed copies to remove to bring the cache under
+// its max size, least recently used first, never including keep
+func (q *unsealQueue) cacheEvictable(keep abi.SectorID) []abi.SectorID {
+	q.lk.Lock()
+	defer q.lk.Unlock()
+
+	if q.cacheMax <= 0 || q.cacheBytes <= q.cacheMax {
+		return nil
+	}
+
+	var out []abi.SectorID
+	over := q.cacheBytes - q.cacheMax
+	for _, c := range q.cachedLRU() {
+		if over <= 0 {
+			break
+		}
+		if c.Sector == keep {
+			continue
+		}
+		out = append(out, c.Sector)
+		over -= int64(c.Size)
+	}
+	return out
+}
+
+// cachedLRU must be called with q.lk held
+func (q *unsealQueue) cachedLRU() []storiface.UnsealedCopy {
+	out := make([]storiface.UnsealedCopy, 0, len(q.cached))
+	for _, c := range q.cached {
+		out = append(out, *c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].LastUsed.Before(out[j].LastUsed)
+	})
+	return out
+}
+
+func (q *unsealQueue) info() storiface.UnsealQueue {
+	q.lk.Lock()
+	defer q.lk.Unlock()
+
+	out := storiface.UnsealQueue{
+		MaxPerPath:    q.maxPerPath,
+		CacheMaxBytes: q.cacheMax,
+		CacheBytes:    q.cacheBytes,
+		Cached:        q.cachedLRU(),
+	}
+	for _, r := range q.running {
+		out.Running = append(out.Running, r.job)
+	}
+	for _, r := range q.waiting {
+		out.Waiting = append(out.Waiting, r.job)
+	}
+	return out
+}
+
+// sealedPath returns the storage path of the sealed copy of a sector, which
+// unseals are limited by
+func (m *Manager) sealedPath(ctx context.Context, sector abi.SectorID) storiface.ID {
+	for _, ft := range []storiface.SectorFileType{storiface.FTSealed, storiface.FTUpdate} {
+		paths, err := m.index.StorageFindSector(ctx, sector, ft, 0, false)
+		if err != nil {
+			log.Warnw("finding sealed sector", "sector", sector, "error", err)
+			continue
+		}
+
+		for _, p := range paths {
+			if p.Primary {
+				return p.ID
+			}
+		}
+		if len(paths) > 0 {
+			return paths[0].ID
+		}
+	}
+
+	return ""
+}
+
+// evictUnsealed removes the least recently used unsealed copies until the
+// cache is under its max size; copies being read are skipped
+func (m *Manager) evictUnsealed(ctx context.Context, keep abi.SectorID) {
+	for _, sector := range m.unseals.cacheEvictable(keep) {
+		if err := m.removeUnsealed(ctx, sector); err != nil {
+			log.Warnw("evicting unsealed copy", "sector", sector, "error", err)
+			continue
+		}
+
+		log.Infow("evicted unsealed copy", "sector", sector)
+		m.unseals.cacheRemove(sector)
+	}
+}
+
+func (m *Manager) removeUnsealed(ctx context.Context, sector abi.SectorID) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	locked, err := m.index.StorageTryLock(ctx, sector, storiface.FTNone, storiface.FTUnsealed)
+	if err != nil {
+		return xerrors.Errorf("acquiring sector lock: %w", err)
+	}
+	if !locked {
+		return xerrors.Errorf("unsealed copy in use")
+	}
+
+	return m.storage.Remove(ctx, sector, storiface.FTUnsealed, true, nil)
+}
+
+// UnsealedRead records a read from the unsealed copy of a sector
+func (m *Manager) UnsealedRead(sector abi.SectorID) {
+	m.unseals.cacheTouch(sector)
+}
+
+func (m *Manager) UnsealQueue(ctx context.Context) (storiface.UnsealQueue, error) {
+	return m.unseals.info(), nil
+}
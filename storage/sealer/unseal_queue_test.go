@@ -0,0 +1,114 @@
+//stm: #unit
+package sealer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+func TestUnsealQueuePriority(t *testing.T) {
+	ctx := context.Background()
+	q := newUnsealQueue(1, 0)
+
+	sector := func(n abi.SectorNumber) abi.SectorID {
+		return abi.SectorID{Miner: 1000, Number: n}
+	}
+
+	release, err := q.acquire(ctx, sector(1), storiface.UnsealTransfer, "a")
+	require.NoError(t, err)
+
+	// other paths aren't limited by the running unseal
+	releaseB, err := q.acquire(ctx, sector(2), storiface.UnsealTransfer, "b")
+	require.NoError(t, err)
+	releaseB()
+
+	started := make(chan abi.SectorNumber, 3)
+	for _, r := range []struct {
+		n     abi.SectorNumber
+		class storiface.UnsealClass
+	}{
+		{3, storiface.UnsealTransfer},
+		{4, storiface.UnsealTransfer},
+		{5, storiface.UnsealRetrieval},
+	} {
+		r := r
+		go func() {
+			rel, err := q.acquire(ctx, sector(r.n), r.class, "a")
+			if err != nil {
+				return
+			}
+			started <- r.n
+			rel()
+		}()
+
+		require.Eventually(t, func() bool {
+			return len(q.info().Waiting) == int(r.n)-2
+		}, time.Second, time.Millisecond)
+	}
+
+	info := q.info()
+	require.Len(t, info.Running, 1)
+	require.Equal(t, storiface.UnsealRetrieval, info.Waiting[0].Class)
+
+	release()
+
+	// retrievals first, then in order of arrival
+	require.Equal(t, abi.SectorNumber(5), <-started)
+	require.Equal(t, abi.SectorNumber(3), <-started)
+	require.Equal(t, abi.SectorNumber(4), <-started)
+
+	require.Empty(t, q.info().Running)
+}
+
+func TestUnsealQueueCancel(t *testing.T) {
+	q := newUnsealQueue(1, 0)
+
+	release, err := q.acquire(context.Background(), abi.SectorID{Number: 1}, storiface.UnsealTransfer, "a")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := q.acquire(ctx, abi.SectorID{Number: 2}, storiface.UnsealRetrieval, "a")
+		done <- err
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(q.info().Waiting) == 1
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+	require.Empty(t, q.info().Waiting)
+
+	release()
+	require.Empty(t, q.info().Running)
+}
+
+func TestUnsealQueueCacheEvictable(t *testing.T) {
+	q := newUnsealQueue(0, 2048)
+
+	for n := abi.SectorNumber(1); n <= 3; n++ {
+		q.cacheAdd(abi.SectorID{Number: n}, 1024)
+		time.Sleep(time.Millisecond)
+	}
+	require.Equal(t, int64(3072), q.info().CacheBytes)
+
+	// sector 1 was read last, sector 2 is the least recently used
+	q.cacheTouch(abi.SectorID{Number: 1})
+	require.Equal(t, []abi.SectorID{{Number: 2}}, q.cacheEvictable(abi.SectorID{Number: 3}))
+
+	// the kept sector is never evicted
+	require.Equal(t, []abi.SectorID{{Number: 3}}, q.cacheEvictable(abi.SectorID{Number: 2}))
+
+	q.cacheRemove(abi.SectorID{Number: 2})
+	require.Empty(t, q.cacheEvictable(abi.SectorID{}))
+	require.Equal(t, int64(2048), q.info().CacheBytes)
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/filecoin-project/lotus/storage/paths"
 	"github.com/filecoin-project/lotus/storage/sealer/ffiwrapper"
+	"github.com/filecoin-project/lotus/storage/sealer/remoteprover"
 	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
 )
@@ -41,6 +42,10 @@ type WorkerConfig struct {
 
 	MaxParallelChallengeReads int           // 0 = no limit
 	ChallengeReadTimeout      time.Duration // 0 = no timeout
+
+	// RemoteProvers run the tasks they are configured for instead of the
+	// worker
+	RemoteProvers []*remoteprover.Prover
 }
 
 // used do provide custom proofs impl (mostly used in testing)
@@ -59,6 +64,8 @@ type LocalWorker struct {
 	// see equivalent field on WorkerConfig.
 	ignoreResources bool
 
+	remoteProvers []*remoteprover.Prover
+
 	ct          *workerCallTracker
 	acceptTasks map[sealtasks.TaskType]struct{}
 	running     sync.WaitGroup
@@ -92,6 +99,7 @@ func newLocalWorker(executor ExecutorFunc, wcfg WorkerConfig, envLookup EnvFunc,
 		noSwap:               wcfg.NoSwap,
 		envLookup:            envLookup,
 		ignoreResources:      wcfg.IgnoreResourceFiltering,
+		remoteProvers:        wcfg.RemoteProvers,
 		challengeReadTimeout: wcfg.ChallengeReadTimeout,
 		session:              uuid.New(),
 		closing:              make(chan struct{}),
@@ -173,7 +181,11 @@ func (l *localWorkerPathProvider) AcquireSector(ctx context.Context, sector stor
 }
 
 func (l *LocalWorker) ffiExec() (storiface.Storage, error) {
-	return ffiwrapper.New(&localWorkerPathProvider{w: l})
+	sb, err := ffiwrapper.New(&localWorkerPathProvider{w: l})
+	if err != nil {
+		return nil, err
+	}
+	return remoteprover.Wrap(sb, &localWorkerPathProvider{w: l}, l.remoteProvers), nil
 }
 
 type ReturnType string
@@ -727,7 +739,7 @@ func (l *LocalWorker) Paths(ctx context.Context) ([]storiface.StoragePath, error
 	return l.localStore.Local(ctx)
 }
 
-func (l *LocalWorker) memInfo() (memPhysical, memUsed, memSwap, memSwapUsed uint64, err error) {
+func hostMemInfo(noSwap bool) (memPhysical, memUsed, memSwap, memSwapUsed uint64, err error) {
 	h, err := sysinfo.Host()
 	if err != nil {
 		return 0, 0, 0, 0, err
@@ -765,7 +777,7 @@ func (l *LocalWorker) memInfo() (memPhysical, memUsed, memSwap, memSwapUsed uint
 		}
 	}
 
-	if l.noSwap {
+	if noSwap {
 		memSwap = 0
 		memSwapUsed = 0
 	}
@@ -773,20 +785,38 @@ func (l *LocalWorker) memInfo() (memPhysical, memUsed, memSwap, memSwapUsed uint
 	return memPhysical, memUsed, memSwap, memSwapUsed, nil
 }
 
-func (l *LocalWorker) Info(context.Context) (storiface.WorkerInfo, error) {
-	hostname, err := os.Hostname() // TODO: allow overriding from config
+// HostResources returns the memory, CPUs and GPUs of the host, as reported by
+// the workers running on it
+func HostResources(noSwap bool) (storiface.WorkerResources, error) {
+	gpus, err := ffi.GetGPUDevices()
 	if err != nil {
-		panic(err)
+		log.Errorf("getting gpu devices failed: %+v", err)
 	}
 
-	gpus, err := ffi.GetGPUDevices()
+	memPhysical, memUsed, memSwap, memSwapUsed, err := hostMemInfo(noSwap)
 	if err != nil {
-		log.Errorf("getting gpu devices failed: %+v", err)
+		return storiface.WorkerResources{}, xerrors.Errorf("getting memory info: %w", err)
 	}
 
-	memPhysical, memUsed, memSwap, memSwapUsed, err := l.memInfo()
+	return storiface.WorkerResources{
+		MemPhysical: memPhysical,
+		MemUsed:     memUsed,
+		MemSwap:     memSwap,
+		MemSwapUsed: memSwapUsed,
+		CPUs:        uint64(runtime.NumCPU()),
+		GPUs:        gpus,
+	}, nil
+}
+
+func (l *LocalWorker) Info(context.Context) (storiface.WorkerInfo, error) {
+	hostname, err := os.Hostname() // TODO: allow overriding from config
 	if err != nil {
-		return storiface.WorkerInfo{}, xerrors.Errorf("getting memory info: %w", err)
+		panic(err)
+	}
+
+	res, err := HostResources(l.noSwap)
+	if err != nil {
+		return storiface.WorkerInfo{}, err
 	}
 
 	resEnv, err := storiface.ParseResourceEnv(func(key, def string) (string, bool) {
@@ -796,18 +826,12 @@ func (l *LocalWorker) Info(context.Context) (storiface.WorkerInfo, error) {
 		return storiface.WorkerInfo{}, xerrors.Errorf("interpreting resource env vars: %w", err)
 	}
 
+	res.Resources = resEnv
+
 	return storiface.WorkerInfo{
 		Hostname:        hostname,
 		IgnoreResources: l.ignoreResources,
-		Resources: storiface.WorkerResources{
-			MemPhysical: memPhysical,
-			MemUsed:     memUsed,
-			MemSwap:     memSwap,
-			MemSwapUsed: memSwapUsed,
-			CPUs:        uint64(runtime.NumCPU()),
-			GPUs:        gpus,
-			Resources:   resEnv,
-		},
+		Resources:       res,
 	}, nil
 }
 
@@ -0,0 +1,45 @@
+package workerboot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// Register registers a worker with the miner API at url, eg.
+// http://10.0.0.1:2345
+func Register(ctx context.Context, url string, token string, p Probe) (Registration, error) {
+	b, err := json.Marshal(&RegisterRequest{Token: token, Probe: p})
+	if err != nil {
+		return Registration{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(url, "/")+RegisterPath, bytes.NewReader(b))
+	if err != nil {
+		return Registration{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return Registration{}, err
+	}
+	defer resp.Body.Close() // nolint
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return Registration{}, xerrors.Errorf("registering worker: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var reg Registration
+	if err := json.NewDecoder(resp.Body).Decode(&reg); err != nil {
+		return Registration{}, xerrors.Errorf("decoding registration: %w", err)
+	}
+	return reg, nil
+}
@@ -0,0 +1,277 @@
+package workerboot
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+)
+
+var log = logging.Logger("workerboot")
+
+// the tokens are stored by their hash in the metadata datastore until they are
+// used or expire
+var tokenPrefix = datastore.NewKey("/workerboot/tokens")
+
+// the templates of the registered hosts, by hostname
+var hostPrefix = datastore.NewKey("/workerboot/hosts")
+
+// ErrInvalidToken is returned for unknown, used and expired tokens
+var ErrInvalidToken = errors.New("invalid or expired registration token")
+
+func tokenKey(token string) datastore.Key {
+	h := sha256.Sum256([]byte(token))
+	return tokenPrefix.ChildString(hex.EncodeToString(h[:]))
+}
+
+type tokenInfo struct {
+	// Template assigned to the worker; matched on the probe when empty
+	Template string
+	Expiry   time.Time
+}
+
+// RegisterRequest is the body of a registration
+type RegisterRequest struct {
+	Token string
+	Probe Probe
+}
+
+// Registration is the answer to a registration
+type Registration struct {
+	// APIToken of the miner API, with the admin permission the workers need
+	APIToken string
+	Template Template
+}
+
+// Registry registers the workers presenting a one-time token
+type Registry struct {
+	ds        datastore.Batching
+	templates []Template
+
+	// newAPIToken creates the API token of a registered worker
+	newAPIToken func(ctx context.Context, hostname string) (string, error)
+	// tag gives the tags of its template to the host of a registered worker
+	tag func(ctx context.Context, hostname string, tags []string) error
+
+	lk sync.Mutex
+}
+
+func NewRegistry(ds datastore.Batching, templates []Template, newAPIToken func(ctx context.Context, hostname string) (string, error), tag func(ctx context.Context, hostname string, tags []string) error) (*Registry, error) {
+	names := map[string]struct{}{}
+	for _, t := range templates {
+		if err := t.Validate(); err != nil {
+			return nil, err
+		}
+		if _, dup := names[t.Name]; dup {
+			return nil, xerrors.Errorf("duplicate template %s", t.Name)
+		}
+		names[t.Name] = struct{}{}
+	}
+
+	return &Registry{
+		ds:          ds,
+		templates:   templates,
+		newAPIToken: newAPIToken,
+		tag:         tag,
+	}, nil
+}
+
+// CreateToken creates a one-time registration token, valid for ttl unless it
+// is zero. The workers registering with it get the named template, or the
+// first template their host satisfies when template is empty.
+func (r *Registry) CreateToken(ctx context.Context, template string, ttl time.Duration) (string, error) {
+	if ttl < 0 {
+		return "", xerrors.Errorf("negative ttl %s", ttl)
+	}
+	if template != "" {
+		if _, ok := r.template(template); !ok {
+			return "", xerrors.Errorf("unknown template %s", template)
+		}
+	}
+
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", xerrors.Errorf("generating token: %w", err)
+	}
+	token := hex.EncodeToString(b[:])
+
+	info := tokenInfo{Template: template}
+	if ttl > 0 {
+		info.Expiry = time.Now().Add(ttl)
+	}
+
+	ib, err := json.Marshal(&info)
+	if err != nil {
+		return "", err
+	}
+	if err := r.ds.Put(ctx, tokenKey(token), ib); err != nil {
+		return "", xerrors.Errorf("storing token: %w", err)
+	}
+
+	return token, nil
+}
+
+func (r *Registry) template(name string) (Template, bool) {
+	for _, t := range r.templates {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Template{}, false
+}
+
+// match returns the template of a worker; workers get an empty template when
+// none are configured
+func (r *Registry) match(name string, p Probe) (Template, error) {
+	if name != "" {
+		t, ok := r.template(name)
+		if !ok {
+			return Template{}, xerrors.Errorf("template %s was removed", name)
+		}
+		if err := t.check(p); err != nil {
+			return Template{}, xerrors.Errorf("host %s has %w", p.Hostname, err)
+		}
+		return t, nil
+	}
+
+	if len(r.templates) == 0 {
+		return Template{}, nil
+	}
+
+	var errs []error
+	for _, t := range r.templates {
+		err := t.check(p)
+		if err == nil {
+			return t, nil
+		}
+		errs = append(errs, err)
+	}
+	return Template{}, xerrors.Errorf("host %s satisfies no template: %v", p.Hostname, errs)
+}
+
+// Register consumes the token, and returns the API token and the template of
+// the worker. Workers not satisfying their template don't consume the token.
+func (r *Registry) Register(ctx context.Context, token string, p Probe) (Registration, error) {
+	if p.Hostname == "" {
+		return Registration{}, xerrors.Errorf("missing hostname")
+	}
+
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	key := tokenKey(token)
+	ib, err := r.ds.Get(ctx, key)
+	if err == datastore.ErrNotFound {
+		return Registration{}, ErrInvalidToken
+	}
+	if err != nil {
+		return Registration{}, xerrors.Errorf("getting token: %w", err)
+	}
+
+	var info tokenInfo
+	if err := json.Unmarshal(ib, &info); err != nil {
+		return Registration{}, xerrors.Errorf("decoding token: %w", err)
+	}
+	if !info.Expiry.IsZero() && time.Now().After(info.Expiry) {
+		if err := r.ds.Delete(ctx, key); err != nil {
+			log.Errorw("removing expired token", "error", err)
+		}
+		return Registration{}, ErrInvalidToken
+	}
+
+	t, err := r.match(info.Template, p)
+	if err != nil {
+		return Registration{}, err
+	}
+
+	if err := r.ds.Delete(ctx, key); err != nil {
+		return Registration{}, xerrors.Errorf("consuming token: %w", err)
+	}
+
+	apiToken, err := r.newAPIToken(ctx, p.Hostname)
+	if err != nil {
+		return Registration{}, xerrors.Errorf("creating API token: %w", err)
+	}
+
+	if len(t.Tags) > 0 && r.tag != nil {
+		if err := r.tag(ctx, p.Hostname, t.Tags); err != nil {
+			return Registration{}, xerrors.Errorf("tagging host %s: %w", p.Hostname, err)
+		}
+	}
+	if err := r.ds.Put(ctx, hostPrefix.ChildString(p.Hostname), []byte(t.Name)); err != nil {
+		return Registration{}, xerrors.Errorf("storing template of host %s: %w", p.Hostname, err)
+	}
+
+	log.Infow("worker registered", "hostname", p.Hostname, "template", t.Name, "cpus", p.Resources.CPUs, "memory", p.Resources.MemPhysical, "gpus", len(p.Resources.GPUs))
+
+	return Registration{APIToken: apiToken, Template: t}, nil
+}
+
+// RestoreTags gives the registered hosts the tags of their template again, as
+// the tags given at runtime are lost when the miner restarts
+func (r *Registry) RestoreTags(ctx context.Context) error {
+	if r.tag == nil {
+		return nil
+	}
+
+	res, err := r.ds.Query(ctx, query.Query{Prefix: hostPrefix.String()})
+	if err != nil {
+		return xerrors.Errorf("listing registered hosts: %w", err)
+	}
+	defer res.Close() // nolint:errcheck
+
+	for e := range res.Next() {
+		if e.Error != nil {
+			return xerrors.Errorf("listing registered hosts: %w", e.Error)
+		}
+
+		t, ok := r.template(string(e.Value))
+		if !ok || len(t.Tags) == 0 {
+			continue
+		}
+		hostname := datastore.NewKey(e.Key).BaseNamespace()
+		if err := r.tag(ctx, hostname, t.Tags); err != nil {
+			return xerrors.Errorf("tagging host %s: %w", hostname, err)
+		}
+	}
+	return nil
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var rr RegisterRequest
+	if err := json.NewDecoder(req.Body).Decode(&rr); err != nil {
+		http.Error(w, "decoding request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reg, err := r.Register(req.Context(), rr.Token, rr.Probe)
+	switch {
+	case err == ErrInvalidToken:
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	case err != nil:
+		log.Warnw("worker registration failed", "hostname", rr.Probe.Hostname, "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&reg); err != nil {
+		log.Errorw("writing registration", "error", err)
+	}
+}
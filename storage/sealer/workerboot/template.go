@@ -0,0 +1,135 @@
+// Package workerboot registers new workers with a miner without copying its API
+// token to each of them.
+//
+// The operator creates a one-time token on the miner, and starts the worker
+// with it. The worker probes the resources of its host (memory, CPUs, GPUs and
+// the space of its storage) and presents them with the token:
+//
+//	POST /worker/register   RegisterRequest -> Registration
+//
+// The miner consumes the token, picks the template the probe satisfies, tags
+// the host with the tags of the template, and answers with an API token for
+// the worker and the settings of the template.
+package workerboot
+
+import (
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+// RegisterPath is the path of the registration endpoint of the miner API
+const RegisterPath = "/worker/register"
+
+// TaskFlags are the flags of 'lotus-worker run' enabling the tasks a template
+// can assign to a worker
+var TaskFlags = map[sealtasks.TaskType]string{
+	sealtasks.TTAddPiece:            "addpiece",
+	sealtasks.TTPreCommit1:          "precommit1",
+	sealtasks.TTUnseal:              "unseal",
+	sealtasks.TTPreCommit2:          "precommit2",
+	sealtasks.TTCommit2:             "commit",
+	sealtasks.TTReplicaUpdate:       "replica-update",
+	sealtasks.TTProveReplicaUpdate2: "prove-replica-update2",
+	sealtasks.TTRegenSectorKey:      "regen-sector-key",
+	sealtasks.TTGenerateWindowPoSt:  "windowpost",
+	sealtasks.TTGenerateWinningPoSt: "winningpost",
+}
+
+// Template is the configuration applied to the workers registering on hosts
+// with enough resources
+type Template struct {
+	Name string
+
+	// Requirements of the host; 0 means no requirement
+	MinCPUs    uint64
+	MinMemory  uint64
+	MinGPUs    int
+	MinStorage int64
+
+	// Tasks run by the worker, by short name; the worker keeps the tasks of
+	// its flags when empty
+	Tasks []string
+	// Tags given to the workers on the host
+	Tags []string
+	// Env sets environment variables of the worker, such as the resource
+	// overrides (PC1_MAX_PARALLELISM, ...)
+	Env map[string]string
+
+	NoSwap             bool
+	ParallelFetchLimit int
+	AllocationPolicy   string
+}
+
+// TaskTypes returns the tasks of the template
+func (t Template) TaskTypes() ([]sealtasks.TaskType, error) {
+	var out []sealtasks.TaskType
+	for _, name := range t.Tasks {
+		tt, err := sealtasks.ParseShort(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := TaskFlags[tt]; !ok {
+			return nil, xerrors.Errorf("task %s can't be assigned to a worker", name)
+		}
+		out = append(out, tt)
+	}
+	return out, nil
+}
+
+func (t Template) Validate() error {
+	if t.Name == "" {
+		return xerrors.Errorf("template without a name")
+	}
+
+	tts, err := t.TaskTypes()
+	if err != nil {
+		return xerrors.Errorf("template %s: %w", t.Name, err)
+	}
+	for _, tt := range tts {
+		if tt.WorkerType() != tts[0].WorkerType() {
+			return xerrors.Errorf("template %s: tasks %s and %s can't run on the same worker", t.Name, tts[0].Short(), tt.Short())
+		}
+	}
+
+	return nil
+}
+
+// ProbedPath is a storage path of a worker
+type ProbedPath struct {
+	Path      string
+	Capacity  int64
+	Available int64
+}
+
+// Probe describes the host of a registering worker
+type Probe struct {
+	Hostname  string
+	Resources storiface.WorkerResources
+	Paths     []ProbedPath
+}
+
+// check returns why the host doesn't satisfy the requirements of the
+// template, nil when it does
+func (t Template) check(p Probe) error {
+	if p.Resources.CPUs < t.MinCPUs {
+		return xerrors.Errorf("%d CPUs, template %s requires %d", p.Resources.CPUs, t.Name, t.MinCPUs)
+	}
+	if p.Resources.MemPhysical < t.MinMemory {
+		return xerrors.Errorf("%d bytes of memory, template %s requires %d", p.Resources.MemPhysical, t.Name, t.MinMemory)
+	}
+	if len(p.Resources.GPUs) < t.MinGPUs {
+		return xerrors.Errorf("%d GPUs, template %s requires %d", len(p.Resources.GPUs), t.Name, t.MinGPUs)
+	}
+
+	var avail int64
+	for _, path := range p.Paths {
+		avail += path.Available
+	}
+	if avail < t.MinStorage {
+		return xerrors.Errorf("%d bytes of storage available, template %s requires %d", avail, t.Name, t.MinStorage)
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+//stm: #unit
+package workerboot
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/lotus/storage/sealer/sealtasks"
+	"github.com/filecoin-project/lotus/storage/sealer/storiface"
+)
+
+func TestRegister(t *testing.T) {
+	ctx := context.Background()
+
+	templates := []Template{
+		{Name: "gpu", MinGPUs: 1, Tasks: []string{"PC2", "C2"}, Tags: []string{"gpu"}},
+		{Name: "pc1", MinMemory: 256 << 30, Tasks: []string{"PC1"}, Env: map[string]string{"PC1_MAX_PARALLELISM": "2"}},
+	}
+
+	tags := map[string][]string{}
+	r, err := NewRegistry(dssync.MutexWrap(datastore.NewMapDatastore()), templates, func(ctx context.Context, hostname string) (string, error) {
+		return "api-" + hostname, nil
+	}, func(ctx context.Context, hostname string, t []string) error {
+		tags[hostname] = t
+		return nil
+	})
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	gpuHost := Probe{Hostname: "gpu-1", Resources: storiface.WorkerResources{CPUs: 32, MemPhysical: 128 << 30, GPUs: []string{"A100"}}}
+	pc1Host := Probe{Hostname: "pc1-1", Resources: storiface.WorkerResources{CPUs: 64, MemPhysical: 512 << 30}}
+	smallHost := Probe{Hostname: "small-1", Resources: storiface.WorkerResources{CPUs: 8, MemPhysical: 32 << 30}}
+
+	t.Run("match", func(t *testing.T) {
+		token, err := r.CreateToken(ctx, "", 0)
+		require.NoError(t, err)
+
+		reg, err := Register(ctx, srv.URL, token, gpuHost)
+		require.NoError(t, err)
+		require.Equal(t, "api-gpu-1", reg.APIToken)
+		require.Equal(t, "gpu", reg.Template.Name)
+		require.Equal(t, []string{"gpu"}, tags["gpu-1"])
+
+		tts, err := reg.Template.TaskTypes()
+		require.NoError(t, err)
+		require.Equal(t, []sealtasks.TaskType{sealtasks.TTPreCommit2, sealtasks.TTCommit2}, tts)
+
+		// tokens are used once
+		_, err = Register(ctx, srv.URL, token, gpuHost)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), ErrInvalidToken.Error())
+	})
+
+	t.Run("no-match", func(t *testing.T) {
+		token, err := r.CreateToken(ctx, "", 0)
+		require.NoError(t, err)
+
+		_, err = Register(ctx, srv.URL, token, smallHost)
+		require.Error(t, err)
+
+		// the token wasn't consumed
+		reg, err := Register(ctx, srv.URL, token, pc1Host)
+		require.NoError(t, err)
+		require.Equal(t, "pc1", reg.Template.Name)
+		require.Equal(t, "2", reg.Template.Env["PC1_MAX_PARALLELISM"])
+	})
+
+	t.Run("pinned", func(t *testing.T) {
+		token, err := r.CreateToken(ctx, "pc1", 0)
+		require.NoError(t, err)
+
+		_, err = r.Register(ctx, token, gpuHost)
+		require.Error(t, err)
+
+		_, err = r.CreateToken(ctx, "unknown", 0)
+		require.Error(t, err)
+	})
+
+	t.Run("restore-tags", func(t *testing.T) {
+		restored := map[string][]string{}
+		r2, err := NewRegistry(r.ds, templates, nil, func(ctx context.Context, hostname string, t []string) error {
+			restored[hostname] = t
+			return nil
+		})
+		require.NoError(t, err)
+		require.NoError(t, r2.RestoreTags(ctx))
+		require.Equal(t, map[string][]string{"gpu-1": {"gpu"}}, restored)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		token, err := r.CreateToken(ctx, "", time.Nanosecond)
+		require.NoError(t, err)
+		time.Sleep(time.Millisecond)
+
+		_, err = r.Register(ctx, token, gpuHost)
+		require.Equal(t, ErrInvalidToken, err)
+	})
+}
+
+func TestTemplateValidate(t *testing.T) {
+	require.NoError(t, Template{Name: "a", Tasks: []string{"pc1", "PC2"}}.Validate())
+	require.Error(t, Template{Tasks: []string{"PC1"}}.Validate())
+	require.Error(t, Template{Name: "a", Tasks: []string{"FIN"}}.Validate())
+	require.Error(t, Template{Name: "a", Tasks: []string{"PC1", "WDP"}}.Validate())
+}
@@ -0,0 +1,126 @@
+package wdpost
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/crypto"
+
+	"github.com/filecoin-project/lotus/api"
+)
+
+// DryRun generates the vanilla proofs of the challenges of the next occurrence
+// of a deadline, without computing or submitting the snarks, so that the
+// problems with the sector files show up before the deadline opens. Until the
+// challenge epoch of the deadline is reached, the challenges are drawn from the
+// randomness of the chain head instead.
+func (s *WindowPoStScheduler) DryRun(ctx context.Context, dlIdx uint64) (api.WdPoStDryRun, error) {
+	ts, err := s.api.ChainHead(ctx)
+	if err != nil {
+		return api.WdPoStDryRun{}, xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	di, err := s.api.StateMinerProvingDeadline(ctx, s.actor, ts.Key())
+	if err != nil {
+		return api.WdPoStDryRun{}, xerrors.Errorf("getting deadline: %w", err)
+	}
+	if dlIdx >= di.WPoStPeriodDeadlines {
+		return api.WdPoStDryRun{}, xerrors.Errorf("deadline %d out of range, there are %d deadlines", dlIdx, di.WPoStPeriodDeadlines)
+	}
+
+	// the next occurrence of the deadline, the current one when it's open
+	shift := abi.ChainEpoch((dlIdx+di.WPoStPeriodDeadlines-di.Index)%di.WPoStPeriodDeadlines) * di.WPoStChallengeWindow
+	out := api.WdPoStDryRun{
+		Deadline:  dlIdx,
+		Height:    ts.Height(),
+		Open:      di.Open + shift,
+		Close:     di.Close + shift,
+		Challenge: di.Challenge + shift,
+	}
+
+	s.proofTimeLk.Lock()
+	out.SnarkTime = s.partitionProofTime
+	s.proofTimeLk.Unlock()
+
+	buf := new(bytes.Buffer)
+	if err := s.actor.MarshalCBOR(buf); err != nil {
+		return api.WdPoStDryRun{}, xerrors.Errorf("failed to marshal address to cbor: %w", err)
+	}
+
+	randEpoch := ts.Height()
+	if out.Challenge <= ts.Height() {
+		randEpoch = out.Challenge
+		out.RealChallenges = true
+	}
+	rand, err := s.api.StateGetRandomnessFromBeacon(ctx, crypto.DomainSeparationTag_WindowedPoStChallengeSeed, randEpoch, buf.Bytes(), ts.Key())
+	if err != nil {
+		return api.WdPoStDryRun{}, xerrors.Errorf("getting chain randomness from beacon (epoch %d): %w", randEpoch, err)
+	}
+
+	partitions, err := s.api.StateMinerPartitions(ctx, s.actor, dlIdx, ts.Key())
+	if err != nil {
+		return api.WdPoStDryRun{}, xerrors.Errorf("getting partitions: %w", err)
+	}
+
+	mid, err := address.IDFromAddress(s.actor)
+	if err != nil {
+		return api.WdPoStDryRun{}, err
+	}
+
+	for partIdx, partition := range partitions {
+		toProve, err := bitfield.SubtractBitField(partition.LiveSectors, partition.FaultySectors)
+		if err != nil {
+			return api.WdPoStDryRun{}, xerrors.Errorf("removing faults from set of sectors to prove: %w", err)
+		}
+		toProve, err = bitfield.MergeBitFields(toProve, partition.RecoveringSectors)
+		if err != nil {
+			return api.WdPoStDryRun{}, xerrors.Errorf("adding recoveries to set of sectors to prove: %w", err)
+		}
+
+		count, err := toProve.Count()
+		if err != nil {
+			return api.WdPoStDryRun{}, xerrors.Errorf("counting sectors to prove: %w", err)
+		}
+
+		// the faulty sectors are substituted in the proofs, like in doPost
+		ssi, err := s.sectorsForProof(ctx, toProve, partition.AllSectors, ts)
+		if err != nil {
+			return api.WdPoStDryRun{}, xerrors.Errorf("getting sorted sector info: %w", err)
+		}
+
+		pr := api.WdPoStDryRunPartition{
+			Index:   uint64(partIdx),
+			Sectors: int(count),
+			Failed:  map[abi.SectorNumber]string{},
+		}
+
+		start := time.Now()
+		checks, err := s.faultTracker.CheckPoStVanilla(ctx, abi.ActorID(mid), ssi, append(abi.PoStRandomness{}, rand...))
+		if err != nil {
+			return api.WdPoStDryRun{}, xerrors.Errorf("generating vanilla proofs of partition %d: %w", partIdx, err)
+		}
+		pr.Vanilla = time.Since(start)
+
+		for _, c := range checks {
+			if c.Elapsed > pr.Slowest {
+				pr.Slowest = c.Elapsed
+			}
+			if c.Err != "" {
+				pr.Failed[c.Sector] = c.Err
+			}
+		}
+		pr.Estimate = pr.Vanilla + out.SnarkTime
+
+		log.Infow("window post dry-run", "deadline", dlIdx, "partition", partIdx, "sectors", pr.Sectors, "failed", len(pr.Failed), "vanilla", pr.Vanilla)
+
+		out.Partitions = append(out.Partitions, pr)
+	}
+
+	return out, nil
+}
@@ -173,7 +173,7 @@ func (s *WindowPoStScheduler) runSubmitPoST(
 		post.ChainCommitRand = commRand
 
 		// Submit PoST
-		sm, err := s.submitPoStMessage(ctx, post)
+		sm, err := s.submitPoStMessage(ctx, deadline, post)
 		if err != nil {
 			log.Errorf("submit window post failed: %+v", err)
 			submitErr = err
@@ -451,6 +451,10 @@ func (s *WindowPoStScheduler) runPoStCycle(ctx context.Context, manual bool, di
 					continue
 				}
 
+				s.proofTimeLk.Lock()
+				s.partitionProofTime = elapsed / time.Duration(len(partitions))
+				s.proofTimeLk.Unlock()
+
 				// Proof generation successful, stop retrying
 				somethingToProve = true
 				params.Partitions = partitions
@@ -587,9 +591,9 @@ func (s *WindowPoStScheduler) sectorsForProof(ctx context.Context, goodSectors,
 }
 
 // submitPoStMessage builds a SubmitWindowedPoSt message and submits it to
-// the mpool. It doesn't synchronously block on confirmations, but it does
-// monitor in the background simply for the purposes of logging.
-func (s *WindowPoStScheduler) submitPoStMessage(ctx context.Context, proof *miner.SubmitWindowedPoStParams) (*types.SignedMessage, error) {
+// the mpool through the sender. It doesn't synchronously block on
+// confirmations, the sender monitors the message in the background.
+func (s *WindowPoStScheduler) submitPoStMessage(ctx context.Context, deadline *dline.Info, proof *miner.SubmitWindowedPoStParams) (*types.SignedMessage, error) {
 	ctx, span := trace.StartSpan(ctx, "storage.commitPost")
 	defer span.End()
 
@@ -606,33 +610,18 @@ func (s *WindowPoStScheduler) submitPoStMessage(ctx context.Context, proof *mine
 		Params: enc,
 		Value:  types.NewInt(0),
 	}
-	spec := &api.MessageSendSpec{MaxFee: abi.TokenAmount(s.feeCfg.MaxWindowPoStGasFee)}
+	spec := &api.MessageSendSpec{MaxFee: s.sender.maxFee(proof.Deadline)}
 	if err := s.prepareMessage(ctx, msg, spec); err != nil {
 		return nil, err
 	}
 
-	sm, err := s.api.MpoolPushMessage(ctx, msg, spec)
+	sm, err := s.sender.send(ctx, deadline, len(proof.Partitions), msg, spec)
 	if err != nil {
-		return nil, xerrors.Errorf("pushing message to mpool: %w", err)
+		return nil, err
 	}
 
 	log.Infof("Submitted window post: %s (deadline %d)", sm.Cid(), proof.Deadline)
 
-	go func() {
-		rec, err := s.api.StateWaitMsg(context.TODO(), sm.Cid(), build.MessageConfidence, api.LookbackNoLimit, true)
-		if err != nil {
-			log.Error(err)
-			return
-		}
-
-		if rec.Receipt.ExitCode == 0 {
-			log.Infow("Window post submission successful", "cid", sm.Cid(), "deadline", proof.Deadline, "epoch", rec.Height, "ts", rec.TipSet.Cids())
-			return
-		}
-
-		log.Errorf("Submitting window post %s failed: exit %d", sm.Cid(), rec.Receipt.ExitCode)
-	}()
-
 	return sm, nil
 }
 
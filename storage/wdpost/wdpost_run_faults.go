@@ -14,7 +14,6 @@ import (
 	"github.com/filecoin-project/go-state-types/dline"
 
 	"github.com/filecoin-project/lotus/api"
-	"github.com/filecoin-project/lotus/build"
 	"github.com/filecoin-project/lotus/chain/actors"
 	"github.com/filecoin-project/lotus/chain/types"
 )
@@ -101,6 +100,8 @@ func (s *WindowPoStScheduler) declareRecoveries(ctx context.Context, dlIdx uint6
 		return nil, nil, nil
 	}
 
+	spec := &api.MessageSendSpec{MaxFee: abi.TokenAmount(s.feeCfg().MaxWindowPoStGasFee)}
+
 	var msgs []*types.SignedMessage
 	for _, recovery := range batchedRecoveryDecls {
 		params := &miner.DeclareFaultsRecoveredParams{
@@ -118,12 +119,11 @@ func (s *WindowPoStScheduler) declareRecoveries(ctx context.Context, dlIdx uint6
 			Params: enc,
 			Value:  types.NewInt(0),
 		}
-		spec := &api.MessageSendSpec{MaxFee: abi.TokenAmount(s.feeCfg.MaxWindowPoStGasFee)}
 		if err := s.prepareMessage(ctx, msg, spec); err != nil {
 			return nil, nil, err
 		}
 
-		sm, err := s.api.MpoolPushMessage(ctx, msg, &api.MessageSendSpec{MaxFee: abi.TokenAmount(s.feeCfg.MaxWindowPoStGasFee)})
+		sm, err := s.api.MpoolPushMessage(ctx, msg, spec)
 		if err != nil {
 			return nil, nil, xerrors.Errorf("pushing message to mpool: %w", err)
 		}
@@ -132,8 +132,9 @@ func (s *WindowPoStScheduler) declareRecoveries(ctx context.Context, dlIdx uint6
 		msgs = append(msgs, sm)
 	}
 
+	confidence, limit := spec.WaitParams()
 	for _, msg := range msgs {
-		rec, err := s.api.StateWaitMsg(context.TODO(), msg.Cid(), build.MessageConfidence, api.LookbackNoLimit, true)
+		rec, err := s.api.StateWaitMsg(context.TODO(), msg.Cid(), confidence, limit, true)
 		if err != nil {
 			return batchedRecoveryDecls, msgs, xerrors.Errorf("declare faults recovered wait error: %w", err)
 		}
@@ -221,7 +222,7 @@ func (s *WindowPoStScheduler) declareFaults(ctx context.Context, dlIdx uint64, p
 		Params: enc,
 		Value:  types.NewInt(0), // TODO: Is there a fee?
 	}
-	spec := &api.MessageSendSpec{MaxFee: abi.TokenAmount(s.feeCfg.MaxWindowPoStGasFee)}
+	spec := &api.MessageSendSpec{MaxFee: abi.TokenAmount(s.feeCfg().MaxWindowPoStGasFee)}
 	if err := s.prepareMessage(ctx, msg, spec); err != nil {
 		return faults, nil, err
 	}
@@ -233,7 +234,8 @@ func (s *WindowPoStScheduler) declareFaults(ctx context.Context, dlIdx uint64, p
 
 	log.Warnw("declare faults Message CID", "cid", sm.Cid())
 
-	rec, err := s.api.StateWaitMsg(context.TODO(), sm.Cid(), build.MessageConfidence, api.LookbackNoLimit, true)
+	confidence, limit := spec.WaitParams()
+	rec, err := s.api.StateWaitMsg(context.TODO(), sm.Cid(), confidence, limit, true)
 	if err != nil {
 		return faults, sm, xerrors.Errorf("declare faults wait error: %w", err)
 	}
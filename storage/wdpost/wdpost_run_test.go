@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"testing"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/stretchr/testify/require"
@@ -28,13 +29,19 @@ import (
 	"github.com/filecoin-project/lotus/chain/actors/policy"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/journal"
+	"github.com/filecoin-project/lotus/node/config"
 	"github.com/filecoin-project/lotus/storage/ctladdr"
 	"github.com/filecoin-project/lotus/storage/sealer/storiface"
 )
 
+func defaultFeeCfg() config.MinerFeeConfig {
+	return config.MinerFeeConfig{}
+}
+
 type mockStorageMinerAPI struct {
 	partitions     []api.Partition
 	pushedMessages chan *types.Message
+	head           *types.TipSet
 	NodeAPI
 }
 
@@ -100,6 +107,15 @@ func (m *mockStorageMinerAPI) StateWaitMsg(ctx context.Context, cid cid.Cid, con
 	}, nil
 }
 
+func (m *mockStorageMinerAPI) StateSearchMsg(ctx context.Context, from types.TipSetKey, msg cid.Cid, limit abi.ChainEpoch, allowReplaced bool) (*api.MsgLookup, error) {
+	return &api.MsgLookup{
+		Message: msg,
+		Receipt: types.MessageReceipt{
+			ExitCode: 0,
+		},
+	}, nil
+}
+
 func (m *mockStorageMinerAPI) GasEstimateGasPremium(_ context.Context, nblocksincl uint64, sender address.Address, gaslimit int64, tsk types.TipSetKey) (types.BigInt, error) {
 	return big.Zero(), nil
 }
@@ -176,6 +192,14 @@ func (m mockFaultTracker) CheckProvable(ctx context.Context, pp abi.RegisteredPo
 	return map[abi.SectorID]string{}, nil
 }
 
+func (m mockFaultTracker) CheckPoStVanilla(ctx context.Context, minerID abi.ActorID, sectorInfo []prooftypes.ExtendedSectorInfo, randomness abi.PoStRandomness) ([]storiface.VanillaCheck, error) {
+	var out []storiface.VanillaCheck
+	for _, s := range sectorInfo {
+		out = append(out, storiface.VanillaCheck{Sector: s.SectorNumber})
+	}
+	return out, nil
+}
+
 // TestWDPostDoPost verifies that doPost will send the correct number of window
 // PoST messages for a given number of partitions
 func TestWDPostDoPost(t *testing.T) {
@@ -228,6 +252,7 @@ func TestWDPostDoPost(t *testing.T) {
 	// Run window PoST
 	scheduler := &WindowPoStScheduler{
 		api:          mockStgMinerAPI,
+		feeCfg:       defaultFeeCfg,
 		prover:       &mockProver{},
 		verifier:     &mockVerif{},
 		faultTracker: &mockFaultTracker{},
@@ -235,6 +260,7 @@ func TestWDPostDoPost(t *testing.T) {
 		actor:        postAct,
 		journal:      journal.NilJournal(),
 		addrSel:      &ctladdr.AddressSelector{},
+		sender:       newPostSender(mockStgMinerAPI, defaultFeeCfg, config.WdPoStSenderConfig{}, address.Undef, nil),
 	}
 
 	di := &dline.Info{
@@ -325,6 +351,7 @@ func TestWDPostDoPostPartLimitConfig(t *testing.T) {
 	// Run window PoST
 	scheduler := &WindowPoStScheduler{
 		api:          mockStgMinerAPI,
+		feeCfg:       defaultFeeCfg,
 		prover:       &mockProver{},
 		verifier:     &mockVerif{},
 		faultTracker: &mockFaultTracker{},
@@ -332,6 +359,7 @@ func TestWDPostDoPostPartLimitConfig(t *testing.T) {
 		actor:        postAct,
 		journal:      journal.NilJournal(),
 		addrSel:      &ctladdr.AddressSelector{},
+		sender:       newPostSender(mockStgMinerAPI, defaultFeeCfg, config.WdPoStSenderConfig{}, address.Undef, nil),
 
 		maxPartitionsPerPostMessage: userPartLimit,
 	}
@@ -417,6 +445,7 @@ func TestWDPostDeclareRecoveriesPartLimitConfig(t *testing.T) {
 	// Run declareRecoverios
 	scheduler := &WindowPoStScheduler{
 		api:          mockStgMinerAPI,
+		feeCfg:       defaultFeeCfg,
 		prover:       &mockProver{},
 		verifier:     &mockVerif{},
 		faultTracker: &mockFaultTracker{},
@@ -460,6 +489,57 @@ func TestWDPostDeclareRecoveriesPartLimitConfig(t *testing.T) {
 	}
 }
 
+// TestWDPostDryRun verifies that the dry-run checks the sectors of all the
+// partitions of the next occurrence of a deadline
+func TestWDPostDryRun(t *testing.T) {
+	ctx := context.Background()
+
+	mockStgMinerAPI := newMockStorageMinerAPI()
+	mockStgMinerAPI.head = mockTipSet(t)
+
+	sectors := bitfield.NewFromSet([]uint64{1, 2, 3})
+	faulty := bitfield.NewFromSet([]uint64{3})
+	for p := 0; p < 2; p++ {
+		mockStgMinerAPI.setPartitions([]api.Partition{{
+			AllSectors:        sectors,
+			FaultySectors:     faulty,
+			RecoveringSectors: bitfield.New(),
+			LiveSectors:       sectors,
+			ActiveSectors:     sectors,
+		}})
+	}
+
+	scheduler := &WindowPoStScheduler{
+		api:                mockStgMinerAPI,
+		faultTracker:       &mockFaultTracker{},
+		proofType:          abi.RegisteredPoStProof_StackedDrgWindow2KiBV1,
+		actor:              tutils.NewIDAddr(t, 100),
+		partitionProofTime: time.Second,
+	}
+
+	// the current deadline, already challenged
+	res, err := scheduler.DryRun(ctx, 0)
+	require.NoError(t, err)
+	require.True(t, res.RealChallenges)
+	require.Equal(t, abi.ChainEpoch(0), res.Open)
+	require.Len(t, res.Partitions, 2)
+	for _, p := range res.Partitions {
+		// the faulty sector isn't proven
+		require.Equal(t, 2, p.Sectors)
+		require.Empty(t, p.Failed)
+		require.Equal(t, p.Vanilla+time.Second, p.Estimate)
+	}
+
+	// a later deadline, with simulated challenges
+	res, err = scheduler.DryRun(ctx, 2)
+	require.NoError(t, err)
+	require.False(t, res.RealChallenges)
+	require.Equal(t, 2*minertypes.WPoStChallengeWindow, res.Open)
+
+	_, err = scheduler.DryRun(ctx, minertypes.WPoStPeriodDeadlines)
+	require.Error(t, err)
+}
+
 func mockTipSet(t *testing.T) *types.TipSet {
 	minerAct := tutils.NewActorAddr(t, "miner")
 	c, err := cid.Decode("QmbFMke1KXqnYyBBWxB74N4c5SBnJMVAiMNRcGu6x1AwQH")
@@ -522,7 +602,7 @@ func (m *mockStorageMinerAPI) GasEstimateMessageGas(ctx context.Context, message
 }
 
 func (m *mockStorageMinerAPI) ChainHead(ctx context.Context) (*types.TipSet, error) {
-	return nil, nil
+	return m.head, nil
 }
 
 func (m *mockStorageMinerAPI) WalletSign(ctx context.Context, address address.Address, bytes []byte) (*crypto.Signature, error) {
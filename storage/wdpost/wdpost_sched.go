@@ -2,6 +2,7 @@ package wdpost
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/ipfs/go-cid"
@@ -41,11 +42,13 @@ type NodeAPI interface {
 	StateGetRandomnessFromTickets(ctx context.Context, personalization crypto.DomainSeparationTag, randEpoch abi.ChainEpoch, entropy []byte, tsk types.TipSetKey) (abi.Randomness, error)
 	StateGetRandomnessFromBeacon(ctx context.Context, personalization crypto.DomainSeparationTag, randEpoch abi.ChainEpoch, entropy []byte, tsk types.TipSetKey) (abi.Randomness, error)
 	StateWaitMsg(ctx context.Context, cid cid.Cid, confidence uint64, limit abi.ChainEpoch, allowReplaced bool) (*api.MsgLookup, error)
+	StateSearchMsg(ctx context.Context, from types.TipSetKey, msg cid.Cid, limit abi.ChainEpoch, allowReplaced bool) (*api.MsgLookup, error)
 	StateMinerPartitions(context.Context, address.Address, uint64, types.TipSetKey) ([]api.Partition, error)
 	StateLookupID(context.Context, address.Address, types.TipSetKey) (address.Address, error)
 	StateAccountKey(context.Context, address.Address, types.TipSetKey) (address.Address, error)
 
 	MpoolPushMessage(context.Context, *types.Message, *api.MessageSendSpec) (*types.SignedMessage, error)
+	MpoolPush(context.Context, *types.SignedMessage) (cid.Cid, error)
 
 	GasEstimateMessageGas(context.Context, *types.Message, *api.MessageSendSpec, types.TipSetKey) (*types.Message, error)
 	GasEstimateFeeCap(context.Context, *types.Message, int64, types.TipSetKey) (types.BigInt, error)
@@ -53,6 +56,7 @@ type NodeAPI interface {
 
 	WalletBalance(context.Context, address.Address) (types.BigInt, error)
 	WalletHas(context.Context, address.Address) (bool, error)
+	WalletSignMessage(context.Context, address.Address, *types.Message) (*types.SignedMessage, error)
 }
 
 // WindowPoStScheduler is the coordinator for WindowPoSt submissions, fault
@@ -63,7 +67,7 @@ type NodeAPI interface {
 // turn calls the scheduler when the time arrives to do work.
 type WindowPoStScheduler struct {
 	api                             NodeAPI
-	feeCfg                          config.MinerFeeConfig
+	feeCfg                          config.GetMinerFeeConfigFunc
 	addrSel                         *ctladdr.AddressSelector
 	prover                          storiface.ProverPoSt
 	verifier                        storiface.Verifier
@@ -74,34 +78,59 @@ type WindowPoStScheduler struct {
 	maxPartitionsPerPostMessage     int
 	maxPartitionsPerRecoveryMessage int
 	ch                              *changeHandler
+	sender                          *postSender
 
 	actor address.Address
 
 	evtTypes [4]journal.EventType
 	journal  journal.Journal
 
+	// partitionProofTime is the time the last WindowPoSt took per partition
+	proofTimeLk        sync.Mutex
+	partitionProofTime time.Duration
+
 	// failed abi.ChainEpoch // eps
 	// failLk sync.Mutex
 }
 
 // NewWindowedPoStScheduler creates a new WindowPoStScheduler scheduler.
 func NewWindowedPoStScheduler(api NodeAPI,
-	cfg config.MinerFeeConfig,
+	feeCfg config.GetMinerFeeConfigFunc,
 	pcfg config.ProvingConfig,
 	as *ctladdr.AddressSelector,
 	sp storiface.ProverPoSt,
 	verif storiface.Verifier,
 	ft sealer.FaultTracker,
 	j journal.Journal,
-	actor address.Address) (*WindowPoStScheduler, error) {
+	actor address.Address,
+	nodes []PushAPI) (*WindowPoStScheduler, error) {
 	mi, err := api.StateMinerInfo(context.TODO(), actor, types.EmptyTSK)
 	if err != nil {
 		return nil, xerrors.Errorf("getting sector size: %w", err)
 	}
 
+	var fallback address.Address
+	if pcfg.Sender.FallbackAddress != "" {
+		fallback, err = address.NewFromString(pcfg.Sender.FallbackAddress)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing fallback address: %w", err)
+		}
+		id, err := api.StateLookupID(context.TODO(), fallback, types.EmptyTSK)
+		if err != nil {
+			return nil, xerrors.Errorf("looking up fallback address %s: %w", fallback, err)
+		}
+		isControl := id == mi.Worker || id == mi.Owner
+		for _, ca := range mi.ControlAddresses {
+			isControl = isControl || id == ca
+		}
+		if !isControl {
+			return nil, xerrors.Errorf("fallback address %s isn't a control address of %s", fallback, actor)
+		}
+	}
+
 	return &WindowPoStScheduler{
 		api:                             api,
-		feeCfg:                          cfg,
+		feeCfg:                          feeCfg,
 		addrSel:                         as,
 		prover:                          sp,
 		verifier:                        verif,
@@ -112,6 +141,7 @@ func NewWindowedPoStScheduler(api NodeAPI,
 		maxPartitionsPerPostMessage:     pcfg.MaxPartitionsPerPoStMessage,
 		maxPartitionsPerRecoveryMessage: pcfg.MaxPartitionsPerRecoveryMessage,
 		actor:                           actor,
+		sender:                          newPostSender(api, feeCfg, pcfg.Sender, fallback, nodes),
 		evtTypes: [...]journal.EventType{
 			evtTypeWdPoStScheduler:  j.RegisterEventType("wdpost", "scheduler"),
 			evtTypeWdPoStProofs:     j.RegisterEventType("wdpost", "proofs_processed"),
@@ -246,3 +276,8 @@ func (s *WindowPoStScheduler) getEvtCommon(err error) evtCommon {
 	}
 	return c
 }
+
+// SenderStatus returns the WindowPoSt messages sent in the last proving period
+func (s *WindowPoStScheduler) SenderStatus() []api.WdPoStMessage {
+	return s.sender.status()
+}
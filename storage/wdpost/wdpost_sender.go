@@ -0,0 +1,345 @@
+package wdpost
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/dline"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/messagepool"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+// PushAPI is an additional full node the WindowPoSt messages are pushed to
+type PushAPI interface {
+	MpoolPush(context.Context, *types.SignedMessage) (cid.Cid, error)
+}
+
+// postSender pushes the SubmitWindowedPoSt messages, and keeps them moving
+// until they land or their deadline closes: the messages waiting in the mpool
+// get their fees bumped, and are sent again from the fallback address when
+// they can't be bumped anymore.
+type postSender struct {
+	api    NodeAPI
+	feeCfg config.GetMinerFeeConfigFunc
+	cfg    config.WdPoStSenderConfig
+	nodes  []PushAPI
+
+	// fallback address, address.Undef when none is configured
+	fallback address.Address
+
+	// the messages are checked at each interval
+	pollInterval time.Duration
+
+	lk sync.Mutex
+	// the messages of the last proving period, by deadline
+	msgs map[uint64][]*sentMsg
+}
+
+type sentMsg struct {
+	status api.WdPoStMessage
+
+	// unsigned message, as prepared by the scheduler
+	orig types.Message
+	// last version of the message
+	smsg *types.SignedMessage
+	// all the versions of the message, including the ones sent from the
+	// fallback address
+	cids []cid.Cid
+
+	// epoch of the last push or bump
+	last      abi.ChainEpoch
+	fellBack  bool
+	exhausted bool
+}
+
+func newPostSender(api NodeAPI, feeCfg config.GetMinerFeeConfigFunc, cfg config.WdPoStSenderConfig, fallback address.Address, nodes []PushAPI) *postSender {
+	return &postSender{
+		api:          api,
+		feeCfg:       feeCfg,
+		cfg:          cfg,
+		nodes:        nodes,
+		fallback:     fallback,
+		pollInterval: time.Duration(build.BlockDelaySecs) * time.Second,
+		msgs:         map[uint64][]*sentMsg{},
+	}
+}
+
+// maxFee returns the maximum fee of the messages of a deadline
+func (ps *postSender) maxFee(dlIdx uint64) abi.TokenAmount {
+	for _, df := range ps.cfg.DeadlineMaxFees {
+		for _, d := range df.Deadlines {
+			if d == dlIdx {
+				return abi.TokenAmount(df.MaxFee)
+			}
+		}
+	}
+	return abi.TokenAmount(ps.feeCfg().MaxWindowPoStGasFee)
+}
+
+// send pushes a prepared message, from the fallback address when it can't be
+// pushed from its sender, and watches it in the background until it lands
+func (ps *postSender) send(ctx context.Context, di *dline.Info, partitions int, msg *types.Message, spec *api.MessageSendSpec) (*types.SignedMessage, error) {
+	m := &sentMsg{
+		status: api.WdPoStMessage{
+			Deadline:   di.Index,
+			Partitions: partitions,
+			Close:      di.Close,
+			Pushed:     di.CurrentEpoch,
+			State:      api.WdPoStMsgPending,
+		},
+		orig: *msg,
+		last: di.CurrentEpoch,
+	}
+
+	sm, err := ps.api.MpoolPushMessage(ctx, msg, spec)
+	if err != nil && ps.fallback != address.Undef && msg.From != ps.fallback {
+		log.Warnw("pushing window post message failed, sending it from the fallback address", "deadline", di.Index, "from", msg.From, "fallback", ps.fallback, "error", err)
+
+		m.fellBack = true
+		m.status.Error = err.Error()
+		sm, err = ps.api.MpoolPushMessage(ctx, ps.fallbackMessage(msg), spec)
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("pushing message to mpool: %w", err)
+	}
+
+	ps.sent(m, sm)
+	m.status.FullNodes = ps.broadcast(ctx, sm)
+
+	ps.lk.Lock()
+	prev := ps.msgs[di.Index]
+	if len(prev) > 0 && prev[0].status.Close != di.Close {
+		// from the previous proving period
+		prev = nil
+	}
+	ps.msgs[di.Index] = append(prev, m)
+	ps.lk.Unlock()
+
+	go ps.watch(m)
+
+	return sm, nil
+}
+
+// fallbackMessage returns a copy of a message sent from the fallback address,
+// with its nonce and gas values to be set by the mpool
+func (ps *postSender) fallbackMessage(msg *types.Message) *types.Message {
+	fm := *msg
+	fm.From = ps.fallback
+	fm.Nonce = 0
+	fm.GasLimit = 0
+	fm.GasFeeCap = big.Zero()
+	fm.GasPremium = big.Zero()
+	return &fm
+}
+
+// sent records a new version of a message
+func (ps *postSender) sent(m *sentMsg, sm *types.SignedMessage) {
+	ps.lk.Lock()
+	defer ps.lk.Unlock()
+
+	m.smsg = sm
+	m.cids = append(m.cids, sm.Cid())
+	m.status.Cid = sm.Cid()
+	m.status.From = sm.Message.From
+	m.status.Nonce = sm.Message.Nonce
+	m.status.GasFeeCap = sm.Message.GasFeeCap
+	m.status.GasPremium = sm.Message.GasPremium
+}
+
+// broadcast pushes a message to the additional full nodes, and returns the
+// number of nodes which accepted it
+func (ps *postSender) broadcast(ctx context.Context, sm *types.SignedMessage) int {
+	var n int
+	for i, node := range ps.nodes {
+		if _, err := node.MpoolPush(ctx, sm); err != nil {
+			log.Warnw("pushing window post message to additional full node", "node", i, "cid", sm.Cid(), "error", err)
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+func (ps *postSender) watch(m *sentMsg) {
+	ctx := context.TODO()
+
+	ticker := time.NewTicker(ps.pollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if ps.check(ctx, m) {
+			return
+		}
+	}
+}
+
+// check updates the state of a message, and bumps it when it waited for too
+// long; it returns true once the message doesn't need to be watched anymore
+func (ps *postSender) check(ctx context.Context, m *sentMsg) bool {
+	ps.lk.Lock()
+	cids := append([]cid.Cid{}, m.cids...)
+	ps.lk.Unlock()
+
+	for _, c := range cids {
+		rec, err := ps.api.StateSearchMsg(ctx, types.EmptyTSK, c, api.LookbackNoLimit, true)
+		if err != nil {
+			log.Warnw("searching window post message", "cid", c, "error", err)
+			return false
+		}
+		if rec == nil {
+			continue
+		}
+
+		ps.lk.Lock()
+		m.status.State = api.WdPoStMsgLanded
+		m.status.Epoch = rec.Height
+		m.status.ExitCode = rec.Receipt.ExitCode
+		ps.lk.Unlock()
+
+		if rec.Receipt.ExitCode == 0 {
+			log.Infow("Window post submission successful", "cid", rec.Message, "deadline", m.status.Deadline, "epoch", rec.Height, "ts", rec.TipSet.Cids())
+		} else {
+			log.Errorf("Submitting window post %s failed: exit %d", rec.Message, rec.Receipt.ExitCode)
+		}
+		return true
+	}
+
+	ts, err := ps.api.ChainHead(ctx)
+	if err != nil {
+		log.Warnw("getting chain head", "error", err)
+		return false
+	}
+
+	if ts.Height() >= m.status.Close {
+		ps.lk.Lock()
+		m.status.State = api.WdPoStMsgExpired
+		ps.lk.Unlock()
+
+		log.Errorw("window post message didn't land before its deadline closed", "deadline", m.status.Deadline, "cid", m.status.Cid, "from", m.status.From, "bumps", m.status.Bumps)
+		return true
+	}
+
+	if ps.cfg.BumpAfterEpochs > 0 && !m.exhausted && ts.Height() >= m.last+abi.ChainEpoch(ps.cfg.BumpAfterEpochs) {
+		ps.bump(ctx, m, ts.Height())
+	}
+	return false
+}
+
+// bump replaces a message with a version with higher fees; the message is sent
+// from the fallback address when its fees reached the maximum fee of its
+// deadline, or it can't be replaced
+func (ps *postSender) bump(ctx context.Context, m *sentMsg, h abi.ChainEpoch) {
+	ps.lk.Lock()
+	prev := m.smsg.Message
+	ps.lk.Unlock()
+
+	minPremium := messagepool.ComputeMinRBF(prev.GasPremium)
+
+	msg := prev
+	msg.GasPremium = big.Max(mulRatio(prev.GasPremium, ps.cfg.BumpRatio), minPremium)
+	msg.GasFeeCap = big.Max(mulRatio(prev.GasFeeCap, ps.cfg.BumpRatio), msg.GasPremium)
+
+	maxFee := ps.maxFee(m.status.Deadline)
+	if maxFee.Int != nil && !maxFee.IsZero() {
+		messagepool.CapGasFee(func() (abi.TokenAmount, error) {
+			return maxFee, nil
+		}, &msg, &api.MessageSendSpec{MaxFee: maxFee})
+	}
+
+	if msg.GasPremium.LessThan(minPremium) {
+		ps.fallBack(ctx, m, h, xerrors.Errorf("fees reached the maximum fee of the deadline (%s)", types.FIL(maxFee)))
+		return
+	}
+
+	sm, err := ps.api.WalletSignMessage(ctx, msg.From, &msg)
+	if err != nil {
+		ps.fallBack(ctx, m, h, xerrors.Errorf("signing bumped message: %w", err))
+		return
+	}
+	if _, err := ps.api.MpoolPush(ctx, sm); err != nil {
+		ps.fallBack(ctx, m, h, xerrors.Errorf("pushing bumped message: %w", err))
+		return
+	}
+
+	ps.sent(m, sm)
+	nodes := ps.broadcast(ctx, sm)
+
+	ps.lk.Lock()
+	m.last = h
+	m.status.Bumps++
+	m.status.FullNodes = nodes
+	ps.lk.Unlock()
+
+	log.Warnw("bumped window post message", "deadline", m.status.Deadline, "replaced", prev.Cid(), "cid", sm.Cid(), "feecap", msg.GasFeeCap, "premium", msg.GasPremium)
+}
+
+func (ps *postSender) fallBack(ctx context.Context, m *sentMsg, h abi.ChainEpoch, reason error) {
+	ps.lk.Lock()
+	m.status.Error = reason.Error()
+	canFallBack := ps.fallback != address.Undef && !m.fellBack && m.orig.From != ps.fallback
+	m.fellBack = true
+	m.exhausted = !canFallBack
+	ps.lk.Unlock()
+
+	if !canFallBack {
+		log.Errorw("can't bump window post message", "deadline", m.status.Deadline, "cid", m.status.Cid, "error", reason)
+		return
+	}
+
+	log.Warnw("can't bump window post message, sending it from the fallback address", "deadline", m.status.Deadline, "cid", m.status.Cid, "fallback", ps.fallback, "error", reason)
+
+	sm, err := ps.api.MpoolPushMessage(ctx, ps.fallbackMessage(&m.orig), &api.MessageSendSpec{MaxFee: ps.maxFee(m.status.Deadline)})
+	if err != nil {
+		log.Errorw("sending window post message from the fallback address", "deadline", m.status.Deadline, "error", err)
+
+		ps.lk.Lock()
+		m.status.Error = err.Error()
+		m.exhausted = true
+		ps.lk.Unlock()
+		return
+	}
+
+	ps.sent(m, sm)
+	nodes := ps.broadcast(ctx, sm)
+
+	ps.lk.Lock()
+	m.last = h
+	m.status.FullNodes = nodes
+	ps.lk.Unlock()
+}
+
+// status returns the messages of the last proving period
+func (ps *postSender) status() []api.WdPoStMessage {
+	ps.lk.Lock()
+	defer ps.lk.Unlock()
+
+	var out []api.WdPoStMessage
+	for _, msgs := range ps.msgs {
+		for _, m := range msgs {
+			out = append(out, m.status)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Close != out[j].Close {
+			return out[i].Close < out[j].Close
+		}
+		return out[i].Pushed < out[j].Pushed
+	})
+	return out
+}
+
+func mulRatio(v abi.TokenAmount, r float64) abi.TokenAmount {
+	return big.Div(big.Mul(v, big.NewInt(int64(r*1000))), big.NewInt(1000))
+}
@@ -0,0 +1,252 @@
+//stm: #unit
+package wdpost
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/dline"
+	tutils "github.com/filecoin-project/specs-actors/v6/support/testing"
+
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/node/config"
+)
+
+type senderTestAPI struct {
+	NodeAPI
+
+	t      *testing.T
+	lk     sync.Mutex
+	head   *types.TipSet
+	nonces map[address.Address]uint64
+	landed map[cid.Cid]abi.ChainEpoch
+	pushed []*types.SignedMessage
+	// MpoolPushMessage fails for the messages from this address
+	failFrom address.Address
+}
+
+func newSenderTestAPI(t *testing.T) *senderTestAPI {
+	a := &senderTestAPI{
+		t:      t,
+		nonces: map[address.Address]uint64{},
+		landed: map[cid.Cid]abi.ChainEpoch{},
+	}
+	a.setHeight(0)
+	return a
+}
+
+func (a *senderTestAPI) setHeight(h abi.ChainEpoch) {
+	c, err := cid.Decode("QmbFMke1KXqnYyBBWxB74N4c5SBnJMVAiMNRcGu6x1AwQH")
+	require.NoError(a.t, err)
+	ts, err := types.NewTipSet([]*types.BlockHeader{{
+		Miner:                 tutils.NewIDAddr(a.t, 1000),
+		Height:                h,
+		ParentStateRoot:       c,
+		ParentMessageReceipts: c,
+		Messages:              c,
+	}})
+	require.NoError(a.t, err)
+
+	a.lk.Lock()
+	a.head = ts
+	a.lk.Unlock()
+}
+
+func (a *senderTestAPI) ChainHead(ctx context.Context) (*types.TipSet, error) {
+	a.lk.Lock()
+	defer a.lk.Unlock()
+	return a.head, nil
+}
+
+func (a *senderTestAPI) MpoolPushMessage(ctx context.Context, msg *types.Message, spec *api.MessageSendSpec) (*types.SignedMessage, error) {
+	a.lk.Lock()
+	defer a.lk.Unlock()
+
+	if msg.From == a.failFrom {
+		return nil, xerrors.Errorf("not enough funds")
+	}
+
+	m := *msg
+	m.Nonce = a.nonces[m.From]
+	a.nonces[m.From]++
+	if m.GasLimit == 0 {
+		m.GasLimit = 1000
+		m.GasFeeCap = big.NewInt(100)
+		m.GasPremium = big.NewInt(10)
+	}
+	sm := &types.SignedMessage{Message: m}
+	a.pushed = append(a.pushed, sm)
+	return sm, nil
+}
+
+func (a *senderTestAPI) MpoolPush(ctx context.Context, sm *types.SignedMessage) (cid.Cid, error) {
+	a.lk.Lock()
+	defer a.lk.Unlock()
+
+	a.pushed = append(a.pushed, sm)
+	return sm.Cid(), nil
+}
+
+func (a *senderTestAPI) WalletSignMessage(ctx context.Context, from address.Address, msg *types.Message) (*types.SignedMessage, error) {
+	return &types.SignedMessage{Message: *msg}, nil
+}
+
+func (a *senderTestAPI) StateSearchMsg(ctx context.Context, from types.TipSetKey, msg cid.Cid, limit abi.ChainEpoch, allowReplaced bool) (*api.MsgLookup, error) {
+	a.lk.Lock()
+	defer a.lk.Unlock()
+
+	h, ok := a.landed[msg]
+	if !ok {
+		return nil, nil
+	}
+	return &api.MsgLookup{Message: msg, Height: h}, nil
+}
+
+func (a *senderTestAPI) pushCount() int {
+	a.lk.Lock()
+	defer a.lk.Unlock()
+	return len(a.pushed)
+}
+
+func TestPoStSender(t *testing.T) {
+	ctx := context.Background()
+
+	worker := tutils.NewIDAddr(t, 101)
+	fallback := tutils.NewIDAddr(t, 102)
+	actor := tutils.NewIDAddr(t, 1000)
+
+	di := &dline.Info{Index: 3, Close: 60, CurrentEpoch: 10}
+	cfg := config.WdPoStSenderConfig{
+		BumpAfterEpochs: 5,
+		BumpRatio:       1.25,
+	}
+
+	newSender := func(a *senderTestAPI, cfg config.WdPoStSenderConfig, nodes []PushAPI) *postSender {
+		ps := newPostSender(a, defaultFeeCfg, cfg, fallback, nodes)
+		// the test checks the messages itself
+		ps.pollInterval = time.Hour
+		return ps
+	}
+	newMsg := func() *types.Message {
+		return &types.Message{To: actor, From: worker, Value: big.Zero()}
+	}
+
+	t.Run("max-fee", func(t *testing.T) {
+		ps := newSender(newSenderTestAPI(t), config.WdPoStSenderConfig{
+			DeadlineMaxFees: []config.DeadlineFeeConfig{{Deadlines: []uint64{3, 7}, MaxFee: types.MustParseFIL("0.5")}},
+		}, nil)
+
+		require.Equal(t, abi.TokenAmount(types.MustParseFIL("0.5")), ps.maxFee(7))
+		require.Equal(t, abi.TokenAmount(defaultFeeCfg().MaxWindowPoStGasFee), ps.maxFee(4))
+	})
+
+	t.Run("bump", func(t *testing.T) {
+		a := newSenderTestAPI(t)
+		extra := newSenderTestAPI(t)
+		ps := newSender(a, cfg, []PushAPI{extra})
+
+		sm, err := ps.send(ctx, di, 2, newMsg(), &api.MessageSendSpec{})
+		require.NoError(t, err)
+		require.Equal(t, 1, extra.pushCount())
+
+		// not waiting for long enough
+		a.setHeight(14)
+		require.False(t, ps.check(ctx, ps.msgs[3][0]))
+		require.Equal(t, 1, a.pushCount())
+
+		a.setHeight(15)
+		require.False(t, ps.check(ctx, ps.msgs[3][0]))
+		require.Equal(t, 2, a.pushCount())
+		require.Equal(t, 2, extra.pushCount())
+
+		st := ps.status()
+		require.Len(t, st, 1)
+		require.Equal(t, 1, st[0].Bumps)
+		require.Equal(t, 1, st[0].FullNodes)
+		require.Equal(t, sm.Message.Nonce, st[0].Nonce)
+		require.NotEqual(t, sm.Cid(), st[0].Cid)
+		require.Equal(t, big.NewInt(13), st[0].GasPremium)
+		require.Equal(t, big.NewInt(125), st[0].GasFeeCap)
+
+		// the replacement lands
+		a.landed[st[0].Cid] = 16
+		a.setHeight(16)
+		require.True(t, ps.check(ctx, ps.msgs[3][0]))
+
+		st = ps.status()
+		require.Equal(t, api.WdPoStMsgLanded, st[0].State)
+		require.Equal(t, abi.ChainEpoch(16), st[0].Epoch)
+	})
+
+	t.Run("fee-cap-fallback", func(t *testing.T) {
+		a := newSenderTestAPI(t)
+		ps := newSender(a, config.WdPoStSenderConfig{
+			BumpAfterEpochs: 5,
+			BumpRatio:       1.25,
+			// the premium can't be bumped above 12
+			DeadlineMaxFees: []config.DeadlineFeeConfig{{Deadlines: []uint64{3}, MaxFee: types.FIL(big.NewInt(12 * 1000))}},
+		}, nil)
+
+		_, err := ps.send(ctx, di, 1, newMsg(), &api.MessageSendSpec{})
+		require.NoError(t, err)
+
+		a.setHeight(15)
+		require.False(t, ps.check(ctx, ps.msgs[3][0]))
+
+		st := ps.status()
+		require.Equal(t, fallback, st[0].From)
+		require.Equal(t, 0, st[0].Bumps)
+		require.Contains(t, st[0].Error, "maximum fee")
+
+		// the message sent from the worker still counts
+		a.landed[a.pushed[0].Cid()] = 16
+		require.True(t, ps.check(ctx, ps.msgs[3][0]))
+	})
+
+	t.Run("push-fallback", func(t *testing.T) {
+		a := newSenderTestAPI(t)
+		a.failFrom = worker
+		ps := newSender(a, cfg, nil)
+
+		sm, err := ps.send(ctx, di, 1, newMsg(), &api.MessageSendSpec{})
+		require.NoError(t, err)
+		require.Equal(t, fallback, sm.Message.From)
+		require.Contains(t, ps.status()[0].Error, "not enough funds")
+
+		// no fallback left when the fallback address fails
+		a.failFrom = fallback
+		_, err = ps.send(ctx, di, 1, &types.Message{To: actor, From: fallback, Value: big.Zero()}, &api.MessageSendSpec{})
+		require.Error(t, err)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		a := newSenderTestAPI(t)
+		ps := newSender(a, cfg, nil)
+
+		_, err := ps.send(ctx, di, 1, newMsg(), &api.MessageSendSpec{})
+		require.NoError(t, err)
+
+		a.setHeight(di.Close)
+		require.True(t, ps.check(ctx, ps.msgs[3][0]))
+		require.Equal(t, api.WdPoStMsgExpired, ps.status()[0].State)
+
+		// the messages of the next proving period replace the old ones
+		next := *di
+		next.Close += 2880
+		_, err = ps.send(ctx, &next, 1, newMsg(), &api.MessageSendSpec{})
+		require.NoError(t, err)
+		st := ps.status()
+		require.Len(t, st, 1)
+		require.Equal(t, api.WdPoStMsgPending, st[0].State)
+	})
+}